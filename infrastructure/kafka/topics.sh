@@ -17,6 +17,7 @@ kafka-topics.sh --bootstrap-server kafka:9092 --create --if-not-exists --topic u
 kafka-topics.sh --bootstrap-server kafka:9092 --create --if-not-exists --topic backtest-events --partitions 3 --replication-factor 1
 kafka-topics.sh --bootstrap-server kafka:9092 --create --if-not-exists --topic backtest-completions --partitions 3 --replication-factor 1
 kafka-topics.sh --bootstrap-server kafka:9092 --create --if-not-exists --topic marketplace-events --partitions 3 --replication-factor 1
+kafka-topics.sh --bootstrap-server kafka:9092 --create --if-not-exists --topic marketplace-listing-views --partitions 3 --replication-factor 1
 
 echo "List of topics:"
 kafka-topics.sh --bootstrap-server kafka:9092 --list
@@ -10,15 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"services/api-gateway/internal/audit"
 	"services/api-gateway/internal/config"
 	"services/api-gateway/internal/handler"
 	"services/api-gateway/internal/kafka"
 	"services/api-gateway/internal/middleware"
 	"services/api-gateway/internal/proxy"
+	"services/shared/auth"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Redis and Kafka configurations
@@ -33,15 +36,27 @@ type KafkaConfig struct {
 	ClientID string
 }
 
+// cacheKeyPrefix namespaces response cache keys in Redis
+const cacheKeyPrefix = "api-cache"
+
+// defaultConfigPath is used unless GATEWAY_CONFIG_FILE points elsewhere
+const defaultConfigPath = "config/config.yaml"
+
 func main() {
 	// Load configuration
-	cfg, err := config.LoadConfig("config/config.yaml")
+	configPath := os.Getenv("GATEWAY_CONFIG_FILE")
+	if configPath == "" {
+		configPath = defaultConfigPath
+	}
+
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Set up logger
-	logger, err := createLogger(cfg.Logging.Level)
+	// Set up logger; the level can change on reload
+	logLevel := zap.NewAtomicLevelAt(parseLogLevel(cfg.Logging.Level))
+	logger, err := createLogger(logLevel, cfg.Logging.Format)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
@@ -72,8 +87,23 @@ func main() {
 		logger,
 	)
 
+	// Tokens of users who logged out everywhere or were deactivated are treated as
+	// anonymous once the user service broadcasts their revocation
+	revocationsCtx, stopRevocations := context.WithCancel(context.Background())
+	var revocations *auth.RevocationList
+	if redisClient != nil {
+		revocations = auth.NewRevocationList(redisClient, logger)
+		go revocations.Run(revocationsCtx)
+	}
+
+	// Access tokens are verified locally against the user service's public keys
+	tokens := middleware.NewTokenVerifier(cfg.Auth.JWKSURL, cfg.Auth.JWTSecret, cfg.Auth.KeysMaxAge, revocations, logger)
+
+	// Rate limiters are created up front so reloads can retune them
+	limiters := newRateLimiters(cfg, redisClient, tokens, logger)
+
 	// Set up HTTP server with Gin
-	router := setupRouter(gatewayHandler, cfg, logger, redisClient, kafkaProducer)
+	router := setupRouter(gatewayHandler, cfg, logger, redisClient, kafkaProducer, limiters, tokens)
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -91,12 +121,27 @@ func main() {
 		}
 	}()
 
+	// Apply rate limit and log level changes on SIGHUP or config file changes
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	current := cfg
+	go config.Watch(reloadCtx, configPath, cfg.Reload.WatchFile, logger, func(updated *config.Config) {
+		limiters.apply(updated)
+		logLevel.SetLevel(parseLogLevel(updated.Logging.Level))
+
+		if sections := config.RestartRequired(current, updated); len(sections) > 0 {
+			logger.Warn("Config changes require a restart to take effect", zap.Strings("sections", sections))
+		}
+		current = updated
+	})
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopReload()
+	stopRevocations()
 
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -179,52 +224,48 @@ func setupRouter(
 	logger *zap.Logger,
 	redisClient *redis.Client,
 	kafkaProducer *kafka.Producer,
+	limiters *rateLimiters,
+	tokens *middleware.TokenVerifier,
 ) *gin.Engine {
 	router := gin.New()
 
 	// Use standard middlewares
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
 	router.Use(middleware.CORS())
 	router.Use(middleware.DuplicatePathLogger(logger))
 
-	// Redis-based rate limiting (if Redis is available)
-	if redisClient != nil && cfg.RateLimit.Enabled {
-		router.Use(middleware.RedisRateLimit(redisClient, middleware.RedisRateLimitConfig{
-			Enabled:            cfg.RateLimit.Enabled,
-			RequestsPerMinute:  cfg.RateLimit.RequestsPerMinute,
-			BurstSize:          cfg.RateLimit.BurstSize,
-			ClientIPHeaderName: cfg.RateLimit.ClientIPHeaderName,
-		}, logger))
-	} else if cfg.RateLimit.Enabled {
-		// Fallback to in-memory rate limiter if Redis is not available
-		router.Use(middleware.RateLimit(
-			cfg.RateLimit.RequestsPerMinute,
-			cfg.RateLimit.BurstSize,
-		))
-	}
+	// Rate limiting, Redis-based if available. Always installed so it can be enabled on reload.
+	router.Use(limiters.middleware())
 
 	// Redis-based caching for read endpoints (if Redis is available)
 	if redisClient != nil {
 		router.Use(middleware.RedisCache(redisClient, middleware.CacheConfig{
-			Enabled:         true,
-			DefaultDuration: 5 * time.Minute,
-			PrefixKey:       "api-cache",
-			ExcludedPaths:   []string{"/health", "/api/v1/auth/login", "/api/v1/auth/register"},
+			Enabled:         cfg.Cache.Enabled,
+			DefaultDuration: cfg.Cache.DefaultDuration,
+			PrefixKey:       cacheKeyPrefix,
+			// Tickers are cached briefly by the historical data service; minutes here would serve stale prices
+			ExcludedPaths: []string{
+				"/health", "/api/docs", "/api/v1/search", "/api/v2/search",
+				"/api/v1/market-data/ticker", "/api/v2/market-data/ticker",
+			},
+			RouteDurations: routeCacheDurations(cfg.Routes),
+			Dependents:     cfg.Cache.Dependents,
 		}, logger))
+
+		// Cache administration
+		cacheHandler := handler.NewCacheHandler(redisClient, cacheKeyPrefix, logger)
+		router.POST("/admin/cache/purge", middleware.RequireAdmin(tokens), cacheHandler.Purge)
 	}
 
 	// Request auditing middleware using Kafka
-	router.Use(func(c *gin.Context) {
-		// Process the request
-		c.Next()
-
-		// After processing, log important requests to Kafka
-		if kafkaProducer != nil && isImportantRequest(c) {
-			// Audit the request
-			auditRequest(c, kafkaProducer, logger)
-		}
-	})
+	if kafkaProducer != nil {
+		router.Use(audit.NewAuditor(kafkaProducer, tokens, logger).Middleware())
+	}
+
+	// Impersonated requests are tagged for the audit above and rejected once revoked
+	router.Use(middleware.Impersonation(tokens, redisClient, logger))
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -252,168 +293,238 @@ func setupRouter(
 	// Media routes
 	router.Any("/media/*path", gatewayHandler.ProxyMediaService)
 
+	// OpenAPI spec merged from the services
+	docsHandler := handler.NewDocsHandler([]handler.DocsSource{
+		{Name: "user", URL: cfg.UserService.URL},
+		{Name: "strategy", URL: cfg.StrategyService.URL},
+		{Name: "historical", URL: cfg.HistoricalService.URL},
+		{Name: "media", URL: cfg.MediaService.URL},
+	}, router.Routes, logger)
+	router.GET("/api/docs", docsHandler.GetDocs)
+
+	// Search across services
+	searchHandler := handler.NewSearchHandler(
+		cfg.StrategyService.URL,
+		cfg.UserService.URL,
+		cfg.Search.Profiles,
+		cfg.Search.Timeout,
+		logger,
+	)
+
 	// API routes
 	api := router.Group("/api")
-	{
-		// USER SERVICE ROUTES
-		api.Any("/v1/auth/login", gatewayHandler.ProxyUserService)
-		api.Any("/v1/auth/register", gatewayHandler.ProxyUserService)
-		api.Any("/v1/auth/refresh", gatewayHandler.ProxyUserService)
-		api.Any("/v1/auth/validate", gatewayHandler.ProxyUserService)
-		api.Any("/v1/users/me", gatewayHandler.ProxyUserService)
-		api.Any("/v1/users", gatewayHandler.ProxyUserService)
-		api.Any("/v1/users/:id", gatewayHandler.ProxyUserService)
-		api.Any("/v1/admin/users", gatewayHandler.ProxyUserService)
-		api.Any("/v1/admin/users/:id", gatewayHandler.ProxyUserService)
-		api.Any("/v1/admin/users/:id/roles", gatewayHandler.ProxyUserService)
-		api.Any("/v1/notifications", gatewayHandler.ProxyUserService)
-		api.Any("/v1/notifications/:id", gatewayHandler.ProxyUserService)
-
-		// STRATEGY SERVICE ROUTES
-		api.Any("/v1/indicators", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/indicators/sync", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/indicators/categories", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/indicators/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/indicators/:id/parameters", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/parameters/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/parameters/:id/enum-values", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/enum-values/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategies", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategies/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategies/:id/versions", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategies/:id/active-version", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategies/:id/thumbnail", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategy-tags", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/strategy-tags/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/marketplace", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/marketplace/:id", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/marketplace/:id/reviews", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/marketplace/:id/purchase", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/marketplace/purchases/:id/cancel", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/reviews", gatewayHandler.ProxyStrategyService)
-		api.Any("/v1/reviews/:id", gatewayHandler.ProxyStrategyService)
-
-		// HISTORICAL SERVICE ROUTES - Use ONE wildcard route for all market-data endpoints
-		api.Any("/v1/market-data/*path", gatewayHandler.ProxyHistoricalService)
-
-		// Other historical service routes that don't start with market-data
-		api.Any("/v1/backtests", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/backtests/:id", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/backtest-runs", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/backtest-runs/:id", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/backtest-runs/:id/*path", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/symbols", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/symbols/:id", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/timeframes", gatewayHandler.ProxyHistoricalService)
-		api.Any("/v1/timeframes/:id", gatewayHandler.ProxyHistoricalService)
-
-		// MEDIA SERVICE ROUTES
-		api.Any("/v1/media/upload", gatewayHandler.ProxyMediaService)
-		api.Any("/v1/media/:id", gatewayHandler.ProxyMediaService)
-		api.Any("/v1/media/by-path/*path", gatewayHandler.ProxyMediaService)
+	registerRoutes(api.Group("/v1"), cfg.Routes, gatewayHandler, searchHandler, tokens)
+
+	// v2 is served from the v1 routes, with adapters transforming requests and responses
+	if cfg.Versioning.V2Enabled {
+		v2 := api.Group("/v2")
+		v2.Use(middleware.APIVersion("v2", versionAdapters(cfg.Versioning), logger))
+		registerRoutes(v2, cfg.Routes, gatewayHandler, searchHandler, tokens)
 	}
 
 	return router
 }
 
-// isImportantRequest checks if a request should be audited
-func isImportantRequest(c *gin.Context) bool {
-	// Audit login/register, admin operations, purchases, and other important operations
-	path := c.Request.URL.Path
-	method := c.Request.Method
+// apiVersionPrefixes are the prefixes configured routes are served under
+var apiVersionPrefixes = []string{"/api/v1", "/api/v2"}
+
+// registerRoutes registers the configured routes of an API version, relative to its prefix
+func registerRoutes(
+	group *gin.RouterGroup,
+	routes []config.RouteConfig,
+	gatewayHandler *handler.GatewayHandler,
+	searchHandler *handler.SearchHandler,
+	tokens *middleware.TokenVerifier,
+) {
+	for _, route := range routes {
+		handlers := make([]gin.HandlerFunc, 0, 2)
+		switch route.Auth {
+		case config.RouteAuthRequired:
+			handlers = append(handlers, middleware.RequireAuth(tokens))
+		case config.RouteAuthAdmin:
+			handlers = append(handlers, middleware.RequireAdmin(tokens))
+		}
+		handlers = append(handlers, serviceProxy(gatewayHandler, route.Service))
+
+		if len(route.Methods) == 0 {
+			group.Any(route.Path, handlers...)
+			continue
+		}
+		for _, method := range route.Methods {
+			group.Handle(strings.ToUpper(method), route.Path, handlers...)
+		}
+	}
+
+	// SEARCH ACROSS SERVICES
+	group.GET("/search", searchHandler.Search)
+}
 
-	// Admin actions
-	if strings.Contains(path, "/admin/") {
-		return true
+// serviceProxy returns the handler proxying to a service by its name in config.yaml
+func serviceProxy(gatewayHandler *handler.GatewayHandler, service string) gin.HandlerFunc {
+	switch service {
+	case config.ServiceUser:
+		return gatewayHandler.ProxyUserService
+	case config.ServiceStrategy:
+		return gatewayHandler.ProxyStrategyService
+	case config.ServiceHistorical:
+		return gatewayHandler.ProxyHistoricalService
+	default:
+		return gatewayHandler.ProxyMediaService
 	}
+}
 
-	// Auth actions
-	if strings.Contains(path, "/auth/") && (method == "POST" || method == "PUT") {
-		return true
+// routeCacheDurations maps the patterns of configured routes with their own cache
+// duration, under every API version, to it; zero leaves a route out of the cache
+func routeCacheDurations(routes []config.RouteConfig) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+	for _, route := range routes {
+		if !route.NoCache && route.CacheTTL == 0 {
+			continue
+		}
+		for _, prefix := range apiVersionPrefixes {
+			durations[prefix+route.Path] = route.CacheTTL
+		}
 	}
+	return durations
+}
 
-	// Marketplace purchases
-	if strings.Contains(path, "/marketplace") &&
-		(strings.Contains(path, "/purchase") || strings.Contains(path, "/cancel")) {
-		return true
+// routeRateLimitClasses maps the patterns of configured routes with a rate limit class,
+// under every API version, to it
+func routeRateLimitClasses(routes []config.RouteConfig) map[string]string {
+	classes := make(map[string]string)
+	for _, route := range routes {
+		if route.RateLimitClass == "" {
+			continue
+		}
+		for _, prefix := range apiVersionPrefixes {
+			classes[prefix+route.Path] = route.RateLimitClass
+		}
 	}
+	return classes
+}
+
+// rateLimiters holds the rate limiter the router uses: tiered in Redis when available,
+// otherwise the in-memory fallback
+type rateLimiters struct {
+	tiered   *middleware.TieredRateLimiter
+	fallback *middleware.RateLimiter
+	tokens   *middleware.TokenVerifier
+}
 
-	// Strategy creation or updates
-	if strings.Contains(path, "/strategies") && (method == "POST" || method == "PUT") {
-		return true
+// newRateLimiters creates the rate limiter for the current configuration
+func newRateLimiters(cfg *config.Config, redisClient *redis.Client, tokens *middleware.TokenVerifier, logger *zap.Logger) *rateLimiters {
+	if redisClient != nil {
+		return &rateLimiters{
+			tiered: middleware.NewTieredRateLimiter(redisClient, tieredRateLimitConfig(cfg, tokens), logger),
+			tokens: tokens,
+		}
 	}
 
-	return false
+	requestsPerMinute, burstSize := fallbackRateLimits(cfg.RateLimit)
+	return &rateLimiters{
+		fallback: middleware.NewRateLimiter(requestsPerMinute, burstSize),
+	}
 }
 
-// auditRequest publishes request audit data to Kafka
-func auditRequest(c *gin.Context, producer *kafka.Producer, logger *zap.Logger) {
-	// Extract user ID from authorization header or context
-	userID := "anonymous"
-	if id, exists := c.Get("user_id"); exists {
-		userID = id.(string)
+// middleware returns the handler of the active rate limiter
+func (r *rateLimiters) middleware() gin.HandlerFunc {
+	if r.tiered != nil {
+		return r.tiered.Middleware()
 	}
+	return r.fallback.Middleware()
+}
 
-	// Create audit message
-	auditData := map[string]interface{}{
-		"user_id":    userID,
-		"client_ip":  c.ClientIP(),
-		"path":       c.Request.URL.Path,
-		"method":     c.Request.Method,
-		"status":     c.Writer.Status(),
-		"user_agent": c.Request.UserAgent(),
-		"timestamp":  time.Now().Format(time.RFC3339),
+// apply retunes the active rate limiter from a reloaded configuration
+func (r *rateLimiters) apply(cfg *config.Config) {
+	if r.tiered != nil {
+		r.tiered.SetConfig(tieredRateLimitConfig(cfg, r.tokens))
+		return
 	}
+	r.fallback.SetLimits(fallbackRateLimits(cfg.RateLimit))
+}
 
-	// Determine which topic to use based on the path
-	topic := "user-events" // Default topic
+// fallbackRateLimits returns the in-memory limiter's limits; zero disables it
+func fallbackRateLimits(cfg config.RateLimitConfig) (int, int) {
+	if !cfg.Enabled {
+		return 0, 0
+	}
+	return cfg.RequestsPerMinute, cfg.BurstSize
+}
 
-	if strings.Contains(c.Request.URL.Path, "/marketplace") {
-		topic = "marketplace-events"
-	} else if strings.Contains(c.Request.URL.Path, "/strategies") {
-		topic = "strategy-events"
-	} else if strings.Contains(c.Request.URL.Path, "/backtest") {
-		topic = "backtest-events"
+// tieredRateLimitConfig builds the tiered rate limiter configuration from config.yaml
+func tieredRateLimitConfig(cfg *config.Config, tokens *middleware.TokenVerifier) middleware.TieredRateLimitConfig {
+	rules := make([]middleware.RateLimitRule, 0, len(cfg.RateLimit.Rules))
+	for _, rule := range cfg.RateLimit.Rules {
+		rules = append(rules, middleware.RateLimitRule{
+			Name:                       rule.Name,
+			Methods:                    rule.Methods,
+			Paths:                      rule.Paths,
+			RequestsPerMinute:          rule.RequestsPerMinute,
+			AnonymousRequestsPerMinute: rule.AnonymousRequestsPerMinute,
+		})
 	}
 
-	// Send to Kafka
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return middleware.TieredRateLimitConfig{
+		Enabled:            cfg.RateLimit.Enabled,
+		ClientIPHeaderName: cfg.RateLimit.ClientIPHeaderName,
+		Tokens:             tokens,
+		Rules:              rules,
+		RouteClasses:       routeRateLimitClasses(cfg.Routes),
+		Default: middleware.RateLimitRule{
+			Name:                       "default",
+			RequestsPerMinute:          cfg.RateLimit.AuthenticatedRequestsPerMinute,
+			AnonymousRequestsPerMinute: cfg.RateLimit.RequestsPerMinute,
+		},
+	}
+}
 
-	err := producer.Publish(ctx, topic, kafka.Message{
-		Key:   userID,
-		Value: auditData,
-	})
+// versionAdapters builds the v2 request and response adapters from config.yaml
+func versionAdapters(cfg config.VersioningConfig) []middleware.VersionAdapter {
+	adapters := make([]middleware.VersionAdapter, 0, len(cfg.Adapters))
+	for _, adapter := range cfg.Adapters {
+		adapters = append(adapters, middleware.VersionAdapter{
+			Name:        adapter.Name,
+			Methods:     adapter.Methods,
+			Paths:       adapter.Paths,
+			Fields:      fieldRenames(adapter.Fields),
+			QueryParams: fieldRenames(adapter.QueryParams),
+			Envelope:    adapter.Envelope,
+		})
+	}
+	return adapters
+}
 
-	if err != nil {
-		logger.Error("Failed to publish audit event",
-			zap.Error(err),
-			zap.String("topic", topic),
-			zap.String("user_id", userID))
+// fieldRenames converts configured field renames
+func fieldRenames(renames []config.FieldRenameConfig) []middleware.FieldRename {
+	converted := make([]middleware.FieldRename, 0, len(renames))
+	for _, rename := range renames {
+		converted = append(converted, middleware.FieldRename{V1: rename.V1, V2: rename.V2})
 	}
+	return converted
 }
 
-func createLogger(level string) (*zap.Logger, error) {
-	// Parse log level
-	var zapLevel zap.AtomicLevel
+// parseLogLevel maps a configured log level to zap, defaulting to info
+func parseLogLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
-		zapLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+		return zap.DebugLevel
 	case "info":
-		zapLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
+		return zap.InfoLevel
 	case "warn":
-		zapLevel = zap.NewAtomicLevelAt(zap.WarnLevel)
+		return zap.WarnLevel
 	case "error":
-		zapLevel = zap.NewAtomicLevelAt(zap.ErrorLevel)
+		return zap.ErrorLevel
 	default:
-		zapLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
+		return zap.InfoLevel
 	}
+}
 
+func createLogger(level zap.AtomicLevel, format string) (*zap.Logger, error) {
 	// Create logger config
 	config := zap.Config{
-		Level:            zapLevel,
+		Level:            level,
 		Development:      false,
-		Encoding:         "json",
+		Encoding:         format,
 		EncoderConfig:    zap.NewProductionEncoderConfig(),
 		OutputPaths:      []string{"stdout"},
 		ErrorOutputPaths: []string{"stderr"},
@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"services/api-gateway/internal/kafka"
+	"services/api-gateway/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxDigestBytes is the largest request body digested; larger bodies are only measured
+const maxDigestBytes = 64 << 10
+
+// publishTimeout bounds publishing a single audit event
+const publishTimeout = 5 * time.Second
+
+// Auditor publishes audit events of important requests to Kafka
+type Auditor struct {
+	producer *kafka.Producer
+	tokens   *middleware.TokenVerifier
+	logger   *zap.Logger
+}
+
+// NewAuditor creates a new auditor. tokens identifies the caller and their role.
+func NewAuditor(producer *kafka.Producer, tokens *middleware.TokenVerifier, logger *zap.Logger) *Auditor {
+	return &Auditor{
+		producer: producer,
+		tokens:   tokens,
+		logger:   logger,
+	}
+}
+
+// Middleware audits requests after they were handled. The JSON bodies of mutations are
+// captured on the way in, so audited ones can be digested.
+func (a *Auditor) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		capture := captureBody(c)
+
+		// Process the request
+		c.Next()
+
+		// After processing, log important requests to Kafka
+		if isImportantRequest(c) {
+			a.publish(c, time.Since(start), capture)
+		}
+	}
+}
+
+// publish sends the audit event of a handled request
+func (a *Auditor) publish(c *gin.Context, latency time.Duration, capture *bodyCapture) {
+	request := Request{
+		EventType:     EventTypeRequest,
+		SchemaVersion: SchemaVersion,
+		RequestID:     c.GetString("request_id"),
+		UserID:        "anonymous",
+		ClientIP:      c.ClientIP(),
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Route:         c.FullPath(),
+		Status:        c.Writer.Status(),
+		LatencyMS:     latency.Milliseconds(),
+		ResponseBytes: max(c.Writer.Size(), 0),
+		UserAgent:     c.Request.UserAgent(),
+		Timestamp:     time.Now().Format(time.RFC3339),
+	}
+
+	// Extract user ID and role from the access token or context
+	if claims, ok := a.tokens.ParseAccessToken(c.Request.Context(), c.GetHeader("Authorization")); ok {
+		request.UserID = claims.UserID
+		request.UserRole = claims.Role
+	} else if id, exists := c.Get("user_id"); exists {
+		request.UserID, _ = id.(string)
+	}
+
+	if impersonatorID, exists := c.Get("impersonator_id"); exists {
+		request.Impersonated = true
+		request.ImpersonatorID, _ = impersonatorID.(string)
+		request.ImpersonationSessionID = c.GetString("impersonation_session_id")
+	}
+
+	if capture != nil {
+		request.Body = capture.digest()
+	}
+
+	resourceID, _ := strconv.Atoi(c.Param("id"))
+	topic := topicOf(request.Path)
+
+	// Send to Kafka
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	err := a.producer.Publish(ctx, topic, kafka.Message{
+		Key:   request.UserID,
+		Value: newEvent(topic, request, resourceID),
+	})
+	if err != nil {
+		a.logger.Error("Failed to publish audit event",
+			zap.Error(err),
+			zap.String("topic", topic),
+			zap.String("user_id", request.UserID),
+			zap.String("request_id", request.RequestID))
+	}
+}
+
+// bodyCapture holds the start of a request body read on its way to a service
+type bodyCapture struct {
+	head      []byte
+	truncated bool // The body is larger than head
+	// read counts the bytes of the body the service read, the whole body once it's done
+	read *countingReader
+}
+
+// digest describes the captured body
+func (b *bodyCapture) digest() *BodyDigest {
+	size := max(b.read.n, int64(len(b.head)))
+	return digestBody(b.head, size, b.truncated)
+}
+
+// captureBody keeps a copy of the start of JSON request bodies of mutations. The body
+// is passed on unchanged. Returns nil for requests without such a body.
+func captureBody(c *gin.Context) *bodyCapture {
+	switch c.Request.Method {
+	case "POST", "PUT", "PATCH", "DELETE":
+	default:
+		return nil
+	}
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return nil
+	}
+
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDigestBytes+1))
+
+	// Pass on what was read, followed by the rest of the body or the read error
+	read := &countingReader{reader: io.MultiReader(bytes.NewReader(head), c.Request.Body)}
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{read, c.Request.Body}
+	if err != nil {
+		return nil
+	}
+
+	truncated := len(head) > maxDigestBytes
+	if truncated {
+		head = head[:maxDigestBytes]
+	}
+	return &bodyCapture{head: head, truncated: truncated, read: read}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read reads from the underlying reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// isImportantRequest checks if a request should be audited
+func isImportantRequest(c *gin.Context) bool {
+	// Audit login/register, admin operations, purchases, and other important operations
+	path := c.Request.URL.Path
+	method := c.Request.Method
+
+	// Everything done while impersonating a user
+	if _, exists := c.Get("impersonator_id"); exists {
+		return true
+	}
+
+	// Admin actions
+	if strings.Contains(path, "/admin/") {
+		return true
+	}
+
+	// Auth actions
+	if strings.Contains(path, "/auth/") && (method == "POST" || method == "PUT") {
+		return true
+	}
+
+	// Marketplace purchases and refund reviews
+	if strings.Contains(path, "/marketplace") &&
+		(strings.Contains(path, "/purchase") || strings.Contains(path, "/cancel") || strings.Contains(path, "/refunds/")) {
+		return true
+	}
+
+	// Strategy creation or updates
+	if strings.Contains(path, "/strategies") && (method == "POST" || method == "PUT") {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the values of sensitive fields before a body is digested
+const redacted = "[REDACTED]"
+
+// sensitiveFields are parts of field names whose values are redacted, so digests can't
+// be used to guess passwords, codes or keys
+var sensitiveFields = []string{
+	"password", "secret", "token", "key", "code", "otp", "card", "cvv", "iban", "authorization",
+}
+
+// BodyDigest describes a request body without revealing it. JSON bodies are digested
+// with sensitive fields redacted and keys sorted, so equal requests have equal digests.
+type BodyDigest struct {
+	SHA256 string   `json:"sha256,omitempty"` // Empty if the body was too large to digest
+	Size   int64    `json:"size"`
+	Fields []string `json:"fields,omitempty"` // Top-level fields of a JSON object, sorted
+	// Redacted lists the fields whose values were left out of the digest
+	Redacted  []string `json:"redacted,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// digestBody describes a captured request body. truncated is set when only part of the
+// body was captured.
+func digestBody(body []byte, size int64, truncated bool) *BodyDigest {
+	digest := &BodyDigest{Size: size, Truncated: truncated}
+	if truncated {
+		return digest
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		sum := sha256.Sum256(body)
+		digest.SHA256 = hex.EncodeToString(sum[:])
+		return digest
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		for field := range object {
+			digest.Fields = append(digest.Fields, field)
+		}
+		sort.Strings(digest.Fields)
+	}
+
+	redactedFields := map[string]bool{}
+	value = redact(value, redactedFields)
+	for field := range redactedFields {
+		digest.Redacted = append(digest.Redacted, field)
+	}
+	sort.Strings(digest.Redacted)
+
+	// Maps are marshalled with sorted keys
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return digest
+	}
+	sum := sha256.Sum256(canonical)
+	digest.SHA256 = hex.EncodeToString(sum[:])
+	return digest
+}
+
+// redact replaces the values of sensitive fields in a decoded JSON value, recording
+// their names
+func redact(value interface{}, redactedFields map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if isSensitive(field) {
+				v[field] = redacted
+				redactedFields[field] = true
+				continue
+			}
+			v[field] = redact(fieldValue, redactedFields)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(v[i], redactedFields)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSensitive reports whether a field name suggests a secret value
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, sensitive := range sensitiveFields {
+		if strings.Contains(field, sensitive) {
+			return true
+		}
+	}
+	return false
+}
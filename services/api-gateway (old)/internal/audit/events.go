@@ -0,0 +1,101 @@
+// Package audit publishes an event for every important request passing through the
+// gateway. Each topic has its own event struct so consumers can rely on a fixed schema;
+// all of them embed Request, which keeps the fields flat in the JSON.
+package audit
+
+import "strings"
+
+// Topics of audit events
+const (
+	TopicUser        = "user-events"
+	TopicStrategy    = "strategy-events"
+	TopicMarketplace = "marketplace-events"
+	TopicBacktest    = "backtest-events"
+)
+
+// SchemaVersion is bumped when fields of the events change incompatibly
+const SchemaVersion = 2
+
+// EventTypeRequest is the type of every audit event, telling them apart from the
+// services' own events on the same topics
+const EventTypeRequest = "gateway_request"
+
+// Request holds the fields every audit event has
+type Request struct {
+	EventType     string `json:"event_type"`
+	SchemaVersion int    `json:"schema_version"`
+	RequestID     string `json:"request_id"`
+	// UserID is the caller's ID as a string, "anonymous" without a valid token
+	UserID    string `json:"user_id"`
+	UserRole  string `json:"user_role,omitempty"`
+	ClientIP  string `json:"client_ip"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Route     string `json:"route,omitempty"` // Matched route pattern, e.g. /api/v1/strategies/:id
+	Status    int    `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	// ResponseBytes is the size of the response body
+	ResponseBytes int    `json:"response_bytes"`
+	UserAgent     string `json:"user_agent"`
+	Timestamp     string `json:"timestamp"` // RFC 3339
+
+	Impersonated           bool   `json:"impersonated,omitempty"`
+	ImpersonatorID         string `json:"impersonator_id,omitempty"`
+	ImpersonationSessionID string `json:"impersonation_session_id,omitempty"`
+
+	// Body describes the request body of audited mutations
+	Body *BodyDigest `json:"body,omitempty"`
+}
+
+// UserEvent audits requests to the user service: auth, profiles and administration
+type UserEvent struct {
+	Request
+	TargetUserID int `json:"target_user_id,omitempty"` // User the request is about, from the path
+}
+
+// StrategyEvent audits requests about strategies
+type StrategyEvent struct {
+	Request
+	StrategyID int `json:"strategy_id,omitempty"`
+}
+
+// MarketplaceEvent audits marketplace requests such as purchases and refunds
+type MarketplaceEvent struct {
+	Request
+	ListingID int `json:"listing_id,omitempty"`
+}
+
+// BacktestEvent audits requests about backtests
+type BacktestEvent struct {
+	Request
+	BacktestID int `json:"backtest_id,omitempty"`
+}
+
+// topicOf returns the topic of a request by its path
+func topicOf(path string) string {
+	switch {
+	case strings.Contains(path, "/marketplace"):
+		return TopicMarketplace
+	case strings.Contains(path, "/strategies"):
+		return TopicStrategy
+	case strings.Contains(path, "/backtest"):
+		return TopicBacktest
+	default:
+		return TopicUser
+	}
+}
+
+// newEvent wraps the common fields in the event struct of a topic. resourceID is the ID
+// in the request path, 0 if there is none.
+func newEvent(topic string, request Request, resourceID int) interface{} {
+	switch topic {
+	case TopicStrategy:
+		return StrategyEvent{Request: request, StrategyID: resourceID}
+	case TopicMarketplace:
+		return MarketplaceEvent{Request: request, ListingID: resourceID}
+	case TopicBacktest:
+		return BacktestEvent{Request: request, BacktestID: resourceID}
+	default:
+		return UserEvent{Request: request, TargetUserID: resourceID}
+	}
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/spf13/viper"
 )
@@ -14,8 +20,14 @@ type Config struct {
 	StrategyService   ServiceConfig
 	HistoricalService ServiceConfig
 	MediaService      ServiceConfig
+	Auth              AuthConfig
 	RateLimit         RateLimitConfig
+	Cache             CacheConfig
+	Search            SearchConfig
+	Versioning        VersioningConfig
+	Routes            []RouteConfig
 	Logging           LoggingConfig
+	Reload            ReloadConfig
 }
 
 // ServerConfig holds server specific configuration
@@ -32,12 +44,78 @@ type ServiceConfig struct {
 	Timeout time.Duration
 }
 
+// AuthConfig holds configuration for verifying user service access tokens
+type AuthConfig struct {
+	JWKSURL    string        // Public keys of RS256 access tokens, served by the user service
+	JWTSecret  string        // Shared secret of HS256 access tokens; empty rejects them
+	KeysMaxAge time.Duration // How long fetched keys are used before refetching
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Enabled            bool
 	RequestsPerMinute  int
 	BurstSize          int
 	ClientIPHeaderName string
+
+	// Tiered limits (Redis only). AuthenticatedRequestsPerMinute is the default
+	// per-user quota; RequestsPerMinute remains the default per-IP quota.
+	AuthenticatedRequestsPerMinute int
+	Rules                          []RateLimitRuleConfig
+}
+
+// RateLimitRuleConfig holds the rate limit for a class of endpoints
+type RateLimitRuleConfig struct {
+	Name                       string
+	Methods                    []string
+	Paths                      []string
+	RequestsPerMinute          int
+	AnonymousRequestsPerMinute int
+}
+
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	Enabled         bool
+	DefaultDuration time.Duration
+	// Dependents maps a resource collection to collections whose cached responses
+	// embed it, so writes to the former also purge the latter
+	Dependents map[string][]string
+}
+
+// SearchConfig holds configuration for the search across services
+type SearchConfig struct {
+	// Timeout bounds each service searched; results of slower services are left out
+	Timeout time.Duration
+	// Profiles includes public user profiles from the user service
+	Profiles bool
+}
+
+// VersioningConfig holds configuration for API versions served on top of v1
+type VersioningConfig struct {
+	// V2Enabled serves /api/v2 from the v1 routes of the services
+	V2Enabled bool
+	// Adapters transform v2 requests and responses; the first match applies
+	Adapters []VersionAdapterConfig
+}
+
+// VersionAdapterConfig holds the request and response transformations of a class of
+// v2 endpoints
+type VersionAdapterConfig struct {
+	Name    string
+	Methods []string
+	Paths   []string
+	// Fields and QueryParams are renamed between their v1 and v2 names
+	Fields      []FieldRenameConfig
+	QueryParams []FieldRenameConfig
+	// Envelope is the field holding the items of a v1 list response, rewrapped as
+	// {"data": [...], "meta": {...}} in v2
+	Envelope string
+}
+
+// FieldRenameConfig maps a v1 field name to its v2 name
+type FieldRenameConfig struct {
+	V1 string
+	V2 string
 }
 
 // LoggingConfig holds logging specific configuration
@@ -46,7 +124,20 @@ type LoggingConfig struct {
 	Format string
 }
 
-// LoadConfig loads the configuration from file and environment variables
+// ReloadConfig holds configuration for reloading tunables without a restart
+type ReloadConfig struct {
+	// WatchFile reloads when the config file changes, in addition to on SIGHUP
+	WatchFile bool
+}
+
+// EnvPrefix namespaces environment overrides. Every scalar field can be overridden by
+// the prefix followed by its path in upper snake case, e.g. GATEWAY_RATE_LIMIT_REQUESTS_PER_MINUTE
+// for rateLimit.requestsPerMinute. Lists of structs and maps (rate limit rules, cache
+// dependents, version adapters, routes) can only be set in the file.
+const EnvPrefix = "GATEWAY"
+
+// LoadConfig loads the configuration from file and environment variables.
+// Precedence, lowest to highest: defaults, config file, environment.
 func LoadConfig(path string) (*Config, error) {
 	v := viper.New()
 
@@ -60,16 +151,206 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	// Environment variables override
-	v.AutomaticEnv()
+	bindEnv(v, reflect.TypeOf(Config{}), "", EnvPrefix)
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// bindEnv binds an environment variable to every scalar field of the struct type
+func bindEnv(v *viper.Viper, t reflect.Type, keyPrefix, envPrefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := keyPrefix + strings.ToLower(field.Name)
+		env := envPrefix + "_" + envName(field.Name)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			bindEnv(v, field.Type, key+".", env)
+		case field.Type.Kind() == reflect.Map,
+			field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			// File only
+		default:
+			v.BindEnv(key, env)
+		}
+	}
+}
+
+// envName converts a field name to upper snake case: ClientIPHeaderName -> CLIENT_IP_HEADER_NAME
+func envName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		fail("server.port", "must be a port number, got %q", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 {
+		fail("server.readTimeout", "must be a positive duration")
+	}
+	if c.Server.WriteTimeout <= 0 {
+		fail("server.writeTimeout", "must be a positive duration")
+	}
+	if c.Server.IdleTimeout <= 0 {
+		fail("server.idleTimeout", "must be a positive duration")
+	}
+
+	services := []struct {
+		key string
+		svc ServiceConfig
+	}{
+		{"userService", c.UserService},
+		{"strategyService", c.StrategyService},
+		{"historicalService", c.HistoricalService},
+		{"mediaService", c.MediaService},
+	}
+	for _, s := range services {
+		if u, err := url.Parse(s.svc.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			fail(s.key+".url", "must be an absolute URL, got %q", s.svc.URL)
+		}
+		if s.svc.Timeout <= 0 {
+			fail(s.key+".timeout", "must be a positive duration")
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerMinute <= 0 {
+			fail("rateLimit.requestsPerMinute", "must be positive when rate limiting is enabled")
+		}
+		if c.RateLimit.BurstSize <= 0 {
+			fail("rateLimit.burstSize", "must be positive when rate limiting is enabled")
+		}
+		if c.RateLimit.AuthenticatedRequestsPerMinute <= 0 {
+			fail("rateLimit.authenticatedRequestsPerMinute", "must be positive when rate limiting is enabled")
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range c.RateLimit.Rules {
+		key := fmt.Sprintf("rateLimit.rules[%d]", i)
+		if rule.Name == "" {
+			fail(key+".name", "is required")
+		} else if names[rule.Name] {
+			fail(key+".name", "duplicate rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.RequestsPerMinute <= 0 {
+			fail(key+".requestsPerMinute", "must be positive")
+		}
+		if rule.AnonymousRequestsPerMinute < 0 {
+			fail(key+".anonymousRequestsPerMinute", "must not be negative")
+		}
+		for _, p := range rule.Paths {
+			if !strings.HasPrefix(p, "/") {
+				fail(key+".paths", "%q must start with /", p)
+			}
+		}
+	}
+
+	adapters := make(map[string]bool)
+	for i, adapter := range c.Versioning.Adapters {
+		key := fmt.Sprintf("versioning.adapters[%d]", i)
+		if adapter.Name == "" {
+			fail(key+".name", "is required")
+		} else if adapters[adapter.Name] {
+			fail(key+".name", "duplicate adapter %q", adapter.Name)
+		}
+		adapters[adapter.Name] = true
+
+		for _, p := range adapter.Paths {
+			if !strings.HasPrefix(p, "/api/v2/") {
+				fail(key+".paths", "%q must start with /api/v2/", p)
+			}
+		}
+		for j, rename := range adapter.Fields {
+			if rename.V1 == "" || rename.V2 == "" {
+				fail(fmt.Sprintf("%s.fields[%d]", key, j), "v1 and v2 names are required")
+			}
+		}
+		for j, rename := range adapter.QueryParams {
+			if rename.V1 == "" || rename.V2 == "" {
+				fail(fmt.Sprintf("%s.queryParams[%d]", key, j), "v1 and v2 names are required")
+			}
+		}
+	}
+
+	c.validateRoutes(fail)
+
+	if c.Cache.Enabled && c.Cache.DefaultDuration <= 0 {
+		fail("cache.defaultDuration", "must be a positive duration when caching is enabled")
+	}
+
+	if c.Search.Timeout <= 0 {
+		fail("search.timeout", "must be a positive duration")
+	}
+
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("logging.level", "must be one of debug, info, warn, error, got %q", c.Logging.Level)
+	}
+	switch c.Logging.Format {
+	case "json", "console":
+	default:
+		fail("logging.format", "must be json or console, got %q", c.Logging.Format)
+	}
+
+	return errors.Join(errs...)
+}
+
+// RestartRequired lists the sections that changed between two configurations but are
+// only read at startup. Rate limits and the log level are applied on reload.
+func RestartRequired(old, updated *Config) []string {
+	var sections []string
+	check := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			sections = append(sections, name)
+		}
+	}
+
+	check("server", old.Server, updated.Server)
+	check("userService", old.UserService, updated.UserService)
+	check("strategyService", old.StrategyService, updated.StrategyService)
+	check("historicalService", old.HistoricalService, updated.HistoricalService)
+	check("mediaService", old.MediaService, updated.MediaService)
+	check("auth", old.Auth, updated.Auth)
+	check("cache", old.Cache, updated.Cache)
+	check("search", old.Search, updated.Search)
+	check("versioning", old.Versioning, updated.Versioning)
+	check("routes", old.Routes, updated.Routes)
+	check("logging.format", old.Logging.Format, updated.Logging.Format)
+	check("reload", old.Reload, updated.Reload)
+
+	return sections
+}
+
 // setDefaults sets default values for configuration
 func setDefaults(v *viper.Viper) {
 	// Server defaults
@@ -84,13 +365,31 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("historicalService.timeout", "30s")
 	v.SetDefault("mediaService.timeout", "30s")
 
+	// Auth defaults
+	v.SetDefault("auth.keysMaxAge", "1h")
+
 	// Rate limit defaults
 	v.SetDefault("rateLimit.enabled", false)
 	v.SetDefault("rateLimit.requestsPerMinute", 60)
 	v.SetDefault("rateLimit.burstSize", 10)
 	v.SetDefault("rateLimit.clientIPHeaderName", "X-Real-IP")
+	v.SetDefault("rateLimit.authenticatedRequestsPerMinute", 120)
+
+	// Cache defaults
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.defaultDuration", "5m")
+
+	// Search defaults
+	v.SetDefault("search.timeout", "3s")
+	v.SetDefault("search.profiles", true)
+
+	// Versioning defaults
+	v.SetDefault("versioning.v2Enabled", false)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
+
+	// Reload defaults
+	v.SetDefault("reload.watchFile", false)
 }
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadDebounce coalesces the burst of events editors and config map updates produce
+const reloadDebounce = 500 * time.Millisecond
+
+// Watch reloads the configuration on SIGHUP and, if watchFile is set, whenever the file
+// changes, until ctx is cancelled. Valid configurations are passed to onReload; invalid
+// ones are logged and the running configuration is kept.
+func Watch(ctx context.Context, path string, watchFile bool, logger *zap.Logger, onReload func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var fileEvents <-chan fsnotify.Event
+	if watchFile {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			logger.Error("Failed to create config file watcher", zap.Error(err))
+		} else {
+			defer watcher.Close()
+
+			// Watch the directory so atomic renames and symlink swaps are seen
+			if err := watcher.Add(filepath.Dir(path)); err != nil {
+				logger.Error("Failed to watch config file", zap.Error(err), zap.String("path", path))
+			} else {
+				fileEvents = watcher.Events
+			}
+		}
+	}
+
+	reload := func(reason string) {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			logger.Error("Config reload failed, keeping current config",
+				zap.Error(err),
+				zap.String("reason", reason))
+			return
+		}
+
+		logger.Info("Config reloaded", zap.String("reason", reason))
+		onReload(cfg)
+	}
+
+	// A nil channel blocks forever, so the debounce case is idle until a file event arms it
+	var debounce <-chan time.Time
+	configFile := filepath.Clean(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("SIGHUP")
+		case event, ok := <-fileEvents:
+			if !ok {
+				fileEvents = nil
+				continue
+			}
+			// Kubernetes config maps swap a ..data symlink rather than writing the file
+			if filepath.Clean(event.Name) == configFile || filepath.Base(event.Name) == "..data" {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			reload("file changed")
+		}
+	}
+}
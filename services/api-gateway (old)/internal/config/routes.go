@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Services routes can proxy to
+const (
+	ServiceUser       = "user"
+	ServiceStrategy   = "strategy"
+	ServiceHistorical = "historical"
+	ServiceMedia      = "media"
+)
+
+// Auth requirements of routes
+const (
+	RouteAuthNone     = "none"     // The service authenticates the request, if needed
+	RouteAuthRequired = "required" // The gateway rejects requests without a valid access token
+	RouteAuthAdmin    = "admin"    // The gateway rejects requests without a valid admin access token
+)
+
+// RouteConfig maps an endpoint of every API version to the service handling it
+type RouteConfig struct {
+	// Path relative to the version prefix, in gin syntax: /strategies/:id, /market-data/*path
+	Path    string
+	Service string
+	// Methods the route accepts; empty accepts any method
+	Methods []string
+	// Auth is none (default), required or admin
+	Auth string
+	// CacheTTL overrides cache.defaultDuration for GET responses of the route
+	CacheTTL time.Duration
+	// NoCache leaves the responses of the route out of the cache
+	NoCache bool
+	// RateLimitClass names the rate limit rule requests to the route count against,
+	// ahead of the rules matching by path. The rule's methods still apply.
+	RateLimitClass string
+}
+
+// validateRoutes checks the route table: services, auth requirements, rate limit
+// classes, and paths that gin can register without conflicts
+func (c *Config) validateRoutes(fail func(key, format string, args ...interface{})) {
+	if len(c.Routes) == 0 {
+		fail("routes", "at least one route is required")
+	}
+
+	rules := make(map[string]bool, len(c.RateLimit.Rules))
+	for _, rule := range c.RateLimit.Rules {
+		rules[rule.Name] = true
+	}
+
+	// Routes are keyed by method and path with parameter names left out, since gin
+	// can't register /users/:id next to /users/:userId either
+	registered := make(map[string]int)
+	for i, route := range c.Routes {
+		key := fmt.Sprintf("routes[%d]", i)
+
+		shape, err := routeShape(route.Path)
+		if err != nil {
+			fail(key+".path", "%v, got %q", err, route.Path)
+		}
+
+		switch route.Service {
+		case ServiceUser, ServiceStrategy, ServiceHistorical, ServiceMedia:
+		default:
+			fail(key+".service", "must be one of user, strategy, historical, media, got %q", route.Service)
+		}
+
+		switch route.Auth {
+		case "", RouteAuthNone, RouteAuthRequired, RouteAuthAdmin:
+		default:
+			fail(key+".auth", "must be one of none, required, admin, got %q", route.Auth)
+		}
+
+		if route.CacheTTL < 0 {
+			fail(key+".cacheTTL", "must not be negative")
+		}
+		if route.NoCache && route.CacheTTL > 0 {
+			fail(key+".cacheTTL", "must not be set together with noCache")
+		}
+
+		if route.RateLimitClass != "" && !rules[route.RateLimitClass] {
+			fail(key+".rateLimitClass", "no rate limit rule named %q", route.RateLimitClass)
+		}
+
+		methods := route.Methods
+		if len(methods) == 0 {
+			methods = anyMethods
+		}
+		for _, method := range methods {
+			if !isHTTPMethod(method) {
+				fail(key+".methods", "unknown method %q", method)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			id := strings.ToUpper(method) + " " + shape
+			if j, ok := registered[id]; ok {
+				fail(key+".path", "%s %s is already routed by routes[%d]", strings.ToUpper(method), route.Path, j)
+				continue
+			}
+			registered[id] = i
+		}
+	}
+}
+
+// anyMethods are the methods a route without methods accepts, as with gin's Any
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodHead,
+	http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+// isHTTPMethod reports whether method is a standard HTTP method, ignoring case
+func isHTTPMethod(method string) bool {
+	for _, m := range anyMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeShape checks a route path and returns it with parameter names left out
+func routeShape(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("must start with /")
+	}
+	if path != "/" && strings.HasSuffix(path, "/") {
+		return "", fmt.Errorf("must not end with /")
+	}
+
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "" && path != "/":
+			return "", fmt.Errorf("must not contain empty segments")
+		case strings.HasPrefix(segment, ":"):
+			if len(segment) == 1 {
+				return "", fmt.Errorf("parameters must be named")
+			}
+			segments[i] = ":"
+		case strings.HasPrefix(segment, "*"):
+			if len(segment) == 1 {
+				return "", fmt.Errorf("parameters must be named")
+			}
+			if i != len(segments)-1 {
+				return "", fmt.Errorf("catch-all parameters must be the last segment")
+			}
+			segments[i] = "*"
+		case strings.ContainsAny(segment, ":*"):
+			return "", fmt.Errorf("parameters must span a whole segment")
+		}
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
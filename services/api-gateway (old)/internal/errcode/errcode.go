@@ -0,0 +1,10 @@
+// Package errcode defines the error codes of the gateway
+package errcode
+
+import "services/shared/apierror"
+
+// Codes of the gateway
+const (
+	CodeInvalidToken       apierror.Code = "INVALID_TOKEN"
+	CodeImpersonationEnded apierror.Code = "IMPERSONATION_ENDED"
+)
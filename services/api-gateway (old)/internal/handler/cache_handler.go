@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"services/api-gateway/internal/middleware"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// CacheHandler handles response cache administration
+type CacheHandler struct {
+	redisClient *redis.Client
+	prefix      string
+	logger      *zap.Logger
+}
+
+// NewCacheHandler creates a new cache handler
+func NewCacheHandler(redisClient *redis.Client, prefix string, logger *zap.Logger) *CacheHandler {
+	return &CacheHandler{
+		redisClient: redisClient,
+		prefix:      prefix,
+		logger:      logger,
+	}
+}
+
+// PurgeRequest selects which cached responses to purge. Exactly one field should be set.
+type PurgeRequest struct {
+	// All purges the whole response cache
+	All bool `json:"all"`
+	// Path purges the resource a request path belongs to, e.g. /api/v1/strategies/42
+	Path string `json:"path"`
+	// Collection purges every cached response of a collection, e.g. strategies
+	Collection string `json:"collection"`
+}
+
+// Purge handles purging cached responses
+// POST /admin/cache/purge
+func (h *CacheHandler) Purge(c *gin.Context) {
+	var request PurgeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	var pattern string
+	switch {
+	case request.All:
+		pattern = h.prefix + ":*"
+	case request.Path != "":
+		if !strings.HasPrefix(request.Path, "/") {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "path must start with /")
+			return
+		}
+		collection, id := middleware.CacheResource(request.Path)
+		pattern = fmt.Sprintf("%s:%s:%s:*", h.prefix, collection, id)
+	case request.Collection != "":
+		if strings.ContainsAny(request.Collection, "*?[]:") {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid collection")
+			return
+		}
+		pattern = fmt.Sprintf("%s:%s:*", h.prefix, request.Collection)
+	default:
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "One of all, path or collection is required")
+		return
+	}
+
+	deleted, err := middleware.PurgeCachePattern(h.redisClient, pattern)
+	if err != nil {
+		h.logger.Error("Failed to purge cache", zap.Error(err), zap.String("pattern", pattern))
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to purge cache")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	h.logger.Info("Cache purged",
+		zap.String("pattern", pattern),
+		zap.Int("deleted", deleted),
+		zap.Any("user_id", userID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"pattern": pattern,
+		"deleted": deleted,
+	})
+}
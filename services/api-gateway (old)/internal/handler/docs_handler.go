@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// docsCacheDuration is how long a merged spec is served before the services are asked again
+const docsCacheDuration = time.Minute
+
+// DocsSource is a service whose OpenAPI spec is merged into the gateway's
+type DocsSource struct {
+	// Name prefixes the service's definitions, e.g. strategy.model.Strategy
+	Name string
+	// URL is the base URL of the service, which serves its spec at /swagger.json
+	URL string
+}
+
+// DocsHandler serves one OpenAPI spec for the operations reachable through the gateway,
+// merged from the specs of the services
+type DocsHandler struct {
+	sources []DocsSource
+	routes  func() gin.RoutesInfo
+	client  *http.Client
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	spec     []byte
+	cachedAt time.Time
+}
+
+// NewDocsHandler creates a new docs handler. routes lists the routes of the gateway;
+// service operations without a matching route are left out of the merged spec.
+func NewDocsHandler(sources []DocsSource, routes func() gin.RoutesInfo, logger *zap.Logger) *DocsHandler {
+	return &DocsHandler{
+		sources: sources,
+		routes:  routes,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		logger:  logger,
+	}
+}
+
+// GetDocs handles retrieving the merged OpenAPI spec
+// GET /api/docs
+func (h *DocsHandler) GetDocs(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.spec == nil || time.Since(h.cachedAt) > docsCacheDuration {
+		spec, complete, err := h.build(c.Request.Context())
+		if err != nil {
+			h.logger.Error("Failed to build API docs", zap.Error(err))
+			apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build API docs")
+			return
+		}
+
+		// A spec missing a service is served but not kept, so the next request retries
+		if !complete {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+			return
+		}
+
+		h.spec = spec
+		h.cachedAt = time.Now()
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// build fetches the spec of every service and merges them. complete is false if a
+// service couldn't be reached.
+func (h *DocsHandler) build(ctx context.Context) ([]byte, bool, error) {
+	merged := map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":       "Trading Strategy Platform API",
+			"description": "Operations available through the API gateway.",
+			"version":     "1.0",
+		},
+		"basePath": "/",
+	}
+	paths := map[string]interface{}{}
+	definitions := map[string]interface{}{}
+	securityDefinitions := map[string]interface{}{}
+	var tags []interface{}
+	seenTags := map[string]bool{}
+
+	routes := h.routes()
+	complete := true
+
+	for _, source := range h.sources {
+		spec, err := h.fetch(ctx, source)
+		if err != nil {
+			h.logger.Warn("Failed to fetch service API docs",
+				zap.String("service", source.Name),
+				zap.Error(err))
+			complete = false
+			continue
+		}
+
+		// Definitions of different services may share a name, so they are prefixed
+		spec = prefixRefs(spec, source.Name+".").(map[string]interface{})
+
+		if serviceDefinitions, ok := spec["definitions"].(map[string]interface{}); ok {
+			for name, definition := range serviceDefinitions {
+				definitions[source.Name+"."+name] = definition
+			}
+		}
+
+		if serviceSecurity, ok := spec["securityDefinitions"].(map[string]interface{}); ok {
+			for name, definition := range serviceSecurity {
+				securityDefinitions[name] = definition
+			}
+		}
+
+		if servicePaths, ok := spec["paths"].(map[string]interface{}); ok {
+			for path, item := range servicePaths {
+				operations, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				for method, operation := range operations {
+					if !hasRoute(routes, method, path) {
+						continue
+					}
+
+					pathItem, ok := paths[path].(map[string]interface{})
+					if !ok {
+						pathItem = map[string]interface{}{}
+						paths[path] = pathItem
+					}
+					if _, exists := pathItem[method]; !exists {
+						pathItem[method] = operation
+					}
+				}
+			}
+		}
+
+		if serviceTags, ok := spec["tags"].([]interface{}); ok {
+			for _, tag := range serviceTags {
+				if object, ok := tag.(map[string]interface{}); ok {
+					name, _ := object["name"].(string)
+					if !seenTags[name] {
+						seenTags[name] = true
+						tags = append(tags, tag)
+					}
+				}
+			}
+		}
+	}
+
+	merged["paths"] = paths
+	merged["definitions"] = definitions
+	if len(securityDefinitions) > 0 {
+		merged["securityDefinitions"] = securityDefinitions
+	}
+	if len(tags) > 0 {
+		merged["tags"] = tags
+	}
+
+	spec, err := json.Marshal(merged)
+	if err != nil {
+		return nil, false, err
+	}
+	return spec, complete, nil
+}
+
+// fetch gets the spec of a service
+func (h *DocsHandler) fetch(ctx context.Context, source DocsSource) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(source.URL, "/")+"/swagger.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(body, &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// prefixRefs prefixes the definition names of every $ref in value
+func prefixRefs(value interface{}, prefix string) interface{} {
+	const definitionsRef = "#/definitions/"
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if ref, ok := field.(string); ok && key == "$ref" && strings.HasPrefix(ref, definitionsRef) {
+				v[key] = definitionsRef + prefix + strings.TrimPrefix(ref, definitionsRef)
+				continue
+			}
+			v[key] = prefixRefs(field, prefix)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = prefixRefs(item, prefix)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// hasRoute reports whether the gateway routes an operation of a service spec. Spec paths
+// use {param} segments, gateway routes :param and *wildcard segments.
+func hasRoute(routes gin.RoutesInfo, method, specPath string) bool {
+	specSegments := strings.Split(strings.Trim(specPath, "/"), "/")
+
+	for _, route := range routes {
+		if !strings.EqualFold(route.Method, method) {
+			continue
+		}
+
+		routeSegments := strings.Split(strings.Trim(route.Path, "/"), "/")
+		if matchSegments(routeSegments, specSegments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments reports whether the segments of a gateway route cover those of a spec path
+func matchSegments(routeSegments, specSegments []string) bool {
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(specSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			continue
+		}
+		if segment != specSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(specSegments)
+}
@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"services/api-gateway/internal/middleware"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// searchDefaultLimit and searchMaxLimit bound the number of merged results
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+	// searchMaxQueryLength keeps search terms to what a search box sends
+	searchMaxQueryLength = 100
+)
+
+// Types of search results
+const (
+	SearchTypeStrategy  = "strategy"
+	SearchTypeTag       = "tag"
+	SearchTypeListing   = "listing"
+	SearchTypeIndicator = "indicator"
+	SearchTypeProfile   = "profile"
+)
+
+// searchSource is a list endpoint of a service searched for one type of result
+type searchSource struct {
+	typ     string
+	baseURL string
+	path    string
+	// authRequired skips the source for anonymous requests
+	authRequired bool
+	// titleFields are matched against the search term, the first one is the result's title
+	titleFields      []string
+	descriptionField string
+}
+
+// SearchResult is an item found by a service, annotated with its type and how well it
+// matches the search term
+type SearchResult struct {
+	Type        string          `json:"type"`
+	ID          int             `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Score       float64         `json:"score"`
+	Item        json.RawMessage `json:"item"`
+
+	// position is the rank the service gave the item, breaking ties between scores
+	position int
+}
+
+// SearchHandler searches strategies, tags, marketplace listings, indicators and public
+// profiles at once, so clients need a single search box
+type SearchHandler struct {
+	sources []searchSource
+	client  *http.Client
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// NewSearchHandler creates a new search handler. Public profiles of the user service are
+// searched only if profiles is set. timeout bounds each service; the results of slower
+// services are left out.
+func NewSearchHandler(strategyServiceURL, userServiceURL string, profiles bool, timeout time.Duration, logger *zap.Logger) *SearchHandler {
+	sources := []searchSource{
+		{
+			typ:              SearchTypeStrategy,
+			baseURL:          strategyServiceURL,
+			path:             "/api/v1/strategies",
+			authRequired:     true,
+			titleFields:      []string{"name"},
+			descriptionField: "description",
+		},
+		{
+			typ:         SearchTypeTag,
+			baseURL:     strategyServiceURL,
+			path:        "/api/v1/strategy-tags",
+			titleFields: []string{"name"},
+		},
+		{
+			typ:              SearchTypeListing,
+			baseURL:          strategyServiceURL,
+			path:             "/api/v1/marketplace",
+			titleFields:      []string{"name"},
+			descriptionField: "description_public",
+		},
+		{
+			typ:              SearchTypeIndicator,
+			baseURL:          strategyServiceURL,
+			path:             "/api/v1/indicators",
+			titleFields:      []string{"name"},
+			descriptionField: "description",
+		},
+	}
+	if profiles {
+		sources = append(sources, searchSource{
+			typ:              SearchTypeProfile,
+			baseURL:          userServiceURL,
+			path:             "/api/v1/users/search",
+			titleFields:      []string{"display_name", "username"},
+			descriptionField: "bio",
+		})
+	}
+
+	return &SearchHandler{
+		sources: sources,
+		client:  &http.Client{Timeout: timeout},
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// Search handles searching across services. types limits the search to a comma-separated
+// list of result types. Results are ranked by how well their title matches: exact, then
+// prefix, then word prefix, then substring matches, then matches on other fields.
+// Services that fail or time out are listed in unavailable instead of failing the search.
+// GET /api/v1/search?q=
+func (h *SearchHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "q is required")
+		return
+	}
+	if len([]rune(query)) > searchMaxQueryLength {
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			fmt.Sprintf("q must be at most %d characters", searchMaxQueryLength))
+		return
+	}
+
+	limit := searchDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid limit")
+			return
+		}
+		limit = min(parsed, searchMaxLimit)
+	}
+
+	sources, unknown := h.selectSources(c.Query("types"))
+	if unknown != "" {
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			fmt.Sprintf("Unknown type %q, expected one of %s", unknown, strings.Join(h.types(), ", ")))
+		return
+	}
+
+	ctx := c.Request.Context()
+	authorization := c.GetHeader("Authorization")
+	clientIP := c.ClientIP()
+	requestID := c.GetString("request_id")
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		results     []SearchResult
+		unavailable []string
+	)
+	for _, source := range sources {
+		if source.authRequired && authorization == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(source searchSource) {
+			defer wg.Done()
+
+			found, err := h.searchSource(ctx, source, query, limit, authorization, clientIP, requestID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				h.logger.Warn("Search source unavailable",
+					zap.String("type", source.typ),
+					zap.String("request_id", requestID),
+					zap.Error(err))
+				unavailable = append(unavailable, source.typ)
+				return
+			}
+			results = append(results, found...)
+		}(source)
+	}
+	wg.Wait()
+
+	rankSearchResults(results, h.typeOrder())
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+
+	response := gin.H{
+		"data":  results,
+		"query": query,
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		response["unavailable"] = unavailable
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// selectSources returns the sources of a comma-separated list of types, or every source
+// if the list is empty. unknown is a requested type without a source.
+func (h *SearchHandler) selectSources(types string) (selected []searchSource, unknown string) {
+	if strings.TrimSpace(types) == "" {
+		return h.sources, ""
+	}
+
+	requested := map[string]bool{}
+	for _, typ := range strings.Split(types, ",") {
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if typ != "" {
+			requested[typ] = true
+		}
+	}
+
+	for _, source := range h.sources {
+		if requested[source.typ] {
+			selected = append(selected, source)
+			delete(requested, source.typ)
+		}
+	}
+	for typ := range requested {
+		return nil, typ
+	}
+
+	return selected, ""
+}
+
+// types lists the result types that can be searched
+func (h *SearchHandler) types() []string {
+	types := make([]string, len(h.sources))
+	for i, source := range h.sources {
+		types[i] = source.typ
+	}
+	return types
+}
+
+// typeOrder ranks result types for results that match equally well
+func (h *SearchHandler) typeOrder() map[string]int {
+	order := make(map[string]int, len(h.sources))
+	for i, source := range h.sources {
+		order[source.typ] = i
+	}
+	return order
+}
+
+// searchSource queries the list endpoint of a source with the search term and converts
+// the items it returns to results
+func (h *SearchHandler) searchSource(ctx context.Context, source searchSource, query string, limit int, authorization, clientIP, requestID string) ([]SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	target, err := url.Parse(source.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	target.Path = source.path
+	target.RawQuery = url.Values{
+		"search": {query},
+		"limit":  {strconv.Itoa(limit)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Forwarded-For", clientIP)
+	if requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", source.path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", source.path, err)
+	}
+
+	term := strings.ToLower(query)
+	results := make([]SearchResult, 0, len(body.Data))
+	for position, raw := range body.Data {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			continue
+		}
+
+		result := SearchResult{
+			Type:     source.typ,
+			Item:     raw,
+			position: position,
+		}
+		if id, ok := fields["id"].(float64); ok {
+			result.ID = int(id)
+		}
+		for i, field := range source.titleFields {
+			text, _ := fields[field].(string)
+			if i == 0 {
+				result.Title = text
+			}
+			result.Score = max(result.Score, matchScore(strings.ToLower(text), term))
+		}
+		if source.descriptionField != "" {
+			result.Description, _ = fields[source.descriptionField].(string)
+		}
+		if result.Score == 0 {
+			// The service matched it on a field other than the title
+			result.Score = 0.2
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchScore rates how well a lowercased text matches a lowercased term, 0 if the text
+// doesn't contain it
+func matchScore(text, term string) float64 {
+	switch {
+	case text == "" || term == "":
+		return 0
+	case text == term:
+		return 1
+	case strings.HasPrefix(text, term):
+		return 0.8
+	}
+
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_' || r == '/' || r == '.' || r == '(' || r == ')'
+	}) {
+		if strings.HasPrefix(word, term) {
+			return 0.6
+		}
+	}
+
+	if strings.Contains(text, term) {
+		return 0.4
+	}
+	return 0
+}
+
+// rankSearchResults sorts results by score, then by the rank their service gave them,
+// then by type
+func rankSearchResults(results []SearchResult, typeOrder map[string]int) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.position != b.position {
+			return a.position < b.position
+		}
+		return typeOrder[a.Type] < typeOrder[b.Type]
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"time"
 
+	"services/shared/apierror"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,11 +35,26 @@ func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
 	}
 }
 
+// SetLimits changes the limits and resets every client's bucket.
+// A non-positive requestsPerMinute disables limiting.
+func (r *RateLimiter) SetLimits(requestsPerMinute, burstSize int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requestsPerMinute = requestsPerMinute
+	r.burstSize = burstSize
+	r.clients = make(map[string]*TokenBucket)
+}
+
 // Allow checks if a request is allowed based on rate limits
 func (r *RateLimiter) Allow(clientIP string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.requestsPerMinute <= 0 {
+		return true
+	}
+
 	// Get or create bucket for client IP
 	bucket, exists := r.clients[clientIP]
 	if !exists {
@@ -71,16 +88,21 @@ func (r *RateLimiter) Allow(clientIP string) bool {
 
 // RateLimit creates middleware for rate limiting requests
 func RateLimit(requestsPerMinute, burstSize int) gin.HandlerFunc {
-	limiter := NewRateLimiter(requestsPerMinute, burstSize)
+	return NewRateLimiter(requestsPerMinute, burstSize).Middleware()
+}
 
+// Middleware returns the gin handler enforcing the limiter
+func (r *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get client IP
 		clientIP := c.ClientIP()
 
 		// Check if request is allowed
-		if !limiter.Allow(clientIP) {
+		if !r.Allow(clientIP) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Try again later.",
+				"error":      "Rate limit exceeded. Try again later.",
+				"code":       apierror.CodeRateLimited,
+				"request_id": c.GetString("request_id"),
 			})
 			c.Abort()
 			return
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,13 +22,39 @@ type CacheConfig struct {
 	DefaultDuration time.Duration
 	PrefixKey       string
 	ExcludedPaths   []string
+	// RouteDurations overrides DefaultDuration per route pattern (as in c.FullPath());
+	// routes mapped to zero aren't cached
+	RouteDurations map[string]time.Duration
+	// Dependents lists, per resource collection, other collections whose cached
+	// responses embed it and must be purged too (e.g. strategies -> marketplace)
+	Dependents map[string][]string
 }
 
-// RedisCache creates middleware for caching responses in Redis
+// RedisCache creates middleware for caching responses in Redis.
+// Successful mutating requests purge the cached responses of the resource they touch.
 func RedisCache(redisClient *redis.Client, config CacheConfig, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip if caching is disabled or request method is not GET
-		if !config.Enabled || c.Request.Method != "GET" {
+		if !config.Enabled {
+			c.Next()
+			return
+		}
+
+		// Invalidate after writes
+		if isMutatingMethod(c.Request.Method) {
+			c.Next()
+
+			if c.Writer.Status() < http.StatusBadRequest {
+				if err := InvalidateResource(redisClient, config.PrefixKey, c.Request.URL.Path, config.Dependents); err != nil {
+					logger.Error("Failed to invalidate cache",
+						zap.Error(err),
+						zap.String("path", c.Request.URL.Path))
+				}
+			}
+			return
+		}
+
+		// Skip if request method is not GET
+		if c.Request.Method != "GET" {
 			c.Next()
 			return
 		}
@@ -40,27 +67,48 @@ func RedisCache(redisClient *redis.Client, config CacheConfig, logger *zap.Logge
 			}
 		}
 
+		duration := config.DefaultDuration
+		if routeDuration, ok := config.RouteDurations[c.FullPath()]; ok {
+			duration = routeDuration
+		}
+		if duration <= 0 {
+			c.Next()
+			return
+		}
+
 		// Generate cache key
 		cacheKey := generateCacheKey(c, config.PrefixKey)
-
-		// Try to get from cache
 		ctx := context.Background()
-		cachedResponse, err := redisClient.Get(ctx, cacheKey).Bytes()
-		if err == nil {
-			// Cache hit
-			logger.Debug("Cache hit",
-				zap.String("path", c.Request.URL.Path),
-				zap.String("cache_key", cacheKey))
-
-			// Create a custom ResponseWriter to capture status and headers
-			c.Writer.Header().Set("Content-Type", "application/json")
-			c.Writer.Header().Set("X-Cache", "HIT")
-			c.Writer.WriteHeader(http.StatusOK)
-			c.Writer.Write(cachedResponse)
-			c.Abort()
+
+		// Cache-Control: no-cache skips the cached copy but refreshes it,
+		// no-store bypasses the cache entirely
+		noCache, noStore := cacheBypass(c.GetHeader("Cache-Control"))
+		if noStore {
+			c.Writer.Header().Set("X-Cache", "BYPASS")
+			c.Next()
 			return
 		}
 
+		// Try to get from cache
+		if !noCache {
+			cachedResponse, err := redisClient.Get(ctx, cacheKey).Bytes()
+			if err == nil {
+				// Cache hit
+				logger.Debug("Cache hit",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("cache_key", cacheKey))
+
+				c.Writer.Header().Set("Content-Type", "application/json")
+				c.Writer.Header().Set("X-Cache", "HIT")
+				c.Writer.WriteHeader(http.StatusOK)
+				c.Writer.Write(cachedResponse)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Writer.Header().Set("X-Cache", "MISS")
+
 		// Create a custom writer to capture the response
 		writer := &responseWriter{
 			ResponseWriter: c.Writer,
@@ -74,7 +122,6 @@ func RedisCache(redisClient *redis.Client, config CacheConfig, logger *zap.Logge
 		// Only cache successful responses
 		if c.Writer.Status() == http.StatusOK {
 			// Store response in cache
-			duration := config.DefaultDuration
 			responseBody := writer.body.Bytes()
 
 			err := redisClient.Set(ctx, cacheKey, responseBody, duration).Err()
@@ -104,44 +151,140 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
-// generateCacheKey creates a unique cache key for a request
+// generateCacheKey creates a unique cache key for a request.
+// Keys are grouped by resource so they can be purged by pattern:
+// {prefix}:{collection}:{id or _}:{hash of path, query and caller}
 func generateCacheKey(c *gin.Context, prefix string) string {
 	// Combine path and query parameters for the key
 	path := c.Request.URL.Path
 	query := c.Request.URL.RawQuery
 
-	// Create a hash of the path and query
+	// Create a hash of the path, query and caller so per-user responses aren't shared
 	hash := sha256.New()
 	if query != "" {
 		io.WriteString(hash, fmt.Sprintf("%s?%s", path, query))
 	} else {
 		io.WriteString(hash, path)
 	}
-	return prefix + ":" + hex.EncodeToString(hash.Sum(nil))
+	io.WriteString(hash, "|"+c.GetHeader("Authorization"))
+
+	collection, id := CacheResource(path)
+	return fmt.Sprintf("%s:%s:%s:%s", prefix, collection, id, hex.EncodeToString(hash.Sum(nil)))
 }
 
-// FlushCache clears the cache for a specific path or all paths
-func FlushCache(redisClient *redis.Client, prefix string, path string) error {
+// CacheResource maps a request path to its resource collection and ID.
+// /api/v1/strategies/42/versions -> ("strategies", "42"); /api/v1/strategies -> ("strategies", "_").
+// Every API version maps to the same resource, so a write through one purges the others.
+func CacheResource(path string) (string, string) {
+	trimmed := strings.TrimPrefix(path, "/api/v1/")
+	trimmed = strings.TrimPrefix(trimmed, "/api/v2/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	segments := strings.Split(trimmed, "/")
+
+	collection := "_"
+	if segments[0] != "" {
+		collection = globEscaper.Replace(segments[0])
+	}
+
+	id := "_"
+	if len(segments) > 1 && segments[1] != "" {
+		id = globEscaper.Replace(segments[1])
+	}
+
+	return collection, id
+}
+
+// globEscaper keeps request paths from injecting Redis glob syntax into purge patterns
+var globEscaper = strings.NewReplacer("*", "_", "?", "_", "[", "_", "]", "_", "\\", "_", ":", "_")
+
+// isMutatingMethod reports whether the method changes server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// cacheBypass parses the no-cache and no-store directives of a Cache-Control header
+func cacheBypass(cacheControl string) (noCache bool, noStore bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache":
+			noCache = true
+		case "no-store":
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
+
+// InvalidateResource purges cached responses affected by a write to the given path:
+// the item itself, its collection listings, and any dependent collections
+func InvalidateResource(redisClient *redis.Client, prefix string, path string, dependents map[string][]string) error {
+	collection, id := CacheResource(path)
+
+	var patterns []string
+	if id == "_" {
+		// A write to the collection itself may touch any item
+		patterns = append(patterns, fmt.Sprintf("%s:%s:*", prefix, collection))
+	} else {
+		patterns = append(patterns,
+			fmt.Sprintf("%s:%s:_:*", prefix, collection),
+			fmt.Sprintf("%s:%s:%s:*", prefix, collection, id))
+	}
+
+	for _, dependent := range dependents[collection] {
+		patterns = append(patterns, fmt.Sprintf("%s:%s:*", prefix, dependent))
+	}
+
+	for _, pattern := range patterns {
+		if _, err := PurgeCachePattern(redisClient, pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeCachePattern deletes all keys matching a Redis glob pattern and returns how many were removed.
+// Uses SCAN so large caches don't block Redis.
+func PurgeCachePattern(redisClient *redis.Client, pattern string) (int, error) {
 	ctx := context.Background()
+	deleted := 0
 
-	if path == "" {
-		// Flush all cache with the prefix
-		pattern := prefix + ":*"
-		keys, err := redisClient.Keys(ctx, pattern).Result()
+	var cursor uint64
+	for {
+		keys, next, err := redisClient.Scan(ctx, cursor, pattern, 500).Result()
 		if err != nil {
-			return err
+			return deleted, err
 		}
 
 		if len(keys) > 0 {
-			return redisClient.Del(ctx, keys...).Err()
+			n, err := redisClient.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += int(n)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
 		}
-		return nil
 	}
+}
 
-	// Flush specific path
-	hash := sha256.New()
-	io.WriteString(hash, path)
-	cacheKey := prefix + ":" + hex.EncodeToString(hash.Sum(nil))
+// FlushCache clears the cache for a specific path or all paths
+func FlushCache(redisClient *redis.Client, prefix string, path string) error {
+	if path == "" {
+		// Flush all cache with the prefix
+		_, err := PurgeCachePattern(redisClient, prefix+":*")
+		return err
+	}
 
-	return redisClient.Del(ctx, cacheKey).Err()
+	// Flush the resource the path belongs to
+	collection, id := CacheResource(path)
+	_, err := PurgeCachePattern(redisClient, fmt.Sprintf("%s:%s:%s:*", prefix, collection, id))
+	return err
 }
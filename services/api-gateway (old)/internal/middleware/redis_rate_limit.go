@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"services/shared/apierror"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
@@ -54,7 +56,9 @@ func RedisRateLimit(redisClient *redis.Client, config RedisRateLimitConfig, logg
 		if !allowed {
 			c.Header("Retry-After", strconv.FormatInt(resetTime-time.Now().Unix(), 10))
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Try again later.",
+				"error":      "Rate limit exceeded. Try again later.",
+				"code":       apierror.CodeRateLimited,
+				"request_id": c.GetString("request_id"),
 			})
 			c.Abort()
 			return
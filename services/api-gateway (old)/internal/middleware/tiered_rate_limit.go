@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// RateLimitRule defines a class of endpoints that share a rate limit bucket
+type RateLimitRule struct {
+	Name string
+	// Methods the rule applies to; empty matches any method
+	Methods []string
+	// Paths the rule applies to; empty matches any path. Patterns use path.Match
+	// syntax, and a trailing "/**" matches the prefix and everything below it.
+	Paths []string
+	// RequestsPerMinute is the quota per authenticated user
+	RequestsPerMinute int
+	// AnonymousRequestsPerMinute is the quota per client IP for unauthenticated requests.
+	// Zero means the same as RequestsPerMinute.
+	AnonymousRequestsPerMinute int
+}
+
+// TieredRateLimitConfig holds configuration for the tiered rate limiter
+type TieredRateLimitConfig struct {
+	Enabled            bool
+	ClientIPHeaderName string
+	// Tokens verifies access tokens so requests can be limited per user.
+	// Without it tokens aren't trusted and every request is limited per client IP.
+	Tokens *TokenVerifier
+	// Rules are matched in order; the first match decides the bucket
+	Rules []RateLimitRule
+	// RouteClasses maps route patterns (as in c.FullPath()) to the name of the rule
+	// their requests count against, ahead of matching the rules by path
+	RouteClasses map[string]string
+	// Default applies to requests no rule matches
+	Default RateLimitRule
+}
+
+// TieredRateLimiter limits requests per user (or client IP when unauthenticated) with
+// separate buckets per endpoint class, using Redis. Its configuration can be swapped at runtime.
+type TieredRateLimiter struct {
+	redisClient *redis.Client
+	config      atomic.Pointer[TieredRateLimitConfig]
+	logger      *zap.Logger
+}
+
+// NewTieredRateLimiter creates a new tiered rate limiter
+func NewTieredRateLimiter(redisClient *redis.Client, config TieredRateLimitConfig, logger *zap.Logger) *TieredRateLimiter {
+	limiter := &TieredRateLimiter{
+		redisClient: redisClient,
+		logger:      logger,
+	}
+	limiter.SetConfig(config)
+	return limiter
+}
+
+// SetConfig replaces the limits applied to subsequent requests.
+// Counts in the current window are kept, keyed by rule name.
+func (l *TieredRateLimiter) SetConfig(config TieredRateLimitConfig) {
+	l.config.Store(&config)
+}
+
+// TieredRateLimit creates middleware that limits requests per user (or client IP when
+// unauthenticated) with separate buckets per endpoint class, using Redis
+func TieredRateLimit(redisClient *redis.Client, config TieredRateLimitConfig, logger *zap.Logger) gin.HandlerFunc {
+	return NewTieredRateLimiter(redisClient, config, logger).Middleware()
+}
+
+// Middleware returns the gin handler enforcing the current limits
+func (l *TieredRateLimiter) Middleware() gin.HandlerFunc {
+	redisClient, logger := l.redisClient, l.logger
+
+	return func(c *gin.Context) {
+		config := l.config.Load()
+		if !config.Enabled {
+			c.Next()
+			return
+		}
+
+		rule, ok := routeRateLimitRule(config, c.Request.Method, c.FullPath())
+		if !ok {
+			rule = matchRateLimitRule(config.Rules, config.Default, c.Request.Method, c.Request.URL.Path)
+		}
+
+		limit := rule.RequestsPerMinute
+		identity := ""
+		if claims, ok := config.Tokens.ParseAccessToken(c.Request.Context(), c.GetHeader("Authorization")); ok {
+			identity = "user:" + claims.UserID
+		} else {
+			identity = "ip:" + clientIP(c, config.ClientIPHeaderName)
+			if rule.AnonymousRequestsPerMinute > 0 {
+				limit = rule.AnonymousRequestsPerMinute
+			}
+		}
+
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		key := fmt.Sprintf("ratelimit:%s:%s", rule.Name, identity)
+		allowed, remaining, resetTime, err := checkWindowLimit(redisClient, key, limit)
+		if err != nil {
+			logger.Error("Rate limit check failed", zap.Error(err), zap.String("key", key))
+			c.Next() // Continue on error
+			return
+		}
+
+		// Set quota headers
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime, 10))
+		c.Header("X-RateLimit-Class", rule.Name)
+
+		if !allowed {
+			retryAfter := resetTime - time.Now().Unix()
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded. Try again later.",
+				"code":        apierror.CodeRateLimited,
+				"class":       rule.Name,
+				"retry_after": retryAfter,
+				"request_id":  c.GetString("request_id"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// routeRateLimitRule returns the rule the route's class names, if its methods match
+func routeRateLimitRule(config *TieredRateLimitConfig, method, route string) (RateLimitRule, bool) {
+	class, ok := config.RouteClasses[route]
+	if !ok {
+		return RateLimitRule{}, false
+	}
+	for _, rule := range config.Rules {
+		if rule.Name != class {
+			continue
+		}
+		if len(rule.Methods) > 0 && !containsFold(rule.Methods, method) {
+			return RateLimitRule{}, false
+		}
+		return rule, true
+	}
+	return RateLimitRule{}, false
+}
+
+// matchRateLimitRule returns the first rule matching the request, or the default rule
+func matchRateLimitRule(rules []RateLimitRule, defaultRule RateLimitRule, method, requestPath string) RateLimitRule {
+	for _, rule := range rules {
+		if len(rule.Methods) > 0 && !containsFold(rule.Methods, method) {
+			continue
+		}
+		if len(rule.Paths) > 0 && !matchAnyPath(rule.Paths, requestPath) {
+			continue
+		}
+		return rule
+	}
+
+	if defaultRule.Name == "" {
+		defaultRule.Name = "default"
+	}
+	return defaultRule
+}
+
+// matchAnyPath reports whether the path matches any of the patterns
+func matchAnyPath(patterns []string, requestPath string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, requestPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the client IP, preferring the configured header when present
+func clientIP(c *gin.Context, headerName string) string {
+	if headerName != "" {
+		if headerIP := c.GetHeader(headerName); headerIP != "" {
+			return headerIP
+		}
+	}
+	return c.ClientIP()
+}
+
+// checkWindowLimit counts a request in the current one-minute window for the key
+func checkWindowLimit(redisClient *redis.Client, key string, limit int) (bool, int, int64, error) {
+	ctx := context.Background()
+	window := time.Now().Unix() / 60
+	windowKey := fmt.Sprintf("%s:%d", key, window)
+	resetTime := (window + 1) * 60
+
+	script := redis.NewScript(`
+		local current = redis.call('INCR', KEYS[1])
+		if current == 1 then
+			redis.call('EXPIRE', KEYS[1], 61)
+		end
+		return current
+	`)
+
+	result, err := script.Run(ctx, redisClient, []string{windowKey}).Int()
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	remaining := limit - result
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return result <= limit, remaining, resetTime, nil
+}
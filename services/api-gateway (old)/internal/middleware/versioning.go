@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIVersionHeader tells clients which API version served a response
+const APIVersionHeader = "API-Version"
+
+// FieldRename maps a field name of the v1 API to its name in a newer version
+type FieldRename struct {
+	V1 string
+	V2 string
+}
+
+// VersionAdapter transforms the requests and responses of a class of endpoints between
+// a newer API version and v1, which the services implement
+type VersionAdapter struct {
+	Name string
+	// Methods the adapter applies to; empty matches any method
+	Methods []string
+	// Paths the adapter applies to, with the versioned prefix (e.g. /api/v2/strategies/*).
+	// Empty matches any path. Same syntax as rate limit rule paths.
+	Paths []string
+	// Fields renames JSON object keys at any depth: new names in request bodies become
+	// v1 names, v1 names in responses become new names
+	Fields []FieldRename
+	// QueryParams renames query parameters of requests from new names to v1 names
+	QueryParams []FieldRename
+	// Envelope is the field holding the items of a v1 list response. Such responses are
+	// rewrapped as {"data": [...], "meta": {...}}. Empty leaves the response shape alone.
+	Envelope string
+}
+
+// APIVersion creates middleware serving a newer API version from the v1 routes of the
+// services. It must be installed on the version's route group: the request path is
+// rewritten to /api/v1 for the proxy, and the first matching adapter transforms the
+// request and response. Endpoints without an adapter behave as in v1.
+func APIVersion(version string, adapters []VersionAdapter, logger *zap.Logger) gin.HandlerFunc {
+	prefix := "/api/" + version
+
+	return func(c *gin.Context) {
+		versionPath := c.Request.URL.Path
+		adapter := matchVersionAdapter(adapters, c.Request.Method, versionPath)
+
+		c.Request.URL.Path = "/api/v1" + strings.TrimPrefix(versionPath, prefix)
+		c.Request.URL.RawPath = ""
+		c.Header(APIVersionHeader, version)
+
+		// Keep the versioned path for logging and auditing
+		defer func() {
+			c.Request.URL.Path = versionPath
+		}()
+
+		if adapter == nil {
+			c.Next()
+			return
+		}
+
+		if len(adapter.QueryParams) > 0 {
+			renameQueryParams(c.Request, adapter.QueryParams)
+		}
+
+		if len(adapter.Fields) > 0 && isJSON(c.Request.Header.Get("Content-Type")) {
+			if err := rewriteRequestBody(c.Request, adapter.Fields); err != nil {
+				apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid JSON request body")
+				c.Abort()
+				return
+			}
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = original
+		body := writer.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+
+		if isJSON(original.Header().Get("Content-Type")) {
+			transformed, err := transformResponseBody(body, adapter)
+			if err != nil {
+				logger.Warn("Failed to transform versioned response",
+					zap.Error(err),
+					zap.String("adapter", adapter.Name),
+					zap.String("path", versionPath))
+			} else {
+				body = transformed
+			}
+		}
+
+		// The proxied Content-Length no longer matches
+		original.Header().Del("Content-Length")
+		if _, err := original.Write(body); err != nil {
+			logger.Error("Failed to write versioned response", zap.Error(err))
+		}
+	}
+}
+
+// matchVersionAdapter returns the first adapter matching the request, or nil
+func matchVersionAdapter(adapters []VersionAdapter, method, requestPath string) *VersionAdapter {
+	for i := range adapters {
+		adapter := &adapters[i]
+		if len(adapter.Methods) > 0 && !containsFold(adapter.Methods, method) {
+			continue
+		}
+		if len(adapter.Paths) > 0 && !matchAnyPath(adapter.Paths, requestPath) {
+			continue
+		}
+		return adapter
+	}
+	return nil
+}
+
+// renameQueryParams renames query parameters from their new names to v1 names
+func renameQueryParams(r *http.Request, renames []FieldRename) {
+	query := r.URL.Query()
+	for _, rename := range renames {
+		if values, ok := query[rename.V2]; ok {
+			delete(query, rename.V2)
+			query[rename.V1] = values
+		}
+	}
+	r.URL.RawQuery = query.Encode()
+}
+
+// rewriteRequestBody renames the fields of a JSON request body to their v1 names
+func rewriteRequestBody(r *http.Request, renames []FieldRename) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return err
+	}
+
+	toV1 := make(map[string]string, len(renames))
+	for _, rename := range renames {
+		toV1[rename.V2] = rename.V1
+	}
+
+	body, err = json.Marshal(renameKeys(value, toV1))
+	if err != nil {
+		return err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// transformResponseBody rewraps and renames the fields of a v1 JSON response
+func transformResponseBody(body []byte, adapter *VersionAdapter) ([]byte, error) {
+	value, err := decodeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if adapter.Envelope != "" {
+		if object, ok := value.(map[string]interface{}); ok {
+			value = listEnvelope(object, adapter.Envelope)
+		}
+	}
+
+	if len(adapter.Fields) > 0 {
+		toVersion := make(map[string]string, len(adapter.Fields))
+		for _, rename := range adapter.Fields {
+			toVersion[rename.V1] = rename.V2
+		}
+		value = renameKeys(value, toVersion)
+	}
+
+	return json.Marshal(value)
+}
+
+// renameKeys renames the keys of every JSON object in value
+func renameKeys(value interface{}, renames map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if name, ok := renames[key]; ok {
+				key = name
+			}
+			renamed[key] = renameKeys(field, renames)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameKeys(item, renames)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// listEnvelope rewraps a v1 list response as {"data": [...], "meta": {...}}. Page
+// metadata comes from the "meta" object the services send, or from the camelCase
+// "pagination" object of older strategy and historical services. Responses whose items
+// field isn't a list, such as single resources and errors, are returned unchanged.
+func listEnvelope(body map[string]interface{}, itemsField string) map[string]interface{} {
+	items, ok := body[itemsField].([]interface{})
+	if !ok {
+		return body
+	}
+
+	meta := map[string]interface{}{}
+	if v1Meta, ok := body["meta"].(map[string]interface{}); ok {
+		for _, key := range []string{"total", "page", "limit", "total_pages", "next_cursor", "has_more"} {
+			copyField(meta, key, v1Meta, key)
+		}
+
+		// The user service doesn't count pages
+		if _, ok := meta["total_pages"]; !ok {
+			total, totalErr := jsonNumber(v1Meta["total"])
+			limit, limitErr := jsonNumber(v1Meta["limit"])
+			if totalErr == nil && limitErr == nil && limit > 0 {
+				meta["total_pages"] = int64(math.Ceil(total / limit))
+			}
+		}
+	} else if pagination, ok := body["pagination"].(map[string]interface{}); ok {
+		copyField(meta, "next_cursor", pagination, "nextCursor")
+		copyField(meta, "has_more", pagination, "hasMore")
+		copyField(meta, "total", pagination, "totalItems")
+		copyField(meta, "page", pagination, "currentPage")
+		copyField(meta, "limit", pagination, "itemsPerPage")
+		copyField(meta, "total_pages", pagination, "totalPages")
+	}
+
+	envelope := map[string]interface{}{
+		"data": items,
+		"meta": meta,
+	}
+
+	// Keep anything else the v1 response carried
+	for key, value := range body {
+		if key == itemsField || key == "pagination" || key == "meta" || key == "data" {
+			continue
+		}
+		envelope[key] = value
+	}
+
+	return envelope
+}
+
+// copyField copies from[fromKey] to to[toKey] if present
+func copyField(to map[string]interface{}, toKey string, from map[string]interface{}, fromKey string) {
+	if value, ok := from[fromKey]; ok {
+		to[toKey] = value
+	}
+}
+
+// decodeJSON decodes a JSON document, keeping numbers exact so IDs survive re-encoding
+func decodeJSON(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// jsonNumber returns the value of a number decoded by decodeJSON
+func jsonNumber(value interface{}) (float64, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, errors.New("not a number")
+	}
+	return number.Float64()
+}
+
+// isJSON reports whether a Content-Type header is JSON
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(strings.TrimSpace(contentType), "application/json")
+}
+
+// bufferedWriter holds back the response body so it can be transformed before sending
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write buffers the response body
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// WriteString buffers the response body
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httputil"
@@ -8,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"services/shared/apierror"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -36,7 +39,7 @@ func (p *ServiceProxy) ProxyRequest(c *gin.Context, path string) {
 	targetURL, err := url.Parse(p.baseURL)
 	if err != nil {
 		p.logger.Error("Failed to parse base URL", zap.Error(err), zap.String("baseURL", p.baseURL))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gateway configuration error"})
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Gateway configuration error")
 		return
 	}
 
@@ -58,7 +61,7 @@ func (p *ServiceProxy) ProxyRequest(c *gin.Context, path string) {
 	req, err := http.NewRequest(c.Request.Method, targetURL.String(), c.Request.Body)
 	if err != nil {
 		p.logger.Error("Failed to create request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
 		return
 	}
 
@@ -79,8 +82,9 @@ func (p *ServiceProxy) ProxyRequest(c *gin.Context, path string) {
 	if err != nil {
 		p.logger.Error("Failed to proxy request",
 			zap.Error(err),
-			zap.String("url", targetURL.String()))
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Service unavailable"})
+			zap.String("url", targetURL.String()),
+			zap.String("request_id", c.GetString("request_id")))
+		apierror.Send(c, http.StatusBadGateway, apierror.CodeServiceUnavailable, "Service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -110,7 +114,7 @@ func (p *ServiceProxy) ProxyWithReverseProxy(c *gin.Context, path string) {
 	targetURL, err := url.Parse(p.baseURL)
 	if err != nil {
 		p.logger.Error("Failed to parse base URL", zap.Error(err), zap.String("baseURL", p.baseURL))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gateway configuration error"})
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Gateway configuration error")
 		return
 	}
 
@@ -138,11 +142,17 @@ func (p *ServiceProxy) ProxyWithReverseProxy(c *gin.Context, path string) {
 	proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
 		p.logger.Error("Reverse proxy error",
 			zap.Error(err),
-			zap.String("url", req.URL.String()))
+			zap.String("url", req.URL.String()),
+			zap.String("request_id", c.GetString("request_id")))
 
 		// Write error response
+		rw.Header().Set("Content-Type", "application/json")
 		rw.WriteHeader(http.StatusBadGateway)
-		_, _ = rw.Write([]byte(`{"error": "Service unavailable"}`))
+		_ = json.NewEncoder(rw).Encode(apierror.Body{
+			Error:     "Service unavailable",
+			Code:      apierror.CodeServiceUnavailable,
+			RequestID: c.GetString("request_id"),
+		})
 	}
 
 	// Serve the request
@@ -9,9 +9,12 @@ from datetime import datetime
 from flask import Flask, request, jsonify
 
 from src.backtest import run_backtest
+from src.signals import preview_signals
 from src.indicators import get_available_indicators, sync_indicators
 from src.strategies import validate_strategy
+from src.custom_indicators import validate_custom_indicator
 import src.db as db
+import src.engine_jobs as engine_jobs
 
 # Configure logging
 logging.basicConfig(
@@ -141,59 +144,201 @@ def backtest_from_db():
         logger.info(f"Fetched {len(candles)} candles for backtest")
         
         # Run the backtest with the candles
-        result = run_backtest(candles, strategy, params)
+        result = run_backtest(candles, strategy, params, **load_market_data_extras(symbol_id, start_date, end_date, params))
         
         # If backtest_run_id is provided, save results to the database
         if backtest_run_id:
-            metrics = result.get('metrics', {})
-            
-            # Save backtest results
-            result_id = db.save_backtest_result(
-                backtest_run_id=backtest_run_id,
-                total_trades=metrics.get('total_trades', 0),
-                winning_trades=metrics.get('winning_trades', 0),
-                losing_trades=metrics.get('losing_trades', 0),
-                profit_factor=metrics.get('profit_factor', 0),
-                sharpe_ratio=metrics.get('sharpe_ratio', 0),
-                max_drawdown=metrics.get('max_drawdown', 0),
-                final_capital=metrics.get('final_capital', 0),
-                total_return=metrics.get('total_return', 0),
-                annualized_return=metrics.get('annualized_return', 0),
-                results_json={
-                    'equity_curve': result.get('equity_curve', []),
-                    'equity_times': result.get('equity_times', [])
-                }
-            )
-            
-            logger.info(f"Saved backtest results with ID {result_id}")
-            
-            # Save trades
-            for trade in result.get('trades', []):
-                # Convert string timestamps to datetime objects
-                entry_time = datetime.fromisoformat(trade['entry_time']) if isinstance(trade['entry_time'], str) else trade['entry_time']
-                exit_time = datetime.fromisoformat(trade['exit_time']) if trade.get('exit_time') and isinstance(trade['exit_time'], str) else trade.get('exit_time')
-                
-                trade_id = db.add_backtest_trade(
-                    backtest_run_id=backtest_run_id,
-                    symbol_id=symbol_id,
-                    entry_time=entry_time,
-                    exit_time=exit_time,
-                    position_type=trade['position_type'],
-                    entry_price=trade['entry_price'],
-                    exit_price=trade.get('exit_price'),
-                    quantity=trade['quantity'],
-                    profit_loss=trade.get('profit_loss'),
-                    profit_loss_percent=trade.get('profit_loss_percent'),
-                    exit_reason=trade.get('exit_reason')
-                )
-            
-            logger.info(f"Saved {len(result.get('trades', []))} trades")
+            save_results(backtest_run_id, symbol_id, result)
         
         return jsonify(result)
     except Exception as e:
         logger.exception(f"Error running backtest from DB: {str(e)}")
         return jsonify({"error": f"Failed to run backtest: {str(e)}"}), 500
 
+@app.route('/v2/backtests', methods=['POST'])
+def submit_backtest():
+    """Submit a backtest run. It runs in the background and reports progress and its
+    outcome to callback_url; the response only carries the job token."""
+    try:
+        data = request.json
+        if not data:
+            return jsonify({"error": "No data provided"}), 400
+            
+        symbol_id = data.get('symbol_id')
+        timeframe = data.get('timeframe')
+        start_date_str = data.get('start_date')
+        end_date_str = data.get('end_date')
+        strategy = data.get('strategy', {})
+        params = data.get('params', {})
+        backtest_run_id = data.get('backtest_run_id')
+        callback_url = data.get('callback_url')
+        lease_seconds = data.get('lease_seconds') or 120
+        
+        # Validate inputs
+        if not backtest_run_id:
+            return jsonify({"error": "Backtest run ID is required"}), 400
+        if not callback_url:
+            return jsonify({"error": "Callback URL is required"}), 400
+        if not symbol_id:
+            return jsonify({"error": "Symbol ID is required"}), 400
+        if not timeframe:
+            return jsonify({"error": "Timeframe is required"}), 400
+        if not start_date_str or not end_date_str:
+            return jsonify({"error": "Start and end date are required"}), 400
+        if not strategy:
+            return jsonify({"error": "No strategy provided"}), 400
+            
+        try:
+            start_date = datetime.fromisoformat(start_date_str.replace('Z', '+00:00'))
+            end_date = datetime.fromisoformat(end_date_str.replace('Z', '+00:00'))
+        except ValueError:
+            return jsonify({"error": "Invalid date format. Use ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)"}), 400
+        
+        def run(job):
+            if not db.get_symbol_by_id(symbol_id):
+                raise ValueError(f"Symbol with ID {symbol_id} not found")
+            
+            candles = db.get_candles(
+                symbol_id=symbol_id,
+                timeframe=timeframe,
+                start_time=start_date,
+                end_time=end_date
+            )
+            if not candles:
+                raise ValueError(f"No data found for symbol {symbol_id} in the specified time range")
+            extras = load_market_data_extras(symbol_id, start_date, end_date, params)
+            job.set_progress(0.1)
+            
+            result = run_backtest(candles, strategy, params, **extras)
+            job.set_progress(0.9)
+            
+            save_results(backtest_run_id, symbol_id, result)
+        
+        job = engine_jobs.submit(backtest_run_id, callback_url, lease_seconds, run)
+        logger.info(f"Accepted backtest job {job.token} for run {backtest_run_id}")
+        
+        return jsonify({"job_token": job.token}), 202
+    except Exception as e:
+        logger.exception(f"Error submitting backtest: {str(e)}")
+        return jsonify({"error": f"Failed to submit backtest: {str(e)}"}), 500
+
+@app.route('/signals/preview', methods=['POST'])
+def signals_preview():
+    """Preview the entry and exit signals of a strategy over stored candles, without
+    running a backtest."""
+    try:
+        data = request.json
+        if not data:
+            return jsonify({"error": "No data provided"}), 400
+            
+        symbol_id = data.get('symbol_id')
+        timeframe = data.get('timeframe')
+        start_date_str = data.get('start_date')
+        end_date_str = data.get('end_date')
+        strategy = data.get('strategy', {})
+        params = data.get('params', {})
+        
+        # Validate inputs
+        if not symbol_id:
+            return jsonify({"error": "Symbol ID is required"}), 400
+        if not timeframe:
+            return jsonify({"error": "Timeframe is required"}), 400
+        if not start_date_str or not end_date_str:
+            return jsonify({"error": "Start and end date are required"}), 400
+        if not strategy:
+            return jsonify({"error": "No strategy provided"}), 400
+            
+        try:
+            start_date = datetime.fromisoformat(start_date_str.replace('Z', '+00:00'))
+            end_date = datetime.fromisoformat(end_date_str.replace('Z', '+00:00'))
+        except ValueError:
+            return jsonify({"error": "Invalid date format. Use ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)"}), 400
+        
+        candles = db.get_candles(
+            symbol_id=symbol_id,
+            timeframe=timeframe,
+            start_time=start_date,
+            end_time=end_date
+        )
+        if not candles:
+            return jsonify({"error": f"No data found for symbol {symbol_id} in the specified time range"}), 404
+        
+        auxiliary_candles = load_market_data_extras(symbol_id, start_date, end_date, params).get('auxiliary_candles')
+        result = preview_signals(candles, strategy, params, auxiliary_candles)
+        
+        return jsonify(result)
+    except Exception as e:
+        logger.exception(f"Error previewing signals: {str(e)}")
+        return jsonify({"error": f"Failed to preview signals: {str(e)}"}), 500
+
+def load_market_data_extras(symbol_id, start_date, end_date, params):
+    """Load the market data besides candles a run's parameters ask for: funding rates,
+    the order book snapshots the spread slippage model prices fills from and the candles
+    of the auxiliary timeframes the strategy's indicators are computed on."""
+    extras = {}
+    auxiliary_timeframes = params.get('auxiliary_timeframes') or []
+    if auxiliary_timeframes:
+        extras['auxiliary_candles'] = {
+            timeframe: db.get_candles(
+                symbol_id=symbol_id,
+                timeframe=timeframe,
+                start_time=start_date,
+                end_time=end_date
+            )
+            for timeframe in auxiliary_timeframes
+        }
+    if params.get('include_funding_rates'):
+        extras['funding_rates'] = db.get_funding_rates(symbol_id, start_date, end_date)
+    if (params.get('slippage_model') or {}).get('type') == 'spread':
+        extras['order_books'] = db.get_order_book_snapshots(symbol_id, start_date, end_date)
+    return extras
+
+def save_results(backtest_run_id, symbol_id, result):
+    """Save the metrics, equity curve and trades of a run to the database."""
+    metrics = result.get('metrics', {})
+    
+    # Save backtest results
+    result_id = db.save_backtest_result(
+        backtest_run_id=backtest_run_id,
+        total_trades=metrics.get('total_trades', 0),
+        winning_trades=metrics.get('winning_trades', 0),
+        losing_trades=metrics.get('losing_trades', 0),
+        profit_factor=metrics.get('profit_factor', 0),
+        sharpe_ratio=metrics.get('sharpe_ratio', 0),
+        max_drawdown=metrics.get('max_drawdown', 0),
+        final_capital=metrics.get('final_capital', 0),
+        total_return=metrics.get('total_return', 0),
+        annualized_return=metrics.get('annualized_return', 0),
+        results_json={
+            'equity_curve': result.get('equity_curve', []),
+            'equity_times': result.get('equity_times', [])
+        }
+    )
+    
+    logger.info(f"Saved backtest results with ID {result_id}")
+    
+    # Save trades
+    for trade in result.get('trades', []):
+        # Convert string timestamps to datetime objects
+        entry_time = datetime.fromisoformat(trade['entry_time']) if isinstance(trade['entry_time'], str) else trade['entry_time']
+        exit_time = datetime.fromisoformat(trade['exit_time']) if trade.get('exit_time') and isinstance(trade['exit_time'], str) else trade.get('exit_time')
+        
+        db.add_backtest_trade(
+            backtest_run_id=backtest_run_id,
+            symbol_id=symbol_id,
+            entry_time=entry_time,
+            exit_time=exit_time,
+            position_type=trade['position_type'],
+            entry_price=trade['entry_price'],
+            exit_price=trade.get('exit_price'),
+            quantity=trade['quantity'],
+            profit_loss=trade.get('profit_loss'),
+            profit_loss_percent=trade.get('profit_loss_percent'),
+            exit_reason=trade.get('exit_reason')
+        )
+    
+    logger.info(f"Saved {len(result.get('trades', []))} trades")
+
 @app.route('/validate-strategy', methods=['POST'])
 def validate():
     """Validate a strategy structure."""
@@ -222,6 +367,30 @@ def validate():
             "error": f"Failed to validate strategy: {str(e)}"
         }), 500
 
+@app.route('/validate-indicator', methods=['POST'])
+def validate_indicator():
+    """Validate a user-defined indicator formula."""
+    try:
+        data = request.json
+        if not data:
+            return jsonify({"error": "No data provided"}), 400
+        
+        formula = data.get('formula', '')
+        parameters = data.get('parameters', [])
+        
+        valid, message = validate_custom_indicator(formula, parameters)
+        
+        return jsonify({
+            "valid": valid,
+            "message": message
+        })
+    except Exception as e:
+        logger.exception(f"Error validating indicator: {str(e)}")
+        return jsonify({
+            "valid": False,
+            "error": f"Failed to validate indicator: {str(e)}"
+        }), 500
+
 @app.route('/indicators', methods=['GET'])
 def indicators():
     """Return list of supported indicators."""
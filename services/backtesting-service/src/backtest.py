@@ -8,7 +8,7 @@ Executes backtest runs based on provided data and strategy configuration.
 import logging
 import pandas as pd
 import numpy as np
-from typing import Dict, List, Any, Tuple
+from typing import Dict, List, Any, Optional, Tuple
 from datetime import datetime
 from backtesting import Backtest
 
@@ -17,6 +17,8 @@ from src.models import (
     TradeResult, BacktestResult
 )
 from src.strategies import build_strategy
+from src.slippage import build_slippage_model
+from src.utils import to_utc_naive
 from src.db import get_candles, get_symbol_by_id, save_backtest_result, add_backtest_trade
 
 logger = logging.getLogger(__name__)
@@ -24,7 +26,10 @@ logger = logging.getLogger(__name__)
 def run_backtest(
     candles: List[Dict[str, Any]],
     strategy: Dict[str, Any],
-    params: Dict[str, Any]
+    params: Dict[str, Any],
+    funding_rates: Optional[List[Dict[str, Any]]] = None,
+    order_books: Optional[List[Dict[str, Any]]] = None,
+    auxiliary_candles: Optional[Dict[str, List[Dict[str, Any]]]] = None
 ) -> Dict[str, Any]:
     """
     Run a backtest using the provided candles and strategy configuration.
@@ -33,6 +38,10 @@ def run_backtest(
         candles: List of candle data (OHLCV)
         strategy: Strategy configuration from the frontend
         params: Backtest parameters
+        funding_rates: Funding events charged on open futures positions, if any
+        order_books: Order book snapshots the spread slippage model prices fills from
+        auxiliary_candles: Candles of the longer timeframes indicators are computed on,
+            by timeframe
     
     Returns:
         Dict containing backtest results
@@ -42,36 +51,29 @@ def run_backtest(
         backtest_params = BacktestParameters.from_dict(params)
         
         # Convert candles to DataFrame
-        df = candles_to_dataframe(candles)
-        
-        # Ensure required columns are present
-        required_columns = ['open', 'high', 'low', 'close', 'volume']
-        for col in required_columns:
-            if col not in df.columns:
-                logger.error(f"Required column {col} not found in data")
-                raise ValueError(f"Required column {col} not found in data")
-        
-        # Rename columns to match backtesting.py expected format
-        df = df.rename(columns={
-            'open': 'Open',
-            'high': 'High',
-            'low': 'Low',
-            'close': 'Close',
-            'volume': 'Volume'
-        })
-        
-        # Remove any NaN values
-        df = df.dropna()
+        df = prepare_candles(candles)
+        auxiliary_data = {
+            timeframe: prepare_candles(aux)
+            for timeframe, aux in (auxiliary_candles or {}).items()
+            if aux
+        }
         
         # Build the strategy class
-        strategy_class = build_strategy(strategy, params)
+        strategy_class = build_strategy(strategy, params, auxiliary_data)
         
-        # Run the backtest
+        # Run the backtest. backtesting.py has no separate slippage model, so fixed slippage
+        # is charged as an extra cost on every fill and other models after the run.
+        # Leverage is expressed as a margin ratio.
+        slippage_model = build_slippage_model(backtest_params.slippage_model, df, order_books)
+        commission = backtest_params.commission_rate
+        if slippage_model is None:
+            commission += backtest_params.slippage_rate
         bt = Backtest(
             df,
             strategy_class,
             cash=backtest_params.initial_capital,
-            commission=backtest_params.commission_rate/100,
+            commission=commission/100,
+            margin=1/backtest_params.leverage,
             exclusive_orders=True
         )
         
@@ -83,6 +85,14 @@ def run_backtest(
         trades = process_backtest_trades(result, backtest_params.symbol_id)
         equity_curve, equity_times = extract_equity_curve(result)
         
+        if slippage_model is not None:
+            apply_slippage(trades, metrics, equity_curve, equity_times, slippage_model,
+                           backtest_params.initial_capital)
+        
+        if backtest_params.include_funding_rates and funding_rates:
+            apply_funding(trades, metrics, equity_curve, equity_times, funding_rates,
+                          backtest_params.initial_capital)
+        
         # Create result object
         backtest_result = BacktestResult(
             trades=trades,
@@ -97,6 +107,29 @@ def run_backtest(
         logger.exception(f"Error running backtest: {str(e)}")
         raise RuntimeError(f"Failed to run backtest: {str(e)}")
 
+def prepare_candles(candles: List[Dict[str, Any]]) -> pd.DataFrame:
+    """Convert candles to the DataFrame format backtesting.py expects."""
+    df = candles_to_dataframe(candles)
+    
+    # Ensure required columns are present
+    required_columns = ['open', 'high', 'low', 'close', 'volume']
+    for col in required_columns:
+        if col not in df.columns:
+            logger.error(f"Required column {col} not found in data")
+            raise ValueError(f"Required column {col} not found in data")
+    
+    # Rename columns to match backtesting.py expected format
+    df = df.rename(columns={
+        'open': 'Open',
+        'high': 'High',
+        'low': 'Low',
+        'close': 'Close',
+        'volume': 'Volume'
+    })
+    
+    # Remove any NaN values
+    return df.dropna()
+
 def run_backtest_with_db(
     symbol_id: int,
     timeframe: str,
@@ -143,8 +176,14 @@ def run_backtest_with_db(
         if 'symbol_id' not in params:
             params['symbol_id'] = symbol_id
             
+        # Indicators on longer timeframes are computed on their own candles
+        auxiliary_candles = {
+            aux_timeframe: get_candles(symbol_id, aux_timeframe, start_time, end_time)
+            for aux_timeframe in params.get('auxiliary_timeframes') or []
+        }
+        
         # Run the backtest
-        result = run_backtest(candles, strategy, params)
+        result = run_backtest(candles, strategy, params, auxiliary_candles=auxiliary_candles)
         
         # If backtest_run_id is provided, save results to the database
         if backtest_run_id:
@@ -197,6 +236,125 @@ def run_backtest_with_db(
         logger.exception(f"Error running backtest with DB: {str(e)}")
         raise RuntimeError(f"Failed to run backtest: {str(e)}")
 
+def apply_funding(
+    trades: List[TradeResult],
+    metrics: BacktestMetrics,
+    equity_curve: List[float],
+    equity_times: List[datetime],
+    funding_rates: List[Dict[str, Any]],
+    initial_capital: float
+) -> None:
+    """
+    Charge the funding of perpetual futures positions on the trades open at each funding
+    event. Longs pay the rate on the position value and shorts receive it; negative rates
+    go the other way. Trades, the equity curve and the capital metrics are adjusted in place.
+    
+    Args:
+        trades: Trades of the backtest
+        metrics: Metrics of the backtest
+        equity_curve: Equity values of the backtest
+        equity_times: Times of the equity values
+        funding_rates: Funding events, oldest first
+        initial_capital: Initial capital amount
+    """
+    events = [(to_utc_naive(f['time']), f['rate'], f['mark_price']) for f in funding_rates]
+    charges = []
+    
+    for trade in trades:
+        entry_time = to_utc_naive(trade.entry_time)
+        exit_time = to_utc_naive(trade.exit_time) if trade.exit_time is not None else None
+        direction = 1 if trade.position_type == 'long' else -1
+        
+        paid = 0.0
+        for time, rate, mark_price in events:
+            if time <= entry_time or (exit_time is not None and time > exit_time):
+                continue
+            charge = direction * trade.quantity * (mark_price or trade.entry_price) * rate
+            charges.append((time, charge))
+            paid += charge
+        
+        if paid:
+            trade.profit_loss -= paid
+            trade.profit_loss_percent = trade.profit_loss / (trade.entry_price * trade.quantity) * 100
+    
+    metrics.funding_paid = charge_equity(metrics, equity_curve, equity_times, charges, initial_capital)
+
+def apply_slippage(
+    trades: List[TradeResult],
+    metrics: BacktestMetrics,
+    equity_curve: List[float],
+    equity_times: List[datetime],
+    slippage_model: Any,
+    initial_capital: float
+) -> None:
+    """
+    Charge the slippage of a slippage model on the entry and exit fills of the trades.
+    Trades, the equity curve and the capital metrics are adjusted in place.
+    
+    Args:
+        trades: Trades of the backtest
+        metrics: Metrics of the backtest
+        equity_curve: Equity values of the backtest
+        equity_times: Times of the equity values
+        slippage_model: Model pricing the slippage of each fill in basis points
+        initial_capital: Initial capital amount
+    """
+    charges = []
+    
+    for trade in trades:
+        # Longs buy on entry and sell on exit, shorts the other way
+        buy_on_entry = trade.position_type == 'long'
+        fills = [(trade.entry_time, trade.entry_price, buy_on_entry)]
+        if trade.exit_time is not None and trade.exit_price is not None:
+            fills.append((trade.exit_time, trade.exit_price, not buy_on_entry))
+        
+        paid = 0.0
+        for time, price, buy in fills:
+            basis_points = slippage_model.basis_points(time, trade.quantity, buy)
+            charge = trade.quantity * price * basis_points / 10000
+            charges.append((to_utc_naive(time), charge))
+            paid += charge
+        
+        if paid:
+            trade.profit_loss -= paid
+            trade.profit_loss_percent = trade.profit_loss / (trade.entry_price * trade.quantity) * 100
+    
+    metrics.slippage_paid = charge_equity(metrics, equity_curve, equity_times, charges, initial_capital)
+
+def charge_equity(
+    metrics: BacktestMetrics,
+    equity_curve: List[float],
+    equity_times: List[datetime],
+    charges: List[Tuple[pd.Timestamp, float]],
+    initial_capital: float
+) -> float:
+    """
+    Take charges made after the run out of the equity curve from their time on, and out of
+    the capital metrics.
+    
+    Returns:
+        Total of the charges
+    """
+    total = sum(charge for _, charge in charges)
+    if not total:
+        return 0.0
+    
+    # Equity after a charge carries it
+    charges = sorted(charges, key=lambda c: c[0])
+    paid, i = 0.0, 0
+    for j, time in enumerate(equity_times):
+        while i < len(charges) and charges[i][0] <= to_utc_naive(time):
+            paid += charges[i][1]
+            i += 1
+        equity_curve[j] -= paid
+    
+    metrics.final_capital -= total
+    metrics.total_return = (metrics.final_capital / initial_capital - 1) * 100
+    if metrics.total_trades > 0:
+        metrics.average_trade = (metrics.final_capital - initial_capital) / metrics.total_trades
+    
+    return total
+
 def process_backtest_metrics(result: pd.Series, initial_capital: float) -> BacktestMetrics:
     """
     Process backtest results to generate performance metrics.
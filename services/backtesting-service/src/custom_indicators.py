@@ -0,0 +1,192 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+User-defined indicators.
+Evaluates indicator formulas such as "(close - SMA(close, period)) / STD(close, period)"
+against candle data. Formulas are parsed and checked before evaluation, so only price
+series, parameters, numbers, arithmetic and the functions below can be used.
+"""
+
+import ast
+import logging
+from typing import Any, Dict, List, Tuple
+
+import numpy as np
+import pandas as pd
+
+logger = logging.getLogger(__name__)
+
+# Price series available to formulas
+SERIES = ["open", "high", "low", "close", "volume"]
+
+MAX_FORMULA_LENGTH = 1000
+
+
+def _window(value: Any) -> int:
+    window = int(value)
+    if window < 1:
+        raise ValueError("window must be at least 1")
+    return window
+
+
+# Functions available to formulas, with their number of arguments
+FUNCTIONS = {
+    "SMA": (2, lambda s, n: s.rolling(_window(n)).mean()),
+    "EMA": (2, lambda s, n: s.ewm(span=_window(n), adjust=False).mean()),
+    "STD": (2, lambda s, n: s.rolling(_window(n)).std()),
+    "MAX": (2, lambda s, n: s.rolling(_window(n)).max()),
+    "MIN": (2, lambda s, n: s.rolling(_window(n)).min()),
+    "SUM": (2, lambda s, n: s.rolling(_window(n)).sum()),
+    "SHIFT": (2, lambda s, n: s.shift(int(n))),
+    "ABS": (1, lambda s: s.abs()),
+    "LOG": (1, lambda s: np.log(s)),
+    "SQRT": (1, lambda s: np.sqrt(s)),
+}
+
+BINARY_OPERATORS = {
+    ast.Add: lambda a, b: a + b,
+    ast.Sub: lambda a, b: a - b,
+    ast.Mult: lambda a, b: a * b,
+    ast.Div: lambda a, b: a / b,
+    ast.Pow: lambda a, b: a ** b,
+    ast.Mod: lambda a, b: a % b,
+}
+
+UNARY_OPERATORS = {
+    ast.USub: lambda a: -a,
+    ast.UAdd: lambda a: a,
+}
+
+
+def _check_node(node: ast.AST, names: List[str]) -> None:
+    """Raise ValueError if the expression uses anything outside the formula language."""
+    if isinstance(node, ast.Expression):
+        _check_node(node.body, names)
+    elif isinstance(node, ast.BinOp):
+        if type(node.op) not in BINARY_OPERATORS:
+            raise ValueError(f"Operator {type(node.op).__name__} is not supported")
+        _check_node(node.left, names)
+        _check_node(node.right, names)
+    elif isinstance(node, ast.UnaryOp):
+        if type(node.op) not in UNARY_OPERATORS:
+            raise ValueError(f"Operator {type(node.op).__name__} is not supported")
+        _check_node(node.operand, names)
+    elif isinstance(node, ast.Constant):
+        if not isinstance(node.value, (int, float)) or isinstance(node.value, bool):
+            raise ValueError("Only numeric constants are supported")
+    elif isinstance(node, ast.Name):
+        if node.id not in names:
+            raise ValueError(f"Unknown name '{node.id}'")
+    elif isinstance(node, ast.Call):
+        if not isinstance(node.func, ast.Name) or node.func.id not in FUNCTIONS:
+            raise ValueError("Unknown function; supported functions are " + ", ".join(FUNCTIONS))
+        if node.keywords:
+            raise ValueError(f"{node.func.id} does not take keyword arguments")
+        arity = FUNCTIONS[node.func.id][0]
+        if len(node.args) != arity:
+            raise ValueError(f"{node.func.id} takes {arity} argument(s)")
+        for arg in node.args:
+            _check_node(arg, names)
+    else:
+        raise ValueError(f"Unsupported expression: {type(node).__name__}")
+
+
+def parse_formula(formula: str, parameters: Dict[str, Any]) -> ast.Expression:
+    """Parse and check a formula. Parameter names can be used alongside the price series."""
+    if not formula or not formula.strip():
+        raise ValueError("Formula is required")
+    if len(formula) > MAX_FORMULA_LENGTH:
+        raise ValueError(f"Formula must be at most {MAX_FORMULA_LENGTH} characters")
+
+    for name in parameters:
+        if name in SERIES or name in FUNCTIONS:
+            raise ValueError(f"Parameter name '{name}' is reserved")
+
+    try:
+        tree = ast.parse(formula.strip(), mode="eval")
+    except SyntaxError as e:
+        raise ValueError(f"Invalid formula syntax: {e.msg}")
+
+    _check_node(tree, SERIES + list(parameters))
+    return tree
+
+
+def _evaluate(node: ast.AST, scope: Dict[str, Any]) -> Any:
+    if isinstance(node, ast.Expression):
+        return _evaluate(node.body, scope)
+    if isinstance(node, ast.BinOp):
+        return BINARY_OPERATORS[type(node.op)](_evaluate(node.left, scope), _evaluate(node.right, scope))
+    if isinstance(node, ast.UnaryOp):
+        return UNARY_OPERATORS[type(node.op)](_evaluate(node.operand, scope))
+    if isinstance(node, ast.Constant):
+        return node.value
+    if isinstance(node, ast.Name):
+        return scope[node.id]
+    if isinstance(node, ast.Call):
+        args = [_evaluate(arg, scope) for arg in node.args]
+        return FUNCTIONS[node.func.id][1](*args)
+    raise ValueError(f"Unsupported expression: {type(node).__name__}")
+
+
+def evaluate_formula(df: pd.DataFrame, formula: str, parameters: Dict[str, Any]) -> pd.Series:
+    """Evaluate a formula over candle data and return the indicator series."""
+    tree = parse_formula(formula, parameters)
+
+    scope: Dict[str, Any] = {name: float(value) for name, value in parameters.items()}
+    for name in SERIES:
+        column = name if name in df.columns else name.capitalize()
+        scope[name] = df[column].astype(float)
+
+    with np.errstate(divide="ignore", invalid="ignore"):
+        result = _evaluate(tree, scope)
+
+    if not isinstance(result, pd.Series):
+        # A formula without price series is constant
+        result = pd.Series(float(result), index=df.index)
+
+    return result.replace([np.inf, -np.inf], np.nan)
+
+
+def calculate_custom_indicator(df: pd.DataFrame, name: str, formula: str,
+                               parameters: Dict[str, Any]) -> pd.DataFrame:
+    """Calculate a user-defined indicator and add it to the dataframe."""
+    try:
+        df[name] = evaluate_formula(df, formula, parameters)
+    except Exception as e:
+        logger.error(f"Error calculating custom indicator {name}: {str(e)}")
+    return df
+
+
+def validate_custom_indicator(formula: str, parameters: List[Dict[str, Any]]) -> Tuple[bool, str]:
+    """
+    Validate a custom indicator by parsing its formula and evaluating it on sample data
+    with the parameters' default values.
+    """
+    try:
+        defaults = {}
+        for param in parameters:
+            name = param.get("name")
+            if not name or not name.isidentifier():
+                return False, f"Invalid parameter name '{name}'"
+            defaults[name] = float(param.get("default") or 0)
+
+        # A random walk is enough to catch runtime errors such as bad windows
+        rng = np.random.default_rng(0)
+        close = pd.Series(100 + rng.normal(0, 1, 300).cumsum())
+        sample = pd.DataFrame({
+            "open": close.shift(1).fillna(close.iloc[0]),
+            "high": close + 1,
+            "low": close - 1,
+            "close": close,
+            "volume": pd.Series(rng.integers(1000, 10000, 300), dtype=float),
+        })
+
+        result = evaluate_formula(sample, formula, defaults)
+        if result.notna().sum() == 0:
+            return False, "Formula produces no values"
+    except ValueError as e:
+        return False, str(e)
+    except Exception as e:
+        return False, f"Formula could not be evaluated: {str(e)}"
+
+    return True, "Indicator formula is valid"
@@ -150,6 +150,91 @@ def get_candles(
         if conn:
             conn.close()
             
+def get_funding_rates(
+    symbol_id: int,
+    start_time: datetime,
+    end_time: datetime,
+    limit: int = 100000
+) -> List[Dict[str, Any]]:
+    """
+    Get the funding rates of a perpetual futures symbol from the database.
+    
+    Args:
+        symbol_id: Symbol ID
+        start_time: Start time
+        end_time: End time
+        limit: Maximum number of funding events
+        
+    Returns:
+        List of funding rate dictionaries, oldest first
+    """
+    conn = None
+    try:
+        conn = get_historical_db_connection()
+        with conn.cursor(cursor_factory=psycopg2.extras.DictCursor) as cursor:
+            cursor.execute(
+                "SELECT * FROM get_funding_rates(%s, %s, %s, %s)",
+                (symbol_id, start_time, end_time, limit)
+            )
+            
+            return [
+                {
+                    'time': row['funding_time'],
+                    'rate': float(row['funding_rate']),
+                    'mark_price': float(row['mark_price']) if row['mark_price'] is not None else None
+                }
+                for row in cursor.fetchall()
+            ]
+    except Exception as e:
+        logger.error(f"Failed to get funding rates: {str(e)}")
+        raise RuntimeError(f"Failed to get funding rates: {str(e)}")
+    finally:
+        if conn:
+            conn.close()
+
+def get_order_book_snapshots(
+    symbol_id: int,
+    start_time: datetime,
+    end_time: datetime,
+    limit: int = 1000000
+) -> List[Dict[str, Any]]:
+    """
+    Get the order book snapshots captured for a symbol from the database.
+    
+    Args:
+        symbol_id: Symbol ID
+        start_time: Start time
+        end_time: End time
+        limit: Maximum number of snapshots
+        
+    Returns:
+        List of snapshot dictionaries with bids and asks as price and quantity levels,
+        oldest first
+    """
+    conn = None
+    try:
+        conn = get_historical_db_connection()
+        with conn.cursor(cursor_factory=psycopg2.extras.DictCursor) as cursor:
+            cursor.execute(
+                "SELECT * FROM get_order_book_snapshots(%s, %s, %s, %s)",
+                (symbol_id, start_time, end_time, limit)
+            )
+            
+            return [
+                {
+                    'time': row['snapshot_time'],
+                    'bids': row['bids'],
+                    'asks': row['asks']
+                }
+                for row in cursor.fetchall()
+            ]
+    except Exception as e:
+        logger.error(f"Failed to get order book snapshots: {str(e)}")
+        raise RuntimeError(f"Failed to get order book snapshots: {str(e)}")
+    finally:
+        if conn:
+            conn.close()
+
 def get_symbol_by_id(symbol_id: int) -> Dict[str, Any]:
     """
     Get symbol information by ID.
@@ -358,7 +443,7 @@ def sync_indicators_to_db(indicators_data: List[Dict[str, Any]]) -> Dict[str, An
         cursor = conn.cursor()
         
         # Get existing indicators for comparison
-        cursor.execute("SELECT id, name FROM indicators")
+        cursor.execute("SELECT id, name FROM indicators WHERE owner_id IS NULL")
         existing_indicators = {name: id for id, name in cursor.fetchall()}
         
         # Start transaction
@@ -0,0 +1,112 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Async backtest jobs: runs submitted through /v2/backtests execute in the background and
+report progress and their outcome to the callback URL they were submitted with.
+"""
+
+import json
+import logging
+import os
+import threading
+import urllib.error
+import urllib.request
+import uuid
+
+logger = logging.getLogger(__name__)
+
+# Key the historical service expects on callbacks
+HISTORICAL_SERVICE_KEY = os.getenv('HISTORICAL_SERVICE_KEY', 'historical-service-key')
+
+# Timeout of a single callback request, in seconds
+CALLBACK_TIMEOUT = 10
+
+
+class JobCancelled(Exception):
+    """Raised when the historical service no longer accepts reports about a job."""
+
+
+class Job:
+    """A run executing in the background."""
+
+    def __init__(self, backtest_run_id, callback_url, lease_seconds):
+        self.token = uuid.uuid4().hex
+        self.backtest_run_id = backtest_run_id
+        self.callback_url = callback_url
+        # Report at least three times per lease, so one lost callback doesn't expire it
+        self.heartbeat = max(lease_seconds / 3.0, 1.0)
+        self.progress = 0.0
+        self.cancelled = False
+        self._done = threading.Event()
+
+    def report(self, status, progress=None, error=None):
+        """Send a callback. Returns False if the historical service rejected it."""
+        body = {
+            'job_token': self.token,
+            'backtest_run_id': self.backtest_run_id,
+            'status': status,
+        }
+        if progress is not None:
+            body['progress'] = progress
+        if error:
+            body['error'] = error
+
+        req = urllib.request.Request(
+            self.callback_url,
+            data=json.dumps(body).encode('utf-8'),
+            headers={
+                'Content-Type': 'application/json',
+                'X-Service-Key': HISTORICAL_SERVICE_KEY,
+            },
+            method='POST'
+        )
+        try:
+            with urllib.request.urlopen(req, timeout=CALLBACK_TIMEOUT):
+                return True
+        except urllib.error.HTTPError as e:
+            # 409: the run failed on the historical side, e.g. its lease expired
+            if e.code == 409:
+                return False
+            logger.warning(f"Callback for run {self.backtest_run_id} returned {e.code}")
+        except Exception as e:
+            logger.warning(f"Callback for run {self.backtest_run_id} failed: {str(e)}")
+        return True
+
+    def set_progress(self, progress):
+        """Record how much of the run is done; raises JobCancelled if it was cancelled."""
+        self.progress = progress
+        if self.cancelled:
+            raise JobCancelled()
+
+    def _heartbeat(self):
+        while not self._done.wait(self.heartbeat):
+            if not self.report('progress', progress=self.progress):
+                logger.info(f"Run {self.backtest_run_id} is no longer active, cancelling")
+                self.cancelled = True
+                return
+
+
+def submit(backtest_run_id, callback_url, lease_seconds, run):
+    """Start run(job) in the background and return the job. run returns when the run
+    completed and raises if it failed."""
+    job = Job(backtest_run_id, callback_url, lease_seconds)
+
+    def execute():
+        heartbeat = threading.Thread(target=job._heartbeat, daemon=True)
+        heartbeat.start()
+        try:
+            run(job)
+        except JobCancelled:
+            return
+        except Exception as e:
+            logger.exception(f"Error running backtest job for run {backtest_run_id}: {str(e)}")
+            job._done.set()
+            job.report('failed', error=f"Failed to run backtest: {str(e)}")
+            return
+        finally:
+            job._done.set()
+
+        job.report('completed', progress=1.0)
+
+    threading.Thread(target=execute, daemon=True).start()
+    return job
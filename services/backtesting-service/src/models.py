@@ -50,12 +50,15 @@ class BacktestParameters:
     leverage: float = 1.0
     commission_rate: float = 0.1  # In percentage
     slippage_rate: float = 0.05  # In percentage
-    position_sizing: str = 'fixed'  # 'fixed', 'percentage', 'risk_based'
+    position_sizing: str = 'fixed'  # 'fixed', 'percentage', 'risk_based', 'fixed_fractional', 'kelly', 'volatility_target'
+    position_sizing_params: Optional[Dict[str, Any]] = None  # Parameters of the sizing mode
     risk_percentage: float = 2.0  # In percentage
     stop_loss: float = 0.0  # In percentage
     take_profit: float = 0.0  # In percentage
     trailing_stop: float = 0.0  # In percentage
     allow_short: bool = False
+    include_funding_rates: bool = False  # Charge funding on open futures positions
+    slippage_model: Optional[Dict[str, Any]] = None  # Without one, slippage_rate is charged on every fill
 
     @classmethod
     def from_dict(cls, data: Dict[str, Any]) -> 'BacktestParameters':
@@ -68,11 +71,14 @@ class BacktestParameters:
             commission_rate=float(data.get('commission_rate', 0.1)),
             slippage_rate=float(data.get('slippage_rate', 0.05)),
             position_sizing=data.get('position_sizing', 'fixed'),
+            position_sizing_params=data.get('position_sizing_params'),
             risk_percentage=float(data.get('risk_percentage', 2.0)),
             stop_loss=float(data.get('stop_loss', 0.0)),
             take_profit=float(data.get('take_profit', 0.0)),
             trailing_stop=float(data.get('trailing_stop', 0.0)),
-            allow_short=bool(data.get('allow_short', False))
+            allow_short=bool(data.get('allow_short', False)),
+            include_funding_rates=bool(data.get('include_funding_rates', False)),
+            slippage_model=data.get('slippage_model')
         )
 
 @dataclass
@@ -107,6 +113,8 @@ class BacktestMetrics:
     average_loss: float
     largest_win: float
     largest_loss: float
+    funding_paid: float = 0.0  # Net funding paid by positions, negative when received
+    slippage_paid: float = 0.0  # Slippage charged after the run by non-fixed slippage models
 
 @dataclass
 class BacktestResult:
@@ -0,0 +1,109 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Signal previews of strategies.
+Evaluates a strategy's rules over candles and reports when it would enter and exit, with
+the conditions behind each signal, without sizing or simulating trades.
+"""
+
+import logging
+import math
+from typing import Dict, List, Any, Optional
+from backtesting import Backtest
+
+from src.backtest import prepare_candles
+from src.strategies import build_strategy
+from src.utils import to_utc_naive
+
+logger = logging.getLogger(__name__)
+
+def preview_signals(
+    candles: List[Dict[str, Any]],
+    strategy: Dict[str, Any],
+    params: Dict[str, Any],
+    auxiliary_candles: Optional[Dict[str, List[Dict[str, Any]]]] = None
+) -> Dict[str, Any]:
+    """
+    Evaluate a strategy's rules on every candle and list its signals. A position is
+    entered when the buy rules match, or the sell rules when shorting is allowed, and
+    exited when the opposite rules match. Stop losses, take profits and trailing stops
+    aren't evaluated.
+
+    Args:
+        candles: List of candle data (OHLCV)
+        strategy: Strategy configuration from the frontend
+        params: Backtest parameters; only allow_short is used
+        auxiliary_candles: Candles of the longer timeframes indicators are computed on,
+            by timeframe
+
+    Returns:
+        Dict with the signals, oldest first, and the number of candles evaluated
+    """
+    df = prepare_candles(candles)
+    auxiliary_data = {
+        timeframe: prepare_candles(aux)
+        for timeframe, aux in (auxiliary_candles or {}).items()
+        if aux
+    }
+
+    class SignalPreviewStrategy(build_strategy(strategy, params, auxiliary_data)):
+        def init(self):
+            super().init()
+            self.signals = []
+            self.side = None
+
+        def next(self):
+            i = len(self.data) - 1
+            if self.side is None:
+                if self.buy_rules and self._evaluate_rules(self.buy_rules, i):
+                    self._record("entry", "long", self.buy_rules, i)
+                elif self.allow_short and self.sell_rules and self._evaluate_rules(self.sell_rules, i):
+                    self._record("entry", "short", self.sell_rules, i)
+            elif self.side == "long":
+                if self.sell_rules and self._evaluate_rules(self.sell_rules, i):
+                    self._record("exit", "long", self.sell_rules, i)
+            elif self.buy_rules and self._evaluate_rules(self.buy_rules, i):
+                self._record("exit", "short", self.buy_rules, i)
+
+        def _record(self, signal_type: str, side: str, rules: Dict[str, Any], i: int) -> None:
+            self.side = side if signal_type == "entry" else None
+            self.signals.append({
+                "time": to_utc_naive(self.data.index[i]).strftime("%Y-%m-%dT%H:%M:%SZ"),
+                "type": signal_type,
+                "side": side,
+                "price": float(self.data.Close[i]),
+                "conditions": rule_conditions(self, rules, i)
+            })
+
+    bt = Backtest(df, SignalPreviewStrategy, cash=10000, commission=0)
+    result = bt.run()
+
+    return {
+        "signals": result._strategy.signals,
+        "candles": len(df)
+    }
+
+def rule_conditions(strategy: Any, rules: Dict[str, Any], i: int) -> List[Dict[str, Any]]:
+    """List the conditions of a rule group and its nested groups, with the value each
+    indicator had on the candle and whether the condition matched."""
+    conditions = []
+    for key, value in rules.items():
+        if not isinstance(value, dict):
+            continue
+        if key.startswith("group"):
+            conditions.extend(rule_conditions(strategy, value, i))
+        elif key.startswith("rule"):
+            indicator = value.get("indicator", {})
+            condition = value.get("condition", {})
+            actual = strategy._get_indicator_value(
+                indicator.get("name"), indicator.get("indicatorSettings", {}), i, indicator.get("timeframe"))
+            actual = float(actual) if actual is not None else None
+            conditions.append({
+                "indicator": indicator.get("name"),
+                "timeframe": indicator.get("timeframe"),
+                "symbol": condition.get("symbol"),
+                "value": float(condition.get("value", 0)),
+                "actual": None if actual is None or math.isnan(actual) else actual,
+                "matched": strategy._evaluate_rule(value, i)
+            })
+    return conditions
@@ -0,0 +1,107 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+Slippage models for backtest fills.
+The fixed model is charged by backtesting.py as part of the commission; the others price
+each fill from the market around it and are charged on the trades after the run.
+"""
+
+import logging
+import bisect
+from typing import Dict, List, Any, Optional
+from datetime import timedelta
+import pandas as pd
+
+from src.utils import to_utc_naive
+
+logger = logging.getLogger(__name__)
+
+# How old an order book snapshot may be to price a fill under the spread model
+MAX_SNAPSHOT_AGE = timedelta(minutes=5)
+
+class VolumeParticipationSlippage:
+    """
+    Charges a fill in proportion to its share of the volume of the candle it fills in:
+    a fill trading the whole candle's volume pays impact_basis_points, capped at
+    max_basis_points.
+    """
+
+    def __init__(self, config: Dict[str, Any], df: pd.DataFrame):
+        self.impact = float(config.get('impact_basis_points', 0))
+        self.cap = float(config.get('max_basis_points', 100))
+        self.volumes = df['Volume'].copy()
+        self.volumes.index = pd.DatetimeIndex([to_utc_naive(t) for t in self.volumes.index])
+
+    def basis_points(self, time: Any, quantity: float, buy: bool) -> float:
+        position = self.volumes.index.searchsorted(to_utc_naive(time), side='right') - 1
+        if position < 0:
+            return self.cap
+        volume = self.volumes.iloc[position]
+        if volume <= 0:
+            return self.cap
+        return min(self.cap, self.impact * quantity / volume)
+
+class SpreadSlippage:
+    """
+    Charges a fill the distance between the mid price and the average price of walking the
+    order book captured shortly before it, capped at max_basis_points. Quantity beyond the
+    captured levels fills at the last level. Fills without a recent snapshot pay
+    fallback_basis_points.
+    """
+
+    def __init__(self, config: Dict[str, Any], order_books: List[Dict[str, Any]]):
+        self.cap = float(config.get('max_basis_points', 100))
+        self.fallback = float(config.get('fallback_basis_points', 5))
+        self.books = sorted(order_books, key=lambda b: to_utc_naive(b['time']))
+        self.times = [to_utc_naive(b['time']) for b in self.books]
+
+    def basis_points(self, time: Any, quantity: float, buy: bool) -> float:
+        time = to_utc_naive(time)
+        position = bisect.bisect_right(self.times, time) - 1
+        if position < 0 or time - self.times[position] > MAX_SNAPSHOT_AGE:
+            return self.fallback
+
+        book = self.books[position]
+        if not book['bids'] or not book['asks']:
+            return self.fallback
+        mid = (book['bids'][0]['price'] + book['asks'][0]['price']) / 2
+
+        # Buys walk up the asks, sells down the bids
+        remaining, cost = quantity, 0.0
+        levels = book['asks'] if buy else book['bids']
+        for level in levels:
+            filled = min(remaining, level['quantity'])
+            cost += filled * level['price']
+            remaining -= filled
+            if remaining <= 0:
+                break
+        if remaining > 0:
+            cost += remaining * levels[-1]['price']
+
+        average_price = cost / quantity if quantity > 0 else mid
+        return min(self.cap, abs(average_price - mid) / mid * 10000)
+
+def build_slippage_model(
+    config: Optional[Dict[str, Any]],
+    df: pd.DataFrame,
+    order_books: Optional[List[Dict[str, Any]]] = None
+):
+    """
+    Build the slippage model charged on trades after the run.
+
+    Args:
+        config: Slippage model configuration from the backtest parameters
+        df: Candles of the backtest
+        order_books: Order book snapshots, needed by the spread model
+
+    Returns:
+        The slippage model, or None when slippage is charged by backtesting.py
+    """
+    model_type = (config or {}).get('type', 'fixed')
+    if model_type == 'volume_participation':
+        return VolumeParticipationSlippage(config, df)
+    if model_type == 'spread':
+        if not order_books:
+            logger.warning("No order book snapshots for the spread slippage model, charging the fallback")
+        return SpreadSlippage(config, order_books or [])
+    return None
@@ -11,6 +11,8 @@ import pandas as pd
 from backtesting import Strategy
 
 from src.indicators import calculate_indicator
+from src.custom_indicators import calculate_custom_indicator
+from src.utils import timeframe_to_timedelta, to_utc_naive
 
 logger = logging.getLogger(__name__)
 
@@ -20,6 +22,9 @@ class DynamicStrategy(Strategy):
     Uses backtesting.py's Strategy class as a base.
     """
     
+    # Candles of the auxiliary timeframes indicators are computed on, by timeframe
+    auxiliary_data: Dict[str, pd.DataFrame] = {}
+    
     def init(self):
         """Initialize the strategy and calculate indicators."""
         # Setup indicators based on the strategy configuration
@@ -35,9 +40,16 @@ class DynamicStrategy(Strategy):
         self.take_profit_pct = self.params.get("take_profit", 0)
         self.trailing_stop_pct = self.params.get("trailing_stop", 0)
         
-        # Position sizing
+        # Position sizing. Runs from before sizing parameters carry risk_percentage alone.
         self.position_sizing = self.params.get("position_sizing", "fixed")
-        self.risk_percentage = self.params.get("risk_percentage", 2.0)
+        self.sizing_params = self.params.get("position_sizing_params") or {}
+        self.risk_percentage = self.sizing_params.get("risk_percent", self.params.get("risk_percentage", 2.0))
+        if self.position_sizing == "volatility_target":
+            self.atr = self.I(average_true_range, self.data.High, self.data.Low, self.data.Close,
+                              int(self.sizing_params.get("atr_period", 14)), name="ATR")
+
+        # Short positions are opened on sell signals and closed on buy signals
+        self.allow_short = bool(self.params.get("allow_short", False))
     
     def _process_rule_group(self, rule_group: Dict[str, Any]) -> None:
         """Process a rule group recursively to identify and calculate indicators."""
@@ -73,10 +85,47 @@ class DynamicStrategy(Strategy):
         
         if not indicator_name:
             return
-            
-        # Calculate indicator using our dynamic indicator system
-        # This adds the indicator values directly to the data
-        calculate_indicator(self.data.df, indicator_name, settings)
+        
+        # Indicators on an auxiliary timeframe are computed on its candles, then carried
+        # over to the backtest's candles
+        timeframe = indicator_config.get("timeframe")
+        df = self.auxiliary_data.get(timeframe) if timeframe else None
+        if df is None:
+            df = self.data.df
+        columns = set(df.columns)
+        
+        # User-defined indicators carry their formula in the strategy structure
+        formula = indicator_config.get("formula")
+        if formula:
+            params = {p.get("name"): settings.get(p.get("name"), p.get("default"))
+                      for p in indicator_config.get("parameters", [])}
+            calculate_custom_indicator(df, indicator_name, formula, params)
+        else:
+            # Calculate indicator using our dynamic indicator system
+            # This adds the indicator values directly to the data
+            calculate_indicator(df, indicator_name, settings)
+        
+        if df is not self.data.df:
+            self._align_auxiliary_columns(df, timeframe, [c for c in df.columns if c not in columns])
+    
+    def _align_auxiliary_columns(self, df: pd.DataFrame, timeframe: str, columns: List[str]) -> None:
+        """
+        Copy indicator columns of an auxiliary timeframe onto the backtest's candles as
+        "<column>@<timeframe>". Each backtest candle sees the values of the last auxiliary
+        candle closed by its own close, so no candle sees a higher timeframe candle still
+        forming.
+        """
+        if not columns:
+            return
+        
+        primary_index = pd.DatetimeIndex([to_utc_naive(t) for t in self.data.df.index])
+        primary_length = primary_index.to_series().diff().min() if len(primary_index) > 1 else pd.Timedelta(0)
+        aux_closes = pd.DatetimeIndex([to_utc_naive(t) for t in df.index]) + timeframe_to_timedelta(timeframe)
+        
+        for column in columns:
+            values = pd.Series(df[column].values, index=aux_closes)
+            aligned = values.reindex(primary_index + primary_length, method="ffill")
+            self.data.df[f"{column}@{timeframe}"] = aligned.values
     
     def _evaluate_rules(self, rules: Dict[str, Any], i: int) -> bool:
         """Evaluate rule structure against the current candle."""
@@ -164,9 +213,10 @@ class DynamicStrategy(Strategy):
             
             indicator_name = indicator.get("name")
             settings = indicator.get("indicatorSettings", {})
+            timeframe = indicator.get("timeframe")
             
             # Get the indicator value
-            indicator_value = self._get_indicator_value(indicator_name, settings, i)
+            indicator_value = self._get_indicator_value(indicator_name, settings, i, timeframe)
             
             # Evaluate the condition
             condition_value = float(condition.get("value", 0))
@@ -190,7 +240,13 @@ class DynamicStrategy(Strategy):
             logger.error(f"Error evaluating rule: {str(e)}")
             return False
     
-    def _get_indicator_value(self, indicator_name: str, settings: Dict[str, Any], i: int) -> float:
+    def _get_indicator_value(
+        self,
+        indicator_name: str,
+        settings: Dict[str, Any],
+        i: int,
+        timeframe: Optional[str] = None
+    ) -> float:
         """Get the indicator value for the current candle."""
         try:
             # For most indicators, we can simply look up the calculated value in the data
@@ -199,8 +255,13 @@ class DynamicStrategy(Strategy):
             # Build a pattern to search for in column names
             indicator_pattern = f"{indicator_name}"
             
-            # Find matching columns
-            matching_columns = [col for col in self.data.df.columns if indicator_pattern in col]
+            # Find matching columns; those of auxiliary timeframes end with "@<timeframe>"
+            if timeframe in self.auxiliary_data:
+                matching_columns = [col for col in self.data.df.columns
+                                    if indicator_pattern in col and col.endswith(f"@{timeframe}")]
+            else:
+                matching_columns = [col for col in self.data.df.columns
+                                    if indicator_pattern in col and "@" not in col]
             
             if matching_columns:
                 # Use the first matching column (in most cases there will be only one)
@@ -227,10 +288,47 @@ class DynamicStrategy(Strategy):
             if self.stop_loss_pct == 0:
                 return 0.1 * equity / price  # Fallback to 10% of equity
             return (equity * self.risk_percentage / 100) / (price * self.stop_loss_pct / 100)
+        elif self.position_sizing == "fixed_fractional":
+            return (equity * self.sizing_params.get("fraction_percent", 10) / 100) / price
+        elif self.position_sizing == "kelly":
+            return equity * self._kelly_fraction() / price
+        elif self.position_sizing == "volatility_target":
+            atr = self.atr[-1]
+            cap = equity * self.sizing_params.get("max_fraction_percent", 100) / 100 / price
+            if not atr > 0:
+                return cap
+            risk = equity * self.risk_percentage / 100
+            return min(cap, risk / (atr * self.sizing_params.get("atr_multiple", 2)))
         else:
             # Default to 10% of equity
             return 0.1 * equity / price
     
+    def _kelly_fraction(self) -> float:
+        """
+        Share of equity to take under Kelly sizing: the Kelly fraction W - (1 - W) / R of the
+        latest closed trades, where W is the win rate and R the average win over the average
+        loss, scaled by kelly_multiplier and capped. Until min_trades trades have closed it
+        is fraction_percent; without an edge it is 0 and no position is opened.
+        """
+        params = self.sizing_params
+        trades = self.closed_trades[-int(params.get("lookback_trades", 50)):]
+        if len(trades) < int(params.get("min_trades", 10)):
+            return params.get("fraction_percent", 10) / 100
+        
+        wins = [t.pl for t in trades if t.pl > 0]
+        losses = [-t.pl for t in trades if t.pl <= 0]
+        win_rate = len(wins) / len(trades)
+        if not losses:
+            kelly = 1.0
+        elif not wins:
+            kelly = 0.0
+        else:
+            payoff = (sum(wins) / len(wins)) / (sum(losses) / len(losses))
+            kelly = win_rate - (1 - win_rate) / payoff if payoff > 0 else 0.0
+        
+        fraction = max(0.0, kelly) * params.get("kelly_multiplier", 0.5)
+        return min(fraction, params.get("max_fraction_percent", 25) / 100)
+    
     def next(self):
         """
         This method is called for each candle in the data.
@@ -242,8 +340,10 @@ class DynamicStrategy(Strategy):
         # Check for buy signal if we're not in a position
         if not self.position:
             if self.buy_rules and self._evaluate_rules(self.buy_rules, i):
-                # Calculate position size
+                # Calculate position size; sizing without an edge opens nothing
                 size = self._calculate_position_size(self.data.Close[i])
+                if size <= 0:
+                    return
                 
                 # Place a buy order
                 self.buy(size=size)
@@ -254,7 +354,13 @@ class DynamicStrategy(Strategy):
                 
                 if self.take_profit_pct > 0:
                     self.take_profit = self.data.Close[i] * (1 + self.take_profit_pct / 100)
-        
+
+            # Open a short position on a sell signal when shorting is allowed
+            elif self.allow_short and self.sell_rules and self._evaluate_rules(self.sell_rules, i):
+                size = self._calculate_position_size(self.data.Close[i])
+                if size > 0:
+                    self.sell(size=size)
+
         # Check for sell signal if we're in a position
         elif self.position.is_long:
             # Update trailing stop if enabled
@@ -271,13 +377,35 @@ class DynamicStrategy(Strategy):
             if self.sell_rules and self._evaluate_rules(self.sell_rules, i):
                 self.position.close()
 
-def build_strategy(strategy_config: Dict[str, Any], params: Dict[str, Any]) -> Type[Strategy]:
-    """Build a dynamic strategy from JSON configuration."""
+        # Cover a short position on a buy signal
+        elif self.position.is_short:
+            if self.buy_rules and self._evaluate_rules(self.buy_rules, i):
+                self.position.close()
+
+def average_true_range(high, low, close, period: int):
+    """Average true range over period candles, for volatility-targeted position sizing."""
+    high, low, close = pd.Series(high), pd.Series(low), pd.Series(close)
+    previous_close = close.shift(1)
+    true_range = pd.concat([
+        high - low,
+        (high - previous_close).abs(),
+        (low - previous_close).abs()
+    ], axis=1).max(axis=1)
+    return true_range.rolling(period).mean().values
+
+def build_strategy(
+    strategy_config: Dict[str, Any],
+    params: Dict[str, Any],
+    auxiliary_data: Optional[Dict[str, pd.DataFrame]] = None
+) -> Type[Strategy]:
+    """Build a dynamic strategy from JSON configuration. auxiliary_data holds the candles
+    of the longer timeframes indicators are computed on, by timeframe."""
     # Create a customized strategy class
     class CustomStrategy(DynamicStrategy):
         # Store configuration as class variables
         strategy_config = strategy_config
         params = params
+        auxiliary_data = auxiliary_data or {}
     
     return CustomStrategy
 
@@ -8,7 +8,7 @@ import logging
 import json
 from typing import Dict, List, Any, Union, Optional
 import pandas as pd
-from datetime import datetime, timezone
+from datetime import datetime, timedelta, timezone
 
 logger = logging.getLogger(__name__)
 
@@ -48,6 +48,43 @@ def parse_iso_datetime(dt_str: str) -> Optional[datetime]:
     logger.warning(f"Failed to parse datetime string: {dt_str}")
     return None
 
+def to_utc_naive(value: Any) -> pd.Timestamp:
+    """
+    Convert a time to a naive UTC timestamp, so candle, trade and market data times
+    from different sources compare.
+    
+    Args:
+        value: datetime, timestamp or ISO string
+        
+    Returns:
+        Naive pandas Timestamp in UTC
+    """
+    ts = pd.Timestamp(value)
+    return ts.tz_convert('UTC').tz_localize(None) if ts.tzinfo is not None else ts
+
+# Length of each timeframe unit
+TIMEFRAME_UNITS = {
+    'm': timedelta(minutes=1),
+    'h': timedelta(hours=1),
+    'd': timedelta(days=1),
+    'w': timedelta(weeks=1),
+}
+
+def timeframe_to_timedelta(timeframe: str) -> timedelta:
+    """
+    Length of the candles of a timeframe written as a count and a unit, e.g. 15m or 4h.
+    
+    Args:
+        timeframe: Timeframe name
+        
+    Returns:
+        Candle length
+    """
+    unit = TIMEFRAME_UNITS.get(timeframe[-1:]) if timeframe else None
+    if unit is None or not timeframe[:-1].isdigit():
+        raise ValueError(f"Invalid timeframe {timeframe!r}")
+    return int(timeframe[:-1]) * unit
+
 def safe_json_serialize(obj: Any) -> Any:
     """
     Safely serialize an object to JSON, handling non-serializable types.
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,20 +11,49 @@ import (
 	"syscall"
 	"time"
 
+	"services/historical-data-service/docs"
 	"services/historical-data-service/internal/client"
 	"services/historical-data-service/internal/config"
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/handler"
 	"services/historical-data-service/internal/middleware"
+	"services/historical-data-service/internal/migrate"
+	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
 	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/validation"
+	"services/shared/apierror"
+	"services/shared/auth"
+	"services/shared/database"
+	"services/shared/idempotency"
+	"services/shared/savedview"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// @title Historical Data Service API
+// @version 1.0
+// @description Market data, data downloads and backtests.
+// @BasePath /
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>"
+//
+// @securityDefinitions.apikey ServiceKey
+// @in header
+// @name X-Service-Key
+// @description Key of a calling internal service
 func main() {
+	applyMigrations := flag.Bool("migrate", false, "Apply pending database migrations before starting")
+	baselineVersion := flag.Int64("migrate-baseline", 0, "Mark migrations up to this version as applied without running them (for databases created by the old init scripts)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadConfig("config/config.yaml")
 	if err != nil {
@@ -37,6 +67,9 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Map the untyped errors of the services to the codes of this service
+	apierror.SetMessageRules(errcode.MessageRules)
+
 	// Connect to database
 	db, err := connectToDB(cfg.Database)
 	if err != nil {
@@ -44,24 +77,122 @@ func main() {
 	}
 	defer db.Close()
 
+	// Bring the schema up to date if requested
+	migrationRunner := migrate.NewRunner(db.DB, migrate.TimescaleDB{
+		Enabled:       cfg.Database.TimescaleDB.Enabled,
+		ChunkInterval: cfg.Database.TimescaleDB.ChunkInterval,
+		CompressAfter: cfg.Database.TimescaleDB.CompressAfter,
+	}, logger)
+	if *baselineVersion > 0 {
+		if err := migrationRunner.Baseline(context.Background(), *baselineVersion); err != nil {
+			logger.Fatal("Failed to baseline database migrations", zap.Error(err))
+		}
+	}
+	if *applyMigrations {
+		if err := migrationRunner.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply database migrations", zap.Error(err))
+		}
+	}
+
+	// Heavy reads go to the read replica, if one is configured, while it's healthy
+	replicaDB, err := connectToReplica(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to configure read replica", zap.Error(err))
+	}
+
+	// Every repository query runs with the deadline of its class; slow ones are logged
+	queryOptions := database.QueryOptions{
+		ReadTimeout:   cfg.Database.Queries.ReadTimeout,
+		WriteTimeout:  cfg.Database.Queries.WriteTimeout,
+		BulkTimeout:   cfg.Database.Queries.BulkTimeout,
+		SlowThreshold: cfg.Database.Queries.SlowThreshold,
+	}
+	primaryDB := database.NewDB(db, queryOptions, logger)
+	dbRouter := database.NewRouter(primaryDB, database.NewDB(replicaDB, queryOptions, logger), database.ReplicaOptions{
+		HealthCheckInterval: cfg.Database.Replica.HealthCheckInterval,
+		MaxLag:              cfg.Database.Replica.MaxLag,
+		RecoverAfter:        cfg.Database.Replica.RecoverAfter,
+	}, logger)
+	defer dbRouter.Close()
+
+	// Sample the connection pools for /metrics and, if enabled, size them to their waits
+	poolMonitor := database.NewPoolMonitor(database.PoolOptions{
+		SampleInterval:    cfg.Database.Pool.SampleInterval,
+		Adaptive:          cfg.Database.Pool.Adaptive,
+		MaxOpenConnsLimit: cfg.Database.Pool.MaxOpenConnsLimit,
+		TargetWait:        cfg.Database.Pool.TargetWait,
+	}, logger)
+	poolMonitor.Add("primary", db)
+	poolMonitor.Add("replica", replicaDB)
+
 	// Initialize repositories
-	marketDataRepo := repository.NewMarketDataRepository(db, logger)
-	backtestRepo := repository.NewBacktestRepository(db, logger)
-	symbolRepo := repository.NewSymbolRepository(db, logger)
-	timeframeRepo := repository.NewTimeframeRepository(db, logger)
-	downloadJobRepo := repository.NewDownloadJobRepository(db, logger)
-	inventoryRepo := repository.NewInventoryRepository(db, logger) // New repository
+	marketDataRepo := repository.NewMarketDataRepository(dbRouter, logger)
+	backtestRepo := repository.NewBacktestRepository(primaryDB, logger)
+	symbolRepo := repository.NewSymbolRepository(primaryDB, logger)
+	timeframeRepo := repository.NewTimeframeRepository(primaryDB, logger)
+	downloadJobRepo := repository.NewDownloadJobRepository(primaryDB, logger)
+	inventoryRepo := repository.NewInventoryRepository(primaryDB, logger) // New repository
+	metricsRepo := repository.NewMetricsRepository(primaryDB, logger)
+	quotaRepo := repository.NewQuotaRepository(primaryDB, logger)
+	statsRepo := repository.NewStatsRepository(primaryDB, logger)
+	regimeRepo := repository.NewRegimeRepository(primaryDB, logger)
+	calendarRepo := repository.NewCalendarRepository(primaryDB, logger)
+	watchlistRepo := repository.NewWatchlistRepository(primaryDB, logger)
+	idempotencyRepo := idempotency.NewRepository(primaryDB, logger)
+	derivativesRepo := repository.NewDerivativesRepository(primaryDB, logger)
+	orderBookRepo := repository.NewOrderBookRepository(primaryDB, logger)
+	jobRepo := repository.NewJobRepository(primaryDB, logger)
 
 	// Initialize clients
-	userClient := client.NewUserClient(cfg.UserService.URL, logger)
-	strategyClient := client.NewStrategyClient(cfg.StrategyService.URL, logger)
+	userClient := client.NewUserClient(cfg.UserService, logger)
+	// Keys issued to other services by the user service are accepted next to cfg.ServiceKey
+	var serviceKeyVerifier middleware.ServiceKeyVerifier
+	if cfg.ServiceAuth.VerifyIssuedKeys {
+		serviceKeyVerifier = client.NewServiceKeyVerifier(userClient, cfg.ServiceAuth.CacheTTL, logger)
+	}
+	strategyClient := client.NewStrategyClient(cfg.StrategyService, logger)
+	// Viper lowercases the topic keys
+	backtestEvents := client.NewEventClient(cfg.Kafka.Brokers, cfg.Kafka.Topics["backtestevents"], logger)
+	// Each provider's rate-limit budget is shared by all of its downloads
+	rateLimits := make(map[string]client.RateBudgetOptions, len(cfg.Downloads.RateLimits))
+	for source, limit := range cfg.Downloads.RateLimits {
+		rateLimits[source] = client.RateBudgetOptions{
+			Weight:    limit.Weight,
+			Window:    limit.Window,
+			MinWeight: limit.MinWeight,
+		}
+	}
+	rateBudgets := client.NewRateBudgets(rateLimits)
+	binanceClient := client.NewBinanceClient(rateBudgets.For(string(model.SourceBinance)), logger)
+	futuresClient := client.NewBinanceFuturesClient(rateBudgets.For(client.BinanceFuturesBudget), logger)
+
+	// Backtests and downloads run in the background; shutdown drains them
+	jobs := service.NewJobRegistry(logger)
 
 	// Initialize services
-	marketDataService := service.NewMarketDataService(marketDataRepo, symbolRepo, logger)
+	quotaService := service.NewQuotaService(quotaRepo, cfg.Quotas, logger)
+	candleCache := service.NewCandleCache(cfg.CandleCache.MaxCandles, cfg.CandleCache.TTL)
+	marketDataService := service.NewMarketDataService(marketDataRepo, symbolRepo, candleCache, logger)
+	tickerService := service.NewTickerService(binanceClient, cfg.Ticker.CacheTTL, cfg.Ticker.MaxSymbols, logger)
+	calendarService := service.NewCalendarService(calendarRepo, symbolRepo, logger)
+	watchlistService := service.NewWatchlistService(watchlistRepo, logger)
 	backtestService := service.NewBacktestService(
 		backtestRepo,
 		marketDataRepo,
+		derivativesRepo,
+		orderBookRepo,
 		strategyClient,
+		quotaService,
+		calendarService,
+		watchlistService,
+		backtestEvents,
+		jobs,
+		cfg.Backtests.SymbolWorkers,
+		service.BacktestEngineOptions{
+			Protocol:    cfg.Backtests.EngineProtocol,
+			CallbackURL: cfg.Backtests.CallbackURL,
+			Lease:       cfg.Backtests.EngineLease,
+		},
 		logger,
 	)
 	symbolService := service.NewSymbolService(symbolRepo, logger)
@@ -71,24 +202,125 @@ func main() {
 		inventoryRepo, // Added inventory repository
 		symbolRepo,
 		marketDataRepo,
+		timeframeRepo,
+		derivativesRepo,
+		quotaService,
+		candleCache,
+		binanceClient,
+		futuresClient,
+		rateBudgets,
 		logger,
 	)
+	metricsService := service.NewMetricsService(metricsRepo, logger)
+	statsService := service.NewStatsService(statsRepo, cfg.Stats.CacheTTL, logger)
+	derivativesService := service.NewDerivativesService(derivativesRepo, symbolRepo, logger)
+	orderBookService := service.NewOrderBookService(orderBookRepo, symbolRepo, client.NewBinanceDepthStream(logger), logger)
+	jobService := service.NewJobService(jobRepo, dataDownloadService, jobs, logger)
+	regimeService := service.NewRegimeService(regimeRepo, backtestRepo, marketDataRepo, symbolRepo, timeframeRepo, logger)
 
 	// Initialize handlers
 	marketDataHandler := handler.NewMarketDataHandler(marketDataService, logger)
-	backtestHandler := handler.NewBacktestHandler(backtestService, logger)
+	tickerHandler := handler.NewTickerHandler(tickerService, logger)
+	backtestHandler := handler.NewBacktestHandler(backtestService, quotaService, logger)
 	symbolHandler := handler.NewSymbolHandler(symbolService, logger)
 	timeframeHandler := handler.NewTimeframeHandler(timeframeService, logger)
-	dataDownloadHandler := handler.NewDataDownloadHandler(dataDownloadService, logger)
+	dataDownloadHandler := handler.NewDataDownloadHandler(dataDownloadService, quotaService, logger)
+	metricsHandler := handler.NewMetricsHandler(metricsService, logger)
+	migrationHandler := handler.NewMigrationHandler(migrationRunner, logger)
+	quotaHandler := handler.NewQuotaHandler(quotaService, logger)
+	statsHandler := handler.NewStatsHandler(statsService, logger)
+	regimeHandler := handler.NewRegimeHandler(regimeService, logger)
+	derivativesHandler := handler.NewDerivativesHandler(derivativesService, logger)
+	orderBookHandler := handler.NewOrderBookHandler(orderBookService, logger)
+	calendarHandler := handler.NewCalendarHandler(calendarService, logger)
+	watchlistHandler := handler.NewWatchlistHandler(watchlistService, logger)
+	jobHandler := handler.NewJobHandler(jobService, logger)
+	debugHandler := handler.NewDebugHandler(poolMonitor, logger)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Check the read replica's health so reads fail over to the primary and back
+	go dbRouter.Run(jobsCtx)
+
+	// Sample and tune the database connection pools
+	go poolMonitor.Run(jobsCtx)
+
+	// Start nightly metrics aggregation
+	if cfg.Metrics.AggregationEnabled {
+		go metricsService.RunNightly(jobsCtx, cfg.Metrics.AggregationHour, cfg.Metrics.MaxCatchUpDays)
+	}
+
+	// Capture order book snapshots of the configured symbols from the provider's depth stream
+	if cfg.OrderBook.Enabled {
+		go orderBookService.RunCapture(jobsCtx, service.OrderBookCaptureOptions{
+			Symbols:       cfg.OrderBook.Symbols,
+			Depth:         cfg.OrderBook.Depth,
+			Interval:      cfg.OrderBook.Interval,
+			Retention:     cfg.OrderBook.Retention,
+			PruneInterval: cfg.OrderBook.PruneInterval,
+		})
+	}
+
+	// Start the download worker pool; queued jobs survive restarts and resume from their checkpoint
+	downloadPool := service.NewDownloadWorkerPool(downloadJobRepo, dataDownloadService, jobs, service.DownloadPoolOptions{
+		Workers:                       cfg.Downloads.Workers,
+		MaxConcurrentPerSource:        cfg.Downloads.MaxConcurrentPerSource,
+		DefaultMaxConcurrentPerSource: cfg.Downloads.DefaultMaxConcurrentPerSource,
+		PollInterval:                  cfg.Downloads.PollInterval,
+		MaxAttempts:                   cfg.Downloads.MaxAttempts,
+		RetryBackoff:                  cfg.Downloads.RetryBackoff,
+		StaleAfter:                    cfg.Downloads.StaleAfter,
+	}, logger)
+	go downloadPool.Run(jobsCtx)
+
+	// Resume backtests queued by instances that shut down while running them
+	go backtestService.ResumeQueuedBacktests(jobsCtx, cfg.Backtests.ResumeInterval)
+	go backtestService.ExpireEngineLeases(jobsCtx, cfg.Backtests.LeaseCheckInterval)
+
+	// Idempotency-Key handling for endpoints that start work
+	idempotent := idempotency.Middleware(idempotencyRepo, cfg.Idempotency.LockTimeout, cfg.Idempotency.TTL, logger)
+
+	// Tokens of users who logged out everywhere or were deactivated are rejected once
+	// the user service broadcasts their revocation
+	var revocations *auth.RevocationList
+	if cfg.Redis.Enabled {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.URL,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer redisClient.Close()
+		revocations = auth.NewRevocationList(redisClient, logger)
+		go revocations.Run(jobsCtx)
+	}
+
+	// Access tokens are verified locally against the user service's public keys
+	tokenVerifier := auth.NewTokenVerifier(cfg.Auth.JWKSURL, cfg.Auth.JWTSecret, cfg.Auth.KeysMaxAge, revocations, logger)
 
 	// Set up HTTP server with Gin
 	router := setupRouter(
 		marketDataHandler,
+		tickerHandler,
 		backtestHandler,
 		symbolHandler,
 		timeframeHandler,
 		dataDownloadHandler,
+		metricsHandler,
+		migrationHandler,
+		quotaHandler,
+		statsHandler,
+		regimeHandler,
+		derivativesHandler,
+		orderBookHandler,
+		calendarHandler,
+		watchlistHandler,
+		jobHandler,
+		debugHandler,
 		userClient,
+		tokenVerifier,
+		idempotent,
+		serviceKeyVerifier,
 		logger,
 		cfg,
 	)
@@ -116,6 +348,18 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Stop background jobs; running downloads and backtests are returned to the queue
+	// and resume from their checkpoint on the next instance
+	stopJobs()
+	if !jobs.Drain(cfg.Server.DrainTimeout) {
+		logger.Warn("Timed out draining background jobs")
+	}
+
+	// Flush pending backtest events
+	if err := backtestEvents.Close(); err != nil {
+		logger.Error("Failed to close backtest event client", zap.Error(err))
+	}
+
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -179,14 +423,48 @@ func connectToDB(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// connectToReplica opens the read replica's connection pool, if a replica is configured.
+// The connection isn't verified here; the router's health checks decide when it's used.
+func connectToReplica(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
+	if dbConfig.Replica.DSN == "" {
+		return nil, nil
+	}
+
+	db, err := sqlx.Open("pgx", dbConfig.Replica.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+
+	return db, nil
+}
+
 // setupRouter function remains the same
 func setupRouter(
 	marketDataHandler *handler.MarketDataHandler,
+	tickerHandler *handler.TickerHandler,
 	backtestHandler *handler.BacktestHandler,
 	symbolHandler *handler.SymbolHandler,
 	timeframeHandler *handler.TimeframeHandler,
 	dataDownloadHandler *handler.DataDownloadHandler,
+	metricsHandler *handler.MetricsHandler,
+	migrationHandler *handler.MigrationHandler,
+	quotaHandler *handler.QuotaHandler,
+	statsHandler *handler.StatsHandler,
+	regimeHandler *handler.RegimeHandler,
+	derivativesHandler *handler.DerivativesHandler,
+	orderBookHandler *handler.OrderBookHandler,
+	calendarHandler *handler.CalendarHandler,
+	watchlistHandler *handler.WatchlistHandler,
+	jobHandler *handler.JobHandler,
+	debugHandler *handler.DebugHandler,
 	userClient *client.UserClient,
+	tokenVerifier *auth.TokenVerifier,
+	idempotency gin.HandlerFunc,
+	serviceKeyVerifier middleware.ServiceKeyVerifier,
 	logger *zap.Logger,
 	cfg *config.Config,
 ) *gin.Engine {
@@ -194,13 +472,23 @@ func setupRouter(
 
 	// Use middlewares
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
+	router.Use(validation.Locale())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// Metrics in the Prometheus text format, for scrapers on the internal network
+	router.GET("/metrics", debugHandler.GetMetrics)
+
+	// OpenAPI spec, also merged into the gateway's /api/docs
+	router.GET("/swagger.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", docs.SwaggerJSON)
+	})
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
@@ -216,18 +504,19 @@ func setupRouter(
 
 			// Protected download routes - requires authentication
 			downloadsAuth := downloads.Group("")
-			downloadsAuth.Use(middleware.AuthMiddleware(userClient, logger))
+			downloadsAuth.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
 			// Routes that require basic user role
-			downloadsAuth.POST("", dataDownloadHandler.InitiateDataDownload)
+			downloadsAuth.POST("", idempotency, dataDownloadHandler.InitiateDataDownload)
 			downloadsAuth.GET("/:id/status", dataDownloadHandler.GetDownloadStatus)
 			downloadsAuth.GET("/active", dataDownloadHandler.GetActiveDownloads)
 			downloadsAuth.DELETE("/:id", dataDownloadHandler.CancelDownload)
 
 			// Admin-only routes
 			downloadsAdmin := downloadsAuth.Group("")
-			downloadsAdmin.Use(middleware.RequireRole(userClient, "admin"))
+			downloadsAdmin.Use(middleware.RequirePermission("downloads:manage"))
 			downloadsAdmin.GET("/summary", dataDownloadHandler.GetJobsSummary)
+			downloadsAdmin.GET("/rate-limits", dataDownloadHandler.GetRateLimits)
 		}
 
 		// Symbol routes
@@ -237,11 +526,11 @@ func setupRouter(
 
 			// Protected symbols management - requires authentication
 			symbolsAuth := symbols.Group("")
-			symbolsAuth.Use(middleware.AuthMiddleware(userClient, logger))
+			symbolsAuth.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
 			// Admin-only symbol management routes
 			symbolsAdmin := symbolsAuth.Group("")
-			symbolsAdmin.Use(middleware.RequireRole(userClient, "admin"))
+			symbolsAdmin.Use(middleware.RequirePermission("symbols:write"))
 			symbolsAdmin.POST("", symbolHandler.CreateSymbol)
 			symbolsAdmin.PUT("/:id", symbolHandler.UpdateSymbol)
 			symbolsAdmin.DELETE("/:id", symbolHandler.DeleteSymbol)
@@ -252,6 +541,28 @@ func setupRouter(
 		{
 			timeframes.GET("", timeframeHandler.GetAllTimeframes)
 			timeframes.GET("/validate/:timeframe", timeframeHandler.ValidateTimeframe)
+
+			// Admin-only timeframe catalog management
+			timeframesAdmin := timeframes.Group("")
+			timeframesAdmin.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			timeframesAdmin.Use(middleware.RequirePermission("timeframes:write"))
+			timeframesAdmin.POST("", timeframeHandler.CreateTimeframe)
+			timeframesAdmin.PUT("/:timeframe", timeframeHandler.UpdateTimeframe)
+			timeframesAdmin.DELETE("/:timeframe", timeframeHandler.DeleteTimeframe)
+		}
+
+		// Exchange trading calendar routes
+		calendars := v1.Group("/calendars")
+		{
+			calendars.GET("", calendarHandler.GetCalendars)
+			calendars.GET("/:exchange", calendarHandler.GetCalendar)
+			calendars.GET("/:exchange/sessions", calendarHandler.GetSessions)
+
+			// Admin-only holiday management
+			calendarsAdmin := calendars.Group("")
+			calendarsAdmin.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			calendarsAdmin.Use(middleware.RequirePermission("symbols:write"))
+			calendarsAdmin.POST("/:exchange/holidays", calendarHandler.AddHoliday)
 		}
 
 		// Market data routes
@@ -259,47 +570,121 @@ func setupRouter(
 		{
 			// Protected market data routes - requires authentication
 			authenticatedMarketData := marketData.Group("")
-			authenticatedMarketData.Use(middleware.AuthMiddleware(userClient, logger))
+			authenticatedMarketData.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
 			authenticatedMarketData.GET("/candles", marketDataHandler.GetCandles)
 			authenticatedMarketData.GET("/asset-types", marketDataHandler.GetAssetTypes)
 			authenticatedMarketData.GET("/exchanges", marketDataHandler.GetExchanges)
+			authenticatedMarketData.GET("/preview", marketDataHandler.PreviewData)
+			authenticatedMarketData.GET("/ticker", tickerHandler.GetTickers)
+			authenticatedMarketData.GET("/:symbol/regimes", regimeHandler.GetRegimes)
+			authenticatedMarketData.GET("/:symbol/funding-rates", derivativesHandler.GetFundingRates)
+			authenticatedMarketData.GET("/:symbol/open-interest", derivativesHandler.GetOpenInterest)
+			authenticatedMarketData.GET("/:symbol/order-book", orderBookHandler.GetSnapshots)
 
 			// Admin-only routes for importing data
 			marketDataAdmin := authenticatedMarketData.Group("")
-			marketDataAdmin.Use(middleware.RequireRole(userClient, "admin"))
+			marketDataAdmin.Use(middleware.RequirePermission("market-data:import"))
 			marketDataAdmin.POST("/candles/batch", marketDataHandler.BatchImportCandles)
+			marketDataAdmin.DELETE("/candles", middleware.RequireRole(userClient, "admin"), marketDataHandler.DeleteCandles)
+			marketDataAdmin.GET("/cache/stats", marketDataHandler.GetCandleCacheStats)
 		}
 
 		// Backtest routes
 		backtests := v1.Group("/backtests")
 		{
-			backtests.Use(middleware.AuthMiddleware(userClient, logger))
+			backtests.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
-			backtests.GET("", backtestHandler.ListBacktests)
-			backtests.POST("", backtestHandler.CreateBacktest)
+			backtests.GET("", savedview.Middleware(userClient, model.ViewListBacktests, logger), backtestHandler.ListBacktests)
+			backtests.POST("", idempotency, backtestHandler.CreateBacktest)
 			backtests.GET("/:id", backtestHandler.GetBacktest)
+			backtests.GET("/:id/data-manifest", backtestHandler.GetBacktestDataManifest)
 			backtests.DELETE("/:id", backtestHandler.DeleteBacktest)
+			backtests.POST("/:id/retry-failed", idempotency, backtestHandler.RetryFailedRuns)
+		}
+
+		// Watchlists of the authenticated user
+		watchlists := v1.Group("/watchlists")
+		{
+			watchlists.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+
+			watchlists.GET("", watchlistHandler.GetWatchlists)
+			watchlists.POST("", watchlistHandler.CreateWatchlist)
+			watchlists.GET("/:id", watchlistHandler.GetWatchlist)
+			watchlists.PUT("/:id", watchlistHandler.UpdateWatchlist)
+			watchlists.DELETE("/:id", watchlistHandler.DeleteWatchlist)
+			watchlists.GET("/:id/quotes", watchlistHandler.GetWatchlistQuotes)
 		}
 
 		// Backtest run management
 		backtestRuns := v1.Group("/backtest-runs")
 		{
-			backtestRuns.Use(middleware.AuthMiddleware(userClient, logger))
+			backtestRuns.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
 			backtestRuns.PUT("/:id/status", backtestHandler.UpdateBacktestRunStatus)
 			backtestRuns.POST("/:id/results", backtestHandler.SaveBacktestResults)
 			backtestRuns.POST("/:id/trades", backtestHandler.AddBacktestTrade)
+			backtestRuns.POST("/:id/trades/batch", backtestHandler.AddBacktestTrades)
 			backtestRuns.GET("/:id/trades", backtestHandler.GetBacktestTrades)
+			backtestRuns.GET("/:id/trades/export", backtestHandler.ExportBacktestTrades)
+			backtestRuns.POST("/:id/trades/:tradeId/annotations", backtestHandler.AnnotateBacktestTrade)
+			backtestRuns.GET("/:id/regime-breakdown", regimeHandler.GetBacktestRunBreakdown)
+			backtestRuns.GET("/:id/chart.png", backtestHandler.GetBacktestRunChartPNG)
+			backtestRuns.GET("/:id/chart.svg", backtestHandler.GetBacktestRunChartSVG)
+		}
+
+		// Quotas of the authenticated user
+		users := v1.Group("/users")
+		{
+			users.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+
+			users.GET("/me/quotas", quotaHandler.GetMyQuotas)
+		}
+
+		// Daily metrics rollups
+		metrics := v1.Group("/metrics")
+		{
+			metrics.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			metrics.Use(middleware.RequirePermission("metrics:read"))
+
+			metrics.GET("/daily/users/:id", metricsHandler.GetUserDailyMetrics)
+			metrics.GET("/daily/strategies/:id", metricsHandler.GetStrategyDailyMetrics)
+			metrics.GET("/daily/symbols/:id", metricsHandler.GetSymbolDailyMetrics)
+			metrics.POST("/daily/refresh", middleware.RequireRole(userClient, "admin"), metricsHandler.RefreshDailyMetrics)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin")
+		{
+			admin.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			admin.Use(middleware.RequireRole(userClient, "admin"))
+
+			admin.GET("/migrations", migrationHandler.GetStatus)
+			admin.GET("/stats/data", statsHandler.GetDataStats)
+
+			// Background jobs: downloads and backtests
+			admin.GET("/jobs", jobHandler.ListJobs)
+			admin.GET("/jobs/summary", jobHandler.GetJobSummary)
+			admin.GET("/jobs/:kind/:id", jobHandler.GetJob)
+			admin.POST("/jobs/:kind/:id/cancel", jobHandler.CancelJob)
+			admin.POST("/jobs/:kind/:id/requeue", jobHandler.RequeueJob)
+
+			// Database connection pools
+			admin.GET("/debug/db", debugHandler.GetDBPools)
 		}
 
 		// Service-to-service routes (requires service key)
 		service := v1.Group("/service")
-		service.Use(middleware.ServiceAuthMiddleware(cfg.ServiceKey, logger))
+		service.Use(middleware.ServiceAuthMiddleware(cfg.ServiceKey, serviceKeyVerifier, logger))
 		{
 			// Internal routes for other services
 			service.POST("/market-data/batch", marketDataHandler.BatchImportMarketData)
 			service.POST("/backtests/notify", backtestHandler.NotifyBacktestComplete)
+			service.POST("/backtests/engine-callback", backtestHandler.EngineCallback)
+			service.GET("/backtests/:id", backtestHandler.GetServiceBacktest)
+			service.GET("/strategy-backtests", backtestHandler.GetServiceStrategyBacktests)
+			service.POST("/signals/preview", backtestHandler.PreviewSignals)
+			service.GET("/debug/db", debugHandler.GetDBPools)
 		}
 	}
 	return router
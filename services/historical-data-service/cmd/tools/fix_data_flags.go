@@ -9,6 +9,7 @@ import (
 
 	"services/historical-data-service/internal/config"
 	"services/historical-data-service/internal/repository"
+	"services/shared/database"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -36,9 +37,17 @@ func main() {
 	}
 	defer db.Close()
 
+	// Queries run with the deadlines of their class, as in the service
+	queryDB := database.NewDB(db, database.QueryOptions{
+		ReadTimeout:   cfg.Database.Queries.ReadTimeout,
+		WriteTimeout:  cfg.Database.Queries.WriteTimeout,
+		BulkTimeout:   cfg.Database.Queries.BulkTimeout,
+		SlowThreshold: cfg.Database.Queries.SlowThreshold,
+	}, logger)
+
 	// Initialize repositories
-	symbolRepo := repository.NewSymbolRepository(db, logger)
-	downloadJobRepo := repository.NewDownloadJobRepository(db, logger)
+	symbolRepo := repository.NewSymbolRepository(queryDB, logger)
+	downloadJobRepo := repository.NewDownloadJobRepository(queryDB, logger)
 
 	// Run the fix
 	err = fixDataAvailabilityFlags(symbolRepo, downloadJobRepo, logger)
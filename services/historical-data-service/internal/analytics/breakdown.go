@@ -0,0 +1,147 @@
+package analytics
+
+import (
+	"sort"
+
+	"services/historical-data-service/internal/model"
+)
+
+// BreakdownByRegime splits the closed trades of a backtest run by the regime segment they were
+// entered in and measures each segment, each regime (trend and volatility) across its segments,
+// and the trades that fall outside every segment. Trades must be in the order they were closed
+// and segments in ascending time order.
+func BreakdownByRegime(
+	trades []model.BacktestTrade,
+	segments []model.MarketRegimeSegment,
+	initialCapital float64,
+) ([]model.RegimeSegmentPerformance, []model.RegimeTypePerformance, model.RegimePerformance) {
+	segmentTrackers := make([]*performanceTracker, len(segments))
+	for i := range segments {
+		segmentTrackers[i] = newPerformanceTracker(initialCapital)
+	}
+
+	regimeTrackers := make(map[regimeLabel]*performanceTracker)
+	regimeSegments := make(map[regimeLabel]int)
+	for _, segment := range segments {
+		label := regimeLabel{trend: segment.Trend, volatility: segment.Volatility}
+		if _, ok := regimeTrackers[label]; !ok {
+			regimeTrackers[label] = newPerformanceTracker(initialCapital)
+		}
+		regimeSegments[label]++
+	}
+
+	unclassified := newPerformanceTracker(initialCapital)
+
+	for _, trade := range trades {
+		if trade.ProfitLoss == nil {
+			continue
+		}
+
+		i := findSegment(segments, trade)
+		if i < 0 {
+			unclassified.add(*trade.ProfitLoss)
+			continue
+		}
+
+		segmentTrackers[i].add(*trade.ProfitLoss)
+		regimeTrackers[regimeLabel{trend: segments[i].Trend, volatility: segments[i].Volatility}].add(*trade.ProfitLoss)
+	}
+
+	segmentPerformance := make([]model.RegimeSegmentPerformance, len(segments))
+	for i, segment := range segments {
+		segmentPerformance[i] = model.RegimeSegmentPerformance{
+			Segment:     segment,
+			Performance: segmentTrackers[i].result(),
+		}
+	}
+
+	regimePerformance := make([]model.RegimeTypePerformance, 0, len(regimeTrackers))
+	for label, tracker := range regimeTrackers {
+		regimePerformance = append(regimePerformance, model.RegimeTypePerformance{
+			Trend:       label.trend,
+			Volatility:  label.volatility,
+			Segments:    regimeSegments[label],
+			Performance: tracker.result(),
+		})
+	}
+	sort.Slice(regimePerformance, func(i, j int) bool {
+		if regimePerformance[i].Trend != regimePerformance[j].Trend {
+			return regimePerformance[i].Trend < regimePerformance[j].Trend
+		}
+		return regimePerformance[i].Volatility < regimePerformance[j].Volatility
+	})
+
+	return segmentPerformance, regimePerformance, unclassified.result()
+}
+
+// findSegment returns the index of the segment a trade was entered in, or -1. A segment runs
+// until the next one starts, so entries inside its last candle still belong to it.
+func findSegment(segments []model.MarketRegimeSegment, trade model.BacktestTrade) int {
+	// Last segment that starts at or before the entry
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].StartTime.After(trade.EntryTime)
+	}) - 1
+	if i < 0 {
+		return -1
+	}
+	if i == len(segments)-1 && trade.EntryTime.After(segments[i].EndTime) {
+		return -1
+	}
+	return i
+}
+
+// performanceTracker accumulates the performance of a series of trades on its own equity curve
+type performanceTracker struct {
+	capital     float64
+	equity      float64
+	peak        float64
+	performance model.RegimePerformance
+}
+
+// newPerformanceTracker creates a tracker whose equity curve starts at capital
+func newPerformanceTracker(capital float64) *performanceTracker {
+	return &performanceTracker{
+		capital: capital,
+		equity:  capital,
+		peak:    capital,
+	}
+}
+
+// add records the profit or loss of a trade
+func (t *performanceTracker) add(profitLoss float64) {
+	t.performance.TotalTrades++
+	switch {
+	case profitLoss > 0:
+		t.performance.WinningTrades++
+	case profitLoss < 0:
+		t.performance.LosingTrades++
+	}
+	t.performance.ProfitLoss += profitLoss
+
+	t.equity += profitLoss
+	if t.equity > t.peak {
+		t.peak = t.equity
+	}
+	if t.peak > 0 {
+		if drawdown := (t.peak - t.equity) / t.peak * 100; drawdown > t.performance.MaxDrawdown {
+			t.performance.MaxDrawdown = drawdown
+		}
+	}
+}
+
+// result returns the accumulated performance with percentages filled in
+func (t *performanceTracker) result() model.RegimePerformance {
+	performance := t.performance
+	if performance.TotalTrades > 0 {
+		performance.WinRate = float64(performance.WinningTrades) / float64(performance.TotalTrades) * 100
+	}
+	if t.capital > 0 {
+		performance.ReturnPercent = performance.ProfitLoss / t.capital * 100
+	}
+
+	performance.WinRate = round(performance.WinRate, 4)
+	performance.ProfitLoss = round(performance.ProfitLoss, 8)
+	performance.ReturnPercent = round(performance.ReturnPercent, 4)
+	performance.MaxDrawdown = round(performance.MaxDrawdown, 4)
+	return performance
+}
@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+
+	"services/historical-data-service/internal/model"
+)
+
+// RegimeParams tunes the market regime classifier
+type RegimeParams struct {
+	// Window is the number of candles the trend and volatility of each candle are measured over
+	Window int
+	// TrendThreshold is the efficiency ratio (net move divided by the total distance travelled)
+	// above which the market counts as trending
+	TrendThreshold float64
+	// MinSegmentCandles is the shortest segment kept; shorter ones are merged into a neighbour
+	MinSegmentCandles int
+}
+
+// DefaultRegimeParams returns the classifier settings used by the API
+func DefaultRegimeParams() RegimeParams {
+	return RegimeParams{
+		Window:            20,
+		TrendThreshold:    0.3,
+		MinSegmentCandles: 5,
+	}
+}
+
+// regimeLabel is the trend and volatility of a single candle
+type regimeLabel struct {
+	trend      string
+	volatility string
+}
+
+// ClassifyRegimes splits candles, in ascending time order, into regime segments.
+//
+// Each candle is labelled from the Window candles leading up to it: the trend from Kaufman's
+// efficiency ratio and the direction of the net move, and the volatility from the standard
+// deviation of log returns compared with its median over the whole history. Runs of equal
+// labels form segments, and segments shorter than MinSegmentCandles are absorbed by the
+// segment before them (or after, for the first one). The first Window candles have no label.
+func ClassifyRegimes(candles []model.Candle, params RegimeParams) []model.MarketRegimeSegment {
+	window := params.Window
+	if window < 2 || len(candles) <= window {
+		return []model.MarketRegimeSegment{}
+	}
+
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	// Per-candle trend and volatility, starting at the first candle with a full window
+	count := len(candles) - window
+	trends := make([]string, count)
+	volatilities := make([]float64, count)
+	for k := 0; k < count; k++ {
+		i := k + window
+		trends[k] = classifyTrend(closes[i-window:i+1], params.TrendThreshold)
+		volatilities[k] = returnVolatility(closes[i-window : i+1])
+	}
+
+	medianVolatility := median(volatilities)
+	labels := make([]regimeLabel, count)
+	for k := range labels {
+		labels[k] = regimeLabel{trend: trends[k], volatility: model.RegimeVolatilityLow}
+		if volatilities[k] > medianVolatility {
+			labels[k].volatility = model.RegimeVolatilityHigh
+		}
+	}
+
+	spans := mergeShortSpans(labelSpans(labels), params.MinSegmentCandles)
+
+	segments := make([]model.MarketRegimeSegment, len(spans))
+	for n, span := range spans {
+		first := span.start + window
+		last := span.end + window
+
+		// The return runs from the close before the segment, so consecutive segments add up
+		returnPercent := 0.0
+		if base := closes[first-1]; base != 0 {
+			returnPercent = (closes[last]/base - 1) * 100
+		}
+
+		volatilitySum := 0.0
+		for k := span.start; k <= span.end; k++ {
+			volatilitySum += volatilities[k]
+		}
+
+		segments[n] = model.MarketRegimeSegment{
+			SymbolID:          candles[first].SymbolID,
+			Trend:             span.label.trend,
+			Volatility:        span.label.volatility,
+			StartTime:         candles[first].Time,
+			EndTime:           candles[last].Time,
+			CandleCount:       last - first + 1,
+			ReturnPercent:     round(returnPercent, 4),
+			VolatilityPercent: round(volatilitySum/float64(span.end-span.start+1)*100, 4),
+		}
+	}
+
+	return segments
+}
+
+// classifyTrend labels a window of closes as trending up, trending down or ranging
+func classifyTrend(closes []float64, threshold float64) string {
+	change := closes[len(closes)-1] - closes[0]
+
+	distance := 0.0
+	for i := 1; i < len(closes); i++ {
+		distance += math.Abs(closes[i] - closes[i-1])
+	}
+	if distance == 0 {
+		return model.RegimeRanging
+	}
+
+	efficiency := math.Abs(change) / distance
+	switch {
+	case efficiency < threshold:
+		return model.RegimeRanging
+	case change > 0:
+		return model.RegimeTrendingUp
+	default:
+		return model.RegimeTrendingDown
+	}
+}
+
+// returnVolatility returns the standard deviation of the log returns of a window of closes
+func returnVolatility(closes []float64) float64 {
+	returns := make([]float64, 0, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] > 0 && closes[i] > 0 {
+			returns = append(returns, math.Log(closes[i]/closes[i-1]))
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// labelSpan is a run of candles with the same label; start and end are inclusive indexes
+type labelSpan struct {
+	start, end int
+	label      regimeLabel
+}
+
+// labelSpans groups consecutive equal labels
+func labelSpans(labels []regimeLabel) []labelSpan {
+	spans := make([]labelSpan, 0)
+	for i, label := range labels {
+		if len(spans) > 0 && spans[len(spans)-1].label == label {
+			spans[len(spans)-1].end = i
+			continue
+		}
+		spans = append(spans, labelSpan{start: i, end: i, label: label})
+	}
+	return spans
+}
+
+// mergeShortSpans absorbs spans shorter than minLength into the span before them, or the
+// span after for a short first span, then joins neighbours that end up with the same label
+func mergeShortSpans(spans []labelSpan, minLength int) []labelSpan {
+	if minLength <= 1 || len(spans) <= 1 {
+		return spans
+	}
+
+	merged := make([]labelSpan, 0, len(spans))
+	for _, span := range spans {
+		if len(merged) > 0 && (span.end-span.start+1 < minLength || merged[len(merged)-1].label == span.label) {
+			merged[len(merged)-1].end = span.end
+			continue
+		}
+		merged = append(merged, span)
+	}
+
+	// A short first span takes the label of the span after it
+	if len(merged) > 1 && merged[0].end-merged[0].start+1 < minLength {
+		merged[1].start = merged[0].start
+		merged = merged[1:]
+	}
+
+	return merged
+}
+
+// median returns the median of values without modifying them
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// round rounds a value to the given number of decimal places
+func round(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,187 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// dateLayout is the layout of holiday and session dates
+const dateLayout = "2006-01-02"
+
+// maxSearchDays bounds the search for the next or previous trading day
+const maxSearchDays = 31
+
+// Calendar answers when an exchange is open from its weekly sessions and holidays
+type Calendar struct {
+	location *time.Location
+	weekly   map[time.Weekday][]sessionHours
+	holidays map[string]model.TradingHoliday
+}
+
+// sessionHours is a session as minutes since local midnight
+type sessionHours struct {
+	open  int
+	close int
+}
+
+// New builds a calendar from its stored definition
+func New(definition *model.TradingCalendar) (*Calendar, error) {
+	location, err := time.LoadLocation(definition.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q for exchange %s", definition.Timezone, definition.Exchange)
+	}
+
+	weekly := make(map[time.Weekday][]sessionHours)
+	for _, session := range definition.Sessions {
+		open, err := ParseClock(session.OpenTime)
+		if err != nil {
+			return nil, err
+		}
+		closing, err := ParseClock(session.CloseTime)
+		if err != nil {
+			return nil, err
+		}
+		day := time.Weekday(session.DayOfWeek)
+		weekly[day] = append(weekly[day], sessionHours{open: open, close: closing})
+	}
+
+	holidays := make(map[string]model.TradingHoliday, len(definition.Holidays))
+	for _, holiday := range definition.Holidays {
+		holidays[holiday.Date] = holiday
+	}
+
+	return &Calendar{
+		location: location,
+		weekly:   weekly,
+		holidays: holidays,
+	}, nil
+}
+
+// ParseClock parses an HH:MM time of day, up to 24:00, into minutes since midnight
+func ParseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+
+	total := hours*60 + minutes
+	if hours < 0 || minutes < 0 || minutes > 59 || total > 24*60 {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+	return total, nil
+}
+
+// IsTradingDay reports whether the exchange has a session on the calendar date of t
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if holiday, ok := c.holidays[t.Format(dateLayout)]; ok && holiday.EarlyClose == nil {
+		return false
+	}
+	return len(c.weekly[t.Weekday()]) > 0
+}
+
+// NextTradingDay returns the first trading day on or after the date of t, keeping its time
+// of day. The second result is false if there is none within a month.
+func (c *Calendar) NextTradingDay(t time.Time) (time.Time, bool) {
+	for i := 0; i <= maxSearchDays; i++ {
+		if day := t.AddDate(0, 0, i); c.IsTradingDay(day) {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PreviousTradingDay returns the last trading day on or before the date of t, keeping its
+// time of day. The second result is false if there is none within a month.
+func (c *Calendar) PreviousTradingDay(t time.Time) (time.Time, bool) {
+	for i := 0; i <= maxSearchDays; i++ {
+		if day := t.AddDate(0, 0, -i); c.IsTradingDay(day) {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Holidays returns the full-day closures between the calendar dates of from and to
+func (c *Calendar) Holidays(from, to time.Time) []model.TradingHoliday {
+	first := from.Format(dateLayout)
+	last := to.Format(dateLayout)
+
+	holidays := make([]model.TradingHoliday, 0)
+	for date, holiday := range c.holidays {
+		if holiday.EarlyClose == nil && date >= first && date <= last {
+			holidays = append(holidays, holiday)
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+	return holidays
+}
+
+// Sessions returns the sessions that overlap the range from-to, in UTC
+func (c *Calendar) Sessions(from, to time.Time) []model.ExchangeSession {
+	sessions := make([]model.ExchangeSession, 0)
+
+	// Start a day early so a session that began the previous local day is included
+	localFrom := from.In(c.location).AddDate(0, 0, -1)
+	localTo := to.In(c.location)
+	day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day(), 0, 0, 0, 0, c.location)
+
+	for !day.After(localTo) {
+		date := day.Format(dateLayout)
+
+		earlyClose := -1
+		if holiday, ok := c.holidays[date]; ok {
+			if holiday.EarlyClose == nil {
+				day = day.AddDate(0, 0, 1)
+				continue
+			}
+			if minutes, err := ParseClock(*holiday.EarlyClose); err == nil {
+				earlyClose = minutes
+			}
+		}
+
+		for _, hours := range c.weekly[day.Weekday()] {
+			closeMinutes := hours.close
+			early := false
+			if earlyClose >= 0 && earlyClose < closeMinutes {
+				closeMinutes = earlyClose
+				early = true
+			}
+			if closeMinutes <= hours.open {
+				continue
+			}
+
+			// time.Date normalises minutes past midnight, so 24:00 becomes the next day
+			opens := time.Date(day.Year(), day.Month(), day.Day(), 0, hours.open, 0, 0, c.location)
+			closes := time.Date(day.Year(), day.Month(), day.Day(), 0, closeMinutes, 0, 0, c.location)
+			if closes.Before(from) || opens.After(to) {
+				continue
+			}
+
+			sessions = append(sessions, model.ExchangeSession{
+				Date:       date,
+				Open:       opens.UTC(),
+				Close:      closes.UTC(),
+				EarlyClose: early,
+			})
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return sessions
+}
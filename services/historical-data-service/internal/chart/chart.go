@@ -0,0 +1,336 @@
+// Package chart renders the equity curve and drawdown of a backtest run as PNG and SVG images.
+// Both formats share one layout, so they look the same.
+package chart
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+const (
+	// DefaultWidth and DefaultHeight are the image size used when none is requested
+	DefaultWidth  = 800
+	DefaultHeight = 400
+
+	// MinWidth, MaxWidth, MinHeight and MaxHeight bound the requested image size
+	MinWidth  = 300
+	MaxWidth  = 2000
+	MinHeight = 200
+	MaxHeight = 1200
+
+	marginLeft   = 72
+	marginRight  = 16
+	marginTop    = 28
+	marginBottom = 24
+	panelGap     = 20
+
+	// equityShare is the part of the plot height given to the equity panel
+	equityShare = 0.68
+	// lineWidth is the stroke width of the equity and drawdown lines, in pixels
+	lineWidth = 1.6
+)
+
+// Colors shared by both renderers
+var (
+	backgroundColor   = rgb{0xff, 0xff, 0xff}
+	gridColor         = rgb{0xe5, 0xe7, 0xeb}
+	axisColor         = rgb{0xd1, 0xd5, 0xdb}
+	textColor         = rgb{0x37, 0x41, 0x51}
+	equityColor       = rgb{0x25, 0x63, 0xeb}
+	drawdownColor     = rgb{0xdc, 0x26, 0x26}
+	drawdownFillAlpha = 0.25
+)
+
+// Options controls the size and title of a rendered chart
+type Options struct {
+	Width  int
+	Height int
+	Title  string
+}
+
+// normalized fills in the default size and clamps the requested one to the allowed range
+func (o Options) normalized() Options {
+	if o.Width <= 0 {
+		o.Width = DefaultWidth
+	}
+	if o.Height <= 0 {
+		o.Height = DefaultHeight
+	}
+	o.Width = min(max(o.Width, MinWidth), MaxWidth)
+	o.Height = min(max(o.Height, MinHeight), MaxHeight)
+	return o
+}
+
+type rgb struct {
+	r, g, b uint8
+}
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+}
+
+// tick is a labelled position on an axis
+type tick struct {
+	value float64
+	label string
+}
+
+// layout maps equity points onto image coordinates. Points are spaced evenly along the x axis,
+// one per bar, so gaps in the data (weekends, missing candles) don't leave flat stretches.
+type layout struct {
+	width, height  int
+	title          string
+	plotLeft       float64
+	plotRight      float64
+	equityTop      float64
+	equityBottom   float64
+	drawdownTop    float64
+	drawdownBottom float64
+
+	points    []model.EquityPoint
+	drawdowns []float64
+
+	minEquity   float64
+	maxEquity   float64
+	maxDrawdown float64
+
+	equityTicks   []tick
+	drawdownTicks []tick
+	timeTicks     []timeTick
+}
+
+// timeTick is a labelled position on the time axis; align is "start", "middle" or "end"
+type timeTick struct {
+	index int
+	label string
+	align string
+}
+
+// newLayout computes the layout of a chart for an equity curve
+func newLayout(curve *model.EquityCurve, opts Options) *layout {
+	opts = opts.normalized()
+
+	l := &layout{
+		width:     opts.Width,
+		height:    opts.Height,
+		title:     opts.Title,
+		plotLeft:  marginLeft,
+		plotRight: float64(opts.Width - marginRight),
+		equityTop: marginTop,
+	}
+	plotHeight := float64(opts.Height-marginTop-marginBottom) - panelGap
+	l.equityBottom = l.equityTop + math.Round(plotHeight*equityShare)
+	l.drawdownTop = l.equityBottom + panelGap
+	l.drawdownBottom = float64(opts.Height - marginBottom)
+
+	drawdowns := Drawdowns(curve.Points)
+	keep := downsample(curve.Points, drawdowns, int(l.plotRight-l.plotLeft)/2)
+	l.points = make([]model.EquityPoint, len(keep))
+	l.drawdowns = make([]float64, len(keep))
+	for i, index := range keep {
+		l.points[i] = curve.Points[index]
+		l.drawdowns[i] = drawdowns[index]
+	}
+
+	l.minEquity, l.maxEquity = curve.InitialCapital, curve.InitialCapital
+	if len(curve.Points) > 0 {
+		l.minEquity, l.maxEquity = curve.Points[0].Equity, curve.Points[0].Equity
+	}
+	for _, point := range curve.Points {
+		l.minEquity = math.Min(l.minEquity, point.Equity)
+		l.maxEquity = math.Max(l.maxEquity, point.Equity)
+	}
+	for _, drawdown := range drawdowns {
+		l.maxDrawdown = math.Min(l.maxDrawdown, drawdown)
+	}
+
+	l.equityTicks = valueTicks(l.minEquity, l.maxEquity, 5, formatEquity)
+	l.minEquity = math.Min(l.minEquity, l.equityTicks[0].value)
+	l.maxEquity = math.Max(l.maxEquity, l.equityTicks[len(l.equityTicks)-1].value)
+
+	l.drawdownTicks = valueTicks(l.maxDrawdown, 0, 3, formatPercent)
+	l.maxDrawdown = math.Min(l.maxDrawdown, l.drawdownTicks[0].value)
+
+	l.timeTicks = timeTicks(l.points)
+
+	return l
+}
+
+// x returns the horizontal position of the point at index i
+func (l *layout) x(i int) float64 {
+	if len(l.points) <= 1 {
+		return l.plotLeft
+	}
+	return l.plotLeft + (l.plotRight-l.plotLeft)*float64(i)/float64(len(l.points)-1)
+}
+
+// equityY returns the vertical position of an equity value in the equity panel
+func (l *layout) equityY(equity float64) float64 {
+	if l.maxEquity == l.minEquity {
+		return (l.equityTop + l.equityBottom) / 2
+	}
+	return l.equityBottom - (equity-l.minEquity)/(l.maxEquity-l.minEquity)*(l.equityBottom-l.equityTop)
+}
+
+// drawdownY returns the vertical position of a drawdown percentage in the drawdown panel
+func (l *layout) drawdownY(drawdown float64) float64 {
+	if l.maxDrawdown == 0 {
+		return l.drawdownTop
+	}
+	return l.drawdownTop + drawdown/l.maxDrawdown*(l.drawdownBottom-l.drawdownTop)
+}
+
+// Drawdowns returns the drawdown of each point from the running equity peak, in percent (<= 0)
+func Drawdowns(points []model.EquityPoint) []float64 {
+	drawdowns := make([]float64, len(points))
+	peak := math.Inf(-1)
+	for i, point := range points {
+		peak = math.Max(peak, point.Equity)
+		if peak > 0 {
+			drawdowns[i] = (point.Equity/peak - 1) * 100
+		}
+	}
+	return drawdowns
+}
+
+// downsample picks the indexes of the points to draw so long curves stay within roughly two
+// points per bucket of pixels. Each bucket keeps its equity extremes and its deepest drawdown,
+// so peaks and troughs survive. The first and last points are always kept.
+func downsample(points []model.EquityPoint, drawdowns []float64, buckets int) []int {
+	if buckets < 1 || len(points) <= buckets*3 {
+		indexes := make([]int, len(points))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes
+	}
+
+	indexes := []int{0}
+	size := float64(len(points)-2) / float64(buckets)
+	for b := 0; b < buckets; b++ {
+		start := 1 + int(float64(b)*size)
+		end := 1 + int(float64(b+1)*size)
+		if end <= start {
+			continue
+		}
+
+		low, high, deepest := start, start, start
+		for i := start; i < end; i++ {
+			if points[i].Equity < points[low].Equity {
+				low = i
+			}
+			if points[i].Equity > points[high].Equity {
+				high = i
+			}
+			if drawdowns[i] < drawdowns[deepest] {
+				deepest = i
+			}
+		}
+
+		bucket := []int{low, high, deepest}
+		for i := 1; i < len(bucket); i++ {
+			for j := i; j > 0 && bucket[j] < bucket[j-1]; j-- {
+				bucket[j], bucket[j-1] = bucket[j-1], bucket[j]
+			}
+		}
+		for _, index := range bucket {
+			if index != indexes[len(indexes)-1] {
+				indexes = append(indexes, index)
+			}
+		}
+	}
+
+	return append(indexes, len(points)-1)
+}
+
+// valueTicks returns evenly spaced ticks at round values covering low to high
+func valueTicks(low, high float64, count int, format func(value, step float64) string) []tick {
+	if high <= low {
+		spread := math.Max(math.Abs(high)*0.01, 1)
+		if high == 0 {
+			// A flat drawdown panel still shows a small scale below zero
+			low = -spread
+		} else {
+			low, high = low-spread, high+spread
+		}
+	}
+
+	step := niceStep((high - low) / float64(count-1))
+	first := math.Floor(low/step) * step
+	last := math.Ceil(high/step) * step
+
+	ticks := []tick{}
+	for value := first; value <= last+step/2; value += step {
+		// Avoid printing -0
+		if math.Abs(value) < step/1e6 {
+			value = 0
+		}
+		ticks = append(ticks, tick{value: value, label: format(value, step)})
+	}
+	return ticks
+}
+
+// niceStep rounds a tick step up to 1, 2, 2.5 or 5 times a power of ten
+func niceStep(raw float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, factor := range []float64{1, 2, 2.5, 5} {
+		if raw <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// decimals returns the number of decimals needed to tell ticks a step apart
+func decimals(step float64) int {
+	if step >= 1 {
+		return 0
+	}
+	return int(math.Ceil(-math.Log10(step) - 1e-9))
+}
+
+// formatEquity formats an equity tick, abbreviating thousands and millions
+func formatEquity(value, step float64) string {
+	switch {
+	case math.Abs(value) >= 1e6 && step >= 1e4:
+		return fmt.Sprintf("%.*fM", decimals(step/1e6), value/1e6)
+	case math.Abs(value) >= 1e4 && step >= 100:
+		return fmt.Sprintf("%.*fk", decimals(step/1e3), value/1e3)
+	default:
+		return fmt.Sprintf("%.*f", decimals(step), value)
+	}
+}
+
+// formatPercent formats a drawdown tick
+func formatPercent(value, step float64) string {
+	return fmt.Sprintf("%.*f%%", decimals(step), value)
+}
+
+// timeTicks labels the first, middle and last point of the time axis. Curves without
+// timestamps get no labels.
+func timeTicks(points []model.EquityPoint) []timeTick {
+	if len(points) == 0 || points[0].Time.IsZero() || points[len(points)-1].Time.IsZero() {
+		return nil
+	}
+
+	layout := "2006-01-02"
+	if points[len(points)-1].Time.Sub(points[0].Time) < 72*time.Hour {
+		layout = "01-02 15:04"
+	}
+
+	ticks := []timeTick{{index: 0, label: points[0].Time.Format(layout), align: "start"}}
+	if len(points) > 2 {
+		middle := len(points) / 2
+		if !points[middle].Time.IsZero() {
+			ticks = append(ticks, timeTick{index: middle, label: points[middle].Time.Format(layout), align: "middle"})
+		}
+	}
+	if len(points) > 1 {
+		last := len(points) - 1
+		ticks = append(ticks, timeTick{index: last, label: points[last].Time.Format(layout), align: "end"})
+	}
+	return ticks
+}
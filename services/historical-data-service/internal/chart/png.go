@@ -0,0 +1,169 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"services/historical-data-service/internal/model"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// RenderPNG draws the equity curve and drawdown chart of a backtest run as a PNG image
+func RenderPNG(w io.Writer, curve *model.EquityCurve, opts Options) error {
+	l := newLayout(curve, opts)
+
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor.rgba()), image.Point{}, draw.Src)
+
+	// Grid lines and axis labels
+	for _, t := range l.equityTicks {
+		y := int(math.Round(l.equityY(t.value)))
+		hline(img, int(l.plotLeft), int(l.plotRight), y, gridColor.rgba())
+		drawText(img, t.label, int(l.plotLeft)-6, y+4, "end")
+	}
+	for _, t := range l.drawdownTicks {
+		y := int(math.Round(l.drawdownY(t.value)))
+		lineColor := gridColor
+		if t.value == 0 {
+			lineColor = axisColor
+		}
+		hline(img, int(l.plotLeft), int(l.plotRight), y, lineColor.rgba())
+		drawText(img, t.label, int(l.plotLeft)-6, y+4, "end")
+	}
+	hline(img, int(l.plotLeft), int(l.plotRight), int(l.drawdownBottom), axisColor.rgba())
+	for _, t := range l.timeTicks {
+		drawText(img, t.label, int(math.Round(l.x(t.index))), l.height-8, t.align)
+	}
+
+	if l.title != "" {
+		drawText(img, l.title, int(l.plotLeft), 18, "start")
+	}
+	drawText(img, "Equity", int(l.plotLeft)+6, int(l.equityTop)+14, "start")
+	drawText(img, "Drawdown", int(l.plotLeft)+6, int(l.drawdownBottom)-6, "start")
+
+	// Drawdown area, filled column by column between the zero line and the curve
+	zeroY := l.drawdownY(0)
+	for i := 1; i < len(l.points); i++ {
+		x0, x1 := l.x(i-1), l.x(i)
+		y0, y1 := l.drawdownY(l.drawdowns[i-1]), l.drawdownY(l.drawdowns[i])
+		for x := int(math.Ceil(x0)); x < int(math.Ceil(x1)); x++ {
+			t := (float64(x) - x0) / (x1 - x0)
+			y := y0 + (y1-y0)*t
+			for py := int(math.Round(zeroY)); py < int(math.Round(y)); py++ {
+				blend(img, x, py, drawdownColor, drawdownFillAlpha)
+			}
+		}
+	}
+
+	// Lines
+	equityLine := newCoverage(l.width, l.height)
+	drawdownLine := newCoverage(l.width, l.height)
+	for i := 1; i < len(l.points); i++ {
+		x0, x1 := l.x(i-1), l.x(i)
+		equityLine.stroke(x0, l.equityY(l.points[i-1].Equity), x1, l.equityY(l.points[i].Equity), lineWidth)
+		drawdownLine.stroke(x0, l.drawdownY(l.drawdowns[i-1]), x1, l.drawdownY(l.drawdowns[i]), lineWidth)
+	}
+	drawdownLine.paint(img, drawdownColor)
+	equityLine.paint(img, equityColor)
+
+	return png.Encode(w, img)
+}
+
+func (c rgb) rgba() color.RGBA {
+	return color.RGBA{R: c.r, G: c.g, B: c.b, A: 0xff}
+}
+
+// hline draws a one pixel horizontal line
+func hline(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	for x := x0; x <= x1; x++ {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+// blend mixes a color into a pixel with the given opacity
+func blend(img *image.RGBA, x, y int, c rgb, alpha float64) {
+	if !(image.Point{X: x, Y: y}).In(img.Rect) {
+		return
+	}
+	dst := img.RGBAAt(x, y)
+	mix := func(d, s uint8) uint8 {
+		return uint8(math.Round(float64(d)*(1-alpha) + float64(s)*alpha))
+	}
+	img.SetRGBA(x, y, color.RGBA{R: mix(dst.R, c.r), G: mix(dst.G, c.g), B: mix(dst.B, c.b), A: 0xff})
+}
+
+// drawText writes a label with its baseline at y, anchored at x by align ("start", "middle"
+// or "end")
+func drawText(img *image.RGBA, text string, x, y int, align string) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Round()
+	switch align {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(textColor.rgba()),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// coverage accumulates how much of each pixel a line covers. Keeping the maximum per pixel
+// instead of blending every segment avoids dark spots where segments join.
+type coverage struct {
+	width, height int
+	alpha         []float64
+}
+
+func newCoverage(width, height int) *coverage {
+	return &coverage{width: width, height: height, alpha: make([]float64, width*height)}
+}
+
+// stroke adds an antialiased segment of the given width
+func (c *coverage) stroke(x0, y0, x1, y1, width float64) {
+	half := width / 2
+	minX := max(0, int(math.Floor(math.Min(x0, x1)-half-1)))
+	maxX := min(c.width-1, int(math.Ceil(math.Max(x0, x1)+half+1)))
+	minY := max(0, int(math.Floor(math.Min(y0, y1)-half-1)))
+	maxY := min(c.height-1, int(math.Ceil(math.Max(y0, y1)+half+1)))
+
+	dx, dy := x1-x0, y1-y0
+	lengthSq := dx*dx + dy*dy
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			// Distance from the pixel center to the segment
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if lengthSq > 0 {
+				t = math.Max(0, math.Min(1, ((cx-x0)*dx+(cy-y0)*dy)/lengthSq))
+			}
+			distance := math.Hypot(cx-(x0+t*dx), cy-(y0+t*dy))
+
+			alpha := math.Max(0, math.Min(1, half+0.5-distance))
+			if i := py*c.width + px; alpha > c.alpha[i] {
+				c.alpha[i] = alpha
+			}
+		}
+	}
+}
+
+// paint blends the covered pixels onto the image in the given color
+func (c *coverage) paint(img *image.RGBA, col rgb) {
+	for i, alpha := range c.alpha {
+		if alpha > 0 {
+			blend(img, i%c.width, i/c.width, col, alpha)
+		}
+	}
+}
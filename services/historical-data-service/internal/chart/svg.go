@@ -0,0 +1,85 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+
+	"services/historical-data-service/internal/model"
+)
+
+// RenderSVG draws the equity curve and drawdown chart of a backtest run as an SVG image
+func RenderSVG(w io.Writer, curve *model.EquityCurve, opts Options) error {
+	l := newLayout(curve, opts)
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" `+
+		`font-family="sans-serif" font-size="11">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", backgroundColor.hex())
+
+	// Grid lines and axis labels
+	for _, t := range l.equityTicks {
+		y := l.equityY(t.value)
+		writeHLine(b, l, y, gridColor)
+		writeText(b, t.label, l.plotLeft-6, y+4, "end")
+	}
+	for _, t := range l.drawdownTicks {
+		y := l.drawdownY(t.value)
+		lineColor := gridColor
+		if t.value == 0 {
+			lineColor = axisColor
+		}
+		writeHLine(b, l, y, lineColor)
+		writeText(b, t.label, l.plotLeft-6, y+4, "end")
+	}
+	writeHLine(b, l, l.drawdownBottom, axisColor)
+	for _, t := range l.timeTicks {
+		writeText(b, t.label, l.x(t.index), float64(l.height-8), t.align)
+	}
+
+	if l.title != "" {
+		writeText(b, l.title, l.plotLeft, 18, "start")
+	}
+	writeText(b, "Equity", l.plotLeft+6, l.equityTop+14, "start")
+	writeText(b, "Drawdown", l.plotLeft+6, l.drawdownBottom-6, "start")
+
+	if len(l.points) > 0 {
+		// Drawdown area closed along the zero line
+		zeroY := l.drawdownY(0)
+		fmt.Fprintf(b, `<path fill="%s" fill-opacity="%.2f" d="M%.1f,%.1f`,
+			drawdownColor.hex(), drawdownFillAlpha, l.x(0), zeroY)
+		for i := range l.points {
+			fmt.Fprintf(b, " L%.1f,%.1f", l.x(i), l.drawdownY(l.drawdowns[i]))
+		}
+		fmt.Fprintf(b, ` L%.1f,%.1f Z"/>`+"\n", l.x(len(l.points)-1), zeroY)
+
+		writePolyline(b, l, drawdownColor, func(i int) float64 { return l.drawdownY(l.drawdowns[i]) })
+		writePolyline(b, l, equityColor, func(i int) float64 { return l.equityY(l.points[i].Equity) })
+	}
+
+	fmt.Fprint(b, "</svg>\n")
+	return b.Flush()
+}
+
+func writeHLine(b *bufio.Writer, l *layout, y float64, c rgb) {
+	fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`+"\n",
+		l.plotLeft, y, l.plotRight, y, c.hex())
+}
+
+func writeText(b *bufio.Writer, text string, x, y float64, anchor string) {
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="%s" fill="%s">%s</text>`+"\n",
+		x, y, anchor, textColor.hex(), html.EscapeString(text))
+}
+
+func writePolyline(b *bufio.Writer, l *layout, c rgb, y func(i int) float64) {
+	fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="%.1f" stroke-linejoin="round" points="`,
+		c.hex(), lineWidth)
+	for i := range l.points {
+		if i > 0 {
+			fmt.Fprint(b, " ")
+		}
+		fmt.Fprintf(b, "%.1f,%.1f", l.x(i), y(i))
+	}
+	fmt.Fprint(b, `"/>`+"\n")
+}
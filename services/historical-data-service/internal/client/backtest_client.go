@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/httpclient"
 
 	"go.uber.org/zap"
 )
@@ -16,7 +17,7 @@ import (
 // BacktestClient handles communication with the Backtesting Service
 type BacktestClient struct {
 	baseURL    string
-	httpClient *http.Client
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
@@ -24,9 +25,10 @@ type BacktestClient struct {
 func NewBacktestClient(baseURL string, logger *zap.Logger) *BacktestClient {
 	return &BacktestClient{
 		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second, // Longer timeout for backtests
-		},
+		httpClient: httpclient.New("backtesting service", httpclient.Config{
+			Timeout:    120 * time.Second, // Longer timeout for backtests
+			MaxRetries: 2,
+		}, logger),
 		logger: logger,
 	}
 }
@@ -164,6 +166,128 @@ func (c *BacktestClient) RunBacktestWithDB(
 	return &result, nil
 }
 
+// PreviewSignals has the engine evaluate a strategy's rules over stored candles and list
+// the entries and exits they give, without running a backtest
+func (c *BacktestClient) PreviewSignals(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+	startDate time.Time,
+	endDate time.Time,
+	strategy json.RawMessage,
+	params map[string]interface{},
+) ([]model.Signal, int, error) {
+	payload := map[string]interface{}{
+		"symbol_id":  symbolID,
+		"timeframe":  timeframe,
+		"start_date": startDate.Format(time.RFC3339),
+		"end_date":   endDate.Format(time.RFC3339),
+		"strategy":   strategy,
+		"params":     params,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal signal preview request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/signals/preview", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to send request to backtesting service", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			return nil, 0, fmt.Errorf("backtest service returned status %d", resp.StatusCode)
+		}
+		return nil, 0, fmt.Errorf("backtest service error: %s", errorResp.Error)
+	}
+
+	var result struct {
+		Signals []model.Signal `json:"signals"`
+		Candles int            `json:"candles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		c.logger.Error("Failed to decode signal preview response", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Signals, result.Candles, nil
+}
+
+// EngineSubmission is a backtest run submitted to the engine with the async protocol
+type EngineSubmission struct {
+	BacktestRunID int                    `json:"backtest_run_id"`
+	SymbolID      int                    `json:"symbol_id"`
+	Timeframe     string                 `json:"timeframe"`
+	StartDate     time.Time              `json:"start_date"`
+	EndDate       time.Time              `json:"end_date"`
+	Strategy      json.RawMessage        `json:"strategy"`
+	Params        map[string]interface{} `json:"params"`
+	// CallbackURL receives the engine's progress reports and the outcome of the run
+	CallbackURL string `json:"callback_url"`
+	// LeaseSeconds is how long the run may go without a report before it fails
+	LeaseSeconds int `json:"lease_seconds"`
+}
+
+// SubmitBacktest submits a run to the engine, which accepts it right away and runs it in
+// the background. Returns the job token the engine's callbacks carry.
+func (c *BacktestClient) SubmitBacktest(ctx context.Context, submission EngineSubmission) (string, error) {
+	jsonData, err := json.Marshal(submission)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal backtest submission: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v2/backtests", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c.logger.Info("Submitting backtest run to the engine",
+		zap.String("url", url),
+		zap.Int("symbolID", submission.SymbolID),
+		zap.Int("runID", submission.BacktestRunID))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to submit backtest run to backtesting service", zap.Error(err))
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		JobToken string `json:"job_token"`
+		Error    string `json:"error"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusAccepted {
+		if decodeErr != nil || result.Error == "" {
+			return "", fmt.Errorf("backtest service returned status %d", resp.StatusCode)
+		}
+		return "", fmt.Errorf("backtest service error: %s", result.Error)
+	}
+	if decodeErr != nil || result.JobToken == "" {
+		return "", fmt.Errorf("backtest service accepted the run without a job token")
+	}
+
+	return result.JobToken, nil
+}
+
 // ValidateStrategy validates a strategy structure
 func (c *BacktestClient) ValidateStrategy(ctx context.Context, strategy json.RawMessage) (bool, string, error) {
 	// Build request payload
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,26 +19,139 @@ import (
 const (
 	BinanceAPIBaseURL = "https://api.binance.com/api/v3"
 	MaxKlinesLimit    = 1000
+
+	// Request weights of the endpoints used, as documented by Binance
+	binanceExchangeInfoWeight = 20
+
+	// MaxTickerSymbols is the most symbols one 24hr ticker request may ask for
+	MaxTickerSymbols = 100
+
+	// binanceInvalidSymbolCode is the error code Binance returns for unknown symbols
+	binanceInvalidSymbolCode = -1121
+
+	// binanceUsedWeightHeader reports the weight used from this address in the current minute
+	binanceUsedWeightHeader = "X-MBX-USED-WEIGHT-1M"
 )
 
 // BinanceClient handles communication with the Binance API
 type BinanceClient struct {
 	baseURL    string
 	httpClient *http.Client
+	budget     *RateBudget
 	logger     *zap.Logger
 }
 
-// NewBinanceClient creates a new Binance API client
-func NewBinanceClient(logger *zap.Logger) *BinanceClient {
+// RequestStats describes the cost of one API request
+type RequestStats struct {
+	Weight int
+	Bytes  int64
+	// Duration is the time spent on the request itself
+	Duration time.Duration
+	// Waited is the time spent waiting for the rate-limit budget first
+	Waited time.Duration
+}
+
+// NewBinanceClient creates a new Binance API client whose requests spend budget. Every
+// client of the same address should share one budget.
+func NewBinanceClient(budget *RateBudget, logger *zap.Logger) *BinanceClient {
 	return &BinanceClient{
 		baseURL: BinanceAPIBaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		budget: budget,
 		logger: logger,
 	}
 }
 
+// do sends a request once the budget allows its weight and keeps the budget in step with
+// the usage Binance reports. Throttled requests return a *RateLimitError.
+func (c *BinanceClient) do(req *http.Request, weight int, stats *RequestStats) (*http.Response, error) {
+	stats.Weight = weight
+
+	waited, err := c.budget.Wait(req.Context(), weight)
+	stats.Waited = waited
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	resp, err := c.httpClient.Do(req)
+	stats.Duration = time.Since(started)
+	if err != nil {
+		return nil, err
+	}
+
+	if used, err := strconv.Atoi(resp.Header.Get(binanceUsedWeightHeader)); err == nil {
+		c.budget.Sync(used)
+	}
+
+	// 429 means the limit was exceeded; 418 means the address is banned for ignoring 429s
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		resp.Body.Close()
+
+		retryAfter := time.Minute
+		if resp.StatusCode == http.StatusTeapot {
+			retryAfter = 2 * time.Minute
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+
+		c.budget.Throttle(resp.StatusCode, retryAfter)
+		c.logger.Warn("Binance throttled request",
+			zap.Int("statusCode", resp.StatusCode),
+			zap.Duration("retryAfter", retryAfter))
+
+		return nil, &RateLimitError{
+			Provider:   string(model.SourceBinance),
+			StatusCode: resp.StatusCode,
+			RetryAfter: retryAfter,
+		}
+	}
+
+	resp.Body = &countingReader{ReadCloser: resp.Body, count: &stats.Bytes}
+	return resp, nil
+}
+
+// countingReader counts the bytes read from a response body
+type countingReader struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.count += int64(n)
+	return n, err
+}
+
+// klinesWeight returns the request weight of a klines request for limit candles
+func klinesWeight(limit int) int {
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// tickerWeight returns the request weight of a 24hr ticker request for count symbols
+func tickerWeight(count int) int {
+	switch {
+	case count <= 20:
+		return 2
+	case count <= 100:
+		return 40
+	default:
+		return 80
+	}
+}
+
 // GetExchangeInfo retrieves all available symbols from Binance
 func (c *BinanceClient) GetExchangeInfo(ctx context.Context) (*model.BinanceExchangeInfo, error) {
 	reqURL := fmt.Sprintf("%s/exchangeInfo", c.baseURL)
@@ -47,7 +161,8 @@ func (c *BinanceClient) GetExchangeInfo(ctx context.Context) (*model.BinanceExch
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	var stats RequestStats
+	resp, err := c.do(req, binanceExchangeInfoWeight, &stats)
 	if err != nil {
 		c.logger.Error("Failed to fetch exchange info from Binance", zap.Error(err))
 		return nil, fmt.Errorf("failed to fetch exchange info: %w", err)
@@ -71,8 +186,15 @@ func (c *BinanceClient) GetExchangeInfo(ctx context.Context) (*model.BinanceExch
 	return &exchangeInfo, nil
 }
 
-// GetKlines retrieves candlestick data for a symbol and interval
-func (c *BinanceClient) GetKlines(ctx context.Context, symbol, interval string, startTime, endTime *time.Time, limit int) ([]model.BinanceKline, error) {
+// GetKlines retrieves candlestick data for a symbol and interval. The stats of the request
+// are returned even when it fails.
+func (c *BinanceClient) GetKlines(
+	ctx context.Context,
+	symbol, interval string,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.BinanceKline, RequestStats, error) {
+	var stats RequestStats
 	if limit > MaxKlinesLimit {
 		limit = MaxKlinesLimit
 	}
@@ -103,16 +225,20 @@ func (c *BinanceClient) GetKlines(ctx context.Context, symbol, interval string,
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, stats, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, klinesWeight(limit), &stats)
 	if err != nil {
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			return nil, stats, err
+		}
 		c.logger.Error("Failed to fetch klines from Binance",
 			zap.Error(err),
 			zap.String("symbol", symbol),
 			zap.String("interval", interval))
-		return nil, fmt.Errorf("failed to fetch klines: %w", err)
+		return nil, stats, fmt.Errorf("failed to fetch klines: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -122,13 +248,13 @@ func (c *BinanceClient) GetKlines(ctx context.Context, symbol, interval string,
 		c.logger.Error("Binance API error response",
 			zap.Int("statusCode", resp.StatusCode),
 			zap.String("response", string(bodyBytes)))
-		return nil, fmt.Errorf("Binance API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, stats, fmt.Errorf("Binance API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	var rawKlines [][]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&rawKlines); err != nil {
 		c.logger.Error("Failed to decode Binance klines", zap.Error(err))
-		return nil, fmt.Errorf("failed to decode klines: %w", err)
+		return nil, stats, fmt.Errorf("failed to decode klines: %w", err)
 	}
 
 	// Add logging for empty responses
@@ -213,7 +339,7 @@ func (c *BinanceClient) GetKlines(ctx context.Context, symbol, interval string,
 		})
 	}
 
-	return klines, nil
+	return klines, stats, nil
 }
 
 // MapBinanceIntervalToTimeframe maps Binance interval strings to our timeframe enum
@@ -263,3 +389,146 @@ func MapTimeframeToBinanceInterval(timeframe string) string {
 		return ""
 	}
 }
+
+// binanceIntervalMinutes lists the kline intervals Binance supports and their length in minutes
+var binanceIntervalMinutes = map[string]int{
+	"1m":  1,
+	"3m":  3,
+	"5m":  5,
+	"15m": 15,
+	"30m": 30,
+	"1h":  60,
+	"2h":  120,
+	"4h":  240,
+	"6h":  360,
+	"8h":  480,
+	"12h": 720,
+	"1d":  1440,
+	"3d":  4320,
+	"1w":  10080,
+}
+
+// BinanceIntervalMinutes returns the length of a Binance kline interval in minutes,
+// or 0 if Binance doesn't support the interval
+func BinanceIntervalMinutes(interval string) int {
+	return binanceIntervalMinutes[interval]
+}
+
+// BinanceIntervalForMinutes returns the Binance interval to download candles of the given
+// length from: the interval of the same length, or else the longest one that divides it
+// evenly so the candles can be aggregated
+func BinanceIntervalForMinutes(minutes int) string {
+	best := ""
+	for interval, length := range binanceIntervalMinutes {
+		if minutes%length == 0 && (best == "" || length > binanceIntervalMinutes[best]) {
+			best = interval
+		}
+	}
+	return best
+}
+
+// binanceTicker is a 24hr ticker as returned by Binance, with decimals as strings
+type binanceTicker struct {
+	Symbol             string `json:"symbol"`
+	LastPrice          string `json:"lastPrice"`
+	PriceChange        string `json:"priceChange"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	HighPrice          string `json:"highPrice"`
+	LowPrice           string `json:"lowPrice"`
+	Volume             string `json:"volume"`
+	QuoteVolume        string `json:"quoteVolume"`
+	CloseTime          int64  `json:"closeTime"`
+}
+
+// GetTickers retrieves the 24hr tickers of up to MaxTickerSymbols symbols in one request.
+// Binance rejects the whole request if any symbol is unknown.
+func (c *BinanceClient) GetTickers(ctx context.Context, symbols []string) ([]model.Ticker, error) {
+	if len(symbols) == 0 {
+		return []model.Ticker{}, nil
+	}
+	if len(symbols) > MaxTickerSymbols {
+		return nil, fmt.Errorf("at most %d symbols can be requested at once", MaxTickerSymbols)
+	}
+
+	encoded, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbols: %w", err)
+	}
+
+	params := url.Values{}
+	params.Add("symbols", string(encoded))
+	reqURL := fmt.Sprintf("%s/ticker/24hr?%s", c.baseURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var stats RequestStats
+	resp, err := c.do(req, tickerWeight(len(symbols)), &stats)
+	if err != nil {
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			return nil, err
+		}
+		c.logger.Error("Failed to fetch tickers from Binance", zap.Error(err), zap.Strings("symbols", symbols))
+		return nil, fmt.Errorf("failed to fetch tickers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		c.logger.Error("Binance API error response",
+			zap.Int("statusCode", resp.StatusCode),
+			zap.String("response", string(bodyBytes)))
+
+		var apiErr struct {
+			Code int `json:"code"`
+		}
+		if json.Unmarshal(bodyBytes, &apiErr) == nil && apiErr.Code == binanceInvalidSymbolCode {
+			return nil, fmt.Errorf("symbol not found on Binance")
+		}
+		return nil, fmt.Errorf("Binance API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var raw []binanceTicker
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		c.logger.Error("Failed to decode Binance tickers", zap.Error(err))
+		return nil, fmt.Errorf("failed to decode tickers: %w", err)
+	}
+
+	tickers := make([]model.Ticker, 0, len(raw))
+	for _, t := range raw {
+		ticker := model.Ticker{
+			Symbol:    t.Symbol,
+			Source:    model.TickerSourceREST,
+			UpdatedAt: time.UnixMilli(t.CloseTime).UTC(),
+		}
+		fields := []struct {
+			value string
+			dest  *float64
+		}{
+			{t.LastPrice, &ticker.LastPrice},
+			{t.PriceChange, &ticker.PriceChange},
+			{t.PriceChangePercent, &ticker.PriceChangePercent},
+			{t.HighPrice, &ticker.HighPrice},
+			{t.LowPrice, &ticker.LowPrice},
+			{t.Volume, &ticker.Volume},
+			{t.QuoteVolume, &ticker.QuoteVolume},
+		}
+		valid := true
+		for _, field := range fields {
+			if *field.dest, err = strconv.ParseFloat(field.value, 64); err != nil {
+				valid = false
+				break
+			}
+		}
+		if !valid {
+			c.logger.Warn("Skipping malformed ticker", zap.String("symbol", t.Symbol), zap.Error(err))
+			continue
+		}
+		tickers = append(tickers, ticker)
+	}
+
+	return tickers, nil
+}
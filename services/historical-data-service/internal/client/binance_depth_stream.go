@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/model"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	BinanceStreamBaseURL = "wss://stream.binance.com:9443"
+
+	// depthStreamSpeed is how often Binance pushes a partial book; a second is as fine as
+	// any snapshot interval needs
+	depthStreamSpeed = "1000ms"
+
+	// depthStreamReadWait is how long the stream may stay silent before the connection is
+	// considered dead. Books are pushed every second.
+	depthStreamReadWait = 30 * time.Second
+)
+
+// DepthUpdate is the top of a symbol's order book pushed by the depth stream
+type DepthUpdate struct {
+	Symbol       string
+	LastUpdateID int64
+	Bids         model.OrderBookLevels
+	Asks         model.OrderBookLevels
+	ReceivedAt   time.Time
+}
+
+// BinanceDepthStream receives the top levels of symbols' order books from the Binance
+// partial book depth streams over one WebSocket connection
+type BinanceDepthStream struct {
+	baseURL string
+	logger  *zap.Logger
+}
+
+// NewBinanceDepthStream creates a new Binance depth stream client
+func NewBinanceDepthStream(logger *zap.Logger) *BinanceDepthStream {
+	return &BinanceDepthStream{
+		baseURL: BinanceStreamBaseURL,
+		logger:  logger,
+	}
+}
+
+// Stream connects to the depth streams of the symbols, at depth levels per side, and calls
+// handle with every book pushed until ctx is cancelled or the connection fails. Binance
+// closes connections after 24 hours, so callers reconnect when it returns.
+func (s *BinanceDepthStream) Stream(ctx context.Context, symbols []string, depth int, handle func(DepthUpdate)) error {
+	streams := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		streams[i] = fmt.Sprintf("%s@depth%d@%s", strings.ToLower(symbol), depth, depthStreamSpeed)
+	}
+	streamURL := fmt.Sprintf("%s/stream?streams=%s", s.baseURL, strings.Join(streams, "/"))
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, streamURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to depth stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read below when ctx is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	s.logger.Info("Connected to Binance depth stream", zap.Strings("symbols", symbols), zap.Int("depth", depth))
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(depthStreamReadWait))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("depth stream closed: %w", err)
+		}
+
+		update, err := parseDepthMessage(message)
+		if err != nil {
+			s.logger.Warn("Skipping malformed depth stream message", zap.Error(err))
+			continue
+		}
+		handle(update)
+	}
+}
+
+// parseDepthMessage parses a combined stream message carrying a partial book
+func parseDepthMessage(message []byte) (DepthUpdate, error) {
+	var envelope struct {
+		Stream string `json:"stream"`
+		Data   struct {
+			LastUpdateID int64       `json:"lastUpdateId"`
+			Bids         [][2]string `json:"bids"`
+			Asks         [][2]string `json:"asks"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return DepthUpdate{}, err
+	}
+
+	symbol, _, _ := strings.Cut(envelope.Stream, "@")
+	if symbol == "" {
+		return DepthUpdate{}, fmt.Errorf("message has no stream name")
+	}
+
+	bids, err := parseDepthLevels(envelope.Data.Bids)
+	if err != nil {
+		return DepthUpdate{}, err
+	}
+	asks, err := parseDepthLevels(envelope.Data.Asks)
+	if err != nil {
+		return DepthUpdate{}, err
+	}
+
+	return DepthUpdate{
+		Symbol:       strings.ToUpper(symbol),
+		LastUpdateID: envelope.Data.LastUpdateID,
+		Bids:         bids,
+		Asks:         asks,
+		ReceivedAt:   time.Now(),
+	}, nil
+}
+
+// parseDepthLevels parses [price, quantity] pairs sent as strings
+func parseDepthLevels(raw [][2]string) (model.OrderBookLevels, error) {
+	levels := make(model.OrderBookLevels, len(raw))
+	for i, pair := range raw {
+		price, err := strconv.ParseFloat(pair[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q: %w", pair[0], err)
+		}
+		quantity, err := strconv.ParseFloat(pair[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q: %w", pair[1], err)
+		}
+		levels[i] = model.OrderBookLevel{Price: price, Quantity: quantity}
+	}
+	return levels, nil
+}
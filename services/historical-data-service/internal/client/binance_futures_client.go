@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	BinanceFuturesAPIBaseURL = "https://fapi.binance.com"
+
+	// BinanceFuturesBudget names the rate-limit budget of the futures API in the config
+	BinanceFuturesBudget = "BINANCE_FUTURES"
+
+	// Most records one request returns
+	MaxFundingRatesLimit = 1000
+	MaxOpenInterestLimit = 500
+
+	// OpenInterestHistoryWindow is how far back Binance keeps open interest history
+	OpenInterestHistoryWindow = 30 * 24 * time.Hour
+
+	// The funding rate and open interest endpoints are limited per 5 minutes on their own
+	// rather than by weight; each request still counts one against the futures budget
+	binanceFuturesDataWeight = 1
+)
+
+// ErrNoFuturesContract is returned for symbols without a perpetual futures contract
+var ErrNoFuturesContract = errors.New("symbol has no perpetual futures contract on Binance")
+
+// BinanceFuturesClient fetches perpetual futures data, funding rates and open interest,
+// from the Binance USDⓈ-M futures API. Its requests spend the futures budget, which is
+// separate from the spot one.
+type BinanceFuturesClient struct {
+	api *BinanceClient
+}
+
+// NewBinanceFuturesClient creates a new Binance futures API client whose requests spend budget
+func NewBinanceFuturesClient(budget *RateBudget, logger *zap.Logger) *BinanceFuturesClient {
+	api := NewBinanceClient(budget, logger)
+	api.baseURL = BinanceFuturesAPIBaseURL
+	return &BinanceFuturesClient{api: api}
+}
+
+// get sends a GET request for path and decodes the JSON response into out. The stats of
+// the request are returned even when it fails.
+func (c *BinanceFuturesClient) get(ctx context.Context, path string, params url.Values, out interface{}) (RequestStats, error) {
+	var stats RequestStats
+	reqURL := fmt.Sprintf("%s%s?%s", c.api.baseURL, path, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.api.do(req, binanceFuturesDataWeight, &stats)
+	if err != nil {
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			return stats, err
+		}
+		c.api.logger.Error("Failed to fetch from Binance futures", zap.Error(err), zap.String("path", path))
+		return stats, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+
+		var apiErr struct {
+			Code int `json:"code"`
+		}
+		if json.Unmarshal(bodyBytes, &apiErr) == nil && apiErr.Code == binanceInvalidSymbolCode {
+			return stats, ErrNoFuturesContract
+		}
+
+		c.api.logger.Error("Binance futures API error response",
+			zap.Int("statusCode", resp.StatusCode),
+			zap.String("response", string(bodyBytes)))
+		return stats, fmt.Errorf("Binance futures API returned status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		c.api.logger.Error("Failed to decode Binance futures response", zap.Error(err), zap.String("path", path))
+		return stats, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return stats, nil
+}
+
+// GetFundingRates retrieves up to limit funding events of a symbol from startTime on,
+// oldest first
+func (c *BinanceFuturesClient) GetFundingRates(
+	ctx context.Context,
+	symbol string,
+	startTime, endTime time.Time,
+	limit int,
+) ([]model.FundingRate, RequestStats, error) {
+	if limit <= 0 || limit > MaxFundingRatesLimit {
+		limit = MaxFundingRatesLimit
+	}
+
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("startTime", strconv.FormatInt(startTime.UnixMilli(), 10))
+	params.Add("endTime", strconv.FormatInt(endTime.UnixMilli(), 10))
+	params.Add("limit", strconv.Itoa(limit))
+
+	var raw []struct {
+		FundingTime int64  `json:"fundingTime"`
+		FundingRate string `json:"fundingRate"`
+		MarkPrice   string `json:"markPrice"`
+	}
+	stats, err := c.get(ctx, "/fapi/v1/fundingRate", params, &raw)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	rates := make([]model.FundingRate, 0, len(raw))
+	for _, r := range raw {
+		rate, err := strconv.ParseFloat(r.FundingRate, 64)
+		if err != nil {
+			c.api.logger.Warn("Skipping malformed funding rate",
+				zap.String("symbol", symbol),
+				zap.String("fundingRate", r.FundingRate))
+			continue
+		}
+
+		fundingRate := model.FundingRate{
+			FundingTime: time.UnixMilli(r.FundingTime).UTC(),
+			FundingRate: rate,
+		}
+		// Older funding events have no mark price
+		if price, err := strconv.ParseFloat(r.MarkPrice, 64); err == nil && price > 0 {
+			fundingRate.MarkPrice = &price
+		}
+		rates = append(rates, fundingRate)
+	}
+
+	return rates, stats, nil
+}
+
+// GetOpenInterest retrieves up to limit open interest samples of a symbol per period from
+// startTime on, oldest first. Binance only keeps the last OpenInterestHistoryWindow.
+func (c *BinanceFuturesClient) GetOpenInterest(
+	ctx context.Context,
+	symbol, period string,
+	startTime, endTime time.Time,
+	limit int,
+) ([]model.OpenInterest, RequestStats, error) {
+	if limit <= 0 || limit > MaxOpenInterestLimit {
+		limit = MaxOpenInterestLimit
+	}
+
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("period", period)
+	params.Add("startTime", strconv.FormatInt(startTime.UnixMilli(), 10))
+	params.Add("endTime", strconv.FormatInt(endTime.UnixMilli(), 10))
+	params.Add("limit", strconv.Itoa(limit))
+
+	var raw []struct {
+		Timestamp            int64  `json:"timestamp"`
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+	}
+	stats, err := c.get(ctx, "/futures/data/openInterestHist", params, &raw)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	samples := make([]model.OpenInterest, 0, len(raw))
+	for _, r := range raw {
+		openInterest, err := strconv.ParseFloat(r.SumOpenInterest, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(r.SumOpenInterestValue, 64)
+		if err != nil {
+			continue
+		}
+
+		samples = append(samples, model.OpenInterest{
+			Period:            period,
+			SampleTime:        time.UnixMilli(r.Timestamp).UTC(),
+			OpenInterest:      openInterest,
+			OpenInterestValue: value,
+		})
+	}
+
+	return samples, stats, nil
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Event types published to the backtest event topic
+const (
+	EventBacktestCompleted = "backtest_completed"
+	EventBacktestFailed    = "backtest_failed"
+)
+
+// Event is the payload published to the backtest event topic.
+// The User Service builds activity feeds from it.
+type Event struct {
+	EventType  string `json:"event_type"`
+	UserID     int    `json:"user_id"`
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   int    `json:"entity_id,omitempty"`
+	EntityName string `json:"entity_name,omitempty"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// EventClient publishes domain events to a Kafka topic without blocking the caller
+type EventClient struct {
+	writer *kafka.Writer
+	logger *zap.Logger
+}
+
+// NewEventClient creates a new event client.
+// brokers is a comma-separated list; an empty list disables publishing.
+func NewEventClient(brokers string, topic string, logger *zap.Logger) *EventClient {
+	var addrs []string
+	for _, broker := range strings.Split(brokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			addrs = append(addrs, broker)
+		}
+	}
+
+	c := &EventClient{logger: logger}
+	if len(addrs) == 0 || topic == "" {
+		logger.Warn("Kafka not configured, events will not be published", zap.String("topic", topic))
+		return c
+	}
+
+	c.writer = &kafka.Writer{
+		Addr:         kafka.TCP(addrs...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Error("Failed to publish events",
+					zap.Error(err),
+					zap.String("topic", topic),
+					zap.Int("count", len(messages)))
+			}
+		},
+	}
+
+	return c
+}
+
+// Publish queues an event, keyed by user so each user's events stay ordered.
+// Events are best effort: failures are logged, never returned to the caller.
+func (c *EventClient) Publish(ctx context.Context, event Event) {
+	if event.Timestamp == "" {
+		event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if c.writer == nil {
+		c.logger.Debug("Event (not published)",
+			zap.String("eventType", event.EventType),
+			zap.Int("userID", event.UserID))
+		return
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		c.logger.Error("Failed to encode event", zap.Error(err), zap.String("eventType", event.EventType))
+		return
+	}
+
+	err = c.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.Itoa(event.UserID)),
+		Value: value,
+	})
+	if err != nil {
+		c.logger.Error("Failed to queue event", zap.Error(err), zap.String("eventType", event.EventType))
+	}
+}
+
+// Close flushes pending events and closes the Kafka writer
+func (c *EventClient) Close() error {
+	if c.writer == nil {
+		return nil
+	}
+	return c.writer.Close()
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// RateBudgetOptions configures the request weight a provider allows per window
+type RateBudgetOptions struct {
+	// Weight is how much request weight may be spent per window
+	Weight int
+	// Window is the length of the provider's limit window
+	Window time.Duration
+	// MinWeight is the lowest the budget shrinks to after the provider throttles requests
+	MinWeight int
+}
+
+// RateLimitError is returned when a provider rejected a request for exceeding its rate
+// limits. The provider's budget is already paused until RetryAfter has passed.
+type RateLimitError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded (status %d), retry after %s", e.Provider, e.StatusCode, e.RetryAfter)
+}
+
+// RateBudget spends a provider's request weight limit across every concurrent download.
+// Windows are aligned to the clock like the provider's own. When the provider throttles a
+// request, all requests pause until it allows them again and the budget halves (or drops
+// to its minimum after a ban), then grows back by a tenth of the configured weight for
+// every window without throttling. A nil budget doesn't limit requests.
+type RateBudget struct {
+	provider string
+	options  RateBudgetOptions
+
+	mu            sync.Mutex
+	weight        int // Weight currently allowed per window
+	windowStart   time.Time
+	used          int
+	pausedUntil   time.Time
+	lastThrottled time.Time
+
+	requests    int64
+	weightSpent int64
+	throttled   int64
+	banned      int64
+	waits       int64
+	waited      time.Duration
+}
+
+// NewRateBudget creates the request weight budget of a provider
+func NewRateBudget(provider string, options RateBudgetOptions) *RateBudget {
+	if options.Window <= 0 {
+		options.Window = time.Minute
+	}
+	if options.Weight < 1 {
+		options.Weight = 1
+	}
+	if options.MinWeight < 1 || options.MinWeight > options.Weight {
+		options.MinWeight = max(options.Weight/10, 1)
+	}
+
+	return &RateBudget{
+		provider: provider,
+		options:  options,
+		weight:   options.Weight,
+	}
+}
+
+// Wait blocks until weight can be spent in the current window, or ctx is done, and
+// spends it. It returns how long it waited.
+func (b *RateBudget) Wait(ctx context.Context, weight int) (time.Duration, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	started := time.Now()
+	slept := false
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.advance(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(b.pausedUntil):
+			wait = b.pausedUntil.Sub(now)
+		case b.used+weight <= b.weight || b.used == 0:
+			// A request heavier than the whole budget still goes out on its own
+			b.used += weight
+			b.requests++
+			b.weightSpent += int64(weight)
+			waited := time.Since(started)
+			if slept {
+				b.waits++
+				b.waited += waited
+			}
+			b.mu.Unlock()
+			return waited, nil
+		default:
+			wait = b.windowStart.Add(b.options.Window).Sub(now)
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(started), ctx.Err()
+		case <-timer.C:
+			slept = true
+		}
+	}
+}
+
+// Sync aligns the budget with the weight the provider reports having used in the current
+// window, which includes requests made outside this budget from the same address
+func (b *RateBudget) Sync(used int) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(time.Now())
+	if used > b.used {
+		b.used = used
+	}
+}
+
+// Throttle pauses every request for retryAfter and shrinks the budget after the provider
+// rejected a request. A ban (HTTP 418) drops the budget to its minimum.
+func (b *RateBudget) Throttle(statusCode int, retryAfter time.Duration) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.advance(now)
+
+	if statusCode == 418 {
+		b.banned++
+		b.weight = b.options.MinWeight
+	} else {
+		b.throttled++
+		b.weight = max(b.weight/2, b.options.MinWeight)
+	}
+
+	b.used = b.weight
+	b.lastThrottled = now
+	if until := now.Add(retryAfter); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// advance starts a new window once the current one has passed, growing the budget back
+// for every window without throttling. Callers hold b.mu.
+func (b *RateBudget) advance(now time.Time) {
+	windowStart := now.Truncate(b.options.Window)
+	if !windowStart.After(b.windowStart) {
+		return
+	}
+
+	if b.weight < b.options.Weight && !b.windowStart.IsZero() {
+		quietSince := b.windowStart
+		if b.lastThrottled.After(quietSince) {
+			quietSince = b.lastThrottled
+		}
+		windows := int(windowStart.Sub(quietSince) / b.options.Window)
+		step := max(b.options.Weight/10, 1)
+		b.weight = min(b.weight+windows*step, b.options.Weight)
+	}
+
+	b.windowStart = windowStart
+	b.used = 0
+}
+
+// Stats reports the budget's current window and its counters
+func (b *RateBudget) Stats() model.RateBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.advance(now)
+
+	stats := model.RateBudgetStats{
+		Provider:         b.provider,
+		ConfiguredWeight: b.options.Weight,
+		Weight:           b.weight,
+		WindowSeconds:    b.options.Window.Seconds(),
+		Used:             b.used,
+		Remaining:        max(b.weight-b.used, 0),
+		Requests:         b.requests,
+		WeightSpent:      b.weightSpent,
+		Throttled:        b.throttled,
+		Banned:           b.banned,
+		Waits:            b.waits,
+		WaitedSeconds:    b.waited.Seconds(),
+	}
+	if now.Before(b.pausedUntil) {
+		pausedUntil := b.pausedUntil
+		stats.PausedUntil = &pausedUntil
+	}
+
+	return stats
+}
+
+// RateBudgets holds the request weight budget of each provider, keyed by source name
+type RateBudgets map[string]*RateBudget
+
+// NewRateBudgets creates a budget for every configured provider. Config keys arrive
+// lower-cased; source names are upper case (BINANCE).
+func NewRateBudgets(options map[string]RateBudgetOptions) RateBudgets {
+	budgets := make(RateBudgets, len(options))
+	for source, opts := range options {
+		source = strings.ToUpper(source)
+		budgets[source] = NewRateBudget(source, opts)
+	}
+	return budgets
+}
+
+// For returns the budget of a source, or nil if its requests aren't limited
+func (b RateBudgets) For(source string) *RateBudget {
+	return b[source]
+}
+
+// Stats reports every budget, ordered by provider
+func (b RateBudgets) Stats() []model.RateBudgetStats {
+	stats := make([]model.RateBudgetStats, 0, len(b))
+	for _, budget := range b {
+		stats = append(stats, budget.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Provider < stats[j].Provider
+	})
+	return stats
+}
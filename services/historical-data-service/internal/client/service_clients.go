@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
+
+	"services/historical-data-service/internal/config"
+	"services/shared/httpclient"
 
 	"go.uber.org/zap"
 )
@@ -14,29 +16,30 @@ import (
 // StrategyClient handles communication with the Strategy Service
 type StrategyClient struct {
 	baseURL    string
-	httpClient *http.Client
+	serviceKey string
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
 // NewStrategyClient creates a new Strategy Service client
-func NewStrategyClient(baseURL string, logger *zap.Logger) *StrategyClient {
+func NewStrategyClient(cfg config.ServiceConfig, logger *zap.Logger) *StrategyClient {
 	return &StrategyClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("strategy service", cfg, logger),
+		logger:     logger,
 	}
 }
 
-// GetStrategy retrieves details of a strategy by ID
+// GetStrategy retrieves details of a strategy by ID, with its indicator presets resolved
+// into plain indicator settings
 func (c *StrategyClient) GetStrategy(ctx context.Context, strategyID int, token string) (*struct {
 	ID        int             `json:"id"`
 	Name      string          `json:"name"`
 	Version   int             `json:"version"`
 	Structure json.RawMessage `json:"structure"`
 }, error) {
-	url := fmt.Sprintf("%s/api/v1/strategies/%d", c.baseURL, strategyID)
+	url := fmt.Sprintf("%s/api/v1/strategies/%d?resolve_presets=true", c.baseURL, strategyID)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -48,7 +51,7 @@ func (c *StrategyClient) GetStrategy(ctx context.Context, strategyID int, token
 		req.Header.Set("Authorization", "Bearer "+token)
 	} else {
 		// Use service auth if no token provided
-		req.Header.Set("X-Service-Key", "historical-service-key")
+		req.Header.Set("X-Service-Key", c.serviceKey)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -63,7 +66,7 @@ func (c *StrategyClient) GetStrategy(ctx context.Context, strategyID int, token
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("strategy service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var strategy struct {
@@ -82,13 +85,14 @@ func (c *StrategyClient) GetStrategy(ctx context.Context, strategyID int, token
 	return &strategy, nil
 }
 
-// GetStrategyVersion retrieves a specific version of a strategy
+// GetStrategyVersion retrieves a specific version of a strategy, with its indicator presets
+// resolved into plain indicator settings
 func (c *StrategyClient) GetStrategyVersion(ctx context.Context, strategyID, version int, token string) (*struct {
 	ID        int             `json:"id"`
 	Version   int             `json:"version"`
 	Structure json.RawMessage `json:"structure"`
 }, error) {
-	url := fmt.Sprintf("%s/api/v1/strategies/%d/versions/%d", c.baseURL, strategyID, version)
+	url := fmt.Sprintf("%s/api/v1/strategies/%d/versions/%d?resolve_presets=true", c.baseURL, strategyID, version)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -100,7 +104,7 @@ func (c *StrategyClient) GetStrategyVersion(ctx context.Context, strategyID, ver
 		req.Header.Set("Authorization", "Bearer "+token)
 	} else {
 		// Use service auth if no token provided
-		req.Header.Set("X-Service-Key", "historical-service-key")
+		req.Header.Set("X-Service-Key", c.serviceKey)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -117,7 +121,7 @@ func (c *StrategyClient) GetStrategyVersion(ctx context.Context, strategyID, ver
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("strategy service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var strategyVersion struct {
@@ -162,7 +166,7 @@ func (c *StrategyClient) NotifyBacktestComplete(ctx context.Context, backtestID,
 	}
 
 	// Use service auth for service-to-service calls
-	req.Header.Set("X-Service-Key", "historical-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
@@ -173,7 +177,7 @@ func (c *StrategyClient) NotifyBacktestComplete(ctx context.Context, backtestID,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("strategy service notification failed with status code %d", resp.StatusCode)
+		return c.httpClient.StatusError(resp)
 	}
 
 	return nil
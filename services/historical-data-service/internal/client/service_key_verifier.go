@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// issuedServiceKeyPrefix starts every key issued by the User Service
+const issuedServiceKeyPrefix = "svc_"
+
+// verifiedServiceKey is a cached verification of a valid key
+type verifiedServiceKey struct {
+	serviceName string
+	expiresAt   time.Time
+}
+
+// ServiceKeyVerifier verifies keys the User Service issued to other services, caching valid
+// keys so calls aren't verified one by one. A revoked key keeps working until its cached
+// verification expires. Invalid keys aren't cached, so unknown keys can't grow the cache.
+type ServiceKeyVerifier struct {
+	userClient *UserClient
+	cacheTTL   time.Duration
+	logger     *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]verifiedServiceKey // By the SHA-256 of the key
+}
+
+// NewServiceKeyVerifier creates a new service key verifier
+func NewServiceKeyVerifier(userClient *UserClient, cacheTTL time.Duration, logger *zap.Logger) *ServiceKeyVerifier {
+	return &ServiceKeyVerifier{
+		userClient: userClient,
+		cacheTTL:   cacheTTL,
+		logger:     logger,
+		cache:      make(map[string]verifiedServiceKey),
+	}
+}
+
+// VerifyServiceKey returns the service a key was issued to, or an empty name if the key
+// isn't valid
+func (v *ServiceKeyVerifier) VerifyServiceKey(ctx context.Context, key string) (string, error) {
+	if !strings.HasPrefix(key, issuedServiceKeyPrefix) {
+		return "", nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	v.mu.Lock()
+	entry, ok := v.cache[hash]
+	v.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.serviceName, nil
+	}
+
+	serviceName, valid, err := v.userClient.VerifyServiceKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !valid {
+		return "", nil
+	}
+
+	if v.cacheTTL > 0 {
+		v.mu.Lock()
+		v.cache[hash] = verifiedServiceKey{serviceName: serviceName, expiresAt: now.Add(v.cacheTTL)}
+		v.mu.Unlock()
+	}
+
+	return serviceName, nil
+}
@@ -1,14 +1,16 @@
 package client
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
-	"time"
+
+	"services/historical-data-service/internal/config"
+	"services/shared/httpclient"
+	"services/shared/savedview"
 
 	"go.uber.org/zap"
 )
@@ -16,18 +18,18 @@ import (
 // UserClient handles communication with the User Service
 type UserClient struct {
 	baseURL    string
-	httpClient *http.Client
+	serviceKey string
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
 // NewUserClient creates a new User Service client
-func NewUserClient(baseURL string, logger *zap.Logger) *UserClient {
+func NewUserClient(cfg config.ServiceConfig, logger *zap.Logger) *UserClient {
 	return &UserClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		logger: logger,
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("user service", cfg, logger),
+		logger:     logger,
 	}
 }
 
@@ -42,7 +44,7 @@ func (c *UserClient) ValidateToken(ctx context.Context, token string) (int, stri
 
 	// Add the token to be validated
 	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("X-Service-Key", "historical-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -61,7 +63,7 @@ func (c *UserClient) ValidateToken(ctx context.Context, token string) (int, stri
 		c.logger.Error("User service returned unexpected status",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", string(bodyBytes)))
-		return 0, "", fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return 0, "", c.httpClient.StatusError(resp)
 	}
 
 	var response struct {
@@ -136,7 +138,7 @@ func (c *UserClient) GetUsername(ctx context.Context, userID int) (string, error
 	}
 
 	// Add service authentication header
-	req.Header.Set("X-Service-Key", "historical-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -146,7 +148,7 @@ func (c *UserClient) GetUsername(ctx context.Context, userID int) (string, error
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return "", c.httpClient.StatusError(resp)
 	}
 
 	var user struct {
@@ -163,48 +165,6 @@ func (c *UserClient) GetUsername(ctx context.Context, userID int) (string, error
 	return user.Username, nil
 }
 
-// ExtractUserIDFromToken extracts the user ID from a JWT token
-func ExtractUserIDFromToken(token string) (int, error) {
-	// Split the token into its parts (header.payload.signature)
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return 0, fmt.Errorf("invalid token format")
-	}
-
-	// Decode the payload (the middle part)
-	// Add padding if needed
-	payload := parts[1]
-	if len(payload)%4 != 0 {
-		payload += strings.Repeat("=", 4-len(payload)%4)
-	}
-
-	decodedPayload, err := base64.URLEncoding.DecodeString(payload)
-	if err != nil {
-		// Try with RawURLEncoding if standard URLEncoding fails
-		decodedPayload, err = base64.RawURLEncoding.DecodeString(parts[1])
-		if err != nil {
-			return 0, fmt.Errorf("failed to decode payload: %w", err)
-		}
-	}
-
-	// Parse the JSON payload
-	var claims struct {
-		Sub  int    `json:"sub"`
-		Type string `json:"type"`
-	}
-
-	if err := json.Unmarshal(decodedPayload, &claims); err != nil {
-		return 0, fmt.Errorf("failed to parse token payload: %w", err)
-	}
-
-	// Check if this is an access token
-	if claims.Type != "access" && claims.Type != "" {
-		return 0, fmt.Errorf("not an access token")
-	}
-
-	return claims.Sub, nil
-}
-
 // GetUserDetails gets user details by ID
 func (c *UserClient) GetUserDetails(ctx context.Context, userID int) (*struct {
 	ID              int    `json:"id"`
@@ -219,7 +179,7 @@ func (c *UserClient) GetUserDetails(ctx context.Context, userID int) (*struct {
 	}
 
 	// Add service authentication header
-	req.Header.Set("X-Service-Key", "historical-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -229,7 +189,7 @@ func (c *UserClient) GetUserDetails(ctx context.Context, userID int) (*struct {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var user struct {
@@ -270,7 +230,7 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 	}
 
 	// Add service authentication header
-	req.Header.Set("X-Service-Key", "historical-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -280,7 +240,7 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var response struct {
@@ -309,3 +269,82 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 
 	return result, nil
 }
+
+// VerifyServiceKey asks the User Service which service an issued key belongs to. ok is
+// false for unknown, expired and revoked keys.
+func (c *UserClient) VerifyServiceKey(ctx context.Context, key string) (serviceName string, ok bool, err error) {
+	url := fmt.Sprintf("%s/api/v1/service/credentials/verify", c.baseURL)
+
+	payload, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return "", false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to verify service key with User Service", zap.Error(err))
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Valid bool `json:"valid"`
+		Data  struct {
+			ServiceName string `json:"service_name"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", false, err
+	}
+
+	return response.Data.ServiceName, response.Valid, nil
+}
+
+// GetSavedView retrieves a saved list view of a user. It returns nil if the user has no
+// such view.
+func (c *UserClient) GetSavedView(ctx context.Context, userID, viewID int) (*savedview.View, error) {
+	url := fmt.Sprintf("%s/api/v1/service/users/%d/views/%d", c.baseURL, userID, viewID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add service authentication header
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to get saved view from User Service", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data savedview.View `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		c.logger.Error("Failed to decode saved view response", zap.Error(err))
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
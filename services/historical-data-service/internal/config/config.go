@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -13,8 +14,21 @@ type Config struct {
 	Database        DatabaseConfig
 	UserService     ServiceConfig
 	StrategyService ServiceConfig
+	Auth            AuthConfig
+	Redis           RedisConfig
 	Kafka           KafkaConfig
 	ServiceKey      string
+	ServiceAuth     ServiceAuthConfig
+	InternalTLS     InternalTLSConfig
+	Metrics         MetricsConfig
+	Downloads       DownloadsConfig
+	Backtests       BacktestsConfig
+	Quotas          QuotasConfig
+	Stats           StatsConfig
+	CandleCache     CandleCacheConfig
+	Ticker          TickerConfig
+	OrderBook       OrderBookConfig
+	Idempotency     IdempotencyConfig
 	Logging         LoggingConfig
 }
 
@@ -24,6 +38,8 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// DrainTimeout bounds how long shutdown waits for background jobs to persist their state
+	DrainTimeout time.Duration
 }
 
 // DatabaseConfig holds database specific configuration
@@ -37,13 +53,83 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	Replica         ReplicaConfig
+	Pool            PoolConfig
+	Queries         QueryConfig
+	TimescaleDB     TimescaleDBConfig
+}
+
+// TimescaleDBConfig holds configuration for storing candles in a TimescaleDB hypertable.
+// Disabled, candles are kept in a plain PostgreSQL table. The settings are applied when
+// migrations run.
+type TimescaleDBConfig struct {
+	Enabled       bool
+	ChunkInterval time.Duration // Span of candle times stored in one chunk
+	CompressAfter time.Duration // Age at which chunks are compressed; 0 disables compression
+}
+
+// ReplicaConfig holds configuration for an optional read replica. Heavy reads are sent
+// to the replica while its health checks pass and fall back to the primary otherwise.
+type ReplicaConfig struct {
+	DSN                 string // Replica connection string; empty disables the replica
+	HealthCheckInterval time.Duration
+	MaxLag              time.Duration // Replication lag above which the replica is considered unhealthy
+	RecoverAfter        int           // Consecutive passing checks before reads fail back to the replica
+}
+
+// PoolConfig holds configuration for monitoring the database connection pools. When
+// adaptive, a pool grows past maxOpenConns while queries wait for connections and shrinks
+// back once they no longer do.
+type PoolConfig struct {
+	SampleInterval    time.Duration
+	Adaptive          bool
+	MaxOpenConnsLimit int           // Most open connections a pool grows to
+	TargetWait        time.Duration // Average wait for a connection above which a pool grows
+}
+
+// QueryConfig holds the deadlines of repository queries by class and the duration above
+// which they are logged as slow. A zero duration disables that deadline or the logging.
+type QueryConfig struct {
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	BulkTimeout   time.Duration // Imports, trade batches, aggregate refreshes and pruning
+	SlowThreshold time.Duration
 }
 
 // ServiceConfig holds configuration for external services
 type ServiceConfig struct {
-	URL        string
-	Timeout    time.Duration
-	ServiceKey string
+	URL          string
+	Timeout      time.Duration // Limit of a single attempt of a call
+	ServiceKey   string
+	MaxRetries   int           // Retries of failed idempotent calls
+	RetryBackoff time.Duration // Longest wait before the first retry, doubled for each further retry
+	MaxConns     int           // Connections open to the service at once; 0 means no limit
+	// TLS is built from InternalTLS when it is enabled
+	TLS *tls.Config `mapstructure:"-"`
+}
+
+// ServiceAuthConfig holds settings of authenticating calls from other services
+type ServiceAuthConfig struct {
+	// VerifyIssuedKeys accepts keys issued by the user service next to ServiceKey,
+	// verifying them with the user service
+	VerifyIssuedKeys bool
+	CacheTTL         time.Duration // How long a verified key is trusted before it is checked again
+}
+
+// AuthConfig holds configuration for verifying access tokens locally
+type AuthConfig struct {
+	JWKSURL    string        // Public keys of RS256 access tokens, served by the user service
+	JWTSecret  string        // Shared secret of HS256 access tokens; empty rejects them
+	KeysMaxAge time.Duration // How long fetched keys are used before refetching
+}
+
+// RedisConfig holds configuration for the Redis the user service broadcasts token
+// revocations on. Without it revoked tokens stay valid until they expire.
+type RedisConfig struct {
+	Enabled  bool
+	URL      string
+	Password string
+	DB       int
 }
 
 // KafkaConfig holds Kafka specific configuration
@@ -52,6 +138,104 @@ type KafkaConfig struct {
 	Topics  map[string]string
 }
 
+// MetricsConfig holds configuration for the daily metrics aggregation job
+type MetricsConfig struct {
+	AggregationEnabled bool
+	AggregationHour    int
+	MaxCatchUpDays     int
+}
+
+// DownloadsConfig holds configuration for the market data download worker pool
+type DownloadsConfig struct {
+	Workers                       int
+	MaxConcurrentPerSource        map[string]int
+	DefaultMaxConcurrentPerSource int
+	PollInterval                  time.Duration
+	MaxAttempts                   int
+	RetryBackoff                  time.Duration
+	StaleAfter                    time.Duration
+	// RateLimits holds the request weight budget of each provider, shared by its downloads
+	RateLimits map[string]RateLimitConfig
+}
+
+// RateLimitConfig holds a provider's request weight limit
+type RateLimitConfig struct {
+	Weight int
+	Window time.Duration
+	// MinWeight is the lowest the budget shrinks to after the provider throttles requests
+	MinWeight int
+}
+
+// BacktestsConfig holds configuration for running backtests
+type BacktestsConfig struct {
+	// SymbolWorkers is how many symbols of one backtest run at once
+	SymbolWorkers int
+	// ResumeInterval is how often backtests queued by a shutdown are looked for
+	ResumeInterval time.Duration
+	// EngineProtocol is v1, holding a request open per run, or v2, submitting runs that
+	// the engine reports on by callback
+	EngineProtocol string
+	// CallbackURL is where the engine reports on runs submitted with the v2 protocol
+	CallbackURL string
+	// EngineLease is how long a v2 run may go without a report before it fails
+	EngineLease time.Duration
+	// LeaseCheckInterval is how often expired engine leases are looked for
+	LeaseCheckInterval time.Duration
+}
+
+// QuotasConfig holds per-user usage limits, grouped into tiers
+type QuotasConfig struct {
+	Enabled bool
+	// DefaultTier applies to users whose role matches no tier
+	DefaultTier string
+	Tiers       map[string]QuotaTierConfig
+}
+
+// QuotaTierConfig holds the limits of one quota tier. A zero limit means unlimited.
+// A tier applies to users whose role has its name, or who hold its Permission.
+type QuotaTierConfig struct {
+	Permission             string
+	MaxConcurrentBacktests int
+	MaxBacktestsPerDay     int
+	MaxDownloadJobsPerDay  int
+	MaxStoredCandles       int64
+}
+
+// StatsConfig holds configuration for admin dashboard statistics
+type StatsConfig struct {
+	CacheTTL time.Duration
+}
+
+// CandleCacheConfig holds configuration for the in-process cache of candle reads
+type CandleCacheConfig struct {
+	MaxCandles int           // Candles held across all cached results; 0 disables the cache
+	TTL        time.Duration // How long a cached result is served
+}
+
+// TickerConfig holds configuration for the ticker endpoint
+type TickerConfig struct {
+	CacheTTL   time.Duration // How long REST tickers are served before being fetched again
+	MaxSymbols int           // Symbols one request may ask for, at most 100
+}
+
+// OrderBookConfig holds configuration for order book snapshot capture
+type OrderBookConfig struct {
+	Enabled       bool
+	Symbols       []string      // Binance tickers whose order books are captured
+	Depth         int           // Levels per side: 5, 10 or 20
+	Interval      time.Duration // How often a snapshot of each book is stored
+	Retention     time.Duration // How long snapshots are kept; 0 keeps them forever
+	PruneInterval time.Duration // How often snapshots past the retention are deleted
+}
+
+// IdempotencyConfig holds configuration for Idempotency-Key handling
+type IdempotencyConfig struct {
+	// TTL is how long a key and its stored response are kept
+	TTL time.Duration
+	// LockTimeout is how long a request holds its key before a retry may run it again
+	LockTimeout time.Duration
+}
+
 // LoggingConfig holds logging specific configuration
 type LoggingConfig struct {
 	Level  string
@@ -79,6 +263,13 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	tlsConfig, err := cfg.InternalTLS.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal TLS config: %w", err)
+	}
+	cfg.UserService.TLS = tlsConfig
+	cfg.StrategyService.TLS = tlsConfig
+
 	return &cfg, nil
 }
 
@@ -89,27 +280,111 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.readTimeout", "10s")
 	v.SetDefault("server.writeTimeout", "10s")
 	v.SetDefault("server.idleTimeout", "120s")
+	v.SetDefault("server.drainTimeout", "30s")
 
 	// Database defaults
 	v.SetDefault("database.sslmode", "disable")
 	v.SetDefault("database.maxOpenConns", 25)
 	v.SetDefault("database.maxIdleConns", 5)
 	v.SetDefault("database.connMaxLifetime", "30m")
+	v.SetDefault("database.replica.healthCheckInterval", "5s")
+	v.SetDefault("database.replica.maxLag", "30s")
+	v.SetDefault("database.replica.recoverAfter", 3)
+	v.SetDefault("database.pool.sampleInterval", "10s")
+	v.SetDefault("database.pool.adaptive", false)
+	v.SetDefault("database.pool.maxOpenConnsLimit", 50)
+	v.SetDefault("database.pool.targetWait", "5ms")
+	v.SetDefault("database.queries.readTimeout", "30s")
+	v.SetDefault("database.queries.writeTimeout", "30s")
+	v.SetDefault("database.queries.bulkTimeout", "10m")
+	v.SetDefault("database.queries.slowThreshold", "1s")
+	v.SetDefault("database.timescaleDB.enabled", true)
+	v.SetDefault("database.timescaleDB.chunkInterval", "168h")
+	v.SetDefault("database.timescaleDB.compressAfter", "720h")
 
 	// User Service defaults
 	v.SetDefault("userService.timeout", "5s")
+	v.SetDefault("userService.maxRetries", 2)
+	v.SetDefault("userService.retryBackoff", "100ms")
 	v.SetDefault("userService.serviceKey", "historical-service-key")
 
 	// Strategy Service defaults
 	v.SetDefault("strategyService.timeout", "30s")
+	v.SetDefault("strategyService.maxRetries", 2)
+	v.SetDefault("strategyService.retryBackoff", "100ms")
 	v.SetDefault("strategyService.serviceKey", "historical-service-key")
 
+	// Auth defaults
+	v.SetDefault("auth.jwksURL", "http://user-service:8083/.well-known/jwks.json")
+	v.SetDefault("auth.keysMaxAge", "1h")
+
+	// Redis defaults
+	v.SetDefault("redis.enabled", false)
+	v.SetDefault("redis.db", 0)
+
 	// Kafka topic defaults
 	v.SetDefault("kafka.topics.backtestEvents", "backtest-events")
 	v.SetDefault("kafka.topics.backtestCompletions", "backtest-completions")
 
 	// Service key for authentication
 	v.SetDefault("serviceKey", "historical-service-key")
+	v.SetDefault("serviceAuth.verifyIssuedKeys", true)
+	v.SetDefault("serviceAuth.cacheTTL", "1m")
+	v.SetDefault("internalTLS.enabled", false)
+
+	// Metrics aggregation defaults
+	v.SetDefault("metrics.aggregationEnabled", true)
+	v.SetDefault("metrics.aggregationHour", 2)
+	v.SetDefault("metrics.maxCatchUpDays", 7)
+
+	// Download worker pool defaults
+	v.SetDefault("downloads.workers", 4)
+	v.SetDefault("downloads.defaultMaxConcurrentPerSource", 2)
+	v.SetDefault("downloads.pollInterval", "5s")
+	v.SetDefault("downloads.maxAttempts", 5)
+	v.SetDefault("downloads.retryBackoff", "1m")
+	v.SetDefault("downloads.staleAfter", "10m")
+	v.SetDefault("downloads.rateLimits.binance.weight", 4800)
+	v.SetDefault("downloads.rateLimits.binance.window", "1m")
+	v.SetDefault("downloads.rateLimits.binance.minWeight", 600)
+	v.SetDefault("downloads.rateLimits.binance_futures.weight", 1800)
+	v.SetDefault("downloads.rateLimits.binance_futures.window", "1m")
+	v.SetDefault("downloads.rateLimits.binance_futures.minWeight", 200)
+
+	// Backtest defaults
+	v.SetDefault("backtests.symbolWorkers", 4)
+	v.SetDefault("backtests.resumeInterval", "30s")
+	v.SetDefault("backtests.engineProtocol", "v1")
+	v.SetDefault("backtests.callbackURL", "http://historical-service:8081/api/v1/service/backtests/engine-callback")
+	v.SetDefault("backtests.engineLease", "2m")
+	v.SetDefault("backtests.leaseCheckInterval", "30s")
+
+	// Quota defaults
+	v.SetDefault("quotas.enabled", true)
+	v.SetDefault("quotas.defaultTier", "free")
+
+	// Stats defaults
+	v.SetDefault("stats.cacheTTL", "1m")
+
+	// Candle cache defaults
+	v.SetDefault("candleCache.maxCandles", 500000)
+	v.SetDefault("candleCache.ttl", "10m")
+
+	// Ticker defaults
+	v.SetDefault("ticker.cacheTTL", "5s")
+	v.SetDefault("ticker.maxSymbols", 50)
+
+	// Order book capture defaults
+	v.SetDefault("orderBook.enabled", false)
+	v.SetDefault("orderBook.symbols", []string{})
+	v.SetDefault("orderBook.depth", 20)
+	v.SetDefault("orderBook.interval", "1m")
+	v.SetDefault("orderBook.retention", "720h")
+	v.SetDefault("orderBook.pruneInterval", "1h")
+
+	// Idempotency defaults
+	v.SetDefault("idempotency.ttl", "24h")
+	v.SetDefault("idempotency.lockTimeout", "1m")
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
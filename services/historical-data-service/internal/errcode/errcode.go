@@ -0,0 +1,103 @@
+// Package errcode defines the error codes and errors of the historical data service, and
+// the rules its untyped errors are mapped to codes with
+package errcode
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+)
+
+// Codes of the historical data service
+const (
+	CodeInvalidToken           apierror.Code = "INVALID_TOKEN"
+	CodeTokenRevoked           apierror.Code = "TOKEN_REVOKED"
+	CodeImpersonationEnded     apierror.Code = "IMPERSONATION_ENDED"
+	CodeQuotaExceeded          apierror.Code = "QUOTA_EXCEEDED"
+	CodeSymbolNotFound         apierror.Code = "SYMBOL_NOT_FOUND"
+	CodeInvalidSymbol          apierror.Code = "INVALID_SYMBOL"
+	CodeTimeframeNotFound      apierror.Code = "TIMEFRAME_NOT_FOUND"
+	CodeTimeframeAlreadyExists apierror.Code = "TIMEFRAME_ALREADY_EXISTS"
+	CodeInvalidTimeframe       apierror.Code = "INVALID_TIMEFRAME"
+	CodeCalendarNotFound       apierror.Code = "CALENDAR_NOT_FOUND"
+	CodeInvalidDateRange       apierror.Code = "INVALID_DATE_RANGE"
+	CodeNoMarketData           apierror.Code = "NO_MARKET_DATA"
+	CodeBacktestNotFound       apierror.Code = "BACKTEST_NOT_FOUND"
+	CodeBacktestRunNotFound    apierror.Code = "BACKTEST_RUN_NOT_FOUND"
+	CodeBacktestTradeNotFound  apierror.Code = "BACKTEST_TRADE_NOT_FOUND"
+	CodeBacktestNotRetryable   apierror.Code = "BACKTEST_NOT_RETRYABLE"
+	CodeRetryLimitReached      apierror.Code = "RETRY_LIMIT_REACHED"
+	CodeEngineJobNotActive     apierror.Code = "ENGINE_JOB_NOT_ACTIVE"
+	CodeDataManifestNotFound   apierror.Code = "DATA_MANIFEST_NOT_FOUND"
+	CodeBacktestDataChanged    apierror.Code = "BACKTEST_DATA_CHANGED"
+	CodeInvalidBacktestSetting apierror.Code = "INVALID_BACKTEST_SETTINGS"
+	CodeWatchlistNotFound      apierror.Code = "WATCHLIST_NOT_FOUND"
+	CodeTradeBatchTooLarge     apierror.Code = "TRADE_BATCH_TOO_LARGE"
+	CodeStrategyNotFound       apierror.Code = "STRATEGY_NOT_FOUND"
+	CodeUnsupportedDataSource  apierror.Code = "UNSUPPORTED_DATA_SOURCE"
+	CodeDownloadJobNotFound    apierror.Code = "DOWNLOAD_JOB_NOT_FOUND"
+	CodeJobNotFound            apierror.Code = "JOB_NOT_FOUND"
+	CodeJobNotCancellable      apierror.Code = "JOB_NOT_CANCELLABLE"
+	CodeJobNotRequeueable      apierror.Code = "JOB_NOT_REQUEUEABLE"
+	CodeInvalidCursor          apierror.Code = "INVALID_CURSOR"
+)
+
+// Errors returned by the services of the historical data service
+var (
+	ErrSymbolNotFound       = apierror.New(http.StatusNotFound, CodeSymbolNotFound, "Symbol not found")
+	ErrInvalidSymbolID      = apierror.New(http.StatusBadRequest, CodeInvalidSymbol, "Invalid symbol ID")
+	ErrInvalidCursor        = apierror.New(http.StatusBadRequest, CodeInvalidCursor, "Invalid cursor")
+	ErrTimeframeNotFound    = apierror.New(http.StatusNotFound, CodeTimeframeNotFound, "Timeframe not found")
+	ErrCalendarNotFound     = apierror.New(http.StatusNotFound, CodeCalendarNotFound, "Trading calendar not found")
+	ErrBacktestNotFound     = apierror.New(http.StatusNotFound, CodeBacktestNotFound, "Backtest not found")
+	ErrBacktestRunNotFound  = apierror.New(http.StatusNotFound, CodeBacktestRunNotFound, "Backtest run not found")
+	ErrTradeNotFound        = apierror.New(http.StatusNotFound, CodeBacktestTradeNotFound, "Trade not found in this backtest run")
+	ErrStrategyNotFound     = apierror.New(http.StatusNotFound, CodeStrategyNotFound, "Strategy not found")
+	ErrTimeframeExists      = apierror.New(http.StatusConflict, CodeTimeframeAlreadyExists, "Timeframe already exists")
+	ErrTradeBatchTooLarge   = apierror.New(http.StatusRequestEntityTooLarge, CodeTradeBatchTooLarge, "Too many trades")
+	ErrBacktestNotRetryable = apierror.New(http.StatusConflict, CodeBacktestNotRetryable, "Only finished backtests with failed runs can be retried")
+	ErrRetryLimitReached    = apierror.New(http.StatusConflict, CodeRetryLimitReached, "The failed runs have no retries left")
+	ErrEngineJobNotActive   = apierror.New(http.StatusConflict, CodeEngineJobNotActive, "The engine job is unknown or no longer running")
+	ErrDataManifestNotFound = apierror.New(http.StatusNotFound, CodeDataManifestNotFound, "No data manifest was recorded for this backtest")
+	ErrBacktestDataChanged  = apierror.New(http.StatusConflict, CodeBacktestDataChanged, "The market data of this pinned backtest changed since it was created")
+	ErrWatchlistNotFound    = apierror.New(http.StatusNotFound, CodeWatchlistNotFound, "Watchlist not found")
+	ErrInvalidJobKind       = apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid job kind; use download or backtest")
+	ErrAnnotationEmpty      = apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "A note or at least one label is required")
+	ErrJobNotFound          = apierror.New(http.StatusNotFound, CodeJobNotFound, "Job not found")
+	ErrJobNotCancellable    = apierror.New(http.StatusConflict, CodeJobNotCancellable, "Only pending, queued or running jobs can be cancelled")
+	ErrJobNotRequeueable    = apierror.New(http.StatusConflict, CodeJobNotRequeueable, "Only failed, partial or cancelled jobs can be requeued")
+)
+
+// MessageRules maps errors by their message, most specific first. They cover the
+// untyped validation errors of the services.
+var MessageRules = []apierror.MessageRule{
+	{Contains: "cursor", Status: http.StatusBadRequest, Code: CodeInvalidCursor},
+	{Contains: "batch too large", Status: http.StatusRequestEntityTooLarge, Code: CodeTradeBatchTooLarge},
+	{Contains: "invalid timeframe", Status: http.StatusBadRequest, Code: CodeInvalidTimeframe},
+	{Contains: "timeframe is required", Status: http.StatusBadRequest, Code: CodeInvalidTimeframe},
+	{Contains: "provider_interval", Status: http.StatusBadRequest, Code: CodeInvalidTimeframe},
+	{Contains: "invalid date range", Status: http.StatusBadRequest, Code: CodeInvalidDateRange},
+	{Contains: "no trading days", Status: http.StatusBadRequest, Code: CodeInvalidDateRange},
+	{Contains: "outside available data range", Status: http.StatusBadRequest, Code: CodeNoMarketData},
+	{Contains: "no market data available", Status: http.StatusBadRequest, Code: CodeNoMarketData},
+	{Contains: "no funding rate data available", Status: http.StatusBadRequest, Code: CodeNoMarketData},
+	{Contains: "no order book snapshots", Status: http.StatusBadRequest, Code: CodeNoMarketData},
+	{Contains: "unsupported data source", Status: http.StatusBadRequest, Code: CodeUnsupportedDataSource},
+	{Contains: "market_type", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "position_sizing", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "leverage", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "allow_short", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "commission_rate", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "slippage_rate", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "slippage_model", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "slippage settings", Status: http.StatusBadRequest, Code: CodeInvalidBacktestSetting},
+	{Contains: "backtest not found", Status: http.StatusNotFound, Code: CodeBacktestNotFound},
+	{Contains: "not found on binance", Status: http.StatusNotFound, Code: CodeSymbolNotFound},
+	{Contains: "symbol not found", Status: http.StatusNotFound, Code: CodeSymbolNotFound},
+	{Contains: "not found", Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Contains: "access denied", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "already exists", Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Contains: "in use", Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Contains: "invalid", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Contains: "required", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// WriteCSV writes the trades of a backtest run as a CSV file with a header row. CSV files
+// hold a single table, so the run summary is only included in Excel exports.
+func WriteCSV(w io.Writer, trades TradeSource) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(tradeHeader); err != nil {
+		return err
+	}
+
+	record := make([]string, len(tradeHeader))
+	err := trades(func(batch []model.BacktestTrade) error {
+		for i := range batch {
+			for j, value := range tradeRow(&batch[i]) {
+				record[j] = csvValue(value)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		// Flush every batch so the export streams to the client as it's read
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue formats a cell of a CSV export. Times are written in RFC 3339 and empty cells as
+// empty strings.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
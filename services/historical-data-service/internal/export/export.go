@@ -0,0 +1,109 @@
+// Package export writes the trades of a backtest run as CSV or Excel files. Trades are
+// written as they are read, so exports of long backtests don't have to fit in memory.
+package export
+
+import (
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// Formats trades can be exported in
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes are the content types of the export formats
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// TradeSource calls yield with every trade of a backtest run in batches, in the order they
+// are exported, and returns the first error yield returns
+type TradeSource func(yield func([]model.BacktestTrade) error) error
+
+// tradeHeader names the columns of exported trades
+var tradeHeader = []string{
+	"ID", "Symbol", "Position", "Entry Time", "Entry Price", "Exit Time", "Exit Price",
+	"Quantity", "Profit/Loss", "Profit/Loss %", "Exit Reason",
+}
+
+// tradeRow returns the cells of an exported trade, with nil for values not set yet
+func tradeRow(trade *model.BacktestTrade) []interface{} {
+	return []interface{}{
+		trade.ID,
+		trade.Symbol,
+		trade.PositionType,
+		trade.EntryTime,
+		trade.EntryPrice,
+		timeValue(trade.ExitTime),
+		floatValue(trade.ExitPrice),
+		trade.Quantity,
+		floatValue(trade.ProfitLoss),
+		floatValue(trade.ProfitLossPercent),
+		stringValue(trade.ExitReason),
+	}
+}
+
+// summaryRows returns the metric and value rows of the summary of a backtest run. Metrics are
+// left empty until the run's results are saved.
+func summaryRows(summary *model.BacktestRunSummary) [][]interface{} {
+	rows := [][]interface{}{
+		{"Backtest Run ID", summary.BacktestRunID},
+		{"Backtest ID", summary.BacktestID},
+		{"Symbol", summary.Symbol},
+		{"Timeframe", summary.Timeframe},
+		{"Status", summary.Status},
+		{"Start Date", summary.StartDate},
+		{"End Date", summary.EndDate},
+		{"Initial Capital", summary.InitialCapital},
+	}
+
+	metrics := summary.Metrics
+	if metrics == nil {
+		metrics = &model.BacktestRunMetrics{}
+	}
+	var winRate interface{}
+	if metrics.TotalTrades > 0 {
+		winRate = float64(metrics.WinningTrades) / float64(metrics.TotalTrades) * 100
+	}
+
+	return append(rows,
+		[]interface{}{"Total Trades", metrics.TotalTrades},
+		[]interface{}{"Winning Trades", metrics.WinningTrades},
+		[]interface{}{"Losing Trades", metrics.LosingTrades},
+		[]interface{}{"Win Rate %", winRate},
+		[]interface{}{"Profit Factor", floatValue(metrics.ProfitFactor)},
+		[]interface{}{"Sharpe Ratio", floatValue(metrics.SharpeRatio)},
+		[]interface{}{"Max Drawdown %", floatValue(metrics.MaxDrawdown)},
+		[]interface{}{"Final Capital", floatValue(metrics.FinalCapital)},
+		[]interface{}{"Total Return %", floatValue(metrics.TotalReturn)},
+		[]interface{}{"Annualized Return %", floatValue(metrics.AnnualizedReturn)},
+	)
+}
+
+// floatValue returns the value of an optional number, or nil if it isn't set
+func floatValue(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// timeValue returns the value of an optional time, or nil if it isn't set
+func timeValue(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// stringValue returns the value of an optional string, or nil if it isn't set
+func stringValue(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
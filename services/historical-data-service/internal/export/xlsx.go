@@ -0,0 +1,191 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// Parts of an Excel workbook with a summary sheet and a trades sheet. Text is written as
+// inline strings, so the workbook needs no shared strings table.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Trades" sheetId="2" r:id="rId2"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+
+	// Cell styles: 0 is the default, 1 formats dates and 2 is the bold header
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`</cellXfs>` +
+		`</styleSheet>`
+
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+
+	xlsxStyleDate   = 1
+	xlsxStyleHeader = 2
+)
+
+// excelEpoch is the day Excel counts date serial numbers from
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// WriteXLSX writes a backtest run as an Excel workbook with a Summary sheet of the run and
+// its metrics and a Trades sheet with a row per trade
+func WriteXLSX(w io.Writer, summary *model.BacktestRunSummary, trades TradeSource) error {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sheet := newXLSXSheet(f)
+	sheet.writeRow([]interface{}{"Metric", "Value"}, xlsxStyleHeader)
+	for _, row := range summaryRows(summary) {
+		sheet.writeRow(row, 0)
+	}
+	if err := sheet.close(); err != nil {
+		return err
+	}
+
+	f, err = zw.Create("xl/worksheets/sheet2.xml")
+	if err != nil {
+		return err
+	}
+	sheet = newXLSXSheet(f)
+	header := make([]interface{}, len(tradeHeader))
+	for i, name := range tradeHeader {
+		header[i] = name
+	}
+	sheet.writeRow(header, xlsxStyleHeader)
+	err = trades(func(batch []model.BacktestTrade) error {
+		for i := range batch {
+			sheet.writeRow(tradeRow(&batch[i]), 0)
+		}
+		// Flush every batch so the export streams to the client as it's read
+		if err := sheet.w.Flush(); err != nil {
+			return err
+		}
+		return zw.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	if err := sheet.close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// xlsxSheet writes the rows of a worksheet. Write errors are kept by the buffered writer and
+// returned when it's flushed.
+type xlsxSheet struct {
+	w   *bufio.Writer
+	row int
+}
+
+func newXLSXSheet(w io.Writer) *xlsxSheet {
+	s := &xlsxSheet{w: bufio.NewWriter(w)}
+	s.w.WriteString(xlsxSheetStart)
+	return s
+}
+
+// writeRow writes a row of cells, styling text and numbers with the given style. Nil cells
+// are left empty.
+func (s *xlsxSheet) writeRow(cells []interface{}, style int) {
+	s.row++
+	fmt.Fprintf(s.w, `<row r="%d">`, s.row)
+	for i, value := range cells {
+		ref := columnName(i) + strconv.Itoa(s.row)
+		switch v := value.(type) {
+		case string:
+			fmt.Fprintf(s.w, `<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(s.w, []byte(v))
+			s.w.WriteString(`</t></is></c>`)
+		case int:
+			fmt.Fprintf(s.w, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			fmt.Fprintf(s.w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'g', -1, 64))
+		case time.Time:
+			serial := v.UTC().Sub(excelEpoch).Hours() / 24
+			fmt.Fprintf(s.w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(serial, 'f', -1, 64))
+		}
+	}
+	s.w.WriteString(`</row>`)
+}
+
+// close ends the worksheet and flushes it
+func (s *xlsxSheet) close() error {
+	s.w.WriteString(xlsxSheetEnd)
+	return s.w.Flush()
+}
+
+// columnName returns the letters of a zero-based column index, as in A, Z and AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -1,12 +1,21 @@
 package handler
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"services/shared/apierror"
+
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/service"
 	"services/historical-data-service/internal/utils"
+	"services/historical-data-service/internal/validation"
+	"services/shared/pagination"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -15,23 +24,39 @@ import (
 // BacktestHandler handles backtest HTTP requests
 type BacktestHandler struct {
 	backtestService *service.BacktestService
+	quotaService    *service.QuotaService
 	logger          *zap.Logger
 }
 
 // NewBacktestHandler creates a new backtest handler
-func NewBacktestHandler(backtestService *service.BacktestService, logger *zap.Logger) *BacktestHandler {
+func NewBacktestHandler(
+	backtestService *service.BacktestService,
+	quotaService *service.QuotaService,
+	logger *zap.Logger,
+) *BacktestHandler {
 	return &BacktestHandler{
 		backtestService: backtestService,
+		quotaService:    quotaService,
 		logger:          logger,
 	}
 }
 
 // CreateBacktest handles creating a new backtest
 // POST /api/v1/backtests
+//
+// @Summary Create a new backtest
+// @Tags backtests
+// @Accept json
+// @Produce json
+// @Param request body model.BacktestRequest true "Request body"
+// @Success 202 {object} object
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests [post]
 func (h *BacktestHandler) CreateBacktest(c *gin.Context) {
 	var request model.BacktestRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -46,30 +71,49 @@ func (h *BacktestHandler) CreateBacktest(c *gin.Context) {
 	tokenStr, _ := token.(string)
 
 	// Create backtest
-	backtestID, err := h.backtestService.CreateBacktest(
+	backtestID, warnings, err := h.backtestService.CreateBacktest(
 		c.Request.Context(),
 		&request,
 		userID.(int),
+		quotaTier(c, h.quotaService),
 		tokenStr,
 	)
 
+	if sendQuotaError(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to create backtest",
 			zap.Error(err),
 			zap.Int("userID", userID.(int)),
 			zap.Int("strategyID", request.StrategyID))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
+	response := gin.H{
 		"backtest_id": backtestID,
 		"message":     "Backtest created and queued for processing",
-	})
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusAccepted, response)
 }
 
 // GetBacktest handles retrieving a backtest by ID
 // GET /api/v1/backtests/:id
+//
+// @Summary Retrieve a backtest by ID
+// @Tags backtests
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.BacktestDetails
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests/{id} [get]
 func (h *BacktestHandler) GetBacktest(c *gin.Context) {
 	// Parse path parameter
 	idStr := c.Param("id")
@@ -93,26 +137,85 @@ func (h *BacktestHandler) GetBacktest(c *gin.Context) {
 			zap.Error(err),
 			zap.Int("id", id),
 			zap.Int("userID", userID.(int)))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.JSON(http.StatusOK, backtest)
 }
 
+// GetBacktestDataManifest handles retrieving the candles a backtest was created on
+// GET /api/v1/backtests/:id/data-manifest
+//
+// @Summary Retrieve the data manifest of a backtest and whether its data changed since
+// @Tags backtests
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.BacktestDataManifest}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests/{id}/data-manifest [get]
+func (h *BacktestHandler) GetBacktestDataManifest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	manifest, err := h.backtestService.GetBacktestDataManifest(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get backtest data manifest",
+				zap.Error(err),
+				zap.Int("id", id),
+				zap.Int("userID", userID.(int)))
+		}
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": manifest})
+}
+
 // ListBacktests handles listing backtests for a user with filtering, sorting, and pagination
 // GET /api/v1/backtests
+//
+// @Summary List backtests for a user with filtering, sorting, and pagination
+// @Tags backtests
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param status query string false "status"
+// @Param tags query string false "comma-separated tags the backtests must all have"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Param view query integer false "ID of a saved view whose filters and sort order apply"
+// @Success 200 {object} object{data=[]model.BacktestSummary,meta=pagination.Meta}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests [get]
 func (h *BacktestHandler) ListBacktests(c *gin.Context) {
 	// Parse query parameters for filtering
 	searchTerm := c.Query("search")
 	status := c.Query("status")
+	tags := c.Query("tags")
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
+	order := pagination.ParseSort(c, "created_at", "DESC")
 
-	// Parse pagination parameters using the utility function
-	params := utils.ParsePaginationParams(c, 10, 100) // default limit: 10, max limit: 100
+	// Parse pagination parameters
+	params := pagination.Parse(c, 10, 100) // default limit: 10, max limit: 100
 
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -127,8 +230,9 @@ func (h *BacktestHandler) ListBacktests(c *gin.Context) {
 		userID.(int),
 		searchTerm,
 		status,
-		sortBy,
-		sortDirection,
+		tags,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
@@ -137,16 +241,28 @@ func (h *BacktestHandler) ListBacktests(c *gin.Context) {
 		h.logger.Error("Failed to list backtests",
 			zap.Error(err),
 			zap.Int("userID", userID.(int)))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve backtests")
+		apierror.Respond(c, err, "Failed to retrieve backtests")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, backtests, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, backtests, total, params.Page, params.Limit)
 }
 
 // UpdateBacktestRunStatus handles updating the status of a backtest run
 // PUT /api/v1/backtest-runs/:id/status
+//
+// @Summary Update the status of a backtest run
+// @Tags backtest-runs
+// @Accept json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/status [put]
 func (h *BacktestHandler) UpdateBacktestRunStatus(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -159,8 +275,7 @@ func (h *BacktestHandler) UpdateBacktestRunStatus(c *gin.Context) {
 		Status string `json:"status" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -182,12 +297,12 @@ func (h *BacktestHandler) UpdateBacktestRunStatus(c *gin.Context) {
 		h.logger.Error("Failed to update backtest run status",
 			zap.Error(err),
 			zap.Int("run_id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update status")
+		apierror.Respond(c, err, "Failed to update status")
 		return
 	}
 
 	if !success {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Backtest run not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeBacktestRunNotFound, "Backtest run not found")
 		return
 	}
 
@@ -196,6 +311,18 @@ func (h *BacktestHandler) UpdateBacktestRunStatus(c *gin.Context) {
 
 // SaveBacktestResults handles saving results for a backtest run
 // POST /api/v1/backtest-runs/:id/results
+//
+// @Summary Save results for a backtest run
+// @Tags backtest-runs
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.BacktestResults true "Request body"
+// @Success 200 {object} object{result_id=integer,message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/results [post]
 func (h *BacktestHandler) SaveBacktestResults(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -205,8 +332,7 @@ func (h *BacktestHandler) SaveBacktestResults(c *gin.Context) {
 	}
 
 	var request model.BacktestResults
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -215,7 +341,7 @@ func (h *BacktestHandler) SaveBacktestResults(c *gin.Context) {
 		h.logger.Error("Failed to save backtest results",
 			zap.Error(err),
 			zap.Int("run_id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save results")
+		apierror.Respond(c, err, "Failed to save results")
 		return
 	}
 
@@ -227,6 +353,18 @@ func (h *BacktestHandler) SaveBacktestResults(c *gin.Context) {
 
 // AddBacktestTrade handles adding a trade to a backtest run
 // POST /api/v1/backtest-runs/:id/trades
+//
+// @Summary Add a trade to a backtest run
+// @Tags backtest-runs
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.BacktestTrade true "Request body"
+// @Success 201 {object} object{trade_id=integer,message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/trades [post]
 func (h *BacktestHandler) AddBacktestTrade(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -236,8 +374,7 @@ func (h *BacktestHandler) AddBacktestTrade(c *gin.Context) {
 	}
 
 	var request model.BacktestTrade
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -249,7 +386,7 @@ func (h *BacktestHandler) AddBacktestTrade(c *gin.Context) {
 		h.logger.Error("Failed to add backtest trade",
 			zap.Error(err),
 			zap.Int("run_id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to add trade")
+		apierror.Respond(c, err, "Failed to add trade")
 		return
 	}
 
@@ -259,8 +396,83 @@ func (h *BacktestHandler) AddBacktestTrade(c *gin.Context) {
 	})
 }
 
-// GetBacktestTrades handles retrieving trades for a backtest run with sorting and pagination
+// AddBacktestTrades handles adding a batch of trades to a backtest run.
+// Valid trades are stored and invalid ones are reported by their index in the batch.
+// POST /api/v1/backtest-runs/:id/trades/batch
+//
+// @Summary Add a batch of trades to a backtest run
+// @Tags backtest-runs
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body []model.BacktestTrade true "Trades"
+// @Success 201 {object} object{message=string,report=model.BacktestTradeBatchReport}
+// @Failure 400 {object} object{message=string,report=model.BacktestTradeBatchReport} "No trade of the batch was valid"
+// @Failure 404 {object} apierror.Body
+// @Failure 413 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/trades/batch [post]
+func (h *BacktestHandler) AddBacktestTrades(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest run ID")
+		return
+	}
+
+	// Bind without validation so that invalid trades are reported individually
+	var request []model.BacktestTrade
+	if err := json.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Request body must be an array of trades")
+		return
+	}
+
+	if len(request) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No trades provided")
+		return
+	}
+
+	report, err := h.backtestService.AddBacktestTrades(c.Request.Context(), id, request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to add backtest trades",
+				zap.Error(err),
+				zap.Int("run_id", id))
+		}
+		apierror.Respond(c, err, "Failed to add trades")
+		return
+	}
+
+	status := http.StatusCreated
+	if report.Inserted == 0 {
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, gin.H{
+		"message": fmt.Sprintf("Added %d of %d trades", report.Inserted, report.Received),
+		"report":  report,
+	})
+}
+
+// GetBacktestTrades handles retrieving trades for a backtest run with sorting and pagination.
+// Passing ?cursor= (empty for the first page) switches from page numbers to cursors.
 // GET /api/v1/backtest-runs/:id/trades
+//
+// @Summary Retrieve trades for a backtest run with sorting and pagination
+// @Tags backtest-runs
+// @Produce json
+// @Param id path integer true "ID"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Param cursor query string false "cursor"
+// @Success 200 {object} object{data=[]model.BacktestTrade,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/trades [get]
 func (h *BacktestHandler) GetBacktestTrades(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -270,35 +482,86 @@ func (h *BacktestHandler) GetBacktestTrades(c *gin.Context) {
 	}
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "entry_time")
-	sortDirection := c.DefaultQuery("sort_direction", "ASC")
+	order := pagination.ParseSort(c, "entry_time", "ASC")
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 100, 1000) // default limit: 100, max limit: 1000
+	params := pagination.Parse(c, 100, 1000) // default limit: 100, max limit: 1000
+
+	if cursor, ok := pagination.ParseCursor(c); ok {
+		h.getBacktestTradesAfter(c, id, order.By, order.Direction, cursor, params.Limit)
+		return
+	}
 
 	trades, total, err := h.backtestService.GetBacktestTrades(
 		c.Request.Context(),
 		id,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Limit,
-		utils.CalculateOffset(params.Page, params.Limit),
+		params.Offset(),
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get backtest trades",
 			zap.Error(err),
 			zap.Int("run_id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve trades")
+		apierror.Respond(c, err, "Failed to retrieve trades")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, trades, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, trades, total, params.Page, params.Limit)
+}
+
+// getBacktestTradesAfter responds with the page of trades that follows a cursor
+func (h *BacktestHandler) getBacktestTradesAfter(c *gin.Context, id int, sortBy, sortDirection, cursor string, limit int) {
+	var after *model.BacktestTradeCursor
+	if cursor != "" {
+		after = &model.BacktestTradeCursor{}
+		if err := pagination.DecodeCursor(cursor, after); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	trades, next, err := h.backtestService.GetBacktestTradesAfter(
+		c.Request.Context(),
+		id,
+		sortBy,
+		sortDirection,
+		after,
+		limit,
+	)
+
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get backtest trades",
+				zap.Error(err),
+				zap.Int("run_id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve trades")
+		return
+	}
+
+	nextCursor := ""
+	if next != nil {
+		nextCursor = pagination.EncodeCursor(next)
+	}
+
+	pagination.SendCursor(c, http.StatusOK, trades, nextCursor, limit)
 }
 
 // DeleteBacktest handles deleting a backtest
 // DELETE /api/v1/backtests/:id
+//
+// @Summary Delete a backtest
+// @Tags backtests
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests/{id} [delete]
 func (h *BacktestHandler) DeleteBacktest(c *gin.Context) {
 	// Parse path parameter
 	idStr := c.Param("id")
@@ -322,13 +585,74 @@ func (h *BacktestHandler) DeleteBacktest(c *gin.Context) {
 			zap.Error(err),
 			zap.Int("id", id),
 			zap.Int("userID", userID.(int)))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// RetryFailedRuns handles queuing the failed runs of a backtest again
+// POST /api/v1/backtests/:id/retry-failed
+//
+// @Summary Retry the failed runs of a backtest
+// @Tags backtests
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 202 {object} object{data=model.BacktestRetry,message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 429 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtests/{id}/retry-failed [post]
+func (h *BacktestHandler) RetryFailedRuns(c *gin.Context) {
+	// Parse path parameter
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest ID")
+		return
+	}
+
+	// Get user ID and token from context
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	token, _ := c.Get("token")
+	tokenStr, _ := token.(string)
+
+	retry, err := h.backtestService.RetryFailedRuns(
+		c.Request.Context(),
+		id,
+		userID.(int),
+		quotaTier(c, h.quotaService),
+		tokenStr,
+	)
+
+	if sendQuotaError(c, err) {
+		return
+	}
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to retry backtest runs",
+				zap.Error(err),
+				zap.Int("id", id),
+				zap.Int("userID", userID.(int)))
+		}
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"data":    retry,
+		"message": "Failed runs queued for processing",
+	})
+}
+
 // GetBacktestRuns handles retrieving all runs for a backtest with sorting and pagination
 // GET /api/v1/backtests/:id/runs
 func (h *BacktestHandler) GetBacktestRuns(c *gin.Context) {
@@ -341,11 +665,10 @@ func (h *BacktestHandler) GetBacktestRuns(c *gin.Context) {
 	}
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
+	order := pagination.ParseSort(c, "created_at", "DESC")
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -365,8 +688,8 @@ func (h *BacktestHandler) GetBacktestRuns(c *gin.Context) {
 	runs, total, err := h.backtestService.GetBacktestRuns(
 		c.Request.Context(),
 		id,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
@@ -375,17 +698,27 @@ func (h *BacktestHandler) GetBacktestRuns(c *gin.Context) {
 		h.logger.Error("Failed to get backtest runs",
 			zap.Error(err),
 			zap.Int("backtest_id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve backtest runs")
+		apierror.Respond(c, err, "Failed to retrieve backtest runs")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, runs, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, runs, total, params.Page, params.Limit)
 }
 
 // NotifyBacktestComplete handles notifications about completed backtests
 // This is used by background workers or other services
 // POST /api/v1/service/backtests/notify
+//
+// @Summary Notify about a completed backtest
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/backtests/notify [post]
 func (h *BacktestHandler) NotifyBacktestComplete(c *gin.Context) {
 	var request struct {
 		BacktestID int    `json:"backtest_id" binding:"required"`
@@ -394,8 +727,7 @@ func (h *BacktestHandler) NotifyBacktestComplete(c *gin.Context) {
 		Status     string `json:"status" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -411,6 +743,142 @@ func (h *BacktestHandler) NotifyBacktestComplete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Notification received"})
 }
 
+// EngineCallback handles the backtesting engine reporting progress or the outcome of a run
+// submitted with the async protocol
+// POST /api/v1/service/backtests/engine-callback
+//
+// @Summary Report progress or the outcome of a backtest run from the engine
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body model.EngineCallback true "Engine callback"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/backtests/engine-callback [post]
+func (h *BacktestHandler) EngineCallback(c *gin.Context) {
+	var callback model.EngineCallback
+	if !validation.BindJSON(c, &callback) {
+		return
+	}
+
+	if err := h.backtestService.HandleEngineCallback(c.Request.Context(), &callback); err != nil {
+		h.logger.Warn("Failed to apply engine callback",
+			zap.Error(err),
+			zap.Int("backtestRunID", callback.BacktestRunID),
+			zap.String("status", callback.Status))
+		apierror.Respond(c, err, "Failed to apply engine callback")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Callback applied"})
+}
+
+// GetServiceBacktest handles retrieving a backtest and its owner for other services
+// GET /api/v1/service/backtests/:id
+//
+// @Summary Retrieve a backtest and its owner for other services
+// @Tags service
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.BacktestDetails
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/backtests/{id} [get]
+func (h *BacktestHandler) GetServiceBacktest(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest ID")
+		return
+	}
+
+	backtest, err := h.backtestService.GetBacktestForService(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Send(c, http.StatusNotFound, errcode.CodeBacktestNotFound, "Backtest not found")
+			return
+		}
+		h.logger.Error("Failed to get backtest for service", zap.Error(err), zap.Int("id", id))
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get backtest")
+		return
+	}
+
+	c.JSON(http.StatusOK, backtest)
+}
+
+// GetServiceStrategyBacktests handles retrieving a user's backtests of the versions of a
+// strategy for other services
+// GET /api/v1/service/strategy-backtests
+//
+// @Summary Retrieve a user's backtests of the versions of a strategy for other services
+// @Tags service
+// @Produce json
+// @Param user_id query integer true "user id"
+// @Param strategy_ids query string true "strategy ids"
+// @Param version query integer false "version"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.StrategyBacktest,versions=[]model.StrategyVersionSummary,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/strategy-backtests [get]
+func (h *BacktestHandler) GetServiceStrategyBacktests(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var strategyIDs []int
+	for _, idStr := range strings.Split(c.Query("strategy_ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy IDs")
+			return
+		}
+		strategyIDs = append(strategyIDs, id)
+	}
+
+	var version *int
+	if versionStr := c.Query("version"); versionStr != "" {
+		v, err := strconv.Atoi(versionStr)
+		if err != nil || v < 1 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid version")
+			return
+		}
+		version = &v
+	}
+
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	backtests, versions, total, err := h.backtestService.GetStrategyBacktestHistory(
+		c.Request.Context(),
+		userID,
+		strategyIDs,
+		version,
+		params.Page,
+		params.Limit,
+	)
+	if err != nil {
+		h.logger.Error("Failed to get strategy backtest history",
+			zap.Error(err),
+			zap.Int("userID", userID),
+			zap.Ints("strategyIDs", strategyIDs))
+		apierror.Respond(c, err, "Failed to get strategy backtest history")
+		return
+	}
+
+	response := pagination.Envelope(backtests, total, params.Page, params.Limit)
+	response["versions"] = versions
+	c.JSON(http.StatusOK, response)
+}
+
 // GetBacktestServiceStatus checks if the backtesting service is healthy
 // GET /api/v1/backtests/service-status
 func (h *BacktestHandler) GetBacktestServiceStatus(c *gin.Context) {
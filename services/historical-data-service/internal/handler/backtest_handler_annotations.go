@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/utils"
+	"services/historical-data-service/internal/validation"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AnnotateBacktestTrade handles the owner of a backtest adding a note with labels to one of
+// its trades. The annotations are returned with the trades of the run.
+// POST /api/v1/backtest-runs/:id/trades/:tradeId/annotations
+//
+// @Summary Annotate a trade of a backtest run
+// @Tags backtest-runs
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param tradeId path integer true "trade ID"
+// @Param request body model.BacktestTradeAnnotationCreate true "Request body"
+// @Success 201 {object} object{data=model.BacktestTradeAnnotation}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/trades/{tradeId}/annotations [post]
+func (h *BacktestHandler) AnnotateBacktestTrade(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest run ID")
+		return
+	}
+
+	tradeID, err := strconv.Atoi(c.Param("tradeId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid trade ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.BacktestTradeAnnotationCreate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	annotation, err := h.backtestService.AnnotateBacktestTrade(c.Request.Context(), id, tradeID, userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to annotate backtest trade",
+				zap.Error(err),
+				zap.Int("run_id", id),
+				zap.Int("trade_id", tradeID))
+		}
+		apierror.Respond(c, err, "Failed to annotate trade")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": annotation})
+}
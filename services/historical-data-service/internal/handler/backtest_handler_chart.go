@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/chart"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// chartRenderer draws an equity curve chart in one image format
+type chartRenderer func(w io.Writer, curve *model.EquityCurve, opts chart.Options) error
+
+// GetBacktestRunChartPNG renders the equity curve and drawdown of a backtest run as a PNG image
+// GET /api/v1/backtest-runs/:id/chart.png
+//
+// @Summary Render the equity curve and drawdown of a backtest run as a PNG image
+// @Tags backtest-runs
+// @Param id path integer true "ID"
+// @Param width query integer false "width"
+// @Param height query integer false "height"
+// @Success 200 {file} binary
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/chart.png [get]
+func (h *BacktestHandler) GetBacktestRunChartPNG(c *gin.Context) {
+	h.renderBacktestRunChart(c, "image/png", chart.RenderPNG)
+}
+
+// GetBacktestRunChartSVG renders the equity curve and drawdown of a backtest run as an SVG image
+// GET /api/v1/backtest-runs/:id/chart.svg
+//
+// @Summary Render the equity curve and drawdown of a backtest run as an SVG image
+// @Tags backtest-runs
+// @Param id path integer true "ID"
+// @Param width query integer false "width"
+// @Param height query integer false "height"
+// @Success 200 {file} binary
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/chart.svg [get]
+func (h *BacktestHandler) GetBacktestRunChartSVG(c *gin.Context) {
+	h.renderBacktestRunChart(c, "image/svg+xml", chart.RenderSVG)
+}
+
+// renderBacktestRunChart renders a backtest run chart sized by the optional width and height
+// query parameters. Charts of finished runs can be cached since their results no longer change.
+func (h *BacktestHandler) renderBacktestRunChart(c *gin.Context, contentType string, render chartRenderer) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest run ID")
+		return
+	}
+
+	opts := chart.Options{Title: fmt.Sprintf("Backtest run #%d", id)}
+	if widthStr := c.Query("width"); widthStr != "" {
+		if opts.Width, err = strconv.Atoi(widthStr); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid width")
+			return
+		}
+	}
+	if heightStr := c.Query("height"); heightStr != "" {
+		if opts.Height, err = strconv.Atoi(heightStr); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid height")
+			return
+		}
+	}
+
+	curve, err := h.backtestService.GetBacktestRunEquityCurve(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get backtest run equity curve",
+				zap.Error(err),
+				zap.Int("run_id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve equity curve")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf, curve, opts); err != nil {
+		h.logger.Error("Failed to render backtest run chart",
+			zap.Error(err),
+			zap.Int("run_id", id))
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to render chart")
+		return
+	}
+
+	if curve.Status == "completed" || curve.Status == "failed" {
+		c.Header("Cache-Control", "private, max-age=3600")
+	} else {
+		c.Header("Cache-Control", "no-store")
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/export"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExportBacktestTrades downloads every trade of a backtest run as a CSV file or an Excel
+// workbook, which also has a summary sheet of the run's metrics. Trades are streamed as they
+// are read, without pagination.
+// GET /api/v1/backtest-runs/:id/trades/export
+//
+// @Summary Export the trades of a backtest run as CSV or Excel
+// @Tags backtest-runs
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path integer true "ID"
+// @Param format query string false "csv (default) or xlsx"
+// @Success 200 {file} binary
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/trades/export [get]
+func (h *BacktestHandler) ExportBacktestTrades(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest run ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatCSV)
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid format: must be csv or xlsx")
+		return
+	}
+
+	ctx := c.Request.Context()
+	summary, err := h.backtestService.GetBacktestRunSummary(ctx, id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get backtest run summary",
+				zap.Error(err),
+				zap.Int("run_id", id))
+		}
+		apierror.Respond(c, err, "Failed to export backtest trades")
+		return
+	}
+
+	trades := func(yield func([]model.BacktestTrade) error) error {
+		return h.backtestService.EachBacktestTradeBatch(ctx, id, yield)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="backtest-run-%d-trades.%s"`, id, format))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if format == export.FormatXLSX {
+		err = export.WriteXLSX(c.Writer, summary, trades)
+	} else {
+		err = export.WriteCSV(c.Writer, trades)
+	}
+	if err != nil {
+		// The response has started, so the download is cut short rather than answered
+		// with an error
+		h.logger.Error("Failed to export backtest trades",
+			zap.Error(err),
+			zap.Int("run_id", id),
+			zap.String("format", format))
+	}
+}
@@ -3,6 +3,9 @@ package handler
 import (
 	"net/http"
 
+	"services/historical-data-service/internal/validation"
+	"services/shared/apierror"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -14,7 +17,7 @@ func (h *BacktestHandler) GetIndicators(c *gin.Context) {
 	indicators, err := h.backtestService.GetIndicators(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get indicators", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve indicators"})
+		apierror.Respond(c, err, "Failed to retrieve indicators")
 		return
 	}
 
@@ -28,8 +31,7 @@ func (h *BacktestHandler) ValidateStrategy(c *gin.Context) {
 		Strategy map[string]interface{} `json:"strategy" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -37,7 +39,7 @@ func (h *BacktestHandler) ValidateStrategy(c *gin.Context) {
 	valid, message, err := h.backtestService.ValidateStrategy(c.Request.Context(), request.Strategy)
 	if err != nil {
 		h.logger.Error("Failed to validate strategy", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate strategy"})
+		apierror.Respond(c, err, "Failed to validate strategy")
 		return
 	}
 
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/validation"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PreviewSignals handles evaluating a strategy structure over the latest candles of a
+// symbol for other services, returning its entry and exit signals without a backtest
+// POST /api/v1/service/signals/preview
+//
+// @Summary Preview the signals of a strategy structure for other services
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body model.SignalPreviewRequest true "Request body"
+// @Success 200 {object} object{data=model.SignalPreview}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/signals/preview [post]
+func (h *BacktestHandler) PreviewSignals(c *gin.Context) {
+	var request model.SignalPreviewRequest
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	preview, err := h.backtestService.PreviewSignals(c.Request.Context(), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to preview signals",
+				zap.Error(err),
+				zap.Int("symbolID", request.SymbolID),
+				zap.String("timeframe", request.Timeframe))
+		}
+		apierror.Respond(c, err, "Failed to preview signals")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preview})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CalendarHandler handles exchange trading calendar HTTP requests
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+	logger          *zap.Logger
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(calendarService *service.CalendarService, logger *zap.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		logger:          logger,
+	}
+}
+
+// GetCalendars handles retrieving all exchange calendars
+// GET /api/v1/calendars
+//
+// @Summary Retrieve all exchange calendars
+// @Tags calendars
+// @Produce json
+// @Success 200 {object} object{data=[]model.TradingCalendar}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/calendars [get]
+func (h *CalendarHandler) GetCalendars(c *gin.Context) {
+	calendars, err := h.calendarService.GetCalendars(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get trading calendars", zap.Error(err))
+		apierror.Respond(c, err, "Failed to retrieve trading calendars")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": calendars})
+}
+
+// GetCalendar handles retrieving the calendar of an exchange with its holidays
+// GET /api/v1/calendars/:exchange
+//
+// @Summary Retrieve the calendar of an exchange with its holidays
+// @Tags calendars
+// @Produce json
+// @Param exchange path string true "exchange"
+// @Success 200 {object} object{data=model.TradingCalendar}
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/calendars/{exchange} [get]
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+	exchange := c.Param("exchange")
+
+	calendar, err := h.calendarService.GetCalendar(c.Request.Context(), exchange)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get trading calendar", zap.Error(err), zap.String("exchange", exchange))
+		}
+		apierror.Respond(c, err, "Failed to retrieve trading calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": calendar})
+}
+
+// GetSessions handles listing the trading sessions of an exchange in a date range.
+// The range defaults to the next 7 days.
+// GET /api/v1/calendars/:exchange/sessions
+//
+// @Summary List the trading sessions of an exchange in a date range
+// @Tags calendars
+// @Produce json
+// @Param exchange path string true "exchange"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} object{data=model.ExchangeSessions}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/calendars/{exchange}/sessions [get]
+func (h *CalendarHandler) GetSessions(c *gin.Context) {
+	exchange := c.Param("exchange")
+
+	startDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", startStr)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start_date format. Use YYYY-MM-DD or RFC3339")
+				return
+			}
+		}
+		startDate = parsed
+	}
+
+	endDate := startDate.AddDate(0, 0, 7)
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", endStr)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end_date format. Use YYYY-MM-DD or RFC3339")
+				return
+			}
+		}
+		endDate = parsed
+	}
+
+	sessions, err := h.calendarService.GetSessions(c.Request.Context(), exchange, startDate, endDate)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get trading sessions", zap.Error(err), zap.String("exchange", exchange))
+		}
+		apierror.Respond(c, err, "Failed to retrieve trading sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// AddHoliday handles adding a holiday to an exchange calendar
+// POST /api/v1/calendars/:exchange/holidays
+//
+// @Summary Add a holiday to an exchange calendar
+// @Tags calendars
+// @Accept json
+// @Produce json
+// @Param exchange path string true "exchange"
+// @Param request body model.TradingHolidayRequest true "Request body"
+// @Success 201 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/calendars/{exchange}/holidays [post]
+func (h *CalendarHandler) AddHoliday(c *gin.Context) {
+	exchange := c.Param("exchange")
+
+	var request model.TradingHolidayRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.calendarService.AddHoliday(c.Request.Context(), exchange, &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to add trading holiday", zap.Error(err), zap.String("exchange", exchange))
+		}
+		apierror.Respond(c, err, "Failed to add holiday")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Holiday saved"})
+}
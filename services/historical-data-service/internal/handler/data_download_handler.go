@@ -5,9 +5,13 @@ import (
 	"net/http"
 	"strconv"
 
+	"services/shared/apierror"
+
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/service"
 	"services/historical-data-service/internal/utils"
+	"services/shared/pagination"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,19 +20,34 @@ import (
 // DataDownloadHandler handles market data download HTTP requests
 type DataDownloadHandler struct {
 	downloadService *service.MarketDataDownloadService
+	quotaService    *service.QuotaService
 	logger          *zap.Logger
 }
 
 // NewDataDownloadHandler creates a new data download handler
-func NewDataDownloadHandler(downloadService *service.MarketDataDownloadService, logger *zap.Logger) *DataDownloadHandler {
+func NewDataDownloadHandler(
+	downloadService *service.MarketDataDownloadService,
+	quotaService *service.QuotaService,
+	logger *zap.Logger,
+) *DataDownloadHandler {
 	return &DataDownloadHandler{
 		downloadService: downloadService,
+		quotaService:    quotaService,
 		logger:          logger,
 	}
 }
 
 // GetAvailableSymbols handles retrieving all available symbols from a specific source
 // GET /api/v1/market-data/downloads/sources/:source/symbols
+//
+// @Summary Retrieve all available symbols from a specific source
+// @Tags market-data
+// @Produce json
+// @Param source path string true "source"
+// @Success 200 {object} object
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/market-data/downloads/sources/{source}/symbols [get]
 func (h *DataDownloadHandler) GetAvailableSymbols(c *gin.Context) {
 	source := c.Param("source")
 	if source == "" {
@@ -41,7 +60,7 @@ func (h *DataDownloadHandler) GetAvailableSymbols(c *gin.Context) {
 		h.logger.Error("Failed to get available symbols",
 			zap.Error(err),
 			zap.String("source", source))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get symbols from source")
+		apierror.Respond(c, err, "Failed to get symbols from source")
 		return
 	}
 
@@ -50,6 +69,15 @@ func (h *DataDownloadHandler) GetAvailableSymbols(c *gin.Context) {
 
 // CheckSymbolStatus handles checking if a symbol exists in the database and what date ranges are available
 // GET /api/v1/market-data/downloads/symbols/:symbol/status
+//
+// @Summary Check if a symbol exists in the database and what date ranges are available
+// @Tags market-data
+// @Produce json
+// @Param symbol path string true "symbol"
+// @Param timeframe query string false "timeframe"
+// @Success 200 {object} model.SymbolDataStatus
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/market-data/downloads/symbols/{symbol}/status [get]
 func (h *DataDownloadHandler) CheckSymbolStatus(c *gin.Context) {
 	symbol := c.Param("symbol")
 	timeframe := c.DefaultQuery("timeframe", "1h")
@@ -60,7 +88,7 @@ func (h *DataDownloadHandler) CheckSymbolStatus(c *gin.Context) {
 			zap.Error(err),
 			zap.String("symbol", symbol),
 			zap.String("timeframe", timeframe))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to check symbol status")
+		apierror.Respond(c, err, "Failed to check symbol status")
 		return
 	}
 
@@ -69,6 +97,18 @@ func (h *DataDownloadHandler) CheckSymbolStatus(c *gin.Context) {
 
 // InitiateDataDownload handles initiating a data download
 // POST /api/v1/market-data/downloads
+//
+// @Summary Initiate a data download
+// @Tags market-data
+// @Accept json
+// @Produce json
+// @Param request body model.MarketDataDownloadRequest true "Request body"
+// @Success 200 {object} object{message=string,job_id=integer}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads [post]
 func (h *DataDownloadHandler) InitiateDataDownload(c *gin.Context) {
 	var request model.MarketDataDownloadRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -82,14 +122,30 @@ func (h *DataDownloadHandler) InitiateDataDownload(c *gin.Context) {
 		return
 	}
 
-	jobID, err := h.downloadService.InitiateDataDownload(c.Request.Context(), &request)
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	jobID, err := h.downloadService.InitiateDataDownload(
+		c.Request.Context(),
+		&request,
+		userID.(int),
+		quotaTier(c, h.quotaService),
+	)
+	if sendQuotaError(c, err) {
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to start data download",
-			zap.Error(err),
-			zap.String("symbol", request.Symbol),
-			zap.String("source", request.Source),
-			zap.String("timeframe", request.Timeframe))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to start data download")
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to start data download",
+				zap.Error(err),
+				zap.String("symbol", request.Symbol),
+				zap.String("source", request.Source),
+				zap.String("timeframe", request.Timeframe))
+		}
+		apierror.Respond(c, err, "Failed to start data download")
 		return
 	}
 
@@ -101,6 +157,17 @@ func (h *DataDownloadHandler) InitiateDataDownload(c *gin.Context) {
 
 // GetDownloadStatus handles checking the status of a data download job
 // GET /api/v1/market-data/downloads/:id/status
+//
+// @Summary Check the status of a data download job
+// @Tags market-data
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.MarketDataDownloadStatus
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads/{id}/status [get]
 func (h *DataDownloadHandler) GetDownloadStatus(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -112,12 +179,12 @@ func (h *DataDownloadHandler) GetDownloadStatus(c *gin.Context) {
 	status, err := h.downloadService.GetDownloadStatus(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get download status", zap.Error(err), zap.Int("jobID", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get download status")
+		apierror.Respond(c, err, "Failed to get download status")
 		return
 	}
 
 	if status == nil {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Download job not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeDownloadJobNotFound, "Download job not found")
 		return
 	}
 
@@ -126,35 +193,59 @@ func (h *DataDownloadHandler) GetDownloadStatus(c *gin.Context) {
 
 // GetActiveDownloads handles retrieving all active download jobs with pagination and sorting
 // GET /api/v1/market-data/downloads/active
+//
+// @Summary Retrieve all active download jobs with pagination and sorting
+// @Tags market-data
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param source query string false "source"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} object{data=[]model.MarketDataDownloadJob,meta=pagination.Meta}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads/active [get]
 func (h *DataDownloadHandler) GetActiveDownloads(c *gin.Context) {
 	source := c.Query("source")
 
 	// Parse pagination and sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
-	params := utils.ParsePaginationParams(c, 10, 100) // default limit: 10, max limit: 100
+	order := pagination.ParseSort(c, "created_at", "DESC")
+	params := pagination.Parse(c, 10, 100) // default limit: 10, max limit: 100
 
 	jobs, total, err := h.downloadService.GetActiveDownloads(
 		c.Request.Context(),
 		source,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get active downloads", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get active downloads")
+		apierror.Respond(c, err, "Failed to get active downloads")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, jobs, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, jobs, total, params.Page, params.Limit)
 }
 
 // CancelDownload handles cancelling a download job
 // DELETE /api/v1/market-data/downloads/:id
+//
+// @Summary Cancel a download job
+// @Tags market-data
+// @Produce json
+// @Param id path integer true "ID"
+// @Param force query string false "force"
+// @Success 200 {object} object{message=string,status=string,job_id=integer,cancelled=boolean,already_done=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads/{id} [delete]
 func (h *DataDownloadHandler) CancelDownload(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -170,12 +261,12 @@ func (h *DataDownloadHandler) CancelDownload(c *gin.Context) {
 	job, err := h.downloadService.GetDownloadStatus(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get download job", zap.Error(err), zap.Int("jobID", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve download job")
+		apierror.Respond(c, err, "Failed to retrieve download job")
 		return
 	}
 
 	if job == nil {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Download job not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeDownloadJobNotFound, "Download job not found")
 		return
 	}
 
@@ -196,12 +287,12 @@ func (h *DataDownloadHandler) CancelDownload(c *gin.Context) {
 	success, err := h.downloadService.CancelDownload(c.Request.Context(), id, force)
 	if err != nil {
 		h.logger.Error("Failed to cancel download", zap.Error(err), zap.Int("jobID", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to cancel download")
+		apierror.Respond(c, err, "Failed to cancel download")
 		return
 	}
 
 	if !success {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Download job not found or cannot be cancelled")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeDownloadJobNotFound, "Download job not found or cannot be cancelled")
 		return
 	}
 
@@ -227,25 +318,59 @@ func (h *DataDownloadHandler) CancelDownload(c *gin.Context) {
 
 // GetJobsSummary handles retrieving a summary of download jobs
 // GET /api/v1/market-data/downloads/summary
+//
+// @Summary Retrieve a summary of download jobs
+// @Tags market-data
+// @Produce json
+// @Success 200 {object} map[string]object
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads/summary [get]
 func (h *DataDownloadHandler) GetJobsSummary(c *gin.Context) {
 	summary, err := h.downloadService.GetJobsSummary(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get jobs summary", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get jobs summary")
+		apierror.Respond(c, err, "Failed to get jobs summary")
 		return
 	}
 
 	c.JSON(http.StatusOK, summary)
 }
 
+// GetRateLimits handles retrieving the request weight budget each provider's downloads share
+// GET /api/v1/market-data/downloads/rate-limits
+//
+// @Summary Retrieve the rate-limit budgets of download providers
+// @Tags market-data
+// @Produce json
+// @Success 200 {object} object{data=[]model.RateBudgetStats}
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/downloads/rate-limits [get]
+func (h *DataDownloadHandler) GetRateLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.downloadService.GetRateLimits()})
+}
+
 // GetDataInventory handles retrieving data inventory information with pagination
 // GET /api/v1/market-data/inventory
+//
+// @Summary Retrieve data inventory information with pagination
+// @Tags market-data
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param asset_type query string false "asset type"
+// @Param exchange query string false "exchange"
+// @Success 200 {object} object{data=[]model.DataInventoryItem,meta=pagination.Meta}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/market-data/inventory [get]
+// @Router /api/v1/market-data/downloads/inventory [get]
 func (h *DataDownloadHandler) GetDataInventory(c *gin.Context) {
 	assetType := c.DefaultQuery("asset_type", "")
 	exchange := c.DefaultQuery("exchange", "")
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// Add more detailed logging
 	h.logger.Info("GetDataInventory request received",
@@ -264,7 +389,7 @@ func (h *DataDownloadHandler) GetDataInventory(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to get data inventory", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get data inventory")
+		apierror.Respond(c, err, "Failed to get data inventory")
 		return
 	}
 
@@ -274,5 +399,5 @@ func (h *DataDownloadHandler) GetDataInventory(c *gin.Context) {
 		zap.Int("totalCount", total))
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, inventory, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, inventory, total, params.Page, params.Limit)
 }
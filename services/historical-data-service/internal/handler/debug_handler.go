@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/shared/database"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DebugHandler handles HTTP requests inspecting the service's runtime state
+type DebugHandler struct {
+	poolMonitor *database.PoolMonitor
+	logger      *zap.Logger
+}
+
+// NewDebugHandler creates a new debug handler
+func NewDebugHandler(poolMonitor *database.PoolMonitor, logger *zap.Logger) *DebugHandler {
+	return &DebugHandler{
+		poolMonitor: poolMonitor,
+		logger:      logger,
+	}
+}
+
+// GetMetrics handles exporting the service's metrics in the Prometheus text format
+// GET /metrics
+func (h *DebugHandler) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := h.poolMonitor.WriteMetrics(c.Writer); err != nil {
+		h.logger.Debug("Failed to write metrics", zap.Error(err))
+	}
+}
+
+// GetDBPools handles inspecting the database connection pools, for admins and other services
+// GET /api/v1/admin/debug/db
+// GET /api/v1/service/debug/db
+//
+// @Summary Inspect the database connection pools
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=[]database.PoolState}
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Security ServiceKey
+// @Router /api/v1/admin/debug/db [get]
+// @Router /api/v1/service/debug/db [get]
+func (h *DebugHandler) GetDBPools(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.poolMonitor.States()})
+}
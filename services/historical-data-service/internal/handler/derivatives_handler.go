@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+	"services/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DerivativesHandler handles funding rate and open interest HTTP requests
+type DerivativesHandler struct {
+	derivativesService *service.DerivativesService
+	logger             *zap.Logger
+}
+
+// NewDerivativesHandler creates a new derivatives handler
+func NewDerivativesHandler(derivativesService *service.DerivativesService, logger *zap.Logger) *DerivativesHandler {
+	return &DerivativesHandler{
+		derivativesService: derivativesService,
+		logger:             logger,
+	}
+}
+
+// parseDateRange parses the optional start_date and end_date query parameters, as
+// YYYY-MM-DD or RFC3339
+func (h *DerivativesHandler) parseDateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var bounds [2]*time.Time
+	for i, name := range []string{"start_date", "end_date"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", value)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid "+name+" format. Use YYYY-MM-DD or RFC3339")
+				return nil, nil, false
+			}
+		}
+		bounds[i] = &parsed
+	}
+	return bounds[0], bounds[1], true
+}
+
+// GetFundingRates handles retrieving the funding rates of a symbol
+// GET /api/v1/market-data/:symbol/funding-rates
+//
+// @Summary Retrieve the funding rates of a symbol's perpetual futures contract
+// @Description Funding rates are stored by downloads with include_funding_rates. A positive rate means longs pay shorts.
+// @Tags market-data
+// @Produce json
+// @Param symbol path string true "symbol"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.FundingRate}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/{symbol}/funding-rates [get]
+func (h *DerivativesHandler) GetFundingRates(c *gin.Context) {
+	symbolRef := c.Param("symbol")
+
+	startDate, endDate, ok := h.parseDateRange(c)
+	if !ok {
+		return
+	}
+	params := pagination.Parse(c, 1000, 5000)
+
+	rates, err := h.derivativesService.GetFundingRates(c.Request.Context(), symbolRef, startDate, endDate, params.Limit)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get funding rates", zap.Error(err), zap.String("symbol", symbolRef))
+		}
+		apierror.Respond(c, err, "Failed to retrieve funding rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rates})
+}
+
+// GetOpenInterest handles retrieving the open interest of a symbol
+// GET /api/v1/market-data/:symbol/open-interest
+//
+// @Summary Retrieve the open interest of a symbol's perpetual futures contract
+// @Description Open interest is stored by downloads with include_open_interest, sampled at the period closest to the download's timeframe.
+// @Tags market-data
+// @Produce json
+// @Param symbol path string true "symbol"
+// @Param period query string false "5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h or 1d"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.OpenInterest}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/{symbol}/open-interest [get]
+func (h *DerivativesHandler) GetOpenInterest(c *gin.Context) {
+	symbolRef := c.Param("symbol")
+	period := c.DefaultQuery("period", "1h")
+
+	startDate, endDate, ok := h.parseDateRange(c)
+	if !ok {
+		return
+	}
+	params := pagination.Parse(c, 1000, 5000)
+
+	samples, err := h.derivativesService.GetOpenInterest(c.Request.Context(), symbolRef, period, startDate, endDate, params.Limit)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get open interest",
+				zap.Error(err),
+				zap.String("symbol", symbolRef),
+				zap.String("period", period))
+		}
+		apierror.Respond(c, err, "Failed to retrieve open interest")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": samples})
+}
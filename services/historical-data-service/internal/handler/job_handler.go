@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+	"services/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// JobHandler handles the admin HTTP requests monitoring background jobs
+type JobHandler struct {
+	jobService *service.JobService
+	logger     *zap.Logger
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobService *service.JobService, logger *zap.Logger) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+// jobAction cancels or requeues a background job on behalf of an admin
+type jobAction func(ctx context.Context, kind string, id int, adminID int) (*model.AdminJob, error)
+
+// ListJobs handles listing the download jobs and backtests of the service, newest first
+// GET /api/v1/admin/jobs
+//
+// @Summary List the download jobs and backtests of the service, newest first
+// @Tags admin
+// @Produce json
+// @Param kind query string false "download or backtest"
+// @Param status query string false "status"
+// @Param user_id query integer false "user id"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.AdminJob,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	params := pagination.Parse(c, 50, 200)
+
+	filter := model.AdminJobFilter{
+		Kind:   c.Query("kind"),
+		Status: c.Query("status"),
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	jobs, total, err := h.jobService.ListJobs(c.Request.Context(), filter, params.Page, params.Limit)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to list jobs", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to list jobs")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, jobs, total, params.Page, params.Limit)
+}
+
+// GetJobSummary handles counting the download jobs and backtests by kind and status
+// GET /api/v1/admin/jobs/summary
+//
+// @Summary Count the download jobs and backtests by kind and status
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=[]model.AdminJobCount}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/jobs/summary [get]
+func (h *JobHandler) GetJobSummary(c *gin.Context) {
+	counts, err := h.jobService.GetJobCounts(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get job counts", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get job summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": counts})
+}
+
+// GetJob handles retrieving a download job or backtest
+// GET /api/v1/admin/jobs/:kind/:id
+//
+// @Summary Retrieve a download job or backtest
+// @Tags admin
+// @Produce json
+// @Param kind path string true "download or backtest"
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.AdminJob}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/jobs/{kind}/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobService.GetJob(c.Request.Context(), c.Param("kind"), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get job", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to get job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// CancelJob handles cancelling a download job or backtest that hasn't finished
+// POST /api/v1/admin/jobs/:kind/:id/cancel
+//
+// @Summary Cancel a download job or backtest that hasn't finished
+// @Tags admin
+// @Produce json
+// @Param kind path string true "download or backtest"
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.AdminJob}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/jobs/{kind}/{id}/cancel [post]
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	h.runJobAction(c, "cancel", h.jobService.CancelJob)
+}
+
+// RequeueJob handles queueing a failed or cancelled download job or backtest to run again
+// POST /api/v1/admin/jobs/:kind/:id/requeue
+//
+// @Summary Queue a failed or cancelled download job or backtest to run again
+// @Tags admin
+// @Produce json
+// @Param kind path string true "download or backtest"
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.AdminJob}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/jobs/{kind}/{id}/requeue [post]
+func (h *JobHandler) RequeueJob(c *gin.Context) {
+	h.runJobAction(c, "requeue", h.jobService.RequeueJob)
+}
+
+// runJobAction runs an action on the job of the request's path and responds with the job
+// as it is afterwards
+func (h *JobHandler) runJobAction(c *gin.Context, name string, action jobAction) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	kind := c.Param("kind")
+	job, err := action(c.Request.Context(), kind, id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to "+name+" job",
+				zap.Error(err),
+				zap.String("kind", kind),
+				zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to "+name+" job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
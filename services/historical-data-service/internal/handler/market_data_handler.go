@@ -8,6 +8,8 @@ import (
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/service"
 	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+	"services/shared/pagination"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -27,8 +29,25 @@ func NewMarketDataHandler(marketDataService *service.MarketDataService, logger *
 	}
 }
 
-// GetCandles handles retrieving candle data with dynamic timeframe and pagination
+// GetCandles handles retrieving candle data with dynamic timeframe and pagination.
+// Passing ?cursor= (empty for the first page) switches from page numbers to cursors.
 // GET /api/v1/market-data/candles
+//
+// @Summary Retrieve candle data with dynamic timeframe and pagination
+// @Tags market-data
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param symbol_id query integer false "symbol id"
+// @Param timeframe query string false "timeframe"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Param cursor query string false "cursor"
+// @Success 200 {object} object{data=[]model.Candle,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/candles [get]
 func (h *MarketDataHandler) GetCandles(c *gin.Context) {
 	// Parse query parameters
 	var query model.MarketDataQuery
@@ -78,7 +97,12 @@ func (h *MarketDataHandler) GetCandles(c *gin.Context) {
 	}
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 1000, 5000) // default: 1000, max: 5000
+	params := pagination.Parse(c, 1000, 5000) // default: 1000, max: 5000
+
+	if cursor, ok := pagination.ParseCursor(c); ok {
+		h.getCandlesAfter(c, &query, cursor, params.Limit)
+		return
+	}
 
 	// Get candle data with pagination
 	candles, total, err := h.marketDataService.GetCandles(
@@ -93,53 +117,193 @@ func (h *MarketDataHandler) GetCandles(c *gin.Context) {
 			zap.Error(err),
 			zap.Int("symbolID", query.SymbolID),
 			zap.String("timeframe", query.Timeframe))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get candle data")
+		apierror.Respond(c, err, "Failed to get candle data")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, candles, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, candles, total, params.Page, params.Limit)
+}
+
+// getCandlesAfter responds with the page of candles that follows a cursor
+func (h *MarketDataHandler) getCandlesAfter(c *gin.Context, query *model.MarketDataQuery, cursor string, limit int) {
+	var after *model.CandleCursor
+	if cursor != "" {
+		after = &model.CandleCursor{}
+		if err := pagination.DecodeCursor(cursor, after); err != nil || after.Time.IsZero() {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	candles, next, err := h.marketDataService.GetCandlesAfter(c.Request.Context(), query, after, limit)
+	if err != nil {
+		h.logger.Error("Failed to get candles",
+			zap.Error(err),
+			zap.Int("symbolID", query.SymbolID),
+			zap.String("timeframe", query.Timeframe))
+		apierror.Respond(c, err, "Failed to get candle data")
+		return
+	}
+
+	nextCursor := ""
+	if next != nil {
+		nextCursor = pagination.EncodeCursor(next)
+	}
+
+	pagination.SendCursor(c, http.StatusOK, candles, nextCursor, limit)
 }
 
 // BatchImportCandles handles batch importing of candle data
 // POST /api/v1/market-data/candles/batch
+//
+// @Summary Import a batch of candle data
+// @Tags market-data
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 200 {object} object{message=string,count=integer,report=model.CandleImportReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/candles/batch [post]
 func (h *MarketDataHandler) BatchImportCandles(c *gin.Context) {
 	var request struct {
 		Candles []model.CandleBatch `json:"candles" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Validate candle data
 	if len(request.Candles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No candle data provided"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No candle data provided")
 		return
 	}
 
 	// Import candles
-	count, err := h.marketDataService.BatchImportCandles(c.Request.Context(), request.Candles)
+	report, err := h.marketDataService.BatchImportCandles(c.Request.Context(), request.Candles)
 	if err != nil {
 		h.logger.Error("Failed to batch import candles", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import candles: " + err.Error()})
+		apierror.Respond(c, err, "Failed to import candles: "+err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Successfully imported candles",
-		"count":   count,
+		"count":   report.Stored(),
+		"report":  report,
 	})
 }
 
+// previewDefaultPoints and previewMaxPoints bound the length of the preview series
+const (
+	previewDefaultPoints = 500
+	previewMaxPoints     = 2000
+)
+
+// PreviewData handles summarizing the candles a backtest would use
+// GET /api/v1/market-data/preview
+//
+// @Summary Preview the candle data of a symbol and timeframe before running a backtest
+// @Tags market-data
+// @Produce json
+// @Param symbol_id query integer true "symbol id"
+// @Param timeframe query string true "timeframe"
+// @Param start query string true "start"
+// @Param end query string true "end"
+// @Param points query integer false "points"
+// @Success 200 {object} object{data=model.DataPreview}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/preview [get]
+func (h *MarketDataHandler) PreviewData(c *gin.Context) {
+	var query model.DataPreviewQuery
+
+	symbolID, err := strconv.Atoi(c.Query("symbol_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid symbol ID")
+		return
+	}
+	query.SymbolID = symbolID
+
+	query.Timeframe = c.Query("timeframe")
+	if query.Timeframe == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Timeframe is required")
+		return
+	}
+
+	start, err := parsePreviewTime(c.Query("start"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start format. Use YYYY-MM-DD or RFC3339")
+		return
+	}
+	query.Start = start
+
+	end, err := parsePreviewTime(c.Query("end"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end format. Use YYYY-MM-DD or RFC3339")
+		return
+	}
+	query.End = end
+
+	query.Points = previewDefaultPoints
+	if pointsStr := c.Query("points"); pointsStr != "" {
+		points, err := strconv.Atoi(pointsStr)
+		if err != nil || points < 1 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid points")
+			return
+		}
+		if points > previewMaxPoints {
+			points = previewMaxPoints
+		}
+		query.Points = points
+	}
+
+	preview, err := h.marketDataService.PreviewData(c.Request.Context(), &query)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to preview market data",
+				zap.Error(err),
+				zap.Int("symbolID", query.SymbolID),
+				zap.String("timeframe", query.Timeframe))
+		}
+		apierror.Respond(c, err, "Failed to preview market data")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preview})
+}
+
+// parsePreviewTime parses a required RFC3339 or YYYY-MM-DD time
+func parsePreviewTime(value string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// Try an alternate format
+		parsed, err = time.Parse("2006-01-02", value)
+	}
+	return parsed, err
+}
+
 // GetAssetTypes handles retrieving available asset types
 // GET /api/v1/market-data/asset-types
+//
+// @Summary Retrieve available asset types
+// @Tags market-data
+// @Produce json
+// @Success 200 {object} object
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/asset-types [get]
 func (h *MarketDataHandler) GetAssetTypes(c *gin.Context) {
 	assetTypes, err := h.marketDataService.GetAssetTypes(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get asset types", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get asset types"})
+		apierror.Respond(c, err, "Failed to get asset types")
 		return
 	}
 
@@ -148,19 +312,132 @@ func (h *MarketDataHandler) GetAssetTypes(c *gin.Context) {
 
 // GetExchanges handles retrieving available exchanges
 // GET /api/v1/market-data/exchanges
+//
+// @Summary Retrieve available exchanges
+// @Tags market-data
+// @Produce json
+// @Success 200 {object} object
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/exchanges [get]
 func (h *MarketDataHandler) GetExchanges(c *gin.Context) {
 	exchanges, err := h.marketDataService.GetExchanges(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get exchanges", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get exchanges"})
+		apierror.Respond(c, err, "Failed to get exchanges")
 		return
 	}
 
 	c.JSON(http.StatusOK, exchanges)
 }
 
+// GetCandleCacheStats handles retrieving the candle cache's hit, miss and size counters
+// GET /api/v1/market-data/cache/stats
+//
+// @Summary Retrieve candle cache statistics
+// @Tags market-data
+// @Produce json
+// @Success 200 {object} model.CandleCacheStats
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/cache/stats [get]
+func (h *MarketDataHandler) GetCandleCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.marketDataService.GetCandleCacheStats())
+}
+
+// DeleteCandles handles deleting a symbol's candles in a range (admin only), e.g. after
+// importing bad data. The range is widened to whole candles of the timeframe; dry_run
+// only reports what would be deleted.
+// DELETE /api/v1/market-data/candles
+//
+// @Summary Delete a symbol's candles in a range (admin only)
+// @Tags market-data
+// @Produce json
+// @Param symbol_id query integer true "symbol ID"
+// @Param timeframe query string true "timeframe the range is aligned to"
+// @Param start query string true "start (YYYY-MM-DD or RFC3339)"
+// @Param end query string true "end, its candle included (YYYY-MM-DD or RFC3339)"
+// @Param dry_run query boolean false "only count the candles that would be deleted"
+// @Param reason query string false "reason recorded with the deletion"
+// @Success 200 {object} object{data=model.CandleDeletion}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/candles [delete]
+func (h *MarketDataHandler) DeleteCandles(c *gin.Context) {
+	var query model.CandleDeletionQuery
+
+	symbolID, err := strconv.Atoi(c.Query("symbol_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid symbol ID")
+		return
+	}
+	query.SymbolID = symbolID
+
+	query.Timeframe = c.Query("timeframe")
+	if query.Timeframe == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Timeframe is required")
+		return
+	}
+
+	start, err := parsePreviewTime(c.Query("start"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start format. Use YYYY-MM-DD or RFC3339")
+		return
+	}
+	query.Start = start
+
+	end, err := parsePreviewTime(c.Query("end"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end format. Use YYYY-MM-DD or RFC3339")
+		return
+	}
+	query.End = end
+
+	if dryRun := c.Query("dry_run"); dryRun != "" {
+		query.DryRun, err = strconv.ParseBool(dryRun)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid dry_run, use true or false")
+			return
+		}
+	}
+	query.Reason = c.Query("reason")
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	deletion, err := h.marketDataService.DeleteCandleRange(c.Request.Context(), &query, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to delete candles",
+				zap.Error(err),
+				zap.Int("symbolID", query.SymbolID),
+				zap.Bool("dryRun", query.DryRun))
+		}
+		apierror.Respond(c, err, "Failed to delete candles")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deletion})
+}
+
 // BatchImportMarketData handles batch importing of market data for internal service use
 // POST /api/v1/service/market-data/batch
+//
+// @Summary Import a batch of market data for internal service use
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body []object true "Request body"
+// @Success 201 {object} object{message=string,count=integer,report=model.CandleImportReport,batches=[]object}
+// @Failure 400 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/market-data/batch [post]
 func (h *MarketDataHandler) BatchImportMarketData(c *gin.Context) {
 	var request []struct {
 		SymbolID  int                 `json:"symbol_id" binding:"required"`
@@ -169,31 +446,44 @@ func (h *MarketDataHandler) BatchImportMarketData(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if len(request) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No import requests provided"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No import requests provided")
 		return
 	}
 
 	// Process each set of candles
-	totalImported := 0
+	var total model.CandleImportReport
+	reports := make([]gin.H, 0, len(request))
 	for _, batch := range request {
-		count, err := h.marketDataService.BatchImportCandles(c.Request.Context(), batch.Candles)
+		report, err := h.marketDataService.BatchImportCandles(c.Request.Context(), batch.Candles)
 		if err != nil {
 			h.logger.Error("Failed to import batch",
 				zap.Error(err),
 				zap.Int("symbolID", batch.SymbolID),
 				zap.String("timeframe", batch.Timeframe))
+			reports = append(reports, gin.H{
+				"symbol_id": batch.SymbolID,
+				"timeframe": batch.Timeframe,
+				"error":     err.Error(),
+			})
 			continue
 		}
-		totalImported += count
+		total.Add(*report)
+		reports = append(reports, gin.H{
+			"symbol_id": batch.SymbolID,
+			"timeframe": batch.Timeframe,
+			"report":    report,
+		})
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Market data batch imported successfully",
-		"count":   totalImported,
+		"count":   total.Stored(),
+		"report":  total,
+		"batches": reports,
 	})
 }
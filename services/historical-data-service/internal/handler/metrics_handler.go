@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MetricsHandler handles HTTP requests for pre-aggregated daily metrics
+type MetricsHandler struct {
+	metricsService *service.MetricsService
+	logger         *zap.Logger
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(metricsService *service.MetricsService, logger *zap.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// GetUserDailyMetrics handles retrieving daily rollups for a user
+// GET /api/v1/metrics/daily/users/:id
+//
+// @Summary Retrieve daily rollups for a user
+// @Tags metrics
+// @Produce json
+// @Param id path integer true "ID"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} []model.DailyUserMetrics
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/metrics/daily/users/{id} [get]
+func (h *MetricsHandler) GetUserDailyMetrics(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	startDate, endDate, ok := parseMetricsDateRange(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.metricsService.GetUserDailyMetrics(c.Request.Context(), userID, startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to get daily user metrics", zap.Error(err), zap.Int("userID", userID))
+		apierror.Respond(c, err, "Failed to get daily user metrics")
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GetStrategyDailyMetrics handles retrieving daily rollups for a strategy
+// GET /api/v1/metrics/daily/strategies/:id
+//
+// @Summary Retrieve daily rollups for a strategy
+// @Tags metrics
+// @Produce json
+// @Param id path integer true "ID"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} []model.DailyStrategyMetrics
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/metrics/daily/strategies/{id} [get]
+func (h *MetricsHandler) GetStrategyDailyMetrics(c *gin.Context) {
+	strategyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	startDate, endDate, ok := parseMetricsDateRange(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.metricsService.GetStrategyDailyMetrics(c.Request.Context(), strategyID, startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to get daily strategy metrics", zap.Error(err), zap.Int("strategyID", strategyID))
+		apierror.Respond(c, err, "Failed to get daily strategy metrics")
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GetSymbolDailyMetrics handles retrieving daily rollups for a symbol
+// GET /api/v1/metrics/daily/symbols/:id
+//
+// @Summary Retrieve daily rollups for a symbol
+// @Tags metrics
+// @Produce json
+// @Param id path integer true "ID"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} []model.DailySymbolMetrics
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/metrics/daily/symbols/{id} [get]
+func (h *MetricsHandler) GetSymbolDailyMetrics(c *gin.Context) {
+	symbolID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid symbol ID")
+		return
+	}
+
+	startDate, endDate, ok := parseMetricsDateRange(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.metricsService.GetSymbolDailyMetrics(c.Request.Context(), symbolID, startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to get daily symbol metrics", zap.Error(err), zap.Int("symbolID", symbolID))
+		apierror.Respond(c, err, "Failed to get daily symbol metrics")
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// RefreshDailyMetrics handles recomputing the rollups for a date range
+// POST /api/v1/metrics/daily/refresh
+//
+// @Summary Recompute the rollups for a date range
+// @Tags metrics
+// @Produce json
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} object{message=string,days=[]model.DailyMetricsRefreshResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/metrics/daily/refresh [post]
+func (h *MetricsHandler) RefreshDailyMetrics(c *gin.Context) {
+	startDate, endDate, ok := parseMetricsDateRange(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.metricsService.Backfill(c.Request.Context(), startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to refresh daily metrics", zap.Error(err))
+		apierror.Respond(c, err, "Failed to refresh daily metrics: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Daily metrics refreshed",
+		"days":    results,
+	})
+}
+
+// parseMetricsDateRange parses start_date and end_date (YYYY-MM-DD), defaulting
+// to the last 30 days. Writes a 400 response and returns false on bad input.
+func parseMetricsDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now().UTC()
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	startDate := endDate.AddDate(0, 0, -30)
+
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start_date format. Use YYYY-MM-DD")
+			return time.Time{}, time.Time{}, false
+		}
+		startDate = parsed
+	}
+
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end_date format. Use YYYY-MM-DD")
+			return time.Time{}, time.Time{}, false
+		}
+		endDate = parsed
+	}
+
+	if endDate.Before(startDate) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "end_date must not be before start_date")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return startDate, endDate, true
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+	"services/shared/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OrderBookHandler handles order book snapshot HTTP requests
+type OrderBookHandler struct {
+	orderBookService *service.OrderBookService
+	logger           *zap.Logger
+}
+
+// NewOrderBookHandler creates a new order book handler
+func NewOrderBookHandler(orderBookService *service.OrderBookService, logger *zap.Logger) *OrderBookHandler {
+	return &OrderBookHandler{
+		orderBookService: orderBookService,
+		logger:           logger,
+	}
+}
+
+// GetSnapshots handles retrieving the order book snapshots of a symbol
+// GET /api/v1/market-data/:symbol/order-book
+//
+// @Summary Retrieve the order book snapshots of a symbol
+// @Description Snapshots of the top levels of the book are captured periodically for the symbols order book capture is configured for, and kept for the configured retention.
+// @Tags market-data
+// @Produce json
+// @Param symbol path string true "symbol"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.OrderBookSnapshot}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/{symbol}/order-book [get]
+func (h *OrderBookHandler) GetSnapshots(c *gin.Context) {
+	symbolRef := c.Param("symbol")
+
+	var bounds [2]*time.Time
+	for i, name := range []string{"start_date", "end_date"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", value)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid "+name+" format. Use YYYY-MM-DD or RFC3339")
+				return
+			}
+		}
+		bounds[i] = &parsed
+	}
+	params := pagination.Parse(c, 500, 2000)
+
+	snapshots, err := h.orderBookService.GetSnapshots(c.Request.Context(), symbolRef, bounds[0], bounds[1], params.Limit)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get order book snapshots", zap.Error(err), zap.String("symbol", symbolRef))
+		}
+		apierror.Respond(c, err, "Failed to retrieve order book snapshots")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": snapshots})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"services/shared/apierror"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// QuotaHandler handles HTTP requests for user quotas
+type QuotaHandler struct {
+	quotaService *service.QuotaService
+	logger       *zap.Logger
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(quotaService *service.QuotaService, logger *zap.Logger) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+		logger:       logger,
+	}
+}
+
+// GetMyQuotas handles retrieving the authenticated user's quota limits and usage
+// GET /api/v1/users/me/quotas
+//
+// @Summary Retrieve the authenticated user's quota limits and usage
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{data=model.UserQuotas}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/quotas [get]
+func (h *QuotaHandler) GetMyQuotas(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	quotas, err := h.quotaService.GetUserQuotas(c.Request.Context(), userID.(int), quotaTier(c, h.quotaService))
+	if err != nil {
+		h.logger.Error("Failed to get user quotas", zap.Error(err), zap.Int("userID", userID.(int)))
+		apierror.Respond(c, err, "Failed to get quotas")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": quotas})
+}
+
+// quotaTier returns the quota tier of the authenticated user
+func quotaTier(c *gin.Context, quotaService *service.QuotaService) string {
+	role, _ := c.Get("userRole")
+	roleStr, _ := role.(string)
+
+	value, _ := c.Get("userPermissions")
+	permissions, _ := value.([]string)
+
+	return quotaService.TierFor(roleStr, permissions)
+}
+
+// sendQuotaError responds to a quota violation and reports whether err was one.
+// Limits that free up over time return 429 (with Retry-After for daily limits);
+// the storage limit returns 403 since waiting doesn't help.
+func sendQuotaError(c *gin.Context, err error) bool {
+	var quotaErr *service.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	status := http.StatusTooManyRequests
+	if quotaErr.Quota == model.QuotaStoredCandles {
+		status = http.StatusForbidden
+	}
+
+	if quotaErr.ResetsAt != nil {
+		retryAfter := int(math.Ceil(time.Until(*quotaErr.ResetsAt).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	c.JSON(status, gin.H{
+		"error":     quotaErr.Error(),
+		"code":      errcode.CodeQuotaExceeded,
+		"quota":     quotaErr.Quota,
+		"tier":      quotaErr.Tier,
+		"limit":     quotaErr.Limit,
+		"used":      quotaErr.Used,
+		"resets_at": quotaErr.ResetsAt,
+	})
+	return true
+}
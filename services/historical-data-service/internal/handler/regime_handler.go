@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RegimeHandler handles market regime HTTP requests
+type RegimeHandler struct {
+	regimeService *service.RegimeService
+	logger        *zap.Logger
+}
+
+// NewRegimeHandler creates a new regime handler
+func NewRegimeHandler(regimeService *service.RegimeService, logger *zap.Logger) *RegimeHandler {
+	return &RegimeHandler{
+		regimeService: regimeService,
+		logger:        logger,
+	}
+}
+
+// GetRegimes handles retrieving the market regime segments of a symbol
+// GET /api/v1/market-data/:symbol/regimes
+//
+// @Summary Retrieve the market regime segments of a symbol
+// @Tags market-data
+// @Produce json
+// @Param symbol path string true "symbol"
+// @Param timeframe query string false "timeframe"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} object{data=model.MarketRegimes}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/{symbol}/regimes [get]
+func (h *RegimeHandler) GetRegimes(c *gin.Context) {
+	symbolRef := c.Param("symbol")
+	timeframe := c.DefaultQuery("timeframe", "1d")
+
+	var startDate, endDate *time.Time
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", startStr)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start_date format. Use YYYY-MM-DD or RFC3339")
+				return
+			}
+		}
+		startDate = &parsed
+	}
+
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			// Try an alternate format
+			parsed, err = time.Parse("2006-01-02", endStr)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end_date format. Use YYYY-MM-DD or RFC3339")
+				return
+			}
+		}
+		endDate = &parsed
+	}
+
+	regimes, err := h.regimeService.GetRegimes(c.Request.Context(), symbolRef, timeframe, startDate, endDate)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get market regimes",
+				zap.Error(err),
+				zap.String("symbol", symbolRef),
+				zap.String("timeframe", timeframe))
+		}
+		apierror.Respond(c, err, "Failed to retrieve market regimes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": regimes})
+}
+
+// GetBacktestRunBreakdown handles retrieving the performance of a backtest run per market regime
+// GET /api/v1/backtest-runs/:id/regime-breakdown
+//
+// @Summary Retrieve the performance of a backtest run per market regime
+// @Tags backtest-runs
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.RegimeBreakdown}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/backtest-runs/{id}/regime-breakdown [get]
+func (h *RegimeHandler) GetBacktestRunBreakdown(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid backtest run ID")
+		return
+	}
+
+	breakdown, err := h.regimeService.GetBacktestRunBreakdown(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get regime breakdown",
+				zap.Error(err),
+				zap.Int("run_id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve regime breakdown")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": breakdown})
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StatsHandler handles admin statistics HTTP requests
+type StatsHandler struct {
+	statsService *service.StatsService
+	logger       *zap.Logger
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService *service.StatsService, logger *zap.Logger) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+		logger:       logger,
+	}
+}
+
+// GetDataStats handles retrieving backtest, download and inventory KPIs over a time window (24h, 7d, 30d or 90d)
+// GET /api/v1/admin/stats/data
+//
+// @Summary Retrieve backtest, download and inventory KPIs over a time window (24h, 7d, 30d or 90d)
+// @Tags admin
+// @Produce json
+// @Param window query string false "window"
+// @Success 200 {object} object{data=model.DataStats}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/stats/data [get]
+func (h *StatsHandler) GetDataStats(c *gin.Context) {
+	window := c.DefaultQuery("window", "7d")
+	if !service.IsValidStatsWindow(window) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid window; use 24h, 7d, 30d or 90d")
+		return
+	}
+
+	stats, err := h.statsService.GetDataStats(c.Request.Context(), window)
+	if err != nil {
+		h.logger.Error("Failed to get data stats", zap.Error(err), zap.String("window", window))
+		apierror.Respond(c, err, "Failed to get data stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
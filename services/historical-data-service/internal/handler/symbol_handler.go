@@ -4,9 +4,13 @@ import (
 	"net/http"
 	"strconv"
 
+	"services/shared/apierror"
+
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/service"
 	"services/historical-data-service/internal/utils"
+	"services/shared/pagination"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -28,6 +32,20 @@ func NewSymbolHandler(symbolService *service.SymbolService, logger *zap.Logger)
 
 // GetAllSymbols handles retrieving all symbols with filtering, pagination and sorting
 // GET /api/v1/symbols
+//
+// @Summary Retrieve all symbols with filtering, pagination and sorting
+// @Tags symbols
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param asset_type query string false "asset type"
+// @Param exchange query string false "exchange"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} []model.Symbol
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/symbols [get]
 func (h *SymbolHandler) GetAllSymbols(c *gin.Context) {
 	// Get query parameters for filtering
 	searchTerm := c.Query("search")
@@ -35,20 +53,19 @@ func (h *SymbolHandler) GetAllSymbols(c *gin.Context) {
 	exchange := c.Query("exchange")
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "symbol")
-	sortDirection := c.DefaultQuery("sort_direction", "ASC")
+	order := pagination.ParseSort(c, "symbol", "ASC")
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// If no filters and no pagination/sorting specified, use the simple method
 	if searchTerm == "" && assetType == "" && exchange == "" &&
-		sortBy == "symbol" && sortDirection == "ASC" &&
+		order.By == "symbol" && order.Direction == "ASC" &&
 		params.Page == 1 && params.Limit == 20 {
 		symbols, err := h.symbolService.GetAllSymbols(c.Request.Context())
 		if err != nil {
 			h.logger.Error("Failed to get all symbols", zap.Error(err))
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve symbols")
+			apierror.Respond(c, err, "Failed to retrieve symbols")
 			return
 		}
 		c.JSON(http.StatusOK, symbols)
@@ -61,20 +78,20 @@ func (h *SymbolHandler) GetAllSymbols(c *gin.Context) {
 		searchTerm,
 		assetType,
 		exchange,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get filtered symbols", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve symbols")
+		apierror.Respond(c, err, "Failed to retrieve symbols")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, symbols, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, symbols, total, params.Page, params.Limit)
 }
 
 // GetSymbol handles retrieving a symbol by ID
@@ -92,12 +109,12 @@ func (h *SymbolHandler) GetSymbol(c *gin.Context) {
 	symbol, err := h.symbolService.GetSymbolByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get symbol", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve symbol")
+		apierror.Respond(c, err, "Failed to retrieve symbol")
 		return
 	}
 
 	if symbol == nil {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Symbol not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeSymbolNotFound, "Symbol not found")
 		return
 	}
 
@@ -106,6 +123,17 @@ func (h *SymbolHandler) GetSymbol(c *gin.Context) {
 
 // CreateSymbol handles creating a new symbol
 // POST /api/v1/symbols
+//
+// @Summary Create a new symbol
+// @Tags symbols
+// @Accept json
+// @Produce json
+// @Param request body model.Symbol true "Request body"
+// @Success 201 {object} model.Symbol
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/symbols [post]
 func (h *SymbolHandler) CreateSymbol(c *gin.Context) {
 	var symbol model.Symbol
 	if err := c.ShouldBindJSON(&symbol); err != nil {
@@ -117,7 +145,7 @@ func (h *SymbolHandler) CreateSymbol(c *gin.Context) {
 	id, err := h.symbolService.CreateSymbol(c.Request.Context(), &symbol)
 	if err != nil {
 		h.logger.Error("Failed to create symbol", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create symbol: "+err.Error())
+		apierror.Respond(c, err, "Failed to create symbol: "+err.Error())
 		return
 	}
 
@@ -129,6 +157,19 @@ func (h *SymbolHandler) CreateSymbol(c *gin.Context) {
 
 // UpdateSymbol handles updating an existing symbol
 // PUT /api/v1/symbols/:id
+//
+// @Summary Update an existing symbol
+// @Tags symbols
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.Symbol true "Request body"
+// @Success 200 {object} model.Symbol
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/symbols/{id} [put]
 func (h *SymbolHandler) UpdateSymbol(c *gin.Context) {
 	// Parse path parameter
 	idStr := c.Param("id")
@@ -151,12 +192,12 @@ func (h *SymbolHandler) UpdateSymbol(c *gin.Context) {
 	success, err := h.symbolService.UpdateSymbol(c.Request.Context(), &symbol)
 	if err != nil {
 		h.logger.Error("Failed to update symbol", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update symbol: "+err.Error())
+		apierror.Respond(c, err, "Failed to update symbol: "+err.Error())
 		return
 	}
 
 	if !success {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Symbol not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeSymbolNotFound, "Symbol not found")
 		return
 	}
 
@@ -165,6 +206,16 @@ func (h *SymbolHandler) UpdateSymbol(c *gin.Context) {
 
 // DeleteSymbol handles deleting a symbol (marking as inactive)
 // DELETE /api/v1/symbols/:id
+//
+// @Summary Delete a symbol (marking as inactive)
+// @Tags symbols
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/symbols/{id} [delete]
 func (h *SymbolHandler) DeleteSymbol(c *gin.Context) {
 	// Parse path parameter
 	idStr := c.Param("id")
@@ -178,12 +229,12 @@ func (h *SymbolHandler) DeleteSymbol(c *gin.Context) {
 	success, err := h.symbolService.DeleteSymbol(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete symbol", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete symbol: "+err.Error())
+		apierror.Respond(c, err, "Failed to delete symbol: "+err.Error())
 		return
 	}
 
 	if !success {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Symbol not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeSymbolNotFound, "Symbol not found")
 		return
 	}
 
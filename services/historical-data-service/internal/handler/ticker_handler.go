@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/service"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TickerHandler handles ticker HTTP requests
+type TickerHandler struct {
+	tickerService *service.TickerService
+	logger        *zap.Logger
+}
+
+// NewTickerHandler creates a new ticker handler
+func NewTickerHandler(tickerService *service.TickerService, logger *zap.Logger) *TickerHandler {
+	return &TickerHandler{
+		tickerService: tickerService,
+		logger:        logger,
+	}
+}
+
+// GetTickers handles retrieving the latest prices and 24h changes of symbols
+// GET /api/v1/market-data/ticker
+//
+// @Summary Retrieve the latest prices and 24h changes of symbols
+// @Description Tickers are served from the live stream when available, and otherwise from the provider's REST API cached for a few seconds.
+// @Tags market-data
+// @Produce json
+// @Param symbols query string true "Comma separated symbols, e.g. BTCUSDT,ETHUSDT"
+// @Success 200 {object} object{data=[]model.Ticker}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 502 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/market-data/ticker [get]
+func (h *TickerHandler) GetTickers(c *gin.Context) {
+	symbols, err := h.tickerService.ParseSymbols(c.Query("symbols"))
+	if err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	tickers, err := h.tickerService.GetTickers(c.Request.Context(), symbols)
+	if err != nil {
+		var rateLimited *client.RateLimitError
+		if errors.As(err, &rateLimited) {
+			c.Header("Retry-After", strconv.Itoa(int(rateLimited.RetryAfter.Seconds())))
+			apierror.Send(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "The market data provider is rate limiting requests, try again later")
+			return
+		}
+
+		if apierror.From(err) != nil {
+			apierror.Respond(c, err, "Failed to fetch tickers")
+			return
+		}
+
+		h.logger.Error("Failed to get tickers", zap.Error(err), zap.Strings("symbols", symbols))
+		apierror.Send(c, http.StatusBadGateway, apierror.CodeUpstreamFailed, "Failed to fetch tickers from the market data provider")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tickers})
+}
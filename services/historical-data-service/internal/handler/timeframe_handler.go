@@ -3,7 +3,10 @@ package handler
 import (
 	"net/http"
 
+	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/shared/apierror"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -25,11 +28,21 @@ func NewTimeframeHandler(timeframeService *service.TimeframeService, logger *zap
 
 // GetAllTimeframes handles retrieving all timeframes
 // GET /api/v1/timeframes
+//
+// @Summary Retrieve all timeframes
+// @Tags timeframes
+// @Produce json
+// @Param include_inactive query string false "include inactive"
+// @Success 200 {object} []model.Timeframe
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/timeframes [get]
 func (h *TimeframeHandler) GetAllTimeframes(c *gin.Context) {
-	timeframes, err := h.timeframeService.GetAllTimeframes(c.Request.Context())
+	includeInactive := c.Query("include_inactive") == "true"
+
+	timeframes, err := h.timeframeService.GetAllTimeframes(c.Request.Context(), includeInactive)
 	if err != nil {
 		h.logger.Error("Failed to get all timeframes", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve timeframes"})
+		apierror.Respond(c, err, "Failed to retrieve timeframes")
 		return
 	}
 
@@ -38,13 +51,21 @@ func (h *TimeframeHandler) GetAllTimeframes(c *gin.Context) {
 
 // ValidateTimeframe handles validating a timeframe
 // GET /api/v1/timeframes/validate/:timeframe
+//
+// @Summary Validate a timeframe
+// @Tags timeframes
+// @Produce json
+// @Param timeframe path string true "timeframe"
+// @Success 200 {object} object{timeframe=string,valid=boolean}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/timeframes/validate/{timeframe} [get]
 func (h *TimeframeHandler) ValidateTimeframe(c *gin.Context) {
 	timeframe := c.Param("timeframe")
 
 	valid, err := h.timeframeService.ValidateTimeframe(c.Request.Context(), timeframe)
 	if err != nil {
 		h.logger.Error("Failed to validate timeframe", zap.Error(err), zap.String("timeframe", timeframe))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate timeframe"})
+		apierror.Respond(c, err, "Failed to validate timeframe")
 		return
 	}
 
@@ -53,3 +74,101 @@ func (h *TimeframeHandler) ValidateTimeframe(c *gin.Context) {
 		"valid":     valid,
 	})
 }
+
+// CreateTimeframe handles adding a custom timeframe
+// POST /api/v1/timeframes
+//
+// @Summary Add a custom timeframe
+// @Tags timeframes
+// @Accept json
+// @Produce json
+// @Param request body model.TimeframeCreateRequest true "Request body"
+// @Success 201 {object} object{data=model.Timeframe}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/timeframes [post]
+func (h *TimeframeHandler) CreateTimeframe(c *gin.Context) {
+	var request model.TimeframeCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	timeframe, err := h.timeframeService.CreateTimeframe(c.Request.Context(), &request)
+	if err != nil {
+		h.sendTimeframeError(c, err, request.Name, "Failed to create timeframe")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": timeframe})
+}
+
+// UpdateTimeframe handles updating a timeframe
+// PUT /api/v1/timeframes/:timeframe
+//
+// @Summary Update a timeframe
+// @Tags timeframes
+// @Accept json
+// @Produce json
+// @Param timeframe path string true "timeframe"
+// @Param request body model.TimeframeUpdateRequest true "Request body"
+// @Success 200 {object} object{data=model.Timeframe}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/timeframes/{timeframe} [put]
+func (h *TimeframeHandler) UpdateTimeframe(c *gin.Context) {
+	name := c.Param("timeframe")
+
+	var request model.TimeframeUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	timeframe, err := h.timeframeService.UpdateTimeframe(c.Request.Context(), name, &request)
+	if err != nil {
+		h.sendTimeframeError(c, err, name, "Failed to update timeframe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": timeframe})
+}
+
+// DeleteTimeframe handles deleting a custom timeframe
+// DELETE /api/v1/timeframes/:timeframe
+//
+// @Summary Delete a custom timeframe
+// @Tags timeframes
+// @Produce json
+// @Param timeframe path string true "timeframe"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/timeframes/{timeframe} [delete]
+func (h *TimeframeHandler) DeleteTimeframe(c *gin.Context) {
+	name := c.Param("timeframe")
+
+	if err := h.timeframeService.DeleteTimeframe(c.Request.Context(), name); err != nil {
+		h.sendTimeframeError(c, err, name, "Failed to delete timeframe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Timeframe deleted"})
+}
+
+// sendTimeframeError sends the response of a timeframe management error
+func (h *TimeframeHandler) sendTimeframeError(c *gin.Context, err error, timeframe string, message string) {
+	if apierror.From(err) == nil {
+		h.logger.Error(message, zap.Error(err), zap.String("timeframe", timeframe))
+	}
+	apierror.Respond(c, err, message)
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/service"
+	"services/historical-data-service/internal/utils"
+	"services/historical-data-service/internal/validation"
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WatchlistHandler handles watchlist HTTP requests
+type WatchlistHandler struct {
+	watchlistService *service.WatchlistService
+	logger           *zap.Logger
+}
+
+// NewWatchlistHandler creates a new watchlist handler
+func NewWatchlistHandler(watchlistService *service.WatchlistService, logger *zap.Logger) *WatchlistHandler {
+	return &WatchlistHandler{
+		watchlistService: watchlistService,
+		logger:           logger,
+	}
+}
+
+// GetWatchlists handles listing the watchlists of the authenticated user
+// GET /api/v1/watchlists
+//
+// @Summary List your watchlists with their symbols
+// @Tags watchlists
+// @Produce json
+// @Success 200 {object} object{data=[]model.Watchlist}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists [get]
+func (h *WatchlistHandler) GetWatchlists(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	watchlists, err := h.watchlistService.GetWatchlists(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get watchlists", zap.Error(err), zap.Int("userID", userID.(int)))
+		apierror.Respond(c, err, "Failed to retrieve watchlists")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": watchlists})
+}
+
+// CreateWatchlist handles creating a watchlist
+// POST /api/v1/watchlists
+//
+// @Summary Create a watchlist
+// @Tags watchlists
+// @Accept json
+// @Produce json
+// @Param request body model.WatchlistCreate true "Request body"
+// @Success 201 {object} object{data=model.Watchlist}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists [post]
+func (h *WatchlistHandler) CreateWatchlist(c *gin.Context) {
+	var request model.WatchlistCreate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	watchlist, err := h.watchlistService.CreateWatchlist(c.Request.Context(), userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to create watchlist", zap.Error(err), zap.Int("userID", userID.(int)))
+		}
+		apierror.Respond(c, err, "Failed to create watchlist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": watchlist})
+}
+
+// GetWatchlist handles retrieving a watchlist with its symbols
+// GET /api/v1/watchlists/:id
+//
+// @Summary Retrieve a watchlist with its symbols
+// @Tags watchlists
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.Watchlist}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists/{id} [get]
+func (h *WatchlistHandler) GetWatchlist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid watchlist ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	watchlist, err := h.watchlistService.GetWatchlist(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get watchlist", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve watchlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": watchlist})
+}
+
+// UpdateWatchlist handles renaming a watchlist or replacing its symbols
+// PUT /api/v1/watchlists/:id
+//
+// @Summary Update a watchlist; symbol_ids replaces its symbols
+// @Tags watchlists
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.WatchlistUpdate true "Request body"
+// @Success 200 {object} object{data=model.Watchlist}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists/{id} [put]
+func (h *WatchlistHandler) UpdateWatchlist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid watchlist ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.WatchlistUpdate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	watchlist, err := h.watchlistService.UpdateWatchlist(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to update watchlist", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to update watchlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": watchlist})
+}
+
+// DeleteWatchlist handles deleting a watchlist
+// DELETE /api/v1/watchlists/:id
+//
+// @Summary Delete a watchlist
+// @Tags watchlists
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists/{id} [delete]
+func (h *WatchlistHandler) DeleteWatchlist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid watchlist ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.watchlistService.DeleteWatchlist(c.Request.Context(), id, userID.(int)); err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to delete watchlist", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to delete watchlist")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWatchlistQuotes handles quoting the symbols of a watchlist: the latest close of
+// each from stored candles and its change over the 24 hours before it
+// GET /api/v1/watchlists/:id/quotes
+//
+// @Summary Quote the symbols of a watchlist from stored candles
+// @Tags watchlists
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.WatchlistQuote}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/watchlists/{id}/quotes [get]
+func (h *WatchlistHandler) GetWatchlistQuotes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid watchlist ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	quotes, err := h.watchlistService.GetWatchlistQuotes(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get watchlist quotes", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve watchlist quotes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": quotes})
+}
@@ -1,22 +1,31 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strings"
 
+	"services/shared/apierror"
+
 	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/errcode"
+	"services/shared/auth"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// AuthMiddleware creates middleware to authenticate users
-func AuthMiddleware(userClient *client.UserClient, logger *zap.Logger) gin.HandlerFunc {
+// AuthMiddleware creates middleware to authenticate users. Access tokens are verified
+// locally; only impersonation tokens, which can be revoked before they expire, are
+// checked with the user service.
+func AuthMiddleware(verifier *auth.TokenVerifier, userClient *client.UserClient, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header required")
 			c.Abort()
 			return
 		}
@@ -25,42 +34,39 @@ func AuthMiddleware(userClient *client.UserClient, logger *zap.Logger) gin.Handl
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == authHeader {
 			// No prefix was removed, invalid format
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid authorization format")
 			c.Abort()
 			return
 		}
 
-		// First, try to extract userID from token to verify format
-		userId, err := client.ExtractUserIDFromToken(token)
-		if err != nil {
-			logger.Debug("Failed to extract user ID from token", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			c.Abort()
-			return
-		}
-
-		// Validate token with User Service - this now returns both userID and role
-		validatedUserID, userRole, err := userClient.ValidateToken(c.Request.Context(), token)
+		// Verify the signature and expiry, and read user ID, role and permissions
+		claims, err := verifier.Verify(c.Request.Context(), token)
 		if err != nil {
 			logger.Debug("Invalid token", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			if errors.Is(err, auth.ErrTokenRevoked) {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeTokenRevoked, "Token has been revoked")
+			} else {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidToken, "Invalid or expired token")
+			}
 			c.Abort()
 			return
 		}
 
-		// Verify the token belongs to the expected user
-		if validatedUserID != userId {
-			logger.Warn("Token validation failed - userIDs don't match",
-				zap.Int("extracted_userID", userId),
-				zap.Int("validated_userID", validatedUserID))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
+		// Impersonation sessions can be revoked, which only the user service knows about
+		if claims.ImpersonatorID != 0 {
+			validatedUserID, _, err := userClient.ValidateToken(c.Request.Context(), token)
+			if err != nil || validatedUserID != claims.UserID {
+				logger.Debug("Impersonation token rejected by user service", zap.Error(err))
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeImpersonationEnded, "Impersonation session has ended")
+				c.Abort()
+				return
+			}
 		}
 
-		// Set user ID, role, and token in context
-		c.Set("userID", userId)
-		c.Set("userRole", userRole)
+		// Set user ID, role, permissions, and token in context
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("userPermissions", claims.Permissions)
 		c.Set("token", token)
 		c.Next()
 	}
@@ -71,7 +77,7 @@ func RequireRole(userClient *client.UserClient, requiredRole string) gin.Handler
 	return func(c *gin.Context) {
 		_, exists := c.Get("userID")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
 			c.Abort()
 			return
 		}
@@ -85,7 +91,7 @@ func RequireRole(userClient *client.UserClient, requiredRole string) gin.Handler
 
 		// Check if user has the required role
 		if userRole.(string) != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
 			c.Abort()
 			return
 		}
@@ -94,26 +100,96 @@ func RequireRole(userClient *client.UserClient, requiredRole string) gin.Handler
 	}
 }
 
-// ServiceAuthMiddleware creates middleware to authenticate service-to-service calls
-func ServiceAuthMiddleware(serviceKey string, logger *zap.Logger) gin.HandlerFunc {
+// RequirePermission checks that the token grants at least one of the given permissions
+func RequirePermission(requiredPermissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, exists := c.Get("userID")
+		if !exists {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		// Get the permissions from the context (set by AuthMiddleware)
+		value, _ := c.Get("userPermissions")
+		granted, _ := value.([]string)
+
+		for _, required := range requiredPermissions {
+			if HasPermission(granted, required) {
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
+		c.Abort()
+	}
+}
+
+// HasPermission reports whether the granted set covers the required permission.
+// "*" grants everything and "resource:*" grants every action on a resource.
+func HasPermission(granted []string, required string) bool {
+	resource := required
+	if i := strings.Index(required, ":"); i >= 0 {
+		resource = required[:i]
+	}
+
+	for _, p := range granted {
+		if p == "*" || p == required || p == resource+":*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServiceKeyVerifier verifies keys issued to other services by the user service
+type ServiceKeyVerifier interface {
+	// VerifyServiceKey returns the service a key was issued to, or "" if it isn't valid
+	VerifyServiceKey(ctx context.Context, key string) (string, error)
+}
+
+// ServiceAuthMiddleware creates middleware to authenticate service-to-service calls. Calls
+// are accepted with the configured service key, or, when verifier is not nil, with a key
+// issued to the calling service, whose name is then set as "serviceName" in the context.
+func ServiceAuthMiddleware(serviceKey string, verifier ServiceKeyVerifier, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get service key from header
 		headerKey := c.GetHeader("X-Service-Key")
 		if headerKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service key required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Service key required")
 			c.Abort()
 			return
 		}
 
 		// Validate service key
-		if headerKey != serviceKey {
-			logger.Warn("Invalid service key")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service key"})
+		if serviceKey != "" && subtle.ConstantTimeCompare([]byte(headerKey), []byte(serviceKey)) == 1 {
+			c.Next()
+			return
+		}
+
+		serviceName := ""
+		if verifier != nil {
+			var err error
+			serviceName, err = verifier.VerifyServiceKey(c.Request.Context(), headerKey)
+			if err != nil {
+				logger.Error("Failed to verify service key", zap.Error(err))
+				apierror.Send(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Failed to verify service key")
+				c.Abort()
+				return
+			}
+		}
+		if serviceName == "" {
+			logger.Warn("Invalid service key",
+				zap.String("IP", c.ClientIP()),
+				zap.String("Path", c.Request.URL.Path))
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid service key")
 			c.Abort()
 			return
 		}
 
 		// Service is authenticated
+		c.Set("serviceName", serviceName)
 		c.Next()
 	}
 }
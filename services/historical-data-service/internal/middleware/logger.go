@@ -34,6 +34,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("path", path),
 			zap.String("client_ip", clientIP),
 			zap.Duration("latency", latency),
+			zap.String("request_id", c.GetString("request_id")),
 		}
 
 		if userID != nil {
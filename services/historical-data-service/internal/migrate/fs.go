@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// timescaleStatements are the statements of the first migrations that need TimescaleDB.
+// They predate TimescaleDB being optional and have been applied as they are, so rather
+// than editing them the runner leaves these statements out when TimescaleDB is disabled.
+var timescaleStatements = map[string]string{
+	"01_schema.sql":  "CREATE EXTENSION IF NOT EXISTS timescaledb;",
+	"02_indexes.sql": "SELECT create_hypertable('candles', 'candle_time', chunk_time_interval => INTERVAL '1 week');",
+}
+
+// withoutTimescaleDB serves the migrations without the statements needing TimescaleDB
+type withoutTimescaleDB struct {
+	fs.FS
+}
+
+func (f withoutTimescaleDB) Open(name string) (fs.File, error) {
+	statement, ok := timescaleStatements[name]
+	if !ok {
+		return f.FS.Open(name)
+	}
+
+	content, err := fs.ReadFile(f.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := strings.Replace(string(content), statement, "", 1)
+	return &strippedFile{File: file, reader: strings.NewReader(stripped), size: int64(len(stripped))}, nil
+}
+
+// strippedFile is a migration file read from its stripped content
+type strippedFile struct {
+	fs.File
+	reader *strings.Reader
+	size   int64
+}
+
+func (f *strippedFile) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+func (f *strippedFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return strippedInfo{FileInfo: info, size: f.size}, nil
+}
+
+// strippedInfo reports the size of the stripped content
+type strippedInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i strippedInfo) Size() int64 {
+	return i.size
+}
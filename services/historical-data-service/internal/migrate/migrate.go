@@ -0,0 +1,289 @@
+// Package migrate applies the embedded SQL migrations with goose and reports their status.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"services/historical-data-service/migrations"
+
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+)
+
+// advisoryLockID serializes migrations across replicas starting at the same time
+const advisoryLockID = 727_003
+
+// MigrationStatus describes one migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Status summarizes the database schema version
+type Status struct {
+	CurrentVersion int64             `json:"current_version"`
+	LatestVersion  int64             `json:"latest_version"`
+	Pending        int               `json:"pending"`
+	Migrations     []MigrationStatus `json:"migrations"`
+}
+
+// TimescaleDB holds how candles are stored in TimescaleDB. Disabled, the extension isn't
+// installed and candles stay in a plain table.
+type TimescaleDB struct {
+	Enabled       bool
+	ChunkInterval time.Duration // Span of candle times stored in one chunk
+	CompressAfter time.Duration // Age at which chunks are compressed; 0 disables compression
+}
+
+// Runner applies and inspects migrations
+type Runner struct {
+	db        *sql.DB
+	timescale TimescaleDB
+	logger    *zap.Logger
+}
+
+// NewRunner creates a new migration runner
+func NewRunner(db *sql.DB, timescale TimescaleDB, logger *zap.Logger) *Runner {
+	if timescale.Enabled {
+		goose.SetBaseFS(migrations.FS)
+	} else {
+		goose.SetBaseFS(withoutTimescaleDB{migrations.FS})
+	}
+	goose.SetLogger(gooseLogger{logger.Sugar()})
+	goose.SetDialect("postgres") // Only fails for unknown dialects
+
+	return &Runner{
+		db:        db,
+		timescale: timescale,
+		logger:    logger,
+	}
+}
+
+// Up applies all pending migrations in version order. With TimescaleDB enabled, the
+// extension is installed first so the migrations create a hypertable, and the hypertable,
+// compression and continuous aggregates are set up afterwards.
+func (r *Runner) Up(ctx context.Context) error {
+	return r.withLock(ctx, func() error {
+		if r.timescale.Enabled {
+			if _, err := r.db.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS timescaledb"); err != nil {
+				return fmt.Errorf("failed to install timescaledb extension: %w", err)
+			}
+		}
+
+		before, err := goose.GetDBVersionContext(ctx, r.db)
+		if err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+
+		if err := goose.UpContext(ctx, r.db, "."); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		after, err := goose.GetDBVersionContext(ctx, r.db)
+		if err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+
+		r.logger.Info("Database migrations applied",
+			zap.Int64("from_version", before),
+			zap.Int64("to_version", after))
+
+		if r.timescale.Enabled {
+			return r.setupTimescaleDB(ctx)
+		}
+		return nil
+	})
+}
+
+// setupTimescaleDB applies the TimescaleDB settings to candles and fills continuous
+// aggregates that were just created
+func (r *Runner) setupTimescaleDB(ctx context.Context) error {
+	var compressAfter *float64
+	if r.timescale.CompressAfter > 0 {
+		seconds := r.timescale.CompressAfter.Seconds()
+		compressAfter = &seconds
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT view_name FROM setup_timescaledb(make_interval(secs => $1), make_interval(secs => $2))",
+		r.timescale.ChunkInterval.Seconds(), compressAfter)
+	if err != nil {
+		return fmt.Errorf("failed to set up timescaledb: %w", err)
+	}
+
+	var created []string
+	for rows.Next() {
+		var view string
+		if err := rows.Scan(&view); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan continuous aggregate: %w", err)
+		}
+		created = append(created, view)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to set up timescaledb: %w", err)
+	}
+
+	// Refreshing can't run inside a transaction, so new aggregates are filled here
+	for _, view := range created {
+		r.logger.Info("Filling continuous aggregate", zap.String("view", view))
+		if _, err := r.db.ExecContext(ctx, "CALL refresh_continuous_aggregate($1::regclass, NULL, NULL)", view); err != nil {
+			return fmt.Errorf("failed to refresh continuous aggregate %s: %w", view, err)
+		}
+	}
+
+	r.logger.Info("TimescaleDB set up",
+		zap.Duration("chunk_interval", r.timescale.ChunkInterval),
+		zap.Duration("compress_after", r.timescale.CompressAfter),
+		zap.Strings("created_aggregates", created))
+	return nil
+}
+
+// Baseline records every migration up to version as applied without running it,
+// for databases created before migrations were tracked
+func (r *Runner) Baseline(ctx context.Context, version int64) error {
+	return r.withLock(ctx, func() error {
+		status, err := r.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		if _, err := goose.EnsureDBVersionContext(ctx, r.db); err != nil {
+			return fmt.Errorf("failed to create version table: %w", err)
+		}
+
+		marked := 0
+		for _, m := range status.Migrations {
+			if m.Version > version || m.Applied {
+				continue
+			}
+
+			_, err := r.db.ExecContext(ctx,
+				fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, TRUE)", goose.TableName()),
+				m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to baseline migration %d: %w", m.Version, err)
+			}
+			marked++
+		}
+
+		r.logger.Info("Database migrations baselined",
+			zap.Int64("version", version),
+			zap.Int("marked", marked))
+		return nil
+	})
+}
+
+// Status lists the embedded migrations and which of them have been applied
+func (r *Runner) Status(ctx context.Context) (*Status, error) {
+	known, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Migrations: make([]MigrationStatus, 0, len(known))}
+	for _, m := range known {
+		entry := MigrationStatus{
+			Version: m.Version,
+			Name:    strings.TrimSuffix(filepath.Base(m.Source), filepath.Ext(m.Source)),
+		}
+
+		if appliedAt, ok := applied[m.Version]; ok {
+			entry.Applied = true
+			entry.AppliedAt = &appliedAt
+			if m.Version > status.CurrentVersion {
+				status.CurrentVersion = m.Version
+			}
+		} else {
+			status.Pending++
+		}
+
+		if m.Version > status.LatestVersion {
+			status.LatestVersion = m.Version
+		}
+		status.Migrations = append(status.Migrations, entry)
+	}
+
+	return status, nil
+}
+
+// appliedVersions returns when each currently applied migration was applied
+func (r *Runner) appliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", goose.TableName()).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check version table: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	// The latest row per version decides whether it is applied
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+		FROM %s
+		WHERE version_id > 0
+		ORDER BY version_id, id DESC`, goose.TableName()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		if isApplied {
+			applied[version] = appliedAt
+		}
+	}
+
+	return applied, rows.Err()
+}
+
+// withLock runs fn while holding a session advisory lock
+func (r *Runner) withLock(ctx context.Context, fn func() error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+
+	return fn()
+}
+
+// gooseLogger routes goose output through zap
+type gooseLogger struct {
+	logger *zap.SugaredLogger
+}
+
+func (l gooseLogger) Printf(format string, v ...interface{}) {
+	l.logger.Infof(strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (l gooseLogger) Fatalf(format string, v ...interface{}) {
+	l.logger.Fatalf(strings.TrimSuffix(format, "\n"), v...)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// Kinds of background jobs operators can monitor
+const (
+	JobKindDownload = "download"
+	JobKindBacktest = "backtest"
+)
+
+// AdminJob is a background job of the service, a market data download or a backtest, as
+// operators monitor it
+type AdminJob struct {
+	Kind        string  `json:"kind" db:"kind"`
+	ID          int     `json:"id" db:"id"`
+	Status      string  `json:"status" db:"status"`
+	Description string  `json:"description" db:"description"`
+	UserID      *int    `json:"user_id" db:"user_id"`   // Nil for downloads requested by other services
+	Progress    float64 `json:"progress" db:"progress"` // Percent done; of runs finished for backtests
+	Error       *string `json:"error,omitempty" db:"error"`
+	// Running is whether the job runs on the instance that answered. Jobs are spread over
+	// every instance, so false doesn't mean no instance runs it.
+	Running         bool       `json:"running"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	DurationSeconds float64    `json:"duration_seconds" db:"duration_seconds"` // Until now for active jobs
+}
+
+// AdminJobFilter narrows a list of background jobs. Empty fields match every job.
+type AdminJobFilter struct {
+	Kind   string
+	Status string
+	UserID *int
+}
+
+// AdminJobCount is the number of background jobs of a kind with a status
+type AdminJobCount struct {
+	Kind   string `json:"kind" db:"kind"`
+	Status string `json:"status" db:"status"`
+	Count  int    `json:"count" db:"job_count"`
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // BacktestSummary represents the summary view of a backtest
@@ -14,9 +16,10 @@ type BacktestSummary struct {
 	StrategyID    int             `json:"strategy_id" db:"strategy_id"`
 	Date          time.Time       `json:"date" db:"date"`
 	Status        string          `json:"status" db:"status"`
-	SymbolResults json.RawMessage `json:"symbol_results" db:"symbol_results"`
+	SymbolResults json.RawMessage `json:"symbol_results" db:"symbol_results" swaggertype:"array,object"`
 	CompletedRuns int             `json:"completed_runs" db:"completed_runs"`
 	TotalRuns     int             `json:"total_runs" db:"total_runs"`
+	Tags          pq.StringArray  `json:"tags" db:"tags" swaggertype:"array,string"`
 }
 
 // BacktestDetails represents the detailed view of a backtest
@@ -33,7 +36,13 @@ type BacktestDetails struct {
 	Status          string          `json:"status" db:"status"`
 	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
 	CompletedAt     *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
-	RunResults      json.RawMessage `json:"run_results" db:"run_results"`
+	RunResults      json.RawMessage `json:"run_results" db:"run_results" swaggertype:"array,object"`
+	// Nil for backtests created before settings were stored
+	Settings *BacktestSettings `json:"settings,omitempty" db:"settings"`
+	Tags     pq.StringArray    `json:"tags" db:"-" swaggertype:"array,string"`
+
+	// Only populated for service-to-service requests
+	UserID int `json:"user_id,omitempty" db:"-"`
 }
 
 // BacktestResults represents the performance results of a backtest
@@ -47,7 +56,7 @@ type BacktestResults struct {
 	FinalCapital     float64         `json:"final_capital" binding:"required"`
 	TotalReturn      float64         `json:"total_return" binding:"required"`
 	AnnualizedReturn float64         `json:"annualized_return" binding:"required"`
-	ResultsJSON      json.RawMessage `json:"results_json" binding:"required"`
+	ResultsJSON      json.RawMessage `json:"results_json" binding:"required" swaggertype:"object"`
 }
 
 // Value implements the driver.Valuer interface for BacktestResults
@@ -79,17 +88,331 @@ type BacktestTrade struct {
 	ProfitLoss        *float64   `json:"profit_loss,omitempty" db:"profit_loss"`
 	ProfitLossPercent *float64   `json:"profit_loss_percent,omitempty" db:"profit_loss_percent"`
 	ExitReason        *string    `json:"exit_reason,omitempty" db:"exit_reason"`
+
+	// Annotations are the notes and labels added to the trade while reviewing the run
+	Annotations []BacktestTradeAnnotation `json:"annotations,omitempty" db:"-"`
+}
+
+// BacktestTradeAnnotation is a note with labels a user added to a backtest trade
+type BacktestTradeAnnotation struct {
+	ID        int            `json:"id" db:"id"`
+	TradeID   int            `json:"trade_id" db:"trade_id"`
+	UserID    int            `json:"user_id" db:"user_id"`
+	Note      string         `json:"note" db:"note"`
+	Labels    pq.StringArray `json:"labels" db:"labels" swaggertype:"array,string"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// BacktestTradeAnnotationCreate is the request to annotate a backtest trade. At least one
+// of note and labels is required.
+type BacktestTradeAnnotationCreate struct {
+	Note   string   `json:"note" binding:"max=2000"`
+	Labels []string `json:"labels,omitempty" binding:"max=10,dive,min=1,max=50"`
+}
+
+// BacktestRunInfo is a backtest run with the parameters of the backtest it belongs to
+type BacktestRunInfo struct {
+	BacktestRunID  int       `json:"backtest_run_id" db:"backtest_run_id"`
+	BacktestID     int       `json:"backtest_id" db:"backtest_id"`
+	SymbolID       int       `json:"symbol_id" db:"symbol_id"`
+	Status         string    `json:"status" db:"status"`
+	Timeframe      string    `json:"timeframe" db:"timeframe"`
+	StartDate      time.Time `json:"start_date" db:"start_date"`
+	EndDate        time.Time `json:"end_date" db:"end_date"`
+	InitialCapital float64   `json:"initial_capital" db:"initial_capital"`
+}
+
+// BacktestTradeBatchError describes why a trade in a batch was rejected
+type BacktestTradeBatchError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BacktestTradeBatchReport describes the outcome of adding a batch of trades.
+// Valid trades are stored even when others in the batch are rejected.
+type BacktestTradeBatchReport struct {
+	Received int                       `json:"received"`
+	Inserted int                       `json:"inserted"`
+	Rejected int                       `json:"rejected"`
+	Errors   []BacktestTradeBatchError `json:"errors"`
+}
+
+// BacktestTradeCursor marks the last trade of a page of trades. The sort order is part
+// of the cursor so it can't be reused with a different order.
+type BacktestTradeCursor struct {
+	SortBy        string `json:"sort_by"`
+	SortDirection string `json:"sort_direction"`
+	Value         string `json:"value"`
+	ID            int    `json:"id"`
 }
 
 // BacktestRequest represents the input parameters for a backtest
 type BacktestRequest struct {
-	StrategyID      int       `json:"strategy_id" binding:"required"`
-	StrategyVersion int       `json:"strategy_version,omitempty"`
-	Name            string    `json:"name,omitempty"`
-	Description     string    `json:"description,omitempty"`
-	Timeframe       string    `json:"timeframe" binding:"required"`
-	SymbolIDs       []int     `json:"symbol_ids" binding:"required,min=1"`
-	StartDate       time.Time `json:"start_date" binding:"required"`
-	EndDate         time.Time `json:"end_date" binding:"required"`
-	InitialCapital  float64   `json:"initial_capital" binding:"required,min=1"`
+	StrategyID      int `json:"strategy_id" binding:"required"`
+	StrategyVersion int `json:"strategy_version,omitempty"`
+	// Name may use the variables {strategy}, {symbol}, {timeframe} and {date}
+	Name           string    `json:"name,omitempty" binding:"max=100"`
+	Description    string    `json:"description,omitempty"`
+	Timeframe      string    `json:"timeframe" binding:"required"`
+	SymbolIDs      []int     `json:"symbol_ids" binding:"required_without=WatchlistID,omitempty,min=1"`
+	StartDate      time.Time `json:"start_date" binding:"required"`
+	EndDate        time.Time `json:"end_date" binding:"required"`
+	InitialCapital float64   `json:"initial_capital" binding:"required,min=1"`
+
+	// Optional trading parameters; omitted ones take the defaults
+	MarketType     *string  `json:"market_type,omitempty"`
+	Leverage       *float64 `json:"leverage,omitempty"`
+	CommissionRate *float64 `json:"commission_rate,omitempty"`
+	SlippageRate   *float64 `json:"slippage_rate,omitempty"`
+	AllowShort     *bool    `json:"allow_short,omitempty"`
+	PositionSizing *string  `json:"position_sizing,omitempty"`
+
+	// PositionSizingParams are the parameters of the position_sizing mode; omitted ones
+	// take the mode's defaults
+	PositionSizingParams *PositionSizingParams `json:"position_sizing_params,omitempty"`
+
+	// SlippageModel charges fills with a slippage model other than a fixed slippage_rate
+	SlippageModel *SlippageModel `json:"slippage_model,omitempty"`
+
+	// IncludeFundingRates charges or credits the funding of open futures positions, from
+	// the funding rates downloaded for the symbols
+	IncludeFundingRates *bool `json:"include_funding_rates,omitempty"`
+
+	// PinData refuses reruns once the candles the backtest was created on change
+	PinData bool `json:"pin_data,omitempty"`
+
+	// WatchlistID runs the backtest on the symbols of a watchlist instead of symbol_ids
+	WatchlistID *int `json:"watchlist_id,omitempty"`
+
+	// Tags label the backtest for filtering, next to the tags added automatically
+	Tags []string `json:"tags,omitempty" binding:"max=20,dive,min=1,max=50"`
+}
+
+// Market types and position sizing strategies supported by the backtesting engine
+const (
+	MarketTypeSpot    = "spot"
+	MarketTypeFutures = "futures"
+
+	PositionSizingFixed            = "fixed"             // 10% of equity
+	PositionSizingPercentage       = "percentage"        // risk_percent of equity
+	PositionSizingRiskBased        = "risk_based"        // risk_percent of equity lost at the stop loss
+	PositionSizingFixedFractional  = "fixed_fractional"  // fraction_percent of equity
+	PositionSizingKelly            = "kelly"             // A multiple of the Kelly fraction of recent trades, capped
+	PositionSizingVolatilityTarget = "volatility_target" // risk_percent of equity lost at atr_multiple ATRs, capped
+)
+
+// PositionSizingParams are the parameters of a position sizing mode. Only those of the
+// backtest's mode are set once resolved, so the stored settings tell how sizing was computed.
+type PositionSizingParams struct {
+	// FractionPercent is the share of equity a position takes under fixed_fractional, and
+	// under kelly until MinTrades trades have closed
+	FractionPercent float64 `json:"fraction_percent,omitempty"`
+
+	// RiskPercent is the share of equity a position risks under percentage, risk_based and
+	// volatility_target
+	RiskPercent float64 `json:"risk_percent,omitempty"`
+
+	// KellyMultiplier scales the Kelly fraction, estimated from the last LookbackTrades
+	// closed trades; 0.5 is half Kelly
+	KellyMultiplier float64 `json:"kelly_multiplier,omitempty"`
+	LookbackTrades  int     `json:"lookback_trades,omitempty"`
+	MinTrades       int     `json:"min_trades,omitempty"`
+
+	// ATRPeriod and ATRMultiple set the price move volatility_target sizes positions
+	// against: ATRMultiple times the average true range over ATRPeriod candles
+	ATRPeriod   int     `json:"atr_period,omitempty"`
+	ATRMultiple float64 `json:"atr_multiple,omitempty"`
+
+	// MaxFractionPercent caps the share of equity a position takes under kelly and
+	// volatility_target
+	MaxFractionPercent float64 `json:"max_fraction_percent,omitempty"`
+}
+
+// Slippage models fills of a backtest can be charged with
+const (
+	SlippageModelFixed               = "fixed"                // The same basis points on every fill
+	SlippageModelVolumeParticipation = "volume_participation" // In proportion to the fill's share of the candle volume
+	SlippageModelSpread              = "spread"               // Half the spread plus walking the captured order book
+)
+
+// SlippageModel configures how fills of a backtest are charged slippage. Which of the
+// basis point settings apply depends on the type.
+type SlippageModel struct {
+	Type string `json:"type"`
+
+	// BasisPoints is the slippage of every fill under the fixed model
+	BasisPoints float64 `json:"basis_points,omitempty"`
+
+	// ImpactBasisPoints is the slippage of a fill trading a whole candle's volume under
+	// the volume participation model; smaller fills pay in proportion
+	ImpactBasisPoints float64 `json:"impact_basis_points,omitempty"`
+
+	// MaxBasisPoints caps the slippage of one fill under the volume participation and
+	// spread models
+	MaxBasisPoints float64 `json:"max_basis_points,omitempty"`
+
+	// FallbackBasisPoints is charged by the spread model on fills without an order book
+	// snapshot captured shortly before them
+	FallbackBasisPoints float64 `json:"fallback_basis_points,omitempty"`
+}
+
+// BacktestSettings holds the trading parameters a backtest runs with.
+// Commission and slippage rates are percentages of the traded value.
+type BacktestSettings struct {
+	MarketType     string  `json:"market_type"`
+	Leverage       float64 `json:"leverage"`
+	CommissionRate float64 `json:"commission_rate"`
+	SlippageRate   float64 `json:"slippage_rate"`
+	AllowShort     bool    `json:"allow_short"`
+	PositionSizing string  `json:"position_sizing"`
+
+	// PositionSizingParams is unset on backtests created before sizing parameters, which
+	// ran percentage and risk_based sizing with a risk_percent of 2
+	PositionSizingParams *PositionSizingParams `json:"position_sizing_params,omitempty"`
+
+	// SlippageModel is unset on backtests created before slippage models, which charged
+	// slippage_rate on every fill
+	SlippageModel       *SlippageModel `json:"slippage_model,omitempty"`
+	IncludeFundingRates bool           `json:"include_funding_rates,omitempty"`
+
+	// AuxiliaryTimeframes are the timeframes longer than the backtest's that the strategy's
+	// indicators are computed on, shortest first. It's derived from the strategy structure.
+	AuxiliaryTimeframes []string `json:"auxiliary_timeframes,omitempty"`
+}
+
+// DefaultBacktestSettings returns the settings used when a request doesn't specify any
+func DefaultBacktestSettings() BacktestSettings {
+	return BacktestSettings{
+		MarketType:     MarketTypeSpot,
+		Leverage:       1.0,
+		CommissionRate: 0.1,
+		SlippageRate:   0.05,
+		AllowShort:     false,
+		PositionSizing: PositionSizingFixed,
+	}
+}
+
+// Value implements the driver.Valuer interface for BacktestSettings
+func (s BacktestSettings) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for BacktestSettings
+func (s *BacktestSettings) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &s)
+}
+
+// BacktestRunRetry is a failed backtest run with how often it was retried
+type BacktestRunRetry struct {
+	RunID      int `json:"run_id" db:"run_id"`
+	SymbolID   int `json:"symbol_id" db:"symbol_id"`
+	RetryCount int `json:"retry_count" db:"retry_count"`
+	MaxRetries int `json:"max_retries" db:"max_retries"`
+}
+
+// BacktestRetry describes the failed runs of a backtest queued again and those
+// left failed because they have no retries left
+type BacktestRetry struct {
+	BacktestID int                `json:"backtest_id"`
+	Retried    []BacktestRunRetry `json:"retried"`
+	Exhausted  []BacktestRunRetry `json:"exhausted"`
+}
+
+// BacktestDataManifest records the candles a backtest was created on, so its results can be
+// reproduced and audited. Changed is set when any symbol's data differs now.
+type BacktestDataManifest struct {
+	BacktestID int                          `json:"backtest_id"`
+	Pinned     bool                         `json:"pinned"`
+	Timeframe  string                       `json:"timeframe"`
+	StartDate  time.Time                    `json:"start_date"`
+	EndDate    time.Time                    `json:"end_date"`
+	Changed    bool                         `json:"changed"`
+	Symbols    []BacktestDataManifestSymbol `json:"symbols"`
+}
+
+// BacktestDataManifestSymbol holds the candles one symbol had in the backtest's range when
+// it was created and the candles it has now
+type BacktestDataManifestSymbol struct {
+	SymbolID        int        `json:"symbol_id" db:"symbol_id"`
+	Symbol          string     `json:"symbol" db:"symbol"`
+	CandleCount     int64      `json:"candle_count" db:"candle_count"`
+	FirstCandleTime *time.Time `json:"first_candle_time,omitempty" db:"first_candle_time"`
+	LastCandleTime  *time.Time `json:"last_candle_time,omitempty" db:"last_candle_time"`
+	Checksum        string     `json:"checksum" db:"checksum"`
+	RecordedAt      time.Time  `json:"recorded_at" db:"recorded_at"`
+
+	CurrentCandleCount     int64      `json:"current_candle_count" db:"current_candle_count"`
+	CurrentFirstCandleTime *time.Time `json:"current_first_candle_time,omitempty" db:"current_first_candle_time"`
+	CurrentLastCandleTime  *time.Time `json:"current_last_candle_time,omitempty" db:"current_last_candle_time"`
+	CurrentChecksum        string     `json:"current_checksum" db:"current_checksum"`
+	Changed                bool       `json:"changed" db:"-"`
+}
+
+// StrategyBacktest is a backtest of one version of a strategy with metrics summarized
+// over its completed runs. The metrics are nil until a run completes.
+type StrategyBacktest struct {
+	BacktestID      int        `json:"backtest_id" db:"backtest_id"`
+	StrategyID      int        `json:"strategy_id" db:"strategy_id"`
+	StrategyVersion int        `json:"strategy_version" db:"strategy_version"`
+	Name            string     `json:"name" db:"name"`
+	Timeframe       string     `json:"timeframe" db:"timeframe"`
+	StartDate       time.Time  `json:"start_date" db:"start_date"`
+	EndDate         time.Time  `json:"end_date" db:"end_date"`
+	InitialCapital  float64    `json:"initial_capital" db:"initial_capital"`
+	Status          string     `json:"status" db:"status"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	TotalRuns       int        `json:"total_runs" db:"total_runs"`
+	CompletedRuns   int        `json:"completed_runs" db:"completed_runs"`
+	TotalTrades     int        `json:"total_trades" db:"total_trades"`
+	AvgTotalReturn  *float64   `json:"avg_total_return" db:"avg_total_return"`
+	BestTotalReturn *float64   `json:"best_total_return" db:"best_total_return"`
+	AvgSharpeRatio  *float64   `json:"avg_sharpe_ratio" db:"avg_sharpe_ratio"`
+	MaxDrawdown     *float64   `json:"max_drawdown" db:"max_drawdown"`
+}
+
+// StrategyVersionSummary summarizes the backtests of one version of a strategy
+type StrategyVersionSummary struct {
+	StrategyVersion    int       `json:"strategy_version" db:"strategy_version"`
+	Backtests          int       `json:"backtests" db:"backtests"`
+	CompletedBacktests int       `json:"completed_backtests" db:"completed_backtests"`
+	CompletedRuns      int       `json:"completed_runs" db:"completed_runs"`
+	AvgTotalReturn     *float64  `json:"avg_total_return" db:"avg_total_return"`
+	BestTotalReturn    *float64  `json:"best_total_return" db:"best_total_return"`
+	AvgSharpeRatio     *float64  `json:"avg_sharpe_ratio" db:"avg_sharpe_ratio"`
+	AvgMaxDrawdown     *float64  `json:"avg_max_drawdown" db:"avg_max_drawdown"`
+	LastBacktestAt     time.Time `json:"last_backtest_at" db:"last_backtest_at"`
+}
+
+// Statuses an engine reports for a run with the async protocol
+const (
+	EngineRunProgress  = "progress"
+	EngineRunCompleted = "completed"
+	EngineRunFailed    = "failed"
+)
+
+// EngineCallback is a report of the backtesting engine about a run it was submitted.
+// Progress reports extend the run's lease; completed and failed ones finish the run.
+type EngineCallback struct {
+	JobToken      string   `json:"job_token" binding:"required,max=64"`
+	BacktestRunID int      `json:"backtest_run_id" binding:"required"`
+	Status        string   `json:"status" binding:"required,oneof=progress completed failed"`
+	Progress      *float64 `json:"progress" binding:"omitempty,min=0,max=1"` // Fraction of the run done
+	Error         string   `json:"error"`                                    // Why a failed run failed
+}
+
+// EngineRunReport is the state of a backtest after an engine callback or lease expiry
+type EngineRunReport struct {
+	BacktestID    int `json:"backtest_id" db:"backtest_id"`
+	RemainingRuns int `json:"remaining_runs" db:"remaining_runs"`
+}
+
+// EngineRunError is the error the engine reported for a failed run
+type EngineRunError struct {
+	SymbolID     int    `db:"symbol_id"`
+	ErrorMessage string `db:"error_message"`
 }
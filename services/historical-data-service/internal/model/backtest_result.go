@@ -11,7 +11,21 @@ type BacktestResult struct {
 	EquityCurve []float64        `json:"equity_curve"`
 	EquityTimes []string         `json:"equity_times"`
 	Metrics     BacktestMetrics  `json:"metrics"`
-	ResultsJSON *json.RawMessage `json:"results_json,omitempty"`
+	ResultsJSON *json.RawMessage `json:"results_json,omitempty" swaggertype:"object"`
+}
+
+// EquityPoint is the equity of a backtest run at one point in time
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// EquityCurve is the equity of a backtest run over time, used to render its charts
+type EquityCurve struct {
+	BacktestRunID  int
+	Status         string
+	InitialCapital float64
+	Points         []EquityPoint
 }
 
 // BacktestMetrics represents performance metrics from a backtest
@@ -74,3 +88,24 @@ type BacktestParameters struct {
 	TakeProfit     float64 `json:"take_profit"`     // In percentage
 	TrailingStop   float64 `json:"trailing_stop"`   // In percentage
 }
+
+// BacktestRunMetrics are the performance metrics saved with the results of a backtest run
+type BacktestRunMetrics struct {
+	TotalTrades      int      `db:"total_trades"`
+	WinningTrades    int      `db:"winning_trades"`
+	LosingTrades     int      `db:"losing_trades"`
+	ProfitFactor     *float64 `db:"profit_factor"`
+	SharpeRatio      *float64 `db:"sharpe_ratio"`
+	MaxDrawdown      *float64 `db:"max_drawdown"`
+	FinalCapital     *float64 `db:"final_capital"`
+	TotalReturn      *float64 `db:"total_return"`
+	AnnualizedReturn *float64 `db:"annualized_return"`
+}
+
+// BacktestRunSummary is a backtest run with its symbol and metrics, as summarized in trade
+// exports
+type BacktestRunSummary struct {
+	BacktestRunInfo
+	Symbol  string
+	Metrics *BacktestRunMetrics // Nil until the run's results are saved
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// TradingCalendar holds the market hours of an exchange
+type TradingCalendar struct {
+	Exchange string           `json:"exchange" db:"exchange"`
+	Name     string           `json:"name" db:"name"`
+	Timezone string           `json:"timezone" db:"timezone"`
+	Sessions []TradingSession `json:"sessions" db:"-"`
+	Holidays []TradingHoliday `json:"holidays,omitempty" db:"-"`
+}
+
+// TradingSession is a weekly session in the exchange's local time. Times are HH:MM and
+// DayOfWeek 0 is Sunday.
+type TradingSession struct {
+	Exchange  string `json:"-" db:"exchange"`
+	DayOfWeek int    `json:"day_of_week" db:"day_of_week"`
+	OpenTime  string `json:"open_time" db:"open_time"`
+	CloseTime string `json:"close_time" db:"close_time"`
+}
+
+// TradingHoliday is a day an exchange is closed, or closes early at EarlyClose
+type TradingHoliday struct {
+	Exchange   string  `json:"-" db:"exchange"`
+	Date       string  `json:"date" db:"date"`
+	Name       string  `json:"name" db:"name"`
+	EarlyClose *string `json:"early_close,omitempty" db:"early_close"`
+}
+
+// TradingHolidayRequest represents the input for adding a holiday to an exchange calendar
+type TradingHolidayRequest struct {
+	Date       string  `json:"date" binding:"required"`
+	Name       string  `json:"name" binding:"required"`
+	EarlyClose *string `json:"early_close,omitempty"`
+}
+
+// ExchangeSession is a concrete trading session of an exchange
+type ExchangeSession struct {
+	Date       string    `json:"date"`
+	Open       time.Time `json:"open"`
+	Close      time.Time `json:"close"`
+	EarlyClose bool      `json:"early_close,omitempty"`
+}
+
+// ExchangeSessions lists the sessions of an exchange within a time range
+type ExchangeSessions struct {
+	Exchange  string            `json:"exchange"`
+	Timezone  string            `json:"timezone"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+	Sessions  []ExchangeSession `json:"sessions"`
+}
@@ -26,6 +26,45 @@ type CandleBatch struct {
 	Volume   float64   `json:"volume"`
 }
 
+// CandleCacheStats reports how well the in-process candle cache is serving repeated reads
+type CandleCacheStats struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Candles       int     `json:"candles"`
+	MaxCandles    int     `json:"max_candles"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Evictions     int64   `json:"evictions"`
+	Invalidations int64   `json:"invalidations"`
+}
+
+// CandleImportReport describes the outcome of importing a batch of candles
+type CandleImportReport struct {
+	Received int `json:"received"`
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+}
+
+// Stored returns the number of candles that were written (inserted or updated)
+func (r CandleImportReport) Stored() int {
+	return r.Inserted + r.Updated
+}
+
+// Add accumulates another report into this one
+func (r *CandleImportReport) Add(other CandleImportReport) {
+	r.Received += other.Received
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Skipped += other.Skipped
+}
+
+// CandleCursor marks the last (oldest) candle of a page of candles
+type CandleCursor struct {
+	Time time.Time `json:"time"`
+}
+
 // MarketDataQuery represents a query for candle data
 type MarketDataQuery struct {
 	SymbolID  int        `json:"symbol_id" form:"symbol_id" binding:"required"`
@@ -36,6 +75,56 @@ type MarketDataQuery struct {
 	Page      *int       `json:"page" form:"page"`
 }
 
+// DataPreviewQuery represents a request to preview the candles a backtest would use
+type DataPreviewQuery struct {
+	SymbolID  int
+	Timeframe string
+	Start     time.Time
+	End       time.Time
+	Points    int
+}
+
+// DataPreview summarizes the candles of a symbol and timeframe in a date range so they
+// can be checked before running a backtest. Series is downsampled to buckets of
+// BucketMinutes for charting.
+type DataPreview struct {
+	SymbolID      int         `json:"symbol_id"`
+	Timeframe     string      `json:"timeframe"`
+	Start         time.Time   `json:"start"`
+	End           time.Time   `json:"end"`
+	CandleCount   int         `json:"candle_count"`
+	FirstCandle   *time.Time  `json:"first_candle,omitempty"`
+	LastCandle    *time.Time  `json:"last_candle,omitempty"`
+	Gaps          []DateRange `json:"gaps"`
+	BucketMinutes int         `json:"bucket_minutes"`
+	Series        []Candle    `json:"series"`
+}
+
+// CandleDeletionQuery represents an admin request to delete a symbol's candles in a range
+type CandleDeletionQuery struct {
+	SymbolID  int
+	Timeframe string
+	Start     time.Time
+	End       time.Time
+	DryRun    bool
+	Reason    string
+}
+
+// CandleDeletion reports the candles deleted from a range, or those a dry run would
+// delete. The range is widened to whole candles of the timeframe and ends exclusively.
+type CandleDeletion struct {
+	SymbolID          int        `json:"symbol_id"`
+	Timeframe         string     `json:"timeframe"`
+	DryRun            bool       `json:"dry_run"`
+	Start             time.Time  `json:"start" db:"range_start"`
+	End               time.Time  `json:"end" db:"range_end"`
+	CandleCount       int64      `json:"candle_count" db:"candle_count"`
+	FirstCandle       *time.Time `json:"first_candle,omitempty" db:"first_candle_time"`
+	LastCandle        *time.Time `json:"last_candle,omitempty" db:"last_candle_time"`
+	RemainingCandles  int64      `json:"remaining_candles" db:"remaining_candles"`
+	BacktestsAffected int64      `json:"backtests_affected" db:"backtests_affected"`
+}
+
 // DateRange represents a range of dates
 type DateRange struct {
 	Start time.Time `json:"start"`
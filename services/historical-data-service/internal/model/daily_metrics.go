@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// DailyUserMetrics represents a per-user daily rollup of backtest activity
+type DailyUserMetrics struct {
+	MetricDate         time.Time `json:"metric_date" db:"metric_date"`
+	UserID             int       `json:"user_id" db:"user_id"`
+	BacktestsCreated   int       `json:"backtests_created" db:"backtests_created"`
+	BacktestsCompleted int       `json:"backtests_completed" db:"backtests_completed"`
+	BacktestsFailed    int       `json:"backtests_failed" db:"backtests_failed"`
+	RunsCompleted      int       `json:"runs_completed" db:"runs_completed"`
+	TotalTrades        int       `json:"total_trades" db:"total_trades"`
+	AvgTotalReturn     *float64  `json:"avg_total_return,omitempty" db:"avg_total_return"`
+	BestTotalReturn    *float64  `json:"best_total_return,omitempty" db:"best_total_return"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DailyStrategyMetrics represents a per-strategy daily rollup of backtest activity
+type DailyStrategyMetrics struct {
+	MetricDate         time.Time `json:"metric_date" db:"metric_date"`
+	StrategyID         int       `json:"strategy_id" db:"strategy_id"`
+	BacktestsCreated   int       `json:"backtests_created" db:"backtests_created"`
+	BacktestsCompleted int       `json:"backtests_completed" db:"backtests_completed"`
+	UniqueUsers        int       `json:"unique_users" db:"unique_users"`
+	RunsCompleted      int       `json:"runs_completed" db:"runs_completed"`
+	TotalTrades        int       `json:"total_trades" db:"total_trades"`
+	WinningTrades      int       `json:"winning_trades" db:"winning_trades"`
+	AvgTotalReturn     *float64  `json:"avg_total_return,omitempty" db:"avg_total_return"`
+	AvgSharpeRatio     *float64  `json:"avg_sharpe_ratio,omitempty" db:"avg_sharpe_ratio"`
+	AvgMaxDrawdown     *float64  `json:"avg_max_drawdown,omitempty" db:"avg_max_drawdown"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DailySymbolMetrics represents a per-symbol daily rollup of data and backtest usage
+type DailySymbolMetrics struct {
+	MetricDate     time.Time `json:"metric_date" db:"metric_date"`
+	SymbolID       int       `json:"symbol_id" db:"symbol_id"`
+	CandleCount    int64     `json:"candle_count" db:"candle_count"`
+	BacktestRuns   int       `json:"backtest_runs" db:"backtest_runs"`
+	RunsCompleted  int       `json:"runs_completed" db:"runs_completed"`
+	TotalTrades    int       `json:"total_trades" db:"total_trades"`
+	AvgTotalReturn *float64  `json:"avg_total_return,omitempty" db:"avg_total_return"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DailyMetricsRefreshResult reports how many rollup rows were written for a day
+type DailyMetricsRefreshResult struct {
+	MetricDate   time.Time `json:"metric_date"`
+	UserRows     int       `json:"user_rows" db:"user_rows"`
+	StrategyRows int       `json:"strategy_rows" db:"strategy_rows"`
+	SymbolRows   int       `json:"symbol_rows" db:"symbol_rows"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// OpenInterestPeriods are the sampling periods open interest is available in, shortest first
+var OpenInterestPeriods = []string{"5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+
+// FundingRate is a funding event of a perpetual futures contract. A positive rate means
+// longs pay shorts.
+type FundingRate struct {
+	SymbolID    int       `json:"symbol_id" db:"symbol_id"`
+	FundingTime time.Time `json:"funding_time" db:"funding_time"`
+	FundingRate float64   `json:"funding_rate" db:"funding_rate"`
+	MarkPrice   *float64  `json:"mark_price,omitempty" db:"mark_price"`
+}
+
+// OpenInterest is the open interest of a perpetual futures contract at the end of a period
+type OpenInterest struct {
+	SymbolID          int       `json:"symbol_id" db:"symbol_id"`
+	Period            string    `json:"period" db:"period"`
+	SampleTime        time.Time `json:"sample_time" db:"sample_time"`
+	OpenInterest      float64   `json:"open_interest" db:"open_interest"`             // In contracts
+	OpenInterestValue float64   `json:"open_interest_value" db:"open_interest_value"` // In the quote asset
+}
+
+// DerivativesCoverage reports the funding rates and open interest stored for a symbol
+type DerivativesCoverage struct {
+	SymbolID             int        `json:"-" db:"symbol_id"`
+	FundingRateCount     int64      `json:"funding_rate_count" db:"funding_rate_count"`
+	FundingRateEarliest  *time.Time `json:"funding_rate_earliest,omitempty" db:"funding_rate_earliest"`
+	FundingRateLatest    *time.Time `json:"funding_rate_latest,omitempty" db:"funding_rate_latest"`
+	OpenInterestCount    int64      `json:"open_interest_count" db:"open_interest_count"`
+	OpenInterestEarliest *time.Time `json:"open_interest_earliest,omitempty" db:"open_interest_earliest"`
+	OpenInterestLatest   *time.Time `json:"open_interest_latest,omitempty" db:"open_interest_latest"`
+	OpenInterestPeriods  []string   `json:"open_interest_periods" db:"-"`
+}
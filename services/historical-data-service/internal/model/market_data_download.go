@@ -22,6 +22,10 @@ type MarketDataDownloadRequest struct {
 	Timeframe string    `json:"timeframe" binding:"required"`
 	StartDate time.Time `json:"start_date" binding:"required"`
 	EndDate   time.Time `json:"end_date" binding:"required"`
+
+	// Perpetual futures data fetched over the same range after the candles; Binance only
+	IncludeFundingRates bool `json:"include_funding_rates,omitempty"`
+	IncludeOpenInterest bool `json:"include_open_interest,omitempty"`
 }
 
 // MarketDataDownloadJob represents a job to download market data
@@ -42,6 +46,11 @@ type MarketDataDownloadJob struct {
 	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 	LastProcessedTime *time.Time `json:"last_processed_time,omitempty" db:"last_processed_time"`
+	Attempts          int        `json:"attempts" db:"attempts"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+
+	IncludeFundingRates bool `json:"include_funding_rates" db:"include_funding_rates"`
+	IncludeOpenInterest bool `json:"include_open_interest" db:"include_open_interest"`
 }
 
 // MarketDataDownloadStatus represents the status of a download job
@@ -60,6 +69,59 @@ type MarketDataDownloadStatus struct {
 	StartDate         time.Time  `json:"start_date"`
 	EndDate           time.Time  `json:"end_date"`
 	LastProcessedTime *time.Time `json:"last_processed_time,omitempty"`
+	Attempts          int        `json:"attempts"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty"`
+
+	IncludeFundingRates bool `json:"include_funding_rates"`
+	IncludeOpenInterest bool `json:"include_open_interest"`
+	// Bandwidth is nil until the job made its first request
+	Bandwidth *DownloadBandwidth `json:"bandwidth,omitempty"`
+}
+
+// DownloadJobMetrics accumulates the provider requests of a download job across attempts
+type DownloadJobMetrics struct {
+	JobID             int       `db:"job_id"`
+	Requests          int       `db:"requests"`
+	RequestWeight     int       `db:"request_weight"`
+	BytesDownloaded   int64     `db:"bytes_downloaded"`
+	CandlesDownloaded int64     `db:"candles_downloaded"`
+	FetchMillis       int64     `db:"fetch_ms"`
+	ThrottledMillis   int64     `db:"throttled_ms"`
+	RateLimited       int       `db:"rate_limited"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+// DownloadBandwidth reports how much a download job fetched from its provider and how
+// fast. Rates are over the time spent fetching, excluding waits for the rate-limit budget.
+type DownloadBandwidth struct {
+	Requests          int     `json:"requests"`
+	RequestWeight     int     `json:"request_weight"`
+	BytesDownloaded   int64   `json:"bytes_downloaded"`
+	CandlesDownloaded int64   `json:"candles_downloaded"`
+	FetchSeconds      float64 `json:"fetch_seconds"`
+	ThrottledSeconds  float64 `json:"throttled_seconds"`
+	RateLimited       int     `json:"rate_limited"`
+	BytesPerSecond    float64 `json:"bytes_per_second"`
+	CandlesPerSecond  float64 `json:"candles_per_second"`
+}
+
+// RateBudgetStats reports a provider's request weight budget shared by all downloads
+type RateBudgetStats struct {
+	Provider string `json:"provider"`
+	// ConfiguredWeight is the weight allowed per window; Weight is lower while the budget
+	// recovers from throttling
+	ConfiguredWeight int        `json:"configured_weight"`
+	Weight           int        `json:"weight"`
+	WindowSeconds    float64    `json:"window_seconds"`
+	Used             int        `json:"used"`
+	Remaining        int        `json:"remaining"`
+	PausedUntil      *time.Time `json:"paused_until,omitempty"`
+	Requests         int64      `json:"requests"`
+	WeightSpent      int64      `json:"weight_spent"`
+	Throttled        int64      `json:"throttled"`
+	Banned           int64      `json:"banned"`
+	Waits            int64      `json:"waits"`
+	WaitedSeconds    float64    `json:"waited_seconds"`
 }
 
 // SymbolDataStatus represents the status of a symbol's data
@@ -82,4 +144,6 @@ type DataInventoryItem struct {
 	EarliestDate        time.Time `db:"earliest_date" json:"earliest_date"`
 	LatestDate          time.Time `db:"latest_date" json:"latest_date"`
 	AvailableTimeframes []string  `db:"available_timeframes" json:"available_timeframes"`
+	// Derivatives is nil for symbols without funding rates or open interest
+	Derivatives *DerivativesCoverage `db:"-" json:"derivatives,omitempty"`
 }
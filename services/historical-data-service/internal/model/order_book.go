@@ -0,0 +1,44 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// OrderBookDepths are the numbers of levels per side an order book can be captured at
+var OrderBookDepths = []int{5, 10, 20}
+
+// OrderBookLevel is a price level of an order book and the quantity resting at it
+type OrderBookLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"` // In the base asset
+}
+
+// OrderBookLevels are the levels of one side of an order book, best price first
+type OrderBookLevels []OrderBookLevel
+
+// Value implements the driver.Valuer interface for OrderBookLevels
+func (l OrderBookLevels) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for OrderBookLevels
+func (l *OrderBookLevels) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, l)
+}
+
+// OrderBookSnapshot is the top of a symbol's order book at a point in time, as captured
+// from the provider's depth stream
+type OrderBookSnapshot struct {
+	SymbolID     int             `json:"symbol_id" db:"symbol_id"`
+	SnapshotTime time.Time       `json:"snapshot_time" db:"snapshot_time"`
+	LastUpdateID int64           `json:"last_update_id" db:"last_update_id"` // The provider's book update the snapshot reflects
+	Bids         OrderBookLevels `json:"bids" db:"bids" swaggertype:"array,object"`
+	Asks         OrderBookLevels `json:"asks" db:"asks" swaggertype:"array,object"`
+}
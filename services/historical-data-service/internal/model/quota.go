@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// Quota names
+const (
+	QuotaConcurrentBacktests = "concurrent_backtests"
+	QuotaBacktestsPerDay     = "backtests_per_day"
+	QuotaDownloadJobsPerDay  = "download_jobs_per_day"
+	QuotaStoredCandles       = "stored_candles"
+)
+
+// QuotaUsage represents a user's current usage of each quota
+type QuotaUsage struct {
+	ConcurrentBacktests int   `db:"concurrent_backtests"`
+	BacktestsToday      int   `db:"backtests_today"`
+	DownloadJobsToday   int   `db:"download_jobs_today"`
+	StoredCandles       int64 `db:"stored_candles"`
+	ReservedCandles     int64 `db:"reserved_candles"`
+}
+
+// Quota represents one limit and how much of it a user has used.
+// Limit and Remaining are nil when the quota is unlimited.
+type Quota struct {
+	Name      string     `json:"name"`
+	Limit     *int64     `json:"limit"`
+	Used      int64      `json:"used"`
+	Remaining *int64     `json:"remaining"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+}
+
+// UserQuotas represents all quotas that apply to a user
+type UserQuotas struct {
+	Tier    string  `json:"tier"`
+	Enabled bool    `json:"enabled"`
+	Quotas  []Quota `json:"quotas"`
+}
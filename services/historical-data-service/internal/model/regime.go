@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+)
+
+// Market regime labels
+const (
+	RegimeTrendingUp   = "trending_up"
+	RegimeTrendingDown = "trending_down"
+	RegimeRanging      = "ranging"
+
+	RegimeVolatilityHigh = "high"
+	RegimeVolatilityLow  = "low"
+)
+
+// MarketRegimeSegment is a stretch of candle history with a consistent trend and volatility
+type MarketRegimeSegment struct {
+	SymbolID    int       `json:"symbol_id" db:"symbol_id"`
+	Timeframe   string    `json:"timeframe" db:"timeframe"`
+	Trend       string    `json:"trend" db:"trend"`
+	Volatility  string    `json:"volatility" db:"volatility"`
+	StartTime   time.Time `json:"start_time" db:"start_time"`
+	EndTime     time.Time `json:"end_time" db:"end_time"`
+	CandleCount int       `json:"candle_count" db:"candle_count"`
+	// Price change over the segment
+	ReturnPercent float64 `json:"return_percent" db:"return_percent"`
+	// Average standard deviation of candle returns over the classification window
+	VolatilityPercent float64   `json:"volatility_percent" db:"volatility_percent"`
+	ClassifiedAt      time.Time `json:"-" db:"classified_at"`
+}
+
+// MarketRegimes is the regime classification of a symbol's candle history
+type MarketRegimes struct {
+	SymbolID     int                   `json:"symbol_id"`
+	Symbol       string                `json:"symbol"`
+	Timeframe    string                `json:"timeframe"`
+	ClassifiedAt *time.Time            `json:"classified_at,omitempty"`
+	Segments     []MarketRegimeSegment `json:"segments"`
+}
+
+// RegimePerformance is the performance of the closed trades that were entered in a regime.
+// Return and drawdown are measured on the initial capital as if only these trades were made.
+type RegimePerformance struct {
+	TotalTrades   int     `json:"total_trades"`
+	WinningTrades int     `json:"winning_trades"`
+	LosingTrades  int     `json:"losing_trades"`
+	WinRate       float64 `json:"win_rate"`
+	ProfitLoss    float64 `json:"profit_loss"`
+	ReturnPercent float64 `json:"return_percent"`
+	MaxDrawdown   float64 `json:"max_drawdown"`
+}
+
+// RegimeSegmentPerformance is the performance of a backtest run within one regime segment
+type RegimeSegmentPerformance struct {
+	Segment     MarketRegimeSegment `json:"segment"`
+	Performance RegimePerformance   `json:"performance"`
+}
+
+// RegimeTypePerformance is the performance of a backtest run across all segments of one regime
+type RegimeTypePerformance struct {
+	Trend       string            `json:"trend"`
+	Volatility  string            `json:"volatility"`
+	Segments    int               `json:"segments"`
+	Performance RegimePerformance `json:"performance"`
+}
+
+// RegimeBreakdown is the performance of a backtest run broken down by market regime.
+// Trades entered outside any classified segment are counted as unclassified.
+type RegimeBreakdown struct {
+	BacktestRunID  int                        `json:"backtest_run_id"`
+	SymbolID       int                        `json:"symbol_id"`
+	Timeframe      string                     `json:"timeframe"`
+	StartDate      time.Time                  `json:"start_date"`
+	EndDate        time.Time                  `json:"end_date"`
+	InitialCapital float64                    `json:"initial_capital"`
+	Segments       []RegimeSegmentPerformance `json:"segments"`
+	Regimes        []RegimeTypePerformance    `json:"regimes"`
+	Unclassified   RegimePerformance          `json:"unclassified"`
+}
@@ -0,0 +1,4 @@
+package model
+
+// ViewListBacktests is the list of the historical data service saved views can be applied to
+const ViewListBacktests = "backtests"
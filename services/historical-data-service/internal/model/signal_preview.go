@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Signal types and the sides of the positions they enter or exit
+const (
+	SignalTypeEntry = "entry"
+	SignalTypeExit  = "exit"
+
+	SignalSideLong  = "long"
+	SignalSideShort = "short"
+)
+
+// SignalPreviewRequest asks for the signals a strategy structure gives on the latest
+// stored candles of a symbol
+type SignalPreviewRequest struct {
+	Strategy  json.RawMessage `json:"strategy" binding:"required" swaggertype:"object"`
+	SymbolID  int             `json:"symbol_id" binding:"required"`
+	Timeframe string          `json:"timeframe" binding:"required"`
+	// Candles is how many of the latest candles to evaluate; defaults to 500
+	Candles    int  `json:"candles,omitempty"`
+	AllowShort bool `json:"allow_short,omitempty"`
+}
+
+// SignalCondition is a condition of the rules behind a signal, with the value its
+// indicator had on the signal's candle
+type SignalCondition struct {
+	Indicator string   `json:"indicator"`
+	Timeframe string   `json:"timeframe,omitempty"` // Set for indicators on an auxiliary timeframe
+	Symbol    string   `json:"symbol"`
+	Value     float64  `json:"value"`
+	Actual    *float64 `json:"actual"` // Nil while the indicator has no value yet
+	Matched   bool     `json:"matched"`
+}
+
+// Signal is an entry or exit the strategy's rules give on a candle
+type Signal struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`
+	Side       string            `json:"side"`
+	Price      float64           `json:"price"` // The candle's close
+	Conditions []SignalCondition `json:"conditions"`
+}
+
+// SignalPreview is the signal timeline of a strategy over a window of candles, oldest
+// first. Stop losses, take profits and trailing stops aren't evaluated.
+type SignalPreview struct {
+	SymbolID  int       `json:"symbol_id"`
+	Timeframe string    `json:"timeframe"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Candles   int       `json:"candles"` // The candles evaluated
+	Signals   []Signal  `json:"signals"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// DataStats represents backtest, download job and data inventory KPIs over a time window.
+// Failure rates are failed / (completed + failed), or 0 when nothing has finished.
+type DataStats struct {
+	Window                string    `json:"window"`
+	From                  time.Time `json:"from"`
+	To                    time.Time `json:"to"`
+	BacktestsCreated      int64     `json:"backtests_created" db:"backtests_created"`
+	BacktestsCompleted    int64     `json:"backtests_completed" db:"backtests_completed"`
+	BacktestsFailed       int64     `json:"backtests_failed" db:"backtests_failed"`
+	BacktestFailureRate   float64   `json:"backtest_failure_rate"`
+	ActiveBacktesters     int64     `json:"active_backtesters" db:"active_backtesters"`
+	DownloadJobsCreated   int64     `json:"download_jobs_created" db:"download_jobs_created"`
+	DownloadJobsCompleted int64     `json:"download_jobs_completed" db:"download_jobs_completed"`
+	DownloadJobsFailed    int64     `json:"download_jobs_failed" db:"download_jobs_failed"`
+	DownloadFailureRate   float64   `json:"download_failure_rate"`
+	CandlesDownloaded     int64     `json:"candles_downloaded" db:"candles_downloaded"`
+	SymbolsWithData       int64     `json:"symbols_with_data" db:"symbols_with_data"`
+	TotalCandles          int64     `json:"total_candles" db:"total_candles"`
+	StorageBytes          int64     `json:"storage_bytes" db:"storage_bytes"`
+	GeneratedAt           time.Time `json:"generated_at"`
+}
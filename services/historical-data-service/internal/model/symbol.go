@@ -26,10 +26,28 @@ type SymbolFilter struct {
 
 // Timeframe represents a data timeframe (1m, 5m, 1h, 1d, etc)
 type Timeframe struct {
-	ID          int        `json:"id" db:"id"`
-	Name        string     `json:"name" db:"name"`
-	Minutes     int        `json:"minutes" db:"minutes"`
-	DisplayName string     `json:"display_name" db:"display_name"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	ID          int    `json:"id" db:"-"`
+	Name        string `json:"name" db:"name"`
+	Minutes     int    `json:"minutes" db:"minutes"`
+	DisplayName string `json:"display_name" db:"display_name"`
+	// Provider interval the candles are downloaded at and aggregated from
+	ProviderInterval *string    `json:"provider_interval,omitempty" db:"provider_interval"`
+	IsCustom         bool       `json:"is_custom" db:"is_custom"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// TimeframeCreateRequest represents the input for adding a custom timeframe
+type TimeframeCreateRequest struct {
+	Name             string  `json:"name" binding:"required"`
+	DisplayName      string  `json:"display_name,omitempty"`
+	ProviderInterval *string `json:"provider_interval,omitempty"`
+}
+
+// TimeframeUpdateRequest represents the input for updating a timeframe; omitted fields are kept
+type TimeframeUpdateRequest struct {
+	DisplayName      *string `json:"display_name,omitempty"`
+	ProviderInterval *string `json:"provider_interval,omitempty"`
+	IsActive         *bool   `json:"is_active,omitempty"`
 }
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Sources a ticker was taken from
+const (
+	TickerSourceLive = "live" // The live market data stream
+	TickerSourceREST = "rest" // The provider's REST API
+)
+
+// Ticker is the latest price of a symbol and its change over the last 24 hours
+type Ticker struct {
+	Symbol             string    `json:"symbol"`
+	LastPrice          float64   `json:"last_price"`
+	PriceChange        float64   `json:"price_change"`
+	PriceChangePercent float64   `json:"price_change_percent"`
+	HighPrice          float64   `json:"high_price"`
+	LowPrice           float64   `json:"low_price"`
+	Volume             float64   `json:"volume"`       // In the base asset
+	QuoteVolume        float64   `json:"quote_volume"` // In the quote asset
+	Source             string    `json:"source"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
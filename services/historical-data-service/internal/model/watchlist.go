@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Watchlist is a named list of symbols a user follows
+type Watchlist struct {
+	ID          int               `json:"id" db:"id"`
+	Name        string            `json:"name" db:"name"`
+	Description string            `json:"description" db:"description"`
+	SymbolCount int               `json:"symbol_count" db:"symbol_count"`
+	Symbols     []WatchlistSymbol `json:"symbols" db:"-"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// WatchlistSymbol is a symbol listed in a watchlist
+type WatchlistSymbol struct {
+	WatchlistID int     `json:"-" db:"watchlist_id"`
+	SymbolID    int     `json:"symbol_id" db:"symbol_id"`
+	Symbol      string  `json:"symbol" db:"symbol"`
+	Name        string  `json:"name" db:"name"`
+	Exchange    *string `json:"exchange,omitempty" db:"exchange"`
+	AssetType   string  `json:"asset_type" db:"asset_type"`
+}
+
+// WatchlistCreate represents data for creating a watchlist
+type WatchlistCreate struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description,omitempty"`
+	SymbolIDs   []int  `json:"symbol_ids" binding:"max=200,dive,min=1"`
+}
+
+// WatchlistUpdate represents data for updating a watchlist; omitted fields are unchanged
+// and symbol_ids replaces the listed symbols
+type WatchlistUpdate struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty"`
+	SymbolIDs   []int   `json:"symbol_ids,omitempty" binding:"omitempty,max=200,dive,min=1"`
+}
+
+// WatchlistQuote is the latest price of a watchlist symbol from stored candles and its
+// change over the 24 hours before it. Quotes are nil for symbols without candles.
+type WatchlistQuote struct {
+	SymbolID         int        `json:"symbol_id" db:"symbol_id"`
+	Symbol           string     `json:"symbol" db:"symbol"`
+	Name             string     `json:"name" db:"name"`
+	LastCandleTime   *time.Time `json:"last_candle_time" db:"last_candle_time"`
+	LastPrice        *float64   `json:"last_price" db:"last_price"`
+	Price24hAgo      *float64   `json:"price_24h_ago" db:"price_24h_ago"`
+	Change24h        *float64   `json:"change_24h" db:"change_24h"`
+	Change24hPercent *float64   `json:"change_24h_percent" db:"change_24h_percent"`
+	High24h          *float64   `json:"high_24h" db:"high_24h"`
+	Low24h           *float64   `json:"low_24h" db:"low_24h"`
+	Volume24h        *float64   `json:"volume_24h" db:"volume_24h"`
+}
@@ -3,23 +3,27 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
 // BacktestRepository handles database operations for backtests
 type BacktestRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
 	logger *zap.Logger
 }
 
 // NewBacktestRepository creates a new backtest repository
-func NewBacktestRepository(db *sqlx.DB, logger *zap.Logger) *BacktestRepository {
+func NewBacktestRepository(db *database.DB, logger *zap.Logger) *BacktestRepository {
 	return &BacktestRepository{
 		db:     db,
 		logger: logger,
@@ -39,8 +43,9 @@ func (r *BacktestRepository) CreateBacktest(
 	endDate time.Time,
 	initialCapital float64,
 	symbolIDs []int,
+	settings model.BacktestSettings,
 ) (int, error) {
-	query := `SELECT create_backtest($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `SELECT create_backtest($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
 	var backtestID int
 	err := r.db.GetContext(
@@ -57,6 +62,7 @@ func (r *BacktestRepository) CreateBacktest(
 		endDate,
 		initialCapital,
 		symbolIDs,
+		settings,
 	)
 
 	if err != nil {
@@ -67,6 +73,57 @@ func (r *BacktestRepository) CreateBacktest(
 	return backtestID, nil
 }
 
+// RecordBacktestDataManifest records the candles a new backtest's symbols have in its range
+// using record_backtest_data_manifest function
+func (r *BacktestRepository) RecordBacktestDataManifest(ctx context.Context, backtestID int, pinned bool) error {
+	query := `SELECT record_backtest_data_manifest($1, $2)`
+
+	var recorded int
+	if err := r.db.GetContext(ctx, &recorded, query, backtestID, pinned); err != nil {
+		r.logger.Error("Failed to record backtest data manifest",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+		return err
+	}
+
+	return nil
+}
+
+// IsBacktestDataPinned reports whether a backtest is pinned to its data manifest using
+// is_backtest_data_pinned function
+func (r *BacktestRepository) IsBacktestDataPinned(ctx context.Context, backtestID int) (bool, error) {
+	query := `SELECT is_backtest_data_pinned($1)`
+
+	var pinned bool
+	if err := r.db.GetContext(ctx, &pinned, query, backtestID); err != nil {
+		r.logger.Error("Failed to check whether backtest data is pinned",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+		return false, err
+	}
+
+	return pinned, nil
+}
+
+// GetBacktestDataManifest retrieves the data manifest of a backtest next to the data its
+// symbols have now using get_backtest_data_manifest function
+func (r *BacktestRepository) GetBacktestDataManifest(
+	ctx context.Context,
+	backtestID int,
+) ([]model.BacktestDataManifestSymbol, error) {
+	query := `SELECT * FROM get_backtest_data_manifest($1)`
+
+	var symbols []model.BacktestDataManifestSymbol
+	if err := r.db.SelectContext(ctx, &symbols, query, backtestID); err != nil {
+		r.logger.Error("Failed to get backtest data manifest",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+		return nil, err
+	}
+
+	return symbols, nil
+}
+
 // GetBacktest retrieves a backtest by ID using get_backtest_by_id function
 func (r *BacktestRepository) GetBacktest(
 	ctx context.Context,
@@ -81,20 +138,56 @@ func (r *BacktestRepository) GetBacktest(
 		return nil, err
 	}
 
+	err = r.db.GetContext(ctx, &backtest.Tags, `SELECT get_backtest_tags($1)`, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to get backtest tags", zap.Error(err), zap.Int("id", backtestID))
+		return nil, err
+	}
+
 	return &backtest, nil
 }
 
+// SetBacktestTags sets the tags of a backtest using set_backtest_tags function
+func (r *BacktestRepository) SetBacktestTags(ctx context.Context, backtestID int, tags []string) error {
+	_, err := r.db.ExecContext(ctx, `SELECT set_backtest_tags($1, $2)`, backtestID, pq.Array(tags))
+	if err != nil {
+		r.logger.Error("Failed to set backtest tags", zap.Error(err), zap.Int("id", backtestID))
+		return err
+	}
+	return nil
+}
+
+// GetSymbolNames returns the names of the given symbols by ID
+func (r *BacktestRepository) GetSymbolNames(ctx context.Context, symbolIDs []int) (map[int]string, error) {
+	var symbols []struct {
+		ID     int    `db:"id"`
+		Symbol string `db:"symbol"`
+	}
+	err := r.db.SelectContext(ctx, &symbols, `SELECT id, symbol FROM symbols WHERE id = ANY($1)`, pq.Array(symbolIDs))
+	if err != nil {
+		r.logger.Error("Failed to look up symbol names", zap.Error(err))
+		return nil, err
+	}
+
+	names := make(map[int]string, len(symbols))
+	for _, symbol := range symbols {
+		names[symbol.ID] = symbol.Symbol
+	}
+	return names, nil
+}
+
 // CountBacktests counts the total number of backtests for a user with filtering
 func (r *BacktestRepository) CountBacktests(
 	ctx context.Context,
 	userID int,
 	searchTerm string,
 	status string,
+	tags []string,
 ) (int, error) {
-	query := `SELECT count_backtests($1, $2, $3)`
+	query := `SELECT count_backtests($1, $2, $3, $4)`
 
 	var count int
-	err := r.db.GetContext(ctx, &count, query, userID, searchTerm, status)
+	err := r.db.GetContext(ctx, &count, query, userID, searchTerm, status, tagsFilter(tags))
 	if err != nil {
 		r.logger.Error("Failed to count user backtests",
 			zap.Error(err),
@@ -113,16 +206,17 @@ func (r *BacktestRepository) GetBacktestsByUser(
 	userID int,
 	searchTerm string,
 	status string,
+	tags []string,
 	sortBy string,
 	sortDirection string,
 	limit int,
 	offset int,
 ) ([]model.BacktestSummary, error) {
-	query := `SELECT * FROM get_backtests($1, $2, $3, $4, $5, $6, $7)`
+	query := `SELECT * FROM get_backtests($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	var backtests []model.BacktestSummary
 	err := r.db.SelectContext(ctx, &backtests, query,
-		userID, searchTerm, status, sortBy, sortDirection, limit, offset)
+		userID, searchTerm, status, tagsFilter(tags), sortBy, sortDirection, limit, offset)
 	if err != nil {
 		r.logger.Error("Failed to get backtest summary",
 			zap.Error(err),
@@ -135,6 +229,14 @@ func (r *BacktestRepository) GetBacktestsByUser(
 	return backtests, nil
 }
 
+// tagsFilter passes a tag filter to the listing functions; NULL doesn't filter by tags
+func tagsFilter(tags []string) interface{} {
+	if len(tags) == 0 {
+		return nil
+	}
+	return pq.Array(tags)
+}
+
 // UpdateBacktestRunStatus updates the status of a backtest run using update_backtest_run_status function
 func (r *BacktestRepository) UpdateBacktestRunStatus(
 	ctx context.Context,
@@ -240,6 +342,100 @@ func (r *BacktestRepository) AddBacktestTrade(
 	return tradeID, nil
 }
 
+// AddBacktestTrades adds a batch of trades to a backtest run with a single COPY.
+// The trades must already be validated; one bad row fails the whole batch.
+func (r *BacktestRepository) AddBacktestTrades(
+	ctx context.Context,
+	runID int,
+	trades []model.BacktestTrade,
+) (int, error) {
+	if len(trades) == 0 {
+		return 0, nil
+	}
+
+	ctx, done := r.db.StartQuery(ctx, database.QueryBulk, "backtest trade batch")
+	defer done()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		r.logger.Error("Failed to acquire connection for trade batch", zap.Error(err))
+		return 0, err
+	}
+	defer conn.Close()
+
+	var inserted int64
+	err = conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection type %T", driverConn)
+		}
+
+		rows := make([][]interface{}, len(trades))
+		for i, t := range trades {
+			rows[i] = []interface{}{
+				runID,
+				t.SymbolID,
+				t.EntryTime,
+				t.ExitTime,
+				t.PositionType,
+				t.EntryPrice,
+				t.ExitPrice,
+				t.Quantity,
+				t.ProfitLoss,
+				t.ProfitLossPercent,
+				t.ExitReason,
+			}
+		}
+
+		inserted, err = stdConn.Conn().CopyFrom(
+			ctx,
+			pgx.Identifier{"backtest_trades"},
+			[]string{
+				"backtest_run_id", "symbol_id", "entry_time", "exit_time", "position_type",
+				"entry_price", "exit_price", "quantity", "profit_loss", "profit_loss_percent", "exit_reason",
+			},
+			pgx.CopyFromRows(rows),
+		)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to add backtest trades",
+			zap.Error(err),
+			zap.Int("runID", runID),
+			zap.Int("tradesInBatch", len(trades)))
+		return 0, err
+	}
+
+	return int(inserted), nil
+}
+
+// BacktestRunExists reports whether a backtest run exists
+func (r *BacktestRepository) BacktestRunExists(ctx context.Context, runID int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM backtest_runs WHERE id = $1)`, runID)
+	if err != nil {
+		r.logger.Error("Failed to check backtest run", zap.Error(err), zap.Int("runID", runID))
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetExistingSymbolIDs returns which of the given symbol IDs exist
+func (r *BacktestRepository) GetExistingSymbolIDs(ctx context.Context, symbolIDs []int) (map[int]bool, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `SELECT id FROM symbols WHERE id = ANY($1)`, pq.Array(symbolIDs))
+	if err != nil {
+		r.logger.Error("Failed to look up symbols", zap.Error(err))
+		return nil, err
+	}
+
+	existing := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
 // GetBacktestTrades retrieves trades for a backtest run using get_backtest_trades function with sorting and pagination
 func (r *BacktestRepository) GetBacktestTrades(
 	ctx context.Context,
@@ -265,6 +461,269 @@ func (r *BacktestRepository) GetBacktestTrades(
 	return trades, nil
 }
 
+// GetBacktestTradesAfter retrieves the page of trades that follows a cursor using the
+// get_backtest_trades_after function. A nil cursor returns the first page. The returned
+// cursor marks the last trade of the page and is nil when there are no more trades.
+func (r *BacktestRepository) GetBacktestTradesAfter(
+	ctx context.Context,
+	runID int,
+	sortBy string,
+	sortDirection string,
+	after *model.BacktestTradeCursor,
+	limit int,
+) ([]model.BacktestTrade, *model.BacktestTradeCursor, error) {
+	query := `SELECT * FROM get_backtest_trades_after($1, $2, $3, $4, $5, $6)`
+
+	var afterValue, afterID interface{}
+	if after != nil {
+		afterValue = after.Value
+		afterID = after.ID
+	}
+
+	var rows []struct {
+		model.BacktestTrade
+		SortValue string `db:"sort_value"`
+	}
+
+	// Fetch one extra trade to learn whether there is another page
+	err := r.db.SelectContext(ctx, &rows, query, runID, sortBy, sortDirection, afterValue, afterID, limit+1)
+	if err != nil {
+		r.logger.Error("Failed to get backtest trades after cursor",
+			zap.Error(err),
+			zap.Int("runID", runID),
+			zap.String("sortBy", sortBy),
+			zap.String("sortDirection", sortDirection))
+		return nil, nil, err
+	}
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	trades := make([]model.BacktestTrade, len(rows))
+	for i, row := range rows {
+		trades[i] = row.BacktestTrade
+	}
+
+	var next *model.BacktestTradeCursor
+	if hasMore {
+		last := rows[len(rows)-1]
+		next = &model.BacktestTradeCursor{
+			SortBy:        sortBy,
+			SortDirection: sortDirection,
+			Value:         last.SortValue,
+			ID:            last.ID,
+		}
+	}
+
+	return trades, next, nil
+}
+
+// GetClosedBacktestTrades retrieves all closed trades of a backtest run in the order they were closed
+func (r *BacktestRepository) GetClosedBacktestTrades(
+	ctx context.Context,
+	runID int,
+) ([]model.BacktestTrade, error) {
+	query := `
+		SELECT
+			id, backtest_run_id, symbol_id, entry_time, exit_time, position_type,
+			entry_price, exit_price, quantity, profit_loss, profit_loss_percent, exit_reason
+		FROM backtest_trades
+		WHERE backtest_run_id = $1 AND exit_time IS NOT NULL
+		ORDER BY exit_time, id
+	`
+
+	trades := []model.BacktestTrade{}
+	err := r.db.SelectContext(ctx, &trades, query, runID)
+	if err != nil {
+		r.logger.Error("Failed to get closed backtest trades",
+			zap.Error(err),
+			zap.Int("runID", runID))
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// AddTradeAnnotation annotates a trade of a backtest run using add_backtest_trade_annotation
+// function. Returns nil if the trade isn't part of the run.
+func (r *BacktestRepository) AddTradeAnnotation(
+	ctx context.Context,
+	runID int,
+	tradeID int,
+	userID int,
+	note string,
+	labels []string,
+) (*model.BacktestTradeAnnotation, error) {
+	query := `SELECT * FROM add_backtest_trade_annotation($1, $2, $3, $4, $5)`
+
+	var annotation model.BacktestTradeAnnotation
+	err := r.db.GetContext(ctx, &annotation, query, runID, tradeID, userID, note, pq.Array(labels))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to add trade annotation",
+			zap.Error(err),
+			zap.Int("runID", runID),
+			zap.Int("tradeID", tradeID))
+		return nil, err
+	}
+
+	return &annotation, nil
+}
+
+// GetTradeAnnotations retrieves the annotations of a set of trades using
+// get_backtest_trade_annotations function, keyed by trade ID
+func (r *BacktestRepository) GetTradeAnnotations(
+	ctx context.Context,
+	tradeIDs []int,
+) (map[int][]model.BacktestTradeAnnotation, error) {
+	annotations := make(map[int][]model.BacktestTradeAnnotation)
+	if len(tradeIDs) == 0 {
+		return annotations, nil
+	}
+
+	query := `SELECT * FROM get_backtest_trade_annotations($1)`
+
+	var rows []model.BacktestTradeAnnotation
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(tradeIDs))
+	if err != nil {
+		r.logger.Error("Failed to get trade annotations", zap.Error(err), zap.Int("trades", len(tradeIDs)))
+		return nil, err
+	}
+
+	for _, annotation := range rows {
+		annotations[annotation.TradeID] = append(annotations[annotation.TradeID], annotation)
+	}
+	return annotations, nil
+}
+
+// GetBacktestRunEquity retrieves the equity curve saved with the results of a backtest run.
+// Points whose time can't be parsed keep a zero time. Returns nil if the run has no results
+// or they don't include an equity curve.
+func (r *BacktestRepository) GetBacktestRunEquity(
+	ctx context.Context,
+	runID int,
+) ([]model.EquityPoint, error) {
+	query := `
+		SELECT results_json
+		FROM backtest_results
+		WHERE backtest_run_id = $1 AND results_json IS NOT NULL
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var resultsJSON []byte
+	err := r.db.GetContext(ctx, &resultsJSON, query, runID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get backtest run equity",
+			zap.Error(err),
+			zap.Int("runID", runID))
+		return nil, err
+	}
+
+	var results struct {
+		EquityCurve []float64 `json:"equity_curve"`
+		EquityTimes []string  `json:"equity_times"`
+	}
+	if err := json.Unmarshal(resultsJSON, &results); err != nil {
+		r.logger.Warn("Invalid backtest results JSON",
+			zap.Error(err),
+			zap.Int("runID", runID))
+		return nil, nil
+	}
+	if len(results.EquityCurve) == 0 {
+		return nil, nil
+	}
+
+	points := make([]model.EquityPoint, len(results.EquityCurve))
+	for i, equity := range results.EquityCurve {
+		points[i].Equity = equity
+		if i < len(results.EquityTimes) {
+			points[i].Time = parseEquityTime(results.EquityTimes[i])
+		}
+	}
+
+	return points, nil
+}
+
+// parseEquityTime parses an equity curve timestamp as written by the backtesting service
+// (Python isoformat, with or without a UTC offset). Returns the zero time if it can't be parsed.
+func parseEquityTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// GetBacktestRunInfo retrieves a backtest run with the parameters of its backtest.
+// Returns nil if the run doesn't exist.
+func (r *BacktestRepository) GetBacktestRunInfo(
+	ctx context.Context,
+	runID int,
+) (*model.BacktestRunInfo, error) {
+	query := `
+		SELECT
+			br.id AS backtest_run_id, br.backtest_id, br.symbol_id, br.status,
+			b.timeframe, b.start_date, b.end_date, b.initial_capital
+		FROM backtest_runs br
+		JOIN backtests b ON b.id = br.backtest_id
+		WHERE br.id = $1
+	`
+
+	var info model.BacktestRunInfo
+	err := r.db.GetContext(ctx, &info, query, runID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get backtest run info",
+			zap.Error(err),
+			zap.Int("runID", runID))
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// GetBacktestRunMetrics retrieves the metrics saved with the latest results of a backtest run.
+// Returns nil if the run has no results.
+func (r *BacktestRepository) GetBacktestRunMetrics(
+	ctx context.Context,
+	runID int,
+) (*model.BacktestRunMetrics, error) {
+	query := `
+		SELECT
+			total_trades, winning_trades, losing_trades, profit_factor, sharpe_ratio,
+			max_drawdown, final_capital, total_return, annualized_return
+		FROM backtest_results
+		WHERE backtest_run_id = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var metrics model.BacktestRunMetrics
+	err := r.db.GetContext(ctx, &metrics, query, runID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get backtest run metrics",
+			zap.Error(err),
+			zap.Int("runID", runID))
+		return nil, err
+	}
+
+	return &metrics, nil
+}
+
 // DeleteBacktest deletes a backtest using delete_backtest function
 func (r *BacktestRepository) DeleteBacktest(
 	ctx context.Context,
@@ -409,45 +868,54 @@ func (r *BacktestRepository) GetBacktestSymbolIDs(
 	return symbolIDs, err
 }
 
-// GetQueuedBacktests retrieves backtests in queued status
-func (r *BacktestRepository) GetQueuedBacktests(
-	ctx context.Context,
-	limit int,
-) ([]model.BacktestSummary, error) {
-	query := `
-		SELECT 
-			b.id AS backtest_id,
-			b.name,
-			b.strategy_id,
-			b.created_at AS date,
-			b.status,
-			NULL AS symbol_results,
-			0 AS completed_runs,
-			COUNT(br.id) AS total_runs
-		FROM 
-			backtests b
-		LEFT JOIN
-			backtest_runs br ON b.id = br.backtest_id
-		WHERE 
-			b.status = 'pending'
-		GROUP BY
-			b.id, b.name, b.strategy_id, b.created_at, b.status
-		ORDER BY 
-			b.created_at ASC
-		LIMIT $1
-	`
+// ClaimQueuedBacktests marks up to limit queued backtests as running and returns their IDs.
+// Backtests claimed by another instance at the same time are skipped.
+func (r *BacktestRepository) ClaimQueuedBacktests(ctx context.Context, limit int) ([]int, error) {
+	query := `SELECT backtest_id FROM claim_queued_backtests($1)`
 
-	var backtests []model.BacktestSummary
-	err := r.db.SelectContext(ctx, &backtests, query, limit)
+	backtestIDs := []int{}
+	err := r.db.SelectContext(ctx, &backtestIDs, query, limit)
 	if err != nil {
-		r.logger.Error("Failed to get queued backtests", zap.Error(err))
+		r.logger.Error("Failed to claim queued backtests", zap.Error(err))
 		return nil, err
 	}
 
-	return backtests, nil
+	return backtestIDs, nil
 }
 
-// UpdateBacktestRunsStatusBulk updates all runs for a backtest to the given status
+// RequeueBacktest queues an interrupted backtest to be resumed. Returns how many of its
+// runs are left to run, or -1 if the backtest was cancelled.
+func (r *BacktestRepository) RequeueBacktest(ctx context.Context, backtestID int) (int, error) {
+	query := `SELECT requeue_backtest($1)`
+
+	var remaining int
+	err := r.db.GetContext(ctx, &remaining, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to requeue backtest", zap.Error(err), zap.Int("backtestID", backtestID))
+		return 0, err
+	}
+
+	return remaining, nil
+}
+
+// GetPendingBacktestSymbolIDs gets the symbol IDs of a backtest's runs that are still to run
+func (r *BacktestRepository) GetPendingBacktestSymbolIDs(ctx context.Context, backtestID int) ([]int, error) {
+	query := `SELECT symbol_id FROM get_pending_backtest_symbol_ids($1)`
+
+	symbolIDs := []int{}
+	err := r.db.SelectContext(ctx, &symbolIDs, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to get pending backtest symbol IDs",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+		return nil, err
+	}
+
+	return symbolIDs, nil
+}
+
+// UpdateBacktestRunsStatusBulk updates all runs for a backtest to the given status, except
+// cancelled runs
 func (r *BacktestRepository) UpdateBacktestRunsStatusBulk(
 	ctx context.Context,
 	backtestID int,
@@ -456,7 +924,7 @@ func (r *BacktestRepository) UpdateBacktestRunsStatusBulk(
 	query := `
 		UPDATE backtest_runs
 		SET status = $2, completed_at = NOW()
-		WHERE backtest_id = $1
+		WHERE backtest_id = $1 AND status <> 'cancelled'
 	`
 	_, err := r.db.ExecContext(ctx, query, backtestID, status)
 	if err != nil {
@@ -468,7 +936,8 @@ func (r *BacktestRepository) UpdateBacktestRunsStatusBulk(
 	return err
 }
 
-// UpdateBacktestStatus updates a backtest status
+// UpdateBacktestStatus updates a backtest status. An empty error message clears it. A
+// cancelled backtest keeps its status.
 func (r *BacktestRepository) UpdateBacktestStatus(
 	ctx context.Context,
 	backtestID int,
@@ -477,8 +946,8 @@ func (r *BacktestRepository) UpdateBacktestStatus(
 ) error {
 	query := `
 		UPDATE backtests
-		SET status = $2, error_message = $3, completed_at = NOW(), updated_at = NOW()
-		WHERE id = $1
+		SET status = $2, error_message = NULLIF($3, ''), completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status <> 'cancelled'
 	`
 	_, err := r.db.ExecContext(ctx, query, backtestID, status, errorMessage)
 	if err != nil {
@@ -502,21 +971,23 @@ func (r *BacktestRepository) GetBacktestDetails(
 	StartDate       time.Time
 	EndDate         time.Time
 	InitialCapital  float64
+	Settings        *model.BacktestSettings
 }, error) {
 	query := `
 		SELECT strategy_id, strategy_version, user_id, timeframe, 
-               start_date, end_date, initial_capital 
+               start_date, end_date, initial_capital, settings
         FROM backtests WHERE id = $1
 	`
 
 	var dbDetails struct {
-		StrategyID      int       `db:"strategy_id"`
-		StrategyVersion int       `db:"strategy_version"`
-		UserID          int       `db:"user_id"`
-		Timeframe       string    `db:"timeframe"`
-		StartDate       time.Time `db:"start_date"`
-		EndDate         time.Time `db:"end_date"`
-		InitialCapital  float64   `db:"initial_capital"`
+		StrategyID      int                     `db:"strategy_id"`
+		StrategyVersion int                     `db:"strategy_version"`
+		UserID          int                     `db:"user_id"`
+		Timeframe       string                  `db:"timeframe"`
+		StartDate       time.Time               `db:"start_date"`
+		EndDate         time.Time               `db:"end_date"`
+		InitialCapital  float64                 `db:"initial_capital"`
+		Settings        *model.BacktestSettings `db:"settings"`
 	}
 
 	err := r.db.GetContext(ctx, &dbDetails, query, backtestID)
@@ -539,6 +1010,7 @@ func (r *BacktestRepository) GetBacktestDetails(
 		StartDate       time.Time
 		EndDate         time.Time
 		InitialCapital  float64
+		Settings        *model.BacktestSettings
 	}{
 		StrategyID:      dbDetails.StrategyID,
 		StrategyVersion: dbDetails.StrategyVersion,
@@ -547,7 +1019,207 @@ func (r *BacktestRepository) GetBacktestDetails(
 		StartDate:       dbDetails.StartDate,
 		EndDate:         dbDetails.EndDate,
 		InitialCapital:  dbDetails.InitialCapital,
+		Settings:        dbDetails.Settings,
 	}
 
 	return &result, nil
 }
+
+// CountFailedBacktestRuns counts the failed runs of a backtest
+func (r *BacktestRepository) CountFailedBacktestRuns(ctx context.Context, backtestID int) (int, error) {
+	query := `SELECT count_failed_backtest_runs($1)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to count failed backtest runs", zap.Error(err), zap.Int("backtestID", backtestID))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetFailedBacktestRuns retrieves the failed runs of a backtest with their retries
+func (r *BacktestRepository) GetFailedBacktestRuns(ctx context.Context, backtestID int) ([]model.BacktestRunRetry, error) {
+	query := `SELECT * FROM get_failed_backtest_runs($1)`
+
+	runs := []model.BacktestRunRetry{}
+	err := r.db.SelectContext(ctx, &runs, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to get failed backtest runs", zap.Error(err), zap.Int("backtestID", backtestID))
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// LeaseEngineRun marks a run as running on the engine with a lease, before it is submitted
+func (r *BacktestRepository) LeaseEngineRun(ctx context.Context, runID int, lease time.Duration) (bool, error) {
+	query := `SELECT lease_engine_run($1, $2)`
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, runID, int(lease.Seconds()))
+	if err != nil {
+		r.logger.Error("Failed to lease engine run", zap.Error(err), zap.Int("runID", runID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// SetEngineJobToken records the job token the engine returned for a submitted run
+func (r *BacktestRepository) SetEngineJobToken(ctx context.Context, runID int, jobToken string) error {
+	query := `SELECT set_engine_job_token($1, $2)`
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, runID, jobToken)
+	if err != nil {
+		r.logger.Error("Failed to set engine job token", zap.Error(err), zap.Int("runID", runID))
+	}
+	return err
+}
+
+// ReportEngineRun applies an engine callback to its run. Returns nil if the job isn't
+// running, e.g. because its lease expired.
+func (r *BacktestRepository) ReportEngineRun(
+	ctx context.Context,
+	callback *model.EngineCallback,
+	lease time.Duration,
+) (*model.EngineRunReport, error) {
+	query := `SELECT * FROM report_engine_run($1, $2, $3, $4, $5, $6)`
+
+	reports := []model.EngineRunReport{}
+	err := r.db.SelectContext(ctx, &reports, query,
+		callback.BacktestRunID,
+		callback.JobToken,
+		callback.Status,
+		callback.Progress,
+		callback.Error,
+		int(lease.Seconds()),
+	)
+	if err != nil {
+		r.logger.Error("Failed to report engine run",
+			zap.Error(err),
+			zap.Int("runID", callback.BacktestRunID),
+			zap.String("status", callback.Status))
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+
+	return &reports[0], nil
+}
+
+// ExpireEngineLeases fails the runs whose engine lease expired, returning each affected
+// backtest with how many of its runs remain
+func (r *BacktestRepository) ExpireEngineLeases(ctx context.Context) ([]model.EngineRunReport, error) {
+	query := `SELECT * FROM expire_engine_leases()`
+
+	reports := []model.EngineRunReport{}
+	err := r.db.SelectContext(ctx, &reports, query)
+	if err != nil {
+		r.logger.Error("Failed to expire engine leases", zap.Error(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// GetEngineRunErrors gets the errors the engine reported for the failed runs of a backtest
+func (r *BacktestRepository) GetEngineRunErrors(ctx context.Context, backtestID int) ([]model.EngineRunError, error) {
+	query := `SELECT * FROM get_engine_run_errors($1)`
+
+	runErrors := []model.EngineRunError{}
+	err := r.db.SelectContext(ctx, &runErrors, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to get engine run errors", zap.Error(err), zap.Int("backtestID", backtestID))
+		return nil, err
+	}
+
+	return runErrors, nil
+}
+
+// RetryFailedBacktestRuns queues the failed runs of a backtest that have retries left
+// using the retry_failed_backtest_runs function, and returns them
+func (r *BacktestRepository) RetryFailedBacktestRuns(ctx context.Context, backtestID int) ([]model.BacktestRunRetry, error) {
+	query := `SELECT * FROM retry_failed_backtest_runs($1)`
+
+	runs := []model.BacktestRunRetry{}
+	err := r.db.SelectContext(ctx, &runs, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to retry failed backtest runs", zap.Error(err), zap.Int("backtestID", backtestID))
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// GetStrategyBacktestHistory retrieves a user's backtests of the given versions of a
+// strategy with summarized metrics, optionally only those of one version
+func (r *BacktestRepository) GetStrategyBacktestHistory(
+	ctx context.Context,
+	userID int,
+	strategyIDs []int,
+	version *int,
+	limit int,
+	offset int,
+) ([]model.StrategyBacktest, error) {
+	query := `SELECT * FROM get_strategy_backtest_history($1, $2, $3, $4, $5)`
+
+	backtests := []model.StrategyBacktest{}
+	err := r.db.SelectContext(ctx, &backtests, query, userID, pq.Array(strategyIDs), version, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get strategy backtest history",
+			zap.Error(err),
+			zap.Int("userID", userID),
+			zap.Ints("strategyIDs", strategyIDs))
+		return nil, err
+	}
+
+	return backtests, nil
+}
+
+// CountStrategyBacktestHistory counts a user's backtests of the given versions of a strategy
+func (r *BacktestRepository) CountStrategyBacktestHistory(
+	ctx context.Context,
+	userID int,
+	strategyIDs []int,
+	version *int,
+) (int, error) {
+	query := `SELECT count_strategy_backtest_history($1, $2, $3)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, userID, pq.Array(strategyIDs), version)
+	if err != nil {
+		r.logger.Error("Failed to count strategy backtest history",
+			zap.Error(err),
+			zap.Int("userID", userID),
+			zap.Ints("strategyIDs", strategyIDs))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetStrategyVersionSummaries summarizes a user's backtests of the given versions of a
+// strategy per version
+func (r *BacktestRepository) GetStrategyVersionSummaries(
+	ctx context.Context,
+	userID int,
+	strategyIDs []int,
+) ([]model.StrategyVersionSummary, error) {
+	query := `SELECT * FROM get_strategy_backtest_version_summary($1, $2)`
+
+	summaries := []model.StrategyVersionSummary{}
+	err := r.db.SelectContext(ctx, &summaries, query, userID, pq.Array(strategyIDs))
+	if err != nil {
+		r.logger.Error("Failed to get strategy version summaries",
+			zap.Error(err),
+			zap.Int("userID", userID),
+			zap.Ints("strategyIDs", strategyIDs))
+		return nil, err
+	}
+
+	return summaries, nil
+}
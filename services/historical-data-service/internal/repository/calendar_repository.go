@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// CalendarRepository handles database operations for exchange trading calendars
+type CalendarRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewCalendarRepository creates a new calendar repository
+func NewCalendarRepository(db *database.DB, logger *zap.Logger) *CalendarRepository {
+	return &CalendarRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetCalendars retrieves all trading calendars with their weekly sessions
+func (r *CalendarRepository) GetCalendars(ctx context.Context) ([]model.TradingCalendar, error) {
+	calendars := []model.TradingCalendar{}
+	err := r.db.SelectContext(ctx, &calendars, `SELECT * FROM get_trading_calendars()`)
+	if err != nil {
+		r.logger.Error("Failed to get trading calendars", zap.Error(err))
+		return nil, err
+	}
+
+	var sessions []model.TradingSession
+	err = r.db.SelectContext(ctx, &sessions, `SELECT * FROM get_trading_sessions(NULL)`)
+	if err != nil {
+		r.logger.Error("Failed to get trading sessions", zap.Error(err))
+		return nil, err
+	}
+
+	byExchange := make(map[string][]model.TradingSession)
+	for _, session := range sessions {
+		byExchange[session.Exchange] = append(byExchange[session.Exchange], session)
+	}
+	for i := range calendars {
+		calendars[i].Sessions = byExchange[calendars[i].Exchange]
+		if calendars[i].Sessions == nil {
+			calendars[i].Sessions = []model.TradingSession{}
+		}
+	}
+
+	return calendars, nil
+}
+
+// GetCalendar retrieves the trading calendar of an exchange with its weekly sessions.
+// Returns nil if the exchange has no calendar.
+func (r *CalendarRepository) GetCalendar(ctx context.Context, exchange string) (*model.TradingCalendar, error) {
+	query := `SELECT * FROM get_trading_calendars() WHERE exchange = $1`
+
+	var calendar model.TradingCalendar
+	err := r.db.GetContext(ctx, &calendar, query, exchange)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get trading calendar", zap.Error(err), zap.String("exchange", exchange))
+		return nil, err
+	}
+
+	calendar.Sessions = []model.TradingSession{}
+	err = r.db.SelectContext(ctx, &calendar.Sessions, `SELECT * FROM get_trading_sessions($1)`, exchange)
+	if err != nil {
+		r.logger.Error("Failed to get trading sessions", zap.Error(err), zap.String("exchange", exchange))
+		return nil, err
+	}
+
+	return &calendar, nil
+}
+
+// GetHolidays retrieves the holidays of an exchange between two dates using get_trading_holidays function.
+// Nil bounds leave that side of the range open.
+func (r *CalendarRepository) GetHolidays(
+	ctx context.Context,
+	exchange string,
+	startDate *time.Time,
+	endDate *time.Time,
+) ([]model.TradingHoliday, error) {
+	query := `SELECT * FROM get_trading_holidays($1, $2::date, $3::date)`
+
+	holidays := []model.TradingHoliday{}
+	err := r.db.SelectContext(ctx, &holidays, query, exchange, formatDate(startDate), formatDate(endDate))
+	if err != nil {
+		r.logger.Error("Failed to get trading holidays", zap.Error(err), zap.String("exchange", exchange))
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+// UpsertHoliday adds a holiday to an exchange or updates the existing one on that date
+func (r *CalendarRepository) UpsertHoliday(
+	ctx context.Context,
+	exchange string,
+	date time.Time,
+	name string,
+	earlyClose *string,
+) error {
+	query := `SELECT upsert_trading_holiday($1, $2::date, $3, $4::time)`
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, exchange, date.Format("2006-01-02"), name, earlyClose)
+	if err != nil {
+		r.logger.Error("Failed to upsert trading holiday",
+			zap.Error(err),
+			zap.String("exchange", exchange),
+			zap.Time("date", date))
+		return err
+	}
+
+	return nil
+}
+
+// formatDate formats the calendar date of t, so it isn't shifted by the database time zone
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	date := t.Format("2006-01-02")
+	return &date
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// DerivativesRepository handles database operations for funding rates and open interest
+type DerivativesRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewDerivativesRepository creates a new derivatives repository
+func NewDerivativesRepository(db *database.DB, logger *zap.Logger) *DerivativesRepository {
+	return &DerivativesRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveFundingRates stores funding rates of a symbol, replacing stored ones for the same
+// times. Returns the number of rates written.
+func (r *DerivativesRepository) SaveFundingRates(ctx context.Context, symbolID int, rates []model.FundingRate) (int, error) {
+	if len(rates) == 0 {
+		return 0, nil
+	}
+
+	times := make([]time.Time, len(rates))
+	values := make([]float64, len(rates))
+	markPrices := make([]sql.NullFloat64, len(rates))
+	for i, rate := range rates {
+		times[i] = rate.FundingTime
+		values[i] = rate.FundingRate
+		if rate.MarkPrice != nil {
+			markPrices[i] = sql.NullFloat64{Float64: *rate.MarkPrice, Valid: true}
+		}
+	}
+
+	query := `SELECT import_funding_rates($1, $2, $3, $4)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, symbolID, pq.Array(times), pq.Array(values), pq.Array(markPrices))
+	if err != nil {
+		r.logger.Error("Failed to save funding rates", zap.Error(err), zap.Int("symbolID", symbolID))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SaveOpenInterest stores open interest samples of a symbol, replacing stored ones for the
+// same period and times. Returns the number of samples written.
+func (r *DerivativesRepository) SaveOpenInterest(ctx context.Context, symbolID int, period string, samples []model.OpenInterest) (int, error) {
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	times := make([]time.Time, len(samples))
+	openInterest := make([]float64, len(samples))
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		times[i] = sample.SampleTime
+		openInterest[i] = sample.OpenInterest
+		values[i] = sample.OpenInterestValue
+	}
+
+	query := `SELECT import_open_interest($1, $2, $3, $4, $5)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, symbolID, period, pq.Array(times), pq.Array(openInterest), pq.Array(values))
+	if err != nil {
+		r.logger.Error("Failed to save open interest",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.String("period", period))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetFundingRates retrieves up to limit funding rates of a symbol in a time range, oldest
+// first. Nil bounds leave that side of the range open.
+func (r *DerivativesRepository) GetFundingRates(
+	ctx context.Context,
+	symbolID int,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.FundingRate, error) {
+	query := `SELECT * FROM get_funding_rates($1, $2, $3, $4)`
+
+	rates := []model.FundingRate{}
+	err := r.db.SelectContext(ctx, &rates, query, symbolID, startTime, endTime, limit)
+	if err != nil {
+		r.logger.Error("Failed to get funding rates", zap.Error(err), zap.Int("symbolID", symbolID))
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// GetOpenInterest retrieves up to limit open interest samples of a symbol per period in a
+// time range, oldest first. Nil bounds leave that side of the range open.
+func (r *DerivativesRepository) GetOpenInterest(
+	ctx context.Context,
+	symbolID int,
+	period string,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.OpenInterest, error) {
+	query := `SELECT * FROM get_open_interest($1, $2, $3, $4, $5)`
+
+	samples := []model.OpenInterest{}
+	err := r.db.SelectContext(ctx, &samples, query, symbolID, period, startTime, endTime, limit)
+	if err != nil {
+		r.logger.Error("Failed to get open interest",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.String("period", period))
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// GetCoverage reports the funding rates and open interest stored for each of the symbols,
+// by symbol ID. Symbols without either are left out.
+func (r *DerivativesRepository) GetCoverage(ctx context.Context, symbolIDs []int) (map[int]*model.DerivativesCoverage, error) {
+	query := `SELECT * FROM get_derivatives_coverage($1)`
+
+	var rows []struct {
+		model.DerivativesCoverage
+		OpenInterestPeriods pq.StringArray `db:"open_interest_periods"`
+	}
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(symbolIDs))
+	if err != nil {
+		r.logger.Error("Failed to get derivatives coverage", zap.Error(err))
+		return nil, err
+	}
+
+	coverage := make(map[int]*model.DerivativesCoverage, len(rows))
+	for _, row := range rows {
+		item := row.DerivativesCoverage
+		item.OpenInterestPeriods = []string(row.OpenInterestPeriods)
+		coverage[item.SymbolID] = &item
+	}
+
+	return coverage, nil
+}
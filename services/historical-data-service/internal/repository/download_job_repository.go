@@ -6,26 +6,27 @@ import (
 	"time"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
 // DownloadJobRepository handles database operations for market data downloads
 type DownloadJobRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
 	logger *zap.Logger
 }
 
 // NewDownloadJobRepository creates a new download job repository
-func NewDownloadJobRepository(db *sqlx.DB, logger *zap.Logger) *DownloadJobRepository {
+func NewDownloadJobRepository(db *database.DB, logger *zap.Logger) *DownloadJobRepository {
 	return &DownloadJobRepository{
 		db:     db,
 		logger: logger,
 	}
 }
 
-// CreateDownloadJob creates a new job for downloading market data
+// CreateDownloadJob creates a new job for downloading market data, owned by userID
 func (r *DownloadJobRepository) CreateDownloadJob(
 	ctx context.Context,
 	symbolID int,
@@ -34,8 +35,12 @@ func (r *DownloadJobRepository) CreateDownloadJob(
 	timeframe string,
 	startDate time.Time,
 	endDate time.Time,
+	userID int,
+	estimatedCandles int,
+	includeFundingRates bool,
+	includeOpenInterest bool,
 ) (int, error) {
-	query := `SELECT create_market_data_download_job($1, $2, $3, $4, $5, $6)`
+	query := `SELECT create_market_data_download_job($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
 	var jobID int
 	err := r.db.GetContext(
@@ -48,6 +53,10 @@ func (r *DownloadJobRepository) CreateDownloadJob(
 		timeframe,
 		startDate,
 		endDate,
+		userID,
+		estimatedCandles,
+		includeFundingRates,
+		includeOpenInterest,
 	)
 
 	if err != nil {
@@ -119,6 +128,164 @@ func (r *DownloadJobRepository) UpdateDownloadJobStatus(
 	return success, nil
 }
 
+// ClaimDownloadJob claims the oldest runnable pending job whose source isn't excluded
+// and marks it in progress. Returns nil if there is nothing to run.
+func (r *DownloadJobRepository) ClaimDownloadJob(ctx context.Context, excludedSources []string) (*model.MarketDataDownloadJob, error) {
+	query := `SELECT * FROM claim_download_job($1)`
+
+	var job model.MarketDataDownloadJob
+	err := r.db.GetContext(ctx, &job, query, pq.Array(excludedSources))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to claim download job",
+			zap.Error(err),
+			zap.Strings("excludedSources", excludedSources))
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CheckpointDownloadJob records the end of the last processed chunk and the job's progress.
+// Returns false if the job is no longer running.
+func (r *DownloadJobRepository) CheckpointDownloadJob(
+	ctx context.Context,
+	jobID int,
+	lastProcessedTime time.Time,
+	progress float64,
+	processedCandles int,
+	totalCandles int,
+	retries int,
+) (bool, error) {
+	query := `SELECT checkpoint_download_job($1, $2, $3, $4, $5, $6)`
+
+	var running bool
+	err := r.db.GetContext(
+		ctx,
+		&running,
+		query,
+		jobID,
+		lastProcessedTime,
+		progress,
+		processedCandles,
+		totalCandles,
+		retries,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to checkpoint download job",
+			zap.Error(err),
+			zap.Int("jobID", jobID),
+			zap.Time("lastProcessedTime", lastProcessedTime))
+		return false, err
+	}
+
+	return running, nil
+}
+
+// RecordDownloadJobMetrics adds the cost of provider requests to a job's metrics
+func (r *DownloadJobRepository) RecordDownloadJobMetrics(ctx context.Context, metrics *model.DownloadJobMetrics) error {
+	query := `SELECT record_download_job_metrics($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		metrics.JobID,
+		metrics.Requests,
+		metrics.RequestWeight,
+		metrics.BytesDownloaded,
+		metrics.CandlesDownloaded,
+		metrics.FetchMillis,
+		metrics.ThrottledMillis,
+		metrics.RateLimited,
+	)
+	if err != nil {
+		r.logger.Error("Failed to record download job metrics",
+			zap.Error(err),
+			zap.Int("jobID", metrics.JobID))
+		return err
+	}
+
+	return nil
+}
+
+// GetDownloadJobMetrics gets the metrics of a job, or nil if it hasn't made a request yet
+func (r *DownloadJobRepository) GetDownloadJobMetrics(ctx context.Context, jobID int) (*model.DownloadJobMetrics, error) {
+	query := `SELECT * FROM get_download_job_metrics($1)`
+
+	var metrics model.DownloadJobMetrics
+	err := r.db.GetContext(ctx, &metrics, query, jobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get download job metrics",
+			zap.Error(err),
+			zap.Int("jobID", jobID))
+		return nil, err
+	}
+
+	return &metrics, nil
+}
+
+// FailDownloadJobAttempt schedules a retry after retryDelay, or fails the job once it
+// has used maxAttempts. Returns the job's new status, or "" if it wasn't running.
+func (r *DownloadJobRepository) FailDownloadJobAttempt(
+	ctx context.Context,
+	jobID int,
+	errorMsg string,
+	maxAttempts int,
+	retryDelay time.Duration,
+) (string, error) {
+	query := `SELECT fail_download_job_attempt($1, $2, $3, $4)`
+
+	var status sql.NullString
+	err := r.db.GetContext(ctx, &status, query, jobID, errorMsg, maxAttempts, int(retryDelay.Seconds()))
+	if err != nil {
+		r.logger.Error("Failed to record download job failure",
+			zap.Error(err),
+			zap.Int("jobID", jobID))
+		return "", err
+	}
+
+	return status.String, nil
+}
+
+// ReleaseDownloadJob returns a running job to the queue without using up an attempt
+func (r *DownloadJobRepository) ReleaseDownloadJob(ctx context.Context, jobID int) (bool, error) {
+	query := `SELECT release_download_job($1)`
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, jobID)
+	if err != nil {
+		r.logger.Error("Failed to release download job",
+			zap.Error(err),
+			zap.Int("jobID", jobID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// RequeueStaleDownloadJobs returns running jobs that haven't checkpointed within staleAfter
+// to the queue, and reports how many were requeued
+func (r *DownloadJobRepository) RequeueStaleDownloadJobs(ctx context.Context, staleAfter time.Duration) (int, error) {
+	query := `SELECT requeue_stale_download_jobs($1)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, int(staleAfter.Seconds()))
+	if err != nil {
+		r.logger.Error("Failed to requeue stale download jobs",
+			zap.Error(err),
+			zap.Duration("staleAfter", staleAfter))
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // CountActiveDownloadJobs counts active market data download jobs for pagination
 func (r *DownloadJobRepository) CountActiveDownloadJobs(ctx context.Context, source string) (int, error) {
 	query := `SELECT count_active_download_jobs($1)`
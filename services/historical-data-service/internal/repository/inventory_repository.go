@@ -3,19 +3,19 @@ package repository
 import (
 	"context"
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 // InventoryRepository handles database operations for market data inventory
 type InventoryRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
 	logger *zap.Logger
 }
 
 // NewInventoryRepository creates a new inventory repository
-func NewInventoryRepository(db *sqlx.DB, logger *zap.Logger) *InventoryRepository {
+func NewInventoryRepository(db *database.DB, logger *zap.Logger) *InventoryRepository {
 	return &InventoryRepository{
 		db:     db,
 		logger: logger,
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// JobRepository handles the background jobs of the service, downloads and backtests, for
+// operators
+type JobRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *database.DB, logger *zap.Logger) *JobRepository {
+	return &JobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetJobs gets background jobs matching a filter, newest first. Empty filters match every
+// job.
+func (r *JobRepository) GetJobs(
+	ctx context.Context,
+	filter model.AdminJobFilter,
+	limit int,
+	offset int,
+) ([]model.AdminJob, error) {
+	query := `SELECT * FROM get_admin_jobs(NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5)`
+
+	jobs := []model.AdminJob{}
+	err := r.db.SelectContext(ctx, &jobs, query, filter.Kind, filter.Status, filter.UserID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get admin jobs", zap.Error(err))
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CountJobs counts the background jobs matching a filter
+func (r *JobRepository) CountJobs(ctx context.Context, filter model.AdminJobFilter) (int, error) {
+	query := `SELECT count_admin_jobs(NULLIF($1, ''), NULLIF($2, ''), $3)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, filter.Kind, filter.Status, filter.UserID)
+	if err != nil {
+		r.logger.Error("Failed to count admin jobs", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetJob gets a background job. Returns nil if it doesn't exist.
+func (r *JobRepository) GetJob(ctx context.Context, kind string, id int) (*model.AdminJob, error) {
+	query := `SELECT * FROM admin_jobs WHERE kind = $1 AND id = $2`
+
+	var job model.AdminJob
+	err := r.db.GetContext(ctx, &job, query, kind, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get admin job",
+			zap.Error(err),
+			zap.String("kind", kind),
+			zap.Int("id", id))
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// GetJobCounts counts the background jobs by kind and status
+func (r *JobRepository) GetJobCounts(ctx context.Context) ([]model.AdminJobCount, error) {
+	query := `SELECT * FROM get_admin_job_counts()`
+
+	counts := []model.AdminJobCount{}
+	err := r.db.SelectContext(ctx, &counts, query)
+	if err != nil {
+		r.logger.Error("Failed to get admin job counts", zap.Error(err))
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// CancelBacktest cancels a backtest that hasn't finished, with its unfinished runs.
+// Returns false if the backtest doesn't exist or already finished.
+func (r *JobRepository) CancelBacktest(ctx context.Context, backtestID int, reason string) (bool, error) {
+	query := `SELECT cancel_backtest($1, $2)`
+
+	var cancelled bool
+	err := r.db.GetContext(ctx, &cancelled, query, backtestID, reason)
+	if err != nil {
+		r.logger.Error("Failed to cancel backtest", zap.Error(err), zap.Int("backtestID", backtestID))
+		return false, err
+	}
+
+	return cancelled, nil
+}
+
+// RequeueBacktest queues the failed and cancelled runs of a finished backtest to run again.
+// Returns how many runs were queued, or 0 if the backtest can't be requeued.
+func (r *JobRepository) RequeueBacktest(ctx context.Context, backtestID int) (int, error) {
+	query := `SELECT requeue_finished_backtest($1)`
+
+	var queued sql.NullInt64
+	err := r.db.GetContext(ctx, &queued, query, backtestID)
+	if err != nil {
+		r.logger.Error("Failed to requeue backtest", zap.Error(err), zap.Int("backtestID", backtestID))
+		return 0, err
+	}
+
+	return int(queued.Int64), nil
+}
+
+// RequeueDownloadJob queues a failed or cancelled download job to run again from its
+// checkpoint. Returns false if the job doesn't exist or can't be requeued.
+func (r *JobRepository) RequeueDownloadJob(ctx context.Context, jobID int) (bool, error) {
+	query := `SELECT requeue_download_job($1)`
+
+	var requeued bool
+	err := r.db.GetContext(ctx, &requeued, query, jobID)
+	if err != nil {
+		r.logger.Error("Failed to requeue download job", zap.Error(err), zap.Int("jobID", jobID))
+		return false, err
+	}
+
+	return requeued, nil
+}
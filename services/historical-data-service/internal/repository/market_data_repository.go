@@ -2,25 +2,30 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"time"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	"go.uber.org/zap"
 )
 
 // MarketDataRepository handles database operations for market data
 type MarketDataRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
+	router *database.Router[*database.DB]
 	logger *zap.Logger
 }
 
-// NewMarketDataRepository creates a new market data repository
-func NewMarketDataRepository(db *sqlx.DB, logger *zap.Logger) *MarketDataRepository {
+// NewMarketDataRepository creates a new market data repository. Candle reads go to the
+// router's reader; imports and the data ranges downloads are planned from use the primary.
+func NewMarketDataRepository(router *database.Router[*database.DB], logger *zap.Logger) *MarketDataRepository {
 	return &MarketDataRepository{
-		db:     db,
+		db:     router.Primary(),
+		router: router,
 		logger: logger,
 	}
 }
@@ -70,7 +75,7 @@ func (r *MarketDataRepository) GetCandles(
 	}
 
 	var candles []model.Candle
-	err := r.db.SelectContext(
+	err := r.router.Reader().SelectContext(
 		ctx,
 		&candles,
 		query,
@@ -119,7 +124,7 @@ func (r *MarketDataRepository) CountCandles(
 	}
 
 	var count int
-	err := r.db.GetContext(
+	err := r.router.Reader().GetContext(
 		ctx,
 		&count,
 		query,
@@ -266,28 +271,232 @@ func (r *MarketDataRepository) CalculateMissingDataRanges(
 	return missingRanges, nil
 }
 
-// BatchImportCandles inserts a batch of candles using the insert_candles function
+// GetCandleBounds returns the times of the first and last stored candle of a symbol in a
+// time range, nil when there are none
+func (r *MarketDataRepository) GetCandleBounds(
+	ctx context.Context,
+	symbolID int,
+	startTime time.Time,
+	endTime time.Time,
+) (first, last *time.Time, err error) {
+	query := `SELECT * FROM get_candle_bounds($1, $2, $3)`
+
+	var bounds struct {
+		FirstTime *time.Time `db:"first_time"`
+		LastTime  *time.Time `db:"last_time"`
+	}
+	err = r.router.Reader().GetContext(ctx, &bounds, query, symbolID, startTime, endTime)
+	if err != nil {
+		r.logger.Error("Failed to get candle bounds",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID))
+		return nil, nil, err
+	}
+
+	return bounds.FirstTime, bounds.LastTime, nil
+}
+
+// GetCandlePreview returns the candles of a symbol in a time range aggregated into
+// buckets of bucketMinutes, oldest first
+func (r *MarketDataRepository) GetCandlePreview(
+	ctx context.Context,
+	symbolID int,
+	startTime time.Time,
+	endTime time.Time,
+	bucketMinutes int,
+) ([]model.Candle, error) {
+	query := `SELECT * FROM get_candle_preview($1, $2, $3, $4)`
+
+	candles := []model.Candle{}
+	err := r.router.Reader().SelectContext(ctx, &candles, query, symbolID, startTime, endTime, bucketMinutes)
+	if err != nil {
+		r.logger.Error("Failed to get candle preview",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.Int("bucketMinutes", bucketMinutes))
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+// BatchImportCandles imports a batch of candles idempotently. Rows are COPYed into
+// a transaction-scoped staging table and merged with the merge_candle_import
+// function, which upserts on (symbol_id, candle_time) and reports exactly how
+// many candles were inserted, updated or skipped as unchanged/duplicate. With
+// TimescaleDB, the continuous aggregates are then refreshed over the imported span.
 func (r *MarketDataRepository) BatchImportCandles(
 	ctx context.Context,
 	candles []model.CandleBatch,
-) (int, error) {
-	// Convert to JSONB for the database function
-	candlesJSON, err := json.Marshal(candles)
+) (*model.CandleImportReport, error) {
+	if len(candles) == 0 {
+		return &model.CandleImportReport{}, nil
+	}
+
+	// The import and the refresh of the aggregates after it share one bulk deadline
+	ctx = database.WithQueryClass(ctx, database.QueryBulk)
+	ctx, done := r.db.StartQuery(ctx, database.QueryBulk, "candle import")
+	defer done()
+
+	conn, err := r.db.Conn(ctx)
 	if err != nil {
-		r.logger.Error("Failed to marshal candles to JSON", zap.Error(err))
-		return 0, err
+		r.logger.Error("Failed to acquire connection for candle import", zap.Error(err))
+		return nil, err
 	}
+	defer conn.Close()
 
-	query := `SELECT insert_candles($1)`
+	var report model.CandleImportReport
+	err = conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection type %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
 
-	var insertedCount int
-	err = r.db.GetContext(ctx, &insertedCount, query, candlesJSON)
+		tx, err := pgConn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		_, err = tx.Exec(ctx, `CREATE TEMP TABLE candles_import (LIKE candles) ON COMMIT DROP`)
+		if err != nil {
+			return fmt.Errorf("failed to create staging table: %w", err)
+		}
+
+		rows := make([][]interface{}, len(candles))
+		for i, c := range candles {
+			rows[i] = []interface{}{c.SymbolID, c.Time, c.Open, c.High, c.Low, c.Close, c.Volume}
+		}
+
+		_, err = tx.CopyFrom(
+			ctx,
+			pgx.Identifier{"candles_import"},
+			[]string{"symbol_id", "candle_time", "open", "high", "low", "close", "volume"},
+			pgx.CopyFromRows(rows),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy candles into staging table: %w", err)
+		}
+
+		err = tx.QueryRow(ctx, `SELECT * FROM merge_candle_import()`).Scan(
+			&report.Received,
+			&report.Inserted,
+			&report.Updated,
+			&report.Skipped,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to merge staged candles: %w", err)
+		}
+
+		return tx.Commit(ctx)
+	})
 	if err != nil {
-		r.logger.Error("Failed to batch import candles", zap.Error(err))
-		return 0, err
+		r.logger.Error("Failed to batch import candles",
+			zap.Error(err),
+			zap.Int("candlesInBatch", len(candles)))
+		return nil, err
+	}
+
+	if report.Inserted > 0 || report.Updated > 0 {
+		from, to := candles[0].Time, candles[0].Time
+		for _, c := range candles[1:] {
+			if c.Time.Before(from) {
+				from = c.Time
+			}
+			if c.Time.After(to) {
+				to = c.Time
+			}
+		}
+
+		// The candles are stored either way, so a failed refresh only leaves the
+		// aggregates behind until the span is refreshed again
+		if err := r.refreshCandleAggregates(ctx, from, to); err != nil {
+			r.logger.Error("Failed to refresh candle aggregates",
+				zap.Error(err),
+				zap.Time("from", from),
+				zap.Time("to", to))
+		}
 	}
 
-	return insertedCount, nil
+	return &report, nil
+}
+
+// DeleteCandleRange deletes a symbol's candles in a range widened to whole candles of
+// bucketMinutes, recording the deletion, or only counts them on a dry run. A deletion
+// commits as one transaction; the continuous aggregates are refreshed after it.
+func (r *MarketDataRepository) DeleteCandleRange(
+	ctx context.Context,
+	query *model.CandleDeletionQuery,
+	bucketMinutes int,
+	userID int,
+) (*model.CandleDeletion, error) {
+	ctx = database.WithQueryClass(ctx, database.QueryBulk)
+
+	deletion := model.CandleDeletion{
+		SymbolID:  query.SymbolID,
+		Timeframe: query.Timeframe,
+		DryRun:    query.DryRun,
+	}
+	err := r.db.GetContext(ctx, &deletion, `SELECT * FROM delete_candle_range($1, $2, $3, $4, $5, $6, $7, $8)`,
+		query.SymbolID,
+		query.Timeframe,
+		bucketMinutes,
+		query.Start,
+		query.End,
+		query.DryRun,
+		userID,
+		query.Reason,
+	)
+	if err != nil {
+		r.logger.Error("Failed to delete candle range",
+			zap.Error(err),
+			zap.Int("symbolID", query.SymbolID),
+			zap.Bool("dryRun", query.DryRun))
+		return nil, err
+	}
+
+	if !query.DryRun && deletion.CandleCount > 0 {
+		// The candles are gone either way; a failed refresh leaves the aggregates behind
+		// until the span is refreshed again
+		if err := r.refreshCandleAggregates(ctx, deletion.Start, deletion.End); err != nil {
+			r.logger.Error("Failed to refresh candle aggregates",
+				zap.Error(err),
+				zap.Time("from", deletion.Start),
+				zap.Time("to", deletion.End))
+		}
+	}
+
+	return &deletion, nil
+}
+
+// refreshCandleAggregates re-materializes the continuous aggregates of candles over a time
+// span. Their refresh policies only cover recent days, so imports of older candles would
+// otherwise never reach them. Does nothing without TimescaleDB.
+func (r *MarketDataRepository) refreshCandleAggregates(ctx context.Context, from, to time.Time) error {
+	var aggregates []struct {
+		ViewName      string `db:"view_name"`
+		BucketMinutes int    `db:"bucket_minutes"`
+	}
+	if err := r.db.SelectContext(ctx, &aggregates, `SELECT * FROM get_candle_aggregates()`); err != nil {
+		return err
+	}
+
+	for _, aggregate := range aggregates {
+		// Only buckets wholly inside the window are refreshed, so widen it to bucket bounds
+		bucket := time.Duration(aggregate.BucketMinutes) * time.Minute
+		start := from.Truncate(bucket)
+		end := to.Add(bucket).Truncate(bucket)
+
+		// Refreshing can't run inside a transaction, so this runs on its own
+		_, err := r.db.ExecContext(ctx, `CALL refresh_continuous_aggregate($1::regclass, $2, $3)`,
+			aggregate.ViewName, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", aggregate.ViewName, err)
+		}
+	}
+
+	return nil
 }
 
 // HasData checks if there is market data for a symbol and timeframe
@@ -310,32 +519,18 @@ func (r *MarketDataRepository) HasData(
 	return len(candles) > 0, nil
 }
 
-// GetDataRange returns the date range of available data
+// GetDataRange returns the date range of available data. The first and last stored
+// candles are looked up by time, so only the chunks holding them are read.
 func (r *MarketDataRepository) GetDataRange(
 	ctx context.Context,
 	symbolID int,
 	timeframe string,
 ) (startDate, endDate time.Time, err error) {
-	// Using get_candles with extreme dates to find boundaries
-	query := `
-		SELECT
-			MIN(candle_time) as start_date,
-			MAX(candle_time) as end_date
-		FROM (
-			SELECT * FROM get_candles($1, $2, $3, $4, NULL)
-		) as candles
-	`
-
-	var result struct {
-		StartDate time.Time `db:"start_date"`
-		EndDate   time.Time `db:"end_date"`
-	}
-
 	// Use extreme dates for the range query
 	startValue := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
 	endValue := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	err = r.db.GetContext(ctx, &result, query, symbolID, timeframe, startValue, endValue)
+	first, last, err := r.GetCandleBounds(ctx, symbolID, startValue, endValue)
 	if err != nil {
 		r.logger.Error("Failed to get data range",
 			zap.Error(err),
@@ -344,7 +539,14 @@ func (r *MarketDataRepository) GetDataRange(
 		return time.Time{}, time.Time{}, err
 	}
 
-	return result.StartDate, result.EndDate, nil
+	if first != nil {
+		startDate = *first
+	}
+	if last != nil {
+		endDate = *last
+	}
+
+	return startDate, endDate, nil
 }
 
 // Add this method to services/historical-data-service/internal/repository/market_data_repository.go
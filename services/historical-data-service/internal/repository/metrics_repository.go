@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// MetricsRepository handles database operations for pre-aggregated daily metrics
+type MetricsRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewMetricsRepository creates a new metrics repository
+func NewMetricsRepository(db *database.DB, logger *zap.Logger) *MetricsRepository {
+	return &MetricsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RefreshDailyMetrics recomputes the user, strategy and symbol rollups for a single day
+func (r *MetricsRepository) RefreshDailyMetrics(ctx context.Context, date time.Time) (*model.DailyMetricsRefreshResult, error) {
+	query := `SELECT * FROM refresh_daily_metrics($1)`
+	ctx = database.WithQueryClass(ctx, database.QueryBulk)
+
+	day := date.UTC().Format("2006-01-02")
+
+	var result model.DailyMetricsRefreshResult
+	err := r.db.GetContext(ctx, &result, query, day)
+	if err != nil {
+		r.logger.Error("Failed to refresh daily metrics",
+			zap.Error(err),
+			zap.String("date", day))
+		return nil, err
+	}
+
+	result.MetricDate = truncateToDay(date)
+	return &result, nil
+}
+
+// GetLastAggregatedDate returns the most recent aggregated day, or nil if none
+func (r *MetricsRepository) GetLastAggregatedDate(ctx context.Context) (*time.Time, error) {
+	query := `SELECT get_last_aggregated_metric_date()`
+
+	var lastDate sql.NullTime
+	err := r.db.GetContext(ctx, &lastDate, query)
+	if err != nil {
+		r.logger.Error("Failed to get last aggregated metric date", zap.Error(err))
+		return nil, err
+	}
+
+	if !lastDate.Valid {
+		return nil, nil
+	}
+
+	day := truncateToDay(lastDate.Time)
+	return &day, nil
+}
+
+// GetUserDailyMetrics retrieves daily rollups for a user in a date range
+func (r *MetricsRepository) GetUserDailyMetrics(
+	ctx context.Context,
+	userID int,
+	startDate, endDate time.Time,
+) ([]model.DailyUserMetrics, error) {
+	query := `SELECT * FROM get_daily_user_metrics($1, $2, $3)`
+
+	var metrics []model.DailyUserMetrics
+	err := r.db.SelectContext(ctx, &metrics, query, userID, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get daily user metrics",
+			zap.Error(err),
+			zap.Int("userID", userID))
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// GetStrategyDailyMetrics retrieves daily rollups for a strategy in a date range
+func (r *MetricsRepository) GetStrategyDailyMetrics(
+	ctx context.Context,
+	strategyID int,
+	startDate, endDate time.Time,
+) ([]model.DailyStrategyMetrics, error) {
+	query := `SELECT * FROM get_daily_strategy_metrics($1, $2, $3)`
+
+	var metrics []model.DailyStrategyMetrics
+	err := r.db.SelectContext(ctx, &metrics, query, strategyID, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get daily strategy metrics",
+			zap.Error(err),
+			zap.Int("strategyID", strategyID))
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// GetSymbolDailyMetrics retrieves daily rollups for a symbol in a date range
+func (r *MetricsRepository) GetSymbolDailyMetrics(
+	ctx context.Context,
+	symbolID int,
+	startDate, endDate time.Time,
+) ([]model.DailySymbolMetrics, error) {
+	query := `SELECT * FROM get_daily_symbol_metrics($1, $2, $3)`
+
+	var metrics []model.DailySymbolMetrics
+	err := r.db.SelectContext(ctx, &metrics, query, symbolID, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get daily symbol metrics",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID))
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// truncateToDay returns midnight UTC of the given time's day
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// OrderBookRepository handles database operations for order book snapshots
+type OrderBookRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewOrderBookRepository creates a new order book repository
+func NewOrderBookRepository(db *database.DB, logger *zap.Logger) *OrderBookRepository {
+	return &OrderBookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveSnapshots stores order book snapshots, replacing stored ones of the same symbol and
+// time. Returns the number of snapshots written.
+func (r *OrderBookRepository) SaveSnapshots(ctx context.Context, snapshots []model.OrderBookSnapshot) (int, error) {
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	symbolIDs := make([]int64, len(snapshots))
+	times := make([]time.Time, len(snapshots))
+	updateIDs := make([]int64, len(snapshots))
+	bids := make([]string, len(snapshots))
+	asks := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		symbolIDs[i] = int64(snapshot.SymbolID)
+		times[i] = snapshot.SnapshotTime
+		updateIDs[i] = snapshot.LastUpdateID
+
+		bidsJSON, err := json.Marshal(snapshot.Bids)
+		if err != nil {
+			return 0, err
+		}
+		asksJSON, err := json.Marshal(snapshot.Asks)
+		if err != nil {
+			return 0, err
+		}
+		bids[i], asks[i] = string(bidsJSON), string(asksJSON)
+	}
+
+	query := `SELECT import_order_book_snapshots($1, $2, $3, $4, $5)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query,
+		pq.Array(symbolIDs), pq.Array(times), pq.Array(updateIDs), pq.Array(bids), pq.Array(asks))
+	if err != nil {
+		r.logger.Error("Failed to save order book snapshots", zap.Error(err), zap.Int("count", len(snapshots)))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetSnapshots retrieves up to limit order book snapshots of a symbol in a time range,
+// oldest first. Nil bounds leave that side of the range open.
+func (r *OrderBookRepository) GetSnapshots(
+	ctx context.Context,
+	symbolID int,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.OrderBookSnapshot, error) {
+	query := `SELECT * FROM get_order_book_snapshots($1, $2, $3, $4)`
+
+	snapshots := []model.OrderBookSnapshot{}
+	err := r.db.SelectContext(ctx, &snapshots, query, symbolID, startTime, endTime, limit)
+	if err != nil {
+		r.logger.Error("Failed to get order book snapshots", zap.Error(err), zap.Int("symbolID", symbolID))
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// CountSnapshots counts the order book snapshots of each of the symbols captured in a
+// time range, by symbol ID
+func (r *OrderBookRepository) CountSnapshots(ctx context.Context, symbolIDs []int, startTime, endTime time.Time) (map[int]int64, error) {
+	query := `SELECT * FROM count_order_book_snapshots($1, $2, $3)`
+
+	var rows []struct {
+		SymbolID      int   `db:"symbol_id"`
+		SnapshotCount int64 `db:"snapshot_count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(symbolIDs), startTime, endTime)
+	if err != nil {
+		r.logger.Error("Failed to count order book snapshots", zap.Error(err))
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.SymbolID] = row.SnapshotCount
+	}
+
+	return counts, nil
+}
+
+// PruneSnapshots deletes the order book snapshots taken before a cutoff. Returns the
+// number deleted.
+func (r *OrderBookRepository) PruneSnapshots(ctx context.Context, before time.Time) (int, error) {
+	query := `SELECT prune_order_book_snapshots($1)`
+	ctx = database.WithQueryClass(ctx, database.QueryBulk)
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, before)
+	if err != nil {
+		r.logger.Error("Failed to prune order book snapshots", zap.Error(err), zap.Time("before", before))
+		return 0, err
+	}
+
+	return count, nil
+}
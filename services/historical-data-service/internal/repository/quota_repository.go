@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// QuotaRepository handles database operations for user quota usage
+type QuotaRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewQuotaRepository creates a new quota repository
+func NewQuotaRepository(db *database.DB, logger *zap.Logger) *QuotaRepository {
+	return &QuotaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUserQuotaUsage returns a user's current usage, counting daily quotas from dayStart
+func (r *QuotaRepository) GetUserQuotaUsage(ctx context.Context, userID int, dayStart time.Time) (*model.QuotaUsage, error) {
+	query := `SELECT * FROM get_user_quota_usage($1, $2)`
+
+	var usage model.QuotaUsage
+	err := r.db.GetContext(ctx, &usage, query, userID, dayStart)
+	if err != nil {
+		r.logger.Error("Failed to get user quota usage",
+			zap.Error(err),
+			zap.Int("userID", userID))
+		return nil, err
+	}
+
+	return &usage, nil
+}
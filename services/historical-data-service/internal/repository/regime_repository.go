@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// RegimeRepository handles database operations for market regime segments
+type RegimeRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewRegimeRepository creates a new regime repository
+func NewRegimeRepository(db *database.DB, logger *zap.Logger) *RegimeRepository {
+	return &RegimeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetRegimes retrieves the regime segments overlapping a time range using get_market_regimes function.
+// Nil bounds leave that side of the range open.
+func (r *RegimeRepository) GetRegimes(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+	startTime *time.Time,
+	endTime *time.Time,
+) ([]model.MarketRegimeSegment, error) {
+	query := `SELECT * FROM get_market_regimes($1, $2, $3, $4)`
+
+	segments := []model.MarketRegimeSegment{}
+	err := r.db.SelectContext(ctx, &segments, query, symbolID, timeframe, startTime, endTime)
+	if err != nil {
+		r.logger.Error("Failed to get market regimes",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.String("timeframe", timeframe))
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// GetLastClassification returns when a symbol and timeframe were last classified and the end
+// of the last segment, or nils if they have never been classified
+func (r *RegimeRepository) GetLastClassification(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+) (*time.Time, *time.Time, error) {
+	query := `
+		SELECT MAX(classified_at) AS classified_at, MAX(end_time) AS end_time
+		FROM market_regimes
+		WHERE symbol_id = $1 AND timeframe = $2
+	`
+
+	var last struct {
+		ClassifiedAt *time.Time `db:"classified_at"`
+		EndTime      *time.Time `db:"end_time"`
+	}
+	err := r.db.GetContext(ctx, &last, query, symbolID, timeframe)
+	if err != nil {
+		r.logger.Error("Failed to get last regime classification",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.String("timeframe", timeframe))
+		return nil, nil, err
+	}
+
+	return last.ClassifiedAt, last.EndTime, nil
+}
+
+// ReplaceRegimes replaces all regime segments of a symbol and timeframe in one transaction
+func (r *RegimeRepository) ReplaceRegimes(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+	segments []model.MarketRegimeSegment,
+) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM market_regimes WHERE symbol_id = $1 AND timeframe = $2`,
+		symbolID, timeframe)
+	if err != nil {
+		r.logger.Error("Failed to delete market regimes",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.String("timeframe", timeframe))
+		return err
+	}
+
+	insert := `
+		INSERT INTO market_regimes (
+			symbol_id, timeframe, trend, volatility, start_time, end_time,
+			candle_count, return_percent, volatility_percent, classified_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+	`
+	for _, segment := range segments {
+		_, err = tx.ExecContext(ctx, insert,
+			symbolID,
+			timeframe,
+			segment.Trend,
+			segment.Volatility,
+			segment.StartTime,
+			segment.EndTime,
+			segment.CandleCount,
+			segment.ReturnPercent,
+			segment.VolatilityPercent,
+		)
+		if err != nil {
+			r.logger.Error("Failed to insert market regime",
+				zap.Error(err),
+				zap.Int("symbolID", symbolID),
+				zap.String("timeframe", timeframe))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"go.uber.org/zap"
+)
+
+// StatsRepository handles aggregation queries for admin statistics
+type StatsRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *database.DB, logger *zap.Logger) *StatsRepository {
+	return &StatsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetDataStats aggregates backtest, download and inventory KPIs, counting activity since the given time
+func (r *StatsRepository) GetDataStats(ctx context.Context, since time.Time) (*model.DataStats, error) {
+	query := `SELECT * FROM get_data_stats($1)`
+
+	var stats model.DataStats
+	err := r.db.GetContext(ctx, &stats, query, since)
+	if err != nil {
+		r.logger.Error("Failed to get data stats", zap.Error(err), zap.Time("since", since))
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -5,19 +5,19 @@ import (
 	"database/sql"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 // SymbolRepository handles database operations for symbols
 type SymbolRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
 	logger *zap.Logger
 }
 
 // NewSymbolRepository creates a new symbol repository
-func NewSymbolRepository(db *sqlx.DB, logger *zap.Logger) *SymbolRepository {
+func NewSymbolRepository(db *database.DB, logger *zap.Logger) *SymbolRepository {
 	return &SymbolRepository{
 		db:     db,
 		logger: logger,
@@ -110,6 +110,31 @@ func (r *SymbolRepository) GetSymbolByID(ctx context.Context, id int) (*model.Sy
 	return &symbol, nil
 }
 
+// GetSymbolByName retrieves a symbol by its ticker, e.g. BTCUSDT
+func (r *SymbolRepository) GetSymbolByName(ctx context.Context, name string) (*model.Symbol, error) {
+	query := `
+		SELECT 
+			id, symbol, name, exchange, asset_type, 
+			is_active, data_available, created_at, updated_at
+		FROM symbols
+		WHERE UPPER(symbol) = UPPER($1)
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var symbol model.Symbol
+	err := r.db.GetContext(ctx, &symbol, query, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get symbol by name", zap.Error(err), zap.String("symbol", name))
+		return nil, err
+	}
+
+	return &symbol, nil
+}
+
 // CreateSymbol creates a new symbol using add_symbol function
 func (r *SymbolRepository) CreateSymbol(ctx context.Context, symbol *model.Symbol) (int, error) {
 	query := `SELECT add_symbol($1, $2, $3, $4)`
@@ -2,87 +2,67 @@ package repository
 
 import (
 	"context"
-	"time"
+	"database/sql"
 
 	"services/historical-data-service/internal/model"
+	"services/shared/database"
 
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 // TimeframeRepository handles database operations for timeframes
 type TimeframeRepository struct {
-	db     *sqlx.DB
+	db     *database.DB
 	logger *zap.Logger
 }
 
 // NewTimeframeRepository creates a new timeframe repository
-func NewTimeframeRepository(db *sqlx.DB, logger *zap.Logger) *TimeframeRepository {
+func NewTimeframeRepository(db *database.DB, logger *zap.Logger) *TimeframeRepository {
 	return &TimeframeRepository{
 		db:     db,
 		logger: logger,
 	}
 }
 
-// GetAllTimeframes retrieves all available timeframes
-func (r *TimeframeRepository) GetAllTimeframes(ctx context.Context) ([]model.Timeframe, error) {
-	// Direct query to get timeframes from the timeframe_type enum
-	query := `
-		SELECT 
-			t.typname AS name,
-			e.enumlabel AS display_name,
-			CASE
-				WHEN e.enumlabel = '1m' THEN 1
-				WHEN e.enumlabel = '5m' THEN 5
-				WHEN e.enumlabel = '15m' THEN 15
-				WHEN e.enumlabel = '30m' THEN 30
-				WHEN e.enumlabel = '1h' THEN 60
-				WHEN e.enumlabel = '4h' THEN 240
-				WHEN e.enumlabel = '1d' THEN 1440
-				WHEN e.enumlabel = '1w' THEN 10080
-			END AS minutes
-		FROM pg_type t
-		JOIN pg_enum e ON t.oid = e.enumtypid
-		WHERE t.typname = 'timeframe_type'
-		ORDER BY minutes
-	`
-
-	var timeframes []struct {
-		Name        string `db:"name"`
-		DisplayName string `db:"display_name"`
-		Minutes     int    `db:"minutes"`
-	}
+// GetAllTimeframes retrieves the timeframe catalog using get_timeframes function
+func (r *TimeframeRepository) GetAllTimeframes(ctx context.Context, includeInactive bool) ([]model.Timeframe, error) {
+	query := `SELECT * FROM get_timeframes($1)`
 
-	err := r.db.SelectContext(ctx, &timeframes, query)
+	timeframes := []model.Timeframe{}
+	err := r.db.SelectContext(ctx, &timeframes, query, includeInactive)
 	if err != nil {
 		r.logger.Error("Failed to get timeframes", zap.Error(err))
 		return nil, err
 	}
 
-	// Convert to the model format
-	result := make([]model.Timeframe, len(timeframes))
-	for i, tf := range timeframes {
-		result[i] = model.Timeframe{
-			ID:          i + 1, // Assign an auto-incrementing ID
-			Name:        tf.Name,
-			DisplayName: tf.DisplayName,
-			Minutes:     tf.Minutes,
-			CreatedAt:   time.Now(),
+	// IDs are positions in the catalog, kept for clients that expect them
+	for i := range timeframes {
+		timeframes[i].ID = i + 1
+	}
+
+	return timeframes, nil
+}
+
+// GetTimeframe retrieves a timeframe by name. Returns nil if it doesn't exist.
+func (r *TimeframeRepository) GetTimeframe(ctx context.Context, name string) (*model.Timeframe, error) {
+	query := `SELECT * FROM get_timeframes(TRUE) WHERE name = $1`
+
+	var timeframe model.Timeframe
+	err := r.db.GetContext(ctx, &timeframe, query, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
+		r.logger.Error("Failed to get timeframe", zap.Error(err), zap.String("timeframe", name))
+		return nil, err
 	}
 
-	return result, nil
+	return &timeframe, nil
 }
 
-// ValidateTimeframe checks if a timeframe is valid
+// ValidateTimeframe checks if a timeframe is in the catalog and active
 func (r *TimeframeRepository) ValidateTimeframe(ctx context.Context, timeframe string) (bool, error) {
-	query := `
-		SELECT EXISTS (
-			SELECT 1 FROM pg_enum e
-			JOIN pg_type t ON e.enumtypid = t.oid
-			WHERE t.typname = 'timeframe_type' AND e.enumlabel = $1
-		)
-	`
+	query := `SELECT EXISTS (SELECT 1 FROM timeframes WHERE name = $1 AND is_active)`
 
 	var exists bool
 	err := r.db.GetContext(ctx, &exists, query, timeframe)
@@ -93,3 +73,66 @@ func (r *TimeframeRepository) ValidateTimeframe(ctx context.Context, timeframe s
 
 	return exists, nil
 }
+
+// CreateTimeframe adds a custom timeframe to the timeframe_type enum and the catalog using
+// create_timeframe function. The name must already be validated, as it can't be passed to
+// ALTER TYPE as a parameter. Returns false if the timeframe already exists.
+func (r *TimeframeRepository) CreateTimeframe(
+	ctx context.Context,
+	name string,
+	displayName string,
+	minutes int,
+	providerInterval string,
+) (bool, error) {
+	// Adding an enum value can't be undone, so it is left in place if the insert fails
+	_, err := r.db.ExecContext(ctx, `ALTER TYPE timeframe_type ADD VALUE IF NOT EXISTS '`+name+`'`)
+	if err != nil {
+		r.logger.Error("Failed to add timeframe to enum", zap.Error(err), zap.String("timeframe", name))
+		return false, err
+	}
+
+	var created bool
+	err = r.db.GetContext(ctx, &created, `SELECT create_timeframe($1, $2, $3, $4)`,
+		name, displayName, minutes, providerInterval)
+	if err != nil {
+		r.logger.Error("Failed to create timeframe", zap.Error(err), zap.String("timeframe", name))
+		return false, err
+	}
+
+	return created, nil
+}
+
+// UpdateTimeframe updates a timeframe using update_timeframe function; nil fields are kept.
+// Returns false if the timeframe doesn't exist.
+func (r *TimeframeRepository) UpdateTimeframe(
+	ctx context.Context,
+	name string,
+	request *model.TimeframeUpdateRequest,
+) (bool, error) {
+	query := `SELECT update_timeframe($1, $2, $3, $4)`
+
+	var updated bool
+	err := r.db.GetContext(ctx, &updated, query,
+		name, request.DisplayName, request.ProviderInterval, request.IsActive)
+	if err != nil {
+		r.logger.Error("Failed to update timeframe", zap.Error(err), zap.String("timeframe", name))
+		return false, err
+	}
+
+	return updated, nil
+}
+
+// DeleteTimeframe deletes an unused custom timeframe using delete_timeframe function.
+// Returns false if there is no such custom timeframe.
+func (r *TimeframeRepository) DeleteTimeframe(ctx context.Context, name string) (bool, error) {
+	query := `SELECT delete_timeframe($1)`
+
+	var deleted bool
+	err := r.db.GetContext(ctx, &deleted, query, name)
+	if err != nil {
+		r.logger.Error("Failed to delete timeframe", zap.Error(err), zap.String("timeframe", name))
+		return false, err
+	}
+
+	return deleted, nil
+}
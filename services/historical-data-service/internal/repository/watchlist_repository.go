@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/historical-data-service/internal/model"
+	"services/shared/database"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// WatchlistRepository handles database operations for user watchlists
+type WatchlistRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewWatchlistRepository creates a new watchlist repository
+func NewWatchlistRepository(db *database.DB, logger *zap.Logger) *WatchlistRepository {
+	return &WatchlistRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetWatchlists retrieves the watchlists of a user with their symbols
+func (r *WatchlistRepository) GetWatchlists(ctx context.Context, userID int) ([]model.Watchlist, error) {
+	watchlists := []model.Watchlist{}
+	err := r.db.SelectContext(ctx, &watchlists, `SELECT * FROM get_user_watchlists($1, NULL)`, userID)
+	if err != nil {
+		r.logger.Error("Failed to get watchlists", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	var symbols []model.WatchlistSymbol
+	err = r.db.SelectContext(ctx, &symbols, `SELECT * FROM get_watchlist_symbols($1, NULL)`, userID)
+	if err != nil {
+		r.logger.Error("Failed to get watchlist symbols", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	byWatchlist := make(map[int][]model.WatchlistSymbol)
+	for _, symbol := range symbols {
+		byWatchlist[symbol.WatchlistID] = append(byWatchlist[symbol.WatchlistID], symbol)
+	}
+	for i := range watchlists {
+		watchlists[i].Symbols = byWatchlist[watchlists[i].ID]
+		if watchlists[i].Symbols == nil {
+			watchlists[i].Symbols = []model.WatchlistSymbol{}
+		}
+	}
+
+	return watchlists, nil
+}
+
+// GetWatchlist retrieves a watchlist of a user with its symbols. Returns nil if the user
+// has no such watchlist.
+func (r *WatchlistRepository) GetWatchlist(ctx context.Context, id, userID int) (*model.Watchlist, error) {
+	var watchlist model.Watchlist
+	err := r.db.GetContext(ctx, &watchlist, `SELECT * FROM get_user_watchlists($1, $2)`, userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get watchlist", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	watchlist.Symbols = []model.WatchlistSymbol{}
+	err = r.db.SelectContext(ctx, &watchlist.Symbols, `SELECT * FROM get_watchlist_symbols($1, $2)`, userID, id)
+	if err != nil {
+		r.logger.Error("Failed to get watchlist symbols", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &watchlist, nil
+}
+
+// CreateWatchlist creates a watchlist for a user and returns its ID
+func (r *WatchlistRepository) CreateWatchlist(ctx context.Context, userID int, create *model.WatchlistCreate) (int, error) {
+	query := `SELECT create_watchlist($1, $2, $3, $4)`
+
+	symbolIDs := create.SymbolIDs
+	if symbolIDs == nil {
+		symbolIDs = []int{}
+	}
+
+	var id int
+	err := r.db.GetContext(ctx, &id, query, userID, create.Name, create.Description, pq.Array(symbolIDs))
+	if err != nil {
+		r.logger.Error("Failed to create watchlist", zap.Error(err), zap.Int("userID", userID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdateWatchlist updates a watchlist of a user. Returns false if the user has no such
+// watchlist.
+func (r *WatchlistRepository) UpdateWatchlist(ctx context.Context, id, userID int, update *model.WatchlistUpdate) (bool, error) {
+	query := `SELECT update_watchlist($1, $2, $3, $4, $5)`
+
+	// NULL leaves the symbols unchanged
+	var symbolIDs interface{}
+	if update.SymbolIDs != nil {
+		symbolIDs = pq.Array(update.SymbolIDs)
+	}
+
+	var updated bool
+	err := r.db.GetContext(ctx, &updated, query, id, userID, update.Name, update.Description, symbolIDs)
+	if err != nil {
+		r.logger.Error("Failed to update watchlist", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return updated, nil
+}
+
+// DeleteWatchlist deletes a watchlist of a user. Returns false if the user has no such
+// watchlist.
+func (r *WatchlistRepository) DeleteWatchlist(ctx context.Context, id, userID int) (bool, error) {
+	var deleted bool
+	err := r.db.GetContext(ctx, &deleted, `SELECT delete_watchlist($1, $2)`, id, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete watchlist", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// GetWatchlistQuotes quotes the symbols of a watchlist from stored candles
+func (r *WatchlistRepository) GetWatchlistQuotes(ctx context.Context, id int) ([]model.WatchlistQuote, error) {
+	quotes := []model.WatchlistQuote{}
+	err := r.db.SelectContext(ctx, &quotes, `SELECT * FROM get_watchlist_quotes($1)`, id)
+	if err != nil {
+		r.logger.Error("Failed to get watchlist quotes", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return quotes, nil
+}
@@ -6,32 +6,65 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
+	"services/shared/pagination"
 
 	"go.uber.org/zap"
 )
 
+// backtestSortFields are the fields backtests can be sorted by
+var backtestSortFields = pagination.SortFields{"name", "created_at", "status", "strategy_id"}
+
+// tradeSortFields are the fields the trades of a backtest run can be sorted by
+var tradeSortFields = pagination.SortFields{"entry_time", "exit_time", "position_type", "profit_loss", "profit_loss_percent"}
+
+// runSortFields are the fields the runs of a backtest can be sorted by
+var runSortFields = pagination.SortFields{"id", "status", "created_at", "completed_at"}
+
 // BacktestService handles backtest operations
 type BacktestService struct {
 	backtestRepo   *repository.BacktestRepository
 	marketDataRepo *repository.MarketDataRepository
+	derivatives    *repository.DerivativesRepository
+	orderBooks     *repository.OrderBookRepository
 	strategyClient *client.StrategyClient
 	backtestClient *client.BacktestClient
-	logger         *zap.Logger
+	quotaService   *QuotaService
+	calendar       *CalendarService
+	watchlists     *WatchlistService
+	events         *client.EventClient
+	jobs           *JobRegistry
+	// symbolWorkers bounds how many symbols of one backtest run at once
+	symbolWorkers int
+	engine        BacktestEngineOptions
+	logger        *zap.Logger
 }
 
 // NewBacktestService creates a new backtest service
 func NewBacktestService(
 	backtestRepo *repository.BacktestRepository,
 	marketDataRepo *repository.MarketDataRepository,
+	derivativesRepo *repository.DerivativesRepository,
+	orderBookRepo *repository.OrderBookRepository,
 	strategyClient *client.StrategyClient,
+	quotaService *QuotaService,
+	calendarService *CalendarService,
+	watchlistService *WatchlistService,
+	events *client.EventClient,
+	jobs *JobRegistry,
+	symbolWorkers int,
+	engine BacktestEngineOptions,
 	logger *zap.Logger,
 ) *BacktestService {
 	// Get backtest service URL from environment or use default
@@ -40,57 +73,440 @@ func NewBacktestService(
 		backtestServiceURL = "http://backtest-service:5000"
 	}
 
+	if symbolWorkers < 1 {
+		symbolWorkers = 1
+	}
+	if engine.Protocol == "" {
+		engine.Protocol = EngineProtocolV1
+	}
+	if engine.Lease <= 0 {
+		engine.Lease = 2 * time.Minute
+	}
+
 	// Create backtest client
 	backtestClient := client.NewBacktestClient(backtestServiceURL, logger)
 
 	return &BacktestService{
 		backtestRepo:   backtestRepo,
 		marketDataRepo: marketDataRepo,
+		derivatives:    derivativesRepo,
+		orderBooks:     orderBookRepo,
 		strategyClient: strategyClient,
 		backtestClient: backtestClient,
+		quotaService:   quotaService,
+		calendar:       calendarService,
+		watchlists:     watchlistService,
+		events:         events,
+		jobs:           jobs,
+		symbolWorkers:  symbolWorkers,
+		engine:         engine,
 		logger:         logger,
 	}
 }
 
-// CreateBacktest creates a new backtest and queues it for processing
+// CreateBacktest creates a new backtest and queues it for processing. The warnings describe
+// how the date range was adjusted to the symbols' trading calendars.
 func (s *BacktestService) CreateBacktest(
 	ctx context.Context,
 	request *model.BacktestRequest,
 	userID int,
+	quotaTier string,
 	token string,
-) (int, error) {
+) (int, []string, error) {
 	// Validate date range
 	if request.EndDate.Before(request.StartDate) {
-		return 0, errors.New("end date must be after start date")
+		return 0, nil, errors.New("end date must be after start date")
+	}
+
+	settings, err := resolveBacktestSettings(request)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tags, err := normalizeBacktestTags(request.Tags)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Run a watchlist's backtest on the symbols it lists now
+	if request.WatchlistID != nil {
+		if len(request.SymbolIDs) > 0 {
+			return 0, nil, errors.New("invalid backtest request: give either symbol_ids or watchlist_id")
+		}
+		request.SymbolIDs, err = s.watchlists.GetWatchlistSymbolIDs(ctx, *request.WatchlistID, userID)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Move the range off days the symbols' exchanges are closed
+	startDate, endDate, warnings, err := s.calendar.AdjustBacktestRange(ctx, request.SymbolIDs, request.StartDate, request.EndDate)
+	if err != nil {
+		return 0, nil, err
+	}
+	request.StartDate = startDate
+	request.EndDate = endDate
+
+	// Enforce the user's concurrent and daily backtest limits
+	if err := s.quotaService.CheckBacktest(ctx, userID, quotaTier); err != nil {
+		return 0, nil, err
 	}
 
 	// Get strategy details
 	strategy, err := s.strategyClient.GetStrategy(ctx, request.StrategyID, token)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get strategy details: %w", err)
+		return 0, nil, fmt.Errorf("failed to get strategy details: %w", err)
 	}
 
 	if strategy == nil {
-		return 0, errors.New("strategy not found")
+		return 0, nil, errcode.ErrStrategyNotFound
+	}
+
+	// Use strategy version from request or default to latest version
+	strategyVersion := request.StrategyVersion
+	if strategyVersion == 0 {
+		strategyVersion = strategy.Version
+	}
+
+	structure := strategy.Structure
+	if strategyVersion != strategy.Version {
+		version, err := s.strategyClient.GetStrategyVersion(ctx, request.StrategyID, strategyVersion, token)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to get strategy version: %w", err)
+		}
+		if version == nil {
+			return 0, nil, errcode.ErrStrategyNotFound
+		}
+		structure = version.Structure
+	}
+
+	// Indicators may be computed on longer timeframes than the backtest's, whose candles
+	// the engine loads next to the backtest's
+	settings.AuxiliaryTimeframes, err = auxiliaryTimeframes(structure, request.Timeframe)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Verify data availability for all symbols, on every timeframe the strategy uses
+	for _, timeframe := range append([]string{request.Timeframe}, settings.AuxiliaryTimeframes...) {
+		if err := s.checkDataAvailability(ctx, request, timeframe); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if settings.IncludeFundingRates {
+		if err := s.checkFundingRateCoverage(ctx, request); err != nil {
+			return 0, nil, err
+		}
+	}
+	if settings.SlippageModel.Type == model.SlippageModelSpread {
+		if err := s.checkOrderBookCoverage(ctx, request); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Set default name if not provided, otherwise fill in its template variables
+	name := request.Name
+	if name == "" {
+		name = strategy.Name + " Backtest"
+	} else {
+		name, err = s.resolveBacktestName(ctx, name, strategy.Name, request)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Create backtest using repository function
+	backtestID, err := s.backtestRepo.CreateBacktest(
+		ctx,
+		userID,
+		request.StrategyID,
+		strategyVersion,
+		name,
+		request.Description,
+		request.Timeframe,
+		request.StartDate,
+		request.EndDate,
+		request.InitialCapital,
+		request.SymbolIDs,
+		settings,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Record the candles the backtest runs on, so reruns and audits can tell if they change
+	if err := s.backtestRepo.RecordBacktestDataManifest(ctx, backtestID, request.PinData); err != nil {
+		s.failBacktest(ctx, backtestID, "Failed to record the backtest's data manifest")
+		return 0, nil, err
+	}
+
+	// Tags only help find the backtest, so failing to store them doesn't stop it
+	tags = append(tags, autoBacktestTags(strategyVersion, structure, settings)...)
+	if err := s.backtestRepo.SetBacktestTags(ctx, backtestID, tags); err != nil {
+		s.logger.Warn("Failed to tag backtest", zap.Error(err), zap.Int("backtestID", backtestID))
+	}
+
+	// Start backtest in the background
+	s.startBacktest(backtestID, request, settings, userID, token)
+
+	return backtestID, warnings, nil
+}
+
+// Bounds of the trading parameters a backtest can request
+const (
+	maxLeverage       = 125.0
+	maxCommissionRate = 5.0
+	maxSlippageRate   = 5.0
+
+	// Slippage models take basis points, up to the same bound as slippage_rate
+	maxSlippageBasisPoints        = maxSlippageRate * 100
+	defaultMaxSlippageBasisPoints = 100.0
+)
+
+// resolveBacktestSettings applies the request's trading parameters over the defaults and
+// validates them. Leverage, short positions and funding are only available on futures markets.
+func resolveBacktestSettings(request *model.BacktestRequest) (model.BacktestSettings, error) {
+	settings := model.DefaultBacktestSettings()
+
+	if request.MarketType != nil {
+		settings.MarketType = strings.ToLower(*request.MarketType)
+	}
+	if request.Leverage != nil {
+		settings.Leverage = *request.Leverage
+	}
+	if request.CommissionRate != nil {
+		settings.CommissionRate = *request.CommissionRate
+	}
+	if request.SlippageRate != nil {
+		settings.SlippageRate = *request.SlippageRate
+	}
+	if request.AllowShort != nil {
+		settings.AllowShort = *request.AllowShort
+	}
+	if request.PositionSizing != nil {
+		settings.PositionSizing = strings.ToLower(*request.PositionSizing)
+	}
+	if request.IncludeFundingRates != nil {
+		settings.IncludeFundingRates = *request.IncludeFundingRates
+	}
+	if request.SlippageModel != nil && request.SlippageRate != nil {
+		return settings, errors.New("invalid slippage settings: give either slippage_rate or slippage_model")
+	}
+
+	switch settings.MarketType {
+	case model.MarketTypeSpot, model.MarketTypeFutures:
+	default:
+		return settings, fmt.Errorf("invalid market_type %q: must be spot or futures", settings.MarketType)
+	}
+
+	sizing, err := resolvePositionSizingParams(settings.PositionSizing, request.PositionSizingParams)
+	if err != nil {
+		return settings, err
+	}
+	settings.PositionSizingParams = sizing
+
+	if settings.Leverage < 1 || settings.Leverage > maxLeverage {
+		return settings, fmt.Errorf("leverage must be between 1 and %g", maxLeverage)
+	}
+	if settings.CommissionRate < 0 || settings.CommissionRate > maxCommissionRate {
+		return settings, fmt.Errorf("commission_rate must be between 0 and %g percent", maxCommissionRate)
+	}
+	if settings.SlippageRate < 0 || settings.SlippageRate > maxSlippageRate {
+		return settings, fmt.Errorf("slippage_rate must be between 0 and %g percent", maxSlippageRate)
+	}
+
+	slippage, err := resolveSlippageModel(request.SlippageModel, settings.SlippageRate)
+	if err != nil {
+		return settings, err
+	}
+	settings.SlippageModel = slippage
+	if slippage.Type == model.SlippageModelFixed {
+		settings.SlippageRate = slippage.BasisPoints / 100
+	}
+
+	if settings.MarketType == model.MarketTypeSpot {
+		if settings.Leverage != 1 {
+			return settings, errors.New("leverage requires market_type futures")
+		}
+		if settings.AllowShort {
+			return settings, errors.New("allow_short requires market_type futures")
+		}
+		if settings.IncludeFundingRates {
+			return settings, errors.New("include_funding_rates requires market_type futures")
+		}
+	}
+
+	return settings, nil
+}
+
+// resolvePositionSizingParams applies the defaults of a position sizing mode to the
+// requested parameters and validates them. Parameters of other modes are dropped.
+func resolvePositionSizingParams(mode string, requested *model.PositionSizingParams) (*model.PositionSizingParams, error) {
+	var given model.PositionSizingParams
+	if requested != nil {
+		given = *requested
+	}
+
+	withDefault := func(value, fallback float64) float64 {
+		if value == 0 {
+			return fallback
+		}
+		return value
+	}
+	percent := func(name string, value float64) error {
+		if value <= 0 || value > 100 {
+			return fmt.Errorf("position_sizing_params %s must be above 0 and at most 100 percent", name)
+		}
+		return nil
+	}
+
+	var sizing model.PositionSizingParams
+	switch mode {
+	case model.PositionSizingFixed:
+		return &sizing, nil
+
+	case model.PositionSizingPercentage, model.PositionSizingRiskBased:
+		sizing.RiskPercent = withDefault(given.RiskPercent, 2)
+		if err := percent("risk_percent", sizing.RiskPercent); err != nil {
+			return nil, err
+		}
+
+	case model.PositionSizingFixedFractional:
+		sizing.FractionPercent = withDefault(given.FractionPercent, 10)
+		if err := percent("fraction_percent", sizing.FractionPercent); err != nil {
+			return nil, err
+		}
+
+	case model.PositionSizingKelly:
+		sizing.KellyMultiplier = withDefault(given.KellyMultiplier, 0.5)
+		sizing.LookbackTrades = int(withDefault(float64(given.LookbackTrades), 50))
+		sizing.MinTrades = int(withDefault(float64(given.MinTrades), 10))
+		sizing.FractionPercent = withDefault(given.FractionPercent, 10)
+		sizing.MaxFractionPercent = withDefault(given.MaxFractionPercent, 25)
+
+		if sizing.KellyMultiplier <= 0 || sizing.KellyMultiplier > 1 {
+			return nil, errors.New("position_sizing_params kelly_multiplier must be above 0 and at most 1")
+		}
+		if sizing.LookbackTrades < 5 || sizing.LookbackTrades > 1000 {
+			return nil, errors.New("position_sizing_params lookback_trades must be between 5 and 1000")
+		}
+		if sizing.MinTrades < 1 || sizing.MinTrades > sizing.LookbackTrades {
+			return nil, errors.New("position_sizing_params min_trades must be between 1 and lookback_trades")
+		}
+		if err := percent("fraction_percent", sizing.FractionPercent); err != nil {
+			return nil, err
+		}
+		if err := percent("max_fraction_percent", sizing.MaxFractionPercent); err != nil {
+			return nil, err
+		}
+
+	case model.PositionSizingVolatilityTarget:
+		sizing.RiskPercent = withDefault(given.RiskPercent, 1)
+		sizing.ATRPeriod = int(withDefault(float64(given.ATRPeriod), 14))
+		sizing.ATRMultiple = withDefault(given.ATRMultiple, 2)
+		sizing.MaxFractionPercent = withDefault(given.MaxFractionPercent, 100)
+
+		if err := percent("risk_percent", sizing.RiskPercent); err != nil {
+			return nil, err
+		}
+		if sizing.ATRPeriod < 2 || sizing.ATRPeriod > 500 {
+			return nil, errors.New("position_sizing_params atr_period must be between 2 and 500")
+		}
+		if sizing.ATRMultiple <= 0 || sizing.ATRMultiple > 20 {
+			return nil, errors.New("position_sizing_params atr_multiple must be above 0 and at most 20")
+		}
+		if err := percent("max_fraction_percent", sizing.MaxFractionPercent); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid position_sizing %q: must be fixed, percentage, risk_based, fixed_fractional, kelly or volatility_target", mode)
+	}
+
+	return &sizing, nil
+}
+
+// resolveSlippageModel applies the defaults of a requested slippage model and validates it.
+// Without one, fills are charged the slippage rate as a fixed model.
+func resolveSlippageModel(requested *model.SlippageModel, slippageRate float64) (*model.SlippageModel, error) {
+	if requested == nil {
+		return &model.SlippageModel{Type: model.SlippageModelFixed, BasisPoints: slippageRate * 100}, nil
 	}
 
-	// Verify data availability for all symbols
+	slippage := *requested
+	slippage.Type = strings.ToLower(slippage.Type)
+
+	bounded := func(name string, value float64) error {
+		if value < 0 || value > maxSlippageBasisPoints {
+			return fmt.Errorf("slippage_model %s must be between 0 and %g", name, maxSlippageBasisPoints)
+		}
+		return nil
+	}
+
+	switch slippage.Type {
+	case model.SlippageModelFixed:
+		if err := bounded("basis_points", slippage.BasisPoints); err != nil {
+			return nil, err
+		}
+		slippage.ImpactBasisPoints, slippage.MaxBasisPoints, slippage.FallbackBasisPoints = 0, 0, 0
+
+	case model.SlippageModelVolumeParticipation:
+		if slippage.ImpactBasisPoints <= 0 {
+			return nil, errors.New("slippage_model impact_basis_points is required by the volume_participation model")
+		}
+		if slippage.MaxBasisPoints == 0 {
+			slippage.MaxBasisPoints = defaultMaxSlippageBasisPoints
+		}
+		if err := bounded("impact_basis_points", slippage.ImpactBasisPoints); err != nil {
+			return nil, err
+		}
+		if err := bounded("max_basis_points", slippage.MaxBasisPoints); err != nil {
+			return nil, err
+		}
+		slippage.BasisPoints, slippage.FallbackBasisPoints = 0, 0
+
+	case model.SlippageModelSpread:
+		if slippage.MaxBasisPoints == 0 {
+			slippage.MaxBasisPoints = defaultMaxSlippageBasisPoints
+		}
+		if slippage.FallbackBasisPoints == 0 {
+			slippage.FallbackBasisPoints = model.DefaultBacktestSettings().SlippageRate * 100
+		}
+		if err := bounded("max_basis_points", slippage.MaxBasisPoints); err != nil {
+			return nil, err
+		}
+		if err := bounded("fallback_basis_points", slippage.FallbackBasisPoints); err != nil {
+			return nil, err
+		}
+		slippage.BasisPoints, slippage.ImpactBasisPoints = 0, 0
+
+	default:
+		return nil, fmt.Errorf("invalid slippage_model type %q: must be fixed, volume_participation or spread", slippage.Type)
+	}
+
+	return &slippage, nil
+}
+
+// checkDataAvailability verifies every symbol of a backtest has candles of a timeframe
+// covering its range
+func (s *BacktestService) checkDataAvailability(ctx context.Context, request *model.BacktestRequest, timeframe string) error {
 	for _, symbolID := range request.SymbolIDs {
 		// Check if there's data available for the requested symbol and timeframe
-		hasData, err := s.marketDataRepo.HasData(ctx, symbolID, request.Timeframe)
+		hasData, err := s.marketDataRepo.HasData(ctx, symbolID, timeframe)
 		if err != nil {
-			return 0, err
+			return err
 		}
 
 		if !hasData {
-			return 0, fmt.Errorf("no market data available for symbol ID %d with timeframe %s",
-				symbolID, request.Timeframe)
+			return fmt.Errorf("no market data available for symbol ID %d with timeframe %s",
+				symbolID, timeframe)
 		}
 
 		// Check data range
-		startDate, endDate, err := s.marketDataRepo.GetDataRange(ctx, symbolID, request.Timeframe)
+		startDate, endDate, err := s.marketDataRepo.GetDataRange(ctx, symbolID, timeframe)
 		if err != nil {
-			return 0, err
+			return err
 		}
 
 		// Convert timestamps to date-only comparison by truncating time parts
@@ -101,7 +517,7 @@ func (s *BacktestService) CreateBacktest(
 
 		// Add a small buffer (1 day) to account for potential timezone differences
 		if requestStartDay.AddDate(0, 0, -1).After(availableStartDay) || requestEndDay.AddDate(0, 0, 1).Before(availableEndDay) {
-			return 0, fmt.Errorf("requested date range (%s to %s) is outside available data range for symbol ID %d (%s to %s)",
+			return fmt.Errorf("requested date range (%s to %s) is outside available data range for symbol ID %d (%s to %s)",
 				requestStartDay.Format("2006-01-02"),
 				requestEndDay.Format("2006-01-02"),
 				symbolID,
@@ -110,40 +526,46 @@ func (s *BacktestService) CreateBacktest(
 		}
 	}
 
-	// Use strategy version from request or default to latest version
-	strategyVersion := request.StrategyVersion
-	if strategyVersion == 0 {
-		strategyVersion = strategy.Version
+	return nil
+}
+
+// checkFundingRateCoverage verifies funding rates are stored for every symbol across the
+// backtest's range, give or take a day as for candles
+func (s *BacktestService) checkFundingRateCoverage(ctx context.Context, request *model.BacktestRequest) error {
+	coverage, err := s.derivatives.GetCoverage(ctx, request.SymbolIDs)
+	if err != nil {
+		return err
 	}
 
-	// Set default name if not provided
-	name := request.Name
-	if name == "" {
-		name = strategy.Name + " Backtest"
+	for _, symbolID := range request.SymbolIDs {
+		item := coverage[symbolID]
+		if item == nil || item.FundingRateCount == 0 ||
+			item.FundingRateEarliest.After(request.StartDate.AddDate(0, 0, 1)) ||
+			item.FundingRateLatest.Before(request.EndDate.AddDate(0, 0, -1)) {
+			return fmt.Errorf("no funding rate data available for symbol ID %d in the requested range; download it with include_funding_rates",
+				symbolID)
+		}
 	}
 
-	// Create backtest using repository function
-	backtestID, err := s.backtestRepo.CreateBacktest(
-		ctx,
-		userID,
-		request.StrategyID,
-		strategyVersion,
-		name,
-		request.Description,
-		request.Timeframe,
-		request.StartDate,
-		request.EndDate,
-		request.InitialCapital,
-		request.SymbolIDs,
-	)
+	return nil
+}
+
+// checkOrderBookCoverage verifies order book snapshots were captured for every symbol in
+// the backtest's range, as the spread slippage model prices fills from them
+func (s *BacktestService) checkOrderBookCoverage(ctx context.Context, request *model.BacktestRequest) error {
+	counts, err := s.orderBooks.CountSnapshots(ctx, request.SymbolIDs, request.StartDate, request.EndDate)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	// Start backtest in the background
-	go s.runBacktest(backtestID, request, userID, token)
+	for _, symbolID := range request.SymbolIDs {
+		if counts[symbolID] == 0 {
+			return fmt.Errorf("no order book snapshots captured for symbol ID %d in the requested range; the spread slippage model needs order book capture",
+				symbolID)
+		}
+	}
 
-	return backtestID, nil
+	return nil
 }
 
 // GetBacktest retrieves a backtest by ID with access control
@@ -171,12 +593,33 @@ func (s *BacktestService) GetBacktest(
 	return backtest, nil
 }
 
-// ListBacktests lists backtests for a user with filtering, sorting, and pagination
+// GetBacktestForService retrieves a backtest with its owner for other services.
+// No access check is made; callers verify ownership themselves.
+func (s *BacktestService) GetBacktestForService(
+	ctx context.Context,
+	backtestID int,
+) (*model.BacktestDetails, error) {
+	backtest, err := s.backtestRepo.GetBacktest(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+
+	backtest.UserID, err = s.backtestRepo.GetBacktestUserID(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return backtest, nil
+}
+
+// ListBacktests lists backtests for a user with filtering, sorting, and pagination.
+// tagsFilter is a comma-separated list of tags the backtests must all have.
 func (s *BacktestService) ListBacktests(
 	ctx context.Context,
 	userID int,
 	searchTerm string,
 	status string,
+	tagsFilter string,
 	sortBy string,
 	sortDirection string,
 	page int,
@@ -189,26 +632,17 @@ func (s *BacktestService) ListBacktests(
 		limit = 10
 	}
 
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"name":        true,
-		"created_at":  true,
-		"status":      true,
-		"strategy_id": true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "created_at" // Default sort by creation date
-	}
-
-	// Validate sort direction
-	sortDirection = noramlizeSortDirection(sortDirection)
+	// Validate sort parameters
+	sortBy = backtestSortFields.Normalize(sortBy, "created_at")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
 
 	// Calculate offset
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
+
+	tags := parseTagsFilter(tagsFilter)
 
 	// Get total count
-	total, err := s.backtestRepo.CountBacktests(ctx, userID, searchTerm, status)
+	total, err := s.backtestRepo.CountBacktests(ctx, userID, searchTerm, status, tags)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -219,6 +653,7 @@ func (s *BacktestService) ListBacktests(
 		userID,
 		searchTerm,
 		status,
+		tags,
 		sortBy,
 		sortDirection,
 		limit,
@@ -240,141 +675,504 @@ func (s *BacktestService) DeleteBacktest(
 	// Use SQL function to delete the backtest
 	success, err := s.backtestRepo.DeleteBacktest(ctx, userID, backtestID)
 	if err != nil {
-		return err
+		return err
+	}
+
+	if !success {
+		return errors.New("backtest not found or not owned by user")
+	}
+
+	return nil
+}
+
+// UpdateBacktestRunStatus updates the status of a backtest run
+func (s *BacktestService) UpdateBacktestRunStatus(
+	ctx context.Context,
+	runID int,
+	status string,
+) (bool, error) {
+	return s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, status)
+}
+
+// SaveBacktestResults saves results for a backtest run
+func (s *BacktestService) SaveBacktestResults(
+	ctx context.Context,
+	runID int,
+	results *model.BacktestResults,
+) (int, error) {
+	return s.backtestRepo.SaveBacktestResults(ctx, runID, results)
+}
+
+// AddBacktestTrade adds a trade to a backtest run
+func (s *BacktestService) AddBacktestTrade(
+	ctx context.Context,
+	trade *model.BacktestTrade,
+) (int, error) {
+	return s.backtestRepo.AddBacktestTrade(ctx, trade)
+}
+
+// MaxTradeBatchSize is the largest number of trades accepted in one batch
+const MaxTradeBatchSize = 10000
+
+// AddBacktestTrades validates a batch of trades and stores the valid ones in one insert.
+// Invalid trades are reported by their index in the batch.
+func (s *BacktestService) AddBacktestTrades(
+	ctx context.Context,
+	runID int,
+	trades []model.BacktestTrade,
+) (*model.BacktestTradeBatchReport, error) {
+	if len(trades) > MaxTradeBatchSize {
+		return nil, errcode.ErrTradeBatchTooLarge.WithMessage(fmt.Sprintf("batch too large: at most %d trades can be added at once", MaxTradeBatchSize))
+	}
+
+	exists, err := s.backtestRepo.BacktestRunExists(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errcode.ErrBacktestRunNotFound
+	}
+
+	symbolIDs := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, trade := range trades {
+		if trade.SymbolID > 0 && !seen[trade.SymbolID] {
+			seen[trade.SymbolID] = true
+			symbolIDs = append(symbolIDs, trade.SymbolID)
+		}
+	}
+
+	existingSymbols, err := s.backtestRepo.GetExistingSymbolIDs(ctx, symbolIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.BacktestTradeBatchReport{
+		Received: len(trades),
+		Errors:   []model.BacktestTradeBatchError{},
+	}
+
+	valid := make([]model.BacktestTrade, 0, len(trades))
+	for i, trade := range trades {
+		if err := validateBacktestTrade(&trade, existingSymbols); err != nil {
+			report.Errors = append(report.Errors, model.BacktestTradeBatchError{Index: i, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, trade)
+	}
+
+	inserted, err := s.backtestRepo.AddBacktestTrades(ctx, runID, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Inserted = inserted
+	report.Rejected = len(report.Errors)
+	return report, nil
+}
+
+// validateBacktestTrade checks a trade against the constraints of the backtest_trades table,
+// so that a single bad trade can't fail a whole batch
+func validateBacktestTrade(trade *model.BacktestTrade, existingSymbols map[int]bool) error {
+	// Limits of the NUMERIC(20,8) and NUMERIC(10,4) columns
+	const maxPrice = 1e12
+	const maxPercent = 1e6
+
+	switch {
+	case trade.SymbolID <= 0:
+		return errors.New("symbol_id is required")
+	case !existingSymbols[trade.SymbolID]:
+		return fmt.Errorf("symbol %d not found", trade.SymbolID)
+	case trade.EntryTime.IsZero():
+		return errors.New("entry_time is required")
+	case trade.ExitTime != nil && trade.ExitTime.Before(trade.EntryTime):
+		return errors.New("exit_time must not be before entry_time")
+	case trade.PositionType != "long" && trade.PositionType != "short":
+		return errors.New("position_type must be long or short")
+	case trade.EntryPrice <= 0 || trade.EntryPrice >= maxPrice:
+		return errors.New("entry_price must be positive")
+	case trade.ExitPrice != nil && (*trade.ExitPrice <= 0 || *trade.ExitPrice >= maxPrice):
+		return errors.New("exit_price must be positive")
+	case trade.Quantity <= 0 || trade.Quantity >= maxPrice:
+		return errors.New("quantity must be positive")
+	case trade.ProfitLoss != nil && math.Abs(*trade.ProfitLoss) >= maxPrice:
+		return errors.New("profit_loss is out of range")
+	case trade.ProfitLossPercent != nil && math.Abs(*trade.ProfitLossPercent) >= maxPercent:
+		return errors.New("profit_loss_percent is out of range")
+	case trade.ExitReason != nil && len(*trade.ExitReason) > 50:
+		return errors.New("exit_reason must be at most 50 characters")
+	}
+
+	return nil
+}
+
+// GetBacktestTrades retrieves trades for a backtest run with sorting and pagination, along
+// with their annotations
+func (s *BacktestService) GetBacktestTrades(
+	ctx context.Context,
+	runID int,
+	sortBy string,
+	sortDirection string,
+	limit int,
+	offset int,
+) ([]model.BacktestTrade, int, error) {
+	// Validate sort parameters
+	sortBy = tradeSortFields.Normalize(sortBy, "entry_time")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
+
+	// Get total count
+	total, err := s.backtestRepo.CountBacktestTrades(ctx, runID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get trades
+	trades, err := s.backtestRepo.GetBacktestTrades(ctx, runID, sortBy, sortDirection, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.attachTradeAnnotations(ctx, trades); err != nil {
+		return nil, 0, err
+	}
+
+	return trades, total, nil
+}
+
+// GetBacktestTradesAfter retrieves the page of trades that follows a cursor, for keyset
+// pagination through large runs. The cursor must have been issued for the same sort order.
+func (s *BacktestService) GetBacktestTradesAfter(
+	ctx context.Context,
+	runID int,
+	sortBy string,
+	sortDirection string,
+	after *model.BacktestTradeCursor,
+	limit int,
+) ([]model.BacktestTrade, *model.BacktestTradeCursor, error) {
+	// Validate sort parameters
+	sortBy = tradeSortFields.Normalize(sortBy, "entry_time")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
+
+	if after != nil && (after.SortBy != sortBy || after.SortDirection != sortDirection) {
+		return nil, nil, errcode.ErrInvalidCursor.WithMessage("cursor does not match the requested sort order")
+	}
+
+	trades, next, err := s.backtestRepo.GetBacktestTradesAfter(ctx, runID, sortBy, sortDirection, after, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.attachTradeAnnotations(ctx, trades); err != nil {
+		return nil, nil, err
+	}
+
+	return trades, next, nil
+}
+
+// queuedBacktestBatch bounds how many queued backtests one instance claims at a time
+const queuedBacktestBatch = 10
+
+// ResumeQueuedBacktests resumes backtests queued by an instance that shut down, on
+// startup and then every interval, until ctx is cancelled
+func (s *BacktestService) ResumeQueuedBacktests(ctx context.Context, interval time.Duration) {
+	s.logger.Info("Starting queued backtest resumption", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if count, err := s.ProcessQueuedBacktests(ctx, queuedBacktestBatch); err == nil && count > 0 {
+			s.logger.Info("Resumed queued backtests", zap.Int("count", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping queued backtest resumption")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessQueuedBacktests claims queued backtests and runs their pending runs. Runs that
+// completed before the backtest was queued keep their results.
+func (s *BacktestService) ProcessQueuedBacktests(
+	ctx context.Context,
+	limit int,
+) (int, error) {
+	backtestIDs, err := s.backtestRepo.ClaimQueuedBacktests(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	processedCount := 0
+
+	for _, backtestID := range backtestIDs {
+		details, err := s.backtestRepo.GetBacktestDetails(ctx, backtestID)
+		if err != nil || details == nil {
+			s.logger.Error("Failed to get details of queued backtest",
+				zap.Error(err),
+				zap.Int("backtestID", backtestID))
+			s.requeueBacktest(backtestID)
+			continue
+		}
+
+		// Only the runs that didn't finish before the backtest was queued
+		symbolIDs, err := s.backtestRepo.GetPendingBacktestSymbolIDs(ctx, backtestID)
+		if err != nil {
+			s.requeueBacktest(backtestID)
+			continue
+		}
+
+		request := &model.BacktestRequest{
+			StrategyID:      details.StrategyID,
+			StrategyVersion: details.StrategyVersion,
+			Timeframe:       details.Timeframe,
+			SymbolIDs:       symbolIDs,
+			StartDate:       details.StartDate,
+			EndDate:         details.EndDate,
+			InitialCapital:  details.InitialCapital,
+		}
+
+		// Backtests created before settings were stored run with the defaults
+		settings := model.DefaultBacktestSettings()
+		if details.Settings != nil {
+			settings = *details.Settings
+		}
+
+		// A pinned backtest doesn't run on data other than it was created on
+		if err := s.checkPinnedData(ctx, backtestID); err != nil {
+			s.logger.Warn("Not running queued backtest",
+				zap.Error(err),
+				zap.Int("backtestID", backtestID))
+			s.failBacktest(ctx, backtestID, err.Error())
+			continue
+		}
+
+		// Run backtest in background
+		s.startBacktest(backtestID, request, settings, details.UserID, "")
+
+		processedCount++
+	}
+
+	return processedCount, nil
+}
+
+// RetryFailedRuns queues the failed runs of a finished backtest again, keeping the results
+// of its completed runs. Runs that were retried as often as they may be stay failed.
+func (s *BacktestService) RetryFailedRuns(
+	ctx context.Context,
+	backtestID int,
+	userID int,
+	quotaTier string,
+	token string,
+) (*model.BacktestRetry, error) {
+	backtest, err := s.GetBacktest(ctx, backtestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if backtest.Status != "failed" && backtest.Status != "partial" {
+		return nil, errcode.ErrBacktestNotRetryable
+	}
+
+	failed, err := s.backtestRepo.GetFailedBacktestRuns(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(failed) == 0 {
+		return nil, errcode.ErrBacktestNotRetryable
+	}
+
+	exhausted := []model.BacktestRunRetry{}
+	for _, run := range failed {
+		if run.RetryCount >= run.MaxRetries {
+			exhausted = append(exhausted, run)
+		}
+	}
+	if len(exhausted) == len(failed) {
+		return nil, errcode.ErrRetryLimitReached
+	}
+
+	// Results of a pinned backtest are only comparable on the data it was created on
+	if err := s.checkPinnedData(ctx, backtestID); err != nil {
+		return nil, err
+	}
+
+	// A retry runs like a new backtest, so it counts against the same limits
+	if err := s.quotaService.CheckBacktest(ctx, userID, quotaTier); err != nil {
+		return nil, err
+	}
+
+	details, err := s.backtestRepo.GetBacktestDetails(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, errcode.ErrBacktestNotFound
 	}
 
-	if !success {
-		return errors.New("backtest not found or not owned by user")
+	retried, err := s.backtestRepo.RetryFailedBacktestRuns(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(retried) == 0 {
+		// Another retry queued the runs first
+		return nil, errcode.ErrBacktestNotRetryable
 	}
 
-	return nil
-}
+	symbolIDs := make([]int, len(retried))
+	for i, run := range retried {
+		symbolIDs[i] = run.SymbolID
+	}
 
-// UpdateBacktestRunStatus updates the status of a backtest run
-func (s *BacktestService) UpdateBacktestRunStatus(
-	ctx context.Context,
-	runID int,
-	status string,
-) (bool, error) {
-	return s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, status)
-}
+	request := &model.BacktestRequest{
+		StrategyID:      details.StrategyID,
+		StrategyVersion: details.StrategyVersion,
+		Name:            backtest.Name,
+		Timeframe:       details.Timeframe,
+		SymbolIDs:       symbolIDs,
+		StartDate:       details.StartDate,
+		EndDate:         details.EndDate,
+		InitialCapital:  details.InitialCapital,
+	}
 
-// SaveBacktestResults saves results for a backtest run
-func (s *BacktestService) SaveBacktestResults(
-	ctx context.Context,
-	runID int,
-	results *model.BacktestResults,
-) (int, error) {
-	return s.backtestRepo.SaveBacktestResults(ctx, runID, results)
-}
+	settings := model.DefaultBacktestSettings()
+	if details.Settings != nil {
+		settings = *details.Settings
+	}
 
-// AddBacktestTrade adds a trade to a backtest run
-func (s *BacktestService) AddBacktestTrade(
-	ctx context.Context,
-	trade *model.BacktestTrade,
-) (int, error) {
-	return s.backtestRepo.AddBacktestTrade(ctx, trade)
+	s.logger.Info("Retrying failed backtest runs",
+		zap.Int("backtestID", backtestID),
+		zap.Int("retried", len(retried)),
+		zap.Int("exhausted", len(exhausted)))
+
+	s.startBacktest(backtestID, request, settings, userID, token)
+
+	return &model.BacktestRetry{
+		BacktestID: backtestID,
+		Retried:    retried,
+		Exhausted:  exhausted,
+	}, nil
 }
 
-// GetBacktestTrades retrieves trades for a backtest run with sorting and pagination
-func (s *BacktestService) GetBacktestTrades(
+// GetBacktestDataManifest retrieves the candles a backtest was created on and whether they
+// changed since, with access control
+func (s *BacktestService) GetBacktestDataManifest(
 	ctx context.Context,
-	runID int,
-	sortBy string,
-	sortDirection string,
-	limit int,
-	offset int,
-) ([]model.BacktestTrade, int, error) {
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"entry_time":          true,
-		"exit_time":           true,
-		"position_type":       true,
-		"profit_loss":         true,
-		"profit_loss_percent": true,
+	backtestID int,
+	userID int,
+) (*model.BacktestDataManifest, error) {
+	backtest, err := s.GetBacktest(ctx, backtestID, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if !validSortFields[sortBy] {
-		sortBy = "entry_time" // Default sort by entry time
+	symbols, err := s.backtestRepo.GetBacktestDataManifest(ctx, backtestID)
+	if err != nil {
+		return nil, err
+	}
+	// Backtests created before manifests were recorded have none
+	if len(symbols) == 0 {
+		return nil, errcode.ErrDataManifestNotFound
 	}
 
-	// Validate sort direction
-	sortDirection = noramlizeSortDirection(sortDirection)
-
-	// Get total count
-	total, err := s.backtestRepo.CountBacktestTrades(ctx, runID)
+	pinned, err := s.backtestRepo.IsBacktestDataPinned(ctx, backtestID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// Get trades
-	trades, err := s.backtestRepo.GetBacktestTrades(ctx, runID, sortBy, sortDirection, limit, offset)
-	if err != nil {
-		return nil, 0, err
+	manifest := &model.BacktestDataManifest{
+		BacktestID: backtestID,
+		Pinned:     pinned,
+		Timeframe:  backtest.Timeframe,
+		StartDate:  backtest.StartDate,
+		EndDate:    backtest.EndDate,
+		Symbols:    symbols,
 	}
+	markChangedData(manifest)
 
-	return trades, total, nil
+	return manifest, nil
 }
 
-// ProcessQueuedBacktests processes queued backtests
-func (s *BacktestService) ProcessQueuedBacktests(
-	ctx context.Context,
-	limit int,
-) (int, error) {
-	// Get queued backtests
-	backtests, err := s.backtestRepo.GetQueuedBacktests(ctx, limit)
+// checkPinnedData returns ErrBacktestDataChanged when a backtest is pinned to its data
+// manifest and the data of any of its symbols changed since
+func (s *BacktestService) checkPinnedData(ctx context.Context, backtestID int) error {
+	pinned, err := s.backtestRepo.IsBacktestDataPinned(ctx, backtestID)
+	if err != nil || !pinned {
+		return err
+	}
+
+	symbols, err := s.backtestRepo.GetBacktestDataManifest(ctx, backtestID)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	processedCount := 0
+	manifest := &model.BacktestDataManifest{BacktestID: backtestID, Symbols: symbols}
+	markChangedData(manifest)
+	if !manifest.Changed {
+		return nil
+	}
 
-	// Process each backtest
-	for _, backtest := range backtests {
-		// Extract the necessary information to create a backtest request
-		// We need to query for additional details since the summary doesn't have everything
-		details, err := s.backtestRepo.GetBacktestDetails(ctx, backtest.BacktestID)
-		if err != nil {
-			s.logger.Error("Failed to get backtest details",
-				zap.Error(err),
-				zap.Int("backtestID", backtest.BacktestID))
-			continue
+	changed := []string{}
+	for _, symbol := range manifest.Symbols {
+		if symbol.Changed {
+			changed = append(changed, symbol.Symbol)
 		}
+	}
+	return errcode.ErrBacktestDataChanged.WithDetails(map[string]interface{}{"symbols": changed})
+}
 
-		if details == nil {
-			s.logger.Error("Backtest not found",
-				zap.Int("backtestID", backtest.BacktestID))
-			continue
+// markChangedData flags the symbols whose candles differ from the manifest
+func markChangedData(manifest *model.BacktestDataManifest) {
+	for i := range manifest.Symbols {
+		symbol := &manifest.Symbols[i]
+		symbol.Changed = symbol.CurrentCandleCount != symbol.CandleCount ||
+			symbol.CurrentChecksum != symbol.Checksum ||
+			!sameTime(symbol.CurrentLastCandleTime, symbol.LastCandleTime)
+		if symbol.Changed {
+			manifest.Changed = true
 		}
+	}
+}
 
-		// Get the symbol IDs for this backtest
-		symbolIDs, err := s.backtestRepo.GetBacktestSymbolIDs(ctx, backtest.BacktestID)
-		if err != nil {
-			s.logger.Error("Failed to get symbol IDs",
-				zap.Error(err),
-				zap.Int("backtestID", backtest.BacktestID))
-			continue
-		}
+// sameTime reports whether two optional times are both unset or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
 
-		// Create a backtest request
-		request := &model.BacktestRequest{
-			StrategyID:      details.StrategyID,
-			StrategyVersion: details.StrategyVersion,
-			Name:            backtest.Name,
-			Timeframe:       details.Timeframe,
-			SymbolIDs:       symbolIDs,
-			StartDate:       details.StartDate,
-			EndDate:         details.EndDate,
-			InitialCapital:  details.InitialCapital,
-		}
+// GetStrategyBacktestHistory retrieves a page of a user's backtests of the given versions
+// of a strategy, optionally only those of one version, and a per-version summary of all of them
+func (s *BacktestService) GetStrategyBacktestHistory(
+	ctx context.Context,
+	userID int,
+	strategyIDs []int,
+	version *int,
+	page int,
+	limit int,
+) ([]model.StrategyBacktest, []model.StrategyVersionSummary, int, error) {
+	if len(strategyIDs) == 0 {
+		return nil, nil, 0, errors.New("strategy_ids is required")
+	}
 
-		// Run backtest in background
-		go s.runBacktest(backtest.BacktestID, request, details.UserID, "")
+	total, err := s.backtestRepo.CountStrategyBacktestHistory(ctx, userID, strategyIDs, version)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
-		processedCount++
+	backtests, err := s.backtestRepo.GetStrategyBacktestHistory(ctx, userID, strategyIDs, version, limit, pagination.Offset(page, limit))
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	return processedCount, nil
+	versions, err := s.backtestRepo.GetStrategyVersionSummaries(ctx, userID, strategyIDs)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return backtests, versions, total, nil
 }
 
 // GetBacktestRuns retrieves all runs for a backtest with sorting and pagination
@@ -394,23 +1192,12 @@ func (s *BacktestService) GetBacktestRuns(
 	CreatedAt   time.Time  `json:"created_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }, int, error) {
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"id":           true,
-		"status":       true,
-		"created_at":   true,
-		"completed_at": true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "created_at" // Default sort by creation date
-	}
-
-	// Validate sort direction
-	sortDirection = noramlizeSortDirection(sortDirection)
+	// Validate sort parameters
+	sortBy = runSortFields.Normalize(sortBy, "created_at")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
 
 	// Calculate offset
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
 
 	// Get total count
 	total, err := s.backtestRepo.CountBacktestRuns(ctx, backtestID)
@@ -463,22 +1250,67 @@ func (s *BacktestService) CheckBacktestServiceHealth(ctx context.Context) (bool,
 	return s.backtestClient.CheckHealth(ctx)
 }
 
-// runBacktest executes a backtest in the background
+// startBacktest runs a backtest in the background. While the service shuts down the
+// backtest is queued instead, for the next instance to resume.
+func (s *BacktestService) startBacktest(
+	backtestID int,
+	request *model.BacktestRequest,
+	settings model.BacktestSettings,
+	userID int,
+	token string,
+) {
+	started := s.jobs.Go("backtest", backtestID, func(ctx context.Context) {
+		s.runBacktest(ctx, backtestID, request, settings, userID, token)
+	})
+	if !started {
+		s.requeueBacktest(backtestID)
+	}
+}
+
+// requeueBacktest queues an interrupted backtest to be resumed from its pending runs
+func (s *BacktestService) requeueBacktest(backtestID int) {
+	// Runs while the service shuts down, when the job's context is already cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	remaining, err := s.backtestRepo.RequeueBacktest(ctx, backtestID)
+	if err != nil || remaining < 0 {
+		// A cancelled backtest stays cancelled
+		return
+	}
+	s.logger.Info("Backtest queued to resume",
+		zap.Int("backtestID", backtestID),
+		zap.Int("pendingRuns", remaining))
+}
+
+// errBacktestInterrupted is returned when a run stops because the service shuts down
+var errBacktestInterrupted = errors.New("backtest interrupted")
+
+// runBacktest executes a backtest in the background. When ctx is cancelled the backtest
+// is queued to resume; its completed runs are kept.
 func (s *BacktestService) runBacktest(
+	ctx context.Context,
 	backtestID int,
 	request *model.BacktestRequest,
+	settings model.BacktestSettings,
 	userID int,
 	token string,
 ) {
-	// Create a new context for background processing
-	ctx := context.Background()
+	// Errors caused by the shutdown don't fail the backtest
+	fail := func(errorMessage string) {
+		if ctx.Err() != nil {
+			s.requeueBacktest(backtestID)
+			return
+		}
+		s.failBacktest(ctx, backtestID, errorMessage)
+	}
 
 	// Added safety check for nil services
 	if s.strategyClient == nil {
 		s.logger.Error("Strategy client is nil",
 			zap.Int("backtestID", backtestID),
 			zap.Int("strategyID", request.StrategyID))
-		s.failBacktest(ctx, backtestID, "Internal service error: strategy client unavailable")
+		fail("Internal service error: strategy client unavailable")
 		return
 	}
 
@@ -486,7 +1318,7 @@ func (s *BacktestService) runBacktest(
 		s.logger.Error("Backtest client is nil",
 			zap.Int("backtestID", backtestID),
 			zap.Int("strategyID", request.StrategyID))
-		s.failBacktest(ctx, backtestID, "Internal service error: backtest client unavailable")
+		fail("Internal service error: backtest client unavailable")
 		return
 	}
 
@@ -509,11 +1341,11 @@ func (s *BacktestService) runBacktest(
 			token,
 		)
 		if err != nil {
-			s.failBacktest(ctx, backtestID, fmt.Sprintf("Failed to get strategy version: %v", err))
+			fail(fmt.Sprintf("Failed to get strategy version: %v", err))
 			return
 		}
 		if version == nil {
-			s.failBacktest(ctx, backtestID, "Strategy version not found")
+			fail("Strategy version not found")
 			return
 		}
 		strategyStructure = version.Structure
@@ -528,11 +1360,11 @@ func (s *BacktestService) runBacktest(
 		}
 		strategy, err = s.strategyClient.GetStrategy(ctx, request.StrategyID, token)
 		if err != nil {
-			s.failBacktest(ctx, backtestID, fmt.Sprintf("Failed to get strategy: %v", err))
+			fail(fmt.Sprintf("Failed to get strategy: %v", err))
 			return
 		}
 		if strategy == nil {
-			s.failBacktest(ctx, backtestID, "Strategy not found")
+			fail("Strategy not found")
 			return
 		}
 		strategyStructure = strategy.Structure
@@ -541,7 +1373,7 @@ func (s *BacktestService) runBacktest(
 
 	// Make sure the strategy structure is not nil
 	if strategyStructure == nil {
-		s.failBacktest(ctx, backtestID, "Strategy structure is empty")
+		fail("Strategy structure is empty")
 		return
 	}
 
@@ -550,12 +1382,12 @@ func (s *BacktestService) runBacktest(
 	var message string
 	valid, message, err = s.backtestClient.ValidateStrategy(ctx, strategyStructure)
 	if err != nil {
-		s.failBacktest(ctx, backtestID, fmt.Sprintf("Failed to validate strategy: %v", err))
+		fail(fmt.Sprintf("Failed to validate strategy: %v", err))
 		return
 	}
 
 	if !valid {
-		s.failBacktest(ctx, backtestID, fmt.Sprintf("Strategy validation failed: %s", message))
+		fail(fmt.Sprintf("Strategy validation failed: %s", message))
 		return
 	}
 
@@ -565,149 +1397,272 @@ func (s *BacktestService) runBacktest(
 		zap.Int("strategyID", request.StrategyID),
 		zap.Int("strategyVersion", strategyVersion))
 
-	// Process each symbol in the backtest
+	// With the async protocol the runs are submitted and the backtest finishes when the
+	// engine reported the outcome of its last run
+	if s.engine.Protocol == EngineProtocolV2 {
+		s.submitBacktest(ctx, backtestID, request, settings, strategyStructure)
+		return
+	}
+
+	// Run the symbols concurrently, at most symbolWorkers at a time. A failed symbol
+	// doesn't stop the others.
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures []string
+	)
+	workers := make(chan struct{}, s.symbolWorkers)
 	for _, symbolID := range request.SymbolIDs {
-		// Find the run ID for this symbol
-		var runID int
-		runID, err = s.backtestRepo.GetBacktestRunIDBySymbol(ctx, backtestID, symbolID)
-		if err != nil {
-			s.logger.Error("Failed to find backtest run ID",
-				zap.Error(err),
-				zap.Int("backtestID", backtestID),
-				zap.Int("symbolID", symbolID))
-			continue
+		workers <- struct{}{}
+		if ctx.Err() != nil {
+			// Symbols not started yet stay pending
+			<-workers
+			break
 		}
+		wg.Add(1)
+		go func(symbolID int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			if err := s.runSymbol(ctx, backtestID, symbolID, request, settings, strategyStructure); err != nil {
+				mu.Lock()
+				failures = append(failures, fmt.Sprintf("symbol %d: %v", symbolID, err))
+				mu.Unlock()
+			}
+		}(symbolID)
+	}
+	wg.Wait()
 
-		// Update run status to 'running'
-		var success bool
-		success, err = s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "running")
-		if err != nil || !success {
-			s.logger.Error("Failed to update backtest run status",
-				zap.Error(err),
-				zap.Int("runID", runID))
-			continue
-		}
+	if ctx.Err() != nil {
+		s.requeueBacktest(backtestID)
+		return
+	}
 
-		// Use the /backtest/db endpoint which will fetch data directly from the database
-		backtestRequest := map[string]interface{}{
-			"symbol_id":       symbolID,
-			"timeframe":       request.Timeframe,
-			"start_date":      request.StartDate.Format(time.RFC3339),
-			"end_date":        request.EndDate.Format(time.RFC3339),
-			"strategy":        strategyStructure,
-			"backtest_run_id": runID,
-			"params": map[string]interface{}{
-				"symbol_id":       symbolID,
-				"initial_capital": request.InitialCapital,
-				"market_type":     "spot",  // Default to spot trading
-				"leverage":        1.0,     // Default leverage (1.0 means no leverage)
-				"commission_rate": 0.1,     // Default commission rate (0.1%)
-				"slippage_rate":   0.05,    // Default slippage rate (0.05%)
-				"position_sizing": "fixed", // Default position sizing strategy
-				"allow_short":     false,   // Default to long-only for spot trading
-			},
-		}
-
-		// Create the request body
-		var jsonData []byte
-		jsonData, err = json.Marshal(backtestRequest)
-		if err != nil {
-			s.failBacktest(ctx, backtestID, fmt.Sprintf("Failed to marshal backtest request: %v", err))
-			continue
-		}
+	s.finishBacktest(ctx, backtestID, request.StrategyID, userID, len(request.SymbolIDs), failures)
+}
 
-		// Create the request
-		url := fmt.Sprintf("%s/backtest/db", s.backtestClient.BaseURL())
-		var req *http.Request
-		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			s.failBacktest(ctx, backtestID, fmt.Sprintf("Failed to create request: %v", err))
-			continue
-		}
+// finishBacktest records the outcome of a backtest whose runs all finished, and notifies
+// the strategy service and event consumers. symbols is the number of symbols run in the
+// last attempt and failures describes those that failed.
+func (s *BacktestService) finishBacktest(
+	ctx context.Context,
+	backtestID int,
+	strategyID int,
+	userID int,
+	symbols int,
+	failures []string,
+) {
+	// Runs of earlier attempts count too when only the failed runs were retried
+	total, failed := symbols, len(failures)
+	if count, err := s.backtestRepo.CountBacktestRuns(ctx, backtestID); err == nil {
+		total = count
+	}
+	if count, err := s.backtestRepo.CountFailedBacktestRuns(ctx, backtestID); err == nil && count > failed {
+		failed = count
+	}
+
+	status, errorMessage := backtestOutcome(total, failed, failures)
+	if err := s.backtestRepo.UpdateBacktestStatus(ctx, backtestID, status, errorMessage); err != nil {
+		s.logger.Error("Failed to update backtest status",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID),
+			zap.String("status", status))
+	}
 
-		req.Header.Set("Content-Type", "application/json")
+	s.logger.Info("Backtest finished",
+		zap.Int("backtestID", backtestID),
+		zap.String("status", status),
+		zap.Int("symbols", symbols),
+		zap.Int("failedSymbols", len(failures)))
 
-		// Execute the request
-		s.logger.Info("Sending backtest request with direct DB access",
-			zap.String("url", url),
-			zap.Int("symbolID", symbolID),
+	// Notify the Strategy Service that the backtest is complete
+	err := s.strategyClient.NotifyBacktestComplete(
+		ctx,
+		backtestID,
+		strategyID,
+		userID,
+		status,
+	)
+	if err != nil {
+		s.logger.Warn("Failed to notify strategy service of backtest completion",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+	}
+
+	if status == "failed" {
+		s.publishBacktestEvent(ctx, backtestID, client.EventBacktestFailed)
+		return
+	}
+	s.publishBacktestEvent(ctx, backtestID, client.EventBacktestCompleted)
+}
+
+// backtestOutcome returns the status of a backtest with the given number of runs and
+// failed runs: completed when every run succeeded, failed when none did and partial
+// otherwise. The error message lists the failures of the last attempt.
+func backtestOutcome(runs, failed int, failures []string) (status, errorMessage string) {
+	switch {
+	case failed == 0:
+		return "completed", ""
+	case failed >= runs:
+		status = "failed"
+	default:
+		status = "partial"
+	}
+
+	errorMessage = fmt.Sprintf("%d of %d symbols failed", failed, runs)
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		errorMessage += ": " + strings.Join(failures, "; ")
+	}
+	return status, errorMessage
+}
+
+// runSymbol runs the backtest of one symbol. The backtesting service saves the results
+// and trades of the run itself; a run that fails is marked as failed here.
+func (s *BacktestService) runSymbol(
+	ctx context.Context,
+	backtestID int,
+	symbolID int,
+	request *model.BacktestRequest,
+	settings model.BacktestSettings,
+	strategyStructure json.RawMessage,
+) error {
+	// Find the run ID for this symbol
+	runID, err := s.backtestRepo.GetBacktestRunIDBySymbol(ctx, backtestID, symbolID)
+	if err != nil {
+		s.logger.Error("Failed to find backtest run ID",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID),
+			zap.Int("symbolID", symbolID))
+		return fmt.Errorf("failed to find backtest run: %w", err)
+	}
+
+	// Update run status to 'running'
+	success, err := s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "running")
+	if err != nil || !success {
+		s.logger.Error("Failed to update backtest run status",
+			zap.Error(err),
 			zap.Int("runID", runID))
+		return errors.New("failed to start backtest run")
+	}
 
-		client := &http.Client{
-			Timeout: 5 * time.Minute, // Extended timeout for backtesting
-		}
-		var resp *http.Response
-		resp, err = client.Do(req)
-		if err != nil {
-			s.logger.Error("Failed to send request to backtesting service",
-				zap.Error(err),
-				zap.Int("symbolID", symbolID),
-				zap.Int("runID", runID))
+	// Use the /backtest/db endpoint which will fetch data directly from the database
+	backtestRequest := map[string]interface{}{
+		"symbol_id":       symbolID,
+		"timeframe":       request.Timeframe,
+		"start_date":      request.StartDate.Format(time.RFC3339),
+		"end_date":        request.EndDate.Format(time.RFC3339),
+		"strategy":        strategyStructure,
+		"backtest_run_id": runID,
+		"params":          runParams(symbolID, request, settings),
+	}
 
-			// Mark this run as failed
-			s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
-			continue
-		}
-		defer resp.Body.Close()
+	// Create the request body
+	jsonData, err := json.Marshal(backtestRequest)
+	if err != nil {
+		s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
+		return fmt.Errorf("failed to marshal backtest request: %w", err)
+	}
 
-		// Check for error status
-		if resp.StatusCode != http.StatusOK {
-			var errorResp struct {
-				Error string `json:"error"`
-			}
-			decodeErr := json.NewDecoder(resp.Body).Decode(&errorResp)
-			if decodeErr != nil {
-				s.logger.Error("Failed to decode error response",
-					zap.Error(decodeErr),
-					zap.Int("statusCode", resp.StatusCode))
-			}
+	// Create the request
+	url := fmt.Sprintf("%s/backtest/db", s.backtestClient.BaseURL())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
+		return fmt.Errorf("failed to create request: %w", err)
+	}
 
-			// Mark this run as failed
-			s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
-			s.logger.Error("Backtest service error",
-				zap.String("error", errorResp.Error),
-				zap.Int("symbolID", symbolID),
-				zap.Int("runID", runID))
-			continue
-		}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Execute the request
+	s.logger.Info("Sending backtest request with direct DB access",
+		zap.String("url", url),
+		zap.Int("symbolID", symbolID),
+		zap.Int("runID", runID))
+
+	client := &http.Client{
+		Timeout: 5 * time.Minute, // Extended timeout for backtesting
+	}
+	resp, err := client.Do(req)
+	if err != nil && ctx.Err() != nil {
+		// The run is returned to pending when the backtest is requeued
+		return errBacktestInterrupted
+	}
+	if err != nil {
+		s.logger.Error("Failed to send request to backtesting service",
+			zap.Error(err),
+			zap.Int("symbolID", symbolID),
+			zap.Int("runID", runID))
+
+		// Mark this run as failed
+		s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
+		return errors.New("backtesting service unavailable")
+	}
+	defer resp.Body.Close()
 
-		// Parse response
-		var result model.BacktestResult
-		decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+	// Check for error status
+	if resp.StatusCode != http.StatusOK {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		decodeErr := json.NewDecoder(resp.Body).Decode(&errorResp)
 		if decodeErr != nil {
-			s.logger.Error("Failed to decode backtest response",
+			s.logger.Error("Failed to decode error response",
 				zap.Error(decodeErr),
-				zap.Int("symbolID", symbolID),
-				zap.Int("runID", runID))
+				zap.Int("statusCode", resp.StatusCode))
+		}
 
-			// Mark this run as failed
-			s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
-			continue
+		// Mark this run as failed
+		s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
+		s.logger.Error("Backtest service error",
+			zap.String("error", errorResp.Error),
+			zap.Int("symbolID", symbolID),
+			zap.Int("runID", runID))
+		if errorResp.Error == "" {
+			return fmt.Errorf("backtesting service returned status %d", resp.StatusCode)
 		}
+		return errors.New(errorResp.Error)
+	}
 
-		s.logger.Info("Backtest completed successfully",
-			zap.Int("runID", runID),
+	// Parse response
+	var result model.BacktestResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		s.logger.Error("Failed to decode backtest response",
+			zap.Error(err),
 			zap.Int("symbolID", symbolID),
-			zap.Int("totalTrades", result.Metrics.TotalTrades),
-			zap.Float64("totalReturn", result.Metrics.TotalReturn))
+			zap.Int("runID", runID))
 
-		// No need to save trades or update status, as the backtest service has
-		// already saved everything directly to the database
+		// Mark this run as failed
+		s.backtestRepo.UpdateBacktestRunStatus(ctx, runID, "failed")
+		return errors.New("invalid response from backtesting service")
 	}
 
-	// Notify the Strategy Service that the backtest is complete
-	// Using = instead of := since err was already declared
-	err = s.strategyClient.NotifyBacktestComplete(
-		ctx,
-		backtestID,
-		request.StrategyID,
-		userID,
-		"completed",
-	)
-	if err != nil {
-		s.logger.Warn("Failed to notify strategy service of backtest completion",
-			zap.Error(err),
-			zap.Int("backtestID", backtestID))
+	s.logger.Info("Backtest completed successfully",
+		zap.Int("runID", runID),
+		zap.Int("symbolID", symbolID),
+		zap.Int("totalTrades", result.Metrics.TotalTrades),
+		zap.Float64("totalReturn", result.Metrics.TotalReturn))
+
+	// No need to save trades or update status, as the backtest service has
+	// already saved everything directly to the database
+	return nil
+}
+
+// runParams returns the trading parameters of a symbol's run sent to the engine
+func runParams(symbolID int, request *model.BacktestRequest, settings model.BacktestSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol_id":              symbolID,
+		"initial_capital":        request.InitialCapital,
+		"market_type":            settings.MarketType,
+		"leverage":               settings.Leverage,
+		"commission_rate":        settings.CommissionRate,
+		"slippage_rate":          settings.SlippageRate,
+		"slippage_model":         settings.SlippageModel,
+		"position_sizing":        settings.PositionSizing,
+		"position_sizing_params": settings.PositionSizingParams,
+		"allow_short":            settings.AllowShort,
+		"include_funding_rates":  settings.IncludeFundingRates,
+		"auxiliary_timeframes":   settings.AuxiliaryTimeframes,
 	}
 }
 
@@ -737,13 +1692,30 @@ func (s *BacktestService) failBacktest(ctx context.Context, backtestID int, erro
 			zap.Error(err),
 			zap.Int("backtestID", backtestID))
 	}
+
+	s.publishBacktestEvent(ctx, backtestID, client.EventBacktestFailed)
 }
 
-// Helper function to normalize sort direction
-func noramlizeSortDirection(direction string) string {
-	direction = strings.ToUpper(direction)
-	if direction != "ASC" && direction != "DESC" {
-		return "DESC" // Default to descending
+// publishBacktestEvent tells the backtest owner's activity feed that a backtest finished
+func (s *BacktestService) publishBacktestEvent(ctx context.Context, backtestID int, eventType string) {
+	if s.events == nil {
+		return
+	}
+
+	backtest, err := s.backtestRepo.GetBacktest(ctx, backtestID)
+	if err != nil {
+		return
 	}
-	return direction
+	userID, err := s.backtestRepo.GetBacktestUserID(ctx, backtestID)
+	if err != nil {
+		return
+	}
+
+	s.events.Publish(ctx, client.Event{
+		EventType:  eventType,
+		UserID:     userID,
+		EntityType: "backtest",
+		EntityID:   backtestID,
+		EntityName: backtest.Name,
+	})
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+)
+
+// AnnotateBacktestTrade adds a note with labels to a trade of a backtest run, for manual
+// review of the run. Only the owner of the backtest can annotate its trades. Labels are
+// trimmed, lowercased and deduplicated.
+func (s *BacktestService) AnnotateBacktestTrade(
+	ctx context.Context,
+	runID int,
+	tradeID int,
+	userID int,
+	request *model.BacktestTradeAnnotationCreate,
+) (*model.BacktestTradeAnnotation, error) {
+	note := strings.TrimSpace(request.Note)
+	labels := normalizeAnnotationLabels(request.Labels)
+	if note == "" && len(labels) == 0 {
+		return nil, errcode.ErrAnnotationEmpty
+	}
+
+	run, err := s.backtestRepo.GetBacktestRunInfo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errcode.ErrBacktestRunNotFound
+	}
+
+	ownerID, err := s.backtestRepo.GetBacktestUserID(ctx, run.BacktestID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != userID {
+		return nil, errors.New("access denied")
+	}
+
+	annotation, err := s.backtestRepo.AddTradeAnnotation(ctx, runID, tradeID, userID, note, labels)
+	if err != nil {
+		return nil, err
+	}
+	if annotation == nil {
+		return nil, errcode.ErrTradeNotFound
+	}
+
+	return annotation, nil
+}
+
+// attachTradeAnnotations fills in the annotations of a page of trades
+func (s *BacktestService) attachTradeAnnotations(ctx context.Context, trades []model.BacktestTrade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	tradeIDs := make([]int, len(trades))
+	for i, trade := range trades {
+		tradeIDs[i] = trade.ID
+	}
+
+	annotations, err := s.backtestRepo.GetTradeAnnotations(ctx, tradeIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range trades {
+		trades[i].Annotations = annotations[trades[i].ID]
+	}
+	return nil
+}
+
+// normalizeAnnotationLabels trims, lowercases and deduplicates labels, dropping empty ones
+func normalizeAnnotationLabels(labels []string) []string {
+	normalized := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	return normalized
+}
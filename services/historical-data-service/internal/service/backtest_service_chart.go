@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+)
+
+// GetBacktestRunEquityCurve returns the equity curve of a backtest run for charting. Runs whose
+// results were saved without an equity curve fall back to the initial capital plus the profit
+// and loss of each closed trade.
+func (s *BacktestService) GetBacktestRunEquityCurve(ctx context.Context, runID int) (*model.EquityCurve, error) {
+	run, err := s.backtestRepo.GetBacktestRunInfo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errcode.ErrBacktestRunNotFound
+	}
+
+	points, err := s.backtestRepo.GetBacktestRunEquity(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(points) == 0 {
+		trades, err := s.backtestRepo.GetClosedBacktestTrades(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if len(trades) == 0 {
+			return nil, errcode.ErrBacktestRunNotFound.WithMessage("Backtest run results not found")
+		}
+
+		equity := run.InitialCapital
+		points = []model.EquityPoint{{Time: run.StartDate, Equity: equity}}
+		for _, trade := range trades {
+			if trade.ProfitLoss == nil || trade.ExitTime == nil {
+				continue
+			}
+			equity += *trade.ProfitLoss
+			points = append(points, model.EquityPoint{Time: *trade.ExitTime, Equity: equity})
+		}
+	}
+
+	return &model.EquityCurve{
+		BacktestRunID:  run.BacktestRunID,
+		Status:         run.Status,
+		InitialCapital: run.InitialCapital,
+		Points:         points,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// Protocols of the backtesting engine
+const (
+	// EngineProtocolV1 holds a request open per run until the engine finished it
+	EngineProtocolV1 = "v1"
+	// EngineProtocolV2 submits runs, and the engine reports progress and outcome by callback
+	EngineProtocolV2 = "v2"
+)
+
+// BacktestEngineOptions configures how runs are handed to the backtesting engine
+type BacktestEngineOptions struct {
+	Protocol string
+	// CallbackURL is where the engine reports on runs submitted with the v2 protocol
+	CallbackURL string
+	// Lease is how long a v2 run may go without a report from the engine before it fails
+	Lease time.Duration
+}
+
+// submitBacktest submits the runs of a backtest to the engine with the v2 protocol. The
+// backtest finishes once the engine reported on its last run, or its lease expired.
+func (s *BacktestService) submitBacktest(
+	ctx context.Context,
+	backtestID int,
+	request *model.BacktestRequest,
+	settings model.BacktestSettings,
+	strategyStructure json.RawMessage,
+) {
+	for _, symbolID := range request.SymbolIDs {
+		if ctx.Err() != nil {
+			// Runs not submitted yet stay pending; submitted ones keep running on the engine
+			s.requeueBacktest(backtestID)
+			return
+		}
+
+		runID, err := s.backtestRepo.GetBacktestRunIDBySymbol(ctx, backtestID, symbolID)
+		leased := false
+		if err == nil {
+			leased, err = s.backtestRepo.LeaseEngineRun(ctx, runID, s.engine.Lease)
+		}
+		if err != nil || !leased {
+			s.logger.Error("Failed to start backtest run on the engine",
+				zap.Error(err),
+				zap.Int("backtestID", backtestID),
+				zap.Int("symbolID", symbolID))
+			s.failBacktest(ctx, backtestID, "Failed to start backtest run")
+			return
+		}
+
+		jobToken, err := s.backtestClient.SubmitBacktest(ctx, client.EngineSubmission{
+			BacktestRunID: runID,
+			SymbolID:      symbolID,
+			Timeframe:     request.Timeframe,
+			StartDate:     request.StartDate,
+			EndDate:       request.EndDate,
+			Strategy:      strategyStructure,
+			Params:        runParams(symbolID, request, settings),
+			CallbackURL:   s.engine.CallbackURL,
+			LeaseSeconds:  int(s.engine.Lease.Seconds()),
+		})
+		if err != nil {
+			// Finishes the backtest if this was its last run
+			s.HandleEngineCallback(ctx, &model.EngineCallback{
+				BacktestRunID: runID,
+				Status:        model.EngineRunFailed,
+				Error:         "backtesting service unavailable",
+			})
+			continue
+		}
+
+		s.backtestRepo.SetEngineJobToken(ctx, runID, jobToken)
+	}
+}
+
+// HandleEngineCallback applies a report of the engine about a run. Progress extends the
+// run's lease; the outcome of a backtest's last run finishes the backtest.
+func (s *BacktestService) HandleEngineCallback(ctx context.Context, callback *model.EngineCallback) error {
+	report, err := s.backtestRepo.ReportEngineRun(ctx, callback, s.engine.Lease)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return errcode.ErrEngineJobNotActive
+	}
+
+	if callback.Status != model.EngineRunProgress && report.RemainingRuns == 0 {
+		s.finishEngineBacktest(ctx, report.BacktestID)
+	}
+	return nil
+}
+
+// ExpireEngineLeases fails runs the engine stopped reporting on, every interval until ctx
+// is cancelled. Backtests whose last run expired finish.
+func (s *BacktestService) ExpireEngineLeases(ctx context.Context, interval time.Duration) {
+	s.logger.Info("Starting engine lease expiry", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping engine lease expiry")
+			return
+		case <-ticker.C:
+		}
+
+		reports, err := s.backtestRepo.ExpireEngineLeases(ctx)
+		if err != nil {
+			continue
+		}
+		if len(reports) > 0 {
+			s.logger.Warn("Failed backtest runs whose engine lease expired", zap.Int("runs", len(reports)))
+		}
+
+		for _, report := range reports {
+			if report.RemainingRuns == 0 {
+				s.finishEngineBacktest(ctx, report.BacktestID)
+			}
+		}
+	}
+}
+
+// finishEngineBacktest finishes a backtest whose runs the engine all reported on
+func (s *BacktestService) finishEngineBacktest(ctx context.Context, backtestID int) {
+	details, err := s.backtestRepo.GetBacktestDetails(ctx, backtestID)
+	if err != nil || details == nil {
+		s.logger.Error("Failed to get details of finished backtest",
+			zap.Error(err),
+			zap.Int("backtestID", backtestID))
+		return
+	}
+
+	runs, err := s.backtestRepo.CountBacktestRuns(ctx, backtestID)
+	if err != nil {
+		return
+	}
+
+	runErrors, err := s.backtestRepo.GetEngineRunErrors(ctx, backtestID)
+	if err != nil {
+		return
+	}
+	failures := make([]string, 0, len(runErrors))
+	for _, runError := range runErrors {
+		failures = append(failures, fmt.Sprintf("symbol %d: %s", runError.SymbolID, runError.ErrorMessage))
+	}
+
+	s.finishBacktest(ctx, backtestID, details.StrategyID, details.UserID, runs, failures)
+}
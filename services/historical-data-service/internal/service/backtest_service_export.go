@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+)
+
+// exportTradeBatchSize is how many trades are read at a time when exporting a backtest run
+const exportTradeBatchSize = 1000
+
+// GetBacktestRunSummary returns a backtest run with its symbol and the metrics saved with
+// its results, for the summary of a trade export
+func (s *BacktestService) GetBacktestRunSummary(ctx context.Context, runID int) (*model.BacktestRunSummary, error) {
+	run, err := s.backtestRepo.GetBacktestRunInfo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errcode.ErrBacktestRunNotFound
+	}
+
+	metrics, err := s.backtestRepo.GetBacktestRunMetrics(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := s.backtestRepo.GetSymbolNames(ctx, []int{run.SymbolID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BacktestRunSummary{
+		BacktestRunInfo: *run,
+		Symbol:          symbols[run.SymbolID],
+		Metrics:         metrics,
+	}, nil
+}
+
+// EachBacktestTradeBatch calls fn with every trade of a backtest run in batches, oldest entry
+// first, so runs with any number of trades can be exported without holding them in memory.
+// It stops at the first error fn returns.
+func (s *BacktestService) EachBacktestTradeBatch(
+	ctx context.Context,
+	runID int,
+	fn func([]model.BacktestTrade) error,
+) error {
+	var after *model.BacktestTradeCursor
+	for {
+		trades, next, err := s.backtestRepo.GetBacktestTradesAfter(ctx, runID, "entry_time", "ASC", after, exportTradeBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(trades) > 0 {
+			if err := fn(trades); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		after = next
+	}
+}
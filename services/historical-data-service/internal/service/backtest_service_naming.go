@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// Prefixes of the tags added to every backtest on creation
+const (
+	tagStrategyVersion = "strategy_version:"
+	tagParams          = "params:"
+)
+
+const (
+	// maxNameSymbols bounds how many symbols {symbol} lists before summarizing the rest
+	maxNameSymbols = 3
+	// maxBacktestName is the length of the name column; longer resolved names are cut
+	maxBacktestName = 100
+)
+
+var (
+	nameVariablePattern = regexp.MustCompile(`\{([A-Za-z_]+)\}`)
+	tagPattern          = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/=-]*$`)
+)
+
+// resolveBacktestName fills in the variables of a backtest name template: {strategy},
+// {symbol} (the symbols, summarized past a few), {timeframe} and {date} (creation day, UTC)
+func (s *BacktestService) resolveBacktestName(
+	ctx context.Context,
+	template string,
+	strategyName string,
+	request *model.BacktestRequest,
+) (string, error) {
+	var unknown []string
+	for _, match := range nameVariablePattern.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "strategy", "symbol", "timeframe", "date":
+		default:
+			unknown = append(unknown, match[0])
+		}
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("invalid name template: unknown variable %s, use {strategy}, {symbol}, {timeframe} or {date}",
+			strings.Join(unknown, ", "))
+	}
+
+	if !strings.Contains(template, "{") {
+		return template, nil
+	}
+
+	symbol := ""
+	if strings.Contains(template, "{symbol}") {
+		names, err := s.backtestRepo.GetSymbolNames(ctx, request.SymbolIDs)
+		if err != nil {
+			return "", err
+		}
+		symbol = nameSymbols(request.SymbolIDs, names)
+	}
+
+	replacer := strings.NewReplacer(
+		"{strategy}", strategyName,
+		"{symbol}", symbol,
+		"{timeframe}", request.Timeframe,
+		"{date}", time.Now().UTC().Format("2006-01-02"),
+	)
+	name := []rune(strings.TrimSpace(replacer.Replace(template)))
+	if len(name) > maxBacktestName {
+		name = name[:maxBacktestName]
+	}
+	return string(name), nil
+}
+
+// nameSymbols lists the symbols of a backtest for its name, e.g. "BTCUSDT,ETHUSDT" or
+// "BTCUSDT,ETHUSDT,SOLUSDT+2"
+func nameSymbols(symbolIDs []int, names map[int]string) string {
+	listed := make([]string, 0, maxNameSymbols)
+	for _, id := range symbolIDs {
+		if len(listed) == maxNameSymbols {
+			break
+		}
+		name, ok := names[id]
+		if !ok {
+			name = strconv.Itoa(id)
+		}
+		listed = append(listed, name)
+	}
+
+	symbols := strings.Join(listed, ",")
+	if rest := len(symbolIDs) - len(listed); rest > 0 {
+		symbols += "+" + strconv.Itoa(rest)
+	}
+	return symbols
+}
+
+// normalizeBacktestTags lower-cases and deduplicates the tags a user gave a backtest.
+// Tags can't contain spaces or commas, so they can be listed in a filter, and can't
+// use the prefixes of the automatic tags.
+func normalizeBacktestTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use letters, digits and _ . : / = -", tag)
+		}
+		if strings.HasPrefix(tag, tagStrategyVersion) || strings.HasPrefix(tag, tagParams) {
+			return nil, fmt.Errorf("invalid tag %q: the prefixes %s and %s are reserved", tag, tagStrategyVersion, tagParams)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// autoBacktestTags returns the tags added to every backtest: its strategy version and a
+// hash of the strategy parameters and trading settings it runs with, so runs of the same
+// configuration can be found together
+func autoBacktestTags(strategyVersion int, structure json.RawMessage, settings model.BacktestSettings) []string {
+	return []string{
+		tagStrategyVersion + strconv.Itoa(strategyVersion),
+		tagParams + paramsHash(structure, settings),
+	}
+}
+
+// paramsHash hashes a strategy structure with the trading settings. The structure is
+// compacted first so formatting doesn't change the hash.
+func paramsHash(structure json.RawMessage, settings model.BacktestSettings) string {
+	hash := sha256.New()
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, structure); err == nil {
+		hash.Write(compacted.Bytes())
+	} else {
+		hash.Write(structure)
+	}
+
+	encoded, _ := json.Marshal(settings)
+	hash.Write(encoded)
+
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// parseTagsFilter splits a comma-separated tag filter, normalized like the tags themselves
+func parseTagsFilter(filter string) []string {
+	var tags []string
+	for _, tag := range strings.Split(filter, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
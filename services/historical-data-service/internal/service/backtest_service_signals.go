@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/utils"
+)
+
+// Bounds of the window of candles a signal preview evaluates
+const (
+	defaultSignalPreviewCandles = 500
+	maxSignalPreviewCandles     = 5000
+)
+
+// PreviewSignals evaluates a strategy structure over the latest stored candles of a symbol
+// and returns the entries and exits it gives, for iterating on a strategy without running
+// a backtest
+func (s *BacktestService) PreviewSignals(ctx context.Context, request *model.SignalPreviewRequest) (*model.SignalPreview, error) {
+	if request.Candles == 0 {
+		request.Candles = defaultSignalPreviewCandles
+	}
+	if request.Candles < 1 || request.Candles > maxSignalPreviewCandles {
+		return nil, fmt.Errorf("invalid candles: must be between 1 and %d", maxSignalPreviewCandles)
+	}
+
+	minutes, err := utils.ParseTimeframe(request.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	auxiliary, err := auxiliaryTimeframes(request.Strategy, request.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	hasData, err := s.marketDataRepo.HasData(ctx, request.SymbolID, request.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if !hasData {
+		return nil, fmt.Errorf("no market data available for symbol ID %d with timeframe %s",
+			request.SymbolID, request.Timeframe)
+	}
+
+	_, endDate, err := s.marketDataRepo.GetDataRange(ctx, request.SymbolID, request.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	startDate := endDate.Add(-time.Duration(request.Candles-1) * time.Duration(minutes) * time.Minute)
+
+	params := map[string]interface{}{
+		"allow_short":          request.AllowShort,
+		"auxiliary_timeframes": auxiliary,
+	}
+	signals, candles, err := s.backtestClient.PreviewSignals(ctx, request.SymbolID, request.Timeframe,
+		startDate, endDate, request.Strategy, params)
+	if err != nil {
+		return nil, err
+	}
+	if signals == nil {
+		signals = []model.Signal{}
+	}
+
+	return &model.SignalPreview{
+		SymbolID:  request.SymbolID,
+		Timeframe: request.Timeframe,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Candles:   candles,
+		Signals:   signals,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"services/historical-data-service/internal/utils"
+)
+
+// auxiliaryTimeframes returns the timeframes other than the backtest's that a strategy's
+// indicators are computed on, shortest first. An indicator's timeframe is set by the
+// "timeframe" of its indicator object; indicators without one, or with the backtest's,
+// use the backtest's candles. Indicators can't use a shorter timeframe than the backtest's,
+// whose candles would only be seen once per backtest candle.
+func auxiliaryTimeframes(structure json.RawMessage, primary string) ([]string, error) {
+	var data interface{}
+	if err := json.Unmarshal(structure, &data); err != nil {
+		return nil, fmt.Errorf("invalid strategy structure: %w", err)
+	}
+
+	primaryMinutes, err := utils.ParseTimeframe(primary)
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := make(map[string]int)
+	for _, timeframe := range indicatorTimeframes(data) {
+		length, err := utils.ParseTimeframe(timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeframe %q of a strategy indicator", timeframe)
+		}
+		if length < primaryMinutes {
+			return nil, fmt.Errorf("invalid timeframe: indicator timeframe %s is shorter than the backtest timeframe %s",
+				timeframe, primary)
+		}
+		if length > primaryMinutes {
+			minutes[timeframe] = length
+		}
+	}
+
+	timeframes := make([]string, 0, len(minutes))
+	for timeframe := range minutes {
+		timeframes = append(timeframes, timeframe)
+	}
+	sort.Slice(timeframes, func(i, j int) bool {
+		return minutes[timeframes[i]] < minutes[timeframes[j]]
+	})
+
+	return timeframes, nil
+}
+
+// indicatorTimeframes returns the timeframes set on the indicator objects of a strategy
+// structure
+func indicatorTimeframes(node interface{}) []string {
+	var timeframes []string
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if indicator, ok := child.(map[string]interface{}); ok && key == "indicator" {
+				if timeframe, ok := indicator["timeframe"].(string); ok && timeframe != "" {
+					timeframes = append(timeframes, timeframe)
+				}
+				continue
+			}
+			timeframes = append(timeframes, indicatorTimeframes(child)...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			timeframes = append(timeframes, indicatorTimeframes(child)...)
+		}
+	}
+	return timeframes
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/calendar"
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxSessionRangeDays bounds the range sessions can be listed for in one request
+const maxSessionRangeDays = 366
+
+// CalendarService handles exchange trading calendars
+type CalendarService struct {
+	calendarRepo *repository.CalendarRepository
+	symbolRepo   *repository.SymbolRepository
+	logger       *zap.Logger
+}
+
+// NewCalendarService creates a new calendar service
+func NewCalendarService(
+	calendarRepo *repository.CalendarRepository,
+	symbolRepo *repository.SymbolRepository,
+	logger *zap.Logger,
+) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		symbolRepo:   symbolRepo,
+		logger:       logger,
+	}
+}
+
+// GetCalendars retrieves all exchange calendars with their weekly sessions
+func (s *CalendarService) GetCalendars(ctx context.Context) ([]model.TradingCalendar, error) {
+	return s.calendarRepo.GetCalendars(ctx)
+}
+
+// GetCalendar retrieves the calendar of an exchange with its sessions and holidays
+func (s *CalendarService) GetCalendar(ctx context.Context, exchange string) (*model.TradingCalendar, error) {
+	definition, err := s.calendarRepo.GetCalendar(ctx, strings.ToUpper(exchange))
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		return nil, errcode.ErrCalendarNotFound
+	}
+
+	definition.Holidays, err = s.calendarRepo.GetHolidays(ctx, definition.Exchange, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+// GetSessions lists the trading sessions of an exchange that overlap a time range
+func (s *CalendarService) GetSessions(
+	ctx context.Context,
+	exchange string,
+	startDate time.Time,
+	endDate time.Time,
+) (*model.ExchangeSessions, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("invalid date range: end date must be after start date")
+	}
+	if endDate.Sub(startDate) > maxSessionRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("invalid date range: sessions can be listed for at most %d days", maxSessionRangeDays)
+	}
+
+	definition, err := s.GetCalendar(ctx, exchange)
+	if err != nil {
+		return nil, err
+	}
+
+	cal, err := calendar.New(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ExchangeSessions{
+		Exchange:  definition.Exchange,
+		Timezone:  definition.Timezone,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Sessions:  cal.Sessions(startDate, endDate),
+	}, nil
+}
+
+// AddHoliday adds a holiday to an exchange calendar, replacing any holiday on the same date
+func (s *CalendarService) AddHoliday(ctx context.Context, exchange string, request *model.TradingHolidayRequest) error {
+	date, err := time.Parse("2006-01-02", request.Date)
+	if err != nil {
+		return errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	if request.EarlyClose != nil {
+		if _, err := calendar.ParseClock(*request.EarlyClose); err != nil {
+			return err
+		}
+	}
+
+	definition, err := s.calendarRepo.GetCalendar(ctx, strings.ToUpper(exchange))
+	if err != nil {
+		return err
+	}
+	if definition == nil {
+		return errcode.ErrCalendarNotFound
+	}
+
+	return s.calendarRepo.UpsertHoliday(ctx, definition.Exchange, date, request.Name, request.EarlyClose)
+}
+
+// AdjustBacktestRange checks a backtest date range against the calendars of the exchanges its
+// symbols trade on. A start or end date on which none of the symbols trade is moved to the
+// nearest trading day inside the range, and closures inside the range are reported as
+// warnings. It fails if a symbol has no trading day in the range at all. Exchanges without a
+// calendar are treated as always open.
+func (s *CalendarService) AdjustBacktestRange(
+	ctx context.Context,
+	symbolIDs []int,
+	startDate time.Time,
+	endDate time.Time,
+) (time.Time, time.Time, []string, error) {
+	warnings := make([]string, 0)
+
+	// Symbols on the same exchange get the same range
+	exchangeRanges := make(map[string][2]time.Time)
+
+	adjustedStart := time.Time{}
+	adjustedEnd := time.Time{}
+	for i, symbolID := range symbolIDs {
+		symbolStart, symbolEnd := startDate, endDate
+
+		symbol, err := s.symbolRepo.GetSymbolByID(ctx, symbolID)
+		if err != nil {
+			return startDate, endDate, nil, err
+		}
+
+		if symbol != nil && symbol.Exchange != "" {
+			exchange := strings.ToUpper(symbol.Exchange)
+			if bounds, ok := exchangeRanges[exchange]; ok {
+				symbolStart, symbolEnd = bounds[0], bounds[1]
+			} else {
+				cal, err := s.loadCalendar(ctx, exchange, startDate, endDate)
+				if err != nil {
+					return startDate, endDate, nil, err
+				}
+				if cal != nil {
+					symbolStart, symbolEnd, err = s.adjustSymbolRange(cal, symbol, exchange, startDate, endDate, &warnings)
+					if err != nil {
+						return startDate, endDate, nil, err
+					}
+				}
+				exchangeRanges[exchange] = [2]time.Time{symbolStart, symbolEnd}
+			}
+		}
+
+		// Symbols share one range, so only trim days on which none of them trade
+		if i == 0 || symbolStart.Before(adjustedStart) {
+			adjustedStart = symbolStart
+		}
+		if i == 0 || symbolEnd.After(adjustedEnd) {
+			adjustedEnd = symbolEnd
+		}
+	}
+
+	if len(symbolIDs) == 0 {
+		return startDate, endDate, warnings, nil
+	}
+
+	if !adjustedStart.Equal(startDate) {
+		warnings = append(warnings, fmt.Sprintf("start date moved from %s to %s, the first trading day",
+			startDate.Format("2006-01-02"), adjustedStart.Format("2006-01-02")))
+	}
+	if !adjustedEnd.Equal(endDate) {
+		warnings = append(warnings, fmt.Sprintf("end date moved from %s to %s, the last trading day",
+			endDate.Format("2006-01-02"), adjustedEnd.Format("2006-01-02")))
+	}
+
+	return adjustedStart, adjustedEnd, warnings, nil
+}
+
+// adjustSymbolRange moves the start and end of a range onto trading days of a symbol's exchange
+// and records the holidays inside the range
+func (s *CalendarService) adjustSymbolRange(
+	cal *calendar.Calendar,
+	symbol *model.Symbol,
+	exchange string,
+	startDate time.Time,
+	endDate time.Time,
+	warnings *[]string,
+) (time.Time, time.Time, error) {
+	// Trading days are compared by the UTC calendar date, like candle timestamps
+	start := startDate.UTC()
+	end := endDate.UTC()
+
+	if !cal.IsTradingDay(start) {
+		next, ok := cal.NextTradingDay(start)
+		if ok {
+			start = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if !ok || start.After(end) {
+			return startDate, endDate, fmt.Errorf("requested date range has no trading days for %s on %s",
+				symbol.Symbol, exchange)
+		}
+	}
+
+	if !cal.IsTradingDay(end) {
+		previous, ok := cal.PreviousTradingDay(end)
+		if !ok || previous.Before(start) {
+			return startDate, endDate, fmt.Errorf("requested date range has no trading days for %s on %s",
+				symbol.Symbol, exchange)
+		}
+		end = time.Date(previous.Year(), previous.Month(), previous.Day(), 23, 59, 59, 999999999, time.UTC)
+	}
+
+	if holidays := cal.Holidays(start, end); len(holidays) > 0 {
+		dates := make([]string, len(holidays))
+		for i, holiday := range holidays {
+			dates[i] = holiday.Date
+		}
+		*warnings = append(*warnings, fmt.Sprintf("%s is closed on %d holidays in the requested range (%s)",
+			exchange, len(holidays), strings.Join(dates, ", ")))
+	}
+
+	return start, end, nil
+}
+
+// loadCalendar builds the calendar of an exchange with the holidays around a date range,
+// or returns nil if the exchange has no calendar
+func (s *CalendarService) loadCalendar(
+	ctx context.Context,
+	exchange string,
+	startDate time.Time,
+	endDate time.Time,
+) (*calendar.Calendar, error) {
+	definition, err := s.calendarRepo.GetCalendar(ctx, exchange)
+	if err != nil || definition == nil {
+		return nil, err
+	}
+
+	// Include the days a start or end date can move across
+	from := startDate.AddDate(0, 0, -31)
+	to := endDate.AddDate(0, 0, 31)
+	definition.Holidays, err = s.calendarRepo.GetHolidays(ctx, exchange, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar.New(definition)
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/model"
+)
+
+// candleCacheKey identifies one candle query. Zero times stand for an open-ended range;
+// count entries hold the total for the range rather than a page of candles.
+type candleCacheKey struct {
+	symbolID  int
+	timeframe string
+	start     time.Time
+	end       time.Time
+	limit     int
+	offset    int
+	count     bool
+}
+
+type candleCacheEntry struct {
+	key       candleCacheKey
+	candles   []model.Candle
+	total     int
+	expiresAt time.Time
+}
+
+// size is what an entry counts against the cache's limit. Counts weigh as one candle so
+// they can't accumulate without bound.
+func (e *candleCacheEntry) size() int {
+	return max(len(e.candles), 1)
+}
+
+// CandleCache is an in-process LRU cache of candle query results. Backtest and
+// optimization runs request the same ranges over and over, so repeated reads are served
+// from memory. Its size is bounded by the number of candles held, and entries are
+// invalidated when candles inside their range are imported. A read racing an import can
+// still cache what it saw just before the import landed; the TTL bounds how long that is
+// served. A nil cache caches nothing.
+type CandleCache struct {
+	maxCandles int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[candleCacheKey]*list.Element
+	lru     *list.List // Front is the most recently used entry
+	candles int
+
+	hits          int64
+	misses        int64
+	evictions     int64
+	invalidations int64
+}
+
+// NewCandleCache creates a candle cache holding at most maxCandles candles, each entry
+// for at most ttl. It returns nil, which disables caching, when maxCandles isn't positive.
+func NewCandleCache(maxCandles int, ttl time.Duration) *CandleCache {
+	if maxCandles <= 0 {
+		return nil
+	}
+	return &CandleCache{
+		maxCandles: maxCandles,
+		ttl:        ttl,
+		entries:    make(map[candleCacheKey]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func newCandleCacheKey(symbolID int, timeframe string, start, end *time.Time) candleCacheKey {
+	key := candleCacheKey{symbolID: symbolID, timeframe: timeframe}
+	if start != nil {
+		key.start = start.UTC()
+	}
+	if end != nil {
+		key.end = end.UTC()
+	}
+	return key
+}
+
+// GetCandles returns a copy of the cached page of candles for a query
+func (c *CandleCache) GetCandles(symbolID int, timeframe string, start, end *time.Time, limit, offset int) ([]model.Candle, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	key := newCandleCacheKey(symbolID, timeframe, start, end)
+	key.limit, key.offset = limit, offset
+
+	entry, ok := c.get(key)
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(entry.candles), true
+}
+
+// SetCandles caches a page of candles for a query
+func (c *CandleCache) SetCandles(symbolID int, timeframe string, start, end *time.Time, limit, offset int, candles []model.Candle) {
+	if c == nil {
+		return
+	}
+
+	key := newCandleCacheKey(symbolID, timeframe, start, end)
+	key.limit, key.offset = limit, offset
+	c.set(&candleCacheEntry{key: key, candles: slices.Clone(candles)})
+}
+
+// GetCount returns the cached number of candles in a range
+func (c *CandleCache) GetCount(symbolID int, timeframe string, start, end *time.Time) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	key := newCandleCacheKey(symbolID, timeframe, start, end)
+	key.count = true
+
+	entry, ok := c.get(key)
+	if !ok {
+		return 0, false
+	}
+	return entry.total, true
+}
+
+// SetCount caches the number of candles in a range
+func (c *CandleCache) SetCount(symbolID int, timeframe string, start, end *time.Time, total int) {
+	if c == nil {
+		return
+	}
+
+	key := newCandleCacheKey(symbolID, timeframe, start, end)
+	key.count = true
+	c.set(&candleCacheEntry{key: key, total: total})
+}
+
+// Invalidate drops every cached result of a symbol, in any timeframe, whose range
+// overlaps candles imported between from and to
+func (c *CandleCache) Invalidate(symbolID int, from, to time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		if key.symbolID != symbolID {
+			continue
+		}
+		if !key.start.IsZero() && key.start.After(to) {
+			continue
+		}
+		if !key.end.IsZero() && key.end.Before(from) {
+			continue
+		}
+		c.remove(element)
+		c.invalidations++
+	}
+}
+
+// InvalidateImported drops the cached results covering a batch of imported candles
+func (c *CandleCache) InvalidateImported(candles []model.CandleBatch) {
+	if c == nil {
+		return
+	}
+
+	type span struct{ from, to time.Time }
+	spans := make(map[int]span)
+	for _, candle := range candles {
+		current, ok := spans[candle.SymbolID]
+		if !ok {
+			spans[candle.SymbolID] = span{from: candle.Time, to: candle.Time}
+			continue
+		}
+		if candle.Time.Before(current.from) {
+			current.from = candle.Time
+		}
+		if candle.Time.After(current.to) {
+			current.to = candle.Time
+		}
+		spans[candle.SymbolID] = current
+	}
+
+	for symbolID, imported := range spans {
+		c.Invalidate(symbolID, imported.from, imported.to)
+	}
+}
+
+// Stats returns the cache's hit, miss and size counters
+func (c *CandleCache) Stats() model.CandleCacheStats {
+	if c == nil {
+		return model.CandleCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := model.CandleCacheStats{
+		Enabled:       true,
+		Entries:       len(c.entries),
+		Candles:       c.candles,
+		MaxCandles:    c.maxCandles,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Invalidations: c.invalidations,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+
+	return stats
+}
+
+func (c *CandleCache) get(key candleCacheKey) (*candleCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*candleCacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		c.misses++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	c.hits++
+	return entry, true
+}
+
+func (c *CandleCache) set(entry *candleCacheEntry) {
+	// A result larger than the whole cache would only evict everything else
+	if entry.size() > c.maxCandles {
+		return
+	}
+	entry.expiresAt = time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[entry.key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.candles += entry.size()
+
+	for c.candles > c.maxCandles {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// remove drops an entry; the caller holds the lock
+func (c *CandleCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*candleCacheEntry)
+	delete(c.entries, entry.key)
+	c.candles -= entry.size()
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// DerivativesService serves the stored funding rates and open interest of perpetual futures
+type DerivativesService struct {
+	derivativesRepo *repository.DerivativesRepository
+	symbolRepo      *repository.SymbolRepository
+	logger          *zap.Logger
+}
+
+// NewDerivativesService creates a new derivatives service
+func NewDerivativesService(
+	derivativesRepo *repository.DerivativesRepository,
+	symbolRepo *repository.SymbolRepository,
+	logger *zap.Logger,
+) *DerivativesService {
+	return &DerivativesService{
+		derivativesRepo: derivativesRepo,
+		symbolRepo:      symbolRepo,
+		logger:          logger,
+	}
+}
+
+// GetFundingRates returns up to limit funding rates of a symbol (ticker or ID) in a time
+// range, oldest first
+func (s *DerivativesService) GetFundingRates(
+	ctx context.Context,
+	symbolRef string,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.FundingRate, error) {
+	symbol, err := s.resolveSymbol(ctx, symbolRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDerivativesRange(startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	return s.derivativesRepo.GetFundingRates(ctx, symbol.ID, startTime, endTime, limit)
+}
+
+// GetOpenInterest returns up to limit open interest samples of a symbol (ticker or ID) per
+// period in a time range, oldest first
+func (s *DerivativesService) GetOpenInterest(
+	ctx context.Context,
+	symbolRef string,
+	period string,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.OpenInterest, error) {
+	if !slices.Contains(model.OpenInterestPeriods, period) {
+		return nil, fmt.Errorf("invalid period %q: must be one of %v", period, model.OpenInterestPeriods)
+	}
+
+	symbol, err := s.resolveSymbol(ctx, symbolRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDerivativesRange(startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	return s.derivativesRepo.GetOpenInterest(ctx, symbol.ID, period, startTime, endTime, limit)
+}
+
+// resolveSymbol looks a symbol up by ID if symbolRef is numeric, otherwise by ticker
+func (s *DerivativesService) resolveSymbol(ctx context.Context, symbolRef string) (*model.Symbol, error) {
+	var symbol *model.Symbol
+	var err error
+	if id, convErr := strconv.Atoi(symbolRef); convErr == nil {
+		symbol, err = s.symbolRepo.GetSymbolByID(ctx, id)
+	} else {
+		symbol, err = s.symbolRepo.GetSymbolByName(ctx, symbolRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return nil, errcode.ErrSymbolNotFound
+	}
+	return symbol, nil
+}
+
+// validateDerivativesRange rejects ranges that end before they start
+func validateDerivativesRange(startTime, endTime *time.Time) error {
+	if startTime != nil && endTime != nil && endTime.Before(*startTime) {
+		return fmt.Errorf("invalid date range: end_date is before start_date")
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// DownloadPoolOptions configures the download worker pool
+type DownloadPoolOptions struct {
+	// Workers is the total number of downloads run at once
+	Workers int
+	// MaxConcurrentPerSource caps concurrent downloads per data source to respect provider
+	// rate limits; sources not listed use DefaultMaxConcurrentPerSource
+	MaxConcurrentPerSource        map[string]int
+	DefaultMaxConcurrentPerSource int
+	// PollInterval is how often the queue is checked when no job was just queued
+	PollInterval time.Duration
+	// MaxAttempts is how many times a job is tried before it fails
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry; it doubles with each attempt
+	RetryBackoff time.Duration
+	// StaleAfter requeues running jobs that haven't checkpointed for this long,
+	// e.g. because the instance running them died
+	StaleAfter time.Duration
+}
+
+// DownloadWorkerPool runs queued market data downloads with bounded concurrency.
+// Jobs live in the database, so queued, running and retrying jobs survive restarts.
+type DownloadWorkerPool struct {
+	downloadRepo    *repository.DownloadJobRepository
+	downloadService *MarketDataDownloadService
+	jobs            *JobRegistry
+	options         DownloadPoolOptions
+	logger          *zap.Logger
+
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// NewDownloadWorkerPool creates a new download worker pool
+func NewDownloadWorkerPool(
+	downloadRepo *repository.DownloadJobRepository,
+	downloadService *MarketDataDownloadService,
+	jobs *JobRegistry,
+	options DownloadPoolOptions,
+	logger *zap.Logger,
+) *DownloadWorkerPool {
+	// Source names are upper case (BINANCE); config keys arrive lower-cased
+	perSource := make(map[string]int, len(options.MaxConcurrentPerSource))
+	for source, limit := range options.MaxConcurrentPerSource {
+		perSource[strings.ToUpper(source)] = limit
+	}
+	options.MaxConcurrentPerSource = perSource
+
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.DefaultMaxConcurrentPerSource < 1 {
+		options.DefaultMaxConcurrentPerSource = options.Workers
+	}
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = 5 * time.Second
+	}
+
+	return &DownloadWorkerPool{
+		downloadRepo:    downloadRepo,
+		downloadService: downloadService,
+		jobs:            jobs,
+		options:         options,
+		logger:          logger,
+		running:         make(map[string]int),
+	}
+}
+
+// Run claims and runs queued jobs until ctx is cancelled. Running jobs are interrupted
+// when the job registry drains; they are returned to the queue and resume from their
+// checkpoint.
+func (p *DownloadWorkerPool) Run(ctx context.Context) {
+	p.logger.Info("Starting download worker pool",
+		zap.Int("workers", p.options.Workers),
+		zap.Any("maxConcurrentPerSource", p.options.MaxConcurrentPerSource),
+		zap.Int("defaultMaxConcurrentPerSource", p.options.DefaultMaxConcurrentPerSource),
+		zap.Int("maxAttempts", p.options.MaxAttempts))
+
+	ticker := time.NewTicker(p.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.requeueStale(ctx)
+		p.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Stopping download worker pool")
+			return
+		case <-ticker.C:
+		case <-p.downloadService.queued:
+		}
+	}
+}
+
+// dispatch claims jobs while there are free workers and runnable jobs
+func (p *DownloadWorkerPool) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		excluded, full := p.saturatedSources()
+		if full {
+			return
+		}
+
+		job, err := p.downloadRepo.ClaimDownloadJob(ctx, excluded)
+		if err != nil || job == nil {
+			return
+		}
+
+		p.start(job)
+	}
+}
+
+// saturatedSources lists sources at their concurrency limit, and reports whether
+// every worker is busy
+func (p *DownloadWorkerPool) saturatedSources() ([]string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := 0
+	excluded := []string{}
+	for source, count := range p.running {
+		total += count
+		if count >= p.sourceLimit(source) {
+			excluded = append(excluded, source)
+		}
+	}
+
+	return excluded, total >= p.options.Workers
+}
+
+// sourceLimit returns the concurrency limit of a source. Callers hold p.mu.
+func (p *DownloadWorkerPool) sourceLimit(source string) int {
+	if limit, ok := p.options.MaxConcurrentPerSource[source]; ok && limit > 0 {
+		return limit
+	}
+	return p.options.DefaultMaxConcurrentPerSource
+}
+
+// start runs a claimed job in the background. A job claimed while the registry drains
+// is returned to the queue.
+func (p *DownloadWorkerPool) start(job *model.MarketDataDownloadJob) {
+	p.mu.Lock()
+	p.running[job.Source]++
+	p.mu.Unlock()
+
+	started := p.jobs.Go("download", job.ID, func(jobCtx context.Context) {
+		defer p.finish(job.Source)
+		p.runJob(jobCtx, job)
+	})
+	if !started {
+		p.finish(job.Source)
+
+		// The pool is stopping too, so its context can't be used
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		p.downloadRepo.ReleaseDownloadJob(releaseCtx, job.ID)
+	}
+}
+
+// finish frees the slot of a job of the source
+func (p *DownloadWorkerPool) finish(source string) {
+	p.mu.Lock()
+	p.running[source]--
+	if p.running[source] == 0 {
+		delete(p.running, source)
+	}
+	p.mu.Unlock()
+
+	// A slot is free, look for the next job
+	p.downloadService.notifyQueued()
+}
+
+// runJob runs one attempt of a job and records its outcome
+func (p *DownloadWorkerPool) runJob(ctx context.Context, job *model.MarketDataDownloadJob) {
+	err := p.downloadService.processDownload(ctx, job)
+	if err == nil {
+		return
+	}
+
+	// Bookkeeping must happen even though ctx may be cancelled
+	bookkeepingCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if errors.Is(err, errDownloadInterrupted) || ctx.Err() != nil {
+		p.logger.Info("Download interrupted, returning job to the queue",
+			zap.Int("jobID", job.ID),
+			zap.String("symbol", job.Symbol))
+		p.downloadRepo.ReleaseDownloadJob(bookkeepingCtx, job.ID)
+		return
+	}
+
+	delay := p.retryDelay(job.Attempts)
+	status, dbErr := p.downloadRepo.FailDownloadJobAttempt(bookkeepingCtx, job.ID, err.Error(), p.options.MaxAttempts, delay)
+	if dbErr != nil {
+		return
+	}
+
+	if status == "pending" {
+		p.logger.Warn("Download attempt failed, retry scheduled",
+			zap.Error(err),
+			zap.Int("jobID", job.ID),
+			zap.String("symbol", job.Symbol),
+			zap.Int("attempt", job.Attempts),
+			zap.Int("maxAttempts", p.options.MaxAttempts),
+			zap.Duration("retryIn", delay))
+	} else {
+		p.logger.Error("Download failed after final attempt",
+			zap.Error(err),
+			zap.Int("jobID", job.ID),
+			zap.String("symbol", job.Symbol),
+			zap.Int("attempts", job.Attempts))
+	}
+}
+
+// retryDelay doubles the backoff with each attempt, capped at one hour
+func (p *DownloadWorkerPool) retryDelay(attempt int) time.Duration {
+	delay := p.options.RetryBackoff
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// requeueStale returns jobs abandoned by a dead worker to the queue
+func (p *DownloadWorkerPool) requeueStale(ctx context.Context) {
+	if p.options.StaleAfter <= 0 {
+		return
+	}
+
+	count, err := p.downloadRepo.RequeueStaleDownloadJobs(ctx, p.options.StaleAfter)
+	if err == nil && count > 0 {
+		p.logger.Warn("Requeued stale download jobs", zap.Int("count", count))
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runningJob describes a job started through the registry
+type runningJob struct {
+	kind    string
+	id      int
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// JobRegistry tracks the background jobs of the service, such as backtests and
+// downloads, so shutdown can interrupt them and wait for them to persist their state.
+// Jobs run with a context that is cancelled once draining starts; they are expected to
+// checkpoint or requeue themselves when it is.
+type JobRegistry struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	draining bool
+	nextKey  int
+	jobs     map[int]runningJob
+	wg       sync.WaitGroup
+}
+
+// NewJobRegistry creates a new job registry
+func NewJobRegistry(logger *zap.Logger) *JobRegistry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobRegistry{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+		jobs:   make(map[int]runningJob),
+	}
+}
+
+// Go runs a job in the background. Once draining started the job isn't run and false is
+// returned; the caller leaves it queued for the next instance.
+func (r *JobRegistry) Go(kind string, id int, run func(ctx context.Context)) bool {
+	r.mu.Lock()
+	if r.draining {
+		r.mu.Unlock()
+		return false
+	}
+	key := r.nextKey
+	r.nextKey++
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.jobs[key] = runningJob{kind: kind, id: id, started: time.Now(), cancel: cancel}
+	r.wg.Add(1)
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.jobs, key)
+			r.mu.Unlock()
+			cancel()
+			r.wg.Done()
+		}()
+
+		run(ctx)
+	}()
+	return true
+}
+
+// Cancel interrupts the jobs of a kind and ID running on this instance, as shutdown does.
+// Jobs see it as an interruption, so their state must be marked cancelled first for them
+// to stop rather than requeue themselves. Reports whether any job was running.
+func (r *JobRegistry) Cancel(kind string, id int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cancelled := false
+	for _, job := range r.jobs {
+		if job.kind == kind && job.id == id {
+			job.cancel()
+			cancelled = true
+		}
+	}
+	return cancelled
+}
+
+// Drain stops new jobs from starting, interrupts the running ones and waits up to timeout
+// for them to persist their state. Reports whether every job finished in time.
+func (r *JobRegistry) Drain(timeout time.Duration) bool {
+	r.mu.Lock()
+	r.draining = true
+	count := len(r.jobs)
+	r.mu.Unlock()
+
+	r.logger.Info("Draining background jobs",
+		zap.Int("jobs", count),
+		zap.Duration("timeout", timeout))
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.logger.Info("Background jobs drained")
+		return true
+	case <-time.After(timeout):
+	}
+
+	// Jobs left behind keep the state they last persisted
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		r.logger.Warn("Background job did not stop in time",
+			zap.String("kind", job.kind),
+			zap.Int("id", job.id),
+			zap.Duration("running", time.Since(job.started)))
+	}
+	return false
+}
+
+// Running reports whether a job of a kind and ID runs on this instance
+func (r *JobRegistry) Running(kind string, id int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range r.jobs {
+		if job.kind == kind && job.id == id {
+			return true
+		}
+	}
+	return false
+}
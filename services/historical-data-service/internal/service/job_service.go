@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+	"services/shared/pagination"
+
+	"go.uber.org/zap"
+)
+
+// adminCancelReason is recorded as the error of backtests an operator cancelled
+const adminCancelReason = "Cancelled by an administrator"
+
+// JobService lets operators monitor the background jobs of the service, downloads and
+// backtests, and cancel or requeue them
+type JobService struct {
+	jobRepo         *repository.JobRepository
+	downloadService *MarketDataDownloadService
+	jobs            *JobRegistry
+	logger          *zap.Logger
+}
+
+// NewJobService creates a new job service
+func NewJobService(
+	jobRepo *repository.JobRepository,
+	downloadService *MarketDataDownloadService,
+	jobs *JobRegistry,
+	logger *zap.Logger,
+) *JobService {
+	return &JobService{
+		jobRepo:         jobRepo,
+		downloadService: downloadService,
+		jobs:            jobs,
+		logger:          logger,
+	}
+}
+
+// isValidJobKind reports whether kind is a kind of background job
+func isValidJobKind(kind string) bool {
+	return kind == model.JobKindDownload || kind == model.JobKindBacktest
+}
+
+// ListJobs lists background jobs matching a filter, newest first, with their total count
+func (s *JobService) ListJobs(
+	ctx context.Context,
+	filter model.AdminJobFilter,
+	page int,
+	limit int,
+) ([]model.AdminJob, int, error) {
+	if filter.Kind != "" && !isValidJobKind(filter.Kind) {
+		return nil, 0, errcode.ErrInvalidJobKind
+	}
+
+	jobs, err := s.jobRepo.GetJobs(ctx, filter, limit, pagination.Offset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.jobRepo.CountJobs(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range jobs {
+		jobs[i].Running = s.jobs.Running(jobs[i].Kind, jobs[i].ID)
+	}
+
+	return jobs, total, nil
+}
+
+// GetJobCounts counts the background jobs by kind and status
+func (s *JobService) GetJobCounts(ctx context.Context) ([]model.AdminJobCount, error) {
+	return s.jobRepo.GetJobCounts(ctx)
+}
+
+// GetJob returns a background job
+func (s *JobService) GetJob(ctx context.Context, kind string, id int) (*model.AdminJob, error) {
+	if !isValidJobKind(kind) {
+		return nil, errcode.ErrInvalidJobKind
+	}
+
+	job, err := s.jobRepo.GetJob(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, errcode.ErrJobNotFound
+	}
+
+	job.Running = s.jobs.Running(kind, id)
+	return job, nil
+}
+
+// CancelJob cancels a background job that hasn't finished. A download stops at its next
+// checkpoint. A backtest running on this instance is interrupted; runs in flight elsewhere
+// finish, but their outcome no longer changes the backtest.
+func (s *JobService) CancelJob(ctx context.Context, kind string, id int, adminID int) (*model.AdminJob, error) {
+	job, err := s.GetJob(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled bool
+	switch kind {
+	case model.JobKindDownload:
+		if job.Status != "pending" && job.Status != "in_progress" {
+			return nil, errcode.ErrJobNotCancellable
+		}
+		cancelled, err = s.downloadService.CancelDownload(ctx, id, true)
+	case model.JobKindBacktest:
+		cancelled, err = s.jobRepo.CancelBacktest(ctx, id, adminCancelReason)
+		if cancelled {
+			s.jobs.Cancel(kind, id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, errcode.ErrJobNotCancellable
+	}
+
+	s.logger.Info("Background job cancelled",
+		zap.String("kind", kind),
+		zap.Int("id", id),
+		zap.Int("adminID", adminID))
+
+	return s.GetJob(ctx, kind, id)
+}
+
+// RequeueJob queues a failed or cancelled background job to run again. A download resumes
+// from its checkpoint; a backtest reruns its failed and cancelled runs and keeps the results
+// of its completed ones. Retry limits don't apply.
+func (s *JobService) RequeueJob(ctx context.Context, kind string, id int, adminID int) (*model.AdminJob, error) {
+	if _, err := s.GetJob(ctx, kind, id); err != nil {
+		return nil, err
+	}
+
+	var requeued bool
+	var err error
+	switch kind {
+	case model.JobKindDownload:
+		requeued, err = s.jobRepo.RequeueDownloadJob(ctx, id)
+		if requeued {
+			s.downloadService.notifyQueued()
+		}
+	case model.JobKindBacktest:
+		// Queued backtests are picked up by the next resumption pass
+		var runs int
+		runs, err = s.jobRepo.RequeueBacktest(ctx, id)
+		requeued = runs > 0
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, errcode.ErrJobNotRequeueable
+	}
+
+	s.logger.Info("Background job requeued",
+		zap.String("kind", kind),
+		zap.Int("id", id),
+		zap.Int("adminID", adminID))
+
+	return s.GetJob(ctx, kind, id)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// openInterestPeriod returns the open interest period a job's timeframe is sampled at: the
+// longest period no longer than one candle, and at least the shortest period
+func openInterestPeriod(timeframe string) string {
+	minutes := timeframeMinutes(timeframe)
+	period := model.OpenInterestPeriods[0]
+	for _, candidate := range model.OpenInterestPeriods {
+		if candidateMinutes, err := utils.ParseTimeframe(candidate); err == nil && candidateMinutes <= minutes {
+			period = candidate
+		}
+	}
+	return period
+}
+
+// downloadDerivatives fetches the funding rates and open interest a job asked for over its
+// range. Both are stored as they arrive and upserted, so a retried attempt fetches them
+// again without duplicates. It returns notes on data that couldn't be fetched, such as
+// open interest older than Binance keeps, and an error if the attempt should be retried.
+func (s *MarketDataDownloadService) downloadDerivatives(ctx context.Context, job *model.MarketDataDownloadJob) (string, error) {
+	var notes []string
+
+	if job.IncludeFundingRates {
+		stored, err := s.downloadFundingRates(ctx, job)
+		if errors.Is(err, client.ErrNoFuturesContract) {
+			return "Funding rates and open interest skipped: the symbol has no perpetual futures contract", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		s.logger.Info("Downloaded funding rates", zap.Int("jobID", job.ID), zap.Int("stored", stored))
+	}
+
+	if job.IncludeOpenInterest {
+		// Binance only keeps recent open interest history
+		start := job.StartDate
+		if earliest := time.Now().Add(-client.OpenInterestHistoryWindow); start.Before(earliest) {
+			start = earliest
+			notes = append(notes, fmt.Sprintf("Open interest is only available from %s", earliest.Format("2006-01-02")))
+		}
+
+		if start.Before(job.EndDate) {
+			stored, err := s.downloadOpenInterest(ctx, job, start)
+			if errors.Is(err, client.ErrNoFuturesContract) {
+				return "Open interest skipped: the symbol has no perpetual futures contract", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			s.logger.Info("Downloaded open interest", zap.Int("jobID", job.ID), zap.Int("stored", stored))
+		}
+	}
+
+	return strings.Join(notes, "; "), nil
+}
+
+// downloadFundingRates fetches and stores the funding rates of a job's range page by page
+func (s *MarketDataDownloadService) downloadFundingRates(ctx context.Context, job *model.MarketDataDownloadJob) (int, error) {
+	stored := 0
+	start := job.StartDate
+	for start.Before(job.EndDate) {
+		if ctx.Err() != nil {
+			return stored, errDownloadInterrupted
+		}
+
+		rates, stats, err := s.futuresClient.GetFundingRates(ctx, job.Symbol, start, job.EndDate, client.MaxFundingRatesLimit)
+		if retry, err := s.handleDerivativesRequest(ctx, job.ID, stats, err); err != nil || retry {
+			if err != nil {
+				return stored, err
+			}
+			continue
+		}
+
+		count, err := s.derivativesRepo.SaveFundingRates(ctx, job.SymbolID, rates)
+		if err != nil {
+			return stored, fmt.Errorf("failed to store funding rates: %w", err)
+		}
+		stored += count
+
+		if len(rates) < client.MaxFundingRatesLimit {
+			break
+		}
+		start = rates[len(rates)-1].FundingTime.Add(time.Millisecond)
+	}
+
+	return stored, nil
+}
+
+// downloadOpenInterest fetches and stores the open interest of a job's range from start
+// on, page by page, sampled at the period matching the job's timeframe
+func (s *MarketDataDownloadService) downloadOpenInterest(ctx context.Context, job *model.MarketDataDownloadJob, start time.Time) (int, error) {
+	period := openInterestPeriod(job.Timeframe)
+
+	stored := 0
+	for start.Before(job.EndDate) {
+		if ctx.Err() != nil {
+			return stored, errDownloadInterrupted
+		}
+
+		samples, stats, err := s.futuresClient.GetOpenInterest(ctx, job.Symbol, period, start, job.EndDate, client.MaxOpenInterestLimit)
+		if retry, err := s.handleDerivativesRequest(ctx, job.ID, stats, err); err != nil || retry {
+			if err != nil {
+				return stored, err
+			}
+			continue
+		}
+
+		count, err := s.derivativesRepo.SaveOpenInterest(ctx, job.SymbolID, period, samples)
+		if err != nil {
+			return stored, fmt.Errorf("failed to store open interest: %w", err)
+		}
+		stored += count
+
+		if len(samples) < client.MaxOpenInterestLimit {
+			break
+		}
+		start = samples[len(samples)-1].SampleTime.Add(time.Millisecond)
+	}
+
+	return stored, nil
+}
+
+// handleDerivativesRequest records a funding rate or open interest request in the job's
+// bandwidth metrics, where it adds to the bytes but not the candles. Throttled requests
+// wait until the limit lifts and report that the request should be sent again; other
+// failures are returned.
+func (s *MarketDataDownloadService) handleDerivativesRequest(
+	ctx context.Context,
+	jobID int,
+	stats client.RequestStats,
+	err error,
+) (bool, error) {
+	var rateLimited *client.RateLimitError
+	rateLimitedErr := errors.As(err, &rateLimited)
+	if rateLimitedErr {
+		stats.Waited += rateLimited.RetryAfter
+	}
+	s.recordRequest(ctx, jobID, stats, 0, rateLimitedErr)
+
+	if err == nil {
+		return false, nil
+	}
+	if ctx.Err() != nil {
+		return false, errDownloadInterrupted
+	}
+	if rateLimitedErr {
+		s.logger.Warn("Binance futures rate limit hit, waiting before fetching again",
+			zap.Int("jobID", jobID),
+			zap.Duration("retryAfter", rateLimited.RetryAfter))
+		if !sleepContext(ctx, rateLimited.RetryAfter) {
+			return false, errDownloadInterrupted
+		}
+		return true, nil
+	}
+	return false, err
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -10,17 +11,26 @@ import (
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
 	"services/historical-data-service/internal/utils"
+	"services/shared/pagination"
 
 	"go.uber.org/zap"
 )
 
 // MarketDataDownloadService handles market data download operations
 type MarketDataDownloadService struct {
-	downloadRepo   *repository.DownloadJobRepository
-	inventoryRepo  *repository.InventoryRepository
-	symbolRepo     *repository.SymbolRepository
-	marketDataRepo *repository.MarketDataRepository
-	logger         *zap.Logger
+	downloadRepo    *repository.DownloadJobRepository
+	inventoryRepo   *repository.InventoryRepository
+	symbolRepo      *repository.SymbolRepository
+	marketDataRepo  *repository.MarketDataRepository
+	timeframeRepo   *repository.TimeframeRepository
+	derivativesRepo *repository.DerivativesRepository
+	quotaService    *QuotaService
+	candleCache     *CandleCache
+	binanceClient   *client.BinanceClient
+	futuresClient   *client.BinanceFuturesClient
+	rateBudgets     client.RateBudgets
+	queued          chan struct{}
+	logger          *zap.Logger
 }
 
 // NewMarketDataDownloadService creates a new market data download service
@@ -29,14 +39,29 @@ func NewMarketDataDownloadService(
 	inventoryRepo *repository.InventoryRepository,
 	symbolRepo *repository.SymbolRepository,
 	marketDataRepo *repository.MarketDataRepository,
+	timeframeRepo *repository.TimeframeRepository,
+	derivativesRepo *repository.DerivativesRepository,
+	quotaService *QuotaService,
+	candleCache *CandleCache,
+	binanceClient *client.BinanceClient,
+	futuresClient *client.BinanceFuturesClient,
+	rateBudgets client.RateBudgets,
 	logger *zap.Logger,
 ) *MarketDataDownloadService {
 	return &MarketDataDownloadService{
-		downloadRepo:   downloadRepo,
-		inventoryRepo:  inventoryRepo,
-		symbolRepo:     symbolRepo,
-		marketDataRepo: marketDataRepo,
-		logger:         logger,
+		downloadRepo:    downloadRepo,
+		inventoryRepo:   inventoryRepo,
+		symbolRepo:      symbolRepo,
+		marketDataRepo:  marketDataRepo,
+		timeframeRepo:   timeframeRepo,
+		derivativesRepo: derivativesRepo,
+		quotaService:    quotaService,
+		candleCache:     candleCache,
+		binanceClient:   binanceClient,
+		futuresClient:   futuresClient,
+		rateBudgets:     rateBudgets,
+		queued:          make(chan struct{}, 1),
+		logger:          logger,
 	}
 }
 
@@ -44,11 +69,8 @@ func NewMarketDataDownloadService(
 func (s *MarketDataDownloadService) GetAvailableSymbols(ctx context.Context, source string) (interface{}, error) {
 	switch source {
 	case string(model.SourceBinance):
-		// Create a Binance client
-		binanceClient := client.NewBinanceClient(s.logger)
-
 		// Get exchange info from Binance
-		exchangeInfo, err := binanceClient.GetExchangeInfo(ctx)
+		exchangeInfo, err := s.binanceClient.GetExchangeInfo(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -152,8 +174,31 @@ func (s *MarketDataDownloadService) CheckSymbolStatus(ctx context.Context, symbo
 	}, nil
 }
 
-// InitiateDataDownload starts a download job for historical data
-func (s *MarketDataDownloadService) InitiateDataDownload(ctx context.Context, request *model.MarketDataDownloadRequest) (int, error) {
+// InitiateDataDownload queues a download job for historical data on behalf of a user
+func (s *MarketDataDownloadService) InitiateDataDownload(
+	ctx context.Context,
+	request *model.MarketDataDownloadRequest,
+	userID int,
+	quotaTier string,
+) (int, error) {
+	valid, err := s.timeframeRepo.ValidateTimeframe(ctx, request.Timeframe)
+	if err != nil {
+		return 0, err
+	}
+	if !valid {
+		return 0, fmt.Errorf("invalid timeframe: %s", request.Timeframe)
+	}
+
+	if (request.IncludeFundingRates || request.IncludeOpenInterest) && request.Source != string(model.SourceBinance) {
+		return 0, fmt.Errorf("unsupported data source for funding rates and open interest: %s", request.Source)
+	}
+
+	// Enforce the user's daily download and storage limits
+	estimatedCandles := estimateCandles(request.Timeframe, request.StartDate, request.EndDate)
+	if err := s.quotaService.CheckDownload(ctx, userID, quotaTier, int64(estimatedCandles)); err != nil {
+		return 0, err
+	}
+
 	// Check if the symbol already exists in our database
 	symbols, err := s.symbolRepo.GetAllSymbols(ctx, request.Symbol, "", "", "", "", 0, 0)
 	if err != nil {
@@ -175,11 +220,8 @@ func (s *MarketDataDownloadService) InitiateDataDownload(ctx context.Context, re
 	// If symbol doesn't exist in our database yet, we need to create it
 	if !foundSymbol {
 		if request.Source == string(model.SourceBinance) {
-			// Create a new Binance client
-			binanceClient := client.NewBinanceClient(s.logger)
-
 			// Get exchange info to get more details about the symbol
-			exchangeInfo, err := binanceClient.GetExchangeInfo(ctx)
+			exchangeInfo, err := s.binanceClient.GetExchangeInfo(ctx)
 			if err != nil {
 				return 0, err
 			}
@@ -246,18 +288,31 @@ func (s *MarketDataDownloadService) InitiateDataDownload(ctx context.Context, re
 		request.Timeframe,
 		request.StartDate,
 		request.EndDate,
+		userID,
+		estimatedCandles,
+		request.IncludeFundingRates,
+		request.IncludeOpenInterest,
 	)
 
 	if err != nil {
 		return 0, err
 	}
 
-	// Start the download process in a background goroutine
-	go s.processDownload(jobID, request.Symbol, symbolID, request.Source, request.Timeframe, request.StartDate, request.EndDate)
+	// The job waits in the queue until the worker pool has a free slot for its source
+	s.notifyQueued()
 
 	return jobID, nil
 }
 
+// notifyQueued wakes the worker pool without waiting for its next poll
+func (s *MarketDataDownloadService) notifyQueued() {
+	select {
+	case s.queued <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
 // GetDownloadStatus gets the status of a download job
 func (s *MarketDataDownloadService) GetDownloadStatus(ctx context.Context, jobID int) (*model.MarketDataDownloadStatus, error) {
 	job, err := s.downloadRepo.GetDownloadJob(ctx, jobID)
@@ -269,6 +324,11 @@ func (s *MarketDataDownloadService) GetDownloadStatus(ctx context.Context, jobID
 		return nil, nil
 	}
 
+	metrics, err := s.downloadRepo.GetDownloadJobMetrics(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &model.MarketDataDownloadStatus{
 		JobID:             job.ID,
 		Symbol:            job.Symbol,
@@ -284,9 +344,43 @@ func (s *MarketDataDownloadService) GetDownloadStatus(ctx context.Context, jobID
 		StartDate:         job.StartDate,
 		EndDate:           job.EndDate,
 		LastProcessedTime: job.LastProcessedTime,
+		Attempts:          job.Attempts,
+		NextAttemptAt:     job.NextAttemptAt,
+
+		IncludeFundingRates: job.IncludeFundingRates,
+		IncludeOpenInterest: job.IncludeOpenInterest,
+		Bandwidth:           downloadBandwidth(metrics),
 	}, nil
 }
 
+// downloadBandwidth summarizes a job's metrics, or returns nil if it has none yet
+func downloadBandwidth(metrics *model.DownloadJobMetrics) *model.DownloadBandwidth {
+	if metrics == nil {
+		return nil
+	}
+
+	bandwidth := &model.DownloadBandwidth{
+		Requests:          metrics.Requests,
+		RequestWeight:     metrics.RequestWeight,
+		BytesDownloaded:   metrics.BytesDownloaded,
+		CandlesDownloaded: metrics.CandlesDownloaded,
+		FetchSeconds:      float64(metrics.FetchMillis) / 1000,
+		ThrottledSeconds:  float64(metrics.ThrottledMillis) / 1000,
+		RateLimited:       metrics.RateLimited,
+	}
+	if bandwidth.FetchSeconds > 0 {
+		bandwidth.BytesPerSecond = float64(metrics.BytesDownloaded) / bandwidth.FetchSeconds
+		bandwidth.CandlesPerSecond = float64(metrics.CandlesDownloaded) / bandwidth.FetchSeconds
+	}
+
+	return bandwidth
+}
+
+// GetRateLimits reports the request weight budget of every provider
+func (s *MarketDataDownloadService) GetRateLimits() []model.RateBudgetStats {
+	return s.rateBudgets.Stats()
+}
+
 // GetActiveDownloads gets all active download jobs with pagination and sorting
 func (s *MarketDataDownloadService) GetActiveDownloads(
 	ctx context.Context,
@@ -301,8 +395,8 @@ func (s *MarketDataDownloadService) GetActiveDownloads(
 		sortBy = "created_at"
 	}
 
-	sortDirection = utils.NormalizeSortDirection(sortDirection)
-	offset := utils.CalculateOffset(page, limit)
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
+	offset := pagination.Offset(page, limit)
 
 	// Get total count for pagination
 	totalCount, err := s.downloadRepo.CountActiveDownloadJobs(ctx, source)
@@ -395,7 +489,7 @@ func (s *MarketDataDownloadService) GetDataInventory(
 		page = 1
 	}
 
-	offset := utils.CalculateOffset(page, limit)
+	offset := pagination.Offset(page, limit)
 
 	// Get total count for pagination
 	totalCount, err := s.inventoryRepo.CountDataInventory(ctx, assetType, exchange)
@@ -415,69 +509,68 @@ func (s *MarketDataDownloadService) GetDataInventory(
 		return nil, 0, err
 	}
 
+	// Add the funding rates and open interest stored for the symbols on the page
+	symbolIDs := make([]int, len(inventory))
+	for i, item := range inventory {
+		symbolIDs[i] = item.SymbolID
+	}
+	coverage, err := s.derivativesRepo.GetCoverage(ctx, symbolIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range inventory {
+		inventory[i].Derivatives = coverage[inventory[i].SymbolID]
+	}
+
 	return inventory, totalCount, nil
 }
 
-// processDownload processes a download job for market data
-func (s *MarketDataDownloadService) processDownload(
-	jobID int,
-	symbol string,
-	symbolID int,
-	source string,
-	timeframe string,
-	startDate time.Time,
-	endDate time.Time,
-) {
-	// Create a new context for this background process
-	ctx := context.Background()
-
-	// Update job status to in_progress
-	s.downloadRepo.UpdateDownloadJobStatus(
-		ctx,
-		jobID,
-		"in_progress",
-		0,
-		0,
-		0,
-		0,
-		"",
-	)
+// errDownloadInterrupted is returned when a download stops because ctx was cancelled
+var errDownloadInterrupted = errors.New("download interrupted")
 
+// processDownload runs a claimed download job, resuming from its last checkpoint.
+// It returns an error if the attempt failed and the job should be retried.
+func (s *MarketDataDownloadService) processDownload(ctx context.Context, job *model.MarketDataDownloadJob) error {
 	// Process the download based on the source
-	switch source {
+	switch job.Source {
 	case string(model.SourceBinance):
-		s.processBinanceDownload(jobID, symbol, symbolID, timeframe, startDate, endDate)
+		return s.processBinanceDownload(ctx, job)
 	// Add other sources as needed
 	default:
+		// Retrying won't help, fail the job outright
 		s.downloadRepo.UpdateDownloadJobStatus(
 			ctx,
-			jobID,
+			job.ID,
 			"failed",
 			0,
 			0,
 			0,
 			0,
-			fmt.Sprintf("Unsupported data source: %s", source),
+			fmt.Sprintf("Unsupported data source: %s", job.Source),
 		)
+		return nil
 	}
 }
 
 // processBinanceDownload handles downloading data from Binance
-func (s *MarketDataDownloadService) processBinanceDownload(
-	jobID int,
-	symbol string,
-	symbolID int,
-	timeframe string,
-	startDate time.Time,
-	endDate time.Time,
-) {
-	ctx := context.Background()
-
-	// Create a Binance client
-	binanceClient := client.NewBinanceClient(s.logger)
-
-	// Map our timeframe to Binance interval
+func (s *MarketDataDownloadService) processBinanceDownload(ctx context.Context, job *model.MarketDataDownloadJob) error {
+	jobID := job.ID
+	symbol := job.Symbol
+	symbolID := job.SymbolID
+	timeframe := job.Timeframe
+	startDate := job.StartDate
+	endDate := job.EndDate
+
+	// Map our timeframe to Binance interval; custom timeframes are downloaded at the
+	// provider interval from the timeframe catalog and aggregated when read
 	interval := client.MapTimeframeToBinanceInterval(timeframe)
+	if interval == "" {
+		var err error
+		interval, err = s.providerInterval(ctx, timeframe)
+		if err != nil {
+			return err
+		}
+	}
 	if interval == "" {
 		s.downloadRepo.UpdateDownloadJobStatus(
 			ctx,
@@ -489,31 +582,11 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 			0,
 			fmt.Sprintf("Invalid timeframe: %s", timeframe),
 		)
-		return
+		return nil
 	}
 
-	// Calculate minutes per candle based on timeframe
-	var minutesPerCandle int
-	switch timeframe {
-	case "1m":
-		minutesPerCandle = 1
-	case "5m":
-		minutesPerCandle = 5
-	case "15m":
-		minutesPerCandle = 15
-	case "30m":
-		minutesPerCandle = 30
-	case "1h":
-		minutesPerCandle = 60
-	case "4h":
-		minutesPerCandle = 240
-	case "1d":
-		minutesPerCandle = 1440
-	case "1w":
-		minutesPerCandle = 10080
-	default:
-		minutesPerCandle = 1
-	}
+	// Calculate minutes per candle based on the downloaded interval
+	minutesPerCandle := client.BinanceIntervalMinutes(interval)
 
 	// Calculate optimal chunk size to get close to 1000 candles per request
 	// Maximum is 1000 candles per request, let's aim for 900 to be safe
@@ -528,11 +601,16 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 		zap.Int("chunkMinutes", chunkMinutes),
 		zap.Duration("chunkDuration", chunkDuration))
 
-	// Calculate the total time range to download
-	totalDuration := endDate.Sub(startDate)
-
 	// Estimate total number of candles
-	totalCandlesEstimate := int(totalDuration.Minutes()) / minutesPerCandle
+	totalCandlesEstimate := estimateCandles(timeframe, startDate, endDate)
+
+	// Resume after the last chunk a previous attempt finished
+	currentStart := startDate
+	processedCandles := 0
+	if job.LastProcessedTime != nil && job.LastProcessedTime.After(startDate) {
+		currentStart = *job.LastProcessedTime
+		processedCandles = job.ProcessedCandles
+	}
 
 	s.logger.Info("Starting download job",
 		zap.Int("jobID", jobID),
@@ -540,6 +618,8 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 		zap.String("timeframe", timeframe),
 		zap.Time("startDate", startDate),
 		zap.Time("endDate", endDate),
+		zap.Time("resumeFrom", currentStart),
+		zap.Int("attempt", job.Attempts),
 		zap.Int("estimatedTotalCandles", totalCandlesEstimate))
 
 	// Update job with total candles estimate
@@ -547,38 +627,38 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 		ctx,
 		jobID,
 		"in_progress",
-		0,
-		0,
+		float64(processedCandles)/float64(totalCandlesEstimate)*100,
+		processedCandles,
 		totalCandlesEstimate,
 		0,
 		"",
 	)
 
 	// Process in chunks
-	currentStart := startDate
-	processedCandles := 0
 	totalDownloaded := 0
 	retryCount := 0
+	var importTotals model.CandleImportReport
 
 	// Keep track of consecutive empty chunks for early termination
 	emptyChunksInARow := 0
 	maxEmptyChunks := 5 // If we get 5 empty chunks in a row, we'll stop
 
 	for currentStart.Before(endDate) {
+		if ctx.Err() != nil {
+			return errDownloadInterrupted
+		}
+
 		// Check if job was cancelled
-		job, err := s.downloadRepo.GetDownloadJob(ctx, jobID)
+		current, err := s.downloadRepo.GetDownloadJob(ctx, jobID)
 		if err != nil {
-			s.logger.Error("Failed to check job status",
-				zap.Error(err),
-				zap.Int("jobID", jobID))
-			break
+			return fmt.Errorf("failed to check job status: %w", err)
 		}
 
-		if job.Status == "cancelled" {
+		if current == nil || current.Status == "cancelled" {
 			s.logger.Info("Job was cancelled",
 				zap.Int("jobID", jobID),
 				zap.String("symbol", symbol))
-			break
+			return nil
 		}
 
 		// Calculate chunk end time
@@ -597,9 +677,35 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 		// Calculate expected candles in this chunk
 		expectedCandlesInChunk := int(chunkEnd.Sub(currentStart).Minutes()) / minutesPerCandle
 
-		// Fetch klines for this chunk
-		klines, err := binanceClient.GetKlines(ctx, symbol, interval, &currentStart, &chunkEnd, 1000)
+		// Fetch klines for this chunk; the shared rate-limit budget paces the requests
+		klines, requestStats, err := s.binanceClient.GetKlines(ctx, symbol, interval, &currentStart, &chunkEnd, 1000)
+		var rateLimited *client.RateLimitError
+		rateLimitedErr := errors.As(err, &rateLimited)
+		if rateLimitedErr {
+			// The job sleeps until the limit lifts below
+			requestStats.Waited += rateLimited.RetryAfter
+		}
+		s.recordRequest(ctx, jobID, requestStats, len(klines), rateLimitedErr)
 		if err != nil {
+			if ctx.Err() != nil {
+				return errDownloadInterrupted
+			}
+
+			// Binance throttled us; every download is paused until it lifts, so this
+			// chunk is fetched again then without using up a retry
+			if rateLimitedErr {
+				s.logger.Warn("Binance rate limit hit, waiting before fetching the chunk again",
+					zap.Int("jobID", jobID),
+					zap.String("symbol", symbol),
+					zap.Int("statusCode", rateLimited.StatusCode),
+					zap.Duration("retryAfter", rateLimited.RetryAfter))
+
+				if !sleepContext(ctx, rateLimited.RetryAfter) {
+					return errDownloadInterrupted
+				}
+				continue
+			}
+
 			// Implement exponential backoff for retries
 			if retryCount < 5 {
 				retryCount++
@@ -622,21 +728,22 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 					fmt.Sprintf("Retry %d/5: %v", retryCount, err),
 				)
 
-				time.Sleep(backoffTime)
+				if !sleepContext(ctx, backoffTime) {
+					return errDownloadInterrupted
+				}
 				continue
 			}
 
-			// Max retries reached, fail this chunk but continue with next
-			s.logger.Error("Max retries reached for chunk, skipping to next chunk",
+			// Max retries reached, fail this attempt. The job is retried later from this chunk.
+			s.logger.Error("Max retries reached for chunk, failing attempt",
 				zap.Error(err),
 				zap.String("symbol", symbol),
 				zap.String("interval", interval),
 				zap.Time("chunkStart", currentStart),
 				zap.Time("chunkEnd", chunkEnd))
 
-			currentStart = chunkEnd
-			retryCount = 0
-			continue
+			return fmt.Errorf("failed to fetch klines from %s to %s: %w",
+				currentStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
 		}
 
 		// If no klines were returned but we haven't reached the end date
@@ -651,6 +758,7 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 
 			// Skip ahead by the chunk size
 			currentStart = chunkEnd
+			s.checkpoint(ctx, jobID, currentStart, processedCandles, totalCandlesEstimate, retryCount)
 
 			// Check if we've hit the limit for empty chunks
 			if emptyChunksInARow >= maxEmptyChunks {
@@ -699,8 +807,12 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 		}
 
 		// Import candles
-		importedCount, err := s.marketDataRepo.BatchImportCandles(ctx, candles)
+		report, err := s.marketDataRepo.BatchImportCandles(ctx, candles)
 		if err != nil {
+			if ctx.Err() != nil {
+				return errDownloadInterrupted
+			}
+
 			// Log in detail
 			s.logger.Error("Failed to import candles",
 				zap.Error(err),
@@ -709,25 +821,36 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 				zap.Time("chunkEnd", chunkEnd),
 				zap.Int("candlesInBatch", len(candles)))
 
-			// Try next chunk
-			currentStart = chunkEnd
-			continue
+			// Fail the attempt; the retry resumes from this chunk
+			return fmt.Errorf("failed to import candles from %s to %s: %w",
+				currentStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+
+		// Account for every candle the database saw, including ones that were
+		// already present, so overlapping chunks don't skew progress
+		processedCandles += report.Received
+		importTotals.Add(*report)
+
+		// Cached reads over the imported chunk are now stale
+		if report.Stored() > 0 {
+			s.candleCache.InvalidateImported(candles)
 		}
 
 		s.logger.Info("Imported candles",
-			zap.Int("importedCount", importedCount),
+			zap.Int("inserted", report.Inserted),
+			zap.Int("updated", report.Updated),
+			zap.Int("skipped", report.Skipped),
 			zap.String("symbol", symbol),
 			zap.String("interval", interval),
 			zap.Time("start", currentStart),
 			zap.Time("end", chunkEnd),
 			zap.Int("totalDownloadedSoFar", totalDownloaded),
-			zap.Int("totalImportedSoFar", processedCandles+importedCount))
+			zap.Int("totalImportedSoFar", importTotals.Stored()))
 
 		// Reset retry counter on success
 		retryCount = 0
 
 		// Update progress
-		processedCandles += importedCount
 		progress := float64(processedCandles) / float64(totalCandlesEstimate) * 100
 
 		// Cap progress at 99% until completely done
@@ -735,23 +858,28 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 			progress = 99.0
 		}
 
-		// Update progress in database
-		s.downloadRepo.UpdateDownloadJobStatus(
-			ctx,
-			jobID,
-			"in_progress",
-			progress,
-			processedCandles,
-			totalCandlesEstimate,
-			retryCount,
-			"",
-		)
-
-		// Move to the next chunk
+		// Move to the next chunk and record it, so a restart resumes from here
 		currentStart = chunkEnd
+		if !s.checkpointProgress(ctx, jobID, currentStart, progress, processedCandles, totalCandlesEstimate, retryCount) {
+			s.logger.Info("Job is no longer running, stopping",
+				zap.Int("jobID", jobID),
+				zap.String("symbol", symbol))
+			return nil
+		}
+	}
 
-		// Sleep to avoid rate limiting
-		time.Sleep(300 * time.Millisecond)
+	// Funding rates and open interest are fetched once the candles are in; a retried
+	// attempt skips straight here since the candles are checkpointed
+	derivativesNote := ""
+	if job.IncludeFundingRates || job.IncludeOpenInterest {
+		var err error
+		derivativesNote, err = s.downloadDerivatives(ctx, job)
+		if err != nil {
+			if errors.Is(err, errDownloadInterrupted) {
+				return err
+			}
+			return fmt.Errorf("failed to download funding rates and open interest: %w", err)
+		}
 	}
 
 	// Calculate final progress percentage
@@ -770,14 +898,24 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 			processedCandles,
 			totalCandlesEstimate,
 			retryCount,
-			"",
+			derivativesNote,
 		)
 		s.logger.Info("Download job completed successfully",
 			zap.Int("jobID", jobID),
 			zap.String("symbol", symbol),
 			zap.Int("processedCandles", processedCandles),
+			zap.Int("inserted", importTotals.Inserted),
+			zap.Int("updated", importTotals.Updated),
+			zap.Int("skipped", importTotals.Skipped),
 			zap.Int("totalCandlesEstimate", totalCandlesEstimate))
 	} else {
+		message := fmt.Sprintf("Download completed with some gaps. Processed %d of ~%d candles (%.1f%%): %d inserted, %d updated, %d skipped",
+			processedCandles, totalCandlesEstimate, finalProgress,
+			importTotals.Inserted, importTotals.Updated, importTotals.Skipped)
+		if derivativesNote != "" {
+			message += ". " + derivativesNote
+		}
+
 		s.downloadRepo.UpdateDownloadJobStatus(
 			ctx,
 			jobID,
@@ -786,8 +924,7 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 			processedCandles,
 			totalCandlesEstimate,
 			retryCount,
-			fmt.Sprintf("Download completed with some gaps. Imported %d of ~%d candles (%.1f%%)",
-				processedCandles, totalCandlesEstimate, finalProgress),
+			message,
 		)
 		s.logger.Info("Download job completed partially",
 			zap.Int("jobID", jobID),
@@ -798,7 +935,119 @@ func (s *MarketDataDownloadService) processBinanceDownload(
 	}
 
 	// Update symbol data availability flag if we imported any data
-	if processedCandles > 0 {
+	if importTotals.Stored() > 0 || processedCandles > 0 {
 		s.symbolRepo.UpdateDataAvailability(ctx, symbolID, true)
 	}
+
+	return nil
+}
+
+// recordRequest adds the cost of a provider request to the job's bandwidth metrics.
+// Failing to record them doesn't stop the download.
+func (s *MarketDataDownloadService) recordRequest(
+	ctx context.Context,
+	jobID int,
+	stats client.RequestStats,
+	candles int,
+	rateLimited bool,
+) {
+	metrics := &model.DownloadJobMetrics{
+		JobID:             jobID,
+		Requests:          1,
+		RequestWeight:     stats.Weight,
+		BytesDownloaded:   stats.Bytes,
+		CandlesDownloaded: int64(candles),
+		FetchMillis:       stats.Duration.Milliseconds(),
+		ThrottledMillis:   stats.Waited.Milliseconds(),
+	}
+	if rateLimited {
+		metrics.RateLimited = 1
+	}
+
+	s.downloadRepo.RecordDownloadJobMetrics(context.WithoutCancel(ctx), metrics)
+}
+
+// checkpoint records the resume point after a chunk that didn't change progress
+func (s *MarketDataDownloadService) checkpoint(ctx context.Context, jobID int, processedUntil time.Time, processedCandles, totalCandles, retries int) {
+	progress := math.Min(float64(processedCandles)/float64(totalCandles)*100, 99)
+	s.checkpointProgress(ctx, jobID, processedUntil, progress, processedCandles, totalCandles, retries)
+}
+
+// checkpointProgress records the resume point and progress of a job.
+// Returns false if the job is no longer running, e.g. it was cancelled.
+func (s *MarketDataDownloadService) checkpointProgress(
+	ctx context.Context,
+	jobID int,
+	processedUntil time.Time,
+	progress float64,
+	processedCandles int,
+	totalCandles int,
+	retries int,
+) bool {
+	running, err := s.downloadRepo.CheckpointDownloadJob(ctx, jobID, processedUntil, progress, processedCandles, totalCandles, retries)
+	if err != nil {
+		// Keep going; the next checkpoint will catch up
+		return true
+	}
+	return running
+}
+
+// sleepContext sleeps for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// timeframeMinutes returns the length of one candle in minutes
+func timeframeMinutes(timeframe string) int {
+	switch timeframe {
+	case "1m":
+		return 1
+	case "5m":
+		return 5
+	case "15m":
+		return 15
+	case "30m":
+		return 30
+	case "1h":
+		return 60
+	case "4h":
+		return 240
+	case "1d":
+		return 1440
+	case "1w":
+		return 10080
+	default:
+		// Custom timeframes are named after their length
+		if minutes, err := utils.ParseTimeframe(timeframe); err == nil {
+			return minutes
+		}
+		return 1
+	}
+}
+
+// providerInterval returns the provider interval a custom timeframe is downloaded at,
+// or "" if the timeframe isn't in the catalog
+func (s *MarketDataDownloadService) providerInterval(ctx context.Context, timeframe string) (string, error) {
+	catalogEntry, err := s.timeframeRepo.GetTimeframe(ctx, timeframe)
+	if err != nil {
+		return "", err
+	}
+	if catalogEntry == nil || catalogEntry.ProviderInterval == nil {
+		return "", nil
+	}
+	return *catalogEntry.ProviderInterval, nil
+}
+
+// estimateCandles estimates how many candles a date range holds, at least one
+func estimateCandles(timeframe string, startDate, endDate time.Time) int {
+	estimate := int(endDate.Sub(startDate).Minutes()) / timeframeMinutes(timeframe)
+	if estimate < 1 {
+		estimate = 1
+	}
+	return estimate
 }
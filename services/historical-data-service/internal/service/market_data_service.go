@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
+	"services/historical-data-service/internal/utils"
+	"services/shared/pagination"
 
 	"go.uber.org/zap"
 )
@@ -15,18 +19,22 @@ import (
 type MarketDataService struct {
 	marketDataRepo *repository.MarketDataRepository
 	symbolRepo     *repository.SymbolRepository
+	candleCache    *CandleCache
 	logger         *zap.Logger
 }
 
-// NewMarketDataService creates a new market data service
+// NewMarketDataService creates a new market data service. Candle reads are served from
+// candleCache when it holds them; a nil cache reads every request from the database.
 func NewMarketDataService(
 	marketDataRepo *repository.MarketDataRepository,
 	symbolRepo *repository.SymbolRepository,
+	candleCache *CandleCache,
 	logger *zap.Logger,
 ) *MarketDataService {
 	return &MarketDataService{
 		marketDataRepo: marketDataRepo,
 		symbolRepo:     symbolRepo,
+		candleCache:    candleCache,
 		logger:         logger,
 	}
 }
@@ -40,7 +48,7 @@ func (s *MarketDataService) GetCandles(
 ) ([]model.Candle, int, error) {
 	// Validate inputs
 	if query.SymbolID <= 0 {
-		return nil, 0, errors.New("invalid symbol ID")
+		return nil, 0, errcode.ErrInvalidSymbolID
 	}
 
 	if query.Timeframe == "" {
@@ -48,32 +56,15 @@ func (s *MarketDataService) GetCandles(
 	}
 
 	// Calculate offset
-	offset := (page - 1) * limit
-	offsetPtr := &offset
+	offset := pagination.Offset(page, limit)
 
 	// Get total count for pagination
-	total, err := s.marketDataRepo.CountCandles(
-		ctx,
-		query.SymbolID,
-		query.Timeframe,
-		query.StartDate,
-		query.EndDate,
-	)
+	total, err := s.countCandles(ctx, query.SymbolID, query.Timeframe, query.StartDate, query.EndDate)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Call repository function with pagination
-	candles, err := s.marketDataRepo.GetCandles(
-		ctx,
-		query.SymbolID,
-		query.Timeframe,
-		query.StartDate,
-		query.EndDate,
-		&limit,
-		offsetPtr,
-	)
-
+	candles, err := s.getCandles(ctx, query.SymbolID, query.Timeframe, query.StartDate, query.EndDate, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -81,19 +72,106 @@ func (s *MarketDataService) GetCandles(
 	return candles, total, nil
 }
 
+// GetCandlesAfter retrieves the page of candles older than a cursor. Candles are returned
+// newest first, so each page ends just before the time of the last candle of the previous
+// page. This avoids counting and skipping rows, which gets slow for deep pages.
+func (s *MarketDataService) GetCandlesAfter(
+	ctx context.Context,
+	query *model.MarketDataQuery,
+	after *model.CandleCursor,
+	limit int,
+) ([]model.Candle, *model.CandleCursor, error) {
+	// Validate inputs
+	if query.SymbolID <= 0 {
+		return nil, nil, errcode.ErrInvalidSymbolID
+	}
+
+	if query.Timeframe == "" {
+		return nil, nil, errors.New("timeframe is required")
+	}
+
+	endDate := query.EndDate
+	if after != nil {
+		// Candle times have microsecond precision in the database
+		before := after.Time.Add(-time.Microsecond)
+		if endDate == nil || before.Before(*endDate) {
+			endDate = &before
+		}
+	}
+
+	// Fetch one extra candle to learn whether there is another page
+	candles, err := s.getCandles(ctx, query.SymbolID, query.Timeframe, query.StartDate, endDate, limit+1, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(candles) <= limit {
+		return candles, nil, nil
+	}
+
+	candles = candles[:limit]
+	return candles, &model.CandleCursor{Time: candles[len(candles)-1].Time}, nil
+}
+
+// getCandles reads a page of candles through the candle cache
+func (s *MarketDataService) getCandles(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+	startDate, endDate *time.Time,
+	limit, offset int,
+) ([]model.Candle, error) {
+	if candles, ok := s.candleCache.GetCandles(symbolID, timeframe, startDate, endDate, limit, offset); ok {
+		return candles, nil
+	}
+
+	candles, err := s.marketDataRepo.GetCandles(ctx, symbolID, timeframe, startDate, endDate, &limit, &offset)
+	if err != nil {
+		return nil, err
+	}
+
+	s.candleCache.SetCandles(symbolID, timeframe, startDate, endDate, limit, offset, candles)
+	return candles, nil
+}
+
+// countCandles counts the candles in a range through the candle cache
+func (s *MarketDataService) countCandles(
+	ctx context.Context,
+	symbolID int,
+	timeframe string,
+	startDate, endDate *time.Time,
+) (int, error) {
+	if total, ok := s.candleCache.GetCount(symbolID, timeframe, startDate, endDate); ok {
+		return total, nil
+	}
+
+	total, err := s.marketDataRepo.CountCandles(ctx, symbolID, timeframe, startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	s.candleCache.SetCount(symbolID, timeframe, startDate, endDate, total)
+	return total, nil
+}
+
 // BatchImportCandles handles batch importing of candle data
 func (s *MarketDataService) BatchImportCandles(
 	ctx context.Context,
 	candles []model.CandleBatch,
-) (int, error) {
+) (*model.CandleImportReport, error) {
 	if len(candles) == 0 {
-		return 0, errors.New("no candle data provided")
+		return nil, errors.New("no candle data provided")
 	}
 
 	// Call repository function
-	insertedCount, err := s.marketDataRepo.BatchImportCandles(ctx, candles)
+	report, err := s.marketDataRepo.BatchImportCandles(ctx, candles)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	// Cached reads over the imported range are now stale
+	if report.Stored() > 0 {
+		s.candleCache.InvalidateImported(candles)
 	}
 
 	// Update symbol data availability for all unique symbols
@@ -107,7 +185,7 @@ func (s *MarketDataService) BatchImportCandles(
 		s.symbolRepo.UpdateDataAvailability(ctx, symbolID, true)
 	}
 
-	return insertedCount, nil
+	return report, nil
 }
 
 // GetDataAvailabilityRange gets the date range for which data is available
@@ -118,7 +196,7 @@ func (s *MarketDataService) GetDataAvailabilityRange(
 ) (*time.Time, *time.Time, error) {
 	// Validate inputs
 	if symbolID <= 0 {
-		return nil, nil, errors.New("invalid symbol ID")
+		return nil, nil, errcode.ErrInvalidSymbolID
 	}
 
 	if timeframe == "" {
@@ -144,6 +222,135 @@ func (s *MarketDataService) GetDataAvailabilityRange(
 	return &startDate, &endDate, nil
 }
 
+// PreviewData summarizes the candles a backtest over the query's date range would use:
+// how many there are, where the stored data starts and ends, the gaps in it and a
+// series downsampled to at most query.Points buckets for charting
+func (s *MarketDataService) PreviewData(ctx context.Context, query *model.DataPreviewQuery) (*model.DataPreview, error) {
+	if query.SymbolID <= 0 {
+		return nil, errcode.ErrInvalidSymbolID
+	}
+
+	timeframeMinutes, err := utils.ParseTimeframe(query.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	if !query.Start.Before(query.End) {
+		return nil, errors.New("invalid date range: start must be before end")
+	}
+
+	symbol, err := s.symbolRepo.GetSymbolByID(ctx, query.SymbolID)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return nil, errcode.ErrSymbolNotFound
+	}
+
+	count, err := s.marketDataRepo.CountCandles(ctx, query.SymbolID, query.Timeframe, &query.Start, &query.End)
+	if err != nil {
+		return nil, err
+	}
+
+	first, last, err := s.marketDataRepo.GetCandleBounds(ctx, query.SymbolID, query.Start, query.End)
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := s.marketDataRepo.CalculateMissingDataRanges(ctx, query.SymbolID, query.Timeframe, query.Start, query.End)
+	if err != nil {
+		return nil, err
+	}
+
+	// The missing ranges are computed against all stored data, keep the parts inside the range
+	gaps := []model.DateRange{}
+	for _, gap := range missing {
+		if gap.End.Before(query.Start) || gap.Start.After(query.End) {
+			continue
+		}
+		if gap.Start.Before(query.Start) {
+			gap.Start = query.Start
+		}
+		if gap.End.After(query.End) {
+			gap.End = query.End
+		}
+		gaps = append(gaps, gap)
+	}
+
+	// Buckets are never shorter than the timeframe itself
+	rangeMinutes := int(math.Ceil(query.End.Sub(query.Start).Minutes()))
+	bucketMinutes := (rangeMinutes + query.Points - 1) / query.Points
+	if bucketMinutes < timeframeMinutes {
+		bucketMinutes = timeframeMinutes
+	}
+
+	series, err := s.marketDataRepo.GetCandlePreview(ctx, query.SymbolID, query.Start, query.End, bucketMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DataPreview{
+		SymbolID:      query.SymbolID,
+		Timeframe:     query.Timeframe,
+		Start:         query.Start,
+		End:           query.End,
+		CandleCount:   count,
+		FirstCandle:   first,
+		LastCandle:    last,
+		Gaps:          gaps,
+		BucketMinutes: bucketMinutes,
+		Series:        series,
+	}, nil
+}
+
+// DeleteCandleRange deletes a symbol's candles in a range widened to whole candles of the
+// query's timeframe, or reports what a dry run would delete. Candles are stored once for
+// all timeframes, so the range is deleted from every timeframe read from them.
+func (s *MarketDataService) DeleteCandleRange(
+	ctx context.Context,
+	query *model.CandleDeletionQuery,
+	userID int,
+) (*model.CandleDeletion, error) {
+	if query.SymbolID <= 0 {
+		return nil, errcode.ErrInvalidSymbolID
+	}
+
+	timeframeMinutes, err := utils.ParseTimeframe(query.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.End.Before(query.Start) {
+		return nil, errors.New("invalid date range: end must not be before start")
+	}
+
+	deletion, err := s.marketDataRepo.DeleteCandleRange(ctx, query, timeframeMinutes, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !query.DryRun {
+		s.logger.Info("Deleted market data range",
+			zap.Int("adminID", userID),
+			zap.Int("symbolID", query.SymbolID),
+			zap.Time("start", deletion.Start),
+			zap.Time("end", deletion.End),
+			zap.Int64("candles", deletion.CandleCount),
+			zap.String("reason", query.Reason))
+
+		if deletion.CandleCount > 0 {
+			s.candleCache.Invalidate(query.SymbolID, deletion.Start, deletion.End)
+		}
+	}
+
+	return deletion, nil
+}
+
+// GetCandleCacheStats returns the candle cache's hit, miss and size counters
+func (s *MarketDataService) GetCandleCacheStats() model.CandleCacheStats {
+	return s.candleCache.Stats()
+}
+
 // GetAssetTypes retrieves all available asset types
 func (s *MarketDataService) GetAssetTypes(ctx context.Context) (interface{}, error) {
 	return s.symbolRepo.GetAssetTypes(ctx)
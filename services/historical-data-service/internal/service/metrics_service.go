@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// MetricsService maintains and serves the pre-aggregated daily metrics rollups
+type MetricsService struct {
+	metricsRepo *repository.MetricsRepository
+	logger      *zap.Logger
+}
+
+// NewMetricsService creates a new metrics service
+func NewMetricsService(metricsRepo *repository.MetricsRepository, logger *zap.Logger) *MetricsService {
+	return &MetricsService{
+		metricsRepo: metricsRepo,
+		logger:      logger,
+	}
+}
+
+// RefreshDay recomputes the rollups for a single day. Safe to re-run.
+func (s *MetricsService) RefreshDay(ctx context.Context, day time.Time) (*model.DailyMetricsRefreshResult, error) {
+	return s.metricsRepo.RefreshDailyMetrics(ctx, day)
+}
+
+// Backfill recomputes the rollups for every day in [startDate, endDate], one day
+// per transaction so a long backfill can be interrupted and resumed
+func (s *MetricsService) Backfill(ctx context.Context, startDate, endDate time.Time) ([]model.DailyMetricsRefreshResult, error) {
+	start := startOfDay(startDate)
+	end := startOfDay(endDate)
+
+	if end.Before(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	var results []model.DailyMetricsRefreshResult
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		result, err := s.metricsRepo.RefreshDailyMetrics(ctx, day)
+		if err != nil {
+			return results, err
+		}
+
+		s.logger.Info("Aggregated daily metrics",
+			zap.String("date", day.Format("2006-01-02")),
+			zap.Int("userRows", result.UserRows),
+			zap.Int("strategyRows", result.StrategyRows),
+			zap.Int("symbolRows", result.SymbolRows))
+
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// RunNightly aggregates completed days once a day at the given UTC hour until ctx
+// is cancelled. On each run it catches up on any days missed since the last
+// aggregation (bounded by maxCatchUpDays) and always recomputes yesterday so late
+// arriving results are picked up.
+func (s *MetricsService) RunNightly(ctx context.Context, runHour int, maxCatchUpDays int) {
+	s.logger.Info("Starting nightly metrics aggregation",
+		zap.Int("runHourUTC", runHour),
+		zap.Int("maxCatchUpDays", maxCatchUpDays))
+
+	// Catch up immediately on startup
+	s.aggregatePendingDays(ctx, maxCatchUpDays)
+
+	for {
+		wait := time.Until(nextRunTime(time.Now(), runHour))
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping nightly metrics aggregation")
+			return
+		case <-time.After(wait):
+			s.aggregatePendingDays(ctx, maxCatchUpDays)
+		}
+	}
+}
+
+// aggregatePendingDays refreshes every day from the last aggregated day through yesterday
+func (s *MetricsService) aggregatePendingDays(ctx context.Context, maxCatchUpDays int) {
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	earliest := yesterday.AddDate(0, 0, -(maxCatchUpDays - 1))
+
+	start := yesterday
+	lastDate, err := s.metricsRepo.GetLastAggregatedDate(ctx)
+	if err != nil {
+		s.logger.Error("Failed to determine last aggregated day", zap.Error(err))
+	} else if lastDate != nil && lastDate.Before(yesterday) {
+		// Re-run the last aggregated day too, it may have been computed before it ended
+		start = *lastDate
+	}
+
+	if start.Before(earliest) {
+		s.logger.Warn("Metrics aggregation is behind, older days need a manual backfill",
+			zap.String("skippedUntil", earliest.Format("2006-01-02")))
+		start = earliest
+	}
+
+	if _, err := s.Backfill(ctx, start, yesterday); err != nil {
+		s.logger.Error("Nightly metrics aggregation failed", zap.Error(err))
+	}
+}
+
+// GetUserDailyMetrics retrieves daily rollups for a user
+func (s *MetricsService) GetUserDailyMetrics(ctx context.Context, userID int, startDate, endDate time.Time) ([]model.DailyUserMetrics, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	return s.metricsRepo.GetUserDailyMetrics(ctx, userID, startDate, endDate)
+}
+
+// GetStrategyDailyMetrics retrieves daily rollups for a strategy
+func (s *MetricsService) GetStrategyDailyMetrics(ctx context.Context, strategyID int, startDate, endDate time.Time) ([]model.DailyStrategyMetrics, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	return s.metricsRepo.GetStrategyDailyMetrics(ctx, strategyID, startDate, endDate)
+}
+
+// GetSymbolDailyMetrics retrieves daily rollups for a symbol
+func (s *MetricsService) GetSymbolDailyMetrics(ctx context.Context, symbolID int, startDate, endDate time.Time) ([]model.DailySymbolMetrics, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	return s.metricsRepo.GetSymbolDailyMetrics(ctx, symbolID, startDate, endDate)
+}
+
+// startOfDay returns midnight UTC of the given time's day
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nextRunTime returns the next occurrence of runHour:00 UTC after now
+func nextRunTime(now time.Time, runHour int) time.Time {
+	next := startOfDay(now).Add(time.Duration(runHour) * time.Hour)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
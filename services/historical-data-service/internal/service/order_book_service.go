@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Bounds of the delay before reconnecting to the depth stream, doubled after each failure
+const (
+	minDepthStreamBackoff = time.Second
+	maxDepthStreamBackoff = time.Minute
+)
+
+// OrderBookCaptureOptions configures order book snapshot capture
+type OrderBookCaptureOptions struct {
+	Symbols       []string
+	Depth         int
+	Interval      time.Duration
+	Retention     time.Duration
+	PruneInterval time.Duration
+}
+
+// OrderBookService captures periodic snapshots of the top of symbols' order books from the
+// provider's depth stream, and serves the stored snapshots
+type OrderBookService struct {
+	orderBookRepo *repository.OrderBookRepository
+	symbolRepo    *repository.SymbolRepository
+	stream        *client.BinanceDepthStream
+	logger        *zap.Logger
+
+	mu     sync.Mutex
+	latest map[string]client.DepthUpdate // The last book pushed per symbol
+}
+
+// NewOrderBookService creates a new order book service
+func NewOrderBookService(
+	orderBookRepo *repository.OrderBookRepository,
+	symbolRepo *repository.SymbolRepository,
+	stream *client.BinanceDepthStream,
+	logger *zap.Logger,
+) *OrderBookService {
+	return &OrderBookService{
+		orderBookRepo: orderBookRepo,
+		symbolRepo:    symbolRepo,
+		stream:        stream,
+		logger:        logger,
+		latest:        make(map[string]client.DepthUpdate),
+	}
+}
+
+// GetSnapshots returns up to limit order book snapshots of a symbol (ticker or ID) in a
+// time range, oldest first
+func (s *OrderBookService) GetSnapshots(
+	ctx context.Context,
+	symbolRef string,
+	startTime, endTime *time.Time,
+	limit int,
+) ([]model.OrderBookSnapshot, error) {
+	symbol, err := s.resolveSymbol(ctx, symbolRef)
+	if err != nil {
+		return nil, err
+	}
+	if startTime != nil && endTime != nil && endTime.Before(*startTime) {
+		return nil, fmt.Errorf("invalid date range: end_date is before start_date")
+	}
+
+	return s.orderBookRepo.GetSnapshots(ctx, symbol.ID, startTime, endTime, limit)
+}
+
+// RunCapture streams the order books of the configured symbols and stores a snapshot of
+// each every interval until ctx is cancelled, deleting snapshots past the retention as it
+// goes. Books not pushed since the last snapshot, such as while the stream reconnects, are
+// skipped rather than stored again.
+func (s *OrderBookService) RunCapture(ctx context.Context, opts OrderBookCaptureOptions) {
+	if !slices.Contains(model.OrderBookDepths, opts.Depth) {
+		s.logger.Error("Order book capture not started: depth must be one of 5, 10 or 20", zap.Int("depth", opts.Depth))
+		return
+	}
+	if opts.Interval < time.Second {
+		opts.Interval = time.Second
+	}
+
+	symbolIDs := s.captureSymbolIDs(ctx, opts.Symbols)
+	if len(symbolIDs) == 0 {
+		s.logger.Warn("Order book capture not started: no known symbols configured")
+		return
+	}
+
+	symbols := make([]string, 0, len(symbolIDs))
+	for symbol := range symbolIDs {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	s.logger.Info("Starting order book capture",
+		zap.Strings("symbols", symbols),
+		zap.Int("depth", opts.Depth),
+		zap.Duration("interval", opts.Interval),
+		zap.Duration("retention", opts.Retention))
+
+	go s.runStream(ctx, symbols, opts.Depth)
+
+	snapshotTicker := time.NewTicker(opts.Interval)
+	defer snapshotTicker.Stop()
+
+	var prune <-chan time.Time
+	if opts.Retention > 0 {
+		if opts.PruneInterval <= 0 {
+			opts.PruneInterval = time.Hour
+		}
+		pruneTicker := time.NewTicker(opts.PruneInterval)
+		defer pruneTicker.Stop()
+		prune = pruneTicker.C
+	}
+
+	since := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping order book capture")
+			return
+		case now := <-snapshotTicker.C:
+			s.storeSnapshots(ctx, symbolIDs, since, now)
+			since = now
+		case now := <-prune:
+			deleted, err := s.orderBookRepo.PruneSnapshots(ctx, now.Add(-opts.Retention))
+			if err != nil {
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Info("Pruned order book snapshots", zap.Int("deleted", deleted))
+			}
+		}
+	}
+}
+
+// runStream keeps the depth stream connected until ctx is cancelled, reconnecting with
+// a growing delay while it fails
+func (s *OrderBookService) runStream(ctx context.Context, symbols []string, depth int) {
+	backoff := minDepthStreamBackoff
+	for {
+		connected := time.Now()
+		err := s.stream.Stream(ctx, symbols, depth, s.recordUpdate)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that held for a while failed on its own, not from a bad state
+		if time.Since(connected) > maxDepthStreamBackoff {
+			backoff = minDepthStreamBackoff
+		}
+		s.logger.Warn("Depth stream disconnected, reconnecting",
+			zap.Error(err),
+			zap.Duration("retryAfter", backoff))
+
+		if !sleepContext(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, maxDepthStreamBackoff)
+	}
+}
+
+// recordUpdate keeps the latest book pushed for a symbol
+func (s *OrderBookService) recordUpdate(update client.DepthUpdate) {
+	s.mu.Lock()
+	s.latest[update.Symbol] = update
+	s.mu.Unlock()
+}
+
+// storeSnapshots stores the books pushed since the last snapshot, at the snapshot time
+func (s *OrderBookService) storeSnapshots(ctx context.Context, symbolIDs map[string]int, since, now time.Time) {
+	snapshotTime := now.UTC().Truncate(time.Second)
+
+	s.mu.Lock()
+	snapshots := make([]model.OrderBookSnapshot, 0, len(s.latest))
+	for symbol, update := range s.latest {
+		if update.ReceivedAt.Before(since) {
+			continue
+		}
+		snapshots = append(snapshots, model.OrderBookSnapshot{
+			SymbolID:     symbolIDs[symbol],
+			SnapshotTime: snapshotTime,
+			LastUpdateID: update.LastUpdateID,
+			Bids:         update.Bids,
+			Asks:         update.Asks,
+		})
+	}
+	s.mu.Unlock()
+
+	if _, err := s.orderBookRepo.SaveSnapshots(ctx, snapshots); err != nil {
+		s.logger.Warn("Failed to store order book snapshots", zap.Error(err), zap.Time("snapshotTime", snapshotTime))
+	}
+}
+
+// captureSymbolIDs resolves the configured tickers to symbol IDs. Unknown tickers are
+// logged and left out.
+func (s *OrderBookService) captureSymbolIDs(ctx context.Context, tickers []string) map[string]int {
+	symbolIDs := make(map[string]int, len(tickers))
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" {
+			continue
+		}
+
+		symbol, err := s.symbolRepo.GetSymbolByName(ctx, ticker)
+		if err != nil || symbol == nil {
+			s.logger.Warn("Skipping unknown symbol for order book capture", zap.String("symbol", ticker), zap.Error(err))
+			continue
+		}
+		symbolIDs[ticker] = symbol.ID
+	}
+	return symbolIDs
+}
+
+// resolveSymbol looks a symbol up by ID if symbolRef is numeric, otherwise by ticker
+func (s *OrderBookService) resolveSymbol(ctx context.Context, symbolRef string) (*model.Symbol, error) {
+	var symbol *model.Symbol
+	var err error
+	if id, convErr := strconv.Atoi(symbolRef); convErr == nil {
+		symbol, err = s.symbolRepo.GetSymbolByID(ctx, id)
+	} else {
+		symbol, err = s.symbolRepo.GetSymbolByName(ctx, symbolRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return nil, errcode.ErrSymbolNotFound
+	}
+	return symbol, nil
+}
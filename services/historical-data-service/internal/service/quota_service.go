@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"services/historical-data-service/internal/config"
+	"services/historical-data-service/internal/middleware"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// QuotaExceededError is returned when a request would take a user over one of their quotas
+type QuotaExceededError struct {
+	Quota string
+	Tier  string
+	Limit int64
+	Used  int64
+	// ResetsAt is set for daily quotas
+	ResetsAt *time.Time
+}
+
+// Error implements the error interface
+func (e *QuotaExceededError) Error() string {
+	switch e.Quota {
+	case model.QuotaConcurrentBacktests:
+		return fmt.Sprintf("concurrent backtest limit reached: %d of %d running on the %s tier; wait for a backtest to finish",
+			e.Used, e.Limit, e.Tier)
+	case model.QuotaBacktestsPerDay:
+		return fmt.Sprintf("daily backtest limit reached: %d of %d used on the %s tier",
+			e.Used, e.Limit, e.Tier)
+	case model.QuotaDownloadJobsPerDay:
+		return fmt.Sprintf("daily download limit reached: %d of %d used on the %s tier",
+			e.Used, e.Limit, e.Tier)
+	case model.QuotaStoredCandles:
+		return fmt.Sprintf("storage limit reached: this download would exceed the %d candles allowed on the %s tier (%d stored or queued)",
+			e.Limit, e.Tier, e.Used)
+	default:
+		return fmt.Sprintf("%s quota exceeded: %d of %d used", e.Quota, e.Used, e.Limit)
+	}
+}
+
+// QuotaService enforces per-user limits on backtests, downloads and stored candles
+type QuotaService struct {
+	quotaRepo *repository.QuotaRepository
+	config    config.QuotasConfig
+	logger    *zap.Logger
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(
+	quotaRepo *repository.QuotaRepository,
+	quotasConfig config.QuotasConfig,
+	logger *zap.Logger,
+) *QuotaService {
+	// Tier names are matched against roles case-insensitively
+	tiers := make(map[string]config.QuotaTierConfig, len(quotasConfig.Tiers))
+	for name, tier := range quotasConfig.Tiers {
+		tiers[strings.ToLower(name)] = tier
+	}
+	quotasConfig.Tiers = tiers
+	quotasConfig.DefaultTier = strings.ToLower(quotasConfig.DefaultTier)
+
+	return &QuotaService{
+		quotaRepo: quotaRepo,
+		config:    quotasConfig,
+		logger:    logger,
+	}
+}
+
+// TierFor returns the quota tier of a user: the tier named after their role, otherwise
+// the first tier (by name) whose permission they hold, otherwise the default tier
+func (s *QuotaService) TierFor(role string, permissions []string) string {
+	if _, ok := s.config.Tiers[strings.ToLower(role)]; ok {
+		return strings.ToLower(role)
+	}
+
+	names := make([]string, 0, len(s.config.Tiers))
+	for name := range s.config.Tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		permission := s.config.Tiers[name].Permission
+		if permission != "" && middleware.HasPermission(permissions, permission) {
+			return name
+		}
+	}
+
+	return s.config.DefaultTier
+}
+
+// CheckBacktest returns a QuotaExceededError if the user can't start another backtest
+func (s *QuotaService) CheckBacktest(ctx context.Context, userID int, tier string) error {
+	limits, ok := s.limits(tier)
+	if !ok {
+		return nil
+	}
+
+	dayStart, resetsAt := quotaDay(time.Now())
+	usage, err := s.quotaRepo.GetUserQuotaUsage(ctx, userID, dayStart)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxConcurrentBacktests > 0 && usage.ConcurrentBacktests >= limits.MaxConcurrentBacktests {
+		return &QuotaExceededError{
+			Quota: model.QuotaConcurrentBacktests,
+			Tier:  tier,
+			Limit: int64(limits.MaxConcurrentBacktests),
+			Used:  int64(usage.ConcurrentBacktests),
+		}
+	}
+
+	if limits.MaxBacktestsPerDay > 0 && usage.BacktestsToday >= limits.MaxBacktestsPerDay {
+		return &QuotaExceededError{
+			Quota:    model.QuotaBacktestsPerDay,
+			Tier:     tier,
+			Limit:    int64(limits.MaxBacktestsPerDay),
+			Used:     int64(usage.BacktestsToday),
+			ResetsAt: &resetsAt,
+		}
+	}
+
+	return nil
+}
+
+// CheckDownload returns a QuotaExceededError if the user can't queue a download of
+// estimatedCandles more candles
+func (s *QuotaService) CheckDownload(ctx context.Context, userID int, tier string, estimatedCandles int64) error {
+	limits, ok := s.limits(tier)
+	if !ok {
+		return nil
+	}
+
+	dayStart, resetsAt := quotaDay(time.Now())
+	usage, err := s.quotaRepo.GetUserQuotaUsage(ctx, userID, dayStart)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxDownloadJobsPerDay > 0 && usage.DownloadJobsToday >= limits.MaxDownloadJobsPerDay {
+		return &QuotaExceededError{
+			Quota:    model.QuotaDownloadJobsPerDay,
+			Tier:     tier,
+			Limit:    int64(limits.MaxDownloadJobsPerDay),
+			Used:     int64(usage.DownloadJobsToday),
+			ResetsAt: &resetsAt,
+		}
+	}
+
+	// Queued and running downloads count at their estimated size
+	committed := usage.StoredCandles + usage.ReservedCandles
+	if limits.MaxStoredCandles > 0 && committed+estimatedCandles > limits.MaxStoredCandles {
+		return &QuotaExceededError{
+			Quota: model.QuotaStoredCandles,
+			Tier:  tier,
+			Limit: limits.MaxStoredCandles,
+			Used:  committed,
+		}
+	}
+
+	return nil
+}
+
+// GetUserQuotas returns the user's tier with the limit and usage of each quota
+func (s *QuotaService) GetUserQuotas(ctx context.Context, userID int, tier string) (*model.UserQuotas, error) {
+	dayStart, resetsAt := quotaDay(time.Now())
+	usage, err := s.quotaRepo.GetUserQuotaUsage(ctx, userID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, enforced := s.limits(tier)
+
+	return &model.UserQuotas{
+		Tier:    tier,
+		Enabled: s.config.Enabled,
+		Quotas: []model.Quota{
+			newQuota(model.QuotaConcurrentBacktests, int64(limits.MaxConcurrentBacktests), int64(usage.ConcurrentBacktests), enforced, nil),
+			newQuota(model.QuotaBacktestsPerDay, int64(limits.MaxBacktestsPerDay), int64(usage.BacktestsToday), enforced, &resetsAt),
+			newQuota(model.QuotaDownloadJobsPerDay, int64(limits.MaxDownloadJobsPerDay), int64(usage.DownloadJobsToday), enforced, &resetsAt),
+			newQuota(model.QuotaStoredCandles, limits.MaxStoredCandles, usage.StoredCandles+usage.ReservedCandles, enforced, nil),
+		},
+	}, nil
+}
+
+// limits returns the limits of a tier, and false if no limits are enforced for it
+func (s *QuotaService) limits(tier string) (config.QuotaTierConfig, bool) {
+	if !s.config.Enabled {
+		return config.QuotaTierConfig{}, false
+	}
+
+	limits, ok := s.config.Tiers[tier]
+	return limits, ok
+}
+
+// newQuota builds a quota entry; a zero or unenforced limit is reported as unlimited
+func newQuota(name string, limit, used int64, enforced bool, resetsAt *time.Time) model.Quota {
+	quota := model.Quota{
+		Name:     name,
+		Used:     used,
+		ResetsAt: resetsAt,
+	}
+
+	if enforced && limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		quota.Limit = &limit
+		quota.Remaining = &remaining
+	}
+
+	return quota
+}
+
+// quotaDay returns the start of the current UTC day and when daily quotas next reset
+func quotaDay(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"services/historical-data-service/internal/analytics"
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxRegimeCandles bounds how much of the most recent history is classified
+const maxRegimeCandles = 20000
+
+// RegimeService classifies candle history into market regimes and serves the stored segments
+type RegimeService struct {
+	regimeRepo     *repository.RegimeRepository
+	backtestRepo   *repository.BacktestRepository
+	marketDataRepo *repository.MarketDataRepository
+	symbolRepo     *repository.SymbolRepository
+	timeframeRepo  *repository.TimeframeRepository
+	logger         *zap.Logger
+}
+
+// NewRegimeService creates a new regime service
+func NewRegimeService(
+	regimeRepo *repository.RegimeRepository,
+	backtestRepo *repository.BacktestRepository,
+	marketDataRepo *repository.MarketDataRepository,
+	symbolRepo *repository.SymbolRepository,
+	timeframeRepo *repository.TimeframeRepository,
+	logger *zap.Logger,
+) *RegimeService {
+	return &RegimeService{
+		regimeRepo:     regimeRepo,
+		backtestRepo:   backtestRepo,
+		marketDataRepo: marketDataRepo,
+		symbolRepo:     symbolRepo,
+		timeframeRepo:  timeframeRepo,
+		logger:         logger,
+	}
+}
+
+// GetRegimes returns the regime segments of a symbol (ticker or ID) and timeframe that overlap
+// a time range. The history is classified first if it never was or new candles have arrived.
+func (s *RegimeService) GetRegimes(
+	ctx context.Context,
+	symbolRef string,
+	timeframe string,
+	startTime *time.Time,
+	endTime *time.Time,
+) (*model.MarketRegimes, error) {
+	symbol, err := s.resolveSymbol(ctx, symbolRef)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return nil, errcode.ErrSymbolNotFound
+	}
+
+	valid, err := s.timeframeRepo.ValidateTimeframe(ctx, timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid timeframe")
+	}
+
+	classifiedAt, lastEnd, err := s.regimeRepo.GetLastClassification(ctx, symbol.ID, timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	stale, err := s.hasNewCandles(ctx, symbol.ID, timeframe, lastEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		if err := s.ClassifyRegimes(ctx, symbol.ID, timeframe); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		classifiedAt = &now
+	}
+
+	segments, err := s.regimeRepo.GetRegimes(ctx, symbol.ID, timeframe, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MarketRegimes{
+		SymbolID:     symbol.ID,
+		Symbol:       symbol.Symbol,
+		Timeframe:    timeframe,
+		ClassifiedAt: classifiedAt,
+		Segments:     segments,
+	}, nil
+}
+
+// GetBacktestRunBreakdown returns the performance of a backtest run broken down by the
+// regime segments of its symbol that overlap the test period
+func (s *RegimeService) GetBacktestRunBreakdown(ctx context.Context, runID int) (*model.RegimeBreakdown, error) {
+	run, err := s.backtestRepo.GetBacktestRunInfo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errcode.ErrBacktestRunNotFound
+	}
+
+	regimes, err := s.GetRegimes(ctx, strconv.Itoa(run.SymbolID), run.Timeframe, &run.StartDate, &run.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := s.backtestRepo.GetClosedBacktestTrades(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, byRegime, unclassified := analytics.BreakdownByRegime(trades, regimes.Segments, run.InitialCapital)
+
+	return &model.RegimeBreakdown{
+		BacktestRunID:  run.BacktestRunID,
+		SymbolID:       run.SymbolID,
+		Timeframe:      run.Timeframe,
+		StartDate:      run.StartDate,
+		EndDate:        run.EndDate,
+		InitialCapital: run.InitialCapital,
+		Segments:       segments,
+		Regimes:        byRegime,
+		Unclassified:   unclassified,
+	}, nil
+}
+
+// ClassifyRegimes classifies the recent candle history of a symbol and timeframe and replaces
+// its stored regime segments
+func (s *RegimeService) ClassifyRegimes(ctx context.Context, symbolID int, timeframe string) error {
+	start := time.Unix(0, 0)
+	end := time.Now()
+	limit := maxRegimeCandles
+	offset := 0
+
+	// Candles come newest first
+	candles, err := s.marketDataRepo.GetCandles(ctx, symbolID, timeframe, &start, &end, &limit, &offset)
+	if err != nil {
+		return err
+	}
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+
+	segments := analytics.ClassifyRegimes(candles, analytics.DefaultRegimeParams())
+
+	s.logger.Info("Classified market regimes",
+		zap.Int("symbolID", symbolID),
+		zap.String("timeframe", timeframe),
+		zap.Int("candles", len(candles)),
+		zap.Int("segments", len(segments)))
+
+	return s.regimeRepo.ReplaceRegimes(ctx, symbolID, timeframe, segments)
+}
+
+// hasNewCandles reports whether there are candles after the end of the last classified segment
+func (s *RegimeService) hasNewCandles(ctx context.Context, symbolID int, timeframe string, lastEnd *time.Time) (bool, error) {
+	if lastEnd == nil {
+		return true, nil
+	}
+
+	start := time.Unix(0, 0)
+	end := time.Now()
+	limit := 1
+	candles, err := s.marketDataRepo.GetCandles(ctx, symbolID, timeframe, &start, &end, &limit, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return len(candles) > 0 && candles[0].Time.After(*lastEnd), nil
+}
+
+// resolveSymbol looks a symbol up by ID if symbolRef is numeric, otherwise by ticker
+func (s *RegimeService) resolveSymbol(ctx context.Context, symbolRef string) (*model.Symbol, error) {
+	if id, err := strconv.Atoi(symbolRef); err == nil {
+		return s.symbolRepo.GetSymbolByID(ctx, id)
+	}
+	return s.symbolRepo.GetSymbolByName(ctx, symbolRef)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// statsWindows are the time windows admin statistics can be requested for
+var statsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// IsValidStatsWindow reports whether window is a supported statistics window
+func IsValidStatsWindow(window string) bool {
+	_, ok := statsWindows[window]
+	return ok
+}
+
+// dataStatsEntry is a cached statistics result
+type dataStatsEntry struct {
+	stats     *model.DataStats
+	expiresAt time.Time
+}
+
+// StatsService computes admin dashboard statistics, caching results briefly since the
+// aggregation queries scan whole tables
+type StatsService struct {
+	statsRepo *repository.StatsRepository
+	cacheTTL  time.Duration
+	logger    *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]dataStatsEntry
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(statsRepo *repository.StatsRepository, cacheTTL time.Duration, logger *zap.Logger) *StatsService {
+	return &StatsService{
+		statsRepo: statsRepo,
+		cacheTTL:  cacheTTL,
+		logger:    logger,
+		cache:     make(map[string]dataStatsEntry),
+	}
+}
+
+// GetDataStats returns backtest, download and inventory KPIs for a window such as "7d"
+func (s *StatsService) GetDataStats(ctx context.Context, window string) (*model.DataStats, error) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	entry, ok := s.cache[window]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.stats, nil
+	}
+
+	from := now.Add(-statsWindows[window])
+	stats, err := s.statsRepo.GetDataStats(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Window = window
+	stats.From = from
+	stats.To = now
+	stats.BacktestFailureRate = failureRate(stats.BacktestsFailed, stats.BacktestsCompleted)
+	stats.DownloadFailureRate = failureRate(stats.DownloadJobsFailed, stats.DownloadJobsCompleted)
+	stats.GeneratedAt = now
+
+	if s.cacheTTL > 0 {
+		s.mu.Lock()
+		s.cache[window] = dataStatsEntry{stats: stats, expiresAt: now.Add(s.cacheTTL)}
+		s.mu.Unlock()
+	}
+
+	return stats, nil
+}
+
+// failureRate returns the share of finished jobs that failed, rounded to four places
+func failureRate(failed, completed int64) float64 {
+	finished := failed + completed
+	if finished == 0 {
+		return 0
+	}
+	return math.Round(float64(failed)/float64(finished)*10000) / 10000
+}
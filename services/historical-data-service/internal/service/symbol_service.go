@@ -4,9 +4,10 @@ import (
 	"context"
 	"errors"
 
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
-	"services/historical-data-service/internal/utils"
+	"services/shared/pagination"
 
 	"go.uber.org/zap"
 )
@@ -57,10 +58,10 @@ func (s *SymbolService) GetSymbolsWithPagination(
 	}
 
 	// Normalize sort direction
-	sortDirection = utils.NormalizeSortDirection(sortDirection)
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
 
 	// Calculate offset
-	offset := utils.CalculateOffset(page, limit)
+	offset := pagination.Offset(page, limit)
 
 	// Get total count for pagination
 	total, err := s.symbolRepo.CountSymbols(ctx, searchTerm, assetType, exchange)
@@ -108,7 +109,7 @@ func (s *SymbolService) GetSymbolByID(ctx context.Context, id int) (*model.Symbo
 	}
 
 	if symbol == nil {
-		return nil, errors.New("symbol not found")
+		return nil, errcode.ErrSymbolNotFound
 	}
 
 	return symbol, nil
@@ -133,7 +134,7 @@ func (s *SymbolService) CreateSymbol(ctx context.Context, symbol *model.Symbol)
 func (s *SymbolService) UpdateSymbol(ctx context.Context, symbol *model.Symbol) (bool, error) {
 	// Validate symbol
 	if symbol.ID <= 0 {
-		return false, errors.New("invalid symbol ID")
+		return false, errcode.ErrInvalidSymbolID
 	}
 
 	// Check if symbol exists
@@ -143,7 +144,7 @@ func (s *SymbolService) UpdateSymbol(ctx context.Context, symbol *model.Symbol)
 	}
 
 	if existingSymbol == nil {
-		return false, errors.New("symbol not found")
+		return false, errcode.ErrSymbolNotFound
 	}
 
 	// Update symbol using the database function via repository
@@ -154,7 +155,7 @@ func (s *SymbolService) UpdateSymbol(ctx context.Context, symbol *model.Symbol)
 func (s *SymbolService) DeleteSymbol(ctx context.Context, id int) (bool, error) {
 	// Validate ID
 	if id <= 0 {
-		return false, errors.New("invalid symbol ID")
+		return false, errcode.ErrInvalidSymbolID
 	}
 
 	// Check if symbol exists
@@ -164,7 +165,7 @@ func (s *SymbolService) DeleteSymbol(ctx context.Context, id int) (bool, error)
 	}
 
 	if existingSymbol == nil {
-		return false, errors.New("symbol not found")
+		return false, errcode.ErrSymbolNotFound
 	}
 
 	// Delete symbol using the database function via repository
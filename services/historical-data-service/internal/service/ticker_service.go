@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// tickerSymbolPattern matches the symbols tickers can be requested for, such as BTCUSDT
+var tickerSymbolPattern = regexp.MustCompile(`^[A-Z0-9]{2,20}$`)
+
+// LiveTickerSource serves tickers from a live market data stream. Ticker reports false
+// for symbols it has no fresh ticker of.
+type LiveTickerSource interface {
+	Ticker(symbol string) (model.Ticker, bool)
+}
+
+// tickerEntry is a cached ticker
+type tickerEntry struct {
+	ticker    model.Ticker
+	expiresAt time.Time
+}
+
+// TickerService serves the latest prices and 24h changes of symbols for dashboards and
+// watchlists. Tickers come from the live stream when one is set and has the symbol, and
+// otherwise from the provider's REST API, cached briefly so polling clients share requests.
+type TickerService struct {
+	binanceClient *client.BinanceClient
+	cacheTTL      time.Duration
+	maxSymbols    int
+	logger        *zap.Logger
+
+	mu    sync.Mutex
+	live  LiveTickerSource
+	cache map[string]tickerEntry
+}
+
+// NewTickerService creates a new ticker service
+func NewTickerService(binanceClient *client.BinanceClient, cacheTTL time.Duration, maxSymbols int, logger *zap.Logger) *TickerService {
+	if maxSymbols <= 0 || maxSymbols > client.MaxTickerSymbols {
+		maxSymbols = client.MaxTickerSymbols
+	}
+	return &TickerService{
+		binanceClient: binanceClient,
+		cacheTTL:      cacheTTL,
+		maxSymbols:    maxSymbols,
+		logger:        logger,
+		cache:         make(map[string]tickerEntry),
+	}
+}
+
+// SetLiveSource sets the live stream tickers are served from ahead of the REST API, or
+// nil to serve them from the REST API only
+func (s *TickerService) SetLiveSource(live LiveTickerSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live = live
+}
+
+// ParseSymbols parses a comma separated list of symbols, upper-casing them and dropping
+// duplicates
+func (s *TickerService) ParseSymbols(list string) ([]string, error) {
+	seen := make(map[string]bool)
+	symbols := []string{}
+	for _, part := range strings.Split(list, ",") {
+		symbol := strings.ToUpper(strings.TrimSpace(part))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		if !tickerSymbolPattern.MatchString(symbol) {
+			return nil, fmt.Errorf("invalid symbol %q", symbol)
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbols is required")
+	}
+	if len(symbols) > s.maxSymbols {
+		return nil, fmt.Errorf("at most %d symbols can be requested at once", s.maxSymbols)
+	}
+	return symbols, nil
+}
+
+// GetTickers returns the tickers of symbols in the order requested. Symbols the live
+// stream and cache can't serve are fetched in one REST request.
+func (s *TickerService) GetTickers(ctx context.Context, symbols []string) ([]model.Ticker, error) {
+	now := time.Now()
+	found := make(map[string]model.Ticker, len(symbols))
+	var missing []string
+
+	s.mu.Lock()
+	for _, symbol := range symbols {
+		if s.live != nil {
+			if ticker, ok := s.live.Ticker(symbol); ok {
+				ticker.Source = model.TickerSourceLive
+				found[symbol] = ticker
+				continue
+			}
+		}
+		if entry, ok := s.cache[symbol]; ok && now.Before(entry.expiresAt) {
+			found[symbol] = entry.ticker
+			continue
+		}
+		missing = append(missing, symbol)
+	}
+	s.mu.Unlock()
+
+	if len(missing) > 0 {
+		fetched, err := s.binanceClient.GetTickers(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		for _, ticker := range fetched {
+			found[ticker.Symbol] = ticker
+			if s.cacheTTL > 0 {
+				s.cache[ticker.Symbol] = tickerEntry{ticker: ticker, expiresAt: now.Add(s.cacheTTL)}
+			}
+		}
+		s.evictExpired(now)
+		s.mu.Unlock()
+	}
+
+	tickers := make([]model.Ticker, 0, len(symbols))
+	for _, symbol := range symbols {
+		if ticker, ok := found[symbol]; ok {
+			tickers = append(tickers, ticker)
+		}
+	}
+	return tickers, nil
+}
+
+// evictExpired drops expired tickers so symbols nobody polls anymore don't accumulate.
+// The caller holds s.mu.
+func (s *TickerService) evictExpired(now time.Time) {
+	for symbol, entry := range s.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.cache, symbol)
+		}
+	}
+}
@@ -2,13 +2,22 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"services/historical-data-service/internal/client"
+	"services/historical-data-service/internal/errcode"
 	"services/historical-data-service/internal/model"
 	"services/historical-data-service/internal/repository"
+	"services/historical-data-service/internal/utils"
 
 	"go.uber.org/zap"
 )
 
+// maxTimeframeMinutes is the longest custom timeframe, four weeks
+const maxTimeframeMinutes = 4 * 10080
+
 // TimeframeService handles timeframe operations
 type TimeframeService struct {
 	timeframeRepo *repository.TimeframeRepository
@@ -24,11 +33,148 @@ func NewTimeframeService(timeframeRepo *repository.TimeframeRepository, logger *
 }
 
 // GetAllTimeframes retrieves all available timeframes
-func (s *TimeframeService) GetAllTimeframes(ctx context.Context) ([]model.Timeframe, error) {
-	return s.timeframeRepo.GetAllTimeframes(ctx)
+func (s *TimeframeService) GetAllTimeframes(ctx context.Context, includeInactive bool) ([]model.Timeframe, error) {
+	return s.timeframeRepo.GetAllTimeframes(ctx, includeInactive)
 }
 
 // ValidateTimeframe checks if a timeframe is valid
 func (s *TimeframeService) ValidateTimeframe(ctx context.Context, timeframe string) (bool, error) {
 	return s.timeframeRepo.ValidateTimeframe(ctx, timeframe)
 }
+
+// CreateTimeframe adds a custom timeframe such as 2h or 12h. Without a provider interval the
+// longest provider interval the timeframe can be aggregated from is used.
+func (s *TimeframeService) CreateTimeframe(ctx context.Context, request *model.TimeframeCreateRequest) (*model.Timeframe, error) {
+	name := strings.ToLower(strings.TrimSpace(request.Name))
+	minutes, err := utils.ParseTimeframe(name)
+	if err != nil {
+		return nil, err
+	}
+	if minutes > maxTimeframeMinutes {
+		return nil, fmt.Errorf("invalid timeframe %q: longer than 4 weeks", name)
+	}
+
+	providerInterval, err := resolveProviderInterval(request.ProviderInterval, minutes)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := strings.TrimSpace(request.DisplayName)
+	if displayName == "" {
+		displayName = timeframeDisplayName(name)
+	}
+
+	existing, err := s.timeframeRepo.GetTimeframe(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errcode.ErrTimeframeExists.WithMessage(fmt.Sprintf("timeframe %s already exists", name))
+	}
+
+	created, err := s.timeframeRepo.CreateTimeframe(ctx, name, displayName, minutes, providerInterval)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errcode.ErrTimeframeExists.WithMessage(fmt.Sprintf("timeframe %s already exists", name))
+	}
+
+	s.logger.Info("Created custom timeframe",
+		zap.String("timeframe", name),
+		zap.Int("minutes", minutes),
+		zap.String("providerInterval", providerInterval))
+
+	return s.timeframeRepo.GetTimeframe(ctx, name)
+}
+
+// UpdateTimeframe updates the display name, provider interval or active flag of a timeframe.
+// Only custom timeframes can be deactivated.
+func (s *TimeframeService) UpdateTimeframe(
+	ctx context.Context,
+	name string,
+	request *model.TimeframeUpdateRequest,
+) (*model.Timeframe, error) {
+	timeframe, err := s.timeframeRepo.GetTimeframe(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if timeframe == nil {
+		return nil, errcode.ErrTimeframeNotFound
+	}
+
+	if request.IsActive != nil && !*request.IsActive && !timeframe.IsCustom {
+		return nil, errors.New("invalid request: standard timeframes can't be deactivated")
+	}
+	if request.ProviderInterval != nil {
+		interval, err := resolveProviderInterval(request.ProviderInterval, timeframe.Minutes)
+		if err != nil {
+			return nil, err
+		}
+		request.ProviderInterval = &interval
+	}
+	if request.DisplayName != nil {
+		displayName := strings.TrimSpace(*request.DisplayName)
+		if displayName == "" {
+			return nil, errors.New("invalid request: display_name can't be empty")
+		}
+		request.DisplayName = &displayName
+	}
+
+	if _, err := s.timeframeRepo.UpdateTimeframe(ctx, name, request); err != nil {
+		return nil, err
+	}
+
+	return s.timeframeRepo.GetTimeframe(ctx, name)
+}
+
+// DeleteTimeframe deletes a custom timeframe that no backtest or download uses
+func (s *TimeframeService) DeleteTimeframe(ctx context.Context, name string) error {
+	timeframe, err := s.timeframeRepo.GetTimeframe(ctx, name)
+	if err != nil {
+		return err
+	}
+	if timeframe == nil {
+		return errcode.ErrTimeframeNotFound
+	}
+	if !timeframe.IsCustom {
+		return errors.New("invalid request: standard timeframes can't be deleted")
+	}
+
+	_, err = s.timeframeRepo.DeleteTimeframe(ctx, name)
+	return err
+}
+
+// resolveProviderInterval validates a requested provider interval against a timeframe length,
+// or picks one when none is requested
+func resolveProviderInterval(requested *string, minutes int) (string, error) {
+	if requested == nil || *requested == "" {
+		interval := client.BinanceIntervalForMinutes(minutes)
+		if interval == "" {
+			return "", fmt.Errorf("invalid timeframe: no provider interval fits %d minutes", minutes)
+		}
+		return interval, nil
+	}
+
+	interval := *requested
+	length := client.BinanceIntervalMinutes(interval)
+	if length == 0 {
+		return "", fmt.Errorf("invalid provider_interval %q", interval)
+	}
+	if minutes%length != 0 {
+		return "", fmt.Errorf("invalid provider_interval %q: candles of %d minutes can't be built from it", interval, minutes)
+	}
+	return interval, nil
+}
+
+// timeframeDisplayName names a timeframe such as 12h as "12 Hours"
+func timeframeDisplayName(name string) string {
+	units := map[byte]string{'m': "Minute", 'h': "Hour", 'd': "Day", 'w': "Week"}
+
+	count := name[:len(name)-1]
+	unit := units[name[len(name)-1]]
+	if count != "1" {
+		unit += "s"
+	}
+	return count + " " + unit
+}
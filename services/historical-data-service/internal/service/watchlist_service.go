@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"services/historical-data-service/internal/errcode"
+	"services/historical-data-service/internal/model"
+	"services/historical-data-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WatchlistService handles user watchlists of symbols
+type WatchlistService struct {
+	watchlistRepo *repository.WatchlistRepository
+	logger        *zap.Logger
+}
+
+// NewWatchlistService creates a new watchlist service
+func NewWatchlistService(watchlistRepo *repository.WatchlistRepository, logger *zap.Logger) *WatchlistService {
+	return &WatchlistService{
+		watchlistRepo: watchlistRepo,
+		logger:        logger,
+	}
+}
+
+// GetWatchlists retrieves the watchlists of a user with their symbols
+func (s *WatchlistService) GetWatchlists(ctx context.Context, userID int) ([]model.Watchlist, error) {
+	return s.watchlistRepo.GetWatchlists(ctx, userID)
+}
+
+// GetWatchlist retrieves a watchlist of a user with its symbols
+func (s *WatchlistService) GetWatchlist(ctx context.Context, id, userID int) (*model.Watchlist, error) {
+	watchlist, err := s.watchlistRepo.GetWatchlist(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if watchlist == nil {
+		return nil, errcode.ErrWatchlistNotFound
+	}
+
+	return watchlist, nil
+}
+
+// CreateWatchlist creates a watchlist for a user
+func (s *WatchlistService) CreateWatchlist(ctx context.Context, userID int, create *model.WatchlistCreate) (*model.Watchlist, error) {
+	create.Name = strings.TrimSpace(create.Name)
+	if create.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	id, err := s.watchlistRepo.CreateWatchlist(ctx, userID, create)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWatchlist(ctx, id, userID)
+}
+
+// UpdateWatchlist updates a watchlist of a user; symbol IDs replace its symbols
+func (s *WatchlistService) UpdateWatchlist(ctx context.Context, id, userID int, update *model.WatchlistUpdate) (*model.Watchlist, error) {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, errors.New("name is required")
+		}
+		update.Name = &name
+	}
+
+	updated, err := s.watchlistRepo.UpdateWatchlist(ctx, id, userID, update)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errcode.ErrWatchlistNotFound
+	}
+
+	return s.GetWatchlist(ctx, id, userID)
+}
+
+// DeleteWatchlist deletes a watchlist of a user
+func (s *WatchlistService) DeleteWatchlist(ctx context.Context, id, userID int) error {
+	deleted, err := s.watchlistRepo.DeleteWatchlist(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errcode.ErrWatchlistNotFound
+	}
+
+	return nil
+}
+
+// GetWatchlistQuotes quotes the symbols of a user's watchlist from stored candles
+func (s *WatchlistService) GetWatchlistQuotes(ctx context.Context, id, userID int) ([]model.WatchlistQuote, error) {
+	if _, err := s.GetWatchlist(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.watchlistRepo.GetWatchlistQuotes(ctx, id)
+}
+
+// GetWatchlistSymbolIDs returns the IDs of the symbols in a user's watchlist, in their
+// listed order. It fails if the watchlist is empty.
+func (s *WatchlistService) GetWatchlistSymbolIDs(ctx context.Context, id, userID int) ([]int, error) {
+	watchlist, err := s.GetWatchlist(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(watchlist.Symbols) == 0 {
+		return nil, errors.New("invalid watchlist: it has no symbols")
+	}
+
+	symbolIDs := make([]int, len(watchlist.Symbols))
+	for i, symbol := range watchlist.Symbols {
+		symbolIDs[i] = symbol.SymbolID
+	}
+
+	return symbolIDs, nil
+}
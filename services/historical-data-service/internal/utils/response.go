@@ -0,0 +1,12 @@
+package utils
+
+import (
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendErrorResponse sends a standardized error response
+func SendErrorResponse(c *gin.Context, statusCode int, message string) {
+	apierror.Send(c, statusCode, apierror.CodeForStatus(statusCode), message)
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// timeframeUnitMinutes is the length in minutes of each timeframe unit
+var timeframeUnitMinutes = map[byte]int{
+	'm': 1,
+	'h': 60,
+	'd': 1440,
+	'w': 10080,
+}
+
+// ParseTimeframe returns the length in minutes of a timeframe written as a count followed
+// by a unit (m, h, d or w), e.g. 15m, 2h or 3d
+func ParseTimeframe(name string) (int, error) {
+	if len(name) < 2 {
+		return 0, fmt.Errorf("invalid timeframe %q", name)
+	}
+
+	unit, ok := timeframeUnitMinutes[name[len(name)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid timeframe %q: unit must be m, h, d or w", name)
+	}
+
+	count, err := strconv.Atoi(name[:len(name)-1])
+	if err != nil || count < 1 || count > 100000 || name[0] == '0' || name[0] == '+' {
+		return 0, fmt.Errorf("invalid timeframe %q", name)
+	}
+
+	return count * unit, nil
+}
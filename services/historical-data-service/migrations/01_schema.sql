@@ -1,5 +1,7 @@
 -- Historical Data Service Database Schema
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create timeframe_type enum
 CREATE TYPE "timeframe_type" AS ENUM (
   '1m', '5m', '15m', '30m', '1h', '4h', '1d', '1w'
@@ -112,4 +114,5 @@ CREATE TABLE IF NOT EXISTS "market_data_download_jobs" (
   "created_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
   "updated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
   "last_processed_time" timestamptz
-);
\ No newline at end of file
+);
+-- +goose StatementEnd
@@ -1,4 +1,7 @@
 -- Indexes
+
+-- +goose Up
+-- +goose StatementBegin
 CREATE INDEX "idx_backtest_trades_backtest_run_id" ON "backtest_trades" ("backtest_run_id");
 CREATE INDEX "idx_backtests_user_id" ON "backtests" ("user_id");
 CREATE INDEX "idx_backtests_strategy_id" ON "backtests" ("strategy_id");
@@ -21,4 +24,5 @@ ALTER TABLE "backtest_trades" ADD FOREIGN KEY ("symbol_id") REFERENCES "symbols"
 ALTER TABLE "market_data_download_jobs" ADD FOREIGN KEY ("symbol_id") REFERENCES "symbols" ("id") ON DELETE CASCADE;
 
 -- Convert candles to hypertable for time series optimization
-SELECT create_hypertable('candles', 'candle_time', chunk_time_interval => INTERVAL '1 week');
\ No newline at end of file
+SELECT create_hypertable('candles', 'candle_time', chunk_time_interval => INTERVAL '1 week');
+-- +goose StatementEnd
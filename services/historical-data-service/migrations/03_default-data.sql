@@ -1,4 +1,7 @@
 -- Insert default symbols
+
+-- +goose Up
+-- +goose StatementBegin
 INSERT INTO symbols (symbol, name, asset_type, exchange, is_active, created_at)
 VALUES 
 ('BTCUSD', 'Bitcoin/US Dollar', 'crypto', 'Coinbase', true, CURRENT_TIMESTAMP),
@@ -8,4 +11,5 @@ VALUES
 ('AMZN', 'Amazon.com, Inc.', 'stock', 'NASDAQ', true, CURRENT_TIMESTAMP),
 ('BTCUSDT', 'Bitcoin/USDT', 'crypto', 'Binance', true, CURRENT_TIMESTAMP),
 ('ETHUSDT', 'Ethereum/USDT', 'crypto', 'Binance', true, CURRENT_TIMESTAMP)
-ON CONFLICT (symbol) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (symbol) DO NOTHING;
+-- +goose StatementEnd
@@ -2,6 +2,8 @@
 -- MARKET DATA (CANDLES) FUNCTIONS
 -- ==========================================
 
+-- +goose Up
+-- +goose StatementBegin
 -- Fixed get_candles function with proper interval casting
 CREATE OR REPLACE FUNCTION get_candles(
     p_symbol_id INT,
@@ -182,4 +184,5 @@ BEGIN
     GROUP BY group_id
     ORDER BY start_date;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
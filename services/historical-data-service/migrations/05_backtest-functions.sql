@@ -2,6 +2,8 @@
 -- BACKTESTING FUNCTIONS AND VIEWS
 -- ==========================================
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create a view to display backtest summary per the UI mockup
 CREATE OR REPLACE VIEW v_backtest_summary AS
 SELECT 
@@ -617,4 +619,5 @@ BEGIN
         CASE WHEN p_sort_by = 'completed_at' AND p_sort_direction = 'DESC' THEN br.completed_at END DESC
     LIMIT p_limit OFFSET p_offset;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
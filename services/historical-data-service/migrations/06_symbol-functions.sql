@@ -1,4 +1,7 @@
 -- Get all symbols
+
+-- +goose Up
+-- +goose StatementBegin
 CREATE OR REPLACE FUNCTION get_symbols(
     p_search_term VARCHAR DEFAULT NULL,
     p_asset_type VARCHAR DEFAULT NULL,
@@ -272,4 +275,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -2,6 +2,8 @@
 -- DOWNLOAD JOB FUNCTIONS
 -- ==========================================
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create a new market data download job
 CREATE OR REPLACE FUNCTION create_market_data_download_job(
     p_symbol_id INT,
@@ -380,4 +382,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -2,6 +2,8 @@
 -- INVENTORY FUNCTIONS
 -- ==========================================
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get data inventory with pagination and filtering
 CREATE OR REPLACE FUNCTION get_data_inventory(
     p_asset_type VARCHAR DEFAULT NULL,
@@ -142,4 +144,5 @@ BEGIN
     
     RETURN count_value;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
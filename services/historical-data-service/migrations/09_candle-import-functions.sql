@@ -0,0 +1,73 @@
+-- ==========================================
+-- CANDLE IMPORT FUNCTIONS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Merge candles staged in the session-local candles_import table into candles.
+-- The staging table is created and filled (via COPY) by the caller inside the
+-- same transaction, e.g.:
+--   CREATE TEMP TABLE candles_import (LIKE candles) ON COMMIT DROP;
+--   COPY candles_import FROM STDIN;
+--   SELECT * FROM merge_candle_import();
+--
+-- Candles are keyed by (symbol_id, candle_time); timeframes are derived at read
+-- time by get_candles, so there is no timeframe column in the conflict target.
+-- Re-importing an identical chunk is a no-op and is reported as skipped.
+CREATE OR REPLACE FUNCTION merge_candle_import()
+RETURNS TABLE (
+    received_count INT,
+    inserted_count INT,
+    updated_count INT,
+    skipped_count INT
+) AS $$
+DECLARE
+    v_received INT;
+    v_inserted INT;
+    v_updated INT;
+BEGIN
+    SELECT COUNT(*) INTO v_received FROM candles_import;
+
+    -- Keep the last staged row per key so duplicates inside a batch don't
+    -- trip "ON CONFLICT DO UPDATE command cannot affect row a second time"
+    CREATE TEMP TABLE candles_import_dedup ON COMMIT DROP AS
+    SELECT DISTINCT ON (ci.symbol_id, ci.candle_time)
+        ci.symbol_id, ci.candle_time, ci.open, ci.high, ci.low, ci.close, ci.volume
+    FROM candles_import ci
+    WHERE ci.candle_time IS NOT NULL
+    ORDER BY ci.symbol_id, ci.candle_time, ci.ctid DESC;
+
+    -- Classify rows before touching candles so the counts are exact
+    SELECT
+        COUNT(*) FILTER (WHERE c.symbol_id IS NULL),
+        COUNT(*) FILTER (WHERE c.symbol_id IS NOT NULL AND
+            (c.open, c.high, c.low, c.close, c.volume) IS DISTINCT FROM
+            (d.open, d.high, d.low, d.close, d.volume))
+    INTO v_inserted, v_updated
+    FROM candles_import_dedup d
+    LEFT JOIN candles c ON c.symbol_id = d.symbol_id AND c.candle_time = d.candle_time;
+
+    INSERT INTO candles (symbol_id, candle_time, open, high, low, close, volume)
+    SELECT d.symbol_id, d.candle_time, d.open, d.high, d.low, d.close, d.volume
+    FROM candles_import_dedup d
+    ON CONFLICT (symbol_id, candle_time)
+    DO UPDATE SET
+        open = EXCLUDED.open,
+        high = EXCLUDED.high,
+        low = EXCLUDED.low,
+        close = EXCLUDED.close,
+        volume = EXCLUDED.volume
+    WHERE (candles.open, candles.high, candles.low, candles.close, candles.volume)
+        IS DISTINCT FROM
+        (EXCLUDED.open, EXCLUDED.high, EXCLUDED.low, EXCLUDED.close, EXCLUDED.volume);
+
+    DROP TABLE candles_import_dedup;
+
+    RETURN QUERY SELECT
+        v_received,
+        v_inserted,
+        v_updated,
+        v_received - v_inserted - v_updated;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,323 @@
+-- ==========================================
+-- DAILY METRICS ROLLUPS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Per-user daily rollup of backtest activity
+CREATE TABLE IF NOT EXISTS "daily_user_metrics" (
+  "metric_date" date NOT NULL,
+  "user_id" int NOT NULL,
+  "backtests_created" int NOT NULL DEFAULT 0,
+  "backtests_completed" int NOT NULL DEFAULT 0,
+  "backtests_failed" int NOT NULL DEFAULT 0,
+  "runs_completed" int NOT NULL DEFAULT 0,
+  "total_trades" int NOT NULL DEFAULT 0,
+  "avg_total_return" numeric(10,4),
+  "best_total_return" numeric(10,4),
+  "updated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("metric_date", "user_id")
+);
+
+-- Per-strategy daily rollup of backtest activity and performance
+CREATE TABLE IF NOT EXISTS "daily_strategy_metrics" (
+  "metric_date" date NOT NULL,
+  "strategy_id" int NOT NULL,
+  "backtests_created" int NOT NULL DEFAULT 0,
+  "backtests_completed" int NOT NULL DEFAULT 0,
+  "unique_users" int NOT NULL DEFAULT 0,
+  "runs_completed" int NOT NULL DEFAULT 0,
+  "total_trades" int NOT NULL DEFAULT 0,
+  "winning_trades" int NOT NULL DEFAULT 0,
+  "avg_total_return" numeric(10,4),
+  "avg_sharpe_ratio" numeric(10,4),
+  "avg_max_drawdown" numeric(10,4),
+  "updated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("metric_date", "strategy_id")
+);
+
+-- Per-symbol daily rollup of market data and backtest usage
+CREATE TABLE IF NOT EXISTS "daily_symbol_metrics" (
+  "metric_date" date NOT NULL,
+  "symbol_id" int NOT NULL,
+  "candle_count" bigint NOT NULL DEFAULT 0,
+  "backtest_runs" int NOT NULL DEFAULT 0,
+  "runs_completed" int NOT NULL DEFAULT 0,
+  "total_trades" int NOT NULL DEFAULT 0,
+  "avg_total_return" numeric(10,4),
+  "updated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("metric_date", "symbol_id")
+);
+
+-- Bookkeeping for aggregation runs, one row per aggregated day
+CREATE TABLE IF NOT EXISTS "daily_metrics_aggregations" (
+  "metric_date" date PRIMARY KEY,
+  "user_rows" int NOT NULL DEFAULT 0,
+  "strategy_rows" int NOT NULL DEFAULT 0,
+  "symbol_rows" int NOT NULL DEFAULT 0,
+  "aggregated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_user_metrics_user ON daily_user_metrics(user_id, metric_date);
+CREATE INDEX IF NOT EXISTS idx_daily_strategy_metrics_strategy ON daily_strategy_metrics(strategy_id, metric_date);
+CREATE INDEX IF NOT EXISTS idx_daily_symbol_metrics_symbol ON daily_symbol_metrics(symbol_id, metric_date);
+
+-- Supporting indexes for the aggregation queries over raw tables
+CREATE INDEX IF NOT EXISTS idx_backtests_created_at ON backtests(created_at);
+CREATE INDEX IF NOT EXISTS idx_backtests_completed_at ON backtests(completed_at);
+CREATE INDEX IF NOT EXISTS idx_backtest_runs_completed_at ON backtest_runs(completed_at);
+CREATE INDEX IF NOT EXISTS idx_backtest_runs_created_at ON backtest_runs(created_at);
+
+-- Recompute all rollups for a single day. The day's rows are replaced inside the
+-- function's transaction, so re-running for the same date is idempotent.
+CREATE OR REPLACE FUNCTION refresh_daily_metrics(
+    p_date DATE
+)
+RETURNS TABLE (
+    user_rows INT,
+    strategy_rows INT,
+    symbol_rows INT
+) AS $$
+DECLARE
+    v_start TIMESTAMPTZ := p_date::TIMESTAMPTZ;
+    v_end TIMESTAMPTZ := (p_date + 1)::TIMESTAMPTZ;
+    v_user_rows INT;
+    v_strategy_rows INT;
+    v_symbol_rows INT;
+BEGIN
+    DELETE FROM daily_user_metrics WHERE metric_date = p_date;
+    DELETE FROM daily_strategy_metrics WHERE metric_date = p_date;
+    DELETE FROM daily_symbol_metrics WHERE metric_date = p_date;
+
+    -- Per user
+    INSERT INTO daily_user_metrics (
+        metric_date, user_id, backtests_created, backtests_completed, backtests_failed,
+        runs_completed, total_trades, avg_total_return, best_total_return
+    )
+    WITH created AS (
+        SELECT b.user_id, COUNT(*) AS cnt
+        FROM backtests b
+        WHERE b.created_at >= v_start AND b.created_at < v_end
+        GROUP BY b.user_id
+    ),
+    finished AS (
+        SELECT
+            b.user_id,
+            COUNT(*) FILTER (WHERE b.status = 'completed') AS completed,
+            COUNT(*) FILTER (WHERE b.status = 'failed') AS failed
+        FROM backtests b
+        WHERE COALESCE(b.completed_at, b.updated_at) >= v_start
+          AND COALESCE(b.completed_at, b.updated_at) < v_end
+          AND b.status IN ('completed', 'failed')
+        GROUP BY b.user_id
+    ),
+    runs AS (
+        SELECT
+            b.user_id,
+            COUNT(*) AS completed,
+            COALESCE(SUM(res.total_trades), 0) AS trades,
+            AVG(res.total_return) AS avg_return,
+            MAX(res.total_return) AS best_return
+        FROM backtest_runs br
+        JOIN backtests b ON br.backtest_id = b.id
+        LEFT JOIN backtest_results res ON res.backtest_run_id = br.id
+        WHERE br.status = 'completed'
+          AND br.completed_at >= v_start AND br.completed_at < v_end
+        GROUP BY b.user_id
+    ),
+    users AS (
+        SELECT user_id FROM created
+        UNION SELECT user_id FROM finished
+        UNION SELECT user_id FROM runs
+    )
+    SELECT
+        p_date,
+        u.user_id,
+        COALESCE(c.cnt, 0),
+        COALESCE(f.completed, 0),
+        COALESCE(f.failed, 0),
+        COALESCE(r.completed, 0),
+        COALESCE(r.trades, 0),
+        r.avg_return,
+        r.best_return
+    FROM users u
+    LEFT JOIN created c ON c.user_id = u.user_id
+    LEFT JOIN finished f ON f.user_id = u.user_id
+    LEFT JOIN runs r ON r.user_id = u.user_id;
+
+    GET DIAGNOSTICS v_user_rows = ROW_COUNT;
+
+    -- Per strategy
+    INSERT INTO daily_strategy_metrics (
+        metric_date, strategy_id, backtests_created, backtests_completed, unique_users,
+        runs_completed, total_trades, winning_trades, avg_total_return, avg_sharpe_ratio, avg_max_drawdown
+    )
+    WITH created AS (
+        SELECT b.strategy_id, COUNT(*) AS cnt, COUNT(DISTINCT b.user_id) AS users
+        FROM backtests b
+        WHERE b.created_at >= v_start AND b.created_at < v_end
+        GROUP BY b.strategy_id
+    ),
+    finished AS (
+        SELECT b.strategy_id, COUNT(*) AS completed
+        FROM backtests b
+        WHERE b.status = 'completed'
+          AND b.completed_at >= v_start AND b.completed_at < v_end
+        GROUP BY b.strategy_id
+    ),
+    runs AS (
+        SELECT
+            b.strategy_id,
+            COUNT(*) AS completed,
+            COALESCE(SUM(res.total_trades), 0) AS trades,
+            COALESCE(SUM(res.winning_trades), 0) AS wins,
+            AVG(res.total_return) AS avg_return,
+            AVG(res.sharpe_ratio) AS avg_sharpe,
+            AVG(res.max_drawdown) AS avg_drawdown
+        FROM backtest_runs br
+        JOIN backtests b ON br.backtest_id = b.id
+        LEFT JOIN backtest_results res ON res.backtest_run_id = br.id
+        WHERE br.status = 'completed'
+          AND br.completed_at >= v_start AND br.completed_at < v_end
+        GROUP BY b.strategy_id
+    ),
+    strategies AS (
+        SELECT strategy_id FROM created
+        UNION SELECT strategy_id FROM finished
+        UNION SELECT strategy_id FROM runs
+    )
+    SELECT
+        p_date,
+        s.strategy_id,
+        COALESCE(c.cnt, 0),
+        COALESCE(f.completed, 0),
+        COALESCE(c.users, 0),
+        COALESCE(r.completed, 0),
+        COALESCE(r.trades, 0),
+        COALESCE(r.wins, 0),
+        r.avg_return,
+        r.avg_sharpe,
+        r.avg_drawdown
+    FROM strategies s
+    LEFT JOIN created c ON c.strategy_id = s.strategy_id
+    LEFT JOIN finished f ON f.strategy_id = s.strategy_id
+    LEFT JOIN runs r ON r.strategy_id = s.strategy_id;
+
+    GET DIAGNOSTICS v_strategy_rows = ROW_COUNT;
+
+    -- Per symbol
+    INSERT INTO daily_symbol_metrics (
+        metric_date, symbol_id, candle_count, backtest_runs, runs_completed, total_trades, avg_total_return
+    )
+    WITH candle_counts AS (
+        SELECT c.symbol_id, COUNT(*) AS cnt
+        FROM candles c
+        WHERE c.candle_time >= v_start AND c.candle_time < v_end
+        GROUP BY c.symbol_id
+    ),
+    created_runs AS (
+        SELECT br.symbol_id, COUNT(*) AS cnt
+        FROM backtest_runs br
+        WHERE br.created_at >= v_start AND br.created_at < v_end
+        GROUP BY br.symbol_id
+    ),
+    runs AS (
+        SELECT
+            br.symbol_id,
+            COUNT(*) AS completed,
+            COALESCE(SUM(res.total_trades), 0) AS trades,
+            AVG(res.total_return) AS avg_return
+        FROM backtest_runs br
+        LEFT JOIN backtest_results res ON res.backtest_run_id = br.id
+        WHERE br.status = 'completed'
+          AND br.completed_at >= v_start AND br.completed_at < v_end
+        GROUP BY br.symbol_id
+    ),
+    symbol_ids AS (
+        SELECT symbol_id FROM candle_counts
+        UNION SELECT symbol_id FROM created_runs
+        UNION SELECT symbol_id FROM runs
+    )
+    SELECT
+        p_date,
+        s.symbol_id,
+        COALESCE(cc.cnt, 0),
+        COALESCE(cr.cnt, 0),
+        COALESCE(r.completed, 0),
+        COALESCE(r.trades, 0),
+        r.avg_return
+    FROM symbol_ids s
+    LEFT JOIN candle_counts cc ON cc.symbol_id = s.symbol_id
+    LEFT JOIN created_runs cr ON cr.symbol_id = s.symbol_id
+    LEFT JOIN runs r ON r.symbol_id = s.symbol_id;
+
+    GET DIAGNOSTICS v_symbol_rows = ROW_COUNT;
+
+    INSERT INTO daily_metrics_aggregations (metric_date, user_rows, strategy_rows, symbol_rows, aggregated_at)
+    VALUES (p_date, v_user_rows, v_strategy_rows, v_symbol_rows, NOW())
+    ON CONFLICT (metric_date) DO UPDATE SET
+        user_rows = EXCLUDED.user_rows,
+        strategy_rows = EXCLUDED.strategy_rows,
+        symbol_rows = EXCLUDED.symbol_rows,
+        aggregated_at = EXCLUDED.aggregated_at;
+
+    RETURN QUERY SELECT v_user_rows, v_strategy_rows, v_symbol_rows;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the most recent day that has been aggregated
+CREATE OR REPLACE FUNCTION get_last_aggregated_metric_date()
+RETURNS DATE AS $$
+BEGIN
+    RETURN (SELECT MAX(metric_date) FROM daily_metrics_aggregations);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get daily rollups for a user
+CREATE OR REPLACE FUNCTION get_daily_user_metrics(
+    p_user_id INT,
+    p_start_date DATE,
+    p_end_date DATE
+)
+RETURNS SETOF daily_user_metrics AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM daily_user_metrics m
+    WHERE m.user_id = p_user_id
+      AND m.metric_date BETWEEN p_start_date AND p_end_date
+    ORDER BY m.metric_date;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get daily rollups for a strategy
+CREATE OR REPLACE FUNCTION get_daily_strategy_metrics(
+    p_strategy_id INT,
+    p_start_date DATE,
+    p_end_date DATE
+)
+RETURNS SETOF daily_strategy_metrics AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM daily_strategy_metrics m
+    WHERE m.strategy_id = p_strategy_id
+      AND m.metric_date BETWEEN p_start_date AND p_end_date
+    ORDER BY m.metric_date;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get daily rollups for a symbol
+CREATE OR REPLACE FUNCTION get_daily_symbol_metrics(
+    p_symbol_id INT,
+    p_start_date DATE,
+    p_end_date DATE
+)
+RETURNS SETOF daily_symbol_metrics AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM daily_symbol_metrics m
+    WHERE m.symbol_id = p_symbol_id
+      AND m.metric_date BETWEEN p_start_date AND p_end_date
+    ORDER BY m.metric_date;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
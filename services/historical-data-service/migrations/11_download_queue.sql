@@ -0,0 +1,276 @@
+-- Historical Data Service Download Queue
+-- File: 11_download_queue.sql
+-- Contains retry bookkeeping, checkpoints and job claiming for the download worker pool
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "market_data_download_jobs"
+  ADD COLUMN IF NOT EXISTS "attempts" int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "next_attempt_at" timestamptz;
+
+CREATE INDEX IF NOT EXISTS "idx_download_jobs_queue"
+  ON "market_data_download_jobs" ("status", "next_attempt_at", "created_at");
+
+-- last_processed_time used to be a heartbeat. It is now the end of the last imported
+-- chunk, so clear it on unfinished jobs rather than resume them from a wall-clock time.
+UPDATE market_data_download_jobs
+SET last_processed_time = NULL
+WHERE status IN ('pending', 'in_progress');
+
+-- Status updates no longer move the checkpoint, and never revive a cancelled job
+CREATE OR REPLACE FUNCTION update_market_data_download_job_status(
+    p_job_id INT,
+    p_status VARCHAR(20),
+    p_progress NUMERIC(5,2),
+    p_processed_candles INT,
+    p_total_candles INT,
+    p_retries INT,
+    p_error TEXT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        status = p_status,
+        progress = p_progress,
+        processed_candles = p_processed_candles,
+        total_candles = p_total_candles,
+        retries = p_retries,
+        error = p_error,
+        updated_at = NOW()
+    WHERE
+        id = p_job_id
+        AND (status <> 'cancelled' OR p_status = 'cancelled');
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The job row now includes retry bookkeeping
+DROP FUNCTION IF EXISTS get_download_job_by_id(INT);
+
+CREATE OR REPLACE FUNCTION get_download_job_by_id(
+    p_job_id INT
+)
+RETURNS TABLE (
+    id INT,
+    symbol_id INT,
+    symbol VARCHAR(20),
+    source VARCHAR(50),
+    timeframe timeframe_type,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    status VARCHAR(20),
+    progress NUMERIC(5,2),
+    total_candles INT,
+    processed_candles INT,
+    retries INT,
+    error TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    last_processed_time TIMESTAMPTZ,
+    attempts INT,
+    next_attempt_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        j.id,
+        j.symbol_id,
+        j.symbol,
+        j.source,
+        j.timeframe,
+        j.start_date,
+        j.end_date,
+        j.status,
+        j.progress,
+        j.total_candles,
+        j.processed_candles,
+        j.retries,
+        j.error,
+        j.created_at,
+        j.updated_at,
+        j.last_processed_time,
+        j.attempts,
+        j.next_attempt_at
+    FROM market_data_download_jobs j
+    WHERE j.id = p_job_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Claim the oldest runnable pending job, skipping sources that are at capacity.
+-- Concurrent workers never claim the same job.
+CREATE OR REPLACE FUNCTION claim_download_job(
+    p_excluded_sources VARCHAR[]
+)
+RETURNS TABLE (
+    id INT,
+    symbol_id INT,
+    symbol VARCHAR(20),
+    source VARCHAR(50),
+    timeframe timeframe_type,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    status VARCHAR(20),
+    progress NUMERIC(5,2),
+    total_candles INT,
+    processed_candles INT,
+    retries INT,
+    error TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    last_processed_time TIMESTAMPTZ,
+    attempts INT,
+    next_attempt_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH next_job AS (
+        SELECT q.id
+        FROM market_data_download_jobs q
+        WHERE q.status = 'pending'
+          AND (q.next_attempt_at IS NULL OR q.next_attempt_at <= NOW())
+          AND NOT (q.source = ANY(COALESCE(p_excluded_sources, '{}')))
+        ORDER BY q.created_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE market_data_download_jobs j
+    SET
+        status = 'in_progress',
+        attempts = j.attempts + 1,
+        next_attempt_at = NULL,
+        updated_at = NOW()
+    FROM next_job
+    WHERE j.id = next_job.id
+    RETURNING
+        j.id,
+        j.symbol_id,
+        j.symbol,
+        j.source,
+        j.timeframe,
+        j.start_date,
+        j.end_date,
+        j.status,
+        j.progress,
+        j.total_candles,
+        j.processed_candles,
+        j.retries,
+        j.error,
+        j.created_at,
+        j.updated_at,
+        j.last_processed_time,
+        j.attempts,
+        j.next_attempt_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Record progress after a chunk so an interrupted job resumes from p_last_processed_time.
+-- Returns FALSE if the job is no longer running (e.g. it was cancelled).
+CREATE OR REPLACE FUNCTION checkpoint_download_job(
+    p_job_id INT,
+    p_last_processed_time TIMESTAMPTZ,
+    p_progress NUMERIC(5,2),
+    p_processed_candles INT,
+    p_total_candles INT,
+    p_retries INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        last_processed_time = p_last_processed_time,
+        progress = p_progress,
+        processed_candles = p_processed_candles,
+        total_candles = p_total_candles,
+        retries = p_retries,
+        error = NULL,
+        updated_at = NOW()
+    WHERE
+        id = p_job_id
+        AND status = 'in_progress';
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Schedule another attempt after p_retry_delay_seconds, or fail the job once it has
+-- used p_max_attempts. Returns the job's new status, or NULL if it wasn't running.
+CREATE OR REPLACE FUNCTION fail_download_job_attempt(
+    p_job_id INT,
+    p_error TEXT,
+    p_max_attempts INT,
+    p_retry_delay_seconds INT
+)
+RETURNS VARCHAR AS $$
+DECLARE
+    v_status VARCHAR(20);
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        status = CASE WHEN attempts < p_max_attempts THEN 'pending' ELSE 'failed' END,
+        next_attempt_at = CASE
+            WHEN attempts < p_max_attempts THEN NOW() + make_interval(secs => p_retry_delay_seconds)
+            ELSE NULL
+        END,
+        error = p_error,
+        updated_at = NOW()
+    WHERE
+        id = p_job_id
+        AND status = 'in_progress'
+    RETURNING status INTO v_status;
+
+    RETURN v_status;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Return a job interrupted by shutdown to the queue without using up an attempt
+CREATE OR REPLACE FUNCTION release_download_job(
+    p_job_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        status = 'pending',
+        attempts = GREATEST(attempts - 1, 0),
+        updated_at = NOW()
+    WHERE
+        id = p_job_id
+        AND status = 'in_progress';
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Requeue running jobs that haven't checkpointed within p_stale_after_seconds,
+-- i.e. whose worker died without releasing them. Returns the number requeued.
+CREATE OR REPLACE FUNCTION requeue_stale_download_jobs(
+    p_stale_after_seconds INT
+)
+RETURNS INT AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        status = 'pending',
+        updated_at = NOW()
+    WHERE
+        status = 'in_progress'
+        AND updated_at < NOW() - make_interval(secs => p_stale_after_seconds);
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
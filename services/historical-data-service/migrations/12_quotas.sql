@@ -0,0 +1,111 @@
+-- ==========================================
+-- USER QUOTAS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Download jobs record who requested them so stored candles can be attributed to a user.
+-- Jobs created before this migration (or by other services) have no owner.
+ALTER TABLE "market_data_download_jobs"
+  ADD COLUMN IF NOT EXISTS "user_id" int;
+
+CREATE INDEX IF NOT EXISTS "idx_download_jobs_user" ON "market_data_download_jobs" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_backtests_user_status" ON "backtests" ("user_id", "status");
+
+-- Jobs now record their owner and start with an estimate of their size, so queued jobs
+-- count against the owner's storage quota. Replace rather than overload the old signature.
+DROP FUNCTION IF EXISTS create_market_data_download_job(INT, VARCHAR, VARCHAR, timeframe_type, TIMESTAMPTZ, TIMESTAMPTZ);
+
+CREATE OR REPLACE FUNCTION create_market_data_download_job(
+    p_symbol_id INT,
+    p_symbol VARCHAR(20),
+    p_source VARCHAR(50),
+    p_timeframe timeframe_type,
+    p_start_date TIMESTAMPTZ,
+    p_end_date TIMESTAMPTZ,
+    p_user_id INT DEFAULT NULL,
+    p_estimated_candles INT DEFAULT 0
+)
+RETURNS INT AS $$
+DECLARE
+    new_job_id INT;
+BEGIN
+    INSERT INTO market_data_download_jobs (
+        symbol_id,
+        symbol,
+        source,
+        timeframe,
+        start_date,
+        end_date,
+        status,
+        progress,
+        total_candles,
+        processed_candles,
+        retries,
+        user_id,
+        created_at,
+        updated_at
+    )
+    VALUES (
+        p_symbol_id,
+        p_symbol,
+        p_source,
+        p_timeframe,
+        p_start_date,
+        p_end_date,
+        'pending',
+        0,
+        p_estimated_candles,
+        0,
+        0,
+        p_user_id,
+        NOW(),
+        NOW()
+    )
+    RETURNING id INTO new_job_id;
+
+    RETURN new_job_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Current usage of every quota for a user. Daily counts start at p_day_start.
+-- Backtests that have been unfinished for over a day don't hold a concurrency slot.
+-- Stored candles are the candles imported by the user's download jobs; reserved candles
+-- are the estimated remainder of their queued and running jobs.
+CREATE OR REPLACE FUNCTION get_user_quota_usage(
+    p_user_id INT,
+    p_day_start TIMESTAMPTZ
+)
+RETURNS TABLE (
+    concurrent_backtests INT,
+    backtests_today INT,
+    download_jobs_today INT,
+    stored_candles BIGINT,
+    reserved_candles BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        (SELECT COUNT(*)::INT
+         FROM backtests b
+         WHERE b.user_id = p_user_id
+           AND b.status NOT IN ('completed', 'failed', 'cancelled')
+           AND b.created_at > NOW() - INTERVAL '1 day'),
+        (SELECT COUNT(*)::INT
+         FROM backtests b
+         WHERE b.user_id = p_user_id
+           AND b.created_at >= p_day_start),
+        (SELECT COUNT(*)::INT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id
+           AND j.created_at >= p_day_start),
+        (SELECT COALESCE(SUM(j.processed_candles), 0)::BIGINT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id),
+        (SELECT COALESCE(SUM(GREATEST(j.total_candles - j.processed_candles, 0)), 0)::BIGINT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id
+           AND j.status IN ('pending', 'in_progress'));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
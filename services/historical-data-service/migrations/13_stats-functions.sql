@@ -0,0 +1,57 @@
+-- ==========================================
+-- ADMIN DASHBOARD STATISTICS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_download_jobs_created_at" ON "market_data_download_jobs" ("created_at");
+
+-- Backtest, download job and data inventory KPIs. Backtests and download jobs are those
+-- created since p_since; inventory figures are current totals. Candle counts and sizes come
+-- from TimescaleDB's estimates, since counting the hypertable exactly is too slow.
+CREATE OR REPLACE FUNCTION get_data_stats(p_since TIMESTAMPTZ)
+RETURNS TABLE (
+    backtests_created BIGINT,
+    backtests_completed BIGINT,
+    backtests_failed BIGINT,
+    active_backtesters BIGINT,
+    download_jobs_created BIGINT,
+    download_jobs_completed BIGINT,
+    download_jobs_failed BIGINT,
+    candles_downloaded BIGINT,
+    symbols_with_data BIGINT,
+    total_candles BIGINT,
+    storage_bytes BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        b.created,
+        b.completed,
+        b.failed,
+        b.users,
+        j.created,
+        j.completed,
+        j.failed,
+        j.candles,
+        (SELECT COUNT(*) FROM symbols s WHERE s.data_available),
+        approximate_row_count('candles'),
+        hypertable_size('candles')
+    FROM
+        (SELECT
+            COUNT(*) AS created,
+            COUNT(*) FILTER (WHERE bt.status = 'completed') AS completed,
+            COUNT(*) FILTER (WHERE bt.status = 'failed') AS failed,
+            COUNT(DISTINCT bt.user_id) AS users
+         FROM backtests bt
+         WHERE bt.created_at >= p_since) b,
+        (SELECT
+            COUNT(*) AS created,
+            COUNT(*) FILTER (WHERE dj.status = 'completed') AS completed,
+            COUNT(*) FILTER (WHERE dj.status = 'failed') AS failed,
+            COALESCE(SUM(dj.processed_candles), 0)::BIGINT AS candles
+         FROM market_data_download_jobs dj
+         WHERE dj.created_at >= p_since) j;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
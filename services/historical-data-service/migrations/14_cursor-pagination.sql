@@ -0,0 +1,135 @@
+-- ==========================================
+-- CURSOR PAGINATION
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Get the trades of a backtest run that come after a cursor, in the same order as
+-- get_backtest_trades with the trade id breaking ties. The cursor is the sort_value and id
+-- of the last trade of the previous page; pass NULLs for the first page.
+-- Missing exit times and profits sort as infinity/NaN, which keeps PostgreSQL's default
+-- placement of NULLs (last ascending, first descending) while making them comparable.
+CREATE OR REPLACE FUNCTION get_backtest_trades_after(
+    p_backtest_run_id INT,
+    p_sort_by VARCHAR DEFAULT 'entry_time',
+    p_sort_direction VARCHAR DEFAULT 'ASC',
+    p_after_value TEXT DEFAULT NULL,
+    p_after_id INT DEFAULT NULL,
+    p_limit INT DEFAULT 100
+)
+RETURNS TABLE (
+    id INT,
+    symbol_id INT,
+    symbol VARCHAR(20),
+    entry_time TIMESTAMPTZ,
+    exit_time TIMESTAMPTZ,
+    position_type VARCHAR(10),
+    entry_price NUMERIC(20,8),
+    exit_price NUMERIC(20,8),
+    quantity NUMERIC(20,8),
+    profit_loss NUMERIC(20,8),
+    profit_loss_percent NUMERIC(10,4),
+    exit_reason VARCHAR(50),
+    sort_value TEXT
+) AS $$
+DECLARE
+    -- Unused sort keys are constant so whole rows can be compared
+    v_after_time TIMESTAMPTZ := '-infinity';
+    v_after_number NUMERIC := 0;
+    v_after_text VARCHAR := '';
+BEGIN
+    -- Validate sort field
+    IF p_sort_by NOT IN ('entry_time', 'exit_time', 'position_type', 'profit_loss', 'profit_loss_percent') THEN
+        p_sort_by := 'entry_time';
+    END IF;
+
+    -- Normalize sort direction
+    p_sort_direction := UPPER(p_sort_direction);
+    IF p_sort_direction NOT IN ('ASC', 'DESC') THEN
+        p_sort_direction := 'ASC';
+    END IF;
+
+    IF p_after_id IS NOT NULL THEN
+        IF p_sort_by IN ('entry_time', 'exit_time') THEN
+            v_after_time := p_after_value::TIMESTAMPTZ;
+        ELSIF p_sort_by IN ('profit_loss', 'profit_loss_percent') THEN
+            v_after_number := p_after_value::NUMERIC;
+        ELSE
+            v_after_text := p_after_value;
+        END IF;
+    END IF;
+
+    RETURN QUERY
+    WITH sorted AS (
+        SELECT
+            t.id,
+            t.symbol_id,
+            s.symbol,
+            t.entry_time,
+            t.exit_time,
+            t.position_type,
+            t.entry_price,
+            t.exit_price,
+            t.quantity,
+            t.profit_loss,
+            t.profit_loss_percent,
+            t.exit_reason,
+            CASE p_sort_by
+                WHEN 'entry_time' THEN t.entry_time
+                WHEN 'exit_time' THEN COALESCE(t.exit_time, 'infinity')
+                ELSE '-infinity'
+            END AS sort_time,
+            CASE p_sort_by
+                WHEN 'profit_loss' THEN COALESCE(t.profit_loss, 'NaN')
+                WHEN 'profit_loss_percent' THEN COALESCE(t.profit_loss_percent, 'NaN')
+                ELSE 0
+            END AS sort_number,
+            CASE p_sort_by
+                WHEN 'position_type' THEN t.position_type
+                ELSE ''
+            END AS sort_text
+        FROM
+            backtest_trades t
+            JOIN symbols s ON t.symbol_id = s.id
+        WHERE
+            t.backtest_run_id = p_backtest_run_id
+    )
+    SELECT
+        st.id,
+        st.symbol_id,
+        st.symbol,
+        st.entry_time,
+        st.exit_time,
+        st.position_type,
+        st.entry_price,
+        st.exit_price,
+        st.quantity,
+        st.profit_loss,
+        st.profit_loss_percent,
+        st.exit_reason,
+        CASE
+            WHEN p_sort_by IN ('entry_time', 'exit_time') THEN st.sort_time::TEXT
+            WHEN p_sort_by IN ('profit_loss', 'profit_loss_percent') THEN st.sort_number::TEXT
+            ELSE st.sort_text::TEXT
+        END AS sort_value
+    FROM
+        sorted st
+    WHERE
+        p_after_id IS NULL
+        OR (p_sort_direction = 'ASC' AND
+            (st.sort_time, st.sort_number, st.sort_text, st.id) > (v_after_time, v_after_number, v_after_text, p_after_id))
+        OR (p_sort_direction = 'DESC' AND
+            (st.sort_time, st.sort_number, st.sort_text, st.id) < (v_after_time, v_after_number, v_after_text, p_after_id))
+    ORDER BY
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_time END ASC,
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_number END ASC,
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_text END ASC,
+        CASE WHEN p_sort_direction = 'ASC' THEN st.id END ASC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_time END DESC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_number END DESC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_text END DESC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.id END DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,158 @@
+-- ==========================================
+-- BACKTEST SETTINGS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Trading parameters (market type, leverage, commission, slippage, shorting and position
+-- sizing) the backtest runs with. Backtests created before this migration have none and
+-- run with the defaults.
+ALTER TABLE "backtests"
+  ADD COLUMN IF NOT EXISTS "settings" jsonb;
+
+-- The settings are stored with the backtest and returned with its details.
+-- Replace rather than overload the old signatures.
+DROP FUNCTION IF EXISTS create_backtest(INT, INT, INT, VARCHAR, TEXT, timeframe_type, TIMESTAMPTZ, TIMESTAMPTZ, NUMERIC, INT[]);
+DROP FUNCTION IF EXISTS get_backtest_by_id(INT);
+
+-- Get backtest by ID with its settings
+CREATE OR REPLACE FUNCTION get_backtest_by_id(p_backtest_id INT)
+RETURNS TABLE (
+    backtest_id INT,
+    name TEXT,
+    description TEXT,
+    strategy_id INT,
+    strategy_version INT,
+    timeframe timeframe_type,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    initial_capital NUMERIC(20,8),
+    status VARCHAR(20),
+    created_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    run_results JSONB,
+    settings JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        b.id AS backtest_id,
+        b.name,
+        b.description,
+        b.strategy_id,
+        b.strategy_version,
+        b.timeframe,
+        b.start_date,
+        b.end_date,
+        b.initial_capital,
+        b.status,
+        b.created_at,
+        b.completed_at,
+        (
+            SELECT jsonb_agg(jsonb_build_object(
+                'run_id', br.id,
+                'symbol_id', br.symbol_id,
+                'symbol', sym.symbol,
+                'status', br.status,
+                'completed_at', br.completed_at,
+                'results', CASE WHEN res.id IS NOT NULL THEN
+                    jsonb_build_object(
+                        'total_trades', res.total_trades,
+                        'winning_trades', res.winning_trades,
+                        'losing_trades', res.losing_trades,
+                        'profit_factor', res.profit_factor,
+                        'sharpe_ratio', res.sharpe_ratio,
+                        'max_drawdown', res.max_drawdown,
+                        'final_capital', res.final_capital,
+                        'total_return', res.total_return,
+                        'annualized_return', res.annualized_return,
+                        'detailed_results', res.results_json
+                    )
+                    ELSE NULL
+                END
+            ))
+            FROM backtest_runs br
+            JOIN symbols sym ON br.symbol_id = sym.id
+            LEFT JOIN backtest_results res ON br.id = res.backtest_run_id
+            WHERE br.backtest_id = b.id
+        ) AS run_results,
+        b.settings
+    FROM 
+        backtests b
+    WHERE 
+        b.id = p_backtest_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create new backtest with its settings
+CREATE OR REPLACE FUNCTION create_backtest(
+    p_user_id INT,
+    p_strategy_id INT,
+    p_strategy_version INT,
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_timeframe timeframe_type,
+    p_start_date TIMESTAMPTZ,
+    p_end_date TIMESTAMPTZ,
+    p_initial_capital NUMERIC(20,8),
+    p_symbol_ids INT[],
+    p_settings JSONB DEFAULT NULL
+)
+RETURNS INT AS $$
+DECLARE
+    new_backtest_id INT;
+    symbol_id INT;
+BEGIN
+    -- Create backtest record
+    INSERT INTO backtests (
+        user_id,
+        strategy_id,
+        strategy_version,
+        name,
+        description,
+        timeframe,
+        start_date,
+        end_date,
+        initial_capital,
+        settings,
+        status,
+        created_at,
+        updated_at
+    )
+    VALUES (
+        p_user_id,
+        p_strategy_id,
+        p_strategy_version,
+        p_name,
+        p_description,
+        p_timeframe,
+        p_start_date,
+        p_end_date,
+        p_initial_capital,
+        p_settings,
+        'pending',
+        NOW(),
+        NOW()
+    )
+    RETURNING id INTO new_backtest_id;
+    
+    -- Create backtest runs for each symbol
+    FOREACH symbol_id IN ARRAY p_symbol_ids LOOP
+        INSERT INTO backtest_runs (
+            backtest_id,
+            symbol_id,
+            status,
+            created_at
+        )
+        VALUES (
+            new_backtest_id,
+            symbol_id,
+            'pending',
+            NOW()
+        );
+    END LOOP;
+    
+    RETURN new_backtest_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
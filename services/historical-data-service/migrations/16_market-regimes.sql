@@ -0,0 +1,72 @@
+-- ==========================================
+-- MARKET REGIMES
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Candle history of a symbol and timeframe split into segments of consistent trend
+-- (trending_up, trending_down, ranging) and volatility (high, low). Segments are
+-- replaced as a whole whenever the history is classified again.
+CREATE TABLE IF NOT EXISTS "market_regimes" (
+  "id" SERIAL PRIMARY KEY,
+  "symbol_id" int NOT NULL REFERENCES "symbols" ("id") ON DELETE CASCADE,
+  "timeframe" timeframe_type NOT NULL,
+  "trend" varchar(20) NOT NULL,
+  "volatility" varchar(10) NOT NULL,
+  "start_time" timestamptz NOT NULL,
+  "end_time" timestamptz NOT NULL,
+  "candle_count" int NOT NULL,
+  "return_percent" numeric(12,4) NOT NULL DEFAULT 0,
+  "volatility_percent" numeric(12,4) NOT NULL DEFAULT 0,
+  "classified_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "market_regimes_trend_check" CHECK (trend IN ('trending_up', 'trending_down', 'ranging')),
+  CONSTRAINT "market_regimes_volatility_check" CHECK (volatility IN ('high', 'low'))
+);
+
+CREATE INDEX IF NOT EXISTS "idx_market_regimes_symbol_timeframe" ON "market_regimes" ("symbol_id", "timeframe", "start_time");
+
+-- Get the regime segments of a symbol and timeframe that overlap a time range.
+-- NULL bounds leave that side of the range open.
+CREATE OR REPLACE FUNCTION get_market_regimes(
+    p_symbol_id INT,
+    p_timeframe timeframe_type,
+    p_start_time TIMESTAMPTZ DEFAULT NULL,
+    p_end_time TIMESTAMPTZ DEFAULT NULL
+)
+RETURNS TABLE (
+    symbol_id INT,
+    timeframe timeframe_type,
+    trend VARCHAR(20),
+    volatility VARCHAR(10),
+    start_time TIMESTAMPTZ,
+    end_time TIMESTAMPTZ,
+    candle_count INT,
+    return_percent NUMERIC(12,4),
+    volatility_percent NUMERIC(12,4),
+    classified_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.symbol_id,
+        r.timeframe,
+        r.trend,
+        r.volatility,
+        r.start_time,
+        r.end_time,
+        r.candle_count,
+        r.return_percent,
+        r.volatility_percent,
+        r.classified_at
+    FROM
+        market_regimes r
+    WHERE
+        r.symbol_id = p_symbol_id
+        AND r.timeframe = p_timeframe
+        AND (p_start_time IS NULL OR r.end_time >= p_start_time)
+        AND (p_end_time IS NULL OR r.start_time <= p_end_time)
+    ORDER BY
+        r.start_time;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,204 @@
+-- ==========================================
+-- TRADING CALENDARS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Market hours of exchanges that don't trade around the clock. Exchanges without a
+-- calendar (crypto exchanges) are treated as always open.
+CREATE TABLE IF NOT EXISTS "trading_calendars" (
+  "exchange" varchar(50) PRIMARY KEY,
+  "name" varchar(100) NOT NULL,
+  "timezone" varchar(50) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Weekly sessions in the exchange's local time; day_of_week 0 is Sunday
+CREATE TABLE IF NOT EXISTS "trading_sessions" (
+  "id" SERIAL PRIMARY KEY,
+  "exchange" varchar(50) NOT NULL REFERENCES "trading_calendars" ("exchange") ON DELETE CASCADE,
+  "day_of_week" smallint NOT NULL,
+  "open_time" time NOT NULL,
+  "close_time" time NOT NULL,
+  CONSTRAINT "trading_sessions_day_check" CHECK (day_of_week BETWEEN 0 AND 6),
+  CONSTRAINT "trading_sessions_time_check" CHECK (close_time > open_time)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_trading_sessions_exchange" ON "trading_sessions" ("exchange", "day_of_week");
+
+-- Days the exchange is closed, or closes early when early_close is set
+CREATE TABLE IF NOT EXISTS "trading_holidays" (
+  "exchange" varchar(50) NOT NULL REFERENCES "trading_calendars" ("exchange") ON DELETE CASCADE,
+  "date" date NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "early_close" time,
+  PRIMARY KEY ("exchange", "date")
+);
+
+INSERT INTO trading_calendars (exchange, name, timezone)
+VALUES
+('NYSE', 'New York Stock Exchange', 'America/New_York'),
+('NASDAQ', 'Nasdaq Stock Market', 'America/New_York'),
+('LSE', 'London Stock Exchange', 'Europe/London'),
+('FOREX', 'Foreign Exchange Market', 'America/New_York')
+ON CONFLICT (exchange) DO NOTHING;
+
+INSERT INTO trading_sessions (exchange, day_of_week, open_time, close_time)
+SELECT s.exchange, d.day, s.open_time, s.close_time
+FROM (VALUES ('NYSE', '09:30'::time, '16:00'::time),
+             ('NASDAQ', '09:30'::time, '16:00'::time),
+             ('LSE', '08:00'::time, '16:30'::time)) AS s(exchange, open_time, close_time)
+CROSS JOIN generate_series(1, 5) AS d(day);
+
+-- Forex trades from Sunday 17:00 to Friday 17:00 New York time
+INSERT INTO trading_sessions (exchange, day_of_week, open_time, close_time)
+VALUES
+('FOREX', 0, '17:00', '24:00'),
+('FOREX', 1, '00:00', '24:00'),
+('FOREX', 2, '00:00', '24:00'),
+('FOREX', 3, '00:00', '24:00'),
+('FOREX', 4, '00:00', '24:00'),
+('FOREX', 5, '00:00', '17:00');
+
+INSERT INTO trading_holidays (exchange, date, name, early_close)
+SELECT e.exchange, h.date::date, h.name, h.early_close::time
+FROM (VALUES ('NYSE'), ('NASDAQ')) AS e(exchange)
+CROSS JOIN (VALUES
+    ('2025-01-01', 'New Year''s Day', NULL),
+    ('2025-01-20', 'Martin Luther King Jr. Day', NULL),
+    ('2025-02-17', 'Washington''s Birthday', NULL),
+    ('2025-04-18', 'Good Friday', NULL),
+    ('2025-05-26', 'Memorial Day', NULL),
+    ('2025-06-19', 'Juneteenth', NULL),
+    ('2025-07-03', 'Independence Day Eve', '13:00'),
+    ('2025-07-04', 'Independence Day', NULL),
+    ('2025-09-01', 'Labor Day', NULL),
+    ('2025-11-27', 'Thanksgiving Day', NULL),
+    ('2025-11-28', 'Day after Thanksgiving', '13:00'),
+    ('2025-12-24', 'Christmas Eve', '13:00'),
+    ('2025-12-25', 'Christmas Day', NULL),
+    ('2026-01-01', 'New Year''s Day', NULL),
+    ('2026-01-19', 'Martin Luther King Jr. Day', NULL),
+    ('2026-02-16', 'Washington''s Birthday', NULL),
+    ('2026-04-03', 'Good Friday', NULL),
+    ('2026-05-25', 'Memorial Day', NULL),
+    ('2026-06-19', 'Juneteenth', NULL),
+    ('2026-07-03', 'Independence Day (observed)', NULL),
+    ('2026-09-07', 'Labor Day', NULL),
+    ('2026-11-26', 'Thanksgiving Day', NULL),
+    ('2026-11-27', 'Day after Thanksgiving', '13:00'),
+    ('2026-12-24', 'Christmas Eve', '13:00'),
+    ('2026-12-25', 'Christmas Day', NULL)
+) AS h(date, name, early_close)
+ON CONFLICT (exchange, date) DO NOTHING;
+
+INSERT INTO trading_holidays (exchange, date, name, early_close)
+VALUES
+('LSE', '2025-01-01', 'New Year''s Day', NULL),
+('LSE', '2025-04-18', 'Good Friday', NULL),
+('LSE', '2025-04-21', 'Easter Monday', NULL),
+('LSE', '2025-05-05', 'Early May Bank Holiday', NULL),
+('LSE', '2025-05-26', 'Spring Bank Holiday', NULL),
+('LSE', '2025-08-25', 'Summer Bank Holiday', NULL),
+('LSE', '2025-12-24', 'Christmas Eve', '12:30'),
+('LSE', '2025-12-25', 'Christmas Day', NULL),
+('LSE', '2025-12-26', 'Boxing Day', NULL),
+('LSE', '2025-12-31', 'New Year''s Eve', '12:30'),
+('LSE', '2026-01-01', 'New Year''s Day', NULL),
+('LSE', '2026-04-03', 'Good Friday', NULL),
+('LSE', '2026-04-06', 'Easter Monday', NULL),
+('LSE', '2026-05-04', 'Early May Bank Holiday', NULL),
+('LSE', '2026-05-25', 'Spring Bank Holiday', NULL),
+('LSE', '2026-08-31', 'Summer Bank Holiday', NULL),
+('LSE', '2026-12-24', 'Christmas Eve', '12:30'),
+('LSE', '2026-12-25', 'Christmas Day', NULL),
+('LSE', '2026-12-28', 'Boxing Day (substitute)', NULL),
+('LSE', '2026-12-31', 'New Year''s Eve', '12:30')
+ON CONFLICT (exchange, date) DO NOTHING;
+
+-- Get all trading calendars
+CREATE OR REPLACE FUNCTION get_trading_calendars()
+RETURNS TABLE (
+    exchange VARCHAR(50),
+    name VARCHAR(100),
+    timezone VARCHAR(50)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT c.exchange, c.name, c.timezone
+    FROM trading_calendars c
+    ORDER BY c.exchange;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the weekly sessions of an exchange, or of all exchanges when p_exchange is NULL.
+-- Times are formatted as HH:MM; a session running to midnight closes at 24:00.
+CREATE OR REPLACE FUNCTION get_trading_sessions(
+    p_exchange VARCHAR(50) DEFAULT NULL
+)
+RETURNS TABLE (
+    exchange VARCHAR(50),
+    day_of_week SMALLINT,
+    open_time TEXT,
+    close_time TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.exchange,
+        s.day_of_week,
+        left(s.open_time::text, 5),
+        left(s.close_time::text, 5)
+    FROM trading_sessions s
+    WHERE p_exchange IS NULL OR s.exchange = p_exchange
+    ORDER BY s.exchange, s.day_of_week, s.open_time;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the holidays of an exchange within a date range. NULL bounds leave that side open.
+CREATE OR REPLACE FUNCTION get_trading_holidays(
+    p_exchange VARCHAR(50),
+    p_start_date DATE DEFAULT NULL,
+    p_end_date DATE DEFAULT NULL
+)
+RETURNS TABLE (
+    exchange VARCHAR(50),
+    date TEXT,
+    name VARCHAR(100),
+    early_close TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        h.exchange,
+        to_char(h.date, 'YYYY-MM-DD'),
+        h.name,
+        left(h.early_close::text, 5)
+    FROM trading_holidays h
+    WHERE
+        h.exchange = p_exchange
+        AND (p_start_date IS NULL OR h.date >= p_start_date)
+        AND (p_end_date IS NULL OR h.date <= p_end_date)
+    ORDER BY h.date;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Add a holiday to an exchange, or update it if the date is already a holiday
+CREATE OR REPLACE FUNCTION upsert_trading_holiday(
+    p_exchange VARCHAR(50),
+    p_date DATE,
+    p_name VARCHAR(100),
+    p_early_close TIME DEFAULT NULL
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    INSERT INTO trading_holidays (exchange, date, name, early_close)
+    VALUES (p_exchange, p_date, p_name, p_early_close)
+    ON CONFLICT (exchange, date) DO UPDATE SET
+        name = EXCLUDED.name,
+        early_close = EXCLUDED.early_close;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,220 @@
+-- ==========================================
+-- TIMEFRAME CATALOG
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Timeframes candles can be aggregated to. Custom timeframes are also added to the
+-- timeframe_type enum so they can be stored wherever a timeframe is; enum values can't be
+-- dropped, so is_active decides whether a timeframe can be used.
+CREATE TABLE IF NOT EXISTS "timeframes" (
+  "name" varchar(10) PRIMARY KEY,
+  "display_name" varchar(50) NOT NULL,
+  "minutes" int NOT NULL,
+  "provider_interval" varchar(10),
+  "is_custom" boolean NOT NULL DEFAULT false,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamptz,
+  CONSTRAINT "timeframes_minutes_check" CHECK (minutes > 0)
+);
+
+INSERT INTO timeframes (name, display_name, minutes, provider_interval)
+VALUES
+('1m', '1 Minute', 1, '1m'),
+('5m', '5 Minutes', 5, '5m'),
+('15m', '15 Minutes', 15, '15m'),
+('30m', '30 Minutes', 30, '30m'),
+('1h', '1 Hour', 60, '1h'),
+('4h', '4 Hours', 240, '4h'),
+('1d', '1 Day', 1440, '1d'),
+('1w', '1 Week', 10080, '1w')
+ON CONFLICT (name) DO NOTHING;
+
+-- Length of a timeframe in minutes, 1 for unknown timeframes
+CREATE OR REPLACE FUNCTION timeframe_minutes(
+    p_timeframe timeframe_type
+)
+RETURNS INT AS $$
+    SELECT COALESCE(
+        (SELECT t.minutes FROM timeframes t WHERE t.name = p_timeframe::text),
+        1
+    );
+$$ LANGUAGE sql STABLE;
+
+-- get_candles with the bucket size taken from the timeframe catalog
+CREATE OR REPLACE FUNCTION get_candles(
+    p_symbol_id INT,
+    p_timeframe timeframe_type,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_limit INT DEFAULT NULL,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    symbol_id INT,
+    candle_time TIMESTAMPTZ,
+    open NUMERIC(20,8),
+    high NUMERIC(20,8),
+    low NUMERIC(20,8),
+    close NUMERIC(20,8),
+    volume NUMERIC(20,8)
+) AS $$
+DECLARE
+    interval_minutes INT;
+BEGIN
+    interval_minutes := timeframe_minutes(p_timeframe);
+
+    -- Return 1m data directly with pagination
+    IF interval_minutes = 1 THEN
+        RETURN QUERY
+        SELECT c.symbol_id, c.candle_time, c.open, c.high, c.low, c.close, c.volume
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time
+        ORDER BY c.candle_time DESC
+        LIMIT p_limit
+        OFFSET p_offset;
+    ELSE
+        -- Aggregate candles for higher timeframes with pagination
+        RETURN QUERY
+        SELECT
+            c.symbol_id,
+            time_bucket((interval_minutes || ' minutes')::interval, c.candle_time) AS candle_time,
+            FIRST(c.open, c.candle_time) AS open,
+            MAX(c.high) AS high,
+            MIN(c.low) AS low,
+            LAST(c.close, c.candle_time) AS close,
+            SUM(c.volume) AS volume
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time
+        GROUP BY c.symbol_id, time_bucket((interval_minutes || ' minutes')::interval, c.candle_time)
+        ORDER BY candle_time DESC
+        LIMIT p_limit
+        OFFSET p_offset;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- count_candles with the bucket size taken from the timeframe catalog
+CREATE OR REPLACE FUNCTION count_candles(
+    p_symbol_id INT,
+    p_timeframe timeframe_type,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ
+)
+RETURNS BIGINT AS $$
+DECLARE
+    interval_minutes INT;
+    candle_count BIGINT;
+BEGIN
+    interval_minutes := timeframe_minutes(p_timeframe);
+
+    -- Count for 1m data directly
+    IF interval_minutes = 1 THEN
+        SELECT COUNT(*)
+        INTO candle_count
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time;
+    ELSE
+        -- Count aggregated candles for higher timeframes
+        SELECT COUNT(DISTINCT time_bucket((interval_minutes || ' minutes')::interval, c.candle_time))
+        INTO candle_count
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time;
+    END IF;
+
+    RETURN candle_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the timeframe catalog ordered by length
+CREATE OR REPLACE FUNCTION get_timeframes(
+    p_include_inactive BOOLEAN DEFAULT FALSE
+)
+RETURNS TABLE (
+    name VARCHAR(10),
+    display_name VARCHAR(50),
+    minutes INT,
+    provider_interval VARCHAR(10),
+    is_custom BOOLEAN,
+    is_active BOOLEAN,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.name,
+        t.display_name,
+        t.minutes,
+        t.provider_interval,
+        t.is_custom,
+        t.is_active,
+        t.created_at,
+        t.updated_at
+    FROM timeframes t
+    WHERE p_include_inactive OR t.is_active
+    ORDER BY t.minutes, t.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Add a custom timeframe. The name must already be a timeframe_type value.
+CREATE OR REPLACE FUNCTION create_timeframe(
+    p_name VARCHAR(10),
+    p_display_name VARCHAR(50),
+    p_minutes INT,
+    p_provider_interval VARCHAR(10)
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    INSERT INTO timeframes (name, display_name, minutes, provider_interval, is_custom)
+    VALUES (p_name, p_display_name, p_minutes, p_provider_interval, TRUE)
+    ON CONFLICT (name) DO NOTHING;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update the display name, provider interval and active flag of a timeframe.
+-- NULL arguments keep the current value.
+CREATE OR REPLACE FUNCTION update_timeframe(
+    p_name VARCHAR(10),
+    p_display_name VARCHAR(50),
+    p_provider_interval VARCHAR(10),
+    p_is_active BOOLEAN
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE timeframes
+    SET
+        display_name = COALESCE(p_display_name, display_name),
+        provider_interval = COALESCE(p_provider_interval, provider_interval),
+        is_active = COALESCE(p_is_active, is_active),
+        updated_at = NOW()
+    WHERE name = p_name;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a custom timeframe that no backtest or download job uses
+CREATE OR REPLACE FUNCTION delete_timeframe(
+    p_name VARCHAR(10)
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM backtests b WHERE b.timeframe::text = p_name)
+       OR EXISTS (SELECT 1 FROM market_data_download_jobs j WHERE j.timeframe::text = p_name) THEN
+        RAISE EXCEPTION 'timeframe % is in use', p_name;
+    END IF;
+
+    DELETE FROM timeframes WHERE name = p_name AND is_custom;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,124 @@
+-- ==========================================
+-- IDEMPOTENCY KEYS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Idempotency-Key headers of a user's POST requests with the stored response, replayed when
+-- the request is retried. fingerprint hashes the method, path and body so a key reused for a
+-- different request is rejected. A key stays 'processing' until its response is stored.
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+  "user_id" int NOT NULL,
+  "key" varchar(255) NOT NULL,
+  "fingerprint" varchar(64) NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'processing' CHECK ("status" IN ('processing', 'completed')),
+  "response_status" int,
+  "response_body" bytea,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "locked_until" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  PRIMARY KEY ("user_id", "key")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("user_id", "expires_at");
+
+-- Claim an idempotency key for a request. Returns 'claimed' when the request should run,
+-- 'completed' with the stored response when it already ran, 'processing' while another
+-- attempt holds the key and 'mismatch' when the key was used for a different request.
+-- A processing key whose lock expired (its request died) can be claimed again.
+CREATE OR REPLACE FUNCTION claim_idempotency_key(
+    p_user_id INT,
+    p_key VARCHAR,
+    p_fingerprint VARCHAR,
+    p_lock_seconds INT,
+    p_ttl_seconds INT
+)
+RETURNS TABLE (
+    outcome VARCHAR,
+    response_status INT,
+    response_body BYTEA
+) AS $$
+DECLARE
+    v_existing RECORD;
+BEGIN
+    DELETE FROM idempotency_keys k
+    WHERE k.user_id = p_user_id AND k.expires_at < NOW();
+
+    INSERT INTO idempotency_keys (user_id, key, fingerprint, created_at, locked_until, expires_at)
+    VALUES (
+        p_user_id,
+        p_key,
+        p_fingerprint,
+        NOW(),
+        NOW() + p_lock_seconds * INTERVAL '1 second',
+        NOW() + p_ttl_seconds * INTERVAL '1 second'
+    )
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        RETURN QUERY SELECT 'claimed'::VARCHAR, NULL::INT, NULL::BYTEA;
+        RETURN;
+    END IF;
+
+    SELECT k.fingerprint, k.status, k.response_status, k.response_body, k.locked_until
+    INTO v_existing
+    FROM idempotency_keys k
+    WHERE k.user_id = p_user_id AND k.key = p_key
+    FOR UPDATE;
+
+    IF v_existing.fingerprint <> p_fingerprint THEN
+        RETURN QUERY SELECT 'mismatch'::VARCHAR, NULL::INT, NULL::BYTEA;
+    ELSIF v_existing.status = 'completed' THEN
+        RETURN QUERY SELECT 'completed'::VARCHAR, v_existing.response_status, v_existing.response_body;
+    ELSIF v_existing.locked_until < NOW() THEN
+        UPDATE idempotency_keys k
+        SET locked_until = NOW() + p_lock_seconds * INTERVAL '1 second'
+        WHERE k.user_id = p_user_id AND k.key = p_key;
+
+        RETURN QUERY SELECT 'claimed'::VARCHAR, NULL::INT, NULL::BYTEA;
+    ELSE
+        RETURN QUERY SELECT 'processing'::VARCHAR, NULL::INT, NULL::BYTEA;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store the response of a claimed idempotency key
+CREATE OR REPLACE FUNCTION complete_idempotency_key(
+    p_user_id INT,
+    p_key VARCHAR,
+    p_response_status INT,
+    p_response_body BYTEA
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE idempotency_keys
+    SET
+        status = 'completed',
+        response_status = p_response_status,
+        response_body = p_response_body
+    WHERE user_id = p_user_id AND key = p_key AND status = 'processing';
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Release a claimed idempotency key without a response, so a retry runs the request again
+CREATE OR REPLACE FUNCTION release_idempotency_key(
+    p_user_id INT,
+    p_key VARCHAR
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM idempotency_keys
+    WHERE user_id = p_user_id AND key = p_key AND status = 'processing';
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
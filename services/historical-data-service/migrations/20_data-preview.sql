@@ -0,0 +1,60 @@
+-- ==========================================
+-- BACKTEST DATA PREVIEW
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Time of the first and last stored candle of a symbol in a time range
+CREATE OR REPLACE FUNCTION get_candle_bounds(
+    p_symbol_id INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ
+)
+RETURNS TABLE (
+    first_time TIMESTAMPTZ,
+    last_time TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT MIN(c.candle_time), MAX(c.candle_time)
+    FROM candles c
+    WHERE c.symbol_id = p_symbol_id
+      AND c.candle_time BETWEEN p_start_time AND p_end_time;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Candles of a symbol in a time range aggregated into buckets of p_bucket_minutes,
+-- oldest first. Used to chart a preview of long ranges with a bounded number of points.
+CREATE OR REPLACE FUNCTION get_candle_preview(
+    p_symbol_id INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_bucket_minutes INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    candle_time TIMESTAMPTZ,
+    open NUMERIC(20,8),
+    high NUMERIC(20,8),
+    low NUMERIC(20,8),
+    close NUMERIC(20,8),
+    volume NUMERIC(20,8)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.symbol_id,
+        time_bucket((p_bucket_minutes || ' minutes')::interval, c.candle_time) AS candle_time,
+        FIRST(c.open, c.candle_time) AS open,
+        MAX(c.high) AS high,
+        MIN(c.low) AS low,
+        LAST(c.close, c.candle_time) AS close,
+        SUM(c.volume) AS volume
+    FROM candles c
+    WHERE c.symbol_id = p_symbol_id
+      AND c.candle_time BETWEEN p_start_time AND p_end_time
+    GROUP BY c.symbol_id, time_bucket((p_bucket_minutes || ' minutes')::interval, c.candle_time)
+    ORDER BY candle_time ASC;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
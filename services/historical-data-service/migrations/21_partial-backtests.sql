@@ -0,0 +1,45 @@
+-- ==========================================
+-- PARTIALLY COMPLETED BACKTESTS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Backtests whose symbols only partly succeeded finish with status 'partial' and no
+-- longer count against the concurrent backtest quota
+CREATE OR REPLACE FUNCTION get_user_quota_usage(
+    p_user_id INT,
+    p_day_start TIMESTAMPTZ
+)
+RETURNS TABLE (
+    concurrent_backtests INT,
+    backtests_today INT,
+    download_jobs_today INT,
+    stored_candles BIGINT,
+    reserved_candles BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        (SELECT COUNT(*)::INT
+         FROM backtests b
+         WHERE b.user_id = p_user_id
+           AND b.status NOT IN ('completed', 'partial', 'failed', 'cancelled')
+           AND b.created_at > NOW() - INTERVAL '1 day'),
+        (SELECT COUNT(*)::INT
+         FROM backtests b
+         WHERE b.user_id = p_user_id
+           AND b.created_at >= p_day_start),
+        (SELECT COUNT(*)::INT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id
+           AND j.created_at >= p_day_start),
+        (SELECT COALESCE(SUM(j.processed_candles), 0)::BIGINT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id),
+        (SELECT COALESCE(SUM(GREATEST(j.total_candles - j.processed_candles, 0)), 0)::BIGINT
+         FROM market_data_download_jobs j
+         WHERE j.user_id = p_user_id
+           AND j.status IN ('pending', 'in_progress'));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,88 @@
+-- ==========================================
+-- BACKTEST RUN RETRIES
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- How often a failed run was retried and how often it may be
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "retry_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "max_retries" int NOT NULL DEFAULT 3;
+
+-- Count the failed runs of a backtest
+CREATE OR REPLACE FUNCTION count_failed_backtest_runs(
+    p_backtest_id INT
+)
+RETURNS INT AS $$
+    SELECT COUNT(*)::INT
+    FROM backtest_runs br
+    WHERE br.backtest_id = p_backtest_id AND br.status = 'failed';
+$$ LANGUAGE sql STABLE;
+
+-- Get the failed runs of a backtest with their retries
+CREATE OR REPLACE FUNCTION get_failed_backtest_runs(
+    p_backtest_id INT
+)
+RETURNS TABLE (
+    run_id INT,
+    symbol_id INT,
+    retry_count INT,
+    max_retries INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT br.id, br.symbol_id, br.retry_count, br.max_retries
+    FROM backtest_runs br
+    WHERE br.backtest_id = p_backtest_id AND br.status = 'failed'
+    ORDER BY br.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Queue the failed runs of a backtest that have retries left again. Their partial
+-- results and trades are dropped; completed runs are left alone. Returns the queued runs.
+CREATE OR REPLACE FUNCTION retry_failed_backtest_runs(
+    p_backtest_id INT
+)
+RETURNS TABLE (
+    run_id INT,
+    symbol_id INT,
+    retry_count INT,
+    max_retries INT
+) AS $$
+BEGIN
+    DELETE FROM backtest_results res
+    USING backtest_runs br
+    WHERE res.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'failed'
+      AND br.retry_count < br.max_retries;
+
+    DELETE FROM backtest_trades t
+    USING backtest_runs br
+    WHERE t.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'failed'
+      AND br.retry_count < br.max_retries;
+
+    RETURN QUERY
+    UPDATE backtest_runs br
+    SET
+        status = 'pending',
+        retry_count = br.retry_count + 1,
+        completed_at = NULL
+    WHERE br.backtest_id = p_backtest_id
+      AND br.status = 'failed'
+      AND br.retry_count < br.max_retries
+    RETURNING br.id, br.symbol_id, br.retry_count, br.max_retries;
+
+    IF FOUND THEN
+        UPDATE backtests
+        SET
+            status = 'running',
+            error_message = NULL,
+            completed_at = NULL,
+            updated_at = NOW()
+        WHERE id = p_backtest_id;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,125 @@
+-- ==========================================
+-- STRATEGY BACKTEST HISTORY
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_backtests_user_strategy" ON "backtests" ("user_id", "strategy_id");
+
+-- Get a user's backtests of any of the given strategies (the versions of one strategy),
+-- newest first, with metrics summarized over their completed runs. A NULL version
+-- returns the backtests of every version.
+CREATE OR REPLACE FUNCTION get_strategy_backtest_history(
+    p_user_id INT,
+    p_strategy_ids INT[],
+    p_version INT DEFAULT NULL,
+    p_limit INT DEFAULT 20,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    backtest_id INT,
+    strategy_id INT,
+    strategy_version INT,
+    name VARCHAR,
+    timeframe VARCHAR,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    initial_capital DOUBLE PRECISION,
+    status VARCHAR,
+    created_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    total_runs INT,
+    completed_runs INT,
+    total_trades INT,
+    avg_total_return DOUBLE PRECISION,
+    best_total_return DOUBLE PRECISION,
+    avg_sharpe_ratio DOUBLE PRECISION,
+    max_drawdown DOUBLE PRECISION
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        b.id,
+        b.strategy_id,
+        b.strategy_version,
+        COALESCE(b.name, '')::VARCHAR,
+        b.timeframe::VARCHAR,
+        b.start_date,
+        b.end_date,
+        b.initial_capital::DOUBLE PRECISION,
+        b.status::VARCHAR,
+        b.created_at,
+        b.completed_at,
+        COUNT(br.id)::INT,
+        COUNT(br.id) FILTER (WHERE br.status = 'completed')::INT,
+        COALESCE(SUM(res.total_trades), 0)::INT,
+        AVG(res.total_return)::DOUBLE PRECISION,
+        MAX(res.total_return)::DOUBLE PRECISION,
+        AVG(res.sharpe_ratio)::DOUBLE PRECISION,
+        MAX(res.max_drawdown)::DOUBLE PRECISION
+    FROM backtests b
+    LEFT JOIN backtest_runs br ON br.backtest_id = b.id
+    LEFT JOIN backtest_results res ON res.backtest_run_id = br.id AND br.status = 'completed'
+    WHERE b.user_id = p_user_id
+      AND b.strategy_id = ANY(p_strategy_ids)
+      AND (p_version IS NULL OR b.strategy_version = p_version)
+    GROUP BY b.id
+    ORDER BY b.created_at DESC, b.id DESC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count the backtests returned by get_strategy_backtest_history
+CREATE OR REPLACE FUNCTION count_strategy_backtest_history(
+    p_user_id INT,
+    p_strategy_ids INT[],
+    p_version INT DEFAULT NULL
+)
+RETURNS INT AS $$
+    SELECT COUNT(*)::INT
+    FROM backtests b
+    WHERE b.user_id = p_user_id
+      AND b.strategy_id = ANY(p_strategy_ids)
+      AND (p_version IS NULL OR b.strategy_version = p_version);
+$$ LANGUAGE sql STABLE;
+
+-- Summarize a user's backtests of the given strategies per strategy version, oldest
+-- version first, to show how performance evolved across versions
+CREATE OR REPLACE FUNCTION get_strategy_backtest_version_summary(
+    p_user_id INT,
+    p_strategy_ids INT[]
+)
+RETURNS TABLE (
+    strategy_version INT,
+    backtests INT,
+    completed_backtests INT,
+    completed_runs INT,
+    avg_total_return DOUBLE PRECISION,
+    best_total_return DOUBLE PRECISION,
+    avg_sharpe_ratio DOUBLE PRECISION,
+    avg_max_drawdown DOUBLE PRECISION,
+    last_backtest_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        b.strategy_version,
+        COUNT(DISTINCT b.id)::INT,
+        COUNT(DISTINCT b.id) FILTER (WHERE b.status = 'completed')::INT,
+        COUNT(res.id)::INT,
+        AVG(res.total_return)::DOUBLE PRECISION,
+        MAX(res.total_return)::DOUBLE PRECISION,
+        AVG(res.sharpe_ratio)::DOUBLE PRECISION,
+        AVG(res.max_drawdown)::DOUBLE PRECISION,
+        MAX(b.created_at)
+    FROM backtests b
+    LEFT JOIN backtest_runs br ON br.backtest_id = b.id AND br.status = 'completed'
+    LEFT JOIN backtest_results res ON res.backtest_run_id = br.id
+    WHERE b.user_id = p_user_id
+      AND b.strategy_id = ANY(p_strategy_ids)
+    GROUP BY b.strategy_version
+    ORDER BY b.strategy_version;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
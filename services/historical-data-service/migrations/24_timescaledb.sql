@@ -0,0 +1,394 @@
+-- ==========================================
+-- OPTIONAL TIMESCALEDB
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Candles are stored in a TimescaleDB hypertable when the extension is installed and in a
+-- plain table otherwise. The migration runner installs the extension and calls
+-- setup_timescaledb when TimescaleDB is enabled in the config; the candle functions below
+-- check which of the two they run on.
+
+-- Whether the TimescaleDB extension is installed
+CREATE OR REPLACE FUNCTION timescaledb_enabled()
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb');
+$$ LANGUAGE sql STABLE;
+
+-- Start of the p_minutes long bucket p_time falls in. Buckets are aligned like
+-- TimescaleDB's time_bucket (to Monday 2000-01-03 UTC), so both give the same candles.
+CREATE OR REPLACE FUNCTION candle_bucket(
+    p_minutes INT,
+    p_time TIMESTAMPTZ
+)
+RETURNS TIMESTAMPTZ AS $$
+    SELECT to_timestamp(
+        946857600 + floor((extract(epoch FROM p_time) - 946857600) / (p_minutes * 60)) * (p_minutes * 60)
+    );
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Continuous aggregates of candles that exist, with their bucket length. Empty without
+-- TimescaleDB.
+CREATE OR REPLACE FUNCTION get_candle_aggregates()
+RETURNS TABLE (
+    view_name TEXT,
+    bucket_minutes INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT v.view_name, v.bucket_minutes
+    FROM (VALUES ('candles_1h', 60), ('candles_1d', 1440)) AS v (view_name, bucket_minutes)
+    WHERE to_regclass(v.view_name) IS NOT NULL
+    ORDER BY v.bucket_minutes;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Turn candles into a hypertable with the given chunk interval, compress chunks older than
+-- p_compress_after (NULL leaves chunks uncompressed) and create the hourly and daily
+-- continuous aggregates higher timeframes are read from. Safe to run again with other
+-- settings. Returns the continuous aggregates it created, which hold no data until they
+-- are first refreshed.
+CREATE OR REPLACE FUNCTION setup_timescaledb(
+    p_chunk_interval INTERVAL,
+    p_compress_after INTERVAL
+)
+RETURNS TABLE (view_name TEXT) AS $$
+DECLARE
+    v_aggregate RECORD;
+BEGIN
+    IF NOT timescaledb_enabled() THEN
+        RAISE EXCEPTION 'timescaledb extension is not installed';
+    END IF;
+
+    PERFORM create_hypertable('candles', 'candle_time',
+        chunk_time_interval => p_chunk_interval,
+        if_not_exists => TRUE,
+        migrate_data => TRUE);
+    -- Applies to chunks created from now on
+    PERFORM set_chunk_time_interval('candles', p_chunk_interval);
+
+    PERFORM remove_compression_policy('candles', if_exists => TRUE);
+    IF p_compress_after IS NOT NULL THEN
+        IF NOT EXISTS (
+            SELECT 1 FROM timescaledb_information.hypertables h
+            WHERE h.hypertable_name = 'candles' AND h.compression_enabled
+        ) THEN
+            ALTER TABLE candles SET (
+                timescaledb.compress,
+                timescaledb.compress_segmentby = 'symbol_id',
+                timescaledb.compress_orderby = 'candle_time DESC'
+            );
+        END IF;
+        PERFORM add_compression_policy('candles', p_compress_after);
+    END IF;
+
+    -- Real-time aggregates, so candles newer than the last refresh are included
+    FOR v_aggregate IN
+        SELECT *
+        FROM (VALUES
+            ('candles_1h', INTERVAL '1 hour', INTERVAL '3 days', INTERVAL '1 hour', INTERVAL '30 minutes'),
+            ('candles_1d', INTERVAL '1 day', INTERVAL '7 days', INTERVAL '1 day', INTERVAL '1 hour')
+        ) AS a (view_name, bucket, start_offset, end_offset, schedule_interval)
+    LOOP
+        IF to_regclass(v_aggregate.view_name) IS NULL THEN
+            EXECUTE format($q$
+                CREATE MATERIALIZED VIEW %I
+                WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+                SELECT
+                    c.symbol_id,
+                    time_bucket(%L::interval, c.candle_time) AS candle_time,
+                    FIRST(c.open, c.candle_time) AS open,
+                    MAX(c.high) AS high,
+                    MIN(c.low) AS low,
+                    LAST(c.close, c.candle_time) AS close,
+                    SUM(c.volume) AS volume
+                FROM candles c
+                GROUP BY c.symbol_id, time_bucket(%L::interval, c.candle_time)
+                WITH NO DATA
+            $q$, v_aggregate.view_name, v_aggregate.bucket, v_aggregate.bucket);
+
+            view_name := v_aggregate.view_name;
+            RETURN NEXT;
+        END IF;
+
+        PERFORM add_continuous_aggregate_policy(v_aggregate.view_name,
+            start_offset => v_aggregate.start_offset,
+            end_offset => v_aggregate.end_offset,
+            schedule_interval => v_aggregate.schedule_interval,
+            if_not_exists => TRUE);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Candles of a symbol in a time range aggregated into buckets of p_bucket_minutes, in no
+-- particular order. With TimescaleDB, buckets that are a whole number of hours or days are
+-- built from the continuous aggregates: the hours or days wholly inside the range come from
+-- the aggregate and only the partial ones at its edges from candles.
+CREATE OR REPLACE FUNCTION aggregate_candles(
+    p_symbol_id INT,
+    p_bucket_minutes INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ
+)
+RETURNS TABLE (
+    symbol_id INT,
+    candle_time TIMESTAMPTZ,
+    open NUMERIC(20,8),
+    high NUMERIC(20,8),
+    low NUMERIC(20,8),
+    close NUMERIC(20,8),
+    volume NUMERIC(20,8)
+) AS $$
+DECLARE
+    v_bucket INTERVAL := make_interval(mins => p_bucket_minutes);
+    v_source TEXT;
+    v_source_bucket INTERVAL;
+    v_inner_start TIMESTAMPTZ;
+    v_inner_end TIMESTAMPTZ;
+BEGIN
+    IF NOT timescaledb_enabled() THEN
+        RETURN QUERY
+        SELECT
+            c.symbol_id,
+            candle_bucket(p_bucket_minutes, c.candle_time) AS candle_time,
+            (array_agg(c.open ORDER BY c.candle_time))[1] AS open,
+            MAX(c.high) AS high,
+            MIN(c.low) AS low,
+            (array_agg(c.close ORDER BY c.candle_time DESC))[1] AS close,
+            SUM(c.volume) AS volume
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time
+        GROUP BY c.symbol_id, candle_bucket(p_bucket_minutes, c.candle_time);
+        RETURN;
+    END IF;
+
+    -- Use the coarsest continuous aggregate the bucket is a multiple of
+    IF p_bucket_minutes % 1440 = 0 AND to_regclass('candles_1d') IS NOT NULL THEN
+        v_source := 'candles_1d';
+        v_source_bucket := INTERVAL '1 day';
+    ELSIF p_bucket_minutes % 60 = 0 AND to_regclass('candles_1h') IS NOT NULL THEN
+        v_source := 'candles_1h';
+        v_source_bucket := INTERVAL '1 hour';
+    END IF;
+
+    IF v_source IS NULL THEN
+        RETURN QUERY
+        SELECT
+            c.symbol_id,
+            time_bucket(v_bucket, c.candle_time) AS candle_time,
+            FIRST(c.open, c.candle_time) AS open,
+            MAX(c.high) AS high,
+            MIN(c.low) AS low,
+            LAST(c.close, c.candle_time) AS close,
+            SUM(c.volume) AS volume
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time
+        GROUP BY c.symbol_id, time_bucket(v_bucket, c.candle_time);
+        RETURN;
+    END IF;
+
+    -- Aggregate buckets in [v_inner_start, v_inner_end) hold only candles inside the range
+    v_inner_start := time_bucket(v_source_bucket, p_start_time);
+    IF v_inner_start < p_start_time THEN
+        v_inner_start := v_inner_start + v_source_bucket;
+    END IF;
+    v_inner_end := time_bucket(v_source_bucket, p_end_time + INTERVAL '1 minute');
+
+    RETURN QUERY EXECUTE format($q$
+        SELECT
+            s.symbol_id,
+            time_bucket($4, s.candle_time) AS candle_time,
+            FIRST(s.open, s.candle_time) AS open,
+            MAX(s.high) AS high,
+            MIN(s.low) AS low,
+            LAST(s.close, s.candle_time) AS close,
+            SUM(s.volume) AS volume
+        FROM (
+            SELECT a.symbol_id, a.candle_time, a.open, a.high, a.low, a.close, a.volume
+            FROM %I a
+            WHERE a.symbol_id = $1
+              AND a.candle_time >= $5
+              AND a.candle_time < $6
+            UNION ALL
+            SELECT c.symbol_id, c.candle_time, c.open, c.high, c.low, c.close, c.volume
+            FROM candles c
+            WHERE c.symbol_id = $1
+              AND c.candle_time BETWEEN $2 AND $3
+              AND (c.candle_time < $5 OR c.candle_time >= $6)
+        ) s
+        GROUP BY s.symbol_id, time_bucket($4, s.candle_time)
+    $q$, v_source)
+    USING p_symbol_id, p_start_time, p_end_time, v_bucket, v_inner_start, v_inner_end;
+END;
+$$ LANGUAGE plpgsql;
+
+-- get_candles reading higher timeframes through aggregate_candles
+CREATE OR REPLACE FUNCTION get_candles(
+    p_symbol_id INT,
+    p_timeframe timeframe_type,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_limit INT DEFAULT NULL,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    symbol_id INT,
+    candle_time TIMESTAMPTZ,
+    open NUMERIC(20,8),
+    high NUMERIC(20,8),
+    low NUMERIC(20,8),
+    close NUMERIC(20,8),
+    volume NUMERIC(20,8)
+) AS $$
+DECLARE
+    interval_minutes INT;
+BEGIN
+    interval_minutes := timeframe_minutes(p_timeframe);
+
+    -- Return 1m data directly with pagination
+    IF interval_minutes = 1 THEN
+        RETURN QUERY
+        SELECT c.symbol_id, c.candle_time, c.open, c.high, c.low, c.close, c.volume
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time
+        ORDER BY c.candle_time DESC
+        LIMIT p_limit
+        OFFSET p_offset;
+    ELSE
+        RETURN QUERY
+        SELECT a.symbol_id, a.candle_time, a.open, a.high, a.low, a.close, a.volume
+        FROM aggregate_candles(p_symbol_id, interval_minutes, p_start_time, p_end_time) a
+        ORDER BY a.candle_time DESC
+        LIMIT p_limit
+        OFFSET p_offset;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- count_candles bucketing without TimescaleDB when it isn't installed
+CREATE OR REPLACE FUNCTION count_candles(
+    p_symbol_id INT,
+    p_timeframe timeframe_type,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ
+)
+RETURNS BIGINT AS $$
+DECLARE
+    interval_minutes INT;
+    candle_count BIGINT;
+BEGIN
+    interval_minutes := timeframe_minutes(p_timeframe);
+
+    -- Count for 1m data directly
+    IF interval_minutes = 1 THEN
+        SELECT COUNT(*)
+        INTO candle_count
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time;
+    ELSIF timescaledb_enabled() THEN
+        SELECT COUNT(DISTINCT time_bucket(make_interval(mins => interval_minutes), c.candle_time))
+        INTO candle_count
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time;
+    ELSE
+        SELECT COUNT(DISTINCT candle_bucket(interval_minutes, c.candle_time))
+        INTO candle_count
+        FROM candles c
+        WHERE c.symbol_id = p_symbol_id
+          AND c.candle_time BETWEEN p_start_time AND p_end_time;
+    END IF;
+
+    RETURN candle_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- get_candle_preview reading through aggregate_candles
+CREATE OR REPLACE FUNCTION get_candle_preview(
+    p_symbol_id INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_bucket_minutes INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    candle_time TIMESTAMPTZ,
+    open NUMERIC(20,8),
+    high NUMERIC(20,8),
+    low NUMERIC(20,8),
+    close NUMERIC(20,8),
+    volume NUMERIC(20,8)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT a.symbol_id, a.candle_time, a.open, a.high, a.low, a.close, a.volume
+    FROM aggregate_candles(p_symbol_id, p_bucket_minutes, p_start_time, p_end_time) a
+    ORDER BY a.candle_time ASC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- get_data_stats estimating candle counts and sizes from the PostgreSQL catalog when
+-- TimescaleDB isn't installed
+CREATE OR REPLACE FUNCTION get_data_stats(p_since TIMESTAMPTZ)
+RETURNS TABLE (
+    backtests_created BIGINT,
+    backtests_completed BIGINT,
+    backtests_failed BIGINT,
+    active_backtesters BIGINT,
+    download_jobs_created BIGINT,
+    download_jobs_completed BIGINT,
+    download_jobs_failed BIGINT,
+    candles_downloaded BIGINT,
+    symbols_with_data BIGINT,
+    total_candles BIGINT,
+    storage_bytes BIGINT
+) AS $$
+DECLARE
+    v_total_candles BIGINT;
+    v_storage_bytes BIGINT;
+BEGIN
+    IF timescaledb_enabled() THEN
+        SELECT approximate_row_count('candles'), hypertable_size('candles')
+        INTO v_total_candles, v_storage_bytes;
+    ELSE
+        SELECT GREATEST(c.reltuples, 0)::BIGINT, pg_total_relation_size(c.oid)
+        INTO v_total_candles, v_storage_bytes
+        FROM pg_class c
+        WHERE c.oid = 'candles'::regclass;
+    END IF;
+
+    RETURN QUERY
+    SELECT
+        b.created,
+        b.completed,
+        b.failed,
+        b.users,
+        j.created,
+        j.completed,
+        j.failed,
+        j.candles,
+        (SELECT COUNT(*) FROM symbols s WHERE s.data_available),
+        v_total_candles,
+        v_storage_bytes
+    FROM
+        (SELECT
+            COUNT(*) AS created,
+            COUNT(*) FILTER (WHERE bt.status = 'completed') AS completed,
+            COUNT(*) FILTER (WHERE bt.status = 'failed') AS failed,
+            COUNT(DISTINCT bt.user_id) AS users
+         FROM backtests bt
+         WHERE bt.created_at >= p_since) b,
+        (SELECT
+            COUNT(*) AS created,
+            COUNT(*) FILTER (WHERE dj.status = 'completed') AS completed,
+            COUNT(*) FILTER (WHERE dj.status = 'failed') AS failed,
+            COALESCE(SUM(dj.processed_candles), 0)::BIGINT AS candles
+         FROM market_data_download_jobs dj
+         WHERE dj.created_at >= p_since) j;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- ==========================================
+-- DOWNLOAD BANDWIDTH METRICS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- What each download job fetched from its provider, summed over all of its attempts.
+-- Rows are written after every provider request.
+CREATE TABLE IF NOT EXISTS "download_job_metrics" (
+  "job_id" int PRIMARY KEY REFERENCES "market_data_download_jobs" ("id") ON DELETE CASCADE,
+  "requests" int NOT NULL DEFAULT 0,
+  "request_weight" int NOT NULL DEFAULT 0,
+  "bytes_downloaded" bigint NOT NULL DEFAULT 0,
+  "candles_downloaded" bigint NOT NULL DEFAULT 0,
+  "fetch_ms" bigint NOT NULL DEFAULT 0, -- Time spent on provider requests
+  "throttled_ms" bigint NOT NULL DEFAULT 0, -- Time spent waiting for the provider's rate-limit budget
+  "rate_limited" int NOT NULL DEFAULT 0, -- Requests the provider rejected with 429 or 418
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Add the cost of provider requests to a job's metrics
+CREATE OR REPLACE FUNCTION record_download_job_metrics(
+    p_job_id INT,
+    p_requests INT,
+    p_request_weight INT,
+    p_bytes BIGINT,
+    p_candles BIGINT,
+    p_fetch_ms BIGINT,
+    p_throttled_ms BIGINT,
+    p_rate_limited INT
+)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO download_job_metrics (
+        job_id, requests, request_weight, bytes_downloaded, candles_downloaded,
+        fetch_ms, throttled_ms, rate_limited
+    )
+    VALUES (
+        p_job_id, p_requests, p_request_weight, p_bytes, p_candles,
+        p_fetch_ms, p_throttled_ms, p_rate_limited
+    )
+    ON CONFLICT (job_id) DO UPDATE SET
+        requests = download_job_metrics.requests + EXCLUDED.requests,
+        request_weight = download_job_metrics.request_weight + EXCLUDED.request_weight,
+        bytes_downloaded = download_job_metrics.bytes_downloaded + EXCLUDED.bytes_downloaded,
+        candles_downloaded = download_job_metrics.candles_downloaded + EXCLUDED.candles_downloaded,
+        fetch_ms = download_job_metrics.fetch_ms + EXCLUDED.fetch_ms,
+        throttled_ms = download_job_metrics.throttled_ms + EXCLUDED.throttled_ms,
+        rate_limited = download_job_metrics.rate_limited + EXCLUDED.rate_limited,
+        updated_at = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a job's metrics; no row means it hasn't made a request yet
+CREATE OR REPLACE FUNCTION get_download_job_metrics(p_job_id INT)
+RETURNS SETOF download_job_metrics AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM download_job_metrics m WHERE m.job_id = p_job_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,141 @@
+-- ==========================================
+-- BACKTEST DATA MANIFESTS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- The candles each symbol of a backtest had in its range when the backtest was created,
+-- so a rerun or an audit can tell whether the data changed since. The checksum covers
+-- every candle's time and values, catching corrected candles as well as added ones.
+CREATE TABLE IF NOT EXISTS "backtest_data_manifests" (
+  "backtest_id" int NOT NULL REFERENCES "backtests" ("id") ON DELETE CASCADE,
+  "symbol_id" int NOT NULL,
+  "candle_count" bigint NOT NULL,
+  "first_candle_time" timestamptz,
+  "last_candle_time" timestamptz,
+  "checksum" text NOT NULL,
+  "recorded_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("backtest_id", "symbol_id")
+);
+
+-- Pinned backtests refuse to rerun on data that differs from their manifest
+ALTER TABLE "backtests" ADD COLUMN IF NOT EXISTS "data_pinned" boolean NOT NULL DEFAULT false;
+
+-- Count, bounds and checksum of a symbol's candles in a range. The checksum sums a hash of
+-- each candle, so it doesn't depend on the order candles are read in.
+CREATE OR REPLACE FUNCTION candle_data_fingerprint(
+    p_symbol_id INT,
+    p_start_date TIMESTAMPTZ,
+    p_end_date TIMESTAMPTZ
+)
+RETURNS TABLE (
+    candle_count BIGINT,
+    first_candle_time TIMESTAMPTZ,
+    last_candle_time TIMESTAMPTZ,
+    checksum TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        COUNT(*),
+        MIN(c.candle_time),
+        MAX(c.candle_time),
+        md5(COALESCE(SUM(hashtextextended(concat_ws(',',
+            EXTRACT(EPOCH FROM c.candle_time), c.open, c.high, c.low, c.close, c.volume
+        ), 0)::NUMERIC), 0)::TEXT)
+    FROM candles c
+    WHERE
+        c.symbol_id = p_symbol_id
+        AND c.candle_time >= p_start_date
+        AND c.candle_time <= p_end_date;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Record the data manifest of a new backtest and whether it is pinned to it.
+-- Returns the number of symbols recorded.
+CREATE OR REPLACE FUNCTION record_backtest_data_manifest(
+    p_backtest_id INT,
+    p_pinned BOOLEAN
+)
+RETURNS INT AS $$
+DECLARE
+    v_start_date TIMESTAMPTZ;
+    v_end_date TIMESTAMPTZ;
+    v_recorded INT;
+BEGIN
+    SELECT b.start_date, b.end_date INTO v_start_date, v_end_date
+    FROM backtests b
+    WHERE b.id = p_backtest_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Backtest not found';
+    END IF;
+
+    INSERT INTO backtest_data_manifests (
+        backtest_id, symbol_id, candle_count, first_candle_time, last_candle_time, checksum
+    )
+    SELECT p_backtest_id, r.symbol_id, f.candle_count, f.first_candle_time, f.last_candle_time, f.checksum
+    FROM (
+        SELECT DISTINCT br.symbol_id
+        FROM backtest_runs br
+        WHERE br.backtest_id = p_backtest_id
+    ) r
+    CROSS JOIN LATERAL candle_data_fingerprint(r.symbol_id, v_start_date, v_end_date) f
+    ON CONFLICT (backtest_id, symbol_id) DO NOTHING;
+
+    GET DIAGNOSTICS v_recorded = ROW_COUNT;
+
+    UPDATE backtests
+    SET data_pinned = p_pinned
+    WHERE id = p_backtest_id;
+
+    RETURN v_recorded;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Whether a backtest is pinned to its data manifest
+CREATE OR REPLACE FUNCTION is_backtest_data_pinned(p_backtest_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    RETURN COALESCE((SELECT b.data_pinned FROM backtests b WHERE b.id = p_backtest_id), false);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the data manifest of a backtest next to the data its symbols have now
+CREATE OR REPLACE FUNCTION get_backtest_data_manifest(p_backtest_id INT)
+RETURNS TABLE (
+    symbol_id INT,
+    symbol VARCHAR,
+    candle_count BIGINT,
+    first_candle_time TIMESTAMPTZ,
+    last_candle_time TIMESTAMPTZ,
+    checksum TEXT,
+    recorded_at TIMESTAMPTZ,
+    current_candle_count BIGINT,
+    current_first_candle_time TIMESTAMPTZ,
+    current_last_candle_time TIMESTAMPTZ,
+    current_checksum TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.symbol_id,
+        COALESCE(s.symbol, '')::VARCHAR,
+        m.candle_count,
+        m.first_candle_time,
+        m.last_candle_time,
+        m.checksum,
+        m.recorded_at,
+        f.candle_count,
+        f.first_candle_time,
+        f.last_candle_time,
+        f.checksum
+    FROM backtest_data_manifests m
+    JOIN backtests b ON b.id = m.backtest_id
+    LEFT JOIN symbols s ON s.id = m.symbol_id
+    CROSS JOIN LATERAL candle_data_fingerprint(m.symbol_id, b.start_date, b.end_date) f
+    WHERE m.backtest_id = p_backtest_id
+    ORDER BY m.symbol_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,236 @@
+-- ==========================================
+-- WATCHLISTS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Named lists of symbols a user follows. Symbols keep the order they were listed in.
+CREATE TABLE IF NOT EXISTS "watchlists" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamptz NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  UNIQUE ("user_id", "name")
+);
+
+CREATE TABLE IF NOT EXISTS "watchlist_symbols" (
+  "watchlist_id" int NOT NULL REFERENCES "watchlists" ("id") ON DELETE CASCADE,
+  "symbol_id" int NOT NULL REFERENCES "symbols" ("id") ON DELETE CASCADE,
+  "position" int NOT NULL,
+  PRIMARY KEY ("watchlist_id", "symbol_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_watchlists_user_id" ON "watchlists" ("user_id");
+
+-- Replace the symbols of a watchlist, keeping the first position of repeated symbols
+CREATE OR REPLACE FUNCTION set_watchlist_symbols(p_watchlist_id INT, p_symbol_ids INT[])
+RETURNS VOID AS $$
+DECLARE
+    v_missing INT;
+BEGIN
+    SELECT ids.symbol_id INTO v_missing
+    FROM unnest(p_symbol_ids) AS ids(symbol_id)
+    WHERE NOT EXISTS (SELECT 1 FROM symbols s WHERE s.id = ids.symbol_id)
+    LIMIT 1;
+
+    IF v_missing IS NOT NULL THEN
+        RAISE EXCEPTION 'Symbol not found: %', v_missing;
+    END IF;
+
+    DELETE FROM watchlist_symbols WHERE watchlist_id = p_watchlist_id;
+
+    INSERT INTO watchlist_symbols (watchlist_id, symbol_id, position)
+    SELECT p_watchlist_id, ids.symbol_id, MIN(ids.position)
+    FROM unnest(p_symbol_ids) WITH ORDINALITY AS ids(symbol_id, position)
+    GROUP BY ids.symbol_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a watchlist of a user with its symbols
+CREATE OR REPLACE FUNCTION create_watchlist(
+    p_user_id INT,
+    p_name VARCHAR,
+    p_description TEXT,
+    p_symbol_ids INT[]
+)
+RETURNS INT AS $$
+DECLARE
+    v_watchlist_id INT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM watchlists w WHERE w.user_id = p_user_id AND w.name = p_name) THEN
+        RAISE EXCEPTION 'A watchlist named "%" already exists', p_name;
+    END IF;
+
+    INSERT INTO watchlists (user_id, name, description)
+    VALUES (p_user_id, p_name, COALESCE(p_description, ''))
+    RETURNING id INTO v_watchlist_id;
+
+    PERFORM set_watchlist_symbols(v_watchlist_id, COALESCE(p_symbol_ids, '{}'));
+
+    RETURN v_watchlist_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a watchlist of a user; NULL arguments leave the field unchanged.
+-- Returns false if the user has no such watchlist.
+CREATE OR REPLACE FUNCTION update_watchlist(
+    p_watchlist_id INT,
+    p_user_id INT,
+    p_name VARCHAR,
+    p_description TEXT,
+    p_symbol_ids INT[]
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    PERFORM 1 FROM watchlists w
+    WHERE w.id = p_watchlist_id AND w.user_id = p_user_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        RETURN false;
+    END IF;
+
+    IF p_name IS NOT NULL AND EXISTS (
+        SELECT 1 FROM watchlists w
+        WHERE w.user_id = p_user_id AND w.name = p_name AND w.id <> p_watchlist_id
+    ) THEN
+        RAISE EXCEPTION 'A watchlist named "%" already exists', p_name;
+    END IF;
+
+    UPDATE watchlists
+    SET
+        name = COALESCE(p_name, name),
+        description = COALESCE(p_description, description),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = p_watchlist_id;
+
+    IF p_symbol_ids IS NOT NULL THEN
+        PERFORM set_watchlist_symbols(p_watchlist_id, p_symbol_ids);
+    END IF;
+
+    RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a watchlist of a user. Returns false if the user has no such watchlist.
+CREATE OR REPLACE FUNCTION delete_watchlist(p_watchlist_id INT, p_user_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM watchlists WHERE id = p_watchlist_id AND user_id = p_user_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- List the watchlists of a user by name; a watchlist ID returns only that watchlist
+CREATE OR REPLACE FUNCTION get_user_watchlists(p_user_id INT, p_watchlist_id INT)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR,
+    description TEXT,
+    symbol_count BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        w.id,
+        w.name,
+        w.description,
+        (SELECT COUNT(*) FROM watchlist_symbols ws WHERE ws.watchlist_id = w.id),
+        w.created_at,
+        w.updated_at
+    FROM watchlists w
+    WHERE
+        w.user_id = p_user_id
+        AND (p_watchlist_id IS NULL OR w.id = p_watchlist_id)
+    ORDER BY w.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the symbols of a user's watchlists in their listed order; a watchlist ID returns
+-- only the symbols of that watchlist
+CREATE OR REPLACE FUNCTION get_watchlist_symbols(p_user_id INT, p_watchlist_id INT)
+RETURNS TABLE (
+    watchlist_id INT,
+    symbol_id INT,
+    symbol VARCHAR,
+    name VARCHAR,
+    exchange VARCHAR,
+    asset_type VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT ws.watchlist_id, s.id, s.symbol, s.name, s.exchange, s.asset_type
+    FROM watchlist_symbols ws
+    JOIN watchlists w ON w.id = ws.watchlist_id
+    JOIN symbols s ON s.id = ws.symbol_id
+    WHERE
+        w.user_id = p_user_id
+        AND (p_watchlist_id IS NULL OR w.id = p_watchlist_id)
+    ORDER BY ws.watchlist_id, ws.position;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Quote the symbols of a watchlist from stored candles: the latest close and its change
+-- from the last close at least 24 hours before it, with the range and volume in between.
+-- Symbols without candles are returned with NULL quotes.
+CREATE OR REPLACE FUNCTION get_watchlist_quotes(p_watchlist_id INT)
+RETURNS TABLE (
+    symbol_id INT,
+    symbol VARCHAR,
+    name VARCHAR,
+    last_candle_time TIMESTAMPTZ,
+    last_price DOUBLE PRECISION,
+    price_24h_ago DOUBLE PRECISION,
+    change_24h DOUBLE PRECISION,
+    change_24h_percent DOUBLE PRECISION,
+    high_24h DOUBLE PRECISION,
+    low_24h DOUBLE PRECISION,
+    volume_24h DOUBLE PRECISION
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.id,
+        s.symbol,
+        s.name,
+        l.candle_time,
+        l.close::DOUBLE PRECISION,
+        p.close::DOUBLE PRECISION,
+        (l.close - p.close)::DOUBLE PRECISION,
+        CASE WHEN p.close > 0 THEN ((l.close - p.close) / p.close * 100)::DOUBLE PRECISION END,
+        d.high::DOUBLE PRECISION,
+        d.low::DOUBLE PRECISION,
+        d.volume::DOUBLE PRECISION
+    FROM watchlist_symbols ws
+    JOIN symbols s ON s.id = ws.symbol_id
+    LEFT JOIN LATERAL (
+        SELECT c.candle_time, c.close
+        FROM candles c
+        WHERE c.symbol_id = ws.symbol_id
+        ORDER BY c.candle_time DESC
+        LIMIT 1
+    ) l ON true
+    LEFT JOIN LATERAL (
+        SELECT c.close
+        FROM candles c
+        WHERE c.symbol_id = ws.symbol_id AND c.candle_time <= l.candle_time - INTERVAL '24 hours'
+        ORDER BY c.candle_time DESC
+        LIMIT 1
+    ) p ON true
+    LEFT JOIN LATERAL (
+        SELECT MAX(c.high) AS high, MIN(c.low) AS low, SUM(c.volume) AS volume
+        FROM candles c
+        WHERE
+            c.symbol_id = ws.symbol_id
+            AND c.candle_time > l.candle_time - INTERVAL '24 hours'
+            AND c.candle_time <= l.candle_time
+    ) d ON true
+    WHERE ws.watchlist_id = p_watchlist_id
+    ORDER BY ws.position;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,124 @@
+-- ==========================================
+-- BACKTEST TAGS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Tags label backtests for filtering: the ones users give and the ones added on creation
+-- (strategy version, hash of the strategy parameters and settings)
+ALTER TABLE "backtests" ADD COLUMN IF NOT EXISTS "tags" text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS "idx_backtests_tags" ON "backtests" USING GIN ("tags");
+
+-- Set the tags of a backtest
+CREATE OR REPLACE FUNCTION set_backtest_tags(p_backtest_id INT, p_tags TEXT[])
+RETURNS VOID AS $$
+BEGIN
+    UPDATE backtests
+    SET tags = COALESCE(p_tags, '{}')
+    WHERE id = p_backtest_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the tags of a backtest
+CREATE OR REPLACE FUNCTION get_backtest_tags(p_backtest_id INT)
+RETURNS TEXT[] AS $$
+BEGIN
+    RETURN COALESCE((SELECT b.tags FROM backtests b WHERE b.id = p_backtest_id), '{}');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Listing filters by tags: a backtest must have all of them.
+-- Replace rather than overload the old signatures.
+DROP FUNCTION IF EXISTS count_backtests(INT, VARCHAR, VARCHAR);
+DROP FUNCTION IF EXISTS get_backtests(INT, VARCHAR, VARCHAR, VARCHAR, VARCHAR, INT, INT);
+
+-- Count backtests function for pagination
+CREATE OR REPLACE FUNCTION count_backtests(
+    p_user_id INT,
+    p_search VARCHAR DEFAULT NULL,
+    p_status VARCHAR DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL
+)
+RETURNS BIGINT AS $$
+DECLARE
+    backtest_count BIGINT;
+BEGIN
+    SELECT COUNT(*)
+    INTO backtest_count
+    FROM backtests b
+    WHERE b.user_id = p_user_id
+    AND (NULLIF(p_search, '') IS NULL OR b.name ILIKE '%' || p_search || '%')
+    AND (NULLIF(p_status, '') IS NULL OR b.status = p_status)
+    AND (p_tags IS NULL OR b.tags @> p_tags);
+
+    RETURN backtest_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to get backtest summary for a user with sorting and pagination
+CREATE OR REPLACE FUNCTION get_backtests(
+    p_user_id INT,
+    p_search VARCHAR DEFAULT NULL,
+    p_status VARCHAR DEFAULT NULL,
+    p_tags TEXT[] DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'created_at',
+    p_sort_direction VARCHAR DEFAULT 'DESC',
+    p_limit INT DEFAULT 10,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    backtest_id INT,
+    name TEXT,
+    strategy_id INT,
+    date TIMESTAMPTZ,
+    status VARCHAR(20),
+    symbol_results JSONB,
+    completed_runs BIGINT,
+    total_runs BIGINT,
+    tags TEXT[]
+) AS $$
+BEGIN
+    -- Validate sort field
+    IF p_sort_by NOT IN ('name', 'created_at', 'status', 'strategy_id') THEN
+        p_sort_by := 'created_at';
+    END IF;
+
+    -- Normalize sort direction
+    p_sort_direction := UPPER(p_sort_direction);
+    IF p_sort_direction NOT IN ('ASC', 'DESC') THEN
+        p_sort_direction := 'DESC';
+    END IF;
+
+    RETURN QUERY
+    SELECT
+        bs.backtest_id,
+        bs.name,
+        bs.strategy_id,
+        bs.date,
+        bs.status,
+        bs.symbol_results,
+        bs.completed_runs,
+        bs.total_runs,
+        b.tags
+    FROM
+        v_backtest_summary bs
+        JOIN backtests b ON bs.backtest_id = b.id
+    WHERE
+        b.user_id = p_user_id
+        AND (NULLIF(p_search, '') IS NULL OR b.name ILIKE '%' || p_search || '%')
+        AND (NULLIF(p_status, '') IS NULL OR b.status = p_status)
+        AND (p_tags IS NULL OR b.tags @> p_tags)
+    ORDER BY
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'ASC' THEN bs.name END ASC,
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'DESC' THEN bs.name END DESC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'ASC' THEN bs.date END ASC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'DESC' THEN bs.date END DESC,
+        CASE WHEN p_sort_by = 'status' AND p_sort_direction = 'ASC' THEN bs.status END ASC,
+        CASE WHEN p_sort_by = 'status' AND p_sort_direction = 'DESC' THEN bs.status END DESC,
+        CASE WHEN p_sort_by = 'strategy_id' AND p_sort_direction = 'ASC' THEN bs.strategy_id END ASC,
+        CASE WHEN p_sort_by = 'strategy_id' AND p_sort_direction = 'DESC' THEN bs.strategy_id END DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
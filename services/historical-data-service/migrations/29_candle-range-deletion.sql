@@ -0,0 +1,91 @@
+-- ==========================================
+-- CANDLE RANGE DELETION
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Audit trail of candle ranges admins deleted, e.g. after importing bad data
+CREATE TABLE IF NOT EXISTS "market_data_deletions" (
+  "id" SERIAL PRIMARY KEY,
+  "symbol_id" int NOT NULL,
+  "timeframe" varchar(10) NOT NULL,
+  "range_start" timestamptz NOT NULL,
+  "range_end" timestamptz NOT NULL,
+  "candles_deleted" bigint NOT NULL,
+  "reason" text,
+  "deleted_by" int NOT NULL,
+  "deleted_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_market_data_deletions_symbol_id" ON "market_data_deletions" ("symbol_id", "deleted_at");
+
+-- Delete a symbol's candles in a range widened to whole p_bucket_minutes candles, the
+-- candle containing p_end included. A dry run only counts what would be deleted. A real
+-- deletion is recorded in market_data_deletions and, when no candles of the symbol are
+-- left, takes the symbol out of the inventory.
+-- Returns the widened range, the candles in it, the candles the symbol has left and the
+-- backtests whose range overlaps it.
+CREATE OR REPLACE FUNCTION delete_candle_range(
+    p_symbol_id INT,
+    p_timeframe VARCHAR,
+    p_bucket_minutes INT,
+    p_start TIMESTAMPTZ,
+    p_end TIMESTAMPTZ,
+    p_dry_run BOOLEAN,
+    p_user_id INT,
+    p_reason TEXT
+)
+RETURNS TABLE (
+    range_start TIMESTAMPTZ,
+    range_end TIMESTAMPTZ,
+    candle_count BIGINT,
+    first_candle_time TIMESTAMPTZ,
+    last_candle_time TIMESTAMPTZ,
+    remaining_candles BIGINT,
+    backtests_affected BIGINT
+) AS $$
+DECLARE
+    v_start TIMESTAMPTZ := candle_bucket(p_bucket_minutes, p_start);
+    v_end TIMESTAMPTZ := candle_bucket(p_bucket_minutes, p_end) + make_interval(mins => p_bucket_minutes);
+    v_count BIGINT;
+    v_first TIMESTAMPTZ;
+    v_last TIMESTAMPTZ;
+    v_total BIGINT;
+    v_backtests BIGINT;
+BEGIN
+    -- Serialize with imports and other deletions of the symbol
+    PERFORM 1 FROM symbols s WHERE s.id = p_symbol_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Symbol not found';
+    END IF;
+
+    SELECT COUNT(*), MIN(c.candle_time), MAX(c.candle_time)
+    INTO v_count, v_first, v_last
+    FROM candles c
+    WHERE c.symbol_id = p_symbol_id AND c.candle_time >= v_start AND c.candle_time < v_end;
+
+    SELECT COUNT(*) INTO v_total FROM candles c WHERE c.symbol_id = p_symbol_id;
+
+    SELECT COUNT(DISTINCT b.id) INTO v_backtests
+    FROM backtests b
+    JOIN backtest_runs br ON br.backtest_id = b.id
+    WHERE br.symbol_id = p_symbol_id AND b.start_date < v_end AND b.end_date >= v_start;
+
+    IF NOT p_dry_run THEN
+        DELETE FROM candles c
+        WHERE c.symbol_id = p_symbol_id AND c.candle_time >= v_start AND c.candle_time < v_end;
+
+        INSERT INTO market_data_deletions (
+            symbol_id, timeframe, range_start, range_end, candles_deleted, reason, deleted_by
+        )
+        VALUES (p_symbol_id, p_timeframe, v_start, v_end, v_count, NULLIF(p_reason, ''), p_user_id);
+
+        IF v_total = v_count THEN
+            UPDATE symbols SET data_available = false, updated_at = NOW() WHERE id = p_symbol_id;
+        END IF;
+    END IF;
+
+    RETURN QUERY SELECT v_start, v_end, v_count, v_first, v_last, v_total - v_count, v_backtests;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,86 @@
+-- ==========================================
+-- BACKTEST REQUEUE
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Queue a backtest interrupted by a shutdown to be resumed. Runs that were running go
+-- back to pending with their partial results and trades dropped; completed and failed
+-- runs are left alone. Returns the number of runs left to run.
+CREATE OR REPLACE FUNCTION requeue_backtest(
+    p_backtest_id INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_remaining INT;
+BEGIN
+    DELETE FROM backtest_results res
+    USING backtest_runs br
+    WHERE res.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running';
+
+    DELETE FROM backtest_trades t
+    USING backtest_runs br
+    WHERE t.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running';
+
+    UPDATE backtest_runs
+    SET status = 'pending', completed_at = NULL
+    WHERE backtest_id = p_backtest_id AND status = 'running';
+
+    SELECT COUNT(*)::INT INTO v_remaining
+    FROM backtest_runs
+    WHERE backtest_id = p_backtest_id AND status = 'pending';
+
+    UPDATE backtests
+    SET status = 'queued', completed_at = NULL, updated_at = NOW()
+    WHERE id = p_backtest_id;
+
+    RETURN v_remaining;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Claim queued backtests for this instance to resume, oldest first. Backtests claimed
+-- by another instance at the same time are skipped.
+CREATE OR REPLACE FUNCTION claim_queued_backtests(
+    p_limit INT
+)
+RETURNS TABLE (
+    backtest_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE backtests b
+    SET status = 'running', updated_at = NOW()
+    WHERE b.id IN (
+        SELECT q.id
+        FROM backtests q
+        WHERE q.status = 'queued'
+        ORDER BY q.updated_at ASC
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING b.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the symbols of a backtest's runs that are still to run
+CREATE OR REPLACE FUNCTION get_pending_backtest_symbol_ids(
+    p_backtest_id INT
+)
+RETURNS TABLE (
+    symbol_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT br.symbol_id
+    FROM backtest_runs br
+    WHERE br.backtest_id = p_backtest_id AND br.status = 'pending'
+    ORDER BY br.id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX IF NOT EXISTS "idx_backtests_queued" ON "backtests" ("updated_at") WHERE "status" = 'queued';
+-- +goose StatementEnd
@@ -0,0 +1,210 @@
+-- ==========================================
+-- BACKTEST ENGINE JOBS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Runs submitted to the backtesting engine with the async protocol. The engine reports
+-- progress and the outcome by callback; a run whose lease expires without a report fails.
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "engine_job_token" varchar(64);
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "engine_lease_expires_at" timestamptz;
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "engine_progress" numeric(5,4);
+ALTER TABLE "backtest_runs" ADD COLUMN IF NOT EXISTS "engine_error" text;
+
+CREATE INDEX IF NOT EXISTS "idx_backtest_runs_engine_lease" ON "backtest_runs" ("engine_lease_expires_at")
+WHERE "status" = 'running' AND "engine_lease_expires_at" IS NOT NULL;
+
+-- Mark a run as running on the engine before it is submitted, so callbacks racing the
+-- submission are accepted. Returns false if the run doesn't exist.
+CREATE OR REPLACE FUNCTION lease_engine_run(
+    p_run_id INT,
+    p_lease_secs INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE backtest_runs
+    SET
+        status = 'running',
+        completed_at = NULL,
+        engine_job_token = NULL,
+        engine_lease_expires_at = NOW() + make_interval(secs => p_lease_secs),
+        engine_progress = 0,
+        engine_error = NULL
+    WHERE id = p_run_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Record the job token the engine returned for a submitted run, unless a callback
+-- recorded it first
+CREATE OR REPLACE FUNCTION set_engine_job_token(
+    p_run_id INT,
+    p_job_token VARCHAR(64)
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE backtest_runs
+    SET engine_job_token = p_job_token
+    WHERE id = p_run_id AND engine_job_token IS NULL AND status = 'running';
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Apply an engine callback to a running run: progress extends its lease, completed and
+-- failed finish it. Callbacks of the backtest are serialized on its row, so exactly one
+-- of them sees no runs remaining. Returns no row if the job isn't running.
+CREATE OR REPLACE FUNCTION report_engine_run(
+    p_run_id INT,
+    p_job_token VARCHAR(64),
+    p_status VARCHAR(20),
+    p_progress NUMERIC,
+    p_error TEXT,
+    p_lease_secs INT
+)
+RETURNS TABLE (
+    backtest_id INT,
+    remaining_runs INT
+) AS $$
+DECLARE
+    v_backtest_id INT;
+BEGIN
+    SELECT br.backtest_id INTO v_backtest_id
+    FROM backtest_runs br
+    WHERE br.id = p_run_id;
+
+    IF v_backtest_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    PERFORM 1 FROM backtests b WHERE b.id = v_backtest_id FOR UPDATE;
+
+    -- The engine may have saved the results, completing the run, before calling back
+    UPDATE backtest_runs br
+    SET
+        status = CASE WHEN p_status = 'progress' THEN br.status ELSE p_status END,
+        completed_at = CASE WHEN p_status = 'completed' THEN COALESCE(br.completed_at, NOW()) ELSE br.completed_at END,
+        engine_job_token = COALESCE(br.engine_job_token, p_job_token),
+        engine_lease_expires_at = CASE WHEN p_status = 'progress'
+            THEN NOW() + make_interval(secs => p_lease_secs)
+            ELSE NULL END,
+        engine_progress = CASE WHEN p_status = 'completed' THEN 1 ELSE COALESCE(p_progress, br.engine_progress) END,
+        engine_error = CASE WHEN p_status = 'failed' THEN p_error ELSE NULL END
+    WHERE br.id = p_run_id
+      AND br.engine_lease_expires_at IS NOT NULL
+      AND (br.engine_job_token IS NULL OR br.engine_job_token = p_job_token)
+      AND (br.status = 'running' OR (br.status = 'completed' AND p_status = 'completed'));
+
+    IF NOT FOUND THEN
+        RETURN;
+    END IF;
+
+    RETURN QUERY
+    SELECT v_backtest_id, COUNT(*)::INT
+    FROM backtest_runs br
+    WHERE br.backtest_id = v_backtest_id AND br.status IN ('pending', 'running');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Fail the runs whose engine lease expired. Returns each affected backtest with how many
+-- of its runs remain; like callbacks, expiries are serialized on the backtest's row.
+CREATE OR REPLACE FUNCTION expire_engine_leases()
+RETURNS TABLE (
+    backtest_id INT,
+    remaining_runs INT
+) AS $$
+DECLARE
+    v_run RECORD;
+    v_remaining INT;
+BEGIN
+    FOR v_run IN
+        SELECT br.id, br.backtest_id
+        FROM backtest_runs br
+        WHERE br.status = 'running' AND br.engine_lease_expires_at < NOW()
+        ORDER BY br.backtest_id, br.id
+    LOOP
+        PERFORM 1 FROM backtests b WHERE b.id = v_run.backtest_id FOR UPDATE;
+
+        UPDATE backtest_runs br
+        SET
+            status = 'failed',
+            engine_lease_expires_at = NULL,
+            engine_error = 'The backtesting engine stopped reporting'
+        WHERE br.id = v_run.id
+          AND br.status = 'running'
+          AND br.engine_lease_expires_at < NOW();
+
+        IF FOUND THEN
+            SELECT COUNT(*)::INT INTO v_remaining
+            FROM backtest_runs r
+            WHERE r.backtest_id = v_run.backtest_id AND r.status IN ('pending', 'running');
+
+            backtest_id := v_run.backtest_id;
+            remaining_runs := v_remaining;
+            RETURN NEXT;
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the errors the engine reported for the failed runs of a backtest
+CREATE OR REPLACE FUNCTION get_engine_run_errors(
+    p_backtest_id INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    error_message TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT br.symbol_id, br.engine_error
+    FROM backtest_runs br
+    WHERE br.backtest_id = p_backtest_id
+      AND br.status = 'failed'
+      AND br.engine_error IS NOT NULL
+    ORDER BY br.id;
+END;
+$$ LANGUAGE plpgsql;
+-- Queue a backtest interrupted by a shutdown to be resumed. Runs submitted to the engine
+-- keep running there and report by callback; the other running runs go back to pending
+-- with their partial results and trades dropped. Returns the number of runs left to run.
+CREATE OR REPLACE FUNCTION requeue_backtest(
+    p_backtest_id INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_remaining INT;
+BEGIN
+    DELETE FROM backtest_results res
+    USING backtest_runs br
+    WHERE res.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running'
+      AND br.engine_lease_expires_at IS NULL;
+
+    DELETE FROM backtest_trades t
+    USING backtest_runs br
+    WHERE t.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running'
+      AND br.engine_lease_expires_at IS NULL;
+
+    UPDATE backtest_runs
+    SET status = 'pending', completed_at = NULL
+    WHERE backtest_id = p_backtest_id
+      AND status = 'running'
+      AND engine_lease_expires_at IS NULL;
+
+    SELECT COUNT(*)::INT INTO v_remaining
+    FROM backtest_runs
+    WHERE backtest_id = p_backtest_id AND status = 'pending';
+
+    UPDATE backtests
+    SET status = 'queued', completed_at = NULL, updated_at = NOW()
+    WHERE id = p_backtest_id;
+
+    RETURN v_remaining;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
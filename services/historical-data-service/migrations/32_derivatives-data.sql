@@ -0,0 +1,371 @@
+-- ==========================================
+-- FUNDING RATES AND OPEN INTEREST
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Perpetual futures funding rates, one row per funding event (every 8 hours on Binance).
+-- mark_price is the mark price at the funding time, NULL where the provider has none.
+CREATE TABLE IF NOT EXISTS "funding_rates" (
+  "symbol_id" int NOT NULL REFERENCES "symbols" ("id") ON DELETE CASCADE,
+  "funding_time" timestamptz NOT NULL,
+  "funding_rate" numeric(18,10) NOT NULL,
+  "mark_price" numeric(20,8),
+  PRIMARY KEY ("symbol_id", "funding_time")
+);
+
+-- Open interest of perpetual futures, sampled per period (5m to 1d)
+CREATE TABLE IF NOT EXISTS "open_interest" (
+  "symbol_id" int NOT NULL REFERENCES "symbols" ("id") ON DELETE CASCADE,
+  "period" varchar(5) NOT NULL,
+  "sample_time" timestamptz NOT NULL,
+  "open_interest" numeric(30,8) NOT NULL,
+  "open_interest_value" numeric(30,8) NOT NULL,
+  PRIMARY KEY ("symbol_id", "period", "sample_time")
+);
+
+-- Download jobs can fetch funding rates and open interest over their range after the candles
+ALTER TABLE "market_data_download_jobs"
+  ADD COLUMN IF NOT EXISTS "include_funding_rates" boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS "include_open_interest" boolean NOT NULL DEFAULT false;
+
+DROP FUNCTION IF EXISTS create_market_data_download_job(INT, VARCHAR, VARCHAR, timeframe_type, TIMESTAMPTZ, TIMESTAMPTZ, INT, INT);
+
+CREATE OR REPLACE FUNCTION create_market_data_download_job(
+    p_symbol_id INT,
+    p_symbol VARCHAR(20),
+    p_source VARCHAR(50),
+    p_timeframe timeframe_type,
+    p_start_date TIMESTAMPTZ,
+    p_end_date TIMESTAMPTZ,
+    p_user_id INT DEFAULT NULL,
+    p_estimated_candles INT DEFAULT 0,
+    p_include_funding_rates BOOLEAN DEFAULT FALSE,
+    p_include_open_interest BOOLEAN DEFAULT FALSE
+)
+RETURNS INT AS $$
+DECLARE
+    new_job_id INT;
+BEGIN
+    INSERT INTO market_data_download_jobs (
+        symbol_id,
+        symbol,
+        source,
+        timeframe,
+        start_date,
+        end_date,
+        status,
+        progress,
+        total_candles,
+        processed_candles,
+        retries,
+        user_id,
+        include_funding_rates,
+        include_open_interest,
+        created_at,
+        updated_at
+    )
+    VALUES (
+        p_symbol_id,
+        p_symbol,
+        p_source,
+        p_timeframe,
+        p_start_date,
+        p_end_date,
+        'pending',
+        0,
+        p_estimated_candles,
+        0,
+        0,
+        p_user_id,
+        p_include_funding_rates,
+        p_include_open_interest,
+        NOW(),
+        NOW()
+    )
+    RETURNING id INTO new_job_id;
+
+    RETURN new_job_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The job row now includes what to download besides candles
+DROP FUNCTION IF EXISTS get_download_job_by_id(INT);
+
+CREATE OR REPLACE FUNCTION get_download_job_by_id(
+    p_job_id INT
+)
+RETURNS TABLE (
+    id INT,
+    symbol_id INT,
+    symbol VARCHAR(20),
+    source VARCHAR(50),
+    timeframe timeframe_type,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    status VARCHAR(20),
+    progress NUMERIC(5,2),
+    total_candles INT,
+    processed_candles INT,
+    retries INT,
+    error TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    last_processed_time TIMESTAMPTZ,
+    attempts INT,
+    next_attempt_at TIMESTAMPTZ,
+    include_funding_rates BOOLEAN,
+    include_open_interest BOOLEAN
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        j.id,
+        j.symbol_id,
+        j.symbol,
+        j.source,
+        j.timeframe,
+        j.start_date,
+        j.end_date,
+        j.status,
+        j.progress,
+        j.total_candles,
+        j.processed_candles,
+        j.retries,
+        j.error,
+        j.created_at,
+        j.updated_at,
+        j.last_processed_time,
+        j.attempts,
+        j.next_attempt_at,
+        j.include_funding_rates,
+        j.include_open_interest
+    FROM market_data_download_jobs j
+    WHERE j.id = p_job_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS claim_download_job(VARCHAR[]);
+
+-- Claim the oldest runnable pending job, skipping sources that are at capacity.
+-- Concurrent workers never claim the same job.
+CREATE OR REPLACE FUNCTION claim_download_job(
+    p_excluded_sources VARCHAR[]
+)
+RETURNS TABLE (
+    id INT,
+    symbol_id INT,
+    symbol VARCHAR(20),
+    source VARCHAR(50),
+    timeframe timeframe_type,
+    start_date TIMESTAMPTZ,
+    end_date TIMESTAMPTZ,
+    status VARCHAR(20),
+    progress NUMERIC(5,2),
+    total_candles INT,
+    processed_candles INT,
+    retries INT,
+    error TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    last_processed_time TIMESTAMPTZ,
+    attempts INT,
+    next_attempt_at TIMESTAMPTZ,
+    include_funding_rates BOOLEAN,
+    include_open_interest BOOLEAN
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH next_job AS (
+        SELECT q.id
+        FROM market_data_download_jobs q
+        WHERE q.status = 'pending'
+          AND (q.next_attempt_at IS NULL OR q.next_attempt_at <= NOW())
+          AND NOT (q.source = ANY(COALESCE(p_excluded_sources, '{}')))
+        ORDER BY q.created_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE market_data_download_jobs j
+    SET
+        status = 'in_progress',
+        attempts = j.attempts + 1,
+        next_attempt_at = NULL,
+        updated_at = NOW()
+    FROM next_job
+    WHERE j.id = next_job.id
+    RETURNING
+        j.id,
+        j.symbol_id,
+        j.symbol,
+        j.source,
+        j.timeframe,
+        j.start_date,
+        j.end_date,
+        j.status,
+        j.progress,
+        j.total_candles,
+        j.processed_candles,
+        j.retries,
+        j.error,
+        j.created_at,
+        j.updated_at,
+        j.last_processed_time,
+        j.attempts,
+        j.next_attempt_at,
+        j.include_funding_rates,
+        j.include_open_interest;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store funding rates of a symbol, replacing those already stored for the same times.
+-- Returns the number of rows written.
+CREATE OR REPLACE FUNCTION import_funding_rates(
+    p_symbol_id INT,
+    p_funding_times TIMESTAMPTZ[],
+    p_funding_rates NUMERIC[],
+    p_mark_prices NUMERIC[]
+)
+RETURNS INT AS $$
+DECLARE
+    v_count INT;
+BEGIN
+    INSERT INTO funding_rates (symbol_id, funding_time, funding_rate, mark_price)
+    SELECT p_symbol_id, f.funding_time, f.funding_rate, f.mark_price
+    FROM unnest(p_funding_times, p_funding_rates, p_mark_prices) AS f (funding_time, funding_rate, mark_price)
+    ON CONFLICT (symbol_id, funding_time) DO UPDATE SET
+        funding_rate = EXCLUDED.funding_rate,
+        mark_price = COALESCE(EXCLUDED.mark_price, funding_rates.mark_price);
+
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store open interest samples of a symbol, replacing those already stored for the same
+-- period and times. Returns the number of rows written.
+CREATE OR REPLACE FUNCTION import_open_interest(
+    p_symbol_id INT,
+    p_period VARCHAR,
+    p_sample_times TIMESTAMPTZ[],
+    p_open_interest NUMERIC[],
+    p_open_interest_values NUMERIC[]
+)
+RETURNS INT AS $$
+DECLARE
+    v_count INT;
+BEGIN
+    INSERT INTO open_interest (symbol_id, period, sample_time, open_interest, open_interest_value)
+    SELECT p_symbol_id, p_period, o.sample_time, o.open_interest, o.open_interest_value
+    FROM unnest(p_sample_times, p_open_interest, p_open_interest_values) AS o (sample_time, open_interest, open_interest_value)
+    ON CONFLICT (symbol_id, period, sample_time) DO UPDATE SET
+        open_interest = EXCLUDED.open_interest,
+        open_interest_value = EXCLUDED.open_interest_value;
+
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the funding rates of a symbol in a time range, oldest first. NULL bounds are open.
+CREATE OR REPLACE FUNCTION get_funding_rates(
+    p_symbol_id INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_limit INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    funding_time TIMESTAMPTZ,
+    funding_rate NUMERIC,
+    mark_price NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT f.symbol_id, f.funding_time, f.funding_rate, f.mark_price
+    FROM funding_rates f
+    WHERE f.symbol_id = p_symbol_id
+      AND (p_start_time IS NULL OR f.funding_time >= p_start_time)
+      AND (p_end_time IS NULL OR f.funding_time <= p_end_time)
+    ORDER BY f.funding_time
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the open interest of a symbol sampled per period in a time range, oldest first.
+-- NULL bounds are open.
+CREATE OR REPLACE FUNCTION get_open_interest(
+    p_symbol_id INT,
+    p_period VARCHAR,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_limit INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    period VARCHAR,
+    sample_time TIMESTAMPTZ,
+    open_interest NUMERIC,
+    open_interest_value NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT o.symbol_id, o.period, o.sample_time, o.open_interest, o.open_interest_value
+    FROM open_interest o
+    WHERE o.symbol_id = p_symbol_id
+      AND o.period = p_period
+      AND (p_start_time IS NULL OR o.sample_time >= p_start_time)
+      AND (p_end_time IS NULL OR o.sample_time <= p_end_time)
+    ORDER BY o.sample_time
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get how much funding rate and open interest data is stored for each of the symbols.
+-- Symbols without either are left out.
+CREATE OR REPLACE FUNCTION get_derivatives_coverage(
+    p_symbol_ids INT[]
+)
+RETURNS TABLE (
+    symbol_id INT,
+    funding_rate_count BIGINT,
+    funding_rate_earliest TIMESTAMPTZ,
+    funding_rate_latest TIMESTAMPTZ,
+    open_interest_count BIGINT,
+    open_interest_earliest TIMESTAMPTZ,
+    open_interest_latest TIMESTAMPTZ,
+    open_interest_periods TEXT[]
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH funding AS (
+        SELECT f.symbol_id, COUNT(*) AS count, MIN(f.funding_time) AS earliest, MAX(f.funding_time) AS latest
+        FROM funding_rates f
+        WHERE f.symbol_id = ANY(p_symbol_ids)
+        GROUP BY f.symbol_id
+    ),
+    interest AS (
+        SELECT
+            o.symbol_id,
+            COUNT(*) AS count,
+            MIN(o.sample_time) AS earliest,
+            MAX(o.sample_time) AS latest,
+            ARRAY_AGG(DISTINCT o.period::text) AS periods
+        FROM open_interest o
+        WHERE o.symbol_id = ANY(p_symbol_ids)
+        GROUP BY o.symbol_id
+    )
+    SELECT
+        COALESCE(f.symbol_id, i.symbol_id),
+        COALESCE(f.count, 0),
+        f.earliest,
+        f.latest,
+        COALESCE(i.count, 0),
+        i.earliest,
+        i.latest,
+        COALESCE(i.periods, '{}')
+    FROM funding f
+    FULL OUTER JOIN interest i ON i.symbol_id = f.symbol_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,86 @@
+-- ==========================================
+-- ORDER BOOK SNAPSHOTS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Periodic snapshots of the top levels of symbols' order books, captured from the
+-- provider's depth stream. bids and asks hold [{"price", "quantity"}], best price first.
+CREATE TABLE IF NOT EXISTS "order_book_snapshots" (
+  "symbol_id" int NOT NULL REFERENCES "symbols" ("id") ON DELETE CASCADE,
+  "snapshot_time" timestamptz NOT NULL,
+  "last_update_id" bigint NOT NULL,
+  "bids" jsonb NOT NULL,
+  "asks" jsonb NOT NULL,
+  PRIMARY KEY ("symbol_id", "snapshot_time")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_order_book_snapshots_time" ON "order_book_snapshots" ("snapshot_time");
+
+-- Store order book snapshots of several symbols, replacing those already stored for the
+-- same symbol and time. Returns the number of rows written.
+CREATE OR REPLACE FUNCTION import_order_book_snapshots(
+    p_symbol_ids INT[],
+    p_snapshot_times TIMESTAMPTZ[],
+    p_last_update_ids BIGINT[],
+    p_bids JSONB[],
+    p_asks JSONB[]
+)
+RETURNS INT AS $$
+DECLARE
+    v_count INT;
+BEGIN
+    INSERT INTO order_book_snapshots (symbol_id, snapshot_time, last_update_id, bids, asks)
+    SELECT s.symbol_id, s.snapshot_time, s.last_update_id, s.bids, s.asks
+    FROM unnest(p_symbol_ids, p_snapshot_times, p_last_update_ids, p_bids, p_asks)
+        AS s (symbol_id, snapshot_time, last_update_id, bids, asks)
+    ON CONFLICT (symbol_id, snapshot_time) DO UPDATE SET
+        last_update_id = EXCLUDED.last_update_id,
+        bids = EXCLUDED.bids,
+        asks = EXCLUDED.asks;
+
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the order book snapshots of a symbol in a time range, oldest first. NULL bounds
+-- are open.
+CREATE OR REPLACE FUNCTION get_order_book_snapshots(
+    p_symbol_id INT,
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ,
+    p_limit INT
+)
+RETURNS TABLE (
+    symbol_id INT,
+    snapshot_time TIMESTAMPTZ,
+    last_update_id BIGINT,
+    bids JSONB,
+    asks JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT o.symbol_id, o.snapshot_time, o.last_update_id, o.bids, o.asks
+    FROM order_book_snapshots o
+    WHERE o.symbol_id = p_symbol_id
+      AND (p_start_time IS NULL OR o.snapshot_time >= p_start_time)
+      AND (p_end_time IS NULL OR o.snapshot_time <= p_end_time)
+    ORDER BY o.snapshot_time
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete the order book snapshots taken before a cutoff. Returns the number deleted.
+CREATE OR REPLACE FUNCTION prune_order_book_snapshots(p_before TIMESTAMPTZ)
+RETURNS INT AS $$
+DECLARE
+    v_count INT;
+BEGIN
+    DELETE FROM order_book_snapshots WHERE snapshot_time < p_before;
+
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- ==========================================
+-- ORDER BOOK SNAPSHOT COVERAGE
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Count the order book snapshots of each of the symbols captured in a time range, for
+-- backtests that price slippage from them. Symbols without any are left out.
+CREATE OR REPLACE FUNCTION count_order_book_snapshots(
+    p_symbol_ids INT[],
+    p_start_time TIMESTAMPTZ,
+    p_end_time TIMESTAMPTZ
+)
+RETURNS TABLE (
+    symbol_id INT,
+    snapshot_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT o.symbol_id, COUNT(*)
+    FROM order_book_snapshots o
+    WHERE o.symbol_id = ANY(p_symbol_ids)
+      AND o.snapshot_time BETWEEN p_start_time AND p_end_time
+    GROUP BY o.symbol_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
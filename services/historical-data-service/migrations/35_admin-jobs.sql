@@ -0,0 +1,284 @@
+-- ==========================================
+-- ADMIN JOB MONITORING
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Download jobs and backtests as one list of background jobs for operators. A job's
+-- duration runs from its creation until it finished, or until now while it's active.
+CREATE OR REPLACE VIEW admin_jobs AS
+SELECT
+    jobs.*,
+    EXTRACT(EPOCH FROM (COALESCE(jobs.finished_at, NOW()) - jobs.created_at))::float8 AS duration_seconds
+FROM (
+    SELECT
+        'download'::varchar(20) AS kind,
+        j.id,
+        j.status,
+        (j.symbol || ' ' || j.timeframe || ' from ' || j.source)::text AS description,
+        j.user_id,
+        j.progress::float8 AS progress,
+        NULLIF(TRIM(j.error), '') AS error,
+        j.created_at,
+        j.updated_at,
+        CASE WHEN j.status IN ('completed', 'failed', 'cancelled') THEN j.updated_at END AS finished_at
+    FROM market_data_download_jobs j
+    UNION ALL
+    SELECT
+        'backtest'::varchar(20),
+        b.id,
+        b.status,
+        COALESCE(b.name, 'Backtest ' || b.id)::text,
+        b.user_id,
+        COALESCE(runs.finished * 100.0 / NULLIF(runs.total, 0), 0)::float8,
+        b.error_message,
+        b.created_at,
+        COALESCE(b.updated_at, b.created_at),
+        CASE WHEN b.status IN ('completed', 'partial', 'failed', 'cancelled') THEN b.completed_at END
+    FROM backtests b
+    LEFT JOIN LATERAL (
+        SELECT
+            COUNT(*) AS total,
+            COUNT(*) FILTER (WHERE br.status IN ('completed', 'failed', 'cancelled')) AS finished
+        FROM backtest_runs br
+        WHERE br.backtest_id = b.id
+    ) runs ON TRUE
+) jobs;
+
+-- Get background jobs, newest first. NULL filters match every job.
+CREATE OR REPLACE FUNCTION get_admin_jobs(
+    p_kind VARCHAR(20),
+    p_status VARCHAR(20),
+    p_user_id INT,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS SETOF admin_jobs AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM admin_jobs a
+    WHERE (p_kind IS NULL OR a.kind = p_kind)
+      AND (p_status IS NULL OR a.status = p_status)
+      AND (p_user_id IS NULL OR a.user_id = p_user_id)
+    ORDER BY a.created_at DESC, a.kind, a.id DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Count background jobs, with the filters of get_admin_jobs
+CREATE OR REPLACE FUNCTION count_admin_jobs(
+    p_kind VARCHAR(20),
+    p_status VARCHAR(20),
+    p_user_id INT
+)
+RETURNS INT AS $$
+    SELECT COUNT(*)::INT
+    FROM admin_jobs a
+    WHERE (p_kind IS NULL OR a.kind = p_kind)
+      AND (p_status IS NULL OR a.status = p_status)
+      AND (p_user_id IS NULL OR a.user_id = p_user_id);
+$$ LANGUAGE sql STABLE;
+
+-- Count background jobs by kind and status
+CREATE OR REPLACE FUNCTION get_admin_job_counts()
+RETURNS TABLE (
+    kind VARCHAR(20),
+    status VARCHAR(20),
+    job_count INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT a.kind, a.status, COUNT(*)::INT
+    FROM admin_jobs a
+    GROUP BY a.kind, a.status
+    ORDER BY a.kind, a.status;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Cancel a backtest that hasn't finished. Its unfinished runs are cancelled too and keep
+-- whatever results they saved; completed runs are left alone.
+CREATE OR REPLACE FUNCTION cancel_backtest(
+    p_backtest_id INT,
+    p_reason TEXT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE backtests
+    SET status = 'cancelled', error_message = p_reason, completed_at = NOW(), updated_at = NOW()
+    WHERE id = p_backtest_id AND status IN ('pending', 'queued', 'running');
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    IF affected_rows = 0 THEN
+        RETURN FALSE;
+    END IF;
+
+    UPDATE backtest_runs
+    SET status = 'cancelled', completed_at = NOW(), engine_lease_expires_at = NULL
+    WHERE backtest_id = p_backtest_id AND status IN ('pending', 'running');
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Queue a failed, partial or cancelled backtest to run again. Its failed and cancelled
+-- runs go back to pending with their partial results and trades dropped; completed runs
+-- keep theirs. Returns the number of runs queued, or NULL if the backtest can't be requeued.
+CREATE OR REPLACE FUNCTION requeue_finished_backtest(
+    p_backtest_id INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_queued INT;
+BEGIN
+    PERFORM 1 FROM backtests
+    WHERE id = p_backtest_id AND status IN ('failed', 'partial', 'cancelled')
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    DELETE FROM backtest_results res
+    USING backtest_runs br
+    WHERE res.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status IN ('failed', 'cancelled');
+
+    DELETE FROM backtest_trades t
+    USING backtest_runs br
+    WHERE t.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status IN ('failed', 'cancelled');
+
+    UPDATE backtest_runs
+    SET status = 'pending', completed_at = NULL, engine_job_token = NULL, engine_error = NULL
+    WHERE backtest_id = p_backtest_id AND status IN ('failed', 'cancelled');
+
+    SELECT COUNT(*)::INT INTO v_queued
+    FROM backtest_runs
+    WHERE backtest_id = p_backtest_id AND status = 'pending';
+
+    IF v_queued = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    UPDATE backtests
+    SET status = 'queued', error_message = NULL, completed_at = NULL, updated_at = NOW()
+    WHERE id = p_backtest_id;
+
+    RETURN v_queued;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Queue a failed or cancelled download job to run again from its checkpoint, with its
+-- attempts reset
+CREATE OR REPLACE FUNCTION requeue_download_job(
+    p_job_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE market_data_download_jobs
+    SET
+        status = 'pending',
+        error = NULL,
+        attempts = 0,
+        next_attempt_at = NULL,
+        updated_at = NOW()
+    WHERE id = p_job_id AND status IN ('failed', 'cancelled');
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A cancelled backtest interrupted by its cancellation isn't queued to resume. Returns -1
+-- for cancelled backtests.
+CREATE OR REPLACE FUNCTION requeue_backtest(
+    p_backtest_id INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_remaining INT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM backtests WHERE id = p_backtest_id AND status = 'cancelled') THEN
+        RETURN -1;
+    END IF;
+
+    DELETE FROM backtest_results res
+    USING backtest_runs br
+    WHERE res.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running';
+
+    DELETE FROM backtest_trades t
+    USING backtest_runs br
+    WHERE t.backtest_run_id = br.id
+      AND br.backtest_id = p_backtest_id
+      AND br.status = 'running';
+
+    UPDATE backtest_runs
+    SET status = 'pending', completed_at = NULL
+    WHERE backtest_id = p_backtest_id AND status = 'running';
+
+    SELECT COUNT(*)::INT INTO v_remaining
+    FROM backtest_runs
+    WHERE backtest_id = p_backtest_id AND status = 'pending';
+
+    UPDATE backtests
+    SET status = 'queued', completed_at = NULL, updated_at = NOW()
+    WHERE id = p_backtest_id;
+
+    RETURN v_remaining;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Status updates of runs still in flight never revive a cancelled run
+CREATE OR REPLACE FUNCTION update_backtest_run_status(
+    p_run_id INT,
+    p_status VARCHAR(20)
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+    backtest_id INT;
+BEGIN
+    -- Update run status
+    UPDATE backtest_runs
+    SET
+        status = p_status,
+        completed_at = CASE WHEN p_status = 'completed' THEN NOW() ELSE NULL END
+    WHERE
+        id = p_run_id
+        AND status <> 'cancelled'
+    RETURNING backtest_id INTO backtest_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+
+    IF affected_rows = 0 THEN
+        RETURN FALSE;
+    END IF;
+
+    -- Check if all runs are completed and update backtest status if needed
+    IF (
+        SELECT COUNT(*)
+        FROM backtest_runs
+        WHERE backtest_id = backtest_id AND status != 'completed'
+    ) = 0 THEN
+        UPDATE backtests
+        SET
+            status = 'completed',
+            completed_at = NOW(),
+            updated_at = NOW()
+        WHERE
+            id = backtest_id;
+    END IF;
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- ==========================================
+-- BACKTEST TRADE ANNOTATIONS
+-- ==========================================
+
+-- +goose Up
+-- +goose StatementBegin
+-- Notes and labels users add to single trades while reviewing a backtest run
+CREATE TABLE IF NOT EXISTS "backtest_trade_annotations" (
+  "id" SERIAL PRIMARY KEY,
+  "trade_id" int NOT NULL REFERENCES "backtest_trades" ("id") ON DELETE CASCADE,
+  "user_id" int NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "labels" varchar(50)[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_backtest_trade_annotations_trade_id" ON "backtest_trade_annotations" ("trade_id");
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Annotate a trade of a backtest run. Returns no row if the trade isn't part of the run.
+CREATE OR REPLACE FUNCTION add_backtest_trade_annotation(
+    p_run_id INT,
+    p_trade_id INT,
+    p_user_id INT,
+    p_note TEXT,
+    p_labels VARCHAR(50)[]
+)
+RETURNS SETOF backtest_trade_annotations AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO backtest_trade_annotations (trade_id, user_id, note, labels)
+    SELECT t.id, p_user_id, COALESCE(p_note, ''), COALESCE(p_labels, '{}')
+    FROM backtest_trades t
+    WHERE t.id = p_trade_id AND t.backtest_run_id = p_run_id
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Get the annotations of a set of trades, oldest first
+CREATE OR REPLACE FUNCTION get_backtest_trade_annotations(
+    p_trade_ids INT[]
+)
+RETURNS SETOF backtest_trade_annotations AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM backtest_trade_annotations a
+    WHERE a.trade_id = ANY(p_trade_ids)
+    ORDER BY a.trade_id, a.created_at, a.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"services/media-service/docs"
 	"services/media-service/internal/config"
 	"services/media-service/internal/handler"
 	"services/media-service/internal/middleware"
@@ -19,6 +20,15 @@ import (
 	"go.uber.org/zap"
 )
 
+// @title Media Service API
+// @version 1.0
+// @description Storage and retrieval of uploaded files.
+// @BasePath /
+//
+// @securityDefinitions.apikey ServiceKey
+// @in header
+// @name X-Service-Key
+// @description Key of a calling internal service
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig("config/config.yaml")
@@ -116,6 +126,7 @@ func setupRouter(cfg *config.Config, mediaHandler *handler.MediaHandler, logger
 
 	// Use middlewares
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
 
 	// Auth middleware
@@ -126,6 +137,11 @@ func setupRouter(cfg *config.Config, mediaHandler *handler.MediaHandler, logger
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// OpenAPI spec, also merged into the gateway's /api/docs
+	router.GET("/swagger.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", docs.SwaggerJSON)
+	})
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
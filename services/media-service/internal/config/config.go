@@ -57,13 +57,19 @@ type AuthConfig struct {
 type UploadConfig struct {
 	MaxFileSize       int64
 	AllowedExtensions []string
-	MaxWidth          int
-	MaxHeight         int
-	ThumbnailSizes    []ThumbnailSize
+	// AllowedContentTypes are checked against the sniffed content, not the declared type
+	AllowedContentTypes []string
+	MaxWidth            int
+	MaxHeight           int
+	// Variants are the resized copies stored next to the processed original
+	Variants []VariantSize
+	// OutputFormat is the format images are converted to: "webp" (lossless) or "jpeg"
+	OutputFormat string
+	JPEGQuality  int
 }
 
-// ThumbnailSize defines a thumbnail size
-type ThumbnailSize struct {
+// VariantSize defines the bounding box of an image variant
+type VariantSize struct {
 	Name   string
 	Width  int
 	Height int
@@ -119,14 +125,16 @@ func setDefaults(v *viper.Viper) {
 
 	// Upload defaults
 	v.SetDefault("upload.maxFileSize", 10485760) // 10MB
-	v.SetDefault("upload.allowedExtensions", []string{".jpg", ".jpeg", ".png", ".gif"})
+	v.SetDefault("upload.allowedExtensions", []string{".jpg", ".jpeg", ".png", ".gif", ".webp"})
+	v.SetDefault("upload.allowedContentTypes", []string{"image/jpeg", "image/png", "image/gif", "image/webp"})
 	v.SetDefault("upload.maxWidth", 4096)
 	v.SetDefault("upload.maxHeight", 4096)
-	v.SetDefault("upload.thumbnailSizes", []map[string]interface{}{
-		{"name": "small", "width": 150, "height": 150},
-		{"name": "medium", "width": 300, "height": 300},
-		{"name": "large", "width": 600, "height": 600},
+	v.SetDefault("upload.variants", []map[string]interface{}{
+		{"name": "thumb", "width": 150, "height": 150},
+		{"name": "medium", "width": 600, "height": 600},
 	})
+	v.SetDefault("upload.outputFormat", "webp")
+	v.SetDefault("upload.jpegQuality", 85)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
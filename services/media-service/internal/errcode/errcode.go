@@ -0,0 +1,10 @@
+// Package errcode defines the error codes of the media service
+package errcode
+
+import "services/shared/apierror"
+
+// Codes of the media service
+const (
+	CodeInvalidFile  apierror.Code = "INVALID_FILE"
+	CodeFileNotFound apierror.Code = "FILE_NOT_FOUND"
+)
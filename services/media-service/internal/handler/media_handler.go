@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"services/shared/apierror"
+
+	"services/media-service/internal/errcode"
 	"services/media-service/internal/model"
 	"services/media-service/internal/service"
 
@@ -29,11 +33,25 @@ func NewMediaHandler(mediaService *service.MediaService, logger *zap.Logger) *Me
 
 // Upload handles file uploads
 // POST /api/v1/media/upload
+//
+// @Summary Upload a file
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to upload"
+// @Param purpose formData string false "What the file is for, e.g. profile, strategy or post"
+// @Param entity_id formData string false "ID of the related entity"
+// @Param generate_thumbnails formData bool false "Also store resized variants"
+// @Success 200 {object} model.UploadResponse
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/media/upload [post]
 func (h *MediaHandler) Upload(c *gin.Context) {
 	// Parse multipart form
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max memory
 		h.logger.Error("Failed to parse multipart form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Failed to parse form data")
 		return
 	}
 
@@ -41,7 +59,7 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		h.logger.Error("Failed to get file from form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "No file uploaded")
 		return
 	}
 	defer file.Close()
@@ -50,7 +68,7 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	var req model.UploadRequest
 	if err := c.ShouldBind(&req); err != nil {
 		h.logger.Error("Failed to bind request parameters", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request parameters")
 		return
 	}
 
@@ -67,8 +85,12 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	// Upload the file
 	mediaFile, err := h.mediaService.Upload(c, header, req.Purpose, req.EntityID, req.GenerateThumbnails)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidFile) {
+			apierror.Send(c, http.StatusBadRequest, errcode.CodeInvalidFile, err.Error())
+			return
+		}
 		h.logger.Error("Failed to upload file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload file: %v", err)})
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, fmt.Sprintf("Failed to upload file: %v", err))
 		return
 	}
 
@@ -82,6 +104,14 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 
 // Get handles file retrieval
 // GET /api/v1/media/:id
+//
+// @Summary Retrieve a file
+// @Tags media
+// @Produce octet-stream
+// @Param id path string true "File ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} apierror.Body
+// @Router /api/v1/media/{id} [get]
 func (h *MediaHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
@@ -119,7 +149,7 @@ func (h *MediaHandler) Get(c *gin.Context) {
 	file, mediaFile, err := h.mediaService.Get(c, id)
 	if err != nil {
 		h.logger.Error("Failed to get file", zap.Error(err), zap.String("id", id))
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeFileNotFound, "File not found")
 		return
 	}
 	defer file.Close()
@@ -142,6 +172,15 @@ func (h *MediaHandler) Get(c *gin.Context) {
 
 // GetByPath handles file retrieval by full path
 // GET /api/v1/media/by-path/*fullpath
+//
+// @Summary Retrieve a file by its path
+// @Tags media
+// @Produce octet-stream
+// @Param fullpath path string true "Storage path of the file"
+// @Success 200 {file} binary
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Router /api/v1/media/by-path/{fullpath} [get]
 func (h *MediaHandler) GetByPath(c *gin.Context) {
 	fullPath := c.Param("fullpath")
 
@@ -151,7 +190,7 @@ func (h *MediaHandler) GetByPath(c *gin.Context) {
 	// Extract the file ID from the path
 	pathParts := strings.Split(fullPath, "/")
 	if len(pathParts) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid path"})
+		apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid path")
 		return
 	}
 
@@ -168,7 +207,7 @@ func (h *MediaHandler) GetByPath(c *gin.Context) {
 	file, mediaFile, err := h.mediaService.Get(c, id)
 	if err != nil {
 		h.logger.Error("Failed to get file", zap.Error(err), zap.String("path", fullPath))
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeFileNotFound, "File not found")
 		return
 	}
 	defer file.Close()
@@ -190,6 +229,15 @@ func (h *MediaHandler) GetByPath(c *gin.Context) {
 
 // Delete handles file deletion
 // DELETE /api/v1/media/:id
+//
+// @Summary Delete a file
+// @Tags media
+// @Produce json
+// @Param id path string true "ID"
+// @Success 200 {object} object{success=boolean,message=string}
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/media/{id} [delete]
 func (h *MediaHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
@@ -200,7 +248,7 @@ func (h *MediaHandler) Delete(c *gin.Context) {
 	// Delete the file
 	if err := h.mediaService.Delete(c, id); err != nil {
 		h.logger.Error("Failed to delete file", zap.Error(err), zap.String("id", id))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to delete file: %v", err)})
+		apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, fmt.Sprintf("Failed to delete file: %v", err))
 		return
 	}
 
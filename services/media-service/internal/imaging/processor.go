@@ -0,0 +1,157 @@
+// Package imaging validates uploaded images and renders the variants that are stored for them.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Register decoders for the accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+
+	"services/media-service/internal/config"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+)
+
+// OriginalVariant names the full size variant. Its URL is the media file's URL.
+const OriginalVariant = "original"
+
+// ErrInvalidImage is returned for uploads that can't be decoded or exceed the size limits
+var ErrInvalidImage = errors.New("invalid image")
+
+// Variant is an encoded rendition of an uploaded image
+type Variant struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Processor turns uploaded images into metadata-free variants in the configured output format
+type Processor struct {
+	cfg *config.UploadConfig
+}
+
+// NewProcessor creates a new image processor
+func NewProcessor(cfg *config.UploadConfig) *Processor {
+	return &Processor{cfg: cfg}
+}
+
+// DetectContentType sniffs the content type of uploaded data, ignoring what the client declared
+func DetectContentType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Process decodes an image and renders the original plus, if requested, the resized variants.
+// Re-encoding drops EXIF and any other metadata; JPEG orientation is applied first so photos
+// stay upright. Animated GIFs keep their first frame only.
+func (p *Processor) Process(data []byte, withResized bool) ([]Variant, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if (p.cfg.MaxWidth > 0 && cfg.Width > p.cfg.MaxWidth) || (p.cfg.MaxHeight > 0 && cfg.Height > p.cfg.MaxHeight) {
+		return nil, fmt.Errorf("%w: %dx%d exceeds the maximum of %dx%d",
+			ErrInvalidImage, cfg.Width, cfg.Height, p.cfg.MaxWidth, p.cfg.MaxHeight)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	original, err := p.encode(OriginalVariant, img)
+	if err != nil {
+		return nil, err
+	}
+	variants := []Variant{*original}
+
+	if !withResized {
+		return variants, nil
+	}
+
+	for _, size := range p.cfg.Variants {
+		resized, err := p.encode(size.Name, resize(img, size.Width, size.Height))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, *resized)
+	}
+
+	return variants, nil
+}
+
+// encode writes an image in the configured output format
+func (p *Processor) encode(name string, img image.Image) (*Variant, error) {
+	var buf bytes.Buffer
+	variant := &Variant{
+		Name:   name,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	switch p.cfg.OutputFormat {
+	case "jpeg":
+		quality := p.cfg.JPEGQuality
+		if quality <= 0 {
+			quality = jpeg.DefaultQuality
+		}
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant as JPEG: %w", name, err)
+		}
+		variant.ContentType = "image/jpeg"
+		variant.Extension = ".jpg"
+	default:
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant as WebP: %w", name, err)
+		}
+		variant.ContentType = "image/webp"
+		variant.Extension = ".webp"
+	}
+
+	variant.Data = buf.Bytes()
+	return variant, nil
+}
+
+// resize scales an image to fit within maxWidth x maxHeight, preserving the aspect ratio.
+// Images that already fit are returned unchanged.
+func resize(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return img
+	}
+
+	scale := float64(maxWidth) / float64(width)
+	if heightScale := float64(maxHeight) / float64(height); heightScale < scale {
+		scale = heightScale
+	}
+	newWidth := max(1, int(float64(width)*scale+0.5))
+	newHeight := max(1, int(float64(height)*scale+0.5))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, newWidth, newHeight))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	return dst
+}
+
+// flatten draws an image onto a white background, since JPEG has no transparency
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"services/media-service/internal/config"
+	"services/shared/apierror"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -22,7 +23,7 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 		serviceKey := c.GetHeader("X-Service-Key")
 		if serviceKey == "" {
 			logger.Warn("Missing service key")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service key required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Service key required")
 			c.Abort()
 			return
 		}
@@ -30,7 +31,7 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 		// Validate the service key
 		if serviceKey != cfg.Auth.ServiceKey {
 			logger.Warn("Invalid service key", zap.String("provided_key", serviceKey))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service key"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid service key")
 			c.Abort()
 			return
 		}
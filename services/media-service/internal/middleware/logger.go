@@ -36,6 +36,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("user_agent", userAgent),
 			zap.Duration("latency", latency),
 			zap.Int("body_size", c.Writer.Size()),
+			zap.String("request_id", c.GetString("request_id")),
 		}
 
 		// Log with appropriate level based on status code
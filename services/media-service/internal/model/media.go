@@ -6,33 +6,35 @@ import (
 
 // MediaFile represents metadata about a stored media file
 type MediaFile struct {
-	ID          string      `json:"id"`
-	FileName    string      `json:"file_name"`
-	ContentType string      `json:"content_type"`
-	Size        int64       `json:"size"`
-	URL         string      `json:"url"`
-	Thumbnails  []Thumbnail `json:"thumbnails,omitempty"`
-	Width       int         `json:"width,omitempty"`
-	Height      int         `json:"height,omitempty"`
-	UploadedBy  string      `json:"uploaded_by,omitempty"`
-	CreatedAt   time.Time   `json:"created_at"`
-	StorageType string      `json:"storage_type"`
-	StoragePath string      `json:"-"` // Internal use only, not exposed in API
+	ID          string    `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	Variants    []Variant `json:"variants,omitempty"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	UploadedBy  string    `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	StorageType string    `json:"storage_type"`
+	StoragePath string    `json:"-"` // Internal use only, not exposed in API
 }
 
-// Thumbnail represents a generated thumbnail of a media file
-type Thumbnail struct {
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
+// Variant represents a processed rendition of an uploaded image, e.g. "thumb", "medium" or "original"
+type Variant struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
 }
 
 // UploadRequest represents a request to upload a file
 type UploadRequest struct {
-	Purpose            string `json:"purpose" form:"purpose"`     // e.g., "profile", "strategy", "post"
-	EntityID           string `json:"entity_id" form:"entity_id"` // ID of related entity (user, strategy, etc.)
-	GenerateThumbnails bool   `json:"generate_thumbnails" form:"generate_thumbnails"`
+	Purpose            string `json:"purpose" form:"purpose"`                         // e.g., "profile", "strategy", "post"
+	EntityID           string `json:"entity_id" form:"entity_id"`                     // ID of related entity (user, strategy, etc.)
+	GenerateThumbnails bool   `json:"generate_thumbnails" form:"generate_thumbnails"` // Resized variants besides the original
 }
 
 // UploadResponse represents the response after a successful upload
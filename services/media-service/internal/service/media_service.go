@@ -2,61 +2,84 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"services/media-service/internal/config"
+	"services/media-service/internal/imaging"
 	"services/media-service/internal/model"
 	"services/media-service/internal/storage"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// ErrInvalidFile is returned for uploads rejected by validation
+var ErrInvalidFile = errors.New("invalid file")
+
 // MediaService handles media operations
 type MediaService struct {
-	storage storage.Storage
-	config  *config.Config
-	logger  *zap.Logger
+	storage   storage.Storage
+	processor *imaging.Processor
+	config    *config.Config
+	logger    *zap.Logger
 }
 
 // NewMediaService creates a new media service
 func NewMediaService(storage storage.Storage, config *config.Config, logger *zap.Logger) *MediaService {
 	return &MediaService{
-		storage: storage,
-		config:  config,
-		logger:  logger,
+		storage:   storage,
+		processor: imaging.NewProcessor(&config.Upload),
+		config:    config,
+		logger:    logger,
 	}
 }
 
-// Upload processes and stores a file
-func (s *MediaService) Upload(ctx context.Context, file *multipart.FileHeader, purpose string, entityID string, generateThumbnails bool) (*model.MediaFile, error) {
+// Upload validates and stores a file. Images are processed into metadata-free variants in the
+// configured output format: the original and, if requested, the resized variants.
+func (s *MediaService) Upload(ctx context.Context, file *multipart.FileHeader, purpose string, entityID string, generateVariants bool) (*model.MediaFile, error) {
 	// Validate the file
 	if err := s.validateFile(file); err != nil {
 		return nil, err
 	}
 
-	// Store the file
-	mediaFile, err := s.storage.Store(ctx, file, purpose, entityID)
+	data, err := readFile(file)
 	if err != nil {
-		s.logger.Error("Failed to store file", zap.Error(err))
 		return nil, err
 	}
 
-	// Generate thumbnails if needed
-	if generateThumbnails && isImage(mediaFile.ContentType) {
-		thumbnails, err := s.storage.GenerateThumbnails(ctx, mediaFile)
+	// Trust the content, not the declared content type
+	contentType := imaging.DetectContentType(data)
+	if !s.contentTypeAllowed(contentType) {
+		return nil, fmt.Errorf("%w: content type not allowed: %s", ErrInvalidFile, contentType)
+	}
+
+	// Anything else allowed is stored as-is
+	if !isImage(contentType) {
+		mediaFile, err := s.storage.Store(ctx, file, purpose, entityID)
 		if err != nil {
-			s.logger.Error("Failed to generate thumbnails", zap.Error(err))
-			// Continue without thumbnails
-		} else {
-			mediaFile.Thumbnails = thumbnails
+			s.logger.Error("Failed to store file", zap.Error(err))
+			return nil, err
 		}
+		return mediaFile, nil
 	}
 
-	return mediaFile, nil
+	variants, err := s.processor.Process(data, generateVariants)
+	if err != nil {
+		if errors.Is(err, imaging.ErrInvalidImage) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		s.logger.Error("Failed to process image", zap.Error(err))
+		return nil, err
+	}
+
+	return s.storeVariants(ctx, file.Filename, purpose, entityID, variants)
 }
 
 // Get retrieves a file by ID
@@ -69,11 +92,64 @@ func (s *MediaService) Delete(ctx context.Context, id string) error {
 	return s.storage.Delete(ctx, id)
 }
 
+// storeVariants saves processed image variants as <id>.<ext> for the original and
+// <id>_<variant>.<ext> for the others
+func (s *MediaService) storeVariants(
+	ctx context.Context,
+	fileName string,
+	purpose string,
+	entityID string,
+	variants []imaging.Variant,
+) (*model.MediaFile, error) {
+	id := uuid.New().String()
+
+	mediaFile := &model.MediaFile{
+		ID:          id,
+		FileName:    fileName,
+		CreatedAt:   time.Now(),
+		StorageType: s.config.Storage.Type,
+	}
+
+	for _, variant := range variants {
+		name := id
+		if variant.Name != imaging.OriginalVariant {
+			name = fmt.Sprintf("%s_%s", id, variant.Name)
+		}
+		storagePath := path.Join(purpose, entityID, name+variant.Extension)
+
+		url, err := s.storage.Put(ctx, storagePath, variant.Data, variant.ContentType)
+		if err != nil {
+			s.logger.Error("Failed to store image variant", zap.Error(err), zap.String("variant", variant.Name))
+			return nil, err
+		}
+
+		if variant.Name == imaging.OriginalVariant {
+			mediaFile.URL = url
+			mediaFile.ContentType = variant.ContentType
+			mediaFile.Size = int64(len(variant.Data))
+			mediaFile.Width = variant.Width
+			mediaFile.Height = variant.Height
+			mediaFile.StoragePath = storagePath
+		}
+
+		mediaFile.Variants = append(mediaFile.Variants, model.Variant{
+			Name:        variant.Name,
+			URL:         url,
+			ContentType: variant.ContentType,
+			Size:        int64(len(variant.Data)),
+			Width:       variant.Width,
+			Height:      variant.Height,
+		})
+	}
+
+	return mediaFile, nil
+}
+
 // validateFile checks if a file meets the requirements
 func (s *MediaService) validateFile(file *multipart.FileHeader) error {
 	// Check file size
 	if file.Size > s.config.Upload.MaxFileSize {
-		return fmt.Errorf("file too large: %d bytes (max %d bytes)", file.Size, s.config.Upload.MaxFileSize)
+		return fmt.Errorf("%w: file too large: %d bytes (max %d bytes)", ErrInvalidFile, file.Size, s.config.Upload.MaxFileSize)
 	}
 
 	// Check file extension
@@ -87,13 +163,42 @@ func (s *MediaService) validateFile(file *multipart.FileHeader) error {
 			}
 		}
 		if !allowed {
-			return fmt.Errorf("file extension not allowed: %s", ext)
+			return fmt.Errorf("%w: file extension not allowed: %s", ErrInvalidFile, ext)
 		}
 	}
 
 	return nil
 }
 
+// contentTypeAllowed checks a sniffed content type against the allowed content types
+func (s *MediaService) contentTypeAllowed(contentType string) bool {
+	if len(s.config.Upload.AllowedContentTypes) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.Upload.AllowedContentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// readFile reads an uploaded file into memory
+func readFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	return data, nil
+}
+
 // isImage checks if a content type represents an image
 func isImage(contentType string) bool {
 	return strings.HasPrefix(contentType, "image/")
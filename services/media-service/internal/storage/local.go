@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"mime"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -18,19 +20,17 @@ import (
 	"services/media-service/internal/model"
 
 	"github.com/google/uuid"
-	"golang.org/x/image/draw"
 )
 
 // LocalStorage implements the Storage interface for local filesystem
 type LocalStorage struct {
-	basePath       string
-	baseURL        string
-	permissions    os.FileMode
-	thumbnailSizes []config.ThumbnailSize
+	basePath    string
+	baseURL     string
+	permissions os.FileMode
 }
 
 // NewLocalStorage creates a new LocalStorage
-func NewLocalStorage(cfg *config.LocalStorageConfig, thumbnailSizes []config.ThumbnailSize) (*LocalStorage, error) {
+func NewLocalStorage(cfg *config.LocalStorageConfig) (*LocalStorage, error) {
 	// Create base directory if it doesn't exist
 	if err := os.MkdirAll(cfg.BasePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
@@ -43,10 +43,9 @@ func NewLocalStorage(cfg *config.LocalStorageConfig, thumbnailSizes []config.Thu
 	}
 
 	return &LocalStorage{
-		basePath:       cfg.BasePath,
-		baseURL:        cfg.BaseURL,
-		permissions:    os.FileMode(perms),
-		thumbnailSizes: thumbnailSizes,
+		basePath:    cfg.BasePath,
+		baseURL:     cfg.BaseURL,
+		permissions: os.FileMode(perms),
 	}, nil
 }
 
@@ -149,8 +148,8 @@ func (s *LocalStorage) Get(ctx context.Context, id string) (io.ReadCloser, *mode
 			return nil
 		}
 
-		// Check if the filename starts with the ID
-		if strings.HasPrefix(info.Name(), id) {
+		// Match the file name without its extension, so variants aren't mistaken for the original
+		if fileID(info.Name()) == id {
 			filePath = path
 
 			// Extract information about the file
@@ -160,6 +159,7 @@ func (s *LocalStorage) Get(ctx context.Context, id string) (io.ReadCloser, *mode
 			media = &model.MediaFile{
 				ID:          id,
 				FileName:    info.Name(),
+				ContentType: mime.TypeByExtension(filepath.Ext(info.Name())),
 				Size:        info.Size(),
 				URL:         fmt.Sprintf("%s/%s", s.baseURL, relPath),
 				CreatedAt:   info.ModTime(),
@@ -205,15 +205,14 @@ func (s *LocalStorage) Delete(ctx context.Context, id string) error {
 			return nil
 		}
 
-		// Check if the filename starts with the ID
-		if strings.HasPrefix(info.Name(), id) {
-			// Delete the file
+		// Delete the file along with its variants (<id>_<variant>.<ext>)
+		name := fileID(info.Name())
+		if name == id || strings.HasPrefix(name, id+"_") {
 			if err := os.Remove(path); err != nil {
 				return fmt.Errorf("failed to delete file: %w", err)
 			}
 
 			found = true
-			return filepath.SkipDir // Stop the walk once we deleted the file
 		}
 
 		return nil
@@ -230,121 +229,41 @@ func (s *LocalStorage) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// GenerateThumbnails creates thumbnails for an image and returns their metadata
-func (s *LocalStorage) GenerateThumbnails(ctx context.Context, originalFile *model.MediaFile) ([]model.Thumbnail, error) {
-	// Check if the file is an image
-	if !isImage(originalFile.ContentType) {
-		return nil, nil // Non-image files don't get thumbnails
+// Put saves processed content to the local filesystem
+func (s *LocalStorage) Put(ctx context.Context, path string, data []byte, contentType string) (string, error) {
+	// Keep the file inside the base directory
+	filePath := filepath.Join(s.basePath, filepath.FromSlash(path))
+	if !strings.HasPrefix(filePath, filepath.Clean(s.basePath)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid storage path: %s", path)
 	}
 
-	// Extract directory and filename parts
-	dir := filepath.Dir(originalFile.StoragePath)
-	filename := filepath.Base(originalFile.StoragePath)
-	ext := filepath.Ext(filename)
-	nameWithoutExt := strings.TrimSuffix(filename, ext)
-
-	// Open the original image
-	originalImg, err := os.Open(originalFile.StoragePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open original image: %w", err)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
-	defer originalImg.Close()
 
-	// Decode the image
-	img, _, err := image.Decode(originalImg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+	if err := os.WriteFile(filePath, data, s.permissions); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	thumbnails := make([]model.Thumbnail, 0, len(s.thumbnailSizes))
-
-	// Generate each thumbnail
-	for _, size := range s.thumbnailSizes {
-		// Calculate dimensions while preserving aspect ratio
-		width, height := calculateDimensions(originalFile.Width, originalFile.Height, size.Width, size.Height)
-
-		// Create the thumbnail image
-		thumbImg := image.NewRGBA(image.Rect(0, 0, width, height))
-		draw.BiLinear.Scale(thumbImg, thumbImg.Bounds(), img, img.Bounds(), draw.Over, nil)
-
-		// Generate thumbnail filename
-		thumbFilename := fmt.Sprintf("%s_%s%s", nameWithoutExt, size.Name, ext)
-		thumbPath := filepath.Join(dir, thumbFilename)
-
-		// Create thumbnail file
-		thumbFile, err := os.Create(thumbPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create thumbnail file: %w", err)
-		}
-
-		// Encode the thumbnail based on original format
-		switch ext {
-		case ".jpeg":
-			if err := jpeg.Encode(thumbFile, thumbImg, &jpeg.Options{Quality: 85}); err != nil {
-				thumbFile.Close()
-				return nil, fmt.Errorf("failed to encode JPEG thumbnail: %w", err)
-			}
-		case ".png":
-			if err := png.Encode(thumbFile, thumbImg); err != nil {
-				thumbFile.Close()
-				return nil, fmt.Errorf("failed to encode PNG thumbnail: %w", err)
-			}
-		default:
-			// Default to JPEG for other formats
-			if err := jpeg.Encode(thumbFile, thumbImg, &jpeg.Options{Quality: 85}); err != nil {
-				thumbFile.Close()
-				return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
-			}
-		}
-
-		thumbFile.Close()
-
-		// Set file permissions
-		if err = os.Chmod(thumbPath, s.permissions); err != nil {
-			return nil, fmt.Errorf("failed to set thumbnail permissions: %w", err)
-		}
-
-		// Calculate relative path for URL
-		relPath, _ := filepath.Rel(s.basePath, thumbPath)
-		relPath = strings.ReplaceAll(relPath, "\\", "/")
-
-		// Add thumbnail to list
-		thumbnails = append(thumbnails, model.Thumbnail{
-			Name:   size.Name,
-			URL:    fmt.Sprintf("%s/%s", s.baseURL, relPath),
-			Width:  width,
-			Height: height,
-		})
+	// Set file permissions
+	if err := os.Chmod(filePath, s.permissions); err != nil {
+		return "", fmt.Errorf("failed to set file permissions: %w", err)
 	}
 
-	return thumbnails, nil
+	relPath, _ := filepath.Rel(s.basePath, filePath)
+	relPath = strings.ReplaceAll(relPath, "\\", "/")
+
+	return fmt.Sprintf("%s/%s", s.baseURL, relPath), nil
 }
 
 // Helper functions
 
+// fileID strips the extension from a stored file name
+func fileID(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
 // isImage checks if a content type represents an image
 func isImage(contentType string) bool {
 	return strings.HasPrefix(contentType, "image/")
 }
-
-// calculateDimensions calculates new dimensions while preserving aspect ratio
-func calculateDimensions(origWidth, origHeight, maxWidth, maxHeight int) (int, int) {
-	if origWidth <= 0 || origHeight <= 0 {
-		return maxWidth, maxHeight
-	}
-
-	// Calculate aspect ratio
-	ratio := float64(origWidth) / float64(origHeight)
-
-	// Calculate new dimensions while preserving aspect ratio
-	width := maxWidth
-	height := int(float64(width) / ratio)
-
-	// If height is too large, recalculate width based on maxHeight
-	if height > maxHeight {
-		height = maxHeight
-		width = int(float64(height) * ratio)
-	}
-
-	return width, height
-}
@@ -5,12 +5,9 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"mime/multipart"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"services/media-service/internal/config"
@@ -22,21 +19,19 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
-	"golang.org/x/image/draw"
 )
 
 // S3Storage implements the Storage interface for Amazon S3
 type S3Storage struct {
-	bucket         string
-	baseURL        string
-	s3Client       *s3.S3
-	s3Uploader     *s3manager.Uploader
-	s3Downloader   *s3manager.Downloader
-	thumbnailSizes []config.ThumbnailSize
+	bucket       string
+	baseURL      string
+	s3Client     *s3.S3
+	s3Uploader   *s3manager.Uploader
+	s3Downloader *s3manager.Downloader
 }
 
 // NewS3Storage creates a new S3Storage
-func NewS3Storage(cfg *config.S3StorageConfig, thumbnailSizes []config.ThumbnailSize) (*S3Storage, error) {
+func NewS3Storage(cfg *config.S3StorageConfig) (*S3Storage, error) {
 	// Create AWS session
 	sess, err := session.NewSession(&aws.Config{
 		Region:      aws.String(cfg.Region),
@@ -72,12 +67,11 @@ func NewS3Storage(cfg *config.S3StorageConfig, thumbnailSizes []config.Thumbnail
 	}
 
 	return &S3Storage{
-		bucket:         cfg.Bucket,
-		baseURL:        baseURL,
-		s3Client:       s3Client,
-		s3Uploader:     s3manager.NewUploader(sess),
-		s3Downloader:   s3manager.NewDownloader(sess),
-		thumbnailSizes: thumbnailSizes,
+		bucket:       cfg.Bucket,
+		baseURL:      baseURL,
+		s3Client:     s3Client,
+		s3Uploader:   s3manager.NewUploader(sess),
+		s3Downloader: s3manager.NewDownloader(sess),
 	}, nil
 }
 
@@ -292,96 +286,18 @@ func (s *S3Storage) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// GenerateThumbnails creates thumbnails for an image and returns their metadata
-func (s *S3Storage) GenerateThumbnails(ctx context.Context, originalFile *model.MediaFile) ([]model.Thumbnail, error) {
-	// Check if the file is an image
-	if !isImage(originalFile.ContentType) {
-		return nil, nil // Non-image files don't get thumbnails
-	}
-
-	// Get the original file
-	getResp, err := s.s3Client.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(originalFile.StoragePath),
+// Put uploads processed content to S3
+func (s *S3Storage) Put(ctx context.Context, path string, data []byte, contentType string) (string, error) {
+	_, err := s.s3Uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(path),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("public-read"),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get original image from S3: %w", err)
-	}
-	defer getResp.Body.Close()
-
-	// Read the entire image
-	imgData, err := io.ReadAll(getResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read image data: %w", err)
-	}
-
-	// Decode the image
-	img, format, err := image.Decode(bytes.NewReader(imgData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
-	}
-
-	thumbnails := make([]model.Thumbnail, 0, len(s.thumbnailSizes))
-
-	// Extract path components
-	pathParts := strings.Split(originalFile.StoragePath, "/")
-	fileName := pathParts[len(pathParts)-1]
-	nameWithoutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	basePath := strings.Join(pathParts[:len(pathParts)-1], "/")
-
-	// Generate each thumbnail
-	for _, size := range s.thumbnailSizes {
-		// Calculate dimensions while preserving aspect ratio
-		width, height := calculateDimensions(originalFile.Width, originalFile.Height, size.Width, size.Height)
-
-		// Create the thumbnail image
-		thumbImg := image.NewRGBA(image.Rect(0, 0, width, height))
-		draw.BiLinear.Scale(thumbImg, thumbImg.Bounds(), img, img.Bounds(), draw.Over, nil)
-
-		// Encode the thumbnail to a buffer
-		var buf bytes.Buffer
-		switch format {
-		case "jpeg":
-			if err := jpeg.Encode(&buf, thumbImg, &jpeg.Options{Quality: 85}); err != nil {
-				return nil, fmt.Errorf("failed to encode JPEG thumbnail: %w", err)
-			}
-		case "png":
-			if err := png.Encode(&buf, thumbImg); err != nil {
-				return nil, fmt.Errorf("failed to encode PNG thumbnail: %w", err)
-			}
-		default:
-			// Default to JPEG for other formats
-			if err := jpeg.Encode(&buf, thumbImg, &jpeg.Options{Quality: 85}); err != nil {
-				return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
-			}
-		}
-
-		// Generate key for thumbnail
-		ext := filepath.Ext(fileName)
-		thumbFileName := fmt.Sprintf("%s_%s%s", nameWithoutExt, size.Name, ext)
-		thumbKey := fmt.Sprintf("%s/%s", basePath, thumbFileName)
-
-		// Upload thumbnail to S3
-		_, err = s.s3Uploader.Upload(&s3manager.UploadInput{
-			Bucket:      aws.String(s.bucket),
-			Key:         aws.String(thumbKey),
-			Body:        bytes.NewReader(buf.Bytes()),
-			ContentType: aws.String(originalFile.ContentType),
-			ACL:         aws.String("public-read"),
-		})
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to upload thumbnail to S3: %w", err)
-		}
-
-		// Add thumbnail to list
-		thumbnails = append(thumbnails, model.Thumbnail{
-			Name:   size.Name,
-			URL:    fmt.Sprintf("%s/%s", s.baseURL, thumbKey),
-			Width:  width,
-			Height: height,
-		})
+		return "", fmt.Errorf("failed to upload file to S3: %w", err)
 	}
 
-	return thumbnails, nil
+	return fmt.Sprintf("%s/%s", s.baseURL, path), nil
 }
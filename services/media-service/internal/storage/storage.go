@@ -20,19 +20,19 @@ type Storage interface {
 	// Delete removes a file from storage
 	Delete(ctx context.Context, id string) error
 
-	// GenerateThumbnails creates thumbnails for an image and returns their metadata
-	GenerateThumbnails(ctx context.Context, originalFile *model.MediaFile) ([]model.Thumbnail, error)
+	// Put saves processed content under a path relative to the storage root and returns its URL
+	Put(ctx context.Context, path string, data []byte, contentType string) (string, error)
 }
 
 // NewStorage creates a new storage implementation based on the configuration
 func NewStorage(cfg *config.Config) (Storage, error) {
 	switch cfg.Storage.Type {
 	case "local":
-		return NewLocalStorage(&cfg.Storage.Local, cfg.Upload.ThumbnailSizes)
+		return NewLocalStorage(&cfg.Storage.Local)
 	case "s3":
-		return NewS3Storage(&cfg.Storage.S3, cfg.Upload.ThumbnailSizes)
+		return NewS3Storage(&cfg.Storage.S3)
 	default:
 		// Default to local storage
-		return NewLocalStorage(&cfg.Storage.Local, cfg.Upload.ThumbnailSizes)
+		return NewLocalStorage(&cfg.Storage.Local)
 	}
 }
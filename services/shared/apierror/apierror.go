@@ -0,0 +1,246 @@
+// Package apierror defines the errors the services return to clients. Every error
+// response has the same JSON body, {"error": message, "code": CODE, "details": ...,
+// "request_id": ID}, where code is a stable machine-readable value clients can branch on,
+// error a human-readable message that may change and request_id identifies the request
+// in the logs of the gateway and the services. Each service declares its own codes and
+// sets the message rules its errors are mapped with.
+package apierror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgconn"
+)
+
+// Code is a machine-readable error code
+type Code string
+
+// Codes shared by every service
+const (
+	CodeInvalidRequest     Code = "INVALID_REQUEST"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeUnprocessable      Code = "UNPROCESSABLE"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeUpstreamFailed     Code = "UPSTREAM_FAILED"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeTimeout            Code = "TIMEOUT"
+)
+
+// Error is an error with the HTTP status and code it's reported to clients with
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	// Details is optional structured data about the error, e.g. the invalid fields
+	Details interface{}
+
+	cause error
+}
+
+// Body is the JSON body of an error response
+type Body struct {
+	Error     string      `json:"error"`
+	Code      Code        `json:"code"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // Identifies the request in the logs
+}
+
+// New creates an error
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Error returns the message, followed by the cause if there is one
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code, so errors.Is matches a
+// sentinel even after its message was changed or a cause attached
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// WithMessage returns a copy of the error with another message
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// WithDetails returns a copy of the error with details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Wrap returns a copy of the error caused by err. The cause is logged, never sent.
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.cause = err
+	return &copied
+}
+
+// Body returns the response body of the error
+func (e *Error) Body() Body {
+	return Body{Error: e.Message, Code: e.Code, Details: e.Details}
+}
+
+// From maps err to the error reported to clients, or returns nil for errors that are
+// internal. Besides *Error, it maps missing rows, timeouts and the database errors
+// raised by constraint violations and by the PL/pgSQL functions of the repositories.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return New(http.StatusNotFound, CodeNotFound, "Not found")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return New(http.StatusGatewayTimeout, CodeTimeout, "The request timed out")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return New(http.StatusConflict, CodeConflict, "Resource already exists")
+		case "23503": // foreign_key_violation
+			return New(http.StatusBadRequest, CodeInvalidRequest, "Referenced resource does not exist")
+		case "22P02", "22001", "22003", "22007", "22008", "23502", "23514":
+			// Invalid text representation, value too long or out of range, invalid
+			// dates, and not-null and check violations
+			return New(http.StatusBadRequest, CodeInvalidRequest, "Invalid value")
+		case "57014": // query_canceled, raised by statement_timeout
+			return New(http.StatusGatewayTimeout, CodeTimeout, "The request timed out")
+		case "P0001": // raise_exception
+			return fromMessage(pgErr.Message)
+		}
+		return nil
+	}
+
+	return fromMessage(err.Error())
+}
+
+// fromMessage maps an error by its message, for errors raised by the PL/pgSQL
+// functions and service errors that predate typed errors. The message is kept.
+func fromMessage(message string) *Error {
+	lower := strings.ToLower(message)
+	for _, rule := range messageRules {
+		if strings.Contains(lower, rule.Contains) {
+			return New(rule.Status, rule.Code, message)
+		}
+	}
+	return nil
+}
+
+// MessageRule maps the errors whose message contains a text, in lower case, to a status
+// and code
+type MessageRule struct {
+	Contains string
+	Status   int
+	Code     Code
+}
+
+// messageRules are the rules of the service, most specific first
+var messageRules []MessageRule
+
+// SetMessageRules sets the rules errors are mapped by their message with, most specific
+// first. Services set theirs once at startup, before serving requests.
+func SetMessageRules(rules []MessageRule) {
+	messageRules = rules
+}
+
+// CodeForStatus returns the generic code of an HTTP status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeUpstreamFailed
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// Send sends an error response
+func Send(c *gin.Context, status int, code Code, message string) {
+	c.JSON(status, Body{Error: message, Code: code, RequestID: c.GetString("request_id")})
+}
+
+// Respond sends the response of err. Errors From doesn't map are reported as an
+// internal error with the fallback message.
+func Respond(c *gin.Context, err error, fallback string) {
+	apiErr := From(err)
+	if apiErr == nil {
+		apiErr = New(http.StatusInternalServerError, CodeInternal, fallback)
+	}
+	respond(c, apiErr)
+}
+
+// RespondWithStatus sends the response of err. Errors From doesn't map are sent with
+// their own message and the status, for handlers whose errors are all client errors.
+func RespondWithStatus(c *gin.Context, err error, status int) {
+	apiErr := From(err)
+	if apiErr == nil {
+		apiErr = New(status, CodeForStatus(status), err.Error())
+	}
+	respond(c, apiErr)
+}
+
+// respond sends the response of an error, tagged with the ID of the request
+func respond(c *gin.Context, apiErr *Error) {
+	body := apiErr.Body()
+	body.RequestID = c.GetString("request_id")
+	c.JSON(apiErr.Status, body)
+}
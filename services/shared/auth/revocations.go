@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// tokenRevocationChannel is the Redis pub/sub channel the user service broadcasts
+// revocations on when a user logs out everywhere or is deactivated
+const tokenRevocationChannel = "auth:token-revocations"
+
+// tokenRevokedKeyPattern matches the Redis keys the user service stores each user's
+// latest revocation under until the revoked tokens would have expired
+const tokenRevokedKeyPattern = "auth:revoked:user:*"
+
+// RevocationList is an in-memory denylist of users whose tokens were revoked, loaded
+// from the revocations the user service stores in Redis and kept up to date from its
+// broadcasts. Entries are dropped once the revoked tokens would have expired anyway.
+type RevocationList struct {
+	redisClient *redis.Client
+	logger      *zap.Logger
+
+	mu    sync.RWMutex
+	users map[int]tokenRevocation
+}
+
+// tokenRevocation is a broadcast revocation: tokens of the user issued before
+// RevokedBeforeMs are rejected until ExpiresAt. Revocations without RevokedBeforeMs only
+// know the second they were made in, and reject the tokens issued in or before it.
+type tokenRevocation struct {
+	UserID          int    `json:"user_id"`
+	RevokedBefore   int64  `json:"revoked_before"`
+	RevokedBeforeMs int64  `json:"revoked_before_ms"`
+	ExpiresAt       int64  `json:"expires_at"`
+	Reason          string `json:"reason"`
+}
+
+// revokedBefore returns the time in Unix milliseconds tokens issued before are revoked
+func (r *tokenRevocation) revokedBefore() int64 {
+	if r.RevokedBeforeMs > 0 {
+		return r.RevokedBeforeMs
+	}
+	return (r.RevokedBefore + 1) * 1000
+}
+
+// NewRevocationList creates a new revocation list. Run must be started to receive revocations.
+func NewRevocationList(redisClient *redis.Client, logger *zap.Logger) *RevocationList {
+	return &RevocationList{
+		redisClient: redisClient,
+		logger:      logger,
+		users:       make(map[int]tokenRevocation),
+	}
+}
+
+// Run loads the stored revocations and receives new ones until the context is cancelled.
+// The subscription reconnects on its own when Redis is unavailable.
+func (l *RevocationList) Run(ctx context.Context) {
+	sub := l.redisClient.Subscribe(ctx, tokenRevocationChannel)
+	defer sub.Close()
+
+	// Loading once subscribed means no revocation falls between the two
+	if _, err := sub.Receive(ctx); err != nil {
+		l.logger.Warn("Failed to subscribe to token revocations", zap.Error(err))
+	}
+	l.load(ctx)
+
+	cleanup := time.NewTicker(time.Minute)
+	defer cleanup.Stop()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			l.dropExpired()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var revocation tokenRevocation
+			if err := json.Unmarshal([]byte(msg.Payload), &revocation); err != nil {
+				l.logger.Warn("Ignoring malformed token revocation", zap.Error(err))
+				continue
+			}
+
+			l.add(revocation)
+			l.logger.Info("Token revocation received",
+				zap.Int("userID", revocation.UserID),
+				zap.String("reason", revocation.Reason))
+		}
+	}
+}
+
+// load adds the revocations stored in Redis, so a restart doesn't forget the tokens
+// revoked before it
+func (l *RevocationList) load(ctx context.Context) {
+	loaded := 0
+	iter := l.redisClient.Scan(ctx, 0, tokenRevokedKeyPattern, 100).Iterator()
+	for iter.Next(ctx) {
+		value, err := l.redisClient.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			// Expired since it was listed, or Redis is unavailable
+			continue
+		}
+
+		// Values stored before revocations were stored whole hold only the time; they
+		// expire within a token lifetime
+		var revocation tokenRevocation
+		if err := json.Unmarshal([]byte(value), &revocation); err != nil {
+			continue
+		}
+
+		l.add(revocation)
+		loaded++
+	}
+	if err := iter.Err(); err != nil {
+		l.logger.Warn("Failed to load stored token revocations", zap.Error(err))
+	}
+
+	l.logger.Info("Token revocations loaded", zap.Int("count", loaded))
+}
+
+// add records a revocation unless a later one of the user is already recorded
+func (l *RevocationList) add(revocation tokenRevocation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A later revocation covers the tokens of an earlier one
+	if current, ok := l.users[revocation.UserID]; !ok || revocation.revokedBefore() >= current.revokedBefore() {
+		l.users[revocation.UserID] = revocation
+	}
+}
+
+// IsRevoked reports whether the user's tokens were revoked after issuedAt, in Unix
+// milliseconds
+func (l *RevocationList) IsRevoked(userID int, issuedAt int64) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.RLock()
+	revocation, ok := l.users[userID]
+	l.mu.RUnlock()
+
+	return ok && issuedAt < revocation.revokedBefore() && time.Now().Unix() < revocation.ExpiresAt
+}
+
+// dropExpired removes revocations whose tokens have all expired
+func (l *RevocationList) dropExpired() {
+	now := time.Now().Unix()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for userID, revocation := range l.users {
+		if now >= revocation.ExpiresAt {
+			delete(l.users, userID)
+		}
+	}
+}
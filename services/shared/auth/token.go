@@ -0,0 +1,288 @@
+// Package auth verifies access tokens issued by the user service without a round-trip to
+// it, and keeps the denylist of revoked tokens. Every service that authenticates users
+// locally uses it, so signing key and revocation handling stays the same everywhere.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// jwksRefetchInterval limits how often unknown key IDs trigger a refetch of the JWKS,
+// so tokens with made-up key IDs can't flood the user service
+const jwksRefetchInterval = 30 * time.Second
+
+// ErrTokenRevoked is returned for tokens of users who logged out everywhere or were
+// deactivated after the token was issued
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenClaims are the verified claims of an access token
+type TokenClaims struct {
+	UserID      int
+	Role        string
+	Permissions []string
+
+	// Set on impersonation tokens: the admin acting as the user and their session
+	ImpersonatorID         int
+	ImpersonationSessionID int
+}
+
+// TokenVerifier verifies access tokens locally, without a round-trip to the user
+// service. RS256 tokens are checked against the public keys the user service publishes
+// at its JWKS endpoint, HS256 tokens against the shared secret if one is configured.
+// Tokens of users on the revocation list are rejected.
+type TokenVerifier struct {
+	jwksURL     string
+	secret      []byte
+	keysMaxAge  time.Duration
+	revocations *RevocationList
+	httpClient  *http.Client
+	logger      *zap.Logger
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewTokenVerifier creates a new token verifier. Fetched keys are reused for keysMaxAge.
+// An empty JWKS URL rejects RS256 tokens, an empty secret rejects HS256 tokens and a nil
+// revocation list revokes nothing.
+func NewTokenVerifier(
+	jwksURL, secret string,
+	keysMaxAge time.Duration,
+	revocations *RevocationList,
+	logger *zap.Logger,
+) *TokenVerifier {
+	return &TokenVerifier{
+		jwksURL:     jwksURL,
+		secret:      []byte(secret),
+		keysMaxAge:  keysMaxAge,
+		revocations: revocations,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		logger:      logger,
+		keys:        make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Verify checks the signature, expiry and type of an access token and returns its claims
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid token format")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("failed to parse token header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch header.Alg {
+	case "RS256":
+		key, err := v.key(ctx, header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, errors.New("invalid token signature")
+		}
+	case "HS256":
+		if len(v.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.New("invalid token signature")
+		}
+	default:
+		return nil, fmt.Errorf("unexpected signing method %q", header.Alg)
+	}
+
+	var claims struct {
+		Sub         int      `json:"sub"`
+		Exp         int64    `json:"exp"`
+		Iat         int64    `json:"iat"`
+		IatMs       int64    `json:"iat_ms"`
+		Type        string   `json:"type"`
+		Role        string   `json:"role"`
+		Permissions []string `json:"permissions"`
+
+		ImpersonatorID         int `json:"impersonator_id"`
+		ImpersonationSessionID int `json:"impersonation_session_id"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse token payload: %w", err)
+	}
+
+	if claims.Exp < time.Now().Unix() {
+		return nil, errors.New("token has expired")
+	}
+	if claims.Type != "access" {
+		return nil, errors.New("not an access token")
+	}
+	// Tokens issued before iat_ms was added only know the second they were issued in
+	issuedAt := claims.IatMs
+	if issuedAt == 0 {
+		issuedAt = claims.Iat * 1000
+	}
+	if v.revocations.IsRevoked(claims.Sub, issuedAt) {
+		return nil, ErrTokenRevoked
+	}
+
+	// If role is empty, default to "user"
+	if claims.Role == "" {
+		claims.Role = "user"
+	}
+
+	// Tokens issued before permissions were added carry no claim; admins keep full access
+	if claims.Permissions == nil {
+		claims.Permissions = []string{}
+		if claims.Role == "admin" {
+			claims.Permissions = []string{"*"}
+		}
+	}
+
+	return &TokenClaims{
+		UserID:                 claims.Sub,
+		Role:                   claims.Role,
+		Permissions:            claims.Permissions,
+		ImpersonatorID:         claims.ImpersonatorID,
+		ImpersonationSessionID: claims.ImpersonationSessionID,
+	}, nil
+}
+
+// key returns the public key with the given ID, refetching the JWKS when the key is
+// unknown or the cached keys are older than keysMaxAge
+func (v *TokenVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if v.jwksURL == "" {
+		return nil, errors.New("RS256 tokens are not accepted")
+	}
+
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	if ok && time.Since(v.fetchedAt) < v.keysMaxAge {
+		v.mu.Unlock()
+		return key, nil
+	}
+
+	// Only one caller refetches per interval; the others keep using the cached keys
+	if time.Since(v.lastAttempt) < jwksRefetchInterval {
+		v.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	v.lastAttempt = time.Now()
+	v.mu.Unlock()
+
+	// The fetch runs unlocked so a slow user service doesn't hold up other verifications
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		if ok {
+			// Keep verifying with the cached key while the user service is unreachable
+			v.logger.Warn("failed to refresh token signing keys", zap.Error(err))
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys reads the RSA signing keys from the JWKS endpoint of the user service
+func (v *TokenVerifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status code %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode token signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			v.logger.Warn("skipping malformed signing key", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			v.logger.Warn("skipping malformed signing key", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	v.logger.Info("fetched token signing keys", zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
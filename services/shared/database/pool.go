@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// poolShrinkAfter is how many consecutive samples without a wait for a connection shrink a
+// grown pool by one step
+const poolShrinkAfter = 30
+
+// PoolOptions controls how connection pools are sampled and tuned
+type PoolOptions struct {
+	SampleInterval    time.Duration
+	Adaptive          bool          // Grow and shrink MaxOpenConns with how long queries wait for a connection
+	MaxOpenConnsLimit int           // Most open connections adaptive tuning grows a pool to
+	TargetWait        time.Duration // Average wait for a connection above which a pool grows
+}
+
+// PoolState is the state of a connection pool. Counters are totals since the pool opened;
+// the recent fields cover the last sample interval.
+type PoolState struct {
+	Name                   string    `json:"name"`
+	MaxOpenConns           int       `json:"max_open_conns"`
+	ConfiguredMaxOpenConns int       `json:"configured_max_open_conns"`
+	Adaptive               bool      `json:"adaptive"`
+	OpenConns              int       `json:"open_conns"`
+	InUse                  int       `json:"in_use"`
+	Idle                   int       `json:"idle"`
+	WaitCount              int64     `json:"wait_count"`
+	WaitDurationSeconds    float64   `json:"wait_duration_seconds"`
+	MaxIdleClosed          int64     `json:"max_idle_closed"`
+	MaxIdleTimeClosed      int64     `json:"max_idle_time_closed"`
+	MaxLifetimeClosed      int64     `json:"max_lifetime_closed"`
+	RecentWaitCount        int64     `json:"recent_wait_count"`
+	RecentAvgWaitSeconds   float64   `json:"recent_avg_wait_seconds"`
+	SampledAt              time.Time `json:"sampled_at"`
+}
+
+// poolMetric is a pool statistic exported in the Prometheus text format
+type poolMetric struct {
+	name  string
+	help  string
+	kind  string
+	value func(s sql.DBStats) float64
+}
+
+var poolMetrics = []poolMetric{
+	{"db_pool_max_open_connections", "Maximum number of open connections to the database.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"db_pool_open_connections", "Number of established connections, in use and idle.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"db_pool_in_use_connections", "Number of connections currently in use.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"db_pool_idle_connections", "Number of idle connections.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"db_pool_wait_count_total", "Total number of connections waited for.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", "counter",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"db_pool_max_idle_closed_total", "Total number of connections closed due to the idle connection limit.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"db_pool_max_idle_time_closed_total", "Total number of connections closed due to their idle time.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"db_pool_max_lifetime_closed_total", "Total number of connections closed due to their lifetime.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+// monitoredPool is a connection pool with what the monitor saw at its last sample
+type monitoredPool struct {
+	name         string
+	db           *sqlx.DB
+	baseMaxOpen  int
+	last         sql.DBStats
+	recentWaits  int64
+	recentWait   time.Duration
+	quietSamples int
+	sampledAt    time.Time
+}
+
+// PoolMonitor samples the statistics of database connection pools and exports them as
+// metrics. When adaptive, it grows a pool's maximum open connections while queries wait
+// too long for a connection, and shrinks it back towards the configured size once they
+// stop waiting.
+type PoolMonitor struct {
+	options PoolOptions
+	mu      sync.Mutex
+	pools   []*monitoredPool
+	logger  *zap.Logger
+}
+
+// NewPoolMonitor creates a new connection pool monitor
+func NewPoolMonitor(options PoolOptions, logger *zap.Logger) *PoolMonitor {
+	if options.SampleInterval <= 0 {
+		options.SampleInterval = 10 * time.Second
+	}
+	return &PoolMonitor{
+		options: options,
+		logger:  logger,
+	}
+}
+
+// Add monitors a connection pool under a name. A nil pool, such as an unconfigured
+// replica, is ignored. The pool's current maximum open connections is the size adaptive
+// tuning never shrinks it below.
+func (m *PoolMonitor) Add(name string, db *sqlx.DB) {
+	if db == nil {
+		return
+	}
+
+	stats := db.Stats()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools = append(m.pools, &monitoredPool{
+		name:        name,
+		db:          db,
+		baseMaxOpen: stats.MaxOpenConnections,
+		last:        stats,
+		sampledAt:   time.Now(),
+	})
+}
+
+// Run samples the pools every interval until the context is cancelled
+func (m *PoolMonitor) Run(ctx context.Context) {
+	m.logger.Info("Starting database connection pool monitor",
+		zap.Duration("interval", m.options.SampleInterval),
+		zap.Bool("adaptive", m.options.Adaptive))
+
+	ticker := time.NewTicker(m.options.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+func (m *PoolMonitor) sample() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, pool := range m.pools {
+		stats := pool.db.Stats()
+		pool.recentWaits = stats.WaitCount - pool.last.WaitCount
+		pool.recentWait = stats.WaitDuration - pool.last.WaitDuration
+		pool.last = stats
+		pool.sampledAt = time.Now()
+
+		if m.options.Adaptive {
+			m.tune(pool)
+		}
+	}
+}
+
+// tune grows a pool by a quarter while its average wait for a connection is above the
+// target, and shrinks it by a step after poolShrinkAfter samples without any wait. A pool
+// with no limit on open connections is left alone.
+func (m *PoolMonitor) tune(pool *monitoredPool) {
+	current := pool.last.MaxOpenConnections
+	if current <= 0 {
+		return
+	}
+
+	if pool.recentWaits > 0 {
+		pool.quietSamples = 0
+		avgWait := pool.recentWait / time.Duration(pool.recentWaits)
+		if avgWait <= m.options.TargetWait || current >= m.options.MaxOpenConnsLimit {
+			return
+		}
+
+		next := min(current+max(current/4, 1), m.options.MaxOpenConnsLimit)
+		pool.db.SetMaxOpenConns(next)
+		m.logger.Info("Growing database connection pool",
+			zap.String("pool", pool.name),
+			zap.Int("from", current),
+			zap.Int("to", next),
+			zap.Int64("waits", pool.recentWaits),
+			zap.Duration("avgWait", avgWait))
+		return
+	}
+
+	if current <= pool.baseMaxOpen {
+		return
+	}
+	pool.quietSamples++
+	if pool.quietSamples < poolShrinkAfter {
+		return
+	}
+
+	pool.quietSamples = 0
+	next := max(current-max(pool.baseMaxOpen/4, 1), pool.baseMaxOpen)
+	pool.db.SetMaxOpenConns(next)
+	m.logger.Info("Shrinking database connection pool",
+		zap.String("pool", pool.name),
+		zap.Int("from", current),
+		zap.Int("to", next))
+}
+
+// States returns the current state of every monitored pool
+func (m *PoolMonitor) States() []PoolState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make([]PoolState, 0, len(m.pools))
+	for _, pool := range m.pools {
+		stats := pool.db.Stats()
+		state := PoolState{
+			Name:                   pool.name,
+			MaxOpenConns:           stats.MaxOpenConnections,
+			ConfiguredMaxOpenConns: pool.baseMaxOpen,
+			Adaptive:               m.options.Adaptive,
+			OpenConns:              stats.OpenConnections,
+			InUse:                  stats.InUse,
+			Idle:                   stats.Idle,
+			WaitCount:              stats.WaitCount,
+			WaitDurationSeconds:    stats.WaitDuration.Seconds(),
+			MaxIdleClosed:          stats.MaxIdleClosed,
+			MaxIdleTimeClosed:      stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:      stats.MaxLifetimeClosed,
+			RecentWaitCount:        pool.recentWaits,
+			SampledAt:              pool.sampledAt,
+		}
+		if pool.recentWaits > 0 {
+			state.RecentAvgWaitSeconds = (pool.recentWait / time.Duration(pool.recentWaits)).Seconds()
+		}
+		states = append(states, state)
+	}
+
+	return states
+}
+
+// WriteMetrics writes the current statistics of every monitored pool in the Prometheus
+// text exposition format, labelled with the pool's name
+func (m *PoolMonitor) WriteMetrics(w io.Writer) error {
+	m.mu.Lock()
+	names := make([]string, len(m.pools))
+	stats := make([]sql.DBStats, len(m.pools))
+	for i, pool := range m.pools {
+		names[i] = pool.name
+		stats[i] = pool.db.Stats()
+	}
+	m.mu.Unlock()
+
+	for _, metric := range poolMetrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind); err != nil {
+			return err
+		}
+		for i, name := range names {
+			if _, err := fmt.Fprintf(w, "%s{pool=%q} %g\n", metric.name, name, metric.value(stats[i])); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// slowQueryLogLength is how much of a slow query's SQL is logged
+const slowQueryLogLength = 200
+
+// QueryClass is a class of queries sharing a deadline
+type QueryClass string
+
+// Classes of queries. Gets and selects are reads and execs are writes unless the context
+// says otherwise.
+const (
+	QueryRead  QueryClass = "read"
+	QueryWrite QueryClass = "write"
+	QueryBulk  QueryClass = "bulk" // Imports, trade batches, aggregate refreshes and pruning
+)
+
+type queryClassKey struct{}
+
+// WithQueryClass returns a context whose queries run with the deadline of class instead
+// of the one of their kind
+func WithQueryClass(ctx context.Context, class QueryClass) context.Context {
+	return context.WithValue(ctx, queryClassKey{}, class)
+}
+
+// QueryOptions holds the deadline of each class of queries and the duration above which a
+// query is logged as slow. A zero duration disables that deadline or the logging.
+type QueryOptions struct {
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	BulkTimeout   time.Duration
+	SlowThreshold time.Duration
+}
+
+func (o QueryOptions) timeout(class QueryClass) time.Duration {
+	switch class {
+	case QueryWrite:
+		return o.WriteTimeout
+	case QueryBulk:
+		return o.BulkTimeout
+	default:
+		return o.ReadTimeout
+	}
+}
+
+// DB is a connection pool whose queries follow a timeout policy. Each query gets the
+// deadline of its class on top of the caller's context, so queries of background jobs
+// whose contexts never expire can't hang on the database either, and queries slower than
+// the threshold are logged. Queries of a context that is already done aren't sent.
+//
+// GetContext, SelectContext and ExecContext follow the policy. Connections, transactions
+// and row cursors are bounded by the caller's context only; StartQuery applies the policy
+// to them.
+type DB struct {
+	*sqlx.DB
+	options QueryOptions
+	logger  *zap.Logger
+}
+
+// NewDB wraps a connection pool with a query timeout policy. A nil pool, such as an
+// unconfigured replica, gives a nil DB.
+func NewDB(db *sqlx.DB, options QueryOptions, logger *zap.Logger) *DB {
+	if db == nil {
+		return nil
+	}
+	return &DB{
+		DB:      db,
+		options: options,
+		logger:  logger,
+	}
+}
+
+// StartQuery applies the timeout policy to an operation, labelled for the slow query log,
+// that runs with the returned context. Operations not otherwise classed get the deadline
+// of class. The returned function ends the operation and must be called once it finished.
+func (d *DB) StartQuery(ctx context.Context, class QueryClass, label string) (context.Context, func()) {
+	if c, ok := ctx.Value(queryClassKey{}).(QueryClass); ok {
+		class = c
+	}
+
+	parent := ctx
+	cancel := context.CancelFunc(func() {})
+	if timeout := d.options.timeout(class); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	started := time.Now()
+	return ctx, func() {
+		elapsed := time.Since(started)
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+		cancel()
+
+		switch {
+		case timedOut:
+			d.logger.Warn("Query timed out",
+				zap.String("class", string(class)),
+				zap.Duration("timeout", d.options.timeout(class)),
+				zap.String("query", compactQuery(label)))
+		case d.options.SlowThreshold > 0 && elapsed >= d.options.SlowThreshold:
+			d.logger.Warn("Slow query",
+				zap.String("class", string(class)),
+				zap.Duration("duration", elapsed),
+				zap.String("query", compactQuery(label)))
+		}
+	}
+}
+
+// GetContext runs a single row query with the deadline of reads
+func (d *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, done := d.StartQuery(ctx, QueryRead, query)
+	defer done()
+
+	return d.DB.GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext runs a query into a slice with the deadline of reads
+func (d *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, done := d.StartQuery(ctx, QueryRead, query)
+	defer done()
+
+	return d.DB.SelectContext(ctx, dest, query, args...)
+}
+
+// ExecContext runs a statement with the deadline of writes
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, done := d.StartQuery(ctx, QueryWrite, query)
+	defer done()
+
+	return d.DB.ExecContext(ctx, query, args...)
+}
+
+// compactQuery collapses the whitespace of a query and shortens it for logging
+func compactQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > slowQueryLogLength {
+		query = query[:slowQueryLogLength] + "..."
+	}
+	return query
+}
@@ -0,0 +1,151 @@
+// Package database holds the database helpers of the services: a router between the
+// primary database and a read replica, a connection pool that applies a timeout policy
+// to its queries and a monitor sampling and sizing the pools.
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// replicationLagQuery returns how many seconds the replica's replayed data is behind the
+// primary. A replica that has replayed everything it received reports no lag even when
+// the primary has been idle since its last transaction.
+const replicationLagQuery = `
+SELECT CASE
+    WHEN NOT pg_is_in_recovery() THEN 0
+    WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+    ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// ReplicaOptions controls how the read replica's health is checked
+type ReplicaOptions struct {
+	HealthCheckInterval time.Duration
+	MaxLag              time.Duration // Lag above which the replica is considered unhealthy
+	RecoverAfter        int           // Consecutive passing checks before reads fail back to the replica
+}
+
+// Conn is a connection pool queries are routed to, such as a *sqlx.DB or a *DB
+type Conn interface {
+	comparable
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Close() error
+}
+
+// Router routes queries between the primary database and an optional read replica.
+// Writes, and reads that must see their own writes, go to Primary. Heavy reads go to
+// Reader, which is the replica while its health checks pass and the primary otherwise.
+type Router[C Conn] struct {
+	primary C
+	replica C
+	options ReplicaOptions
+	healthy atomic.Bool
+	logger  *zap.Logger
+}
+
+// NewRouter creates a new query router. A nil replica sends every query to the primary.
+// The replica only receives reads once Run has seen it pass a health check.
+func NewRouter[C Conn](primary, replica C, options ReplicaOptions, logger *zap.Logger) *Router[C] {
+	if options.HealthCheckInterval <= 0 {
+		options.HealthCheckInterval = 5 * time.Second
+	}
+	if options.RecoverAfter <= 0 {
+		options.RecoverAfter = 1
+	}
+	return &Router[C]{
+		primary: primary,
+		replica: replica,
+		options: options,
+		logger:  logger,
+	}
+}
+
+// Primary returns the primary database, used for writes and consistent reads
+func (r *Router[C]) Primary() C {
+	return r.primary
+}
+
+// Reader returns the database heavy reads should use: the replica while it's healthy,
+// otherwise the primary. Data read from it may lag slightly behind recent writes.
+func (r *Router[C]) Reader() C {
+	if r.hasReplica() && r.healthy.Load() {
+		return r.replica
+	}
+	return r.primary
+}
+
+// ReplicaHealthy reports whether reads are currently routed to the replica
+func (r *Router[C]) ReplicaHealthy() bool {
+	return r.hasReplica() && r.healthy.Load()
+}
+
+// hasReplica reports whether a replica was configured, i.e. isn't a nil pool
+func (r *Router[C]) hasReplica() bool {
+	var none C
+	return r.replica != none
+}
+
+// Run checks the replica's health once at startup and then every interval until the
+// context is cancelled. A failed check routes reads to the primary straight away; they
+// fail back to the replica after RecoverAfter consecutive passing checks.
+func (r *Router[C]) Run(ctx context.Context) {
+	if !r.hasReplica() {
+		return
+	}
+
+	r.logger.Info("Starting read replica health checks",
+		zap.Duration("interval", r.options.HealthCheckInterval),
+		zap.Duration("maxLag", r.options.MaxLag))
+
+	ticker := time.NewTicker(r.options.HealthCheckInterval)
+	defer ticker.Stop()
+
+	passed := 0
+	for {
+		lag, err := r.replicationLag(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil || (r.options.MaxLag > 0 && lag > r.options.MaxLag):
+			passed = 0
+			if r.healthy.Swap(false) {
+				r.logger.Warn("Read replica unhealthy, routing reads to primary",
+					zap.Error(err), zap.Duration("lag", lag))
+			}
+		default:
+			passed++
+			if passed >= r.options.RecoverAfter && !r.healthy.Swap(true) {
+				r.logger.Info("Read replica healthy, routing reads to replica", zap.Duration("lag", lag))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Router[C]) replicationLag(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.options.HealthCheckInterval)
+	defer cancel()
+
+	var seconds float64
+	if err := r.replica.GetContext(ctx, &seconds, replicationLagQuery); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Close closes the replica connection pool. The primary is owned by the caller.
+func (r *Router[C]) Close() error {
+	if !r.hasReplica() {
+		return nil
+	}
+	return r.replica.Close()
+}
@@ -0,0 +1,212 @@
+// Package httpclient provides the HTTP client used to call other services. Every attempt
+// of a request is bounded by a timeout, idempotent requests that fail in a way worth
+// retrying are retried with jittered exponential backoff, and connections are pooled per host.
+package httpclient
+
+import (
+	"crypto/tls"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config holds the settings of a Client. Zero values fall back to defaults.
+type Config struct {
+	Timeout             time.Duration // Limit of a single attempt, including reading the response body
+	MaxRetries          int           // Retries after the first attempt; only idempotent requests are retried
+	BaseBackoff         time.Duration // Longest wait before the first retry, doubled for each further retry
+	MaxBackoff          time.Duration // Longest wait between two attempts
+	MaxIdleConnsPerHost int           // Idle connections kept open to each host
+	MaxConnsPerHost     int           // Connections open to each host at once; 0 means no limit
+	IdleConnTimeout     time.Duration // How long an idle connection is kept open
+	TLS                 *tls.Config   // TLS settings such as a client certificate for mutual TLS; nil uses the defaults
+}
+
+const (
+	defaultTimeout             = 10 * time.Second
+	defaultBaseBackoff         = 100 * time.Millisecond
+	defaultMaxBackoff          = 2 * time.Second
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// withDefaults returns the config with unset values replaced by defaults
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = defaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.MaxBackoff < c.BaseBackoff {
+		c.MaxBackoff = c.BaseBackoff
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	return c
+}
+
+// Client sends requests to one service
+type Client struct {
+	service string
+	config  Config
+	http    *http.Client
+	logger  *zap.Logger
+}
+
+// New creates a client for the named service, e.g. "user service". The name is used in
+// errors and logs.
+func New(service string, config Config, logger *zap.Logger) *Client {
+	config = config.withDefaults()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	if config.TLS != nil {
+		transport.TLSClientConfig = config.TLS.Clone()
+	}
+
+	return &Client{
+		service: service,
+		config:  config,
+		http: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+		logger: logger,
+	}
+}
+
+// Do sends a request and returns its response. Idempotent requests are retried on network
+// errors, timeouts and 429, 502, 503 and 504 responses. A response is returned whatever its
+// status, including the last one of a request that kept failing, so callers check the status
+// and can turn unexpected ones into an *Error with StatusError. Failures to get a response
+// are returned as *Error. The ID of the request that caused the call is sent along.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(RequestIDHeader) == "" {
+		if requestID := RequestIDFromContext(req.Context()); requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+	}
+
+	retries := 0
+	if canRetry(req) {
+		retries = c.config.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, c.requestError(req, err)
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := c.http.Do(attemptReq)
+		var failure *Error
+		if err != nil {
+			failure = c.requestError(req, err)
+		} else if retryableStatus(resp.StatusCode) {
+			failure = c.StatusError(resp)
+		}
+
+		if failure == nil || attempt >= retries || !failure.Retryable() {
+			if err != nil {
+				return nil, failure
+			}
+			return resp, nil
+		}
+
+		wait := c.backoff(attempt, resp)
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		c.logger.Warn("Retrying request",
+			zap.String("service", c.service),
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.String()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("wait", wait),
+			zap.Error(failure))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, c.requestError(req, req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before the retry following the given attempt. The
+// wait is drawn at random up to an exponentially growing ceiling ("full jitter") so
+// clients failing together don't retry together. A Retry-After header is honored up
+// to MaxBackoff.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	ceiling := c.config.MaxBackoff
+	if attempt < 30 {
+		if d := c.config.BaseBackoff << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	wait := time.Duration(rand.Int63n(int64(ceiling))) + 1
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter := time.Duration(seconds) * time.Second
+			if retryAfter > c.config.MaxBackoff {
+				retryAfter = c.config.MaxBackoff
+			}
+			if retryAfter > wait {
+				wait = retryAfter
+			}
+		}
+	}
+
+	return wait
+}
+
+// canRetry reports whether a request may be sent again: its method must be idempotent or
+// it must carry an Idempotency-Key, and its body must be replayable
+func canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// retryableStatus reports whether a response status means the request may succeed later
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Kind classifies why a request to another service failed
+type Kind string
+
+const (
+	KindNetwork  Kind = "network"  // The service could not be reached or the connection broke
+	KindTimeout  Kind = "timeout"  // An attempt took longer than the client's timeout
+	KindCanceled Kind = "canceled" // The caller's context was canceled or ran out
+	KindServer   Kind = "server"   // The service answered with a 5xx status
+	KindClient   Kind = "client"   // The service rejected the request with a 4xx status
+)
+
+// Error describes a failed request to another service
+type Error struct {
+	Service    string
+	Method     string
+	URL        string
+	Kind       Kind
+	StatusCode int // Set for KindServer and KindClient
+	Err        error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s returned status code %d", e.Service, e.StatusCode)
+	}
+	return fmt.Sprintf("request to %s failed: %v", e.Service, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is likely temporary, so that sending the
+// request again may succeed
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindNetwork, KindTimeout:
+		return true
+	case KindServer, KindClient:
+		return retryableStatus(e.StatusCode)
+	}
+	return false
+}
+
+// StatusError returns an *Error for a response with an unexpected status
+func (c *Client) StatusError(resp *http.Response) *Error {
+	kind := KindClient
+	if resp.StatusCode >= 500 {
+		kind = KindServer
+	}
+
+	e := &Error{
+		Service:    c.service,
+		Kind:       kind,
+		StatusCode: resp.StatusCode,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+	return e
+}
+
+// requestError classifies an error that kept a request from getting a response
+func (c *Client) requestError(req *http.Request, err error) *Error {
+	kind := KindNetwork
+	var netErr net.Error
+	switch {
+	case req.Context().Err() != nil || errors.Is(err, context.Canceled):
+		kind = KindCanceled
+	case errors.As(err, &netErr) && netErr.Timeout():
+		kind = KindTimeout
+	}
+
+	return &Error{
+		Service: c.service,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Kind:    kind,
+		Err:     err,
+	}
+}
@@ -0,0 +1,28 @@
+// Package idempotency makes POST endpoints safe to retry with an Idempotency-Key header.
+// Keys and the responses of their requests are stored by the claim_idempotency_key,
+// complete_idempotency_key and release_idempotency_key functions, which each service
+// creates in its own database with its migrations.
+package idempotency
+
+import "services/shared/apierror"
+
+// Outcomes of claiming an idempotency key
+const (
+	Claimed    = "claimed"    // The request runs and its response is stored
+	Completed  = "completed"  // The request already ran; its response is replayed
+	Processing = "processing" // Another attempt of the request is still running
+	Mismatch   = "mismatch"   // The key was used for a different request
+)
+
+// Codes of the errors of requests whose key can't be used
+const (
+	CodeKeyInUse  apierror.Code = "IDEMPOTENCY_KEY_IN_USE"
+	CodeKeyReused apierror.Code = "IDEMPOTENCY_KEY_REUSED"
+)
+
+// Claim is the result of claiming the Idempotency-Key of a request
+type Claim struct {
+	Outcome        string `db:"outcome"`
+	ResponseStatus *int   `db:"response_status"`
+	ResponseBody   []byte `db:"response_body"`
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// KeyHeader is the request header carrying a client-chosen key for retries
+const KeyHeader = "Idempotency-Key"
+
+// maxKeyLength bounds the accepted keys
+const maxKeyLength = 255
+
+// Store persists idempotency keys and the responses of their requests
+type Store interface {
+	Claim(ctx context.Context, userID int, key, fingerprint string, lockTimeout, ttl time.Duration) (*Claim, error)
+	Complete(ctx context.Context, userID int, key string, status int, body []byte) error
+	Release(ctx context.Context, userID int, key string) error
+}
+
+// Middleware creates middleware making a POST endpoint safe to retry. A request with an
+// Idempotency-Key header runs once per user and key; retries get the stored response
+// replayed with an Idempotent-Replayed header. Reusing a key for a different request fails
+// with 422 and retrying while the first attempt runs with 409. Server errors aren't stored,
+// so the request can be retried with the same key. Requests without the header are not
+// affected. Must run after the middleware authenticating the user, which sets userID.
+func Middleware(store Store, lockTimeout, ttl time.Duration, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+			c.Abort()
+			return
+		}
+
+		value, exists := c.Get("userID")
+		if !exists {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+		userID := value.(int)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		claim, err := store.Claim(c.Request.Context(), userID, key, requestFingerprint(c.Request, body), lockTimeout, ttl)
+		if err != nil {
+			logger.Error("Failed to claim idempotency key", zap.Error(err), zap.Int("user_id", userID))
+			apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to process request")
+			c.Abort()
+			return
+		}
+
+		switch claim.Outcome {
+		case Completed:
+			status := http.StatusOK
+			if claim.ResponseStatus != nil {
+				status = *claim.ResponseStatus
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(status, "application/json; charset=utf-8", claim.ResponseBody)
+			c.Abort()
+			return
+		case Processing:
+			apierror.Send(c, http.StatusConflict, CodeKeyInUse, "A request with this Idempotency-Key is still being processed")
+			c.Abort()
+			return
+		case Mismatch:
+			apierror.Send(c, http.StatusUnprocessableEntity, CodeKeyReused, "Idempotency-Key was already used for a different request")
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// Store the outcome even if the client went away, so its retry gets the response
+		ctx := context.WithoutCancel(c.Request.Context())
+		if status := recorder.Status(); status >= http.StatusInternalServerError {
+			err = store.Release(ctx, userID, key)
+		} else {
+			err = store.Complete(ctx, userID, key, status, recorder.body.Bytes())
+		}
+		if err != nil {
+			logger.Warn("Failed to store idempotency key outcome", zap.Error(err), zap.Int("user_id", userID))
+		}
+	}
+}
+
+// requestFingerprint hashes what identifies a request: its method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DB is the connection pool keys are stored in, such as a *sqlx.DB or a *database.DB
+type DB interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Repository stores the Idempotency-Key headers of requests and their responses
+type Repository struct {
+	db     DB
+	logger *zap.Logger
+}
+
+// NewRepository creates a new idempotency repository
+func NewRepository(db DB, logger *zap.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Claim claims a user's idempotency key for a request using claim_idempotency_key function.
+// The claim holds for lockTimeout while the request runs; the key is kept for ttl.
+func (r *Repository) Claim(
+	ctx context.Context,
+	userID int,
+	key string,
+	fingerprint string,
+	lockTimeout time.Duration,
+	ttl time.Duration,
+) (*Claim, error) {
+	query := `SELECT * FROM claim_idempotency_key($1, $2, $3, $4, $5)`
+
+	var claim Claim
+	err := r.db.GetContext(ctx, &claim, query, userID, key, fingerprint, int(lockTimeout.Seconds()), int(ttl.Seconds()))
+	if err != nil {
+		r.logger.Error("Failed to claim idempotency key", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+// Complete stores the response of a claimed idempotency key using complete_idempotency_key function
+func (r *Repository) Complete(ctx context.Context, userID int, key string, status int, body []byte) error {
+	query := `SELECT complete_idempotency_key($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, userID, key, status, body)
+	if err != nil {
+		r.logger.Error("Failed to complete idempotency key", zap.Error(err), zap.Int("user_id", userID))
+		return err
+	}
+
+	return nil
+}
+
+// Release gives up a claimed idempotency key using release_idempotency_key function,
+// so a retry runs the request again
+func (r *Repository) Release(ctx context.Context, userID int, key string) error {
+	query := `SELECT release_idempotency_key($1, $2)`
+
+	_, err := r.db.ExecContext(ctx, query, userID, key)
+	if err != nil {
+		r.logger.Error("Failed to release idempotency key", zap.Error(err), zap.Int("user_id", userID))
+		return err
+	}
+
+	return nil
+}
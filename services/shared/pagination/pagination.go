@@ -0,0 +1,225 @@
+// Package pagination parses the page, cursor and sort parameters of list endpoints and
+// writes their responses in one envelope:
+//
+//	{"data": [...], "meta": {"total", "page", "limit", "total_pages", "next_cursor", "has_more"}}
+//
+// Page-numbered lists fill in total, page and total_pages; cursor-paginated lists fill in
+// next_cursor. Until v1 clients move to meta, responses also carry the camelCase
+// "pagination" object they read. The package is shared by the services so every list
+// endpoint pages the same way.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Params holds the page parameters of a request
+type Params struct {
+	Page  int
+	Limit int
+}
+
+// Parse parses the page and limit query parameters. A missing or invalid page is the
+// first, a missing or invalid limit is defaultLimit and a limit above maxLimit is capped.
+func Parse(c *gin.Context, defaultLimit int, maxLimit int) Params {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = defaultLimit
+	} else if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	return Params{
+		Page:  page,
+		Limit: limit,
+	}
+}
+
+// Offset returns the number of items before the page
+func (p Params) Offset() int {
+	return Offset(p.Page, p.Limit)
+}
+
+// Offset returns the number of items before a page, for SQL queries
+func Offset(page, limit int) int {
+	return (page - 1) * limit
+}
+
+// TotalPages returns the number of pages of total items. An empty list has one page.
+func TotalPages(total, limit int) int {
+	if limit < 1 {
+		return 1
+	}
+	return max((total+limit-1)/limit, 1)
+}
+
+// Sort is the order of a list
+type Sort struct {
+	By        string
+	Direction string // ASC or DESC
+}
+
+// ParseSort parses the sort_by and sort_direction query parameters. The direction is
+// normalized; the field is left for the service to check against the fields it sorts by.
+func ParseSort(c *gin.Context, defaultBy, defaultDirection string) Sort {
+	return Sort{
+		By:        c.DefaultQuery("sort_by", defaultBy),
+		Direction: NormalizeDirection(c.Query("sort_direction"), defaultDirection),
+	}
+}
+
+// NormalizeDirection returns a sort direction as ASC or DESC, or defaultDirection if it's
+// neither
+func NormalizeDirection(direction, defaultDirection string) string {
+	direction = strings.ToUpper(direction)
+	if direction != "ASC" && direction != "DESC" {
+		return defaultDirection
+	}
+	return direction
+}
+
+// SortFields is the set of fields a list can be sorted by
+type SortFields []string
+
+// Normalize returns by if the list can be sorted by it, or defaultBy
+func (f SortFields) Normalize(by, defaultBy string) string {
+	if !slices.Contains(f, by) {
+		return defaultBy
+	}
+	return by
+}
+
+// Meta is the page metadata of a list response. Total, page and total pages are set for
+// page-numbered lists only, and the next cursor for cursor-paginated lists with more items.
+type Meta struct {
+	Total      *int   `json:"total,omitempty"`
+	Page       *int   `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewMeta creates the metadata of a page of a page-numbered list
+func NewMeta(total, page, limit int) Meta {
+	totalPages := TotalPages(total, limit)
+	return Meta{
+		Total:      &total,
+		Page:       &page,
+		Limit:      limit,
+		TotalPages: &totalPages,
+		HasMore:    page < totalPages,
+	}
+}
+
+// NewCursorMeta creates the metadata of a page of a cursor-paginated list. An empty next
+// cursor means there are no more items.
+func NewCursorMeta(nextCursor string, limit int) Meta {
+	return Meta{
+		Limit:      limit,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// LegacyPage is the "pagination" object v1 clients read from page-numbered lists
+type LegacyPage struct {
+	TotalItems   int `json:"totalItems"`
+	CurrentPage  int `json:"currentPage"`
+	TotalPages   int `json:"totalPages"`
+	ItemsPerPage int `json:"itemsPerPage"`
+}
+
+// LegacyCursor is the "pagination" object v1 clients read from cursor-paginated lists
+type LegacyCursor struct {
+	NextCursor   string `json:"nextCursor,omitempty"`
+	HasMore      bool   `json:"hasMore"`
+	ItemsPerPage int    `json:"itemsPerPage"`
+}
+
+// NewLegacyPage creates the v1 metadata of a page of a page-numbered list
+func NewLegacyPage(total, page, limit int) LegacyPage {
+	return LegacyPage{
+		TotalItems:   total,
+		CurrentPage:  page,
+		TotalPages:   TotalPages(total, limit),
+		ItemsPerPage: limit,
+	}
+}
+
+// Envelope wraps a page of a page-numbered list, for handlers adding fields of their own
+func Envelope(data interface{}, total, page, limit int) gin.H {
+	return gin.H{
+		"data":       data,
+		"meta":       NewMeta(total, page, limit),
+		"pagination": NewLegacyPage(total, page, limit),
+	}
+}
+
+// CursorEnvelope wraps a page of a cursor-paginated list, for handlers adding fields of
+// their own
+func CursorEnvelope(data interface{}, nextCursor string, limit int) gin.H {
+	return gin.H{
+		"data": data,
+		"meta": NewCursorMeta(nextCursor, limit),
+		"pagination": LegacyCursor{
+			NextCursor:   nextCursor,
+			HasMore:      nextCursor != "",
+			ItemsPerPage: limit,
+		},
+	}
+}
+
+// Send sends a page of a page-numbered list
+func Send(c *gin.Context, statusCode int, data interface{}, total, page, limit int) {
+	c.JSON(statusCode, Envelope(data, total, page, limit))
+}
+
+// SendCursor sends a page of a cursor-paginated list. An empty next cursor means there
+// are no more items.
+func SendCursor(c *gin.Context, statusCode int, data interface{}, nextCursor string, limit int) {
+	c.JSON(statusCode, CursorEnvelope(data, nextCursor, limit))
+}
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ParseCursor returns the cursor query parameter and whether cursor pagination was
+// requested. An empty cursor requests the first page.
+func ParseCursor(c *gin.Context) (string, bool) {
+	return c.GetQuery("cursor")
+}
+
+// EncodeCursor encodes a cursor value as an opaque token
+func EncodeCursor(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a token produced by EncodeCursor into value
+func DecodeCursor(token string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
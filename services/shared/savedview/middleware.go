@@ -0,0 +1,79 @@
+package savedview
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Resolver looks up the list views users saved
+type Resolver interface {
+	GetSavedView(ctx context.Context, userID, viewID int) (*View, error)
+}
+
+// Middleware creates middleware applying a saved view to a list endpoint. A request with
+// ?view=<id> gets the view's filters and sort order as query parameters, except those the
+// request sets itself, so the handler filters and sorts as if the client had sent them.
+// The view must belong to the user and to listType. Requests without the parameter are
+// not affected. Must run after the authentication middleware, optional or not, which sets
+// userID.
+func Middleware(resolver Resolver, listType string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Read from the URL rather than c.Query, which would cache the query before the
+		// view is applied
+		query := c.Request.URL.Query()
+		viewParam := query.Get("view")
+		if viewParam == "" {
+			c.Next()
+			return
+		}
+
+		viewID, err := strconv.Atoi(viewParam)
+		if err != nil || viewID < 1 {
+			apierror.Send(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid view ID")
+			c.Abort()
+			return
+		}
+
+		value, exists := c.Get("userID")
+		if !exists {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Sign in to use saved views")
+			c.Abort()
+			return
+		}
+		userID := value.(int)
+
+		view, err := resolver.GetSavedView(c.Request.Context(), userID, viewID)
+		if err != nil {
+			logger.Error("Failed to get saved view", zap.Error(err), zap.Int("user_id", userID), zap.Int("view_id", viewID))
+			apierror.Send(c, http.StatusBadGateway, apierror.CodeUpstreamFailed, "Failed to get saved view")
+			c.Abort()
+			return
+		}
+		if view == nil || view.ListType != listType {
+			apierror.Respond(c, ErrNotFound, "Saved view not found")
+			c.Abort()
+			return
+		}
+
+		for name, filter := range view.Filters {
+			if !query.Has(name) {
+				query.Set(name, filter)
+			}
+		}
+		if view.SortBy != nil && !query.Has("sort_by") {
+			query.Set("sort_by", *view.SortBy)
+		}
+		if view.SortDirection != nil && !query.Has("sort_direction") {
+			query.Set("sort_direction", *view.SortDirection)
+		}
+		c.Request.URL.RawQuery = query.Encode()
+
+		c.Next()
+	}
+}
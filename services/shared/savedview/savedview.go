@@ -0,0 +1,26 @@
+// Package savedview applies the list views users saved in the User Service to the list
+// endpoints of the other services. A view is applied with ?view=<id>.
+package savedview
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+)
+
+// CodeNotFound is the code of the error of views the user doesn't have for the list
+const CodeNotFound apierror.Code = "SAVED_VIEW_NOT_FOUND"
+
+// ErrNotFound is returned for views the user doesn't have for the list
+var ErrNotFound = apierror.New(http.StatusNotFound, CodeNotFound, "Saved view not found")
+
+// View is a named filter and sort combination a user saved for a list in the User
+// Service. Filters are the list's query parameters by name.
+type View struct {
+	ID            int               `json:"id"`
+	ListType      string            `json:"list_type"`
+	Name          string            `json:"name"`
+	Filters       map[string]string `json:"filters"`
+	SortBy        *string           `json:"sort_by,omitempty"`
+	SortDirection *string           `json:"sort_direction,omitempty"`
+}
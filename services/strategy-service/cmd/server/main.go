@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,20 +11,50 @@ import (
 	"syscall"
 	"time"
 
+	"services/shared/apierror"
+	"services/shared/auth"
+	"services/shared/database"
+	"services/shared/idempotency"
+	"services/shared/savedview"
+	"services/strategy-service/docs"
 	"services/strategy-service/internal/client"
 	"services/strategy-service/internal/config"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/handler"
+	"services/strategy-service/internal/lint"
 	"services/strategy-service/internal/middleware"
+	"services/strategy-service/internal/migrate"
+	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/repository"
 	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// @title Strategy Service API
+// @version 1.0
+// @description Strategies, indicators and the strategy marketplace.
+// @BasePath /
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>"
+//
+// @securityDefinitions.apikey ServiceKey
+// @in header
+// @name X-Service-Key
+// @description Key of a calling internal service
 func main() {
+	applyMigrations := flag.Bool("migrate", false, "Apply pending database migrations before starting")
+	baselineVersion := flag.Int64("migrate-baseline", 0, "Mark migrations up to this version as applied without running them (for databases created by the old init scripts)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadConfig("config/config.yaml")
 	if err != nil {
@@ -37,6 +68,9 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Map the untyped errors of the services to the codes of this service
+	apierror.SetMessageRules(errcode.MessageRules)
+
 	// Connect to database
 	db, err := connectToDB(cfg.Database)
 	if err != nil {
@@ -44,19 +78,83 @@ func main() {
 	}
 	defer db.Close()
 
+	// Bring the schema up to date if requested
+	migrationRunner := migrate.NewRunner(db.DB, logger)
+	if *baselineVersion > 0 {
+		if err := migrationRunner.Baseline(context.Background(), *baselineVersion); err != nil {
+			logger.Fatal("Failed to baseline database migrations", zap.Error(err))
+		}
+	}
+	if *applyMigrations {
+		if err := migrationRunner.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply database migrations", zap.Error(err))
+		}
+	}
+
+	// Heavy reads go to the read replica, if one is configured, while it's healthy
+	replicaDB, err := connectToReplica(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to configure read replica", zap.Error(err))
+	}
+	dbRouter := database.NewRouter(db, replicaDB, database.ReplicaOptions{
+		HealthCheckInterval: cfg.Database.Replica.HealthCheckInterval,
+		MaxLag:              cfg.Database.Replica.MaxLag,
+		RecoverAfter:        cfg.Database.Replica.RecoverAfter,
+	}, logger)
+	defer dbRouter.Close()
+
+	// Sample the connection pools for /metrics and, if enabled, size them to their waits
+	poolMonitor := database.NewPoolMonitor(database.PoolOptions{
+		SampleInterval:    cfg.Database.Pool.SampleInterval,
+		Adaptive:          cfg.Database.Pool.Adaptive,
+		MaxOpenConnsLimit: cfg.Database.Pool.MaxOpenConnsLimit,
+		TargetWait:        cfg.Database.Pool.TargetWait,
+	}, logger)
+	poolMonitor.Add("primary", db)
+	poolMonitor.Add("replica", replicaDB)
+
 	// Initialize repositories
 	strategyRepo := repository.NewStrategyRepository(db, logger)
 	versionRepo := repository.NewVersionRepository(db, logger)
 	tagRepo := repository.NewTagRepository(db, logger)
+	shareRepo := repository.NewShareRepository(db, logger)
 	indicatorRepo := repository.NewIndicatorRepository(db, logger)
-	marketplaceRepo := repository.NewMarketplaceRepository(db, logger)
+	presetRepo := repository.NewIndicatorPresetRepository(db, logger)
+	riskRepo := repository.NewRiskRepository(db, logger)
+	marketplaceRepo := repository.NewMarketplaceRepository(dbRouter, logger)
 	purchaseRepo := repository.NewPurchaseRepository(db, logger)
 	reviewRepo := repository.NewReviewRepository(db, logger)
+	couponRepo := repository.NewCouponRepository(db, logger)
+	refundRepo := repository.NewRefundRepository(db, logger)
+	reportRepo := repository.NewReportRepository(db, logger)
+	currencyRepo := repository.NewCurrencyRepository(db, logger)
+	earningsRepo := repository.NewEarningsRepository(db, logger)
+	templateRepo := repository.NewTemplateRepository(db, logger)
+	statsRepo := repository.NewStatsRepository(db, logger)
+	idempotencyRepo := idempotency.NewRepository(db, logger)
+	walletRepo := repository.NewWalletRepository(db, logger)
+	collaborationRepo := repository.NewCollaborationRepository(db, logger)
 
 	// Initialize clients
-	userClient := client.NewUserClient(cfg.UserService.URL, logger)
-	historicalClient := client.NewHistoricalClient(cfg.HistoricalService.URL, logger)
-	mediaClient := client.NewMediaClient(cfg.MediaService.URL, cfg.MediaService.ServiceKey, logger)
+	userClient := client.NewUserClient(cfg.UserService, logger)
+	historicalClient := client.NewHistoricalClient(cfg.HistoricalService, logger)
+	fxClient := client.NewFXClient(cfg.FX.URL, cfg.FX.Timeout, logger)
+	mediaClient := client.NewMediaClient(cfg.MediaService, logger)
+	// Keys issued to other services by the user service are accepted next to cfg.ServiceKey
+	var serviceKeyVerifier middleware.ServiceKeyVerifier
+	if cfg.ServiceAuth.VerifyIssuedKeys {
+		serviceKeyVerifier = client.NewServiceKeyVerifier(userClient, cfg.ServiceAuth.CacheTTL, logger)
+	}
+	// Approved refunds are paid back through the payment provider, if one is configured
+	var paymentProvider service.PaymentProvider
+	if cfg.Payments.URL != "" {
+		paymentProvider = client.NewPaymentClient(cfg.Payments.URL, cfg.Payments.APIKey, cfg.Payments.Timeout, logger)
+	}
+	notificationClient := client.NewNotificationClient(cfg.Kafka.Brokers, cfg.Kafka.Topics["notifications"], logger)
+	// Viper lowercases the topic keys
+	strategyEvents := client.NewEventClient(cfg.Kafka.Brokers, cfg.Kafka.Topics["strategyevents"], logger)
+	marketplaceEvents := client.NewEventClient(cfg.Kafka.Brokers, cfg.Kafka.Topics["marketplaceevents"], logger)
+	listingViews := client.NewEventClient(cfg.Kafka.Brokers, cfg.Kafka.Topics["listingviews"], logger)
 
 	// Initialize services
 	strategyService := service.NewStrategyService(
@@ -64,39 +162,157 @@ func main() {
 		strategyRepo,
 		versionRepo,
 		tagRepo,
+		shareRepo,
+		indicatorRepo,
+		presetRepo,
 		userClient,
 		historicalClient,
+		strategyEvents,
+		lint.NewLinter(lint.DefaultRules()...),
 		logger,
 	)
+	templateService := service.NewTemplateService(templateRepo, strategyService, logger)
+
+	// Redis receives token revocations broadcast by the user service and caches popular tags
+	var redisClient *redis.Client
+	if cfg.Redis.Enabled {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.URL,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer redisClient.Close()
+	}
 
-	tagService := service.NewTagService(tagRepo, logger)
+	tagService := service.NewTagService(tagRepo, redisClient, cfg.Tags.PopularCacheTTL, logger)
 	indicatorService := service.NewIndicatorService(db, indicatorRepo, logger)
+	presetService := service.NewIndicatorPresetService(presetRepo, indicatorService, logger)
+	riskService := service.NewRiskService(riskRepo, strategyRepo, logger)
 	marketplaceService := service.NewMarketplaceService(
 		db,
 		marketplaceRepo,
 		strategyRepo,
 		purchaseRepo,
 		reviewRepo,
+		couponRepo,
 		userClient,
+		historicalClient,
+		marketplaceEvents,
+		notificationClient,
+		riskService,
+		logger,
+	)
+	recommendationService := service.NewRecommendationService(
+		marketplaceRepo,
+		marketplaceService,
+		listingViews,
+		cfg.Marketplace.Trending,
+		logger,
+	)
+	couponService := service.NewCouponService(couponRepo, marketplaceRepo, logger)
+	refundService := service.NewRefundService(refundRepo, purchaseRepo, walletRepo, paymentProvider, userClient, notificationClient, logger)
+	reportService := service.NewReportService(reportRepo, notificationClient, logger)
+	currencyService := service.NewCurrencyService(currencyRepo, fxClient, cfg.FX.CacheTTL, logger)
+	earningsService := service.NewEarningsService(earningsRepo, cfg.Marketplace.PlatformFeePercent, logger)
+	statsService := service.NewStatsService(statsRepo, cfg.Marketplace.PlatformFeePercent, cfg.Stats.CacheTTL, logger)
+	walletService := service.NewWalletService(walletRepo, currencyService, notificationClient, logger)
+	collaborationService := service.NewCollaborationService(strategyRepo, shareRepo, collaborationRepo, userClient, notificationClient, logger)
+
+	// Start the subscription worker to expire lapsed subscriptions and send renewal reminders
+	subscriptionWorker := service.NewSubscriptionWorker(
+		purchaseRepo,
+		notificationClient,
+		cfg.Marketplace.SubscriptionCheckInterval,
+		cfg.Marketplace.RenewalReminderDays,
 		logger,
 	)
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	go subscriptionWorker.Run(workerCtx)
+
+	// Check the read replica's health so reads fail over to the primary and back
+	go dbRouter.Run(workerCtx)
+
+	// Sample and tune the database connection pools
+	go poolMonitor.Run(workerCtx)
+
+	// Start the tag popularity worker to keep popular tags current
+	tagPopularityWorker := service.NewTagPopularityWorker(tagService, cfg.Tags.PopularityRefreshInterval, logger)
+	go tagPopularityWorker.Run(workerCtx)
+
+	// Start the listing event consumer (if Kafka is enabled) to track views and purchases
+	// for trending scores and recommendations
+	var listingEventConsumer *service.ListingEventConsumer
+	if brokers := client.SplitBrokers(cfg.Kafka.Brokers); len(brokers) > 0 {
+		listingEventConsumer = service.NewListingEventConsumer(
+			brokers,
+			cfg.Kafka.TrendingGroupID,
+			[]string{cfg.Kafka.Topics["marketplaceevents"], cfg.Kafka.Topics["listingviews"]},
+			recommendationService,
+			logger,
+		)
+		go listingEventConsumer.Run(workerCtx)
+	}
 
 	// Initialize handlers
 	strategyHandler := handler.NewStrategyHandler(strategyService, userClient, logger)
 	tagHandler := handler.NewTagHandler(tagService, logger)
+	templateHandler := handler.NewTemplateHandler(templateService, logger)
 	// Updated to pass userClient to IndicatorHandler for role checking
 	indicatorHandler := handler.NewIndicatorHandler(indicatorService, userClient, logger)
-	marketplaceHandler := handler.NewMarketplaceHandler(marketplaceService, logger)
+	presetHandler := handler.NewIndicatorPresetHandler(presetService, logger)
+	riskHandler := handler.NewRiskHandler(riskService, logger)
+	marketplaceHandler := handler.NewMarketplaceHandler(marketplaceService, recommendationService, currencyService, logger)
+	couponHandler := handler.NewCouponHandler(couponService, logger)
+	refundHandler := handler.NewRefundHandler(refundService, logger)
+	reportHandler := handler.NewReportHandler(reportService, logger)
+	currencyHandler := handler.NewCurrencyHandler(currencyService, logger)
+	earningsHandler := handler.NewEarningsHandler(earningsService, logger)
 	thumbnailHandler := handler.NewThumbnailHandler(strategyService, mediaClient, logger)
+	migrationHandler := handler.NewMigrationHandler(migrationRunner, logger)
+	debugHandler := handler.NewDebugHandler(poolMonitor, logger)
+	statsHandler := handler.NewStatsHandler(statsService, logger)
+	walletHandler := handler.NewWalletHandler(walletService, logger)
+	collaborationHandler := handler.NewCollaborationHandler(collaborationService, logger)
+
+	// Idempotency-Key handling for purchases and backtests
+	idempotent := idempotency.Middleware(idempotencyRepo, cfg.Idempotency.LockTimeout, cfg.Idempotency.TTL, logger)
+
+	// Tokens of users who logged out everywhere or were deactivated are rejected once
+	// the user service broadcasts their revocation
+	var revocations *auth.RevocationList
+	if redisClient != nil {
+		revocations = auth.NewRevocationList(redisClient, logger)
+		go revocations.Run(workerCtx)
+	}
+
+	// Access tokens are verified locally against the user service's public keys
+	tokenVerifier := auth.NewTokenVerifier(cfg.Auth.JWKSURL, cfg.Auth.JWTSecret, cfg.Auth.KeysMaxAge, revocations, logger)
 
 	// Set up HTTP server with Gin
 	router := setupRouter(
 		strategyHandler,
 		tagHandler,
+		templateHandler,
 		indicatorHandler,
+		presetHandler,
+		riskHandler,
 		marketplaceHandler,
+		couponHandler,
+		refundHandler,
+		reportHandler,
+		currencyHandler,
+		earningsHandler,
 		thumbnailHandler,
+		migrationHandler,
+		debugHandler,
+		statsHandler,
+		walletHandler,
+		collaborationHandler,
 		userClient,
+		tokenVerifier,
+		idempotent,
+		cfg.ServiceKey,
+		serviceKeyVerifier,
 		logger,
 	)
 
@@ -123,6 +339,26 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Stop background workers and flush pending notifications and events
+	stopWorker()
+	if listingEventConsumer != nil {
+		if err := listingEventConsumer.Close(); err != nil {
+			logger.Error("Failed to close listing event consumer", zap.Error(err))
+		}
+	}
+	if err := notificationClient.Close(); err != nil {
+		logger.Error("Failed to close notification client", zap.Error(err))
+	}
+	if err := strategyEvents.Close(); err != nil {
+		logger.Error("Failed to close strategy event client", zap.Error(err))
+	}
+	if err := marketplaceEvents.Close(); err != nil {
+		logger.Error("Failed to close marketplace event client", zap.Error(err))
+	}
+	if err := listingViews.Close(); err != nil {
+		logger.Error("Failed to close listing view event client", zap.Error(err))
+	}
+
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -186,26 +422,72 @@ func connectToDB(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// connectToReplica opens the read replica's connection pool, if a replica is configured.
+// The connection isn't verified here; the router's health checks decide when it's used.
+func connectToReplica(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
+	if dbConfig.Replica.DSN == "" {
+		return nil, nil
+	}
+
+	db, err := sqlx.Open("pgx", dbConfig.Replica.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+
+	return db, nil
+}
+
 func setupRouter(
 	strategyHandler *handler.StrategyHandler,
 	tagHandler *handler.TagHandler,
+	templateHandler *handler.TemplateHandler,
 	indicatorHandler *handler.IndicatorHandler,
+	presetHandler *handler.IndicatorPresetHandler,
+	riskHandler *handler.RiskHandler,
 	marketplaceHandler *handler.MarketplaceHandler,
+	couponHandler *handler.CouponHandler,
+	refundHandler *handler.RefundHandler,
+	reportHandler *handler.ReportHandler,
+	currencyHandler *handler.CurrencyHandler,
+	earningsHandler *handler.EarningsHandler,
 	thumbnailHandler *handler.ThumbnailHandler,
+	migrationHandler *handler.MigrationHandler,
+	debugHandler *handler.DebugHandler,
+	statsHandler *handler.StatsHandler,
+	walletHandler *handler.WalletHandler,
+	collaborationHandler *handler.CollaborationHandler,
 	userClient *client.UserClient,
+	tokenVerifier *auth.TokenVerifier,
+	idempotency gin.HandlerFunc,
+	serviceKey string,
+	serviceKeyVerifier middleware.ServiceKeyVerifier,
 	logger *zap.Logger,
 ) *gin.Engine {
 	router := gin.New()
 
 	// Use middlewares
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
+	router.Use(validation.Locale())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// Metrics in the Prometheus text format, for scrapers on the internal network
+	router.GET("/metrics", debugHandler.GetMetrics)
+
+	// OpenAPI spec, also merged into the gateway's /api/docs
+	router.GET("/swagger.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", docs.SwaggerJSON)
+	})
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
@@ -213,21 +495,43 @@ func setupRouter(
 		// IMPORTANT: Order matters - specific routes must come before parameter routes
 		indicators := v1.Group("/indicators")
 		{
-			// 1. Base endpoint - public routes
-			indicators.GET("", indicatorHandler.GetAllIndicators)                  // GET /api/v1/indicators
-			indicators.GET("/categories", indicatorHandler.GetIndicatorCategories) // GET /api/v1/indicators/categories
-			indicators.GET("/:id", indicatorHandler.GetIndicator)                  // GET /api/v1/indicators/{id}
+			// 1. Base endpoint - public routes; signed-in users also see their own custom indicators
+			publicIndicators := indicators.Group("")
+			publicIndicators.Use(middleware.OptionalAuthMiddleware(tokenVerifier, logger))
+
+			publicIndicators.GET("", indicatorHandler.GetAllIndicators)                  // GET /api/v1/indicators
+			publicIndicators.GET("/categories", indicatorHandler.GetIndicatorCategories) // GET /api/v1/indicators/categories
+			publicIndicators.GET("/:id", indicatorHandler.GetIndicator)                  // GET /api/v1/indicators/{id}
+			publicIndicators.GET("/:id/presets", presetHandler.GetPresets)               // GET /api/v1/indicators/{id}/presets
+
+			// 2. User-defined indicators - any signed-in user
+			customIndicators := indicators.Group("/custom")
+			customIndicators.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
-			// 2. Admin-only routes for managing indicators
+			customIndicators.POST("", indicatorHandler.CreateCustomIndicator) // POST /api/v1/indicators/custom
+
+			// Parameter presets - any signed-in user for their own; global presets need indicators:write
+			presets := indicators.Group("/:id/presets")
+			presets.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+
+			presets.POST("", presetHandler.CreatePreset)             // POST /api/v1/indicators/{id}/presets
+			presets.PUT("/:presetId", presetHandler.UpdatePreset)    // PUT /api/v1/indicators/{id}/presets/{presetId}
+			presets.DELETE("/:presetId", presetHandler.DeletePreset) // DELETE /api/v1/indicators/{id}/presets/{presetId}
+
+			// 3. Admin-only routes for managing indicators
 			adminIndicators := indicators.Group("")
-			adminIndicators.Use(middleware.AuthMiddleware(userClient, logger))
-			adminIndicators.Use(middleware.RequireRole("admin")) // No longer passing userClient
+			adminIndicators.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			adminIndicators.Use(middleware.RequirePermission("indicators:write"))
 
 			adminIndicators.POST("", indicatorHandler.CreateIndicator)                      // POST /api/v1/indicators
 			adminIndicators.PUT("/:id", indicatorHandler.UpdateIndicator)                   // PUT /api/v1/indicators/{id}
 			adminIndicators.DELETE("/:id", indicatorHandler.DeleteIndicator)                // DELETE /api/v1/indicators/{id}
 			adminIndicators.POST("/sync", indicatorHandler.SyncIndicators)                  // POST /api/v1/indicators/sync
+			adminIndicators.GET("/export", indicatorHandler.ExportIndicators)               // GET /api/v1/indicators/export
+			adminIndicators.POST("/import", indicatorHandler.ImportIndicators)              // POST /api/v1/indicators/import
 			adminIndicators.POST("/:id/parameters", indicatorHandler.AddIndicatorParameter) // POST /api/v1/indicators/{id}/parameters
+			adminIndicators.POST("/:id/deprecate", indicatorHandler.DeprecateIndicator)     // POST /api/v1/indicators/{id}/deprecate
+			adminIndicators.DELETE("/:id/deprecate", indicatorHandler.UndeprecateIndicator) // DELETE /api/v1/indicators/{id}/deprecate
 		}
 
 		// ==================== PARAMETER ROUTES ====================
@@ -235,8 +539,8 @@ func setupRouter(
 		{
 			// Admin-only routes for managing parameters
 			adminParameters := parameters.Group("")
-			adminParameters.Use(middleware.AuthMiddleware(userClient, logger))
-			adminParameters.Use(middleware.RequireRole("admin"))
+			adminParameters.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			adminParameters.Use(middleware.RequirePermission("indicators:write"))
 
 			adminParameters.PUT("/:id", indicatorHandler.UpdateIndicatorParameter)           // PUT /api/v1/parameters/{id}
 			adminParameters.DELETE("/:id", indicatorHandler.DeleteIndicatorParameter)        // DELETE /api/v1/parameters/{id}
@@ -248,8 +552,8 @@ func setupRouter(
 		{
 			// Admin-only routes for managing enum values
 			adminEnumValues := enumValues.Group("")
-			adminEnumValues.Use(middleware.AuthMiddleware(userClient, logger))
-			adminEnumValues.Use(middleware.RequireRole("admin"))
+			adminEnumValues.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			adminEnumValues.Use(middleware.RequirePermission("indicators:write"))
 
 			adminEnumValues.PUT("/:id", indicatorHandler.UpdateIndicatorParameterEnumValue)    // PUT /api/v1/enum-values/{id}
 			adminEnumValues.DELETE("/:id", indicatorHandler.DeleteIndicatorParameterEnumValue) // DELETE /api/v1/enum-values/{id}
@@ -258,66 +562,200 @@ func setupRouter(
 		// ==================== STRATEGY ROUTES ====================
 		strategies := v1.Group("/strategies")
 		{
-			strategies.Use(middleware.AuthMiddleware(userClient, logger))
+			strategies.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
-			// Base routes with standardized naming to match indicator routes
-			strategies.GET("", strategyHandler.GetAllStrategies) // GET /api/v1/strategies
-			strategies.POST("", strategyHandler.CreateStrategy)  // POST /api/v1/strategies
+			// Base routes with standardized naming to match indicator routes. The list applies
+			// the user's saved views.
+			strategiesView := savedview.Middleware(userClient, model.ViewListStrategies, logger)
+			strategies.GET("", strategiesView, strategyHandler.GetAllStrategies) // GET /api/v1/strategies
+			strategies.POST("", strategyHandler.CreateStrategy)                  // POST /api/v1/strategies
+
+			// Bulk operations
+			strategies.POST("/bulk/tags", strategyHandler.BulkUpdateTags) // POST /api/v1/strategies/bulk/tags
 
 			// Parameter routes
-			strategies.GET("/:id", strategyHandler.GetStrategyByID)                  // GET /api/v1/strategies/{id}
-			strategies.PUT("/:id", strategyHandler.UpdateStrategy)                   // PUT /api/v1/strategies/{id}
-			strategies.DELETE("/:id", strategyHandler.DeleteStrategy)                // DELETE /api/v1/strategies/{id}
-			strategies.GET("/:id/versions", strategyHandler.GetVersions)             // GET /api/v1/strategies/{id}/versions
-			strategies.GET("/:id/versions/:version", strategyHandler.GetVersionByID) // GET /api/v1/strategies/{id}/versions/{version}
-			strategies.POST("/:id/thumbnail", thumbnailHandler.UploadThumbnail)      // POST /api/v1/strategies/{id}/thumbnail
+			strategies.GET("/:id", strategyHandler.GetStrategyByID)                         // GET /api/v1/strategies/{id}
+			strategies.PUT("/:id", strategyHandler.UpdateStrategy)                          // PUT /api/v1/strategies/{id}
+			strategies.DELETE("/:id", strategyHandler.DeleteStrategy)                       // DELETE /api/v1/strategies/{id}
+			strategies.GET("/:id/versions", strategyHandler.GetVersions)                    // GET /api/v1/strategies/{id}/versions
+			strategies.GET("/:id/versions/:version", strategyHandler.GetVersionByID)        // GET /api/v1/strategies/{id}/versions/{version}
+			strategies.POST("/:id/thumbnail", thumbnailHandler.UploadThumbnail)             // POST /api/v1/strategies/{id}/thumbnail
+			strategies.POST("/:id/backtest", idempotency, strategyHandler.BacktestStrategy) // POST /api/v1/strategies/{id}/backtest
+			strategies.GET("/:id/backtests", strategyHandler.GetBacktestHistory)            // GET /api/v1/strategies/{id}/backtests
+			strategies.POST("/:id/lint", strategyHandler.LintStrategy)                      // POST /api/v1/strategies/{id}/lint
+			strategies.POST("/:id/signals/preview", strategyHandler.PreviewSignals)         // POST /api/v1/strategies/{id}/signals/preview
+			strategies.GET("/:id/risk-score", riskHandler.GetRiskScore)                     // GET /api/v1/strategies/{id}/risk-score
+
+			// Sharing with specific users (owner only)
+			strategies.GET("/:id/shares", strategyHandler.GetShares)              // GET /api/v1/strategies/{id}/shares
+			strategies.POST("/:id/share", strategyHandler.ShareStrategy)          // POST /api/v1/strategies/{id}/share
+			strategies.DELETE("/:id/shares/:userId", strategyHandler.RevokeShare) // DELETE /api/v1/strategies/{id}/shares/{userId}
+
+			// Collaborators editing with the owner, and their comments on versions
+			strategies.GET("/collaborations", collaborationHandler.GetMyCollaborations)              // GET /api/v1/strategies/collaborations
+			strategies.GET("/:id/collaborators", collaborationHandler.GetCollaborators)              // GET /api/v1/strategies/{id}/collaborators
+			strategies.POST("/:id/collaborators", collaborationHandler.InviteCollaborator)           // POST /api/v1/strategies/{id}/collaborators
+			strategies.POST("/:id/collaborators/accept", collaborationHandler.AcceptInvitation)      // POST /api/v1/strategies/{id}/collaborators/accept
+			strategies.DELETE("/:id/collaborators/:userId", collaborationHandler.RemoveCollaborator) // DELETE /api/v1/strategies/{id}/collaborators/{userId}
+			strategies.GET("/:id/versions/:version/comments", collaborationHandler.GetComments)      // GET /api/v1/strategies/{id}/versions/{version}/comments
+			strategies.POST("/:id/versions/:version/comments", collaborationHandler.AddComment)      // POST /api/v1/strategies/{id}/versions/{version}/comments
+			strategies.DELETE("/:id/comments/:commentId", collaborationHandler.DeleteComment)        // DELETE /api/v1/strategies/{id}/comments/{commentId}
 		}
 
 		// ==================== TAG ROUTES ====================
 		tags := v1.Group("/strategy-tags")
 		{
 			// Public routes - anyone can get tags
-			tags.GET("", tagHandler.GetAllTags)             // GET /api/v1/strategy-tags
-			tags.GET("/:id", tagHandler.GetTagByID)         // GET /api/v1/strategy-tags/{id}
-			tags.GET("/popular", tagHandler.GetPopularTags) // GET /api/v1/strategy-tags/popular
+			tags.GET("", tagHandler.GetAllTags)                  // GET /api/v1/strategy-tags
+			tags.GET("/:id", tagHandler.GetTagByID)              // GET /api/v1/strategy-tags/{id}
+			tags.GET("/popular", tagHandler.GetPopularTags)      // GET /api/v1/strategy-tags/popular
+			tags.GET("/:id/children", tagHandler.GetTagChildren) // GET /api/v1/strategy-tags/{id}/children
+			tags.GET("/:id/aliases", tagHandler.GetTagAliases)   // GET /api/v1/strategy-tags/{id}/aliases
 
 			// Admin-only routes - only admins can modify tags
 			adminTags := tags.Group("")
-			adminTags.Use(middleware.AuthMiddleware(userClient, logger))
-			adminTags.Use(middleware.RequireRole("admin"))
+			adminTags.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			adminTags.Use(middleware.RequirePermission("tags:write"))
+
+			adminTags.POST("", tagHandler.CreateTag)                           // POST /api/v1/strategy-tags
+			adminTags.PUT("/:id", tagHandler.UpdateTag)                        // PUT /api/v1/strategy-tags/{id}
+			adminTags.DELETE("/:id", tagHandler.DeleteTag)                     // DELETE /api/v1/strategy-tags/{id}
+			adminTags.PUT("/:id/parent", tagHandler.SetTagParent)              // PUT /api/v1/strategy-tags/{id}/parent
+			adminTags.POST("/:id/aliases", tagHandler.AddTagAlias)             // POST /api/v1/strategy-tags/{id}/aliases
+			adminTags.DELETE("/:id/aliases/:alias", tagHandler.RemoveTagAlias) // DELETE /api/v1/strategy-tags/{id}/aliases/{alias}
+			adminTags.POST("/:id/merge-into/:targetId", tagHandler.MergeTag)   // POST /api/v1/strategy-tags/{id}/merge-into/{targetId}
+		}
+
+		// ==================== TEMPLATE ROUTES ====================
+		templates := v1.Group("/strategy-templates")
+		{
+			// Public routes - template admins also see inactive templates
+			publicTemplates := templates.Group("")
+			publicTemplates.Use(middleware.OptionalAuthMiddleware(tokenVerifier, logger))
+
+			publicTemplates.GET("", templateHandler.GetTemplates)    // GET /api/v1/strategy-templates
+			publicTemplates.GET("/:id", templateHandler.GetTemplate) // GET /api/v1/strategy-templates/{id}
+
+			// Authenticated routes - any user can create a strategy from a template
+			userTemplates := templates.Group("")
+			userTemplates.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 
-			adminTags.POST("", tagHandler.CreateTag)       // POST /api/v1/strategy-tags
-			adminTags.PUT("/:id", tagHandler.UpdateTag)    // PUT /api/v1/strategy-tags/{id}
-			adminTags.DELETE("/:id", tagHandler.DeleteTag) // DELETE /api/v1/strategy-tags/{id}
+			userTemplates.POST("/:id/instantiate", templateHandler.InstantiateTemplate) // POST /api/v1/strategy-templates/{id}/instantiate
+
+			// Admin-only routes - only template admins can curate templates
+			adminTemplates := templates.Group("")
+			adminTemplates.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			adminTemplates.Use(middleware.RequirePermission("templates:write"))
+
+			adminTemplates.POST("", templateHandler.CreateTemplate)       // POST /api/v1/strategy-templates
+			adminTemplates.PUT("/:id", templateHandler.UpdateTemplate)    // PUT /api/v1/strategy-templates/{id}
+			adminTemplates.DELETE("/:id", templateHandler.DeleteTemplate) // DELETE /api/v1/strategy-templates/{id}
 		}
 
 		// ==================== MARKETPLACE ROUTES ====================
 		marketplace := v1.Group("/marketplace")
 		{
+			// Public listings; signed-in users can apply their saved views
+			marketplaceView := savedview.Middleware(userClient, model.ViewListMarketplace, logger)
+			marketplace.GET("", middleware.OptionalAuthMiddleware(tokenVerifier, logger), marketplaceView, marketplaceHandler.GetAllListings) // GET /api/v1/marketplace
+
 			// Public routes
-			marketplace.GET("", marketplaceHandler.GetAllListings)         // GET /api/v1/marketplace
-			marketplace.GET("/:id", marketplaceHandler.GetListingByID)     // GET /api/v1/marketplace/{id}
-			marketplace.GET("/:id/reviews", marketplaceHandler.GetReviews) // GET /api/v1/marketplace/{id}/reviews
+			marketplace.GET("/facets", marketplaceHandler.GetFacets)                  // GET /api/v1/marketplace/facets
+			marketplace.GET("/trending", marketplaceHandler.GetTrendingListings)      // GET /api/v1/marketplace/trending
+			marketplace.GET("/currencies", currencyHandler.GetCurrencies)             // GET /api/v1/marketplace/currencies
+			marketplace.GET("/sellers/:id", marketplaceHandler.GetSellerStats)        // GET /api/v1/marketplace/sellers/{id}
+			marketplace.GET("/:id/reviews", marketplaceHandler.GetReviews)            // GET /api/v1/marketplace/{id}/reviews
+			marketplace.GET("/:id/changelog", marketplaceHandler.GetChangelog)        // GET /api/v1/marketplace/{id}/changelog
+			marketplace.GET("/:id/price-history", marketplaceHandler.GetPriceHistory) // GET /api/v1/marketplace/{id}/price-history
+
+			// Signed-in viewers count towards their recommendations
+			marketplace.GET("/:id", middleware.OptionalAuthMiddleware(tokenVerifier, logger), marketplaceHandler.GetListingByID) // GET /api/v1/marketplace/{id}
 
 			// Protected marketplace endpoints
 			marketplaceAuth := marketplace.Group("")
-			marketplaceAuth.Use(middleware.AuthMiddleware(userClient, logger))
+			marketplaceAuth.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+
+			marketplaceAuth.POST("", marketplaceHandler.CreateListing)                              // POST /api/v1/marketplace
+			marketplaceAuth.DELETE("/:id", marketplaceHandler.DeleteListing)                        // DELETE /api/v1/marketplace/{id}
+			marketplaceAuth.POST("/:id/purchase", idempotency, marketplaceHandler.PurchaseStrategy) // POST /api/v1/marketplace/{id}/purchase
+			marketplaceAuth.POST("/:id/reviews", marketplaceHandler.CreateReview)                   // POST /api/v1/marketplace/{id}/reviews
+			marketplaceAuth.POST("/:id/attach-backtest", marketplaceHandler.AttachBacktest)         // POST /api/v1/marketplace/{id}/attach-backtest
+			marketplaceAuth.POST("/:id/versions", marketplaceHandler.PublishVersion)                // POST /api/v1/marketplace/{id}/versions
+			marketplaceAuth.PUT("/:id/update-policy", marketplaceHandler.SetUpdatePolicy)           // PUT /api/v1/marketplace/{id}/update-policy
+			marketplaceAuth.POST("/:id/report", reportHandler.ReportListing)                        // POST /api/v1/marketplace/{id}/report
 
-			marketplaceAuth.POST("", marketplaceHandler.CreateListing)                 // POST /api/v1/marketplace
-			marketplaceAuth.DELETE("/:id", marketplaceHandler.DeleteListing)           // DELETE /api/v1/marketplace/{id}
-			marketplaceAuth.POST("/:id/purchase", marketplaceHandler.PurchaseStrategy) // POST /api/v1/marketplace/{id}/purchase
-			marketplaceAuth.POST("/:id/reviews", marketplaceHandler.CreateReview)      // POST /api/v1/marketplace/{id}/reviews
+			marketplaceAuth.GET("/recommended", marketplaceHandler.GetRecommendedListings) // GET /api/v1/marketplace/recommended
+
+			// Seller coupons
+			marketplaceAuth.GET("/:id/coupons", couponHandler.GetCoupons)                // GET /api/v1/marketplace/{id}/coupons
+			marketplaceAuth.POST("/:id/coupons", couponHandler.CreateCoupon)             // POST /api/v1/marketplace/{id}/coupons
+			marketplaceAuth.PUT("/:id/coupons/:couponId", couponHandler.UpdateCoupon)    // PUT /api/v1/marketplace/{id}/coupons/{couponId}
+			marketplaceAuth.DELETE("/:id/coupons/:couponId", couponHandler.DeleteCoupon) // DELETE /api/v1/marketplace/{id}/coupons/{couponId}
+
+			// Seller analytics
+			marketplaceAuth.GET("/my-sales", earningsHandler.GetMySales)     // GET /api/v1/marketplace/my-sales
+			marketplaceAuth.GET("/my-payouts", earningsHandler.GetMyPayouts) // GET /api/v1/marketplace/my-payouts
 
 			// Purchases management
+			marketplaceAuth.GET("/purchases", marketplaceHandler.GetPurchaseHistory)            // GET /api/v1/marketplace/purchases
+			marketplaceAuth.GET("/purchases/:id", marketplaceHandler.GetPurchase)               // GET /api/v1/marketplace/purchases/{id}
 			marketplaceAuth.PUT("/purchases/:id/cancel", marketplaceHandler.CancelSubscription) // PUT /api/v1/marketplace/purchases/{id}/cancel
+			marketplaceAuth.POST("/purchases/:id/upgrade", marketplaceHandler.UpgradePurchase)  // POST /api/v1/marketplace/purchases/{id}/upgrade
+			marketplaceAuth.POST("/purchases/:id/refund-request", refundHandler.RequestRefund)  // POST /api/v1/marketplace/purchases/{id}/refund-request
+
+			// Refund review queue of the seller's sales; moderators can review any refund
+			marketplaceAuth.GET("/refunds", refundHandler.GetRefundRequests)              // GET /api/v1/marketplace/refunds
+			marketplaceAuth.PUT("/refunds/:id/review", refundHandler.ReviewRefundRequest) // PUT /api/v1/marketplace/refunds/{id}/review
+
+			// Platform credit of the user, spent on purchases
+			marketplaceAuth.GET("/wallet", walletHandler.GetMyWallet)        // GET /api/v1/marketplace/wallet
+			marketplaceAuth.GET("/wallet/ledger", walletHandler.GetMyLedger) // GET /api/v1/marketplace/wallet/ledger
 		}
 
 		// ==================== REVIEWS ROUTES ====================
 		reviews := v1.Group("/reviews")
 		{
-			reviews.Use(middleware.AuthMiddleware(userClient, logger))
+			reviews.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
 			reviews.PUT("/:id", marketplaceHandler.UpdateReview)    // PUT /api/v1/reviews/{id}
 			reviews.DELETE("/:id", marketplaceHandler.DeleteReview) // DELETE /api/v1/reviews/{id}
+			reviews.POST("/:id/report", reportHandler.ReportReview) // POST /api/v1/reviews/{id}/report
+		}
+
+		// ==================== ADMIN ROUTES ====================
+		admin := v1.Group("/admin")
+		{
+			admin.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			admin.Use(middleware.RequireRole("admin"))
+			admin.GET("/migrations", migrationHandler.GetStatus)                                    // GET /api/v1/admin/migrations
+			admin.GET("/debug/db", debugHandler.GetDBPools)                                         // GET /api/v1/admin/debug/db
+			admin.GET("/stats/strategies", statsHandler.GetStrategyStats)                           // GET /api/v1/admin/stats/strategies
+			admin.GET("/refunds", refundHandler.GetAllRefundRequests)                               // GET /api/v1/admin/refunds
+			admin.GET("/indicators/deprecated-usage", indicatorHandler.GetDeprecatedIndicatorUsage) // GET /api/v1/admin/indicators/deprecated-usage
+			admin.GET("/wallets/:userId", walletHandler.GetUserWallet)                              // GET /api/v1/admin/wallets/{userId}
+			admin.GET("/wallets/:userId/ledger", walletHandler.GetUserLedger)                       // GET /api/v1/admin/wallets/{userId}/ledger
+			admin.POST("/wallets/:userId/grant", walletHandler.GrantCredit)                         // POST /api/v1/admin/wallets/{userId}/grant
+			admin.POST("/wallets/:userId/revoke", walletHandler.RevokeCredit)                       // POST /api/v1/admin/wallets/{userId}/revoke
+		}
+
+		// ==================== MODERATION ROUTES ====================
+		// Reports of listings and reviews, open to moderators as well as admins
+		moderation := v1.Group("/admin/reports")
+		{
+			moderation.Use(middleware.AuthMiddleware(tokenVerifier, userClient, logger))
+			moderation.Use(middleware.RequirePermission("marketplace:moderate"))
+			moderation.GET("", reportHandler.GetReports)                // GET /api/v1/admin/reports
+			moderation.GET("/:id", reportHandler.GetReport)             // GET /api/v1/admin/reports/{id}
+			moderation.PUT("/:id/resolve", reportHandler.ResolveReport) // PUT /api/v1/admin/reports/{id}/resolve
+		}
+
+		// ==================== SERVICE ROUTES ====================
+		// Internal routes for other services
+		service := v1.Group("/service")
+		{
+			service.Use(middleware.ServiceAuthMiddleware(serviceKey, serviceKeyVerifier, logger))
+			service.GET("/marketplace/:id/purchasers", marketplaceHandler.GetListingPurchasers) // GET /api/v1/service/marketplace/{id}/purchasers
+			service.GET("/strategies/:id/users", strategyHandler.GetStrategyUsers)              // GET /api/v1/service/strategies/{id}/users
+			service.GET("/debug/db", debugHandler.GetDBPools)                                   // GET /api/v1/service/debug/db
 		}
 	}
 
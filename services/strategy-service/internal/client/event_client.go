@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Event types published to the strategy and marketplace event topics
+const (
+	EventStrategyCreated   = "strategy_created"
+	EventStrategyUpdated   = "strategy_updated"
+	EventStrategyDeleted   = "strategy_deleted"
+	EventListingCreated    = "listing_created"
+	EventStrategyPurchased = "strategy_purchased"
+	EventListingViewed     = "listing_viewed"
+	EventReviewCreated     = "review_created"
+	// EventVersionPublished is a new version of a strategy that buyers have access to
+	EventVersionPublished = "strategy_version_published"
+)
+
+// Event is the payload published to the strategy and marketplace event topics.
+// The User Service builds activity feeds and follower notifications from it.
+type Event struct {
+	EventType  string `json:"event_type"`
+	UserID     int    `json:"user_id"`            // User who performed the action
+	OwnerID    int    `json:"owner_id,omitempty"` // Owner of the strategy, if someone else
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   int    `json:"entity_id,omitempty"`
+	EntityName string `json:"entity_name,omitempty"`
+	BuyerIDs   []int  `json:"buyer_ids,omitempty"` // Buyers of the strategy, for new versions
+	Timestamp  string `json:"timestamp"`
+}
+
+// EventClient publishes domain events to a Kafka topic without blocking the caller
+type EventClient struct {
+	writer *kafka.Writer
+	logger *zap.Logger
+}
+
+// NewEventClient creates a new event client.
+// brokers is a comma-separated list; an empty list disables publishing.
+func NewEventClient(brokers string, topic string, logger *zap.Logger) *EventClient {
+	addrs := SplitBrokers(brokers)
+
+	c := &EventClient{logger: logger}
+	if len(addrs) == 0 || topic == "" {
+		logger.Warn("Kafka not configured, events will not be published", zap.String("topic", topic))
+		return c
+	}
+
+	c.writer = &kafka.Writer{
+		Addr:         kafka.TCP(addrs...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Error("Failed to publish events",
+					zap.Error(err),
+					zap.String("topic", topic),
+					zap.Int("count", len(messages)))
+			}
+		},
+	}
+
+	return c
+}
+
+// Publish queues an event, keyed by the acting user so each user's events stay ordered.
+// Events are best effort: failures are logged, never returned to the caller.
+func (c *EventClient) Publish(ctx context.Context, event Event) {
+	if event.Timestamp == "" {
+		event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if c.writer == nil {
+		c.logger.Debug("Event (not published)",
+			zap.String("eventType", event.EventType),
+			zap.Int("userID", event.UserID))
+		return
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		c.logger.Error("Failed to encode event", zap.Error(err), zap.String("eventType", event.EventType))
+		return
+	}
+
+	err = c.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.Itoa(event.UserID)),
+		Value: value,
+	})
+	if err != nil {
+		c.logger.Error("Failed to queue event", zap.Error(err), zap.String("eventType", event.EventType))
+	}
+}
+
+// Close flushes pending events and closes the Kafka writer
+func (c *EventClient) Close() error {
+	if c.writer == nil {
+		return nil
+	}
+	return c.writer.Close()
+}
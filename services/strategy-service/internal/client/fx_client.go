@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ecbEnvelope matches the daily reference rates published by the European Central Bank.
+// Rates are in units of each currency per euro.
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// FXClient fetches daily exchange rates from the European Central Bank reference rates feed
+type FXClient struct {
+	url        string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewFXClient creates a new exchange rate client
+func NewFXClient(url string, timeout time.Duration, logger *zap.Logger) *FXClient {
+	return &FXClient{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		logger: logger,
+	}
+}
+
+// GetDailyRates fetches the latest published rates, converted to units per US dollar
+func (c *FXClient) GetDailyRates(ctx context.Context) (*model.FXRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate feed returned status %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange rates: %w", err)
+	}
+
+	date, err := time.Parse("2006-01-02", envelope.Cube.Cube.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exchange rate date %q: %w", envelope.Cube.Cube.Time, err)
+	}
+
+	perEuro := map[string]float64{"EUR": 1}
+	for _, r := range envelope.Cube.Cube.Rates {
+		rate, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil || rate <= 0 {
+			c.logger.Warn("Skipping invalid exchange rate", zap.String("currency", r.Currency), zap.String("rate", r.Rate))
+			continue
+		}
+		perEuro[r.Currency] = rate
+	}
+
+	usdPerEuro, ok := perEuro[model.DefaultCurrency]
+	if !ok {
+		return nil, fmt.Errorf("exchange rate feed has no %s rate", model.DefaultCurrency)
+	}
+
+	rates := &model.FXRates{Date: date, Rates: make(map[string]float64, len(perEuro))}
+	for currency, rate := range perEuro {
+		rates.Rates[currency] = rate / usdPerEuro
+	}
+
+	return rates, nil
+}
@@ -5,35 +5,163 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"services/shared/httpclient"
+	"services/shared/pagination"
+	"services/strategy-service/internal/config"
 	"services/strategy-service/internal/model"
 
 	"go.uber.org/zap"
 )
 
+// BacktestDetails represents a backtest as reported by the Historical Data Service
+type BacktestDetails struct {
+	BacktestID      int                 `json:"backtest_id"`
+	UserID          int                 `json:"user_id"`
+	StrategyID      int                 `json:"strategy_id"`
+	StrategyVersion int                 `json:"strategy_version"`
+	Timeframe       string              `json:"timeframe"`
+	StartDate       time.Time           `json:"start_date"`
+	EndDate         time.Time           `json:"end_date"`
+	InitialCapital  float64             `json:"initial_capital"`
+	Status          string              `json:"status"`
+	CompletedAt     *time.Time          `json:"completed_at,omitempty"`
+	RunResults      []BacktestRunResult `json:"run_results"`
+	Settings        *BacktestSettings   `json:"settings,omitempty"` // Nil for backtests run before settings were stored
+}
+
+// BacktestSettings holds the trading parameters a backtest ran with
+type BacktestSettings struct {
+	MarketType     string  `json:"market_type"`
+	Leverage       float64 `json:"leverage"`
+	PositionSizing string  `json:"position_sizing"`
+}
+
+// BacktestRunResult represents a single symbol run of a backtest
+type BacktestRunResult struct {
+	RunID   int    `json:"run_id"`
+	Symbol  string `json:"symbol"`
+	Status  string `json:"status"`
+	Results *struct {
+		TotalTrades      int     `json:"total_trades"`
+		WinningTrades    int     `json:"winning_trades"`
+		LosingTrades     int     `json:"losing_trades"`
+		ProfitFactor     float64 `json:"profit_factor"`
+		SharpeRatio      float64 `json:"sharpe_ratio"`
+		MaxDrawdown      float64 `json:"max_drawdown"`
+		FinalCapital     float64 `json:"final_capital"`
+		TotalReturn      float64 `json:"total_return"`
+		AnnualizedReturn float64 `json:"annualized_return"`
+	} `json:"results"`
+}
+
+// StrategyBacktest represents a backtest of one version of a strategy with metrics
+// summarized over its completed runs, as reported by the Historical Data Service
+type StrategyBacktest struct {
+	BacktestID      int        `json:"backtest_id"`
+	StrategyID      int        `json:"strategy_id"`
+	StrategyVersion int        `json:"strategy_version"`
+	Name            string     `json:"name"`
+	Timeframe       string     `json:"timeframe"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         time.Time  `json:"end_date"`
+	InitialCapital  float64    `json:"initial_capital"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	TotalRuns       int        `json:"total_runs"`
+	CompletedRuns   int        `json:"completed_runs"`
+	TotalTrades     int        `json:"total_trades"`
+	AvgTotalReturn  *float64   `json:"avg_total_return"`
+	BestTotalReturn *float64   `json:"best_total_return"`
+	AvgSharpeRatio  *float64   `json:"avg_sharpe_ratio"`
+	MaxDrawdown     *float64   `json:"max_drawdown"`
+}
+
+// StrategyVersionSummary summarizes the backtests of one version of a strategy
+type StrategyVersionSummary struct {
+	StrategyVersion    int       `json:"strategy_version"`
+	Backtests          int       `json:"backtests"`
+	CompletedBacktests int       `json:"completed_backtests"`
+	CompletedRuns      int       `json:"completed_runs"`
+	AvgTotalReturn     *float64  `json:"avg_total_return"`
+	BestTotalReturn    *float64  `json:"best_total_return"`
+	AvgSharpeRatio     *float64  `json:"avg_sharpe_ratio"`
+	AvgMaxDrawdown     *float64  `json:"avg_max_drawdown"`
+	LastBacktestAt     time.Time `json:"last_backtest_at"`
+}
+
+// StrategyBacktestHistory is a page of a user's backtests of the versions of a strategy
+// with a per-version summary of all of them
+type StrategyBacktestHistory struct {
+	Data       []StrategyBacktest       `json:"data"`
+	Versions   []StrategyVersionSummary `json:"versions"`
+	Meta       pagination.Meta          `json:"meta"`
+	Pagination pagination.LegacyPage    `json:"pagination"`
+}
+
+// ErrInvalidSignalPreview is returned when the Historical Data Service rejects a signal
+// preview, such as for a symbol or timeframe without candles
+var ErrInvalidSignalPreview = errors.New("invalid signal preview")
+
+// SignalCondition is a condition of the rules behind a signal, with the value its
+// indicator had on the signal's candle
+type SignalCondition struct {
+	Indicator string   `json:"indicator"`
+	Timeframe string   `json:"timeframe,omitempty"` // Set for indicators on an auxiliary timeframe
+	Symbol    string   `json:"symbol"`
+	Value     float64  `json:"value"`
+	Actual    *float64 `json:"actual"` // Nil while the indicator has no value yet
+	Matched   bool     `json:"matched"`
+}
+
+// Signal is an entry or exit a strategy's rules give on a candle
+type Signal struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`  // entry or exit
+	Side       string            `json:"side"`  // long or short
+	Price      float64           `json:"price"` // The candle's close
+	Conditions []SignalCondition `json:"conditions"`
+}
+
+// SignalPreview is the signal timeline of a strategy over a window of candles, oldest
+// first, as reported by the Historical Data Service
+type SignalPreview struct {
+	SymbolID  int       `json:"symbol_id"`
+	Timeframe string    `json:"timeframe"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Candles   int       `json:"candles"` // The candles evaluated
+	Signals   []Signal  `json:"signals"`
+}
+
 // HistoricalClient handles communication with the Historical Data Service
 type HistoricalClient struct {
 	baseURL    string
-	httpClient *http.Client
+	serviceKey string
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
 // NewHistoricalClient creates a new Historical Data Service client
-func NewHistoricalClient(baseURL string, logger *zap.Logger) *HistoricalClient {
+func NewHistoricalClient(cfg config.ServiceConfig, logger *zap.Logger) *HistoricalClient {
 	return &HistoricalClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		logger: logger,
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("historical service", cfg, logger),
+		logger:     logger,
 	}
 }
 
 // CreateBacktest sends a backtest request to the Historical Data Service
-func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.BacktestRequest, userID int) (int, error) {
+func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.BacktestRequest, strategyVersion int, userID int) (int, error) {
 	url := fmt.Sprintf("%s/api/v1/backtests", c.baseURL)
 
 	// Create the request payload
@@ -49,7 +177,7 @@ func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.Ba
 		Strategy        json.RawMessage `json:"strategy"`
 	}{
 		StrategyID:      request.StrategyID,
-		StrategyVersion: 1, // Default to latest version
+		StrategyVersion: strategyVersion,
 		SymbolID:        request.SymbolID,
 		TimeframeID:     request.TimeframeID,
 		StartDate:       request.StartDate,
@@ -74,7 +202,7 @@ func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.Ba
 
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	// Send the request
 	resp, err := c.httpClient.Do(req)
@@ -88,7 +216,7 @@ func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.Ba
 	if resp.StatusCode != http.StatusAccepted {
 		c.logger.Error("Historical service returned unexpected status",
 			zap.Int("status_code", resp.StatusCode))
-		return 0, fmt.Errorf("historical service returned status code %d", resp.StatusCode)
+		return 0, c.httpClient.StatusError(resp)
 	}
 
 	// Parse response
@@ -106,6 +234,43 @@ func (c *HistoricalClient) CreateBacktest(ctx context.Context, request *model.Ba
 	return response.BacktestID, nil
 }
 
+// GetBacktest retrieves a backtest and its owner from the Historical Data Service.
+// Returns nil if the backtest doesn't exist.
+func (c *HistoricalClient) GetBacktest(ctx context.Context, backtestID int) (*BacktestDetails, error) {
+	url := fmt.Sprintf("%s/api/v1/service/backtests/%d", c.baseURL, backtestID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to get backtest", zap.Error(err), zap.Int("backtest_id", backtestID))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var backtest BacktestDetails
+	err = json.NewDecoder(resp.Body).Decode(&backtest)
+	if err != nil {
+		c.logger.Error("Failed to decode backtest response", zap.Error(err))
+		return nil, err
+	}
+
+	return &backtest, nil
+}
+
 // GetSymbols retrieves available trading symbols
 func (c *HistoricalClient) GetSymbols(ctx context.Context) ([]struct {
 	ID       int    `json:"id"`
@@ -120,7 +285,7 @@ func (c *HistoricalClient) GetSymbols(ctx context.Context) ([]struct {
 		return nil, err
 	}
 
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -130,7 +295,7 @@ func (c *HistoricalClient) GetSymbols(ctx context.Context) ([]struct {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("historical service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var symbols []struct {
@@ -163,7 +328,7 @@ func (c *HistoricalClient) GetTimeframes(ctx context.Context) ([]struct {
 		return nil, err
 	}
 
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -173,7 +338,7 @@ func (c *HistoricalClient) GetTimeframes(ctx context.Context) ([]struct {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("historical service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var timeframes []struct {
@@ -191,3 +356,125 @@ func (c *HistoricalClient) GetTimeframes(ctx context.Context) ([]struct {
 
 	return timeframes, nil
 }
+
+// GetStrategyBacktestHistory retrieves a page of a user's backtests of the given versions
+// of a strategy, optionally only those of one version
+func (c *HistoricalClient) GetStrategyBacktestHistory(
+	ctx context.Context,
+	userID int,
+	strategyIDs []int,
+	version *int,
+	page int,
+	limit int,
+) (*StrategyBacktestHistory, error) {
+	ids := make([]string, len(strategyIDs))
+	for i, id := range strategyIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	query := url.Values{}
+	query.Set("user_id", strconv.Itoa(userID))
+	query.Set("strategy_ids", strings.Join(ids, ","))
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	if version != nil {
+		query.Set("version", strconv.Itoa(*version))
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/service/strategy-backtests?%s", c.baseURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to get strategy backtest history", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var history StrategyBacktestHistory
+	err = json.NewDecoder(resp.Body).Decode(&history)
+	if err != nil {
+		c.logger.Error("Failed to decode strategy backtest history response", zap.Error(err))
+		return nil, err
+	}
+
+	return &history, nil
+}
+
+// PreviewSignals has the Historical Data Service evaluate a strategy structure over the
+// latest candles of a symbol and list the entries and exits it gives
+func (c *HistoricalClient) PreviewSignals(
+	ctx context.Context,
+	structure json.RawMessage,
+	request *model.SignalPreviewRequest,
+) (*SignalPreview, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/service/signals/preview", c.baseURL)
+
+	payload := struct {
+		Strategy   json.RawMessage `json:"strategy"`
+		SymbolID   int             `json:"symbol_id"`
+		Timeframe  string          `json:"timeframe"`
+		Candles    int             `json:"candles,omitempty"`
+		AllowShort bool            `json:"allow_short,omitempty"`
+	}{
+		Strategy:   structure,
+		SymbolID:   request.SymbolID,
+		Timeframe:  request.Timeframe,
+		Candles:    request.Candles,
+		AllowShort: request.AllowShort,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Error("Failed to marshal signal preview request", zap.Error(err))
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to send signal preview request", zap.Error(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Rejected previews are passed on as ErrInvalidSignalPreview with the reason
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignalPreview, errorResponse.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data SignalPreview `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		c.logger.Error("Failed to decode signal preview response", zap.Error(err))
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
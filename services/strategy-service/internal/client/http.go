@@ -0,0 +1,20 @@
+package client
+
+import (
+	"services/shared/httpclient"
+	"services/strategy-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// newHTTPClient creates the HTTP client calling the named service with its configured
+// timeout, retries, connection limit and TLS settings
+func newHTTPClient(service string, cfg config.ServiceConfig, logger *zap.Logger) *httpclient.Client {
+	return httpclient.New(service, httpclient.Config{
+		Timeout:         cfg.Timeout,
+		MaxRetries:      cfg.MaxRetries,
+		BaseBackoff:     cfg.RetryBackoff,
+		MaxConnsPerHost: cfg.MaxConns,
+		TLS:             cfg.TLS,
+	}, logger)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// NotificationEvent is the payload published to the user notifications topic.
+// It matches the event consumed by the User Service.
+type NotificationEvent struct {
+	UserID  int    `json:"user_id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Link    string `json:"link,omitempty"`
+}
+
+// NotificationClient publishes user notifications to Kafka
+type NotificationClient struct {
+	writer *kafka.Writer
+	logger *zap.Logger
+}
+
+// NewNotificationClient creates a new notification client.
+// brokers is a comma-separated list; an empty list disables publishing.
+func NewNotificationClient(brokers string, topic string, logger *zap.Logger) *NotificationClient {
+	addrs := SplitBrokers(brokers)
+
+	c := &NotificationClient{logger: logger}
+	if len(addrs) == 0 || topic == "" {
+		logger.Warn("Kafka not configured, notifications will only be logged")
+		return c
+	}
+
+	c.writer = &kafka.Writer{
+		Addr:         kafka.TCP(addrs...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+	}
+
+	return c
+}
+
+// Send publishes notification events, keyed by user so each user's events stay ordered
+func (c *NotificationClient) Send(ctx context.Context, events ...NotificationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if c.writer == nil {
+		for _, event := range events {
+			c.logger.Info("Notification (not published)",
+				zap.Int("userID", event.UserID),
+				zap.String("type", event.Type),
+				zap.String("title", event.Title))
+		}
+		return nil
+	}
+
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{
+			Key:   []byte(strconv.Itoa(event.UserID)),
+			Value: value,
+		})
+	}
+
+	if err := c.writer.WriteMessages(ctx, messages...); err != nil {
+		c.logger.Error("Failed to publish notifications", zap.Error(err), zap.Int("count", len(messages)))
+		return err
+	}
+
+	return nil
+}
+
+// Close flushes pending messages and closes the Kafka writer
+func (c *NotificationClient) Close() error {
+	if c.writer == nil {
+		return nil
+	}
+	return c.writer.Close()
+}
+
+// SplitBrokers parses a comma-separated broker list, skipping empty entries
+func SplitBrokers(brokers string) []string {
+	var addrs []string
+	for _, broker := range strings.Split(brokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			addrs = append(addrs, broker)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// PaymentClient issues refunds through the payment provider
+type PaymentClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewPaymentClient creates a new payment provider client
+func NewPaymentClient(baseURL string, apiKey string, timeout time.Duration, logger *zap.Logger) *PaymentClient {
+	return &PaymentClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		logger: logger,
+	}
+}
+
+// Refund asks the payment provider to refund the amount of a refund request and returns the
+// provider's refund ID. The request ID is sent as idempotency key, so retrying a refund
+// that already went through doesn't pay it out twice.
+func (c *PaymentClient) Refund(ctx context.Context, refund *model.RefundRequest) (string, error) {
+	url := fmt.Sprintf("%s/refunds", c.baseURL)
+
+	payload := struct {
+		PurchaseID int     `json:"purchase_id"`
+		BuyerID    int     `json:"buyer_id"`
+		Amount     float64 `json:"amount"`
+		Currency   string  `json:"currency"`
+		Reason     string  `json:"reason"`
+	}{
+		PurchaseID: refund.PurchaseID,
+		BuyerID:    refund.BuyerID,
+		Amount:     refund.Amount,
+		Currency:   refund.Currency,
+		Reason:     refund.Reason,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("refund-%d", refund.ID))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to send refund to payment provider", zap.Error(err), zap.Int("refund_id", refund.ID))
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		c.logger.Error("Payment provider returned unexpected status",
+			zap.Int("status_code", resp.StatusCode),
+			zap.Int("refund_id", refund.ID))
+		return "", fmt.Errorf("payment provider returned status code %d", resp.StatusCode)
+	}
+
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		c.logger.Error("Failed to decode payment provider response", zap.Error(err))
+		return "", err
+	}
+
+	return response.ID, nil
+}
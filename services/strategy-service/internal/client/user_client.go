@@ -1,13 +1,17 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
+
+	"services/shared/httpclient"
+	"services/shared/savedview"
+	"services/strategy-service/internal/config"
 
 	"go.uber.org/zap"
 )
@@ -15,18 +19,18 @@ import (
 // UserClient handles communication with the User Service
 type UserClient struct {
 	baseURL    string
-	httpClient *http.Client
+	serviceKey string
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
 // NewUserClient creates a new User Service client
-func NewUserClient(baseURL string, logger *zap.Logger) *UserClient {
+func NewUserClient(cfg config.ServiceConfig, logger *zap.Logger) *UserClient {
 	return &UserClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		logger: logger,
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("user service", cfg, logger),
+		logger:     logger,
 	}
 }
 
@@ -65,7 +69,7 @@ func (c *UserClient) CheckUserRole(ctx context.Context, userID int, role string,
 	}
 
 	// Add service key header
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -89,7 +93,7 @@ func (c *UserClient) CheckUserRole(ctx context.Context, userID int, role string,
 		return response.Valid && response.Role == role, nil
 	}
 
-	return false, fmt.Errorf("user service returned status code %d", resp.StatusCode)
+	return false, c.httpClient.StatusError(resp)
 }
 
 // GetUserByID retrieves a user's username by ID
@@ -102,7 +106,7 @@ func (c *UserClient) GetUserByID(ctx context.Context, userID int) (string, error
 	}
 
 	// Add service authentication header
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -112,7 +116,7 @@ func (c *UserClient) GetUserByID(ctx context.Context, userID int) (string, error
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return "", c.httpClient.StatusError(resp)
 	}
 
 	var user struct {
@@ -140,7 +144,7 @@ func (c *UserClient) ValidateUserAccess(ctx context.Context, userID int, token s
 
 	// Add headers
 	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -184,7 +188,7 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 	}
 
 	// Add service authentication header
-	req.Header.Set("X-Service-Key", "strategy-service-key")
+	req.Header.Set("X-Service-Key", c.serviceKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -197,7 +201,7 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 		c.logger.Warn("User service returned non-200 status",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("url", url))
-		return nil, fmt.Errorf("user service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	var response struct {
@@ -219,6 +223,159 @@ func (c *UserClient) BatchGetUsersByIDs(ctx context.Context, userIDs []int) (map
 	return result, nil
 }
 
+// VerifyServiceKey asks the User Service which service an issued key belongs to. ok is
+// false for unknown, expired and revoked keys.
+func (c *UserClient) VerifyServiceKey(ctx context.Context, key string) (serviceName string, ok bool, err error) {
+	url := fmt.Sprintf("%s/api/v1/service/credentials/verify", c.baseURL)
+
+	payload, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return "", false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to verify service key with User Service", zap.Error(err))
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Valid bool `json:"valid"`
+		Data  struct {
+			ServiceName string `json:"service_name"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", false, err
+	}
+
+	return response.Data.ServiceName, response.Valid, nil
+}
+
+// ReferralRedemption is referral credit of a user spent through the User Service. Amount
+// is 0, with no redemption to reverse, when the user had no credit in the currency.
+type ReferralRedemption struct {
+	RedemptionID int     `json:"redemption_id"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+}
+
+// RedeemReferralCredit spends up to amount of a user's referral credit in a currency.
+// Less is spent when the balance is lower. The call is never retried, so credit can't be
+// spent twice.
+func (c *UserClient) RedeemReferralCredit(ctx context.Context, userID int, amount float64, currency, reference string) (*ReferralRedemption, error) {
+	url := fmt.Sprintf("%s/api/v1/service/referral-credit/redeem", c.baseURL)
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id":   userID,
+		"amount":    amount,
+		"currency":  currency,
+		"reference": reference,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to redeem referral credit with User Service", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data ReferralRedemption `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// ReverseReferralRedemption gives back the referral credit of a redemption, e.g. when the
+// purchase it paid for failed
+func (c *UserClient) ReverseReferralRedemption(ctx context.Context, redemptionID int) error {
+	url := fmt.Sprintf("%s/api/v1/service/referral-credit/redemptions/%d/reverse", c.baseURL, redemptionID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to reverse referral credit redemption with User Service", zap.Error(err), zap.Int("redemption_id", redemptionID))
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.httpClient.StatusError(resp)
+	}
+
+	return nil
+}
+
+// GetSavedView retrieves a saved list view of a user. It returns nil if the user has no
+// such view.
+func (c *UserClient) GetSavedView(ctx context.Context, userID, viewID int) (*savedview.View, error) {
+	url := fmt.Sprintf("%s/api/v1/service/users/%d/views/%d", c.baseURL, userID, viewID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("Failed to get saved view from User Service", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data savedview.View `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // UserDetails represents the user information returned by the user service
 type UserDetails struct {
 	ID              int    `json:"id"`
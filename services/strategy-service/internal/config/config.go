@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -14,7 +15,18 @@ type Config struct {
 	UserService       ServiceConfig
 	HistoricalService ServiceConfig
 	MediaService      ServiceConfig // Added for media service
+	Auth              AuthConfig
+	Redis             RedisConfig
 	Kafka             KafkaConfig
+	Marketplace       MarketplaceConfig
+	FX                FXConfig
+	Payments          PaymentsConfig
+	Stats             StatsConfig
+	Tags              TagsConfig
+	Idempotency       IdempotencyConfig
+	ServiceKey        string // Key other services authenticate their calls with
+	ServiceAuth       ServiceAuthConfig
+	InternalTLS       InternalTLSConfig
 	Logging           LoggingConfig
 }
 
@@ -37,19 +49,121 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	Replica         ReplicaConfig
+	Pool            PoolConfig
+}
+
+// ReplicaConfig holds configuration for an optional read replica. Heavy reads are sent
+// to the replica while its health checks pass and fall back to the primary otherwise.
+type ReplicaConfig struct {
+	DSN                 string // Replica connection string; empty disables the replica
+	HealthCheckInterval time.Duration
+	MaxLag              time.Duration // Replication lag above which the replica is considered unhealthy
+	RecoverAfter        int           // Consecutive passing checks before reads fail back to the replica
+}
+
+// PoolConfig holds configuration for monitoring the database connection pools. When
+// adaptive, a pool grows past maxOpenConns while queries wait for connections and shrinks
+// back once they no longer do.
+type PoolConfig struct {
+	SampleInterval    time.Duration
+	Adaptive          bool
+	MaxOpenConnsLimit int           // Most open connections a pool grows to
+	TargetWait        time.Duration // Average wait for a connection above which a pool grows
 }
 
 // ServiceConfig holds configuration for external services
 type ServiceConfig struct {
-	URL        string
-	Timeout    time.Duration
-	ServiceKey string
+	URL          string
+	Timeout      time.Duration // Limit of a single attempt of a call
+	ServiceKey   string
+	MaxRetries   int           // Retries of failed idempotent calls
+	RetryBackoff time.Duration // Longest wait before the first retry, doubled for each further retry
+	MaxConns     int           // Connections open to the service at once; 0 means no limit
+	// TLS is built from InternalTLS when it is enabled
+	TLS *tls.Config `mapstructure:"-"`
+}
+
+// ServiceAuthConfig holds settings of authenticating calls from other services
+type ServiceAuthConfig struct {
+	// VerifyIssuedKeys accepts keys issued by the user service next to ServiceKey,
+	// verifying them with the user service
+	VerifyIssuedKeys bool
+	CacheTTL         time.Duration // How long a verified key is trusted before it is checked again
+}
+
+// AuthConfig holds configuration for verifying access tokens locally
+type AuthConfig struct {
+	JWKSURL    string        // Public keys of RS256 access tokens, served by the user service
+	JWTSecret  string        // Shared secret of HS256 access tokens; empty rejects them
+	KeysMaxAge time.Duration // How long fetched keys are used before refetching
+}
+
+// RedisConfig holds configuration for the Redis the user service broadcasts token
+// revocations on, also used to cache popular tags. Without it revoked tokens stay
+// valid until they expire.
+type RedisConfig struct {
+	Enabled  bool
+	URL      string
+	Password string
+	DB       int
 }
 
 // KafkaConfig holds Kafka specific configuration
 type KafkaConfig struct {
-	Brokers string
-	Topics  map[string]string
+	Brokers         string
+	Topics          map[string]string
+	TrendingGroupID string // Consumer group tracking listing views and purchases
+}
+
+// MarketplaceConfig holds marketplace specific configuration
+type MarketplaceConfig struct {
+	PlatformFeePercent        float64
+	SubscriptionCheckInterval time.Duration
+	RenewalReminderDays       int
+	Trending                  TrendingConfig
+}
+
+// TrendingConfig holds configuration for marketplace trending scores and recommendations
+type TrendingConfig struct {
+	Window         time.Duration // Only views and purchases this recent count
+	HalfLife       time.Duration // Age at which a view or purchase counts half
+	ViewWeight     float64
+	PurchaseWeight float64
+}
+
+// FXConfig holds configuration for exchange rates used to convert listing prices
+type FXConfig struct {
+	URL      string // Daily reference rates feed of the European Central Bank
+	Timeout  time.Duration
+	CacheTTL time.Duration // How long currencies and rates are cached in memory
+}
+
+// PaymentsConfig holds configuration for the payment provider used to pay back approved
+// refunds. Without a URL, refunds are only recorded.
+type PaymentsConfig struct {
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// StatsConfig holds configuration for admin dashboard statistics
+type StatsConfig struct {
+	CacheTTL time.Duration
+}
+
+// TagsConfig holds configuration for strategy tags
+type TagsConfig struct {
+	PopularityRefreshInterval time.Duration // How often the usage counts popular tags are ranked by are recomputed
+	PopularCacheTTL           time.Duration // How long popular tags are cached in Redis
+}
+
+// IdempotencyConfig holds configuration for Idempotency-Key handling
+type IdempotencyConfig struct {
+	// TTL is how long a key and its stored response are kept
+	TTL time.Duration
+	// LockTimeout is how long a request holds its key before a retry may run it again
+	LockTimeout time.Duration
 }
 
 // LoggingConfig holds logging specific configuration
@@ -79,6 +193,14 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	tlsConfig, err := cfg.InternalTLS.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal TLS config: %w", err)
+	}
+	cfg.UserService.TLS = tlsConfig
+	cfg.HistoricalService.TLS = tlsConfig
+	cfg.MediaService.TLS = tlsConfig
+
 	return &cfg, nil
 }
 
@@ -95,23 +217,82 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.maxOpenConns", 25)
 	v.SetDefault("database.maxIdleConns", 5)
 	v.SetDefault("database.connMaxLifetime", "30m")
+	v.SetDefault("database.replica.healthCheckInterval", "5s")
+	v.SetDefault("database.replica.maxLag", "30s")
+	v.SetDefault("database.replica.recoverAfter", 3)
+	v.SetDefault("database.pool.sampleInterval", "10s")
+	v.SetDefault("database.pool.adaptive", false)
+	v.SetDefault("database.pool.maxOpenConnsLimit", 50)
+	v.SetDefault("database.pool.targetWait", "5ms")
 
 	// User Service defaults
 	v.SetDefault("userService.timeout", "5s")
+	v.SetDefault("userService.maxRetries", 2)
+	v.SetDefault("userService.retryBackoff", "100ms")
 	v.SetDefault("userService.serviceKey", "strategy-service-key")
 
 	// Historical Service defaults
 	v.SetDefault("historicalService.timeout", "30s")
+	v.SetDefault("historicalService.maxRetries", 2)
+	v.SetDefault("historicalService.retryBackoff", "100ms")
 	v.SetDefault("historicalService.serviceKey", "strategy-service-key")
 
 	// Media Service defaults
 	v.SetDefault("mediaService.url", "http://media-service:8085")
 	v.SetDefault("mediaService.timeout", "30s")
+	v.SetDefault("mediaService.maxRetries", 2)
+	v.SetDefault("mediaService.retryBackoff", "100ms")
 	v.SetDefault("mediaService.serviceKey", "media-service-key")
 
+	// Auth defaults
+	v.SetDefault("auth.jwksURL", "http://user-service:8083/.well-known/jwks.json")
+	v.SetDefault("auth.keysMaxAge", "1h")
+
+	// Redis defaults
+	v.SetDefault("redis.enabled", false)
+	v.SetDefault("redis.db", 0)
+
 	// Kafka topic defaults
 	v.SetDefault("kafka.topics.strategyEvents", "strategy-events")
 	v.SetDefault("kafka.topics.marketplaceEvents", "marketplace-events")
+	v.SetDefault("kafka.topics.notifications", "user-notifications")
+	v.SetDefault("kafka.topics.listingViews", "marketplace-listing-views")
+	v.SetDefault("kafka.trendingGroupID", "strategy-service-trending")
+
+	// Marketplace defaults
+	v.SetDefault("marketplace.platformFeePercent", 10.0)
+	v.SetDefault("marketplace.subscriptionCheckInterval", "24h")
+	v.SetDefault("marketplace.renewalReminderDays", 3)
+	v.SetDefault("marketplace.trending.window", "336h")
+	v.SetDefault("marketplace.trending.halfLife", "48h")
+	v.SetDefault("marketplace.trending.viewWeight", 1.0)
+	v.SetDefault("marketplace.trending.purchaseWeight", 10.0)
+
+	// Exchange rate defaults
+	v.SetDefault("fx.url", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml")
+	v.SetDefault("fx.timeout", "10s")
+	v.SetDefault("fx.cacheTTL", "1h")
+
+	// Payments defaults
+	v.SetDefault("payments.url", "")
+	v.SetDefault("payments.timeout", "15s")
+
+	// Stats defaults
+	v.SetDefault("stats.cacheTTL", "1m")
+
+	// Tag defaults
+	v.SetDefault("tags.popularityRefreshInterval", "15m")
+	v.SetDefault("tags.popularCacheTTL", "15m")
+
+	// Idempotency defaults
+	v.SetDefault("idempotency.ttl", "24h")
+	v.SetDefault("idempotency.lockTimeout", "1m")
+
+	// Service key for authentication
+	v.SetDefault("serviceKey", "strategy-service-key")
+	v.SetDefault("serviceAuth.verifyIssuedKeys", true)
+	v.SetDefault("serviceAuth.cacheTTL", "1m")
+	v.SetDefault("internalTLS.enabled", false)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
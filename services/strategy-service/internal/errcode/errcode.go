@@ -0,0 +1,147 @@
+// Package errcode defines the error codes and errors of the strategy service, and the
+// rules its untyped errors are mapped to codes with
+package errcode
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+)
+
+// Codes of the strategy service
+const (
+	CodeInvalidToken                   apierror.Code = "INVALID_TOKEN"
+	CodeTokenRevoked                   apierror.Code = "TOKEN_REVOKED"
+	CodeImpersonationEnded             apierror.Code = "IMPERSONATION_ENDED"
+	CodeStrategyNotFound               apierror.Code = "STRATEGY_NOT_FOUND"
+	CodeStrategyVersionNotFound        apierror.Code = "STRATEGY_VERSION_NOT_FOUND"
+	CodeStrategyAccessDenied           apierror.Code = "STRATEGY_ACCESS_DENIED"
+	CodeInvalidStrategy                apierror.Code = "INVALID_STRATEGY"
+	CodeListingNotFound                apierror.Code = "LISTING_NOT_FOUND"
+	CodeListingAlreadyExists           apierror.Code = "LISTING_ALREADY_EXISTS"
+	CodeListingInactive                apierror.Code = "LISTING_INACTIVE"
+	CodeAlreadyPurchased               apierror.Code = "ALREADY_PURCHASED"
+	CodeCannotPurchaseOwnStrategy      apierror.Code = "CANNOT_PURCHASE_OWN_STRATEGY"
+	CodePurchaseNotFound               apierror.Code = "PURCHASE_NOT_FOUND"
+	CodePurchaseRequired               apierror.Code = "PURCHASE_REQUIRED"
+	CodeReviewNotFound                 apierror.Code = "REVIEW_NOT_FOUND"
+	CodeAlreadyReviewed                apierror.Code = "ALREADY_REVIEWED"
+	CodeIndicatorNotFound              apierror.Code = "INDICATOR_NOT_FOUND"
+	CodeIndicatorPresetNotFound        apierror.Code = "INDICATOR_PRESET_NOT_FOUND"
+	CodeIndicatorPresetAlreadyExists   apierror.Code = "INDICATOR_PRESET_ALREADY_EXISTS"
+	CodeInvalidIndicatorPreset         apierror.Code = "INVALID_INDICATOR_PRESET"
+	CodeParameterNotFound              apierror.Code = "PARAMETER_NOT_FOUND"
+	CodeEnumValueNotFound              apierror.Code = "ENUM_VALUE_NOT_FOUND"
+	CodeTagNotFound                    apierror.Code = "TAG_NOT_FOUND"
+	CodeTagAlreadyExists               apierror.Code = "TAG_ALREADY_EXISTS"
+	CodeTagInUse                       apierror.Code = "TAG_IN_USE"
+	CodeTagAliasNotFound               apierror.Code = "TAG_ALIAS_NOT_FOUND"
+	CodeTemplateNotFound               apierror.Code = "TEMPLATE_NOT_FOUND"
+	CodeTemplateAlreadyExists          apierror.Code = "TEMPLATE_ALREADY_EXISTS"
+	CodeInvalidTemplate                apierror.Code = "INVALID_TEMPLATE"
+	CodeInvalidTemplateParameters      apierror.Code = "INVALID_TEMPLATE_PARAMETERS"
+	CodeCouponNotFound                 apierror.Code = "COUPON_NOT_FOUND"
+	CodeCouponAlreadyExists            apierror.Code = "COUPON_ALREADY_EXISTS"
+	CodeCouponInvalid                  apierror.Code = "COUPON_INVALID"
+	CodeUnsupportedCurrency            apierror.Code = "UNSUPPORTED_CURRENCY"
+	CodeExchangeRatesUnavailable       apierror.Code = "EXCHANGE_RATES_UNAVAILABLE"
+	CodeInvalidIndicatorFormula        apierror.Code = "INVALID_INDICATOR_FORMULA"
+	CodeIndicatorValidationUnavailable apierror.Code = "INDICATOR_VALIDATION_UNAVAILABLE"
+	CodeRefundNotFound                 apierror.Code = "REFUND_NOT_FOUND"
+	CodeRefundAlreadyRequested         apierror.Code = "REFUND_ALREADY_REQUESTED"
+	CodeRefundAlreadyReviewed          apierror.Code = "REFUND_ALREADY_REVIEWED"
+	CodeAlreadyRefunded                apierror.Code = "ALREADY_REFUNDED"
+	CodePaymentFailed                  apierror.Code = "PAYMENT_FAILED"
+	CodeReportNotFound                 apierror.Code = "REPORT_NOT_FOUND"
+	CodeAlreadyReported                apierror.Code = "ALREADY_REPORTED"
+	CodeReportAlreadyResolved          apierror.Code = "REPORT_ALREADY_RESOLVED"
+	CodeCannotReportOwnContent         apierror.Code = "CANNOT_REPORT_OWN_CONTENT"
+	CodeBacktestNotFound               apierror.Code = "BACKTEST_NOT_FOUND"
+	CodeUserNotFound                   apierror.Code = "USER_NOT_FOUND"
+	CodeInvalidCursor                  apierror.Code = "INVALID_CURSOR"
+	CodeInvalidMedia                   apierror.Code = "INVALID_MEDIA"
+	CodeInsufficientBalance            apierror.Code = "INSUFFICIENT_BALANCE"
+	CodeCollaboratorNotFound           apierror.Code = "COLLABORATOR_NOT_FOUND"
+	CodeCommentNotFound                apierror.Code = "COMMENT_NOT_FOUND"
+)
+
+// Errors returned by the services of the strategy service
+var (
+	ErrStrategyNotFound               = apierror.New(http.StatusNotFound, CodeStrategyNotFound, "Strategy not found")
+	ErrListingNotFound                = apierror.New(http.StatusNotFound, CodeListingNotFound, "Listing not found")
+	ErrInvalidCursor                  = apierror.New(http.StatusBadRequest, CodeInvalidCursor, "Invalid cursor")
+	ErrIndicatorNotFound              = apierror.New(http.StatusNotFound, CodeIndicatorNotFound, "Indicator not found")
+	ErrIndicatorPresetNotFound        = apierror.New(http.StatusNotFound, CodeIndicatorPresetNotFound, "Indicator preset not found")
+	ErrIndicatorPresetAlreadyExists   = apierror.New(http.StatusConflict, CodeIndicatorPresetAlreadyExists, "Indicator preset name already exists")
+	ErrInvalidIndicatorPreset         = apierror.New(http.StatusBadRequest, CodeInvalidIndicatorPreset, "Invalid indicator preset")
+	ErrParameterNotFound              = apierror.New(http.StatusNotFound, CodeParameterNotFound, "Parameter not found")
+	ErrEnumValueNotFound              = apierror.New(http.StatusNotFound, CodeEnumValueNotFound, "Enum value not found")
+	ErrTagNotFound                    = apierror.New(http.StatusNotFound, CodeTagNotFound, "Tag not found")
+	ErrTagInUse                       = apierror.New(http.StatusConflict, CodeTagInUse, "Cannot delete tag because it's in use")
+	ErrTagAlreadyExists               = apierror.New(http.StatusConflict, CodeTagAlreadyExists, "Tag name already exists")
+	ErrTagAliasNotFound               = apierror.New(http.StatusNotFound, CodeTagAliasNotFound, "Tag alias not found")
+	ErrTemplateNotFound               = apierror.New(http.StatusNotFound, CodeTemplateNotFound, "Strategy template not found")
+	ErrTemplateAlreadyExists          = apierror.New(http.StatusConflict, CodeTemplateAlreadyExists, "Strategy template name already exists")
+	ErrInvalidTemplate                = apierror.New(http.StatusBadRequest, CodeInvalidTemplate, "Invalid strategy template")
+	ErrInvalidTemplateParameters      = apierror.New(http.StatusBadRequest, CodeInvalidTemplateParameters, "Invalid strategy template parameters")
+	ErrCouponNotFound                 = apierror.New(http.StatusNotFound, CodeCouponNotFound, "Coupon not found")
+	ErrPurchaseNotFound               = apierror.New(http.StatusNotFound, CodePurchaseNotFound, "Purchase not found")
+	ErrBacktestNotFound               = apierror.New(http.StatusNotFound, CodeBacktestNotFound, "Backtest not found")
+	ErrUnsupportedCurrency            = apierror.New(http.StatusBadRequest, CodeUnsupportedCurrency, "Unsupported currency")
+	ErrInvalidIndicatorFormula        = apierror.New(http.StatusBadRequest, CodeInvalidIndicatorFormula, "Invalid indicator formula")
+	ErrIndicatorValidationUnavailable = apierror.New(http.StatusServiceUnavailable, CodeIndicatorValidationUnavailable, "Indicator validation is temporarily unavailable")
+	ErrInvalidStrategy                = apierror.New(http.StatusBadRequest, CodeInvalidStrategy, "Invalid strategy")
+	ErrPaymentFailed                  = apierror.New(http.StatusBadGateway, CodePaymentFailed, "Failed to refund the payment, please try again later")
+	ErrExchangeRatesUnavailable       = apierror.New(http.StatusServiceUnavailable, CodeExchangeRatesUnavailable, "Exchange rates unavailable")
+	ErrCollaboratorNotFound           = apierror.New(http.StatusNotFound, CodeCollaboratorNotFound, "Collaborator not found")
+	ErrCommentNotFound                = apierror.New(http.StatusNotFound, CodeCommentNotFound, "Comment not found")
+)
+
+// MessageRules maps errors by their message, most specific first. They cover the
+// exceptions raised by the PL/pgSQL functions and the untyped errors of the services.
+var MessageRules = []apierror.MessageRule{
+	{Contains: "cannot purchase your own", Status: http.StatusBadRequest, Code: CodeCannotPurchaseOwnStrategy},
+	{Contains: "already purchased", Status: http.StatusConflict, Code: CodeAlreadyPurchased},
+	{Contains: "strategy is already listed", Status: http.StatusConflict, Code: CodeListingAlreadyExists},
+	{Contains: "must purchase strategy", Status: http.StatusForbidden, Code: CodePurchaseRequired},
+	{Contains: "already reviewed", Status: http.StatusConflict, Code: CodeAlreadyReviewed},
+	{Contains: "purchase is already refunded", Status: http.StatusConflict, Code: CodeAlreadyRefunded},
+	{Contains: "refund request is already pending", Status: http.StatusConflict, Code: CodeRefundAlreadyRequested},
+	{Contains: "refund request has already been reviewed", Status: http.StatusConflict, Code: CodeRefundAlreadyReviewed},
+	{Contains: "refund request not found", Status: http.StatusNotFound, Code: CodeRefundNotFound},
+	{Contains: "cannot report your own", Status: http.StatusBadRequest, Code: CodeCannotReportOwnContent},
+	{Contains: "already reported", Status: http.StatusConflict, Code: CodeAlreadyReported},
+	{Contains: "report has already been resolved", Status: http.StatusConflict, Code: CodeReportAlreadyResolved},
+	{Contains: "report not found", Status: http.StatusNotFound, Code: CodeReportNotFound},
+	{Contains: "coupon code already exists", Status: http.StatusConflict, Code: CodeCouponAlreadyExists},
+	{Contains: "invalid coupon", Status: http.StatusBadRequest, Code: CodeCouponInvalid},
+	{Contains: "coupon is no longer valid", Status: http.StatusBadRequest, Code: CodeCouponInvalid},
+	{Contains: "coupon not found", Status: http.StatusNotFound, Code: CodeCouponNotFound},
+	{Contains: "unsupported currency", Status: http.StatusBadRequest, Code: CodeUnsupportedCurrency},
+	{Contains: "insufficient wallet balance", Status: http.StatusConflict, Code: CodeInsufficientBalance},
+	{Contains: "cursor", Status: http.StatusBadRequest, Code: CodeInvalidCursor},
+	{Contains: "invalid media", Status: http.StatusBadRequest, Code: CodeInvalidMedia},
+	{Contains: "listing is not active", Status: http.StatusBadRequest, Code: CodeListingInactive},
+	{Contains: "listing not found", Status: http.StatusNotFound, Code: CodeListingNotFound},
+	{Contains: "review not found", Status: http.StatusNotFound, Code: CodeReviewNotFound},
+	{Contains: "purchase not found", Status: http.StatusNotFound, Code: CodePurchaseNotFound},
+	{Contains: "is in use", Status: http.StatusConflict, Code: CodeTagInUse},
+	{Contains: "tag name already exists", Status: http.StatusConflict, Code: CodeTagAlreadyExists},
+	{Contains: "tag not found", Status: http.StatusNotFound, Code: CodeTagNotFound},
+	{Contains: "enum value not found", Status: http.StatusNotFound, Code: CodeEnumValueNotFound},
+	{Contains: "parameter not found", Status: http.StatusNotFound, Code: CodeParameterNotFound},
+	{Contains: "indicator not found", Status: http.StatusNotFound, Code: CodeIndicatorNotFound},
+	{Contains: "backtest not found", Status: http.StatusNotFound, Code: CodeBacktestNotFound},
+	{Contains: "version not found", Status: http.StatusNotFound, Code: CodeStrategyVersionNotFound},
+	{Contains: "strategy not found", Status: http.StatusNotFound, Code: CodeStrategyNotFound},
+	{Contains: "strategy does not belong to user", Status: http.StatusForbidden, Code: CodeStrategyAccessDenied},
+	{Contains: "user not found", Status: http.StatusNotFound, Code: CodeUserNotFound},
+	{Contains: "not found", Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Contains: "access denied", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "permission", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "not authorized", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "view-only", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "already", Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Contains: "invalid", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Contains: "required", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+}
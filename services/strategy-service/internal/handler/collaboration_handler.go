@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+	"services/strategy-service/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CollaborationHandler handles HTTP requests for strategy collaborators and the comments on
+// strategy versions
+type CollaborationHandler struct {
+	collaborationService *service.CollaborationService
+	logger               *zap.Logger
+}
+
+// NewCollaborationHandler creates a new collaboration handler
+func NewCollaborationHandler(collaborationService *service.CollaborationService, logger *zap.Logger) *CollaborationHandler {
+	return &CollaborationHandler{
+		collaborationService: collaborationService,
+		logger:               logger,
+	}
+}
+
+// GetCollaborators handles listing the collaborators and pending invitations of a strategy
+// GET /api/v1/strategies/{id}/collaborators
+//
+// @Summary List the collaborators of a strategy
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.StrategyCollaborator}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/collaborators [get]
+func (h *CollaborationHandler) GetCollaborators(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	collaborators, err := h.collaborationService.GetCollaborators(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get collaborators")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collaborators})
+}
+
+// InviteCollaborator handles the owner of a strategy inviting a user to edit it
+// POST /api/v1/strategies/{id}/collaborators
+//
+// @Summary Invite a user to collaborate on a strategy
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.StrategyCollaboratorInvite true "Request body"
+// @Success 201 {object} object{data=model.StrategyCollaborator}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/collaborators [post]
+func (h *CollaborationHandler) InviteCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var invite model.StrategyCollaboratorInvite
+	if !validation.BindJSON(c, &invite) {
+		return
+	}
+
+	collaborator, err := h.collaborationService.InviteCollaborator(c.Request.Context(), id, userID.(int), &invite)
+	if err != nil {
+		h.logger.Error("Failed to invite collaborator", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to invite collaborator")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": collaborator})
+}
+
+// AcceptInvitation handles a user accepting their invitation to collaborate on a strategy
+// POST /api/v1/strategies/{id}/collaborators/accept
+//
+// @Summary Accept an invitation to collaborate on a strategy
+// @Tags strategies
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/collaborators/accept [post]
+func (h *CollaborationHandler) AcceptInvitation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.collaborationService.AcceptInvitation(c.Request.Context(), id, userID.(int)); err != nil {
+		apierror.Respond(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveCollaborator handles the owner removing a collaborator of a strategy, or a
+// collaborator leaving it or declining their invitation
+// DELETE /api/v1/strategies/{id}/collaborators/{userId}
+//
+// @Summary Remove a collaborator of a strategy
+// @Tags strategies
+// @Param id path integer true "ID"
+// @Param userId path integer true "user ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/collaborators/{userId} [delete]
+func (h *CollaborationHandler) RemoveCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	targetUserID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.collaborationService.RemoveCollaborator(c.Request.Context(), id, userID.(int), targetUserID); err != nil {
+		apierror.Respond(c, err, "Failed to remove collaborator")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyCollaborations handles listing the strategies the user collaborates on or is
+// invited to
+// GET /api/v1/strategies/collaborations
+//
+// @Summary List the strategies the user collaborates on
+// @Tags strategies
+// @Produce json
+// @Success 200 {object} object{data=[]model.StrategyCollaborator}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/collaborations [get]
+func (h *CollaborationHandler) GetMyCollaborations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	collaborations, err := h.collaborationService.GetUserCollaborations(c.Request.Context(), userID.(int))
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get collaborations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collaborations})
+}
+
+// GetComments handles listing the comments on a strategy version
+// GET /api/v1/strategies/{id}/versions/{version}/comments
+//
+// @Summary List the comments on a strategy version
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Param version path integer true "version"
+// @Success 200 {object} object{data=[]model.StrategyComment}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/versions/{version}/comments [get]
+func (h *CollaborationHandler) GetComments(c *gin.Context) {
+	id, versionID, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	comments, err := h.collaborationService.GetComments(c.Request.Context(), id, versionID, userID.(int))
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get comments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": comments})
+}
+
+// AddComment handles the owner or a collaborator commenting on a strategy version. The
+// collaborators mentioned as @username are notified.
+// POST /api/v1/strategies/{id}/versions/{version}/comments
+//
+// @Summary Comment on a strategy version
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param version path integer true "version"
+// @Param request body model.StrategyCommentCreate true "Request body"
+// @Success 201 {object} object{data=model.StrategyComment}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/versions/{version}/comments [post]
+func (h *CollaborationHandler) AddComment(c *gin.Context) {
+	id, versionID, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.StrategyCommentCreate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	comment, err := h.collaborationService.AddComment(c.Request.Context(), id, versionID, userID.(int), &request)
+	if err != nil {
+		h.logger.Error("Failed to add comment", zap.Error(err), zap.Int("id", id), zap.Int("version_id", versionID))
+		apierror.Respond(c, err, "Failed to add comment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": comment})
+}
+
+// DeleteComment handles a user deleting their comment on a strategy
+// DELETE /api/v1/strategies/{id}/comments/{commentId}
+//
+// @Summary Delete a comment on a strategy
+// @Tags strategies
+// @Param id path integer true "ID"
+// @Param commentId path integer true "comment ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/comments/{commentId} [delete]
+func (h *CollaborationHandler) DeleteComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	commentID, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.collaborationService.DeleteComment(c.Request.Context(), id, commentID, userID.(int)); err != nil {
+		apierror.Respond(c, err, "Failed to delete comment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseVersionParams parses the strategy and version IDs of a strategy version route,
+// sending the error response if either is invalid
+func parseVersionParams(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return 0, 0, false
+	}
+
+	versionID, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid version ID")
+		return 0, 0, false
+	}
+
+	return id, versionID, true
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CouponHandler handles listing coupon HTTP requests
+type CouponHandler struct {
+	couponService *service.CouponService
+	logger        *zap.Logger
+}
+
+// NewCouponHandler creates a new coupon handler
+func NewCouponHandler(couponService *service.CouponService, logger *zap.Logger) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+		logger:        logger,
+	}
+}
+
+// GetCoupons handles retrieving the coupons of a listing
+// GET /api/v1/marketplace/{id}/coupons
+//
+// @Summary Retrieve the coupons of a listing
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.Coupon}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/coupons [get]
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	coupons, err := h.couponService.GetCoupons(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get coupons", zap.Error(err), zap.Int("listing_id", id))
+		h.sendCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": coupons})
+}
+
+// CreateCoupon handles creating a coupon for a listing
+// POST /api/v1/marketplace/{id}/coupons
+//
+// @Summary Create a coupon for a listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.CouponCreate true "Request body"
+// @Success 201 {object} object{data=model.Coupon}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/coupons [post]
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	var request model.CouponCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(c.Request.Context(), id, &request, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to create coupon", zap.Error(err), zap.Int("listing_id", id))
+		h.sendCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": coupon})
+}
+
+// UpdateCoupon handles updating a coupon of a listing
+// PUT /api/v1/marketplace/{id}/coupons/{couponId}
+//
+// @Summary Update a coupon of a listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param couponId path integer true "coupon ID"
+// @Param request body model.CouponUpdate true "Request body"
+// @Success 200 {object} object{data=model.Coupon}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/coupons/{couponId} [put]
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	couponID, err := strconv.Atoi(c.Param("couponId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	var request model.CouponUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(c.Request.Context(), id, couponID, &request, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to update coupon", zap.Error(err), zap.Int("listing_id", id), zap.Int("coupon_id", couponID))
+		h.sendCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": coupon})
+}
+
+// DeleteCoupon handles deleting a coupon of a listing
+// DELETE /api/v1/marketplace/{id}/coupons/{couponId}
+//
+// @Summary Delete a coupon of a listing
+// @Tags marketplace
+// @Param id path integer true "ID"
+// @Param couponId path integer true "coupon ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/coupons/{couponId} [delete]
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	couponID, err := strconv.Atoi(c.Param("couponId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	err = h.couponService.DeleteCoupon(c.Request.Context(), id, couponID, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to delete coupon", zap.Error(err), zap.Int("listing_id", id), zap.Int("coupon_id", couponID))
+		h.sendCouponError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// sendCouponError sends the response of a coupon service error. Errors the service doesn't
+// type are validation errors.
+func (h *CouponHandler) sendCouponError(c *gin.Context, err error) {
+	apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+}
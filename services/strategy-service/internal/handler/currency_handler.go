@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CurrencyHandler handles currency catalog HTTP requests
+type CurrencyHandler struct {
+	currencyService *service.CurrencyService
+	logger          *zap.Logger
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currencyService *service.CurrencyService, logger *zap.Logger) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+		logger:          logger,
+	}
+}
+
+// GetCurrencies handles listing the supported currencies with their current rate per US dollar
+// GET /api/v1/marketplace/currencies
+//
+// @Summary List the supported currencies with their current rate per US dollar
+// @Tags marketplace
+// @Produce json
+// @Success 200 {object} object{data=[]model.Currency}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/currencies [get]
+func (h *CurrencyHandler) GetCurrencies(c *gin.Context) {
+	currencies, err := h.currencyService.GetCurrencies(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get currencies", zap.Error(err))
+		apierror.Respond(c, err, "Failed to retrieve currencies")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": currencies})
+}
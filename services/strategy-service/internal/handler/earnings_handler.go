@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EarningsHandler handles seller analytics HTTP requests
+type EarningsHandler struct {
+	earningsService *service.EarningsService
+	logger          *zap.Logger
+}
+
+// NewEarningsHandler creates a new earnings handler
+func NewEarningsHandler(earningsService *service.EarningsService, logger *zap.Logger) *EarningsHandler {
+	return &EarningsHandler{
+		earningsService: earningsService,
+		logger:          logger,
+	}
+}
+
+// GetMySales handles retrieving the seller earnings dashboard for the current user
+// GET /api/v1/marketplace/my-sales
+//
+// @Summary Retrieve the seller earnings dashboard for the current user
+// @Tags marketplace
+// @Produce json
+// @Param interval query string false "interval"
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} object{data=model.SellerSales}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/my-sales [get]
+func (h *EarningsHandler) GetMySales(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "interval must be one of day, week, month")
+		return
+	}
+
+	startDate, endDate, ok := parseEarningsRange(c, time.Now().UTC().AddDate(0, 0, -90))
+	if !ok {
+		return
+	}
+
+	sales, err := h.earningsService.GetSales(c.Request.Context(), userID.(int), interval, startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to get seller sales", zap.Error(err), zap.Int("userID", userID.(int)))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sales})
+}
+
+// GetMyPayouts handles retrieving monthly payout summaries for the current user
+// GET /api/v1/marketplace/my-payouts
+//
+// @Summary Retrieve monthly payout summaries for the current user
+// @Tags marketplace
+// @Produce json
+// @Param start_date query string false "start date"
+// @Param end_date query string false "end date"
+// @Success 200 {object} object{data=model.SellerPayouts}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/my-payouts [get]
+func (h *EarningsHandler) GetMyPayouts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	startDate, endDate, ok := parseEarningsRange(c, time.Now().UTC().AddDate(-1, 0, 0))
+	if !ok {
+		return
+	}
+
+	payouts, err := h.earningsService.GetPayouts(c.Request.Context(), userID.(int), startDate, endDate)
+	if err != nil {
+		h.logger.Error("Failed to get seller payouts", zap.Error(err), zap.Int("userID", userID.(int)))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": payouts})
+}
+
+// parseEarningsRange parses the start_date and end_date query parameters (YYYY-MM-DD).
+// end_date is inclusive and defaults to today; start_date defaults to defaultStart.
+// Writes an error response and returns false if either date is malformed.
+func parseEarningsRange(c *gin.Context, defaultStart time.Time) (time.Time, time.Time, bool) {
+	startDate := defaultStart.Truncate(24 * time.Hour)
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if startStr := c.Query("start_date"); startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid start_date format, expected YYYY-MM-DD")
+			return time.Time{}, time.Time{}, false
+		}
+		startDate = parsed
+	}
+
+	if endStr := c.Query("end_date"); endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid end_date format, expected YYYY-MM-DD")
+			return time.Time{}, time.Time{}, false
+		}
+		endDate = parsed
+	}
+
+	// Make end_date inclusive of the whole day
+	return startDate, endDate.AddDate(0, 0, 1), true
+}
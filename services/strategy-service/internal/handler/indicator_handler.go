@@ -6,10 +6,13 @@ import (
 	"strconv"
 	"strings"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
 	"services/strategy-service/internal/client"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/service"
 	"services/strategy-service/internal/utils"
+	"services/strategy-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -41,8 +44,27 @@ func (h *IndicatorHandler) checkIsAdmin(c *gin.Context) bool {
 	return userRole.(string) == "admin"
 }
 
+// currentUserID returns the authenticated user's ID, or 0 for anonymous requests
+func (h *IndicatorHandler) currentUserID(c *gin.Context) int {
+	return c.GetInt("userID")
+}
+
 // GetAllIndicators handles retrieving all indicators with filtering options
 // GET /api/v1/indicators
+//
+// @Summary Retrieve all indicators with filtering options
+// @Tags indicators
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param categories query string false "categories"
+// @Param active query string false "active"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} object{data=[]model.TechnicalIndicator,meta=pagination.Meta}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/indicators [get]
 func (h *IndicatorHandler) GetAllIndicators(c *gin.Context) {
 	// Parse search parameter
 	searchTerm := c.Query("search")
@@ -60,15 +82,11 @@ func (h *IndicatorHandler) GetAllIndicators(c *gin.Context) {
 		active = &activeBool
 	}
 
-	// Parse pagination parameters using the utility function
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	// Parse pagination parameters
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "name")
-	sortDirection := strings.ToUpper(c.DefaultQuery("sort_direction", "ASC"))
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "ASC"
-	}
+	order := pagination.ParseSort(c, "name", "ASC")
 
 	// Check if user is admin
 	isAdmin := h.checkIsAdmin(c)
@@ -78,25 +96,34 @@ func (h *IndicatorHandler) GetAllIndicators(c *gin.Context) {
 		searchTerm,
 		categories,
 		active,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 		isAdmin,
+		h.currentUserID(c),
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get indicators", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch indicators")
+		apierror.Respond(c, err, "Failed to fetch indicators")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, indicators, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, indicators, total, params.Page, params.Limit)
 }
 
 // GetIndicatorCategories handles retrieving indicator categories
 // GET /api/v1/indicators/categories
+//
+// @Summary Retrieve indicator categories
+// @Tags indicators
+// @Produce json
+// @Param t query string false "t"
+// @Success 200 {object} object{categories=[]service.CategoryInfo}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/indicators/categories [get]
 func (h *IndicatorHandler) GetIndicatorCategories(c *gin.Context) {
 	// Log the request with a distinctive message
 	h.logger.Info("CATEGORIES ENDPOINT EXPLICITLY CALLED",
@@ -110,7 +137,7 @@ func (h *IndicatorHandler) GetIndicatorCategories(c *gin.Context) {
 	categories, err := h.indicatorService.GetIndicatorCategories(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get indicator categories", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch indicator categories")
+		apierror.Respond(c, err, "Failed to fetch indicator categories")
 		return
 	}
 
@@ -127,6 +154,15 @@ func (h *IndicatorHandler) GetIndicatorCategories(c *gin.Context) {
 
 // GetIndicator handles retrieving a specific indicator
 // GET /api/v1/indicators/{id}
+//
+// @Summary Retrieve a specific indicator
+// @Tags indicators
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.TechnicalIndicator}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Router /api/v1/indicators/{id} [get]
 func (h *IndicatorHandler) GetIndicator(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -138,10 +174,10 @@ func (h *IndicatorHandler) GetIndicator(c *gin.Context) {
 	// Check if user is admin
 	isAdmin := h.checkIsAdmin(c)
 
-	indicator, err := h.indicatorService.GetIndicator(c.Request.Context(), id, isAdmin)
+	indicator, err := h.indicatorService.GetIndicator(c.Request.Context(), id, isAdmin, h.currentUserID(c))
 	if err != nil {
 		h.logger.Error("Failed to get indicator", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusNotFound)
 		return
 	}
 
@@ -150,6 +186,18 @@ func (h *IndicatorHandler) GetIndicator(c *gin.Context) {
 
 // CreateIndicator handles creating a new indicator
 // POST /api/v1/indicators
+//
+// @Summary Create a new indicator
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 201 {object} object{data=model.TechnicalIndicator}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators [post]
 func (h *IndicatorHandler) CreateIndicator(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -186,9 +234,7 @@ func (h *IndicatorHandler) CreateIndicator(c *gin.Context) {
 		} `json:"parameters"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		h.logger.Error("Failed to bind request", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -240,7 +286,7 @@ func (h *IndicatorHandler) CreateIndicator(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to create indicator", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create indicator: "+err.Error())
+		apierror.Respond(c, err, "Failed to create indicator: "+err.Error())
 		return
 	}
 
@@ -251,8 +297,89 @@ func (h *IndicatorHandler) CreateIndicator(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": indicator})
 }
 
+// CreateCustomIndicator handles registering a user-defined indicator
+// POST /api/v1/indicators/custom
+//
+// @Summary Register a user-defined indicator
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 201 {object} object{data=model.TechnicalIndicator}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/custom [post]
+func (h *IndicatorHandler) CreateCustomIndicator(c *gin.Context) {
+	userID := h.currentUserID(c)
+
+	var request struct {
+		Name        string   `json:"name" binding:"required,max=50"`
+		Description string   `json:"description"`
+		Category    string   `json:"category"`
+		Formula     string   `json:"formula" binding:"required"`
+		MinValue    *float64 `json:"min_value"`
+		MaxValue    *float64 `json:"max_value"`
+		Visibility  string   `json:"visibility"`
+		Parameters  []struct {
+			Name         string   `json:"name" binding:"required"`
+			Type         string   `json:"type" binding:"required"`
+			IsRequired   bool     `json:"is_required"`
+			MinValue     *float64 `json:"min_value"`
+			MaxValue     *float64 `json:"max_value"`
+			DefaultValue string   `json:"default_value"`
+			Description  string   `json:"description"`
+		} `json:"parameters"`
+	}
+
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	create := &model.CustomIndicatorCreate{
+		Name:        request.Name,
+		Description: request.Description,
+		Category:    request.Category,
+		Formula:     request.Formula,
+		MinValue:    request.MinValue,
+		MaxValue:    request.MaxValue,
+		Visibility:  request.Visibility,
+	}
+	for _, param := range request.Parameters {
+		create.Parameters = append(create.Parameters, model.IndicatorParameterCreate{
+			ParameterName: param.Name,
+			ParameterType: param.Type,
+			IsRequired:    param.IsRequired,
+			MinValue:      param.MinValue,
+			MaxValue:      param.MaxValue,
+			DefaultValue:  param.DefaultValue,
+			Description:   param.Description,
+		})
+	}
+
+	indicator, err := h.indicatorService.CreateCustomIndicator(c.Request.Context(), userID, create)
+	if err != nil {
+		h.logger.Error("Failed to create custom indicator", zap.Error(err), zap.Int("userID", userID))
+		apierror.Respond(c, err, "Failed to create indicator")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": indicator})
+}
+
 // DeleteIndicator handles deleting an indicator
 // DELETE /api/v1/indicators/{id}
+//
+// @Summary Delete an indicator
+// @Tags indicators
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id} [delete]
 func (h *IndicatorHandler) DeleteIndicator(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -272,7 +399,7 @@ func (h *IndicatorHandler) DeleteIndicator(c *gin.Context) {
 	err = h.indicatorService.DeleteIndicator(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete indicator", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -281,6 +408,18 @@ func (h *IndicatorHandler) DeleteIndicator(c *gin.Context) {
 
 // UpdateIndicator handles updating an indicator
 // PUT /api/v1/indicators/{id}
+//
+// @Summary Update an indicator
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 200 {object} object{data=model.TechnicalIndicator}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id} [put]
 func (h *IndicatorHandler) UpdateIndicator(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -307,8 +446,7 @@ func (h *IndicatorHandler) UpdateIndicator(c *gin.Context) {
 		IsActive    *bool    `json:"is_active"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -330,7 +468,7 @@ func (h *IndicatorHandler) UpdateIndicator(c *gin.Context) {
 			zap.Bool("is_active", indicator.IsActive))
 	} else {
 		// Fetch current indicator to get current active status
-		currentIndicator, err := h.indicatorService.GetIndicator(c.Request.Context(), id, true)
+		currentIndicator, err := h.indicatorService.GetIndicator(c.Request.Context(), id, true, 0)
 		if err == nil && currentIndicator != nil {
 			indicator.IsActive = currentIndicator.IsActive
 		} else {
@@ -342,7 +480,7 @@ func (h *IndicatorHandler) UpdateIndicator(c *gin.Context) {
 	updatedIndicator, err := h.indicatorService.UpdateIndicator(c.Request.Context(), id, indicator)
 	if err != nil {
 		h.logger.Error("Failed to update indicator", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -351,6 +489,18 @@ func (h *IndicatorHandler) UpdateIndicator(c *gin.Context) {
 
 // AddIndicatorParameter handles adding a parameter to an indicator
 // POST /api/v1/indicators/{id}/parameters
+//
+// @Summary Add a parameter to an indicator
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body handler.ParameterRequest true "Request body"
+// @Success 201 {object} object{data=model.IndicatorParameter}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/parameters [post]
 func (h *IndicatorHandler) AddIndicatorParameter(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -366,8 +516,7 @@ func (h *IndicatorHandler) AddIndicatorParameter(c *gin.Context) {
 	}
 
 	var request ParameterRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -400,7 +549,7 @@ func (h *IndicatorHandler) AddIndicatorParameter(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to add parameter", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -420,6 +569,18 @@ type ParameterRequest struct {
 
 // AddParameterEnumValue handles adding an enum value to a parameter
 // POST /api/v1/parameters/{id}/enum-values
+//
+// @Summary Add an enum value to a parameter
+// @Tags parameters
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 201 {object} object{data=model.ParameterEnumValue}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/parameters/{id}/enum-values [post]
 func (h *IndicatorHandler) AddParameterEnumValue(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -439,8 +600,7 @@ func (h *IndicatorHandler) AddParameterEnumValue(c *gin.Context) {
 		DisplayName string `json:"display_name"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -453,7 +613,7 @@ func (h *IndicatorHandler) AddParameterEnumValue(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to add enum value", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -462,6 +622,15 @@ func (h *IndicatorHandler) AddParameterEnumValue(c *gin.Context) {
 
 // DeleteParameter handles deleting a parameter
 // DELETE /api/v1/parameters/{id}
+//
+// @Summary Delete a parameter
+// @Tags parameters
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/parameters/{id} [delete]
 func (h *IndicatorHandler) DeleteIndicatorParameter(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -481,7 +650,7 @@ func (h *IndicatorHandler) DeleteIndicatorParameter(c *gin.Context) {
 	err = h.indicatorService.DeleteIndicatorParameter(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete parameter", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -490,6 +659,18 @@ func (h *IndicatorHandler) DeleteIndicatorParameter(c *gin.Context) {
 
 // UpdateIndicatorParameter handles updating a parameter
 // PUT /api/v1/parameters/{id}
+//
+// @Summary Update a parameter
+// @Tags parameters
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 200 {object} object{data=model.IndicatorParameter}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/parameters/{id} [put]
 func (h *IndicatorHandler) UpdateIndicatorParameter(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -517,8 +698,7 @@ func (h *IndicatorHandler) UpdateIndicatorParameter(c *gin.Context) {
 		IsPublic      bool     `json:"is_public"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -539,7 +719,7 @@ func (h *IndicatorHandler) UpdateIndicatorParameter(c *gin.Context) {
 	updatedParameter, err := h.indicatorService.UpdateIndicatorParameter(c.Request.Context(), id, parameter)
 	if err != nil {
 		h.logger.Error("Failed to update parameter", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -548,6 +728,15 @@ func (h *IndicatorHandler) UpdateIndicatorParameter(c *gin.Context) {
 
 // DeleteParameterEnumValue handles deleting an enum value
 // DELETE /api/v1/enum-values/{id}
+//
+// @Summary Delete an enum value
+// @Tags enum-values
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/enum-values/{id} [delete]
 func (h *IndicatorHandler) DeleteIndicatorParameterEnumValue(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -567,7 +756,7 @@ func (h *IndicatorHandler) DeleteIndicatorParameterEnumValue(c *gin.Context) {
 	err = h.indicatorService.DeleteIndicatorParameterEnumValue(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete enum value", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -576,6 +765,18 @@ func (h *IndicatorHandler) DeleteIndicatorParameterEnumValue(c *gin.Context) {
 
 // UpdateParameterEnumValue handles updating an enum value
 // PUT /api/v1/enum-values/{id}
+//
+// @Summary Update an enum value
+// @Tags enum-values
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 200 {object} object{data=model.ParameterEnumValue}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/enum-values/{id} [put]
 func (h *IndicatorHandler) UpdateIndicatorParameterEnumValue(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -597,8 +798,7 @@ func (h *IndicatorHandler) UpdateIndicatorParameterEnumValue(c *gin.Context) {
 		DisplayName string `json:"display_name"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -612,15 +812,192 @@ func (h *IndicatorHandler) UpdateIndicatorParameterEnumValue(c *gin.Context) {
 	updatedEnumValue, err := h.indicatorService.UpdateIndicatorParameterEnumValue(c.Request.Context(), id, enumValue)
 	if err != nil {
 		h.logger.Error("Failed to update enum value", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": updatedEnumValue})
 }
 
-// SyncIndicators syncs indicators from the backtesting service
+// ExportIndicators handles exporting the platform indicator catalog as JSON
+// GET /api/v1/indicators/export
+//
+// @Summary Export the platform indicator catalog as JSON
+// @Tags indicators
+// @Produce json
+// @Success 200 {object} model.IndicatorCatalog
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/export [get]
+func (h *IndicatorHandler) ExportIndicators(c *gin.Context) {
+	catalog, err := h.indicatorService.ExportIndicatorCatalog(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to export indicators", zap.Error(err))
+		apierror.Respond(c, err, "Failed to export indicators")
+		return
+	}
+
+	filename := fmt.Sprintf("indicators-%s.json", catalog.ExportedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.JSON(http.StatusOK, catalog)
+}
+
+// ImportIndicators handles importing an indicator catalog exported from another environment.
+// The conflict query parameter (skip, overwrite or merge; default skip) decides what happens
+// to indicators that already exist.
+// POST /api/v1/indicators/import
+//
+// @Summary Import an indicator catalog exported from another environment
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param conflict query string false "conflict"
+// @Param request body model.IndicatorCatalog true "Request body"
+// @Success 200 {object} object{data=model.IndicatorImportReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/import [post]
+func (h *IndicatorHandler) ImportIndicators(c *gin.Context) {
+	var catalog model.IndicatorCatalog
+	if !validation.BindJSON(c, &catalog) {
+		return
+	}
+
+	mode := strings.ToLower(c.DefaultQuery("conflict", model.IndicatorImportSkip))
+
+	report, err := h.indicatorService.ImportIndicatorCatalog(c.Request.Context(), &catalog, mode)
+	if err != nil {
+		h.logger.Error("Failed to import indicators", zap.Error(err))
+		if strings.HasPrefix(err.Error(), "invalid") {
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
+		apierror.RespondWithStatus(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// DeprecateIndicator handles deprecating a platform indicator, or changing its deprecation.
+// Strategies using it keep working but are warned when they are linted, saved or
+// backtested.
+// POST /api/v1/indicators/{id}/deprecate
+//
+// @Summary Deprecate an indicator in favor of a replacement
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.IndicatorDeprecate true "Request body"
+// @Success 200 {object} object{data=model.TechnicalIndicator}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/deprecate [post]
+func (h *IndicatorHandler) DeprecateIndicator(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid indicator ID")
+		return
+	}
+
+	var request model.IndicatorDeprecate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	indicator, err := h.indicatorService.DeprecateIndicator(c.Request.Context(), id, &request, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to deprecate indicator", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to deprecate indicator")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": indicator})
+}
+
+// UndeprecateIndicator handles withdrawing the deprecation of an indicator
+// DELETE /api/v1/indicators/{id}/deprecate
+//
+// @Summary Withdraw the deprecation of an indicator
+// @Tags indicators
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/deprecate [delete]
+func (h *IndicatorHandler) UndeprecateIndicator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid indicator ID")
+		return
+	}
+
+	if err := h.indicatorService.UndeprecateIndicator(c.Request.Context(), id); err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to undeprecate indicator", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to undeprecate indicator")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeprecatedIndicatorUsage handles the admin report of strategies whose current
+// version still uses a deprecated indicator, soonest sunset first
+// GET /api/v1/admin/indicators/deprecated-usage
+//
+// @Summary List strategies still using deprecated indicators
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=model.DeprecatedIndicatorReport}
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/indicators/deprecated-usage [get]
+func (h *IndicatorHandler) GetDeprecatedIndicatorUsage(c *gin.Context) {
+	report, err := h.indicatorService.GetDeprecatedIndicatorReport(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get deprecated indicator usage", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get deprecated indicator usage")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// SyncIndicators syncs indicators from the backtesting service and returns a report of
+// what was created, updated and deactivated. With dry_run=true nothing is written.
 // POST /api/v1/indicators/sync
+//
+// @Summary Sync indicators from the backtesting service
+// @Tags indicators
+// @Produce json
+// @Param dry_run query integer false "dry run"
+// @Success 200 {object} object{status=string,message=string,indicators_synced=integer,report=model.IndicatorSyncReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/sync [post]
 func (h *IndicatorHandler) SyncIndicators(c *gin.Context) {
 	// Check if user has admin role
 	if !h.checkIsAdmin(c) {
@@ -628,22 +1005,34 @@ func (h *IndicatorHandler) SyncIndicators(c *gin.Context) {
 		return
 	}
 
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid dry_run value")
+		return
+	}
+
 	// Get user ID from context
 	userID, _ := c.Get("userID")
-	h.logger.Info("Syncing indicators", zap.Int("userID", userID.(int)))
+	h.logger.Info("Syncing indicators", zap.Int("userID", userID.(int)), zap.Bool("dryRun", dryRun))
 
 	// Sync indicators
-	count, err := h.indicatorService.SyncIndicatorsFromBacktestingService(c.Request.Context())
+	report, err := h.indicatorService.SyncIndicatorsFromBacktestingService(c.Request.Context(), dryRun)
 	if err != nil {
 		h.logger.Error("Failed to sync indicators", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to sync indicators: "+err.Error())
+		apierror.Respond(c, err, "Failed to sync indicators: "+err.Error())
 		return
 	}
 
-	h.logger.Info("Successfully synced indicators", zap.Int("count", count))
+	message := fmt.Sprintf("Successfully synced %d indicators", report.Synced)
+	if dryRun {
+		message = fmt.Sprintf("Dry run: %d indicators would be synced", report.Synced)
+	}
+
+	h.logger.Info("Successfully synced indicators", zap.Int("count", report.Synced), zap.Bool("dryRun", dryRun))
 	c.JSON(http.StatusOK, gin.H{
 		"status":            "success",
-		"message":           fmt.Sprintf("Successfully synced %d indicators", count),
-		"indicators_synced": count,
+		"message":           message,
+		"indicators_synced": report.Synced,
+		"report":            report,
 	})
 }
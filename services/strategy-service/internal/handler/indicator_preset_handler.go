@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/middleware"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+	"services/strategy-service/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// IndicatorPresetHandler handles indicator preset HTTP requests
+type IndicatorPresetHandler struct {
+	presetService *service.IndicatorPresetService
+	logger        *zap.Logger
+}
+
+// NewIndicatorPresetHandler creates a new indicator preset handler
+func NewIndicatorPresetHandler(presetService *service.IndicatorPresetService, logger *zap.Logger) *IndicatorPresetHandler {
+	return &IndicatorPresetHandler{
+		presetService: presetService,
+		logger:        logger,
+	}
+}
+
+// isAdmin reports whether the current user has the admin role, and so sees every
+// indicator parameter
+func (h *IndicatorPresetHandler) isAdmin(c *gin.Context) bool {
+	return c.GetString("userRole") == "admin"
+}
+
+// canManageGlobalPresets reports whether the current user curates indicators, and so
+// manages global presets
+func (h *IndicatorPresetHandler) canManageGlobalPresets(c *gin.Context) bool {
+	value, _ := c.Get("userPermissions")
+	granted, _ := value.([]string)
+	return middleware.HasPermission(granted, "indicators:write")
+}
+
+// parseIDs parses the indicator ID, and the preset ID when withPreset is set, from the URL
+func (h *IndicatorPresetHandler) parseIDs(c *gin.Context, withPreset bool) (int, int, bool) {
+	indicatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid indicator ID")
+		return 0, 0, false
+	}
+	if !withPreset {
+		return indicatorID, 0, true
+	}
+
+	presetID, err := strconv.Atoi(c.Param("presetId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid preset ID")
+		return 0, 0, false
+	}
+	return indicatorID, presetID, true
+}
+
+// GetPresets handles retrieving the parameter presets of an indicator
+// GET /api/v1/indicators/{id}/presets
+//
+// @Summary Retrieve the parameter presets of an indicator
+// @Description Returns the global presets and, for signed-in users, their own.
+// @Tags indicators
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.IndicatorPreset}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/indicators/{id}/presets [get]
+func (h *IndicatorPresetHandler) GetPresets(c *gin.Context) {
+	indicatorID, _, ok := h.parseIDs(c, false)
+	if !ok {
+		return
+	}
+
+	presets, err := h.presetService.GetPresets(c.Request.Context(), indicatorID, h.isAdmin(c), c.GetInt("userID"))
+	if err != nil {
+		h.logger.Error("Failed to get indicator presets", zap.Error(err), zap.Int("indicatorID", indicatorID))
+		apierror.Respond(c, err, "Failed to fetch indicator presets")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": presets})
+}
+
+// CreatePreset handles creating a parameter preset of an indicator
+// POST /api/v1/indicators/{id}/presets
+//
+// @Summary Create a parameter preset of an indicator
+// @Description Settings map parameter names to values within the parameters' bounds. Global presets require the indicators:write permission.
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.IndicatorPresetInput true "Request body"
+// @Success 201 {object} object{data=model.IndicatorPreset}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/presets [post]
+func (h *IndicatorPresetHandler) CreatePreset(c *gin.Context) {
+	indicatorID, _, ok := h.parseIDs(c, false)
+	if !ok {
+		return
+	}
+
+	var request model.IndicatorPresetInput
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	if request.Global && !h.canManageGlobalPresets(c) {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Creating global presets requires the indicators:write permission")
+		return
+	}
+
+	preset, err := h.presetService.CreatePreset(c.Request.Context(), indicatorID, &request, h.isAdmin(c), c.GetInt("userID"))
+	if err != nil {
+		h.logger.Error("Failed to create indicator preset", zap.Error(err), zap.Int("indicatorID", indicatorID))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": preset})
+}
+
+// UpdatePreset handles replacing a parameter preset of an indicator
+// PUT /api/v1/indicators/{id}/presets/{presetId}
+//
+// @Summary Update a parameter preset of an indicator
+// @Description Users update their own presets; global presets require the indicators:write permission. Strategies referencing the preset use the new settings from their next backtest.
+// @Tags indicators
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param presetId path integer true "Preset ID"
+// @Param request body model.IndicatorPresetInput true "Request body"
+// @Success 200 {object} object{data=model.IndicatorPreset}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/presets/{presetId} [put]
+func (h *IndicatorPresetHandler) UpdatePreset(c *gin.Context) {
+	indicatorID, presetID, ok := h.parseIDs(c, true)
+	if !ok {
+		return
+	}
+
+	var request model.IndicatorPresetInput
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	preset, err := h.presetService.UpdatePreset(
+		c.Request.Context(),
+		indicatorID,
+		presetID,
+		&request,
+		h.isAdmin(c),
+		h.canManageGlobalPresets(c),
+		c.GetInt("userID"),
+	)
+	if err != nil {
+		h.logger.Error("Failed to update indicator preset", zap.Error(err), zap.Int("presetID", presetID))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preset})
+}
+
+// DeletePreset handles deleting a parameter preset of an indicator
+// DELETE /api/v1/indicators/{id}/presets/{presetId}
+//
+// @Summary Delete a parameter preset of an indicator
+// @Description Users delete their own presets; global presets require the indicators:write permission. Strategies still referencing the preset can't be backtested until the reference is removed.
+// @Tags indicators
+// @Param id path integer true "ID"
+// @Param presetId path integer true "Preset ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/indicators/{id}/presets/{presetId} [delete]
+func (h *IndicatorPresetHandler) DeletePreset(c *gin.Context) {
+	indicatorID, presetID, ok := h.parseIDs(c, true)
+	if !ok {
+		return
+	}
+
+	err := h.presetService.DeletePreset(c.Request.Context(), indicatorID, presetID, h.canManageGlobalPresets(c), c.GetInt("userID"))
+	if err != nil {
+		h.logger.Error("Failed to delete indicator preset", zap.Error(err), zap.Int("presetID", presetID))
+		apierror.Respond(c, err, "Failed to delete indicator preset")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
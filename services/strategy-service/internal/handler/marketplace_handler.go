@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/service"
 	"services/strategy-service/internal/utils"
@@ -15,24 +19,197 @@ import (
 
 // MarketplaceHandler handles marketplace-related HTTP requests
 type MarketplaceHandler struct {
-	marketplaceService *service.MarketplaceService
-	logger             *zap.Logger
+	marketplaceService    *service.MarketplaceService
+	recommendationService *service.RecommendationService
+	currencyService       *service.CurrencyService
+	logger                *zap.Logger
 }
 
 // NewMarketplaceHandler creates a new marketplace handler
-func NewMarketplaceHandler(marketplaceService *service.MarketplaceService, logger *zap.Logger) *MarketplaceHandler {
+func NewMarketplaceHandler(
+	marketplaceService *service.MarketplaceService,
+	recommendationService *service.RecommendationService,
+	currencyService *service.CurrencyService,
+	logger *zap.Logger,
+) *MarketplaceHandler {
 	return &MarketplaceHandler{
-		marketplaceService: marketplaceService,
-		logger:             logger,
+		marketplaceService:    marketplaceService,
+		recommendationService: recommendationService,
+		currencyService:       currencyService,
+		logger:                logger,
 	}
 }
 
-// GetAllListings handles listing marketplace listings
+// GetAllListings handles listing marketplace listings.
+// Passing ?cursor= (empty for the first page) switches from page numbers to cursors.
 // GET /api/v1/marketplace
+//
+// @Summary List marketplace listings
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param currency query string false "currency"
+// @Param search query string false "search"
+// @Param min_price query integer false "min price"
+// @Param max_price query integer false "max price"
+// @Param is_free query string false "is free"
+// @Param tags query string false "tags"
+// @Param min_rating query integer false "min rating"
+// @Param seller_id query integer false "seller id"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Param cursor query string false "cursor"
+// @Param view query integer false "ID of a saved view whose filters and sort order apply"
+// @Success 200 {object} object{data=[]model.MarketplaceItem,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Router /api/v1/marketplace [get]
 func (h *MarketplaceHandler) GetAllListings(c *gin.Context) {
-	// Parse pagination parameters using the utility function
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	// Parse pagination parameters
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
+	currency, ok := h.parseDisplayCurrency(c)
+	if !ok {
+		return
+	}
+
+	searchTerm, minPrice, maxPrice, isFree, tags, minRating := parseListingFilters(c)
+
+	// Parse seller_id filter, to browse the catalog of a single seller
+	var sellerID *int
+	if sellerIDStr := c.Query("seller_id"); sellerIDStr != "" {
+		sellerIDVal, err := strconv.Atoi(sellerIDStr)
+		if err != nil || sellerIDVal < 1 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid seller ID")
+			return
+		}
+		sellerID = &sellerIDVal
+	}
+
+	// Parse sort_by parameter; searches are ranked by relevance unless told otherwise
+	defaultSort := "popularity"
+	if searchTerm != "" {
+		defaultSort = "relevance"
+	}
+	order := pagination.ParseSort(c, defaultSort, "DESC")
+	order.By = service.ListingSortFields.Normalize(order.By, defaultSort)
+
+	if cursor, ok := pagination.ParseCursor(c); ok {
+		var after *model.MarketplaceCursor
+		if cursor != "" {
+			after = &model.MarketplaceCursor{}
+			if err := pagination.DecodeCursor(cursor, after); err != nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid cursor")
+				return
+			}
+		}
+
+		listings, next, err := h.marketplaceService.GetListingsAfter(
+			c.Request.Context(),
+			searchTerm,
+			minPrice,
+			maxPrice,
+			isFree,
+			tags,
+			minRating,
+			sellerID,
+			order.By,
+			order.Direction,
+			after,
+			params.Limit,
+		)
+		if err != nil {
+			if errors.Is(err, errcode.ErrInvalidCursor) {
+				apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+				return
+			}
+			h.logger.Error("Failed to get marketplace listings", zap.Error(err))
+			apierror.Respond(c, err, "Failed to fetch listings")
+			return
+		}
+
+		if !h.convertListingPrices(c, listings, currency) {
+			return
+		}
+
+		nextCursor := ""
+		if next != nil {
+			nextCursor = pagination.EncodeCursor(next)
+		}
+
+		pagination.SendCursor(c, http.StatusOK, listings, nextCursor, params.Limit)
+		return
+	}
+
+	listings, total, err := h.marketplaceService.GetAllListings(
+		c.Request.Context(),
+		searchTerm,
+		minPrice,
+		maxPrice,
+		isFree,
+		tags,
+		minRating,
+		sellerID,
+		order.By,
+		order.Direction,
+		params.Page,
+		params.Limit,
+	)
+
+	if err != nil {
+		h.logger.Error("Failed to get marketplace listings", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch listings")
+		return
+	}
+
+	if !h.convertListingPrices(c, listings, currency) {
+		return
+	}
+
+	// Use standardized pagination response
+	pagination.Send(c, http.StatusOK, listings, total, params.Page, params.Limit)
+}
+
+// GetFacets handles retrieving filter counts for the current search and filters
+// GET /api/v1/marketplace/facets
+//
+// @Summary Retrieve filter counts for the current search and filters
+// @Tags marketplace
+// @Produce json
+// @Param search query string false "search"
+// @Param min_price query integer false "min price"
+// @Param max_price query integer false "max price"
+// @Param is_free query string false "is free"
+// @Param tags query string false "tags"
+// @Param min_rating query integer false "min rating"
+// @Success 200 {object} object{data=model.MarketplaceFacets}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/facets [get]
+func (h *MarketplaceHandler) GetFacets(c *gin.Context) {
+	searchTerm, minPrice, maxPrice, isFree, tags, minRating := parseListingFilters(c)
+
+	facets, err := h.marketplaceService.GetFacets(
+		c.Request.Context(),
+		searchTerm,
+		minPrice,
+		maxPrice,
+		isFree,
+		tags,
+		minRating,
+	)
+	if err != nil {
+		h.logger.Error("Failed to get marketplace facets", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch facets")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": facets})
+}
+
+// parseListingFilters parses the search and filter query parameters shared by listings and facets
+func parseListingFilters(c *gin.Context) (string, *float64, *float64, *bool, []int, *float64) {
 	// Parse search term
 	searchTerm := c.Query("search")
 
@@ -79,53 +256,61 @@ func (h *MarketplaceHandler) GetAllListings(c *gin.Context) {
 		}
 	}
 
-	// Parse sort_by parameter
-	sortBy := c.DefaultQuery("sort_by", "popularity")
-	validSortOptions := map[string]bool{
-		"popularity": true,
-		"rating":     true,
-		"price":      true,
-		"newest":     true,
-		"name":       true,
-	}
+	return searchTerm, minPrice, maxPrice, isFree, tags, minRating
+}
 
-	if !validSortOptions[sortBy] {
-		sortBy = "popularity"
+// parseDisplayCurrency parses the optional ?currency= parameter listing prices are shown in.
+// It sends an error response and returns false if the currency isn't supported.
+func (h *MarketplaceHandler) parseDisplayCurrency(c *gin.Context) (string, bool) {
+	code := c.Query("currency")
+	if code == "" {
+		return "", true
 	}
 
-	// Parse sort_direction parameter
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "DESC"
+	currency, err := h.currencyService.NormalizeCurrency(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, errcode.ErrUnsupportedCurrency) {
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return "", false
+		}
+		h.logger.Error("Failed to load currencies", zap.Error(err))
+		apierror.Respond(c, err, "Failed to load currencies")
+		return "", false
 	}
 
-	listings, total, err := h.marketplaceService.GetAllListings(
-		c.Request.Context(),
-		searchTerm,
-		minPrice,
-		maxPrice,
-		isFree,
-		tags,
-		minRating,
-		sortBy,
-		sortDirection,
-		params.Page,
-		params.Limit,
-	)
+	return currency, true
+}
 
-	if err != nil {
-		h.logger.Error("Failed to get marketplace listings", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch listings")
-		return
+// convertListingPrices sets the display prices of listings when a display currency was
+// requested. It sends an error response and returns false if they can't be converted.
+func (h *MarketplaceHandler) convertListingPrices(c *gin.Context, listings []model.MarketplaceItem, currency string) bool {
+	if currency == "" {
+		return true
 	}
 
-	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, listings, total, params.Page, params.Limit)
+	if err := h.currencyService.ConvertListingPrices(c.Request.Context(), listings, currency); err != nil {
+		h.logger.Error("Failed to convert listing prices", zap.Error(err), zap.String("currency", currency))
+		utils.SendErrorResponse(c, http.StatusServiceUnavailable, "Exchange rates are currently unavailable")
+		return false
+	}
+
+	return true
 }
 
 // GetListingByID handles getting a single marketplace listing
 // GET /api/v1/marketplace/{id}
+//
+// @Summary Get a single marketplace listing
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Param currency query string false "currency"
+// @Success 200 {object} object{data=model.MarketplaceItem}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Router /api/v1/marketplace/{id} [get]
 func (h *MarketplaceHandler) GetListingByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -134,23 +319,191 @@ func (h *MarketplaceHandler) GetListingByID(c *gin.Context) {
 		return
 	}
 
+	currency, ok := h.parseDisplayCurrency(c)
+	if !ok {
+		return
+	}
+
 	listing, err := h.marketplaceService.GetListingByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get marketplace listing", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	if listing == nil {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Listing not found")
+		apierror.Send(c, http.StatusNotFound, errcode.CodeListingNotFound, "Listing not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": listing})
+	// Count the view for trending scores; signed-in viewers also get recommendations from it
+	h.recommendationService.RecordListingView(c.Request.Context(), listing, c.GetInt("userID"))
+
+	listings := []model.MarketplaceItem{*listing}
+	if !h.convertListingPrices(c, listings, currency) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": listings[0]})
+}
+
+// GetTrendingListings handles listing the marketplace listings with the most recent views
+// and purchases
+// GET /api/v1/marketplace/trending
+//
+// @Summary List the marketplace listings with the most recent views and purchases
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param currency query string false "currency"
+// @Success 200 {object} object{data=[]model.MarketplaceItem}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Router /api/v1/marketplace/trending [get]
+func (h *MarketplaceHandler) GetTrendingListings(c *gin.Context) {
+	params := pagination.Parse(c, 20, 50) // default limit: 20, max limit: 50
+
+	currency, ok := h.parseDisplayCurrency(c)
+	if !ok {
+		return
+	}
+
+	listings, err := h.recommendationService.GetTrendingListings(c.Request.Context(), params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get trending listings", zap.Error(err))
+		apierror.Respond(c, err, "Failed to retrieve trending listings")
+		return
+	}
+
+	if !h.convertListingPrices(c, listings, currency) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": listings})
+}
+
+// GetSellerStats handles retrieving the public marketplace statistics of a seller, shown on
+// their public profile
+// GET /api/v1/marketplace/sellers/{id}
+//
+// @Summary Retrieve the public marketplace statistics of a seller
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} object{data=model.SellerStats}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/sellers/{id} [get]
+func (h *MarketplaceHandler) GetSellerStats(c *gin.Context) {
+	sellerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	stats, err := h.marketplaceService.GetSellerStats(c.Request.Context(), sellerID)
+	if err != nil {
+		h.logger.Error("Failed to get seller stats", zap.Error(err), zap.Int("sellerID", sellerID))
+		apierror.Respond(c, err, "Failed to retrieve seller statistics")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// GetListingPurchasers handles retrieving the IDs of the buyers of a listing who still
+// have access to it, for the user service's notification broadcasts
+// GET /api/v1/service/marketplace/{id}/purchasers
+//
+// @Summary Retrieve the IDs of the buyers of a listing
+// @Tags service
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} object{data=[]int}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/marketplace/{id}/purchasers [get]
+func (h *MarketplaceHandler) GetListingPurchasers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	userIDs, err := h.marketplaceService.GetListingPurchasers(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get listing purchasers", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to retrieve listing purchasers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": userIDs})
+}
+
+// GetRecommendedListings handles listing marketplace listings recommended for the user from
+// the tags of the strategies they bought or viewed
+// GET /api/v1/marketplace/recommended
+//
+// @Summary List marketplace listings recommended for the user from the tags of the strategies they bought or viewed
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param currency query string false "currency"
+// @Success 200 {object} object{data=[]model.MarketplaceItem}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 503 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/recommended [get]
+func (h *MarketplaceHandler) GetRecommendedListings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params := pagination.Parse(c, 20, 50) // default limit: 20, max limit: 50
+
+	currency, ok := h.parseDisplayCurrency(c)
+	if !ok {
+		return
+	}
+
+	listings, err := h.recommendationService.GetRecommendedListings(c.Request.Context(), userID.(int), params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get recommended listings", zap.Error(err), zap.Int("userID", userID.(int)))
+		apierror.Respond(c, err, "Failed to retrieve recommended listings")
+		return
+	}
+
+	if !h.convertListingPrices(c, listings, currency) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": listings})
 }
 
 // CreateListing handles creating a new marketplace listing
 // POST /api/v1/marketplace
+//
+// @Summary Create a new marketplace listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param request body model.MarketplaceCreate true "Request body"
+// @Success 201 {object} object{data=model.MarketplaceItem}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace [post]
 func (h *MarketplaceHandler) CreateListing(c *gin.Context) {
 	var request model.MarketplaceCreate
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -162,6 +515,18 @@ func (h *MarketplaceHandler) CreateListing(c *gin.Context) {
 		request.Price = 0
 	}
 
+	currency, err := h.currencyService.NormalizeCurrency(c.Request.Context(), request.Currency)
+	if err != nil {
+		if errors.Is(err, errcode.ErrUnsupportedCurrency) {
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to load currencies", zap.Error(err))
+		apierror.Respond(c, err, "Failed to load currencies")
+		return
+	}
+	request.Currency = currency
+
 	userID, exists := c.Get("userID")
 	if !exists {
 		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
@@ -171,7 +536,7 @@ func (h *MarketplaceHandler) CreateListing(c *gin.Context) {
 	listing, err := h.marketplaceService.CreateListing(c.Request.Context(), &request, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to create listing", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -180,6 +545,15 @@ func (h *MarketplaceHandler) CreateListing(c *gin.Context) {
 
 // DeleteListing handles deleting a marketplace listing
 // DELETE /api/v1/marketplace/{id}
+//
+// @Summary Delete a marketplace listing
+// @Tags marketplace
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id} [delete]
 func (h *MarketplaceHandler) DeleteListing(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -197,7 +571,7 @@ func (h *MarketplaceHandler) DeleteListing(c *gin.Context) {
 	err = h.marketplaceService.DeleteListing(c.Request.Context(), id, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to delete listing", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -206,6 +580,18 @@ func (h *MarketplaceHandler) DeleteListing(c *gin.Context) {
 
 // PurchaseStrategy handles purchasing a strategy from the marketplace
 // POST /api/v1/marketplace/{id}/purchase
+//
+// @Summary Purchase a strategy from the marketplace
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.PurchaseRequest true "Request body"
+// @Success 200 {object} object{data=model.StrategyPurchase}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/purchase [post]
 func (h *MarketplaceHandler) PurchaseStrategy(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -220,10 +606,20 @@ func (h *MarketplaceHandler) PurchaseStrategy(c *gin.Context) {
 		return
 	}
 
-	purchase, err := h.marketplaceService.PurchaseStrategy(c.Request.Context(), id, userID.(int))
+	// The body is optional; it only carries a coupon code and whether to use referral credit
+	// and the wallet
+	var request model.PurchaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	purchase, err := h.marketplaceService.PurchaseStrategy(c.Request.Context(), id, userID.(int), request.CouponCode, request.UseReferralCredit, request.UseWalletBalance)
 	if err != nil {
 		h.logger.Error("Failed to purchase strategy", zap.Error(err), zap.Int("listing_id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -232,6 +628,15 @@ func (h *MarketplaceHandler) PurchaseStrategy(c *gin.Context) {
 
 // CancelSubscription handles canceling a marketplace subscription
 // PUT /api/v1/marketplace/purchases/{id}/cancel
+//
+// @Summary Cancel a marketplace subscription
+// @Tags marketplace
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/purchases/{id}/cancel [put]
 func (h *MarketplaceHandler) CancelSubscription(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -249,15 +654,353 @@ func (h *MarketplaceHandler) CancelSubscription(c *gin.Context) {
 	err = h.marketplaceService.CancelSubscription(c.Request.Context(), id, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to cancel subscription", zap.Error(err), zap.Int("purchase_id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// UpgradePurchase handles upgrading a version-locked purchase to the listing's current version
+// POST /api/v1/marketplace/purchases/{id}/upgrade
+//
+// @Summary Upgrade a version-locked purchase to the latest version
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.PurchaseUpgrade}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/purchases/{id}/upgrade [post]
+func (h *MarketplaceHandler) UpgradePurchase(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid purchase ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	upgrade, err := h.marketplaceService.UpgradePurchase(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to upgrade purchase", zap.Error(err), zap.Int("purchase_id", id))
+		}
+		apierror.Respond(c, err, "Failed to upgrade purchase")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": upgrade})
+}
+
+// SetUpdatePolicy handles a seller choosing whether buyers of a listing get the versions
+// published after their purchase ("lifetime_updates") or need to upgrade ("version_locked")
+// PUT /api/v1/marketplace/{id}/update-policy
+//
+// @Summary Set whether buyers of a listing get later versions
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ListingUpdatePolicyUpdate true "Request body"
+// @Success 200 {object} object{data=model.MarketplaceItem}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/update-policy [put]
+func (h *MarketplaceHandler) SetUpdatePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	var request model.ListingUpdatePolicyUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	listing, err := h.marketplaceService.SetUpdatePolicy(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to set listing update policy", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to set listing update policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": listing})
+}
+
+// AttachBacktest handles attaching a verified backtest to a listing
+// POST /api/v1/marketplace/{id}/attach-backtest
+//
+// @Summary Attach a verified backtest to a listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.AttachBacktestRequest true "Request body"
+// @Success 200 {object} object{data=model.VerifiedBacktest}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/attach-backtest [post]
+func (h *MarketplaceHandler) AttachBacktest(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	var request model.AttachBacktestRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	snapshot, err := h.marketplaceService.AttachBacktest(c.Request.Context(), id, request.BacktestID, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to attach backtest",
+			zap.Error(err),
+			zap.Int("id", id),
+			zap.Int("backtest_id", request.BacktestID))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": snapshot})
+}
+
+// PublishVersion handles a seller publishing a newer version of the listed strategy to a
+// listing, with notes on what changed for the changelog
+// POST /api/v1/marketplace/{id}/versions
+//
+// @Summary Publish a new strategy version to a listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ListingVersionPublish true "Request body"
+// @Success 201 {object} object{data=model.ListingVersion,notified_purchasers=integer}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/versions [post]
+func (h *MarketplaceHandler) PublishVersion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.ListingVersionPublish
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, notified, err := h.marketplaceService.PublishListingVersion(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to publish listing version", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to publish listing version")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":                version,
+		"notified_purchasers": notified,
+	})
+}
+
+// GetChangelog handles retrieving the strategy versions published to a listing, newest first
+// GET /api/v1/marketplace/{id}/changelog
+//
+// @Summary Retrieve the changelog of a marketplace listing
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.ListingVersion}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/{id}/changelog [get]
+func (h *MarketplaceHandler) GetChangelog(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	versions, err := h.marketplaceService.GetListingChangelog(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get listing changelog", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to get listing changelog")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// GetPriceHistory handles retrieving the prices a listing had, newest first
+// GET /api/v1/marketplace/{id}/price-history
+//
+// @Summary Retrieve the price history of a marketplace listing
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.ListingPrice}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/{id}/price-history [get]
+func (h *MarketplaceHandler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid listing ID")
+		return
+	}
+
+	prices, err := h.marketplaceService.GetListingPriceHistory(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get listing price history", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to get listing price history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": prices})
+}
+
+// GetPurchase handles retrieving a purchase and its current subscription state
+// GET /api/v1/marketplace/purchases/{id}
+//
+// @Summary Retrieve a purchase and its current subscription state
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.PurchaseDetails}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/purchases/{id} [get]
+func (h *MarketplaceHandler) GetPurchase(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid purchase ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	purchase, err := h.marketplaceService.GetPurchase(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get purchase", zap.Error(err), zap.Int("purchase_id", id))
+		apierror.Respond(c, err, "Failed to fetch purchase")
+		return
+	}
+
+	if purchase == nil {
+		apierror.Send(c, http.StatusNotFound, errcode.CodePurchaseNotFound, "Purchase not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": purchase})
+}
+
+// GetPurchaseHistory handles retrieving the user's purchases, newest first, with their
+// subscription and refund state
+// GET /api/v1/marketplace/purchases
+//
+// @Summary Retrieve the user's purchases, newest first, with their subscription and refund state
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.PurchaseDetails,meta=pagination.Meta}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/purchases [get]
+func (h *MarketplaceHandler) GetPurchaseHistory(c *gin.Context) {
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	purchases, total, err := h.marketplaceService.GetPurchaseHistory(c.Request.Context(), userID.(int), params.Page, params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get purchase history", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch purchases")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, purchases, total, params.Page, params.Limit)
+}
+
 // GetReviews handles retrieving reviews for a marketplace listing
 // GET /api/v1/marketplace/{id}/reviews
+//
+// @Summary Retrieve reviews for a marketplace listing
+// @Tags marketplace
+// @Produce json
+// @Param id path integer true "ID"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param min_rating query integer false "min rating"
+// @Success 200 {object} object{data=[]model.StrategyReview,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/marketplace/{id}/reviews [get]
 func (h *MarketplaceHandler) GetReviews(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -266,8 +1009,8 @@ func (h *MarketplaceHandler) GetReviews(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters using the utility function
-	params := utils.ParsePaginationParams(c, 10, 50) // default limit: 10, max limit: 50
+	// Parse pagination parameters
+	params := pagination.Parse(c, 10, 50) // default limit: 10, max limit: 50
 
 	// Parse min_rating filter
 	var minRating *float64
@@ -288,16 +1031,28 @@ func (h *MarketplaceHandler) GetReviews(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to get reviews", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch reviews")
+		apierror.Respond(c, err, "Failed to fetch reviews")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, reviews, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, reviews, total, params.Page, params.Limit)
 }
 
 // CreateReview handles creating a review for a purchased strategy
 // POST /api/v1/marketplace/{id}/reviews
+//
+// @Summary Create a review for a purchased strategy
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 201 {object} object{data=model.StrategyReview}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/reviews [post]
 func (h *MarketplaceHandler) CreateReview(c *gin.Context) {
 	idStr := c.Param("id")
 	marketplaceID, err := strconv.Atoi(idStr)
@@ -331,7 +1086,7 @@ func (h *MarketplaceHandler) CreateReview(c *gin.Context) {
 	review, err := h.marketplaceService.CreateReview(c.Request.Context(), reviewCreate, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to create review", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -340,6 +1095,17 @@ func (h *MarketplaceHandler) CreateReview(c *gin.Context) {
 
 // UpdateReview handles updating a review
 // PUT /api/v1/reviews/{id}
+//
+// @Summary Update a review
+// @Tags reviews
+// @Accept json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/reviews/{id} [put]
 func (h *MarketplaceHandler) UpdateReview(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -374,7 +1140,7 @@ func (h *MarketplaceHandler) UpdateReview(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to update review", zap.Error(err), zap.Int("review_id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -383,6 +1149,15 @@ func (h *MarketplaceHandler) UpdateReview(c *gin.Context) {
 
 // DeleteReview handles deleting a review
 // DELETE /api/v1/reviews/{id}
+//
+// @Summary Delete a review
+// @Tags reviews
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/reviews/{id} [delete]
 func (h *MarketplaceHandler) DeleteReview(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -400,7 +1175,7 @@ func (h *MarketplaceHandler) DeleteReview(c *gin.Context) {
 	err = h.marketplaceService.DeleteReview(c.Request.Context(), id, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to delete review", zap.Error(err), zap.Int("review_id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
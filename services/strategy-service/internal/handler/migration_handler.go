@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/migrate"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MigrationHandler handles database migration status HTTP requests
+type MigrationHandler struct {
+	runner *migrate.Runner
+	logger *zap.Logger
+}
+
+// NewMigrationHandler creates a new migration handler
+func NewMigrationHandler(runner *migrate.Runner, logger *zap.Logger) *MigrationHandler {
+	return &MigrationHandler{
+		runner: runner,
+		logger: logger,
+	}
+}
+
+// GetStatus handles retrieving applied and pending database migrations
+// GET /api/v1/admin/migrations
+//
+// @Summary Retrieve applied and pending database migrations
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=migrate.Status}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/migrations [get]
+func (h *MigrationHandler) GetStatus(c *gin.Context) {
+	status, err := h.runner.Status(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get migration status", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get migration status")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/strategy-service/internal/middleware"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RefundHandler handles purchase refund HTTP requests
+type RefundHandler struct {
+	refundService *service.RefundService
+	logger        *zap.Logger
+}
+
+// NewRefundHandler creates a new refund handler
+func NewRefundHandler(refundService *service.RefundService, logger *zap.Logger) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+		logger:        logger,
+	}
+}
+
+// RequestRefund handles a buyer requesting a refund of a purchase
+// POST /api/v1/marketplace/purchases/{id}/refund-request
+//
+// @Summary Request a refund of a purchase
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.RefundRequestCreate true "Request body"
+// @Success 201 {object} object{data=model.RefundRequest}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 502 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/purchases/{id}/refund-request [post]
+func (h *RefundHandler) RequestRefund(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid purchase ID")
+		return
+	}
+
+	var request model.RefundRequestCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	refund, err := h.refundService.RequestRefund(c.Request.Context(), id, userID.(int), strings.TrimSpace(request.Reason))
+	if err != nil {
+		h.logger.Error("Failed to request refund", zap.Error(err), zap.Int("purchase_id", id))
+		h.sendRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": refund})
+}
+
+// GetRefundRequests handles retrieving the refund requests for the seller's sales.
+// Filter with ?status=pending|approved|rejected.
+// GET /api/v1/marketplace/refunds
+//
+// @Summary Retrieve the refund requests for the seller's sales
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param status query string false "status"
+// @Success 200 {object} object{data=[]model.RefundRequest,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/refunds [get]
+func (h *RefundHandler) GetRefundRequests(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sellerID := userID.(int)
+	h.getRefundRequests(c, &sellerID)
+}
+
+// GetAllRefundRequests handles retrieving the refund requests of every seller
+// GET /api/v1/admin/refunds
+//
+// @Summary Retrieve the refund requests of every seller
+// @Tags admin
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param status query string false "status"
+// @Success 200 {object} object{data=[]model.RefundRequest,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/refunds [get]
+func (h *RefundHandler) GetAllRefundRequests(c *gin.Context) {
+	h.getRefundRequests(c, nil)
+}
+
+func (h *RefundHandler) getRefundRequests(c *gin.Context, sellerID *int) {
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	status := c.Query("status")
+	switch status {
+	case "", model.RefundStatusPending, model.RefundStatusApproved, model.RefundStatusRejected:
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid status. Valid options are: pending, approved, rejected")
+		return
+	}
+
+	refunds, total, err := h.refundService.GetRefundRequests(c.Request.Context(), sellerID, status, params.Page, params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get refund requests", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch refund requests")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, refunds, total, params.Page, params.Limit)
+}
+
+// ReviewRefundRequest handles a seller or moderator approving or rejecting a refund request
+// PUT /api/v1/marketplace/refunds/{id}/review
+//
+// @Summary Approve or reject a refund request
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.RefundReview true "Request body"
+// @Success 200 {object} object{data=model.RefundRequest}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Failure 502 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/refunds/{id}/review [put]
+func (h *RefundHandler) ReviewRefundRequest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid refund request ID")
+		return
+	}
+
+	var review model.RefundReview
+	if err := c.ShouldBindJSON(&review); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	value, _ := c.Get("userPermissions")
+	granted, _ := value.([]string)
+	isModerator := middleware.HasPermission(granted, "marketplace:moderate")
+
+	refund, err := h.refundService.ReviewRefund(c.Request.Context(), id, userID.(int), isModerator, &review)
+	if err != nil {
+		h.logger.Error("Failed to review refund request", zap.Error(err), zap.Int("refund_id", id))
+		h.sendRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": refund})
+}
+
+// sendRefundError sends the response of a refund service error
+func (h *RefundHandler) sendRefundError(c *gin.Context, err error) {
+	apierror.Respond(c, err, "Failed to process refund request")
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReportHandler handles reports of listings and reviews and the moderation queue
+type ReportHandler struct {
+	reportService *service.ReportService
+	logger        *zap.Logger
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportService *service.ReportService, logger *zap.Logger) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// ReportListing handles reporting a marketplace listing
+// POST /api/v1/marketplace/{id}/report
+//
+// @Summary Report a marketplace listing
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ReportCreate true "Request body"
+// @Success 201 {object} object{data=model.ContentReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/{id}/report [post]
+func (h *ReportHandler) ReportListing(c *gin.Context) {
+	h.createReport(c, model.ReportTargetListing, "Invalid listing ID", h.reportService.ReportListing)
+}
+
+// ReportReview handles reporting a review
+// POST /api/v1/reviews/{id}/report
+//
+// @Summary Report a review
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ReportCreate true "Request body"
+// @Success 201 {object} object{data=model.ContentReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/reviews/{id}/report [post]
+func (h *ReportHandler) ReportReview(c *gin.Context) {
+	h.createReport(c, model.ReportTargetReview, "Invalid review ID", h.reportService.ReportReview)
+}
+
+func (h *ReportHandler) createReport(
+	c *gin.Context,
+	targetType string,
+	invalidIDMessage string,
+	report func(ctx context.Context, targetID int, reporterID int, report *model.ReportCreate) (*model.ContentReport, error),
+) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, invalidIDMessage)
+		return
+	}
+
+	var request model.ReportCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	request.Details = strings.TrimSpace(request.Details)
+
+	created, err := report(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		h.logger.Error("Failed to create report", zap.Error(err), zap.String("target_type", targetType), zap.Int("target_id", id))
+		h.sendReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": created})
+}
+
+// GetReports handles retrieving the moderation queue.
+// Filter with ?status=open|resolved|dismissed (default open) and ?target_type=listing|review.
+// GET /api/v1/admin/reports
+//
+// @Summary Retrieve the moderation queue
+// @Tags admin
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param status query string false "status"
+// @Param target_type query string false "target type"
+// @Success 200 {object} object{data=[]model.ContentReport,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/reports [get]
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	status := c.DefaultQuery("status", model.ReportStatusOpen)
+	switch status {
+	case "all":
+		status = ""
+	case model.ReportStatusOpen, model.ReportStatusResolved, model.ReportStatusDismissed:
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid status. Valid options are: open, resolved, dismissed, all")
+		return
+	}
+
+	targetType := c.Query("target_type")
+	switch targetType {
+	case "", model.ReportTargetListing, model.ReportTargetReview:
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid target_type. Valid options are: listing, review")
+		return
+	}
+
+	reports, total, err := h.reportService.GetReports(c.Request.Context(), status, targetType, params.Page, params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to get reports", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch reports")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, reports, total, params.Page, params.Limit)
+}
+
+// GetReport handles retrieving a report
+// GET /api/v1/admin/reports/{id}
+//
+// @Summary Retrieve a report
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.ContentReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/reports/{id} [get]
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	report, err := h.reportService.GetReport(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get report", zap.Error(err), zap.Int("report_id", id))
+		h.sendReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// ResolveReport handles a moderator dismissing a report or taking action on its target
+// PUT /api/v1/admin/reports/{id}/resolve
+//
+// @Summary Dismiss a report or act on its target
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ReportResolution true "Request body"
+// @Success 200 {object} object{data=model.ContentReport}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/reports/{id}/resolve [put]
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var resolution model.ReportResolution
+	if err := c.ShouldBindJSON(&resolution); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	resolution.Note = strings.TrimSpace(resolution.Note)
+
+	report, err := h.reportService.ResolveReport(c.Request.Context(), id, userID.(int), &resolution)
+	if err != nil {
+		h.logger.Error("Failed to resolve report", zap.Error(err), zap.Int("report_id", id))
+		h.sendReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// sendReportError sends the response of a report service error
+func (h *ReportHandler) sendReportError(c *gin.Context, err error) {
+	apierror.Respond(c, err, "Failed to process report")
+}
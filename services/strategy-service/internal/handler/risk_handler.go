@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RiskHandler handles strategy risk score HTTP requests
+type RiskHandler struct {
+	riskService *service.RiskService
+	logger      *zap.Logger
+}
+
+// NewRiskHandler creates a new risk handler
+func NewRiskHandler(riskService *service.RiskService, logger *zap.Logger) *RiskHandler {
+	return &RiskHandler{
+		riskService: riskService,
+		logger:      logger,
+	}
+}
+
+// GetRiskScore handles retrieving the risk score of a strategy version
+// GET /api/v1/strategies/{id}/risk-score
+//
+// @Summary Retrieve the risk score of a strategy version
+// @Description Scores from 0 (lowest risk) to 100, from the version's verified backtests (drawdown, leverage, position sizing, trade frequency) and structure heuristics. Recomputed when a verified backtest is attached.
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.StrategyRiskScore}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/risk-score [get]
+func (h *RiskHandler) GetRiskScore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	score, err := h.riskService.GetRiskScore(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get strategy risk score", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to fetch strategy risk score")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": score})
+}
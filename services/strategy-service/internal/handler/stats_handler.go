@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StatsHandler handles admin statistics HTTP requests
+type StatsHandler struct {
+	statsService *service.StatsService
+	logger       *zap.Logger
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService *service.StatsService, logger *zap.Logger) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+		logger:       logger,
+	}
+}
+
+// GetStrategyStats handles retrieving strategy and marketplace KPIs over a time window (24h, 7d, 30d or 90d)
+// GET /api/v1/admin/stats/strategies
+//
+// @Summary Retrieve strategy and marketplace KPIs over a time window (24h, 7d, 30d or 90d)
+// @Tags admin
+// @Produce json
+// @Param window query string false "window"
+// @Success 200 {object} object{data=model.StrategyStats}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/stats/strategies [get]
+func (h *StatsHandler) GetStrategyStats(c *gin.Context) {
+	window := c.DefaultQuery("window", "7d")
+	if !service.IsValidStatsWindow(window) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid window; use 24h, 7d, 30d or 90d")
+		return
+	}
+
+	stats, err := h.statsService.GetStrategyStats(c.Request.Context(), window)
+	if err != nil {
+		h.logger.Error("Failed to get strategy stats", zap.Error(err), zap.String("window", window))
+		apierror.Respond(c, err, "Failed to get strategy stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
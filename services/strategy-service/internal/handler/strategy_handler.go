@@ -6,10 +6,13 @@ import (
 	"strings"
 	"time"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
 	"services/strategy-service/internal/client"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/service"
 	"services/strategy-service/internal/utils"
+	"services/strategy-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -33,6 +36,23 @@ func NewStrategyHandler(strategyService *service.StrategyService, userClient *cl
 
 // GetAllStrategies handles retrieving all strategies for a user
 // GET /api/v1/strategies
+//
+// @Summary Retrieve all strategies for a user
+// @Tags strategies
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param purchased_only query string false "purchased only"
+// @Param tags query string false "tags"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Param view query integer false "ID of a saved view whose filters and sort order apply"
+// @Success 200 {object} object{data=[]model.Strategy,meta=pagination.Meta}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies [get]
 func (h *StrategyHandler) GetAllStrategies(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
@@ -57,11 +77,10 @@ func (h *StrategyHandler) GetAllStrategies(c *gin.Context) {
 	}
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
+	order := pagination.ParseSort(c, "created_at", "DESC")
 
 	// Get strategies from service
 	strategies, total, err := h.strategyService.GetAllStrategies(
@@ -70,24 +89,37 @@ func (h *StrategyHandler) GetAllStrategies(c *gin.Context) {
 		searchTerm,
 		purchasedOnly,
 		tagIDs,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get strategies", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch strategies")
+		apierror.Respond(c, err, "Failed to fetch strategies")
 		return
 	}
 
 	// Return paginated response
-	utils.SendPaginatedResponse(c, http.StatusOK, strategies, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, strategies, total, params.Page, params.Limit)
 }
 
 // GetStrategyByID handles retrieving a strategy by ID
 // GET /api/v1/strategies/{id}
+//
+// @Summary Retrieve a strategy by ID
+// @Tags strategies
+// @Produce json
+// @Description With resolve_presets, the indicator preset references of the structure are replaced with the presets' settings, as the backtesting engine needs them.
+// @Param id path integer true "ID"
+// @Param resolve_presets query boolean false "Resolve indicator presets"
+// @Success 200 {object} object{data=model.Strategy}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id} [get]
 func (h *StrategyHandler) GetStrategyByID(c *gin.Context) {
 	// Parse strategy ID from URL
 	idStr := c.Param("id")
@@ -108,15 +140,72 @@ func (h *StrategyHandler) GetStrategyByID(c *gin.Context) {
 	strategy, err := h.strategyService.GetStrategyByID(c.Request.Context(), id, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to get strategy", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusNotFound)
 		return
 	}
 
+	if c.Query("resolve_presets") == "true" {
+		if err := h.strategyService.ResolveIndicatorPresets(c.Request.Context(), strategy); err != nil {
+			h.logger.Error("Failed to resolve indicator presets", zap.Error(err), zap.Int("id", id))
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": strategy})
 }
 
+// LintStrategy handles checking a strategy's structure for non-fatal problems: unused
+// indicators, conditions that are always or never true, entry and exit rules that
+// conflict and lookahead bias
+// POST /api/v1/strategies/{id}/lint
+//
+// @Summary Lint a strategy's structure
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.StrategyLintResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/lint [post]
+func (h *StrategyHandler) LintStrategy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	result, err := h.strategyService.LintStrategy(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to lint strategy", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 // CreateStrategy handles creating a new strategy
 // POST /api/v1/strategies
+//
+// @Summary Create a new strategy
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param request body model.StrategyCreate true "Request body"
+// @Success 201 {object} object{data=model.Strategy}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies [post]
 func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -127,8 +216,7 @@ func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
 
 	// Parse request body
 	var request model.StrategyCreate
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -142,7 +230,7 @@ func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
 	strategy, err := h.strategyService.CreateStrategy(c.Request.Context(), &request, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to create strategy", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -151,6 +239,18 @@ func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
 
 // UpdateStrategy handles updating a strategy (creates a new version)
 // PUT /api/v1/strategies/{id}
+//
+// @Summary Update a strategy (creates a new version)
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.StrategyUpdate true "Request body"
+// @Success 200 {object} object{data=model.Strategy}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id} [put]
 func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
 	// Parse strategy ID from URL
 	idStr := c.Param("id")
@@ -169,8 +269,7 @@ func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
 
 	// Parse request body
 	var request model.StrategyUpdate
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -184,7 +283,7 @@ func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
 	strategy, err := h.strategyService.UpdateStrategy(c.Request.Context(), id, userID.(int), &request)
 	if err != nil {
 		h.logger.Error("Failed to update strategy", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -193,6 +292,15 @@ func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
 
 // DeleteStrategy handles deleting a strategy
 // DELETE /api/v1/strategies/{id}
+//
+// @Summary Delete a strategy
+// @Tags strategies
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id} [delete]
 func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
 	// Parse strategy ID from URL
 	idStr := c.Param("id")
@@ -213,7 +321,7 @@ func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
 	err = h.strategyService.DeleteStrategy(c.Request.Context(), id, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to delete strategy", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -222,6 +330,20 @@ func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
 
 // GetVersions handles retrieving all versions of a strategy
 // GET /api/v1/strategies/{id}/versions
+//
+// @Summary Retrieve all versions of a strategy
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} object{data=[]model.Strategy,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/versions [get]
 func (h *StrategyHandler) GetVersions(c *gin.Context) {
 	// Parse strategy ID from URL
 	idStr := c.Param("id")
@@ -239,35 +361,46 @@ func (h *StrategyHandler) GetVersions(c *gin.Context) {
 	}
 
 	// Parse pagination parameters
-	params := utils.ParsePaginationParams(c, 20, 100) // default limit: 20, max limit: 100
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "version")
-	sortDirection := c.DefaultQuery("sort_direction", "DESC")
+	order := pagination.ParseSort(c, "version", "DESC")
 
 	// Get strategy versions from service
 	versions, total, err := h.strategyService.GetVersions(
 		c.Request.Context(),
 		id,
 		userID.(int),
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get strategy versions", zap.Error(err), zap.Int("id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	// Return paginated response
-	utils.SendPaginatedResponse(c, http.StatusOK, versions, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, versions, total, params.Page, params.Limit)
 }
 
 // GetVersionByID handles retrieving a specific version of a strategy
 // GET /api/v1/strategies/{id}/versions/{version}
+//
+// @Summary Retrieve a specific version of a strategy
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Param version path integer true "version"
+// @Param resolve_presets query boolean false "Resolve indicator presets"
+// @Success 200 {object} object{data=model.Strategy}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/versions/{version} [get]
 func (h *StrategyHandler) GetVersionByID(c *gin.Context) {
 	// Parse strategy ID and version ID from URL
 	strategyIDStr := c.Param("id")
@@ -304,10 +437,21 @@ func (h *StrategyHandler) GetVersionByID(c *gin.Context) {
 			zap.Error(err),
 			zap.Int("strategy_id", strategyID),
 			zap.Int("version_id", versionID))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
+	if c.Query("resolve_presets") == "true" {
+		if err := h.strategyService.ResolveIndicatorPresets(c.Request.Context(), version); err != nil {
+			h.logger.Error("Failed to resolve indicator presets",
+				zap.Error(err),
+				zap.Int("strategy_id", strategyID),
+				zap.Int("version_id", versionID))
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": version})
 }
 
@@ -349,15 +493,134 @@ func (h *StrategyHandler) SetActiveVersion(c *gin.Context) {
 			zap.Error(err),
 			zap.Int("strategy_id", strategyID),
 			zap.Int("version_id", versionID))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// GetBacktestHistory handles retrieving the user's backtests of every version of a strategy
+// GET /api/v1/strategies/{id}/backtests
+//
+// @Summary Retrieve the backtests of every version of a strategy
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Param version query integer false "version"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} client.StrategyBacktestHistory
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/backtests [get]
+func (h *StrategyHandler) GetBacktestHistory(c *gin.Context) {
+	// Parse strategy ID from URL
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var version *int
+	if versionStr := c.Query("version"); versionStr != "" {
+		v, err := strconv.Atoi(versionStr)
+		if err != nil || v < 1 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid version")
+			return
+		}
+		version = &v
+	}
+
+	// Parse pagination parameters
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	history, err := h.strategyService.GetBacktestHistory(
+		c.Request.Context(),
+		id,
+		userID.(int),
+		version,
+		params.Page,
+		params.Limit,
+	)
+	if err != nil {
+		h.logger.Error("Failed to get backtest history", zap.Error(err), zap.Int("strategy_id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// PreviewSignals handles evaluating a strategy over the latest candles of a symbol and
+// returning its entry and exit signals with the conditions behind them, for quick
+// iteration without a full backtest
+// POST /api/v1/strategies/{id}/signals/preview
+//
+// @Summary Preview the signals of a strategy
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.SignalPreviewRequest true "Request body"
+// @Success 200 {object} object{data=client.SignalPreview}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/signals/preview [post]
+func (h *StrategyHandler) PreviewSignals(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.SignalPreviewRequest
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	preview, err := h.strategyService.PreviewSignals(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		h.logger.Error("Failed to preview strategy signals", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preview})
+}
+
 // BacktestStrategy handles submitting a backtest for a strategy
 // POST /api/v1/strategies/{id}/backtest
+//
+// @Summary Submit a backtest for a strategy
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 202 {object} object{message=string,backtest_id=integer,warnings=[]model.LintWarning}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/backtest [post]
 func (h *StrategyHandler) BacktestStrategy(c *gin.Context) {
 	// Parse strategy ID from URL
 	idStr := c.Param("id")
@@ -383,8 +646,7 @@ func (h *StrategyHandler) BacktestStrategy(c *gin.Context) {
 		InitialCapital float64 `json:"initial_capital" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -413,15 +675,220 @@ func (h *StrategyHandler) BacktestStrategy(c *gin.Context) {
 	}
 
 	// Submit backtest using service
-	backtestID, err := h.strategyService.CreateBacktest(c.Request.Context(), backtestRequest, userID.(int))
+	backtestID, warnings, err := h.strategyService.CreateBacktest(c.Request.Context(), backtestRequest, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to submit backtest", zap.Error(err), zap.Int("strategy_id", id))
-		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
+	response := gin.H{
 		"message":     "Backtest submitted successfully",
 		"backtest_id": backtestID,
-	})
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// BulkUpdateTags handles adding tags to and removing tags from many strategies at once
+// POST /api/v1/strategies/bulk/tags
+//
+// @Summary Add and remove tags on many strategies at once
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param request body model.StrategyTagsBulkUpdate true "Request body"
+// @Success 200 {object} object{data=model.StrategyTagsBulkResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/bulk/tags [post]
+func (h *StrategyHandler) BulkUpdateTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.StrategyTagsBulkUpdate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	result, err := h.strategyService.BulkUpdateTags(c.Request.Context(), userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to bulk update strategy tags", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to update strategy tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ShareStrategy handles sharing a strategy with another user
+// POST /api/v1/strategies/{id}/share
+//
+// @Summary Share a strategy with another user
+// @Tags strategies
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.StrategyShareRequest true "Request body"
+// @Success 201 {object} object{data=model.StrategyShare}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/share [post]
+func (h *StrategyHandler) ShareStrategy(c *gin.Context) {
+	// Parse strategy ID from URL
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse request body
+	var request model.StrategyShareRequest
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	// Share strategy using service
+	share, err := h.strategyService.ShareStrategy(c.Request.Context(), id, userID.(int), &request)
+	if err != nil {
+		h.logger.Error("Failed to share strategy", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": share})
+}
+
+// GetShares handles listing the users a strategy is shared with
+// GET /api/v1/strategies/{id}/shares
+//
+// @Summary List the users a strategy is shared with
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.StrategyShare}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/shares [get]
+func (h *StrategyHandler) GetShares(c *gin.Context) {
+	// Parse strategy ID from URL
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	shares, err := h.strategyService.GetShares(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get strategy shares", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shares})
+}
+
+// RevokeShare handles revoking a user's access to a shared strategy
+// DELETE /api/v1/strategies/{id}/shares/{userId}
+//
+// @Summary Revoke a user's access to a shared strategy
+// @Tags strategies
+// @Param id path integer true "ID"
+// @Param userId path integer true "user ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/shares/{userId} [delete]
+func (h *StrategyHandler) RevokeShare(c *gin.Context) {
+	// Parse strategy ID and target user ID from URL
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	targetUserID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	err = h.strategyService.RevokeShare(c.Request.Context(), id, userID.(int), targetUserID)
+	if err != nil {
+		h.logger.Error("Failed to revoke strategy share", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetStrategyUsers handles retrieving the IDs of a strategy's owner, buyers and the users
+// it is shared with, for the user service's notification broadcasts
+// GET /api/v1/service/strategies/{id}/users
+//
+// @Summary Retrieve the IDs of the users of a strategy
+// @Tags service
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]int}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/strategies/{id}/users [get]
+func (h *StrategyHandler) GetStrategyUsers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
+		return
+	}
+
+	userIDs, err := h.strategyService.GetStrategyUsers(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get strategy users", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to retrieve strategy users")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": userIDs})
 }
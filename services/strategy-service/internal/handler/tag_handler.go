@@ -3,8 +3,10 @@ package handler
 import (
 	"net/http"
 	"strconv"
-	"strings"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/service"
 	"services/strategy-service/internal/utils"
 
@@ -28,38 +30,59 @@ func NewTagHandler(tagService *service.TagService, logger *zap.Logger) *TagHandl
 
 // GetAllTags handles retrieving all tags
 // GET /api/v1/strategy-tags
+//
+// @Summary Retrieve all tags
+// @Tags strategy-tags
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} object{data=[]model.TagWithCount,meta=pagination.Meta}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-tags [get]
 func (h *TagHandler) GetAllTags(c *gin.Context) {
-	// Parse pagination parameters using the utility function
-	params := utils.ParsePaginationParams(c, 100, 500) // default limit: 100, max limit: 500
+	// Parse pagination parameters
+	params := pagination.Parse(c, 100, 500) // default limit: 100, max limit: 500
 
 	// Parse search parameter
 	searchTerm := c.Query("search")
 
 	// Parse sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "name")
-	sortDirection := c.DefaultQuery("sort_direction", "ASC")
+	order := pagination.ParseSort(c, "name", "ASC")
 
 	tags, total, err := h.tagService.GetAllTags(
 		c.Request.Context(),
 		searchTerm,
-		sortBy,
-		sortDirection,
+		order.By,
+		order.Direction,
 		params.Page,
 		params.Limit,
 	)
 
 	if err != nil {
 		h.logger.Error("Failed to get tags", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tags")
+		apierror.Respond(c, err, "Failed to fetch tags")
 		return
 	}
 
 	// Use standardized pagination response
-	utils.SendPaginatedResponse(c, http.StatusOK, tags, total, params.Page, params.Limit)
+	pagination.Send(c, http.StatusOK, tags, total, params.Page, params.Limit)
 }
 
 // GetTagByID handles retrieving a single tag
 // GET /api/v1/strategy-tags/{id}
+//
+// @Summary Retrieve a single tag
+// @Tags strategy-tags
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.TagWithCount}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-tags/{id} [get]
 func (h *TagHandler) GetTagByID(c *gin.Context) {
 	// Parse tag ID from URL path
 	idStr := c.Param("id")
@@ -73,11 +96,7 @@ func (h *TagHandler) GetTagByID(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("Failed to get tag", zap.Error(err), zap.Int("id", id))
 
-		if strings.Contains(err.Error(), "not found") {
-			utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tag")
-		}
+		apierror.Respond(c, err, "Failed to fetch tag")
 		return
 	}
 
@@ -86,6 +105,17 @@ func (h *TagHandler) GetTagByID(c *gin.Context) {
 
 // GetPopularTags handles retrieving popular tags
 // GET /api/v1/strategy-tags/popular
+//
+// @Summary Retrieve popular tags
+// @Description Ranks tags by the active strategies created or edited and the active marketplace listings created within the window. Usage counts are recomputed periodically.
+// @Tags strategy-tags
+// @Produce json
+// @Param limit query integer false "limit"
+// @Param window query string false "Time window: 7d, 30d (default), 90d or all"
+// @Success 200 {object} object{data=[]model.PopularTag}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-tags/popular [get]
 func (h *TagHandler) GetPopularTags(c *gin.Context) {
 	// Parse limit parameter
 	limitStr := c.DefaultQuery("limit", "10")
@@ -94,10 +124,16 @@ func (h *TagHandler) GetPopularTags(c *gin.Context) {
 		limit = 10
 	}
 
-	tags, err := h.tagService.GetPopularTags(c.Request.Context(), limit)
+	window := c.DefaultQuery("window", "30d")
+	if !service.IsValidPopularTagWindow(window) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid window; use 7d, 30d, 90d or all")
+		return
+	}
+
+	tags, err := h.tagService.GetPopularTags(c.Request.Context(), window, limit)
 	if err != nil {
 		h.logger.Error("Failed to get popular tags", zap.Error(err))
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch popular tags")
+		apierror.Respond(c, err, "Failed to fetch popular tags")
 		return
 	}
 
@@ -106,6 +142,17 @@ func (h *TagHandler) GetPopularTags(c *gin.Context) {
 
 // CreateTag handles creating a new tag
 // POST /api/v1/strategy-tags
+//
+// @Summary Create a new tag
+// @Tags strategy-tags
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 201 {object} object{data=model.TagWithCount}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags [post]
 func (h *TagHandler) CreateTag(c *gin.Context) {
 	var request struct {
 		Name string `json:"name" binding:"required"`
@@ -120,11 +167,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("Failed to create tag", zap.Error(err))
 
-		if strings.Contains(err.Error(), "already exists") {
-			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
-		} else {
-			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
-		}
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -133,6 +176,19 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 
 // UpdateTag handles updating an existing tag
 // PUT /api/v1/strategy-tags/{id}
+//
+// @Summary Update an existing tag
+// @Tags strategy-tags
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body object true "Request body"
+// @Success 200 {object} object{data=model.TagWithCount}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id} [put]
 func (h *TagHandler) UpdateTag(c *gin.Context) {
 	// Parse tag ID from URL path
 	idStr := c.Param("id")
@@ -156,15 +212,7 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 	tag, err := h.tagService.UpdateTag(c.Request.Context(), id, request.Name)
 	if err != nil {
 		h.logger.Error("Failed to update tag", zap.Error(err), zap.Int("id", id))
-
-		// Return appropriate status code based on error
-		if strings.Contains(err.Error(), "not found") {
-			utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
-		} else if strings.Contains(err.Error(), "already exists") {
-			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
-		} else {
-			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
-		}
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -173,6 +221,17 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 
 // DeleteTag handles deleting a tag
 // DELETE /api/v1/strategy-tags/{id}
+//
+// @Summary Delete a tag
+// @Tags strategy-tags
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id} [delete]
 func (h *TagHandler) DeleteTag(c *gin.Context) {
 	// Parse tag ID from URL path
 	idStr := c.Param("id")
@@ -186,17 +245,211 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 	err = h.tagService.DeleteTag(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete tag", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to delete tag")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTagChildren handles retrieving the direct children of a tag
+// GET /api/v1/strategy-tags/{id}/children
+//
+// @Summary Retrieve the children of a tag
+// @Tags strategy-tags
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.TagWithCount}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-tags/{id}/children [get]
+func (h *TagHandler) GetTagChildren(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	tags, err := h.tagService.GetTagChildren(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get tag children", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to fetch tag children")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+// SetTagParent handles moving a tag under another tag
+// PUT /api/v1/strategy-tags/{id}/parent
+//
+// @Summary Set the parent of a tag
+// @Tags strategy-tags
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.TagParentUpdate true "Request body"
+// @Success 200 {object} object{data=model.TagWithCount}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id}/parent [put]
+func (h *TagHandler) SetTagParent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	var request model.TagParentUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tag, err := h.tagService.SetTagParent(c.Request.Context(), id, request.ParentID)
+	if err != nil {
+		h.logger.Error("Failed to set tag parent", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to set tag parent")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tag})
+}
+
+// GetTagAliases handles retrieving the aliases of a tag
+// GET /api/v1/strategy-tags/{id}/aliases
+//
+// @Summary Retrieve the aliases of a tag
+// @Tags strategy-tags
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=[]model.TagAlias}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-tags/{id}/aliases [get]
+func (h *TagHandler) GetTagAliases(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
 
-		// Return appropriate status code based on error
-		if strings.Contains(err.Error(), "in use") {
-			utils.SendErrorResponse(c, http.StatusConflict, "Cannot delete tag because it's in use")
-		} else if strings.Contains(err.Error(), "not found") {
-			utils.SendErrorResponse(c, http.StatusNotFound, err.Error())
-		} else {
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete tag")
-		}
+	aliases, err := h.tagService.GetTagAliases(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get tag aliases", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to fetch tag aliases")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": aliases})
+}
+
+// AddTagAlias handles adding an alias to a tag
+// POST /api/v1/strategy-tags/{id}/aliases
+//
+// @Summary Add an alias to a tag
+// @Tags strategy-tags
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.TagAliasCreate true "Request body"
+// @Success 201 {object} object{data=[]model.TagAlias}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id}/aliases [post]
+func (h *TagHandler) AddTagAlias(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	var request model.TagAliasCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	aliases, err := h.tagService.AddTagAlias(c.Request.Context(), id, request.Alias)
+	if err != nil {
+		h.logger.Error("Failed to add tag alias", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": aliases})
+}
+
+// RemoveTagAlias handles removing an alias from a tag
+// DELETE /api/v1/strategy-tags/{id}/aliases/{alias}
+//
+// @Summary Remove an alias from a tag
+// @Tags strategy-tags
+// @Param id path integer true "ID"
+// @Param alias path string true "Alias"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id}/aliases/{alias} [delete]
+func (h *TagHandler) RemoveTagAlias(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	err = h.tagService.RemoveTagAlias(c.Request.Context(), id, c.Param("alias"))
+	if err != nil {
+		h.logger.Error("Failed to remove tag alias", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to remove tag alias")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// MergeTag handles merging a duplicate tag into another tag
+// POST /api/v1/strategy-tags/{id}/merge-into/{targetId}
+//
+// @Summary Merge a tag into another tag
+// @Description Re-tags the strategies of the tag with the target tag, moves its aliases and children to the target, keeps its name as an alias of the target and deletes it.
+// @Tags strategy-tags
+// @Produce json
+// @Param id path integer true "ID of the tag to merge"
+// @Param targetId path integer true "ID of the tag to merge into"
+// @Success 200 {object} object{data=model.TagMergeResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-tags/{id}/merge-into/{targetId} [post]
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	targetID, err := strconv.Atoi(c.Param("targetId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid target tag ID")
+		return
+	}
+
+	result, err := h.tagService.MergeTag(c.Request.Context(), id, targetID)
+	if err != nil {
+		h.logger.Error("Failed to merge tag", zap.Error(err), zap.Int("id", id), zap.Int("targetId", targetID))
+		apierror.Respond(c, err, "Failed to merge tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
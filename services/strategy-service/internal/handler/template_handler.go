@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/strategy-service/internal/middleware"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+	"services/strategy-service/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TemplateHandler handles strategy template HTTP requests
+type TemplateHandler struct {
+	templateService *service.TemplateService
+	logger          *zap.Logger
+}
+
+// NewTemplateHandler creates a new template handler
+func NewTemplateHandler(templateService *service.TemplateService, logger *zap.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// canManageTemplates reports whether the current user curates templates, and so also
+// sees inactive ones
+func (h *TemplateHandler) canManageTemplates(c *gin.Context) bool {
+	value, _ := c.Get("userPermissions")
+	granted, _ := value.([]string)
+	return middleware.HasPermission(granted, "templates:write")
+}
+
+// GetTemplates handles retrieving strategy templates and their categories
+// GET /api/v1/strategy-templates
+//
+// @Summary Retrieve strategy templates and their categories
+// @Description Template admins also see inactive templates.
+// @Tags strategy-templates
+// @Produce json
+// @Param category query string false "Only templates of this category"
+// @Success 200 {object} object{data=[]model.StrategyTemplate,categories=[]model.StrategyTemplateCategory}
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-templates [get]
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	templates, categories, err := h.templateService.GetTemplates(
+		c.Request.Context(),
+		c.Query("category"),
+		h.canManageTemplates(c),
+	)
+	if err != nil {
+		h.logger.Error("Failed to get strategy templates", zap.Error(err))
+		apierror.Respond(c, err, "Failed to fetch strategy templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates, "categories": categories})
+}
+
+// GetTemplate handles retrieving a single strategy template
+// GET /api/v1/strategy-templates/{id}
+//
+// @Summary Retrieve a single strategy template
+// @Tags strategy-templates
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.StrategyTemplate}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/strategy-templates/{id} [get]
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Request.Context(), id, h.canManageTemplates(c))
+	if err != nil {
+		h.logger.Error("Failed to get strategy template", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to fetch strategy template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// InstantiateTemplate handles creating a strategy from a template
+// POST /api/v1/strategy-templates/{id}/instantiate
+//
+// @Summary Create a strategy from a template
+// @Description Fills the template's placeholders with the given parameter values; parameters left out take their default.
+// @Tags strategy-templates
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.StrategyTemplateInstantiate true "Request body"
+// @Success 201 {object} object{data=model.Strategy}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-templates/{id}/instantiate [post]
+func (h *TemplateHandler) InstantiateTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var request model.StrategyTemplateInstantiate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	strategy, err := h.templateService.InstantiateTemplate(c.Request.Context(), id, &request, userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to instantiate strategy template", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": strategy})
+}
+
+// CreateTemplate handles creating a strategy template
+// POST /api/v1/strategy-templates
+//
+// @Summary Create a strategy template
+// @Description String values "{{name}}" in the structure are placeholders; every placeholder needs a parameter and every parameter a placeholder.
+// @Tags strategy-templates
+// @Accept json
+// @Produce json
+// @Param request body model.StrategyTemplateInput true "Request body"
+// @Success 201 {object} object{data=model.StrategyTemplate}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-templates [post]
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	var request model.StrategyTemplateInput
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(c.Request.Context(), &request, c.GetInt("userID"))
+	if err != nil {
+		h.logger.Error("Failed to create strategy template", zap.Error(err))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+// UpdateTemplate handles replacing a strategy template
+// PUT /api/v1/strategy-templates/{id}
+//
+// @Summary Update a strategy template
+// @Description Strategies already created from the template are unaffected.
+// @Tags strategy-templates
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.StrategyTemplateInput true "Request body"
+// @Success 200 {object} object{data=model.StrategyTemplate}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-templates/{id} [put]
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var request model.StrategyTemplateInput
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(c.Request.Context(), id, &request)
+	if err != nil {
+		h.logger.Error("Failed to update strategy template", zap.Error(err), zap.Int("id", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// DeleteTemplate handles deleting a strategy template
+// DELETE /api/v1/strategy-templates/{id}
+//
+// @Summary Delete a strategy template
+// @Description Strategies already created from the template are unaffected.
+// @Tags strategy-templates
+// @Param id path integer true "ID"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategy-templates/{id} [delete]
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete strategy template", zap.Error(err), zap.Int("id", id))
+		apierror.Respond(c, err, "Failed to delete strategy template")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
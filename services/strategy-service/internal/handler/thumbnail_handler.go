@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
 
+	"services/shared/apierror"
+
 	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -30,19 +35,32 @@ func NewThumbnailHandler(strategyService *service.StrategyService, mediaClient *
 
 // UploadThumbnail handles uploading a strategy thumbnail
 // POST /api/v1/strategies/:id/thumbnail
+//
+// @Summary Upload a strategy thumbnail
+// @Tags strategies
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/strategies/{id}/thumbnail [post]
 func (h *ThumbnailHandler) UploadThumbnail(c *gin.Context) {
 	// Get strategy ID from URL path
 	idStr := c.Param("id")
 	strategyID, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy ID"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid strategy ID")
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -50,19 +68,19 @@ func (h *ThumbnailHandler) UploadThumbnail(c *gin.Context) {
 	strategy, err := h.strategyService.GetStrategyByID(c.Request.Context(), strategyID, userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to get strategy", zap.Error(err), zap.Int("strategy_id", strategyID))
-		c.JSON(http.StatusNotFound, gin.H{"error": "Strategy not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeStrategyNotFound, "Strategy not found")
 		return
 	}
 
 	if strategy.UserID != userID.(int) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to modify this strategy"})
+		utils.SendErrorResponse(c, http.StatusForbidden, "Not authorized to modify this strategy")
 		return
 	}
 
 	// Parse multipart form
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max memory
 		h.logger.Error("Failed to parse multipart form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Failed to parse form data")
 		return
 	}
 
@@ -70,21 +88,22 @@ func (h *ThumbnailHandler) UploadThumbnail(c *gin.Context) {
 	file, header, err := c.Request.FormFile("thumbnail")
 	if err != nil {
 		h.logger.Error("Failed to get file from form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No file uploaded")
 		return
 	}
 	defer file.Close()
 
 	// Check file size
 	if header.Size > 5*1024*1024 { // 5MB limit
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 5MB)"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "File too large (max 5MB)")
 		return
 	}
 
 	// Check content type
 	contentType := header.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type (allowed: jpeg, png, gif)"})
+	// The media service checks the actual content; this only rejects obvious mismatches early
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" && contentType != "image/webp" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid file type (allowed: jpeg, png, gif, webp)")
 		return
 	}
 
@@ -92,15 +111,19 @@ func (h *ThumbnailHandler) UploadThumbnail(c *gin.Context) {
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
 		h.logger.Error("Failed to read file content", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to read file")
 		return
 	}
 
 	// Upload to media service
 	mediaFile, err := h.mediaClient.UploadStrategyThumbnail(c, strategyID, fileContent, header.Filename, contentType)
 	if err != nil {
+		if errors.Is(err, client.ErrInvalidMedia) {
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("Failed to upload file to media service", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		apierror.Respond(c, err, "Failed to upload file")
 		return
 	}
 
@@ -108,15 +131,15 @@ func (h *ThumbnailHandler) UploadThumbnail(c *gin.Context) {
 	err = h.strategyService.UpdateThumbnail(c.Request.Context(), strategyID, userID.(int), mediaFile.URL)
 	if err != nil {
 		h.logger.Error("Failed to update strategy thumbnail", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update strategy"})
+		apierror.Respond(c, err, "Failed to update strategy")
 		return
 	}
 
 	// Return success response with URLs
 	response := gin.H{
-		"message":    "Thumbnail uploaded successfully",
-		"url":        mediaFile.URL,
-		"thumbnails": mediaFile.Thumbnails,
+		"message":  "Thumbnail uploaded successfully",
+		"url":      mediaFile.URL,
+		"variants": mediaFile.Variants,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/service"
+	"services/strategy-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WalletHandler handles wallet HTTP requests
+type WalletHandler struct {
+	walletService *service.WalletService
+	logger        *zap.Logger
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(walletService *service.WalletService, logger *zap.Logger) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		logger:        logger,
+	}
+}
+
+// GetMyWallet handles retrieving the balances of the user's wallet
+// GET /api/v1/marketplace/wallet
+//
+// @Summary Retrieve the balances of the user's wallet
+// @Tags marketplace
+// @Produce json
+// @Success 200 {object} object{data=[]model.WalletBalance}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/wallet [get]
+func (h *WalletHandler) GetMyWallet(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	h.getBalances(c, userID.(int))
+}
+
+// GetMyLedger handles retrieving the entries of the user's wallet, newest first. Filter
+// with ?currency=.
+// GET /api/v1/marketplace/wallet/ledger
+//
+// @Summary Retrieve the entries of the user's wallet
+// @Tags marketplace
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param currency query string false "currency"
+// @Success 200 {object} object{data=[]model.WalletEntry,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/marketplace/wallet/ledger [get]
+func (h *WalletHandler) GetMyLedger(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	h.getLedger(c, userID.(int))
+}
+
+// GetUserWallet handles an admin retrieving the balances of a user's wallet
+// GET /api/v1/admin/wallets/{userId}
+//
+// @Summary Retrieve the balances of a user's wallet
+// @Tags admin
+// @Produce json
+// @Param userId path integer true "User ID"
+// @Success 200 {object} object{data=[]model.WalletBalance}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/wallets/{userId} [get]
+func (h *WalletHandler) GetUserWallet(c *gin.Context) {
+	userID, ok := parseWalletUserID(c)
+	if !ok {
+		return
+	}
+
+	h.getBalances(c, userID)
+}
+
+// GetUserLedger handles an admin retrieving the entries of a user's wallet, newest first
+// GET /api/v1/admin/wallets/{userId}/ledger
+//
+// @Summary Retrieve the entries of a user's wallet
+// @Tags admin
+// @Produce json
+// @Param userId path integer true "User ID"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param currency query string false "currency"
+// @Success 200 {object} object{data=[]model.WalletEntry,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/wallets/{userId}/ledger [get]
+func (h *WalletHandler) GetUserLedger(c *gin.Context) {
+	userID, ok := parseWalletUserID(c)
+	if !ok {
+		return
+	}
+
+	h.getLedger(c, userID)
+}
+
+// GrantCredit handles an admin adding credit to a user's wallet
+// POST /api/v1/admin/wallets/{userId}/grant
+//
+// @Summary Add credit to a user's wallet
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path integer true "User ID"
+// @Param request body model.WalletAdjustment true "Request body"
+// @Success 201 {object} object{data=model.WalletEntry}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/wallets/{userId}/grant [post]
+func (h *WalletHandler) GrantCredit(c *gin.Context) {
+	h.adjust(c, h.walletService.Grant, "Failed to grant wallet credit")
+}
+
+// RevokeCredit handles an admin taking credit from a user's wallet. It can't take more
+// than the balance.
+// POST /api/v1/admin/wallets/{userId}/revoke
+//
+// @Summary Take credit from a user's wallet
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path integer true "User ID"
+// @Param request body model.WalletAdjustment true "Request body"
+// @Success 201 {object} object{data=model.WalletEntry}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/wallets/{userId}/revoke [post]
+func (h *WalletHandler) RevokeCredit(c *gin.Context) {
+	h.adjust(c, h.walletService.Revoke, "Failed to revoke wallet credit")
+}
+
+func (h *WalletHandler) getBalances(c *gin.Context, userID int) {
+	balances, err := h.walletService.GetBalances(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to fetch wallet")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": balances})
+}
+
+func (h *WalletHandler) getLedger(c *gin.Context, userID int) {
+	params := pagination.Parse(c, 20, 100) // default limit: 20, max limit: 100
+
+	entries, total, err := h.walletService.GetLedger(c.Request.Context(), userID, c.Query("currency"), params.Page, params.Limit)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to fetch wallet ledger")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, entries, total, params.Page, params.Limit)
+}
+
+// adjust binds an admin's grant or revocation and applies it to the user's wallet
+func (h *WalletHandler) adjust(
+	c *gin.Context,
+	apply func(ctx context.Context, userID int, adminID int, adjustment *model.WalletAdjustment) (*model.WalletEntry, error),
+	failure string,
+) {
+	userID, ok := parseWalletUserID(c)
+	if !ok {
+		return
+	}
+
+	var adjustment model.WalletAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	adjustment.Note = strings.TrimSpace(adjustment.Note)
+
+	adminID, exists := c.Get("userID")
+	if !exists {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	entry, err := apply(c.Request.Context(), userID, adminID.(int), &adjustment)
+	if err != nil {
+		h.logger.Error(failure, zap.Error(err), zap.Int("user_id", userID))
+		apierror.Respond(c, err, failure)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": entry})
+}
+
+// parseWalletUserID parses the user ID of an admin wallet route, sending the error
+// response if it's invalid
+func parseWalletUserID(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,127 @@
+package lint
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// interval is the set of indicator values a condition accepts
+type interval struct {
+	lo, hi         float64
+	loOpen, hiOpen bool
+}
+
+var unbounded = interval{lo: math.Inf(-1), hi: math.Inf(1), loOpen: true, hiOpen: true}
+
+// indicatorRanges are the values bounded oscillators can take, keyed by normalized name
+var indicatorRanges = map[string]interval{
+	"RSI":        {lo: 0, hi: 100},
+	"STOCH":      {lo: 0, hi: 100},
+	"STOCHASTIC": {lo: 0, hi: 100},
+	"STOCHRSI":   {lo: 0, hi: 100},
+	"MFI":        {lo: 0, hi: 100},
+	"ADX":        {lo: 0, hi: 100},
+	"ADXR":       {lo: 0, hi: 100},
+	"ULTOSC":     {lo: 0, hi: 100},
+	"CMO":        {lo: -100, hi: 100},
+	"WILLR":      {lo: -100, hi: 0},
+	"WILLIAMSR":  {lo: -100, hi: 0},
+}
+
+// normalizeName upper-cases an indicator name and drops everything but letters and digits
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// indicatorRange returns the values an indicator can take. Custom indicators and ones
+// without a known range are unbounded.
+func indicatorRange(indicator Indicator) (interval, bool) {
+	if indicator.Formula != "" {
+		return unbounded, false
+	}
+	if r, ok := indicatorRanges[normalizeName(indicator.Name)]; ok {
+		return r, true
+	}
+	return unbounded, false
+}
+
+// conditionInterval returns the values a condition accepts. Conditions that aren't a
+// single interval ("!=") or can't be read report false.
+func conditionInterval(condition *Condition) (interval, bool) {
+	if !condition.HasValue {
+		return interval{}, false
+	}
+
+	v := condition.Value
+	switch condition.Symbol {
+	case "<":
+		return interval{lo: math.Inf(-1), hi: v, loOpen: true, hiOpen: true}, true
+	case "<=":
+		return interval{lo: math.Inf(-1), hi: v, loOpen: true}, true
+	case ">":
+		return interval{lo: v, hi: math.Inf(1), loOpen: true, hiOpen: true}, true
+	case ">=":
+		return interval{lo: v, hi: math.Inf(1), hiOpen: true}, true
+	case "==":
+		return interval{lo: v, hi: v}, true
+	}
+	return interval{}, false
+}
+
+func (a interval) intersect(b interval) interval {
+	result := a
+	if b.lo > result.lo || (b.lo == result.lo && b.loOpen) {
+		result.lo, result.loOpen = b.lo, b.loOpen
+	}
+	if b.hi < result.hi || (b.hi == result.hi && b.hiOpen) {
+		result.hi, result.hiOpen = b.hi, b.hiOpen
+	}
+	return result
+}
+
+func (a interval) empty() bool {
+	return a.lo > a.hi || (a.lo == a.hi && (a.loOpen || a.hiOpen))
+}
+
+// contains reports whether every value of b is in a
+func (a interval) contains(b interval) bool {
+	lowOK := a.lo < b.lo || (a.lo == b.lo && (!a.loOpen || b.loOpen))
+	highOK := a.hi > b.hi || (a.hi == b.hi && (!a.hiOpen || b.hiOpen))
+	return lowOK && highOK
+}
+
+// covers reports whether the union of parts includes every value of target
+func covers(parts []interval, target interval) bool {
+	sorted := append([]interval(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].lo != sorted[j].lo {
+			return sorted[i].lo < sorted[j].lo
+		}
+		return !sorted[i].loOpen && sorted[j].loOpen
+	})
+
+	// Everything in target up to reach is covered; reachDone says whether reach itself is
+	reach, reachDone := target.lo, target.loOpen
+	for _, part := range sorted {
+		if part.lo > reach || (part.lo == reach && part.loOpen && !reachDone) {
+			break
+		}
+		if part.hi > reach || (part.hi == reach && !part.hiOpen) {
+			reach, reachDone = part.hi, !part.hiOpen
+		}
+	}
+
+	return reach > target.hi || (reach == target.hi && (reachDone || target.hiOpen))
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,340 @@
+// Package lint reports non-fatal problems in strategy structures. Each check is a Rule;
+// a Linter runs its rules over a parsed structure and collects their warnings, so new
+// checks are added by implementing Rule and registering it.
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"services/strategy-service/internal/model"
+)
+
+// Rule is one lint check over a strategy structure
+type Rule interface {
+	// Name identifies the rule in the warnings it reports, e.g. "unused-indicator"
+	Name() string
+	// Check returns the rule's warnings; the linter fills in their Rule field
+	Check(structure *Structure) []model.LintWarning
+}
+
+// Linter runs a set of rules over strategy structures
+type Linter struct {
+	rules []Rule
+}
+
+// NewLinter creates a linter running the given rules in order
+func NewLinter(rules ...Rule) *Linter {
+	return &Linter{rules: rules}
+}
+
+// Register adds a rule to the linter
+func (l *Linter) Register(rule Rule) {
+	l.rules = append(l.rules, rule)
+}
+
+// Lint parses a strategy structure and returns the warnings of every rule
+func (l *Linter) Lint(data json.RawMessage) ([]model.LintWarning, error) {
+	structure, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []model.LintWarning{}
+	for _, rule := range l.rules {
+		for _, warning := range rule.Check(structure) {
+			warning.Rule = rule.Name()
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings, nil
+}
+
+// Structure is the parsed form of a strategy structure. Entry holds the buy rules and
+// Exit the sell rules; either is nil when the structure has none.
+type Structure struct {
+	Indicators []Declaration
+	Entry      *Group
+	Exit       *Group
+	Raw        map[string]interface{}
+}
+
+// Declaration is an indicator listed in the structure's top-level "indicators"
+type Declaration struct {
+	Path      string
+	Indicator Indicator
+}
+
+// Indicator is the indicator a condition compares, or one that is declared
+type Indicator struct {
+	Name      string
+	Settings  map[string]interface{}
+	Formula   string // Set for custom indicators
+	Timeframe string // Empty when computed on the timeframe the strategy runs on
+}
+
+// Key identifies an indicator together with its settings and timeframe, so conditions on
+// the same series can be compared
+func (i Indicator) Key() string {
+	settings, _ := json.Marshal(i.Settings)
+	return i.Name + string(settings) + "@" + i.Timeframe
+}
+
+// Condition is a single rule comparing an indicator to a value
+type Condition struct {
+	Path      string
+	Indicator Indicator
+	Symbol    string
+	Value     float64
+	HasValue  bool // False when the value isn't a number
+}
+
+// Node is one item of a group: either a condition or a nested group
+type Node struct {
+	Condition *Condition
+	Group     *Group
+}
+
+// Group is a list of conditions and groups combined left to right by operators.
+// Operators[i] joins the result so far with Items[i+1]; it is empty when missing.
+type Group struct {
+	Path      string
+	Items     []Node
+	Operators []string
+}
+
+// Conditions returns every condition in the group and its nested groups
+func (g *Group) Conditions() []*Condition {
+	var conditions []*Condition
+	for _, item := range g.Items {
+		if item.Condition != nil {
+			conditions = append(conditions, item.Condition)
+		} else {
+			conditions = append(conditions, item.Group.Conditions()...)
+		}
+	}
+	return conditions
+}
+
+// Groups returns the group and all of its nested groups
+func (g *Group) Groups() []*Group {
+	groups := []*Group{g}
+	for _, item := range g.Items {
+		if item.Group != nil {
+			groups = append(groups, item.Group.Groups()...)
+		}
+	}
+	return groups
+}
+
+// AllOperators reports whether every item of the group is joined by the operator,
+// without looking into nested groups
+func (g *Group) AllOperators(operator string) bool {
+	for _, op := range g.Operators {
+		if op != operator {
+			return false
+		}
+	}
+	return true
+}
+
+// Conjunctive reports whether the group, nested groups included, only uses AND
+func (g *Group) Conjunctive() bool {
+	if !g.AllOperators("AND") {
+		return false
+	}
+	for _, item := range g.Items {
+		if item.Group != nil && !item.Group.Conjunctive() {
+			return false
+		}
+	}
+	return true
+}
+
+// Conditions returns every entry and exit condition
+func (s *Structure) Conditions() []*Condition {
+	var conditions []*Condition
+	for _, group := range []*Group{s.Entry, s.Exit} {
+		if group != nil {
+			conditions = append(conditions, group.Conditions()...)
+		}
+	}
+	return conditions
+}
+
+// Groups returns every entry and exit group
+func (s *Structure) Groups() []*Group {
+	var groups []*Group
+	for _, group := range []*Group{s.Entry, s.Exit} {
+		if group != nil {
+			groups = append(groups, group.Groups()...)
+		}
+	}
+	return groups
+}
+
+// Parse reads a strategy structure. Rule groups are read the way the backtesting
+// engine evaluates them: in "_sequence" order when present, otherwise by the index in
+// their "ruleN" and "groupN" keys.
+func Parse(data json.RawMessage) (*Structure, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid strategy structure JSON: %w", err)
+	}
+	if raw == nil {
+		return nil, errors.New("strategy structure cannot be empty")
+	}
+
+	structure := &Structure{Raw: raw}
+
+	if rules, ok := raw["buyRules"].(map[string]interface{}); ok {
+		structure.Entry = parseGroup("buyRules", rules)
+	}
+	if rules, ok := raw["sellRules"].(map[string]interface{}); ok {
+		structure.Exit = parseGroup("sellRules", rules)
+	}
+
+	switch declared := raw["indicators"].(type) {
+	case []interface{}:
+		for i, item := range declared {
+			path := fmt.Sprintf("indicators[%d]", i)
+			switch value := item.(type) {
+			case string:
+				structure.Indicators = append(structure.Indicators, Declaration{Path: path, Indicator: Indicator{Name: value}})
+			case map[string]interface{}:
+				structure.Indicators = append(structure.Indicators, Declaration{Path: path, Indicator: parseIndicator(value)})
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range sortedKeys(declared) {
+			indicator := Indicator{Name: name}
+			if settings, ok := declared[name].(map[string]interface{}); ok {
+				indicator.Settings = settings
+			}
+			structure.Indicators = append(structure.Indicators, Declaration{Path: "indicators." + name, Indicator: indicator})
+		}
+	}
+
+	return structure, nil
+}
+
+func parseGroup(path string, rules map[string]interface{}) *Group {
+	group := &Group{Path: path}
+
+	addItem := func(kind, index string) {
+		key := kind + index
+		value, ok := rules[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		if kind == "rule" {
+			group.Items = append(group.Items, Node{Condition: parseCondition(path+"."+key, value)})
+		} else {
+			group.Items = append(group.Items, Node{Group: parseGroup(path+"."+key, value)})
+		}
+	}
+
+	if sequence, ok := rules["_sequence"].([]interface{}); ok {
+		for _, entry := range sequence {
+			item, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			kind, _ := item["type"].(string)
+			if kind == "rule" || kind == "group" {
+				addItem(kind, indexString(item["index"]))
+			}
+		}
+	} else {
+		for _, kind := range []string{"rule", "group"} {
+			for _, index := range keyIndexes(rules, kind) {
+				addItem(kind, index)
+			}
+		}
+	}
+
+	// The engine joins the j-th result to the ones before it with operator j-1
+	for j := 1; j < len(group.Items); j++ {
+		operator, _ := rules[fmt.Sprintf("operator%d", j-1)].(string)
+		group.Operators = append(group.Operators, operator)
+	}
+
+	return group
+}
+
+func parseCondition(path string, rule map[string]interface{}) *Condition {
+	condition := &Condition{Path: path}
+
+	if indicator, ok := rule["indicator"].(map[string]interface{}); ok {
+		condition.Indicator = parseIndicator(indicator)
+	}
+
+	if value, ok := rule["condition"].(map[string]interface{}); ok {
+		condition.Symbol, _ = value["symbol"].(string)
+		condition.Value, condition.HasValue = number(value["value"])
+	}
+
+	return condition
+}
+
+func parseIndicator(indicator map[string]interface{}) Indicator {
+	parsed := Indicator{}
+	parsed.Name, _ = indicator["name"].(string)
+	parsed.Settings, _ = indicator["indicatorSettings"].(map[string]interface{})
+	parsed.Formula, _ = indicator["formula"].(string)
+	parsed.Timeframe, _ = indicator["timeframe"].(string)
+	return parsed
+}
+
+// keyIndexes returns the indexes of a group's "<kind>N" keys in numeric order
+func keyIndexes(rules map[string]interface{}, kind string) []string {
+	var indexes []int
+	for key := range rules {
+		if !strings.HasPrefix(key, kind) {
+			continue
+		}
+		if index, err := strconv.Atoi(strings.TrimPrefix(key, kind)); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+
+	result := make([]string, len(indexes))
+	for i, index := range indexes {
+		result[i] = strconv.Itoa(index)
+	}
+	return result
+}
+
+func indexString(value interface{}) string {
+	if index, ok := value.(float64); ok {
+		return strconv.Itoa(int(index))
+	}
+	return fmt.Sprint(value)
+}
+
+// number reads a JSON number or a numeric string, as the backtesting engine does
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,394 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"services/strategy-service/internal/model"
+)
+
+// DefaultRules returns the built-in lint rules
+func DefaultRules() []Rule {
+	return []Rule{
+		UnusedIndicators{},
+		ConstantConditions{},
+		ConflictingEntryExit{},
+		LookaheadBias{},
+	}
+}
+
+// UnusedIndicators warns about declared indicators that no entry or exit rule uses
+type UnusedIndicators struct{}
+
+// Name identifies the rule
+func (UnusedIndicators) Name() string { return "unused-indicator" }
+
+// Check reports each unused declaration
+func (UnusedIndicators) Check(structure *Structure) []model.LintWarning {
+	used := make(map[string]bool)
+	for _, condition := range structure.Conditions() {
+		used[condition.Indicator.Name] = true
+	}
+
+	var warnings []model.LintWarning
+	for _, declaration := range structure.Indicators {
+		name := declaration.Indicator.Name
+		if name == "" || used[name] {
+			continue
+		}
+		warnings = append(warnings, model.LintWarning{
+			Message: fmt.Sprintf("Indicator %q is declared but no entry or exit rule uses it", name),
+			Path:    declaration.Path,
+		})
+	}
+
+	return warnings
+}
+
+// ConstantConditions warns about conditions and groups that are always true or never
+// true: comparisons outside the range of a bounded oscillator, contradicting conditions
+// joined by AND and conditions joined by OR that accept every value
+type ConstantConditions struct{}
+
+// Name identifies the rule
+func (ConstantConditions) Name() string { return "constant-condition" }
+
+// Check reports each constant condition or group
+func (ConstantConditions) Check(structure *Structure) []model.LintWarning {
+	var warnings []model.LintWarning
+
+	for _, condition := range structure.Conditions() {
+		valueRange, bounded := indicatorRange(condition.Indicator)
+		if !bounded {
+			continue
+		}
+
+		name := condition.Indicator.Name
+		describe := fmt.Sprintf("%s %s %s", name, condition.Symbol, formatNumber(condition.Value))
+		rangeNote := fmt.Sprintf("%s ranges from %s to %s", name, formatNumber(valueRange.lo), formatNumber(valueRange.hi))
+
+		accepted, ok := conditionInterval(condition)
+		switch {
+		case ok && accepted.intersect(valueRange).empty():
+			warnings = append(warnings, model.LintWarning{
+				Message: fmt.Sprintf("%s is never true; %s", describe, rangeNote),
+				Path:    condition.Path,
+			})
+		case ok && accepted.contains(valueRange):
+			warnings = append(warnings, model.LintWarning{
+				Message: fmt.Sprintf("%s is always true; %s", describe, rangeNote),
+				Path:    condition.Path,
+			})
+		case condition.Symbol == "!=" && condition.HasValue &&
+			!valueRange.contains(interval{lo: condition.Value, hi: condition.Value}):
+			warnings = append(warnings, model.LintWarning{
+				Message: fmt.Sprintf("%s is always true; %s", describe, rangeNote),
+				Path:    condition.Path,
+			})
+		}
+	}
+
+	for _, group := range structure.Groups() {
+		if len(group.Items) == 0 {
+			warnings = append(warnings, model.LintWarning{
+				Message: "Rule group has no rules, so it never matches",
+				Path:    group.Path,
+			})
+			continue
+		}
+
+		for _, same := range sameIndicatorConditions(group) {
+			name := same[0].Indicator.Name
+			valueRange, _ := indicatorRange(same[0].Indicator)
+
+			intervals := make([]interval, 0, len(same))
+			for _, condition := range same {
+				if accepted, ok := conditionInterval(condition); ok {
+					intervals = append(intervals, accepted)
+				}
+			}
+			if len(intervals) < 2 {
+				continue
+			}
+
+			if group.AllOperators("AND") {
+				combined := valueRange
+				for _, accepted := range intervals {
+					combined = combined.intersect(accepted)
+				}
+				if combined.empty() {
+					warnings = append(warnings, model.LintWarning{
+						Message: fmt.Sprintf("Conditions on %s joined by AND contradict each other, so the group is never true", name),
+						Path:    group.Path,
+					})
+				}
+			} else if group.AllOperators("OR") && covers(intervals, valueRange) {
+				warnings = append(warnings, model.LintWarning{
+					Message: fmt.Sprintf("Conditions on %s joined by OR accept every value, so the group is always true", name),
+					Path:    group.Path,
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// sameIndicatorConditions groups the conditions directly in a group by indicator and
+// settings, keeping the indicators compared more than once
+func sameIndicatorConditions(group *Group) [][]*Condition {
+	byKey := make(map[string][]*Condition)
+	var keys []string
+	for _, item := range group.Items {
+		if item.Condition == nil || item.Condition.Indicator.Name == "" {
+			continue
+		}
+		key := item.Condition.Indicator.Key()
+		if _, seen := byKey[key]; !seen {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], item.Condition)
+	}
+
+	var result [][]*Condition
+	for _, key := range keys {
+		if len(byKey[key]) > 1 {
+			result = append(result, byKey[key])
+		}
+	}
+	return result
+}
+
+// ConflictingEntryExit warns when the entry and exit rules can match on the same
+// candle, which closes positions as soon as they open
+type ConflictingEntryExit struct{}
+
+// Name identifies the rule
+func (ConflictingEntryExit) Name() string { return "conflicting-entry-exit" }
+
+// Check compares the entry and exit rules. Only rules made of AND-joined conditions
+// are compared value by value; anything with OR is only checked for being identical.
+func (ConflictingEntryExit) Check(structure *Structure) []model.LintWarning {
+	if structure.Entry == nil || structure.Exit == nil {
+		return nil
+	}
+
+	if reflect.DeepEqual(structure.Raw["buyRules"], structure.Raw["sellRules"]) {
+		return []model.LintWarning{{
+			Message: "Entry and exit rules are identical, so positions close as soon as they open",
+		}}
+	}
+
+	if !structure.Entry.Conjunctive() || !structure.Exit.Conjunctive() {
+		return nil
+	}
+
+	entry, entryNames := conjunctionRanges(structure.Entry)
+	exit, _ := conjunctionRanges(structure.Exit)
+	if entry == nil || exit == nil {
+		return nil
+	}
+
+	var overlapping []string
+	for key, entryRange := range entry {
+		exitRange, shared := exit[key]
+		if !shared {
+			continue
+		}
+		// Conditions that can't hold together on one indicator keep the rules apart
+		if entryRange.intersect(exitRange).empty() {
+			return nil
+		}
+		overlapping = append(overlapping, entryNames[key])
+	}
+	if len(overlapping) == 0 {
+		return nil
+	}
+
+	sort.Strings(overlapping)
+	return []model.LintWarning{{
+		Message: fmt.Sprintf(
+			"Entry and exit rules can both be true on the same candle (their conditions on %s overlap), so positions may close as soon as they open",
+			strings.Join(dedupe(overlapping), ", "),
+		),
+	}}
+}
+
+// conjunctionRanges returns the values each indicator may take for an AND-only group to
+// match, keyed by indicator and settings, along with the indicator names. It returns nil
+// when the group can never match.
+func conjunctionRanges(group *Group) (map[string]interval, map[string]string) {
+	ranges := make(map[string]interval)
+	names := make(map[string]string)
+
+	for _, condition := range group.Conditions() {
+		accepted, ok := conditionInterval(condition)
+		if !ok || condition.Indicator.Name == "" {
+			continue
+		}
+
+		key := condition.Indicator.Key()
+		current, seen := ranges[key]
+		if !seen {
+			current, _ = indicatorRange(condition.Indicator)
+		}
+		current = current.intersect(accepted)
+		if current.empty() {
+			return nil, nil
+		}
+
+		ranges[key] = current
+		names[key] = condition.Indicator.Name
+	}
+
+	return ranges, names
+}
+
+func dedupe(sorted []string) []string {
+	result := sorted[:0]
+	for i, value := range sorted {
+		if i == 0 || value != sorted[i-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// lookaheadIndicators are computed from candles after the one they're reported on, so
+// their values in a backtest aren't known at the time in live trading
+var lookaheadIndicators = map[string]bool{
+	"ZIGZAG":    true,
+	"FRACTAL":   true,
+	"FRACTALS":  true,
+	"PIVOTHIGH": true,
+	"PIVOTLOW":  true,
+	"DPO":       true,
+	"ICHIMOKU":  true,
+}
+
+// negativeShift matches pandas-style shifts by a negative period, which pull later rows
+// onto earlier ones
+var negativeShift = regexp.MustCompile(`shift\s*\(\s*(periods\s*=\s*)?-`)
+
+// LookaheadBias warns about indicators whose values depend on candles after the one
+// being evaluated, which makes backtest results unachievable in live trading
+type LookaheadBias struct{}
+
+// Name identifies the rule
+func (LookaheadBias) Name() string { return "lookahead-bias" }
+
+// Check reports each indicator with a lookahead risk
+func (LookaheadBias) Check(structure *Structure) []model.LintWarning {
+	var warnings []model.LintWarning
+
+	for _, condition := range structure.Conditions() {
+		indicator := condition.Indicator
+		path := condition.Path + ".indicator"
+
+		if lookaheadIndicators[normalizeName(indicator.Name)] {
+			warnings = append(warnings, model.LintWarning{
+				Message: fmt.Sprintf("%s is computed from candles after the one it is reported on, so backtests see values live trading won't have yet", indicator.Name),
+				Path:    path,
+			})
+		}
+
+		for _, key := range sortedKeys(indicator.Settings) {
+			value := indicator.Settings[key]
+			switch strings.ToLower(key) {
+			case "offset", "shift", "displacement":
+				if shift, ok := number(value); ok && shift < 0 {
+					warnings = append(warnings, model.LintWarning{
+						Message: fmt.Sprintf("%s uses a negative %s (%s), which reads candles after the current one", indicator.Name, key, formatNumber(shift)),
+						Path:    path,
+					})
+				}
+			case "center", "centered":
+				if centered, ok := value.(bool); ok && centered {
+					warnings = append(warnings, model.LintWarning{
+						Message: fmt.Sprintf("%s uses a centered window, which reads candles after the current one", indicator.Name),
+						Path:    path,
+					})
+				}
+			}
+		}
+
+		if negativeShift.MatchString(indicator.Formula) {
+			warnings = append(warnings, model.LintWarning{
+				Message: fmt.Sprintf("The formula of %s shifts by a negative period, which reads candles after the current one", indicator.Name),
+				Path:    path,
+			})
+		}
+	}
+
+	return warnings
+}
+
+// DeprecatedIndicators warns about uses of deprecated indicators, naming their replacement
+// and sunset date. Deprecations change at runtime, so the rule isn't among the defaults;
+// it is built from the current deprecations, keyed by indicator name, for each check.
+type DeprecatedIndicators struct {
+	Deprecations map[string]model.IndicatorDeprecation
+	// Now dates the sunset; the current time if zero
+	Now time.Time
+}
+
+// Name identifies the rule
+func (DeprecatedIndicators) Name() string { return "deprecated-indicator" }
+
+// Check reports each declaration and condition using a deprecated indicator
+func (r DeprecatedIndicators) Check(structure *Structure) []model.LintWarning {
+	if len(r.Deprecations) == 0 {
+		return nil
+	}
+
+	deprecations := make(map[string]model.IndicatorDeprecation, len(r.Deprecations))
+	for name, deprecation := range r.Deprecations {
+		deprecations[normalizeName(name)] = deprecation
+	}
+
+	var warnings []model.LintWarning
+	check := func(name, path string) {
+		deprecation, ok := deprecations[normalizeName(name)]
+		if name == "" || !ok {
+			return
+		}
+		warnings = append(warnings, model.LintWarning{
+			Message: r.message(name, deprecation),
+			Path:    path,
+		})
+	}
+
+	for _, declaration := range structure.Indicators {
+		check(declaration.Indicator.Name, declaration.Path)
+	}
+	for _, condition := range structure.Conditions() {
+		check(condition.Indicator.Name, condition.Path+".indicator")
+	}
+
+	return warnings
+}
+
+// message describes the deprecation of an indicator
+func (r DeprecatedIndicators) message(name string, deprecation model.IndicatorDeprecation) string {
+	message := fmt.Sprintf("%s is deprecated", name)
+	if deprecation.SunsetDate != nil {
+		now := r.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		date := deprecation.SunsetDate.Format("2006-01-02")
+		if deprecation.SunsetDate.After(now) {
+			message += fmt.Sprintf(" and will be retired on %s", date)
+		} else {
+			message += fmt.Sprintf(" and was retired on %s", date)
+		}
+	}
+	if deprecation.ReplacementName != nil {
+		message += fmt.Sprintf("; use %s instead", *deprecation.ReplacementName)
+	}
+	return message
+}
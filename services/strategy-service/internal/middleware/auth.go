@@ -3,13 +3,16 @@ package middleware
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"services/shared/apierror"
+	"services/shared/auth"
+	"services/strategy-service/internal/errcode"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -19,8 +22,10 @@ type UserClient interface {
 	ValidateUserAccess(ctx context.Context, userID int, token string) (bool, error)
 }
 
-// AuthMiddleware authenticates requests against the user service
-func AuthMiddleware(userClient UserClient, logger *zap.Logger) gin.HandlerFunc {
+// AuthMiddleware authenticates requests by verifying their access token locally. Only
+// impersonation tokens, which can be revoked before they expire, are checked with the
+// user service.
+func AuthMiddleware(verifier *auth.TokenVerifier, userClient UserClient, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -29,7 +34,7 @@ func AuthMiddleware(userClient UserClient, logger *zap.Logger) gin.HandlerFunc {
 			zap.String("auth_header_exists", strconv.FormatBool(authHeader != "")))
 
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header required")
 			c.Abort()
 			return
 		}
@@ -39,7 +44,7 @@ func AuthMiddleware(userClient UserClient, logger *zap.Logger) gin.HandlerFunc {
 		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
 			logger.Warn("Invalid authorization format",
 				zap.String("auth_header", authHeader))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid authorization format")
 			c.Abort()
 			return
 		}
@@ -53,24 +58,71 @@ func AuthMiddleware(userClient UserClient, logger *zap.Logger) gin.HandlerFunc {
 		}
 		logger.Info("Token received", zap.String("token_preview", tokenPreview))
 
-		// Parse token to get user ID and role (JWT validation happens in extractUserInfoFromToken)
-		userId, userRole, err := extractUserInfoFromToken(token)
+		// Verify the signature and expiry, and read user ID, role and permissions
+		claims, err := verifier.Verify(c.Request.Context(), token)
 		if err != nil {
-			logger.Error("Failed to extract user info from token",
+			logger.Warn("Failed to verify token",
 				zap.Error(err),
 				zap.String("token_preview", tokenPreview))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
+			if errors.Is(err, auth.ErrTokenRevoked) {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeTokenRevoked, "Token has been revoked")
+			} else {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidToken, "Invalid or expired token")
+			}
 			c.Abort()
 			return
 		}
 
+		// Impersonation sessions can be revoked, which only the user service knows about
+		if claims.ImpersonatorID != 0 {
+			valid, err := userClient.ValidateUserAccess(c.Request.Context(), claims.UserID, token)
+			if err != nil {
+				logger.Error("Failed to check impersonation token with user service", zap.Error(err))
+				apierror.Send(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Failed to validate token")
+				c.Abort()
+				return
+			}
+			if !valid {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeImpersonationEnded, "Impersonation session has ended")
+				c.Abort()
+				return
+			}
+		}
+
 		logger.Info("User info extracted from token",
-			zap.Int("extracted_userID", userId),
-			zap.String("extracted_userRole", userRole))
+			zap.Int("extracted_userID", claims.UserID),
+			zap.String("extracted_userRole", claims.Role))
 
-		// Set user ID and role in context
-		c.Set("userID", userId)
-		c.Set("userRole", userRole)
+		// Set user ID, role and permissions in context
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("userPermissions", claims.Permissions)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware sets the user context when a valid token is supplied and lets
+// anonymous requests through, for public routes whose results depend on the caller
+func OptionalAuthMiddleware(verifier *auth.TokenVerifier, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := extractTokenFromHeader(c.GetHeader("Authorization"))
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := verifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			logger.Debug("Ignoring invalid token on public route",
+				zap.Error(err),
+				zap.String("path", c.Request.URL.Path))
+			c.Next()
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("userPermissions", claims.Permissions)
 		c.Next()
 	}
 }
@@ -81,7 +133,7 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 		// Check if the user is authenticated
 		_, exists := c.Get("userID")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
 			c.Abort()
 			return
 		}
@@ -89,14 +141,14 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 		// Get the role directly from the context (set by AuthMiddleware)
 		userRole, exists := c.Get("userRole")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Role information missing"})
+			apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Role information missing")
 			c.Abort()
 			return
 		}
 
 		// Check if user has the required role
 		if userRole.(string) != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
 			c.Abort()
 			return
 		}
@@ -105,6 +157,50 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission checks that the token grants at least one of the given permissions
+func RequirePermission(requiredPermissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Check if the user is authenticated
+		_, exists := c.Get("userID")
+		if !exists {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		// Get the permissions from the context (set by AuthMiddleware)
+		value, _ := c.Get("userPermissions")
+		granted, _ := value.([]string)
+
+		for _, required := range requiredPermissions {
+			if HasPermission(granted, required) {
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
+		c.Abort()
+	}
+}
+
+// HasPermission reports whether the granted set covers the required permission.
+// "*" grants everything and "resource:*" grants every action on a resource.
+func HasPermission(granted []string, required string) bool {
+	resource := required
+	if i := strings.Index(required, ":"); i >= 0 {
+		resource = required[:i]
+	}
+
+	for _, p := range granted {
+		if p == "*" || p == required || p == resource+":*" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // extractTokenFromHeader extracts the token from the Authorization header
 func extractTokenFromHeader(authHeader string) string {
 	if authHeader == "" {
@@ -119,50 +215,53 @@ func extractTokenFromHeader(authHeader string) string {
 	return parts[1]
 }
 
-// extractUserInfoFromToken extracts both user ID and role from a JWT token
-func extractUserInfoFromToken(token string) (int, string, error) {
-	// Split the token into its parts (header.payload.signature)
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return 0, "", fmt.Errorf("invalid token format")
-	}
-
-	// Decode the payload (the middle part)
-	// Add padding if needed
-	payload := parts[1]
-	if len(payload)%4 != 0 {
-		payload += strings.Repeat("=", 4-len(payload)%4)
-	}
+// ServiceKeyVerifier verifies keys issued to other services by the user service
+type ServiceKeyVerifier interface {
+	// VerifyServiceKey returns the service a key was issued to, or "" if it isn't valid
+	VerifyServiceKey(ctx context.Context, key string) (string, error)
+}
 
-	decodedPayload, err := base64.URLEncoding.DecodeString(payload)
-	if err != nil {
-		// Try with RawURLEncoding if standard URLEncoding fails
-		decodedPayload, err = base64.RawURLEncoding.DecodeString(parts[1])
-		if err != nil {
-			return 0, "", fmt.Errorf("failed to decode payload: %w", err)
+// ServiceAuthMiddleware creates middleware to authenticate service-to-service calls. Calls
+// are accepted with the configured service key, or, when verifier is not nil, with a key
+// issued to the calling service, whose name is then set as "serviceName" in the context.
+func ServiceAuthMiddleware(serviceKey string, verifier ServiceKeyVerifier, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get service key from header
+		headerKey := c.GetHeader("X-Service-Key")
+		if headerKey == "" {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Service key required")
+			c.Abort()
+			return
 		}
-	}
-
-	// Parse the JSON payload
-	var claims struct {
-		Sub  int    `json:"sub"`
-		Type string `json:"type"`
-		Role string `json:"role"`
-	}
 
-	if err := json.Unmarshal(decodedPayload, &claims); err != nil {
-		return 0, "", fmt.Errorf("failed to parse token payload: %w", err)
-	}
+		// Validate service key
+		if serviceKey != "" && subtle.ConstantTimeCompare([]byte(headerKey), []byte(serviceKey)) == 1 {
+			c.Next()
+			return
+		}
 
-	// Check if this is an access token
-	if claims.Type != "access" && claims.Type != "" {
-		return 0, "", fmt.Errorf("not an access token")
-	}
+		serviceName := ""
+		if verifier != nil {
+			var err error
+			serviceName, err = verifier.VerifyServiceKey(c.Request.Context(), headerKey)
+			if err != nil {
+				logger.Error("Failed to verify service key", zap.Error(err))
+				apierror.Send(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Failed to verify service key")
+				c.Abort()
+				return
+			}
+		}
+		if serviceName == "" {
+			logger.Warn("Invalid service key",
+				zap.String("IP", c.ClientIP()),
+				zap.String("Path", c.Request.URL.Path))
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid service key")
+			c.Abort()
+			return
+		}
 
-	// If role is empty, default to "user"
-	if claims.Role == "" {
-		claims.Role = "user"
+		// Service is authenticated
+		c.Set("serviceName", serviceName)
+		c.Next()
 	}
-
-	return claims.Sub, claims.Role, nil
 }
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Coupon discount types
+const (
+	CouponDiscountPercent = "percent" // DiscountValue is a percentage of the price (0-100)
+	CouponDiscountFixed   = "fixed"   // DiscountValue is an amount off the price, in the listing currency
+)
+
+// Coupon is a discount code a seller created for one of their listings
+type Coupon struct {
+	ID               int        `json:"id" db:"id"`
+	MarketplaceID    int        `json:"marketplace_id" db:"marketplace_id"`
+	Code             string     `json:"code" db:"code"`
+	DiscountType     string     `json:"discount_type" db:"discount_type"`
+	DiscountValue    float64    `json:"discount_value" db:"discount_value"`
+	MaxRedemptions   *int       `json:"max_redemptions,omitempty" db:"max_redemptions"`
+	RedemptionsCount int        `json:"redemptions_count" db:"redemptions_count"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// CouponCreate represents the data needed to create a coupon
+type CouponCreate struct {
+	Code           string     `json:"code" binding:"required,min=3,max=32"`
+	DiscountType   string     `json:"discount_type" binding:"required,oneof=percent fixed"`
+	DiscountValue  float64    `json:"discount_value" binding:"required,gt=0"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty" binding:"omitempty,min=1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// CouponUpdate represents the data needed to update a coupon. The code can't be changed.
+type CouponUpdate struct {
+	DiscountType   string     `json:"discount_type" binding:"required,oneof=percent fixed"`
+	DiscountValue  float64    `json:"discount_value" binding:"required,gt=0"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty" binding:"omitempty,min=1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	IsActive       *bool      `json:"is_active,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// DefaultCurrency is the currency of listings and purchases that don't name one. Exchange
+// rates are stored against it.
+const DefaultCurrency = "USD"
+
+// Currency is a currency listings can be priced and displayed in
+type Currency struct {
+	Code     string  `json:"code" db:"code"`
+	Name     string  `json:"name" db:"name"`
+	Symbol   string  `json:"symbol" db:"symbol"`
+	Decimals int     `json:"decimals" db:"decimals"`
+	IsActive bool    `json:"-" db:"is_active"`
+	Rate     float64 `json:"rate,omitempty" db:"-"` // Units per US dollar, when known
+}
+
+// FXRates holds the exchange rates of one day, in units of each currency per US dollar
+type FXRates struct {
+	Date  time.Time          `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
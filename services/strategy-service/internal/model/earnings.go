@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// RevenuePoint represents a seller's revenue for a single period
+type RevenuePoint struct {
+	PeriodStart        time.Time `json:"period_start" db:"period_start"`
+	Revenue            float64   `json:"revenue" db:"revenue"`
+	PurchasesCount     int       `json:"purchases_count" db:"purchases_count"`
+	SubscriptionsCount int       `json:"subscriptions_count" db:"subscriptions_count"`
+}
+
+// ListingSales represents the sales of a single marketplace listing
+type ListingSales struct {
+	MarketplaceID       int     `json:"marketplace_id" db:"marketplace_id"`
+	StrategyID          int     `json:"strategy_id" db:"strategy_id"`
+	Name                string  `json:"name" db:"name"`
+	Price               float64 `json:"price" db:"price"`
+	IsSubscription      bool    `json:"is_subscription" db:"is_subscription"`
+	IsActive            bool    `json:"is_active" db:"is_active"`
+	PurchasesCount      int     `json:"purchases_count" db:"purchases_count"`
+	Revenue             float64 `json:"revenue" db:"revenue"`
+	ActiveSubscriptions int     `json:"active_subscriptions" db:"active_subscriptions"`
+	LifetimeRevenue     float64 `json:"lifetime_revenue" db:"lifetime_revenue"`
+}
+
+// ChurnPoint represents subscription churn for a single period
+type ChurnPoint struct {
+	PeriodStart      time.Time `json:"period_start" db:"period_start"`
+	ActiveAtStart    int       `json:"active_at_start" db:"active_at_start"`
+	NewSubscriptions int       `json:"new_subscriptions" db:"new_subscriptions"`
+	Churned          int       `json:"churned" db:"churned"`
+	ChurnRate        float64   `json:"churn_rate" db:"churn_rate"`
+}
+
+// SalesSummary represents totals over the requested range
+type SalesSummary struct {
+	TotalRevenue         float64 `json:"total_revenue"`
+	TotalPurchases       int     `json:"total_purchases"`
+	ActiveSubscriptions  int     `json:"active_subscriptions"`
+	ChurnedSubscriptions int     `json:"churned_subscriptions"`
+}
+
+// SellerSales represents the seller earnings dashboard
+type SellerSales struct {
+	StartDate time.Time      `json:"start_date"`
+	EndDate   time.Time      `json:"end_date"`
+	Interval  string         `json:"interval"`
+	Summary   SalesSummary   `json:"summary"`
+	Revenue   []RevenuePoint `json:"revenue"`
+	Listings  []ListingSales `json:"listings"`
+	Churn     []ChurnPoint   `json:"churn"`
+}
+
+// PayoutPeriod represents a seller's payout for a single month
+type PayoutPeriod struct {
+	PeriodStart    time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd      time.Time `json:"period_end" db:"period_end"`
+	GrossRevenue   float64   `json:"gross_revenue" db:"gross_revenue"`
+	PlatformFee    float64   `json:"platform_fee" db:"platform_fee"`
+	NetRevenue     float64   `json:"net_revenue" db:"net_revenue"`
+	PurchasesCount int       `json:"purchases_count" db:"purchases_count"`
+	Status         string    `json:"status" db:"status"` // open, closed
+}
+
+// SellerPayouts represents payout period summaries and their totals
+type SellerPayouts struct {
+	PlatformFeePercent float64        `json:"platform_fee_percent"`
+	TotalGross         float64        `json:"total_gross"`
+	TotalFees          float64        `json:"total_fees"`
+	TotalNet           float64        `json:"total_net"`
+	PendingNet         float64        `json:"pending_net"`
+	Periods            []PayoutPeriod `json:"periods"`
+}
@@ -4,19 +4,90 @@ import (
 	"time"
 )
 
-// TechnicalIndicator represents a technical indicator definition
+// TechnicalIndicator represents a technical indicator definition.
+// Platform indicators have no owner; user-defined indicators have an OwnerID.
 type TechnicalIndicator struct {
-	ID          int                  `json:"id" db:"id"`
-	Name        string               `json:"name" db:"name"`
-	Description string               `json:"description" db:"description"`
-	Category    string               `json:"category" db:"category"`
-	Formula     string               `json:"formula" db:"formula"`
-	MinValue    *float64             `json:"min_value,omitempty" db:"min_value"`
-	MaxValue    *float64             `json:"max_value,omitempty" db:"max_value"`
-	IsActive    bool                 `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
-	UpdatedAt   *time.Time           `json:"updated_at,omitempty" db:"updated_at"`
-	Parameters  []IndicatorParameter `json:"parameters,omitempty" db:"-"`
+	ID          int                   `json:"id" db:"id"`
+	Name        string                `json:"name" db:"name"`
+	Description string                `json:"description" db:"description"`
+	Category    string                `json:"category" db:"category"`
+	Formula     string                `json:"formula" db:"formula"`
+	MinValue    *float64              `json:"min_value,omitempty" db:"min_value"`
+	MaxValue    *float64              `json:"max_value,omitempty" db:"max_value"`
+	IsActive    bool                  `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time            `json:"updated_at,omitempty" db:"updated_at"`
+	Parameters  []IndicatorParameter  `json:"parameters,omitempty" db:"-"`
+	OwnerID     *int                  `json:"owner_id,omitempty" db:"owner_id"`
+	Visibility  string                `json:"visibility" db:"visibility"`
+	Deprecation *IndicatorDeprecation `json:"deprecation,omitempty" db:"-"`
+}
+
+// Indicator visibility values
+const (
+	IndicatorVisibilityPublic  = "public"
+	IndicatorVisibilityPrivate = "private"
+)
+
+// IndicatorDeprecation marks an indicator as on its way out. It stays usable until the
+// sunset date, if any; strategies using it should move to the replacement.
+type IndicatorDeprecation struct {
+	IndicatorID     int        `json:"indicator_id" db:"indicator_id"`
+	IndicatorName   string     `json:"indicator_name" db:"indicator_name"`
+	ReplacementID   *int       `json:"replacement_id,omitempty" db:"replacement_id"`
+	ReplacementName *string    `json:"replacement_name,omitempty" db:"replacement_name"`
+	SunsetDate      *time.Time `json:"sunset_date,omitempty" db:"sunset_date"`
+	Reason          *string    `json:"reason,omitempty" db:"reason"`
+	DeprecatedAt    time.Time  `json:"deprecated_at" db:"deprecated_at"`
+}
+
+// IndicatorDeprecate represents deprecating an indicator. SunsetDate is YYYY-MM-DD.
+type IndicatorDeprecate struct {
+	ReplacementID *int   `json:"replacement_id" binding:"omitempty,min=1"`
+	SunsetDate    string `json:"sunset_date" binding:"omitempty,datetime=2006-01-02"`
+	Reason        string `json:"reason" binding:"max=500"`
+}
+
+// DeprecatedIndicatorUsage is a strategy whose current version still uses a deprecated
+// indicator
+type DeprecatedIndicatorUsage struct {
+	IndicatorID     int        `json:"indicator_id" db:"indicator_id"`
+	IndicatorName   string     `json:"indicator_name" db:"indicator_name"`
+	ReplacementName *string    `json:"replacement_name,omitempty" db:"replacement_name"`
+	SunsetDate      *time.Time `json:"sunset_date,omitempty" db:"sunset_date"`
+	StrategyID      int        `json:"strategy_id" db:"strategy_id"`
+	StrategyGroupID int        `json:"strategy_group_id" db:"strategy_group_id"`
+	StrategyName    string     `json:"strategy_name" db:"strategy_name"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	Version         int        `json:"version" db:"version"`
+	IsListed        bool       `json:"is_listed" db:"is_listed"` // Sold on the marketplace, so buyers are affected too
+}
+
+// DeprecatedIndicatorReport lists the strategies still using deprecated indicators
+type DeprecatedIndicatorReport struct {
+	Deprecations []IndicatorDeprecation     `json:"deprecations"`
+	Usage        []DeprecatedIndicatorUsage `json:"usage"`
+}
+
+// CustomIndicatorCreate represents the data needed to register a user-defined indicator.
+// The formula is an expression over the price series (open, high, low, close, volume)
+// and the indicator's parameters, evaluated by the backtesting service.
+type CustomIndicatorCreate struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Category    string                     `json:"category"`
+	Formula     string                     `json:"formula"`
+	MinValue    *float64                   `json:"min_value"`
+	MaxValue    *float64                   `json:"max_value"`
+	Visibility  string                     `json:"visibility"`
+	Parameters  []IndicatorParameterCreate `json:"parameters"`
+}
+
+// IndicatorValidationResult is the backtesting service's verdict on an indicator formula
+type IndicatorValidationResult struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
 }
 
 // IndicatorParameter represents a parameter for a technical indicator
@@ -79,3 +150,88 @@ type ParameterFromBacktesting struct {
 	Description string        `json:"description,omitempty"`
 	Options     []interface{} `json:"options,omitempty"`
 }
+
+// IndicatorSyncReport describes what a sync from the backtesting service changed, or would
+// change when DryRun is set
+type IndicatorSyncReport struct {
+	DryRun      bool                  `json:"dry_run"`
+	Created     []IndicatorSyncChange `json:"created"`
+	Updated     []IndicatorSyncChange `json:"updated"`
+	Deactivated []IndicatorSyncChange `json:"deactivated"`
+	// Missing lists platform indicators the backtesting service no longer offers; they are left as they are
+	Missing   []IndicatorSyncChange `json:"missing"`
+	Unchanged int                   `json:"unchanged"`
+	Synced    int                   `json:"synced"`
+}
+
+// IndicatorSyncChange is one indicator in a sync report. ID is unset for indicators a dry run
+// would create.
+type IndicatorSyncChange struct {
+	ID      *int              `json:"id,omitempty"`
+	Name    string            `json:"name"`
+	Changes []IndicatorChange `json:"changes,omitempty"`
+}
+
+// IndicatorChange is a single field change. Parameter fields are named "parameters.{name}.{field}".
+type IndicatorChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Conflict resolution modes for indicator imports
+const (
+	// IndicatorImportSkip leaves existing indicators untouched
+	IndicatorImportSkip = "skip"
+	// IndicatorImportOverwrite replaces existing indicators, including their parameters
+	IndicatorImportOverwrite = "overwrite"
+	// IndicatorImportMerge updates existing indicators and adds missing parameters and
+	// enum values, keeping anything the import doesn't mention
+	IndicatorImportMerge = "merge"
+)
+
+// IndicatorCatalog is the platform indicator catalog as exported and imported between environments
+type IndicatorCatalog struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Indicators []IndicatorExport `json:"indicators"`
+}
+
+// IndicatorExport is an indicator in an exported catalog. Indicators are matched by name.
+type IndicatorExport struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Category    string                     `json:"category"`
+	Formula     string                     `json:"formula,omitempty"`
+	MinValue    *float64                   `json:"min_value,omitempty"`
+	MaxValue    *float64                   `json:"max_value,omitempty"`
+	IsActive    bool                       `json:"is_active"`
+	Parameters  []IndicatorParameterExport `json:"parameters"`
+}
+
+// IndicatorParameterExport is an indicator parameter in an exported catalog
+type IndicatorParameterExport struct {
+	Name         string                     `json:"name"`
+	Type         string                     `json:"type"`
+	IsRequired   bool                       `json:"is_required"`
+	MinValue     *float64                   `json:"min_value,omitempty"`
+	MaxValue     *float64                   `json:"max_value,omitempty"`
+	DefaultValue string                     `json:"default_value,omitempty"`
+	Description  string                     `json:"description,omitempty"`
+	IsPublic     bool                       `json:"is_public"`
+	EnumValues   []ParameterEnumValueExport `json:"enum_values,omitempty"`
+}
+
+// ParameterEnumValueExport is a parameter enum value in an exported catalog
+type ParameterEnumValueExport struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// IndicatorImportReport lists the indicators an import created, updated and skipped
+type IndicatorImportReport struct {
+	Mode    string   `json:"mode"`
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
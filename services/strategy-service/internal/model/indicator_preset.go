@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IndicatorPreset is a named set of parameter values for an indicator, e.g. "RSI aggressive".
+// Presets without a user are global. Strategy structures reference presets with "presetId"
+// next to "indicatorSettings"; settings given in the structure override the preset's.
+type IndicatorPreset struct {
+	ID            int             `json:"id" db:"id"`
+	IndicatorID   int             `json:"indicator_id" db:"indicator_id"`
+	IndicatorName string          `json:"indicator_name" db:"indicator_name"`
+	UserID        *int            `json:"user_id,omitempty" db:"user_id"`
+	IsGlobal      bool            `json:"is_global" db:"-"`
+	Name          string          `json:"name" db:"name"`
+	Description   string          `json:"description" db:"description"`
+	Settings      json.RawMessage `json:"settings" db:"settings" swaggertype:"object"` // Parameter name to value
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     *time.Time      `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// IndicatorPresetInput represents the data needed to create or update an indicator preset
+type IndicatorPresetInput struct {
+	Name        string                 `json:"name" binding:"required,max=100"`
+	Description string                 `json:"description"`
+	Settings    map[string]interface{} `json:"settings" binding:"required"`
+	Global      bool                   `json:"global"` // Creates a global preset; requires indicators:write. Ignored on update.
+}
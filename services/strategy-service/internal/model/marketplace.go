@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,10 +12,13 @@ type MarketplaceItem struct {
 	VersionID          int        `json:"version_id" db:"version_id"`
 	UserID             int        `json:"user_id" db:"user_id"`
 	Price              float64    `json:"price" db:"price"`
+	Currency           string     `json:"currency" db:"currency"`
 	IsSubscription     bool       `json:"is_subscription" db:"is_subscription"`
 	SubscriptionPeriod string     `json:"subscription_period,omitempty" db:"subscription_period"`
 	IsActive           bool       `json:"is_active" db:"is_active"`
 	DescriptionPublic  string     `json:"description_public" db:"description_public"`
+	UpdatePolicy       string     `json:"update_policy,omitempty" db:"update_policy"`
+	UpgradePrice       *float64   `json:"upgrade_price,omitempty" db:"upgrade_price"`
 	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt          *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 
@@ -27,16 +31,188 @@ type MarketplaceItem struct {
 	AverageRating   float64   `json:"average_rating,omitempty" db:"-"`
 	ReviewsCount    int       `json:"reviews_count,omitempty" db:"-"`
 	PurchasesCount  int       `json:"purchases_count,omitempty" db:"-"`
+	Relevance       float64   `json:"relevance,omitempty" db:"-"`
+
+	// Lowest price of the last 30 days in the listing's currency, for discount transparency
+	LowestPrice30d *float64 `json:"lowest_price_30d,omitempty" db:"-"`
+
+	// Prices converted to the currency requested with ?currency=, only set when one was requested
+	DisplayPrice          *float64 `json:"display_price,omitempty" db:"-"`
+	DisplayLowestPrice30d *float64 `json:"display_lowest_price_30d,omitempty" db:"-"`
+	DisplayCurrency       string   `json:"display_currency,omitempty" db:"-"`
+
+	// Trending and recommendation ranking, only set on those lists
+	TrendingScore float64 `json:"trending_score,omitempty" db:"-"`
+	MatchScore    float64 `json:"match_score,omitempty" db:"-"`
+
+	// Verified backtest badge; the snapshot itself is only loaded for single listings
+	HasVerifiedBacktest bool              `json:"has_verified_backtest" db:"-"`
+	VerifiedBacktest    *VerifiedBacktest `json:"verified_backtest,omitempty" db:"-"`
+
+	// Risk score of the listed strategy version, once computed
+	RiskScore *StrategyRiskScore `json:"risk_score,omitempty" db:"-"`
+}
+
+// Update policies of listings: whether buyers get the versions published after their purchase
+const (
+	ListingUpdatePolicyLifetime = "lifetime_updates"
+	ListingUpdatePolicyLocked   = "version_locked" // Later versions need an upgrade
+)
+
+// Listing event types tracked for trending scores and recommendations
+const (
+	ListingEventView     = "view"
+	ListingEventPurchase = "purchase"
+)
+
+// TrendingParams controls how listing views and purchases add up to trending scores
+type TrendingParams struct {
+	Since          time.Time // Only views and purchases since then count
+	HalfLifeHours  float64   // Age at which a view or purchase counts half
+	ViewWeight     float64
+	PurchaseWeight float64
+}
+
+// MarketplaceCursor marks the last listing of a page of listings. The sort order is part
+// of the cursor so it can't be reused with a different order.
+type MarketplaceCursor struct {
+	SortBy        string `json:"sort_by"`
+	SortDirection string `json:"sort_direction"`
+	Value         string `json:"value"`
+	ID            int    `json:"id"`
+}
+
+// VerifiedBacktest is a read-only snapshot of a backtest attached to a listing.
+// Metrics are copied from the historical service when attached and can't be edited.
+type VerifiedBacktest struct {
+	ID                  int             `json:"id" db:"id"`
+	MarketplaceID       int             `json:"marketplace_id" db:"marketplace_id"`
+	BacktestID          int             `json:"backtest_id" db:"backtest_id"`
+	StrategyID          int             `json:"strategy_id" db:"strategy_id"`
+	StrategyVersion     int             `json:"strategy_version" db:"strategy_version"`
+	Timeframe           string          `json:"timeframe" db:"timeframe"`
+	StartDate           time.Time       `json:"start_date" db:"start_date"`
+	EndDate             time.Time       `json:"end_date" db:"end_date"`
+	InitialCapital      float64         `json:"initial_capital" db:"initial_capital"`
+	TotalTrades         int             `json:"total_trades" db:"total_trades"`
+	WinRate             float64         `json:"win_rate" db:"win_rate"`
+	ProfitFactor        float64         `json:"profit_factor" db:"profit_factor"`
+	SharpeRatio         float64         `json:"sharpe_ratio" db:"sharpe_ratio"`
+	MaxDrawdown         float64         `json:"max_drawdown" db:"max_drawdown"`
+	TotalReturn         float64         `json:"total_return" db:"total_return"`
+	AnnualizedReturn    float64         `json:"annualized_return" db:"annualized_return"`
+	Runs                json.RawMessage `json:"runs" db:"runs" swaggertype:"array,object"`
+	BacktestCompletedAt *time.Time      `json:"backtest_completed_at,omitempty" db:"backtest_completed_at"`
+	VerifiedAt          time.Time       `json:"verified_at" db:"verified_at"`
+	Leverage            float64         `json:"leverage" db:"leverage"`
+	PositionSizing      string          `json:"position_sizing" db:"position_sizing"`
+}
+
+// VerifiedBacktestRun is the per-symbol result stored in a verified backtest snapshot
+type VerifiedBacktestRun struct {
+	RunID            int     `json:"run_id"`
+	Symbol           string  `json:"symbol"`
+	TotalTrades      int     `json:"total_trades"`
+	WinningTrades    int     `json:"winning_trades"`
+	LosingTrades     int     `json:"losing_trades"`
+	ProfitFactor     float64 `json:"profit_factor"`
+	SharpeRatio      float64 `json:"sharpe_ratio"`
+	MaxDrawdown      float64 `json:"max_drawdown"`
+	FinalCapital     float64 `json:"final_capital"`
+	TotalReturn      float64 `json:"total_return"`
+	AnnualizedReturn float64 `json:"annualized_return"`
+}
+
+// AttachBacktestRequest represents a request to attach a backtest to a listing
+type AttachBacktestRequest struct {
+	BacktestID int `json:"backtest_id" binding:"required"`
+}
+
+// ListingVersion is a changelog entry of a listing: a strategy version published to it
+type ListingVersion struct {
+	Version     int       `json:"version" db:"version"`
+	Notes       string    `json:"notes,omitempty" db:"notes"`
+	PublishedAt time.Time `json:"published_at" db:"published_at"`
+	IsCurrent   bool      `json:"is_current" db:"is_current"`
+}
+
+// ListingPrice is a price a listing had, from when it took effect until the next one
+type ListingPrice struct {
+	Price     float64   `json:"price" db:"price"`
+	Currency  string    `json:"currency" db:"currency"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// ListingVersionPublish represents a seller publishing a newer strategy version to a listing
+type ListingVersionPublish struct {
+	Version          int    `json:"version" binding:"required,min=1"`
+	Notes            string `json:"notes" binding:"max=5000"`
+	NotifyPurchasers bool   `json:"notify_purchasers"` // Notify buyers who still have access
+}
+
+// PurchaseRequest represents the optional body of a purchase request
+type PurchaseRequest struct {
+	CouponCode string `json:"coupon_code" binding:"omitempty,max=32"`
+	// UseReferralCredit pays what the coupon leaves with the buyer's referral credit, as far
+	// as it goes and if it's in the listing's currency
+	UseReferralCredit bool `json:"use_referral_credit"`
+	// UseWalletBalance pays what's left after the coupon and referral credit from the
+	// buyer's wallet, as far as its balance in the listing's currency goes
+	UseWalletBalance bool `json:"use_wallet_balance"`
+}
+
+// MarketplaceFacetValue is a single filter option with the number of matching listings
+type MarketplaceFacetValue struct {
+	Key   string `json:"key" db:"facet_key"`
+	Label string `json:"label" db:"label"`
+	Count int    `json:"count" db:"count"`
+}
+
+// MarketplaceFacets holds the filter counts shown on the marketplace browse page
+type MarketplaceFacets struct {
+	Tags    []MarketplaceFacetValue `json:"tags"`
+	Price   []MarketplaceFacetValue `json:"price"`
+	Ratings []MarketplaceFacetValue `json:"ratings"`
+}
+
+// SellerStats holds the public marketplace statistics of a seller shown on their profile
+type SellerStats struct {
+	SellerID      int     `json:"seller_id" db:"-"`
+	ListingsCount int     `json:"listings_count" db:"listings_count"`
+	SalesCount    int     `json:"sales_count" db:"sales_count"`
+	ReviewsCount  int     `json:"reviews_count" db:"reviews_count"`
+	AverageRating float64 `json:"average_rating" db:"average_rating"`
 }
 
 // MarketplaceCreate represents data needed to create a marketplace listing
 type MarketplaceCreate struct {
-	StrategyID         int     `json:"strategy_id" binding:"required"`
-	VersionID          int     `json:"version_id" binding:"required"`
-	Price              float64 `json:"price" binding:"min=0"`
-	IsSubscription     bool    `json:"is_subscription"`
-	SubscriptionPeriod string  `json:"subscription_period,omitempty"`
-	DescriptionPublic  string  `json:"description_public"`
+	StrategyID         int      `json:"strategy_id" binding:"required"`
+	VersionID          int      `json:"version_id" binding:"required"`
+	Price              float64  `json:"price" binding:"min=0"`
+	Currency           string   `json:"currency,omitempty" binding:"omitempty,len=3"` // Defaults to USD
+	IsSubscription     bool     `json:"is_subscription"`
+	SubscriptionPeriod string   `json:"subscription_period,omitempty"`
+	DescriptionPublic  string   `json:"description_public"`
+	UpdatePolicy       string   `json:"update_policy,omitempty" binding:"omitempty,oneof=lifetime_updates version_locked"` // Defaults to version_locked
+	UpgradePrice       *float64 `json:"upgrade_price,omitempty" binding:"omitempty,min=0"`                                 // Defaults to the price
+}
+
+// ListingUpdatePolicyUpdate represents a seller changing whether buyers get later versions
+type ListingUpdatePolicyUpdate struct {
+	UpdatePolicy string   `json:"update_policy" binding:"required,oneof=lifetime_updates version_locked"`
+	UpgradePrice *float64 `json:"upgrade_price,omitempty" binding:"omitempty,min=0"` // Defaults to the price
+}
+
+// PurchaseUpgrade is the upgrade of a version-locked purchase to the listing's current
+// version. FromVersion and ToVersion are strategy version IDs.
+type PurchaseUpgrade struct {
+	ID          int       `json:"id" db:"id"`
+	PurchaseID  int       `json:"purchase_id" db:"purchase_id"`
+	FromVersion int       `json:"from_version" db:"from_version"`
+	ToVersion   int       `json:"to_version" db:"to_version"`
+	Price       float64   `json:"price" db:"price"`
+	Currency    string    `json:"currency" db:"currency"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
 // StrategyPurchase represents a purchase of a strategy from the marketplace
@@ -45,10 +221,52 @@ type StrategyPurchase struct {
 	MarketplaceID   int        `json:"marketplace_id" db:"marketplace_id"`
 	BuyerID         int        `json:"buyer_id" db:"buyer_id"`
 	PurchasePrice   float64    `json:"purchase_price" db:"purchase_price"`
+	OriginalPrice   float64    `json:"original_price" db:"original_price"`
+	DiscountAmount  float64    `json:"discount_amount" db:"discount_amount"`
+	CreditAmount    float64    `json:"credit_amount,omitempty" db:"credit_amount"` // Paid with referral credit
+	WalletAmount    float64    `json:"wallet_amount,omitempty" db:"wallet_amount"` // Paid from the buyer's wallet
+	CouponCode      string     `json:"coupon_code,omitempty" db:"coupon_code"`
+	Currency        string     `json:"currency" db:"currency"`
 	SubscriptionEnd *time.Time `json:"subscription_end,omitempty" db:"subscription_end"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
 
+// PurchaseDetails represents a purchase with its current subscription state
+type PurchaseDetails struct {
+	ID                    int        `json:"id" db:"id"`
+	MarketplaceID         int        `json:"marketplace_id" db:"marketplace_id"`
+	BuyerID               int        `json:"buyer_id" db:"buyer_id"`
+	SellerID              int        `json:"seller_id" db:"seller_id"`
+	StrategyID            int        `json:"strategy_id" db:"strategy_id"`
+	StrategyName          string     `json:"strategy_name" db:"strategy_name"`
+	StrategyVersion       int        `json:"strategy_version" db:"strategy_version"`
+	PurchasePrice         float64    `json:"purchase_price" db:"purchase_price"`
+	OriginalPrice         float64    `json:"original_price" db:"original_price"`
+	DiscountAmount        float64    `json:"discount_amount" db:"discount_amount"`
+	CouponCode            *string    `json:"coupon_code,omitempty" db:"coupon_code"`
+	Currency              string     `json:"currency" db:"currency"`
+	IsSubscription        bool       `json:"is_subscription" db:"is_subscription"`
+	SubscriptionPeriod    *string    `json:"subscription_period,omitempty" db:"subscription_period"`
+	SubscriptionEnd       *time.Time `json:"subscription_end,omitempty" db:"subscription_end"`
+	Status                string     `json:"status" db:"status"` // active, cancelled, expired, refunded
+	HasAccess             bool       `json:"has_access" db:"has_access"`
+	DaysRemaining         *int       `json:"days_remaining,omitempty" db:"days_remaining"`
+	RenewalReminderSentAt *time.Time `json:"renewal_reminder_sent_at,omitempty" db:"renewal_reminder_sent_at"`
+	ExpiredAt             *time.Time `json:"expired_at,omitempty" db:"expired_at"`
+	RefundStatus          *string    `json:"refund_status,omitempty" db:"refund_status"` // Status of the latest refund request
+	RefundedAt            *time.Time `json:"refunded_at,omitempty" db:"refunded_at"`
+	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SubscriptionEvent represents a subscription that expired or is due for renewal
+type SubscriptionEvent struct {
+	PurchaseID      int       `db:"purchase_id"`
+	BuyerID         int       `db:"buyer_id"`
+	MarketplaceID   int       `db:"marketplace_id"`
+	StrategyName    string    `db:"strategy_name"`
+	SubscriptionEnd time.Time `db:"subscription_end"`
+}
+
 // StrategyReview represents a review of a purchased strategy
 type StrategyReview struct {
 	ID            int        `json:"id" db:"id"`
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Refund request statuses
+const (
+	RefundStatusPending  = "pending"
+	RefundStatusApproved = "approved"
+	RefundStatusRejected = "rejected"
+)
+
+// RefundRequest is a buyer's request to refund a purchase, reviewed by the seller or a moderator
+type RefundRequest struct {
+	ID               int        `json:"id" db:"id"`
+	PurchaseID       int        `json:"purchase_id" db:"purchase_id"`
+	MarketplaceID    int        `json:"marketplace_id" db:"marketplace_id"`
+	StrategyName     string     `json:"strategy_name" db:"strategy_name"`
+	BuyerID          int        `json:"buyer_id" db:"buyer_id"`
+	SellerID         int        `json:"seller_id" db:"seller_id"`
+	Reason           string     `json:"reason" db:"reason"`
+	Status           string     `json:"status" db:"status"` // pending, approved, rejected
+	Amount           float64    `json:"amount" db:"amount"`
+	Currency         string     `json:"currency" db:"currency"`
+	ReviewerID       *int       `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	ReviewNote       *string    `json:"review_note,omitempty" db:"review_note"`
+	ProviderRefundID *string    `json:"provider_refund_id,omitempty" db:"provider_refund_id"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// RefundRequestCreate represents the data needed to request a refund
+type RefundRequestCreate struct {
+	Reason string `json:"reason" binding:"required,min=10,max=2000"`
+}
+
+// RefundReview represents a seller's or moderator's decision on a refund request
+type RefundReview struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	Note     string `json:"note,omitempty" binding:"max=2000"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Report target types
+const (
+	ReportTargetListing = "listing"
+	ReportTargetReview  = "review"
+)
+
+// Report statuses
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Report resolution actions
+const (
+	ReportActionDismiss      = "dismiss"
+	ReportActionHideListing  = "hide_listing"
+	ReportActionRemoveReview = "remove_review"
+	ReportActionWarnUser     = "warn_user"
+)
+
+// ContentReport is a user's report of a marketplace listing or review, resolved by a moderator
+type ContentReport struct {
+	ID             int     `json:"id" db:"id"`
+	TargetType     string  `json:"target_type" db:"target_type"` // listing, review
+	TargetID       int     `json:"target_id" db:"target_id"`
+	MarketplaceID  int     `json:"marketplace_id" db:"marketplace_id"`
+	TargetUserID   int     `json:"target_user_id" db:"target_user_id"` // Seller of the listing or author of the review
+	Content        *string `json:"content,omitempty" db:"content"`     // Strategy name or review comment when reported
+	ReporterID     int     `json:"reporter_id" db:"reporter_id"`
+	Category       string  `json:"category" db:"category"`
+	Details        *string `json:"details,omitempty" db:"details"`
+	Status         string  `json:"status" db:"status"` // open, resolved, dismissed
+	Action         *string `json:"action,omitempty" db:"action"`
+	ResolverID     *int    `json:"resolver_id,omitempty" db:"resolver_id"`
+	ResolutionNote *string `json:"resolution_note,omitempty" db:"resolution_note"`
+
+	// Open reports of the same target and warnings its owner already received
+	TargetReports      int `json:"target_reports" db:"target_reports"`
+	TargetUserWarnings int `json:"target_user_warnings" db:"target_user_warnings"`
+
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// ReportCreate represents the data needed to report a listing or review
+type ReportCreate struct {
+	Category string `json:"category" binding:"required,oneof=spam fraud misleading offensive copyright other"`
+	Details  string `json:"details,omitempty" binding:"max=2000"`
+}
+
+// ReportResolution represents a moderator's resolution of a report
+type ReportResolution struct {
+	Action string `json:"action" binding:"required,oneof=dismiss hide_listing remove_review warn_user"`
+	Note   string `json:"note,omitempty" binding:"max=2000"`
+}
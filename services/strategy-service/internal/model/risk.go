@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Risk levels of strategies, by score
+const (
+	RiskLevelLow    = "low"    // Below 34
+	RiskLevelMedium = "medium" // Below 67
+	RiskLevelHigh   = "high"
+)
+
+// Risk factors a strategy's risk score is made of
+const (
+	RiskFactorDrawdown       = "drawdown"
+	RiskFactorLeverage       = "leverage"
+	RiskFactorPositionSizing = "position_sizing"
+	RiskFactorTradeFrequency = "trade_frequency"
+	RiskFactorExitRules      = "exit_rules"
+)
+
+// StrategyRiskScore is the risk score of a strategy version, from 0 (lowest risk) to 100.
+// It is computed from the verified backtests of the version and from heuristics on its
+// structure, and recomputed when a verified backtest is attached.
+type StrategyRiskScore struct {
+	StrategyID    int             `json:"strategy_id" db:"strategy_id"`
+	Score         int             `json:"score" db:"score"`
+	Level         string          `json:"level" db:"level"`
+	Components    json.RawMessage `json:"components" db:"components" swaggertype:"array,object"` // []RiskComponent
+	BacktestCount int             `json:"backtest_count" db:"backtest_count"`
+	ComputedAt    time.Time       `json:"computed_at" db:"computed_at"`
+}
+
+// RiskComponent is the score of one risk factor and how much it weighs in the total
+type RiskComponent struct {
+	Factor string  `json:"factor"`
+	Score  int     `json:"score"`
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// Lists of the strategy service saved views can be applied to
+const (
+	ViewListStrategies  = "strategies"
+	ViewListMarketplace = "marketplace"
+)
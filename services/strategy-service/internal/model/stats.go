@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// StrategyStats represents platform strategy and marketplace KPIs over a time window
+type StrategyStats struct {
+	Window              string    `json:"window"`
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	TotalStrategies     int64     `json:"total_strategies" db:"total_strategies"`
+	StrategiesCreated   int64     `json:"strategies_created" db:"strategies_created"`
+	PublicStrategies    int64     `json:"public_strategies" db:"public_strategies"`
+	ActiveListings      int64     `json:"active_listings" db:"active_listings"`
+	ListingsCreated     int64     `json:"listings_created" db:"listings_created"`
+	Purchases           int64     `json:"purchases" db:"purchases"`
+	GMV                 float64   `json:"gmv" db:"gmv"`
+	PlatformRevenue     float64   `json:"platform_revenue"`
+	UniqueBuyers        int64     `json:"unique_buyers" db:"unique_buyers"`
+	ActiveSubscriptions int64     `json:"active_subscriptions" db:"active_subscriptions"`
+	GeneratedAt         time.Time `json:"generated_at"`
+}
@@ -12,7 +12,7 @@ type Strategy struct {
 	UserID          int             `json:"user_id" db:"user_id"`
 	Description     string          `json:"description" db:"description"`
 	ThumbnailURL    string          `json:"thumbnail_url" db:"thumbnail_url"`
-	Structure       json.RawMessage `json:"structure" db:"structure"`
+	Structure       json.RawMessage `json:"structure" db:"structure" swaggertype:"object"`
 	IsPublic        bool            `json:"is_public" db:"is_public"`
 	IsActive        bool            `json:"is_active" db:"is_active"`
 	Version         int             `json:"version" db:"version"`
@@ -28,6 +28,8 @@ type Strategy struct {
 	AccessType       string     `json:"access_type,omitempty" db:"-"`
 	PurchaseID       *int       `json:"purchase_id,omitempty" db:"-"`
 	PurchaseDate     *time.Time `json:"purchase_date,omitempty" db:"-"`
+	// Warnings about the saved structure, such as deprecated indicators it uses
+	Warnings []LintWarning `json:"warnings,omitempty" db:"-"`
 }
 
 // StrategyCreate represents the data needed to create a new strategy
@@ -35,7 +37,7 @@ type StrategyCreate struct {
 	Name         string          `json:"name" binding:"required"`
 	Description  string          `json:"description"`
 	ThumbnailURL string          `json:"thumbnail_url"`
-	Structure    json.RawMessage `json:"structure" binding:"required"`
+	Structure    json.RawMessage `json:"structure" binding:"required" swaggertype:"object"`
 	IsPublic     bool            `json:"is_public"`
 	TagIDs       []int           `json:"tag_ids,omitempty"`
 }
@@ -45,12 +47,51 @@ type StrategyUpdate struct {
 	Name         string          `json:"name" binding:"required"`
 	Description  string          `json:"description"`
 	ThumbnailURL string          `json:"thumbnail_url"`
-	Structure    json.RawMessage `json:"structure" binding:"required"`
+	Structure    json.RawMessage `json:"structure" binding:"required" swaggertype:"object"`
 	IsPublic     bool            `json:"is_public"`
 	ChangeNotes  string          `json:"change_notes"`
 	TagIDs       []int           `json:"tag_ids,omitempty"`
 }
 
+// StrategyTagsBulkUpdate represents adding tags to and removing tags from many strategies
+// at once
+type StrategyTagsBulkUpdate struct {
+	StrategyIDs  []int `json:"strategy_ids" binding:"required,min=1,max=500"`
+	AddTagIDs    []int `json:"add_tag_ids" binding:"max=50"`
+	RemoveTagIDs []int `json:"remove_tag_ids" binding:"max=50"`
+}
+
+// StrategyTagsBulkItem reports the outcome of a bulk tag update for one strategy
+type StrategyTagsBulkItem struct {
+	StrategyID  int    `json:"strategy_id" db:"strategy_id"`
+	Status      string `json:"status" db:"status"` // updated, unchanged, not_found or forbidden
+	TagsAdded   int    `json:"tags_added" db:"tags_added"`
+	TagsRemoved int    `json:"tags_removed" db:"tags_removed"`
+}
+
+// StrategyTagsBulkResult reports the outcome of a bulk tag update
+type StrategyTagsBulkResult struct {
+	Requested int                    `json:"requested"` // Distinct strategies in the request
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Failed    int                    `json:"failed"` // Strategies not found or not owned by the user
+	Items     []StrategyTagsBulkItem `json:"items"`
+}
+
+// LintWarning is a non-fatal problem found in a strategy structure
+type LintWarning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // Location in the structure, e.g. "buyRules.group0.rule1"
+}
+
+// StrategyLintResult lists the lint warnings of a strategy's structure
+type StrategyLintResult struct {
+	StrategyID int           `json:"strategy_id"`
+	Version    int           `json:"version"`
+	Warnings   []LintWarning `json:"warnings"`
+}
+
 // BacktestRequest represents the data needed to backtest a strategy
 type BacktestRequest struct {
 	StrategyID     int       `json:"strategy_id" binding:"required"`
@@ -60,3 +101,83 @@ type BacktestRequest struct {
 	EndDate        time.Time `json:"end_date" binding:"required"`
 	InitialCapital float64   `json:"initial_capital" binding:"required"`
 }
+
+// SignalPreviewRequest asks for the entry and exit signals of a strategy on the latest
+// candles of a symbol
+type SignalPreviewRequest struct {
+	SymbolID   int    `json:"symbol_id" binding:"required"`
+	Timeframe  string `json:"timeframe" binding:"required"`
+	Candles    int    `json:"candles,omitempty"` // How many of the latest candles to evaluate; defaults to 500
+	AllowShort bool   `json:"allow_short,omitempty"`
+}
+
+// StrategyShare represents a strategy shared with a specific user
+type StrategyShare struct {
+	ID               int        `json:"id" db:"id"`
+	StrategyGroupID  int        `json:"strategy_group_id" db:"strategy_group_id"`
+	OwnerID          int        `json:"owner_id" db:"owner_id"`
+	SharedWithUserID int        `json:"shared_with_user_id" db:"shared_with_user_id"`
+	Permission       string     `json:"permission" db:"permission"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+
+	// Additional fields not in DB but used in responses
+	Username string `json:"username,omitempty" db:"-"`
+}
+
+// StrategyShareRequest represents the data needed to share a strategy
+type StrategyShareRequest struct {
+	UserID     int    `json:"user_id" binding:"required"`
+	Permission string `json:"permission" binding:"required,oneof=view backtest"`
+}
+
+// Collaborator roles
+const (
+	CollaboratorRoleEditor = "editor" // Creates new versions and comments on them
+)
+
+// StrategyCollaborator represents a user invited to edit a strategy with its owner. The
+// invitation is pending until AcceptedAt is set.
+type StrategyCollaborator struct {
+	ID              int        `json:"id" db:"id"`
+	StrategyGroupID int        `json:"strategy_group_id" db:"strategy_group_id"`
+	OwnerID         int        `json:"owner_id" db:"owner_id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	Role            string     `json:"role" db:"role"`
+	InvitedAt       time.Time  `json:"invited_at" db:"invited_at"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+
+	// Latest version of the strategy, in the collaborations of a user
+	StrategyID   int    `json:"strategy_id,omitempty" db:"strategy_id"`
+	StrategyName string `json:"strategy_name,omitempty" db:"strategy_name"`
+
+	// Additional fields not in DB but used in responses
+	Username string `json:"username,omitempty" db:"-"`
+}
+
+// StrategyCollaboratorInvite represents the data needed to invite a collaborator
+type StrategyCollaboratorInvite struct {
+	UserID int    `json:"user_id" binding:"required"`
+	Role   string `json:"role,omitempty" binding:"omitempty,oneof=editor"` // Defaults to editor
+}
+
+// StrategyComment is a comment of the owner or a collaborator on a strategy version
+type StrategyComment struct {
+	ID         int       `json:"id"`
+	StrategyID int       `json:"strategy_id"` // The version commented on
+	UserID     int       `json:"user_id"`
+	Body       string    `json:"body"`
+	NodePath   *string   `json:"node_path,omitempty"` // Part of the structure commented on, e.g. /rules/entry/0
+	Mentions   []int     `json:"mentions"`            // Users mentioned in the body as @username
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Additional fields not in DB but used in responses
+	Username string `json:"username,omitempty"`
+}
+
+// StrategyCommentCreate represents the data needed to comment on a strategy version
+type StrategyCommentCreate struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	NodePath string `json:"node_path,omitempty" binding:"omitempty,max=255,startswith=/"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Tag represents a strategy tag
 type Tag struct {
 	ID   int    `json:"id" db:"id"`
@@ -10,5 +12,38 @@ type Tag struct {
 type TagWithCount struct {
 	ID            int    `json:"id" db:"id"`
 	Name          string `json:"name" db:"name"`
+	ParentID      *int   `json:"parent_id,omitempty" db:"parent_id"`
 	StrategyCount int64  `json:"strategy_count" db:"strategy_count"`
 }
+
+// PopularTag represents a tag ranked by its recent usage
+type PopularTag struct {
+	ID            int    `json:"id" db:"id"`
+	Name          string `json:"name" db:"name"`
+	ParentID      *int   `json:"parent_id,omitempty" db:"parent_id"`
+	StrategyCount int64  `json:"strategy_count" db:"strategy_count"` // Active strategies created or edited within the window
+	ListingCount  int64  `json:"listing_count" db:"listing_count"`   // Active marketplace listings created within the window
+}
+
+// TagAlias represents an alternative name that resolves to a tag
+type TagAlias struct {
+	Alias     string    `json:"alias" db:"alias"`
+	TagID     int       `json:"tag_id" db:"tag_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TagParentUpdate represents a request to move a tag under another tag
+type TagParentUpdate struct {
+	ParentID *int `json:"parent_id"` // Null makes the tag a top-level tag
+}
+
+// TagAliasCreate represents a request to add an alias to a tag
+type TagAliasCreate struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+// TagMergeResult represents the outcome of merging a tag into another
+type TagMergeResult struct {
+	Tag                TagWithCount `json:"tag"`                 // The tag merged into
+	RemappedStrategies int          `json:"remapped_strategies"` // Strategies that were tagged with the merged tag
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of strategy template parameters
+const (
+	TemplateParameterInteger = "integer"
+	TemplateParameterNumber  = "number"
+)
+
+// StrategyTemplate is an admin-curated starter strategy. String values "{{name}}" in its
+// structure are placeholders, replaced with the value of parameter name when a user
+// instantiates the template.
+type StrategyTemplate struct {
+	ID          int             `json:"id" db:"id"`
+	Name        string          `json:"name" db:"name"`
+	Description string          `json:"description" db:"description"`
+	Category    string          `json:"category" db:"category"`
+	Structure   json.RawMessage `json:"structure" db:"structure" swaggertype:"object"`
+	Parameters  json.RawMessage `json:"parameters" db:"parameters" swaggertype:"array,object"` // []TemplateParameter
+	IsActive    bool            `json:"is_active" db:"is_active"`
+	CreatedBy   *int            `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// TemplateParameter is a placeholder of a strategy template
+type TemplateParameter struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Label       string   `json:"label"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type" binding:"required,oneof=integer number"`
+	Default     *float64 `json:"default,omitempty"` // Without a default, instantiating requires a value
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+}
+
+// StrategyTemplateInput represents the data needed to create or update a strategy template
+type StrategyTemplateInput struct {
+	Name        string              `json:"name" binding:"required,max=100"`
+	Description string              `json:"description"`
+	Category    string              `json:"category" binding:"required,max=50"`
+	Structure   json.RawMessage     `json:"structure" binding:"required" swaggertype:"object"`
+	Parameters  []TemplateParameter `json:"parameters" binding:"dive"`
+	IsActive    *bool               `json:"is_active"` // Defaults to true
+}
+
+// StrategyTemplateCategory is a category of strategy templates
+type StrategyTemplateCategory struct {
+	Category string `json:"category" db:"category"`
+	Count    int64  `json:"count" db:"count"`
+}
+
+// StrategyTemplateInstantiate represents the data needed to create a strategy from a
+// template
+type StrategyTemplateInstantiate struct {
+	Name        string             `json:"name" binding:"max=100"` // Defaults to the template's name
+	Description string             `json:"description"`            // Defaults to the template's description
+	IsPublic    bool               `json:"is_public"`
+	TagIDs      []int              `json:"tag_ids,omitempty"`
+	Parameters  map[string]float64 `json:"parameters"` // Values of the template's parameters; missing ones take their default
+}
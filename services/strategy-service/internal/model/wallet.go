@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Types of wallet ledger entries
+const (
+	WalletEntryGrant    = "grant"    // Credit granted by an admin
+	WalletEntryRevoke   = "revoke"   // Credit revoked by an admin
+	WalletEntryPurchase = "purchase" // Credit spent on a purchase
+	WalletEntryRefund   = "refund"   // Credit given back by a refunded purchase
+)
+
+// WalletBalance is a user's platform credit in one currency
+type WalletBalance struct {
+	Currency  string    `json:"currency" db:"currency"`
+	Balance   float64   `json:"balance" db:"balance"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WalletEntry is a change to a user's wallet. Entries are never changed or removed.
+type WalletEntry struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	Amount       float64   `json:"amount" db:"amount"` // Positive for credit, negative for debit
+	Currency     string    `json:"currency" db:"currency"`
+	EntryType    string    `json:"entry_type" db:"entry_type"` // grant, revoke, purchase, refund
+	BalanceAfter float64   `json:"balance_after" db:"balance_after"`
+	PurchaseID   *int      `json:"purchase_id,omitempty" db:"purchase_id"`
+	ActorID      *int      `json:"actor_id,omitempty" db:"actor_id"` // Admin who granted or revoked
+	Note         *string   `json:"note,omitempty" db:"note"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// WalletAdjustment represents an admin granting or revoking credit of a user's wallet
+type WalletAdjustment struct {
+	Amount   float64 `json:"amount" binding:"required,gt=0"`
+	Currency string  `json:"currency" binding:"required,len=3"`
+	Note     string  `json:"note" binding:"required,max=500"`
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// CollaborationRepository handles database operations for strategy collaborators and the
+// comments on strategy versions
+type CollaborationRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewCollaborationRepository creates a new collaboration repository
+func NewCollaborationRepository(db *sqlx.DB, logger *zap.Logger) *CollaborationRepository {
+	return &CollaborationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// InviteCollaborator invites a user to collaborate on a strategy group, or changes the role
+// of a collaborator, using the invite_strategy_collaborator function
+func (r *CollaborationRepository) InviteCollaborator(ctx context.Context, strategyGroupID, ownerID, userID int, role string) (int, error) {
+	query := `SELECT invite_strategy_collaborator($1, $2, $3, $4)`
+
+	var id int
+	err := r.db.GetContext(ctx, &id, query, strategyGroupID, ownerID, userID, role)
+	if err != nil {
+		r.logger.Error("Failed to invite strategy collaborator",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID),
+			zap.Int("user_id", userID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// AcceptInvitation accepts a pending invitation using the accept_strategy_collaboration
+// function. It returns false if there is no pending invitation.
+func (r *CollaborationRepository) AcceptInvitation(ctx context.Context, strategyGroupID, userID int) (bool, error) {
+	query := `SELECT accept_strategy_collaboration($1, $2)`
+
+	var accepted bool
+	err := r.db.GetContext(ctx, &accepted, query, strategyGroupID, userID)
+	if err != nil {
+		r.logger.Error("Failed to accept strategy collaboration",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID),
+			zap.Int("user_id", userID))
+		return false, err
+	}
+
+	return accepted, nil
+}
+
+// RemoveCollaborator removes a collaborator or invitation using the
+// remove_strategy_collaborator function
+func (r *CollaborationRepository) RemoveCollaborator(ctx context.Context, strategyGroupID, userID int) (bool, error) {
+	query := `SELECT remove_strategy_collaborator($1, $2)`
+
+	var removed bool
+	err := r.db.GetContext(ctx, &removed, query, strategyGroupID, userID)
+	if err != nil {
+		r.logger.Error("Failed to remove strategy collaborator",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID),
+			zap.Int("user_id", userID))
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// GetCollaborators retrieves the collaborators and invitations of a strategy group using
+// the get_strategy_collaborators function
+func (r *CollaborationRepository) GetCollaborators(ctx context.Context, strategyGroupID int) ([]model.StrategyCollaborator, error) {
+	query := `SELECT * FROM get_strategy_collaborators($1)`
+
+	collaborators := []model.StrategyCollaborator{}
+	err := r.db.SelectContext(ctx, &collaborators, query, strategyGroupID)
+	if err != nil {
+		r.logger.Error("Failed to get strategy collaborators",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID))
+		return nil, err
+	}
+
+	return collaborators, nil
+}
+
+// GetUserCollaborations retrieves the strategies a user collaborates on or is invited to
+// using the get_user_collaborations function
+func (r *CollaborationRepository) GetUserCollaborations(ctx context.Context, userID int) ([]model.StrategyCollaborator, error) {
+	query := `SELECT * FROM get_user_collaborations($1)`
+
+	collaborations := []model.StrategyCollaborator{}
+	err := r.db.SelectContext(ctx, &collaborations, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get user collaborations", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return collaborations, nil
+}
+
+// commentRow is a row of strategy_version_comments
+type commentRow struct {
+	ID         int           `db:"id"`
+	StrategyID int           `db:"strategy_id"`
+	UserID     int           `db:"user_id"`
+	Body       string        `db:"body"`
+	NodePath   *string       `db:"node_path"`
+	Mentions   pq.Int64Array `db:"mentions"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+// toComment converts a comment row to a model.StrategyComment
+func (row commentRow) toComment() model.StrategyComment {
+	mentions := make([]int, len(row.Mentions))
+	for i, id := range row.Mentions {
+		mentions[i] = int(id)
+	}
+
+	return model.StrategyComment{
+		ID:         row.ID,
+		StrategyID: row.StrategyID,
+		UserID:     row.UserID,
+		Body:       row.Body,
+		NodePath:   row.NodePath,
+		Mentions:   mentions,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+// AddComment adds a comment to a strategy version using the add_strategy_comment function
+func (r *CollaborationRepository) AddComment(
+	ctx context.Context,
+	strategyID int,
+	userID int,
+	body string,
+	nodePath string,
+	mentions []int,
+) (*model.StrategyComment, error) {
+	query := `SELECT * FROM add_strategy_comment($1, $2, $3, $4, $5)`
+
+	var row commentRow
+	err := r.db.GetContext(ctx, &row, query, strategyID, userID, body, nodePath, pq.Array(mentions))
+	if err != nil {
+		r.logger.Error("Failed to add strategy comment", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, err
+	}
+
+	comment := row.toComment()
+	return &comment, nil
+}
+
+// GetComments retrieves the comments of a strategy version, oldest first, using the
+// get_strategy_comments function
+func (r *CollaborationRepository) GetComments(ctx context.Context, strategyID int) ([]model.StrategyComment, error) {
+	query := `SELECT * FROM get_strategy_comments($1)`
+
+	var rows []commentRow
+	if err := r.db.SelectContext(ctx, &rows, query, strategyID); err != nil {
+		r.logger.Error("Failed to get strategy comments", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, err
+	}
+
+	comments := make([]model.StrategyComment, len(rows))
+	for i, row := range rows {
+		comments[i] = row.toComment()
+	}
+
+	return comments, nil
+}
+
+// DeleteComment deletes a comment of its author using the delete_strategy_comment function.
+// It returns false if the user has no such comment.
+func (r *CollaborationRepository) DeleteComment(ctx context.Context, commentID, userID int) (bool, error) {
+	query := `SELECT delete_strategy_comment($1, $2)`
+
+	var deleted bool
+	if err := r.db.GetContext(ctx, &deleted, query, commentID, userID); err != nil {
+		r.logger.Error("Failed to delete strategy comment", zap.Error(err), zap.Int("comment_id", commentID))
+		return false, err
+	}
+
+	return deleted, nil
+}
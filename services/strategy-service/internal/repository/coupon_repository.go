@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// CouponRepository handles database operations for listing coupons
+type CouponRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewCouponRepository creates a new coupon repository
+func NewCouponRepository(db *sqlx.DB, logger *zap.Logger) *CouponRepository {
+	return &CouponRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateCoupon adds a coupon to a listing using create_marketplace_coupon function
+func (r *CouponRepository) CreateCoupon(ctx context.Context, marketplaceID int, coupon *model.CouponCreate, userID int) (int, error) {
+	query := `SELECT create_marketplace_coupon($1, $2, $3, $4, $5, $6, $7)`
+
+	var id int
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		marketplaceID,
+		coupon.Code,
+		coupon.DiscountType,
+		coupon.DiscountValue,
+		coupon.MaxRedemptions,
+		coupon.ExpiresAt,
+	).Scan(&id)
+
+	if err != nil {
+		r.logger.Error("Failed to create coupon", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetCoupons retrieves the coupons of a listing using get_marketplace_coupons function
+func (r *CouponRepository) GetCoupons(ctx context.Context, marketplaceID int) ([]model.Coupon, error) {
+	query := `SELECT * FROM get_marketplace_coupons($1)`
+
+	coupons := []model.Coupon{}
+	err := r.db.SelectContext(ctx, &coupons, query, marketplaceID)
+	if err != nil {
+		r.logger.Error("Failed to get coupons", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+// GetCouponByID retrieves a coupon of a listing using get_marketplace_coupon_by_id function.
+// Returns nil if the listing has no such coupon.
+func (r *CouponRepository) GetCouponByID(ctx context.Context, marketplaceID int, couponID int) (*model.Coupon, error) {
+	query := `SELECT * FROM get_marketplace_coupon_by_id($1, $2)`
+
+	var coupon model.Coupon
+	err := r.db.GetContext(ctx, &coupon, query, marketplaceID, couponID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get coupon", zap.Error(err), zap.Int("coupon_id", couponID))
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+// GetCouponByCode retrieves a coupon of a listing by its code, ignoring case, using
+// get_marketplace_coupon_by_code function. Returns nil if the listing has no such coupon.
+func (r *CouponRepository) GetCouponByCode(ctx context.Context, marketplaceID int, code string) (*model.Coupon, error) {
+	query := `SELECT * FROM get_marketplace_coupon_by_code($1, $2)`
+
+	var coupon model.Coupon
+	err := r.db.GetContext(ctx, &coupon, query, marketplaceID, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get coupon by code", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+// UpdateCoupon updates a coupon of a listing owned by the user using update_marketplace_coupon
+// function. Returns false if the coupon wasn't found.
+func (r *CouponRepository) UpdateCoupon(
+	ctx context.Context,
+	marketplaceID int,
+	couponID int,
+	update *model.CouponUpdate,
+	isActive bool,
+	userID int,
+) (bool, error) {
+	query := `SELECT update_marketplace_coupon($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	var success bool
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		marketplaceID,
+		couponID,
+		update.DiscountType,
+		update.DiscountValue,
+		update.MaxRedemptions,
+		update.ExpiresAt,
+		isActive,
+	).Scan(&success)
+
+	if err != nil {
+		r.logger.Error("Failed to update coupon", zap.Error(err), zap.Int("coupon_id", couponID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// DeleteCoupon removes a coupon of a listing owned by the user using delete_marketplace_coupon
+// function. Returns false if the coupon wasn't found.
+func (r *CouponRepository) DeleteCoupon(ctx context.Context, marketplaceID int, couponID int, userID int) (bool, error) {
+	query := `SELECT delete_marketplace_coupon($1, $2, $3)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, userID, marketplaceID, couponID).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to delete coupon", zap.Error(err), zap.Int("coupon_id", couponID))
+		return false, err
+	}
+
+	return success, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// CurrencyRepository handles database operations for currencies and exchange rates
+type CurrencyRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewCurrencyRepository creates a new currency repository
+func NewCurrencyRepository(db *sqlx.DB, logger *zap.Logger) *CurrencyRepository {
+	return &CurrencyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetCurrencies retrieves the active currencies using get_currencies function
+func (r *CurrencyRepository) GetCurrencies(ctx context.Context) ([]model.Currency, error) {
+	query := `SELECT * FROM get_currencies()`
+
+	currencies := []model.Currency{}
+	err := r.db.SelectContext(ctx, &currencies, query)
+	if err != nil {
+		r.logger.Error("Failed to get currencies", zap.Error(err))
+		return nil, err
+	}
+
+	return currencies, nil
+}
+
+// SaveFXRates stores the exchange rates of a day using save_fx_rate function. Rates of
+// currencies outside the catalog are skipped.
+func (r *CurrencyRepository) SaveFXRates(ctx context.Context, rates *model.FXRates, currencies []model.Currency) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, currency := range currencies {
+		rate, ok := rates.Rates[currency.Code]
+		if !ok {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `SELECT save_fx_rate($1, $2, $3)`, currency.Code, rates.Date, rate); err != nil {
+			r.logger.Error("Failed to save exchange rate", zap.Error(err), zap.String("currency", currency.Code))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLatestFXRates retrieves the most recent stored rate of every currency using
+// get_latest_fx_rates function. The date is the oldest of those rates. Returns nil if
+// no rates are stored.
+func (r *CurrencyRepository) GetLatestFXRates(ctx context.Context) (*model.FXRates, error) {
+	query := `SELECT * FROM get_latest_fx_rates()`
+
+	var rows []struct {
+		Currency string    `db:"currency"`
+		RateDate time.Time `db:"rate_date"`
+		Rate     float64   `db:"rate"`
+	}
+
+	err := r.db.SelectContext(ctx, &rows, query)
+	if err != nil {
+		r.logger.Error("Failed to get exchange rates", zap.Error(err))
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	rates := &model.FXRates{Date: rows[0].RateDate, Rates: make(map[string]float64, len(rows))}
+	for _, row := range rows {
+		rates.Rates[row.Currency] = row.Rate
+		if row.RateDate.Before(rates.Date) {
+			rates.Date = row.RateDate
+		}
+	}
+
+	return rates, nil
+}
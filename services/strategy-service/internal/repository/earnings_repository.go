@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// EarningsRepository handles seller sales aggregation queries
+type EarningsRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewEarningsRepository creates a new earnings repository
+func NewEarningsRepository(db *sqlx.DB, logger *zap.Logger) *EarningsRepository {
+	return &EarningsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetRevenueOverTime retrieves a seller's revenue per period using the get_seller_revenue_over_time function
+func (r *EarningsRepository) GetRevenueOverTime(ctx context.Context, sellerID int, interval string, startDate, endDate time.Time) ([]model.RevenuePoint, error) {
+	query := `SELECT * FROM get_seller_revenue_over_time($1, $2, $3, $4)`
+
+	var points []model.RevenuePoint
+	err := r.db.SelectContext(ctx, &points, query, sellerID, interval, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get seller revenue over time",
+			zap.Error(err),
+			zap.Int("seller_id", sellerID),
+			zap.String("interval", interval))
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// GetListingSales retrieves per-listing sales for a seller using the get_seller_listing_sales function
+func (r *EarningsRepository) GetListingSales(ctx context.Context, sellerID int, startDate, endDate time.Time) ([]model.ListingSales, error) {
+	query := `SELECT * FROM get_seller_listing_sales($1, $2, $3)`
+
+	var listings []model.ListingSales
+	err := r.db.SelectContext(ctx, &listings, query, sellerID, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get seller listing sales",
+			zap.Error(err),
+			zap.Int("seller_id", sellerID))
+		return nil, err
+	}
+
+	return listings, nil
+}
+
+// GetSubscriptionChurn retrieves subscription churn per period using the get_seller_subscription_churn function
+func (r *EarningsRepository) GetSubscriptionChurn(ctx context.Context, sellerID int, interval string, startDate, endDate time.Time) ([]model.ChurnPoint, error) {
+	query := `SELECT * FROM get_seller_subscription_churn($1, $2, $3, $4)`
+
+	var points []model.ChurnPoint
+	err := r.db.SelectContext(ctx, &points, query, sellerID, interval, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get seller subscription churn",
+			zap.Error(err),
+			zap.Int("seller_id", sellerID),
+			zap.String("interval", interval))
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// GetPayoutPeriods retrieves monthly payout summaries using the get_seller_payout_periods function
+func (r *EarningsRepository) GetPayoutPeriods(ctx context.Context, sellerID int, feePercent float64, startDate, endDate time.Time) ([]model.PayoutPeriod, error) {
+	query := `SELECT * FROM get_seller_payout_periods($1, $2, $3, $4)`
+
+	var periods []model.PayoutPeriod
+	err := r.db.SelectContext(ctx, &periods, query, sellerID, feePercent, startDate, endDate)
+	if err != nil {
+		r.logger.Error("Failed to get seller payout periods",
+			zap.Error(err),
+			zap.Int("seller_id", sellerID))
+		return nil, err
+	}
+
+	return periods, nil
+}
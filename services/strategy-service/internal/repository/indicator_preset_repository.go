@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// IndicatorPresetRepository handles database operations for indicator presets
+type IndicatorPresetRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewIndicatorPresetRepository creates a new indicator preset repository
+func NewIndicatorPresetRepository(db *sqlx.DB, logger *zap.Logger) *IndicatorPresetRepository {
+	return &IndicatorPresetRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetPresets retrieves the global presets of an indicator and those of the user.
+// userID is 0 for anonymous requests, which only see global presets.
+func (r *IndicatorPresetRepository) GetPresets(ctx context.Context, indicatorID int, userID int) ([]model.IndicatorPreset, error) {
+	query := `SELECT * FROM get_indicator_presets($1, $2)`
+
+	presets := []model.IndicatorPreset{}
+	err := r.db.SelectContext(ctx, &presets, query, indicatorID, userID)
+	if err != nil {
+		r.logger.Error("Failed to get indicator presets", zap.Error(err), zap.Int("indicatorID", indicatorID))
+		return nil, err
+	}
+
+	markGlobalPresets(presets)
+	return presets, nil
+}
+
+// GetPresetByID retrieves a preset by ID, or nil if it doesn't exist
+func (r *IndicatorPresetRepository) GetPresetByID(ctx context.Context, id int) (*model.IndicatorPreset, error) {
+	query := `SELECT * FROM get_indicator_preset_by_id($1)`
+
+	var preset model.IndicatorPreset
+	err := r.db.GetContext(ctx, &preset, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get indicator preset by ID", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	preset.IsGlobal = preset.UserID == nil
+	return &preset, nil
+}
+
+// GetUsablePresets retrieves the presets with the given IDs that the user can use: global
+// presets and the user's own
+func (r *IndicatorPresetRepository) GetUsablePresets(ctx context.Context, userID int, ids []int) ([]model.IndicatorPreset, error) {
+	query := `SELECT * FROM get_usable_indicator_presets($1, $2)`
+
+	presets := []model.IndicatorPreset{}
+	err := r.db.SelectContext(ctx, &presets, query, userID, pq.Array(ids))
+	if err != nil {
+		r.logger.Error("Failed to get usable indicator presets", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	markGlobalPresets(presets)
+	return presets, nil
+}
+
+// CreatePreset adds a new preset; a nil userID makes it global
+func (r *IndicatorPresetRepository) CreatePreset(
+	ctx context.Context,
+	indicatorID int,
+	userID *int,
+	input *model.IndicatorPresetInput,
+	settings json.RawMessage,
+) (int, error) {
+	query := `SELECT create_indicator_preset($1, $2, $3, $4, $5)`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query,
+		indicatorID,
+		userID,
+		input.Name,
+		input.Description,
+		settings,
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to create indicator preset",
+			zap.Error(err),
+			zap.Int("indicatorID", indicatorID),
+			zap.String("name", input.Name))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdatePreset updates a preset. Returns false if it doesn't exist.
+func (r *IndicatorPresetRepository) UpdatePreset(
+	ctx context.Context,
+	id int,
+	input *model.IndicatorPresetInput,
+	settings json.RawMessage,
+) (bool, error) {
+	query := `SELECT update_indicator_preset($1, $2, $3, $4)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, id, input.Name, input.Description, settings).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to update indicator preset", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// DeletePreset deletes a preset. Returns false if it doesn't exist.
+func (r *IndicatorPresetRepository) DeletePreset(ctx context.Context, id int) (bool, error) {
+	query := `SELECT delete_indicator_preset($1)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to delete indicator preset", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// markGlobalPresets sets IsGlobal on the presets without a user
+func markGlobalPresets(presets []model.IndicatorPreset) {
+	for i := range presets {
+		presets[i].IsGlobal = presets[i].UserID == nil
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"services/shared/pagination"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
@@ -33,6 +34,7 @@ func NewIndicatorRepository(db *sqlx.DB, logger *zap.Logger) *IndicatorRepositor
 // GetAllIndicators retrieves all indicators with parameters and enum values using get_indicators function
 // isAdmin parameter controls visibility of parameters
 // Added sortBy and sortDirection parameters for sorting
+// userID (0 for anonymous requests) adds the user's own custom indicators to the results
 func (r *IndicatorRepository) GetAllIndicators(
 	ctx context.Context,
 	searchTerm string,
@@ -43,34 +45,16 @@ func (r *IndicatorRepository) GetAllIndicators(
 	page,
 	limit int,
 	isAdmin bool,
+	userID int,
 ) ([]model.TechnicalIndicator, int, error) {
 	// Calculate offset
-	offset := (page - 1) * limit
-
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"name":       true,
-		"category":   true,
-		"created_at": true,
-		"updated_at": true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "name" // Default sort by name
-	}
-
-	// Validate sort direction
-	if sortDirection != "ASC" && sortDirection != "asc" {
-		sortDirection = "DESC"
-	} else {
-		sortDirection = "ASC"
-	}
+	offset := pagination.Offset(page, limit)
 
 	// First, get total count with the count function
-	countQuery := `SELECT count_indicators($1, $2, $3)`
+	countQuery := `SELECT count_indicators($1, $2, $3, $4, $5)`
 
 	var totalCount int
-	err := r.db.GetContext(ctx, &totalCount, countQuery, searchTerm, pq.Array(categories), active)
+	err := r.db.GetContext(ctx, &totalCount, countQuery, searchTerm, pq.Array(categories), active, isAdmin, nullableUserID(userID))
 	if err != nil {
 		r.logger.Error("Failed to count indicators", zap.Error(err))
 		return nil, 0, err
@@ -78,7 +62,7 @@ func (r *IndicatorRepository) GetAllIndicators(
 
 	// Use the updated get_indicators function with isAdmin, sorting and pagination parameters
 	// Note: This would require modifying the SQL function to accept sort parameters
-	query := `SELECT * FROM get_indicators($1, $2, $3, $4, $5, $6, $7, $8)`
+	query := `SELECT * FROM get_indicators($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	var args []interface{}
 	args = append(args, searchTerm)
@@ -103,311 +87,204 @@ func (r *IndicatorRepository) GetAllIndicators(
 	// Add pagination parameters
 	args = append(args, limit, offset)
 
+	// Add the requesting user for access checks
+	args = append(args, nullableUserID(userID))
+
 	// Execute query
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
+	var rows []indicatorRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
 		r.logger.Error("Failed to execute get indicators query", zap.Error(err))
 		return nil, 0, err
 	}
-	defer rows.Close()
 
-	// Scan rows into indicator objects
 	var indicators []model.TechnicalIndicator
-	for rows.Next() {
-		var indicator model.TechnicalIndicator
-		var updatedAt sql.NullTime
-		var parametersJSON []byte
-		// Use sql.NullString for fields that can be NULL
-		var formulaNull sql.NullString
-		var minValueNull sql.NullFloat64
-		var maxValueNull sql.NullFloat64
-
-		err := rows.Scan(
-			&indicator.ID,
-			&indicator.Name,
-			&indicator.Description,
-			&indicator.Category,
-			&formulaNull,
-			&minValueNull,
-			&maxValueNull,
-			&indicator.IsActive,
-			&indicator.CreatedAt,
-			&updatedAt,
-			&parametersJSON,
-		)
-		if err != nil {
-			r.logger.Error("Failed to scan indicator row", zap.Error(err))
-			return nil, 0, err
-		}
-
-		// Assign values of NULL fields
-		if formulaNull.Valid {
-			indicator.Formula = formulaNull.String
-		} else {
-			indicator.Formula = ""
-		}
-
-		// Assign values for min_value and max_value only if valid
-		if minValueNull.Valid {
-			indicator.MinValue = &minValueNull.Float64
-		}
-
-		if maxValueNull.Valid {
-			indicator.MaxValue = &maxValueNull.Float64
-		}
-
-		// Convert nullable time
-		if updatedAt.Valid {
-			indicator.UpdatedAt = &updatedAt.Time
-		}
-
-		// Parse parameters from JSON
-		indicator.Parameters = []model.IndicatorParameter{} // Initialize with empty array
-
-		if len(parametersJSON) > 0 && string(parametersJSON) != "[]" && string(parametersJSON) != "null" {
-			// Parse the JSON array of parameters
-			var paramsArray []json.RawMessage
-			err = json.Unmarshal(parametersJSON, &paramsArray)
-			if err != nil {
-				r.logger.Error("Failed to unmarshal parameters array",
-					zap.Error(err),
-					zap.String("parametersJSON", string(parametersJSON)))
-				continue // Skip but don't fail completely
-			}
-
-			for _, paramJSON := range paramsArray {
-				var param struct {
-					ID           int             `json:"id"`
-					Name         string          `json:"name"`
-					Type         string          `json:"type"`
-					IsRequired   bool            `json:"is_required"`
-					MinValue     *float64        `json:"min_value,omitempty"`
-					MaxValue     *float64        `json:"max_value,omitempty"`
-					DefaultValue string          `json:"default_value,omitempty"`
-					Description  string          `json:"description,omitempty"`
-					IsPublic     bool            `json:"is_public"`
-					EnumValues   json.RawMessage `json:"enum_values,omitempty"`
-				}
-
-				err = json.Unmarshal(paramJSON, &param)
-				if err != nil {
-					r.logger.Error("Failed to unmarshal parameter",
-						zap.Error(err),
-						zap.String("paramJSON", string(paramJSON)))
-					continue
-				}
-
-				// Create parameter with basic properties
-				parameterObj := model.IndicatorParameter{
-					ID:            param.ID,
-					IndicatorID:   indicator.ID,
-					ParameterName: param.Name,
-					ParameterType: param.Type,
-					IsRequired:    param.IsRequired,
-					MinValue:      param.MinValue,
-					MaxValue:      param.MaxValue,
-					DefaultValue:  param.DefaultValue,
-					Description:   param.Description,
-					IsPublic:      param.IsPublic,
-					EnumValues:    []model.ParameterEnumValue{},
-				}
-
-				// Parse enum values separately if they exist
-				if len(param.EnumValues) > 0 && string(param.EnumValues) != "null" && string(param.EnumValues) != "[]" {
-					var enumValues []struct {
-						ID          int    `json:"id"`
-						EnumValue   string `json:"enum_value"`
-						DisplayName string `json:"display_name"`
-					}
-
-					if err := json.Unmarshal(param.EnumValues, &enumValues); err != nil {
-						r.logger.Warn("Failed to unmarshal enum values",
-							zap.Error(err),
-							zap.String("enum_values_json", string(param.EnumValues)))
-					} else {
-						for _, ev := range enumValues {
-							parameterObj.EnumValues = append(parameterObj.EnumValues, model.ParameterEnumValue{
-								ID:          ev.ID,
-								ParameterID: param.ID,
-								EnumValue:   ev.EnumValue,
-								DisplayName: ev.DisplayName,
-							})
-						}
-					}
-				}
+	for _, row := range rows {
+		indicator := row.toIndicator()
 
-				indicator.Parameters = append(indicator.Parameters, parameterObj)
-			}
+		parameters, err := r.parseIndicatorParameters(indicator.ID, row.Parameters)
+		if err != nil {
+			continue // Skip but don't fail completely
 		}
+		indicator.Parameters = parameters
 
 		indicators = append(indicators, indicator)
 	}
 
-	if err = rows.Err(); err != nil {
-		r.logger.Error("Error iterating indicator rows", zap.Error(err))
-		return nil, 0, err
-	}
-
 	return indicators, totalCount, nil
 }
 
 // GetIndicatorByID retrieves an indicator by ID with parameters and enum values
 // isAdmin parameter controls visibility of parameters
-func (r *IndicatorRepository) GetIndicatorByID(ctx context.Context, id int, isAdmin bool) (*model.TechnicalIndicator, error) {
+// Private custom indicators are only returned to their owner (userID) and admins
+func (r *IndicatorRepository) GetIndicatorByID(ctx context.Context, id int, isAdmin bool, userID int) (*model.TechnicalIndicator, error) {
 	// Use the updated get_indicator_by_id function with isAdmin parameter
-	query := `SELECT * FROM get_indicator_by_id($1, $2)`
+	query := `SELECT * FROM get_indicator_by_id($1, $2, $3)`
 
-	rows, err := r.db.QueryContext(ctx, query, id, isAdmin)
+	var row indicatorRow
+	err := r.db.GetContext(ctx, &row, query, id, isAdmin, nullableUserID(userID))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No indicator found
+		}
 		r.logger.Error("Failed to execute get indicator by ID query", zap.Error(err))
 		return nil, err
 	}
-	defer rows.Close()
-
-	// We should get only one row
-	if !rows.Next() {
-		return nil, nil // No indicator found
-	}
-
-	var indicator model.TechnicalIndicator
-	var updatedAt sql.NullTime
-	var parametersJSON []byte
-	// Use sql.NullString and sql.NullFloat64 for fields that can be NULL
-	var formulaNull sql.NullString
-	var minValueNull sql.NullFloat64
-	var maxValueNull sql.NullFloat64
-
-	err = rows.Scan(
-		&indicator.ID,
-		&indicator.Name,
-		&indicator.Description,
-		&indicator.Category,
-		&formulaNull,
-		&minValueNull,
-		&maxValueNull,
-		&indicator.IsActive,
-		&indicator.CreatedAt,
-		&updatedAt,
-		&parametersJSON,
-	)
 
+	indicator := row.toIndicator()
+
+	parameters, err := r.parseIndicatorParameters(indicator.ID, row.Parameters)
 	if err != nil {
-		r.logger.Error("Failed to scan indicator row", zap.Error(err))
-		return nil, err
+		return &indicator, nil // Return indicator without parameters rather than failing
 	}
+	indicator.Parameters = parameters
 
-	// Handle NULL fields
-	if formulaNull.Valid {
-		indicator.Formula = formulaNull.String
-	} else {
-		indicator.Formula = ""
-	}
+	return &indicator, nil
+}
+
+// indicatorRow matches the columns returned by get_indicators and get_indicator_by_id.
+// Rows are scanned by column name, so a column added to or renamed in either function
+// fails the scan instead of shifting values into the wrong fields.
+type indicatorRow struct {
+	ID          int            `db:"id"`
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	Category    string         `db:"category"`
+	Formula     sql.NullString `db:"formula"`
+	MinValue    *float64       `db:"min_value"`
+	MaxValue    *float64       `db:"max_value"`
+	IsActive    bool           `db:"is_active"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   *time.Time     `db:"updated_at"`
+	Parameters  []byte         `db:"parameters"`
+	OwnerID     *int           `db:"owner_id"`
+	Visibility  string         `db:"visibility"`
+}
 
-	// Assign values for min_value and max_value only if valid
-	if minValueNull.Valid {
-		indicator.MinValue = &minValueNull.Float64
+// toIndicator converts an indicator row to a model.TechnicalIndicator without parameters
+func (row indicatorRow) toIndicator() model.TechnicalIndicator {
+	return model.TechnicalIndicator{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		Category:    row.Category,
+		Formula:     row.Formula.String,
+		MinValue:    row.MinValue,
+		MaxValue:    row.MaxValue,
+		IsActive:    row.IsActive,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+		Parameters:  []model.IndicatorParameter{},
+		OwnerID:     row.OwnerID,
+		Visibility:  row.Visibility,
 	}
+}
 
-	if maxValueNull.Valid {
-		indicator.MaxValue = &maxValueNull.Float64
+// parseIndicatorParameters parses the JSON array of parameters, with their enum values,
+// the indicator functions return. Parameters that don't parse are logged and skipped; an
+// array that doesn't parse is an error.
+func (r *IndicatorRepository) parseIndicatorParameters(indicatorID int, parametersJSON []byte) ([]model.IndicatorParameter, error) {
+	parameters := []model.IndicatorParameter{}
+	if len(parametersJSON) == 0 || string(parametersJSON) == "[]" || string(parametersJSON) == "null" {
+		return parameters, nil
 	}
 
-	// Convert nullable time
-	if updatedAt.Valid {
-		indicator.UpdatedAt = &updatedAt.Time
+	var paramsArray []json.RawMessage
+	if err := json.Unmarshal(parametersJSON, &paramsArray); err != nil {
+		r.logger.Error("Failed to unmarshal parameters array",
+			zap.Error(err),
+			zap.String("parametersJSON", string(parametersJSON)))
+		return nil, err
 	}
 
-	// Initialize parameters with empty array
-	indicator.Parameters = []model.IndicatorParameter{}
+	for _, paramJSON := range paramsArray {
+		var param struct {
+			ID           int             `json:"id"`
+			Name         string          `json:"name"`
+			Type         string          `json:"type"`
+			IsRequired   bool            `json:"is_required"`
+			MinValue     *float64        `json:"min_value,omitempty"`
+			MaxValue     *float64        `json:"max_value,omitempty"`
+			DefaultValue string          `json:"default_value,omitempty"`
+			Description  string          `json:"description,omitempty"`
+			IsPublic     bool            `json:"is_public"`
+			EnumValues   json.RawMessage `json:"enum_values,omitempty"`
+		}
 
-	// Parse parameters from JSON
-	if len(parametersJSON) > 0 && string(parametersJSON) != "[]" && string(parametersJSON) != "null" {
-		// Parse the JSON array of parameters
-		var paramsArray []json.RawMessage
-		err = json.Unmarshal(parametersJSON, &paramsArray)
-		if err != nil {
-			r.logger.Error("Failed to unmarshal parameters array",
+		if err := json.Unmarshal(paramJSON, &param); err != nil {
+			r.logger.Error("Failed to unmarshal parameter",
 				zap.Error(err),
-				zap.String("parametersJSON", string(parametersJSON)))
-			return &indicator, nil // Return indicator without parameters rather than failing
+				zap.String("paramJSON", string(paramJSON)))
+			continue
 		}
 
-		for _, paramJSON := range paramsArray {
-			var param struct {
-				ID           int             `json:"id"`
-				Name         string          `json:"name"`
-				Type         string          `json:"type"`
-				IsRequired   bool            `json:"is_required"`
-				MinValue     *float64        `json:"min_value,omitempty"`
-				MaxValue     *float64        `json:"max_value,omitempty"`
-				DefaultValue string          `json:"default_value,omitempty"`
-				Description  string          `json:"description,omitempty"`
-				IsPublic     bool            `json:"is_public"`
-				EnumValues   json.RawMessage `json:"enum_values,omitempty"`
-			}
-
-			err = json.Unmarshal(paramJSON, &param)
-			if err != nil {
-				r.logger.Error("Failed to unmarshal parameter",
-					zap.Error(err),
-					zap.String("paramJSON", string(paramJSON)))
-				continue
-			}
+		// Create parameter with basic properties
+		parameterObj := model.IndicatorParameter{
+			ID:            param.ID,
+			IndicatorID:   indicatorID,
+			ParameterName: param.Name,
+			ParameterType: param.Type,
+			IsRequired:    param.IsRequired,
+			MinValue:      param.MinValue,
+			MaxValue:      param.MaxValue,
+			DefaultValue:  param.DefaultValue,
+			Description:   param.Description,
+			IsPublic:      param.IsPublic,
+			EnumValues:    []model.ParameterEnumValue{},
+		}
 
-			// Create parameter with basic properties
-			parameterObj := model.IndicatorParameter{
-				ID:            param.ID,
-				IndicatorID:   indicator.ID,
-				ParameterName: param.Name,
-				ParameterType: param.Type,
-				IsRequired:    param.IsRequired,
-				MinValue:      param.MinValue,
-				MaxValue:      param.MaxValue,
-				DefaultValue:  param.DefaultValue,
-				Description:   param.Description,
-				IsPublic:      param.IsPublic,
-				EnumValues:    []model.ParameterEnumValue{},
+		// Parse enum values separately if they exist
+		if len(param.EnumValues) > 0 && string(param.EnumValues) != "null" && string(param.EnumValues) != "[]" {
+			var enumValues []struct {
+				ID          int    `json:"id"`
+				EnumValue   string `json:"enum_value"`
+				DisplayName string `json:"display_name"`
 			}
 
-			// Parse enum values separately if they exist
-			if len(param.EnumValues) > 0 && string(param.EnumValues) != "null" && string(param.EnumValues) != "[]" {
-				var enumValues []struct {
-					ID          int    `json:"id"`
-					EnumValue   string `json:"enum_value"`
-					DisplayName string `json:"display_name"`
-				}
-
-				if err := json.Unmarshal(param.EnumValues, &enumValues); err != nil {
-					r.logger.Warn("Failed to unmarshal enum values",
-						zap.Error(err),
-						zap.String("enum_values_json", string(param.EnumValues)))
-				} else {
-					for _, ev := range enumValues {
-						parameterObj.EnumValues = append(parameterObj.EnumValues, model.ParameterEnumValue{
-							ID:          ev.ID,
-							ParameterID: param.ID,
-							EnumValue:   ev.EnumValue,
-							DisplayName: ev.DisplayName,
-						})
-					}
+			if err := json.Unmarshal(param.EnumValues, &enumValues); err != nil {
+				r.logger.Warn("Failed to unmarshal enum values",
+					zap.Error(err),
+					zap.String("enum_values_json", string(param.EnumValues)))
+			} else {
+				for _, ev := range enumValues {
+					parameterObj.EnumValues = append(parameterObj.EnumValues, model.ParameterEnumValue{
+						ID:          ev.ID,
+						ParameterID: param.ID,
+						EnumValue:   ev.EnumValue,
+						DisplayName: ev.DisplayName,
+					})
 				}
 			}
-
-			indicator.Parameters = append(indicator.Parameters, parameterObj)
 		}
+
+		parameters = append(parameters, parameterObj)
 	}
 
-	return &indicator, nil
+	return parameters, nil
 }
 
 // CreateIndicator adds a new indicator to the database
+// Indicators with an OwnerID are custom indicators whose formula has been validated
 func (r *IndicatorRepository) CreateIndicator(ctx context.Context, indicator *model.TechnicalIndicator) (int, error) {
 	query := `
-		INSERT INTO indicators (name, description, category, formula, min_value, max_value, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		INSERT INTO indicators (
+			name, description, category, formula, min_value, max_value, is_active,
+			owner_id, visibility, validated_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
 		RETURNING id
 	`
 
+	visibility := indicator.Visibility
+	if visibility == "" {
+		visibility = model.IndicatorVisibilityPublic
+	}
+
+	now := time.Now()
+	var validatedAt *time.Time
+	if indicator.OwnerID != nil {
+		validatedAt = &now
+	}
+
 	var id int
 	err := r.db.QueryRowContext(
 		ctx,
@@ -419,7 +296,10 @@ func (r *IndicatorRepository) CreateIndicator(ctx context.Context, indicator *mo
 		indicator.MinValue,
 		indicator.MaxValue,
 		indicator.IsActive,
-		time.Now(),
+		indicator.OwnerID,
+		visibility,
+		validatedAt,
+		now,
 	).Scan(&id)
 
 	if err != nil {
@@ -430,6 +310,78 @@ func (r *IndicatorRepository) CreateIndicator(ctx context.Context, indicator *mo
 	return id, nil
 }
 
+// PlatformIndicatorExists checks whether a platform indicator has the given name
+func (r *IndicatorRepository) PlatformIndicatorExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM indicators WHERE owner_id IS NULL AND LOWER(name) = LOWER($1))`, name)
+	if err != nil {
+		r.logger.Error("Failed to check platform indicator name", zap.Error(err), zap.String("name", name))
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetUsableCustomIndicators retrieves the active custom indicators with the given names that
+// the user owns or that are public, with each parameter's name and default value
+func (r *IndicatorRepository) GetUsableCustomIndicators(ctx context.Context, userID int, names []string) ([]model.TechnicalIndicator, error) {
+	var rows []struct {
+		ID         int             `db:"id"`
+		Name       string          `db:"name"`
+		Formula    sql.NullString  `db:"formula"`
+		OwnerID    int             `db:"owner_id"`
+		Parameters json.RawMessage `db:"parameters"`
+	}
+
+	err := r.db.SelectContext(ctx, &rows, `SELECT * FROM get_usable_custom_indicators($1, $2)`, userID, pq.Array(names))
+	if err != nil {
+		r.logger.Error("Failed to get usable custom indicators", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	indicators := make([]model.TechnicalIndicator, 0, len(rows))
+	for _, row := range rows {
+		var params []struct {
+			Name    string `json:"name"`
+			Default string `json:"default"`
+		}
+		if err := json.Unmarshal(row.Parameters, &params); err != nil {
+			r.logger.Warn("Failed to unmarshal custom indicator parameters",
+				zap.Error(err),
+				zap.Int("indicator_id", row.ID))
+		}
+
+		ownerID := row.OwnerID
+		indicator := model.TechnicalIndicator{
+			ID:         row.ID,
+			Name:       row.Name,
+			Formula:    row.Formula.String,
+			OwnerID:    &ownerID,
+			Parameters: make([]model.IndicatorParameter, 0, len(params)),
+		}
+		for _, param := range params {
+			indicator.Parameters = append(indicator.Parameters, model.IndicatorParameter{
+				IndicatorID:   row.ID,
+				ParameterName: param.Name,
+				DefaultValue:  param.Default,
+			})
+		}
+
+		indicators = append(indicators, indicator)
+	}
+
+	return indicators, nil
+}
+
+// nullableUserID maps the zero user ID of anonymous requests to NULL
+func nullableUserID(userID int) interface{} {
+	if userID == 0 {
+		return nil
+	}
+	return userID
+}
+
 // CreateIndicatorParameter adds a parameter to an indicator
 func (r *IndicatorRepository) CreateIndicatorParameter(ctx context.Context, parameter *model.IndicatorParameterCreate) (int, error) {
 	query := `
@@ -527,7 +479,7 @@ func (r *IndicatorRepository) DeleteIndicator(ctx context.Context, id int) error
 	}
 
 	if !success {
-		return errors.New("indicator not found")
+		return errcode.ErrIndicatorNotFound
 	}
 
 	return nil
@@ -557,7 +509,7 @@ func (r *IndicatorRepository) UpdateIndicator(ctx context.Context, id int, indic
 	}
 
 	if !success {
-		return errors.New("indicator not found")
+		return errcode.ErrIndicatorNotFound
 	}
 
 	return nil
@@ -575,7 +527,7 @@ func (r *IndicatorRepository) DeleteIndicatorParameter(ctx context.Context, id i
 	}
 
 	if !success {
-		return errors.New("parameter not found")
+		return errcode.ErrParameterNotFound
 	}
 
 	return nil
@@ -630,7 +582,7 @@ func (r *IndicatorRepository) DeleteIndicatorParameterEnumValue(ctx context.Cont
 	}
 
 	if !success {
-		return errors.New("enum value not found")
+		return errcode.ErrEnumValueNotFound
 	}
 
 	return nil
@@ -655,7 +607,7 @@ func (r *IndicatorRepository) UpdateIndicatorParameterEnumValue(ctx context.Cont
 	}
 
 	if !success {
-		return errors.New("enum value not found")
+		return errcode.ErrEnumValueNotFound
 	}
 
 	return nil
@@ -664,31 +616,15 @@ func (r *IndicatorRepository) UpdateIndicatorParameterEnumValue(ctx context.Cont
 // GetIndicatorParameterByID retrieves a single parameter by its ID
 func (r *IndicatorRepository) GetIndicatorParameterByID(ctx context.Context, id int) (*model.IndicatorParameter, error) {
 	query := `
-		SELECT id, indicator_id, parameter_name, parameter_type, is_required, 
-			min_value, max_value, default_value, description, is_public
+		SELECT id, indicator_id, parameter_name, parameter_type, is_required,
+			min_value, max_value, COALESCE(default_value, '') AS default_value,
+			COALESCE(description, '') AS description, is_public
 		FROM indicator_parameters
 		WHERE id = $1
 	`
 
 	var param model.IndicatorParameter
-	var minValueNull sql.NullFloat64
-	var maxValueNull sql.NullFloat64
-	var defaultValueNull sql.NullString
-	var descriptionNull sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&param.ID,
-		&param.IndicatorID,
-		&param.ParameterName,
-		&param.ParameterType,
-		&param.IsRequired,
-		&minValueNull,
-		&maxValueNull,
-		&defaultValueNull,
-		&descriptionNull,
-		&param.IsPublic,
-	)
-
+	err := r.db.GetContext(ctx, &param, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Parameter not found
@@ -697,20 +633,6 @@ func (r *IndicatorRepository) GetIndicatorParameterByID(ctx context.Context, id
 		return nil, err
 	}
 
-	// Handle nullable fields
-	if minValueNull.Valid {
-		param.MinValue = &minValueNull.Float64
-	}
-	if maxValueNull.Valid {
-		param.MaxValue = &maxValueNull.Float64
-	}
-	if defaultValueNull.Valid {
-		param.DefaultValue = defaultValueNull.String
-	}
-	if descriptionNull.Valid {
-		param.Description = descriptionNull.String
-	}
-
 	// Get enum values for this parameter
 	enumValues, err := r.GetIndicatorParameterEnumValuesByParameterID(ctx, id)
 	if err != nil {
@@ -742,53 +664,89 @@ func (r *IndicatorRepository) GetIndicatorParameterEnumValuesByParameterID(ctx c
 	return enumValues, nil
 }
 
-// SyncIndicators syncs indicators from the provided list
-func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []model.IndicatorFromBacktesting) (int, error) {
+// SyncIndicators syncs indicators from the provided list and reports every change.
+// With dryRun set, the report is built but nothing is written.
+func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []model.IndicatorFromBacktesting, dryRun bool) (*model.IndicatorSyncReport, error) {
 	// Start a transaction
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		r.logger.Error("Failed to begin transaction", zap.Error(err))
-		return 0, err
+		return nil, err
 	}
 	defer tx.Rollback() // Rollback if not committed
 
 	// Get existing indicators from database
 	var existingIndicators []struct {
-		ID   int    `db:"id"`
-		Name string `db:"name"`
+		ID          int            `db:"id"`
+		Name        string         `db:"name"`
+		Description sql.NullString `db:"description"`
+		IsActive    bool           `db:"is_active"`
 	}
-	err = tx.SelectContext(ctx, &existingIndicators, "SELECT id, name FROM indicators")
+	err = tx.SelectContext(ctx, &existingIndicators,
+		"SELECT id, name, description, is_active FROM indicators WHERE owner_id IS NULL")
 	if err != nil {
 		r.logger.Error("Failed to get existing indicators", zap.Error(err))
-		return 0, err
+		return nil, err
 	}
 
 	// Create map of existing indicators by name for easy lookup
 	existingIndicatorMap := make(map[string]int)
-	for _, indicator := range existingIndicators {
-		existingIndicatorMap[indicator.Name] = indicator.ID
+	for i, indicator := range existingIndicators {
+		existingIndicatorMap[indicator.Name] = i
 	}
 
-	// Count of synced indicators
-	syncedCount := 0
+	report := &model.IndicatorSyncReport{
+		DryRun:      dryRun,
+		Created:     []model.IndicatorSyncChange{},
+		Updated:     []model.IndicatorSyncChange{},
+		Deactivated: []model.IndicatorSyncChange{},
+		Missing:     []model.IndicatorSyncChange{},
+	}
+	seen := make(map[string]bool, len(indicators))
 
 	// Process each indicator
 	for _, indicator := range indicators {
+		seen[indicator.Name] = true
+
+		var changes []model.IndicatorChange
+		var indicatorID int
+		deactivated := false
+
 		// Determine if this is an update or insert
-		indicatorID, exists := existingIndicatorMap[indicator.Name]
+		existingIndex, exists := existingIndicatorMap[indicator.Name]
 
 		if exists {
-			// Update existing indicator - now setting is_active=false
-			_, err = tx.ExecContext(ctx,
-				"UPDATE indicators SET description = $1, is_active = $2, updated_at = NOW() WHERE id = $3",
-				indicator.Description, false, indicatorID)
-			if err != nil {
-				r.logger.Error("Failed to update indicator",
-					zap.Error(err),
-					zap.String("name", indicator.Name))
-				return 0, err
+			existing := existingIndicators[existingIndex]
+			indicatorID = existing.ID
+
+			if existing.Description.String != indicator.Description {
+				changes = append(changes, model.IndicatorChange{
+					Field: "description",
+					Old:   existing.Description.String,
+					New:   indicator.Description,
+				})
 			}
-		} else {
+
+			// Synced indicators are inactive until an admin reviews them
+			if existing.IsActive {
+				deactivated = true
+				id := existing.ID
+				report.Deactivated = append(report.Deactivated, model.IndicatorSyncChange{ID: &id, Name: indicator.Name})
+			}
+
+			if !dryRun {
+				// Update existing indicator - now setting is_active=false
+				_, err = tx.ExecContext(ctx,
+					"UPDATE indicators SET description = $1, is_active = $2, updated_at = NOW() WHERE id = $3",
+					indicator.Description, false, indicatorID)
+				if err != nil {
+					r.logger.Error("Failed to update indicator",
+						zap.Error(err),
+						zap.String("name", indicator.Name))
+					return nil, err
+				}
+			}
+		} else if !dryRun {
 			// Insert new indicator - explicitly set is_active=false
 			// Categorize indicator based on name
 			category := categorizeIndicator(indicator.Name)
@@ -802,29 +760,34 @@ func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []m
 				r.logger.Error("Failed to insert indicator",
 					zap.Error(err),
 					zap.String("name", indicator.Name))
-				return 0, err
+				return nil, err
 			}
 		}
 
 		// Get existing parameters for this indicator
 		var existingParams []struct {
-			ID            int    `db:"id"`
-			ParameterName string `db:"parameter_name"`
+			ID            int            `db:"id"`
+			ParameterName string         `db:"parameter_name"`
+			ParameterType string         `db:"parameter_type"`
+			DefaultValue  sql.NullString `db:"default_value"`
+			IsPublic      bool           `db:"is_public"`
 		}
-		err = tx.SelectContext(ctx, &existingParams,
-			`SELECT id, parameter_name FROM indicator_parameters WHERE indicator_id = $1`,
-			indicatorID)
-		if err != nil {
-			r.logger.Error("Failed to get existing parameters",
-				zap.Error(err),
-				zap.Int("indicator_id", indicatorID))
-			return 0, err
+		if indicatorID != 0 {
+			err = tx.SelectContext(ctx, &existingParams,
+				`SELECT id, parameter_name, parameter_type, default_value, is_public FROM indicator_parameters WHERE indicator_id = $1`,
+				indicatorID)
+			if err != nil {
+				r.logger.Error("Failed to get existing parameters",
+					zap.Error(err),
+					zap.Int("indicator_id", indicatorID))
+				return nil, err
+			}
 		}
 
 		// Create map of existing parameters by name for easy lookup
 		existingParamMap := make(map[string]int)
-		for _, param := range existingParams {
-			existingParamMap[param.ParameterName] = param.ID
+		for i, param := range existingParams {
+			existingParamMap[param.ParameterName] = i
 		}
 
 		// Process parameters
@@ -847,37 +810,60 @@ func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []m
 				paramType = "enum"
 			}
 
-			paramID, paramExists := existingParamMap[param.Name]
+			field := "parameters." + param.Name
+			var paramID int
+			paramIndex, paramExists := existingParamMap[param.Name]
 
 			if paramExists {
-				// Update existing parameter
-				_, err = tx.ExecContext(ctx,
-					`UPDATE indicator_parameters 
+				existingParam := existingParams[paramIndex]
+				paramID = existingParam.ID
+
+				if existingParam.ParameterType != paramType {
+					changes = append(changes, model.IndicatorChange{Field: field + ".type", Old: existingParam.ParameterType, New: paramType})
+				}
+				if existingParam.DefaultValue.String != defaultValue {
+					changes = append(changes, model.IndicatorChange{Field: field + ".default_value", Old: existingParam.DefaultValue.String, New: defaultValue})
+				}
+				if !existingParam.IsPublic {
+					changes = append(changes, model.IndicatorChange{Field: field + ".is_public", Old: "false", New: "true"})
+				}
+
+				if !dryRun {
+					// Update existing parameter
+					_, err = tx.ExecContext(ctx,
+						`UPDATE indicator_parameters 
                      SET parameter_type = $1, default_value = $2, is_public = $3 
                      WHERE id = $4`,
-					paramType, defaultValue, true, paramID)
-				if err != nil {
-					r.logger.Error("Failed to update parameter",
-						zap.Error(err),
-						zap.String("name", param.Name),
-						zap.String("default_value", defaultValue),
-						zap.Int("default_value_length", len(defaultValue)))
-					return 0, err
+						paramType, defaultValue, true, paramID)
+					if err != nil {
+						r.logger.Error("Failed to update parameter",
+							zap.Error(err),
+							zap.String("name", param.Name),
+							zap.String("default_value", defaultValue),
+							zap.Int("default_value_length", len(defaultValue)))
+						return nil, err
+					}
 				}
 			} else {
-				// Insert new parameter - default to public=true
-				err = tx.QueryRowContext(ctx,
-					`INSERT INTO indicator_parameters 
+				if exists {
+					changes = append(changes, model.IndicatorChange{Field: field, New: paramType})
+				}
+
+				if !dryRun {
+					// Insert new parameter - default to public=true
+					err = tx.QueryRowContext(ctx,
+						`INSERT INTO indicator_parameters 
                      (indicator_id, parameter_name, parameter_type, default_value, is_required, is_public) 
                      VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
-					indicatorID, param.Name, paramType, defaultValue, true, true).Scan(&paramID)
-				if err != nil {
-					r.logger.Error("Failed to insert parameter",
-						zap.Error(err),
-						zap.String("name", param.Name),
-						zap.String("default_value", defaultValue),
-						zap.Int("default_value_length", len(defaultValue)))
-					return 0, err
+						indicatorID, param.Name, paramType, defaultValue, true, true).Scan(&paramID)
+					if err != nil {
+						r.logger.Error("Failed to insert parameter",
+							zap.Error(err),
+							zap.String("name", param.Name),
+							zap.String("default_value", defaultValue),
+							zap.Int("default_value_length", len(defaultValue)))
+						return nil, err
+					}
 				}
 			}
 
@@ -888,14 +874,16 @@ func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []m
 					ID        int    `db:"id"`
 					EnumValue string `db:"enum_value"`
 				}
-				err = tx.SelectContext(ctx, &existingEnums,
-					`SELECT id, enum_value FROM parameter_enum_values WHERE parameter_id = $1`,
-					paramID)
-				if err != nil {
-					r.logger.Error("Failed to get existing enum values",
-						zap.Error(err),
-						zap.Int("parameter_id", paramID))
-					return 0, err
+				if paramID != 0 {
+					err = tx.SelectContext(ctx, &existingEnums,
+						`SELECT id, enum_value FROM parameter_enum_values WHERE parameter_id = $1`,
+						paramID)
+					if err != nil {
+						r.logger.Error("Failed to get existing enum values",
+							zap.Error(err),
+							zap.Int("parameter_id", paramID))
+						return nil, err
+					}
 				}
 
 				// Create map of existing enum values by value for easy lookup
@@ -917,6 +905,14 @@ func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []m
 					}
 
 					if _, enumExists := existingEnumMap[optionStr]; !enumExists {
+						if paramExists {
+							changes = append(changes, model.IndicatorChange{Field: field + ".enum_values", New: optionStr})
+						}
+
+						if dryRun {
+							continue
+						}
+
 						// Insert new enum value
 						_, err = tx.ExecContext(ctx,
 							`INSERT INTO parameter_enum_values 
@@ -928,24 +924,369 @@ func (r *IndicatorRepository) SyncIndicators(ctx context.Context, indicators []m
 								zap.Error(err),
 								zap.String("value", optionStr),
 								zap.Int("value_length", len(optionStr)))
-							return 0, err
+							return nil, err
 						}
 					}
 				}
 			}
 		}
 
-		syncedCount++
+		switch {
+		case !exists:
+			created := model.IndicatorSyncChange{Name: indicator.Name}
+			if indicatorID != 0 {
+				id := indicatorID
+				created.ID = &id
+			}
+			report.Created = append(report.Created, created)
+		case len(changes) > 0:
+			id := indicatorID
+			report.Updated = append(report.Updated, model.IndicatorSyncChange{ID: &id, Name: indicator.Name, Changes: changes})
+		case !deactivated:
+			report.Unchanged++
+		}
+
+		report.Synced++
+	}
+
+	for _, existing := range existingIndicators {
+		if !seen[existing.Name] {
+			id := existing.ID
+			report.Missing = append(report.Missing, model.IndicatorSyncChange{ID: &id, Name: existing.Name})
+		}
+	}
+
+	if dryRun {
+		return report, nil
 	}
 
 	// Commit transaction
 	err = tx.Commit()
 	if err != nil {
 		r.logger.Error("Failed to commit transaction", zap.Error(err))
-		return 0, err
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// ExportIndicators retrieves the platform indicator catalog with parameters and enum values
+func (r *IndicatorRepository) ExportIndicators(ctx context.Context) ([]model.IndicatorExport, error) {
+	var indicators []struct {
+		ID          int             `db:"id"`
+		Name        string          `db:"name"`
+		Description sql.NullString  `db:"description"`
+		Category    sql.NullString  `db:"category"`
+		Formula     sql.NullString  `db:"formula"`
+		MinValue    sql.NullFloat64 `db:"min_value"`
+		MaxValue    sql.NullFloat64 `db:"max_value"`
+		IsActive    bool            `db:"is_active"`
+	}
+	err := r.db.SelectContext(ctx, &indicators,
+		`SELECT id, name, description, category, formula, min_value, max_value, is_active
+		 FROM indicators WHERE owner_id IS NULL ORDER BY name`)
+	if err != nil {
+		r.logger.Error("Failed to export indicators", zap.Error(err))
+		return nil, err
+	}
+
+	var parameters []struct {
+		ID            int             `db:"id"`
+		IndicatorID   int             `db:"indicator_id"`
+		ParameterName string          `db:"parameter_name"`
+		ParameterType string          `db:"parameter_type"`
+		IsRequired    bool            `db:"is_required"`
+		MinValue      sql.NullFloat64 `db:"min_value"`
+		MaxValue      sql.NullFloat64 `db:"max_value"`
+		DefaultValue  sql.NullString  `db:"default_value"`
+		Description   sql.NullString  `db:"description"`
+		IsPublic      bool            `db:"is_public"`
+	}
+	err = r.db.SelectContext(ctx, &parameters,
+		`SELECT p.id, p.indicator_id, p.parameter_name, p.parameter_type, p.is_required,
+		        p.min_value, p.max_value, p.default_value, p.description, p.is_public
+		 FROM indicator_parameters p
+		 JOIN indicators i ON i.id = p.indicator_id
+		 WHERE i.owner_id IS NULL
+		 ORDER BY p.indicator_id, p.id`)
+	if err != nil {
+		r.logger.Error("Failed to export indicator parameters", zap.Error(err))
+		return nil, err
 	}
 
-	return syncedCount, nil
+	var enumValues []struct {
+		ParameterID int            `db:"parameter_id"`
+		EnumValue   string         `db:"enum_value"`
+		DisplayName sql.NullString `db:"display_name"`
+	}
+	err = r.db.SelectContext(ctx, &enumValues,
+		`SELECT ev.parameter_id, ev.enum_value, ev.display_name
+		 FROM parameter_enum_values ev
+		 JOIN indicator_parameters p ON p.id = ev.parameter_id
+		 JOIN indicators i ON i.id = p.indicator_id
+		 WHERE i.owner_id IS NULL
+		 ORDER BY ev.parameter_id, ev.id`)
+	if err != nil {
+		r.logger.Error("Failed to export parameter enum values", zap.Error(err))
+		return nil, err
+	}
+
+	enumsByParameter := make(map[int][]model.ParameterEnumValueExport)
+	for _, ev := range enumValues {
+		enumsByParameter[ev.ParameterID] = append(enumsByParameter[ev.ParameterID], model.ParameterEnumValueExport{
+			Value:       ev.EnumValue,
+			DisplayName: ev.DisplayName.String,
+		})
+	}
+
+	parametersByIndicator := make(map[int][]model.IndicatorParameterExport)
+	for _, p := range parameters {
+		parametersByIndicator[p.IndicatorID] = append(parametersByIndicator[p.IndicatorID], model.IndicatorParameterExport{
+			Name:         p.ParameterName,
+			Type:         p.ParameterType,
+			IsRequired:   p.IsRequired,
+			MinValue:     nullFloatPtr(p.MinValue),
+			MaxValue:     nullFloatPtr(p.MaxValue),
+			DefaultValue: p.DefaultValue.String,
+			Description:  p.Description.String,
+			IsPublic:     p.IsPublic,
+			EnumValues:   enumsByParameter[p.ID],
+		})
+	}
+
+	catalog := make([]model.IndicatorExport, 0, len(indicators))
+	for _, i := range indicators {
+		params := parametersByIndicator[i.ID]
+		if params == nil {
+			params = []model.IndicatorParameterExport{}
+		}
+
+		catalog = append(catalog, model.IndicatorExport{
+			Name:        i.Name,
+			Description: i.Description.String,
+			Category:    i.Category.String,
+			Formula:     i.Formula.String,
+			MinValue:    nullFloatPtr(i.MinValue),
+			MaxValue:    nullFloatPtr(i.MaxValue),
+			IsActive:    i.IsActive,
+			Parameters:  params,
+		})
+	}
+
+	return catalog, nil
+}
+
+// ImportIndicators applies an indicator catalog in a single transaction. Existing platform
+// indicators are matched by name and resolved according to mode; any error rolls back the
+// whole import.
+func (r *IndicatorRepository) ImportIndicators(ctx context.Context, indicators []model.IndicatorExport, mode string) (*model.IndicatorImportReport, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	var existingIndicators []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	err = tx.SelectContext(ctx, &existingIndicators, "SELECT id, name FROM indicators WHERE owner_id IS NULL")
+	if err != nil {
+		r.logger.Error("Failed to get existing indicators", zap.Error(err))
+		return nil, err
+	}
+
+	existingIndicatorMap := make(map[string]int)
+	for _, indicator := range existingIndicators {
+		existingIndicatorMap[indicator.Name] = indicator.ID
+	}
+
+	report := &model.IndicatorImportReport{
+		Mode:    mode,
+		Created: []string{},
+		Updated: []string{},
+		Skipped: []string{},
+	}
+
+	for _, indicator := range indicators {
+		indicatorID, exists := existingIndicatorMap[indicator.Name]
+
+		if exists && mode == model.IndicatorImportSkip {
+			report.Skipped = append(report.Skipped, indicator.Name)
+			continue
+		}
+
+		if exists {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE indicators
+				 SET description = $1, category = $2, formula = $3, min_value = $4, max_value = $5,
+				     is_active = $6, updated_at = NOW()
+				 WHERE id = $7`,
+				indicator.Description, indicator.Category, indicator.Formula,
+				indicator.MinValue, indicator.MaxValue, indicator.IsActive, indicatorID)
+			if err != nil {
+				r.logger.Error("Failed to update indicator", zap.Error(err), zap.String("name", indicator.Name))
+				return nil, err
+			}
+
+			// Overwrite replaces the parameter list; enum values go with it
+			if mode == model.IndicatorImportOverwrite {
+				_, err = tx.ExecContext(ctx, "DELETE FROM indicator_parameters WHERE indicator_id = $1", indicatorID)
+				if err != nil {
+					r.logger.Error("Failed to clear indicator parameters", zap.Error(err), zap.String("name", indicator.Name))
+					return nil, err
+				}
+			}
+
+			report.Updated = append(report.Updated, indicator.Name)
+		} else {
+			err = tx.QueryRowContext(ctx,
+				`INSERT INTO indicators (name, description, category, formula, min_value, max_value, is_active, created_at, updated_at)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+				 RETURNING id`,
+				indicator.Name, indicator.Description, indicator.Category, indicator.Formula,
+				indicator.MinValue, indicator.MaxValue, indicator.IsActive).Scan(&indicatorID)
+			if err != nil {
+				r.logger.Error("Failed to insert indicator", zap.Error(err), zap.String("name", indicator.Name))
+				return nil, err
+			}
+
+			report.Created = append(report.Created, indicator.Name)
+		}
+
+		for _, param := range indicator.Parameters {
+			// Upsert by name: new parameters are inserted, merged ones take the imported values
+			var paramID int
+			err = tx.QueryRowContext(ctx,
+				`INSERT INTO indicator_parameters (
+				     indicator_id, parameter_name, parameter_type, is_required,
+				     min_value, max_value, default_value, description, is_public
+				 )
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				 ON CONFLICT (indicator_id, parameter_name) DO UPDATE SET
+				     parameter_type = EXCLUDED.parameter_type,
+				     is_required = EXCLUDED.is_required,
+				     min_value = EXCLUDED.min_value,
+				     max_value = EXCLUDED.max_value,
+				     default_value = EXCLUDED.default_value,
+				     description = EXCLUDED.description,
+				     is_public = EXCLUDED.is_public
+				 RETURNING id`,
+				indicatorID, param.Name, param.Type, param.IsRequired,
+				param.MinValue, param.MaxValue, param.DefaultValue, param.Description, param.IsPublic).Scan(&paramID)
+			if err != nil {
+				r.logger.Error("Failed to import parameter",
+					zap.Error(err),
+					zap.String("indicator", indicator.Name),
+					zap.String("parameter", param.Name))
+				return nil, err
+			}
+
+			for _, ev := range param.EnumValues {
+				displayName := ev.DisplayName
+				if displayName == "" {
+					displayName = ev.Value
+				}
+
+				// Enum values have no unique key, so only add the ones that are missing
+				_, err = tx.ExecContext(ctx,
+					`INSERT INTO parameter_enum_values (parameter_id, enum_value, display_name)
+					 SELECT $1, $2, $3
+					 WHERE NOT EXISTS (
+					     SELECT 1 FROM parameter_enum_values WHERE parameter_id = $1 AND enum_value = $2
+					 )`,
+					paramID, ev.Value, displayName)
+				if err != nil {
+					r.logger.Error("Failed to import enum value",
+						zap.Error(err),
+						zap.String("indicator", indicator.Name),
+						zap.String("parameter", param.Name),
+						zap.String("value", ev.Value))
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// DeprecateIndicator deprecates a platform indicator, or changes its deprecation
+func (r *IndicatorRepository) DeprecateIndicator(ctx context.Context, id int, req *model.IndicatorDeprecate, userID int) error {
+	var sunsetDate interface{}
+	if req.SunsetDate != "" {
+		sunsetDate = req.SunsetDate
+	}
+
+	_, err := r.db.ExecContext(ctx, `SELECT deprecate_indicator($1, $2, $3, $4, $5)`,
+		id, req.ReplacementID, sunsetDate, req.Reason, userID)
+	if err != nil {
+		r.logger.Error("Failed to deprecate indicator", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
+// UndeprecateIndicator withdraws the deprecation of an indicator
+func (r *IndicatorRepository) UndeprecateIndicator(ctx context.Context, id int) error {
+	var success bool
+	err := r.db.QueryRowContext(ctx, `SELECT undeprecate_indicator($1)`, id).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to undeprecate indicator", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	if !success {
+		return errcode.ErrIndicatorNotFound
+	}
+
+	return nil
+}
+
+// GetIndicatorDeprecations retrieves the deprecations of the given indicators, or of every
+// deprecated indicator if ids is nil
+func (r *IndicatorRepository) GetIndicatorDeprecations(ctx context.Context, ids []int) ([]model.IndicatorDeprecation, error) {
+	var idsArg interface{}
+	if ids != nil {
+		idsArg = pq.Array(ids)
+	}
+
+	var deprecations []model.IndicatorDeprecation
+	err := r.db.SelectContext(ctx, &deprecations, `SELECT * FROM get_indicator_deprecations($1)`, idsArg)
+	if err != nil {
+		r.logger.Error("Failed to get indicator deprecations", zap.Error(err))
+		return nil, err
+	}
+
+	return deprecations, nil
+}
+
+// GetDeprecatedIndicatorUsage retrieves the strategies whose current version uses a
+// deprecated indicator
+func (r *IndicatorRepository) GetDeprecatedIndicatorUsage(ctx context.Context) ([]model.DeprecatedIndicatorUsage, error) {
+	var usage []model.DeprecatedIndicatorUsage
+	err := r.db.SelectContext(ctx, &usage, `SELECT * FROM get_deprecated_indicator_usage()`)
+	if err != nil {
+		r.logger.Error("Failed to get deprecated indicator usage", zap.Error(err))
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// nullFloatPtr converts a nullable float to a pointer
+func nullFloatPtr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
 }
 
 // Helper function to categorize indicators based on their name
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"strings"
+	"time"
 
+	"services/shared/database"
+	"services/shared/pagination"
 	"services/strategy-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
@@ -16,13 +18,16 @@ import (
 // MarketplaceRepository handles database operations for the strategy marketplace
 type MarketplaceRepository struct {
 	db     *sqlx.DB
+	router *database.Router[*sqlx.DB]
 	logger *zap.Logger
 }
 
-// NewMarketplaceRepository creates a new marketplace repository
-func NewMarketplaceRepository(db *sqlx.DB, logger *zap.Logger) *MarketplaceRepository {
+// NewMarketplaceRepository creates a new marketplace repository. Marketplace browsing goes
+// to the router's reader; listing changes and reads made to act on them use the primary.
+func NewMarketplaceRepository(router *database.Router[*sqlx.DB], logger *zap.Logger) *MarketplaceRepository {
 	return &MarketplaceRepository{
-		db:     db,
+		db:     router.Primary(),
+		router: router,
 		logger: logger,
 	}
 }
@@ -36,12 +41,13 @@ func (r *MarketplaceRepository) GetAllListings(
 	isFree *bool,
 	tags []int,
 	minRating *float64,
+	sellerID *int,
 	sortBy string,
 	sortDirection string,
 	page, limit int,
 ) ([]model.MarketplaceItem, int, error) {
 	// Calculate offset from page and limit
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
 
 	// Convert Go nil values to SQL NULL values where needed
 	var minPriceSQL interface{} = sql.NullFloat64{Float64: 0, Valid: false}
@@ -59,26 +65,6 @@ func (r *MarketplaceRepository) GetAllListings(
 		minRatingSQL = *minRating
 	}
 
-	// Validate and normalize sort parameters
-	validSortOptions := map[string]bool{
-		"popularity": true,
-		"rating":     true,
-		"price":      true,
-		"newest":     true,
-		"name":       true,
-	}
-
-	// If sortBy is empty or invalid, use default 'popularity'
-	if sortBy == "" || !validSortOptions[sortBy] {
-		sortBy = "popularity"
-	}
-
-	// Normalize sort direction
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "DESC" // Default to descending for most fields
-	}
-
 	// Use a zero-length array if tags is nil
 	tagsParam := pq.Array(tags)
 	if tags == nil {
@@ -86,16 +72,17 @@ func (r *MarketplaceRepository) GetAllListings(
 	}
 
 	// First, get total count using count_marketplace_listings function
-	countQuery := `SELECT count_marketplace_listings($1, $2, $3, $4, $5, $6)`
+	countQuery := `SELECT count_marketplace_listings($1, $2, $3, $4, $5, $6, $7)`
 
 	var total int
-	err := r.db.GetContext(ctx, &total, countQuery,
+	err := r.router.Reader().GetContext(ctx, &total, countQuery,
 		searchTerm,
 		minPriceSQL,
 		maxPriceSQL,
 		isFree,
 		tagsParam,
 		minRatingSQL,
+		sellerID,
 	)
 
 	if err != nil {
@@ -104,35 +91,18 @@ func (r *MarketplaceRepository) GetAllListings(
 	}
 
 	// Now, get paginated data using get_all_marketplace_listings function
-	dataQuery := `SELECT * FROM get_all_marketplace_listings($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-
-	// Define a struct that matches the SQL function columns exactly
-	type listing struct {
-		ID                 int          `db:"id"`
-		StrategyID         int          `db:"strategy_id"`
-		Name               string       `db:"name"`
-		DescriptionPublic  string       `db:"description_public"`
-		ThumbnailURL       string       `db:"thumbnail_url"`
-		UserID             int          `db:"user_id"`
-		Price              float64      `db:"price"`
-		IsSubscription     bool         `db:"is_subscription"`
-		SubscriptionPeriod string       `db:"subscription_period"`
-		IsActive           bool         `db:"is_active"`
-		CreatedAt          sql.NullTime `db:"created_at"`
-		UpdatedAt          sql.NullTime `db:"updated_at"`
-		AverageRating      float64      `db:"average_rating"`
-		ReviewsCount       int64        `db:"reviews_count"`
-	}
-
-	var listings []listing
-
-	err = r.db.SelectContext(ctx, &listings, dataQuery,
+	dataQuery := `SELECT * FROM get_all_marketplace_listings($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	var listings []listingRow
+
+	err = r.router.Reader().SelectContext(ctx, &listings, dataQuery,
 		searchTerm,    // p_search_term
 		minPriceSQL,   // p_min_price
 		maxPriceSQL,   // p_max_price
 		isFree,        // p_is_free
 		tagsParam,     // p_tags
 		minRatingSQL,  // p_min_rating
+		sellerID,      // p_seller_id
 		sortBy,        // p_sort_by
 		sortDirection, // p_sort_direction
 		limit,         // p_limit
@@ -147,38 +117,235 @@ func (r *MarketplaceRepository) GetAllListings(
 	// Convert to model.MarketplaceItem
 	items := make([]model.MarketplaceItem, len(listings))
 	for i, l := range listings {
-		items[i] = model.MarketplaceItem{
-			ID:                 l.ID,
-			StrategyID:         l.StrategyID,
-			Name:               l.Name,
-			ThumbnailURL:       l.ThumbnailURL,
-			UserID:             l.UserID,
-			Price:              l.Price,
-			IsSubscription:     l.IsSubscription,
-			SubscriptionPeriod: l.SubscriptionPeriod,
-			IsActive:           l.IsActive,
-			DescriptionPublic:  l.DescriptionPublic,
-			AverageRating:      l.AverageRating,
-			ReviewsCount:       int(l.ReviewsCount),
-		}
+		items[i] = l.toItem()
+	}
+
+	return items, total, nil
+}
+
+// GetListingsAfter retrieves the page of marketplace listings that follows a cursor using
+// the get_marketplace_listings_after function. A nil cursor returns the first page. The
+// returned cursor marks the last listing of the page and is nil when there are no more.
+func (r *MarketplaceRepository) GetListingsAfter(
+	ctx context.Context,
+	searchTerm string,
+	minPrice *float64,
+	maxPrice *float64,
+	isFree *bool,
+	tags []int,
+	minRating *float64,
+	sellerID *int,
+	sortBy string,
+	sortDirection string,
+	after *model.MarketplaceCursor,
+	limit int,
+) ([]model.MarketplaceItem, *model.MarketplaceCursor, error) {
+	// Use a zero-length array if tags is nil
+	tagsParam := pq.Array(tags)
+	if tags == nil {
+		tagsParam = pq.Array([]int{})
+	}
+
+	var afterValue, afterID interface{}
+	if after != nil {
+		afterValue = after.Value
+		afterID = after.ID
+	}
+
+	query := `SELECT * FROM get_marketplace_listings_after($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	var rows []struct {
+		listingRow
+		SortValue string `db:"sort_value"`
+	}
+
+	// Fetch one extra listing to learn whether there is another page
+	err := r.router.Reader().SelectContext(ctx, &rows, query,
+		searchTerm,
+		minPrice,
+		maxPrice,
+		isFree,
+		tagsParam,
+		minRating,
+		sellerID,
+		sortBy,
+		sortDirection,
+		afterValue,
+		afterID,
+		limit+1,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get marketplace listings after cursor", zap.Error(err))
+		return nil, nil, err
+	}
 
-		// Set CreatedAt if valid
-		if l.CreatedAt.Valid {
-			items[i].CreatedAt = l.CreatedAt.Time
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	items := make([]model.MarketplaceItem, len(rows))
+	for i, row := range rows {
+		items[i] = row.toItem()
+	}
+
+	var next *model.MarketplaceCursor
+	if hasMore {
+		last := rows[len(rows)-1]
+		next = &model.MarketplaceCursor{
+			SortBy:        sortBy,
+			SortDirection: sortDirection,
+			Value:         last.SortValue,
+			ID:            last.ID,
 		}
+	}
 
-		// Set UpdatedAt if valid
-		if l.UpdatedAt.Valid {
-			items[i].UpdatedAt = &l.UpdatedAt.Time
+	return items, next, nil
+}
+
+// listingRow matches the columns returned by the marketplace listing functions
+type listingRow struct {
+	ID                 int          `db:"id"`
+	StrategyID         int          `db:"strategy_id"`
+	Name               string       `db:"name"`
+	DescriptionPublic  string       `db:"description_public"`
+	ThumbnailURL       string       `db:"thumbnail_url"`
+	UserID             int          `db:"user_id"`
+	Price              float64      `db:"price"`
+	IsSubscription     bool         `db:"is_subscription"`
+	SubscriptionPeriod string       `db:"subscription_period"`
+	IsActive           bool         `db:"is_active"`
+	CreatedAt          sql.NullTime `db:"created_at"`
+	UpdatedAt          sql.NullTime `db:"updated_at"`
+	AverageRating      float64      `db:"average_rating"`
+	ReviewsCount       int64        `db:"reviews_count"`
+	Relevance          float64      `db:"relevance"`
+}
+
+// toItem converts a listing row to a model.MarketplaceItem
+func (l listingRow) toItem() model.MarketplaceItem {
+	item := model.MarketplaceItem{
+		ID:                 l.ID,
+		StrategyID:         l.StrategyID,
+		Name:               l.Name,
+		ThumbnailURL:       l.ThumbnailURL,
+		UserID:             l.UserID,
+		Price:              l.Price,
+		IsSubscription:     l.IsSubscription,
+		SubscriptionPeriod: l.SubscriptionPeriod,
+		IsActive:           l.IsActive,
+		DescriptionPublic:  l.DescriptionPublic,
+		AverageRating:      l.AverageRating,
+		ReviewsCount:       int(l.ReviewsCount),
+		Relevance:          l.Relevance,
+	}
+
+	// Set CreatedAt if valid
+	if l.CreatedAt.Valid {
+		item.CreatedAt = l.CreatedAt.Time
+	}
+
+	// Set UpdatedAt if valid
+	if l.UpdatedAt.Valid {
+		updatedAt := l.UpdatedAt.Time
+		item.UpdatedAt = &updatedAt
+	}
+
+	return item
+}
+
+// GetFacets retrieves tag, price and rating facet counts using get_marketplace_facets function
+func (r *MarketplaceRepository) GetFacets(
+	ctx context.Context,
+	searchTerm string,
+	minPrice *float64,
+	maxPrice *float64,
+	isFree *bool,
+	tags []int,
+	minRating *float64,
+) (*model.MarketplaceFacets, error) {
+	// Use a zero-length array if tags is nil
+	tagsParam := pq.Array(tags)
+	if tags == nil {
+		tagsParam = pq.Array([]int{})
+	}
+
+	query := `SELECT * FROM get_marketplace_facets($1, $2, $3, $4, $5, $6)`
+
+	var rows []struct {
+		Facet string `db:"facet"`
+		model.MarketplaceFacetValue
+	}
+
+	err := r.router.Reader().SelectContext(ctx, &rows, query,
+		searchTerm,
+		minPrice,
+		maxPrice,
+		isFree,
+		tagsParam,
+		minRating,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get marketplace facets", zap.Error(err))
+		return nil, err
+	}
+
+	facets := &model.MarketplaceFacets{
+		Tags:    []model.MarketplaceFacetValue{},
+		Price:   []model.MarketplaceFacetValue{},
+		Ratings: []model.MarketplaceFacetValue{},
+	}
+
+	for _, row := range rows {
+		switch row.Facet {
+		case "tag":
+			facets.Tags = append(facets.Tags, row.MarketplaceFacetValue)
+		case "price":
+			facets.Price = append(facets.Price, row.MarketplaceFacetValue)
+		case "rating":
+			facets.Ratings = append(facets.Ratings, row.MarketplaceFacetValue)
 		}
 	}
 
-	return items, total, nil
+	return facets, nil
+}
+
+// GetSellerStats retrieves the public statistics of a seller using get_seller_stats function
+func (r *MarketplaceRepository) GetSellerStats(ctx context.Context, sellerID int) (*model.SellerStats, error) {
+	query := `SELECT * FROM get_seller_stats($1)`
+
+	var stats model.SellerStats
+	if err := r.router.Reader().GetContext(ctx, &stats, query, sellerID); err != nil {
+		r.logger.Error("Failed to get seller stats", zap.Error(err), zap.Int("seller_id", sellerID))
+		return nil, err
+	}
+	stats.SellerID = sellerID
+
+	return &stats, nil
+}
+
+// GetListingPurchasers retrieves the buyers of a listing who still have access to it using
+// get_listing_purchasers function
+func (r *MarketplaceRepository) GetListingPurchasers(ctx context.Context, marketplaceID int) ([]int, error) {
+	query := `SELECT user_id FROM get_listing_purchasers($1)`
+
+	userIDs := []int{}
+	if err := r.db.SelectContext(ctx, &userIDs, query, marketplaceID); err != nil {
+		r.logger.Error("Failed to get listing purchasers", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return userIDs, nil
 }
 
 // CreateListing adds a new marketplace listing using create_marketplace_listing function
 func (r *MarketplaceRepository) CreateListing(ctx context.Context, listing *model.MarketplaceCreate, userID int) (int, error) {
-	query := `SELECT create_marketplace_listing($1, $2, $3, $4, $5, $6, $7)`
+	query := `SELECT create_marketplace_listing($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	updatePolicy := listing.UpdatePolicy
+	if updatePolicy == "" {
+		updatePolicy = model.ListingUpdatePolicyLocked
+	}
 
 	var id int
 	err := r.db.QueryRowContext(
@@ -191,6 +358,9 @@ func (r *MarketplaceRepository) CreateListing(ctx context.Context, listing *mode
 		listing.IsSubscription,
 		listing.SubscriptionPeriod,
 		listing.DescriptionPublic,
+		listing.Currency,
+		updatePolicy,
+		listing.UpgradePrice,
 	).Scan(&id)
 
 	if err != nil {
@@ -205,24 +375,8 @@ func (r *MarketplaceRepository) CreateListing(ctx context.Context, listing *mode
 func (r *MarketplaceRepository) GetListingByID(ctx context.Context, id int) (*model.MarketplaceItem, error) {
 	query := `SELECT * FROM get_marketplace_listing_by_id($1)`
 
-	var item model.MarketplaceItem
-	var createdAt sql.NullTime
-	var updatedAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&item.ID,
-		&item.StrategyID,
-		&item.VersionID,
-		&item.UserID,
-		&item.Price,
-		&item.IsSubscription,
-		&item.SubscriptionPeriod,
-		&item.IsActive,
-		&item.DescriptionPublic,
-		&createdAt,
-		&updatedAt,
-	)
-
+	var row listingByIDRow
+	err := r.db.GetContext(ctx, &row, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -231,16 +385,32 @@ func (r *MarketplaceRepository) GetListingByID(ctx context.Context, id int) (*mo
 		return nil, err
 	}
 
-	// Handle nullable times
-	if createdAt.Valid {
-		item.CreatedAt = createdAt.Time
+	item := row.MarketplaceItem
+	if row.CreatedAt.Valid {
+		item.CreatedAt = row.CreatedAt.Time
 	}
 
-	if updatedAt.Valid {
-		item.UpdatedAt = &updatedAt.Time
+	return &item, nil
+}
+
+// listingByIDRow matches the columns returned by get_marketplace_listing_by_id, whose
+// created_at may be NULL
+type listingByIDRow struct {
+	model.MarketplaceItem
+	CreatedAt sql.NullTime `db:"created_at"`
+}
+
+// SetUpdatePolicy changes whether buyers of a listing get later versions using
+// set_listing_update_policy function
+func (r *MarketplaceRepository) SetUpdatePolicy(ctx context.Context, id int, userID int, update *model.ListingUpdatePolicyUpdate) error {
+	query := `SELECT set_listing_update_policy($1, $2, $3, $4)`
+
+	if _, err := r.db.ExecContext(ctx, query, id, userID, update.UpdatePolicy, update.UpgradePrice); err != nil {
+		r.logger.Error("Failed to set listing update policy", zap.Error(err), zap.Int("id", id))
+		return err
 	}
 
-	return &item, nil
+	return nil
 }
 
 // DeleteListing removes a marketplace listing using delete_marketplace_listing function
@@ -260,3 +430,283 @@ func (r *MarketplaceRepository) DeleteListing(ctx context.Context, id int, userI
 
 	return nil
 }
+
+// AttachBacktest stores a verified backtest snapshot using attach_marketplace_backtest function
+func (r *MarketplaceRepository) AttachBacktest(ctx context.Context, sellerID int, snapshot *model.VerifiedBacktest) (int, error) {
+	query := `SELECT attach_marketplace_backtest($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`
+
+	var id sql.NullInt64
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		snapshot.MarketplaceID,
+		sellerID,
+		snapshot.BacktestID,
+		snapshot.StrategyID,
+		snapshot.StrategyVersion,
+		snapshot.Timeframe,
+		snapshot.StartDate,
+		snapshot.EndDate,
+		snapshot.InitialCapital,
+		snapshot.TotalTrades,
+		snapshot.WinRate,
+		snapshot.ProfitFactor,
+		snapshot.SharpeRatio,
+		snapshot.MaxDrawdown,
+		snapshot.TotalReturn,
+		snapshot.AnnualizedReturn,
+		snapshot.Runs,
+		snapshot.BacktestCompletedAt,
+		snapshot.Leverage,
+		snapshot.PositionSizing,
+	).Scan(&id)
+
+	if err != nil {
+		r.logger.Error("Failed to attach backtest to listing",
+			zap.Error(err),
+			zap.Int("marketplace_id", snapshot.MarketplaceID),
+			zap.Int("backtest_id", snapshot.BacktestID))
+		return 0, err
+	}
+
+	if !id.Valid {
+		return 0, errors.New("listing not found or not authorized")
+	}
+
+	return int(id.Int64), nil
+}
+
+// PublishListingVersion publishes a newer strategy version to a listing and records it in
+// the changelog using publish_listing_version function
+func (r *MarketplaceRepository) PublishListingVersion(ctx context.Context, marketplaceID int, userID int, publish *model.ListingVersionPublish) (*model.ListingVersion, error) {
+	query := `SELECT version, COALESCE(notes, '') AS notes, published_at, TRUE AS is_current
+		FROM publish_listing_version($1, $2, $3, $4)`
+
+	var version model.ListingVersion
+	err := r.db.GetContext(ctx, &version, query, marketplaceID, userID, publish.Version, publish.Notes)
+	if err != nil {
+		r.logger.Error("Failed to publish listing version",
+			zap.Error(err),
+			zap.Int("marketplace_id", marketplaceID),
+			zap.Int("version", publish.Version))
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// GetListingChangelog retrieves the versions published to a listing, newest first, using
+// get_listing_changelog function
+func (r *MarketplaceRepository) GetListingChangelog(ctx context.Context, marketplaceID int) ([]model.ListingVersion, error) {
+	query := `SELECT version, COALESCE(notes, '') AS notes, published_at, is_current
+		FROM get_listing_changelog($1)`
+
+	versions := []model.ListingVersion{}
+	if err := r.db.SelectContext(ctx, &versions, query, marketplaceID); err != nil {
+		r.logger.Error("Failed to get listing changelog", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// GetListingPriceHistory retrieves the prices a listing had, newest first, using
+// get_listing_price_history function
+func (r *MarketplaceRepository) GetListingPriceHistory(ctx context.Context, marketplaceID int) ([]model.ListingPrice, error) {
+	query := `SELECT * FROM get_listing_price_history($1)`
+
+	prices := []model.ListingPrice{}
+	if err := r.db.SelectContext(ctx, &prices, query, marketplaceID); err != nil {
+		r.logger.Error("Failed to get listing price history", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// GetLowestPrices returns the lowest price each of the given listings had since a point in
+// time, in its current currency, using get_listing_lowest_prices function
+func (r *MarketplaceRepository) GetLowestPrices(ctx context.Context, marketplaceIDs []int, since time.Time) (map[int]float64, error) {
+	query := `SELECT * FROM get_listing_lowest_prices($1, $2)`
+
+	var rows []struct {
+		MarketplaceID int     `db:"marketplace_id"`
+		LowestPrice   float64 `db:"lowest_price"`
+	}
+
+	err := r.router.Reader().SelectContext(ctx, &rows, query, pq.Array(marketplaceIDs), since)
+	if err != nil {
+		r.logger.Error("Failed to get lowest listing prices", zap.Error(err))
+		return nil, err
+	}
+
+	prices := make(map[int]float64, len(rows))
+	for _, row := range rows {
+		prices[row.MarketplaceID] = row.LowestPrice
+	}
+
+	return prices, nil
+}
+
+// GetVerifiedBacktest retrieves the verified backtest snapshot of a listing using get_marketplace_backtest function
+func (r *MarketplaceRepository) GetVerifiedBacktest(ctx context.Context, marketplaceID int) (*model.VerifiedBacktest, error) {
+	query := `SELECT * FROM get_marketplace_backtest($1)`
+
+	var snapshot model.VerifiedBacktest
+	err := r.db.GetContext(ctx, &snapshot, query, marketplaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get listing backtest", zap.Error(err), zap.Int("marketplace_id", marketplaceID))
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetListingCurrencies returns the currency of each of the given listings using
+// get_listing_currencies function
+func (r *MarketplaceRepository) GetListingCurrencies(ctx context.Context, marketplaceIDs []int) (map[int]string, error) {
+	query := `SELECT * FROM get_listing_currencies($1)`
+
+	var rows []struct {
+		MarketplaceID int    `db:"marketplace_id"`
+		Currency      string `db:"currency"`
+	}
+
+	err := r.router.Reader().SelectContext(ctx, &rows, query, pq.Array(marketplaceIDs))
+	if err != nil {
+		r.logger.Error("Failed to get listing currencies", zap.Error(err))
+		return nil, err
+	}
+
+	currencies := make(map[int]string, len(rows))
+	for _, row := range rows {
+		currencies[row.MarketplaceID] = row.Currency
+	}
+
+	return currencies, nil
+}
+
+// GetVerifiedListingIDs returns which of the given listings have a verified backtest
+func (r *MarketplaceRepository) GetVerifiedListingIDs(ctx context.Context, marketplaceIDs []int) (map[int]bool, error) {
+	query := `SELECT marketplace_id FROM get_verified_marketplace_ids($1)`
+
+	var ids []int
+	err := r.router.Reader().SelectContext(ctx, &ids, query, pq.Array(marketplaceIDs))
+	if err != nil {
+		r.logger.Error("Failed to get verified listing IDs", zap.Error(err))
+		return nil, err
+	}
+
+	verified := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		verified[id] = true
+	}
+
+	return verified, nil
+}
+
+// rankedListingRow is a listing row with the scores returned by the trending functions
+type rankedListingRow struct {
+	listingRow
+	TrendingScore float64 `db:"trending_score"`
+	MatchScore    float64 `db:"match_score"`
+}
+
+// toItem converts a ranked listing row to a model.MarketplaceItem
+func (l rankedListingRow) toItem() model.MarketplaceItem {
+	item := l.listingRow.toItem()
+	item.TrendingScore = l.TrendingScore
+	item.MatchScore = l.MatchScore
+	return item
+}
+
+// RecordListingEvent records a listing view or purchase using record_listing_event function.
+// userID is nil for anonymous views. Returns false if the event was already recorded, is a
+// repeated view or the listing doesn't exist.
+func (r *MarketplaceRepository) RecordListingEvent(
+	ctx context.Context,
+	marketplaceID int,
+	userID *int,
+	eventType string,
+	eventKey string,
+	occurredAt time.Time,
+) (bool, error) {
+	query := `SELECT record_listing_event($1, $2, $3, $4, $5)`
+
+	var recorded bool
+	err := r.db.GetContext(ctx, &recorded, query, marketplaceID, userID, eventType, eventKey, occurredAt)
+	if err != nil {
+		r.logger.Error("Failed to record listing event",
+			zap.Error(err),
+			zap.Int("marketplace_id", marketplaceID),
+			zap.String("event_type", eventType))
+		return false, err
+	}
+
+	return recorded, nil
+}
+
+// GetTrendingListings retrieves the active listings with the highest trending scores using
+// get_trending_listings function
+func (r *MarketplaceRepository) GetTrendingListings(
+	ctx context.Context,
+	params model.TrendingParams,
+	limit int,
+) ([]model.MarketplaceItem, error) {
+	query := `SELECT * FROM get_trending_listings($1, $2, $3, $4, $5)`
+
+	var rows []rankedListingRow
+	err := r.router.Reader().SelectContext(ctx, &rows, query,
+		params.Since,
+		params.HalfLifeHours,
+		params.ViewWeight,
+		params.PurchaseWeight,
+		limit,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get trending listings", zap.Error(err))
+		return nil, err
+	}
+
+	items := make([]model.MarketplaceItem, len(rows))
+	for i, row := range rows {
+		items[i] = row.toItem()
+	}
+
+	return items, nil
+}
+
+// GetRecommendedListings retrieves the active listings that best match the tags of the
+// strategies a user bought or viewed using get_recommended_listings function
+func (r *MarketplaceRepository) GetRecommendedListings(
+	ctx context.Context,
+	userID int,
+	params model.TrendingParams,
+	limit int,
+) ([]model.MarketplaceItem, error) {
+	query := `SELECT * FROM get_recommended_listings($1, $2, $3, $4, $5, $6)`
+
+	var rows []rankedListingRow
+	err := r.router.Reader().SelectContext(ctx, &rows, query,
+		userID,
+		params.Since,
+		params.HalfLifeHours,
+		params.ViewWeight,
+		params.PurchaseWeight,
+		limit,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get recommended listings", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	items := make([]model.MarketplaceItem, len(rows))
+	for i, row := range rows {
+		items[i] = row.toItem()
+	}
+
+	return items, nil
+}
@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
@@ -22,24 +26,62 @@ func NewPurchaseRepository(db *sqlx.DB, logger *zap.Logger) *PurchaseRepository
 	}
 }
 
-// Purchase adds a new purchase record using purchase_strategy function
-func (r *PurchaseRepository) Purchase(ctx context.Context, marketplaceID int, userID int) (int, error) {
-	query := `SELECT purchase_strategy($1, $2)`
+// Purchase adds a new purchase record using purchase_strategy function. With a coupon, the
+// coupon is redeemed and discount is taken off the list price in the same transaction.
+// credit is referral credit already redeemed for the purchase through redemptionID, paying
+// for what's left. With useWallet, the buyer's wallet pays what remains as far as it goes;
+// the amount it paid is returned with the purchase ID.
+func (r *PurchaseRepository) Purchase(ctx context.Context, marketplaceID int, userID int, couponID *int, discount, credit float64, redemptionID *int, useWallet bool) (int, float64, error) {
+	query := `SELECT purchase_id, wallet_amount FROM purchase_strategy($1, $2, $3, $4, $5, $6, $7)`
 
 	var id int
+	var walletAmount float64
 	err := r.db.QueryRowContext(
 		ctx,
 		query,
 		userID,
 		marketplaceID,
-	).Scan(&id)
+		couponID,
+		discount,
+		credit,
+		useWallet,
+		redemptionID,
+	).Scan(&id, &walletAmount)
 
 	if err != nil {
 		r.logger.Error("Failed to purchase strategy", zap.Error(err))
-		return 0, err
+		return 0, 0, err
+	}
+
+	return id, walletAmount, nil
+}
+
+// GetPurchaseReferralRedemption retrieves the referral credit redemption that paid for part
+// of a purchase using get_purchase_referral_redemption function. It returns nil if none did.
+func (r *PurchaseRepository) GetPurchaseReferralRedemption(ctx context.Context, purchaseID int) (*int, error) {
+	query := `SELECT get_purchase_referral_redemption($1)`
+
+	var redemptionID *int
+	if err := r.db.GetContext(ctx, &redemptionID, query, purchaseID); err != nil {
+		r.logger.Error("Failed to get purchase referral redemption", zap.Error(err), zap.Int("purchase_id", purchaseID))
+		return nil, err
+	}
+
+	return redemptionID, nil
+}
+
+// UpgradePurchase upgrades a version-locked purchase to the listing's current version using
+// upgrade_purchase function
+func (r *PurchaseRepository) UpgradePurchase(ctx context.Context, purchaseID int, userID int) (*model.PurchaseUpgrade, error) {
+	query := `SELECT * FROM upgrade_purchase($1, $2)`
+
+	var upgrade model.PurchaseUpgrade
+	if err := r.db.GetContext(ctx, &upgrade, query, purchaseID, userID); err != nil {
+		r.logger.Error("Failed to upgrade purchase", zap.Error(err), zap.Int("purchase_id", purchaseID))
+		return nil, err
 	}
 
-	return id, nil
+	return &upgrade, nil
 }
 
 // CancelSubscription cancels a subscription using cancel_subscription function
@@ -59,3 +101,72 @@ func (r *PurchaseRepository) CancelSubscription(ctx context.Context, purchaseID
 
 	return nil
 }
+
+// GetPurchaseByID retrieves a purchase visible to the buyer or seller using get_purchase_by_id function
+func (r *PurchaseRepository) GetPurchaseByID(ctx context.Context, purchaseID int, userID int) (*model.PurchaseDetails, error) {
+	query := `SELECT * FROM get_purchase_by_id($1, $2)`
+
+	var purchase model.PurchaseDetails
+	err := r.db.GetContext(ctx, &purchase, query, purchaseID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get purchase", zap.Error(err), zap.Int("purchase_id", purchaseID))
+		return nil, err
+	}
+
+	return &purchase, nil
+}
+
+// GetUserPurchases retrieves the purchase history of a buyer using get_user_purchases function
+func (r *PurchaseRepository) GetUserPurchases(ctx context.Context, userID int, page, limit int) ([]model.PurchaseDetails, int, error) {
+	countQuery := `SELECT count_user_purchases($1)`
+
+	var total int
+	err := r.db.GetContext(ctx, &total, countQuery, userID)
+	if err != nil {
+		r.logger.Error("Failed to count purchases", zap.Error(err), zap.Int("user_id", userID))
+		return nil, 0, err
+	}
+
+	offset := pagination.Offset(page, limit)
+	query := `SELECT * FROM get_user_purchases($1, $2, $3)`
+
+	purchases := []model.PurchaseDetails{}
+	err = r.db.SelectContext(ctx, &purchases, query, userID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get purchases", zap.Error(err), zap.Int("user_id", userID))
+		return nil, 0, err
+	}
+
+	return purchases, total, nil
+}
+
+// ExpireSubscriptions marks lapsed subscriptions as expired and revokes access using expire_subscriptions function
+func (r *PurchaseRepository) ExpireSubscriptions(ctx context.Context) ([]model.SubscriptionEvent, error) {
+	query := `SELECT * FROM expire_subscriptions()`
+
+	var expired []model.SubscriptionEvent
+	err := r.db.SelectContext(ctx, &expired, query)
+	if err != nil {
+		r.logger.Error("Failed to expire subscriptions", zap.Error(err))
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// ClaimRenewalReminders claims subscriptions ending within the given days using claim_renewal_reminders function
+func (r *PurchaseRepository) ClaimRenewalReminders(ctx context.Context, days int) ([]model.SubscriptionEvent, error) {
+	query := `SELECT * FROM claim_renewal_reminders($1)`
+
+	var due []model.SubscriptionEvent
+	err := r.db.SelectContext(ctx, &due, query, days)
+	if err != nil {
+		r.logger.Error("Failed to claim renewal reminders", zap.Error(err), zap.Int("days", days))
+		return nil, err
+	}
+
+	return due, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// RefundRepository handles database operations for purchase refund requests
+type RefundRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewRefundRepository creates a new refund repository
+func NewRefundRepository(db *sqlx.DB, logger *zap.Logger) *RefundRepository {
+	return &RefundRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RequestRefund adds a refund request for a purchase of the buyer using request_purchase_refund function
+func (r *RefundRepository) RequestRefund(ctx context.Context, purchaseID int, buyerID int, reason string) (int, error) {
+	query := `SELECT request_purchase_refund($1, $2, $3)`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, purchaseID, buyerID, reason).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to request refund", zap.Error(err), zap.Int("purchase_id", purchaseID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetRefundRequests retrieves refund requests using get_refund_requests function. A nil
+// sellerID returns the requests of every seller and an empty status those of every status.
+func (r *RefundRepository) GetRefundRequests(
+	ctx context.Context,
+	sellerID *int,
+	status string,
+	page, limit int,
+) ([]model.RefundRequest, int, error) {
+	var statusParam interface{}
+	if status != "" {
+		statusParam = status
+	}
+
+	countQuery := `SELECT count_refund_requests($1, $2)`
+
+	var total int
+	err := r.db.GetContext(ctx, &total, countQuery, sellerID, statusParam)
+	if err != nil {
+		r.logger.Error("Failed to count refund requests", zap.Error(err))
+		return nil, 0, err
+	}
+
+	offset := pagination.Offset(page, limit)
+	query := `SELECT * FROM get_refund_requests($1, $2, $3, $4)`
+
+	refunds := []model.RefundRequest{}
+	err = r.db.SelectContext(ctx, &refunds, query, sellerID, statusParam, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get refund requests", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return refunds, total, nil
+}
+
+// GetRefundRequestByID retrieves a refund request using get_refund_request_by_id function.
+// Returns nil if it doesn't exist.
+func (r *RefundRepository) GetRefundRequestByID(ctx context.Context, refundID int) (*model.RefundRequest, error) {
+	query := `SELECT * FROM get_refund_request_by_id($1)`
+
+	var refund model.RefundRequest
+	err := r.db.GetContext(ctx, &refund, query, refundID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get refund request", zap.Error(err), zap.Int("refund_id", refundID))
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// ReviewRefundRequest approves or rejects a pending refund request using review_refund_request
+// function. Approving also revokes the buyer's access. Returns false if the request isn't pending.
+func (r *RefundRepository) ReviewRefundRequest(
+	ctx context.Context,
+	refundID int,
+	reviewerID int,
+	approve bool,
+	note string,
+	providerRefundID string,
+) (bool, error) {
+	query := `SELECT review_refund_request($1, $2, $3, $4, $5)`
+
+	var noteParam, providerParam interface{}
+	if note != "" {
+		noteParam = note
+	}
+	if providerRefundID != "" {
+		providerParam = providerRefundID
+	}
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, refundID, reviewerID, approve, noteParam, providerParam).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to review refund request", zap.Error(err), zap.Int("refund_id", refundID))
+		return false, err
+	}
+
+	return success, nil
+}
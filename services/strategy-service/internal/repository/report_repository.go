@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ReportRepository handles database operations for reports of listings and reviews
+type ReportRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *sqlx.DB, logger *zap.Logger) *ReportRepository {
+	return &ReportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateReport adds a report of a listing or review using create_content_report function
+func (r *ReportRepository) CreateReport(
+	ctx context.Context,
+	targetType string,
+	targetID int,
+	reporterID int,
+	report *model.ReportCreate,
+) (int, error) {
+	query := `SELECT create_content_report($1, $2, $3, $4, $5)`
+
+	var details interface{}
+	if report.Details != "" {
+		details = report.Details
+	}
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, targetType, targetID, reporterID, report.Category, details).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to create report", zap.Error(err),
+			zap.String("target_type", targetType), zap.Int("target_id", targetID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetReports retrieves reports using get_content_reports function. Empty filters match
+// every status or target type.
+func (r *ReportRepository) GetReports(
+	ctx context.Context,
+	status string,
+	targetType string,
+	page, limit int,
+) ([]model.ContentReport, int, error) {
+	var statusParam, targetTypeParam interface{}
+	if status != "" {
+		statusParam = status
+	}
+	if targetType != "" {
+		targetTypeParam = targetType
+	}
+
+	countQuery := `SELECT count_content_reports($1, $2)`
+
+	var total int
+	err := r.db.GetContext(ctx, &total, countQuery, statusParam, targetTypeParam)
+	if err != nil {
+		r.logger.Error("Failed to count reports", zap.Error(err))
+		return nil, 0, err
+	}
+
+	offset := pagination.Offset(page, limit)
+	query := `SELECT * FROM get_content_reports($1, $2, $3, $4)`
+
+	reports := []model.ContentReport{}
+	err = r.db.SelectContext(ctx, &reports, query, statusParam, targetTypeParam, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get reports", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// GetReportByID retrieves a report using get_content_report_by_id function.
+// Returns nil if it doesn't exist.
+func (r *ReportRepository) GetReportByID(ctx context.Context, reportID int) (*model.ContentReport, error) {
+	query := `SELECT * FROM get_content_report_by_id($1)`
+
+	var report model.ContentReport
+	err := r.db.GetContext(ctx, &report, query, reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get report", zap.Error(err), zap.Int("report_id", reportID))
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// ResolveReport resolves an open report and the other open reports of its target using
+// resolve_content_report function, applying the action to the target. Returns the IDs of
+// the users whose reports were resolved, none if the report isn't open.
+func (r *ReportRepository) ResolveReport(
+	ctx context.Context,
+	reportID int,
+	resolverID int,
+	resolution *model.ReportResolution,
+) ([]int, error) {
+	query := `SELECT reporter_id FROM resolve_content_report($1, $2, $3, $4)`
+
+	var note interface{}
+	if resolution.Note != "" {
+		note = resolution.Note
+	}
+
+	reporterIDs := []int{}
+	err := r.db.SelectContext(ctx, &reporterIDs, query, reportID, resolverID, resolution.Action, note)
+	if err != nil {
+		r.logger.Error("Failed to resolve report", zap.Error(err), zap.Int("report_id", reportID))
+		return nil, err
+	}
+
+	return reporterIDs, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"services/shared/pagination"
 	"services/strategy-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
@@ -110,7 +111,7 @@ func (r *ReviewRepository) GetByMarketplaceID(
 	}
 
 	// Calculate offset for pagination
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
 
 	// Use the updated get_strategy_reviews function with minRating parameter
 	query := `SELECT * FROM get_strategy_reviews($1, $2, $3, $4)`
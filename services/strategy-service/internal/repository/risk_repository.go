@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// RiskRepository handles database operations for strategy risk scores
+type RiskRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewRiskRepository creates a new risk repository
+func NewRiskRepository(db *sqlx.DB, logger *zap.Logger) *RiskRepository {
+	return &RiskRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetVerifiedBacktests retrieves the verified backtest snapshots of a strategy version
+func (r *RiskRepository) GetVerifiedBacktests(ctx context.Context, strategyID int) ([]model.VerifiedBacktest, error) {
+	query := `SELECT * FROM get_strategy_verified_backtests($1)`
+
+	backtests := []model.VerifiedBacktest{}
+	err := r.db.SelectContext(ctx, &backtests, query, strategyID)
+	if err != nil {
+		r.logger.Error("Failed to get verified backtests of strategy", zap.Error(err), zap.Int("strategyID", strategyID))
+		return nil, err
+	}
+
+	return backtests, nil
+}
+
+// GetListingStrategyID returns the ID of the strategy version a listing sells, or 0 if
+// the listing or version doesn't exist
+func (r *RiskRepository) GetListingStrategyID(ctx context.Context, marketplaceID int) (int, error) {
+	query := `SELECT get_listing_version_strategy_id($1)`
+
+	var id sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, marketplaceID).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to get strategy version of listing", zap.Error(err), zap.Int("marketplaceID", marketplaceID))
+		return 0, err
+	}
+
+	return int(id.Int64), nil
+}
+
+// SaveRiskScore stores the risk score of a strategy version, replacing the previous one
+func (r *RiskRepository) SaveRiskScore(ctx context.Context, score *model.StrategyRiskScore) error {
+	query := `SELECT upsert_strategy_risk_score($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		score.StrategyID,
+		score.Score,
+		score.Level,
+		score.Components,
+		score.BacktestCount,
+	)
+	if err != nil {
+		r.logger.Error("Failed to save strategy risk score", zap.Error(err), zap.Int("strategyID", score.StrategyID))
+		return err
+	}
+
+	return nil
+}
+
+// GetRiskScore retrieves the risk score of a strategy version, or nil if none was computed
+func (r *RiskRepository) GetRiskScore(ctx context.Context, strategyID int) (*model.StrategyRiskScore, error) {
+	query := `SELECT * FROM get_strategy_risk_score($1)`
+
+	var score model.StrategyRiskScore
+	err := r.db.GetContext(ctx, &score, query, strategyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get strategy risk score", zap.Error(err), zap.Int("strategyID", strategyID))
+		return nil, err
+	}
+
+	return &score, nil
+}
+
+// GetListingRiskScores returns the risk scores of the strategy versions the given listings
+// sell, by listing ID. Listings without a score are left out.
+func (r *RiskRepository) GetListingRiskScores(ctx context.Context, marketplaceIDs []int) (map[int]*model.StrategyRiskScore, error) {
+	query := `SELECT * FROM get_listing_risk_scores($1)`
+
+	var rows []struct {
+		MarketplaceID int             `db:"marketplace_id"`
+		StrategyID    int             `db:"strategy_id"`
+		Score         int             `db:"score"`
+		Level         string          `db:"level"`
+		Components    json.RawMessage `db:"components"`
+		BacktestCount int             `db:"backtest_count"`
+		ComputedAt    sql.NullTime    `db:"computed_at"`
+	}
+
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(marketplaceIDs))
+	if err != nil {
+		r.logger.Error("Failed to get listing risk scores", zap.Error(err))
+		return nil, err
+	}
+
+	scores := make(map[int]*model.StrategyRiskScore, len(rows))
+	for _, row := range rows {
+		scores[row.MarketplaceID] = &model.StrategyRiskScore{
+			StrategyID:    row.StrategyID,
+			Score:         row.Score,
+			Level:         row.Level,
+			Components:    row.Components,
+			BacktestCount: row.BacktestCount,
+			ComputedAt:    row.ComputedAt.Time,
+		}
+	}
+
+	return scores, nil
+}
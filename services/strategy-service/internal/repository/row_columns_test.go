@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"io/fs"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"services/strategy-service/migrations"
+)
+
+// TestRowColumns checks the row structs rows are scanned into against the RETURNS TABLE
+// columns of the latest definition of their functions in the migrations. Every column
+// needs a field, or scanning fails; rows declared for their function alone must not have
+// fields the function doesn't return either.
+func TestRowColumns(t *testing.T) {
+	functions := returnedColumns(t)
+
+	tests := []struct {
+		function string
+		row      interface{}
+		exact    bool // The row has a field for every column and nothing else
+	}{
+		{"get_all_strategies", strategyListRow{}, true},
+		{"get_strategy_versions", strategyVersionRow{}, false},
+		{"get_indicators", indicatorRow{}, true},
+		{"get_indicator_by_id", indicatorRow{}, true},
+		{"get_all_marketplace_listings", listingRow{}, true},
+		{"get_marketplace_listings_after", struct {
+			listingRow
+			SortValue string `db:"sort_value"`
+		}{}, true},
+		{"get_trending_listings", rankedListingRow{}, false},
+		{"get_recommended_listings", rankedListingRow{}, false},
+		{"get_marketplace_listing_by_id", listingByIDRow{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			columns, ok := functions[tt.function]
+			if !ok {
+				t.Fatalf("no RETURNS TABLE definition of %s in the migrations", tt.function)
+			}
+
+			fields := dbFields(reflect.TypeOf(tt.row))
+			for _, column := range columns {
+				if !fields[column] {
+					t.Errorf("%s returns column %q, which %T has no field for", tt.function, column, tt.row)
+				}
+			}
+
+			if !tt.exact {
+				return
+			}
+			returned := make(map[string]bool, len(columns))
+			for _, column := range columns {
+				returned[column] = true
+			}
+			var extra []string
+			for field := range fields {
+				if !returned[field] {
+					extra = append(extra, field)
+				}
+			}
+			sort.Strings(extra)
+			if len(extra) > 0 {
+				t.Errorf("%T has fields %v, which %s doesn't return", tt.row, extra, tt.function)
+			}
+		})
+	}
+}
+
+var (
+	sqlComment     = regexp.MustCompile(`--[^\n]*`)
+	createFunction = regexp.MustCompile(`(?i)CREATE\s+OR\s+REPLACE\s+FUNCTION\s+(\w+)\s*\(`)
+	returnsTable   = regexp.MustCompile(`(?i)^\s*RETURNS\s+TABLE\s*\(`)
+)
+
+// returnedColumns returns the RETURNS TABLE columns of every function, as defined by the
+// last migration that creates it
+func returnedColumns(t *testing.T) map[string][]string {
+	t.Helper()
+
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	// Versions are zero-padded, so name order is version order
+	sort.Strings(files)
+
+	functions := make(map[string][]string)
+	for _, file := range files {
+		content, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		sql := sqlComment.ReplaceAllString(string(content), "")
+
+		for _, match := range createFunction.FindAllStringSubmatchIndex(sql, -1) {
+			name := strings.ToLower(sql[match[2]:match[3]])
+
+			// Skip the parameter list
+			paramsEnd := closingParen(sql, match[1])
+			if paramsEnd < 0 {
+				t.Fatalf("%s: unterminated parameter list of %s", file, name)
+			}
+
+			rest := sql[paramsEnd+1:]
+			table := returnsTable.FindStringIndex(rest)
+			if table == nil {
+				// Redefined without a table result
+				delete(functions, name)
+				continue
+			}
+
+			tableEnd := closingParen(rest, table[1])
+			if tableEnd < 0 {
+				t.Fatalf("%s: unterminated RETURNS TABLE of %s", file, name)
+			}
+			functions[name] = columnNames(rest[table[1]:tableEnd])
+		}
+	}
+
+	return functions
+}
+
+// closingParen returns the index of the parenthesis closing the one opened right before
+// start, or -1 if there is none
+func closingParen(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// columnNames returns the column names of a RETURNS TABLE column list
+func columnNames(list string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) {
+			switch list[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		// Each column is its name followed by its type, e.g. "id" INT or numeric(10,2)
+		if fields := strings.Fields(list[start:i]); len(fields) > 0 {
+			names = append(names, strings.ToLower(strings.Trim(fields[0], `"`)))
+		}
+		start = i + 1
+	}
+	return names
+}
+
+// dbFields returns the column names sqlx scans into a struct type: the db tags of its
+// fields and of the structs it embeds, or the lowercased field name without a tag
+func dbFields(typ reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			for name := range dbFields(field.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = true
+	}
+	return fields
+}
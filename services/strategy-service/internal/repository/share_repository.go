@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ShareRepository handles database operations for strategy shares
+type ShareRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewShareRepository creates a new share repository
+func NewShareRepository(db *sqlx.DB, logger *zap.Logger) *ShareRepository {
+	return &ShareRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ShareStrategy creates or updates a share using the share_strategy function
+func (r *ShareRepository) ShareStrategy(ctx context.Context, strategyGroupID, ownerID, userID int, permission string) (int, error) {
+	query := `SELECT share_strategy($1, $2, $3, $4)`
+
+	var shareID int
+	err := r.db.GetContext(ctx, &shareID, query, strategyGroupID, ownerID, userID, permission)
+	if err != nil {
+		r.logger.Error("Failed to share strategy",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID),
+			zap.Int("user_id", userID))
+		return 0, err
+	}
+
+	return shareID, nil
+}
+
+// RevokeShare removes a share using the revoke_strategy_share function
+func (r *ShareRepository) RevokeShare(ctx context.Context, strategyGroupID, ownerID, userID int) (bool, error) {
+	query := `SELECT revoke_strategy_share($1, $2, $3)`
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, strategyGroupID, ownerID, userID)
+	if err != nil {
+		r.logger.Error("Failed to revoke strategy share",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID),
+			zap.Int("user_id", userID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// GetShares retrieves the shares of a strategy group using the get_strategy_shares function
+func (r *ShareRepository) GetShares(ctx context.Context, strategyGroupID int) ([]model.StrategyShare, error) {
+	query := `SELECT * FROM get_strategy_shares($1)`
+
+	var shares []model.StrategyShare
+	err := r.db.SelectContext(ctx, &shares, query, strategyGroupID)
+	if err != nil {
+		r.logger.Error("Failed to get strategy shares",
+			zap.Error(err),
+			zap.Int("strategy_group_id", strategyGroupID))
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// GetAccessLevel resolves how a user can access a strategy using the get_strategy_access_level function.
+// Returns an empty string if the user has no access.
+func (r *ShareRepository) GetAccessLevel(ctx context.Context, strategyID, userID int) (string, error) {
+	query := `SELECT get_strategy_access_level($1, $2)`
+
+	var accessLevel sql.NullString
+	err := r.db.GetContext(ctx, &accessLevel, query, strategyID, userID)
+	if err != nil {
+		r.logger.Error("Failed to get strategy access level",
+			zap.Error(err),
+			zap.Int("strategy_id", strategyID),
+			zap.Int("user_id", userID))
+		return "", err
+	}
+
+	return accessLevel.String, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// StatsRepository handles aggregation queries for admin statistics
+type StatsRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *sqlx.DB, logger *zap.Logger) *StatsRepository {
+	return &StatsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetStrategyStats aggregates strategy and marketplace KPIs using the get_strategy_stats function
+func (r *StatsRepository) GetStrategyStats(ctx context.Context, since time.Time) (*model.StrategyStats, error) {
+	query := `SELECT * FROM get_strategy_stats($1)`
+
+	var stats model.StrategyStats
+	err := r.db.GetContext(ctx, &stats, query, since)
+	if err != nil {
+		r.logger.Error("Failed to get strategy stats", zap.Error(err), zap.Time("since", since))
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"services/strategy-service/internal/model"
 
@@ -51,8 +53,10 @@ func (r *StrategyRepository) GetAllStrategies(
 	// Use the get_all_strategies function to fetch strategies with pagination
 	query := `SELECT * FROM get_all_strategies($1, $2, $3, $4, $5, $6, $7, $8)`
 
-	rows, err := r.db.QueryxContext(
+	var rows []strategyListRow
+	err = r.db.SelectContext(
 		ctx,
+		&rows,
 		query,
 		userID,
 		searchTerm,
@@ -67,64 +71,66 @@ func (r *StrategyRepository) GetAllStrategies(
 		r.logger.Error("Failed to get strategies", zap.Error(err))
 		return nil, 0, err
 	}
-	defer rows.Close()
 
 	var strategies []model.Strategy
-	for rows.Next() {
-		var s model.Strategy
-		var updatedAt sql.NullTime
-		var purchaseID sql.NullInt64
-		var purchaseDate sql.NullTime
-		var tagIDsArray []int
-
-		err := rows.Scan(
-			&s.ID,
-			&s.Name,
-			&s.Description,
-			&s.ThumbnailURL,
-			&s.UserID, // This is owner_id
-			&s.UserID, // This is owner_user_id (duplicate in the function)
-			&s.IsPublic,
-			&s.IsActive,
-			&s.Version,
-			&s.CreatedAt,
-			&updatedAt,
-			&s.StrategyGroupID,
-			&s.AccessType,
-			&purchaseID,
-			&purchaseDate,
-			pq.Array(&tagIDsArray),
-			&s.Structure,
-		)
-
-		if err != nil {
-			r.logger.Error("Failed to scan strategy row", zap.Error(err))
-			return nil, 0, err
-		}
+	for _, row := range rows {
+		strategies = append(strategies, row.toStrategy())
+	}
 
-		// Set nullable fields
-		if updatedAt.Valid {
-			s.UpdatedAt = &updatedAt.Time
-		}
-		if purchaseID.Valid {
-			pid := int(purchaseID.Int64)
-			s.PurchaseID = &pid
-		}
-		if purchaseDate.Valid {
-			s.PurchaseDate = &purchaseDate.Time
-		}
+	return strategies, totalCount, nil
+}
 
-		s.TagIDs = tagIDsArray
+// strategyListRow matches the columns returned by get_all_strategies. Rows are scanned by
+// column name, so a column added to or renamed in the function fails the scan instead of
+// shifting values into the wrong fields.
+type strategyListRow struct {
+	ID              int             `db:"id"`
+	Name            string          `db:"name"`
+	Description     string          `db:"description"`
+	ThumbnailURL    string          `db:"thumbnail_url"`
+	OwnerID         int             `db:"owner_id"` // Same as owner_user_id
+	OwnerUserID     int             `db:"owner_user_id"`
+	IsPublic        bool            `db:"is_public"`
+	IsActive        bool            `db:"is_active"`
+	Version         int             `db:"version"`
+	CreatedAt       time.Time       `db:"created_at"`
+	UpdatedAt       *time.Time      `db:"updated_at"`
+	StrategyGroupID int             `db:"strategy_group_id"`
+	AccessType      string          `db:"access_type"`
+	PurchaseID      *int            `db:"purchase_id"`
+	PurchaseDate    *time.Time      `db:"purchase_date"`
+	TagIDs          pq.Int64Array   `db:"tag_ids"`
+	Structure       json.RawMessage `db:"structure"`
+}
 
-		strategies = append(strategies, s)
+// toStrategy converts a strategy list row to a model.Strategy
+func (row strategyListRow) toStrategy() model.Strategy {
+	var tagIDs []int
+	if row.TagIDs != nil {
+		tagIDs = make([]int, len(row.TagIDs))
+		for i, id := range row.TagIDs {
+			tagIDs[i] = int(id)
+		}
 	}
 
-	if err = rows.Err(); err != nil {
-		r.logger.Error("Error iterating strategy rows", zap.Error(err))
-		return nil, 0, err
+	return model.Strategy{
+		ID:              row.ID,
+		Name:            row.Name,
+		UserID:          row.OwnerUserID,
+		Description:     row.Description,
+		ThumbnailURL:    row.ThumbnailURL,
+		Structure:       row.Structure,
+		IsPublic:        row.IsPublic,
+		IsActive:        row.IsActive,
+		Version:         row.Version,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+		StrategyGroupID: row.StrategyGroupID,
+		TagIDs:          tagIDs,
+		AccessType:      row.AccessType,
+		PurchaseID:      row.PurchaseID,
+		PurchaseDate:    row.PurchaseDate,
 	}
-
-	return strategies, totalCount, nil
 }
 
 // GetStrategyByID retrieves a strategy by ID
@@ -138,23 +144,7 @@ func (r *StrategyRepository) GetStrategyByID(ctx context.Context, id int) (*mode
 	`
 
 	var strategy model.Strategy
-	var updatedAt sql.NullTime
-
-	err := r.db.QueryRowxContext(ctx, query, id).Scan(
-		&strategy.ID,
-		&strategy.Name,
-		&strategy.UserID,
-		&strategy.Description,
-		&strategy.ThumbnailURL,
-		&strategy.Structure,
-		&strategy.IsPublic,
-		&strategy.IsActive,
-		&strategy.Version,
-		&strategy.CreatedAt,
-		&updatedAt,
-		&strategy.StrategyGroupID,
-	)
-
+	err := r.db.GetContext(ctx, &strategy, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Not found is not an error
@@ -163,10 +153,6 @@ func (r *StrategyRepository) GetStrategyByID(ctx context.Context, id int) (*mode
 		return nil, err
 	}
 
-	if updatedAt.Valid {
-		strategy.UpdatedAt = &updatedAt.Time
-	}
-
 	// Get tags for the strategy
 	tagsQuery := `
 		SELECT t.id, t.name
@@ -198,44 +184,16 @@ func (r *StrategyRepository) GetStrategyByID(ctx context.Context, id int) (*mode
 func (r *StrategyRepository) GetStrategyByIDWithAccess(ctx context.Context, id int, userID int) (*model.Strategy, error) {
 	query := `SELECT * FROM get_strategy_by_id($1, $2)`
 
-	rows, err := r.db.QueryxContext(ctx, query, id, userID)
-	if err != nil {
-		r.logger.Error("Failed to execute get_strategy_by_id function", zap.Error(err))
-		return nil, err
-	}
-	defer rows.Close()
-
-	if !rows.Next() {
-		return nil, nil // No strategy found
-	}
-
 	var strategy model.Strategy
-	var updatedAt sql.NullTime
-
-	err = rows.Scan(
-		&strategy.ID,
-		&strategy.Name,
-		&strategy.UserID,
-		&strategy.Description,
-		&strategy.ThumbnailURL,
-		&strategy.Structure,
-		&strategy.IsPublic,
-		&strategy.IsActive,
-		&strategy.Version,
-		&strategy.CreatedAt,
-		&updatedAt,
-		&strategy.StrategyGroupID,
-	)
-
+	err := r.db.GetContext(ctx, &strategy, query, id, userID)
 	if err != nil {
-		r.logger.Error("Failed to scan strategy row", zap.Error(err))
+		if err == sql.ErrNoRows {
+			return nil, nil // No strategy found
+		}
+		r.logger.Error("Failed to execute get_strategy_by_id function", zap.Error(err))
 		return nil, err
 	}
 
-	if updatedAt.Valid {
-		strategy.UpdatedAt = &updatedAt.Time
-	}
-
 	// Get tags for the strategy
 	tagsQuery := `
 		SELECT t.id, t.name
@@ -338,6 +296,33 @@ func (r *StrategyRepository) DeleteStrategy(ctx context.Context, strategyID int,
 	return nil
 }
 
+// BulkUpdateStrategyTags adds tags to and removes tags from many strategies of a user in
+// one transaction using bulk_update_strategy_tags function
+func (r *StrategyRepository) BulkUpdateStrategyTags(
+	ctx context.Context,
+	userID int,
+	update *model.StrategyTagsBulkUpdate,
+) ([]model.StrategyTagsBulkItem, error) {
+	query := `SELECT * FROM bulk_update_strategy_tags($1, $2, $3, $4)`
+
+	items := []model.StrategyTagsBulkItem{}
+	err := r.db.SelectContext(
+		ctx,
+		&items,
+		query,
+		userID,
+		pq.Array(update.StrategyIDs),
+		pq.Array(update.AddTagIDs),
+		pq.Array(update.RemoveTagIDs),
+	)
+	if err != nil {
+		r.logger.Error("Failed to bulk update strategy tags", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return items, nil
+}
+
 // GetStrategyVersions retrieves all versions of a strategy
 func (r *StrategyRepository) GetStrategyVersions(
 	ctx context.Context,
@@ -360,8 +345,10 @@ func (r *StrategyRepository) GetStrategyVersions(
 	// Get versions with pagination
 	query := `SELECT * FROM get_strategy_versions($1, $2, $3, $4, $5, $6)`
 
-	rows, err := r.db.QueryxContext(
+	var rows []strategyVersionRow
+	err = r.db.SelectContext(
 		ctx,
+		&rows,
 		query,
 		strategyGroupID,
 		userID,
@@ -374,49 +361,23 @@ func (r *StrategyRepository) GetStrategyVersions(
 		r.logger.Error("Failed to get strategy versions", zap.Error(err))
 		return nil, 0, err
 	}
-	defer rows.Close()
 
 	var versions []model.Strategy
-	for rows.Next() {
-		var version model.Strategy
-		var updatedAt sql.NullTime
-
-		err := rows.Scan(
-			&version.ID,
-			&version.Name,
-			&version.UserID,
-			&version.Description,
-			&version.ThumbnailURL,
-			&version.Structure,
-			&version.IsPublic,
-			&version.IsActive,
-			&version.Version,
-			&version.CreatedAt,
-			&updatedAt,
-			&version.StrategyGroupID,
-			&version.IsCurrentVersion,
-		)
-
-		if err != nil {
-			r.logger.Error("Failed to scan version row", zap.Error(err))
-			return nil, 0, err
-		}
-
-		if updatedAt.Valid {
-			version.UpdatedAt = &updatedAt.Time
-		}
-
+	for _, row := range rows {
+		version := row.Strategy
+		version.IsCurrentVersion = row.IsCurrentVersion
 		versions = append(versions, version)
 	}
 
-	if err = rows.Err(); err != nil {
-		r.logger.Error("Error iterating version rows", zap.Error(err))
-		return nil, 0, err
-	}
-
 	return versions, totalCount, nil
 }
 
+// strategyVersionRow matches the columns returned by get_strategy_versions
+type strategyVersionRow struct {
+	model.Strategy
+	IsCurrentVersion bool `db:"is_current_version"`
+}
+
 // SetUserActiveVersion sets the active version for a user
 func (r *StrategyRepository) SetUserActiveVersion(ctx context.Context, userID, strategyGroupID, versionID int) error {
 	query := `SELECT set_user_active_version($1, $2, $3)`
@@ -435,6 +396,47 @@ func (r *StrategyRepository) SetUserActiveVersion(ctx context.Context, userID, s
 	return nil
 }
 
+// GetStrategyBuyers retrieves the users with access to a bought version of a strategy using
+// get_strategy_buyers function
+func (r *StrategyRepository) GetStrategyBuyers(ctx context.Context, strategyID int) ([]int, error) {
+	query := `SELECT buyer_id FROM get_strategy_buyers($1)`
+
+	var buyerIDs []int
+	if err := r.db.SelectContext(ctx, &buyerIDs, query, strategyID); err != nil {
+		r.logger.Error("Failed to get strategy buyers", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, err
+	}
+
+	return buyerIDs, nil
+}
+
+// GetStrategyUsers retrieves the owner, buyers and users a strategy is shared with using
+// get_strategy_users function
+func (r *StrategyRepository) GetStrategyUsers(ctx context.Context, strategyID int) ([]int, error) {
+	query := `SELECT user_id FROM get_strategy_users($1)`
+
+	userIDs := []int{}
+	if err := r.db.SelectContext(ctx, &userIDs, query, strategyID); err != nil {
+		r.logger.Error("Failed to get strategy users", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// GetStrategyVersionIDs returns the IDs of every version of the strategy a version belongs to
+func (r *StrategyRepository) GetStrategyVersionIDs(ctx context.Context, strategyID int) ([]int, error) {
+	query := `SELECT id FROM get_strategy_version_ids($1)`
+
+	var ids []int
+	if err := r.db.SelectContext(ctx, &ids, query, strategyID); err != nil {
+		r.logger.Error("Failed to get strategy version IDs", zap.Error(err), zap.Int("strategy_id", strategyID))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 // UpdateThumbnail updates a strategy's thumbnail URL
 func (r *StrategyRepository) UpdateThumbnail(ctx context.Context, strategyID int, userID int, thumbnailURL string) error {
 	query := `
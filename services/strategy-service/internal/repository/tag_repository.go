@@ -3,9 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"strings"
 
+	"services/shared/pagination"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
@@ -35,24 +35,7 @@ func (r *TagRepository) GetAllTags(
 	page, limit int,
 ) ([]model.TagWithCount, int, error) {
 	// Calculate offset
-	offset := (page - 1) * limit
-
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"name":           true,
-		"strategy_count": true,
-		"id":             true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "name" // Default sort by name
-	}
-
-	// Normalize sort direction
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "ASC" // Default ascending for tags
-	}
+	offset := pagination.Offset(page, limit)
 
 	// First, get total count using the count function
 	var totalCount int
@@ -119,7 +102,7 @@ func (r *TagRepository) UpdateTag(ctx context.Context, id int, name string) erro
 	}
 
 	if !success {
-		return errors.New("tag not found")
+		return errcode.ErrTagNotFound
 	}
 
 	return nil
@@ -137,22 +120,121 @@ func (r *TagRepository) DeleteTag(ctx context.Context, id int) error {
 	}
 
 	if !success {
-		return errors.New("tag not found or is in use")
+		return errcode.ErrTagInUse
 	}
 
 	return nil
 }
 
-// GetPopularTags returns the most frequently used tags
-func (r *TagRepository) GetPopularTags(ctx context.Context, limit int) ([]model.TagWithCount, error) {
-	query := `SELECT * FROM get_popular_tags($1)`
+// GetPopularTags returns the most used tags within a time window such as "30d"
+func (r *TagRepository) GetPopularTags(ctx context.Context, window string, limit int) ([]model.PopularTag, error) {
+	query := `SELECT * FROM get_popular_tags($1, $2)`
 
-	var tags []model.TagWithCount
-	err := r.db.SelectContext(ctx, &tags, query, limit)
+	tags := []model.PopularTag{}
+	err := r.db.SelectContext(ctx, &tags, query, window, limit)
+	if err != nil {
+		r.logger.Error("Failed to get popular tags", zap.Error(err), zap.String("window", window))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// RefreshTagPopularity recomputes the tag usage counts popular tags are ranked by
+func (r *TagRepository) RefreshTagPopularity(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `SELECT refresh_tag_popularity()`)
+	if err != nil {
+		r.logger.Error("Failed to refresh tag popularity", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetTagChildren returns the direct children of a tag
+func (r *TagRepository) GetTagChildren(ctx context.Context, id int) ([]model.TagWithCount, error) {
+	query := `SELECT * FROM get_tag_children($1)`
+
+	tags := []model.TagWithCount{}
+	err := r.db.SelectContext(ctx, &tags, query, id)
 	if err != nil {
-		r.logger.Error("Failed to get popular tags", zap.Error(err))
+		r.logger.Error("Failed to get tag children", zap.Error(err), zap.Int("id", id))
 		return nil, err
 	}
 
 	return tags, nil
 }
+
+// SetTagParent sets or clears the parent of a tag
+func (r *TagRepository) SetTagParent(ctx context.Context, id int, parentID *int) error {
+	query := `SELECT set_tag_parent($1, $2)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, id, parentID).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to set tag parent", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	if !success {
+		return errcode.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// GetTagAliases returns the aliases of a tag
+func (r *TagRepository) GetTagAliases(ctx context.Context, id int) ([]model.TagAlias, error) {
+	query := `SELECT * FROM get_tag_aliases($1)`
+
+	aliases := []model.TagAlias{}
+	err := r.db.SelectContext(ctx, &aliases, query, id)
+	if err != nil {
+		r.logger.Error("Failed to get tag aliases", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// AddTagAlias adds an alias to a tag
+func (r *TagRepository) AddTagAlias(ctx context.Context, id int, alias string) error {
+	query := `SELECT add_tag_alias($1, $2)`
+
+	_, err := r.db.ExecContext(ctx, query, id, alias)
+	if err != nil {
+		r.logger.Error("Failed to add tag alias", zap.Error(err), zap.Int("id", id), zap.String("alias", alias))
+		return err
+	}
+
+	return nil
+}
+
+// RemoveTagAlias removes an alias from a tag. It returns false if the tag has no such alias.
+func (r *TagRepository) RemoveTagAlias(ctx context.Context, id int, alias string) (bool, error) {
+	query := `SELECT remove_tag_alias($1, $2)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, id, alias).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to remove tag alias", zap.Error(err), zap.Int("id", id), zap.String("alias", alias))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// MergeTag moves the strategies, aliases and children of a tag to another tag and deletes
+// it, all in one transaction. It returns the number of strategies that had the source tag.
+func (r *TagRepository) MergeTag(ctx context.Context, sourceID, targetID int) (int, error) {
+	query := `SELECT merge_tag($1, $2)`
+
+	var remapped int
+	err := r.db.QueryRowContext(ctx, query, sourceID, targetID).Scan(&remapped)
+	if err != nil {
+		r.logger.Error("Failed to merge tag", zap.Error(err), zap.Int("sourceID", sourceID), zap.Int("targetID", targetID))
+		return 0, err
+	}
+
+	return remapped, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// TemplateRepository handles database operations for strategy templates
+type TemplateRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewTemplateRepository creates a new template repository
+func NewTemplateRepository(db *sqlx.DB, logger *zap.Logger) *TemplateRepository {
+	return &TemplateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetTemplates retrieves the templates of a category, or of every category when it is empty
+func (r *TemplateRepository) GetTemplates(ctx context.Context, category string, includeInactive bool) ([]model.StrategyTemplate, error) {
+	query := `SELECT * FROM get_strategy_templates($1, $2)`
+
+	templates := []model.StrategyTemplate{}
+	err := r.db.SelectContext(ctx, &templates, query, sql.NullString{String: category, Valid: category != ""}, includeInactive)
+	if err != nil {
+		r.logger.Error("Failed to get strategy templates", zap.Error(err), zap.String("category", category))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// GetCategories retrieves the template categories with their template counts
+func (r *TemplateRepository) GetCategories(ctx context.Context, includeInactive bool) ([]model.StrategyTemplateCategory, error) {
+	query := `SELECT * FROM get_strategy_template_categories($1)`
+
+	categories := []model.StrategyTemplateCategory{}
+	err := r.db.SelectContext(ctx, &categories, query, includeInactive)
+	if err != nil {
+		r.logger.Error("Failed to get strategy template categories", zap.Error(err))
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetTemplateByID retrieves a template by ID, or nil if it doesn't exist
+func (r *TemplateRepository) GetTemplateByID(ctx context.Context, id int) (*model.StrategyTemplate, error) {
+	query := `SELECT * FROM get_strategy_template_by_id($1)`
+
+	var template model.StrategyTemplate
+	err := r.db.GetContext(ctx, &template, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get strategy template by ID", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// CreateTemplate adds a new template
+func (r *TemplateRepository) CreateTemplate(
+	ctx context.Context,
+	input *model.StrategyTemplateInput,
+	parameters json.RawMessage,
+	isActive bool,
+	createdBy int,
+) (int, error) {
+	query := `SELECT create_strategy_template($1, $2, $3, $4, $5, $6, $7)`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query,
+		input.Name,
+		input.Description,
+		input.Category,
+		input.Structure,
+		parameters,
+		isActive,
+		createdBy,
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to create strategy template", zap.Error(err), zap.String("name", input.Name))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdateTemplate updates a template. Returns false if it doesn't exist.
+func (r *TemplateRepository) UpdateTemplate(
+	ctx context.Context,
+	id int,
+	input *model.StrategyTemplateInput,
+	parameters json.RawMessage,
+	isActive bool,
+) (bool, error) {
+	query := `SELECT update_strategy_template($1, $2, $3, $4, $5, $6, $7)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query,
+		id,
+		input.Name,
+		input.Description,
+		input.Category,
+		input.Structure,
+		parameters,
+		isActive,
+	).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to update strategy template", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// DeleteTemplate deletes a template. Returns false if it doesn't exist.
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, id int) (bool, error) {
+	query := `SELECT delete_strategy_template($1)`
+
+	var success bool
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&success)
+	if err != nil {
+		r.logger.Error("Failed to delete strategy template", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
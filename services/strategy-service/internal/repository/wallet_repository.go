@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"services/shared/pagination"
+	"services/strategy-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// WalletRepository handles database operations for wallet balances and the wallet ledger
+type WalletRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewWalletRepository creates a new wallet repository
+func NewWalletRepository(db *sqlx.DB, logger *zap.Logger) *WalletRepository {
+	return &WalletRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetBalances retrieves the balances of a user's wallet using get_wallet_balances function
+func (r *WalletRepository) GetBalances(ctx context.Context, userID int) ([]model.WalletBalance, error) {
+	query := `SELECT * FROM get_wallet_balances($1)`
+
+	balances := []model.WalletBalance{}
+	if err := r.db.SelectContext(ctx, &balances, query, userID); err != nil {
+		r.logger.Error("Failed to get wallet balances", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// GetLedger retrieves the entries of a user's wallet, newest first, using get_wallet_ledger
+// function. An empty currency returns the entries of every currency.
+func (r *WalletRepository) GetLedger(ctx context.Context, userID int, currency string, page, limit int) ([]model.WalletEntry, int, error) {
+	var currencyParam interface{}
+	if currency != "" {
+		currencyParam = currency
+	}
+
+	countQuery := `SELECT count_wallet_ledger($1, $2)`
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, countQuery, userID, currencyParam); err != nil {
+		r.logger.Error("Failed to count wallet ledger entries", zap.Error(err), zap.Int("user_id", userID))
+		return nil, 0, err
+	}
+
+	offset := pagination.Offset(page, limit)
+	query := `SELECT * FROM get_wallet_ledger($1, $2, $3, $4)`
+
+	entries := []model.WalletEntry{}
+	if err := r.db.SelectContext(ctx, &entries, query, userID, currencyParam, limit, offset); err != nil {
+		r.logger.Error("Failed to get wallet ledger", zap.Error(err), zap.Int("user_id", userID))
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// AddEntry adds an entry to a user's wallet and updates its balance using add_wallet_entry
+// function. A negative amount is a debit, which fails beyond the balance.
+func (r *WalletRepository) AddEntry(
+	ctx context.Context,
+	userID int,
+	amount float64,
+	currency string,
+	entryType string,
+	actorID *int,
+	note string,
+) (*model.WalletEntry, error) {
+	query := `SELECT * FROM add_wallet_entry($1, $2, $3, $4, NULL, $5, $6)`
+
+	var entry model.WalletEntry
+	err := r.db.GetContext(ctx, &entry, query, userID, amount, currency, entryType, actorID, note)
+	if err != nil {
+		r.logger.Error("Failed to add wallet entry", zap.Error(err),
+			zap.Int("user_id", userID),
+			zap.String("entry_type", entryType))
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetPurchaseWalletAmount retrieves the part of a purchase paid from the buyer's wallet
+// using get_purchase_wallet_amount function
+func (r *WalletRepository) GetPurchaseWalletAmount(ctx context.Context, purchaseID int) (float64, error) {
+	query := `SELECT get_purchase_wallet_amount($1)`
+
+	var amount float64
+	if err := r.db.GetContext(ctx, &amount, query, purchaseID); err != nil {
+		r.logger.Error("Failed to get purchase wallet amount", zap.Error(err), zap.Int("purchase_id", purchaseID))
+		return 0, err
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// CollaborationService handles owners inviting collaborators to edit their strategies and
+// the comments the owner and collaborators leave on strategy versions
+type CollaborationService struct {
+	strategyRepo       *repository.StrategyRepository
+	shareRepo          *repository.ShareRepository
+	collaborationRepo  *repository.CollaborationRepository
+	userClient         *client.UserClient
+	notificationClient *client.NotificationClient
+	logger             *zap.Logger
+}
+
+// NewCollaborationService creates a new collaboration service
+func NewCollaborationService(
+	strategyRepo *repository.StrategyRepository,
+	shareRepo *repository.ShareRepository,
+	collaborationRepo *repository.CollaborationRepository,
+	userClient *client.UserClient,
+	notificationClient *client.NotificationClient,
+	logger *zap.Logger,
+) *CollaborationService {
+	return &CollaborationService{
+		strategyRepo:       strategyRepo,
+		shareRepo:          shareRepo,
+		collaborationRepo:  collaborationRepo,
+		userClient:         userClient,
+		notificationClient: notificationClient,
+		logger:             logger,
+	}
+}
+
+// InviteCollaborator invites a user to edit a strategy with its owner and notifies them.
+// Inviting again changes the role of the collaborator.
+func (s *CollaborationService) InviteCollaborator(ctx context.Context, strategyID int, ownerID int, invite *model.StrategyCollaboratorInvite) (*model.StrategyCollaborator, error) {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy.UserID != ownerID {
+		return nil, errors.New("you don't have permission to manage collaborators of this strategy")
+	}
+
+	if invite.UserID == ownerID {
+		return nil, errors.New("invalid collaborator: cannot invite yourself")
+	}
+
+	role := invite.Role
+	if role == "" {
+		role = model.CollaboratorRoleEditor
+	}
+
+	// Verify the invited user exists
+	username, err := s.userClient.GetUserByID(ctx, invite.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	collaboratorID, err := s.collaborationRepo.InviteCollaborator(ctx, strategy.StrategyGroupID, ownerID, invite.UserID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.collaborationRepo.GetCollaborators(ctx, strategy.StrategyGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range collaborators {
+		if collaborators[i].ID != collaboratorID {
+			continue
+		}
+		collaborators[i].Username = username
+
+		if collaborators[i].AcceptedAt == nil {
+			s.notify(ctx, client.NotificationEvent{
+				UserID:  invite.UserID,
+				Type:    "strategy_collaboration_invite",
+				Title:   "Invitation to collaborate",
+				Message: fmt.Sprintf("You were invited to edit the strategy %s.", strategy.Name),
+				Link:    "/strategies/collaborations",
+			})
+		}
+		return &collaborators[i], nil
+	}
+
+	return nil, errors.New("collaborator not found after invitation")
+}
+
+// GetCollaborators retrieves the collaborators and pending invitations of a strategy. The
+// owner and collaborators can see them.
+func (s *CollaborationService) GetCollaborators(ctx context.Context, strategyID int, userID int) ([]model.StrategyCollaborator, error) {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCollaborator(ctx, strategy, userID); err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.collaborationRepo.GetCollaborators(ctx, strategy.StrategyGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, len(collaborators))
+	for i := range collaborators {
+		userIDs[i] = collaborators[i].UserID
+	}
+	usernames := s.usernames(ctx, userIDs)
+	for i := range collaborators {
+		collaborators[i].Username = usernames[collaborators[i].UserID]
+	}
+
+	return collaborators, nil
+}
+
+// GetUserCollaborations retrieves the strategies a user collaborates on or is invited to,
+// pending invitations first
+func (s *CollaborationService) GetUserCollaborations(ctx context.Context, userID int) ([]model.StrategyCollaborator, error) {
+	return s.collaborationRepo.GetUserCollaborations(ctx, userID)
+}
+
+// AcceptInvitation accepts the user's pending invitation to collaborate on a strategy and
+// notifies the owner
+func (s *CollaborationService) AcceptInvitation(ctx context.Context, strategyID int, userID int) error {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return err
+	}
+
+	accepted, err := s.collaborationRepo.AcceptInvitation(ctx, strategy.StrategyGroupID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !accepted {
+		return errcode.ErrCollaboratorNotFound.WithMessage("No pending invitation to collaborate on this strategy")
+	}
+
+	username, err := s.userClient.GetUserByID(ctx, userID)
+	if err != nil {
+		username = fmt.Sprintf("User %d", userID)
+	}
+
+	s.notify(ctx, client.NotificationEvent{
+		UserID:  strategy.UserID,
+		Type:    "strategy_collaboration_accepted",
+		Title:   "Collaborator joined",
+		Message: fmt.Sprintf("%s accepted your invitation to edit %s.", username, strategy.Name),
+		Link:    fmt.Sprintf("/strategies/%d", strategy.ID),
+	})
+
+	return nil
+}
+
+// RemoveCollaborator removes a collaborator of a strategy or withdraws an invitation. The
+// owner can remove anyone; collaborators and invited users can only remove themselves,
+// leaving the strategy or declining the invitation.
+func (s *CollaborationService) RemoveCollaborator(ctx context.Context, strategyID int, actorID int, userID int) error {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return err
+	}
+
+	if strategy.UserID != actorID && userID != actorID {
+		return errors.New("you don't have permission to manage collaborators of this strategy")
+	}
+
+	removed, err := s.collaborationRepo.RemoveCollaborator(ctx, strategy.StrategyGroupID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return errcode.ErrCollaboratorNotFound
+	}
+
+	return nil
+}
+
+// AddComment adds a comment of the owner or a collaborator to a version of a strategy. The
+// owner and collaborators mentioned in it as @username are notified.
+func (s *CollaborationService) AddComment(
+	ctx context.Context,
+	strategyID int,
+	versionID int,
+	userID int,
+	request *model.StrategyCommentCreate,
+) (*model.StrategyComment, error) {
+	version, err := s.getVersion(ctx, strategyID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCollaborator(ctx, version, userID); err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(request.Body)
+	if body == "" {
+		return nil, errors.New("invalid comment: body is required")
+	}
+
+	// Only the owner and collaborators can be mentioned
+	members, err := s.members(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	mentions := mentionedUsers(body, members, userID)
+
+	comment, err := s.collaborationRepo.AddComment(ctx, version.ID, userID, body, request.NodePath, mentions)
+	if err != nil {
+		return nil, err
+	}
+	comment.Username = members[userID]
+
+	for _, mentionedID := range mentions {
+		s.notify(ctx, client.NotificationEvent{
+			UserID:  mentionedID,
+			Type:    "strategy_comment_mention",
+			Title:   "You were mentioned in a comment",
+			Message: fmt.Sprintf("%s mentioned you in a comment on %s (version %d).", comment.Username, version.Name, version.Version),
+			Link:    fmt.Sprintf("/strategies/%d/versions/%d", version.StrategyGroupID, version.ID),
+		})
+	}
+
+	return comment, nil
+}
+
+// GetComments retrieves the comments on a version of a strategy, oldest first. The owner
+// and collaborators can see them.
+func (s *CollaborationService) GetComments(ctx context.Context, strategyID int, versionID int, userID int) ([]model.StrategyComment, error) {
+	version, err := s.getVersion(ctx, strategyID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCollaborator(ctx, version, userID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.collaborationRepo.GetComments(ctx, version.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, len(comments))
+	for i := range comments {
+		userIDs[i] = comments[i].UserID
+	}
+	usernames := s.usernames(ctx, userIDs)
+	for i := range comments {
+		comments[i].Username = usernames[comments[i].UserID]
+	}
+
+	return comments, nil
+}
+
+// DeleteComment deletes a comment of the user on a strategy
+func (s *CollaborationService) DeleteComment(ctx context.Context, strategyID int, commentID int, userID int) error {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkCollaborator(ctx, strategy, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.collaborationRepo.DeleteComment(ctx, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return errcode.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// getStrategy retrieves a strategy version, failing if it doesn't exist
+func (s *CollaborationService) getStrategy(ctx context.Context, strategyID int) (*model.Strategy, error) {
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	return strategy, nil
+}
+
+// getVersion retrieves a version of a strategy, failing if it belongs to another strategy
+func (s *CollaborationService) getVersion(ctx context.Context, strategyID int, versionID int) (*model.Strategy, error) {
+	strategy, err := s.getStrategy(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.strategyRepo.GetStrategyByID(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil {
+		return nil, errors.New("strategy version not found")
+	}
+
+	if version.StrategyGroupID != strategy.StrategyGroupID {
+		return nil, errors.New("requested version does not belong to this strategy")
+	}
+
+	return version, nil
+}
+
+// checkCollaborator fails unless the user owns the strategy or collaborates on it
+func (s *CollaborationService) checkCollaborator(ctx context.Context, strategy *model.Strategy, userID int) error {
+	if strategy.UserID == userID {
+		return nil
+	}
+
+	accessLevel, err := s.shareRepo.GetAccessLevel(ctx, strategy.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if accessLevel != model.CollaboratorRoleEditor {
+		return errors.New("access denied: only the owner and collaborators of this strategy can do this")
+	}
+
+	return nil
+}
+
+// members returns the usernames of the owner and accepted collaborators of a strategy by
+// their user ID
+func (s *CollaborationService) members(ctx context.Context, strategy *model.Strategy) (map[int]string, error) {
+	collaborators, err := s.collaborationRepo.GetCollaborators(ctx, strategy.StrategyGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := []int{strategy.UserID}
+	for _, collaborator := range collaborators {
+		if collaborator.AcceptedAt != nil {
+			userIDs = append(userIDs, collaborator.UserID)
+		}
+	}
+
+	return s.usernames(ctx, userIDs), nil
+}
+
+// usernames looks up the usernames of users, falling back to "User <id>" for those the
+// user service doesn't return
+func (s *CollaborationService) usernames(ctx context.Context, userIDs []int) map[int]string {
+	usernames := make(map[int]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return usernames
+	}
+
+	users, err := s.userClient.BatchGetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		s.logger.Warn("Failed to get usernames of collaborators", zap.Error(err))
+	}
+
+	for _, id := range userIDs {
+		if user, ok := users[id]; ok {
+			usernames[id] = user.Username
+		} else {
+			usernames[id] = fmt.Sprintf("User %d", id)
+		}
+	}
+
+	return usernames
+}
+
+// mentionedUsers returns the IDs of the members mentioned in a comment body as @username,
+// other than its author
+func mentionedUsers(body string, members map[int]string, authorID int) []int {
+	mentions := []int{}
+	for id, username := range members {
+		if id == authorID || username == "" {
+			continue
+		}
+		pattern := regexp.MustCompile(`(?i)(^|[^\w@])@` + regexp.QuoteMeta(username) + `($|[^\w])`)
+		if pattern.MatchString(body) {
+			mentions = append(mentions, id)
+		}
+	}
+	sort.Ints(mentions)
+	return mentions
+}
+
+// notify sends a notification, logging rather than failing when it can't be sent
+func (s *CollaborationService) notify(ctx context.Context, event client.NotificationEvent) {
+	if err := s.notificationClient.Send(ctx, event); err != nil {
+		s.logger.Error("Failed to send collaboration notification", zap.Error(err), zap.String("type", event.Type))
+	}
+}
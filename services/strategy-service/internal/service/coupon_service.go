@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// couponCodePattern limits coupon codes to letters, digits, dashes and underscores
+var couponCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CouponService handles seller discount codes for marketplace listings
+type CouponService struct {
+	couponRepo      *repository.CouponRepository
+	marketplaceRepo *repository.MarketplaceRepository
+	logger          *zap.Logger
+}
+
+// NewCouponService creates a new coupon service
+func NewCouponService(
+	couponRepo *repository.CouponRepository,
+	marketplaceRepo *repository.MarketplaceRepository,
+	logger *zap.Logger,
+) *CouponService {
+	return &CouponService{
+		couponRepo:      couponRepo,
+		marketplaceRepo: marketplaceRepo,
+		logger:          logger,
+	}
+}
+
+// GetCoupons retrieves the coupons of a listing owned by the user
+func (s *CouponService) GetCoupons(ctx context.Context, marketplaceID int, userID int) ([]model.Coupon, error) {
+	if err := s.checkListingOwner(ctx, marketplaceID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.couponRepo.GetCoupons(ctx, marketplaceID)
+}
+
+// CreateCoupon creates a coupon for a listing owned by the user
+func (s *CouponService) CreateCoupon(ctx context.Context, marketplaceID int, coupon *model.CouponCreate, userID int) (*model.Coupon, error) {
+	if err := s.checkListingOwner(ctx, marketplaceID, userID); err != nil {
+		return nil, err
+	}
+
+	if !couponCodePattern.MatchString(coupon.Code) {
+		return nil, errors.New("coupon code can only contain letters, digits, dashes and underscores")
+	}
+	coupon.Code = strings.ToUpper(coupon.Code)
+
+	if err := validateCouponTerms(coupon.DiscountType, coupon.DiscountValue, coupon.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.couponRepo.GetCouponByCode(ctx, marketplaceID, coupon.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("coupon code already exists for this listing")
+	}
+
+	couponID, err := s.couponRepo.CreateCoupon(ctx, marketplaceID, coupon, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.couponRepo.GetCouponByID(ctx, marketplaceID, couponID)
+}
+
+// UpdateCoupon updates a coupon of a listing owned by the user. A coupon stays active unless
+// is_active is set to false.
+func (s *CouponService) UpdateCoupon(
+	ctx context.Context,
+	marketplaceID int,
+	couponID int,
+	update *model.CouponUpdate,
+	userID int,
+) (*model.Coupon, error) {
+	if err := s.checkListingOwner(ctx, marketplaceID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := validateCouponTerms(update.DiscountType, update.DiscountValue, update.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if update.IsActive != nil {
+		isActive = *update.IsActive
+	}
+
+	updated, err := s.couponRepo.UpdateCoupon(ctx, marketplaceID, couponID, update, isActive, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errcode.ErrCouponNotFound
+	}
+
+	return s.couponRepo.GetCouponByID(ctx, marketplaceID, couponID)
+}
+
+// DeleteCoupon deletes a coupon of a listing owned by the user
+func (s *CouponService) DeleteCoupon(ctx context.Context, marketplaceID int, couponID int, userID int) error {
+	if err := s.checkListingOwner(ctx, marketplaceID, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.couponRepo.DeleteCoupon(ctx, marketplaceID, couponID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errcode.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+// checkListingOwner verifies the listing exists and belongs to the user
+func (s *CouponService) checkListingOwner(ctx context.Context, marketplaceID int, userID int) error {
+	listing, err := s.marketplaceRepo.GetListingByID(ctx, marketplaceID)
+	if err != nil {
+		return err
+	}
+
+	if listing == nil {
+		return errcode.ErrListingNotFound
+	}
+
+	if listing.UserID != userID {
+		return errors.New("access denied: you can only manage coupons of your own listings")
+	}
+
+	return nil
+}
+
+// validateCouponTerms checks the discount and expiry of a coupon being created or updated
+func validateCouponTerms(discountType string, discountValue float64, expiresAt *time.Time) error {
+	if discountType == model.CouponDiscountPercent && discountValue > 100 {
+		return errors.New("percent discount cannot exceed 100")
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// couponDiscount returns the amount a valid coupon takes off a price, rounded to cents and
+// never more than the price
+func couponDiscount(coupon *model.Coupon, price float64) float64 {
+	discount := coupon.DiscountValue
+	if coupon.DiscountType == model.CouponDiscountPercent {
+		discount = math.Round(price*coupon.DiscountValue) / 100
+	}
+	return math.Min(discount, price)
+}
+
+// checkCouponRedeemable returns why a coupon can't be used for a purchase right now, if at all
+func checkCouponRedeemable(coupon *model.Coupon) error {
+	if !coupon.IsActive {
+		return errors.New("invalid coupon: coupon is not active")
+	}
+
+	if coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(time.Now()) {
+		return errors.New("invalid coupon: coupon has expired")
+	}
+
+	if coupon.MaxRedemptions != nil && coupon.RedemptionsCount >= *coupon.MaxRedemptions {
+		return errors.New("invalid coupon: coupon usage limit reached")
+	}
+
+	return nil
+}
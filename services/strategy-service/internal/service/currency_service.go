@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// CurrencyService handles the supported currency catalog and price conversion. Rates are
+// fetched once a day, stored in the database and cached in memory for cacheTTL.
+type CurrencyService struct {
+	currencyRepo *repository.CurrencyRepository
+	fxClient     *client.FXClient
+	cacheTTL     time.Duration
+	logger       *zap.Logger
+
+	mu         sync.Mutex
+	currencies map[string]model.Currency
+	rates      *model.FXRates
+	loadedAt   time.Time
+}
+
+// NewCurrencyService creates a new currency service
+func NewCurrencyService(
+	currencyRepo *repository.CurrencyRepository,
+	fxClient *client.FXClient,
+	cacheTTL time.Duration,
+	logger *zap.Logger,
+) *CurrencyService {
+	return &CurrencyService{
+		currencyRepo: currencyRepo,
+		fxClient:     fxClient,
+		cacheTTL:     cacheTTL,
+		logger:       logger,
+	}
+}
+
+// GetCurrencies retrieves the supported currencies with their current rate per US dollar
+func (s *CurrencyService) GetCurrencies(ctx context.Context) ([]model.Currency, error) {
+	currencies, rates, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Currency, 0, len(currencies))
+	for _, currency := range currencies {
+		if rates != nil {
+			currency.Rate = rates.Rates[currency.Code]
+		}
+		result = append(result, currency)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+
+	return result, nil
+}
+
+// NormalizeCurrency upper-cases a currency code and checks it is supported. An empty code
+// is the default currency.
+func (s *CurrencyService) NormalizeCurrency(ctx context.Context, code string) (string, error) {
+	if code == "" {
+		return model.DefaultCurrency, nil
+	}
+	code = strings.ToUpper(code)
+
+	currencies, _, err := s.load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := currencies[code]; !ok {
+		return "", errcode.ErrUnsupportedCurrency.WithMessage("Unsupported currency: " + code)
+	}
+
+	return code, nil
+}
+
+// Convert converts an amount between currencies, rounded to the minor unit of the target
+func (s *CurrencyService) Convert(ctx context.Context, amount float64, from string, to string) (float64, error) {
+	currencies, rates, err := s.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return convert(amount, from, to, currencies, rates)
+}
+
+// ConvertListingPrices sets the display price of each listing in the given currency, which
+// must be supported
+func (s *CurrencyService) ConvertListingPrices(ctx context.Context, items []model.MarketplaceItem, to string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	currencies, rates, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		// Listings whose currency couldn't be loaded are left without a display price
+		if items[i].Currency == "" {
+			continue
+		}
+
+		price, err := convert(items[i].Price, items[i].Currency, to, currencies, rates)
+		if err != nil {
+			return err
+		}
+
+		items[i].DisplayPrice = &price
+		items[i].DisplayCurrency = to
+
+		if items[i].LowestPrice30d != nil {
+			lowest, err := convert(*items[i].LowestPrice30d, items[i].Currency, to, currencies, rates)
+			if err != nil {
+				return err
+			}
+			items[i].DisplayLowestPrice30d = &lowest
+		}
+	}
+
+	return nil
+}
+
+// convert converts an amount using rates in units per US dollar
+func convert(amount float64, from string, to string, currencies map[string]model.Currency, rates *model.FXRates) (float64, error) {
+	target, ok := currencies[to]
+	if !ok {
+		return 0, errcode.ErrUnsupportedCurrency.WithMessage("Unsupported currency: " + to)
+	}
+
+	if from != to {
+		if rates == nil {
+			return 0, errcode.ErrExchangeRatesUnavailable
+		}
+
+		fromRate, toRate := rates.Rates[from], rates.Rates[to]
+		if fromRate <= 0 || toRate <= 0 {
+			return 0, fmt.Errorf("exchange rate unavailable for %s to %s", from, to)
+		}
+		amount = amount / fromRate * toRate
+	}
+
+	scale := math.Pow(10, float64(target.Decimals))
+	return math.Round(amount*scale) / scale, nil
+}
+
+// load returns the cached currency catalog and rates, reloading them when the cache is older
+// than cacheTTL. Stored rates older than today are refreshed from the rate feed; if that
+// fails the stored ones keep being used.
+func (s *CurrencyService) load(ctx context.Context) (map[string]model.Currency, *model.FXRates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.currencies != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		return s.currencies, s.rates, nil
+	}
+
+	list, err := s.currencyRepo.GetCurrencies(ctx)
+	if err != nil {
+		if s.currencies != nil {
+			s.logger.Warn("Failed to reload currencies, using cached ones", zap.Error(err))
+			return s.currencies, s.rates, nil
+		}
+		return nil, nil, err
+	}
+
+	currencies := make(map[string]model.Currency, len(list))
+	for _, currency := range list {
+		currencies[currency.Code] = currency
+	}
+
+	rates, err := s.currencyRepo.GetLatestFXRates(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to get stored exchange rates", zap.Error(err))
+		rates = s.rates
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if rates == nil || rates.Date.Before(today) {
+		fetched, err := s.fxClient.GetDailyRates(ctx)
+		if err != nil {
+			s.logger.Warn("Failed to fetch exchange rates", zap.Error(err))
+		} else {
+			if err := s.currencyRepo.SaveFXRates(ctx, fetched, list); err != nil {
+				s.logger.Warn("Failed to store exchange rates", zap.Error(err))
+			}
+			rates = fetched
+		}
+	}
+
+	if rates != nil {
+		rates.Rates[model.DefaultCurrency] = 1
+	}
+
+	s.currencies = currencies
+	s.rates = rates
+	s.loadedAt = time.Now()
+
+	return s.currencies, s.rates, nil
+}
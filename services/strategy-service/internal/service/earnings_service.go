@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxEarningsRange caps how far back a single earnings query may look
+const maxEarningsRange = 5 * 366 * 24 * time.Hour
+
+// EarningsService handles seller analytics and payout summaries
+type EarningsService struct {
+	earningsRepo       *repository.EarningsRepository
+	platformFeePercent float64
+	logger             *zap.Logger
+}
+
+// NewEarningsService creates a new earnings service
+func NewEarningsService(
+	earningsRepo *repository.EarningsRepository,
+	platformFeePercent float64,
+	logger *zap.Logger,
+) *EarningsService {
+	return &EarningsService{
+		earningsRepo:       earningsRepo,
+		platformFeePercent: platformFeePercent,
+		logger:             logger,
+	}
+}
+
+// GetSales builds the seller earnings dashboard: revenue over time, sales per listing and subscription churn
+func (s *EarningsService) GetSales(ctx context.Context, sellerID int, interval string, startDate, endDate time.Time) (*model.SellerSales, error) {
+	if err := validateEarningsRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	revenue, err := s.earningsRepo.GetRevenueOverTime(ctx, sellerID, interval, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := s.earningsRepo.GetListingSales(ctx, sellerID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	churn, err := s.earningsRepo.GetSubscriptionChurn(ctx, sellerID, interval, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := &model.SellerSales{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Interval:  interval,
+		Revenue:   revenue,
+		Listings:  listings,
+		Churn:     churn,
+	}
+
+	for _, point := range revenue {
+		sales.Summary.TotalRevenue += point.Revenue
+		sales.Summary.TotalPurchases += point.PurchasesCount
+	}
+	for _, listing := range listings {
+		sales.Summary.ActiveSubscriptions += listing.ActiveSubscriptions
+	}
+	for _, point := range churn {
+		sales.Summary.ChurnedSubscriptions += point.Churned
+	}
+
+	return sales, nil
+}
+
+// GetPayouts returns monthly payout summaries, newest first, with the platform fee deducted
+func (s *EarningsService) GetPayouts(ctx context.Context, sellerID int, startDate, endDate time.Time) (*model.SellerPayouts, error) {
+	if err := validateEarningsRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	periods, err := s.earningsRepo.GetPayoutPeriods(ctx, sellerID, s.platformFeePercent, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts := &model.SellerPayouts{
+		PlatformFeePercent: s.platformFeePercent,
+		Periods:            periods,
+	}
+
+	for _, period := range periods {
+		payouts.TotalGross += period.GrossRevenue
+		payouts.TotalFees += period.PlatformFee
+		payouts.TotalNet += period.NetRevenue
+		if period.Status == "open" {
+			payouts.PendingNet += period.NetRevenue
+		}
+	}
+
+	return payouts, nil
+}
+
+// validateEarningsRange checks that a reporting range is ordered and not unreasonably long
+func validateEarningsRange(startDate, endDate time.Time) error {
+	if !startDate.Before(endDate) {
+		return errors.New("start_date must be before end_date")
+	}
+	if endDate.Sub(startDate) > maxEarningsRange {
+		return errors.New("date range cannot exceed 5 years")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// IndicatorPresetService handles indicator preset operations
+type IndicatorPresetService struct {
+	presetRepo       *repository.IndicatorPresetRepository
+	indicatorService *IndicatorService
+	logger           *zap.Logger
+}
+
+// NewIndicatorPresetService creates a new indicator preset service
+func NewIndicatorPresetService(
+	presetRepo *repository.IndicatorPresetRepository,
+	indicatorService *IndicatorService,
+	logger *zap.Logger,
+) *IndicatorPresetService {
+	return &IndicatorPresetService{
+		presetRepo:       presetRepo,
+		indicatorService: indicatorService,
+		logger:           logger,
+	}
+}
+
+// GetPresets retrieves the global presets of an indicator the user can see, and the
+// user's own. userID is 0 for anonymous requests, which only see global presets.
+func (s *IndicatorPresetService) GetPresets(ctx context.Context, indicatorID int, isAdmin bool, userID int) ([]model.IndicatorPreset, error) {
+	if _, err := s.indicatorService.GetIndicator(ctx, indicatorID, isAdmin, userID); err != nil {
+		return nil, err
+	}
+
+	return s.presetRepo.GetPresets(ctx, indicatorID, userID)
+}
+
+// CreatePreset creates a preset of the user, or a global one when global is set. Callers
+// check that the user may manage global presets.
+func (s *IndicatorPresetService) CreatePreset(
+	ctx context.Context,
+	indicatorID int,
+	input *model.IndicatorPresetInput,
+	isAdmin bool,
+	userID int,
+) (*model.IndicatorPreset, error) {
+	indicator, err := s.indicatorService.GetIndicator(ctx, indicatorID, isAdmin, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := validatePreset(indicator, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner *int
+	if !input.Global {
+		owner = &userID
+	}
+
+	id, err := s.presetRepo.CreatePreset(ctx, indicatorID, owner, input, settings)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errcode.ErrIndicatorPresetAlreadyExists
+		}
+		return nil, err
+	}
+
+	return s.presetRepo.GetPresetByID(ctx, id)
+}
+
+// UpdatePreset replaces the name, description and settings of a preset of the indicator.
+// Users update their own presets; global ones need canManageGlobal.
+func (s *IndicatorPresetService) UpdatePreset(
+	ctx context.Context,
+	indicatorID int,
+	presetID int,
+	input *model.IndicatorPresetInput,
+	isAdmin bool,
+	canManageGlobal bool,
+	userID int,
+) (*model.IndicatorPreset, error) {
+	preset, err := s.getManagedPreset(ctx, indicatorID, presetID, canManageGlobal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	indicator, err := s.indicatorService.GetIndicator(ctx, indicatorID, isAdmin, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := validatePreset(indicator, input)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.presetRepo.UpdatePreset(ctx, preset.ID, input, settings)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errcode.ErrIndicatorPresetAlreadyExists
+		}
+		return nil, err
+	}
+	if !updated {
+		return nil, errcode.ErrIndicatorPresetNotFound
+	}
+
+	return s.presetRepo.GetPresetByID(ctx, preset.ID)
+}
+
+// DeletePreset deletes a preset of the indicator. Users delete their own presets; global
+// ones need canManageGlobal.
+func (s *IndicatorPresetService) DeletePreset(ctx context.Context, indicatorID int, presetID int, canManageGlobal bool, userID int) error {
+	preset, err := s.getManagedPreset(ctx, indicatorID, presetID, canManageGlobal, userID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.presetRepo.DeletePreset(ctx, preset.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errcode.ErrIndicatorPresetNotFound
+	}
+
+	return nil
+}
+
+// getManagedPreset retrieves a preset of the indicator the user may change. Other users'
+// presets are reported as not found.
+func (s *IndicatorPresetService) getManagedPreset(
+	ctx context.Context,
+	indicatorID int,
+	presetID int,
+	canManageGlobal bool,
+	userID int,
+) (*model.IndicatorPreset, error) {
+	preset, err := s.presetRepo.GetPresetByID(ctx, presetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if preset == nil || preset.IndicatorID != indicatorID {
+		return nil, errcode.ErrIndicatorPresetNotFound
+	}
+	if preset.UserID != nil && *preset.UserID != userID {
+		return nil, errcode.ErrIndicatorPresetNotFound
+	}
+	if preset.IsGlobal && !canManageGlobal {
+		return nil, errors.New("managing global presets requires the indicators:write permission")
+	}
+
+	return preset, nil
+}
+
+// validatePreset checks a preset's name and its settings against the indicator's
+// parameters. Returns the settings to store.
+func validatePreset(indicator *model.TechnicalIndicator, input *model.IndicatorPresetInput) (json.RawMessage, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, errcode.ErrInvalidIndicatorPreset.WithMessage("Preset name cannot be empty")
+	}
+	if len(input.Settings) == 0 {
+		return nil, errcode.ErrInvalidIndicatorPreset.WithMessage("Preset settings cannot be empty")
+	}
+
+	params := make(map[string]model.IndicatorParameter, len(indicator.Parameters))
+	for _, param := range indicator.Parameters {
+		params[param.ParameterName] = param
+	}
+
+	for name, value := range input.Settings {
+		param, ok := params[name]
+		if !ok {
+			return nil, errcode.ErrInvalidIndicatorPreset.WithMessage(
+				fmt.Sprintf("Indicator %s has no parameter %q", indicator.Name, name))
+		}
+		if err := checkPresetValue(param, value); err != nil {
+			return nil, errcode.ErrInvalidIndicatorPreset.WithMessage(err.Error())
+		}
+	}
+
+	return json.Marshal(input.Settings)
+}
+
+// checkPresetValue checks a preset value against its parameter's type, bounds and enum values
+func checkPresetValue(param model.IndicatorParameter, value interface{}) error {
+	switch strings.ToLower(param.ParameterType) {
+	case "int", "integer", "float", "number", "double":
+		number, ok := value.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return fmt.Errorf("parameter %q must be a number", param.ParameterName)
+		}
+		if lower := strings.ToLower(param.ParameterType); (lower == "int" || lower == "integer") && number != math.Trunc(number) {
+			return fmt.Errorf("parameter %q must be an integer", param.ParameterName)
+		}
+		if param.MinValue != nil && number < *param.MinValue {
+			return fmt.Errorf("parameter %q must be at least %g", param.ParameterName, *param.MinValue)
+		}
+		if param.MaxValue != nil && number > *param.MaxValue {
+			return fmt.Errorf("parameter %q must be at most %g", param.ParameterName, *param.MaxValue)
+		}
+	case "enum":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("parameter %q must be one of its enum values", param.ParameterName)
+		}
+		for _, enumValue := range param.EnumValues {
+			if enumValue.EnumValue == text {
+				return nil
+			}
+		}
+		return fmt.Errorf("parameter %q must be one of its enum values", param.ParameterName)
+	default:
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("parameter %q must be a single value", param.ParameterName)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"services/shared/pagination"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/repository"
 
@@ -18,6 +21,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// indicatorSortFields are the fields indicators can be sorted by
+var indicatorSortFields = pagination.SortFields{"name", "category", "created_at", "updated_at"}
+
 // IndicatorService handles technical indicator operations
 type IndicatorService struct {
 	db            *sqlx.DB
@@ -36,6 +42,7 @@ func NewIndicatorService(db *sqlx.DB, indicatorRepo *repository.IndicatorReposit
 
 // GetAllIndicators retrieves all technical indicators with their parameters and enum values
 // Now includes isAdmin parameter to control visibility of parameters
+// userID is 0 for anonymous requests, which only see platform and public indicators
 func (s *IndicatorService) GetAllIndicators(
 	ctx context.Context,
 	searchTerm string,
@@ -46,6 +53,7 @@ func (s *IndicatorService) GetAllIndicators(
 	page,
 	limit int,
 	isAdmin bool,
+	userID int,
 ) ([]model.TechnicalIndicator, int, error) {
 	// Validate pagination
 	if page < 1 {
@@ -55,41 +63,70 @@ func (s *IndicatorService) GetAllIndicators(
 		limit = 20
 	}
 
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"name":       true,
-		"category":   true,
-		"created_at": true,
-		"updated_at": true,
-	}
+	// Validate sort parameters
+	sortBy = indicatorSortFields.Normalize(sortBy, "name")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "ASC")
 
-	if !validSortFields[sortBy] {
-		sortBy = "name" // Default sort by name
+	// Forward the parameters to the repository layer
+	indicators, total, err := s.indicatorRepo.GetAllIndicators(ctx, searchTerm, categories, active, sortBy, sortDirection, page, limit, isAdmin, userID)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// Validate sort direction
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "ASC" // Default ascending for indicators
+	if err := s.attachDeprecations(ctx, indicators); err != nil {
+		return nil, 0, err
 	}
 
-	// Forward the parameters to the repository layer
-	return s.indicatorRepo.GetAllIndicators(ctx, searchTerm, categories, active, sortBy, sortDirection, page, limit, isAdmin)
+	return indicators, total, nil
 }
 
 // GetIndicator retrieves a specific indicator by ID with parameters and enum values
-func (s *IndicatorService) GetIndicator(ctx context.Context, id int, isAdmin bool) (*model.TechnicalIndicator, error) {
+func (s *IndicatorService) GetIndicator(ctx context.Context, id int, isAdmin bool, userID int) (*model.TechnicalIndicator, error) {
 	// Forward the isAdmin flag to the repository layer
-	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, isAdmin)
+	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, isAdmin, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	if indicator == nil {
-		return nil, errors.New("indicator not found")
+		return nil, errcode.ErrIndicatorNotFound
 	}
 
-	return indicator, nil
+	indicators := []model.TechnicalIndicator{*indicator}
+	if err := s.attachDeprecations(ctx, indicators); err != nil {
+		return nil, err
+	}
+
+	return &indicators[0], nil
+}
+
+// attachDeprecations fills in the deprecation of each deprecated indicator
+func (s *IndicatorService) attachDeprecations(ctx context.Context, indicators []model.TechnicalIndicator) error {
+	if len(indicators) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(indicators))
+	for i, indicator := range indicators {
+		ids[i] = indicator.ID
+	}
+
+	deprecations, err := s.indicatorRepo.GetIndicatorDeprecations(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]model.IndicatorDeprecation, len(deprecations))
+	for _, deprecation := range deprecations {
+		byID[deprecation.IndicatorID] = deprecation
+	}
+	for i := range indicators {
+		if deprecation, ok := byID[indicators[i].ID]; ok {
+			indicators[i].Deprecation = &deprecation
+		}
+	}
+
+	return nil
 }
 
 // CreateIndicator creates a new technical indicator with parameters and enum values
@@ -212,16 +249,174 @@ func (s *IndicatorService) CreateIndicator(
 	return indicator, nil
 }
 
+// CreateCustomIndicator registers a user-defined indicator. The formula is validated by the
+// backtesting service before it is stored; the indicator is private unless requested otherwise.
+func (s *IndicatorService) CreateCustomIndicator(ctx context.Context, userID int, request *model.CustomIndicatorCreate) (*model.TechnicalIndicator, error) {
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		return nil, errors.New("indicator name is required")
+	}
+	if strings.TrimSpace(request.Formula) == "" {
+		return nil, errors.New("indicator formula is required")
+	}
+
+	visibility := strings.ToLower(request.Visibility)
+	if visibility == "" {
+		visibility = model.IndicatorVisibilityPrivate
+	}
+	if visibility != model.IndicatorVisibilityPrivate && visibility != model.IndicatorVisibilityPublic {
+		return nil, fmt.Errorf("invalid visibility %q: must be private or public", request.Visibility)
+	}
+
+	category := request.Category
+	if category == "" {
+		category = "Custom"
+	}
+
+	// Strategy structures refer to indicators by name, so custom indicators can't shadow platform ones
+	exists, err := s.indicatorRepo.PlatformIndicatorExists(ctx, request.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("indicator name %q already exists as a platform indicator", request.Name)
+	}
+
+	if err := s.validateCustomIndicator(ctx, request); err != nil {
+		return nil, err
+	}
+
+	indicator := &model.TechnicalIndicator{
+		Name:        request.Name,
+		Description: request.Description,
+		Category:    category,
+		Formula:     request.Formula,
+		MinValue:    request.MinValue,
+		MaxValue:    request.MaxValue,
+		IsActive:    true,
+		OwnerID:     &userID,
+		Visibility:  visibility,
+		CreatedAt:   time.Now(),
+	}
+
+	indicatorID, err := s.indicatorRepo.CreateIndicator(ctx, indicator)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_indicators_owner_name") {
+			return nil, fmt.Errorf("you already have an indicator named %q", request.Name)
+		}
+		return nil, err
+	}
+
+	indicator.ID = indicatorID
+	indicator.Parameters = make([]model.IndicatorParameter, 0, len(request.Parameters))
+
+	// The owner always sees their parameters; public ones are shown to everyone else
+	for _, paramCreate := range request.Parameters {
+		paramCreate.IndicatorID = indicatorID
+		paramCreate.IsPublic = true
+
+		paramID, err := s.indicatorRepo.CreateIndicatorParameter(ctx, &paramCreate)
+		if err != nil {
+			s.logger.Error("Failed to add custom indicator parameter", zap.Error(err))
+			return nil, err
+		}
+
+		indicator.Parameters = append(indicator.Parameters, model.IndicatorParameter{
+			ID:            paramID,
+			IndicatorID:   indicatorID,
+			ParameterName: paramCreate.ParameterName,
+			ParameterType: paramCreate.ParameterType,
+			IsRequired:    paramCreate.IsRequired,
+			MinValue:      paramCreate.MinValue,
+			MaxValue:      paramCreate.MaxValue,
+			DefaultValue:  paramCreate.DefaultValue,
+			Description:   paramCreate.Description,
+			IsPublic:      paramCreate.IsPublic,
+			EnumValues:    []model.ParameterEnumValue{},
+		})
+	}
+
+	s.logger.Info("Successfully created custom indicator",
+		zap.Int("id", indicatorID),
+		zap.String("name", indicator.Name),
+		zap.Int("owner_id", userID),
+		zap.String("visibility", visibility))
+
+	return indicator, nil
+}
+
+// validateCustomIndicator asks the backtesting service to parse and evaluate the formula
+func (s *IndicatorService) validateCustomIndicator(ctx context.Context, request *model.CustomIndicatorCreate) error {
+	type parameter struct {
+		Name    string `json:"name"`
+		Default string `json:"default"`
+	}
+
+	payload := struct {
+		Formula    string      `json:"formula"`
+		Parameters []parameter `json:"parameters"`
+	}{
+		Formula:    request.Formula,
+		Parameters: make([]parameter, 0, len(request.Parameters)),
+	}
+	for _, param := range request.Parameters {
+		payload.Parameters = append(payload.Parameters, parameter{Name: param.ParameterName, Default: param.DefaultValue})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	validateURL := backtestingServiceURL() + "/validate-indicator"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, validateURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		s.logger.Error("Failed to reach backtesting service for indicator validation",
+			zap.Error(err),
+			zap.String("url", validateURL))
+		return errcode.ErrIndicatorValidationUnavailable.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	var result model.IndicatorValidationResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return errcode.ErrIndicatorValidationUnavailable.Wrap(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		s.logger.Error("Backtesting service failed to validate indicator",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("error", result.Error))
+		return errcode.ErrIndicatorValidationUnavailable.Wrap(fmt.Errorf("backtesting service returned status code %d", resp.StatusCode))
+	}
+
+	if !result.Valid {
+		return errcode.ErrInvalidIndicatorFormula.WithMessage("Invalid indicator formula: " + result.Message)
+	}
+
+	return nil
+}
+
 // UpdateIndicator updates an indicator
 func (s *IndicatorService) UpdateIndicator(ctx context.Context, id int, update *model.TechnicalIndicator) (*model.TechnicalIndicator, error) {
 	// Check if indicator exists
-	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true) // Admin view for checking existence
+	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true, 0) // Admin view for checking existence
 	if err != nil {
 		return nil, err
 	}
 
 	if indicator == nil {
-		return nil, errors.New("indicator not found")
+		return nil, errcode.ErrIndicatorNotFound
 	}
 
 	// Update indicator
@@ -231,7 +426,7 @@ func (s *IndicatorService) UpdateIndicator(ctx context.Context, id int, update *
 	}
 
 	// Get the updated indicator (admin view for complete data)
-	updatedIndicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true)
+	updatedIndicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -242,18 +437,60 @@ func (s *IndicatorService) UpdateIndicator(ctx context.Context, id int, update *
 // DeleteIndicator deletes an indicator by ID
 func (s *IndicatorService) DeleteIndicator(ctx context.Context, id int) error {
 	// Check if indicator exists
-	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true) // Admin view for checking existence
+	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, id, true, 0) // Admin view for checking existence
 	if err != nil {
 		return err
 	}
 
 	if indicator == nil {
-		return errors.New("indicator not found")
+		return errcode.ErrIndicatorNotFound
 	}
 
 	return s.indicatorRepo.DeleteIndicator(ctx, id)
 }
 
+// DeprecateIndicator deprecates a platform indicator in favor of an optional replacement,
+// or changes its deprecation, and returns the indicator with its deprecation
+func (s *IndicatorService) DeprecateIndicator(ctx context.Context, id int, request *model.IndicatorDeprecate, userID int) (*model.TechnicalIndicator, error) {
+	if err := s.indicatorRepo.DeprecateIndicator(ctx, id, request, userID); err != nil {
+		return nil, err
+	}
+
+	return s.GetIndicator(ctx, id, true, 0)
+}
+
+// UndeprecateIndicator withdraws the deprecation of an indicator
+func (s *IndicatorService) UndeprecateIndicator(ctx context.Context, id int) error {
+	return s.indicatorRepo.UndeprecateIndicator(ctx, id)
+}
+
+// GetDeprecatedIndicatorReport lists the deprecated indicators and the strategies whose
+// current version still uses them
+func (s *IndicatorService) GetDeprecatedIndicatorReport(ctx context.Context) (*model.DeprecatedIndicatorReport, error) {
+	deprecations, err := s.indicatorRepo.GetIndicatorDeprecations(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.indicatorRepo.GetDeprecatedIndicatorUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.DeprecatedIndicatorReport{
+		Deprecations: deprecations,
+		Usage:        usage,
+	}
+	if report.Deprecations == nil {
+		report.Deprecations = []model.IndicatorDeprecation{}
+	}
+	if report.Usage == nil {
+		report.Usage = []model.DeprecatedIndicatorUsage{}
+	}
+
+	return report, nil
+}
+
 // GetIndicatorCategories retrieves indicator categories
 func (s *IndicatorService) GetIndicatorCategories(ctx context.Context) ([]CategoryInfo, error) {
 	repoCategories, err := s.indicatorRepo.GetIndicatorCategories(ctx)
@@ -309,7 +546,7 @@ func (s *IndicatorService) AddIndicatorParameter(
 	}
 
 	// Check if indicator exists
-	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, indicatorID, true) // Admin view for checking existence
+	indicator, err := s.indicatorRepo.GetIndicatorByID(ctx, indicatorID, true, 0) // Admin view for checking existence
 	if err != nil {
 		s.logger.Error("Failed to get indicator", zap.Error(err), zap.Int("indicator_id", indicatorID))
 		return nil, fmt.Errorf("error checking indicator: %w", err)
@@ -512,17 +749,11 @@ func (s *IndicatorService) getIndicatorParameterByID(ctx context.Context, id int
 	return s.indicatorRepo.GetIndicatorParameterByID(ctx, id)
 }
 
-// SyncIndicatorsFromBacktestingService syncs indicators from the backtesting service
-func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Context) (int, error) {
-	// Get backtesting service URL from environment or use default
-	backtestingServiceURL := os.Getenv("BACKTEST_SERVICE_URL")
-	if backtestingServiceURL == "" {
-		// Default base URL if environment variable not set
-		backtestingServiceURL = "http://backtesting-service:5000"
-	}
-
+// SyncIndicatorsFromBacktestingService syncs indicators from the backtesting service and
+// reports what changed. A dry run reports what would change without writing anything.
+func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Context, dryRun bool) (*model.IndicatorSyncReport, error) {
 	// Add the endpoint path
-	indicatorsURL := backtestingServiceURL + "/indicators"
+	indicatorsURL := backtestingServiceURL() + "/indicators"
 
 	s.logger.Info("Connecting to backtesting service", zap.String("url", indicatorsURL))
 
@@ -535,7 +766,7 @@ func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Cont
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indicatorsURL, nil)
 	if err != nil {
 		s.logger.Error("Failed to create request to backtesting service", zap.Error(err))
-		return 0, err
+		return nil, err
 	}
 
 	// Send request
@@ -568,7 +799,7 @@ func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Cont
 		}
 
 		if resp == nil {
-			return 0, fmt.Errorf("all connection attempts to backtesting service failed: %w", err)
+			return nil, fmt.Errorf("all connection attempts to backtesting service failed: %w", err)
 		}
 	}
 
@@ -580,7 +811,7 @@ func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Cont
 		s.logger.Error("Backtesting service returned non-200 status",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("response", string(bodyBytes)))
-		return 0, fmt.Errorf("backtesting service returned status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("backtesting service returned status code %d", resp.StatusCode)
 	}
 
 	// Parse response
@@ -588,14 +819,96 @@ func (s *IndicatorService) SyncIndicatorsFromBacktestingService(ctx context.Cont
 	err = json.NewDecoder(resp.Body).Decode(&indicators)
 	if err != nil {
 		s.logger.Error("Failed to decode indicators response", zap.Error(err))
-		return 0, err
+		return nil, err
 	}
 
 	// Sync indicators using repository
-	syncedCount, err := s.indicatorRepo.SyncIndicators(ctx, indicators)
+	report, err := s.indicatorRepo.SyncIndicators(ctx, indicators, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync indicators: %w", err)
+	}
+
+	s.logger.Info("Indicator sync finished",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("created", len(report.Created)),
+		zap.Int("updated", len(report.Updated)),
+		zap.Int("deactivated", len(report.Deactivated)),
+		zap.Int("missing", len(report.Missing)),
+		zap.Int("unchanged", report.Unchanged))
+
+	return report, nil
+}
+
+// indicatorCatalogVersion is the version of the exported catalog format
+const indicatorCatalogVersion = 1
+
+// ExportIndicatorCatalog exports the platform indicators with their parameters and enum values
+func (s *IndicatorService) ExportIndicatorCatalog(ctx context.Context) (*model.IndicatorCatalog, error) {
+	indicators, err := s.indicatorRepo.ExportIndicators(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.IndicatorCatalog{
+		Version:    indicatorCatalogVersion,
+		ExportedAt: time.Now().UTC(),
+		Indicators: indicators,
+	}, nil
+}
+
+// ImportIndicatorCatalog validates a catalog and applies it in one transaction, resolving
+// conflicts with existing indicators according to mode (skip, overwrite or merge)
+func (s *IndicatorService) ImportIndicatorCatalog(ctx context.Context, catalog *model.IndicatorCatalog, mode string) (*model.IndicatorImportReport, error) {
+	switch mode {
+	case model.IndicatorImportSkip, model.IndicatorImportOverwrite, model.IndicatorImportMerge:
+	default:
+		return nil, fmt.Errorf("invalid conflict mode %q: must be skip, overwrite or merge", mode)
+	}
+
+	if catalog.Version > indicatorCatalogVersion {
+		return nil, fmt.Errorf("invalid catalog: version %d is newer than the supported version %d", catalog.Version, indicatorCatalogVersion)
+	}
+
+	names := make(map[string]bool, len(catalog.Indicators))
+	for _, indicator := range catalog.Indicators {
+		if indicator.Name == "" {
+			return nil, errors.New("invalid catalog: every indicator needs a name")
+		}
+		if names[indicator.Name] {
+			return nil, fmt.Errorf("invalid catalog: indicator %q appears more than once", indicator.Name)
+		}
+		names[indicator.Name] = true
+
+		paramNames := make(map[string]bool, len(indicator.Parameters))
+		for _, param := range indicator.Parameters {
+			if param.Name == "" || param.Type == "" {
+				return nil, fmt.Errorf("invalid catalog: parameters of %q need a name and a type", indicator.Name)
+			}
+			if paramNames[param.Name] {
+				return nil, fmt.Errorf("invalid catalog: parameter %q of %q appears more than once", param.Name, indicator.Name)
+			}
+			paramNames[param.Name] = true
+		}
+	}
+
+	report, err := s.indicatorRepo.ImportIndicators(ctx, catalog.Indicators, mode)
 	if err != nil {
-		return 0, fmt.Errorf("failed to sync indicators: %w", err)
+		return nil, fmt.Errorf("failed to import indicators: %w", err)
 	}
 
-	return syncedCount, nil
+	s.logger.Info("Imported indicator catalog",
+		zap.String("mode", mode),
+		zap.Int("created", len(report.Created)),
+		zap.Int("updated", len(report.Updated)),
+		zap.Int("skipped", len(report.Skipped)))
+
+	return report, nil
+}
+
+// backtestingServiceURL returns the backtesting service base URL from the environment or the default
+func backtestingServiceURL() string {
+	if url := os.Getenv("BACKTEST_SERVICE_URL"); url != "" {
+		return url
+	}
+	return "http://backtesting-service:5000"
 }
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"services/strategy-service/internal/client"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// ListingEventConsumer reads listing views and purchases from Kafka and records them for
+// trending scores and recommendations
+type ListingEventConsumer struct {
+	reader                *kafka.Reader
+	recommendationService *RecommendationService
+	logger                *zap.Logger
+}
+
+// NewListingEventConsumer creates a new listing event consumer for a set of event topics
+func NewListingEventConsumer(
+	brokers []string,
+	groupID string,
+	topics []string,
+	recommendationService *RecommendationService,
+	logger *zap.Logger,
+) *ListingEventConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        groupID,
+		GroupTopics:    topics,
+		MinBytes:       1,
+		MaxBytes:       1 << 20,
+		CommitInterval: time.Second,
+	})
+
+	return &ListingEventConsumer{
+		reader:                reader,
+		recommendationService: recommendationService,
+		logger:                logger,
+	}
+}
+
+// Run consumes events until the context is cancelled
+func (c *ListingEventConsumer) Run(ctx context.Context) {
+	c.logger.Info("Starting listing event consumer", zap.Strings("topics", c.reader.Config().GroupTopics))
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to read listing event", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.handleMessage(ctx, msg)
+	}
+}
+
+// handleMessage records a single event. The marketplace topic also carries other events
+// (and gateway audit records), which are skipped without decoding them fully.
+func (c *ListingEventConsumer) handleMessage(ctx context.Context, msg kafka.Message) {
+	var header struct {
+		EventType string `json:"event_type"`
+	}
+	if err := json.Unmarshal(msg.Value, &header); err != nil {
+		c.logger.Warn("Skipping malformed listing event",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+	if header.EventType != client.EventListingViewed && header.EventType != client.EventStrategyPurchased {
+		return
+	}
+
+	var event client.Event
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Warn("Skipping malformed listing event",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	eventKey := fmt.Sprintf("%s:%d:%d", msg.Topic, msg.Partition, msg.Offset)
+	if err := c.recommendationService.RecordListingEvent(ctx, &event, eventKey); err != nil {
+		c.logger.Error("Failed to record listing event",
+			zap.Error(err),
+			zap.Int("listingID", event.EntityID),
+			zap.String("eventType", event.EventType))
+	}
+}
+
+// Close closes the underlying Kafka reader
+func (c *ListingEventConsumer) Close() error {
+	return c.reader.Close()
+}
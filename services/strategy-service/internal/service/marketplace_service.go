@@ -2,11 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
+	"services/shared/pagination"
 	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/repository"
 
@@ -14,15 +18,26 @@ import (
 	"go.uber.org/zap"
 )
 
+// lowestPriceWindow is how far back the lowest price shown on listings looks
+const lowestPriceWindow = 30 * 24 * time.Hour
+
+// ListingSortFields are the fields marketplace listings can be sorted by
+var ListingSortFields = pagination.SortFields{"relevance", "popularity", "rating", "price", "newest", "name"}
+
 // MarketplaceService handles marketplace operations
 type MarketplaceService struct {
-	db              *sqlx.DB
-	marketplaceRepo *repository.MarketplaceRepository
-	strategyRepo    *repository.StrategyRepository
-	purchaseRepo    *repository.PurchaseRepository
-	reviewRepo      *repository.ReviewRepository
-	userClient      *client.UserClient
-	logger          *zap.Logger
+	db               *sqlx.DB
+	marketplaceRepo  *repository.MarketplaceRepository
+	strategyRepo     *repository.StrategyRepository
+	purchaseRepo     *repository.PurchaseRepository
+	reviewRepo       *repository.ReviewRepository
+	couponRepo       *repository.CouponRepository
+	userClient       *client.UserClient
+	historicalClient *client.HistoricalClient
+	events           *client.EventClient
+	notifications    *client.NotificationClient
+	riskService      *RiskService
+	logger           *zap.Logger
 }
 
 // NewMarketplaceService creates a new marketplace service
@@ -32,17 +47,27 @@ func NewMarketplaceService(
 	strategyRepo *repository.StrategyRepository,
 	purchaseRepo *repository.PurchaseRepository,
 	reviewRepo *repository.ReviewRepository,
+	couponRepo *repository.CouponRepository,
 	userClient *client.UserClient,
+	historicalClient *client.HistoricalClient,
+	events *client.EventClient,
+	notifications *client.NotificationClient,
+	riskService *RiskService,
 	logger *zap.Logger,
 ) *MarketplaceService {
 	return &MarketplaceService{
-		db:              db,
-		marketplaceRepo: marketplaceRepo,
-		strategyRepo:    strategyRepo,
-		purchaseRepo:    purchaseRepo,
-		reviewRepo:      reviewRepo,
-		userClient:      userClient,
-		logger:          logger,
+		db:               db,
+		marketplaceRepo:  marketplaceRepo,
+		strategyRepo:     strategyRepo,
+		purchaseRepo:     purchaseRepo,
+		reviewRepo:       reviewRepo,
+		couponRepo:       couponRepo,
+		userClient:       userClient,
+		historicalClient: historicalClient,
+		events:           events,
+		notifications:    notifications,
+		riskService:      riskService,
+		logger:           logger,
 	}
 }
 
@@ -55,6 +80,7 @@ func (s *MarketplaceService) GetAllListings(
 	isFree *bool,
 	tags []int,
 	minRating *float64,
+	sellerID *int,
 	sortBy string,
 	sortDirection string,
 	page, limit int,
@@ -67,6 +93,10 @@ func (s *MarketplaceService) GetAllListings(
 		limit = 20
 	}
 
+	// Validate sort parameters
+	sortBy = ListingSortFields.Normalize(sortBy, "popularity")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
+
 	// Get listings from repository with all filtering and sorting parameters
 	items, total, err := s.marketplaceRepo.GetAllListings(
 		ctx,
@@ -76,6 +106,7 @@ func (s *MarketplaceService) GetAllListings(
 		isFree,
 		tags,
 		minRating,
+		sellerID,
 		sortBy,
 		sortDirection,
 		page,
@@ -85,9 +116,67 @@ func (s *MarketplaceService) GetAllListings(
 		return nil, 0, err
 	}
 
+	s.enrichListings(ctx, items)
+
+	return items, total, nil
+}
+
+// GetListingsAfter retrieves the page of marketplace listings that follows a cursor, for
+// keyset pagination. The cursor must have been issued for the same sort order.
+func (s *MarketplaceService) GetListingsAfter(
+	ctx context.Context,
+	searchTerm string,
+	minPrice *float64,
+	maxPrice *float64,
+	isFree *bool,
+	tags []int,
+	minRating *float64,
+	sellerID *int,
+	sortBy string,
+	sortDirection string,
+	after *model.MarketplaceCursor,
+	limit int,
+) ([]model.MarketplaceItem, *model.MarketplaceCursor, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	sortBy = ListingSortFields.Normalize(sortBy, "popularity")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
+
+	if after != nil && (after.SortBy != sortBy || after.SortDirection != sortDirection) {
+		return nil, nil, errcode.ErrInvalidCursor.WithMessage("Cursor does not match the requested sort order")
+	}
+
+	items, next, err := s.marketplaceRepo.GetListingsAfter(
+		ctx,
+		searchTerm,
+		minPrice,
+		maxPrice,
+		isFree,
+		tags,
+		minRating,
+		sellerID,
+		sortBy,
+		sortDirection,
+		after,
+		limit,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.enrichListings(ctx, items)
+
+	return items, next, nil
+}
+
+// enrichListings adds creator details, currencies, verified backtest badges and risk scores
+// to listings
+func (s *MarketplaceService) enrichListings(ctx context.Context, items []model.MarketplaceItem) {
 	// If no items, return early
 	if len(items) == 0 {
-		return items, total, nil
+		return
 	}
 
 	// Extract unique user IDs from listings
@@ -131,13 +220,79 @@ func (s *MarketplaceService) GetAllListings(
 		}
 	}
 
+	listingIDs := make([]int, len(items))
+	for i := range items {
+		listingIDs[i] = items[i].ID
+	}
+
+	// Set the currency each listing is priced in
+	if currencies, err := s.marketplaceRepo.GetListingCurrencies(ctx, listingIDs); err != nil {
+		s.logger.Warn("Failed to get listing currencies", zap.Error(err))
+	} else {
+		for i := range items {
+			items[i].Currency = currencies[items[i].ID]
+		}
+	}
+
+	// Mark listings that carry a verified backtest
+	if verified, err := s.marketplaceRepo.GetVerifiedListingIDs(ctx, listingIDs); err != nil {
+		s.logger.Warn("Failed to get verified backtest badges", zap.Error(err))
+	} else {
+		for i := range items {
+			items[i].HasVerifiedBacktest = verified[items[i].ID]
+		}
+	}
+
+	// Set the lowest price each listing had over the last 30 days
+	if lowest, err := s.marketplaceRepo.GetLowestPrices(ctx, listingIDs, time.Now().Add(-lowestPriceWindow)); err != nil {
+		s.logger.Warn("Failed to get lowest listing prices", zap.Error(err))
+	} else {
+		for i := range items {
+			if price, ok := lowest[items[i].ID]; ok {
+				items[i].LowestPrice30d = &price
+			}
+		}
+	}
+
+	// Set the risk score of each listed strategy version
+	if scores, err := s.riskService.GetListingRiskScores(ctx, listingIDs); err != nil {
+		s.logger.Warn("Failed to get listing risk scores", zap.Error(err))
+	} else {
+		for i := range items {
+			items[i].RiskScore = scores[items[i].ID]
+		}
+	}
+
 	// Add debug info to the first item if there was an error
 	if len(items) > 0 && userDetailsErr != "" {
 		s.logger.Debug("Including user service error in debug_info",
 			zap.String("error", userDetailsErr))
 	}
 
-	return items, total, nil
+}
+
+// GetFacets retrieves the filter counts for the marketplace browse page
+func (s *MarketplaceService) GetFacets(
+	ctx context.Context,
+	searchTerm string,
+	minPrice *float64,
+	maxPrice *float64,
+	isFree *bool,
+	tags []int,
+	minRating *float64,
+) (*model.MarketplaceFacets, error) {
+	return s.marketplaceRepo.GetFacets(ctx, searchTerm, minPrice, maxPrice, isFree, tags, minRating)
+}
+
+// GetSellerStats retrieves the public marketplace statistics of a seller
+func (s *MarketplaceService) GetSellerStats(ctx context.Context, sellerID int) (*model.SellerStats, error) {
+	return s.marketplaceRepo.GetSellerStats(ctx, sellerID)
+}
+
+// GetListingPurchasers retrieves the IDs of the buyers of a listing who still have access
+// to it, for notifications other services send them
+func (s *MarketplaceService) GetListingPurchasers(ctx context.Context, marketplaceID int) ([]int, error) {
+	return s.marketplaceRepo.GetListingPurchasers(ctx, marketplaceID)
 }
 
 // CreateListing creates a new marketplace listing
@@ -149,7 +304,7 @@ func (s *MarketplaceService) CreateListing(ctx context.Context, listing *model.M
 	}
 
 	if strategy == nil {
-		return nil, errors.New("strategy not found")
+		return nil, errcode.ErrStrategyNotFound
 	}
 
 	if strategy.UserID != userID {
@@ -162,6 +317,8 @@ func (s *MarketplaceService) CreateListing(ctx context.Context, listing *model.M
 		return nil, err
 	}
 
+	s.riskService.RecomputeListingRiskScore(ctx, id)
+
 	// Get created listing
 	createdListing, err := s.marketplaceRepo.GetListingByID(ctx, id)
 	if err != nil {
@@ -180,6 +337,14 @@ func (s *MarketplaceService) CreateListing(ctx context.Context, listing *model.M
 		createdListing.CreatorName = fmt.Sprintf("User %d", userID)
 	}
 
+	s.events.Publish(ctx, client.Event{
+		EventType:  client.EventListingCreated,
+		UserID:     userID,
+		EntityType: "listing",
+		EntityID:   id,
+		EntityName: strategy.Name,
+	})
+
 	return createdListing, nil
 }
 
@@ -192,7 +357,7 @@ func (s *MarketplaceService) GetListingByID(ctx context.Context, id int) (*model
 	}
 
 	if listing == nil {
-		return nil, errors.New("listing not found")
+		return nil, errcode.ErrListingNotFound
 	}
 
 	// Get strategy details to enhance the listing
@@ -206,6 +371,31 @@ func (s *MarketplaceService) GetListingByID(ctx context.Context, id int) (*model
 		listing.ThumbnailURL = strategy.ThumbnailURL
 	}
 
+	// Attach the verified backtest snapshot, if any
+	verified, err := s.marketplaceRepo.GetVerifiedBacktest(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to get verified backtest for listing", zap.Error(err), zap.Int("id", id))
+	} else if verified != nil {
+		listing.VerifiedBacktest = verified
+		listing.HasVerifiedBacktest = true
+	}
+
+	// Attach the lowest price of the last 30 days
+	lowest, err := s.marketplaceRepo.GetLowestPrices(ctx, []int{id}, time.Now().Add(-lowestPriceWindow))
+	if err != nil {
+		s.logger.Warn("Failed to get lowest price for listing", zap.Error(err), zap.Int("id", id))
+	} else if price, ok := lowest[id]; ok {
+		listing.LowestPrice30d = &price
+	}
+
+	// Attach the risk score of the listed strategy version, once computed
+	scores, err := s.riskService.GetListingRiskScores(ctx, []int{id})
+	if err != nil {
+		s.logger.Warn("Failed to get risk score for listing", zap.Error(err), zap.Int("id", id))
+	} else {
+		listing.RiskScore = scores[id]
+	}
+
 	// Try to get creator name
 	username, err := s.userClient.GetUserByID(ctx, listing.UserID)
 	if err == nil {
@@ -243,7 +433,7 @@ func (s *MarketplaceService) DeleteListing(ctx context.Context, id int, userID i
 	}
 
 	if listing == nil {
-		return errors.New("listing not found")
+		return errcode.ErrListingNotFound
 	}
 
 	// Check if strategy belongs to the user
@@ -264,8 +454,99 @@ func (s *MarketplaceService) DeleteListing(ctx context.Context, id int, userID i
 	return s.marketplaceRepo.DeleteListing(ctx, id, userID)
 }
 
-// PurchaseStrategy purchases a strategy from the marketplace
-func (s *MarketplaceService) PurchaseStrategy(ctx context.Context, marketplaceID int, userID int) (*model.StrategyPurchase, error) {
+// PublishListingVersion publishes a newer version of the listed strategy to a listing of
+// the seller and records the seller's notes in its changelog. If asked to, the buyers who
+// still have access are notified; it returns how many were.
+func (s *MarketplaceService) PublishListingVersion(ctx context.Context, id int, userID int, publish *model.ListingVersionPublish) (*model.ListingVersion, int, error) {
+	version, err := s.marketplaceRepo.PublishListingVersion(ctx, id, userID, publish)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.riskService.RecomputeListingRiskScore(ctx, id)
+
+	if !publish.NotifyPurchasers {
+		return version, 0, nil
+	}
+
+	return version, s.notifyPurchasersOfVersion(ctx, id, userID, version), nil
+}
+
+// notifyPurchasersOfVersion tells the buyers of a listing about a new version. Failing to
+// notify them doesn't undo the publish, so errors are logged and no one counts as notified.
+func (s *MarketplaceService) notifyPurchasersOfVersion(ctx context.Context, id int, sellerID int, version *model.ListingVersion) int {
+	buyerIDs, err := s.marketplaceRepo.GetListingPurchasers(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to get listing purchasers, new version not announced", zap.Error(err), zap.Int("id", id))
+		return 0
+	}
+
+	name := fmt.Sprintf("Listing #%d", id)
+	if listing, err := s.GetListingByID(ctx, id); err == nil && listing.Name != "" {
+		name = listing.Name
+	}
+
+	message := fmt.Sprintf("Version %d of %s is available.", version.Version, name)
+	if version.Notes != "" {
+		message += " " + version.Notes
+	}
+
+	events := make([]client.NotificationEvent, 0, len(buyerIDs))
+	for _, buyerID := range buyerIDs {
+		if buyerID == sellerID {
+			continue
+		}
+		events = append(events, client.NotificationEvent{
+			UserID:  buyerID,
+			Type:    "listing_version_published",
+			Title:   fmt.Sprintf("%s was updated", name),
+			Message: message,
+			Link:    fmt.Sprintf("/marketplace/%d/changelog", id),
+		})
+	}
+
+	if err := s.notifications.Send(ctx, events...); err != nil {
+		s.logger.Error("Failed to notify purchasers of new listing version", zap.Error(err), zap.Int("id", id))
+		return 0
+	}
+
+	return len(events)
+}
+
+// GetListingChangelog retrieves the strategy versions published to a listing, newest first
+func (s *MarketplaceService) GetListingChangelog(ctx context.Context, id int) ([]model.ListingVersion, error) {
+	listing, err := s.marketplaceRepo.GetListingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if listing == nil {
+		return nil, errcode.ErrListingNotFound
+	}
+
+	return s.marketplaceRepo.GetListingChangelog(ctx, id)
+}
+
+// GetListingPriceHistory retrieves the prices a listing had, newest first
+func (s *MarketplaceService) GetListingPriceHistory(ctx context.Context, id int) ([]model.ListingPrice, error) {
+	listing, err := s.marketplaceRepo.GetListingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if listing == nil {
+		return nil, errcode.ErrListingNotFound
+	}
+
+	return s.marketplaceRepo.GetListingPriceHistory(ctx, id)
+}
+
+// PurchaseStrategy purchases a strategy from the marketplace. A non-empty coupon code must
+// name a valid coupon of the listing; its discount is taken off the listing price. With
+// useReferralCredit, the buyer's referral credit pays for what's left as far as it goes;
+// it's given back if the purchase fails. With useWalletBalance, the buyer's wallet pays
+// what remains after that.
+func (s *MarketplaceService) PurchaseStrategy(ctx context.Context, marketplaceID int, userID int, couponCode string, useReferralCredit, useWalletBalance bool) (*model.StrategyPurchase, error) {
 	// Get listing
 	listing, err := s.marketplaceRepo.GetListingByID(ctx, marketplaceID)
 	if err != nil {
@@ -273,7 +554,7 @@ func (s *MarketplaceService) PurchaseStrategy(ctx context.Context, marketplaceID
 	}
 
 	if listing == nil {
-		return nil, errors.New("listing not found")
+		return nil, errcode.ErrListingNotFound
 	}
 
 	if !listing.IsActive {
@@ -287,16 +568,56 @@ func (s *MarketplaceService) PurchaseStrategy(ctx context.Context, marketplaceID
 	}
 
 	if strategy == nil {
-		return nil, errors.New("strategy not found")
+		return nil, errcode.ErrStrategyNotFound
 	}
 
 	if strategy.UserID == userID {
 		return nil, errors.New("cannot purchase your own strategy")
 	}
 
+	// Apply coupon
+	var couponID *int
+	discount := 0.0
+	if couponCode != "" {
+		coupon, err := s.couponRepo.GetCouponByCode(ctx, marketplaceID, couponCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if coupon == nil {
+			return nil, errors.New("invalid coupon: coupon not found")
+		}
+
+		if err := checkCouponRedeemable(coupon); err != nil {
+			return nil, err
+		}
+
+		couponID = &coupon.ID
+		couponCode = coupon.Code
+		discount = couponDiscount(coupon, listing.Price)
+	}
+
+	// Spend referral credit on what the coupon left
+	var redemption *client.ReferralRedemption
+	var redemptionID *int
+	credit := 0.0
+	if remaining := math.Round((listing.Price-discount)*100) / 100; useReferralCredit && remaining > 0 {
+		redemption, err = s.userClient.RedeemReferralCredit(ctx, userID, remaining, listing.Currency, fmt.Sprintf("listing:%d", marketplaceID))
+		if err != nil {
+			return nil, err
+		}
+		credit = redemption.Amount
+		if credit > 0 {
+			redemptionID = &redemption.RedemptionID
+		}
+	}
+
 	// Create purchase record
-	purchaseID, err := s.purchaseRepo.Purchase(ctx, marketplaceID, userID)
+	purchaseID, walletAmount, err := s.purchaseRepo.Purchase(ctx, marketplaceID, userID, couponID, discount, credit, redemptionID, useWalletBalance)
 	if err != nil {
+		if credit > 0 {
+			s.reverseReferralCredit(ctx, redemption.RedemptionID, userID)
+		}
 		return nil, err
 	}
 
@@ -315,22 +636,216 @@ func (s *MarketplaceService) PurchaseStrategy(ctx context.Context, marketplaceID
 		subscriptionEnd = &endDate
 	}
 
+	s.events.Publish(ctx, client.Event{
+		EventType:  client.EventStrategyPurchased,
+		UserID:     userID,
+		OwnerID:    strategy.UserID,
+		EntityType: "listing",
+		EntityID:   marketplaceID,
+		EntityName: strategy.Name,
+	})
+
 	// Return purchase info
 	return &model.StrategyPurchase{
 		ID:              purchaseID,
 		MarketplaceID:   marketplaceID,
 		BuyerID:         userID,
-		PurchasePrice:   listing.Price,
+		PurchasePrice:   math.Round((listing.Price-discount-credit)*100) / 100,
+		OriginalPrice:   listing.Price,
+		DiscountAmount:  discount,
+		CreditAmount:    credit,
+		WalletAmount:    walletAmount,
+		CouponCode:      couponCode,
+		Currency:        listing.Currency,
 		SubscriptionEnd: subscriptionEnd,
 		CreatedAt:       time.Now(),
 	}, nil
 }
 
+// reverseReferralCredit gives back referral credit spent on a purchase that failed. It
+// isn't cancelled with the request, so the credit is given back even if the request was.
+func (s *MarketplaceService) reverseReferralCredit(ctx context.Context, redemptionID int, userID int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if err := s.userClient.ReverseReferralRedemption(ctx, redemptionID); err != nil {
+		s.logger.Error("Failed to give back referral credit of a failed purchase", zap.Error(err),
+			zap.Int("redemption_id", redemptionID),
+			zap.Int("user_id", userID))
+	}
+}
+
+// GetPurchaseHistory retrieves the purchases of a buyer with their subscription and refund state
+func (s *MarketplaceService) GetPurchaseHistory(ctx context.Context, userID int, page, limit int) ([]model.PurchaseDetails, int, error) {
+	return s.purchaseRepo.GetUserPurchases(ctx, userID, page, limit)
+}
+
 // CancelSubscription cancels a subscription
 func (s *MarketplaceService) CancelSubscription(ctx context.Context, purchaseID int, userID int) error {
 	return s.purchaseRepo.CancelSubscription(ctx, purchaseID, userID)
 }
 
+// UpgradePurchase upgrades a version-locked purchase of the buyer to the listing's current
+// version, at the listing's upgrade price
+func (s *MarketplaceService) UpgradePurchase(ctx context.Context, purchaseID int, userID int) (*model.PurchaseUpgrade, error) {
+	return s.purchaseRepo.UpgradePurchase(ctx, purchaseID, userID)
+}
+
+// SetUpdatePolicy changes whether buyers of a listing of the seller get the versions
+// published after their purchase, and what upgrading costs them if they don't
+func (s *MarketplaceService) SetUpdatePolicy(ctx context.Context, id int, userID int, update *model.ListingUpdatePolicyUpdate) (*model.MarketplaceItem, error) {
+	if err := s.marketplaceRepo.SetUpdatePolicy(ctx, id, userID, update); err != nil {
+		return nil, err
+	}
+
+	return s.GetListingByID(ctx, id)
+}
+
+// AttachBacktest verifies a completed backtest of the listed strategy version and
+// attaches a snapshot of its metrics to the listing
+func (s *MarketplaceService) AttachBacktest(ctx context.Context, marketplaceID int, backtestID int, userID int) (*model.VerifiedBacktest, error) {
+	listing, err := s.marketplaceRepo.GetListingByID(ctx, marketplaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if listing == nil {
+		return nil, errcode.ErrListingNotFound
+	}
+
+	if listing.UserID != userID {
+		return nil, errors.New("access denied: you can only attach backtests to your own listings")
+	}
+
+	backtest, err := s.historicalClient.GetBacktest(ctx, backtestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify backtest: %w", err)
+	}
+
+	if backtest == nil || backtest.UserID != userID {
+		return nil, errcode.ErrBacktestNotFound
+	}
+
+	if backtest.Status != "completed" {
+		return nil, errors.New("only completed backtests can be attached")
+	}
+
+	// The backtest must have been run on the exact strategy version being sold
+	listedStrategy, err := s.strategyRepo.GetStrategyByID(ctx, listing.StrategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	testedStrategy, err := s.strategyRepo.GetStrategyByID(ctx, backtest.StrategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if listedStrategy == nil || testedStrategy == nil ||
+		testedStrategy.StrategyGroupID != listedStrategy.StrategyGroupID ||
+		testedStrategy.Version != listing.VersionID ||
+		backtest.StrategyVersion != listing.VersionID {
+		return nil, errors.New("backtest was not run on the listed strategy version")
+	}
+
+	snapshot, err := buildBacktestSnapshot(marketplaceID, backtest)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.marketplaceRepo.AttachBacktest(ctx, userID, snapshot); err != nil {
+		return nil, err
+	}
+
+	// A new verified backtest changes the risk of the listed version
+	s.riskService.RecomputeListingRiskScore(ctx, marketplaceID)
+
+	return s.marketplaceRepo.GetVerifiedBacktest(ctx, marketplaceID)
+}
+
+// buildBacktestSnapshot aggregates the completed runs of a backtest into a listing snapshot.
+// Trades are summed, drawdown is the worst run and the remaining ratios are averaged.
+func buildBacktestSnapshot(marketplaceID int, backtest *client.BacktestDetails) (*model.VerifiedBacktest, error) {
+	snapshot := &model.VerifiedBacktest{
+		MarketplaceID:       marketplaceID,
+		BacktestID:          backtest.BacktestID,
+		StrategyID:          backtest.StrategyID,
+		StrategyVersion:     backtest.StrategyVersion,
+		Timeframe:           backtest.Timeframe,
+		StartDate:           backtest.StartDate,
+		EndDate:             backtest.EndDate,
+		InitialCapital:      backtest.InitialCapital,
+		BacktestCompletedAt: backtest.CompletedAt,
+		Leverage:            1,
+		PositionSizing:      "fixed",
+	}
+	if backtest.Settings != nil {
+		if backtest.Settings.Leverage > 0 {
+			snapshot.Leverage = backtest.Settings.Leverage
+		}
+		if backtest.Settings.PositionSizing != "" {
+			snapshot.PositionSizing = backtest.Settings.PositionSizing
+		}
+	}
+
+	runs := make([]model.VerifiedBacktestRun, 0, len(backtest.RunResults))
+	winningTrades := 0
+	for _, run := range backtest.RunResults {
+		if run.Results == nil {
+			continue
+		}
+		runs = append(runs, model.VerifiedBacktestRun{
+			RunID:            run.RunID,
+			Symbol:           run.Symbol,
+			TotalTrades:      run.Results.TotalTrades,
+			WinningTrades:    run.Results.WinningTrades,
+			LosingTrades:     run.Results.LosingTrades,
+			ProfitFactor:     run.Results.ProfitFactor,
+			SharpeRatio:      run.Results.SharpeRatio,
+			MaxDrawdown:      run.Results.MaxDrawdown,
+			FinalCapital:     run.Results.FinalCapital,
+			TotalReturn:      run.Results.TotalReturn,
+			AnnualizedReturn: run.Results.AnnualizedReturn,
+		})
+
+		snapshot.TotalTrades += run.Results.TotalTrades
+		winningTrades += run.Results.WinningTrades
+		snapshot.ProfitFactor += run.Results.ProfitFactor
+		snapshot.SharpeRatio += run.Results.SharpeRatio
+		snapshot.TotalReturn += run.Results.TotalReturn
+		snapshot.AnnualizedReturn += run.Results.AnnualizedReturn
+		if run.Results.MaxDrawdown > snapshot.MaxDrawdown {
+			snapshot.MaxDrawdown = run.Results.MaxDrawdown
+		}
+	}
+
+	if len(runs) == 0 {
+		return nil, errors.New("backtest has no results to attach")
+	}
+
+	n := float64(len(runs))
+	snapshot.ProfitFactor /= n
+	snapshot.SharpeRatio /= n
+	snapshot.TotalReturn /= n
+	snapshot.AnnualizedReturn /= n
+	if snapshot.TotalTrades > 0 {
+		snapshot.WinRate = float64(winningTrades) / float64(snapshot.TotalTrades) * 100
+	}
+
+	runsJSON, err := json.Marshal(runs)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Runs = runsJSON
+
+	return snapshot, nil
+}
+
+// GetPurchase retrieves a purchase and its subscription state for the buyer or seller
+func (s *MarketplaceService) GetPurchase(ctx context.Context, purchaseID int, userID int) (*model.PurchaseDetails, error) {
+	return s.purchaseRepo.GetPurchaseByID(ctx, purchaseID, userID)
+}
+
 // GetReviews retrieves reviews for a marketplace listing
 func (s *MarketplaceService) GetReviews(
 	ctx context.Context,
@@ -346,7 +861,7 @@ func (s *MarketplaceService) GetReviews(
 	}
 
 	if listing == nil {
-		return nil, 0, errors.New("listing not found")
+		return nil, 0, errcode.ErrListingNotFound
 	}
 
 	// Validate pagination
@@ -405,7 +920,7 @@ func (s *MarketplaceService) CreateReview(ctx context.Context, review *model.Rev
 	}
 
 	if listing == nil {
-		return nil, errors.New("listing not found")
+		return nil, errcode.ErrListingNotFound
 	}
 
 	// Check if user has purchased the strategy
@@ -420,6 +935,15 @@ func (s *MarketplaceService) CreateReview(ctx context.Context, review *model.Rev
 		return nil, err
 	}
 
+	s.events.Publish(ctx, client.Event{
+		EventType:  client.EventReviewCreated,
+		UserID:     userID,
+		OwnerID:    listing.UserID,
+		EntityType: "listing",
+		EntityID:   review.MarketplaceID,
+		EntityName: listing.Name,
+	})
+
 	// Get user name
 	userName, err := s.userClient.GetUserByID(ctx, userID)
 	if err != nil {
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/config"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// RecommendationService ranks marketplace listings by how much attention they have been
+// getting lately and recommends listings to users based on what they bought and viewed
+type RecommendationService struct {
+	marketplaceRepo    *repository.MarketplaceRepository
+	marketplaceService *MarketplaceService
+	viewEvents         *client.EventClient
+	cfg                config.TrendingConfig
+	logger             *zap.Logger
+}
+
+// NewRecommendationService creates a new recommendation service
+func NewRecommendationService(
+	marketplaceRepo *repository.MarketplaceRepository,
+	marketplaceService *MarketplaceService,
+	viewEvents *client.EventClient,
+	cfg config.TrendingConfig,
+	logger *zap.Logger,
+) *RecommendationService {
+	if cfg.Window <= 0 {
+		cfg.Window = 14 * 24 * time.Hour
+	}
+	if cfg.HalfLife <= 0 {
+		cfg.HalfLife = 48 * time.Hour
+	}
+	return &RecommendationService{
+		marketplaceRepo:    marketplaceRepo,
+		marketplaceService: marketplaceService,
+		viewEvents:         viewEvents,
+		cfg:                cfg,
+		logger:             logger,
+	}
+}
+
+// RecordListingView publishes a view of a listing. userID is 0 for anonymous visitors;
+// sellers viewing their own listings are not counted.
+func (s *RecommendationService) RecordListingView(ctx context.Context, listing *model.MarketplaceItem, userID int) {
+	if userID != 0 && userID == listing.UserID {
+		return
+	}
+
+	s.viewEvents.Publish(ctx, client.Event{
+		EventType:  client.EventListingViewed,
+		UserID:     userID,
+		OwnerID:    listing.UserID,
+		EntityType: "listing",
+		EntityID:   listing.ID,
+		EntityName: listing.Name,
+	})
+}
+
+// RecordListingEvent stores a listing view or purchase consumed from Kafka. eventKey identifies
+// the Kafka message so redelivered events are only counted once. Other events are ignored.
+func (s *RecommendationService) RecordListingEvent(ctx context.Context, event *client.Event, eventKey string) error {
+	var eventType string
+	switch event.EventType {
+	case client.EventListingViewed:
+		eventType = model.ListingEventView
+	case client.EventStrategyPurchased:
+		eventType = model.ListingEventPurchase
+	default:
+		return nil
+	}
+
+	if event.EntityType != "listing" || event.EntityID == 0 {
+		return fmt.Errorf("%s event has no listing", event.EventType)
+	}
+
+	occurredAt, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		occurredAt = time.Now()
+	}
+
+	var userID *int
+	if event.UserID != 0 {
+		userID = &event.UserID
+	}
+
+	_, err = s.marketplaceRepo.RecordListingEvent(ctx, event.EntityID, userID, eventType, eventKey, occurredAt.UTC())
+	return err
+}
+
+// GetTrendingListings returns the active listings with the highest trending scores. Each view
+// and purchase in the configured window adds its weight, halved for every half-life of age.
+func (s *RecommendationService) GetTrendingListings(ctx context.Context, limit int) ([]model.MarketplaceItem, error) {
+	items, err := s.marketplaceRepo.GetTrendingListings(ctx, s.trendingParams(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.marketplaceService.enrichListings(ctx, items)
+
+	return items, nil
+}
+
+// GetRecommendedListings returns listings for a user, ranked by how well their tags match the
+// strategies the user bought or viewed. Users with no purchases or recent views get trending
+// listings that aren't their own instead.
+func (s *RecommendationService) GetRecommendedListings(ctx context.Context, userID int, limit int) ([]model.MarketplaceItem, error) {
+	items, err := s.marketplaceRepo.GetRecommendedListings(ctx, userID, s.trendingParams(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		trending, err := s.marketplaceRepo.GetTrendingListings(ctx, s.trendingParams(), limit+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range trending {
+			if item.UserID != userID && len(items) < limit {
+				items = append(items, item)
+			}
+		}
+	}
+
+	s.marketplaceService.enrichListings(ctx, items)
+
+	return items, nil
+}
+
+// trendingParams builds the trending score parameters from the configuration
+func (s *RecommendationService) trendingParams() model.TrendingParams {
+	return model.TrendingParams{
+		Since:          time.Now().Add(-s.cfg.Window),
+		HalfLifeHours:  s.cfg.HalfLife.Hours(),
+		ViewWeight:     s.cfg.ViewWeight,
+		PurchaseWeight: s.cfg.PurchaseWeight,
+	}
+}
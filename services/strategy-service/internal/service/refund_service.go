@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// PaymentProvider issues refunds of approved refund requests. Without one, refunds are only
+// recorded and paying the buyer back is left to the platform operators.
+type PaymentProvider interface {
+	Refund(ctx context.Context, refund *model.RefundRequest) (string, error)
+}
+
+// RefundService handles refund requests of purchases and their review
+type RefundService struct {
+	refundRepo         *repository.RefundRepository
+	purchaseRepo       *repository.PurchaseRepository
+	walletRepo         *repository.WalletRepository
+	payments           PaymentProvider
+	userClient         *client.UserClient
+	notificationClient *client.NotificationClient
+	logger             *zap.Logger
+}
+
+// NewRefundService creates a new refund service. payments may be nil.
+func NewRefundService(
+	refundRepo *repository.RefundRepository,
+	purchaseRepo *repository.PurchaseRepository,
+	walletRepo *repository.WalletRepository,
+	payments PaymentProvider,
+	userClient *client.UserClient,
+	notificationClient *client.NotificationClient,
+	logger *zap.Logger,
+) *RefundService {
+	return &RefundService{
+		refundRepo:         refundRepo,
+		purchaseRepo:       purchaseRepo,
+		walletRepo:         walletRepo,
+		payments:           payments,
+		userClient:         userClient,
+		notificationClient: notificationClient,
+		logger:             logger,
+	}
+}
+
+// RequestRefund records a refund request for a purchase of the buyer and notifies the seller
+func (s *RefundService) RequestRefund(ctx context.Context, purchaseID int, buyerID int, reason string) (*model.RefundRequest, error) {
+	purchase, err := s.purchaseRepo.GetPurchaseByID(ctx, purchaseID, buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if purchase == nil || purchase.BuyerID != buyerID {
+		return nil, errcode.ErrPurchaseNotFound
+	}
+
+	if purchase.Status == "refunded" {
+		return nil, errors.New("purchase is already refunded")
+	}
+
+	if purchase.RefundStatus != nil && *purchase.RefundStatus == model.RefundStatusPending {
+		return nil, errors.New("a refund request is already pending for this purchase")
+	}
+
+	refundID, err := s.refundRepo.RequestRefund(ctx, purchaseID, buyerID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := s.refundRepo.GetRefundRequestByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, client.NotificationEvent{
+		UserID:  refund.SellerID,
+		Type:    "refund_requested",
+		Title:   "Refund requested",
+		Message: fmt.Sprintf("A buyer requested a refund of %s.", refund.StrategyName),
+		Link:    "/marketplace/refunds",
+	})
+
+	return refund, nil
+}
+
+// GetRefundRequests retrieves refund requests. A nil sellerID returns those of every seller,
+// for moderators; an empty status those of every status.
+func (s *RefundService) GetRefundRequests(ctx context.Context, sellerID *int, status string, page, limit int) ([]model.RefundRequest, int, error) {
+	return s.refundRepo.GetRefundRequests(ctx, sellerID, status, page, limit)
+}
+
+// ReviewRefund approves or rejects a pending refund request. Sellers review requests for
+// their own sales; moderators review any. An approved refund is paid back through the
+// payment provider, when there is one, before the purchase is marked refunded and access
+// is revoked. The part of the purchase paid from the buyer's wallet goes back to the wallet
+// instead, along with the approval, and referral credit spent on it goes back to the buyer
+// once it is approved.
+func (s *RefundService) ReviewRefund(
+	ctx context.Context,
+	refundID int,
+	reviewerID int,
+	isModerator bool,
+	review *model.RefundReview,
+) (*model.RefundRequest, error) {
+	refund, err := s.refundRepo.GetRefundRequestByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund == nil {
+		return nil, errors.New("refund request not found")
+	}
+
+	if !isModerator && refund.SellerID != reviewerID {
+		return nil, errors.New("access denied: you can only review refunds of your own sales")
+	}
+
+	if refund.Status != model.RefundStatusPending {
+		return nil, errors.New("refund request has already been reviewed")
+	}
+
+	approve := review.Decision == "approve"
+
+	providerRefundID := ""
+	if approve && s.payments != nil {
+		walletAmount, err := s.walletRepo.GetPurchaseWalletAmount(ctx, refund.PurchaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The provider only pays back what the buyer didn't pay from their wallet
+		paid := *refund
+		paid.Amount = math.Round((refund.Amount-walletAmount)*100) / 100
+		if paid.Amount > 0 {
+			providerRefundID, err = s.payments.Refund(ctx, &paid)
+			if err != nil {
+				return nil, errcode.ErrPaymentFailed.Wrap(err)
+			}
+		}
+	}
+
+	reviewed, err := s.refundRepo.ReviewRefundRequest(ctx, refundID, reviewerID, approve, review.Note, providerRefundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reviewed {
+		if providerRefundID != "" {
+			s.logger.Error("Refund paid by the payment provider but the request was reviewed concurrently",
+				zap.Int("refund_id", refundID),
+				zap.String("provider_refund_id", providerRefundID))
+		}
+		return nil, errors.New("refund request has already been reviewed")
+	}
+
+	if approve {
+		s.reverseReferralCredit(ctx, refund.PurchaseID, refund.BuyerID)
+	}
+
+	refund, err = s.refundRepo.GetRefundRequestByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := client.NotificationEvent{
+		UserID:  refund.BuyerID,
+		Type:    "refund_rejected",
+		Title:   "Refund request declined",
+		Message: fmt.Sprintf("Your refund request for %s was declined.", refund.StrategyName),
+		Link:    fmt.Sprintf("/marketplace/purchases/%d", refund.PurchaseID),
+	}
+	if approve {
+		notification.Type = "refund_approved"
+		notification.Title = "Refund approved"
+		notification.Message = fmt.Sprintf("Your refund of %.2f %s for %s was approved.", refund.Amount, refund.Currency, refund.StrategyName)
+	}
+	s.notify(ctx, notification)
+
+	return refund, nil
+}
+
+// reverseReferralCredit gives back the referral credit spent on a refunded purchase. The
+// refund is already approved, so failures are logged for the platform operators rather
+// than returned, and the credit is given back even if the request was cancelled.
+func (s *RefundService) reverseReferralCredit(ctx context.Context, purchaseID int, buyerID int) {
+	redemptionID, err := s.purchaseRepo.GetPurchaseReferralRedemption(ctx, purchaseID)
+	if err != nil || redemptionID == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if err := s.userClient.ReverseReferralRedemption(ctx, *redemptionID); err != nil {
+		s.logger.Error("Failed to give back referral credit of a refunded purchase", zap.Error(err),
+			zap.Int("purchase_id", purchaseID),
+			zap.Int("redemption_id", *redemptionID),
+			zap.Int("user_id", buyerID))
+	}
+}
+
+// notify sends a notification, logging rather than failing when it can't be sent
+func (s *RefundService) notify(ctx context.Context, event client.NotificationEvent) {
+	if err := s.notificationClient.Send(ctx, event); err != nil {
+		s.logger.Error("Failed to send refund notification", zap.Error(err), zap.String("type", event.Type))
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ReportService handles reports of listings and reviews and their moderation
+type ReportService struct {
+	reportRepo         *repository.ReportRepository
+	notificationClient *client.NotificationClient
+	logger             *zap.Logger
+}
+
+// NewReportService creates a new report service
+func NewReportService(
+	reportRepo *repository.ReportRepository,
+	notificationClient *client.NotificationClient,
+	logger *zap.Logger,
+) *ReportService {
+	return &ReportService{
+		reportRepo:         reportRepo,
+		notificationClient: notificationClient,
+		logger:             logger,
+	}
+}
+
+// ReportListing reports a marketplace listing
+func (s *ReportService) ReportListing(ctx context.Context, marketplaceID int, reporterID int, report *model.ReportCreate) (*model.ContentReport, error) {
+	return s.createReport(ctx, model.ReportTargetListing, marketplaceID, reporterID, report)
+}
+
+// ReportReview reports a review of a marketplace listing
+func (s *ReportService) ReportReview(ctx context.Context, reviewID int, reporterID int, report *model.ReportCreate) (*model.ContentReport, error) {
+	return s.createReport(ctx, model.ReportTargetReview, reviewID, reporterID, report)
+}
+
+func (s *ReportService) createReport(
+	ctx context.Context,
+	targetType string,
+	targetID int,
+	reporterID int,
+	report *model.ReportCreate,
+) (*model.ContentReport, error) {
+	reportID, err := s.reportRepo.CreateReport(ctx, targetType, targetID, reporterID, report)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reportRepo.GetReportByID(ctx, reportID)
+}
+
+// GetReports retrieves the moderation queue. Empty filters match every status or target type.
+func (s *ReportService) GetReports(ctx context.Context, status, targetType string, page, limit int) ([]model.ContentReport, int, error) {
+	return s.reportRepo.GetReports(ctx, status, targetType, page, limit)
+}
+
+// GetReport retrieves a report
+func (s *ReportService) GetReport(ctx context.Context, reportID int) (*model.ContentReport, error) {
+	report, err := s.reportRepo.GetReportByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if report == nil {
+		return nil, errors.New("report not found")
+	}
+
+	return report, nil
+}
+
+// ResolveReport resolves an open report, and every other open report of the same target,
+// by dismissing it or taking action on the target. The reporters and, unless the report is
+// dismissed, the owner of the target are notified.
+func (s *ReportService) ResolveReport(
+	ctx context.Context,
+	reportID int,
+	resolverID int,
+	resolution *model.ReportResolution,
+) (*model.ContentReport, error) {
+	report, err := s.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Status != model.ReportStatusOpen {
+		return nil, errors.New("report has already been resolved")
+	}
+
+	switch {
+	case resolution.Action == model.ReportActionHideListing && report.TargetType != model.ReportTargetListing:
+		return nil, errors.New("invalid action: only listings can be hidden")
+	case resolution.Action == model.ReportActionRemoveReview && report.TargetType != model.ReportTargetReview:
+		return nil, errors.New("invalid action: only reviews can be removed")
+	}
+
+	reporterIDs, err := s.reportRepo.ResolveReport(ctx, reportID, resolverID, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reporterIDs) == 0 {
+		return nil, errors.New("report has already been resolved")
+	}
+
+	outcome := "We reviewed it and took action."
+	if resolution.Action == model.ReportActionDismiss {
+		outcome = "We reviewed it and found no violation of our guidelines."
+	}
+	for _, reporterID := range reporterIDs {
+		s.notify(ctx, client.NotificationEvent{
+			UserID:  reporterID,
+			Type:    "report_resolved",
+			Title:   "Your report was reviewed",
+			Message: fmt.Sprintf("Thank you for reporting a %s. %s", report.TargetType, outcome),
+			Link:    fmt.Sprintf("/marketplace/%d", report.MarketplaceID),
+		})
+	}
+
+	if notification, ok := targetNotification(report, resolution); ok {
+		s.notify(ctx, notification)
+	}
+
+	return s.reportRepo.GetReportByID(ctx, reportID)
+}
+
+// targetNotification returns the notification telling the owner of a reported listing or
+// review about the action taken on it, if any
+func targetNotification(report *model.ContentReport, resolution *model.ReportResolution) (client.NotificationEvent, bool) {
+	notification := client.NotificationEvent{
+		UserID: report.TargetUserID,
+		Link:   fmt.Sprintf("/marketplace/%d", report.MarketplaceID),
+	}
+
+	switch resolution.Action {
+	case model.ReportActionHideListing:
+		notification.Type = "listing_hidden"
+		notification.Title = "Your listing was hidden"
+		notification.Message = "Your marketplace listing was hidden by a moderator after it was reported."
+	case model.ReportActionRemoveReview:
+		notification.Type = "review_removed"
+		notification.Title = "Your review was removed"
+		notification.Message = "Your review was removed by a moderator after it was reported."
+	case model.ReportActionWarnUser:
+		notification.Type = "moderation_warning"
+		notification.Title = "Warning from the moderators"
+		notification.Message = fmt.Sprintf("Your %s was reported and breaks our guidelines. Repeated violations may lead to your content being removed.", report.TargetType)
+	default:
+		return notification, false
+	}
+
+	if resolution.Note != "" {
+		notification.Message += " " + resolution.Note
+	}
+
+	return notification, true
+}
+
+// notify sends a notification, logging rather than failing when it can't be sent
+func (s *ReportService) notify(ctx context.Context, event client.NotificationEvent) {
+	if err := s.notificationClient.Send(ctx, event); err != nil {
+		s.logger.Error("Failed to send report notification", zap.Error(err), zap.String("type", event.Type))
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Weights of the risk factors in a risk score. Factors that can't be assessed, such as
+// drawdown without verified backtests, are left out and the others reweighted.
+var riskWeights = map[string]float64{
+	model.RiskFactorDrawdown:       0.35,
+	model.RiskFactorLeverage:       0.20,
+	model.RiskFactorTradeFrequency: 0.20,
+	model.RiskFactorExitRules:      0.15,
+	model.RiskFactorPositionSizing: 0.10,
+}
+
+// positionSizingRisk scores the position sizing strategies of the backtesting engine: fixed
+// sizing uses 10% of equity, risk-based and volatility-targeted sizing are bounded by the
+// stop loss or volatility, percentage sizing is only bounded by the configured percentage
+// and Kelly sizing grows positions after winning streaks
+var positionSizingRisk = map[string]int{
+	"fixed":             20,
+	"risk_based":        40,
+	"volatility_target": 40,
+	"percentage":        60,
+	"fixed_fractional":  60,
+	"kelly":             80,
+}
+
+// RiskService computes and stores the risk scores of strategy versions
+type RiskService struct {
+	riskRepo     *repository.RiskRepository
+	strategyRepo *repository.StrategyRepository
+	logger       *zap.Logger
+}
+
+// NewRiskService creates a new risk service
+func NewRiskService(riskRepo *repository.RiskRepository, strategyRepo *repository.StrategyRepository, logger *zap.Logger) *RiskService {
+	return &RiskService{
+		riskRepo:     riskRepo,
+		strategyRepo: strategyRepo,
+		logger:       logger,
+	}
+}
+
+// GetRiskScore retrieves the risk score of a strategy version the user can access,
+// computing it first if it never was
+func (s *RiskService) GetRiskScore(ctx context.Context, strategyID int, userID int) (*model.StrategyRiskScore, error) {
+	strategy, err := s.strategyRepo.GetStrategyByIDWithAccess(ctx, strategyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	score, err := s.riskRepo.GetRiskScore(ctx, strategy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if score != nil {
+		return score, nil
+	}
+
+	return s.ComputeRiskScore(ctx, strategy)
+}
+
+// ComputeRiskScore computes the risk score of a strategy version from its verified
+// backtests and structure, and stores it
+func (s *RiskService) ComputeRiskScore(ctx context.Context, strategy *model.Strategy) (*model.StrategyRiskScore, error) {
+	backtests, err := s.riskRepo.GetVerifiedBacktests(ctx, strategy.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	components := backtestRiskComponents(backtests)
+	components = append(components, structureRiskComponents(strategy.Structure, len(backtests) > 0)...)
+
+	total, weights := 0.0, 0.0
+	for i := range components {
+		components[i].Weight = riskWeights[components[i].Factor]
+		total += float64(components[i].Score) * components[i].Weight
+		weights += components[i].Weight
+	}
+
+	value := 0
+	if weights > 0 {
+		value = int(math.Round(total / weights))
+	}
+
+	componentsJSON, err := json.Marshal(components)
+	if err != nil {
+		return nil, err
+	}
+
+	score := &model.StrategyRiskScore{
+		StrategyID:    strategy.ID,
+		Score:         value,
+		Level:         riskLevel(value),
+		Components:    componentsJSON,
+		BacktestCount: len(backtests),
+	}
+	if err := s.riskRepo.SaveRiskScore(ctx, score); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Computed strategy risk score",
+		zap.Int("strategyID", strategy.ID),
+		zap.Int("score", value),
+		zap.Int("backtests", len(backtests)))
+
+	return s.riskRepo.GetRiskScore(ctx, strategy.ID)
+}
+
+// RecomputeListingRiskScore recomputes the risk score of the strategy version a listing
+// sells. The listing change that triggers it has already happened, so errors are logged
+// rather than returned.
+func (s *RiskService) RecomputeListingRiskScore(ctx context.Context, marketplaceID int) {
+	strategyID, err := s.riskRepo.GetListingStrategyID(ctx, marketplaceID)
+	if err != nil || strategyID == 0 {
+		return
+	}
+
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
+	if err != nil || strategy == nil {
+		return
+	}
+
+	if _, err := s.ComputeRiskScore(ctx, strategy); err != nil {
+		s.logger.Warn("Failed to recompute listing risk score",
+			zap.Error(err),
+			zap.Int("marketplaceID", marketplaceID),
+			zap.Int("strategyID", strategyID))
+	}
+}
+
+// GetListingRiskScores returns the risk scores of the strategy versions the given listings
+// sell, by listing ID
+func (s *RiskService) GetListingRiskScores(ctx context.Context, marketplaceIDs []int) (map[int]*model.StrategyRiskScore, error) {
+	return s.riskRepo.GetListingRiskScores(ctx, marketplaceIDs)
+}
+
+// backtestRiskComponents scores drawdown, leverage, position sizing and trade frequency
+// from verified backtests, taking the riskiest backtest for each
+func backtestRiskComponents(backtests []model.VerifiedBacktest) []model.RiskComponent {
+	if len(backtests) == 0 {
+		return nil
+	}
+
+	drawdown, leverage, tradesPerDay := 0.0, 1.0, 0.0
+	sizing, sizingRisk := "", -1
+	for _, backtest := range backtests {
+		drawdown = math.Max(drawdown, backtest.MaxDrawdown)
+		leverage = math.Max(leverage, backtest.Leverage)
+
+		if risk, ok := positionSizingRisk[backtest.PositionSizing]; ok && risk > sizingRisk {
+			sizing, sizingRisk = backtest.PositionSizing, risk
+		}
+
+		var runs []model.VerifiedBacktestRun
+		_ = json.Unmarshal(backtest.Runs, &runs)
+		days := backtest.EndDate.Sub(backtest.StartDate).Hours() / 24
+		if days > 0 && len(runs) > 0 {
+			tradesPerDay = math.Max(tradesPerDay, float64(backtest.TotalTrades)/float64(len(runs))/days)
+		}
+	}
+
+	components := []model.RiskComponent{
+		{
+			Factor: model.RiskFactorDrawdown,
+			Score:  clampRisk(drawdown * 2), // 50% drawdown or worse is the highest risk
+			Detail: fmt.Sprintf("Worst max drawdown %.1f%%", drawdown),
+		},
+		{
+			Factor: model.RiskFactorLeverage,
+			Score:  clampRisk((leverage - 1) / 9 * 100), // 10x or more is the highest risk
+			Detail: fmt.Sprintf("Up to %gx leverage", leverage),
+		},
+		{
+			Factor: model.RiskFactorTradeFrequency,
+			Score:  clampRisk(tradesPerDay / 5 * 100), // 5 trades a day per symbol or more
+			Detail: fmt.Sprintf("%.2f trades per day per symbol", tradesPerDay),
+		},
+	}
+	if sizingRisk >= 0 {
+		components = append(components, model.RiskComponent{
+			Factor: model.RiskFactorPositionSizing,
+			Score:  sizingRisk,
+			Detail: fmt.Sprintf("%s position sizing", strings.ReplaceAll(sizing, "_", "-")),
+		})
+	}
+
+	return components
+}
+
+// structureRiskComponents scores a strategy structure: whether it has exit rules and,
+// when no backtest tells the actual trade frequency, how short its indicator periods are
+func structureRiskComponents(data json.RawMessage, backtested bool) []model.RiskComponent {
+	var structure map[string]interface{}
+	if err := json.Unmarshal(data, &structure); err != nil {
+		return nil
+	}
+
+	hasExits := false
+	switch rules := structure["sellRules"].(type) {
+	case map[string]interface{}:
+		hasExits = len(rules) > 0
+	case []interface{}:
+		hasExits = len(rules) > 0
+	}
+
+	exits := model.RiskComponent{Factor: model.RiskFactorExitRules, Detail: "Has sell rules"}
+	if !hasExits {
+		exits.Score = 100
+		exits.Detail = "No sell rules, positions are only closed at the end"
+	}
+	components := []model.RiskComponent{exits}
+
+	if backtested {
+		return components
+	}
+
+	var refs []map[string]interface{}
+	collectIndicatorRefs(structure, &refs)
+
+	shortest := 0.0
+	for _, ref := range refs {
+		settings, _ := ref["indicatorSettings"].(map[string]interface{})
+		for key, value := range settings {
+			period, ok := value.(float64)
+			if !ok || period <= 0 || !strings.Contains(strings.ToLower(key), "period") {
+				continue
+			}
+			if shortest == 0 || period < shortest {
+				shortest = period
+			}
+		}
+	}
+
+	if shortest > 0 {
+		components = append(components, model.RiskComponent{
+			Factor: model.RiskFactorTradeFrequency,
+			Score:  clampRisk((30 - shortest) / 28 * 100), // Periods of 2 or less signal most often
+			Detail: fmt.Sprintf("Shortest indicator period %g", shortest),
+		})
+	}
+
+	return components
+}
+
+// clampRisk rounds a risk factor score into 0-100
+func clampRisk(value float64) int {
+	return int(math.Round(math.Max(0, math.Min(100, value))))
+}
+
+// riskLevel returns the risk level of a score
+func riskLevel(score int) string {
+	switch {
+	case score < 34:
+		return model.RiskLevelLow
+	case score < 67:
+		return model.RiskLevelMedium
+	default:
+		return model.RiskLevelHigh
+	}
+}
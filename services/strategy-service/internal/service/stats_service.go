@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// statsWindows are the time windows admin statistics can be requested for
+var statsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// IsValidStatsWindow reports whether window is a supported statistics window
+func IsValidStatsWindow(window string) bool {
+	_, ok := statsWindows[window]
+	return ok
+}
+
+// strategyStatsEntry is a cached statistics result
+type strategyStatsEntry struct {
+	stats     *model.StrategyStats
+	expiresAt time.Time
+}
+
+// StatsService computes admin dashboard statistics, caching results briefly since the
+// aggregation queries scan whole tables
+type StatsService struct {
+	statsRepo          *repository.StatsRepository
+	platformFeePercent float64
+	cacheTTL           time.Duration
+	logger             *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]strategyStatsEntry
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(
+	statsRepo *repository.StatsRepository,
+	platformFeePercent float64,
+	cacheTTL time.Duration,
+	logger *zap.Logger,
+) *StatsService {
+	return &StatsService{
+		statsRepo:          statsRepo,
+		platformFeePercent: platformFeePercent,
+		cacheTTL:           cacheTTL,
+		logger:             logger,
+		cache:              make(map[string]strategyStatsEntry),
+	}
+}
+
+// GetStrategyStats returns strategy and marketplace KPIs for a window such as "7d"
+func (s *StatsService) GetStrategyStats(ctx context.Context, window string) (*model.StrategyStats, error) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	entry, ok := s.cache[window]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.stats, nil
+	}
+
+	from := now.Add(-statsWindows[window])
+	stats, err := s.statsRepo.GetStrategyStats(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Window = window
+	stats.From = from
+	stats.To = now
+	stats.PlatformRevenue = math.Round(stats.GMV*s.platformFeePercent) / 100
+	stats.GeneratedAt = now
+
+	if s.cacheTTL > 0 {
+		s.mu.Lock()
+		s.cache[window] = strategyStatsEntry{stats: stats, expiresAt: now.Add(s.cacheTTL)}
+		s.mu.Unlock()
+	}
+
+	return stats, nil
+}
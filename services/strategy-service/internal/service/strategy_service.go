@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"net/http"
+	"regexp"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
 	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/lint"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/repository"
 
@@ -15,14 +20,31 @@ import (
 	"go.uber.org/zap"
 )
 
+// strategySortFields are the fields strategies can be sorted by
+var strategySortFields = pagination.SortFields{"name", "created_at", "updated_at", "version"}
+
+// versionSortFields are the fields the versions of a strategy can be sorted by
+var versionSortFields = pagination.SortFields{"version", "created_at"}
+
+// indicatorTimeframePattern matches the timeframe of an indicator, a count and a unit
+var indicatorTimeframePattern = regexp.MustCompile(`^[1-9][0-9]*[mhdw]$`)
+
+// maxIndicatorTimeframes is how many distinct timeframes a strategy's indicators may use
+const maxIndicatorTimeframes = 3
+
 // StrategyService handles strategy operations
 type StrategyService struct {
 	db               *sqlx.DB
 	strategyRepo     *repository.StrategyRepository
 	versionRepo      *repository.VersionRepository
 	tagRepo          *repository.TagRepository
+	shareRepo        *repository.ShareRepository
+	indicatorRepo    *repository.IndicatorRepository
+	presetRepo       *repository.IndicatorPresetRepository
 	userClient       *client.UserClient
 	historicalClient *client.HistoricalClient
+	events           *client.EventClient
+	linter           *lint.Linter
 	logger           *zap.Logger
 }
 
@@ -32,8 +54,13 @@ func NewStrategyService(
 	strategyRepo *repository.StrategyRepository,
 	versionRepo *repository.VersionRepository,
 	tagRepo *repository.TagRepository,
+	shareRepo *repository.ShareRepository,
+	indicatorRepo *repository.IndicatorRepository,
+	presetRepo *repository.IndicatorPresetRepository,
 	userClient *client.UserClient,
 	historicalClient *client.HistoricalClient,
+	events *client.EventClient,
+	linter *lint.Linter,
 	logger *zap.Logger,
 ) *StrategyService {
 	return &StrategyService{
@@ -41,8 +68,13 @@ func NewStrategyService(
 		strategyRepo:     strategyRepo,
 		versionRepo:      versionRepo,
 		tagRepo:          tagRepo,
+		shareRepo:        shareRepo,
+		indicatorRepo:    indicatorRepo,
+		presetRepo:       presetRepo,
 		userClient:       userClient,
 		historicalClient: historicalClient,
+		events:           events,
+		linter:           linter,
 		logger:           logger,
 	}
 }
@@ -67,25 +99,11 @@ func (s *StrategyService) GetAllStrategies(
 	}
 
 	// Calculate offset
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
 
 	// Validate sort parameters
-	validSortFields := map[string]bool{
-		"name":       true,
-		"created_at": true,
-		"updated_at": true,
-		"version":    true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "created_at" // Default sort by creation date
-	}
-
-	// Normalize sort direction
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "DESC" // Default to descending (newest first)
-	}
+	sortBy = strategySortFields.Normalize(sortBy, "created_at")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
 
 	// Get strategies using repository
 	strategies, totalCount, err := s.strategyRepo.GetAllStrategies(
@@ -146,6 +164,14 @@ func (s *StrategyService) GetStrategyByID(ctx context.Context, strategyID int, u
 		return nil, errors.New("strategy not found or you don't have access to it")
 	}
 
+	// Tell the client how the user can access it (owner, purchased, shared_view, ...)
+	accessLevel, err := s.shareRepo.GetAccessLevel(ctx, strategy.ID, userID)
+	if err != nil {
+		s.logger.Warn("Failed to resolve strategy access level", zap.Error(err), zap.Int("strategy_id", strategy.ID))
+	} else {
+		strategy.AccessType = accessLevel
+	}
+
 	// Try to get username
 	owner, err := s.userClient.GetUserByID(ctx, strategy.UserID)
 	if err == nil {
@@ -157,6 +183,57 @@ func (s *StrategyService) GetStrategyByID(ctx context.Context, strategyID int, u
 	return strategy, nil
 }
 
+// LintStrategy checks the structure of a strategy the user can access for non-fatal
+// problems, such as unused or deprecated indicators or rules that can never match
+func (s *StrategyService) LintStrategy(ctx context.Context, strategyID int, userID int) (*model.StrategyLintResult, error) {
+	strategy, err := s.strategyRepo.GetStrategyByIDWithAccess(ctx, strategyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	warnings, err := s.linter.Lint(strategy.Structure)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, s.deprecationWarnings(ctx, strategy.Structure)...)
+
+	return &model.StrategyLintResult{
+		StrategyID: strategy.ID,
+		Version:    strategy.Version,
+		Warnings:   warnings,
+	}, nil
+}
+
+// deprecationWarnings warns about the deprecated indicators a structure uses. Failing to
+// check only loses the warnings, so errors are logged rather than returned.
+func (s *StrategyService) deprecationWarnings(ctx context.Context, structure json.RawMessage) []model.LintWarning {
+	deprecations, err := s.indicatorRepo.GetIndicatorDeprecations(ctx, nil)
+	if err != nil {
+		s.logger.Warn("Failed to check for deprecated indicators", zap.Error(err))
+		return nil
+	}
+	if len(deprecations) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]model.IndicatorDeprecation, len(deprecations))
+	for _, deprecation := range deprecations {
+		byName[deprecation.IndicatorName] = deprecation
+	}
+
+	warnings, err := lint.NewLinter(lint.DeprecatedIndicators{Deprecations: byName}).Lint(structure)
+	if err != nil {
+		s.logger.Warn("Failed to check for deprecated indicators", zap.Error(err))
+		return nil
+	}
+
+	return warnings
+}
+
 // validateStrategyData validates strategy data before creating or updating
 func (s *StrategyService) validateStrategyData(data json.RawMessage) error {
 	if len(data) == 0 {
@@ -169,9 +246,196 @@ func (s *StrategyService) validateStrategyData(data json.RawMessage) error {
 		return fmt.Errorf("invalid strategy structure JSON: %w", err)
 	}
 
+	return validateIndicatorTimeframes(strategyData)
+}
+
+// validateIndicatorTimeframes checks the timeframes indicators are computed on. Indicators
+// without one use the timeframe the strategy runs on; the others need candles of their own,
+// so a strategy may use only a few.
+func validateIndicatorTimeframes(structure map[string]interface{}) error {
+	var refs []map[string]interface{}
+	collectIndicatorRefs(structure, &refs)
+
+	timeframes := make(map[string]bool)
+	for _, ref := range refs {
+		value, ok := ref["timeframe"]
+		if !ok {
+			continue
+		}
+		timeframe, ok := value.(string)
+		if !ok || !indicatorTimeframePattern.MatchString(timeframe) {
+			return fmt.Errorf("invalid indicator timeframe %v: expected a count and a unit (m, h, d or w), e.g. 1h", value)
+		}
+		timeframes[timeframe] = true
+	}
+
+	if len(timeframes) > maxIndicatorTimeframes {
+		return fmt.Errorf("invalid strategy structure: indicators use %d timeframes, at most %d are allowed",
+			len(timeframes), maxIndicatorTimeframes)
+	}
+
+	return nil
+}
+
+// resolveCustomIndicators embeds the formula and parameters of every custom indicator used in
+// a strategy structure, so the backtesting engine can compute it alongside platform indicators.
+// Indicators are referenced by name; a formula in the structure for a name the user can't use
+// (another user's private indicator, or one that no longer exists) is rejected.
+func (s *StrategyService) resolveCustomIndicators(ctx context.Context, userID int, data json.RawMessage) (json.RawMessage, error) {
+	var structure interface{}
+	if err := json.Unmarshal(data, &structure); err != nil {
+		return nil, fmt.Errorf("invalid strategy structure JSON: %w", err)
+	}
+
+	var refs []map[string]interface{}
+	collectIndicatorRefs(structure, &refs)
+	if len(refs) == 0 {
+		return data, nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if name, ok := ref["name"].(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+
+	custom, err := s.indicatorRepo.GetUsableCustomIndicators(ctx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]model.TechnicalIndicator, len(custom))
+	for _, indicator := range custom {
+		byName[indicator.Name] = indicator
+	}
+
+	for _, ref := range refs {
+		name, _ := ref["name"].(string)
+		indicator, ok := byName[name]
+		if !ok {
+			if _, hasFormula := ref["formula"]; hasFormula {
+				return nil, fmt.Errorf("custom indicator %q not found", name)
+			}
+			continue
+		}
+
+		params := make([]map[string]string, 0, len(indicator.Parameters))
+		for _, param := range indicator.Parameters {
+			params = append(params, map[string]string{"name": param.ParameterName, "default": param.DefaultValue})
+		}
+
+		ref["customIndicatorId"] = indicator.ID
+		ref["formula"] = indicator.Formula
+		ref["parameters"] = params
+	}
+
+	return json.Marshal(structure)
+}
+
+// collectIndicatorRefs finds the indicator objects of every rule in a strategy structure
+func collectIndicatorRefs(node interface{}, refs *[]map[string]interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if indicator, ok := child.(map[string]interface{}); ok && key == "indicator" {
+				*refs = append(*refs, indicator)
+				continue
+			}
+			collectIndicatorRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range value {
+			collectIndicatorRefs(child, refs)
+		}
+	}
+}
+
+// ResolveIndicatorPresets replaces the preset references of a strategy's structure with
+// the preset's settings, so the backtesting engine gets plain indicator settings. Settings
+// given in the structure override the preset's. Presets are resolved for the strategy's
+// owner, whose presets the structure may use.
+func (s *StrategyService) ResolveIndicatorPresets(ctx context.Context, strategy *model.Strategy) error {
+	structure, err := s.resolveIndicatorPresets(ctx, strategy.UserID, strategy.Structure, true)
+	if err != nil {
+		return err
+	}
+
+	strategy.Structure = structure
 	return nil
 }
 
+// resolveIndicatorPresets checks that every preset a strategy structure references
+// ("presetId" in an indicator) is a global or the user's own preset of that indicator.
+// With merge set, the preset's settings are also merged under the indicator's settings.
+func (s *StrategyService) resolveIndicatorPresets(ctx context.Context, userID int, data json.RawMessage, merge bool) (json.RawMessage, error) {
+	var structure interface{}
+	if err := json.Unmarshal(data, &structure); err != nil {
+		return nil, fmt.Errorf("invalid strategy structure JSON: %w", err)
+	}
+
+	var refs []map[string]interface{}
+	collectIndicatorRefs(structure, &refs)
+
+	ids := []int{}
+	for _, ref := range refs {
+		value, ok := ref["presetId"]
+		if !ok {
+			continue
+		}
+		id, ok := value.(float64)
+		if !ok || id != float64(int(id)) {
+			return nil, errcode.ErrInvalidIndicatorPreset.WithMessage("presetId must be a preset ID")
+		}
+		ids = append(ids, int(id))
+	}
+	if len(ids) == 0 {
+		return data, nil
+	}
+
+	presets, err := s.presetRepo.GetUsablePresets(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]model.IndicatorPreset, len(presets))
+	for _, preset := range presets {
+		byID[preset.ID] = preset
+	}
+
+	for _, ref := range refs {
+		value, ok := ref["presetId"].(float64)
+		if !ok {
+			continue
+		}
+		preset, ok := byID[int(value)]
+		if !ok {
+			return nil, errcode.ErrInvalidIndicatorPreset.WithMessage(fmt.Sprintf("Indicator preset %d not found", int(value)))
+		}
+		if name, _ := ref["name"].(string); name != preset.IndicatorName {
+			return nil, errcode.ErrInvalidIndicatorPreset.WithMessage(
+				fmt.Sprintf("Indicator preset %d is a preset of %s, not %s", preset.ID, preset.IndicatorName, name))
+		}
+		if !merge {
+			continue
+		}
+
+		settings := map[string]interface{}{}
+		if err := json.Unmarshal(preset.Settings, &settings); err != nil {
+			return nil, fmt.Errorf("invalid settings of indicator preset %d: %w", preset.ID, err)
+		}
+		if explicit, ok := ref["indicatorSettings"].(map[string]interface{}); ok {
+			for key, setting := range explicit {
+				settings[key] = setting
+			}
+		}
+		ref["indicatorSettings"] = settings
+	}
+
+	if !merge {
+		return data, nil
+	}
+	return json.Marshal(structure)
+}
+
 // CreateStrategy creates a new strategy
 func (s *StrategyService) CreateStrategy(ctx context.Context, strategy *model.StrategyCreate, userID int) (*model.Strategy, error) {
 	// Validate strategy data
@@ -179,6 +443,16 @@ func (s *StrategyService) CreateStrategy(ctx context.Context, strategy *model.St
 		return nil, err
 	}
 
+	structure, err := s.resolveCustomIndicators(ctx, userID, strategy.Structure)
+	if err != nil {
+		return nil, err
+	}
+	strategy.Structure = structure
+
+	if _, err := s.resolveIndicatorPresets(ctx, userID, strategy.Structure, false); err != nil {
+		return nil, err
+	}
+
 	// Validate tag IDs if provided
 	if len(strategy.TagIDs) > 0 {
 		// Verify all tag IDs exist
@@ -213,10 +487,15 @@ func (s *StrategyService) CreateStrategy(ctx context.Context, strategy *model.St
 		createdStrategy.Username = fmt.Sprintf("User %d", userID)
 	}
 
+	createdStrategy.Warnings = s.deprecationWarnings(ctx, createdStrategy.Structure)
+
+	s.publishStrategyEvent(ctx, client.EventStrategyCreated, createdStrategy)
+
 	return createdStrategy, nil
 }
 
-// UpdateStrategy updates a strategy by creating a new version
+// UpdateStrategy updates a strategy by creating a new version. Its owner and collaborators
+// with the editor role can update it.
 func (s *StrategyService) UpdateStrategy(ctx context.Context, strategyID int, userID int, update *model.StrategyUpdate) (*model.Strategy, error) {
 	// Validate strategy data
 	if err := s.validateStrategyData(update.Structure); err != nil {
@@ -230,11 +509,30 @@ func (s *StrategyService) UpdateStrategy(ctx context.Context, strategyID int, us
 	}
 
 	if strategy == nil {
-		return nil, errors.New("strategy not found")
+		return nil, errcode.ErrStrategyNotFound
 	}
 
+	// Collaborators with the editor role can update it too
 	if strategy.UserID != userID {
-		return nil, errors.New("you don't have permission to update this strategy")
+		accessLevel, err := s.shareRepo.GetAccessLevel(ctx, strategy.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if accessLevel != model.CollaboratorRoleEditor {
+			return nil, errors.New("you don't have permission to update this strategy")
+		}
+	}
+
+	// Indicators and presets are resolved for the owner, whoever edits the strategy, so
+	// the owner can keep running it
+	structure, err := s.resolveCustomIndicators(ctx, strategy.UserID, update.Structure)
+	if err != nil {
+		return nil, err
+	}
+	update.Structure = structure
+
+	if _, err := s.resolveIndicatorPresets(ctx, strategy.UserID, update.Structure, false); err != nil {
+		return nil, err
 	}
 
 	// Validate tag IDs if provided
@@ -264,13 +562,18 @@ func (s *StrategyService) UpdateStrategy(ctx context.Context, strategyID int, us
 	}
 
 	// Try to get username
-	owner, err := s.userClient.GetUserByID(ctx, userID)
+	owner, err := s.userClient.GetUserByID(ctx, updatedStrategy.UserID)
 	if err == nil {
 		updatedStrategy.Username = owner
 	} else {
-		updatedStrategy.Username = fmt.Sprintf("User %d", userID)
+		updatedStrategy.Username = fmt.Sprintf("User %d", updatedStrategy.UserID)
 	}
 
+	updatedStrategy.Warnings = s.deprecationWarnings(ctx, updatedStrategy.Structure)
+
+	s.publishStrategyEvent(ctx, client.EventStrategyUpdated, updatedStrategy)
+	s.publishVersionToBuyers(ctx, updatedStrategy)
+
 	return updatedStrategy, nil
 }
 
@@ -283,14 +586,102 @@ func (s *StrategyService) DeleteStrategy(ctx context.Context, strategyID int, us
 	}
 
 	if strategy == nil {
-		return errors.New("strategy not found")
+		return errcode.ErrStrategyNotFound
 	}
 
 	if strategy.UserID != userID {
 		return errors.New("you don't have permission to delete this strategy")
 	}
 
-	return s.strategyRepo.DeleteStrategy(ctx, strategyID, userID)
+	if err := s.strategyRepo.DeleteStrategy(ctx, strategyID, userID); err != nil {
+		return err
+	}
+
+	s.publishStrategyEvent(ctx, client.EventStrategyDeleted, strategy)
+
+	return nil
+}
+
+// BulkUpdateTags adds tags to and removes tags from many strategies of a user at once.
+// Strategies the user doesn't own are reported per item and left unchanged.
+func (s *StrategyService) BulkUpdateTags(ctx context.Context, userID int, update *model.StrategyTagsBulkUpdate) (*model.StrategyTagsBulkResult, error) {
+	if len(update.AddTagIDs) == 0 && len(update.RemoveTagIDs) == 0 {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "At least one tag to add or remove is required")
+	}
+
+	adding := make(map[int]bool, len(update.AddTagIDs))
+	for _, tagID := range update.AddTagIDs {
+		adding[tagID] = true
+	}
+	for _, tagID := range update.RemoveTagIDs {
+		if adding[tagID] {
+			return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("Tag %d can't be both added and removed", tagID))
+		}
+	}
+
+	items, err := s.strategyRepo.BulkUpdateStrategyTags(ctx, userID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.StrategyTagsBulkResult{
+		Requested: len(items),
+		Items:     items,
+	}
+	for _, item := range items {
+		switch item.Status {
+		case "updated":
+			result.Updated++
+		case "unchanged":
+			result.Unchanged++
+		default:
+			result.Failed++
+		}
+	}
+
+	s.logger.Info("Bulk updated strategy tags",
+		zap.Int("user_id", userID),
+		zap.Int("requested", result.Requested),
+		zap.Int("updated", result.Updated),
+		zap.Int("failed", result.Failed))
+
+	return result, nil
+}
+
+// publishStrategyEvent publishes a change to one of the owner's strategies
+func (s *StrategyService) publishStrategyEvent(ctx context.Context, eventType string, strategy *model.Strategy) {
+	s.events.Publish(ctx, client.Event{
+		EventType:  eventType,
+		UserID:     strategy.UserID,
+		EntityType: "strategy",
+		EntityID:   strategy.ID,
+		EntityName: strategy.Name,
+	})
+}
+
+// publishVersionToBuyers publishes a new version of a strategy that was bought, so buyers
+// following the owner are notified
+func (s *StrategyService) publishVersionToBuyers(ctx context.Context, strategy *model.Strategy) {
+	buyerIDs, err := s.strategyRepo.GetStrategyBuyers(ctx, strategy.ID)
+	if err != nil {
+		s.logger.Warn("Failed to get strategy buyers, new version not announced",
+			zap.Error(err),
+			zap.Int("strategyID", strategy.ID))
+		return
+	}
+	if len(buyerIDs) == 0 {
+		return
+	}
+
+	s.events.Publish(ctx, client.Event{
+		EventType:  client.EventVersionPublished,
+		UserID:     strategy.UserID,
+		EntityType: "strategy",
+		EntityID:   strategy.ID,
+		EntityName: strategy.Name,
+		BuyerIDs:   buyerIDs,
+	})
 }
 
 // GetVersions retrieves all versions of a strategy with pagination
@@ -311,23 +702,11 @@ func (s *StrategyService) GetVersions(
 	}
 
 	// Calculate offset
-	offset := (page - 1) * limit
+	offset := pagination.Offset(page, limit)
 
 	// Validate sort parameters
-	validSortFields := map[string]bool{
-		"version":    true,
-		"created_at": true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "version" // Default sort by version
-	}
-
-	// Normalize sort direction
-	sortDirection = strings.ToUpper(sortDirection)
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "DESC" // Default to descending for versions
-	}
+	sortBy = versionSortFields.Normalize(sortBy, "version")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "DESC")
 
 	return s.strategyRepo.GetStrategyVersions(
 		ctx,
@@ -349,7 +728,7 @@ func (s *StrategyService) GetVersionByID(ctx context.Context, strategyID int, ve
 	}
 
 	if strategy == nil {
-		return nil, errors.New("strategy not found")
+		return nil, errcode.ErrStrategyNotFound
 	}
 
 	// Get the specific version
@@ -387,7 +766,7 @@ func (s *StrategyService) SetActiveVersion(ctx context.Context, userID, strategy
 	}
 
 	if strategy == nil {
-		return errors.New("strategy not found")
+		return errcode.ErrStrategyNotFound
 	}
 
 	// Verify version exists and belongs to the strategy
@@ -416,7 +795,7 @@ func (s *StrategyService) UpdateThumbnail(ctx context.Context, strategyID int, u
 	}
 
 	if strategy == nil {
-		return errors.New("strategy not found")
+		return errcode.ErrStrategyNotFound
 	}
 
 	if strategy.UserID != userID {
@@ -426,23 +805,215 @@ func (s *StrategyService) UpdateThumbnail(ctx context.Context, strategyID int, u
 	return s.strategyRepo.UpdateThumbnail(ctx, strategyID, userID, thumbnailURL)
 }
 
-// CreateBacktest submits a backtest request to the historical data service
-func (s *StrategyService) CreateBacktest(ctx context.Context, request *model.BacktestRequest, userID int) (int, error) {
+// CreateBacktest submits a backtest request to the historical data service. It returns
+// warnings about deprecated indicators the strategy uses, which don't stop the backtest.
+func (s *StrategyService) CreateBacktest(ctx context.Context, request *model.BacktestRequest, userID int) (int, []model.LintWarning, error) {
 	// Verify strategy exists and user has access to it
 	strategy, err := s.strategyRepo.GetStrategyByIDWithAccess(ctx, request.StrategyID, userID)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	if strategy == nil {
-		return 0, errors.New("strategy not found or you don't have access to it")
+		return 0, nil, errors.New("strategy not found or you don't have access to it")
+	}
+
+	// View-only shares can read the strategy but not backtest it
+	accessLevel, err := s.shareRepo.GetAccessLevel(ctx, strategy.ID, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if accessLevel == "shared_view" {
+		return 0, nil, errors.New("this strategy was shared with you as view-only")
 	}
 
 	// Submit backtest request to historical data service
-	backtestID, err := s.historicalClient.CreateBacktest(ctx, request, userID)
+	backtestID, err := s.historicalClient.CreateBacktest(ctx, request, strategy.Version, userID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to submit backtest: %w", err)
+	}
+
+	return backtestID, s.deprecationWarnings(ctx, strategy.Structure), nil
+}
+
+// PreviewSignals evaluates a strategy the user can access over the latest candles of a
+// symbol and returns the entries and exits its rules give, without running a backtest
+func (s *StrategyService) PreviewSignals(
+	ctx context.Context,
+	strategyID int,
+	userID int,
+	request *model.SignalPreviewRequest,
+) (*client.SignalPreview, error) {
+	strategy, err := s.strategyRepo.GetStrategyByIDWithAccess(ctx, strategyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	// The engine takes plain indicator settings
+	if err := s.ResolveIndicatorPresets(ctx, strategy); err != nil {
+		return nil, err
+	}
+
+	return s.historicalClient.PreviewSignals(ctx, strategy.Structure, request)
+}
+
+// GetBacktestHistory retrieves the user's backtests of every version of a strategy,
+// optionally only those of one version, with a per-version summary to compare them
+func (s *StrategyService) GetBacktestHistory(
+	ctx context.Context,
+	strategyID int,
+	userID int,
+	version *int,
+	page int,
+	limit int,
+) (*client.StrategyBacktestHistory, error) {
+	// Verify strategy exists and user has access to it
+	strategy, err := s.strategyRepo.GetStrategyByIDWithAccess(ctx, strategyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	versionIDs, err := s.strategyRepo.GetStrategyVersionIDs(ctx, strategy.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.historicalClient.GetStrategyBacktestHistory(ctx, userID, versionIDs, version, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backtest history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ShareStrategy shares a strategy with another user. Sharing again updates the permission.
+func (s *StrategyService) ShareStrategy(ctx context.Context, strategyID int, ownerID int, request *model.StrategyShareRequest) (*model.StrategyShare, error) {
+	// Verify strategy exists and user has ownership
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	if strategy.UserID != ownerID {
+		return nil, errors.New("you don't have permission to share this strategy")
+	}
+
+	if request.UserID == ownerID {
+		return nil, errors.New("cannot share a strategy with yourself")
+	}
+
+	// Verify the target user exists
+	username, err := s.userClient.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	shareID, err := s.shareRepo.ShareStrategy(ctx, strategy.StrategyGroupID, ownerID, request.UserID, request.Permission)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := s.shareRepo.GetShares(ctx, strategy.StrategyGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range shares {
+		if shares[i].ID == shareID {
+			shares[i].Username = username
+			return &shares[i], nil
+		}
+	}
+
+	return nil, errors.New("share not found after creation")
+}
+
+// GetShares retrieves the users a strategy is shared with
+func (s *StrategyService) GetShares(ctx context.Context, strategyID int, ownerID int) ([]model.StrategyShare, error) {
+	// Verify strategy exists and user has ownership
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
+	}
+
+	if strategy.UserID != ownerID {
+		return nil, errors.New("you don't have permission to view shares of this strategy")
+	}
+
+	shares, err := s.shareRepo.GetShares(ctx, strategy.StrategyGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to get usernames
+	for i := range shares {
+		username, err := s.userClient.GetUserByID(ctx, shares[i].SharedWithUserID)
+		if err == nil {
+			shares[i].Username = username
+		} else {
+			shares[i].Username = fmt.Sprintf("User %d", shares[i].SharedWithUserID)
+		}
+	}
+
+	return shares, nil
+}
+
+// RevokeShare stops sharing a strategy with a user
+func (s *StrategyService) RevokeShare(ctx context.Context, strategyID int, ownerID int, userID int) error {
+	// Verify strategy exists and user has ownership
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to submit backtest: %w", err)
+		return err
+	}
+
+	if strategy == nil {
+		return errcode.ErrStrategyNotFound
+	}
+
+	if strategy.UserID != ownerID {
+		return errors.New("you don't have permission to manage shares of this strategy")
+	}
+
+	success, err := s.shareRepo.RevokeShare(ctx, strategy.StrategyGroupID, ownerID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !success {
+		return errors.New("strategy is not shared with this user")
+	}
+
+	return nil
+}
+
+// GetStrategyUsers retrieves the IDs of a strategy's owner, the buyers who still have access
+// to it and the users it is shared with, for notifications other services send them
+func (s *StrategyService) GetStrategyUsers(ctx context.Context, strategyID int) ([]int, error) {
+	strategy, err := s.strategyRepo.GetStrategyByID(ctx, strategyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == nil {
+		return nil, errcode.ErrStrategyNotFound
 	}
 
-	return backtestID, nil
+	return s.strategyRepo.GetStrategyUsers(ctx, strategyID)
 }
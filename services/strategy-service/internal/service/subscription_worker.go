@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// SubscriptionWorker periodically expires lapsed subscriptions and sends renewal reminders
+type SubscriptionWorker struct {
+	purchaseRepo       *repository.PurchaseRepository
+	notificationClient *client.NotificationClient
+	interval           time.Duration
+	reminderDays       int
+	logger             *zap.Logger
+}
+
+// NewSubscriptionWorker creates a new subscription worker
+func NewSubscriptionWorker(
+	purchaseRepo *repository.PurchaseRepository,
+	notificationClient *client.NotificationClient,
+	interval time.Duration,
+	reminderDays int,
+	logger *zap.Logger,
+) *SubscriptionWorker {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &SubscriptionWorker{
+		purchaseRepo:       purchaseRepo,
+		notificationClient: notificationClient,
+		interval:           interval,
+		reminderDays:       reminderDays,
+		logger:             logger,
+	}
+}
+
+// Run scans subscriptions once at startup and then every interval until the context is cancelled
+func (w *SubscriptionWorker) Run(ctx context.Context) {
+	w.logger.Info("Starting subscription worker",
+		zap.Duration("interval", w.interval),
+		zap.Int("reminder_days", w.reminderDays))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce expires lapsed subscriptions and sends due renewal reminders.
+// Failures are logged; the next scan picks up anything left over.
+func (w *SubscriptionWorker) RunOnce(ctx context.Context) {
+	expired, err := w.purchaseRepo.ExpireSubscriptions(ctx)
+	if err != nil {
+		w.logger.Error("Subscription expiry scan failed", zap.Error(err))
+	} else if len(expired) > 0 {
+		w.logger.Info("Expired subscriptions", zap.Int("count", len(expired)))
+		w.notify(ctx, expired, expiryNotification)
+	}
+
+	if w.reminderDays <= 0 {
+		return
+	}
+
+	due, err := w.purchaseRepo.ClaimRenewalReminders(ctx, w.reminderDays)
+	if err != nil {
+		w.logger.Error("Renewal reminder scan failed", zap.Error(err))
+	} else if len(due) > 0 {
+		w.logger.Info("Sending renewal reminders", zap.Int("count", len(due)))
+		w.notify(ctx, due, reminderNotification)
+	}
+}
+
+// notify publishes one notification per subscription event
+func (w *SubscriptionWorker) notify(
+	ctx context.Context,
+	events []model.SubscriptionEvent,
+	build func(model.SubscriptionEvent) client.NotificationEvent,
+) {
+	notifications := make([]client.NotificationEvent, 0, len(events))
+	for _, event := range events {
+		notifications = append(notifications, build(event))
+	}
+
+	if err := w.notificationClient.Send(ctx, notifications...); err != nil {
+		w.logger.Error("Failed to send subscription notifications", zap.Error(err))
+	}
+}
+
+// expiryNotification builds the notification sent when a subscription has expired
+func expiryNotification(event model.SubscriptionEvent) client.NotificationEvent {
+	return client.NotificationEvent{
+		UserID:  event.BuyerID,
+		Type:    "subscription_expired",
+		Title:   "Subscription expired",
+		Message: fmt.Sprintf("Your subscription to %s has expired. Renew it in the marketplace to regain access.", event.StrategyName),
+		Link:    fmt.Sprintf("/marketplace/%d", event.MarketplaceID),
+	}
+}
+
+// reminderNotification builds the notification sent before a subscription ends
+func reminderNotification(event model.SubscriptionEvent) client.NotificationEvent {
+	return client.NotificationEvent{
+		UserID:  event.BuyerID,
+		Type:    "subscription_renewal",
+		Title:   "Subscription ending soon",
+		Message: fmt.Sprintf("Your subscription to %s ends on %s.", event.StrategyName, event.SubscriptionEnd.Format("Jan 2, 2006")),
+		Link:    fmt.Sprintf("/marketplace/purchases/%d", event.PurchaseID),
+	}
+}
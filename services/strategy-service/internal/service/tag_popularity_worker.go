@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TagPopularityWorker periodically recomputes the tag usage counts popular tags are ranked by
+type TagPopularityWorker struct {
+	tagService *TagService
+	interval   time.Duration
+	logger     *zap.Logger
+}
+
+// NewTagPopularityWorker creates a new tag popularity worker
+func NewTagPopularityWorker(tagService *TagService, interval time.Duration, logger *zap.Logger) *TagPopularityWorker {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return &TagPopularityWorker{
+		tagService: tagService,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Run refreshes tag popularity once at startup and then every interval until the context is cancelled
+func (w *TagPopularityWorker) Run(ctx context.Context) {
+	w.logger.Info("Starting tag popularity worker", zap.Duration("interval", w.interval))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.tagService.RefreshPopularity(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Error("Tag popularity refresh failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,26 +2,61 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"services/shared/pagination"
+	"services/strategy-service/internal/errcode"
 	"services/strategy-service/internal/model"
 	"services/strategy-service/internal/repository"
 
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
+// tagSortFields are the fields tags can be sorted by
+var tagSortFields = pagination.SortFields{"name", "strategy_count", "id"}
+
+// popularTagWindows are the time windows popular tags can be ranked over. They must
+// match the windows of the strategy_tag_popularity view.
+var popularTagWindows = map[string]bool{
+	"7d":  true,
+	"30d": true,
+	"90d": true,
+	"all": true,
+}
+
+// IsValidPopularTagWindow reports whether window is a supported popular tags window
+func IsValidPopularTagWindow(window string) bool {
+	return popularTagWindows[window]
+}
+
+// popularTagsKeyPrefix prefixes the Redis keys popular tags are cached under
+const popularTagsKeyPrefix = "strategy:popular-tags:"
+
 // TagService handles strategy tag operations
 type TagService struct {
-	tagRepo *repository.TagRepository
-	logger  *zap.Logger
+	tagRepo         *repository.TagRepository
+	redisClient     *redis.Client // Caches popular tags; nil disables caching
+	popularCacheTTL time.Duration
+	logger          *zap.Logger
 }
 
 // NewTagService creates a new tag service
-func NewTagService(tagRepo *repository.TagRepository, logger *zap.Logger) *TagService {
+func NewTagService(
+	tagRepo *repository.TagRepository,
+	redisClient *redis.Client,
+	popularCacheTTL time.Duration,
+	logger *zap.Logger,
+) *TagService {
 	return &TagService{
-		tagRepo: tagRepo,
-		logger:  logger,
+		tagRepo:         tagRepo,
+		redisClient:     redisClient,
+		popularCacheTTL: popularCacheTTL,
+		logger:          logger,
 	}
 }
 
@@ -42,19 +77,8 @@ func (s *TagService) GetAllTags(
 	}
 
 	// Validate sort parameters
-	validSortFields := map[string]bool{
-		"name":           true,
-		"strategy_count": true,
-		"id":             true,
-	}
-
-	if !validSortFields[sortBy] {
-		sortBy = "name" // Default to name
-	}
-
-	if sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "ASC" // Default to ascending for tags
-	}
+	sortBy = tagSortFields.Normalize(sortBy, "name")
+	sortDirection = pagination.NormalizeDirection(sortDirection, "ASC")
 
 	return s.tagRepo.GetAllTags(ctx, searchTerm, sortBy, sortDirection, page, limit)
 }
@@ -67,7 +91,7 @@ func (s *TagService) GetTagByID(ctx context.Context, id int) (*model.TagWithCoun
 	}
 
 	if tag == nil {
-		return nil, errors.New("tag not found")
+		return nil, errcode.ErrTagNotFound
 	}
 
 	return tag, nil
@@ -88,7 +112,7 @@ func (s *TagService) CreateTag(ctx context.Context, name string) (*model.TagWith
 	id, err := s.tagRepo.CreateTag(ctx, name)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
-			return nil, errors.New("tag name already exists")
+			return nil, errcode.ErrTagAlreadyExists
 		}
 		return nil, err
 	}
@@ -124,17 +148,19 @@ func (s *TagService) UpdateTag(ctx context.Context, id int, name string) (*model
 	}
 
 	if existingTag == nil {
-		return nil, errors.New("tag not found")
+		return nil, errcode.ErrTagNotFound
 	}
 
 	err = s.tagRepo.UpdateTag(ctx, id, name)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
-			return nil, errors.New("tag name already exists")
+			return nil, errcode.ErrTagAlreadyExists
 		}
 		return nil, err
 	}
 
+	s.invalidatePopularTags(ctx)
+
 	// Return the updated tag
 	return &model.TagWithCount{
 		ID:            id,
@@ -149,16 +175,201 @@ func (s *TagService) DeleteTag(ctx context.Context, id int) error {
 		return errors.New("invalid tag ID")
 	}
 
-	return s.tagRepo.DeleteTag(ctx, id)
+	if err := s.tagRepo.DeleteTag(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidatePopularTags(ctx)
+	return nil
 }
 
-// GetPopularTags returns the most frequently used tags
-func (s *TagService) GetPopularTags(ctx context.Context, limit int) ([]model.TagWithCount, error) {
+// GetPopularTags returns the most used tags within a time window such as "30d". Results
+// are cached in Redis until the usage counts are next refreshed or the cache TTL passes.
+func (s *TagService) GetPopularTags(ctx context.Context, window string, limit int) ([]model.PopularTag, error) {
 	if limit < 1 {
 		limit = 10 // Default to top 10
 	} else if limit > 100 {
 		limit = 100 // Max 100 tags
 	}
 
-	return s.tagRepo.GetPopularTags(ctx, limit)
+	if !IsValidPopularTagWindow(window) {
+		return nil, errors.New("invalid window")
+	}
+
+	cacheKey := fmt.Sprintf("%s%s:%d", popularTagsKeyPrefix, window, limit)
+	if s.redisClient != nil {
+		if data, err := s.redisClient.Get(ctx, cacheKey).Bytes(); err == nil {
+			var tags []model.PopularTag
+			if err := json.Unmarshal(data, &tags); err == nil {
+				return tags, nil
+			}
+		} else if err != redis.Nil {
+			s.logger.Warn("Failed to read cached popular tags", zap.Error(err))
+		}
+	}
+
+	tags, err := s.tagRepo.GetPopularTags(ctx, window, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.redisClient != nil && s.popularCacheTTL > 0 {
+		if data, err := json.Marshal(tags); err == nil {
+			if err := s.redisClient.Set(ctx, cacheKey, data, s.popularCacheTTL).Err(); err != nil {
+				s.logger.Warn("Failed to cache popular tags", zap.Error(err))
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// RefreshPopularity recomputes the tag usage counts popular tags are ranked by and drops
+// the cached rankings
+func (s *TagService) RefreshPopularity(ctx context.Context) error {
+	if err := s.tagRepo.RefreshTagPopularity(ctx); err != nil {
+		return err
+	}
+
+	s.invalidatePopularTags(ctx)
+	return nil
+}
+
+// invalidatePopularTags drops the cached popular tags of every window and limit
+func (s *TagService) invalidatePopularTags(ctx context.Context) {
+	if s.redisClient == nil {
+		return
+	}
+
+	var keys []string
+	iter := s.redisClient.Scan(ctx, 0, popularTagsKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		s.logger.Warn("Failed to list cached popular tags", zap.Error(err))
+		return
+	}
+
+	if len(keys) > 0 {
+		if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+			s.logger.Warn("Failed to drop cached popular tags", zap.Error(err))
+		}
+	}
+}
+
+// GetTagChildren returns the direct children of a tag
+func (s *TagService) GetTagChildren(ctx context.Context, id int) ([]model.TagWithCount, error) {
+	if _, err := s.GetTagByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.GetTagChildren(ctx, id)
+}
+
+// SetTagParent moves a tag under another tag, or to the top level when parentID is nil
+func (s *TagService) SetTagParent(ctx context.Context, id int, parentID *int) (*model.TagWithCount, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid tag ID")
+	}
+	if parentID != nil && *parentID <= 0 {
+		return nil, errors.New("invalid parent tag ID")
+	}
+
+	if err := s.tagRepo.SetTagParent(ctx, id, parentID); err != nil {
+		return nil, err
+	}
+
+	s.invalidatePopularTags(ctx)
+
+	return s.GetTagByID(ctx, id)
+}
+
+// GetTagAliases returns the aliases of a tag
+func (s *TagService) GetTagAliases(ctx context.Context, id int) ([]model.TagAlias, error) {
+	if _, err := s.GetTagByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.GetTagAliases(ctx, id)
+}
+
+// AddTagAlias adds an alias to a tag, so that searching for the alias finds the tag
+func (s *TagService) AddTagAlias(ctx context.Context, id int, alias string) ([]model.TagAlias, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid tag ID")
+	}
+
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil, errors.New("tag alias cannot be empty")
+	}
+
+	if len(alias) > 50 {
+		return nil, errors.New("tag alias cannot exceed 50 characters")
+	}
+
+	if err := s.tagRepo.AddTagAlias(ctx, id, alias); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errcode.ErrTagAlreadyExists
+		}
+		return nil, err
+	}
+
+	return s.tagRepo.GetTagAliases(ctx, id)
+}
+
+// RemoveTagAlias removes an alias from a tag
+func (s *TagService) RemoveTagAlias(ctx context.Context, id int, alias string) error {
+	if id <= 0 {
+		return errors.New("invalid tag ID")
+	}
+
+	removed, err := s.tagRepo.RemoveTagAlias(ctx, id, alias)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return errcode.ErrTagAliasNotFound
+	}
+
+	return nil
+}
+
+// MergeTag merges a duplicate tag into a target tag. Strategies tagged with the source
+// are re-tagged with the target, the source's aliases and children move to the target,
+// and the source's name becomes an alias of the target.
+func (s *TagService) MergeTag(ctx context.Context, sourceID, targetID int) (*model.TagMergeResult, error) {
+	if sourceID <= 0 || targetID <= 0 {
+		return nil, errors.New("invalid tag ID")
+	}
+
+	if sourceID == targetID {
+		return nil, errors.New("invalid merge: a tag cannot be merged into itself")
+	}
+
+	remapped, err := s.tagRepo.MergeTag(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Merged tag",
+		zap.Int("sourceID", sourceID),
+		zap.Int("targetID", targetID),
+		zap.Int("remappedStrategies", remapped))
+
+	// The merged tag drops out of the rankings now; its usage counts toward the target
+	// from the next refresh
+	s.invalidatePopularTags(ctx)
+
+	target, err := s.GetTagByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TagMergeResult{
+		Tag:                *target,
+		RemappedStrategies: remapped,
+	}, nil
 }
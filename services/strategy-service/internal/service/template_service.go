@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"services/strategy-service/internal/errcode"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// templatePlaceholder matches a structure value that is a template placeholder, "{{name}}"
+var templatePlaceholder = regexp.MustCompile(`^\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}$`)
+
+// templateParameterName matches the names template parameters may have
+var templateParameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplateService handles strategy template operations
+type TemplateService struct {
+	templateRepo    *repository.TemplateRepository
+	strategyService *StrategyService
+	logger          *zap.Logger
+}
+
+// NewTemplateService creates a new template service
+func NewTemplateService(
+	templateRepo *repository.TemplateRepository,
+	strategyService *StrategyService,
+	logger *zap.Logger,
+) *TemplateService {
+	return &TemplateService{
+		templateRepo:    templateRepo,
+		strategyService: strategyService,
+		logger:          logger,
+	}
+}
+
+// GetTemplates retrieves the templates of a category, or of every category when it is
+// empty, along with all template categories. Inactive templates are only included for
+// template admins.
+func (s *TemplateService) GetTemplates(
+	ctx context.Context,
+	category string,
+	includeInactive bool,
+) ([]model.StrategyTemplate, []model.StrategyTemplateCategory, error) {
+	templates, err := s.templateRepo.GetTemplates(ctx, strings.TrimSpace(category), includeInactive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	categories, err := s.templateRepo.GetCategories(ctx, includeInactive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return templates, categories, nil
+}
+
+// GetTemplate retrieves a template. Inactive templates are only found for template admins.
+func (s *TemplateService) GetTemplate(ctx context.Context, id int, includeInactive bool) (*model.StrategyTemplate, error) {
+	template, err := s.templateRepo.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if template == nil || (!template.IsActive && !includeInactive) {
+		return nil, errcode.ErrTemplateNotFound
+	}
+
+	return template, nil
+}
+
+// CreateTemplate creates a new template
+func (s *TemplateService) CreateTemplate(ctx context.Context, input *model.StrategyTemplateInput, userID int) (*model.StrategyTemplate, error) {
+	parameters, err := s.validateTemplate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.templateRepo.CreateTemplate(ctx, input, parameters, isActiveTemplate(input), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errcode.ErrTemplateAlreadyExists
+		}
+		return nil, err
+	}
+
+	return s.GetTemplate(ctx, id, true)
+}
+
+// UpdateTemplate replaces a template. Strategies created from it are unaffected.
+func (s *TemplateService) UpdateTemplate(ctx context.Context, id int, input *model.StrategyTemplateInput) (*model.StrategyTemplate, error) {
+	parameters, err := s.validateTemplate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.templateRepo.UpdateTemplate(ctx, id, input, parameters, isActiveTemplate(input))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errcode.ErrTemplateAlreadyExists
+		}
+		return nil, err
+	}
+	if !updated {
+		return nil, errcode.ErrTemplateNotFound
+	}
+
+	return s.GetTemplate(ctx, id, true)
+}
+
+// DeleteTemplate deletes a template. Strategies created from it are unaffected.
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id int) error {
+	deleted, err := s.templateRepo.DeleteTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errcode.ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+// InstantiateTemplate creates a strategy of the user from an active template, with its
+// placeholders filled in from the given parameter values and the defaults
+func (s *TemplateService) InstantiateTemplate(
+	ctx context.Context,
+	id int,
+	request *model.StrategyTemplateInstantiate,
+	userID int,
+) (*model.Strategy, error) {
+	template, err := s.GetTemplate(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var parameters []model.TemplateParameter
+	if err := json.Unmarshal(template.Parameters, &parameters); err != nil {
+		return nil, fmt.Errorf("invalid parameters of template %d: %w", id, err)
+	}
+
+	values, err := templateValues(parameters, request.Parameters)
+	if err != nil {
+		return nil, errcode.ErrInvalidTemplateParameters.WithMessage(err.Error())
+	}
+
+	structure, err := fillTemplate(template.Structure, values)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := &model.StrategyCreate{
+		Name:        strings.TrimSpace(request.Name),
+		Description: request.Description,
+		Structure:   structure,
+		IsPublic:    request.IsPublic,
+		TagIDs:      request.TagIDs,
+	}
+	if strategy.Name == "" {
+		strategy.Name = template.Name
+	}
+	if strategy.Description == "" {
+		strategy.Description = template.Description
+	}
+
+	created, err := s.strategyService.CreateStrategy(ctx, strategy, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Created strategy from template",
+		zap.Int("templateID", id),
+		zap.Int("strategyID", created.ID),
+		zap.Int("userID", userID))
+
+	return created, nil
+}
+
+// validateTemplate checks a template's structure, and its parameters against the
+// placeholders of the structure. Returns the parameters to store.
+func (s *TemplateService) validateTemplate(input *model.StrategyTemplateInput) (json.RawMessage, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Category = strings.TrimSpace(input.Category)
+	if input.Name == "" {
+		return nil, errcode.ErrInvalidTemplate.WithMessage("Template name cannot be empty")
+	}
+	if input.Category == "" {
+		return nil, errcode.ErrInvalidTemplate.WithMessage("Template category cannot be empty")
+	}
+	if err := s.strategyService.validateStrategyData(input.Structure); err != nil {
+		return nil, errcode.ErrInvalidTemplate.WithMessage(err.Error())
+	}
+
+	declared := make(map[string]bool, len(input.Parameters))
+	for _, param := range input.Parameters {
+		if err := validateTemplateParameter(param); err != nil {
+			return nil, errcode.ErrInvalidTemplate.WithMessage(err.Error())
+		}
+		if declared[param.Name] {
+			return nil, errcode.ErrInvalidTemplate.WithMessage(fmt.Sprintf("Parameter %q is declared twice", param.Name))
+		}
+		declared[param.Name] = true
+	}
+
+	var structure interface{}
+	if err := json.Unmarshal(input.Structure, &structure); err != nil {
+		return nil, errcode.ErrInvalidTemplate.WithMessage(err.Error())
+	}
+	used := make(map[string]bool)
+	collectPlaceholders(structure, used)
+
+	for name := range used {
+		if !declared[name] {
+			return nil, errcode.ErrInvalidTemplate.WithMessage(fmt.Sprintf("Placeholder {{%s}} has no parameter", name))
+		}
+	}
+	for name := range declared {
+		if !used[name] {
+			return nil, errcode.ErrInvalidTemplate.WithMessage(fmt.Sprintf("Parameter %q is not used in the structure", name))
+		}
+	}
+
+	if input.Parameters == nil {
+		input.Parameters = []model.TemplateParameter{}
+	}
+	return json.Marshal(input.Parameters)
+}
+
+// validateTemplateParameter checks a parameter's name, bounds and default
+func validateTemplateParameter(param model.TemplateParameter) error {
+	if !templateParameterName.MatchString(param.Name) {
+		return fmt.Errorf("parameter name %q must be letters, digits and underscores", param.Name)
+	}
+	if param.Min != nil && param.Max != nil && *param.Min > *param.Max {
+		return fmt.Errorf("parameter %q has a minimum above its maximum", param.Name)
+	}
+	if param.Default != nil {
+		if err := checkTemplateValue(param, *param.Default); err != nil {
+			return fmt.Errorf("default of %w", err)
+		}
+	}
+	return nil
+}
+
+// checkTemplateValue checks a value against a parameter's type and bounds
+func checkTemplateValue(param model.TemplateParameter, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("parameter %q must be a finite number", param.Name)
+	}
+	if param.Type == model.TemplateParameterInteger && value != math.Trunc(value) {
+		return fmt.Errorf("parameter %q must be an integer", param.Name)
+	}
+	if param.Min != nil && value < *param.Min {
+		return fmt.Errorf("parameter %q must be at least %g", param.Name, *param.Min)
+	}
+	if param.Max != nil && value > *param.Max {
+		return fmt.Errorf("parameter %q must be at most %g", param.Name, *param.Max)
+	}
+	return nil
+}
+
+// templateValues resolves the value of every template parameter from the given values
+// and the defaults
+func templateValues(parameters []model.TemplateParameter, given map[string]float64) (map[string]float64, error) {
+	values := make(map[string]float64, len(parameters))
+	for _, param := range parameters {
+		value, ok := given[param.Name]
+		if !ok {
+			if param.Default == nil {
+				return nil, fmt.Errorf("parameter %q is required", param.Name)
+			}
+			value = *param.Default
+		}
+		if err := checkTemplateValue(param, value); err != nil {
+			return nil, err
+		}
+		values[param.Name] = value
+	}
+
+	for name := range given {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+
+	return values, nil
+}
+
+// fillTemplate replaces the placeholders of a template structure with their values
+func fillTemplate(data json.RawMessage, values map[string]float64) (json.RawMessage, error) {
+	var structure interface{}
+	if err := json.Unmarshal(data, &structure); err != nil {
+		return nil, fmt.Errorf("invalid template structure JSON: %w", err)
+	}
+
+	return json.Marshal(replacePlaceholders(structure, values))
+}
+
+// replacePlaceholders returns node with every placeholder replaced with its value
+func replacePlaceholders(node interface{}, values map[string]float64) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = replacePlaceholders(child, values)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = replacePlaceholders(child, values)
+		}
+	case string:
+		if match := templatePlaceholder.FindStringSubmatch(value); match != nil {
+			if replacement, ok := values[match[1]]; ok {
+				return replacement
+			}
+		}
+	}
+	return node
+}
+
+// collectPlaceholders adds the names of the placeholders in node to names
+func collectPlaceholders(node interface{}, names map[string]bool) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for _, child := range value {
+			collectPlaceholders(child, names)
+		}
+	case []interface{}:
+		for _, child := range value {
+			collectPlaceholders(child, names)
+		}
+	case string:
+		if match := templatePlaceholder.FindStringSubmatch(value); match != nil {
+			names[match[1]] = true
+		}
+	}
+}
+
+// isActiveTemplate reports whether a created or updated template is active, the default
+func isActiveTemplate(input *model.StrategyTemplateInput) bool {
+	return input.IsActive == nil || *input.IsActive
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"services/strategy-service/internal/client"
+	"services/strategy-service/internal/model"
+	"services/strategy-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WalletService handles users' platform credit: their wallet balances, the ledger of every
+// change to them and admins granting and revoking credit. Purchases spend it and refunds
+// give it back through the purchase and refund services.
+type WalletService struct {
+	walletRepo         *repository.WalletRepository
+	currencyService    *CurrencyService
+	notificationClient *client.NotificationClient
+	logger             *zap.Logger
+}
+
+// NewWalletService creates a new wallet service
+func NewWalletService(
+	walletRepo *repository.WalletRepository,
+	currencyService *CurrencyService,
+	notificationClient *client.NotificationClient,
+	logger *zap.Logger,
+) *WalletService {
+	return &WalletService{
+		walletRepo:         walletRepo,
+		currencyService:    currencyService,
+		notificationClient: notificationClient,
+		logger:             logger,
+	}
+}
+
+// GetBalances retrieves the balances of a user's wallet, one per currency it ever held
+func (s *WalletService) GetBalances(ctx context.Context, userID int) ([]model.WalletBalance, error) {
+	return s.walletRepo.GetBalances(ctx, userID)
+}
+
+// GetLedger retrieves the entries of a user's wallet, newest first. An empty currency
+// returns the entries of every currency.
+func (s *WalletService) GetLedger(ctx context.Context, userID int, currency string, page, limit int) ([]model.WalletEntry, int, error) {
+	if currency != "" {
+		var err error
+		currency, err = s.currencyService.NormalizeCurrency(ctx, currency)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return s.walletRepo.GetLedger(ctx, userID, currency, page, limit)
+}
+
+// Grant credits a user's wallet on behalf of an admin and notifies the user
+func (s *WalletService) Grant(ctx context.Context, userID int, adminID int, adjustment *model.WalletAdjustment) (*model.WalletEntry, error) {
+	entry, err := s.adjust(ctx, userID, adminID, adjustment, model.WalletEntryGrant)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notificationClient.Send(ctx, client.NotificationEvent{
+		UserID:  userID,
+		Type:    "wallet_credit_granted",
+		Title:   "Credit added to your wallet",
+		Message: fmt.Sprintf("%.2f %s was added to your wallet.", entry.Amount, entry.Currency),
+		Link:    "/wallet",
+	}); err != nil {
+		s.logger.Error("Failed to send wallet notification", zap.Error(err), zap.Int("user_id", userID))
+	}
+
+	return entry, nil
+}
+
+// Revoke debits a user's wallet on behalf of an admin. It fails when the balance is lower
+// than the amount.
+func (s *WalletService) Revoke(ctx context.Context, userID int, adminID int, adjustment *model.WalletAdjustment) (*model.WalletEntry, error) {
+	return s.adjust(ctx, userID, adminID, adjustment, model.WalletEntryRevoke)
+}
+
+// adjust adds an admin's grant or revocation to a user's wallet
+func (s *WalletService) adjust(
+	ctx context.Context,
+	userID int,
+	adminID int,
+	adjustment *model.WalletAdjustment,
+	entryType string,
+) (*model.WalletEntry, error) {
+	currency, err := s.currencyService.NormalizeCurrency(ctx, adjustment.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := math.Round(adjustment.Amount*100) / 100
+	if amount <= 0 {
+		return nil, errors.New("invalid amount: must be at least 0.01")
+	}
+	if entryType == model.WalletEntryRevoke {
+		amount = -amount
+	}
+
+	entry, err := s.walletRepo.AddEntry(ctx, userID, amount, currency, entryType, &adminID, adjustment.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Adjusted wallet balance",
+		zap.Int("user_id", userID),
+		zap.Int("admin_id", adminID),
+		zap.String("entry_type", entryType),
+		zap.Float64("amount", amount),
+		zap.String("currency", currency))
+	return entry, nil
+}
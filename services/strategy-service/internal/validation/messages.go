@@ -0,0 +1,86 @@
+package validation
+
+import "strings"
+
+// DefaultLanguage is used when the client accepts none of the catalog languages
+const DefaultLanguage = "en"
+
+// catalogs holds the validation messages of each language by rule. {field} and
+// {param} are replaced by the field and the parameter of the rule.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"required":   "{field} is required",
+		"email":      "{field} must be a valid email address",
+		"url":        "{field} must be a valid URL",
+		"uuid":       "{field} must be a valid UUID",
+		"oneof":      "{field} must be one of: {param}",
+		"min":        "{field} must be at least {param}",
+		"max":        "{field} must be at most {param}",
+		"len":        "{field} must be {param}",
+		"gt":         "{field} must be greater than {param}",
+		"gte":        "{field} must be greater than or equal to {param}",
+		"lt":         "{field} must be less than {param}",
+		"lte":        "{field} must be less than or equal to {param}",
+		"min.string": "{field} must be at least {param} characters long",
+		"max.string": "{field} must be at most {param} characters long",
+		"len.string": "{field} must be {param} characters long",
+		"min.list":   "{field} must contain at least {param} items",
+		"max.list":   "{field} must contain at most {param} items",
+		"len.list":   "{field} must contain {param} items",
+		"type":       "{field} must be of type {param}",
+		"json":       "The request body is not valid JSON",
+		"format":     "The request body contains a value in an invalid format",
+		"default":    "{field} is invalid",
+		"value":      "value",
+	},
+	"es": {
+		"required":   "{field} es obligatorio",
+		"email":      "{field} debe ser un correo electrónico válido",
+		"url":        "{field} debe ser una URL válida",
+		"uuid":       "{field} debe ser un UUID válido",
+		"oneof":      "{field} debe ser uno de: {param}",
+		"min":        "{field} debe ser como mínimo {param}",
+		"max":        "{field} debe ser como máximo {param}",
+		"len":        "{field} debe ser {param}",
+		"gt":         "{field} debe ser mayor que {param}",
+		"gte":        "{field} debe ser mayor o igual que {param}",
+		"lt":         "{field} debe ser menor que {param}",
+		"lte":        "{field} debe ser menor o igual que {param}",
+		"min.string": "{field} debe tener al menos {param} caracteres",
+		"max.string": "{field} debe tener como máximo {param} caracteres",
+		"len.string": "{field} debe tener {param} caracteres",
+		"min.list":   "{field} debe contener al menos {param} elementos",
+		"max.list":   "{field} debe contener como máximo {param} elementos",
+		"len.list":   "{field} debe contener {param} elementos",
+		"type":       "{field} debe ser de tipo {param}",
+		"json":       "El cuerpo de la solicitud no es un JSON válido",
+		"format":     "El cuerpo de la solicitud contiene un valor con un formato no válido",
+		"default":    "{field} no es válido",
+		"value":      "el valor",
+	},
+}
+
+// message returns the message of a failed rule in lang. Rules without a message fall
+// back to the rule without its kind suffix, then to the default message.
+func message(lang, rule, field, param string) string {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		catalog = catalogs[DefaultLanguage]
+	}
+
+	template, ok := catalog[rule]
+	if !ok {
+		template, ok = catalog[strings.SplitN(rule, ".", 2)[0]]
+	}
+	if !ok {
+		template = catalog["default"]
+	}
+
+	if field == "" {
+		field = catalog["value"]
+	}
+	if rule == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(template)
+}
@@ -0,0 +1,154 @@
+// Package validation binds request bodies and reports what's wrong with them as field
+// errors, with messages in the language the client asked for.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"services/shared/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes a field of a request that failed validation
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. parameters[0].name. Empty for errors
+	// about the body as a whole.
+	Field string `json:"field"`
+	// Rule is the validation rule that failed, e.g. required or max
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. 255 for max=255
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// Report fields by their JSON name rather than their Go name
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// BindJSON binds the JSON body of the request into obj and validates it. If the body
+// is invalid it sends a VALIDATION_FAILED response with the field errors and returns false.
+func BindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	lang := Language(c)
+	fieldErrors := Errors(err, lang)
+
+	messages := make([]string, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		messages[i] = fieldError.Message
+	}
+
+	apiErr := apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, strings.Join(messages, "; ")).
+		WithDetails(fieldErrors)
+	c.JSON(apiErr.Status, apiErr.Body())
+	return false
+}
+
+// Errors converts an error returned by gin binding into field errors with messages in lang
+func Errors(err error, lang string) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fieldErrors := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fieldError := FieldError{
+				Field: fieldPath(fe.Namespace()),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+			}
+			fieldError.Message = message(lang, ruleKey(fe), fieldError.Field, fieldError.Param)
+			fieldErrors = append(fieldErrors, fieldError)
+		}
+		return fieldErrors
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		param := jsonTypeName(typeErr.Type)
+		return []FieldError{{
+			Field:   field,
+			Rule:    "type",
+			Param:   param,
+			Message: message(lang, "type", field, param),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || err.Error() == "EOF" || err.Error() == "unexpected EOF" {
+		return []FieldError{{Rule: "json", Message: message(lang, "json", "", "")}}
+	}
+
+	// Errors of custom unmarshalers, e.g. malformed dates
+	return []FieldError{{Rule: "format", Message: message(lang, "format", "", "")}}
+}
+
+// ruleKey returns the catalog key of a failed rule. Size rules read differently for
+// text, lists and numbers, so they get a suffix for the kind of field.
+func ruleKey(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "min", "max", "len", "gt", "gte", "lt", "lte":
+		switch fe.Kind() {
+		case reflect.String:
+			return fe.Tag() + ".string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fe.Tag() + ".list"
+		}
+	}
+	return fe.Tag()
+}
+
+// fieldPath strips the struct name from a validator namespace, e.g.
+// StrategyCreate.parameters[0].name becomes parameters[0].name
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// jsonFieldName returns the JSON name of a struct field
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// jsonTypeName returns the JSON type of a Go type
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
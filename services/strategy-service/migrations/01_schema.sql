@@ -1,7 +1,9 @@
 -- Strategy Service Database Schema
--- File: 01-schema.sql
+-- File: 01_schema.sql
 -- Contains type definitions and table structures without constraints
 
+-- +goose Up
+-- +goose StatementBegin
 -- Type definitions
 CREATE TYPE "user_role" AS ENUM (
   'admin',
@@ -134,4 +136,5 @@ CREATE TABLE IF NOT EXISTS "strategy_reviews" (
   "comment" text,
   "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
   "updated_at" timestamp
-);
\ No newline at end of file
+);
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Database Indexes and Constraints
--- File: 02-indexes.sql
+-- File: 02_indexes.sql
 -- Contains indexes, foreign keys, and other constraints
 
+-- +goose Up
+-- +goose StatementBegin
 -- Indexes for strategies table
 CREATE INDEX "idx_strategies_user_id" ON "strategies" ("user_id");
 CREATE INDEX ON "strategies" ("is_public");
@@ -25,4 +27,5 @@ ALTER TABLE "indicator_parameters" ADD FOREIGN KEY ("indicator_id") REFERENCES "
 ALTER TABLE "parameter_enum_values" ADD FOREIGN KEY ("parameter_id") REFERENCES "indicator_parameters" ("id") ON DELETE CASCADE;
 ALTER TABLE "strategy_marketplace" ADD FOREIGN KEY ("strategy_id") REFERENCES "strategy_groups" ("id") ON DELETE CASCADE;
 ALTER TABLE "strategy_purchases" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
-ALTER TABLE "strategy_reviews" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
\ No newline at end of file
+ALTER TABLE "strategy_reviews" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Default Data
--- File: 03-default-data.sql
+-- File: 03_default-data.sql
 -- Contains initial data for the database
 
+-- +goose Up
+-- +goose StatementBegin
 -- Insert default tags
 INSERT INTO strategy_tags (name) VALUES 
 ('Trend Following'),
@@ -14,4 +16,5 @@ INSERT INTO strategy_tags (name) VALUES
 ('Day Trading'),
 ('Algorithmic'),
 ('Machine Learning')
-ON CONFLICT (name) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Strategy Functions
--- File: 04-strategy-functions.sql
+-- File: 04_strategy-functions.sql
 -- Contains functions for strategy operations
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create a view for my strategies (created by me + purchased)
 CREATE OR REPLACE VIEW v_my_strategies AS
 SELECT 
@@ -848,4 +850,5 @@ BEGIN
     
     RETURN TRUE;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Tag Functions
--- File: 06-tag-functions.sql
+-- File: 05_tag-functions.sql
 -- Contains functions for tag operations
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get all strategy tags with enhanced filtering and sorting
 CREATE OR REPLACE FUNCTION get_all_tags(
     p_search VARCHAR DEFAULT NULL,
@@ -173,4 +175,5 @@ BEGIN
         t.name ASC
     LIMIT p_limit;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Marketplace Functions
--- File: 07-marketplace-functions.sql
+-- File: 06_marketplace-functions.sql
 -- Contains functions for marketplace operations
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create marketplace listing view with ratings 
 CREATE OR REPLACE VIEW v_marketplace_listings AS
 SELECT 
@@ -329,4 +331,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -1,7 +1,9 @@
 -- Strategy Service Purchase Functions
--- File: 08-purchase-functions.sql
+-- File: 07_purchase-functions.sql
 -- Contains functions for purchases and subscriptions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Purchase a strategy
 CREATE OR REPLACE FUNCTION purchase_strategy(
     p_buyer_id INT,
@@ -137,4 +139,5 @@ BEGIN
     
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
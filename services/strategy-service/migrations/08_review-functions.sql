@@ -1,7 +1,9 @@
 -- Strategy Service Review Functions
--- File: 09-review-functions.sql
+-- File: 08_review-functions.sql
 -- Contains functions for reviews
 
+-- +goose Up
+-- +goose StatementBegin
 -- Add review
 CREATE OR REPLACE FUNCTION add_review(
     p_user_id INT,
@@ -183,4 +185,5 @@ BEGIN
         
     RETURN review_count;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
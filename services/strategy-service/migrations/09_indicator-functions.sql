@@ -1,7 +1,9 @@
 -- Strategy Service Indicator Functions
--- File: 10-indicator-functions.sql
+-- File: 09_indicator-functions.sql
 -- Contains functions for technical indicators
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create view for indicators with parameters
 CREATE OR REPLACE VIEW v_indicators_with_parameters AS
 SELECT 
@@ -356,4 +358,5 @@ BEGIN
         
     RETURN indicator_count;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,235 @@
+-- Strategy Service Share Functions
+-- File: 10_share-functions.sql
+-- Contains the strategy_shares table, share management and share-aware access resolution
+
+-- +goose Up
+-- +goose StatementBegin
+-- Shares grant a specific user access to every version of a strategy group.
+-- 'view' allows reading the strategy, 'backtest' also allows running backtests.
+CREATE TABLE IF NOT EXISTS "strategy_shares" (
+  "id" SERIAL PRIMARY KEY,
+  "strategy_group_id" int NOT NULL,
+  "owner_id" int NOT NULL,
+  "shared_with_user_id" int NOT NULL,
+  "permission" varchar(20) NOT NULL DEFAULT 'view',
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp,
+  CONSTRAINT "strategy_shares_permission_check" CHECK ("permission" IN ('view', 'backtest')),
+  CONSTRAINT "strategy_shares_unique" UNIQUE ("strategy_group_id", "shared_with_user_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_shares_user" ON "strategy_shares" ("shared_with_user_id");
+
+ALTER TABLE "strategy_shares" ADD FOREIGN KEY ("strategy_group_id") REFERENCES "strategy_groups" ("id") ON DELETE CASCADE;
+
+-- Share a strategy group with a user, or change the permission of an existing share
+CREATE OR REPLACE FUNCTION share_strategy(
+    p_strategy_group_id INT,
+    p_owner_id INT,
+    p_user_id INT,
+    p_permission VARCHAR(20)
+)
+RETURNS INT AS $$
+DECLARE
+    v_share_id INT;
+BEGIN
+    INSERT INTO strategy_shares (strategy_group_id, owner_id, shared_with_user_id, permission, created_at)
+    VALUES (p_strategy_group_id, p_owner_id, p_user_id, p_permission, NOW())
+    ON CONFLICT (strategy_group_id, shared_with_user_id)
+    DO UPDATE SET permission = EXCLUDED.permission, updated_at = NOW()
+    RETURNING id INTO v_share_id;
+
+    RETURN v_share_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Revoke a share
+CREATE OR REPLACE FUNCTION revoke_strategy_share(
+    p_strategy_group_id INT,
+    p_owner_id INT,
+    p_user_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM strategy_shares
+    WHERE strategy_group_id = p_strategy_group_id
+      AND owner_id = p_owner_id
+      AND shared_with_user_id = p_user_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the shares of a strategy group
+CREATE OR REPLACE FUNCTION get_strategy_shares(p_strategy_group_id INT)
+RETURNS TABLE (
+    id INT,
+    strategy_group_id INT,
+    owner_id INT,
+    shared_with_user_id INT,
+    permission VARCHAR(20),
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        sh.id,
+        sh.strategy_group_id,
+        sh.owner_id,
+        sh.shared_with_user_id,
+        sh.permission,
+        sh.created_at,
+        sh.updated_at
+    FROM strategy_shares sh
+    WHERE sh.strategy_group_id = p_strategy_group_id
+    ORDER BY sh.created_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Resolve how a user can access a strategy. Returns, in order of precedence,
+-- 'owner', 'purchased', 'shared_backtest', 'public', 'shared_view', or NULL for no access.
+CREATE OR REPLACE FUNCTION get_strategy_access_level(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TEXT AS $$
+DECLARE
+    v_strategy RECORD;
+    v_share_permission VARCHAR(20);
+BEGIN
+    SELECT s.id, s.user_id, s.is_public, s.strategy_group_id
+    INTO v_strategy
+    FROM strategies s
+    WHERE s.id = p_strategy_id AND s.is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    IF v_strategy.user_id = p_user_id THEN
+        RETURN 'owner';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM strategy_purchases p
+        WHERE p.buyer_id = p_user_id
+        AND p.strategy_version = v_strategy.id
+        AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+    ) THEN
+        RETURN 'purchased';
+    END IF;
+
+    SELECT sh.permission INTO v_share_permission
+    FROM strategy_shares sh
+    WHERE sh.strategy_group_id = v_strategy.strategy_group_id
+    AND sh.shared_with_user_id = p_user_id;
+
+    IF v_share_permission = 'backtest' THEN
+        RETURN 'shared_backtest';
+    END IF;
+
+    IF v_strategy.is_public THEN
+        RETURN 'public';
+    END IF;
+
+    IF v_share_permission = 'view' THEN
+        RETURN 'shared_view';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get strategy by ID (replaces the version in 04-strategy-functions.sql to add shared access)
+CREATE OR REPLACE FUNCTION get_strategy_by_id(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    user_id INT,
+    description TEXT,
+    thumbnail_url VARCHAR(255),
+    structure JSONB,
+    is_public BOOLEAN,
+    is_active BOOLEAN,
+    version INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    strategy_group_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.id,
+        s.name,
+        s.user_id,
+        s.description,
+        s.thumbnail_url,
+        s.structure,
+        s.is_public,
+        s.is_active,
+        s.version,
+        s.created_at,
+        s.updated_at,
+        s.strategy_group_id
+    FROM
+        strategies s
+    LEFT JOIN
+        user_strategy_versions usv ON s.strategy_group_id = usv.strategy_group_id AND usv.user_id = p_user_id
+    WHERE
+        (
+            -- Case 1: User owns the strategy, show their active version or the strategy directly requested
+            (s.user_id = p_user_id AND (s.id = p_strategy_id OR (s.strategy_group_id = p_strategy_id AND (usv.active_version_id = s.id OR usv.active_version_id IS NULL))))
+
+            OR
+
+            -- Case 2: User purchased the strategy, show the version they bought
+            EXISTS (
+                SELECT 1
+                FROM strategy_purchases p
+                JOIN strategy_marketplace m ON p.marketplace_id = m.id
+                WHERE p.buyer_id = p_user_id
+                AND p.strategy_version = s.id
+                AND (s.id = p_strategy_id OR s.strategy_group_id = p_strategy_id)
+                AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+            )
+
+            OR
+
+            -- Case 3: Strategy is public and the user is accessing by ID directly
+            (s.is_public = TRUE AND s.id = p_strategy_id)
+
+            OR
+
+            -- Case 4: Strategy is shared with the user, show the requested version or the latest one
+            (
+                EXISTS (
+                    SELECT 1
+                    FROM strategy_shares sh
+                    WHERE sh.strategy_group_id = s.strategy_group_id
+                    AND sh.shared_with_user_id = p_user_id
+                )
+                AND (
+                    s.id = p_strategy_id
+                    OR (
+                        s.strategy_group_id = p_strategy_id
+                        AND s.version = (
+                            SELECT MAX(s2.version)
+                            FROM strategies s2
+                            WHERE s2.strategy_group_id = s.strategy_group_id AND s2.is_active = TRUE
+                        )
+                    )
+                )
+            )
+        )
+        AND s.is_active = TRUE;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,407 @@
+-- Strategy Service Marketplace Search Functions
+-- File: 11_marketplace-search.sql
+-- Contains full-text search over marketplace listings with ranking, typo tolerance and facets
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Search document: strategy name (weight A), tag names (B), public description (C).
+-- The 'simple' configuration keeps tokens unstemmed so prefix queries behave predictably.
+ALTER TABLE "strategy_marketplace" ADD COLUMN IF NOT EXISTS "search_vector" tsvector;
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_marketplace_search" ON "strategy_marketplace" USING GIN ("search_vector");
+CREATE INDEX IF NOT EXISTS "idx_strategies_name_trgm" ON "strategies" USING GIN ("name" gin_trgm_ops);
+
+-- Build the search vector of a listing
+CREATE OR REPLACE FUNCTION build_marketplace_search_vector(
+    p_strategy_id INT,
+    p_description TEXT
+)
+RETURNS tsvector AS $$
+DECLARE
+    v_name TEXT;
+    v_tags TEXT;
+BEGIN
+    SELECT s.name INTO v_name
+    FROM strategies s
+    WHERE s.id = p_strategy_id;
+
+    SELECT string_agg(t.name, ' ') INTO v_tags
+    FROM strategy_tag_mappings tm
+    JOIN strategy_tags t ON tm.tag_id = t.id
+    WHERE tm.strategy_id = p_strategy_id;
+
+    RETURN setweight(to_tsvector('simple', COALESCE(v_name, '')), 'A') ||
+           setweight(to_tsvector('simple', COALESCE(v_tags, '')), 'B') ||
+           setweight(to_tsvector('simple', COALESCE(p_description, '')), 'C');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Rebuild the search vectors of every listing for a strategy
+CREATE OR REPLACE FUNCTION refresh_marketplace_search_vector(p_strategy_id INT)
+RETURNS VOID AS $$
+BEGIN
+    UPDATE strategy_marketplace m
+    SET search_vector = build_marketplace_search_vector(m.strategy_id, m.description_public)
+    WHERE m.strategy_id = p_strategy_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Keep search vectors current when listings, strategy names or tags change
+CREATE OR REPLACE FUNCTION trg_marketplace_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := build_marketplace_search_vector(NEW.strategy_id, NEW.description_public);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION trg_strategy_name_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_marketplace_search_vector(NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION trg_tag_mapping_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_marketplace_search_vector(OLD.strategy_id);
+        RETURN OLD;
+    END IF;
+
+    PERFORM refresh_marketplace_search_vector(NEW.strategy_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION trg_tag_name_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_marketplace_search_vector(tm.strategy_id)
+    FROM strategy_tag_mappings tm
+    WHERE tm.tag_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS marketplace_search_vector ON strategy_marketplace;
+CREATE TRIGGER marketplace_search_vector
+    BEFORE INSERT OR UPDATE OF strategy_id, description_public ON strategy_marketplace
+    FOR EACH ROW EXECUTE FUNCTION trg_marketplace_search_vector();
+
+DROP TRIGGER IF EXISTS strategy_name_search_vector ON strategies;
+CREATE TRIGGER strategy_name_search_vector
+    AFTER UPDATE OF name ON strategies
+    FOR EACH ROW EXECUTE FUNCTION trg_strategy_name_search_vector();
+
+DROP TRIGGER IF EXISTS tag_mapping_search_vector ON strategy_tag_mappings;
+CREATE TRIGGER tag_mapping_search_vector
+    AFTER INSERT OR DELETE ON strategy_tag_mappings
+    FOR EACH ROW EXECUTE FUNCTION trg_tag_mapping_search_vector();
+
+DROP TRIGGER IF EXISTS tag_name_search_vector ON strategy_tags;
+CREATE TRIGGER tag_name_search_vector
+    AFTER UPDATE OF name ON strategy_tags
+    FOR EACH ROW EXECUTE FUNCTION trg_tag_name_search_vector();
+
+-- Backfill existing listings
+UPDATE strategy_marketplace m
+SET search_vector = build_marketplace_search_vector(m.strategy_id, m.description_public)
+WHERE m.search_vector IS NULL;
+
+-- Turn a free-text search term into a prefix query: "mom cross" -> 'mom':* & 'cross':*
+-- Returns NULL when the term has no searchable words.
+CREATE OR REPLACE FUNCTION marketplace_search_query(p_search_term VARCHAR)
+RETURNS tsquery AS $$
+DECLARE
+    v_query TEXT;
+BEGIN
+    SELECT string_agg(word || ':*', ' & ')
+    INTO v_query
+    FROM regexp_split_to_table(lower(COALESCE(p_search_term, '')), '[^a-z0-9]+') AS word
+    WHERE word <> '';
+
+    IF v_query IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN to_tsquery('simple', v_query);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- The listing function gains a relevance column, so it has to be dropped before being recreated
+DROP FUNCTION IF EXISTS get_all_marketplace_listings(VARCHAR, NUMERIC, NUMERIC, BOOLEAN, INT[], NUMERIC, VARCHAR, VARCHAR, INT, INT);
+
+-- Get all marketplace listings with full-text search, filtering and sorting.
+-- Listings match on the prefix query or, to tolerate typos, on trigram similarity of the name.
+CREATE OR REPLACE FUNCTION get_all_marketplace_listings(
+    p_search_term VARCHAR DEFAULT NULL,
+    p_min_price NUMERIC DEFAULT NULL,
+    p_max_price NUMERIC DEFAULT NULL,
+    p_is_free BOOLEAN DEFAULT NULL,
+    p_tags INT[] DEFAULT NULL,
+    p_min_rating NUMERIC DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'popularity',
+    p_sort_direction VARCHAR DEFAULT 'DESC',
+    p_limit INT DEFAULT 20,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    name VARCHAR,
+    description_public TEXT,
+    thumbnail_url VARCHAR,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    average_rating FLOAT,
+    reviews_count BIGINT,
+    relevance FLOAT
+) AS $$
+DECLARE
+    v_query tsquery := marketplace_search_query(p_search_term);
+BEGIN
+    -- Validate sort field; relevance only makes sense with a search term
+    IF p_sort_by NOT IN ('relevance', 'popularity', 'rating', 'price', 'newest', 'name') THEN
+        p_sort_by := 'popularity'; -- Default sort by popularity
+    END IF;
+
+    IF p_sort_by = 'relevance' AND v_query IS NULL THEN
+        p_sort_by := 'popularity';
+    END IF;
+
+    -- Validate sort direction
+    IF UPPER(p_sort_direction) NOT IN ('ASC', 'DESC') THEN
+        IF p_sort_by = 'price' THEN
+            p_sort_direction := 'ASC'; -- Default ascending for price
+        ELSE
+            p_sort_direction := 'DESC'; -- Default descending for other fields
+        END IF;
+    ELSE
+        p_sort_direction := UPPER(p_sort_direction);
+    END IF;
+
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        s.name,
+        m.description_public,
+        s.thumbnail_url,
+        m.user_id,
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        m.is_active,
+        m.created_at,
+        m.updated_at,
+        COALESCE(AVG(r.rating), 0)::FLOAT AS average_rating,
+        COUNT(DISTINCT r.id) AS reviews_count,
+        CASE WHEN v_query IS NULL THEN 0
+             ELSE (ts_rank_cd(m.search_vector, v_query) + similarity(s.name, p_search_term))::FLOAT
+        END AS relevance
+    FROM
+        strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.id
+        LEFT JOIN strategy_reviews r ON m.id = r.marketplace_id
+    WHERE
+        m.is_active = TRUE
+        AND s.is_active = TRUE
+        AND (v_query IS NULL OR
+             m.search_vector @@ v_query OR
+             similarity(s.name, p_search_term) >= 0.3)
+        AND (p_min_price IS NULL OR m.price >= p_min_price)
+        AND (p_max_price IS NULL OR m.price <= p_max_price)
+        AND (p_is_free IS NULL OR (p_is_free = TRUE AND m.price = 0) OR (p_is_free = FALSE AND m.price > 0))
+        AND (p_tags IS NULL OR p_tags = '{}' OR EXISTS (
+            SELECT 1 FROM strategy_tag_mappings tm
+            WHERE tm.strategy_id = s.id AND tm.tag_id = ANY(p_tags)
+        ))
+    GROUP BY
+        m.id, m.strategy_id, s.name, m.description_public, s.thumbnail_url, m.user_id,
+        m.price, m.is_subscription, m.subscription_period, m.is_active, m.created_at, m.updated_at,
+        m.search_vector
+    HAVING
+        (p_min_rating IS NULL OR COALESCE(AVG(r.rating), 0) >= p_min_rating)
+    ORDER BY
+        CASE WHEN p_sort_by = 'relevance' AND p_sort_direction = 'DESC' THEN
+            ts_rank_cd(m.search_vector, v_query) + similarity(s.name, p_search_term) END DESC,
+        CASE WHEN p_sort_by = 'relevance' AND p_sort_direction = 'ASC' THEN
+            ts_rank_cd(m.search_vector, v_query) + similarity(s.name, p_search_term) END ASC,
+        CASE WHEN p_sort_by = 'popularity' AND p_sort_direction = 'DESC' THEN COUNT(DISTINCT r.id) END DESC,
+        CASE WHEN p_sort_by = 'popularity' AND p_sort_direction = 'ASC' THEN COUNT(DISTINCT r.id) END ASC,
+        CASE WHEN p_sort_by = 'rating' AND p_sort_direction = 'DESC' THEN COALESCE(AVG(r.rating), 0) END DESC,
+        CASE WHEN p_sort_by = 'rating' AND p_sort_direction = 'ASC' THEN COALESCE(AVG(r.rating), 0) END ASC,
+        CASE WHEN p_sort_by = 'price' AND p_sort_direction = 'ASC' THEN m.price END ASC,
+        CASE WHEN p_sort_by = 'price' AND p_sort_direction = 'DESC' THEN m.price END DESC,
+        CASE WHEN p_sort_by = 'newest' AND p_sort_direction = 'DESC' THEN m.created_at END DESC,
+        CASE WHEN p_sort_by = 'newest' AND p_sort_direction = 'ASC' THEN m.created_at END ASC,
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'ASC' THEN s.name END ASC,
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'DESC' THEN s.name END DESC,
+        m.id DESC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count marketplace listings (same matching rules as get_all_marketplace_listings)
+CREATE OR REPLACE FUNCTION count_marketplace_listings(
+    p_search_term VARCHAR DEFAULT NULL,
+    p_min_price NUMERIC DEFAULT NULL,
+    p_max_price NUMERIC DEFAULT NULL,
+    p_is_free BOOLEAN DEFAULT NULL,
+    p_tags INT[] DEFAULT NULL,
+    p_min_rating NUMERIC DEFAULT NULL
+)
+RETURNS BIGINT AS $$
+DECLARE
+    v_query tsquery := marketplace_search_query(p_search_term);
+    total_count BIGINT;
+BEGIN
+    SELECT COUNT(*)
+    INTO total_count
+    FROM (
+        SELECT
+            m.id
+        FROM
+            strategy_marketplace m
+            JOIN strategies s ON m.strategy_id = s.id
+            LEFT JOIN strategy_reviews r ON m.id = r.marketplace_id
+        WHERE
+            m.is_active = TRUE
+            AND s.is_active = TRUE
+            AND (v_query IS NULL OR
+                 m.search_vector @@ v_query OR
+                 similarity(s.name, p_search_term) >= 0.3)
+            AND (p_min_price IS NULL OR m.price >= p_min_price)
+            AND (p_max_price IS NULL OR m.price <= p_max_price)
+            AND (p_is_free IS NULL OR (p_is_free = TRUE AND m.price = 0) OR (p_is_free = FALSE AND m.price > 0))
+            AND (p_tags IS NULL OR p_tags = '{}' OR EXISTS (
+                SELECT 1 FROM strategy_tag_mappings tm
+                WHERE tm.strategy_id = s.id AND tm.tag_id = ANY(p_tags)
+            ))
+        GROUP BY m.id
+        HAVING
+            (p_min_rating IS NULL OR COALESCE(AVG(r.rating), 0) >= p_min_rating)
+    ) subquery;
+
+    RETURN total_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get facet counts for the marketplace browse filters.
+-- Each facet ignores its own filter so the UI can show alternatives, e.g. the tag
+-- counts reflect the price and rating filters but not the selected tags.
+CREATE OR REPLACE FUNCTION get_marketplace_facets(
+    p_search_term VARCHAR DEFAULT NULL,
+    p_min_price NUMERIC DEFAULT NULL,
+    p_max_price NUMERIC DEFAULT NULL,
+    p_is_free BOOLEAN DEFAULT NULL,
+    p_tags INT[] DEFAULT NULL,
+    p_min_rating NUMERIC DEFAULT NULL
+)
+RETURNS TABLE (
+    facet VARCHAR,
+    facet_key VARCHAR,
+    label VARCHAR,
+    count BIGINT
+) AS $$
+DECLARE
+    v_query tsquery := marketplace_search_query(p_search_term);
+BEGIN
+    RETURN QUERY
+    WITH matched AS (
+        SELECT
+            m.id,
+            s.id AS strategy_id,
+            m.price,
+            COALESCE(AVG(r.rating), 0) AS avg_rating
+        FROM
+            strategy_marketplace m
+            JOIN strategies s ON m.strategy_id = s.id
+            LEFT JOIN strategy_reviews r ON m.id = r.marketplace_id
+        WHERE
+            m.is_active = TRUE
+            AND s.is_active = TRUE
+            AND (v_query IS NULL OR
+                 m.search_vector @@ v_query OR
+                 similarity(s.name, p_search_term) >= 0.3)
+        GROUP BY m.id, s.id, m.price
+    ),
+    flagged AS (
+        SELECT
+            mt.*,
+            ((p_min_price IS NULL OR mt.price >= p_min_price)
+             AND (p_max_price IS NULL OR mt.price <= p_max_price)
+             AND (p_is_free IS NULL OR (p_is_free = TRUE AND mt.price = 0) OR (p_is_free = FALSE AND mt.price > 0))
+            ) AS price_ok,
+            (p_tags IS NULL OR p_tags = '{}' OR EXISTS (
+                SELECT 1 FROM strategy_tag_mappings tm
+                WHERE tm.strategy_id = mt.strategy_id AND tm.tag_id = ANY(p_tags)
+            )) AS tags_ok,
+            (p_min_rating IS NULL OR mt.avg_rating >= p_min_rating) AS rating_ok
+        FROM matched mt
+    )
+    -- Tags
+    SELECT
+        'tag'::VARCHAR,
+        t.id::VARCHAR,
+        t.name::VARCHAR,
+        COUNT(DISTINCT f.id)
+    FROM flagged f
+    JOIN strategy_tag_mappings tm ON tm.strategy_id = f.strategy_id
+    JOIN strategy_tags t ON tm.tag_id = t.id
+    WHERE f.price_ok AND f.rating_ok
+    GROUP BY t.id, t.name
+
+    UNION ALL
+
+    -- Price buckets
+    SELECT
+        'price'::VARCHAR,
+        b.facet_key::VARCHAR,
+        b.label::VARCHAR,
+        COUNT(f.id)
+    FROM (VALUES
+        ('free', 'Free', 0::NUMERIC, 0::NUMERIC, 1),
+        ('0-10', 'Under $10', 0.01, 9.99, 2),
+        ('10-50', '$10 - $50', 10, 49.99, 3),
+        ('50-100', '$50 - $100', 50, 99.99, 4),
+        ('100+', '$100 and up', 100, NULL, 5)
+    ) AS b(facet_key, label, min_price, max_price, sort_order)
+    LEFT JOIN flagged f
+        ON f.tags_ok AND f.rating_ok
+        AND f.price >= b.min_price
+        AND (b.max_price IS NULL OR f.price <= b.max_price)
+    GROUP BY b.facet_key, b.label, b.sort_order
+
+    UNION ALL
+
+    -- Rating buckets (cumulative, matching the min_rating filter)
+    SELECT
+        'rating'::VARCHAR,
+        b.facet_key::VARCHAR,
+        b.label::VARCHAR,
+        COUNT(f.id)
+    FROM (VALUES
+        ('4', '4 stars & up', 4),
+        ('3', '3 stars & up', 3),
+        ('2', '2 stars & up', 2),
+        ('1', '1 star & up', 1)
+    ) AS b(facet_key, label, min_rating)
+    LEFT JOIN flagged f
+        ON f.tags_ok AND f.price_ok
+        AND f.avg_rating >= b.min_rating
+    GROUP BY b.facet_key, b.label, b.min_rating;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
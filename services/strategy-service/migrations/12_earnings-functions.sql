@@ -0,0 +1,217 @@
+-- Strategy Service Earnings Functions
+-- File: 12_earnings-functions.sql
+-- Contains seller analytics: revenue over time, per-listing sales, subscription churn and payout periods
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_strategy_purchases_marketplace_created" ON "strategy_purchases" ("marketplace_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_strategy_marketplace_user" ON "strategy_marketplace" ("user_id");
+
+-- Revenue per period for a seller. p_interval is one of 'day', 'week' or 'month'.
+-- Periods without sales are included with zero revenue.
+CREATE OR REPLACE FUNCTION get_seller_revenue_over_time(
+    p_seller_id INT,
+    p_interval VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    revenue NUMERIC,
+    purchases_count BIGINT,
+    subscriptions_count BIGINT
+) AS $$
+BEGIN
+    IF p_interval NOT IN ('day', 'week', 'month') THEN
+        p_interval := 'day';
+    END IF;
+
+    RETURN QUERY
+    WITH periods AS (
+        SELECT generate_series(
+            date_trunc(p_interval, p_start_date),
+            date_trunc(p_interval, p_end_date),
+            ('1 ' || p_interval)::INTERVAL
+        ) AS period_start
+    ),
+    sales AS (
+        SELECT
+            date_trunc(p_interval, p.created_at) AS period_start,
+            p.purchase_price,
+            m.is_subscription
+        FROM strategy_purchases p
+        JOIN strategy_marketplace m ON p.marketplace_id = m.id
+        WHERE m.user_id = p_seller_id
+        AND p.created_at >= p_start_date
+        AND p.created_at < p_end_date
+    )
+    SELECT
+        pr.period_start,
+        COALESCE(SUM(sa.purchase_price), 0)::NUMERIC,
+        COUNT(sa.purchase_price),
+        COUNT(sa.purchase_price) FILTER (WHERE sa.is_subscription)
+    FROM periods pr
+    LEFT JOIN sales sa ON sa.period_start = pr.period_start
+    GROUP BY pr.period_start
+    ORDER BY pr.period_start;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sales per listing for a seller within a date range
+CREATE OR REPLACE FUNCTION get_seller_listing_sales(
+    p_seller_id INT,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    marketplace_id INT,
+    strategy_id INT,
+    name VARCHAR,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    is_active BOOLEAN,
+    purchases_count BIGINT,
+    revenue NUMERIC,
+    active_subscriptions BIGINT,
+    lifetime_revenue NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        s.name,
+        m.price,
+        m.is_subscription,
+        m.is_active,
+        COUNT(p.id) FILTER (WHERE p.created_at >= p_start_date AND p.created_at < p_end_date),
+        COALESCE(SUM(p.purchase_price) FILTER (WHERE p.created_at >= p_start_date AND p.created_at < p_end_date), 0)::NUMERIC,
+        COUNT(p.id) FILTER (WHERE m.is_subscription AND p.subscription_end > NOW()),
+        COALESCE(SUM(p.purchase_price), 0)::NUMERIC
+    FROM strategy_marketplace m
+    JOIN strategies s ON m.strategy_id = s.id
+    LEFT JOIN strategy_purchases p ON p.marketplace_id = m.id
+    WHERE m.user_id = p_seller_id
+    GROUP BY m.id, m.strategy_id, s.name, m.price, m.is_subscription, m.is_active
+    ORDER BY 8 DESC, m.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Subscription churn per period for a seller. A subscription churns in the period
+-- its subscription_end falls in; churn_rate is churned / active at period start.
+CREATE OR REPLACE FUNCTION get_seller_subscription_churn(
+    p_seller_id INT,
+    p_interval VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    active_at_start BIGINT,
+    new_subscriptions BIGINT,
+    churned BIGINT,
+    churn_rate FLOAT
+) AS $$
+BEGIN
+    IF p_interval NOT IN ('day', 'week', 'month') THEN
+        p_interval := 'month';
+    END IF;
+
+    RETURN QUERY
+    WITH periods AS (
+        SELECT
+            gs AS period_start,
+            gs + ('1 ' || p_interval)::INTERVAL AS period_end
+        FROM generate_series(
+            date_trunc(p_interval, p_start_date),
+            date_trunc(p_interval, p_end_date),
+            ('1 ' || p_interval)::INTERVAL
+        ) AS gs
+    ),
+    subs AS (
+        SELECT p.created_at, p.subscription_end
+        FROM strategy_purchases p
+        JOIN strategy_marketplace m ON p.marketplace_id = m.id
+        WHERE m.user_id = p_seller_id
+        AND m.is_subscription = TRUE
+        AND p.subscription_end IS NOT NULL
+    ),
+    counts AS (
+        SELECT
+            pr.period_start,
+            COUNT(*) FILTER (WHERE su.created_at < pr.period_start AND su.subscription_end >= pr.period_start) AS active_at_start,
+            COUNT(*) FILTER (WHERE su.created_at >= pr.period_start AND su.created_at < pr.period_end) AS new_subscriptions,
+            COUNT(*) FILTER (WHERE su.subscription_end >= pr.period_start AND su.subscription_end < pr.period_end
+                             AND su.subscription_end <= NOW()) AS churned
+        FROM periods pr
+        LEFT JOIN subs su ON su.created_at < pr.period_end
+        GROUP BY pr.period_start
+    )
+    SELECT
+        c.period_start,
+        c.active_at_start,
+        c.new_subscriptions,
+        c.churned,
+        CASE WHEN c.active_at_start = 0 THEN 0
+             ELSE c.churned::FLOAT / c.active_at_start
+        END
+    FROM counts c
+    ORDER BY c.period_start;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Monthly payout summaries for a seller. The platform fee is taken as a percentage
+-- of gross revenue; the current month is still 'open', earlier months are 'closed'.
+CREATE OR REPLACE FUNCTION get_seller_payout_periods(
+    p_seller_id INT,
+    p_fee_percent NUMERIC,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    period_end TIMESTAMP,
+    gross_revenue NUMERIC,
+    platform_fee NUMERIC,
+    net_revenue NUMERIC,
+    purchases_count BIGINT,
+    status VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH periods AS (
+        SELECT
+            gs AS period_start,
+            gs + INTERVAL '1 month' AS period_end
+        FROM generate_series(
+            date_trunc('month', p_start_date),
+            date_trunc('month', p_end_date),
+            INTERVAL '1 month'
+        ) AS gs
+    ),
+    totals AS (
+        SELECT
+            pr.period_start,
+            pr.period_end,
+            COALESCE(SUM(p.purchase_price), 0) AS gross,
+            COUNT(p.id) AS purchases
+        FROM periods pr
+        LEFT JOIN strategy_purchases p
+            ON p.created_at >= pr.period_start
+            AND p.created_at < pr.period_end
+            AND p.marketplace_id IN (SELECT m.id FROM strategy_marketplace m WHERE m.user_id = p_seller_id)
+        GROUP BY pr.period_start, pr.period_end
+    )
+    SELECT
+        t.period_start,
+        t.period_end,
+        t.gross::NUMERIC,
+        ROUND(t.gross * p_fee_percent / 100, 2)::NUMERIC,
+        (t.gross - ROUND(t.gross * p_fee_percent / 100, 2))::NUMERIC,
+        t.purchases,
+        (CASE WHEN t.period_end > NOW() THEN 'open' ELSE 'closed' END)::VARCHAR
+    FROM totals t
+    ORDER BY t.period_start DESC;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,197 @@
+-- Strategy Service Subscription Functions
+-- File: 13_subscription-functions.sql
+-- Contains subscription state tracking, expiry enforcement and renewal reminders
+
+-- +goose Up
+-- +goose StatementBegin
+-- 'active' until the subscription ends; 'cancelled' when the buyer cancels, 'expired' when
+-- the expiry worker finds subscription_end in the past. One-off purchases stay 'active'.
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "status" varchar(20) NOT NULL DEFAULT 'active';
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "renewal_reminder_sent_at" timestamp;
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "expired_at" timestamp;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'strategy_purchases_status_check'
+    ) THEN
+        ALTER TABLE "strategy_purchases"
+            ADD CONSTRAINT "strategy_purchases_status_check" CHECK ("status" IN ('active', 'cancelled', 'expired'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_purchases_subscription_end"
+    ON "strategy_purchases" ("subscription_end")
+    WHERE "subscription_end" IS NOT NULL AND "status" = 'active';
+
+-- Cancel subscription (replaces the version in 07-purchase-functions.sql to record the status)
+CREATE OR REPLACE FUNCTION cancel_subscription(
+    p_user_id INT,
+    p_purchase_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE strategy_purchases
+    SET
+        subscription_end = NOW(),
+        status = 'cancelled'
+    WHERE
+        id = p_purchase_id
+        AND buyer_id = p_user_id
+        AND subscription_end > NOW();
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a purchase with its current subscription state. Visible to the buyer and the seller.
+CREATE OR REPLACE FUNCTION get_purchase_by_id(
+    p_purchase_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id,
+        p.marketplace_id,
+        p.buyer_id,
+        m.user_id,
+        m.strategy_id,
+        s.name,
+        p.strategy_version,
+        p.purchase_price,
+        m.is_subscription,
+        m.subscription_period,
+        p.subscription_end,
+        -- An active subscription past its end date is expired even if the worker hasn't run yet
+        (CASE
+            WHEN p.status = 'active' AND p.subscription_end IS NOT NULL AND p.subscription_end <= NOW() THEN 'expired'
+            ELSE p.status
+        END)::VARCHAR,
+        (p.subscription_end IS NULL OR p.subscription_end > NOW()),
+        (CASE
+            WHEN p.subscription_end IS NULL THEN NULL
+            ELSE GREATEST(0, CEIL(EXTRACT(EPOCH FROM (p.subscription_end - NOW())) / 86400))::INT
+        END),
+        p.renewal_reminder_sent_at,
+        p.expired_at,
+        p.created_at
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    WHERE p.id = p_purchase_id
+    AND (p.buyer_id = p_user_id OR m.user_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Mark active subscriptions past their end date as expired and revoke the buyer's
+-- active version of the strategy, unless another current purchase still grants it.
+-- Returns the expired purchases so the caller can notify buyers.
+CREATE OR REPLACE FUNCTION expire_subscriptions()
+RETURNS TABLE (
+    purchase_id INT,
+    buyer_id INT,
+    marketplace_id INT,
+    strategy_name VARCHAR,
+    subscription_end TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH expired AS (
+        UPDATE strategy_purchases p
+        SET
+            status = 'expired',
+            expired_at = NOW()
+        WHERE p.status = 'active'
+        AND p.subscription_end IS NOT NULL
+        AND p.subscription_end <= NOW()
+        RETURNING p.id, p.buyer_id, p.marketplace_id, p.strategy_version, p.subscription_end
+    ),
+    revoked AS (
+        DELETE FROM user_strategy_versions usv
+        USING expired e, strategies sv
+        WHERE sv.id = e.strategy_version
+        AND usv.user_id = e.buyer_id
+        AND usv.strategy_group_id = sv.strategy_group_id
+        AND usv.active_version_id = e.strategy_version
+        AND sv.user_id <> e.buyer_id
+        AND NOT EXISTS (
+            SELECT 1
+            FROM strategy_purchases p2
+            JOIN strategies s2 ON p2.strategy_version = s2.id
+            WHERE p2.buyer_id = e.buyer_id
+            AND s2.strategy_group_id = sv.strategy_group_id
+            AND p2.id <> e.id
+            AND (p2.subscription_end IS NULL OR p2.subscription_end > NOW())
+        )
+        RETURNING usv.user_id
+    )
+    SELECT
+        e.id,
+        e.buyer_id,
+        e.marketplace_id,
+        s.name,
+        e.subscription_end
+    FROM expired e
+    JOIN strategy_marketplace m ON e.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Claim active subscriptions ending within p_days that haven't been reminded yet.
+-- Marks them as reminded and returns them so each buyer is reminded once per period.
+CREATE OR REPLACE FUNCTION claim_renewal_reminders(p_days INT)
+RETURNS TABLE (
+    purchase_id INT,
+    buyer_id INT,
+    marketplace_id INT,
+    strategy_name VARCHAR,
+    subscription_end TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH due AS (
+        UPDATE strategy_purchases p
+        SET renewal_reminder_sent_at = NOW()
+        WHERE p.status = 'active'
+        AND p.subscription_end IS NOT NULL
+        AND p.subscription_end > NOW()
+        AND p.subscription_end <= NOW() + make_interval(days => p_days)
+        AND (p.renewal_reminder_sent_at IS NULL OR p.renewal_reminder_sent_at < p.subscription_end - make_interval(days => p_days))
+        RETURNING p.id, p.buyer_id, p.marketplace_id, p.subscription_end
+    )
+    SELECT
+        d.id,
+        d.buyer_id,
+        d.marketplace_id,
+        s.name,
+        d.subscription_end
+    FROM due d
+    JOIN strategy_marketplace m ON d.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,163 @@
+-- Strategy Service Listing Backtest Functions
+-- File: 14_listing-backtest-functions.sql
+-- Contains verified backtest snapshots attached to marketplace listings
+
+-- +goose Up
+-- +goose StatementBegin
+-- A snapshot of a completed backtest, copied from the historical service when the seller
+-- attaches it. Metrics are never updated in place; attaching again replaces the whole snapshot.
+CREATE TABLE IF NOT EXISTS "marketplace_backtests" (
+  "id" SERIAL PRIMARY KEY,
+  "marketplace_id" int NOT NULL,
+  "backtest_id" int NOT NULL,
+  "strategy_id" int NOT NULL,
+  "strategy_version" int NOT NULL,
+  "timeframe" varchar(10) NOT NULL,
+  "start_date" timestamp NOT NULL,
+  "end_date" timestamp NOT NULL,
+  "initial_capital" numeric(20,8) NOT NULL,
+  "total_trades" int NOT NULL DEFAULT 0,
+  "win_rate" float NOT NULL DEFAULT 0,
+  "profit_factor" float NOT NULL DEFAULT 0,
+  "sharpe_ratio" float NOT NULL DEFAULT 0,
+  "max_drawdown" float NOT NULL DEFAULT 0,
+  "total_return" float NOT NULL DEFAULT 0,
+  "annualized_return" float NOT NULL DEFAULT 0,
+  "runs" jsonb NOT NULL DEFAULT '[]',
+  "backtest_completed_at" timestamp,
+  "verified_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "marketplace_backtests_listing_unique" UNIQUE ("marketplace_id")
+);
+
+ALTER TABLE "marketplace_backtests" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
+
+-- Attach a verified backtest snapshot to a listing owned by the seller.
+-- Returns the snapshot ID, or NULL if the listing doesn't belong to the seller.
+CREATE OR REPLACE FUNCTION attach_marketplace_backtest(
+    p_marketplace_id INT,
+    p_seller_id INT,
+    p_backtest_id INT,
+    p_strategy_id INT,
+    p_strategy_version INT,
+    p_timeframe VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP,
+    p_initial_capital NUMERIC,
+    p_total_trades INT,
+    p_win_rate FLOAT,
+    p_profit_factor FLOAT,
+    p_sharpe_ratio FLOAT,
+    p_max_drawdown FLOAT,
+    p_total_return FLOAT,
+    p_annualized_return FLOAT,
+    p_runs JSONB,
+    p_backtest_completed_at TIMESTAMP
+)
+RETURNS INT AS $$
+DECLARE
+    v_snapshot_id INT;
+BEGIN
+    PERFORM 1 FROM strategy_marketplace
+    WHERE id = p_marketplace_id AND user_id = p_seller_id;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO marketplace_backtests (
+        marketplace_id, backtest_id, strategy_id, strategy_version, timeframe,
+        start_date, end_date, initial_capital, total_trades, win_rate, profit_factor,
+        sharpe_ratio, max_drawdown, total_return, annualized_return, runs,
+        backtest_completed_at, verified_at
+    )
+    VALUES (
+        p_marketplace_id, p_backtest_id, p_strategy_id, p_strategy_version, p_timeframe,
+        p_start_date, p_end_date, p_initial_capital, p_total_trades, p_win_rate, p_profit_factor,
+        p_sharpe_ratio, p_max_drawdown, p_total_return, p_annualized_return, p_runs,
+        p_backtest_completed_at, NOW()
+    )
+    ON CONFLICT (marketplace_id) DO UPDATE SET
+        backtest_id = EXCLUDED.backtest_id,
+        strategy_id = EXCLUDED.strategy_id,
+        strategy_version = EXCLUDED.strategy_version,
+        timeframe = EXCLUDED.timeframe,
+        start_date = EXCLUDED.start_date,
+        end_date = EXCLUDED.end_date,
+        initial_capital = EXCLUDED.initial_capital,
+        total_trades = EXCLUDED.total_trades,
+        win_rate = EXCLUDED.win_rate,
+        profit_factor = EXCLUDED.profit_factor,
+        sharpe_ratio = EXCLUDED.sharpe_ratio,
+        max_drawdown = EXCLUDED.max_drawdown,
+        total_return = EXCLUDED.total_return,
+        annualized_return = EXCLUDED.annualized_return,
+        runs = EXCLUDED.runs,
+        backtest_completed_at = EXCLUDED.backtest_completed_at,
+        verified_at = NOW()
+    RETURNING id INTO v_snapshot_id;
+
+    RETURN v_snapshot_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the verified backtest snapshot of a listing
+CREATE OR REPLACE FUNCTION get_marketplace_backtest(p_marketplace_id INT)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    backtest_id INT,
+    strategy_id INT,
+    strategy_version INT,
+    timeframe VARCHAR,
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    initial_capital NUMERIC,
+    total_trades INT,
+    win_rate FLOAT,
+    profit_factor FLOAT,
+    sharpe_ratio FLOAT,
+    max_drawdown FLOAT,
+    total_return FLOAT,
+    annualized_return FLOAT,
+    runs JSONB,
+    backtest_completed_at TIMESTAMP,
+    verified_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        mb.id,
+        mb.marketplace_id,
+        mb.backtest_id,
+        mb.strategy_id,
+        mb.strategy_version,
+        mb.timeframe,
+        mb.start_date,
+        mb.end_date,
+        mb.initial_capital,
+        mb.total_trades,
+        mb.win_rate,
+        mb.profit_factor,
+        mb.sharpe_ratio,
+        mb.max_drawdown,
+        mb.total_return,
+        mb.annualized_return,
+        mb.runs,
+        mb.backtest_completed_at,
+        mb.verified_at
+    FROM marketplace_backtests mb
+    WHERE mb.marketplace_id = p_marketplace_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get which of the given listings carry a verified backtest, for list badges
+CREATE OR REPLACE FUNCTION get_verified_marketplace_ids(p_marketplace_ids INT[])
+RETURNS TABLE (marketplace_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT mb.marketplace_id
+    FROM marketplace_backtests mb
+    WHERE mb.marketplace_id = ANY(p_marketplace_ids);
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- Strategy Service Statistics Functions
+-- File: 15_stats-functions.sql
+-- Contains platform-wide strategy and marketplace KPIs for the admin dashboard
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_strategies_created_at" ON "strategies" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_strategy_purchases_created_at" ON "strategy_purchases" ("created_at");
+
+-- Strategy and marketplace KPIs. Created counts, purchases and GMV (gross value of
+-- purchases) cover activity since p_since; the rest are current totals.
+CREATE OR REPLACE FUNCTION get_strategy_stats(p_since TIMESTAMP)
+RETURNS TABLE (
+    total_strategies BIGINT,
+    strategies_created BIGINT,
+    public_strategies BIGINT,
+    active_listings BIGINT,
+    listings_created BIGINT,
+    purchases BIGINT,
+    gmv NUMERIC,
+    unique_buyers BIGINT,
+    active_subscriptions BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.total_strategies,
+        s.strategies_created,
+        s.public_strategies,
+        m.active_listings,
+        m.listings_created,
+        p.purchases,
+        p.gmv,
+        p.unique_buyers,
+        sub.active_subscriptions
+    FROM
+        (SELECT
+            COUNT(*) AS total_strategies,
+            COUNT(*) FILTER (WHERE st.created_at >= p_since) AS strategies_created,
+            COUNT(*) FILTER (WHERE st.is_public) AS public_strategies
+         FROM strategies st
+         WHERE st.is_active) s,
+        (SELECT
+            COUNT(*) FILTER (WHERE sm.is_active) AS active_listings,
+            COUNT(*) FILTER (WHERE sm.created_at >= p_since) AS listings_created
+         FROM strategy_marketplace sm) m,
+        (SELECT
+            COUNT(*) AS purchases,
+            COALESCE(SUM(sp.purchase_price), 0)::NUMERIC AS gmv,
+            COUNT(DISTINCT sp.buyer_id) AS unique_buyers
+         FROM strategy_purchases sp
+         WHERE sp.created_at >= p_since) p,
+        (SELECT COUNT(*) AS active_subscriptions
+         FROM strategy_purchases sp
+         WHERE sp.status = 'active'
+           AND sp.subscription_end IS NOT NULL
+           AND sp.subscription_end > NOW()) sub;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,287 @@
+-- Strategy Service Custom Indicator Functions
+-- File: 16_custom-indicators.sql
+-- Contains schema changes and access-aware queries for user-defined indicators
+
+-- +goose Up
+-- +goose StatementBegin
+-- Indicators without an owner are platform indicators. User-defined indicators belong
+-- to their owner and are only visible to others once made public.
+ALTER TABLE "indicators"
+  ADD COLUMN IF NOT EXISTS "owner_id" int,
+  ADD COLUMN IF NOT EXISTS "visibility" varchar(20) NOT NULL DEFAULT 'public',
+  ADD COLUMN IF NOT EXISTS "validated_at" timestamp;
+
+ALTER TABLE "indicators" DROP CONSTRAINT IF EXISTS "indicators_visibility_check";
+ALTER TABLE "indicators"
+  ADD CONSTRAINT "indicators_visibility_check" CHECK (visibility IN ('public', 'private'));
+
+-- Names are unique among platform indicators and per owner, so users can pick names freely
+ALTER TABLE "indicators" DROP CONSTRAINT IF EXISTS "indicators_name_key";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_indicators_platform_name" ON "indicators" ("name") WHERE owner_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_indicators_owner_name" ON "indicators" ("owner_id", "name") WHERE owner_id IS NOT NULL;
+
+-- The result rows now include ownership, and the queries take the requesting user
+DROP FUNCTION IF EXISTS get_indicators(VARCHAR, VARCHAR[], BOOLEAN, BOOLEAN, VARCHAR, VARCHAR, INT, INT);
+DROP FUNCTION IF EXISTS get_indicator_by_id(INT, BOOLEAN);
+DROP FUNCTION IF EXISTS count_indicators(VARCHAR, VARCHAR[], BOOLEAN);
+
+-- Get the indicators visible to a user: platform indicators, public custom indicators
+-- and the user's own. Admins see every indicator.
+CREATE OR REPLACE FUNCTION get_indicators(
+    p_search VARCHAR,
+    p_categories VARCHAR[],
+    p_active BOOLEAN = NULL,
+    p_is_admin BOOLEAN = FALSE,
+    p_sort_by VARCHAR = 'name',
+    p_sort_direction VARCHAR = 'ASC',
+    p_limit INT DEFAULT 20,
+    p_offset INT DEFAULT 0,
+    p_user_id INT DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    description TEXT,
+    category VARCHAR(50),
+    formula TEXT,
+    min_value FLOAT,
+    max_value FLOAT,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    parameters JSONB,
+    owner_id INT,
+    visibility VARCHAR(20)
+) AS $$
+BEGIN
+    -- Validate sort field
+    IF p_sort_by NOT IN ('name', 'category', 'created_at', 'updated_at') THEN
+        p_sort_by := 'name';
+    END IF;
+
+    -- Normalize sort direction
+    p_sort_direction := UPPER(p_sort_direction);
+    IF p_sort_direction NOT IN ('ASC', 'DESC') THEN
+        p_sort_direction := 'ASC';
+    END IF;
+
+    RETURN QUERY
+    SELECT
+        i.id,
+        i.name,
+        i.description,
+        i.category,
+        i.formula,
+        i.min_value,
+        i.max_value,
+        i.is_active,
+        i.created_at,
+        i.updated_at,
+        COALESCE(
+            (SELECT jsonb_agg(jsonb_build_object(
+                'id', p.id,
+                'name', p.parameter_name,
+                'type', p.parameter_type,
+                'is_required', p.is_required,
+                'min_value', p.min_value,
+                'max_value', p.max_value,
+                'default_value', p.default_value,
+                'description', p.description,
+                'is_public', p.is_public,
+                'enum_values', COALESCE(
+                    (SELECT jsonb_agg(jsonb_build_object(
+                        'id', ev.id,
+                        'enum_value', ev.enum_value,
+                        'display_name', ev.display_name
+                    ))
+                    FROM parameter_enum_values ev
+                    WHERE ev.parameter_id = p.id), '[]'::jsonb)
+            ))
+            FROM indicator_parameters p
+            WHERE p.indicator_id = i.id
+              -- Owners always see the parameters of their own indicators
+              AND (p_is_admin OR p.is_public OR i.owner_id = p_user_id)
+            ), '[]'::jsonb
+        ) AS parameters,
+        i.owner_id,
+        i.visibility
+    FROM
+        indicators i
+    WHERE
+        (p_search IS NULL OR i.name ILIKE '%' || p_search || '%' OR i.description ILIKE '%' || p_search || '%')
+        AND (p_categories IS NULL OR array_length(p_categories, 1) IS NULL OR i.category = ANY(p_categories))
+        AND (p_active IS NULL OR i.is_active = p_active)
+        AND (p_is_admin OR i.owner_id IS NULL OR i.visibility = 'public' OR i.owner_id = p_user_id)
+    ORDER BY
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'ASC' THEN i.name END ASC,
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'DESC' THEN i.name END DESC,
+        CASE WHEN p_sort_by = 'category' AND p_sort_direction = 'ASC' THEN i.category END ASC,
+        CASE WHEN p_sort_by = 'category' AND p_sort_direction = 'DESC' THEN i.category END DESC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'ASC' THEN i.created_at END ASC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'DESC' THEN i.created_at END DESC,
+        CASE WHEN p_sort_by = 'updated_at' AND p_sort_direction = 'ASC' THEN i.updated_at END ASC,
+        CASE WHEN p_sort_by = 'updated_at' AND p_sort_direction = 'DESC' THEN i.updated_at END DESC,
+        -- Platform indicators before custom ones of the same name
+        i.owner_id NULLS FIRST
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION count_indicators(
+    p_search VARCHAR,
+    p_categories VARCHAR[],
+    p_active BOOLEAN = NULL,
+    p_is_admin BOOLEAN = FALSE,
+    p_user_id INT DEFAULT NULL
+)
+RETURNS BIGINT AS $$
+DECLARE
+    indicator_count BIGINT;
+BEGIN
+    SELECT COUNT(*)
+    INTO indicator_count
+    FROM indicators i
+    WHERE
+        (p_search IS NULL OR i.name ILIKE '%' || p_search || '%' OR i.description ILIKE '%' || p_search || '%')
+        AND (p_categories IS NULL OR array_length(p_categories, 1) IS NULL OR i.category = ANY(p_categories))
+        AND (p_active IS NULL OR i.is_active = p_active)
+        AND (p_is_admin OR i.owner_id IS NULL OR i.visibility = 'public' OR i.owner_id = p_user_id);
+
+    RETURN indicator_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get an indicator by ID if it is visible to the user
+CREATE OR REPLACE FUNCTION get_indicator_by_id(
+    p_indicator_id INT,
+    p_is_admin BOOLEAN = FALSE,
+    p_user_id INT DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    description TEXT,
+    category VARCHAR(50),
+    formula TEXT,
+    min_value FLOAT,
+    max_value FLOAT,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    parameters JSONB,
+    owner_id INT,
+    visibility VARCHAR(20)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        i.id,
+        i.name,
+        i.description,
+        i.category,
+        i.formula,
+        i.min_value,
+        i.max_value,
+        i.is_active,
+        i.created_at,
+        i.updated_at,
+        COALESCE(
+            (SELECT jsonb_agg(jsonb_build_object(
+                'id', p.id,
+                'name', p.parameter_name,
+                'type', p.parameter_type,
+                'is_required', p.is_required,
+                'min_value', p.min_value,
+                'max_value', p.max_value,
+                'default_value', p.default_value,
+                'description', p.description,
+                'is_public', p.is_public,
+                'enum_values', COALESCE(
+                    (SELECT jsonb_agg(jsonb_build_object(
+                        'id', ev.id,
+                        'enum_value', ev.enum_value,
+                        'display_name', ev.display_name
+                    ))
+                    FROM parameter_enum_values ev
+                    WHERE ev.parameter_id = p.id), '[]'::jsonb)
+            ))
+            FROM indicator_parameters p
+            WHERE p.indicator_id = i.id
+              AND (p_is_admin OR p.is_public OR i.owner_id = p_user_id)
+            ), '[]'::jsonb
+        ) AS parameters,
+        i.owner_id,
+        i.visibility
+    FROM
+        indicators i
+    WHERE
+        i.id = p_indicator_id
+        AND (p_is_admin OR i.owner_id IS NULL OR i.visibility = 'public' OR i.owner_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Custom indicators named in a strategy structure that the user may use. When a public
+-- indicator and the user's own share a name, the user's own wins.
+CREATE OR REPLACE FUNCTION get_usable_custom_indicators(
+    p_user_id INT,
+    p_names VARCHAR[]
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    formula TEXT,
+    owner_id INT,
+    parameters JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT DISTINCT ON (i.name)
+        i.id,
+        i.name,
+        i.formula,
+        i.owner_id,
+        COALESCE(
+            (SELECT jsonb_agg(jsonb_build_object(
+                'name', p.parameter_name,
+                'default', p.default_value
+            ))
+            FROM indicator_parameters p
+            WHERE p.indicator_id = i.id
+            ), '[]'::jsonb
+        ) AS parameters
+    FROM
+        indicators i
+    WHERE
+        i.owner_id IS NOT NULL
+        AND i.is_active
+        AND i.name = ANY(p_names)
+        AND (i.owner_id = p_user_id OR i.visibility = 'public')
+    ORDER BY
+        i.name,
+        (i.owner_id = p_user_id) DESC,
+        i.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Categories only count indicators everyone can see
+CREATE OR REPLACE FUNCTION get_indicator_categories()
+RETURNS TABLE (
+    category VARCHAR(50),
+    count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        COALESCE(i.category, 'Uncategorized') AS category,
+        COUNT(*) AS count
+    FROM
+        indicators i
+    WHERE
+        i.owner_id IS NULL OR i.visibility = 'public'
+    GROUP BY
+        COALESCE(i.category, 'Uncategorized')
+    ORDER BY
+        category;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,177 @@
+-- Strategy Service Marketplace Cursor Functions
+-- File: 17_marketplace-cursor.sql
+-- Contains keyset pagination for marketplace listings
+
+-- +goose Up
+-- +goose StatementBegin
+-- Get the marketplace listings that come after a cursor, with the same matching and
+-- order as get_all_marketplace_listings. The cursor is the sort_value and id of the last
+-- listing of the previous page; pass NULLs for the first page.
+CREATE OR REPLACE FUNCTION get_marketplace_listings_after(
+    p_search_term VARCHAR DEFAULT NULL,
+    p_min_price NUMERIC DEFAULT NULL,
+    p_max_price NUMERIC DEFAULT NULL,
+    p_is_free BOOLEAN DEFAULT NULL,
+    p_tags INT[] DEFAULT NULL,
+    p_min_rating NUMERIC DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'popularity',
+    p_sort_direction VARCHAR DEFAULT 'DESC',
+    p_after_value TEXT DEFAULT NULL,
+    p_after_id INT DEFAULT NULL,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    name VARCHAR,
+    description_public TEXT,
+    thumbnail_url VARCHAR,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    average_rating FLOAT,
+    reviews_count BIGINT,
+    relevance FLOAT,
+    sort_value TEXT
+) AS $$
+DECLARE
+    v_query tsquery := marketplace_search_query(p_search_term);
+    -- Unused sort keys are constant so whole rows can be compared
+    v_after_number FLOAT := 0;
+    v_after_time TIMESTAMP := '-infinity';
+    v_after_text VARCHAR := '';
+BEGIN
+    -- Validate sort field; relevance only makes sense with a search term
+    IF p_sort_by NOT IN ('relevance', 'popularity', 'rating', 'price', 'newest', 'name') THEN
+        p_sort_by := 'popularity';
+    END IF;
+
+    IF p_sort_by = 'relevance' AND v_query IS NULL THEN
+        p_sort_by := 'popularity';
+    END IF;
+
+    -- Validate sort direction
+    IF UPPER(p_sort_direction) NOT IN ('ASC', 'DESC') THEN
+        IF p_sort_by = 'price' THEN
+            p_sort_direction := 'ASC';
+        ELSE
+            p_sort_direction := 'DESC';
+        END IF;
+    ELSE
+        p_sort_direction := UPPER(p_sort_direction);
+    END IF;
+
+    IF p_after_id IS NOT NULL THEN
+        IF p_sort_by = 'newest' THEN
+            v_after_time := p_after_value::TIMESTAMP;
+        ELSIF p_sort_by = 'name' THEN
+            v_after_text := p_after_value;
+        ELSE
+            v_after_number := p_after_value::FLOAT;
+        END IF;
+    END IF;
+
+    RETURN QUERY
+    WITH listings AS (
+        SELECT
+            m.id,
+            m.strategy_id,
+            s.name,
+            m.description_public,
+            s.thumbnail_url,
+            m.user_id,
+            m.price,
+            m.is_subscription,
+            m.subscription_period,
+            m.is_active,
+            m.created_at,
+            m.updated_at,
+            COALESCE(AVG(r.rating), 0)::FLOAT AS average_rating,
+            COUNT(DISTINCT r.id) AS reviews_count,
+            CASE WHEN v_query IS NULL THEN 0
+                 ELSE (ts_rank_cd(m.search_vector, v_query) + similarity(s.name, p_search_term))::FLOAT
+            END AS relevance
+        FROM
+            strategy_marketplace m
+            JOIN strategies s ON m.strategy_id = s.id
+            LEFT JOIN strategy_reviews r ON m.id = r.marketplace_id
+        WHERE
+            m.is_active = TRUE
+            AND s.is_active = TRUE
+            AND (v_query IS NULL OR
+                 m.search_vector @@ v_query OR
+                 similarity(s.name, p_search_term) >= 0.3)
+            AND (p_min_price IS NULL OR m.price >= p_min_price)
+            AND (p_max_price IS NULL OR m.price <= p_max_price)
+            AND (p_is_free IS NULL OR (p_is_free = TRUE AND m.price = 0) OR (p_is_free = FALSE AND m.price > 0))
+            AND (p_tags IS NULL OR p_tags = '{}' OR EXISTS (
+                SELECT 1 FROM strategy_tag_mappings tm
+                WHERE tm.strategy_id = s.id AND tm.tag_id = ANY(p_tags)
+            ))
+        GROUP BY
+            m.id, m.strategy_id, s.name, m.description_public, s.thumbnail_url, m.user_id,
+            m.price, m.is_subscription, m.subscription_period, m.is_active, m.created_at, m.updated_at,
+            m.search_vector
+        HAVING
+            (p_min_rating IS NULL OR COALESCE(AVG(r.rating), 0) >= p_min_rating)
+    ),
+    sorted AS (
+        SELECT
+            l.*,
+            CASE p_sort_by
+                WHEN 'relevance' THEN l.relevance
+                WHEN 'popularity' THEN l.reviews_count::FLOAT
+                WHEN 'rating' THEN l.average_rating
+                WHEN 'price' THEN l.price::FLOAT
+                ELSE 0
+            END AS sort_number,
+            CASE WHEN p_sort_by = 'newest' THEN COALESCE(l.created_at, '-infinity') ELSE '-infinity' END AS sort_time,
+            CASE WHEN p_sort_by = 'name' THEN l.name ELSE '' END AS sort_text
+        FROM listings l
+    )
+    SELECT
+        st.id,
+        st.strategy_id,
+        st.name,
+        st.description_public,
+        st.thumbnail_url,
+        st.user_id,
+        st.price,
+        st.is_subscription,
+        st.subscription_period,
+        st.is_active,
+        st.created_at,
+        st.updated_at,
+        st.average_rating,
+        st.reviews_count,
+        st.relevance,
+        CASE p_sort_by
+            WHEN 'newest' THEN st.sort_time::TEXT
+            WHEN 'name' THEN st.sort_text::TEXT
+            ELSE st.sort_number::TEXT
+        END AS sort_value
+    FROM
+        sorted st
+    WHERE
+        -- Ties are always broken by newest listing first, whatever the direction
+        p_after_id IS NULL
+        OR (p_sort_direction = 'ASC' AND
+            (st.sort_number, st.sort_time, st.sort_text, -st.id) > (v_after_number, v_after_time, v_after_text, -p_after_id))
+        OR (p_sort_direction = 'DESC' AND
+            (st.sort_number, st.sort_time, st.sort_text, st.id) < (v_after_number, v_after_time, v_after_text, p_after_id))
+    ORDER BY
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_number END ASC,
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_time END ASC,
+        CASE WHEN p_sort_direction = 'ASC' THEN st.sort_text END ASC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_number END DESC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_time END DESC,
+        CASE WHEN p_sort_direction = 'DESC' THEN st.sort_text END DESC,
+        st.id DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
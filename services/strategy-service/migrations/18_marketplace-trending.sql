@@ -0,0 +1,238 @@
+-- Strategy Service Marketplace Trending Functions
+-- File: 18_marketplace-trending.sql
+-- Contains listing view and purchase tracking, trending scores and personalized recommendations
+
+-- +goose Up
+-- +goose StatementBegin
+-- Listing views and purchases consumed from Kafka. event_key (topic:partition:offset)
+-- makes redelivered events a no-op. user_id is NULL for anonymous views.
+CREATE TABLE IF NOT EXISTS "marketplace_listing_events" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "marketplace_id" int NOT NULL,
+  "user_id" int,
+  "event_type" varchar(20) NOT NULL,
+  "event_key" varchar(100) NOT NULL UNIQUE,
+  "occurred_at" timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_marketplace_listing_events_occurred_at" ON "marketplace_listing_events" ("occurred_at");
+CREATE INDEX IF NOT EXISTS "idx_marketplace_listing_events_user" ON "marketplace_listing_events" ("user_id", "event_type", "occurred_at");
+CREATE INDEX IF NOT EXISTS "idx_marketplace_listing_events_listing" ON "marketplace_listing_events" ("marketplace_id", "user_id", "occurred_at");
+
+ALTER TABLE "marketplace_listing_events" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
+
+-- Record a listing view or purchase. Repeated views of a listing by the same user within
+-- an hour count once. Returns FALSE if the event was not recorded.
+CREATE OR REPLACE FUNCTION record_listing_event(
+    p_marketplace_id INT,
+    p_user_id INT,
+    p_event_type VARCHAR(20),
+    p_event_key VARCHAR(100),
+    p_occurred_at TIMESTAMP
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_id BIGINT;
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM strategy_marketplace WHERE id = p_marketplace_id) THEN
+        RETURN FALSE;
+    END IF;
+
+    IF p_event_type = 'view' AND p_user_id IS NOT NULL AND EXISTS (
+        SELECT 1 FROM marketplace_listing_events e
+        WHERE e.marketplace_id = p_marketplace_id
+          AND e.user_id = p_user_id
+          AND e.event_type = 'view'
+          AND e.occurred_at > p_occurred_at - INTERVAL '1 hour'
+          AND e.occurred_at <= p_occurred_at
+    ) THEN
+        RETURN FALSE;
+    END IF;
+
+    INSERT INTO marketplace_listing_events (marketplace_id, user_id, event_type, event_key, occurred_at)
+    VALUES (p_marketplace_id, p_user_id, p_event_type, p_event_key, p_occurred_at)
+    ON CONFLICT (event_key) DO NOTHING
+    RETURNING id INTO v_id;
+
+    RETURN v_id IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Trending score of every active listing with events since p_since. Each event adds its
+-- weight, halved for every p_half_life_hours of age.
+CREATE OR REPLACE FUNCTION listing_trending_scores(
+    p_since TIMESTAMP,
+    p_half_life_hours FLOAT,
+    p_view_weight FLOAT,
+    p_purchase_weight FLOAT
+)
+RETURNS TABLE (
+    marketplace_id INT,
+    trending_score FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        e.marketplace_id,
+        SUM(
+            CASE e.event_type WHEN 'purchase' THEN p_purchase_weight ELSE p_view_weight END
+            * POWER(0.5, EXTRACT(EPOCH FROM (NOW() - e.occurred_at)) / 3600 / p_half_life_hours)
+        )::FLOAT AS trending_score
+    FROM marketplace_listing_events e
+    WHERE e.occurred_at >= p_since
+    GROUP BY e.marketplace_id;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Get the most trending active listings
+CREATE OR REPLACE FUNCTION get_trending_listings(
+    p_since TIMESTAMP,
+    p_half_life_hours FLOAT,
+    p_view_weight FLOAT,
+    p_purchase_weight FLOAT,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    name VARCHAR,
+    description_public TEXT,
+    thumbnail_url VARCHAR,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    average_rating FLOAT,
+    reviews_count BIGINT,
+    relevance FLOAT,
+    trending_score FLOAT,
+    match_score FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        s.name,
+        m.description_public,
+        s.thumbnail_url,
+        m.user_id,
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        m.is_active,
+        m.created_at,
+        m.updated_at,
+        COALESCE((SELECT AVG(r.rating) FROM strategy_reviews r WHERE r.marketplace_id = m.id), 0)::FLOAT,
+        (SELECT COUNT(*) FROM strategy_reviews r WHERE r.marketplace_id = m.id),
+        0::FLOAT,
+        t.trending_score,
+        0::FLOAT
+    FROM
+        listing_trending_scores(p_since, p_half_life_hours, p_view_weight, p_purchase_weight) t
+        JOIN strategy_marketplace m ON m.id = t.marketplace_id
+        JOIN strategies s ON s.id = m.strategy_id
+    WHERE
+        m.is_active = TRUE
+        AND s.is_active = TRUE
+    ORDER BY t.trending_score DESC, m.id DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Get active listings for a user, ranked by how well their tags match the tags of the
+-- strategies the user bought (weight 3) or viewed since p_since (weight 1), then by trending
+-- score. The user's own listings and listings they already bought are left out. Returns no
+-- rows for users without purchases or views.
+CREATE OR REPLACE FUNCTION get_recommended_listings(
+    p_user_id INT,
+    p_since TIMESTAMP,
+    p_half_life_hours FLOAT,
+    p_view_weight FLOAT,
+    p_purchase_weight FLOAT,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    name VARCHAR,
+    description_public TEXT,
+    thumbnail_url VARCHAR,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    average_rating FLOAT,
+    reviews_count BIGINT,
+    relevance FLOAT,
+    trending_score FLOAT,
+    match_score FLOAT
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH purchased AS (
+        SELECT DISTINCT p.marketplace_id
+        FROM strategy_purchases p
+        WHERE p.buyer_id = p_user_id
+    ),
+    interactions AS (
+        SELECT pu.marketplace_id, 3.0 AS weight FROM purchased pu
+        UNION ALL
+        SELECT e.marketplace_id, 1.0 AS weight
+        FROM marketplace_listing_events e
+        WHERE e.user_id = p_user_id AND e.event_type = 'view' AND e.occurred_at >= p_since
+    ),
+    tag_profile AS (
+        SELECT tm.tag_id, SUM(i.weight) AS weight
+        FROM interactions i
+        JOIN strategy_marketplace m ON m.id = i.marketplace_id
+        JOIN strategy_tag_mappings tm ON tm.strategy_id = m.strategy_id
+        GROUP BY tm.tag_id
+    ),
+    candidates AS (
+        SELECT m.id AS marketplace_id, SUM(tp.weight)::FLOAT AS match_score
+        FROM strategy_marketplace m
+        JOIN strategies s ON s.id = m.strategy_id
+        JOIN strategy_tag_mappings tm ON tm.strategy_id = m.strategy_id
+        JOIN tag_profile tp ON tp.tag_id = tm.tag_id
+        WHERE m.is_active = TRUE
+          AND s.is_active = TRUE
+          AND m.user_id <> p_user_id
+          AND m.id NOT IN (SELECT pu.marketplace_id FROM purchased pu)
+        GROUP BY m.id
+    )
+    SELECT
+        m.id,
+        m.strategy_id,
+        s.name,
+        m.description_public,
+        s.thumbnail_url,
+        m.user_id,
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        m.is_active,
+        m.created_at,
+        m.updated_at,
+        COALESCE((SELECT AVG(r.rating) FROM strategy_reviews r WHERE r.marketplace_id = m.id), 0)::FLOAT,
+        (SELECT COUNT(*) FROM strategy_reviews r WHERE r.marketplace_id = m.id),
+        0::FLOAT,
+        COALESCE(t.trending_score, 0)::FLOAT,
+        c.match_score
+    FROM
+        candidates c
+        JOIN strategy_marketplace m ON m.id = c.marketplace_id
+        JOIN strategies s ON s.id = m.strategy_id
+        LEFT JOIN listing_trending_scores(p_since, p_half_life_hours, p_view_weight, p_purchase_weight) t
+            ON t.marketplace_id = m.id
+    ORDER BY c.match_score DESC, COALESCE(t.trending_score, 0) DESC, m.id DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,386 @@
+-- Strategy Service Marketplace Coupon Functions
+-- File: 19_marketplace-coupons.sql
+-- Contains seller discount codes for listings and coupon redemption at purchase time
+
+-- +goose Up
+-- +goose StatementBegin
+-- Discount codes created by sellers for their listings. Codes are stored uppercase and are
+-- unique per listing. A percent discount_value is 0-100; a fixed one is an amount off the price.
+CREATE TABLE IF NOT EXISTS "marketplace_coupons" (
+  "id" SERIAL PRIMARY KEY,
+  "marketplace_id" int NOT NULL,
+  "code" varchar(32) NOT NULL,
+  "discount_type" varchar(10) NOT NULL CHECK ("discount_type" IN ('percent', 'fixed')),
+  "discount_value" numeric(10,2) NOT NULL CHECK ("discount_value" > 0),
+  "max_redemptions" int CHECK ("max_redemptions" > 0),
+  "redemptions_count" int NOT NULL DEFAULT 0,
+  "expires_at" timestamp,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp,
+  UNIQUE ("marketplace_id", "code")
+);
+
+ALTER TABLE "marketplace_coupons" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
+
+-- Purchases record the list price and the discount; purchase_price stays what the buyer paid
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "original_price" numeric(10,2);
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "discount_amount" numeric(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "coupon_id" int;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'strategy_purchases_coupon_id_fkey'
+    ) THEN
+        ALTER TABLE "strategy_purchases"
+            ADD CONSTRAINT "strategy_purchases_coupon_id_fkey"
+            FOREIGN KEY ("coupon_id") REFERENCES "marketplace_coupons" ("id") ON DELETE SET NULL;
+    END IF;
+END $$;
+
+UPDATE strategy_purchases SET original_price = purchase_price WHERE original_price IS NULL;
+
+-- Create a coupon for a listing owned by the user
+CREATE OR REPLACE FUNCTION create_marketplace_coupon(
+    p_user_id INT,
+    p_marketplace_id INT,
+    p_code VARCHAR(32),
+    p_discount_type VARCHAR(10),
+    p_discount_value NUMERIC,
+    p_max_redemptions INT,
+    p_expires_at TIMESTAMP
+)
+RETURNS INT AS $$
+DECLARE
+    new_coupon_id INT;
+BEGIN
+    PERFORM 1 FROM strategy_marketplace
+    WHERE id = p_marketplace_id AND user_id = p_user_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Listing not found or not owned by user';
+    END IF;
+
+    PERFORM 1 FROM marketplace_coupons
+    WHERE marketplace_id = p_marketplace_id AND code = UPPER(p_code);
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Coupon code already exists for this listing';
+    END IF;
+
+    INSERT INTO marketplace_coupons (
+        marketplace_id,
+        code,
+        discount_type,
+        discount_value,
+        max_redemptions,
+        expires_at,
+        created_at
+    )
+    VALUES (
+        p_marketplace_id,
+        UPPER(p_code),
+        p_discount_type,
+        p_discount_value,
+        p_max_redemptions,
+        p_expires_at,
+        NOW()
+    )
+    RETURNING id INTO new_coupon_id;
+
+    RETURN new_coupon_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the coupons of a listing, newest first
+CREATE OR REPLACE FUNCTION get_marketplace_coupons(p_marketplace_id INT)
+RETURNS SETOF marketplace_coupons AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM marketplace_coupons c
+    WHERE c.marketplace_id = p_marketplace_id
+    ORDER BY c.created_at DESC, c.id DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a coupon of a listing by ID
+CREATE OR REPLACE FUNCTION get_marketplace_coupon_by_id(
+    p_marketplace_id INT,
+    p_coupon_id INT
+)
+RETURNS SETOF marketplace_coupons AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM marketplace_coupons c
+    WHERE c.marketplace_id = p_marketplace_id AND c.id = p_coupon_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a coupon of a listing by code (case-insensitive)
+CREATE OR REPLACE FUNCTION get_marketplace_coupon_by_code(
+    p_marketplace_id INT,
+    p_code VARCHAR(32)
+)
+RETURNS SETOF marketplace_coupons AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM marketplace_coupons c
+    WHERE c.marketplace_id = p_marketplace_id AND c.code = UPPER(p_code);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a coupon of a listing owned by the user. The code can't be changed.
+CREATE OR REPLACE FUNCTION update_marketplace_coupon(
+    p_user_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT,
+    p_discount_type VARCHAR(10),
+    p_discount_value NUMERIC,
+    p_max_redemptions INT,
+    p_expires_at TIMESTAMP,
+    p_is_active BOOLEAN
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE marketplace_coupons c
+    SET
+        discount_type = p_discount_type,
+        discount_value = p_discount_value,
+        max_redemptions = p_max_redemptions,
+        expires_at = p_expires_at,
+        is_active = p_is_active,
+        updated_at = NOW()
+    FROM strategy_marketplace m
+    WHERE
+        c.id = p_coupon_id
+        AND c.marketplace_id = p_marketplace_id
+        AND m.id = c.marketplace_id
+        AND m.user_id = p_user_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a coupon of a listing owned by the user. Purchases that used it keep their
+-- recorded prices.
+CREATE OR REPLACE FUNCTION delete_marketplace_coupon(
+    p_user_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM marketplace_coupons c
+    USING strategy_marketplace m
+    WHERE
+        c.id = p_coupon_id
+        AND c.marketplace_id = p_marketplace_id
+        AND m.id = c.marketplace_id
+        AND m.user_id = p_user_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Purchase a strategy (replaces the version in 07_purchase-functions.sql). With a coupon,
+-- the coupon is redeemed in the same transaction and p_discount, computed by the service,
+-- is taken off the list price. The redemption fails if the coupon stopped being valid
+-- since it was checked or reached its usage limit.
+DROP FUNCTION IF EXISTS purchase_strategy(INT, INT);
+
+CREATE OR REPLACE FUNCTION purchase_strategy(
+    p_buyer_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT DEFAULT NULL,
+    p_discount NUMERIC DEFAULT 0
+)
+RETURNS INT AS $$
+DECLARE
+    new_purchase_id INT;
+    v_listing RECORD;
+    v_discount NUMERIC := 0;
+BEGIN
+    -- Get the listing and the strategy version being sold
+    SELECT
+        m.price,
+        m.version_id AS version_number,
+        m.is_subscription,
+        m.subscription_period,
+        s.user_id AS seller_id,
+        s.id AS strategy_version_id,
+        s.strategy_group_id
+    INTO v_listing
+    FROM
+        strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.strategy_group_id
+    WHERE
+        m.id = p_marketplace_id
+        AND m.is_active = TRUE
+        AND s.version = m.version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Marketplace listing not found or inactive';
+    END IF;
+
+    -- Check user is not buying their own strategy
+    IF v_listing.seller_id = p_buyer_id THEN
+        RAISE EXCEPTION 'Cannot purchase your own strategy';
+    END IF;
+
+    -- Check for existing purchase
+    PERFORM 1 FROM strategy_purchases
+    WHERE marketplace_id = p_marketplace_id AND buyer_id = p_buyer_id;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Already purchased this strategy';
+    END IF;
+
+    -- Redeem the coupon
+    IF p_coupon_id IS NOT NULL THEN
+        UPDATE marketplace_coupons
+        SET redemptions_count = redemptions_count + 1
+        WHERE
+            id = p_coupon_id
+            AND marketplace_id = p_marketplace_id
+            AND is_active = TRUE
+            AND (expires_at IS NULL OR expires_at > NOW())
+            AND (max_redemptions IS NULL OR redemptions_count < max_redemptions);
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Coupon is no longer valid';
+        END IF;
+
+        v_discount := LEAST(GREATEST(COALESCE(p_discount, 0), 0), v_listing.price);
+    END IF;
+
+    INSERT INTO strategy_purchases (
+        marketplace_id,
+        buyer_id,
+        strategy_version,
+        purchase_price,
+        original_price,
+        discount_amount,
+        coupon_id,
+        subscription_end,
+        created_at
+    )
+    VALUES (
+        p_marketplace_id,
+        p_buyer_id,
+        v_listing.version_number,
+        v_listing.price - v_discount,
+        v_listing.price,
+        v_discount,
+        p_coupon_id,
+        CASE
+            WHEN v_listing.is_subscription THEN
+                CASE
+                    WHEN v_listing.subscription_period = 'monthly' THEN NOW() + INTERVAL '1 month'
+                    WHEN v_listing.subscription_period = 'quarterly' THEN NOW() + INTERVAL '3 months'
+                    WHEN v_listing.subscription_period = 'yearly' THEN NOW() + INTERVAL '1 year'
+                    ELSE NULL
+                END
+            ELSE NULL
+        END,
+        NOW()
+    )
+    RETURNING id INTO new_purchase_id;
+
+    -- Set the purchased version as the buyer's active version
+    INSERT INTO user_strategy_versions (
+        user_id,
+        strategy_group_id,
+        active_version_id,
+        updated_at
+    )
+    VALUES (
+        p_buyer_id,
+        v_listing.strategy_group_id,
+        v_listing.strategy_version_id,
+        NOW()
+    )
+    ON CONFLICT (user_id, strategy_group_id) DO UPDATE
+    SET
+        active_version_id = v_listing.strategy_version_id,
+        updated_at = NOW();
+
+    RETURN new_purchase_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a purchase with its current subscription state and any discount (replaces the
+-- version in 13_subscription-functions.sql). Visible to the buyer and the seller.
+DROP FUNCTION IF EXISTS get_purchase_by_id(INT, INT);
+
+CREATE OR REPLACE FUNCTION get_purchase_by_id(
+    p_purchase_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    original_price NUMERIC,
+    discount_amount NUMERIC,
+    coupon_code VARCHAR,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id,
+        p.marketplace_id,
+        p.buyer_id,
+        m.user_id,
+        m.strategy_id,
+        s.name,
+        p.strategy_version,
+        p.purchase_price,
+        COALESCE(p.original_price, p.purchase_price),
+        p.discount_amount,
+        c.code,
+        m.is_subscription,
+        m.subscription_period,
+        p.subscription_end,
+        -- An active subscription past its end date is expired even if the worker hasn't run yet
+        (CASE
+            WHEN p.status = 'active' AND p.subscription_end IS NOT NULL AND p.subscription_end <= NOW() THEN 'expired'
+            ELSE p.status
+        END)::VARCHAR,
+        (p.subscription_end IS NULL OR p.subscription_end > NOW()),
+        (CASE
+            WHEN p.subscription_end IS NULL THEN NULL
+            ELSE GREATEST(0, CEIL(EXTRACT(EPOCH FROM (p.subscription_end - NOW())) / 86400))::INT
+        END),
+        p.renewal_reminder_sent_at,
+        p.expired_at,
+        p.created_at
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    LEFT JOIN marketplace_coupons c ON c.id = p.coupon_id
+    WHERE p.id = p_purchase_id
+    AND (p.buyer_id = p_user_id OR m.user_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,320 @@
+-- Strategy Service Marketplace Currency Functions
+-- File: 20_marketplace-currencies.sql
+-- Contains the supported currency catalog, daily exchange rates and listing and purchase currencies
+
+-- +goose Up
+-- +goose StatementBegin
+-- Currencies listings can be priced and displayed in. decimals is the number of minor units
+-- prices are rounded to.
+CREATE TABLE IF NOT EXISTS "currencies" (
+  "code" varchar(3) PRIMARY KEY,
+  "name" varchar(50) NOT NULL,
+  "symbol" varchar(5) NOT NULL,
+  "decimals" int NOT NULL DEFAULT 2,
+  "is_active" boolean NOT NULL DEFAULT true
+);
+
+INSERT INTO currencies (code, name, symbol, decimals) VALUES
+    ('USD', 'US Dollar', '$', 2),
+    ('EUR', 'Euro', '€', 2),
+    ('GBP', 'British Pound', '£', 2),
+    ('JPY', 'Japanese Yen', '¥', 0),
+    ('CHF', 'Swiss Franc', 'CHF', 2),
+    ('CAD', 'Canadian Dollar', 'CA$', 2),
+    ('AUD', 'Australian Dollar', 'A$', 2)
+ON CONFLICT (code) DO NOTHING;
+
+-- Daily exchange rates, in units of the currency per US dollar
+CREATE TABLE IF NOT EXISTS "fx_rates" (
+  "currency" varchar(3) NOT NULL,
+  "rate_date" date NOT NULL,
+  "rate" numeric(18,8) NOT NULL CHECK ("rate" > 0),
+  "fetched_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("currency", "rate_date")
+);
+
+ALTER TABLE "fx_rates" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON DELETE CASCADE;
+
+-- Listings are priced in a currency; purchases record the currency they were paid in
+ALTER TABLE "strategy_marketplace" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'USD';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'strategy_marketplace_currency_fkey'
+    ) THEN
+        ALTER TABLE "strategy_marketplace"
+            ADD CONSTRAINT "strategy_marketplace_currency_fkey"
+            FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+    END IF;
+END $$;
+
+-- Purchases are paid in the currency of the listing at the time of purchase
+CREATE OR REPLACE FUNCTION set_purchase_currency()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.currency := COALESCE(
+        (SELECT m.currency FROM strategy_marketplace m WHERE m.id = NEW.marketplace_id),
+        'USD'
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS purchase_currency ON strategy_purchases;
+CREATE TRIGGER purchase_currency
+    BEFORE INSERT ON strategy_purchases
+    FOR EACH ROW EXECUTE FUNCTION set_purchase_currency();
+
+-- Get the active currencies
+CREATE OR REPLACE FUNCTION get_currencies()
+RETURNS SETOF currencies AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM currencies c
+    WHERE c.is_active = TRUE
+    ORDER BY c.code;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store the rate of a currency for a day, replacing any rate already stored for it
+CREATE OR REPLACE FUNCTION save_fx_rate(
+    p_currency VARCHAR(3),
+    p_rate_date DATE,
+    p_rate NUMERIC
+)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO fx_rates (currency, rate_date, rate, fetched_at)
+    VALUES (p_currency, p_rate_date, p_rate, NOW())
+    ON CONFLICT (currency, rate_date) DO UPDATE
+    SET
+        rate = EXCLUDED.rate,
+        fetched_at = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the most recent rate of every active currency
+CREATE OR REPLACE FUNCTION get_latest_fx_rates()
+RETURNS TABLE (
+    currency VARCHAR,
+    rate_date DATE,
+    rate NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT DISTINCT ON (r.currency)
+        r.currency,
+        r.rate_date,
+        r.rate
+    FROM fx_rates r
+    JOIN currencies c ON c.code = r.currency
+    WHERE c.is_active = TRUE
+    ORDER BY r.currency, r.rate_date DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the currencies of the given listings
+CREATE OR REPLACE FUNCTION get_listing_currencies(p_marketplace_ids INT[])
+RETURNS TABLE (
+    marketplace_id INT,
+    currency VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT m.id, m.currency
+    FROM strategy_marketplace m
+    WHERE m.id = ANY(p_marketplace_ids);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Put strategy on marketplace (replaces the version in 06_marketplace-functions.sql)
+DROP FUNCTION IF EXISTS create_marketplace_listing(INT, INT, INT, NUMERIC, BOOLEAN, VARCHAR, TEXT);
+
+CREATE OR REPLACE FUNCTION create_marketplace_listing(
+    p_user_id INT,
+    p_strategy_id INT, -- This is actually the strategy_group_id
+    p_version_id INT,  -- This is the version number (not the ID)
+    p_price NUMERIC,
+    p_is_subscription BOOLEAN,
+    p_subscription_period VARCHAR,
+    p_description_public TEXT,
+    p_currency VARCHAR(3) DEFAULT 'USD'
+)
+RETURNS INT AS $$
+DECLARE
+    new_listing_id INT;
+BEGIN
+    -- Check if strategy belongs to user and the version exists
+    PERFORM 1
+    FROM strategies s
+    WHERE s.strategy_group_id = p_strategy_id
+    AND s.user_id = p_user_id
+    AND s.version = p_version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Strategy does not belong to user or version does not exist';
+    END IF;
+
+    PERFORM 1 FROM currencies
+    WHERE code = p_currency AND is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Unsupported currency';
+    END IF;
+
+    -- Check if listing already exists
+    PERFORM 1 FROM strategy_marketplace
+    WHERE strategy_id = p_strategy_id AND is_active = TRUE;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Strategy is already listed on marketplace';
+    END IF;
+
+    -- Insert marketplace listing
+    INSERT INTO strategy_marketplace (
+        strategy_id,
+        version_id,
+        user_id,
+        price,
+        currency,
+        is_subscription,
+        subscription_period,
+        is_active,
+        description_public,
+        created_at,
+        updated_at
+    )
+    VALUES (
+        p_strategy_id,
+        p_version_id,
+        p_user_id,
+        p_price,
+        p_currency,
+        p_is_subscription,
+        p_subscription_period,
+        TRUE,
+        p_description_public,
+        NOW(),
+        NOW()
+    )
+    RETURNING id INTO new_listing_id;
+
+    RETURN new_listing_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get marketplace listing by ID (replaces the version in 06_marketplace-functions.sql)
+DROP FUNCTION IF EXISTS get_marketplace_listing_by_id(INT);
+
+CREATE OR REPLACE FUNCTION get_marketplace_listing_by_id(
+    p_listing_id INT
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    version_id INT,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    description_public TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    currency VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        m.version_id,
+        m.user_id,
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        m.is_active,
+        m.description_public,
+        m.created_at,
+        m.updated_at,
+        m.currency
+    FROM
+        strategy_marketplace m
+    WHERE
+        m.id = p_listing_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a purchase with its current subscription state, any discount and its currency
+-- (replaces the version in 19_marketplace-coupons.sql). Visible to the buyer and the seller.
+DROP FUNCTION IF EXISTS get_purchase_by_id(INT, INT);
+
+CREATE OR REPLACE FUNCTION get_purchase_by_id(
+    p_purchase_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    original_price NUMERIC,
+    discount_amount NUMERIC,
+    coupon_code VARCHAR,
+    currency VARCHAR,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id,
+        p.marketplace_id,
+        p.buyer_id,
+        m.user_id,
+        m.strategy_id,
+        s.name,
+        p.strategy_version,
+        p.purchase_price,
+        COALESCE(p.original_price, p.purchase_price),
+        p.discount_amount,
+        c.code,
+        p.currency,
+        m.is_subscription,
+        m.subscription_period,
+        p.subscription_end,
+        -- An active subscription past its end date is expired even if the worker hasn't run yet
+        (CASE
+            WHEN p.status = 'active' AND p.subscription_end IS NOT NULL AND p.subscription_end <= NOW() THEN 'expired'
+            ELSE p.status
+        END)::VARCHAR,
+        (p.subscription_end IS NULL OR p.subscription_end > NOW()),
+        (CASE
+            WHEN p.subscription_end IS NULL THEN NULL
+            ELSE GREATEST(0, CEIL(EXTRACT(EPOCH FROM (p.subscription_end - NOW())) / 86400))::INT
+        END),
+        p.renewal_reminder_sent_at,
+        p.expired_at,
+        p.created_at
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    LEFT JOIN marketplace_coupons c ON c.id = p.coupon_id
+    WHERE p.id = p_purchase_id
+    AND (p.buyer_id = p_user_id OR m.user_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,657 @@
+-- Strategy Service Purchase Refund Functions
+-- File: 21_purchase-refunds.sql
+-- Contains refund requests by buyers, their review by sellers and moderators, and purchase history
+
+-- +goose Up
+-- +goose StatementBegin
+-- Refunded purchases keep their row; status says they were refunded and access ends at refunded_at
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "refunded_at" timestamp;
+
+ALTER TABLE "strategy_purchases" DROP CONSTRAINT IF EXISTS "strategy_purchases_status_check";
+ALTER TABLE "strategy_purchases"
+    ADD CONSTRAINT "strategy_purchases_status_check" CHECK ("status" IN ('active', 'cancelled', 'expired', 'refunded'));
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_purchases_buyer_created" ON "strategy_purchases" ("buyer_id", "created_at");
+
+-- Refund requests of buyers. A purchase has at most one pending or approved request;
+-- a rejected one can be followed by a new request. amount and currency are what the
+-- buyer paid, and provider_refund_id is set when a payment provider issued the refund.
+CREATE TABLE IF NOT EXISTS "purchase_refunds" (
+  "id" SERIAL PRIMARY KEY,
+  "purchase_id" int NOT NULL,
+  "buyer_id" int NOT NULL,
+  "seller_id" int NOT NULL,
+  "reason" text NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'approved', 'rejected')),
+  "amount" numeric(10,2) NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "reviewer_id" int,
+  "review_note" text,
+  "provider_refund_id" varchar(100),
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "reviewed_at" timestamp
+);
+
+ALTER TABLE "purchase_refunds" ADD FOREIGN KEY ("purchase_id") REFERENCES "strategy_purchases" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_purchase_refunds_open" ON "purchase_refunds" ("purchase_id") WHERE "status" IN ('pending', 'approved');
+CREATE INDEX IF NOT EXISTS "idx_purchase_refunds_seller_status" ON "purchase_refunds" ("seller_id", "status", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_purchase_refunds_status" ON "purchase_refunds" ("status", "created_at");
+
+-- Request a refund of a purchase made by the buyer
+CREATE OR REPLACE FUNCTION request_purchase_refund(
+    p_purchase_id INT,
+    p_buyer_id INT,
+    p_reason TEXT
+)
+RETURNS INT AS $$
+DECLARE
+    v_purchase RECORD;
+    new_refund_id INT;
+BEGIN
+    SELECT p.id, p.status, p.purchase_price, p.currency, m.user_id AS seller_id
+    INTO v_purchase
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    WHERE p.id = p_purchase_id AND p.buyer_id = p_buyer_id
+    FOR UPDATE OF p;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Purchase not found';
+    END IF;
+
+    IF v_purchase.status = 'refunded' THEN
+        RAISE EXCEPTION 'Purchase is already refunded';
+    END IF;
+
+    PERFORM 1 FROM purchase_refunds
+    WHERE purchase_id = p_purchase_id AND status = 'pending';
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'A refund request is already pending for this purchase';
+    END IF;
+
+    INSERT INTO purchase_refunds (
+        purchase_id,
+        buyer_id,
+        seller_id,
+        reason,
+        amount,
+        currency,
+        created_at
+    )
+    VALUES (
+        p_purchase_id,
+        p_buyer_id,
+        v_purchase.seller_id,
+        p_reason,
+        v_purchase.purchase_price,
+        v_purchase.currency,
+        NOW()
+    )
+    RETURNING id INTO new_refund_id;
+
+    RETURN new_refund_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get refund requests, newest first. p_seller_id limits them to one seller's sales and
+-- p_status to one status; NULL returns all.
+CREATE OR REPLACE FUNCTION get_refund_requests(
+    p_seller_id INT,
+    p_status VARCHAR,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS TABLE (
+    id INT,
+    purchase_id INT,
+    marketplace_id INT,
+    strategy_name VARCHAR,
+    buyer_id INT,
+    seller_id INT,
+    reason TEXT,
+    status VARCHAR,
+    amount NUMERIC,
+    currency VARCHAR,
+    reviewer_id INT,
+    review_note TEXT,
+    provider_refund_id VARCHAR,
+    created_at TIMESTAMP,
+    reviewed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.id,
+        r.purchase_id,
+        p.marketplace_id,
+        s.name,
+        r.buyer_id,
+        r.seller_id,
+        r.reason,
+        r.status,
+        r.amount,
+        r.currency,
+        r.reviewer_id,
+        r.review_note,
+        r.provider_refund_id,
+        r.created_at,
+        r.reviewed_at
+    FROM purchase_refunds r
+    JOIN strategy_purchases p ON r.purchase_id = p.id
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    WHERE (p_seller_id IS NULL OR r.seller_id = p_seller_id)
+    AND (p_status IS NULL OR r.status = p_status)
+    ORDER BY r.created_at DESC, r.id DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count refund requests matching the filters of get_refund_requests
+CREATE OR REPLACE FUNCTION count_refund_requests(
+    p_seller_id INT,
+    p_status VARCHAR
+)
+RETURNS INT AS $$
+DECLARE
+    total INT;
+BEGIN
+    SELECT COUNT(*) INTO total
+    FROM purchase_refunds r
+    WHERE (p_seller_id IS NULL OR r.seller_id = p_seller_id)
+    AND (p_status IS NULL OR r.status = p_status);
+
+    RETURN total;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a refund request by ID
+CREATE OR REPLACE FUNCTION get_refund_request_by_id(p_refund_id INT)
+RETURNS TABLE (
+    id INT,
+    purchase_id INT,
+    marketplace_id INT,
+    strategy_name VARCHAR,
+    buyer_id INT,
+    seller_id INT,
+    reason TEXT,
+    status VARCHAR,
+    amount NUMERIC,
+    currency VARCHAR,
+    reviewer_id INT,
+    review_note TEXT,
+    provider_refund_id VARCHAR,
+    created_at TIMESTAMP,
+    reviewed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.id,
+        r.purchase_id,
+        p.marketplace_id,
+        s.name,
+        r.buyer_id,
+        r.seller_id,
+        r.reason,
+        r.status,
+        r.amount,
+        r.currency,
+        r.reviewer_id,
+        r.review_note,
+        r.provider_refund_id,
+        r.created_at,
+        r.reviewed_at
+    FROM purchase_refunds r
+    JOIN strategy_purchases p ON r.purchase_id = p.id
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    WHERE r.id = p_refund_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Approve or reject a pending refund request. Approving marks the purchase refunded and
+-- ends it now, so every access check based on subscription_end stops granting the strategy,
+-- and revokes the buyer's active version unless another current purchase still grants it.
+-- Returns FALSE if the request isn't pending.
+CREATE OR REPLACE FUNCTION review_refund_request(
+    p_refund_id INT,
+    p_reviewer_id INT,
+    p_approve BOOLEAN,
+    p_review_note TEXT,
+    p_provider_refund_id VARCHAR
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_purchase_id INT;
+BEGIN
+    UPDATE purchase_refunds
+    SET
+        status = CASE WHEN p_approve THEN 'approved' ELSE 'rejected' END,
+        reviewer_id = p_reviewer_id,
+        review_note = p_review_note,
+        provider_refund_id = p_provider_refund_id,
+        reviewed_at = NOW()
+    WHERE id = p_refund_id AND status = 'pending'
+    RETURNING purchase_id INTO v_purchase_id;
+
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    IF NOT p_approve THEN
+        RETURN TRUE;
+    END IF;
+
+    WITH refunded AS (
+        UPDATE strategy_purchases p
+        SET
+            status = 'refunded',
+            refunded_at = NOW(),
+            subscription_end = NOW()
+        WHERE p.id = v_purchase_id
+        RETURNING p.id, p.buyer_id, p.strategy_version
+    )
+    DELETE FROM user_strategy_versions usv
+    USING refunded e, strategies sv
+    WHERE sv.id = e.strategy_version
+    AND usv.user_id = e.buyer_id
+    AND usv.strategy_group_id = sv.strategy_group_id
+    AND usv.active_version_id = e.strategy_version
+    AND sv.user_id <> e.buyer_id
+    AND NOT EXISTS (
+        SELECT 1
+        FROM strategy_purchases p2
+        JOIN strategies s2 ON p2.strategy_version = s2.id
+        WHERE p2.buyer_id = e.buyer_id
+        AND s2.strategy_group_id = sv.strategy_group_id
+        AND p2.id <> e.id
+        AND (p2.subscription_end IS NULL OR p2.subscription_end > NOW())
+    );
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Purchases visible to a user (as buyer or seller) with their current subscription state,
+-- any discount, their currency and the status of their latest refund request
+CREATE OR REPLACE FUNCTION purchase_details(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    original_price NUMERIC,
+    discount_amount NUMERIC,
+    coupon_code VARCHAR,
+    currency VARCHAR,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    refund_status VARCHAR,
+    refunded_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id,
+        p.marketplace_id,
+        p.buyer_id,
+        m.user_id,
+        m.strategy_id,
+        s.name,
+        p.strategy_version,
+        p.purchase_price,
+        COALESCE(p.original_price, p.purchase_price),
+        p.discount_amount,
+        c.code,
+        p.currency,
+        m.is_subscription,
+        m.subscription_period,
+        p.subscription_end,
+        -- An active subscription past its end date is expired even if the worker hasn't run yet
+        (CASE
+            WHEN p.status = 'active' AND p.subscription_end IS NOT NULL AND p.subscription_end <= NOW() THEN 'expired'
+            ELSE p.status
+        END)::VARCHAR,
+        (p.subscription_end IS NULL OR p.subscription_end > NOW()),
+        (CASE
+            WHEN p.subscription_end IS NULL THEN NULL
+            ELSE GREATEST(0, CEIL(EXTRACT(EPOCH FROM (p.subscription_end - NOW())) / 86400))::INT
+        END),
+        p.renewal_reminder_sent_at,
+        p.expired_at,
+        (SELECT r.status FROM purchase_refunds r
+         WHERE r.purchase_id = p.id
+         ORDER BY r.created_at DESC, r.id DESC
+         LIMIT 1),
+        p.refunded_at,
+        p.created_at
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON p.marketplace_id = m.id
+    JOIN strategies s ON m.strategy_id = s.id
+    LEFT JOIN marketplace_coupons c ON c.id = p.coupon_id
+    WHERE p.buyer_id = p_user_id OR m.user_id = p_user_id;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Get a purchase with its current subscription state, any discount, its currency and the
+-- status of its latest refund request (replaces the version in 20_marketplace-currencies.sql).
+-- Visible to the buyer and the seller.
+DROP FUNCTION IF EXISTS get_purchase_by_id(INT, INT);
+
+CREATE OR REPLACE FUNCTION get_purchase_by_id(
+    p_purchase_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    original_price NUMERIC,
+    discount_amount NUMERIC,
+    coupon_code VARCHAR,
+    currency VARCHAR,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    refund_status VARCHAR,
+    refunded_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM purchase_details(p_user_id) d
+    WHERE d.id = p_purchase_id
+    AND (d.buyer_id = p_user_id OR d.seller_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the purchase history of a buyer, newest first
+CREATE OR REPLACE FUNCTION get_user_purchases(
+    p_user_id INT,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    buyer_id INT,
+    seller_id INT,
+    strategy_id INT,
+    strategy_name VARCHAR,
+    strategy_version INT,
+    purchase_price NUMERIC,
+    original_price NUMERIC,
+    discount_amount NUMERIC,
+    coupon_code VARCHAR,
+    currency VARCHAR,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    subscription_end TIMESTAMP,
+    status VARCHAR,
+    has_access BOOLEAN,
+    days_remaining INT,
+    renewal_reminder_sent_at TIMESTAMP,
+    expired_at TIMESTAMP,
+    refund_status VARCHAR,
+    refunded_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM purchase_details(p_user_id) d
+    WHERE d.buyer_id = p_user_id
+    ORDER BY d.created_at DESC, d.id DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count the purchases of a buyer
+CREATE OR REPLACE FUNCTION count_user_purchases(p_user_id INT)
+RETURNS INT AS $$
+DECLARE
+    total INT;
+BEGIN
+    SELECT COUNT(*) INTO total
+    FROM strategy_purchases p
+    WHERE p.buyer_id = p_user_id;
+
+    RETURN total;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Seller earnings leave out refunded purchases (replace the versions in 12_earnings-functions.sql)
+-- Revenue per period for a seller. p_interval is one of 'day', 'week' or 'month'.
+-- Periods without sales are included with zero revenue.
+CREATE OR REPLACE FUNCTION get_seller_revenue_over_time(
+    p_seller_id INT,
+    p_interval VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    revenue NUMERIC,
+    purchases_count BIGINT,
+    subscriptions_count BIGINT
+) AS $$
+BEGIN
+    IF p_interval NOT IN ('day', 'week', 'month') THEN
+        p_interval := 'day';
+    END IF;
+
+    RETURN QUERY
+    WITH periods AS (
+        SELECT generate_series(
+            date_trunc(p_interval, p_start_date),
+            date_trunc(p_interval, p_end_date),
+            ('1 ' || p_interval)::INTERVAL
+        ) AS period_start
+    ),
+    sales AS (
+        SELECT
+            date_trunc(p_interval, p.created_at) AS period_start,
+            p.purchase_price,
+            m.is_subscription
+        FROM strategy_purchases p
+        JOIN strategy_marketplace m ON p.marketplace_id = m.id
+        WHERE m.user_id = p_seller_id
+        AND p.status <> 'refunded'
+        AND p.created_at >= p_start_date
+        AND p.created_at < p_end_date
+    )
+    SELECT
+        pr.period_start,
+        COALESCE(SUM(sa.purchase_price), 0)::NUMERIC,
+        COUNT(sa.purchase_price),
+        COUNT(sa.purchase_price) FILTER (WHERE sa.is_subscription)
+    FROM periods pr
+    LEFT JOIN sales sa ON sa.period_start = pr.period_start
+    GROUP BY pr.period_start
+    ORDER BY pr.period_start;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sales per listing for a seller within a date range
+CREATE OR REPLACE FUNCTION get_seller_listing_sales(
+    p_seller_id INT,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    marketplace_id INT,
+    strategy_id INT,
+    name VARCHAR,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    is_active BOOLEAN,
+    purchases_count BIGINT,
+    revenue NUMERIC,
+    active_subscriptions BIGINT,
+    lifetime_revenue NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        s.name,
+        m.price,
+        m.is_subscription,
+        m.is_active,
+        COUNT(p.id) FILTER (WHERE p.created_at >= p_start_date AND p.created_at < p_end_date),
+        COALESCE(SUM(p.purchase_price) FILTER (WHERE p.created_at >= p_start_date AND p.created_at < p_end_date), 0)::NUMERIC,
+        COUNT(p.id) FILTER (WHERE m.is_subscription AND p.subscription_end > NOW()),
+        COALESCE(SUM(p.purchase_price), 0)::NUMERIC
+    FROM strategy_marketplace m
+    JOIN strategies s ON m.strategy_id = s.id
+    LEFT JOIN strategy_purchases p ON p.marketplace_id = m.id AND p.status <> 'refunded'
+    WHERE m.user_id = p_seller_id
+    GROUP BY m.id, m.strategy_id, s.name, m.price, m.is_subscription, m.is_active
+    ORDER BY 8 DESC, m.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Subscription churn per period for a seller. A subscription churns in the period
+-- its subscription_end falls in; churn_rate is churned / active at period start.
+CREATE OR REPLACE FUNCTION get_seller_subscription_churn(
+    p_seller_id INT,
+    p_interval VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    active_at_start BIGINT,
+    new_subscriptions BIGINT,
+    churned BIGINT,
+    churn_rate FLOAT
+) AS $$
+BEGIN
+    IF p_interval NOT IN ('day', 'week', 'month') THEN
+        p_interval := 'month';
+    END IF;
+
+    RETURN QUERY
+    WITH periods AS (
+        SELECT
+            gs AS period_start,
+            gs + ('1 ' || p_interval)::INTERVAL AS period_end
+        FROM generate_series(
+            date_trunc(p_interval, p_start_date),
+            date_trunc(p_interval, p_end_date),
+            ('1 ' || p_interval)::INTERVAL
+        ) AS gs
+    ),
+    subs AS (
+        SELECT p.created_at, p.subscription_end
+        FROM strategy_purchases p
+        JOIN strategy_marketplace m ON p.marketplace_id = m.id
+        WHERE m.user_id = p_seller_id
+        AND m.is_subscription = TRUE
+        AND p.status <> 'refunded'
+        AND p.subscription_end IS NOT NULL
+    ),
+    counts AS (
+        SELECT
+            pr.period_start,
+            COUNT(*) FILTER (WHERE su.created_at < pr.period_start AND su.subscription_end >= pr.period_start) AS active_at_start,
+            COUNT(*) FILTER (WHERE su.created_at >= pr.period_start AND su.created_at < pr.period_end) AS new_subscriptions,
+            COUNT(*) FILTER (WHERE su.subscription_end >= pr.period_start AND su.subscription_end < pr.period_end
+                             AND su.subscription_end <= NOW()) AS churned
+        FROM periods pr
+        LEFT JOIN subs su ON su.created_at < pr.period_end
+        GROUP BY pr.period_start
+    )
+    SELECT
+        c.period_start,
+        c.active_at_start,
+        c.new_subscriptions,
+        c.churned,
+        CASE WHEN c.active_at_start = 0 THEN 0
+             ELSE c.churned::FLOAT / c.active_at_start
+        END
+    FROM counts c
+    ORDER BY c.period_start;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Monthly payout summaries for a seller. The platform fee is taken as a percentage
+-- of gross revenue; the current month is still 'open', earlier months are 'closed'.
+CREATE OR REPLACE FUNCTION get_seller_payout_periods(
+    p_seller_id INT,
+    p_fee_percent NUMERIC,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP
+)
+RETURNS TABLE (
+    period_start TIMESTAMP,
+    period_end TIMESTAMP,
+    gross_revenue NUMERIC,
+    platform_fee NUMERIC,
+    net_revenue NUMERIC,
+    purchases_count BIGINT,
+    status VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH periods AS (
+        SELECT
+            gs AS period_start,
+            gs + INTERVAL '1 month' AS period_end
+        FROM generate_series(
+            date_trunc('month', p_start_date),
+            date_trunc('month', p_end_date),
+            INTERVAL '1 month'
+        ) AS gs
+    ),
+    totals AS (
+        SELECT
+            pr.period_start,
+            pr.period_end,
+            COALESCE(SUM(p.purchase_price), 0) AS gross,
+            COUNT(p.id) AS purchases
+        FROM periods pr
+        LEFT JOIN strategy_purchases p
+            ON p.created_at >= pr.period_start
+            AND p.created_at < pr.period_end
+            AND p.status <> 'refunded'
+            AND p.marketplace_id IN (SELECT m.id FROM strategy_marketplace m WHERE m.user_id = p_seller_id)
+        GROUP BY pr.period_start, pr.period_end
+    )
+    SELECT
+        t.period_start,
+        t.period_end,
+        t.gross::NUMERIC,
+        ROUND(t.gross * p_fee_percent / 100, 2)::NUMERIC,
+        (t.gross - ROUND(t.gross * p_fee_percent / 100, 2))::NUMERIC,
+        t.purchases,
+        (CASE WHEN t.period_end > NOW() THEN 'open' ELSE 'closed' END)::VARCHAR
+    FROM totals t
+    ORDER BY t.period_start DESC;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
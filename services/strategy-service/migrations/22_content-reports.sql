@@ -0,0 +1,294 @@
+-- Strategy Service Content Report Functions
+-- File: 22_content-reports.sql
+-- Contains reports of marketplace listings and reviews and their resolution by moderators
+
+-- +goose Up
+-- +goose StatementBegin
+-- Reports of listings and reviews. target_user_id is the listing's seller or the review's
+-- author and content a snapshot of what was reported (the strategy name or review comment),
+-- so both survive the review being removed. A user has at most one open report per target.
+CREATE TABLE IF NOT EXISTS "content_reports" (
+  "id" SERIAL PRIMARY KEY,
+  "target_type" varchar(20) NOT NULL CHECK ("target_type" IN ('listing', 'review')),
+  "target_id" int NOT NULL,
+  "marketplace_id" int NOT NULL,
+  "target_user_id" int NOT NULL,
+  "content" text,
+  "reporter_id" int NOT NULL,
+  "category" varchar(20) NOT NULL CHECK ("category" IN ('spam', 'fraud', 'misleading', 'offensive', 'copyright', 'other')),
+  "details" text,
+  "status" varchar(20) NOT NULL DEFAULT 'open' CHECK ("status" IN ('open', 'resolved', 'dismissed')),
+  "action" varchar(20) CHECK ("action" IN ('hide_listing', 'remove_review', 'warn_user')),
+  "resolver_id" int,
+  "resolution_note" text,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "resolved_at" timestamp
+);
+
+ALTER TABLE "content_reports" ADD FOREIGN KEY ("marketplace_id") REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_content_reports_open" ON "content_reports" ("target_type", "target_id", "reporter_id") WHERE "status" = 'open';
+CREATE INDEX IF NOT EXISTS "idx_content_reports_status" ON "content_reports" ("status", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_content_reports_target_user" ON "content_reports" ("target_user_id", "action");
+
+-- Report a listing or a review
+CREATE OR REPLACE FUNCTION create_content_report(
+    p_target_type VARCHAR,
+    p_target_id INT,
+    p_reporter_id INT,
+    p_category VARCHAR,
+    p_details TEXT
+)
+RETURNS INT AS $$
+DECLARE
+    v_marketplace_id INT;
+    v_target_user_id INT;
+    v_content TEXT;
+    new_report_id INT;
+BEGIN
+    IF p_target_type = 'listing' THEN
+        SELECT m.id, m.user_id, s.name
+        INTO v_marketplace_id, v_target_user_id, v_content
+        FROM strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.id
+        WHERE m.id = p_target_id AND m.is_active = TRUE;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Listing not found';
+        END IF;
+    ELSE
+        SELECT r.marketplace_id, r.user_id, r.comment
+        INTO v_marketplace_id, v_target_user_id, v_content
+        FROM strategy_reviews r
+        WHERE r.id = p_target_id;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Review not found';
+        END IF;
+    END IF;
+
+    IF v_target_user_id = p_reporter_id THEN
+        RAISE EXCEPTION 'Cannot report your own content';
+    END IF;
+
+    PERFORM 1 FROM content_reports
+    WHERE target_type = p_target_type
+    AND target_id = p_target_id
+    AND reporter_id = p_reporter_id
+    AND status = 'open';
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'You have already reported this %', p_target_type;
+    END IF;
+
+    INSERT INTO content_reports (
+        target_type,
+        target_id,
+        marketplace_id,
+        target_user_id,
+        content,
+        reporter_id,
+        category,
+        details,
+        created_at
+    )
+    VALUES (
+        p_target_type,
+        p_target_id,
+        v_marketplace_id,
+        v_target_user_id,
+        v_content,
+        p_reporter_id,
+        p_category,
+        p_details,
+        NOW()
+    )
+    RETURNING id INTO new_report_id;
+
+    RETURN new_report_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get reports for the moderation queue, oldest first. NULL filters match every status or
+-- target type. target_reports counts the open reports of the same target and
+-- target_user_warnings the warnings its owner already received.
+CREATE OR REPLACE FUNCTION get_content_reports(
+    p_status VARCHAR,
+    p_target_type VARCHAR,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS TABLE (
+    id INT,
+    target_type VARCHAR,
+    target_id INT,
+    marketplace_id INT,
+    target_user_id INT,
+    content TEXT,
+    reporter_id INT,
+    category VARCHAR,
+    details TEXT,
+    status VARCHAR,
+    action VARCHAR,
+    resolver_id INT,
+    resolution_note TEXT,
+    target_reports INT,
+    target_user_warnings INT,
+    created_at TIMESTAMP,
+    resolved_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.id,
+        r.target_type,
+        r.target_id,
+        r.marketplace_id,
+        r.target_user_id,
+        r.content,
+        r.reporter_id,
+        r.category,
+        r.details,
+        r.status,
+        r.action,
+        r.resolver_id,
+        r.resolution_note,
+        (SELECT COUNT(*)::INT FROM content_reports o
+         WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status = 'open'),
+        (SELECT COUNT(*)::INT FROM content_reports w
+         WHERE w.target_user_id = r.target_user_id AND w.action = 'warn_user'),
+        r.created_at,
+        r.resolved_at
+    FROM content_reports r
+    WHERE (p_status IS NULL OR r.status = p_status)
+    AND (p_target_type IS NULL OR r.target_type = p_target_type)
+    ORDER BY r.created_at ASC, r.id ASC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count reports matching the filters of get_content_reports
+CREATE OR REPLACE FUNCTION count_content_reports(
+    p_status VARCHAR,
+    p_target_type VARCHAR
+)
+RETURNS INT AS $$
+DECLARE
+    total INT;
+BEGIN
+    SELECT COUNT(*) INTO total
+    FROM content_reports r
+    WHERE (p_status IS NULL OR r.status = p_status)
+    AND (p_target_type IS NULL OR r.target_type = p_target_type);
+
+    RETURN total;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a report by ID
+CREATE OR REPLACE FUNCTION get_content_report_by_id(p_report_id INT)
+RETURNS TABLE (
+    id INT,
+    target_type VARCHAR,
+    target_id INT,
+    marketplace_id INT,
+    target_user_id INT,
+    content TEXT,
+    reporter_id INT,
+    category VARCHAR,
+    details TEXT,
+    status VARCHAR,
+    action VARCHAR,
+    resolver_id INT,
+    resolution_note TEXT,
+    target_reports INT,
+    target_user_warnings INT,
+    created_at TIMESTAMP,
+    resolved_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.id,
+        r.target_type,
+        r.target_id,
+        r.marketplace_id,
+        r.target_user_id,
+        r.content,
+        r.reporter_id,
+        r.category,
+        r.details,
+        r.status,
+        r.action,
+        r.resolver_id,
+        r.resolution_note,
+        (SELECT COUNT(*)::INT FROM content_reports o
+         WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status = 'open'),
+        (SELECT COUNT(*)::INT FROM content_reports w
+         WHERE w.target_user_id = r.target_user_id AND w.action = 'warn_user'),
+        r.created_at,
+        r.resolved_at
+    FROM content_reports r
+    WHERE r.id = p_report_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Resolve an open report and every other open report of the same target. p_action is
+-- 'dismiss' or a resolution action: 'hide_listing' deactivates the reported listing,
+-- 'remove_review' deletes the reported review and 'warn_user' only records the warning.
+-- Returns the reporters of the resolved reports, no rows if the report isn't open.
+CREATE OR REPLACE FUNCTION resolve_content_report(
+    p_report_id INT,
+    p_resolver_id INT,
+    p_action VARCHAR,
+    p_resolution_note TEXT
+)
+RETURNS TABLE (
+    report_id INT,
+    reporter_id INT
+) AS $$
+DECLARE
+    v_report RECORD;
+BEGIN
+    SELECT r.target_type, r.target_id
+    INTO v_report
+    FROM content_reports r
+    WHERE r.id = p_report_id AND r.status = 'open'
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        RETURN;
+    END IF;
+
+    IF p_action = 'hide_listing' THEN
+        IF v_report.target_type <> 'listing' THEN
+            RAISE EXCEPTION 'Only listings can be hidden';
+        END IF;
+
+        UPDATE strategy_marketplace
+        SET is_active = FALSE, updated_at = NOW()
+        WHERE id = v_report.target_id;
+    ELSIF p_action = 'remove_review' THEN
+        IF v_report.target_type <> 'review' THEN
+            RAISE EXCEPTION 'Only reviews can be removed';
+        END IF;
+
+        DELETE FROM strategy_reviews WHERE id = v_report.target_id;
+    END IF;
+
+    RETURN QUERY
+    UPDATE content_reports r
+    SET
+        status = CASE WHEN p_action = 'dismiss' THEN 'dismissed' ELSE 'resolved' END,
+        -- The action is recorded once, on the report it was taken on
+        action = CASE WHEN p_action <> 'dismiss' AND r.id = p_report_id THEN p_action END,
+        resolver_id = p_resolver_id,
+        resolution_note = p_resolution_note,
+        resolved_at = NOW()
+    WHERE r.target_type = v_report.target_type
+    AND r.target_id = v_report.target_id
+    AND r.status = 'open'
+    RETURNING r.id, r.reporter_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- Strategy Service Strategy Buyer Functions
+-- File: 25_strategy-buyers.sql
+-- Contains the lookup of the buyers told about new versions of a strategy
+
+-- +goose Up
+-- +goose StatementBegin
+-- Get the users who bought any version of a strategy and still have access to it
+CREATE OR REPLACE FUNCTION get_strategy_buyers(p_strategy_id INT)
+RETURNS TABLE (buyer_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT DISTINCT p.buyer_id
+    FROM strategy_purchases p
+    JOIN strategies bought ON p.strategy_version = bought.id
+    JOIN strategies s ON s.strategy_group_id = bought.strategy_group_id
+    WHERE
+        s.id = p_strategy_id
+        AND p.status <> 'refunded'
+        AND (p.subscription_end IS NULL OR p.subscription_end > NOW());
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- Strategy Service Strategy Version Lookup Functions
+-- File: 26_strategy-version-ids.sql
+-- Contains the lookup of the versions backtest history is gathered over
+
+-- +goose Up
+-- +goose StatementBegin
+-- Get the IDs of every version of the strategy a version belongs to
+CREATE OR REPLACE FUNCTION get_strategy_version_ids(p_strategy_id INT)
+RETURNS TABLE (id INT) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT s.id
+    FROM strategies s
+    JOIN strategies v ON v.strategy_group_id = s.strategy_group_id
+    WHERE v.id = p_strategy_id
+    ORDER BY s.version;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
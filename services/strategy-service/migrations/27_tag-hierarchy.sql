@@ -0,0 +1,355 @@
+-- Strategy Service Tag Hierarchy and Alias Functions
+-- File: 27_tag-hierarchy.sql
+-- Contains parent/child tag relationships, tag aliases and merging duplicate tags
+
+-- +goose Up
+-- +goose StatementBegin
+-- Tags may sit under a parent tag; removing the parent detaches its children
+ALTER TABLE "strategy_tags" ADD COLUMN IF NOT EXISTS "parent_id" int REFERENCES "strategy_tags" ("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_tags_parent_id" ON "strategy_tags" ("parent_id");
+
+-- Alternative names of a tag. Searching for an alias finds the tag it points to.
+CREATE TABLE IF NOT EXISTS "strategy_tag_aliases" (
+  "alias" varchar(50) PRIMARY KEY,
+  "tag_id" int NOT NULL REFERENCES "strategy_tags" ("id") ON DELETE CASCADE,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_tag_aliases_tag_id" ON "strategy_tag_aliases" ("tag_id");
+
+-- The listing now returns the parent of each tag and matches searches against aliases,
+-- so the return type changes
+DROP FUNCTION IF EXISTS get_all_tags(VARCHAR, VARCHAR, VARCHAR, INT, INT);
+DROP FUNCTION IF EXISTS get_tag_by_id(INT);
+
+-- Get all strategy tags with filtering and sorting. A search matching an alias returns
+-- the tag the alias points to.
+CREATE OR REPLACE FUNCTION get_all_tags(
+    p_search VARCHAR DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'name',
+    p_sort_direction VARCHAR DEFAULT 'ASC',
+    p_limit INT DEFAULT 100,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    parent_id INT,
+    strategy_count BIGINT
+) AS $$
+BEGIN
+    -- Validate sort field
+    IF p_sort_by NOT IN ('name', 'strategy_count', 'id') THEN
+        p_sort_by := 'name';
+    END IF;
+
+    -- Validate sort direction
+    IF UPPER(p_sort_direction) NOT IN ('ASC', 'DESC') THEN
+        p_sort_direction := 'ASC';
+    ELSE
+        p_sort_direction := UPPER(p_sort_direction);
+    END IF;
+
+    RETURN QUERY
+    SELECT
+        t.id,
+        t.name,
+        t.parent_id,
+        COUNT(DISTINCT stm.strategy_id) AS strategy_count
+    FROM
+        strategy_tags t
+        LEFT JOIN strategy_tag_mappings stm ON t.id = stm.tag_id
+    WHERE
+        p_search IS NULL
+        OR t.name ILIKE '%' || p_search || '%'
+        OR EXISTS (
+            SELECT 1 FROM strategy_tag_aliases a
+            WHERE a.tag_id = t.id AND a.alias ILIKE '%' || p_search || '%'
+        )
+    GROUP BY t.id, t.name, t.parent_id
+    ORDER BY
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'ASC' THEN t.name END ASC,
+        CASE WHEN p_sort_by = 'name' AND p_sort_direction = 'DESC' THEN t.name END DESC,
+        CASE WHEN p_sort_by = 'strategy_count' AND p_sort_direction = 'ASC' THEN COUNT(DISTINCT stm.strategy_id) END ASC,
+        CASE WHEN p_sort_by = 'strategy_count' AND p_sort_direction = 'DESC' THEN COUNT(DISTINCT stm.strategy_id) END DESC,
+        CASE WHEN p_sort_by = 'id' AND p_sort_direction = 'ASC' THEN t.id END ASC,
+        CASE WHEN p_sort_by = 'id' AND p_sort_direction = 'DESC' THEN t.id END DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count tags with filtering, matching searches against aliases like get_all_tags
+CREATE OR REPLACE FUNCTION count_tags(
+    p_search VARCHAR DEFAULT NULL
+)
+RETURNS BIGINT AS $$
+DECLARE
+    tag_count BIGINT;
+BEGIN
+    SELECT COUNT(*)
+    INTO tag_count
+    FROM strategy_tags t
+    WHERE
+        p_search IS NULL
+        OR t.name ILIKE '%' || p_search || '%'
+        OR EXISTS (
+            SELECT 1 FROM strategy_tag_aliases a
+            WHERE a.tag_id = t.id AND a.alias ILIKE '%' || p_search || '%'
+        );
+
+    RETURN tag_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get tag by ID
+CREATE OR REPLACE FUNCTION get_tag_by_id(
+    p_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    parent_id INT,
+    strategy_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.id,
+        t.name,
+        t.parent_id,
+        COUNT(DISTINCT stm.strategy_id) AS strategy_count
+    FROM
+        strategy_tags t
+        LEFT JOIN strategy_tag_mappings stm ON t.id = stm.tag_id
+    WHERE
+        t.id = p_id
+    GROUP BY t.id, t.name, t.parent_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the direct children of a tag
+CREATE OR REPLACE FUNCTION get_tag_children(
+    p_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    parent_id INT,
+    strategy_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.id,
+        t.name,
+        t.parent_id,
+        COUNT(DISTINCT stm.strategy_id) AS strategy_count
+    FROM
+        strategy_tags t
+        LEFT JOIN strategy_tag_mappings stm ON t.id = stm.tag_id
+    WHERE
+        t.parent_id = p_id
+    GROUP BY t.id, t.name, t.parent_id
+    ORDER BY t.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a new tag. A name already used as an alias is rejected so the duplicate the
+-- alias stands for isn't created again.
+CREATE OR REPLACE FUNCTION create_tag(
+    p_name VARCHAR(50)
+)
+RETURNS INT AS $$
+DECLARE
+    new_tag_id INT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM strategy_tag_aliases WHERE alias = p_name) THEN
+        RAISE EXCEPTION 'tag name already exists as an alias';
+    END IF;
+
+    INSERT INTO strategy_tags (name)
+    VALUES (p_name)
+    RETURNING id INTO new_tag_id;
+
+    RETURN new_tag_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a tag
+CREATE OR REPLACE FUNCTION update_tag(
+    p_id INT,
+    p_name VARCHAR(50)
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM strategy_tag_aliases WHERE alias = p_name) THEN
+        RAISE EXCEPTION 'tag name already exists as an alias';
+    END IF;
+
+    UPDATE strategy_tags
+    SET name = p_name
+    WHERE id = p_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Set or clear the parent of a tag. Hierarchy changes are serialized so two concurrent
+-- changes can't form a cycle between them.
+CREATE OR REPLACE FUNCTION set_tag_parent(
+    p_id INT,
+    p_parent_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    LOCK TABLE strategy_tags IN SHARE ROW EXCLUSIVE MODE;
+
+    IF p_parent_id IS NOT NULL THEN
+        IF NOT EXISTS (SELECT 1 FROM strategy_tags WHERE id = p_parent_id) THEN
+            RAISE EXCEPTION 'parent tag not found';
+        END IF;
+
+        -- The tag may not sit under itself or under one of its own descendants
+        IF EXISTS (
+            WITH RECURSIVE ancestors AS (
+                SELECT t.id, t.parent_id FROM strategy_tags t WHERE t.id = p_parent_id
+                UNION
+                SELECT t.id, t.parent_id FROM strategy_tags t JOIN ancestors a ON t.id = a.parent_id
+            )
+            SELECT 1 FROM ancestors WHERE ancestors.id = p_id
+        ) THEN
+            RAISE EXCEPTION 'invalid parent tag: a tag cannot be placed under itself or its descendants';
+        END IF;
+    END IF;
+
+    UPDATE strategy_tags
+    SET parent_id = p_parent_id
+    WHERE id = p_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the aliases of a tag
+CREATE OR REPLACE FUNCTION get_tag_aliases(
+    p_tag_id INT
+)
+RETURNS TABLE (
+    alias VARCHAR(50),
+    tag_id INT,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT a.alias, a.tag_id, a.created_at
+    FROM strategy_tag_aliases a
+    WHERE a.tag_id = p_tag_id
+    ORDER BY a.alias;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Add an alias to a tag. An alias can't be the name of a tag.
+CREATE OR REPLACE FUNCTION add_tag_alias(
+    p_tag_id INT,
+    p_alias VARCHAR(50)
+)
+RETURNS VOID AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM strategy_tags WHERE id = p_tag_id) THEN
+        RAISE EXCEPTION 'tag not found';
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM strategy_tags WHERE name = p_alias) THEN
+        RAISE EXCEPTION 'tag name already exists';
+    END IF;
+
+    INSERT INTO strategy_tag_aliases (alias, tag_id)
+    VALUES (p_alias, p_tag_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Remove an alias from a tag
+CREATE OR REPLACE FUNCTION remove_tag_alias(
+    p_tag_id INT,
+    p_alias VARCHAR(50)
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM strategy_tag_aliases
+    WHERE tag_id = p_tag_id AND alias = p_alias;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Merge a tag into another. The source tag's strategies, aliases and children move to
+-- the target, the source name becomes an alias of the target and the source is deleted.
+-- Returns the number of strategies that were tagged with the source.
+CREATE OR REPLACE FUNCTION merge_tag(
+    p_source_id INT,
+    p_target_id INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_source strategy_tags%ROWTYPE;
+    v_remapped INT;
+BEGIN
+    IF p_source_id = p_target_id THEN
+        RAISE EXCEPTION 'invalid merge: a tag cannot be merged into itself';
+    END IF;
+
+    LOCK TABLE strategy_tags IN SHARE ROW EXCLUSIVE MODE;
+
+    SELECT * INTO v_source FROM strategy_tags WHERE id = p_source_id;
+    IF NOT FOUND OR NOT EXISTS (SELECT 1 FROM strategy_tags WHERE id = p_target_id) THEN
+        RAISE EXCEPTION 'tag not found';
+    END IF;
+
+    -- Re-map the strategies, skipping those already tagged with the target
+    SELECT COUNT(*) INTO v_remapped FROM strategy_tag_mappings WHERE tag_id = p_source_id;
+
+    INSERT INTO strategy_tag_mappings (strategy_id, tag_id)
+    SELECT strategy_id, p_target_id
+    FROM strategy_tag_mappings
+    WHERE tag_id = p_source_id
+    ON CONFLICT DO NOTHING;
+
+    DELETE FROM strategy_tag_mappings WHERE tag_id = p_source_id;
+
+    -- A target below the source takes the source's place first, so handing it the
+    -- source's children can't form a cycle
+    IF EXISTS (
+        WITH RECURSIVE ancestors AS (
+            SELECT t.id, t.parent_id FROM strategy_tags t WHERE t.id = p_target_id
+            UNION
+            SELECT t.id, t.parent_id FROM strategy_tags t JOIN ancestors a ON t.id = a.parent_id
+        )
+        SELECT 1 FROM ancestors WHERE ancestors.id = p_source_id
+    ) THEN
+        UPDATE strategy_tags SET parent_id = v_source.parent_id WHERE id = p_target_id;
+    END IF;
+
+    UPDATE strategy_tags SET parent_id = p_target_id WHERE parent_id = p_source_id;
+
+    UPDATE strategy_tag_aliases SET tag_id = p_target_id WHERE tag_id = p_source_id;
+
+    INSERT INTO strategy_tag_aliases (alias, tag_id)
+    VALUES (v_source.name, p_target_id)
+    ON CONFLICT (alias) DO UPDATE SET tag_id = EXCLUDED.tag_id;
+
+    DELETE FROM strategy_tags WHERE id = p_source_id;
+
+    RETURN v_remapped;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
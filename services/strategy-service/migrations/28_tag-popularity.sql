@@ -0,0 +1,87 @@
+-- Strategy Service Tag Popularity Functions
+-- File: 28_tag-popularity.sql
+-- Contains the precomputed tag usage counts popular tags are ranked by
+
+-- +goose Up
+-- +goose StatementBegin
+-- Usage of every tag over each supported time window: active strategies with the tag
+-- that were created or edited within the window, and active marketplace listings of
+-- those strategies created within the window. Windows are relative to the last refresh.
+CREATE MATERIALIZED VIEW IF NOT EXISTS "strategy_tag_popularity" AS
+WITH strategy_activity AS (
+    SELECT s.strategy_group_id, MAX(COALESCE(s.updated_at, s.created_at)) AS active_at
+    FROM strategies s
+    WHERE s.is_active
+    GROUP BY s.strategy_group_id
+),
+windows (time_window, since) AS (
+    VALUES
+        ('7d', NOW() - INTERVAL '7 days'),
+        ('30d', NOW() - INTERVAL '30 days'),
+        ('90d', NOW() - INTERVAL '90 days'),
+        ('all', NULL::TIMESTAMPTZ)
+)
+SELECT
+    t.id AS tag_id,
+    w.time_window,
+    COUNT(DISTINCT sa.strategy_group_id) FILTER (
+        WHERE w.since IS NULL OR sa.active_at >= w.since
+    ) AS strategy_count,
+    COUNT(DISTINCT m.id) FILTER (
+        WHERE w.since IS NULL OR m.created_at >= w.since
+    ) AS listing_count,
+    NOW() AS computed_at
+FROM strategy_tags t
+CROSS JOIN windows w
+LEFT JOIN strategy_tag_mappings stm ON stm.tag_id = t.id
+LEFT JOIN strategy_activity sa ON sa.strategy_group_id = stm.strategy_id
+LEFT JOIN strategy_marketplace m ON m.strategy_id = sa.strategy_group_id AND m.is_active
+GROUP BY t.id, w.time_window;
+
+-- Required to refresh the view concurrently
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_strategy_tag_popularity_tag_window" ON "strategy_tag_popularity" ("tag_id", "time_window");
+
+-- Recompute tag usage. Reads of the view aren't blocked while it refreshes.
+CREATE OR REPLACE FUNCTION refresh_tag_popularity()
+RETURNS VOID AS $$
+BEGIN
+    REFRESH MATERIALIZED VIEW CONCURRENTLY strategy_tag_popularity;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Popular tags now rank by the precomputed usage within a time window
+DROP FUNCTION IF EXISTS get_popular_tags(INT);
+
+-- Get the most used tags within a time window ('7d', '30d', '90d' or 'all'). Tags with
+-- no usage in the window are left out.
+CREATE OR REPLACE FUNCTION get_popular_tags(
+    p_window VARCHAR DEFAULT '30d',
+    p_limit INT DEFAULT 10
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    parent_id INT,
+    strategy_count BIGINT,
+    listing_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.id,
+        t.name,
+        t.parent_id,
+        p.strategy_count,
+        p.listing_count
+    FROM strategy_tag_popularity p
+    JOIN strategy_tags t ON t.id = p.tag_id
+    WHERE p.time_window = p_window
+      AND p.strategy_count + p.listing_count > 0
+    ORDER BY
+        p.strategy_count + p.listing_count DESC,
+        p.strategy_count DESC,
+        t.name ASC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,67 @@
+-- Strategy Service Broadcast Audience Functions
+-- File: 29_broadcast-audiences.sql
+-- Contains the lookups of the users admin notification broadcasts are targeted at
+
+-- +goose Up
+-- +goose StatementBegin
+-- Get the buyers of a listing who still have access to it
+CREATE OR REPLACE FUNCTION get_listing_purchasers(p_marketplace_id INT)
+RETURNS TABLE (user_id INT) AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM strategy_marketplace WHERE id = p_marketplace_id) THEN
+        RAISE EXCEPTION 'Listing not found';
+    END IF;
+
+    RETURN QUERY
+    SELECT DISTINCT p.buyer_id
+    FROM strategy_purchases p
+    WHERE
+        p.marketplace_id = p_marketplace_id
+        AND p.status <> 'refunded'
+        AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+    ORDER BY p.buyer_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the users of a strategy: its owner, the buyers who still have access to any of its
+-- versions and the users it is shared with
+CREATE OR REPLACE FUNCTION get_strategy_users(p_strategy_id INT)
+RETURNS TABLE (user_id INT) AS $$
+DECLARE
+    v_group_id INT;
+BEGIN
+    SELECT s.strategy_group_id INTO v_group_id
+    FROM strategies s
+    WHERE s.id = p_strategy_id;
+
+    IF v_group_id IS NULL THEN
+        RAISE EXCEPTION 'Strategy not found';
+    END IF;
+
+    RETURN QUERY
+    SELECT users.id
+    FROM (
+        SELECT s.user_id AS id
+        FROM strategies s
+        WHERE s.strategy_group_id = v_group_id
+
+        UNION
+
+        SELECT p.buyer_id
+        FROM strategy_purchases p
+        JOIN strategies bought ON p.strategy_version = bought.id
+        WHERE
+            bought.strategy_group_id = v_group_id
+            AND p.status <> 'refunded'
+            AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+
+        UNION
+
+        SELECT sh.shared_with_user_id
+        FROM strategy_shares sh
+        WHERE sh.strategy_group_id = v_group_id
+    ) users
+    ORDER BY users.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,79 @@
+-- Strategy Service Bulk Strategy Tag Functions
+-- File: 30_bulk-strategy-tags.sql
+-- Contains adding and removing tags on many strategies of a user at once
+
+-- +goose Up
+-- +goose StatementBegin
+-- Add tags to and remove tags from many strategies at once. Tags belong to the strategy
+-- group, so any version of a strategy can be given. Strategies that don't exist, are
+-- deleted or belong to another user are reported and skipped; the others are changed
+-- together, in the transaction of the call.
+-- Returns one row per distinct strategy: its status (updated, unchanged, not_found or
+-- forbidden) and the number of tags added and removed.
+CREATE OR REPLACE FUNCTION bulk_update_strategy_tags(
+    p_user_id INT,
+    p_strategy_ids INT[],
+    p_add_tag_ids INT[],
+    p_remove_tag_ids INT[]
+)
+RETURNS TABLE (
+    strategy_id INT,
+    status VARCHAR,
+    tags_added INT,
+    tags_removed INT
+) AS $$
+DECLARE
+    v_missing_tag INT;
+    v_strategy RECORD;
+    v_added INT;
+    v_removed INT;
+BEGIN
+    SELECT ids.tag_id INTO v_missing_tag
+    FROM unnest(COALESCE(p_add_tag_ids, '{}') || COALESCE(p_remove_tag_ids, '{}')) AS ids(tag_id)
+    WHERE NOT EXISTS (SELECT 1 FROM strategy_tags t WHERE t.id = ids.tag_id)
+    LIMIT 1;
+
+    IF v_missing_tag IS NOT NULL THEN
+        RAISE EXCEPTION 'Tag not found: %', v_missing_tag;
+    END IF;
+
+    FOR v_strategy IN
+        SELECT ids.strategy_id, s.user_id, s.strategy_group_id, s.is_active
+        FROM (
+            SELECT DISTINCT ON (i.strategy_id) i.strategy_id, i.position
+            FROM unnest(p_strategy_ids) WITH ORDINALITY AS i(strategy_id, position)
+            ORDER BY i.strategy_id, i.position
+        ) ids
+        LEFT JOIN strategies s ON s.id = ids.strategy_id
+        ORDER BY ids.position
+    LOOP
+        IF v_strategy.user_id IS NULL OR NOT v_strategy.is_active THEN
+            RETURN QUERY SELECT v_strategy.strategy_id, 'not_found'::VARCHAR, 0, 0;
+            CONTINUE;
+        END IF;
+
+        IF v_strategy.user_id <> p_user_id THEN
+            RETURN QUERY SELECT v_strategy.strategy_id, 'forbidden'::VARCHAR, 0, 0;
+            CONTINUE;
+        END IF;
+
+        DELETE FROM strategy_tag_mappings stm
+        WHERE stm.strategy_id = v_strategy.strategy_group_id
+          AND stm.tag_id = ANY(COALESCE(p_remove_tag_ids, '{}'));
+        GET DIAGNOSTICS v_removed = ROW_COUNT;
+
+        INSERT INTO strategy_tag_mappings (strategy_id, tag_id)
+        SELECT DISTINCT v_strategy.strategy_group_id, t.tag_id
+        FROM unnest(COALESCE(p_add_tag_ids, '{}')) AS t(tag_id)
+        ON CONFLICT DO NOTHING;
+        GET DIAGNOSTICS v_added = ROW_COUNT;
+
+        RETURN QUERY SELECT
+            v_strategy.strategy_id,
+            (CASE WHEN v_added + v_removed > 0 THEN 'updated' ELSE 'unchanged' END)::VARCHAR,
+            v_added,
+            v_removed;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,141 @@
+-- Strategy Service Indicator Deprecation Functions
+-- File: 31_indicator-deprecation.sql
+-- Contains deprecating indicators in favor of a replacement and finding the strategies
+-- that still use them
+
+-- +goose Up
+-- +goose StatementBegin
+-- Deprecated indicators stay usable until their sunset date; strategies using them are
+-- warned when they are linted, saved or backtested
+CREATE TABLE IF NOT EXISTS "indicator_deprecations" (
+  "indicator_id" int PRIMARY KEY REFERENCES "indicators" ("id") ON DELETE CASCADE,
+  "replacement_id" int REFERENCES "indicators" ("id") ON DELETE SET NULL,
+  "sunset_date" date,
+  "reason" text,
+  "deprecated_by" int NOT NULL,
+  "deprecated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Deprecate a platform indicator, or change its deprecation. The replacement must be another
+-- platform indicator that isn't deprecated itself.
+CREATE OR REPLACE FUNCTION deprecate_indicator(
+    p_indicator_id INT,
+    p_replacement_id INT,
+    p_sunset_date DATE,
+    p_reason TEXT,
+    p_user_id INT
+)
+RETURNS VOID AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM indicators i WHERE i.id = p_indicator_id AND i.owner_id IS NULL) THEN
+        RAISE EXCEPTION 'Indicator not found';
+    END IF;
+
+    IF p_replacement_id IS NOT NULL THEN
+        IF p_replacement_id = p_indicator_id THEN
+            RAISE EXCEPTION 'Invalid replacement: an indicator cannot replace itself';
+        END IF;
+
+        IF NOT EXISTS (
+            SELECT 1 FROM indicators i
+            WHERE i.id = p_replacement_id AND i.owner_id IS NULL AND i.is_active = TRUE
+        ) THEN
+            RAISE EXCEPTION 'Invalid replacement: indicator % is not an active platform indicator', p_replacement_id;
+        END IF;
+
+        IF EXISTS (SELECT 1 FROM indicator_deprecations d WHERE d.indicator_id = p_replacement_id) THEN
+            RAISE EXCEPTION 'Invalid replacement: indicator % is deprecated', p_replacement_id;
+        END IF;
+    END IF;
+
+    INSERT INTO indicator_deprecations (indicator_id, replacement_id, sunset_date, reason, deprecated_by)
+    VALUES (p_indicator_id, p_replacement_id, p_sunset_date, NULLIF(p_reason, ''), p_user_id)
+    ON CONFLICT (indicator_id) DO UPDATE SET
+        replacement_id = EXCLUDED.replacement_id,
+        sunset_date = EXCLUDED.sunset_date,
+        reason = EXCLUDED.reason,
+        deprecated_by = EXCLUDED.deprecated_by,
+        deprecated_at = CURRENT_TIMESTAMP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Withdraw the deprecation of an indicator. Returns false if it wasn't deprecated.
+CREATE OR REPLACE FUNCTION undeprecate_indicator(p_indicator_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM indicator_deprecations WHERE indicator_id = p_indicator_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the deprecations of the given indicators, or of every deprecated indicator when
+-- p_indicator_ids is NULL
+CREATE OR REPLACE FUNCTION get_indicator_deprecations(p_indicator_ids INT[])
+RETURNS TABLE (
+    indicator_id INT,
+    indicator_name VARCHAR,
+    replacement_id INT,
+    replacement_name VARCHAR,
+    sunset_date DATE,
+    reason TEXT,
+    deprecated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT d.indicator_id, i.name, d.replacement_id, r.name, d.sunset_date, d.reason, d.deprecated_at
+    FROM indicator_deprecations d
+    JOIN indicators i ON i.id = d.indicator_id
+    LEFT JOIN indicators r ON r.id = d.replacement_id
+    WHERE p_indicator_ids IS NULL OR d.indicator_id = ANY(p_indicator_ids)
+    ORDER BY i.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Strategies whose current version uses a deprecated indicator, in a rule or among the
+-- declared indicators, soonest sunset first
+CREATE OR REPLACE FUNCTION get_deprecated_indicator_usage()
+RETURNS TABLE (
+    indicator_id INT,
+    indicator_name VARCHAR,
+    replacement_name VARCHAR,
+    sunset_date DATE,
+    strategy_id INT,
+    strategy_group_id INT,
+    strategy_name VARCHAR,
+    user_id INT,
+    version INT,
+    is_listed BOOLEAN
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        d.indicator_id,
+        i.name,
+        r.name,
+        d.sunset_date,
+        s.id,
+        s.strategy_group_id,
+        s.name,
+        s.user_id,
+        s.version,
+        EXISTS (
+            SELECT 1 FROM strategy_marketplace m
+            WHERE m.strategy_id IN (s.id, s.strategy_group_id) AND m.is_active = TRUE
+        )
+    FROM indicator_deprecations d
+    JOIN indicators i ON i.id = d.indicator_id
+    LEFT JOIN indicators r ON r.id = d.replacement_id
+    JOIN strategies s ON
+        s.is_active = TRUE
+        AND (
+            jsonb_path_exists(s.structure, '$.**.indicator.name ? (@ == $name)', jsonb_build_object('name', i.name))
+            OR COALESCE(s.structure->'indicators', '{}'::jsonb) ? i.name
+            OR jsonb_path_exists(s.structure, '$.indicators[*].name ? (@ == $name)', jsonb_build_object('name', i.name))
+        )
+    LEFT JOIN user_strategy_versions usv ON usv.strategy_group_id = s.strategy_group_id AND usv.user_id = s.user_id
+    WHERE
+        (usv.active_version_id IS NULL OR s.id = usv.active_version_id)
+    ORDER BY d.sunset_date NULLS LAST, i.name, s.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
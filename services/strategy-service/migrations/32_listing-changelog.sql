@@ -0,0 +1,103 @@
+-- Strategy Service Listing Changelog Functions
+-- File: 32_listing-changelog.sql
+-- Contains publishing new strategy versions to marketplace listings and their changelog
+
+-- +goose Up
+-- +goose StatementBegin
+-- One entry per strategy version published to a listing, with the seller's notes on what
+-- changed. The version a listing was created with is recorded on the first publish.
+CREATE TABLE IF NOT EXISTS "listing_versions" (
+  "id" SERIAL PRIMARY KEY,
+  "marketplace_id" int NOT NULL REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE,
+  "version" int NOT NULL,
+  "notes" text,
+  "published_by" int NOT NULL,
+  "published_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  CONSTRAINT "listing_versions_version_unique" UNIQUE ("marketplace_id", "version")
+);
+
+-- Publish a newer version of the listed strategy to an active listing of the seller and
+-- record it in the changelog. Returns the new changelog entry.
+CREATE OR REPLACE FUNCTION publish_listing_version(
+    p_marketplace_id INT,
+    p_user_id INT,
+    p_version INT,
+    p_notes TEXT
+)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    version INT,
+    notes TEXT,
+    published_at TIMESTAMP
+) AS $$
+DECLARE
+    v_listing RECORD;
+BEGIN
+    SELECT m.id, m.strategy_id, m.version_id, m.user_id, m.is_active, m.created_at
+    INTO v_listing
+    FROM strategy_marketplace m
+    WHERE m.id = p_marketplace_id
+    FOR UPDATE;
+
+    IF NOT FOUND OR NOT v_listing.is_active THEN
+        RAISE EXCEPTION 'Listing not found';
+    END IF;
+
+    IF v_listing.user_id <> p_user_id THEN
+        RAISE EXCEPTION 'You don''t have permission to update this listing';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM strategies s
+        WHERE s.strategy_group_id = v_listing.strategy_id
+          AND s.version = p_version
+          AND s.is_active = TRUE
+    ) THEN
+        RAISE EXCEPTION 'Strategy version not found';
+    END IF;
+
+    IF p_version <= v_listing.version_id THEN
+        RAISE EXCEPTION 'Invalid version: the listing already has version %', v_listing.version_id;
+    END IF;
+
+    -- Keep the version the listing started with in the changelog
+    INSERT INTO listing_versions (marketplace_id, version, published_by, published_at)
+    VALUES (p_marketplace_id, v_listing.version_id, v_listing.user_id, v_listing.created_at)
+    ON CONFLICT DO NOTHING;
+
+    UPDATE strategy_marketplace
+    SET version_id = p_version, updated_at = NOW()
+    WHERE strategy_marketplace.id = p_marketplace_id;
+
+    RETURN QUERY
+    INSERT INTO listing_versions AS lv (marketplace_id, version, notes, published_by)
+    VALUES (p_marketplace_id, p_version, NULLIF(p_notes, ''), p_user_id)
+    RETURNING lv.id, lv.marketplace_id, lv.version, lv.notes, lv.published_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the changelog of a listing, newest version first. Listings that never had a new
+-- version published get a single entry for the version they were created with.
+CREATE OR REPLACE FUNCTION get_listing_changelog(p_marketplace_id INT)
+RETURNS TABLE (
+    version INT,
+    notes TEXT,
+    published_at TIMESTAMP,
+    is_current BOOLEAN
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT lv.version, lv.notes, lv.published_at, lv.version = m.version_id
+    FROM listing_versions lv
+    JOIN strategy_marketplace m ON m.id = lv.marketplace_id
+    WHERE lv.marketplace_id = p_marketplace_id
+    UNION ALL
+    SELECT m.version_id, NULL::TEXT, m.created_at, TRUE
+    FROM strategy_marketplace m
+    WHERE m.id = p_marketplace_id
+      AND NOT EXISTS (SELECT 1 FROM listing_versions lv WHERE lv.marketplace_id = m.id)
+    ORDER BY 1 DESC;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
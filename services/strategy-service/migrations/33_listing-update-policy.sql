@@ -0,0 +1,435 @@
+-- Strategy Service Listing Update Policy Functions
+-- File: 33_listing-update-policy.sql
+-- Contains whether buyers of a listing get the versions published after their purchase, and
+-- upgrading the purchases of listings that don't
+
+-- +goose Up
+-- +goose StatementBegin
+-- With lifetime_updates, buyers can use every version published to the listing; with
+-- version_locked, only the version they bought or upgraded to and the ones before it.
+-- upgrade_price is what an upgrade costs; the listing price when it isn't set.
+ALTER TABLE "strategy_marketplace" ADD COLUMN IF NOT EXISTS "update_policy" varchar(20) NOT NULL DEFAULT 'version_locked';
+ALTER TABLE "strategy_marketplace" ADD COLUMN IF NOT EXISTS "upgrade_price" numeric(10,2);
+
+ALTER TABLE "strategy_marketplace" DROP CONSTRAINT IF EXISTS "strategy_marketplace_update_policy_check";
+ALTER TABLE "strategy_marketplace"
+    ADD CONSTRAINT "strategy_marketplace_update_policy_check" CHECK ("update_policy" IN ('lifetime_updates', 'version_locked'));
+
+-- Upgrades of version-locked purchases to the listing's current version. from_version and
+-- to_version are strategy version IDs, like strategy_purchases.strategy_version.
+CREATE TABLE IF NOT EXISTS "purchase_upgrades" (
+  "id" SERIAL PRIMARY KEY,
+  "purchase_id" int NOT NULL REFERENCES "strategy_purchases" ("id") ON DELETE CASCADE,
+  "from_version" int NOT NULL,
+  "to_version" int NOT NULL,
+  "price" numeric(10,2) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_purchase_upgrades_purchase" ON "purchase_upgrades" ("purchase_id");
+
+-- Put strategy on marketplace (replaces the version in 20_marketplace-currencies.sql)
+DROP FUNCTION IF EXISTS create_marketplace_listing(INT, INT, INT, NUMERIC, BOOLEAN, VARCHAR, TEXT, VARCHAR);
+
+CREATE OR REPLACE FUNCTION create_marketplace_listing(
+    p_user_id INT,
+    p_strategy_id INT, -- This is actually the strategy_group_id
+    p_version_id INT,  -- This is the version number (not the ID)
+    p_price NUMERIC,
+    p_is_subscription BOOLEAN,
+    p_subscription_period VARCHAR,
+    p_description_public TEXT,
+    p_currency VARCHAR(3) DEFAULT 'USD',
+    p_update_policy VARCHAR DEFAULT 'version_locked',
+    p_upgrade_price NUMERIC DEFAULT NULL
+)
+RETURNS INT AS $$
+DECLARE
+    new_listing_id INT;
+BEGIN
+    -- Check if strategy belongs to user and the version exists
+    PERFORM 1
+    FROM strategies s
+    WHERE s.strategy_group_id = p_strategy_id
+    AND s.user_id = p_user_id
+    AND s.version = p_version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Strategy does not belong to user or version does not exist';
+    END IF;
+
+    PERFORM 1 FROM currencies
+    WHERE code = p_currency AND is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Unsupported currency';
+    END IF;
+
+    -- Check if listing already exists
+    PERFORM 1 FROM strategy_marketplace
+    WHERE strategy_id = p_strategy_id AND is_active = TRUE;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Strategy is already listed on marketplace';
+    END IF;
+
+    -- Insert marketplace listing
+    INSERT INTO strategy_marketplace (
+        strategy_id,
+        version_id,
+        user_id,
+        price,
+        currency,
+        is_subscription,
+        subscription_period,
+        is_active,
+        description_public,
+        update_policy,
+        upgrade_price,
+        created_at,
+        updated_at
+    )
+    VALUES (
+        p_strategy_id,
+        p_version_id,
+        p_user_id,
+        p_price,
+        p_currency,
+        p_is_subscription,
+        p_subscription_period,
+        TRUE,
+        p_description_public,
+        COALESCE(p_update_policy, 'version_locked'),
+        p_upgrade_price,
+        NOW(),
+        NOW()
+    )
+    RETURNING id INTO new_listing_id;
+
+    RETURN new_listing_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get marketplace listing by ID (replaces the version in 20_marketplace-currencies.sql)
+DROP FUNCTION IF EXISTS get_marketplace_listing_by_id(INT);
+
+CREATE OR REPLACE FUNCTION get_marketplace_listing_by_id(
+    p_listing_id INT
+)
+RETURNS TABLE (
+    id INT,
+    strategy_id INT,
+    version_id INT,
+    user_id INT,
+    price NUMERIC,
+    is_subscription BOOLEAN,
+    subscription_period VARCHAR,
+    is_active BOOLEAN,
+    description_public TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    currency VARCHAR,
+    update_policy VARCHAR,
+    upgrade_price NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.id,
+        m.strategy_id,
+        m.version_id,
+        m.user_id,
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        m.is_active,
+        m.description_public,
+        m.created_at,
+        m.updated_at,
+        m.currency,
+        m.update_policy,
+        m.upgrade_price
+    FROM
+        strategy_marketplace m
+    WHERE
+        m.id = p_listing_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Change the update policy and upgrade price of an active listing of the seller. Buyers
+-- of the listing gain or lose access to later versions right away.
+CREATE OR REPLACE FUNCTION set_listing_update_policy(
+    p_marketplace_id INT,
+    p_user_id INT,
+    p_update_policy VARCHAR,
+    p_upgrade_price NUMERIC
+)
+RETURNS VOID AS $$
+DECLARE
+    v_owner_id INT;
+BEGIN
+    SELECT m.user_id INTO v_owner_id
+    FROM strategy_marketplace m
+    WHERE m.id = p_marketplace_id AND m.is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Listing not found';
+    END IF;
+
+    IF v_owner_id <> p_user_id THEN
+        RAISE EXCEPTION 'You don''t have permission to update this listing';
+    END IF;
+
+    UPDATE strategy_marketplace
+    SET update_policy = p_update_policy, upgrade_price = p_upgrade_price, updated_at = NOW()
+    WHERE id = p_marketplace_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The highest version number of a strategy group the user can use through purchases that
+-- still grant access, or NULL if none does
+CREATE OR REPLACE FUNCTION get_purchased_version_ceiling(
+    p_user_id INT,
+    p_strategy_group_id INT
+)
+RETURNS INT AS $$
+BEGIN
+    RETURN (
+        SELECT MAX(
+            CASE
+                WHEN m.update_policy = 'lifetime_updates' THEN GREATEST(m.version_id, bought.version)
+                ELSE bought.version
+            END
+        )
+        FROM strategy_purchases p
+        JOIN strategy_marketplace m ON m.id = p.marketplace_id
+        JOIN strategies bought ON bought.id = p.strategy_version
+        WHERE p.buyer_id = p_user_id
+        AND bought.strategy_group_id = p_strategy_group_id
+        AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Resolve how a user can access a strategy (replaces the version in 10_share-functions.sql
+-- to apply the update policy of purchased listings). Returns, in order of precedence,
+-- 'owner', 'purchased', 'shared_backtest', 'public', 'shared_view', or NULL for no access.
+CREATE OR REPLACE FUNCTION get_strategy_access_level(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TEXT AS $$
+DECLARE
+    v_strategy RECORD;
+    v_share_permission VARCHAR(20);
+BEGIN
+    SELECT s.id, s.user_id, s.is_public, s.strategy_group_id, s.version
+    INTO v_strategy
+    FROM strategies s
+    WHERE s.id = p_strategy_id AND s.is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    IF v_strategy.user_id = p_user_id THEN
+        RETURN 'owner';
+    END IF;
+
+    IF v_strategy.version <= get_purchased_version_ceiling(p_user_id, v_strategy.strategy_group_id) THEN
+        RETURN 'purchased';
+    END IF;
+
+    SELECT sh.permission INTO v_share_permission
+    FROM strategy_shares sh
+    WHERE sh.strategy_group_id = v_strategy.strategy_group_id
+    AND sh.shared_with_user_id = p_user_id;
+
+    IF v_share_permission = 'backtest' THEN
+        RETURN 'shared_backtest';
+    END IF;
+
+    IF v_strategy.is_public THEN
+        RETURN 'public';
+    END IF;
+
+    IF v_share_permission = 'view' THEN
+        RETURN 'shared_view';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get strategy by ID (replaces the version in 10_share-functions.sql). Buyers can open any
+-- version up to their purchased version ceiling; by strategy group they get the ceiling.
+CREATE OR REPLACE FUNCTION get_strategy_by_id(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    user_id INT,
+    description TEXT,
+    thumbnail_url VARCHAR(255),
+    structure JSONB,
+    is_public BOOLEAN,
+    is_active BOOLEAN,
+    version INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    strategy_group_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.id,
+        s.name,
+        s.user_id,
+        s.description,
+        s.thumbnail_url,
+        s.structure,
+        s.is_public,
+        s.is_active,
+        s.version,
+        s.created_at,
+        s.updated_at,
+        s.strategy_group_id
+    FROM
+        strategies s
+    LEFT JOIN
+        user_strategy_versions usv ON s.strategy_group_id = usv.strategy_group_id AND usv.user_id = p_user_id
+    WHERE
+        (
+            -- Case 1: User owns the strategy, show their active version or the strategy directly requested
+            (s.user_id = p_user_id AND (s.id = p_strategy_id OR (s.strategy_group_id = p_strategy_id AND (usv.active_version_id = s.id OR usv.active_version_id IS NULL))))
+
+            OR
+
+            -- Case 2: User purchased the strategy, show the requested version if the
+            -- purchase covers it, or the latest version it covers
+            (
+                s.user_id <> p_user_id
+                AND (
+                    (s.id = p_strategy_id AND s.version <= get_purchased_version_ceiling(p_user_id, s.strategy_group_id))
+                    OR (s.strategy_group_id = p_strategy_id AND s.version = get_purchased_version_ceiling(p_user_id, s.strategy_group_id))
+                )
+            )
+
+            OR
+
+            -- Case 3: Strategy is public and the user is accessing by ID directly
+            (s.is_public = TRUE AND s.id = p_strategy_id)
+
+            OR
+
+            -- Case 4: Strategy is shared with the user, show the requested version or the latest one
+            (
+                EXISTS (
+                    SELECT 1
+                    FROM strategy_shares sh
+                    WHERE sh.strategy_group_id = s.strategy_group_id
+                    AND sh.shared_with_user_id = p_user_id
+                )
+                AND (
+                    s.id = p_strategy_id
+                    OR (
+                        s.strategy_group_id = p_strategy_id
+                        AND s.version = (
+                            SELECT MAX(s2.version)
+                            FROM strategies s2
+                            WHERE s2.strategy_group_id = s.strategy_group_id AND s2.is_active = TRUE
+                        )
+                    )
+                )
+            )
+        )
+        AND s.is_active = TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Upgrade a version-locked purchase of the buyer to the listing's current version. The
+-- buyer's active version follows if it was the version they bought.
+CREATE OR REPLACE FUNCTION upgrade_purchase(
+    p_purchase_id INT,
+    p_buyer_id INT
+)
+RETURNS TABLE (
+    id INT,
+    purchase_id INT,
+    from_version INT,
+    to_version INT,
+    price NUMERIC,
+    currency VARCHAR,
+    created_at TIMESTAMP
+) AS $$
+DECLARE
+    v_purchase RECORD;
+    v_to_version INT;
+BEGIN
+    SELECT
+        p.id,
+        p.strategy_version,
+        p.subscription_end,
+        bought.version AS bought_version,
+        bought.strategy_group_id,
+        m.version_id AS listing_version,
+        m.is_active AS listing_active,
+        m.update_policy,
+        COALESCE(m.upgrade_price, m.price) AS upgrade_price,
+        m.currency
+    INTO v_purchase
+    FROM strategy_purchases p
+    JOIN strategy_marketplace m ON m.id = p.marketplace_id
+    JOIN strategies bought ON bought.id = p.strategy_version
+    WHERE p.id = p_purchase_id AND p.buyer_id = p_buyer_id
+    FOR UPDATE OF p;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Purchase not found';
+    END IF;
+
+    IF v_purchase.subscription_end IS NOT NULL AND v_purchase.subscription_end <= NOW() THEN
+        RAISE EXCEPTION 'Invalid upgrade: the purchase no longer grants access';
+    END IF;
+
+    IF NOT v_purchase.listing_active THEN
+        RAISE EXCEPTION 'Listing is not active';
+    END IF;
+
+    IF v_purchase.update_policy = 'lifetime_updates' THEN
+        RAISE EXCEPTION 'Invalid upgrade: the listing includes lifetime updates';
+    END IF;
+
+    IF v_purchase.bought_version >= v_purchase.listing_version THEN
+        RAISE EXCEPTION 'Purchase is already on the latest version';
+    END IF;
+
+    SELECT s.id INTO v_to_version
+    FROM strategies s
+    WHERE s.strategy_group_id = v_purchase.strategy_group_id
+    AND s.version = v_purchase.listing_version
+    AND s.is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Strategy version not found';
+    END IF;
+
+    UPDATE strategy_purchases
+    SET strategy_version = v_to_version
+    WHERE strategy_purchases.id = p_purchase_id;
+
+    UPDATE user_strategy_versions usv
+    SET active_version_id = v_to_version, updated_at = NOW()
+    WHERE usv.user_id = p_buyer_id
+    AND usv.strategy_group_id = v_purchase.strategy_group_id
+    AND usv.active_version_id = v_purchase.strategy_version;
+
+    RETURN QUERY
+    INSERT INTO purchase_upgrades AS u (purchase_id, from_version, to_version, price)
+    VALUES (p_purchase_id, v_purchase.strategy_version, v_to_version, v_purchase.upgrade_price)
+    RETURNING u.id, u.purchase_id, u.from_version, u.to_version, u.price, v_purchase.currency::VARCHAR, u.created_at;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
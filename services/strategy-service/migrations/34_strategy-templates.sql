@@ -0,0 +1,211 @@
+-- Strategy Service Strategy Template Functions
+-- File: 34_strategy-templates.sql
+-- Contains admin-curated starter strategies users create their own strategies from
+
+-- +goose Up
+-- +goose StatementBegin
+-- Starter strategies. String values "{{name}}" in structure are placeholders for the
+-- parameter name; parameters lists them with their type, default and bounds. Inactive
+-- templates are only shown to template admins.
+CREATE TABLE IF NOT EXISTS "strategy_templates" (
+  "id" SERIAL PRIMARY KEY,
+  "name" varchar(100) UNIQUE NOT NULL,
+  "description" text,
+  "category" varchar(50) NOT NULL,
+  "structure" jsonb NOT NULL,
+  "parameters" jsonb NOT NULL DEFAULT '[]',
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_by" int,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_templates_category" ON "strategy_templates" ("category", "name") WHERE "is_active" = true;
+
+-- Get templates, optionally of one category, by name
+CREATE OR REPLACE FUNCTION get_strategy_templates(
+    p_category VARCHAR,
+    p_include_inactive BOOLEAN
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    description TEXT,
+    category VARCHAR(50),
+    structure JSONB,
+    parameters JSONB,
+    is_active BOOLEAN,
+    created_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.id, t.name, COALESCE(t.description, ''), t.category, t.structure, t.parameters,
+        t.is_active, t.created_by, t.created_at, t.updated_at
+    FROM strategy_templates t
+    WHERE (p_category IS NULL OR t.category = p_category)
+      AND (p_include_inactive OR t.is_active = TRUE)
+    ORDER BY t.category, t.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the categories of templates with how many templates each has
+CREATE OR REPLACE FUNCTION get_strategy_template_categories(
+    p_include_inactive BOOLEAN
+)
+RETURNS TABLE (
+    category VARCHAR(50),
+    count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.category, COUNT(*) AS count
+    FROM strategy_templates t
+    WHERE p_include_inactive OR t.is_active = TRUE
+    GROUP BY t.category
+    ORDER BY t.category;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a template by ID
+CREATE OR REPLACE FUNCTION get_strategy_template_by_id(
+    p_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    description TEXT,
+    category VARCHAR(50),
+    structure JSONB,
+    parameters JSONB,
+    is_active BOOLEAN,
+    created_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        t.id, t.name, COALESCE(t.description, ''), t.category, t.structure, t.parameters,
+        t.is_active, t.created_by, t.created_at, t.updated_at
+    FROM strategy_templates t
+    WHERE t.id = p_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a template
+CREATE OR REPLACE FUNCTION create_strategy_template(
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_category VARCHAR(50),
+    p_structure JSONB,
+    p_parameters JSONB,
+    p_is_active BOOLEAN,
+    p_created_by INT
+)
+RETURNS INT AS $$
+DECLARE
+    new_template_id INT;
+BEGIN
+    INSERT INTO strategy_templates (name, description, category, structure, parameters, is_active, created_by)
+    VALUES (p_name, p_description, p_category, p_structure, p_parameters, p_is_active, p_created_by)
+    RETURNING id INTO new_template_id;
+
+    RETURN new_template_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a template. Returns false if it doesn't exist.
+CREATE OR REPLACE FUNCTION update_strategy_template(
+    p_id INT,
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_category VARCHAR(50),
+    p_structure JSONB,
+    p_parameters JSONB,
+    p_is_active BOOLEAN
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE strategy_templates
+    SET
+        name = p_name,
+        description = p_description,
+        category = p_category,
+        structure = p_structure,
+        parameters = p_parameters,
+        is_active = p_is_active,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = p_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a template. Strategies created from it are unaffected. Returns false if it
+-- doesn't exist.
+CREATE OR REPLACE FUNCTION delete_strategy_template(
+    p_id INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM strategy_templates WHERE id = p_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Default templates
+INSERT INTO strategy_templates (name, description, category, structure, parameters) VALUES
+(
+    'RSI mean reversion',
+    'Buys when the RSI falls below the oversold level and sells when it rises above the overbought level.',
+    'Mean Reversion',
+    '{
+        "buyRules": {
+            "rule0": {
+                "indicator": {"name": "RSI", "indicatorSettings": {"timeperiod": "{{period}}"}},
+                "condition": {"symbol": "<", "value": "{{oversold}}"}
+            }
+        },
+        "sellRules": {
+            "rule0": {
+                "indicator": {"name": "RSI", "indicatorSettings": {"timeperiod": "{{period}}"}},
+                "condition": {"symbol": ">", "value": "{{overbought}}"}
+            }
+        }
+    }',
+    '[
+        {"name": "period", "label": "RSI period", "type": "integer", "default": 14, "min": 2, "max": 100},
+        {"name": "oversold", "label": "Oversold level", "type": "number", "default": 30, "min": 0, "max": 100},
+        {"name": "overbought", "label": "Overbought level", "type": "number", "default": 70, "min": 0, "max": 100}
+    ]'
+),
+(
+    'MACD crossover',
+    'Buys when the MACD line rises above zero and sells when it falls below zero.',
+    'Momentum',
+    '{
+        "buyRules": {
+            "rule0": {
+                "indicator": {"name": "MACD", "indicatorSettings": {"fastperiod": "{{fast_period}}", "slowperiod": "{{slow_period}}", "signalperiod": "{{signal_period}}"}},
+                "condition": {"symbol": ">", "value": 0}
+            }
+        },
+        "sellRules": {
+            "rule0": {
+                "indicator": {"name": "MACD", "indicatorSettings": {"fastperiod": "{{fast_period}}", "slowperiod": "{{slow_period}}", "signalperiod": "{{signal_period}}"}},
+                "condition": {"symbol": "<", "value": 0}
+            }
+        }
+    }',
+    '[
+        {"name": "fast_period", "label": "Fast EMA period", "type": "integer", "default": 12, "min": 2, "max": 100},
+        {"name": "slow_period", "label": "Slow EMA period", "type": "integer", "default": 26, "min": 2, "max": 200},
+        {"name": "signal_period", "label": "Signal period", "type": "integer", "default": 9, "min": 1, "max": 50}
+    ]'
+)
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
@@ -0,0 +1,160 @@
+-- Strategy Service Indicator Preset Functions
+-- File: 35_indicator-presets.sql
+-- Contains named parameter presets for indicators, e.g. "RSI aggressive"
+
+-- +goose Up
+-- +goose StatementBegin
+-- Named parameter values for an indicator. Presets without a user are global and curated
+-- by indicator admins; the others belong to, and are only visible to, their user.
+-- Strategy structures reference presets by ID ("presetId" next to "indicatorSettings").
+CREATE TABLE IF NOT EXISTS "indicator_presets" (
+  "id" SERIAL PRIMARY KEY,
+  "indicator_id" int NOT NULL REFERENCES "indicators" ("id") ON DELETE CASCADE,
+  "user_id" int,
+  "name" varchar(100) NOT NULL,
+  "description" text,
+  "settings" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_indicator_presets_name"
+  ON "indicator_presets" ("indicator_id", COALESCE("user_id", 0), lower("name"));
+
+-- Get the presets of an indicator a user can see, global ones first, by name
+CREATE OR REPLACE FUNCTION get_indicator_presets(
+    p_indicator_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    indicator_id INT,
+    indicator_name VARCHAR,
+    user_id INT,
+    name VARCHAR(100),
+    description TEXT,
+    settings JSONB,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id, p.indicator_id, i.name::VARCHAR, p.user_id, p.name, COALESCE(p.description, ''),
+        p.settings, p.created_at, p.updated_at
+    FROM indicator_presets p
+    JOIN indicators i ON i.id = p.indicator_id
+    WHERE p.indicator_id = p_indicator_id
+      AND (p.user_id IS NULL OR p.user_id = p_user_id)
+    ORDER BY p.user_id NULLS FIRST, lower(p.name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a preset by ID
+CREATE OR REPLACE FUNCTION get_indicator_preset_by_id(
+    p_id INT
+)
+RETURNS TABLE (
+    id INT,
+    indicator_id INT,
+    indicator_name VARCHAR,
+    user_id INT,
+    name VARCHAR(100),
+    description TEXT,
+    settings JSONB,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id, p.indicator_id, i.name::VARCHAR, p.user_id, p.name, COALESCE(p.description, ''),
+        p.settings, p.created_at, p.updated_at
+    FROM indicator_presets p
+    JOIN indicators i ON i.id = p.indicator_id
+    WHERE p.id = p_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the presets with the given IDs that a user can use: global ones and their own
+CREATE OR REPLACE FUNCTION get_usable_indicator_presets(
+    p_user_id INT,
+    p_ids INT[]
+)
+RETURNS TABLE (
+    id INT,
+    indicator_id INT,
+    indicator_name VARCHAR,
+    user_id INT,
+    name VARCHAR(100),
+    description TEXT,
+    settings JSONB,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        p.id, p.indicator_id, i.name::VARCHAR, p.user_id, p.name, COALESCE(p.description, ''),
+        p.settings, p.created_at, p.updated_at
+    FROM indicator_presets p
+    JOIN indicators i ON i.id = p.indicator_id
+    WHERE p.id = ANY(p_ids)
+      AND (p.user_id IS NULL OR p.user_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a preset; a NULL user makes it global
+CREATE OR REPLACE FUNCTION create_indicator_preset(
+    p_indicator_id INT,
+    p_user_id INT,
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_settings JSONB
+)
+RETURNS INT AS $$
+DECLARE
+    new_preset_id INT;
+BEGIN
+    INSERT INTO indicator_presets (indicator_id, user_id, name, description, settings)
+    VALUES (p_indicator_id, p_user_id, p_name, p_description, p_settings)
+    RETURNING id INTO new_preset_id;
+
+    RETURN new_preset_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a preset. Returns false if it doesn't exist.
+CREATE OR REPLACE FUNCTION update_indicator_preset(
+    p_id INT,
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_settings JSONB
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE indicator_presets
+    SET
+        name = p_name,
+        description = p_description,
+        settings = p_settings,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = p_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a preset. Strategies still referencing it fail validation until they drop the
+-- reference. Returns false if it doesn't exist.
+CREATE OR REPLACE FUNCTION delete_indicator_preset(
+    p_id INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM indicator_presets WHERE id = p_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,288 @@
+-- Strategy Service Strategy Risk Score Functions
+-- File: 36_strategy-risk-scores.sql
+-- Contains the risk scores of strategy versions, shown on marketplace listings
+
+-- +goose Up
+-- +goose StatementBegin
+-- The trading settings of the backtest a snapshot was copied from; snapshots attached
+-- before they were recorded ran with the defaults
+ALTER TABLE "marketplace_backtests" ADD COLUMN IF NOT EXISTS "leverage" float NOT NULL DEFAULT 1;
+ALTER TABLE "marketplace_backtests" ADD COLUMN IF NOT EXISTS "position_sizing" varchar(20) NOT NULL DEFAULT 'fixed';
+
+-- The risk score of a strategy version (a row of strategies), computed from its verified
+-- backtests and structure. components holds the score of each factor that went into it.
+CREATE TABLE IF NOT EXISTS "strategy_risk_scores" (
+  "strategy_id" int PRIMARY KEY REFERENCES "strategies" ("id") ON DELETE CASCADE,
+  "score" int NOT NULL,
+  "level" varchar(10) NOT NULL,
+  "components" jsonb NOT NULL DEFAULT '[]',
+  "backtest_count" int NOT NULL DEFAULT 0,
+  "computed_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+DROP FUNCTION IF EXISTS attach_marketplace_backtest(
+    INT, INT, INT, INT, INT, VARCHAR, TIMESTAMP, TIMESTAMP, NUMERIC, INT,
+    FLOAT, FLOAT, FLOAT, FLOAT, FLOAT, FLOAT, JSONB, TIMESTAMP
+);
+
+-- Attach a verified backtest snapshot to a listing owned by the seller.
+-- Returns the snapshot ID, or NULL if the listing doesn't belong to the seller.
+CREATE OR REPLACE FUNCTION attach_marketplace_backtest(
+    p_marketplace_id INT,
+    p_seller_id INT,
+    p_backtest_id INT,
+    p_strategy_id INT,
+    p_strategy_version INT,
+    p_timeframe VARCHAR,
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP,
+    p_initial_capital NUMERIC,
+    p_total_trades INT,
+    p_win_rate FLOAT,
+    p_profit_factor FLOAT,
+    p_sharpe_ratio FLOAT,
+    p_max_drawdown FLOAT,
+    p_total_return FLOAT,
+    p_annualized_return FLOAT,
+    p_runs JSONB,
+    p_backtest_completed_at TIMESTAMP,
+    p_leverage FLOAT,
+    p_position_sizing VARCHAR
+)
+RETURNS INT AS $$
+DECLARE
+    v_snapshot_id INT;
+BEGIN
+    PERFORM 1 FROM strategy_marketplace
+    WHERE id = p_marketplace_id AND user_id = p_seller_id;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO marketplace_backtests (
+        marketplace_id, backtest_id, strategy_id, strategy_version, timeframe,
+        start_date, end_date, initial_capital, total_trades, win_rate, profit_factor,
+        sharpe_ratio, max_drawdown, total_return, annualized_return, runs,
+        backtest_completed_at, verified_at, leverage, position_sizing
+    )
+    VALUES (
+        p_marketplace_id, p_backtest_id, p_strategy_id, p_strategy_version, p_timeframe,
+        p_start_date, p_end_date, p_initial_capital, p_total_trades, p_win_rate, p_profit_factor,
+        p_sharpe_ratio, p_max_drawdown, p_total_return, p_annualized_return, p_runs,
+        p_backtest_completed_at, NOW(), p_leverage, p_position_sizing
+    )
+    ON CONFLICT (marketplace_id) DO UPDATE SET
+        backtest_id = EXCLUDED.backtest_id,
+        strategy_id = EXCLUDED.strategy_id,
+        strategy_version = EXCLUDED.strategy_version,
+        timeframe = EXCLUDED.timeframe,
+        start_date = EXCLUDED.start_date,
+        end_date = EXCLUDED.end_date,
+        initial_capital = EXCLUDED.initial_capital,
+        total_trades = EXCLUDED.total_trades,
+        win_rate = EXCLUDED.win_rate,
+        profit_factor = EXCLUDED.profit_factor,
+        sharpe_ratio = EXCLUDED.sharpe_ratio,
+        max_drawdown = EXCLUDED.max_drawdown,
+        total_return = EXCLUDED.total_return,
+        annualized_return = EXCLUDED.annualized_return,
+        runs = EXCLUDED.runs,
+        backtest_completed_at = EXCLUDED.backtest_completed_at,
+        verified_at = NOW(),
+        leverage = EXCLUDED.leverage,
+        position_sizing = EXCLUDED.position_sizing
+    RETURNING id INTO v_snapshot_id;
+
+    RETURN v_snapshot_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS get_marketplace_backtest(INT);
+
+-- Get the verified backtest snapshot of a listing
+CREATE OR REPLACE FUNCTION get_marketplace_backtest(p_marketplace_id INT)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    backtest_id INT,
+    strategy_id INT,
+    strategy_version INT,
+    timeframe VARCHAR,
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    initial_capital NUMERIC,
+    total_trades INT,
+    win_rate FLOAT,
+    profit_factor FLOAT,
+    sharpe_ratio FLOAT,
+    max_drawdown FLOAT,
+    total_return FLOAT,
+    annualized_return FLOAT,
+    runs JSONB,
+    backtest_completed_at TIMESTAMP,
+    verified_at TIMESTAMP,
+    leverage FLOAT,
+    position_sizing VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        mb.id,
+        mb.marketplace_id,
+        mb.backtest_id,
+        mb.strategy_id,
+        mb.strategy_version,
+        mb.timeframe,
+        mb.start_date,
+        mb.end_date,
+        mb.initial_capital,
+        mb.total_trades,
+        mb.win_rate,
+        mb.profit_factor,
+        mb.sharpe_ratio,
+        mb.max_drawdown,
+        mb.total_return,
+        mb.annualized_return,
+        mb.runs,
+        mb.backtest_completed_at,
+        mb.verified_at,
+        mb.leverage,
+        mb.position_sizing
+    FROM marketplace_backtests mb
+    WHERE mb.marketplace_id = p_marketplace_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the verified backtest snapshots of a strategy version, across all its listings
+CREATE OR REPLACE FUNCTION get_strategy_verified_backtests(p_strategy_id INT)
+RETURNS TABLE (
+    id INT,
+    marketplace_id INT,
+    backtest_id INT,
+    strategy_id INT,
+    strategy_version INT,
+    timeframe VARCHAR,
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    initial_capital NUMERIC,
+    total_trades INT,
+    win_rate FLOAT,
+    profit_factor FLOAT,
+    sharpe_ratio FLOAT,
+    max_drawdown FLOAT,
+    total_return FLOAT,
+    annualized_return FLOAT,
+    runs JSONB,
+    backtest_completed_at TIMESTAMP,
+    verified_at TIMESTAMP,
+    leverage FLOAT,
+    position_sizing VARCHAR
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        mb.id,
+        mb.marketplace_id,
+        mb.backtest_id,
+        mb.strategy_id,
+        mb.strategy_version,
+        mb.timeframe,
+        mb.start_date,
+        mb.end_date,
+        mb.initial_capital,
+        mb.total_trades,
+        mb.win_rate,
+        mb.profit_factor,
+        mb.sharpe_ratio,
+        mb.max_drawdown,
+        mb.total_return,
+        mb.annualized_return,
+        mb.runs,
+        mb.backtest_completed_at,
+        mb.verified_at,
+        mb.leverage,
+        mb.position_sizing
+    FROM marketplace_backtests mb
+    WHERE mb.strategy_id = p_strategy_id
+    ORDER BY mb.verified_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the ID of the strategy version a listing sells
+CREATE OR REPLACE FUNCTION get_listing_version_strategy_id(p_marketplace_id INT)
+RETURNS INT AS $$
+DECLARE
+    v_strategy_id INT;
+BEGIN
+    SELECT v.id INTO v_strategy_id
+    FROM strategy_marketplace m
+    JOIN strategies s ON s.id = m.strategy_id
+    JOIN strategies v ON v.strategy_group_id = s.strategy_group_id AND v.version = m.version_id
+    WHERE m.id = p_marketplace_id;
+
+    RETURN v_strategy_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store the risk score of a strategy version, replacing the previous one
+CREATE OR REPLACE FUNCTION upsert_strategy_risk_score(
+    p_strategy_id INT,
+    p_score INT,
+    p_level VARCHAR,
+    p_components JSONB,
+    p_backtest_count INT
+)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO strategy_risk_scores (strategy_id, score, level, components, backtest_count, computed_at)
+    VALUES (p_strategy_id, p_score, p_level, p_components, p_backtest_count, NOW())
+    ON CONFLICT (strategy_id) DO UPDATE SET
+        score = EXCLUDED.score,
+        level = EXCLUDED.level,
+        components = EXCLUDED.components,
+        backtest_count = EXCLUDED.backtest_count,
+        computed_at = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the risk score of a strategy version
+CREATE OR REPLACE FUNCTION get_strategy_risk_score(p_strategy_id INT)
+RETURNS TABLE (
+    strategy_id INT,
+    score INT,
+    level VARCHAR,
+    components JSONB,
+    backtest_count INT,
+    computed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT r.strategy_id, r.score, r.level, r.components, r.backtest_count, r.computed_at
+    FROM strategy_risk_scores r
+    WHERE r.strategy_id = p_strategy_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the risk scores of the strategy versions the given listings sell, for list badges
+CREATE OR REPLACE FUNCTION get_listing_risk_scores(p_marketplace_ids INT[])
+RETURNS TABLE (
+    marketplace_id INT,
+    strategy_id INT,
+    score INT,
+    level VARCHAR,
+    components JSONB,
+    backtest_count INT,
+    computed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT m.id, r.strategy_id, r.score, r.level, r.components, r.backtest_count, r.computed_at
+    FROM strategy_marketplace m
+    JOIN strategies s ON s.id = m.strategy_id
+    JOIN strategies v ON v.strategy_group_id = s.strategy_group_id AND v.version = m.version_id
+    JOIN strategy_risk_scores r ON r.strategy_id = v.id
+    WHERE m.id = ANY(p_marketplace_ids);
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,85 @@
+-- Strategy Service Listing Price History Functions
+-- File: 37_listing-price-history.sql
+-- Contains the history of listing prices and the lowest price of a listing over a period,
+-- shown next to its current price for discount transparency
+
+-- +goose Up
+-- +goose StatementBegin
+-- One entry per price a listing had, from when it took effect until the next entry
+CREATE TABLE IF NOT EXISTS "price_history" (
+  "id" SERIAL PRIMARY KEY,
+  "marketplace_id" int NOT NULL REFERENCES "strategy_marketplace" ("id") ON DELETE CASCADE,
+  "price" numeric(10,2) NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "changed_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_price_history_marketplace" ON "price_history" ("marketplace_id", "changed_at");
+
+-- Listings created before prices were tracked start with the price they have now
+INSERT INTO price_history (marketplace_id, price, currency, changed_at)
+SELECT m.id, m.price, m.currency, m.created_at
+FROM strategy_marketplace m
+WHERE NOT EXISTS (SELECT 1 FROM price_history ph WHERE ph.marketplace_id = m.id);
+
+-- Record the price of new listings and every change of price or currency, whichever path
+-- changed it
+CREATE OR REPLACE FUNCTION trg_listing_price_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND NEW.price IS NOT DISTINCT FROM OLD.price
+       AND NEW.currency IS NOT DISTINCT FROM OLD.currency THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO price_history (marketplace_id, price, currency)
+    VALUES (NEW.id, NEW.price, NEW.currency);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS listing_price_history ON strategy_marketplace;
+CREATE TRIGGER listing_price_history
+    AFTER INSERT OR UPDATE OF price, currency ON strategy_marketplace
+    FOR EACH ROW EXECUTE FUNCTION trg_listing_price_history();
+
+-- Get the prices a listing had, newest first
+CREATE OR REPLACE FUNCTION get_listing_price_history(p_marketplace_id INT)
+RETURNS TABLE (
+    price NUMERIC,
+    currency VARCHAR,
+    changed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT ph.price, ph.currency, ph.changed_at
+    FROM price_history ph
+    WHERE ph.marketplace_id = p_marketplace_id
+    ORDER BY ph.changed_at DESC, ph.id DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the lowest price each of the given listings had since a point in time, counting the
+-- price in effect at that point. Only prices in the listing's current currency are compared.
+CREATE OR REPLACE FUNCTION get_listing_lowest_prices(p_marketplace_ids INT[], p_since TIMESTAMP)
+RETURNS TABLE (
+    marketplace_id INT,
+    lowest_price NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT m.id, MIN(ph.price)
+    FROM strategy_marketplace m
+    JOIN price_history ph ON ph.marketplace_id = m.id AND ph.currency = m.currency
+    WHERE m.id = ANY(p_marketplace_ids)
+      AND ph.changed_at >= COALESCE((
+          SELECT MAX(prev.changed_at)
+          FROM price_history prev
+          WHERE prev.marketplace_id = m.id
+            AND prev.changed_at <= p_since
+      ), p_since)
+    GROUP BY m.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,140 @@
+-- Strategy Service Purchase Referral Credit Functions
+-- File: 38_purchase-referral-credit.sql
+-- Contains paying for marketplace purchases with referral credit earned in the user service
+
+-- +goose Up
+-- +goose StatementBegin
+-- Purchases record the referral credit spent on them; purchase_price stays what the buyer
+-- paid on top of it
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "credit_amount" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- Purchase a strategy, with an optional coupon discount and referral credit already
+-- redeemed in the user service
+DROP FUNCTION IF EXISTS purchase_strategy(INT, INT, INT, NUMERIC);
+
+CREATE OR REPLACE FUNCTION purchase_strategy(
+    p_buyer_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT DEFAULT NULL,
+    p_discount NUMERIC DEFAULT 0,
+    p_credit NUMERIC DEFAULT 0
+)
+RETURNS INT AS $$
+DECLARE
+    new_purchase_id INT;
+    v_listing RECORD;
+    v_discount NUMERIC := 0;
+    v_credit NUMERIC := 0;
+BEGIN
+    -- Get the listing and the strategy version being sold
+    SELECT
+        m.price,
+        m.is_subscription,
+        m.subscription_period,
+        s.user_id AS seller_id,
+        s.id AS strategy_version_id,
+        s.strategy_group_id
+    INTO v_listing
+    FROM
+        strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.strategy_group_id
+    WHERE
+        m.id = p_marketplace_id
+        AND m.is_active = TRUE
+        AND s.version = m.version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Marketplace listing not found or inactive';
+    END IF;
+
+    -- Check user is not buying their own strategy
+    IF v_listing.seller_id = p_buyer_id THEN
+        RAISE EXCEPTION 'Cannot purchase your own strategy';
+    END IF;
+
+    -- Check for existing purchase
+    PERFORM 1 FROM strategy_purchases
+    WHERE marketplace_id = p_marketplace_id AND buyer_id = p_buyer_id;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Already purchased this strategy';
+    END IF;
+
+    -- Redeem the coupon
+    IF p_coupon_id IS NOT NULL THEN
+        UPDATE marketplace_coupons
+        SET redemptions_count = redemptions_count + 1
+        WHERE
+            id = p_coupon_id
+            AND marketplace_id = p_marketplace_id
+            AND is_active = TRUE
+            AND (expires_at IS NULL OR expires_at > NOW())
+            AND (max_redemptions IS NULL OR redemptions_count < max_redemptions);
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Coupon is no longer valid';
+        END IF;
+
+        v_discount := LEAST(GREATEST(COALESCE(p_discount, 0), 0), v_listing.price);
+    END IF;
+
+    -- Referral credit pays for what the coupon left
+    v_credit := LEAST(GREATEST(COALESCE(p_credit, 0), 0), v_listing.price - v_discount);
+
+    INSERT INTO strategy_purchases (
+        marketplace_id,
+        buyer_id,
+        strategy_version,
+        purchase_price,
+        original_price,
+        discount_amount,
+        credit_amount,
+        coupon_id,
+        subscription_end,
+        created_at
+    )
+    VALUES (
+        p_marketplace_id,
+        p_buyer_id,
+        v_listing.strategy_version_id,
+        v_listing.price - v_discount - v_credit,
+        v_listing.price,
+        v_discount,
+        v_credit,
+        p_coupon_id,
+        CASE
+            WHEN v_listing.is_subscription THEN
+                CASE
+                    WHEN v_listing.subscription_period = 'monthly' THEN NOW() + INTERVAL '1 month'
+                    WHEN v_listing.subscription_period = 'quarterly' THEN NOW() + INTERVAL '3 months'
+                    WHEN v_listing.subscription_period = 'yearly' THEN NOW() + INTERVAL '1 year'
+                    ELSE NULL
+                END
+            ELSE NULL
+        END,
+        NOW()
+    )
+    RETURNING id INTO new_purchase_id;
+
+    -- Set the purchased version as the buyer's active version
+    INSERT INTO user_strategy_versions (
+        user_id,
+        strategy_group_id,
+        active_version_id,
+        updated_at
+    )
+    VALUES (
+        p_buyer_id,
+        v_listing.strategy_group_id,
+        v_listing.strategy_version_id,
+        NOW()
+    )
+    ON CONFLICT (user_id, strategy_group_id) DO UPDATE
+    SET
+        active_version_id = v_listing.strategy_version_id,
+        updated_at = NOW();
+
+    RETURN new_purchase_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
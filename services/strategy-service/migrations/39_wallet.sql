@@ -0,0 +1,333 @@
+-- Strategy Service Wallet Functions
+-- File: 39_wallet.sql
+-- Contains the platform wallet: credit balances of users, the immutable ledger of every change
+-- to them, admin grants and revocations, paying for purchases and refunds back to the wallet
+
+-- +goose Up
+-- +goose StatementBegin
+-- Every change to a wallet. Entries are never changed or removed; a mistake is corrected by
+-- another entry. Credit is positive and debit negative, and balance_after is the balance of
+-- the currency after the entry.
+CREATE TABLE IF NOT EXISTS "wallet_ledger" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "amount" numeric(12,2) NOT NULL CHECK ("amount" <> 0),
+  "currency" varchar(3) NOT NULL REFERENCES "currencies" ("code"),
+  "entry_type" varchar(20) NOT NULL CHECK ("entry_type" IN ('grant', 'revoke', 'purchase', 'refund')),
+  "balance_after" numeric(12,2) NOT NULL CHECK ("balance_after" >= 0),
+  "purchase_id" int,
+  "actor_id" int,
+  "note" text,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_wallet_ledger_user" ON "wallet_ledger" ("user_id", "currency", "created_at" DESC);
+
+-- A purchase is paid from a wallet, and refunded to it, at most once
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wallet_ledger_purchase" ON "wallet_ledger" ("purchase_id", "entry_type")
+    WHERE "purchase_id" IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION trg_wallet_ledger_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'Wallet ledger entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_ledger_immutable ON wallet_ledger;
+CREATE TRIGGER wallet_ledger_immutable
+    BEFORE UPDATE OR DELETE ON wallet_ledger
+    FOR EACH ROW EXECUTE FUNCTION trg_wallet_ledger_immutable();
+
+-- Current balance of each wallet currency, kept with the ledger. Its rows are locked while an
+-- entry is added, so concurrent entries can't overdraw a wallet.
+CREATE TABLE IF NOT EXISTS "wallet_balances" (
+  "user_id" int NOT NULL,
+  "currency" varchar(3) NOT NULL REFERENCES "currencies" ("code"),
+  "balance" numeric(12,2) NOT NULL DEFAULT 0 CHECK ("balance" >= 0),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("user_id", "currency")
+);
+
+-- Purchases record the part paid from the buyer's wallet, which is part of purchase_price
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "wallet_amount" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- Add an entry to a user's wallet and update its balance. Debits beyond the balance fail.
+-- Returns the entry.
+CREATE OR REPLACE FUNCTION add_wallet_entry(
+    p_user_id INT,
+    p_amount NUMERIC,
+    p_currency VARCHAR,
+    p_entry_type VARCHAR,
+    p_purchase_id INT,
+    p_actor_id INT,
+    p_note TEXT
+)
+RETURNS SETOF wallet_ledger AS $$
+DECLARE
+    v_balance NUMERIC;
+BEGIN
+    INSERT INTO wallet_balances (user_id, currency)
+    VALUES (p_user_id, p_currency)
+    ON CONFLICT (user_id, currency) DO NOTHING;
+
+    SELECT wb.balance INTO v_balance
+    FROM wallet_balances wb
+    WHERE wb.user_id = p_user_id AND wb.currency = p_currency
+    FOR UPDATE;
+
+    IF v_balance + p_amount < 0 THEN
+        RAISE EXCEPTION 'Insufficient wallet balance';
+    END IF;
+
+    UPDATE wallet_balances
+    SET balance = v_balance + p_amount, updated_at = NOW()
+    WHERE user_id = p_user_id AND currency = p_currency;
+
+    RETURN QUERY
+    INSERT INTO wallet_ledger (user_id, amount, currency, entry_type, balance_after, purchase_id, actor_id, note)
+    VALUES (p_user_id, p_amount, p_currency, p_entry_type, v_balance + p_amount, p_purchase_id, p_actor_id, NULLIF(p_note, ''))
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the balances of a user's wallet, one per currency it ever held
+CREATE OR REPLACE FUNCTION get_wallet_balances(p_user_id INT)
+RETURNS TABLE (
+    currency VARCHAR,
+    balance NUMERIC,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT wb.currency, wb.balance, wb.updated_at
+    FROM wallet_balances wb
+    WHERE wb.user_id = p_user_id
+    ORDER BY wb.currency;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the entries of a user's wallet, newest first. p_currency limits them to one currency;
+-- NULL returns all.
+CREATE OR REPLACE FUNCTION get_wallet_ledger(
+    p_user_id INT,
+    p_currency VARCHAR,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS SETOF wallet_ledger AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM wallet_ledger wl
+    WHERE wl.user_id = p_user_id
+      AND (p_currency IS NULL OR wl.currency = p_currency)
+    ORDER BY wl.created_at DESC, wl.id DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count the entries of a user's wallet
+CREATE OR REPLACE FUNCTION count_wallet_ledger(p_user_id INT, p_currency VARCHAR)
+RETURNS INT AS $$
+BEGIN
+    RETURN (
+        SELECT COUNT(*)
+        FROM wallet_ledger wl
+        WHERE wl.user_id = p_user_id
+          AND (p_currency IS NULL OR wl.currency = p_currency)
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the part of a purchase paid from the buyer's wallet
+CREATE OR REPLACE FUNCTION get_purchase_wallet_amount(p_purchase_id INT)
+RETURNS NUMERIC AS $$
+BEGIN
+    RETURN COALESCE((SELECT wallet_amount FROM strategy_purchases WHERE id = p_purchase_id), 0);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Give the part of a refunded purchase paid from the buyer's wallet back to the wallet.
+-- Returns the amount given back, 0 if none was paid from it or it was already given back.
+CREATE OR REPLACE FUNCTION refund_purchase_to_wallet(p_purchase_id INT, p_actor_id INT)
+RETURNS NUMERIC AS $$
+DECLARE
+    v_purchase RECORD;
+BEGIN
+    SELECT p.buyer_id, p.wallet_amount, p.currency
+    INTO v_purchase
+    FROM strategy_purchases p
+    WHERE p.id = p_purchase_id
+    FOR UPDATE;
+
+    IF NOT FOUND OR v_purchase.wallet_amount <= 0 THEN
+        RETURN 0;
+    END IF;
+
+    PERFORM 1 FROM wallet_ledger
+    WHERE purchase_id = p_purchase_id AND entry_type = 'refund';
+
+    IF FOUND THEN
+        RETURN 0;
+    END IF;
+
+    PERFORM add_wallet_entry(v_purchase.buyer_id, v_purchase.wallet_amount, v_purchase.currency,
+        'refund', p_purchase_id, p_actor_id, NULL);
+    RETURN v_purchase.wallet_amount;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Purchase a strategy, with an optional coupon discount, referral credit already redeemed in
+-- the user service and, with p_use_wallet, the buyer's wallet paying what's left. Returns
+-- the purchase and the amount paid from the wallet.
+DROP FUNCTION IF EXISTS purchase_strategy(INT, INT, INT, NUMERIC, NUMERIC);
+
+CREATE OR REPLACE FUNCTION purchase_strategy(
+    p_buyer_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT DEFAULT NULL,
+    p_discount NUMERIC DEFAULT 0,
+    p_credit NUMERIC DEFAULT 0,
+    p_use_wallet BOOLEAN DEFAULT FALSE
+)
+RETURNS TABLE (
+    purchase_id INT,
+    wallet_amount NUMERIC
+) AS $$
+DECLARE
+    new_purchase_id INT;
+    v_listing RECORD;
+    v_discount NUMERIC := 0;
+    v_credit NUMERIC := 0;
+    v_wallet NUMERIC := 0;
+BEGIN
+    -- Get the listing and the strategy version being sold
+    SELECT
+        m.price,
+        m.currency,
+        m.is_subscription,
+        m.subscription_period,
+        s.user_id AS seller_id,
+        s.id AS strategy_version_id,
+        s.strategy_group_id
+    INTO v_listing
+    FROM
+        strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.strategy_group_id
+    WHERE
+        m.id = p_marketplace_id
+        AND m.is_active = TRUE
+        AND s.version = m.version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Marketplace listing not found or inactive';
+    END IF;
+
+    -- Check user is not buying their own strategy
+    IF v_listing.seller_id = p_buyer_id THEN
+        RAISE EXCEPTION 'Cannot purchase your own strategy';
+    END IF;
+
+    -- Check for existing purchase
+    PERFORM 1 FROM strategy_purchases sp
+    WHERE sp.marketplace_id = p_marketplace_id AND sp.buyer_id = p_buyer_id;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Already purchased this strategy';
+    END IF;
+
+    -- Redeem the coupon
+    IF p_coupon_id IS NOT NULL THEN
+        UPDATE marketplace_coupons
+        SET redemptions_count = redemptions_count + 1
+        WHERE
+            id = p_coupon_id
+            AND marketplace_id = p_marketplace_id
+            AND is_active = TRUE
+            AND (expires_at IS NULL OR expires_at > NOW())
+            AND (max_redemptions IS NULL OR redemptions_count < max_redemptions);
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Coupon is no longer valid';
+        END IF;
+
+        v_discount := LEAST(GREATEST(COALESCE(p_discount, 0), 0), v_listing.price);
+    END IF;
+
+    -- Referral credit pays for what the coupon left
+    v_credit := LEAST(GREATEST(COALESCE(p_credit, 0), 0), v_listing.price - v_discount);
+
+    -- The wallet pays what's left, as far as its balance in the listing's currency goes
+    IF p_use_wallet AND v_listing.price - v_discount - v_credit > 0 THEN
+        SELECT wb.balance INTO v_wallet
+        FROM wallet_balances wb
+        WHERE wb.user_id = p_buyer_id AND wb.currency = v_listing.currency
+        FOR UPDATE;
+
+        v_wallet := LEAST(GREATEST(COALESCE(v_wallet, 0), 0), v_listing.price - v_discount - v_credit);
+    END IF;
+
+    INSERT INTO strategy_purchases (
+        marketplace_id,
+        buyer_id,
+        strategy_version,
+        purchase_price,
+        original_price,
+        discount_amount,
+        credit_amount,
+        wallet_amount,
+        coupon_id,
+        subscription_end,
+        created_at
+    )
+    VALUES (
+        p_marketplace_id,
+        p_buyer_id,
+        v_listing.strategy_version_id,
+        v_listing.price - v_discount - v_credit,
+        v_listing.price,
+        v_discount,
+        v_credit,
+        v_wallet,
+        p_coupon_id,
+        CASE
+            WHEN v_listing.is_subscription THEN
+                CASE
+                    WHEN v_listing.subscription_period = 'monthly' THEN NOW() + INTERVAL '1 month'
+                    WHEN v_listing.subscription_period = 'quarterly' THEN NOW() + INTERVAL '3 months'
+                    WHEN v_listing.subscription_period = 'yearly' THEN NOW() + INTERVAL '1 year'
+                    ELSE NULL
+                END
+            ELSE NULL
+        END,
+        NOW()
+    )
+    RETURNING id INTO new_purchase_id;
+
+    IF v_wallet > 0 THEN
+        PERFORM add_wallet_entry(p_buyer_id, -v_wallet, v_listing.currency, 'purchase', new_purchase_id, NULL, NULL);
+    END IF;
+
+    -- Set the purchased version as the buyer's active version
+    INSERT INTO user_strategy_versions (
+        user_id,
+        strategy_group_id,
+        active_version_id,
+        updated_at
+    )
+    VALUES (
+        p_buyer_id,
+        v_listing.strategy_group_id,
+        v_listing.strategy_version_id,
+        NOW()
+    )
+    ON CONFLICT (user_id, strategy_group_id) DO UPDATE
+    SET
+        active_version_id = v_listing.strategy_version_id,
+        updated_at = NOW();
+
+    RETURN QUERY SELECT new_purchase_id, v_wallet;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,515 @@
+-- Strategy Service Collaboration Functions
+-- File: 40_strategy-collaborators.sql
+-- Contains strategy collaborators who edit a strategy with its owner, their invitations and
+-- the inline comments collaborators leave on strategy versions
+
+-- +goose Up
+-- +goose StatementBegin
+-- Collaborators are invited by the owner of a strategy group and can edit it once they
+-- accepted. 'editor' can create new versions and comment on them.
+CREATE TABLE IF NOT EXISTS "strategy_collaborators" (
+  "id" SERIAL PRIMARY KEY,
+  "strategy_group_id" int NOT NULL,
+  "owner_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "role" varchar(20) NOT NULL DEFAULT 'editor',
+  "invited_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "accepted_at" timestamp,
+  "updated_at" timestamp,
+  CONSTRAINT "strategy_collaborators_role_check" CHECK ("role" IN ('editor')),
+  CONSTRAINT "strategy_collaborators_unique" UNIQUE ("strategy_group_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_collaborators_user" ON "strategy_collaborators" ("user_id");
+
+ALTER TABLE "strategy_collaborators" ADD FOREIGN KEY ("strategy_group_id") REFERENCES "strategy_groups" ("id") ON DELETE CASCADE;
+
+-- Comments on a strategy version. node_path points at the part of the structure a comment
+-- is about, e.g. /rules/entry/0; NULL comments on the version as a whole. mentions are the
+-- IDs of the users mentioned in the body.
+CREATE TABLE IF NOT EXISTS "strategy_version_comments" (
+  "id" SERIAL PRIMARY KEY,
+  "strategy_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "body" text NOT NULL,
+  "node_path" varchar(255),
+  "mentions" int[] NOT NULL DEFAULT '{}',
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_strategy_version_comments_strategy" ON "strategy_version_comments" ("strategy_id", "created_at");
+
+ALTER TABLE "strategy_version_comments" ADD FOREIGN KEY ("strategy_id") REFERENCES "strategies" ("id") ON DELETE CASCADE;
+
+-- Invite a user to collaborate on a strategy group, or change the role of a collaborator.
+-- An accepted invitation stays accepted.
+CREATE OR REPLACE FUNCTION invite_strategy_collaborator(
+    p_strategy_group_id INT,
+    p_owner_id INT,
+    p_user_id INT,
+    p_role VARCHAR(20)
+)
+RETURNS INT AS $$
+DECLARE
+    v_collaborator_id INT;
+BEGIN
+    INSERT INTO strategy_collaborators (strategy_group_id, owner_id, user_id, role, invited_at)
+    VALUES (p_strategy_group_id, p_owner_id, p_user_id, p_role, NOW())
+    ON CONFLICT (strategy_group_id, user_id)
+    DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+    RETURNING id INTO v_collaborator_id;
+
+    RETURN v_collaborator_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Accept a pending invitation to collaborate on a strategy group
+CREATE OR REPLACE FUNCTION accept_strategy_collaboration(
+    p_strategy_group_id INT,
+    p_user_id INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE strategy_collaborators
+    SET accepted_at = NOW(), updated_at = NOW()
+    WHERE strategy_group_id = p_strategy_group_id
+      AND user_id = p_user_id
+      AND accepted_at IS NULL;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Remove a collaborator, or decline or withdraw an invitation
+CREATE OR REPLACE FUNCTION remove_strategy_collaborator(
+    p_strategy_group_id INT,
+    p_user_id INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM strategy_collaborators
+    WHERE strategy_group_id = p_strategy_group_id
+      AND user_id = p_user_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the collaborators of a strategy group, invitations included
+CREATE OR REPLACE FUNCTION get_strategy_collaborators(p_strategy_group_id INT)
+RETURNS SETOF strategy_collaborators AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM strategy_collaborators sc
+    WHERE sc.strategy_group_id = p_strategy_group_id
+    ORDER BY sc.invited_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the strategies a user collaborates on or is invited to, with the latest version of each
+CREATE OR REPLACE FUNCTION get_user_collaborations(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    strategy_group_id INT,
+    owner_id INT,
+    user_id INT,
+    role VARCHAR(20),
+    invited_at TIMESTAMP,
+    accepted_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    strategy_id INT,
+    strategy_name VARCHAR(100)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        sc.id,
+        sc.strategy_group_id,
+        sc.owner_id,
+        sc.user_id,
+        sc.role,
+        sc.invited_at,
+        sc.accepted_at,
+        sc.updated_at,
+        s.id,
+        s.name
+    FROM strategy_collaborators sc
+    JOIN LATERAL (
+        SELECT s2.id, s2.name
+        FROM strategies s2
+        WHERE s2.strategy_group_id = sc.strategy_group_id AND s2.is_active = TRUE
+        ORDER BY s2.version DESC
+        LIMIT 1
+    ) s ON TRUE
+    WHERE sc.user_id = p_user_id
+    ORDER BY sc.accepted_at IS NOT NULL, sc.invited_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Add a comment to a strategy version
+CREATE OR REPLACE FUNCTION add_strategy_comment(
+    p_strategy_id INT,
+    p_user_id INT,
+    p_body TEXT,
+    p_node_path VARCHAR(255),
+    p_mentions INT[]
+)
+RETURNS SETOF strategy_version_comments AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO strategy_version_comments (strategy_id, user_id, body, node_path, mentions, created_at)
+    VALUES (p_strategy_id, p_user_id, p_body, NULLIF(p_node_path, ''), COALESCE(p_mentions, '{}'), NOW())
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the comments of a strategy version, oldest first
+CREATE OR REPLACE FUNCTION get_strategy_comments(p_strategy_id INT)
+RETURNS SETOF strategy_version_comments AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM strategy_version_comments c
+    WHERE c.strategy_id = p_strategy_id
+    ORDER BY c.created_at, c.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a comment of its author
+CREATE OR REPLACE FUNCTION delete_strategy_comment(
+    p_comment_id INT,
+    p_user_id INT
+)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM strategy_version_comments
+    WHERE id = p_comment_id AND user_id = p_user_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the users of a strategy (replaces the version in 29_broadcast-audiences.sql to add
+-- collaborators): its owner, its collaborators, the buyers who still have access to any of
+-- its versions and the users it is shared with
+CREATE OR REPLACE FUNCTION get_strategy_users(p_strategy_id INT)
+RETURNS TABLE (user_id INT) AS $$
+DECLARE
+    v_group_id INT;
+BEGIN
+    SELECT s.strategy_group_id INTO v_group_id
+    FROM strategies s
+    WHERE s.id = p_strategy_id;
+
+    IF v_group_id IS NULL THEN
+        RAISE EXCEPTION 'Strategy not found';
+    END IF;
+
+    RETURN QUERY
+    SELECT users.id
+    FROM (
+        SELECT s.user_id AS id
+        FROM strategies s
+        WHERE s.strategy_group_id = v_group_id
+
+        UNION
+
+        SELECT sc.user_id
+        FROM strategy_collaborators sc
+        WHERE sc.strategy_group_id = v_group_id AND sc.accepted_at IS NOT NULL
+
+        UNION
+
+        SELECT p.buyer_id
+        FROM strategy_purchases p
+        JOIN strategies bought ON p.strategy_version = bought.id
+        WHERE
+            bought.strategy_group_id = v_group_id
+            AND p.status <> 'refunded'
+            AND (p.subscription_end IS NULL OR p.subscription_end > NOW())
+
+        UNION
+
+        SELECT sh.shared_with_user_id
+        FROM strategy_shares sh
+        WHERE sh.strategy_group_id = v_group_id
+    ) users
+    ORDER BY users.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Resolve how a user can access a strategy (replaces the version in
+-- 33_listing-update-policy.sql to add collaborators). Returns, in order of precedence,
+-- 'owner', 'editor', 'purchased', 'shared_backtest', 'public', 'shared_view', or NULL for
+-- no access.
+CREATE OR REPLACE FUNCTION get_strategy_access_level(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TEXT AS $$
+DECLARE
+    v_strategy RECORD;
+    v_share_permission VARCHAR(20);
+BEGIN
+    SELECT s.id, s.user_id, s.is_public, s.strategy_group_id, s.version
+    INTO v_strategy
+    FROM strategies s
+    WHERE s.id = p_strategy_id AND s.is_active = TRUE;
+
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    IF v_strategy.user_id = p_user_id THEN
+        RETURN 'owner';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM strategy_collaborators sc
+        WHERE sc.strategy_group_id = v_strategy.strategy_group_id
+        AND sc.user_id = p_user_id
+        AND sc.accepted_at IS NOT NULL
+    ) THEN
+        RETURN 'editor';
+    END IF;
+
+    IF v_strategy.version <= get_purchased_version_ceiling(p_user_id, v_strategy.strategy_group_id) THEN
+        RETURN 'purchased';
+    END IF;
+
+    SELECT sh.permission INTO v_share_permission
+    FROM strategy_shares sh
+    WHERE sh.strategy_group_id = v_strategy.strategy_group_id
+    AND sh.shared_with_user_id = p_user_id;
+
+    IF v_share_permission = 'backtest' THEN
+        RETURN 'shared_backtest';
+    END IF;
+
+    IF v_strategy.is_public THEN
+        RETURN 'public';
+    END IF;
+
+    IF v_share_permission = 'view' THEN
+        RETURN 'shared_view';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get strategy by ID (replaces the version in 33_listing-update-policy.sql). Collaborators
+-- see every version like users it is shared with.
+CREATE OR REPLACE FUNCTION get_strategy_by_id(
+    p_strategy_id INT,
+    p_user_id INT
+)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    user_id INT,
+    description TEXT,
+    thumbnail_url VARCHAR(255),
+    structure JSONB,
+    is_public BOOLEAN,
+    is_active BOOLEAN,
+    version INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    strategy_group_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.id,
+        s.name,
+        s.user_id,
+        s.description,
+        s.thumbnail_url,
+        s.structure,
+        s.is_public,
+        s.is_active,
+        s.version,
+        s.created_at,
+        s.updated_at,
+        s.strategy_group_id
+    FROM
+        strategies s
+    LEFT JOIN
+        user_strategy_versions usv ON s.strategy_group_id = usv.strategy_group_id AND usv.user_id = p_user_id
+    WHERE
+        (
+            -- Case 1: User owns the strategy, show their active version or the strategy directly requested
+            (s.user_id = p_user_id AND (s.id = p_strategy_id OR (s.strategy_group_id = p_strategy_id AND (usv.active_version_id = s.id OR usv.active_version_id IS NULL))))
+
+            OR
+
+            -- Case 2: User purchased the strategy, show the requested version if the
+            -- purchase covers it, or the latest version it covers
+            (
+                s.user_id <> p_user_id
+                AND (
+                    (s.id = p_strategy_id AND s.version <= get_purchased_version_ceiling(p_user_id, s.strategy_group_id))
+                    OR (s.strategy_group_id = p_strategy_id AND s.version = get_purchased_version_ceiling(p_user_id, s.strategy_group_id))
+                )
+            )
+
+            OR
+
+            -- Case 3: Strategy is public and the user is accessing by ID directly
+            (s.is_public = TRUE AND s.id = p_strategy_id)
+
+            OR
+
+            -- Case 4: Strategy is shared with the user or the user collaborates on it, show
+            -- the requested version or the latest one
+            (
+                (
+                    EXISTS (
+                        SELECT 1
+                        FROM strategy_shares sh
+                        WHERE sh.strategy_group_id = s.strategy_group_id
+                        AND sh.shared_with_user_id = p_user_id
+                    )
+                    OR EXISTS (
+                        SELECT 1
+                        FROM strategy_collaborators sc
+                        WHERE sc.strategy_group_id = s.strategy_group_id
+                        AND sc.user_id = p_user_id
+                        AND sc.accepted_at IS NOT NULL
+                    )
+                )
+                AND (
+                    s.id = p_strategy_id
+                    OR (
+                        s.strategy_group_id = p_strategy_id
+                        AND s.version = (
+                            SELECT MAX(s2.version)
+                            FROM strategies s2
+                            WHERE s2.strategy_group_id = s.strategy_group_id AND s2.is_active = TRUE
+                        )
+                    )
+                )
+            )
+        )
+        AND s.is_active = TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update strategy (replaces the version in 04_strategy-functions.sql to let collaborators
+-- edit). New versions belong to the owner whoever created them. The owner's active version
+-- follows an editor's new version if it was the version edited.
+CREATE OR REPLACE FUNCTION update_strategy(
+    p_strategy_id INT,
+    p_user_id INT,
+    p_name VARCHAR(100),
+    p_description TEXT,
+    p_thumbnail_url VARCHAR(255),
+    p_structure JSONB,
+    p_is_public BOOLEAN,
+    p_change_notes TEXT,
+    p_tag_ids INT[] DEFAULT NULL
+)
+RETURNS INT AS $$
+DECLARE
+    v_group_id INT;
+    v_owner_id INT;
+    current_version INT;
+    tag_id INT;
+    new_version_id INT;
+BEGIN
+    -- Check the user owns the strategy or edits it with the owner
+    SELECT s.strategy_group_id, s.user_id, s.version
+    INTO v_group_id, v_owner_id, current_version
+    FROM strategies s
+    WHERE s.id = p_strategy_id
+    AND (
+        s.user_id = p_user_id
+        OR EXISTS (
+            SELECT 1
+            FROM strategy_collaborators sc
+            WHERE sc.strategy_group_id = s.strategy_group_id
+            AND sc.user_id = p_user_id
+            AND sc.role = 'editor'
+            AND sc.accepted_at IS NOT NULL
+        )
+    );
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Strategy not found or you do not have permission to update it';
+    END IF;
+
+    -- Create new version
+    INSERT INTO strategies (
+        name,
+        user_id,
+        description,
+        thumbnail_url,
+        structure,
+        is_public,
+        is_active,
+        version,
+        created_at,
+        updated_at,
+        strategy_group_id
+    )
+    VALUES (
+        p_name,
+        v_owner_id,
+        p_description,
+        p_thumbnail_url,
+        p_structure,
+        p_is_public,
+        TRUE,
+        current_version + 1,
+        NOW(),
+        NOW(),
+        v_group_id
+    )
+    RETURNING id INTO new_version_id;
+
+    -- Update user's active version to the new version
+    INSERT INTO user_strategy_versions (
+        user_id,
+        strategy_group_id,
+        active_version_id,
+        updated_at
+    )
+    VALUES (
+        p_user_id,
+        v_group_id,
+        new_version_id,
+        NOW()
+    )
+    ON CONFLICT (user_id, strategy_group_id)
+    DO UPDATE SET
+        active_version_id = new_version_id,
+        updated_at = NOW();
+
+    IF v_owner_id <> p_user_id THEN
+        UPDATE user_strategy_versions
+        SET active_version_id = new_version_id, updated_at = NOW()
+        WHERE user_id = v_owner_id
+          AND strategy_group_id = v_group_id
+          AND active_version_id = p_strategy_id;
+    END IF;
+
+    -- Update tags if provided
+    IF p_tag_ids IS NOT NULL THEN
+        -- Delete current tags
+        DELETE FROM strategy_tag_mappings
+        WHERE strategy_id = v_group_id;
+
+        -- Add new tags
+        FOREACH tag_id IN ARRAY p_tag_ids LOOP
+            INSERT INTO strategy_tag_mappings (strategy_id, tag_id)
+            VALUES (v_group_id, tag_id);
+        END LOOP;
+    END IF;
+
+    RETURN new_version_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
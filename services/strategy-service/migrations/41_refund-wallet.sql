@@ -0,0 +1,74 @@
+-- Strategy Service Refund Wallet Functions
+-- File: 41_refund-wallet.sql
+-- Gives the wallet part of a purchase back when its refund is approved, in the same
+-- transaction as the approval
+
+-- +goose Up
+-- +goose StatementBegin
+-- Approve or reject a pending refund request. Approving marks the purchase refunded and
+-- ends it now, so every access check based on subscription_end stops granting the strategy,
+-- and revokes the buyer's active version unless another current purchase still grants it.
+-- The part of the purchase paid from the buyer's wallet goes back to the wallet in the same
+-- transaction, so a refund is never approved without it. Returns FALSE if the request isn't
+-- pending.
+CREATE OR REPLACE FUNCTION review_refund_request(
+    p_refund_id INT,
+    p_reviewer_id INT,
+    p_approve BOOLEAN,
+    p_review_note TEXT,
+    p_provider_refund_id VARCHAR
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_purchase_id INT;
+BEGIN
+    UPDATE purchase_refunds
+    SET
+        status = CASE WHEN p_approve THEN 'approved' ELSE 'rejected' END,
+        reviewer_id = p_reviewer_id,
+        review_note = p_review_note,
+        provider_refund_id = p_provider_refund_id,
+        reviewed_at = NOW()
+    WHERE id = p_refund_id AND status = 'pending'
+    RETURNING purchase_id INTO v_purchase_id;
+
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    IF NOT p_approve THEN
+        RETURN TRUE;
+    END IF;
+
+    WITH refunded AS (
+        UPDATE strategy_purchases p
+        SET
+            status = 'refunded',
+            refunded_at = NOW(),
+            subscription_end = NOW()
+        WHERE p.id = v_purchase_id
+        RETURNING p.id, p.buyer_id, p.strategy_version
+    )
+    DELETE FROM user_strategy_versions usv
+    USING refunded e, strategies sv
+    WHERE sv.id = e.strategy_version
+    AND usv.user_id = e.buyer_id
+    AND usv.strategy_group_id = sv.strategy_group_id
+    AND usv.active_version_id = e.strategy_version
+    AND sv.user_id <> e.buyer_id
+    AND NOT EXISTS (
+        SELECT 1
+        FROM strategy_purchases p2
+        JOIN strategies s2 ON p2.strategy_version = s2.id
+        WHERE p2.buyer_id = e.buyer_id
+        AND s2.strategy_group_id = sv.strategy_group_id
+        AND p2.id <> e.id
+        AND (p2.subscription_end IS NULL OR p2.subscription_end > NOW())
+    );
+
+    PERFORM refund_purchase_to_wallet(v_purchase_id, p_reviewer_id);
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- Strategy Service Coupon Purchase Version Repair
+-- File: 42_coupon-purchase-versions.sql
+-- Contains the repair of purchases recorded with a version number instead of a version ID
+
+-- +goose Up
+-- +goose StatementBegin
+-- purchase_strategy as created by 19_marketplace-coupons.sql stored the listed version
+-- number in strategy_version, which every access check joins on strategies.id. The function
+-- has since been replaced by one storing the version ID; point the purchases it recorded at
+-- the ID of the version they bought.
+UPDATE strategy_purchases p
+SET strategy_version = s.id
+FROM
+    strategy_marketplace m
+    JOIN strategies s ON s.strategy_group_id = m.strategy_id
+WHERE
+    p.marketplace_id = m.id
+    AND s.version = p.strategy_version
+    AND NOT EXISTS (
+        SELECT 1 FROM strategies v
+        WHERE v.id = p.strategy_version AND v.strategy_group_id = m.strategy_id
+    );
+-- +goose StatementEnd
@@ -0,0 +1,174 @@
+-- Strategy Service Purchase Referral Redemption Functions
+-- File: 43_purchase-referral-redemption.sql
+-- Contains recording the referral credit redemption paying for a purchase, so approving a
+-- refund of the purchase can give the credit back
+
+-- +goose Up
+-- +goose StatementBegin
+-- The user service redemption the purchase's credit_amount was spent through
+ALTER TABLE "strategy_purchases" ADD COLUMN IF NOT EXISTS "referral_redemption_id" INT;
+
+-- Get the referral credit redemption that paid for part of a purchase, NULL if none did
+CREATE OR REPLACE FUNCTION get_purchase_referral_redemption(p_purchase_id INT)
+RETURNS INT AS $$
+BEGIN
+    RETURN (SELECT referral_redemption_id FROM strategy_purchases WHERE id = p_purchase_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Purchase a strategy, with an optional coupon discount, referral credit already redeemed in
+-- the user service through p_redemption_id and, with p_use_wallet, the buyer's wallet
+-- paying what's left. Returns the purchase and the amount paid from the wallet.
+DROP FUNCTION IF EXISTS purchase_strategy(INT, INT, INT, NUMERIC, NUMERIC, BOOLEAN);
+
+CREATE OR REPLACE FUNCTION purchase_strategy(
+    p_buyer_id INT,
+    p_marketplace_id INT,
+    p_coupon_id INT DEFAULT NULL,
+    p_discount NUMERIC DEFAULT 0,
+    p_credit NUMERIC DEFAULT 0,
+    p_use_wallet BOOLEAN DEFAULT FALSE,
+    p_redemption_id INT DEFAULT NULL
+)
+RETURNS TABLE (
+    purchase_id INT,
+    wallet_amount NUMERIC
+) AS $$
+DECLARE
+    new_purchase_id INT;
+    v_listing RECORD;
+    v_discount NUMERIC := 0;
+    v_credit NUMERIC := 0;
+    v_wallet NUMERIC := 0;
+BEGIN
+    -- Get the listing and the strategy version being sold
+    SELECT
+        m.price,
+        m.currency,
+        m.is_subscription,
+        m.subscription_period,
+        s.user_id AS seller_id,
+        s.id AS strategy_version_id,
+        s.strategy_group_id
+    INTO v_listing
+    FROM
+        strategy_marketplace m
+        JOIN strategies s ON m.strategy_id = s.strategy_group_id
+    WHERE
+        m.id = p_marketplace_id
+        AND m.is_active = TRUE
+        AND s.version = m.version_id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Marketplace listing not found or inactive';
+    END IF;
+
+    -- Check user is not buying their own strategy
+    IF v_listing.seller_id = p_buyer_id THEN
+        RAISE EXCEPTION 'Cannot purchase your own strategy';
+    END IF;
+
+    -- Check for existing purchase
+    PERFORM 1 FROM strategy_purchases sp
+    WHERE sp.marketplace_id = p_marketplace_id AND sp.buyer_id = p_buyer_id;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'Already purchased this strategy';
+    END IF;
+
+    -- Redeem the coupon
+    IF p_coupon_id IS NOT NULL THEN
+        UPDATE marketplace_coupons
+        SET redemptions_count = redemptions_count + 1
+        WHERE
+            id = p_coupon_id
+            AND marketplace_id = p_marketplace_id
+            AND is_active = TRUE
+            AND (expires_at IS NULL OR expires_at > NOW())
+            AND (max_redemptions IS NULL OR redemptions_count < max_redemptions);
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'Coupon is no longer valid';
+        END IF;
+
+        v_discount := LEAST(GREATEST(COALESCE(p_discount, 0), 0), v_listing.price);
+    END IF;
+
+    -- Referral credit pays for what the coupon left
+    v_credit := LEAST(GREATEST(COALESCE(p_credit, 0), 0), v_listing.price - v_discount);
+
+    -- The wallet pays what's left, as far as its balance in the listing's currency goes
+    IF p_use_wallet AND v_listing.price - v_discount - v_credit > 0 THEN
+        SELECT wb.balance INTO v_wallet
+        FROM wallet_balances wb
+        WHERE wb.user_id = p_buyer_id AND wb.currency = v_listing.currency
+        FOR UPDATE;
+
+        v_wallet := LEAST(GREATEST(COALESCE(v_wallet, 0), 0), v_listing.price - v_discount - v_credit);
+    END IF;
+
+    INSERT INTO strategy_purchases (
+        marketplace_id,
+        buyer_id,
+        strategy_version,
+        purchase_price,
+        original_price,
+        discount_amount,
+        credit_amount,
+        referral_redemption_id,
+        wallet_amount,
+        coupon_id,
+        subscription_end,
+        created_at
+    )
+    VALUES (
+        p_marketplace_id,
+        p_buyer_id,
+        v_listing.strategy_version_id,
+        v_listing.price - v_discount - v_credit,
+        v_listing.price,
+        v_discount,
+        v_credit,
+        CASE WHEN v_credit > 0 THEN p_redemption_id END,
+        v_wallet,
+        p_coupon_id,
+        CASE
+            WHEN v_listing.is_subscription THEN
+                CASE
+                    WHEN v_listing.subscription_period = 'monthly' THEN NOW() + INTERVAL '1 month'
+                    WHEN v_listing.subscription_period = 'quarterly' THEN NOW() + INTERVAL '3 months'
+                    WHEN v_listing.subscription_period = 'yearly' THEN NOW() + INTERVAL '1 year'
+                    ELSE NULL
+                END
+            ELSE NULL
+        END,
+        NOW()
+    )
+    RETURNING id INTO new_purchase_id;
+
+    IF v_wallet > 0 THEN
+        PERFORM add_wallet_entry(p_buyer_id, -v_wallet, v_listing.currency, 'purchase', new_purchase_id, NULL, NULL);
+    END IF;
+
+    -- Set the purchased version as the buyer's active version
+    INSERT INTO user_strategy_versions (
+        user_id,
+        strategy_group_id,
+        active_version_id,
+        updated_at
+    )
+    VALUES (
+        p_buyer_id,
+        v_listing.strategy_group_id,
+        v_listing.strategy_version_id,
+        NOW()
+    )
+    ON CONFLICT (user_id, strategy_group_id) DO UPDATE
+    SET
+        active_version_id = v_listing.strategy_version_id,
+        updated_at = NOW();
+
+    RETURN QUERY SELECT new_purchase_id, v_wallet;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations applied by internal/migrate.
+// Files are named {version}_{description}.sql and use goose annotations.
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,12 +11,18 @@ import (
 	"syscall"
 	"time"
 
+	"services/shared/apierror"
+	"services/shared/database"
+	"services/user-service/docs"
 	"services/user-service/internal/client"
 	"services/user-service/internal/config"
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/handler"
 	"services/user-service/internal/middleware"
+	"services/user-service/internal/migrate"
 	"services/user-service/internal/repository"
 	"services/user-service/internal/service"
+	"services/user-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -25,7 +32,25 @@ import (
 	"go.uber.org/zap"
 )
 
+// @title User Service API
+// @version 1.0
+// @description Users, authentication, notifications and administration.
+// @BasePath /
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>"
+//
+// @securityDefinitions.apikey ServiceKey
+// @in header
+// @name X-Service-Key
+// @description Key of a calling internal service
 func main() {
+	applyMigrations := flag.Bool("migrate", false, "Apply pending database migrations before starting")
+	baselineVersion := flag.Int64("migrate-baseline", 0, "Mark migrations up to this version as applied without running them (for databases created by the old init scripts)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadConfig("config/config.yaml")
 	if err != nil {
@@ -39,6 +64,9 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Map the untyped errors of the services to the codes of this service
+	apierror.SetMessageRules(errcode.MessageRules)
+
 	// Connect to database with retries
 	var db *sqlx.DB
 	maxRetries := 10
@@ -67,6 +95,19 @@ func main() {
 	}
 	defer db.Close()
 
+	// Bring the schema up to date if requested
+	migrationRunner := migrate.NewRunner(db.DB, logger)
+	if *baselineVersion > 0 {
+		if err := migrationRunner.Baseline(context.Background(), *baselineVersion); err != nil {
+			logger.Fatal("Failed to baseline database migrations", zap.Error(err))
+		}
+	}
+	if *applyMigrations {
+		if err := migrationRunner.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply database migrations", zap.Error(err))
+		}
+	}
+
 	// Initialize Redis client (if enabled)
 	var redisClient *redis.Client
 	if cfg.Redis.Enabled {
@@ -97,27 +138,187 @@ func main() {
 		logger.Info("Initialized Kafka writer", zap.Strings("brokers", cfg.Kafka.Brokers))
 	}
 
+	// Heavy reads go to the read replica, if one is configured, while it's healthy
+	replicaDB, err := connectToReplica(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to configure read replica", zap.Error(err))
+	}
+	dbRouter := database.NewRouter(db, replicaDB, database.ReplicaOptions{
+		HealthCheckInterval: cfg.Database.Replica.HealthCheckInterval,
+		MaxLag:              cfg.Database.Replica.MaxLag,
+		RecoverAfter:        cfg.Database.Replica.RecoverAfter,
+	}, logger)
+	defer dbRouter.Close()
+
+	// Sample the connection pools for /metrics and, if enabled, size them to their waits
+	poolMonitor := database.NewPoolMonitor(database.PoolOptions{
+		SampleInterval:    cfg.Database.Pool.SampleInterval,
+		Adaptive:          cfg.Database.Pool.Adaptive,
+		MaxOpenConnsLimit: cfg.Database.Pool.MaxOpenConnsLimit,
+		TargetWait:        cfg.Database.Pool.TargetWait,
+	}, logger)
+	poolMonitor.Add("primary", db)
+	poolMonitor.Add("replica", replicaDB)
+
 	// Create repositories
-	userRepo := repository.NewUserRepository(db, logger)
+	userRepo := repository.NewUserRepository(dbRouter, logger)
 	authRepo := repository.NewAuthRepository(db, logger)
+	twoFactorRepo := repository.NewTwoFactorRepository(db, logger)
+	roleRepo := repository.NewRoleRepository(db, logger)
 	notificationRepo := repository.NewNotificationRepository(db, logger)
 	preferenceRepo := repository.NewPreferenceRepository(db, logger)
 	profileRepo := repository.NewProfileRepository(db, logger)
+	statsRepo := repository.NewStatsRepository(db, logger)
+	impersonationRepo := repository.NewImpersonationRepository(db, logger)
+	activityRepo := repository.NewActivityRepository(db, logger)
+	followRepo := repository.NewFollowRepository(db, logger)
+	broadcastRepo := repository.NewBroadcastRepository(db, logger)
+	serviceCredentialRepo := repository.NewServiceCredentialRepository(db, logger)
+	referralRepo := repository.NewReferralRepository(db, logger)
+	savedViewRepo := repository.NewSavedViewRepository(db, logger)
 
 	// Create clients
-	mediaClient := client.NewMediaClient(cfg.Media.URL, cfg.Media.ServiceKey, logger)
+	mediaClient := client.NewMediaClient(cfg.Media, logger)
+	strategyClient := client.NewStrategyClient(cfg.Strategy, logger)
+	mailClient := client.NewMailClient(cfg.Mail, logger)
 
 	// Create services with Redis and Kafka integration
-	authService := service.NewAuthService(userRepo, authRepo, cfg, logger)
+	notificationHub := service.NewNotificationHub(logger)
+	notificationService := service.NewNotificationService(
+		notificationRepo,
+		userRepo,
+		notificationHub,
+		cfg.Notifications.BatchWindow,
+		cfg.Notifications.Digest.Enabled,
+		logger,
+	)
+	// Revocations are kept until the longest-lived access token would have expired
+	tokenLifetime := cfg.Auth.AccessTokenDuration
+	if cfg.Auth.ImpersonationTokenDuration > tokenLifetime {
+		tokenLifetime = cfg.Auth.ImpersonationTokenDuration
+	}
+	tokenRevoker := service.NewTokenRevoker(redisClient, tokenLifetime, logger)
+	referralService := service.NewReferralService(referralRepo, cfg.Referrals, logger)
+	savedViewService := service.NewSavedViewService(savedViewRepo, logger)
+	authService := service.NewAuthService(
+		userRepo,
+		authRepo,
+		twoFactorRepo,
+		roleRepo,
+		impersonationRepo,
+		notificationService,
+		mailClient,
+		referralService,
+		redisClient,
+		tokenRevoker,
+		cfg,
+		logger,
+	)
+	if cfg.Auth.JWTPrivateKeyFile != "" {
+		if err := authService.LoadSigningKey(cfg.Auth.JWTPrivateKeyFile); err != nil {
+			logger.Fatal("Failed to load JWT signing key", zap.Error(err))
+		}
+	}
 	userService := service.NewUserService(
 		userRepo,
 		logger,
 		redisClient, // Add Redis client
 		kafkaWriter, // Add Kafka writer
+		tokenRevoker,
+	)
+	preferenceService := service.NewPreferenceService(
+		preferenceRepo,
+		userRepo,
+		cfg.Preferences.Workspaces.MaxBytes,
+		cfg.Preferences.Workspaces.MaxCount,
+		logger,
 	)
-	notificationService := service.NewNotificationService(notificationRepo, userRepo, logger)
-	preferenceService := service.NewPreferenceService(preferenceRepo, userRepo, logger)
-	profileService := service.NewProfileService(profileRepo, userRepo, mediaClient, logger)
+	profileService := service.NewProfileService(profileRepo, userRepo, followRepo, mediaClient, strategyClient, logger)
+	roleService := service.NewRoleService(roleRepo, userRepo, logger)
+	statsService := service.NewStatsService(statsRepo, cfg.Stats.CacheTTL, logger)
+	serviceCredentialService := service.NewServiceCredentialService(serviceCredentialRepo, cfg.ServiceAuth.CacheTTL, logger)
+	activityService := service.NewActivityService(activityRepo, userRepo, logger)
+	followService := service.NewFollowService(followRepo, userRepo, notificationService, logger)
+	broadcastService := service.NewBroadcastService(
+		broadcastRepo,
+		notificationService,
+		strategyClient,
+		cfg.Notifications.Broadcast.BatchSize,
+		cfg.Notifications.Broadcast.StaleAfter,
+		logger,
+	)
+
+	// Start the notification consumer (if Kafka is enabled) so websocket clients get pushes
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	var notificationConsumer *service.NotificationConsumer
+	if cfg.Kafka.Enabled && len(cfg.Kafka.Brokers) > 0 {
+		notificationConsumer = service.NewNotificationConsumer(
+			cfg.Kafka.Brokers,
+			cfg.Kafka.GroupID,
+			cfg.Kafka.Topics["notifications"],
+			// Viper lowercases the topic keys
+			[]string{
+				cfg.Kafka.Topics["marketplaceevents"],
+				cfg.Kafka.Topics["backtestevents"],
+			},
+			notificationService,
+			userRepo,
+			logger,
+		)
+		go notificationConsumer.Run(consumerCtx)
+	}
+
+	// Start the digest worker (if enabled) to collapse notifications of digest categories daily
+	if cfg.Notifications.Digest.Enabled {
+		digestWorker := service.NewNotificationDigestWorker(notificationService, cfg.Notifications.Digest.Hour, logger)
+		go digestWorker.Run(consumerCtx)
+	}
+
+	// Start the broadcast worker to send scheduled admin notification broadcasts
+	broadcastWorker := service.NewBroadcastWorker(broadcastService, cfg.Notifications.Broadcast.PollInterval, logger)
+	go broadcastWorker.Run(consumerCtx)
+
+	// Start the activity consumer (if Kafka is enabled) to build users' activity feeds
+	var activityConsumer *service.ActivityConsumer
+	if cfg.Kafka.Enabled && len(cfg.Kafka.Brokers) > 0 {
+		activityConsumer = service.NewActivityConsumer(
+			cfg.Kafka.Brokers,
+			cfg.Kafka.ActivityGroupID,
+			// Viper lowercases the topic keys
+			[]string{
+				cfg.Kafka.Topics["events"],
+				cfg.Kafka.Topics["strategyevents"],
+				cfg.Kafka.Topics["marketplaceevents"],
+				cfg.Kafka.Topics["backtestevents"],
+			},
+			activityService,
+			logger,
+		)
+		go activityConsumer.Run(consumerCtx)
+	}
+
+	// Start the follow consumer (if Kafka is enabled) to notify followers of sellers' new
+	// listings and strategy versions
+	var followConsumer *service.FollowConsumer
+	if cfg.Kafka.Enabled && len(cfg.Kafka.Brokers) > 0 {
+		followConsumer = service.NewFollowConsumer(
+			cfg.Kafka.Brokers,
+			cfg.Kafka.FollowGroupID,
+			[]string{
+				cfg.Kafka.Topics["strategyevents"],
+				cfg.Kafka.Topics["marketplaceevents"],
+			},
+			followService,
+			logger,
+		)
+		go followConsumer.Run(consumerCtx)
+	}
+
+	// Check the read replica's health so reads fail over to the primary and back
+	go dbRouter.Run(consumerCtx)
+
+	// Sample and tune the database connection pools
+	go poolMonitor.Run(consumerCtx)
 
 	// Create HTTP server
 	router := setupRouter(
@@ -126,6 +327,17 @@ func main() {
 		notificationService,
 		preferenceService,
 		profileService,
+		roleService,
+		statsService,
+		activityService,
+		followService,
+		broadcastService,
+		serviceCredentialService,
+		referralService,
+		savedViewService,
+		notificationHub,
+		migrationRunner,
+		poolMonitor,
 		logger,
 		cfg, // Add config parameter
 	)
@@ -157,6 +369,18 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Stop the notification, activity and follow consumers if running
+	stopConsumer()
+	if notificationConsumer != nil {
+		notificationConsumer.Close()
+	}
+	if activityConsumer != nil {
+		activityConsumer.Close()
+	}
+	if followConsumer != nil {
+		followConsumer.Close()
+	}
+
 	// Close Kafka writer if initialized
 	if kafkaWriter != nil {
 		kafkaWriter.Close()
@@ -221,12 +445,42 @@ func connectToDB(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// connectToReplica opens the read replica's connection pool, if a replica is configured.
+// The connection isn't verified here; the router's health checks decide when it's used.
+func connectToReplica(dbConfig config.DatabaseConfig) (*sqlx.DB, error) {
+	if dbConfig.Replica.DSN == "" {
+		return nil, nil
+	}
+
+	db, err := sqlx.Open("pgx", dbConfig.Replica.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+
+	return db, nil
+}
+
 func setupRouter(
 	authService *service.AuthService,
 	userService *service.UserService,
 	notificationService *service.NotificationService,
 	preferenceService *service.PreferenceService,
 	profileService *service.ProfileService,
+	roleService *service.RoleService,
+	statsService *service.StatsService,
+	activityService *service.ActivityService,
+	followService *service.FollowService,
+	broadcastService *service.BroadcastService,
+	serviceCredentialService *service.ServiceCredentialService,
+	referralService *service.ReferralService,
+	savedViewService *service.SavedViewService,
+	notificationHub *service.NotificationHub,
+	migrationRunner *migrate.Runner,
+	poolMonitor *database.PoolMonitor,
 	logger *zap.Logger,
 	cfg *config.Config, // Added config parameter
 ) *gin.Engine {
@@ -234,13 +488,37 @@ func setupRouter(
 
 	// Use middlewares
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
+	router.Use(validation.Locale())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// Metrics in the Prometheus text format, for scrapers on the internal network
+	debugHandler := handler.NewDebugHandler(poolMonitor, logger)
+	router.GET("/metrics", debugHandler.GetMetrics)
+
+	// OpenAPI spec, also merged into the gateway's /api/docs
+	router.GET("/swagger.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", docs.SwaggerJSON)
+	})
+
+	// Public keys of access tokens, for services verifying them locally
+	router.GET("/.well-known/jwks.json", handler.NewAuthHandler(authService, logger).JWKS)
+
+	// Real-time notification pushes (authenticates its own token)
+	notifWSHandler := handler.NewNotificationWSHandler(
+		authService,
+		notificationService,
+		notificationHub,
+		cfg.Server.AllowedOrigins,
+		logger,
+	)
+	router.GET("/ws/notifications", notifWSHandler.Connect)
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
@@ -258,7 +536,13 @@ func setupRouter(
 			authProtected := auth.Group("")
 			authProtected.Use(middleware.AuthMiddleware(authService, logger))
 			authProtected.POST("/logout", authHandler.Logout)
-			authProtected.POST("/logout-all", authHandler.LogoutAll)
+			authProtected.POST("/logout-all", middleware.ForbidImpersonation(), authHandler.LogoutAll)
+
+			// Two-factor authentication management
+			authProtected.GET("/2fa", authHandler.GetTwoFactorStatus)
+			authProtected.POST("/2fa/enroll", middleware.ForbidImpersonation(), authHandler.EnrollTwoFactor)
+			authProtected.POST("/2fa/verify", middleware.ForbidImpersonation(), authHandler.VerifyTwoFactor)
+			authProtected.POST("/2fa/disable", middleware.ForbidImpersonation(), authHandler.DisableTwoFactor)
 
 			// Only validation endpoint needed - for Nginx auth_request
 			// Even this could be eliminated if Nginx used JWT libraries directly
@@ -277,32 +561,68 @@ func setupRouter(
 			prefHandler := handler.NewPreferenceHandler(preferenceService, logger)
 			notifHandler := handler.NewNotificationHandler(notificationService, logger)
 			profileHandler := handler.NewProfileHandler(profileService, logger)
+			activityHandler := handler.NewActivityHandler(activityService, logger)
+			followHandler := handler.NewFollowHandler(followService, logger)
+			referralHandler := handler.NewReferralHandler(referralService, logger)
+			savedViewHandler := handler.NewSavedViewHandler(savedViewService, logger)
 
 			// User profile routes
 			users.GET("/me", userHandler.GetCurrentUser)
 			users.PUT("/me", userHandler.UpdateCurrentUser)
-			users.DELETE("/me", userHandler.DeleteCurrentUser)
+			users.DELETE("/me", middleware.ForbidImpersonation(), userHandler.DeleteCurrentUser)
 
 			// Password management
-			users.PUT("/me/password", passwordHandler.ChangePassword)
+			users.PUT("/me/password", middleware.ForbidImpersonation(), passwordHandler.ChangePassword)
 
 			// User preferences routes
 			users.GET("/me/preferences", prefHandler.GetUserPreferences)
 			users.PUT("/me/preferences", prefHandler.UpdateUserPreferences)
 			users.POST("/me/preferences/reset", prefHandler.ResetUserPreferences)
+			users.GET("/me/workspaces", prefHandler.ListWorkspaces)
+			users.GET("/me/workspaces/:name", prefHandler.GetWorkspace)
+			users.PUT("/me/workspaces/:name", prefHandler.SaveWorkspace)
+			users.DELETE("/me/workspaces/:name", prefHandler.DeleteWorkspace)
 
 			// User notifications routes
 			users.GET("/me/notifications", notifHandler.GetNotifications)
 			users.GET("/me/notifications/count", notifHandler.GetUnreadCount)
 			users.PUT("/me/notifications/:id/read", notifHandler.MarkNotificationAsRead)
 			users.PUT("/me/notifications/read-all", notifHandler.MarkAllAsRead)
+			users.GET("/me/notifications/preferences", notifHandler.GetPreferences)
+			users.PUT("/me/notifications/preferences", notifHandler.UpdatePreferences)
+			users.GET("/me/notifications/:id/items", notifHandler.GetDigestNotifications)
+
+			// Activity feed
+			users.GET("/me/activity", activityHandler.GetActivity)
 
 			// Profile photo routes
 			users.GET("/me/profile-photo", profileHandler.GetProfilePhoto)
 			users.POST("/me/profile-photo", profileHandler.UploadProfilePhoto)
 			users.DELETE("/me/profile-photo", profileHandler.DeleteProfilePhoto)
+
+			// Public profile details
+			users.GET("/me/profile", profileHandler.GetMyProfile)
+			users.PUT("/me/profile", profileHandler.UpdateProfileDetails)
+
+			// Following sellers
+			users.GET("/me/following", followHandler.GetFollowing)
+			users.POST("/:id/follow", followHandler.Follow)
+			users.DELETE("/:id/follow", followHandler.Unfollow)
+
+			// Referral code and referral statistics
+			users.GET("/me/referrals", referralHandler.GetMyReferrals)
+
+			// Saved views of the backtest, strategy and marketplace lists
+			users.GET("/me/views", savedViewHandler.ListViews)
+			users.POST("/me/views", savedViewHandler.CreateView)
+			users.DELETE("/me/views/:id", savedViewHandler.DeleteView)
 		}
 
+		// Public profiles, e.g. of sellers buyers are browsing
+		v1.GET("/users/search", handler.NewProfileHandler(profileService, logger).SearchProfiles)
+		v1.GET("/users/:id/profile", handler.NewProfileHandler(profileService, logger).GetPublicProfile)
+		v1.GET("/users/:id/followers", handler.NewFollowHandler(followService, logger).GetFollowers)
+
 		// ==================== ADMIN ROUTES ====================
 		admin := v1.Group("/admin")
 		{
@@ -312,28 +632,118 @@ func setupRouter(
 
 			userHandler := handler.NewUserHandler(userService, logger)
 			notifHandler := handler.NewNotificationHandler(notificationService, logger)
+			broadcastHandler := handler.NewBroadcastHandler(broadcastService, logger)
+			migrationHandler := handler.NewMigrationHandler(migrationRunner, logger)
+			statsHandler := handler.NewStatsHandler(statsService, logger)
+			lockoutHandler := handler.NewLockoutHandler(authService, logger)
+			credentialHandler := handler.NewServiceCredentialHandler(serviceCredentialService, logger)
+			referralHandler := handler.NewReferralHandler(referralService, logger)
 
 			// User management (admin only)
 			admin.GET("/users", userHandler.ListUsers)
+			admin.GET("/users/export", userHandler.ExportUsers)
 			admin.GET("/users/:id", userHandler.GetUserByID)
 			admin.PUT("/users/:id", userHandler.UpdateUser)
+			admin.POST("/users/bulk/activate", userHandler.BulkActivateUsers)
+			admin.POST("/users/bulk/deactivate", userHandler.BulkDeactivateUsers)
+
+			// Login lockouts after repeated failed logins (admin)
+			admin.GET("/lockouts", lockoutHandler.GetLockouts)
+			admin.DELETE("/users/:id/lockout", lockoutHandler.ClearLockout)
 
 			// Notification management (admin)
 			admin.POST("/notifications", notifHandler.CreateNotification)
+			admin.POST("/notifications/broadcast", broadcastHandler.CreateBroadcast)
+			admin.GET("/notifications/broadcasts", broadcastHandler.ListBroadcasts)
+			admin.GET("/notifications/broadcasts/:id", broadcastHandler.GetBroadcast)
+			admin.POST("/notifications/broadcasts/:id/cancel", broadcastHandler.CancelBroadcast)
+
+			// Keys of internal services, issued and rotated here (admin)
+			admin.GET("/service-credentials", credentialHandler.ListCredentials)
+			admin.POST("/service-credentials", credentialHandler.IssueCredential)
+			admin.DELETE("/service-credentials/:id", credentialHandler.RevokeCredential)
+
+			// Referral fraud indicators (admin)
+			admin.GET("/referrals/fraud", referralHandler.GetFraudReport)
+
+			// Database migration status (admin)
+			admin.GET("/migrations", migrationHandler.GetStatus)
+
+			// Dashboard statistics (admin)
+			admin.GET("/stats/users", statsHandler.GetUserStats)
+
+			// Database connection pools (admin)
+			admin.GET("/debug/db", debugHandler.GetDBPools)
+		}
+
+		// ==================== ROLE MANAGEMENT ROUTES ====================
+		roleAdmin := v1.Group("/admin")
+		{
+			roleAdmin.Use(middleware.AuthMiddleware(authService, logger))
+			roleAdmin.Use(middleware.RequirePermission("roles:manage"))
+
+			roleHandler := handler.NewRoleHandler(roleService, logger)
+
+			// Roles CRUD
+			roleAdmin.GET("/roles", roleHandler.ListRoles)
+			roleAdmin.GET("/roles/:id", roleHandler.GetRole)
+			roleAdmin.POST("/roles", roleHandler.CreateRole)
+			roleAdmin.PUT("/roles/:id", roleHandler.UpdateRole)
+			roleAdmin.DELETE("/roles/:id", roleHandler.DeleteRole)
+			roleAdmin.GET("/permissions", roleHandler.ListPermissions)
+
+			// User role assignments
+			roleAdmin.GET("/users/:id/roles", roleHandler.GetUserRoles)
+			roleAdmin.POST("/users/:id/roles", roleHandler.AssignUserRole)
+			roleAdmin.DELETE("/users/:id/roles/:roleId", roleHandler.RemoveUserRole)
+			roleAdmin.POST("/users/bulk/roles", roleHandler.BulkAssignUserRole)
+			roleAdmin.POST("/users/bulk/roles/remove", roleHandler.BulkRemoveUserRole)
+		}
+
+		// ==================== IMPERSONATION ROUTES ====================
+		impersonationAdmin := v1.Group("/admin")
+		{
+			impersonationAdmin.Use(middleware.AuthMiddleware(authService, logger))
+			impersonationAdmin.Use(middleware.RequirePermission("users:impersonate"))
+			impersonationAdmin.Use(middleware.ForbidImpersonation())
+
+			impersonationHandler := handler.NewImpersonationHandler(authService, logger)
+
+			// Short-lived tokens acting as a user, and the sessions behind them
+			impersonationAdmin.POST("/users/:id/impersonate", impersonationHandler.Impersonate)
+			impersonationAdmin.GET("/impersonations", impersonationHandler.GetActiveImpersonations)
+			impersonationAdmin.DELETE("/impersonations/:id", impersonationHandler.RevokeImpersonation)
 		}
 
 		// ==================== SERVICE API ====================
 		// Only for data not available in tokens
 		service := v1.Group("/service")
 		{
-			// Protected with service key
-			service.Use(middleware.ServiceAuthMiddleware(cfg.Media.ServiceKey, logger))
+			// Protected with the static service key or a key issued to the calling service
+			service.Use(middleware.ServiceAuthMiddleware(cfg.ServiceAuth.StaticKey, serviceCredentialService, logger))
 
 			serviceHandler := handler.NewServiceHandler(userService, logger)
+			credentialHandler := handler.NewServiceCredentialHandler(serviceCredentialService, logger)
+			referralHandler := handler.NewReferralHandler(referralService, logger)
+			savedViewHandler := handler.NewSavedViewHandler(savedViewService, logger)
+
+			// Issued keys other services were called with
+			service.POST("/credentials/verify", credentialHandler.VerifyCredential)
 
 			// User profile data (only for getting data NOT in the token)
 			service.GET("/users/batch", serviceHandler.BatchGetUsers)
 			service.GET("/users/:id", serviceHandler.GetUserByID)
+
+			// Referral credit spent on marketplace purchases
+			service.GET("/users/:id/referral-credit", referralHandler.GetCredit)
+			service.POST("/referral-credit/redeem", referralHandler.RedeemCredit)
+			service.POST("/referral-credit/redemptions/:id/reverse", referralHandler.ReverseRedemption)
+
+			// Saved list views, applied by the services owning the lists
+			service.GET("/users/:id/views/:viewId", savedViewHandler.GetUserView)
+
+			// Database connection pools
+			service.GET("/debug/db", debugHandler.GetDBPools)
 		}
 	}
 
@@ -0,0 +1,12 @@
+// Package docs embeds the OpenAPI (Swagger 2.0) spec of the service, generated from the
+// annotations of the HTTP handlers. Run go generate after changing a route or handler.
+package docs
+
+import _ "embed"
+
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.1 init --dir .. --generalInfo cmd/server/main.go --output . --outputTypes json --parseInternal
+
+// SwaggerJSON is the generated spec served at /swagger.json
+//
+//go:embed swagger.json
+var SwaggerJSON []byte
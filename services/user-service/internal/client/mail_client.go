@@ -0,0 +1,58 @@
+package client
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"services/user-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// MailClient sends plain text emails over SMTP
+type MailClient struct {
+	cfg    config.MailConfig
+	logger *zap.Logger
+}
+
+// NewMailClient creates a new mail client. When mail is disabled messages are only logged.
+func NewMailClient(cfg config.MailConfig, logger *zap.Logger) *MailClient {
+	return &MailClient{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Send delivers a plain text email to a single recipient
+func (c *MailClient) Send(to, subject, body string) error {
+	if !c.cfg.Enabled || c.cfg.Host == "" {
+		c.logger.Info("mail disabled, not sending email",
+			zap.String("to", to),
+			zap.String("subject", subject))
+		return nil
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", c.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	addr := fmt.Sprintf("%s:%d", c.cfg.Host, c.cfg.Port)
+	if err := smtp.SendMail(addr, auth, c.cfg.From, []string{to}, []byte(msg.String())); err != nil {
+		c.logger.Error("failed to send email", zap.Error(err), zap.String("to", to))
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
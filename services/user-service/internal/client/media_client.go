@@ -10,14 +10,21 @@ import (
 	"net/http"
 	"time"
 
+	"services/shared/httpclient"
+	"services/user-service/internal/config"
+	"services/user-service/internal/errcode"
+
 	"go.uber.org/zap"
 )
 
+// ErrInvalidMedia is returned when the media service rejects an uploaded file
+var ErrInvalidMedia = errcode.ErrInvalidMedia
+
 // MediaClient handles communication with the Media Service
 type MediaClient struct {
 	baseURL    string
 	serviceKey string
-	httpClient *http.Client
+	httpClient *httpclient.Client
 	logger     *zap.Logger
 }
 
@@ -30,34 +37,34 @@ type MediaUploadResponse struct {
 
 // MediaFile represents metadata about a stored media file
 type MediaFile struct {
-	ID          string      `json:"id"`
-	FileName    string      `json:"file_name"`
-	ContentType string      `json:"content_type"`
-	Size        int64       `json:"size"`
-	URL         string      `json:"url"`
-	Thumbnails  []Thumbnail `json:"thumbnails,omitempty"`
-	Width       int         `json:"width,omitempty"`
-	Height      int         `json:"height,omitempty"`
-	CreatedAt   time.Time   `json:"created_at"`
+	ID          string         `json:"id"`
+	FileName    string         `json:"file_name"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	URL         string         `json:"url"`
+	Variants    []MediaVariant `json:"variants,omitempty"`
+	Width       int            `json:"width,omitempty"`
+	Height      int            `json:"height,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
 
-// Thumbnail represents a generated thumbnail
-type Thumbnail struct {
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
+// MediaVariant represents a processed rendition of an image, e.g. "thumb", "medium" or "original"
+type MediaVariant struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
 }
 
 // NewMediaClient creates a new media client
-func NewMediaClient(baseURL, serviceKey string, logger *zap.Logger) *MediaClient {
+func NewMediaClient(cfg config.ServiceConfig, logger *zap.Logger) *MediaClient {
 	return &MediaClient{
-		baseURL:    baseURL,
-		serviceKey: serviceKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("media service", cfg, logger),
+		logger:     logger,
 	}
 }
 
@@ -108,10 +115,17 @@ func (c *MediaClient) UploadProfilePhoto(ctx context.Context, userID int, fileCo
 	}
 	defer resp.Body.Close()
 
-	// Check response status
+	// Check response status; rejected images are passed on as ErrInvalidMedia
+	if resp.StatusCode == http.StatusBadRequest {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return nil, ErrInvalidMedia.WithMessage("Invalid media: " + errorResponse.Error)
+	}
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Error("media service returned error", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("media service returned status code %d", resp.StatusCode)
+		return nil, c.httpClient.StatusError(resp)
 	}
 
 	// Parse response
@@ -151,7 +165,7 @@ func (c *MediaClient) DeleteMedia(ctx context.Context, mediaID string) error {
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Error("media service returned error on delete", zap.Int("status", resp.StatusCode))
-		return fmt.Errorf("media service returned status code %d", resp.StatusCode)
+		return c.httpClient.StatusError(resp)
 	}
 
 	return nil
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"services/shared/httpclient"
+	"services/user-service/internal/config"
+	"services/user-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// StrategyClient handles communication with the Strategy Service
+type StrategyClient struct {
+	baseURL    string
+	serviceKey string
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+// NewStrategyClient creates a new strategy client
+func NewStrategyClient(cfg config.ServiceConfig, logger *zap.Logger) *StrategyClient {
+	return &StrategyClient{
+		baseURL:    cfg.URL,
+		serviceKey: cfg.ServiceKey,
+		httpClient: newHTTPClient("strategy service", cfg, logger),
+		logger:     logger,
+	}
+}
+
+// GetSellerStats gets the public marketplace statistics of a seller
+func (c *StrategyClient) GetSellerStats(ctx context.Context, sellerID int) (*model.SellerStats, error) {
+	url := fmt.Sprintf("%s/api/v1/marketplace/sellers/%d", c.baseURL, sellerID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to strategy service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data model.SellerStats `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode seller stats: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
+// GetListingPurchasers gets the IDs of the buyers of a listing who still have access to it
+func (c *StrategyClient) GetListingPurchasers(ctx context.Context, listingID int) ([]int, error) {
+	url := fmt.Sprintf("%s/api/v1/service/marketplace/%d/purchasers", c.baseURL, listingID)
+	return c.getUserIDs(ctx, url, "listing not found")
+}
+
+// GetStrategyUsers gets the IDs of a strategy's owner, the buyers who still have access to
+// it and the users it is shared with
+func (c *StrategyClient) GetStrategyUsers(ctx context.Context, strategyID int) ([]int, error) {
+	url := fmt.Sprintf("%s/api/v1/service/strategies/%d/users", c.baseURL, strategyID)
+	return c.getUserIDs(ctx, url, "strategy not found")
+}
+
+// getUserIDs gets a list of user IDs from a service route, returning notFound as the
+// error when the service doesn't know the entity
+func (c *StrategyClient) getUserIDs(ctx context.Context, url, notFound string) ([]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Service-Key", c.serviceKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to strategy service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.New(notFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.httpClient.StatusError(resp)
+	}
+
+	var response struct {
+		Data []int `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode user IDs: %w", err)
+	}
+
+	return response.Data, nil
+}
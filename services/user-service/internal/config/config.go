@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -9,13 +10,21 @@ import (
 
 // Config holds all configuration for the service
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	Media    ServiceConfig
-	Kafka    KafkaConfig
-	Redis    RedisConfig
-	Logging  LoggingConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Auth          AuthConfig
+	Media         ServiceConfig
+	Strategy      ServiceConfig
+	Mail          MailConfig
+	Kafka         KafkaConfig
+	Redis         RedisConfig
+	Stats         StatsConfig
+	Notifications NotificationsConfig
+	Preferences   PreferencesConfig
+	Referrals     ReferralsConfig
+	ServiceAuth   ServiceAuthConfig
+	InternalTLS   InternalTLSConfig
+	Logging       LoggingConfig
 }
 
 // ServerConfig holds server specific configuration
@@ -24,6 +33,8 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// AllowedOrigins are the browser origins allowed to open websockets, e.g. https://app.example.com
+	AllowedOrigins []string
 }
 
 // DatabaseConfig holds database specific configuration
@@ -37,13 +48,60 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	Replica         ReplicaConfig
+	Pool            PoolConfig
+}
+
+// ReplicaConfig holds configuration for an optional read replica. Heavy reads are sent
+// to the replica while its health checks pass and fall back to the primary otherwise.
+type ReplicaConfig struct {
+	DSN                 string // Replica connection string; empty disables the replica
+	HealthCheckInterval time.Duration
+	MaxLag              time.Duration // Replication lag above which the replica is considered unhealthy
+	RecoverAfter        int           // Consecutive passing checks before reads fail back to the replica
+}
+
+// PoolConfig holds configuration for monitoring the database connection pools. When
+// adaptive, a pool grows past maxOpenConns while queries wait for connections and shrinks
+// back once they no longer do.
+type PoolConfig struct {
+	SampleInterval    time.Duration
+	Adaptive          bool
+	MaxOpenConnsLimit int           // Most open connections a pool grows to
+	TargetWait        time.Duration // Average wait for a connection above which a pool grows
 }
 
 // AuthConfig holds authentication specific configuration
 type AuthConfig struct {
-	JWTSecret            string
+	JWTSecret string
+	// JWTPrivateKeyFile is a PEM encoded RSA key. When set, access tokens are signed
+	// RS256 and other services verify them against the JWKS endpoint instead of
+	// sharing JWTSecret. Refresh tokens are only read here and stay HS256.
+	JWTPrivateKeyFile    string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
+	TwoFactorIssuer      string
+	// ImpersonationTokenDuration is how long an admin impersonation token is valid
+	ImpersonationTokenDuration time.Duration
+	Lockout                    LockoutConfig
+}
+
+// LockoutConfig holds brute-force protection settings for logins
+type LockoutConfig struct {
+	// MaxFailedAttempts within FailureWindow lock the account for Duration
+	MaxFailedAttempts int
+	FailureWindow     time.Duration
+	Duration          time.Duration
+}
+
+// MailConfig holds SMTP settings for security alert emails
+type MailConfig struct {
+	Enabled  bool
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
 }
 
 // KafkaConfig holds Kafka specific configuration
@@ -51,6 +109,12 @@ type KafkaConfig struct {
 	Brokers  []string
 	Enabled  bool
 	ClientID string
+	GroupID  string
+	// ActivityGroupID is the consumer group reading the event topics into activity feeds
+	ActivityGroupID string
+	// FollowGroupID is the consumer group reading strategy and marketplace events into follower notifications
+	FollowGroupID string
+	Topics        map[string]string
 }
 
 // RedisConfig holds Redis specific configuration
@@ -61,6 +125,61 @@ type RedisConfig struct {
 	Enabled  bool
 }
 
+// StatsConfig holds configuration for admin dashboard statistics
+type StatsConfig struct {
+	CacheTTL time.Duration
+}
+
+// NotificationsConfig holds notification batching and digest settings
+type NotificationsConfig struct {
+	// BatchWindow merges repeated events of the same type into one unread notification; 0 disables batching
+	BatchWindow time.Duration
+	Digest      DigestConfig
+	Broadcast   BroadcastConfig
+}
+
+// DigestConfig holds settings of the daily digest collapsing notifications of digest categories
+type DigestConfig struct {
+	Enabled bool
+	Hour    int // Hour of the day (UTC) the digests are built
+}
+
+// BroadcastConfig holds settings of the worker sending admin notification broadcasts
+type BroadcastConfig struct {
+	PollInterval time.Duration // How often due broadcasts are looked for
+	BatchSize    int           // Recipients sent to between progress updates
+	StaleAfter   time.Duration // Running broadcasts without progress for this long are resumed by another run
+}
+
+// PreferencesConfig holds settings of synced user preferences
+type PreferencesConfig struct {
+	Workspaces WorkspacesConfig
+}
+
+// WorkspacesConfig holds the per-user quota of saved UI workspaces
+type WorkspacesConfig struct {
+	MaxBytes int // Total size of a user's workspace layouts
+	MaxCount int // Workspaces a user can save
+}
+
+// ReferralsConfig holds settings of the referral program
+type ReferralsConfig struct {
+	Enabled        bool    // Attribute registrations to referral codes and reward them
+	ReferrerCredit float64 // Credit for the user whose code was used
+	RefereeCredit  float64 // Credit for the new user
+	Currency       string  // Currency of the credit, spent on marketplace listings priced in it
+	// MaxRewardedReferrals is how many referrals a user is rewarded for; 0 means no limit
+	MaxRewardedReferrals int
+}
+
+// ServiceAuthConfig holds settings of authenticating calls from other services
+type ServiceAuthConfig struct {
+	// StaticKey is the legacy key shared by every service, accepted next to issued keys;
+	// empty accepts issued keys only. Defaults to media.serviceKey.
+	StaticKey string
+	CacheTTL  time.Duration // How long issued keys are trusted before they are checked again
+}
+
 // LoggingConfig holds logging specific configuration
 type LoggingConfig struct {
 	Level  string
@@ -69,9 +188,14 @@ type LoggingConfig struct {
 
 // ServiceConfig holds configuration for external services
 type ServiceConfig struct {
-	URL        string
-	Timeout    time.Duration
-	ServiceKey string
+	URL          string
+	Timeout      time.Duration // Limit of a single attempt of a call
+	ServiceKey   string
+	MaxRetries   int           // Retries of failed idempotent calls
+	RetryBackoff time.Duration // Longest wait before the first retry, doubled for each further retry
+	MaxConns     int           // Connections open to the service at once; 0 means no limit
+	// TLS is built from InternalTLS when it is enabled
+	TLS *tls.Config `mapstructure:"-"`
 }
 
 // LoadConfig loads the configuration from file and environment variables
@@ -95,6 +219,17 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if !v.IsSet("serviceAuth.staticKey") {
+		cfg.ServiceAuth.StaticKey = cfg.Media.ServiceKey
+	}
+
+	tlsConfig, err := cfg.InternalTLS.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal TLS config: %w", err)
+	}
+	cfg.Media.TLS = tlsConfig
+	cfg.Strategy.TLS = tlsConfig
+
 	return &cfg, nil
 }
 
@@ -105,25 +240,87 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.readTimeout", "10s")
 	v.SetDefault("server.writeTimeout", "10s")
 	v.SetDefault("server.idleTimeout", "120s")
+	v.SetDefault("server.allowedOrigins", []string{"http://localhost:3000"})
 
 	// Database defaults
 	v.SetDefault("database.sslmode", "disable")
 	v.SetDefault("database.maxOpenConns", 25)
 	v.SetDefault("database.maxIdleConns", 5)
 	v.SetDefault("database.connMaxLifetime", "30m")
+	v.SetDefault("database.replica.healthCheckInterval", "5s")
+	v.SetDefault("database.replica.maxLag", "30s")
+	v.SetDefault("database.replica.recoverAfter", 3)
+	v.SetDefault("database.pool.sampleInterval", "10s")
+	v.SetDefault("database.pool.adaptive", false)
+	v.SetDefault("database.pool.maxOpenConnsLimit", 50)
+	v.SetDefault("database.pool.targetWait", "5ms")
 
 	// Auth defaults
 	v.SetDefault("auth.accessTokenDuration", "15m")
 	v.SetDefault("auth.refreshTokenDuration", "7d")
+	v.SetDefault("auth.twoFactorIssuer", "Trading Strategy Platform")
+	v.SetDefault("auth.impersonationTokenDuration", "15m")
+	v.SetDefault("auth.lockout.maxFailedAttempts", 5)
+	v.SetDefault("auth.lockout.failureWindow", "15m")
+	v.SetDefault("auth.lockout.duration", "15m")
+
+	// Mail defaults
+	v.SetDefault("mail.enabled", false)
+	v.SetDefault("mail.port", 587)
+	v.SetDefault("mail.from", "no-reply@tradingstrategyplatform.local")
 
 	// Kafka topic defaults
 	v.SetDefault("kafka.topics.notifications", "user-notifications")
 	v.SetDefault("kafka.topics.events", "user-events")
+	v.SetDefault("kafka.topics.strategyEvents", "strategy-events")
+	v.SetDefault("kafka.topics.marketplaceEvents", "marketplace-events")
+	v.SetDefault("kafka.topics.backtestEvents", "backtest-events")
+	v.SetDefault("kafka.groupID", "user-service-notifications")
+	v.SetDefault("kafka.activityGroupID", "user-service-activity")
+	v.SetDefault("kafka.followGroupID", "user-service-follows")
 
 	// Redis defaults
 	v.SetDefault("redis.sessionPrefix", "user-session:")
 	v.SetDefault("redis.sessionDuration", "24h")
 
+	// Media service defaults
+	v.SetDefault("media.timeout", "30s")
+	v.SetDefault("media.maxRetries", 2)
+	v.SetDefault("media.retryBackoff", "100ms")
+
+	// Strategy service defaults
+	v.SetDefault("strategy.url", "http://strategy-service:8082")
+	v.SetDefault("strategy.timeout", "5s")
+	v.SetDefault("strategy.maxRetries", 2)
+	v.SetDefault("strategy.retryBackoff", "100ms")
+	v.SetDefault("strategy.serviceKey", "strategy-service-key")
+
+	// Stats defaults
+	v.SetDefault("stats.cacheTTL", "1m")
+
+	// Notification defaults
+	v.SetDefault("notifications.batchWindow", "5m")
+	v.SetDefault("notifications.digest.enabled", false)
+	v.SetDefault("notifications.digest.hour", 8)
+	v.SetDefault("notifications.broadcast.pollInterval", "30s")
+	v.SetDefault("notifications.broadcast.batchSize", 500)
+	v.SetDefault("notifications.broadcast.staleAfter", "5m")
+
+	// Preference defaults
+	v.SetDefault("preferences.workspaces.maxBytes", 1048576)
+	v.SetDefault("preferences.workspaces.maxCount", 50)
+
+	// Referral defaults
+	v.SetDefault("referrals.enabled", false)
+	v.SetDefault("referrals.referrerCredit", 10)
+	v.SetDefault("referrals.refereeCredit", 5)
+	v.SetDefault("referrals.currency", "USD")
+	v.SetDefault("referrals.maxRewardedReferrals", 50)
+
+	// Service authentication defaults
+	v.SetDefault("serviceAuth.cacheTTL", "1m")
+	v.SetDefault("internalTLS.enabled", false)
+
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
@@ -0,0 +1,99 @@
+// Package errcode defines the error codes and errors of the user service, and the rules
+// its untyped errors are mapped to codes with
+package errcode
+
+import (
+	"net/http"
+
+	"services/shared/apierror"
+)
+
+// Codes of the user service
+const (
+	CodeInvalidCredentials    apierror.Code = "INVALID_CREDENTIALS"
+	CodeAccountLocked         apierror.Code = "ACCOUNT_LOCKED"
+	CodeTwoFactorRequired     apierror.Code = "TWO_FACTOR_REQUIRED"
+	CodeInvalidTwoFactorCode  apierror.Code = "INVALID_TWO_FACTOR_CODE"
+	CodeInvalidToken          apierror.Code = "INVALID_TOKEN"
+	CodeSessionExpired        apierror.Code = "SESSION_EXPIRED"
+	CodeImpersonationEnded    apierror.Code = "IMPERSONATION_ENDED"
+	CodeTokenRevoked          apierror.Code = "TOKEN_REVOKED"
+	CodeUserNotFound          apierror.Code = "USER_NOT_FOUND"
+	CodeEmailInUse            apierror.Code = "EMAIL_IN_USE"
+	CodeRoleNotFound          apierror.Code = "ROLE_NOT_FOUND"
+	CodeRoleAlreadyExists     apierror.Code = "ROLE_ALREADY_EXISTS"
+	CodeSystemRole            apierror.Code = "SYSTEM_ROLE"
+	CodeUnknownPermission     apierror.Code = "UNKNOWN_PERMISSION"
+	CodeNotificationNotFound  apierror.Code = "NOTIFICATION_NOT_FOUND"
+	CodeDigestNotFound        apierror.Code = "DIGEST_NOT_FOUND"
+	CodeInvalidPreference     apierror.Code = "INVALID_PREFERENCE"
+	CodeLockoutNotFound       apierror.Code = "LOCKOUT_NOT_FOUND"
+	CodeImpersonationNotFound apierror.Code = "IMPERSONATION_NOT_FOUND"
+	CodeInvalidMedia          apierror.Code = "INVALID_MEDIA"
+	CodeCannotFollowSelf      apierror.Code = "CANNOT_FOLLOW_SELF"
+	CodeCannotDeactivateSelf  apierror.Code = "CANNOT_DEACTIVATE_SELF"
+	CodeBroadcastNotFound     apierror.Code = "BROADCAST_NOT_FOUND"
+	CodeBroadcastFinished     apierror.Code = "BROADCAST_FINISHED"
+	CodeWorkspaceNotFound     apierror.Code = "WORKSPACE_NOT_FOUND"
+	CodeWorkspaceModified     apierror.Code = "WORKSPACE_MODIFIED"
+	CodeWorkspaceQuota        apierror.Code = "WORKSPACE_QUOTA_EXCEEDED"
+	CodeCredentialNotFound    apierror.Code = "SERVICE_CREDENTIAL_NOT_FOUND"
+	CodeCredentialRevoked     apierror.Code = "SERVICE_CREDENTIAL_REVOKED"
+	CodeReferralsDisabled     apierror.Code = "REFERRALS_DISABLED"
+	CodeRedemptionNotFound    apierror.Code = "REDEMPTION_NOT_FOUND"
+	CodeSavedViewNotFound     apierror.Code = "SAVED_VIEW_NOT_FOUND"
+	CodeSavedViewLimit        apierror.Code = "SAVED_VIEW_LIMIT_REACHED"
+)
+
+// Errors returned by the services of the user service
+var (
+	ErrAccountLocked        = apierror.New(http.StatusTooManyRequests, CodeAccountLocked, "Account temporarily locked after too many failed login attempts")
+	ErrTwoFactorRequired    = apierror.New(http.StatusUnauthorized, CodeTwoFactorRequired, "Two-factor authentication code required")
+	ErrInvalidTwoFactorCode = apierror.New(http.StatusBadRequest, CodeInvalidTwoFactorCode, "Invalid two-factor authentication code")
+	ErrImpersonationEnded   = apierror.New(http.StatusUnauthorized, CodeImpersonationEnded, "Impersonation session has ended")
+	ErrTokenRevoked         = apierror.New(http.StatusUnauthorized, CodeTokenRevoked, "Token has been revoked")
+	ErrUserNotFound         = apierror.New(http.StatusNotFound, CodeUserNotFound, "User not found")
+	ErrRoleNotFound         = apierror.New(http.StatusNotFound, CodeRoleNotFound, "Role not found")
+	ErrDigestNotFound       = apierror.New(http.StatusNotFound, CodeDigestNotFound, "Digest not found")
+	ErrLockoutNotFound      = apierror.New(http.StatusNotFound, CodeLockoutNotFound, "Login lockout not found")
+	ErrImpersonationMissing = apierror.New(http.StatusNotFound, CodeImpersonationNotFound, "Impersonation session not found")
+	ErrInvalidMedia         = apierror.New(http.StatusBadRequest, CodeInvalidMedia, "Invalid media")
+	ErrCannotFollowSelf     = apierror.New(http.StatusBadRequest, CodeCannotFollowSelf, "You cannot follow yourself")
+	ErrCannotDeactivateSelf = apierror.New(http.StatusBadRequest, CodeCannotDeactivateSelf, "You cannot deactivate your own account")
+	ErrBroadcastNotFound    = apierror.New(http.StatusNotFound, CodeBroadcastNotFound, "Broadcast not found")
+	ErrBroadcastFinished    = apierror.New(http.StatusConflict, CodeBroadcastFinished, "Broadcast has already finished")
+	ErrWorkspaceNotFound    = apierror.New(http.StatusNotFound, CodeWorkspaceNotFound, "Workspace not found")
+	ErrWorkspaceModified    = apierror.New(http.StatusPreconditionFailed, CodeWorkspaceModified, "Workspace was modified since it was last read")
+	ErrWorkspaceQuota       = apierror.New(http.StatusRequestEntityTooLarge, CodeWorkspaceQuota, "Workspace quota exceeded")
+	ErrCredentialNotFound   = apierror.New(http.StatusNotFound, CodeCredentialNotFound, "Service credential not found")
+	ErrCredentialRevoked    = apierror.New(http.StatusConflict, CodeCredentialRevoked, "Service credential has already been revoked")
+	ErrReferralsDisabled    = apierror.New(http.StatusNotFound, CodeReferralsDisabled, "The referral program is not available")
+	ErrRedemptionNotFound   = apierror.New(http.StatusNotFound, CodeRedemptionNotFound, "Referral credit redemption not found or already reversed")
+	ErrSavedViewNotFound    = apierror.New(http.StatusNotFound, CodeSavedViewNotFound, "Saved view not found")
+)
+
+// MessageRules maps errors by their message, most specific first. They cover the
+// untyped errors of the services.
+var MessageRules = []apierror.MessageRule{
+	{Contains: "saved view limit", Status: http.StatusConflict, Code: CodeSavedViewLimit},
+	{Contains: "invalid email or password", Status: http.StatusUnauthorized, Code: CodeInvalidCredentials},
+	{Contains: "email already in use", Status: http.StatusConflict, Code: CodeEmailInUse},
+	{Contains: "session not found or expired", Status: http.StatusUnauthorized, Code: CodeSessionExpired},
+	{Contains: "invalid refresh token", Status: http.StatusUnauthorized, Code: CodeInvalidToken},
+	{Contains: "invalid token", Status: http.StatusUnauthorized, Code: CodeInvalidToken},
+	{Contains: "role with this name already exists", Status: http.StatusConflict, Code: CodeRoleAlreadyExists},
+	{Contains: "system roles", Status: http.StatusBadRequest, Code: CodeSystemRole},
+	{Contains: "unknown permission", Status: http.StatusBadRequest, Code: CodeUnknownPermission},
+	{Contains: "invalid preference", Status: http.StatusBadRequest, Code: CodeInvalidPreference},
+	{Contains: "notification not found", Status: http.StatusNotFound, Code: CodeNotificationNotFound},
+	{Contains: "digest not found", Status: http.StatusNotFound, Code: CodeDigestNotFound},
+	{Contains: "lockout not found", Status: http.StatusNotFound, Code: CodeLockoutNotFound},
+	{Contains: "impersonation session not found", Status: http.StatusNotFound, Code: CodeImpersonationNotFound},
+	{Contains: "role not found", Status: http.StatusNotFound, Code: CodeRoleNotFound},
+	{Contains: "user not found", Status: http.StatusNotFound, Code: CodeUserNotFound},
+	{Contains: "not found", Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Contains: "forbidden", Status: http.StatusForbidden, Code: apierror.CodeForbidden},
+	{Contains: "already", Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Contains: "invalid", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Contains: "required", Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/user-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ActivityHandler handles activity feed HTTP requests
+type ActivityHandler struct {
+	activityService *service.ActivityService
+	logger          *zap.Logger
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(activityService *service.ActivityService, logger *zap.Logger) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+		logger:          logger,
+	}
+}
+
+// GetActivity handles retrieving the current user's activity feed. The type parameter
+// filters by activity type and can be repeated or comma-separated.
+// GET /api/v1/users/me/activity
+//
+// @Summary Retrieve the current user's activity feed
+// @Tags users
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param type query string false "type"
+// @Success 200 {object} object{data=[]model.Activity,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/activity [get]
+func (h *ActivityHandler) GetActivity(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	params := pagination.Parse(c, 20, 100)
+
+	var types []string
+	for _, value := range c.QueryArray("type") {
+		for _, activityType := range strings.Split(value, ",") {
+			if activityType = strings.TrimSpace(activityType); activityType != "" {
+				types = append(types, activityType)
+			}
+		}
+	}
+
+	activities, total, err := h.activityService.GetActivities(
+		c.Request.Context(),
+		userID.(int),
+		types,
+		params.Limit,
+		params.Offset(),
+	)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get activity feed", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to get activity feed")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, activities, total, params.Page, params.Limit)
+}
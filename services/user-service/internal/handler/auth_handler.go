@@ -1,12 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+	"services/user-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,19 +32,28 @@ func NewAuthHandler(authService *service.AuthService, logger *zap.Logger) *AuthH
 	}
 }
 
-// Register handles user registration
+// Register handles user registration, optionally with the referral code of the user who
+// invited them
 // POST /api/v1/auth/register
+//
+// @Summary Register a user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.UserCreate true "Request body"
+// @Success 201 {object} model.TokenResponse
+// @Failure 400 {object} apierror.Body
+// @Router /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var request model.UserCreate
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
-	response, err := h.authService.Register(c.Request.Context(), &request)
+	response, err := h.authService.Register(c.Request.Context(), &request, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		h.logger.Error("registration failed", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -47,17 +62,37 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login handles user login
 // POST /api/v1/auth/login
+//
+// @Summary Log in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.UserLogin true "Request body"
+// @Success 200 {object} model.TokenResponse
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var request model.UserLogin
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
-	response, err := h.authService.Login(c.Request.Context(), &request)
+	response, err := h.authService.Login(c.Request.Context(), &request, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		h.logger.Debug("login failed", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		if errors.Is(err, service.ErrTwoFactorRequired) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":               "Two-factor authentication code required",
+				"code":                errcode.CodeTwoFactorRequired,
+				"two_factor_required": true,
+			})
+			return
+		}
+		// Locked accounts get the same response as a wrong password, so probing an
+		// address until it locks doesn't reveal that it is registered. The owner is
+		// alerted of the lockout by email.
+		apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
@@ -66,14 +101,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Logout handles user logout
 // POST /api/v1/auth/logout
+//
+// @Summary Log out
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body object true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Get the refresh token from the request body
 	var request struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -81,7 +126,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	err := h.authService.Logout(c.Request.Context(), request.RefreshToken)
 	if err != nil {
 		h.logger.Error("logout failed", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		apierror.Respond(c, err, "Failed to logout")
 		return
 	}
 
@@ -90,17 +135,26 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // LogoutAll handles logging out all devices for a user
 // POST /api/v1/auth/logout-all
+//
+// @Summary Log out all devices for a user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object{message=string,session_count=integer}
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/logout-all [post]
 func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
 	count, err := h.authService.LogoutAll(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("logout all failed", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout from all devices"})
+		apierror.Respond(c, err, "Failed to logout from all devices")
 		return
 	}
 
@@ -112,11 +166,19 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 
 // Validate handles token validation
 // GET /api/v1/auth/validate
+//
+// @Summary Validate an access token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object
+// @Failure 401 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/validate [get]
 func (h *AuthHandler) Validate(c *gin.Context) {
 	// The AuthMiddleware has already validated the token and set the userID and userRole in the context
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
@@ -127,37 +189,181 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 		userRole = "user"
 	}
 
+	// Get the permissions from context (set by AuthMiddleware)
+	permissions, _ := c.Get("userPermissions")
+	permissionList, _ := permissions.([]string)
+
 	// Set headers for Nginx auth_request module
 	c.Header("X-User-ID", fmt.Sprintf("%d", userID))
 	c.Header("X-User-Role", userRole.(string))
+	c.Header("X-User-Permissions", strings.Join(permissionList, ","))
+
+	response := gin.H{
+		"valid":       true,
+		"user_id":     userID,
+		"role":        userRole,
+		"permissions": permissionList,
+	}
+
+	// Flag impersonated requests so they can be audited downstream
+	if impersonatorID, ok := c.Get("impersonatorID"); ok {
+		sessionID, _ := c.Get("impersonationSessionID")
+		c.Header("X-Impersonator-ID", fmt.Sprintf("%d", impersonatorID))
+		c.Header("X-Impersonation-Session-ID", fmt.Sprintf("%d", sessionID))
+		response["impersonator_id"] = impersonatorID
+		response["impersonation_session_id"] = sessionID
+	}
 
 	// Return success response
-	c.JSON(http.StatusOK, gin.H{
-		"valid":   true,
-		"user_id": userID,
-		"role":    userRole,
-	})
+	c.JSON(http.StatusOK, response)
+}
+
+// JWKS handles serving the public keys access tokens are signed with
+// GET /.well-known/jwks.json
+//
+// @Summary Get the public keys of access tokens
+// @Description Services verify access tokens locally against these keys. The set is empty while tokens are signed with the shared secret.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} model.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	// Verifiers refetch on unknown key IDs, so a short cache is enough for rotation
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
 }
 
 // RefreshToken handles refreshing access tokens
 // POST /api/v1/auth/refresh-token
+//
+// @Summary Refresh access tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshRequest true "Request body"
+// @Success 200 {object} model.TokenResponse
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Router /api/v1/auth/refresh-token [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var request model.RefreshRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
 	response, err := h.authService.RefreshToken(c.Request.Context(), request.RefreshToken)
 	if err != nil {
 		h.logger.Debug("token refresh failed", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid refresh token")
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GetTwoFactorStatus handles retrieving the current user's 2FA state
+// GET /api/v1/auth/2fa
+//
+// @Summary Retrieve the current user's 2FA state
+// @Tags auth
+// @Produce json
+// @Success 200 {object} model.TwoFactorStatus
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa [get]
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	status, err := h.authService.GetTwoFactorStatus(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Error("failed to get two-factor status", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get two-factor status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactor handles starting 2FA enrollment
+// POST /api/v1/auth/2fa/enroll
+//
+// @Summary Start 2FA enrollment
+// @Tags auth
+// @Produce json
+// @Success 200 {object} model.TwoFactorEnrollment
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	enrollment, err := h.authService.EnrollTwoFactor(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Debug("two-factor enrollment failed", zap.Error(err))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// VerifyTwoFactor handles confirming 2FA enrollment with a TOTP code
+// POST /api/v1/auth/2fa/verify
+//
+// @Summary Confirm 2FA enrollment with a TOTP code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorVerifyRequest true "Request body"
+// @Success 200 {object} model.TwoFactorVerifyResponse
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var request model.TwoFactorVerifyRequest
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	response, err := h.authService.VerifyTwoFactor(c.Request.Context(), userID.(int), request.Code)
+	if err != nil {
+		h.logger.Debug("two-factor verification failed", zap.Error(err))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableTwoFactor handles turning 2FA off
+// POST /api/v1/auth/2fa/disable
+//
+// @Summary Turn 2FA off
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorDisableRequest true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var request model.TwoFactorDisableRequest
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), userID.(int), &request); err != nil {
+		h.logger.Debug("two-factor disable failed", zap.Error(err))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
 // AuthHeader extracts the token from the Authorization header
 func AuthHeader(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
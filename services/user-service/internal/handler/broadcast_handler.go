@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BroadcastHandler handles admin notification broadcast HTTP requests
+type BroadcastHandler struct {
+	broadcastService *service.BroadcastService
+	logger           *zap.Logger
+}
+
+// NewBroadcastHandler creates a new broadcast handler
+func NewBroadcastHandler(broadcastService *service.BroadcastService, logger *zap.Logger) *BroadcastHandler {
+	return &BroadcastHandler{
+		broadcastService: broadcastService,
+		logger:           logger,
+	}
+}
+
+// CreateBroadcast handles scheduling a notification to an audience (admin only). The
+// broadcast is sent in the background; its progress is read from the status endpoint.
+// POST /api/v1/admin/notifications/broadcast
+//
+// @Summary Broadcast a notification to an audience (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationBroadcastCreate true "Request body"
+// @Success 202 {object} object{data=model.NotificationBroadcast}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/notifications/broadcast [post]
+func (h *BroadcastHandler) CreateBroadcast(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	var request model.NotificationBroadcastCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	broadcast, err := h.broadcastService.CreateBroadcast(c.Request.Context(), adminID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to create notification broadcast", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to create notification broadcast")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": broadcast})
+}
+
+// ListBroadcasts handles listing notification broadcasts newest first (admin only)
+// GET /api/v1/admin/notifications/broadcasts
+//
+// @Summary List notification broadcasts (admin only)
+// @Tags admin
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param status query string false "status"
+// @Success 200 {object} object{data=[]model.NotificationBroadcast,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/notifications/broadcasts [get]
+func (h *BroadcastHandler) ListBroadcasts(c *gin.Context) {
+	params := pagination.Parse(c, 20, 100)
+
+	status := c.Query("status")
+	switch status {
+	case "", model.BroadcastStatusScheduled, model.BroadcastStatusRunning, model.BroadcastStatusCompleted,
+		model.BroadcastStatusFailed, model.BroadcastStatusCancelled:
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid status. Valid options are: scheduled, running, completed, failed, cancelled")
+		return
+	}
+
+	broadcasts, total, err := h.broadcastService.ListBroadcasts(c.Request.Context(), status, params.Page, params.Limit)
+	if err != nil {
+		h.logger.Error("Failed to list notification broadcasts", zap.Error(err))
+		apierror.Respond(c, err, "Failed to list notification broadcasts")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, broadcasts, total, params.Page, params.Limit)
+}
+
+// GetBroadcast handles retrieving a notification broadcast with its progress (admin only)
+// GET /api/v1/admin/notifications/broadcasts/{id}
+//
+// @Summary Retrieve a notification broadcast and its progress (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.NotificationBroadcast}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/notifications/broadcasts/{id} [get]
+func (h *BroadcastHandler) GetBroadcast(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid broadcast ID")
+		return
+	}
+
+	broadcast, err := h.broadcastService.GetBroadcast(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get notification broadcast", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to retrieve notification broadcast")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": broadcast})
+}
+
+// CancelBroadcast handles cancelling a notification broadcast that hasn't finished (admin only)
+// POST /api/v1/admin/notifications/broadcasts/{id}/cancel
+//
+// @Summary Cancel a notification broadcast that hasn't finished (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{data=model.NotificationBroadcast}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/notifications/broadcasts/{id}/cancel [post]
+func (h *BroadcastHandler) CancelBroadcast(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid broadcast ID")
+		return
+	}
+
+	broadcast, err := h.broadcastService.CancelBroadcast(c.Request.Context(), id)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to cancel notification broadcast", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to cancel notification broadcast")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": broadcast})
+}
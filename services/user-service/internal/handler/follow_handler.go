@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FollowHandler handles HTTP requests for users following sellers
+type FollowHandler struct {
+	followService *service.FollowService
+	logger        *zap.Logger
+}
+
+// NewFollowHandler creates a new follow handler
+func NewFollowHandler(followService *service.FollowService, logger *zap.Logger) *FollowHandler {
+	return &FollowHandler{
+		followService: followService,
+		logger:        logger,
+	}
+}
+
+// Follow handles following a user, e.g. a seller whose new strategies the user wants to hear about
+// POST /api/v1/users/:id/follow
+//
+// @Summary Follow a user
+// @Tags users
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} model.FollowCounts
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/{id}/follow [post]
+func (h *FollowHandler) Follow(c *gin.Context) {
+	followeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	counts, err := h.followService.Follow(c.Request.Context(), userID.(int), followeeID)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to follow user", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to follow user")
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// Unfollow handles unfollowing a user
+// DELETE /api/v1/users/:id/follow
+//
+// @Summary Unfollow a user
+// @Tags users
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} model.FollowCounts
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/{id}/follow [delete]
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	followeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	counts, err := h.followService.Unfollow(c.Request.Context(), userID.(int), followeeID)
+	if err != nil {
+		h.logger.Error("failed to unfollow user", zap.Error(err))
+		apierror.Respond(c, err, "Failed to unfollow user")
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetFollowers handles listing the followers of a user
+// GET /api/v1/users/:id/followers
+//
+// @Summary List the followers of a user
+// @Tags users
+// @Produce json
+// @Param id path integer true "id"
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.FollowedUser,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/users/{id}/followers [get]
+func (h *FollowHandler) GetFollowers(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	params := pagination.Parse(c, 20, 100)
+
+	followers, total, err := h.followService.GetFollowers(
+		c.Request.Context(),
+		userID,
+		params.Limit,
+		params.Offset(),
+	)
+	if err != nil {
+		h.logger.Error("failed to get followers", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get followers")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, followers, total, params.Page, params.Limit)
+}
+
+// GetFollowing handles listing the users the current user follows
+// GET /api/v1/users/me/following
+//
+// @Summary List the users the current user follows
+// @Tags users
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.FollowedUser,meta=pagination.Meta}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/following [get]
+func (h *FollowHandler) GetFollowing(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	params := pagination.Parse(c, 20, 100)
+
+	following, total, err := h.followService.GetFollowing(
+		c.Request.Context(),
+		userID.(int),
+		params.Limit,
+		params.Offset(),
+	)
+	if err != nil {
+		h.logger.Error("failed to get followed users", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get followed users")
+		return
+	}
+
+	pagination.Send(c, http.StatusOK, following, total, params.Page, params.Limit)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImpersonationHandler handles admin impersonation HTTP requests
+type ImpersonationHandler struct {
+	authService *service.AuthService
+	logger      *zap.Logger
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(authService *service.AuthService, logger *zap.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Impersonate handles starting an impersonation session for a user
+// POST /api/v1/admin/users/:id/impersonate
+//
+// @Summary Start an impersonation session for a user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.ImpersonationRequest true "Request body"
+// @Success 201 {object} model.ImpersonationResponse
+// @Failure 400 {object} apierror.Body
+// @Failure 403 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var request model.ImpersonationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.authService.Impersonate(c.Request.Context(), adminID.(int), userID, &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to start impersonation", zap.Error(err), zap.Int("userID", userID))
+		}
+		apierror.Respond(c, err, "Failed to start impersonation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetActiveImpersonations handles listing active impersonation sessions
+// GET /api/v1/admin/impersonations
+//
+// @Summary List active impersonation sessions
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=[]model.ImpersonationSession}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/impersonations [get]
+func (h *ImpersonationHandler) GetActiveImpersonations(c *gin.Context) {
+	sessions, err := h.authService.GetActiveImpersonations(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to list impersonation sessions", zap.Error(err))
+		apierror.Respond(c, err, "Failed to list impersonation sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeImpersonation handles ending an impersonation session
+// DELETE /api/v1/admin/impersonations/:id
+//
+// @Summary End an impersonation session
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/impersonations/{id} [delete]
+func (h *ImpersonationHandler) RevokeImpersonation(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeImpersonation(c.Request.Context(), sessionID, adminID.(int)); err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to revoke impersonation", zap.Error(err), zap.Int("sessionID", sessionID))
+		}
+		apierror.Respond(c, err, "Failed to revoke impersonation session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LockoutHandler handles admin requests for login lockouts
+type LockoutHandler struct {
+	authService *service.AuthService
+	logger      *zap.Logger
+}
+
+// NewLockoutHandler creates a new lockout handler
+func NewLockoutHandler(authService *service.AuthService, logger *zap.Logger) *LockoutHandler {
+	return &LockoutHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// GetLockouts handles listing accounts locked after failed logins
+// GET /api/v1/admin/lockouts
+//
+// @Summary List accounts locked after failed logins
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{data=[]model.LoginLockout}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/lockouts [get]
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.authService.GetLoginLockouts(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, err, "Failed to list login lockouts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lockouts})
+}
+
+// ClearLockout handles unlocking an account locked after failed logins
+// DELETE /api/v1/admin/users/:id/lockout
+//
+// @Summary Unlock an account locked after failed logins
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/lockout [delete]
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.authService.ClearLoginLockout(c.Request.Context(), userID, adminID.(int)); err != nil {
+		apierror.Respond(c, err, "Failed to clear login lockout")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -4,8 +4,12 @@ import (
 	"net/http"
 	"strconv"
 
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -27,11 +31,29 @@ func NewNotificationHandler(notificationService *service.NotificationService, lo
 
 // GetNotifications handles retrieving user notifications
 // GET /api/v1/users/me/notifications
+//
+// @Summary Retrieve user notifications
+// @Tags users
+// @Produce json
+// @Param limit query integer false "limit"
+// @Param offset query integer false "offset"
+// @Param unread_only query string false "unread only"
+// @Param category query string false "category"
+// @Success 200 {object} model.NotificationListResponse
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications [get]
 func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	unreadOnly := c.Query("unread_only") == "true"
+	category := c.Query("category")
+	if category != "" && !model.IsNotificationCategory(category) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid category. Valid options are: backtest, marketplace, system, security")
+		return
+	}
 
 	var notifications []model.Notification
 	var err error
@@ -45,12 +67,13 @@ func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 			userID.(int),
 			limit,
 			offset,
+			category,
 		)
 	}
 
 	if err != nil {
 		h.logger.Error("Failed to get notifications", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+		apierror.Respond(c, err, "Failed to get notifications")
 		return
 	}
 
@@ -72,13 +95,21 @@ func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 
 // GetUnreadCount handles retrieving unread notification count
 // GET /api/v1/users/me/notifications/count
+//
+// @Summary Retrieve unread notification count
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.NotificationCountResponse
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/count [get]
 func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	count, err := h.notificationService.GetUnreadCount(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to get unread notification count", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification count"})
+		apierror.Respond(c, err, "Failed to get notification count")
 		return
 	}
 
@@ -91,23 +122,34 @@ func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 
 // MarkNotificationAsRead handles marking a notification as read
 // PUT /api/v1/users/me/notifications/:id/read
+//
+// @Summary Mark a notification as read
+// @Tags users
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/{id}/read [put]
 func (h *NotificationHandler) MarkNotificationAsRead(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid notification ID")
 		return
 	}
 
 	success, err := h.notificationService.MarkNotificationAsRead(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to mark notification as read", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		apierror.Respond(c, err, "Failed to update notification")
 		return
 	}
 
 	if !success {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeNotificationNotFound, "Notification not found")
 		return
 	}
 
@@ -116,13 +158,21 @@ func (h *NotificationHandler) MarkNotificationAsRead(c *gin.Context) {
 
 // MarkAllAsRead handles marking all notifications as read
 // PUT /api/v1/users/me/notifications/read-all
+//
+// @Summary Mark all notifications as read
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.NotificationMarkResponse
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/read-all [put]
 func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	count, err := h.notificationService.MarkAllNotificationsAsRead(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to mark all notifications as read", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		apierror.Respond(c, err, "Failed to update notifications")
 		return
 	}
 
@@ -134,19 +184,122 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetDigestNotifications handles retrieving the notifications collapsed into a digest
+// GET /api/v1/users/me/notifications/:id/items
+//
+// @Summary Retrieve the notifications collapsed into a digest
+// @Tags users
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{notifications=[]model.Notification}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/{id}/items [get]
+func (h *NotificationHandler) GetDigestNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	notifications, err := h.notificationService.GetDigestNotifications(c.Request.Context(), id, userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to get digest notifications", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to get notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+// GetPreferences handles retrieving the delivery setting of every notification category
+// GET /api/v1/users/me/notifications/preferences
+//
+// @Summary Retrieve the delivery setting of every notification category
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{preferences=[]model.NotificationPreference}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	preferences, err := h.notificationService.GetPreferences(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Error("Failed to get notification preferences", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get notification preferences")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// UpdatePreferences handles setting the delivery of notification categories to instant,
+// digest or off
+// PUT /api/v1/users/me/notifications/preferences
+//
+// @Summary Set the delivery of notification categories to instant, digest or off
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationPreferencesUpdate true "Request body"
+// @Success 200 {object} object{preferences=[]model.NotificationPreference}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request model.NotificationPreferencesUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Request.Context(), userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("Failed to update notification preferences", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to update notification preferences")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
 // CreateNotification handles creating a new notification (admin or service-to-service)
 // POST /api/v1/admin/notifications
+//
+// @Summary Create a new notification (admin or service-to-service)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationCreate true "Request body"
+// @Success 201 {object} object{id=integer,success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/notifications [post]
 func (h *NotificationHandler) CreateNotification(c *gin.Context) {
 	var request model.NotificationCreate
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	id, err := h.notificationService.AddNotification(c.Request.Context(), &request)
 	if err != nil {
 		h.logger.Error("Failed to create notification", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		apierror.Respond(c, err, "Failed to create notification")
 		return
 	}
 
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// wsTokenProtocol is the websocket subprotocol browsers send the access token with,
+	// as "Sec-WebSocket-Protocol: bearer, <token>"
+	wsTokenProtocol = "bearer"
+	// wsWriteWait is the time allowed to write a message to the client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is the time allowed to read the next pong from the client
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be shorter than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// NotificationWSHandler pushes notifications to clients over websockets
+type NotificationWSHandler struct {
+	authService         *service.AuthService
+	notificationService *service.NotificationService
+	hub                 *service.NotificationHub
+	upgrader            websocket.Upgrader
+	logger              *zap.Logger
+}
+
+// NewNotificationWSHandler creates a new websocket notification handler
+func NewNotificationWSHandler(
+	authService *service.AuthService,
+	notificationService *service.NotificationService,
+	hub *service.NotificationHub,
+	allowedOrigins []string,
+	logger *zap.Logger,
+) *NotificationWSHandler {
+	return &NotificationWSHandler{
+		authService:         authService,
+		notificationService: notificationService,
+		hub:                 hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     allowOrigins(allowedOrigins),
+		},
+		logger: logger,
+	}
+}
+
+// Connect authenticates the token and streams notification pushes until the client disconnects.
+// Browsers can't set headers on websocket requests, so they send the token as the second
+// subprotocol after "bearer" instead.
+// GET /ws/notifications
+//
+// @Summary Stream notification pushes over a websocket
+// @Tags notifications
+// @Param Sec-WebSocket-Protocol header string false "bearer, <access token>, if the token isn't sent in the Authorization header"
+// @Success 101
+// @Failure 401 {object} apierror.Body
+// @Router /ws/notifications [get]
+func (h *NotificationWSHandler) Connect(c *gin.Context) {
+	var responseHeader http.Header
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if protocols := websocket.Subprotocols(c.Request); len(protocols) == 2 && protocols[0] == wsTokenProtocol {
+		token = protocols[1]
+		// The browser closes the connection unless one of its subprotocols is accepted
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": {wsTokenProtocol}}
+	}
+	if token == "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Token required")
+		return
+	}
+
+	claims, err := h.authService.ParseAccessToken(token)
+	if err != nil {
+		apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidToken, "Invalid or expired token")
+		return
+	}
+	if err := h.authService.CheckImpersonation(c.Request.Context(), claims); err != nil {
+		apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidToken, "Invalid or expired token")
+		return
+	}
+
+	// Also confirms the user still exists and is active
+	unread, err := h.notificationService.GetUnreadCount(c.Request.Context(), claims.UserID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "User not found or inactive")
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, responseHeader)
+	if err != nil {
+		// Upgrade has already written an HTTP error response
+		h.logger.Debug("Websocket upgrade failed", zap.Error(err))
+		return
+	}
+
+	pushes, unsubscribe := h.hub.Subscribe(claims.UserID)
+	defer unsubscribe()
+
+	h.logger.Debug("Notification client connected", zap.Int("userID", claims.UserID))
+
+	// The read loop only handles pongs and detects disconnects
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	h.writeLoop(conn, pushes, done, &model.NotificationPush{Event: "connected", UnreadCount: unread})
+
+	conn.Close()
+	h.logger.Debug("Notification client disconnected", zap.Int("userID", claims.UserID))
+}
+
+// writeLoop sends the initial push, then forwards hub pushes and keepalive pings
+func (h *NotificationWSHandler) writeLoop(
+	conn *websocket.Conn,
+	pushes <-chan *model.NotificationPush,
+	done <-chan struct{},
+	initial *model.NotificationPush,
+) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := conn.WriteJSON(initial); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case push, ok := <-pushes:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(push); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// allowOrigins returns an origin check accepting requests from the allowed origins, and
+// from clients that send no Origin header and so aren't browsers
+func allowOrigins(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if strings.EqualFold(origin, allowed) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -3,8 +3,10 @@ package handler
 import (
 	"net/http"
 
+	"services/shared/apierror"
 	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,10 +28,19 @@ func NewPasswordHandler(authService *service.AuthService, logger *zap.Logger) *P
 
 // ChangePassword handles changing user's password
 // PUT /api/v1/users/me/password
+//
+// @Summary Change user's password
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.UserChangePassword true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/password [put]
 func (h *PasswordHandler) ChangePassword(c *gin.Context) {
 	var request model.UserChangePassword
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &request) {
 		return
 	}
 
@@ -37,7 +48,7 @@ func (h *PasswordHandler) ChangePassword(c *gin.Context) {
 	err := h.authService.ChangePassword(c.Request.Context(), userID.(int), &request)
 	if err != nil {
 		h.logger.Debug("password change failed", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
 		return
 	}
 
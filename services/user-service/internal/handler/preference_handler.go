@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"services/shared/apierror"
 	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,13 +31,21 @@ func NewPreferenceHandler(preferenceService *service.PreferenceService, logger *
 
 // GetUserPreferences handles fetching user preferences
 // GET /api/v1/users/me/preferences
+//
+// @Summary Fetch user preferences
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.UserPreferences
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/preferences [get]
 func (h *PreferenceHandler) GetUserPreferences(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	preferences, err := h.preferenceService.GetPreferences(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("failed to get user preferences", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		apierror.Respond(c, err, "Failed to get preferences")
 		return
 	}
 
@@ -41,10 +54,21 @@ func (h *PreferenceHandler) GetUserPreferences(c *gin.Context) {
 
 // UpdateUserPreferences handles updating user preferences
 // PUT /api/v1/users/me/preferences
+//
+// @Summary Update user preferences
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.PreferencesUpdate true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/preferences [put]
 func (h *PreferenceHandler) UpdateUserPreferences(c *gin.Context) {
 	var request model.PreferencesUpdate
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -52,7 +76,7 @@ func (h *PreferenceHandler) UpdateUserPreferences(c *gin.Context) {
 	err := h.preferenceService.UpdatePreferences(c.Request.Context(), userID.(int), &request)
 	if err != nil {
 		h.logger.Error("failed to update preferences", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		apierror.Respond(c, err, "Failed to update preferences")
 		return
 	}
 
@@ -69,13 +93,21 @@ func (h *PreferenceHandler) UpdateUserPreferences(c *gin.Context) {
 
 // ResetUserPreferences handles resetting user preferences to defaults
 // POST /api/v1/users/me/preferences/reset
+//
+// @Summary Reset user preferences to defaults
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{message=string}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/preferences/reset [post]
 func (h *PreferenceHandler) ResetUserPreferences(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	err := h.preferenceService.ResetPreferences(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("failed to reset preferences", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset preferences"})
+		apierror.Respond(c, err, "Failed to reset preferences")
 		return
 	}
 
@@ -89,3 +121,202 @@ func (h *PreferenceHandler) ResetUserPreferences(c *gin.Context) {
 
 	c.JSON(http.StatusOK, preferences)
 }
+
+// ListWorkspaces handles listing the user's saved UI workspaces, without their layouts
+// GET /api/v1/users/me/workspaces
+//
+// @Summary List saved UI workspaces and the quota they use
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{data=model.WorkspaceList}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/workspaces [get]
+func (h *PreferenceHandler) ListWorkspaces(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	list, err := h.preferenceService.ListWorkspaces(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Error("failed to list workspaces", zap.Error(err))
+		apierror.Respond(c, err, "Failed to list workspaces")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetWorkspace handles fetching a saved UI workspace. Its version is sent as the ETag;
+// a matching If-None-Match gets 304 Not Modified.
+// GET /api/v1/users/me/workspaces/{name}
+//
+// @Summary Fetch a saved UI workspace
+// @Tags users
+// @Produce json
+// @Param name path string true "Workspace name"
+// @Param If-None-Match header string false "ETag of the version the client has"
+// @Success 200 {object} object{data=model.Workspace}
+// @Success 304 "Not modified"
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/workspaces/{name} [get]
+func (h *PreferenceHandler) GetWorkspace(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	workspace, err := h.preferenceService.GetWorkspace(c.Request.Context(), userID.(int), c.Param("name"))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to get workspace", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to get workspace")
+		return
+	}
+
+	etag := workspaceETag(workspace.Version)
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if version, err := parseWorkspaceETag(match); err == nil && version == workspace.Version {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": workspace})
+}
+
+// SaveWorkspace handles creating or replacing a saved UI workspace. If-Match with the
+// ETag last read only replaces that version, so edits from another device aren't lost;
+// If-None-Match: * only creates. Without either the workspace is saved unconditionally.
+// PUT /api/v1/users/me/workspaces/{name}
+//
+// @Summary Create or replace a saved UI workspace
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param name path string true "Workspace name"
+// @Param If-Match header string false "ETag of the version being replaced, or *"
+// @Param If-None-Match header string false "* to only create the workspace"
+// @Param request body model.WorkspaceUpdate true "Request body"
+// @Success 200 {object} object{data=model.Workspace}
+// @Success 201 {object} object{data=model.Workspace}
+// @Failure 400 {object} apierror.Body
+// @Failure 412 {object} apierror.Body
+// @Failure 413 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/workspaces/{name} [put]
+func (h *PreferenceHandler) SaveWorkspace(c *gin.Context) {
+	expectedVersion, err := parseWorkspacePrecondition(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var request model.WorkspaceUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	workspace, created, err := h.preferenceService.SaveWorkspace(
+		c.Request.Context(),
+		userID.(int),
+		c.Param("name"),
+		request.Layout,
+		expectedVersion,
+	)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to save workspace", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to save workspace")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	c.Header("ETag", workspaceETag(workspace.Version))
+	c.JSON(status, gin.H{"data": workspace})
+}
+
+// DeleteWorkspace handles deleting a saved UI workspace, only if it is still the version
+// in If-Match when given
+// DELETE /api/v1/users/me/workspaces/{name}
+//
+// @Summary Delete a saved UI workspace
+// @Tags users
+// @Param name path string true "Workspace name"
+// @Param If-Match header string false "ETag of the version being deleted"
+// @Success 204 "No content"
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 412 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/workspaces/{name} [delete]
+func (h *PreferenceHandler) DeleteWorkspace(c *gin.Context) {
+	expectedVersion, err := parseWorkspacePrecondition(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	err = h.preferenceService.DeleteWorkspace(c.Request.Context(), userID.(int), c.Param("name"), expectedVersion)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to delete workspace", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to delete workspace")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// workspaceETag formats a workspace version as an ETag
+func workspaceETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseWorkspaceETag reads the version from an ETag, accepting weak ones (W/"3") that
+// proxies may turn strong ETags into
+func parseWorkspaceETag(etag string) (int, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	version, err := strconv.Atoi(strings.Trim(etag, `"`))
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid ETag")
+	}
+	return version, nil
+}
+
+// parseWorkspacePrecondition reads the version a save or delete is conditional on: nil
+// without a precondition, 0 for If-None-Match: * (only create) and -1 for If-Match: *
+// (only replace)
+func parseWorkspacePrecondition(c *gin.Context) (*int, error) {
+	if match := strings.TrimSpace(c.GetHeader("If-Match")); match != "" {
+		version := -1
+		if match != "*" {
+			var err error
+			if version, err = parseWorkspaceETag(match); err != nil {
+				return nil, errors.New("invalid If-Match header: expected a workspace ETag or *")
+			}
+		}
+		return &version, nil
+	}
+
+	if noneMatch := strings.TrimSpace(c.GetHeader("If-None-Match")); noneMatch != "" {
+		if noneMatch != "*" {
+			return nil, errors.New("invalid If-None-Match header: only * is supported when saving")
+		}
+		version := 0
+		return &version, nil
+	}
+
+	return nil, nil
+}
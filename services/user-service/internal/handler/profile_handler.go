@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/user-service/internal/client"
+	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+	"services/user-service/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,13 +34,21 @@ func NewProfileHandler(profileService *service.ProfileService, logger *zap.Logge
 
 // GetProfilePhoto handles retrieving a user's profile photo URL
 // GET /api/v1/users/me/profile-photo
+//
+// @Summary Retrieve a user's profile photo URL
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{url=string}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/profile-photo [get]
 func (h *ProfileHandler) GetProfilePhoto(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	photoURL, err := h.profileService.GetProfilePhotoURL(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("failed to get profile photo URL", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile photo"})
+		apierror.Respond(c, err, "Failed to get profile photo")
 		return
 	}
 
@@ -43,18 +59,28 @@ func (h *ProfileHandler) GetProfilePhoto(c *gin.Context) {
 
 // UploadProfilePhoto handles uploading a user profile photo
 // POST /api/v1/users/me/profile-photo
+//
+// @Summary Upload a user profile photo
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{message=string,url=string,variants=[]model.ImageVariant}
+// @Failure 400 {object} apierror.Body
+// @Failure 401 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/profile-photo [post]
 func (h *ProfileHandler) UploadProfilePhoto(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Parse multipart form
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max memory
 		h.logger.Error("Failed to parse multipart form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Failed to parse form data")
 		return
 	}
 
@@ -62,21 +88,22 @@ func (h *ProfileHandler) UploadProfilePhoto(c *gin.Context) {
 	file, header, err := c.Request.FormFile("photo")
 	if err != nil {
 		h.logger.Error("Failed to get file from form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No file uploaded")
 		return
 	}
 	defer file.Close()
 
 	// Check file size
 	if header.Size > 5*1024*1024 { // 5MB limit
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 5MB)"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "File too large (max 5MB)")
 		return
 	}
 
 	// Check content type
 	contentType := header.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type (allowed: jpeg, png, gif)"})
+	// The media service checks the actual content; this only rejects obvious mismatches early
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" && contentType != "image/webp" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid file type (allowed: jpeg, png, gif, webp)")
 		return
 	}
 
@@ -84,7 +111,7 @@ func (h *ProfileHandler) UploadProfilePhoto(c *gin.Context) {
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
 		h.logger.Error("Failed to read file content", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to read file")
 		return
 	}
 
@@ -97,28 +124,40 @@ func (h *ProfileHandler) UploadProfilePhoto(c *gin.Context) {
 		contentType,
 	)
 	if err != nil {
+		if errors.Is(err, client.ErrInvalidMedia) {
+			apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("Failed to upload profile photo", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload profile photo"})
+		apierror.Respond(c, err, "Failed to upload profile photo")
 		return
 	}
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Profile photo uploaded successfully",
-		"url":        result.URL,
-		"thumbnails": result.Thumbnails,
+		"message":  "Profile photo uploaded successfully",
+		"url":      result.URL,
+		"variants": result.Variants,
 	})
 }
 
 // DeleteProfilePhoto handles deleting a user's profile photo
 // DELETE /api/v1/users/me/profile-photo
+//
+// @Summary Delete a user's profile photo
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{message=string}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/profile-photo [delete]
 func (h *ProfileHandler) DeleteProfilePhoto(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	err := h.profileService.ClearProfilePhoto(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("Failed to delete profile photo", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile photo"})
+		apierror.Respond(c, err, "Failed to delete profile photo")
 		return
 	}
 
@@ -126,3 +165,116 @@ func (h *ProfileHandler) DeleteProfilePhoto(c *gin.Context) {
 		"message": "Profile photo deleted successfully",
 	})
 }
+
+// GetPublicProfile handles retrieving the public profile of a user
+// GET /api/v1/users/:id/profile
+//
+// @Summary Retrieve the public profile of a user
+// @Tags users
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} model.PublicProfile
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/users/{id}/profile [get]
+func (h *ProfileHandler) GetPublicProfile(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	profile, err := h.profileService.GetPublicProfile(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to get public profile", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SearchProfiles handles searching the public profiles of active users
+// GET /api/v1/users/search
+//
+// @Summary Search public profiles by username or display name
+// @Tags users
+// @Produce json
+// @Param search query string true "search"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.ProfileSearchResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Router /api/v1/users/search [get]
+func (h *ProfileHandler) SearchProfiles(c *gin.Context) {
+	params := pagination.Parse(c, 10, 50)
+
+	profiles, err := h.profileService.SearchPublicProfiles(c.Request.Context(), c.Query("search"), params.Limit)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to search profiles", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to search profiles")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profiles})
+}
+
+// GetMyProfile handles retrieving the public profile of the current user
+// GET /api/v1/users/me/profile
+//
+// @Summary Retrieve the public profile of the current user
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.PublicProfile
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/profile [get]
+func (h *ProfileHandler) GetMyProfile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	profile, err := h.profileService.GetPublicProfile(c.Request.Context(), userID.(int))
+	if err != nil {
+		h.logger.Error("failed to get profile", zap.Error(err))
+		apierror.Respond(c, err, "Failed to get profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfileDetails handles updating the display name, bio and social links of the
+// current user's public profile
+// PUT /api/v1/users/me/profile
+//
+// @Summary Update the display name, bio and social links of the current user's public profile
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.ProfileDetailsUpdate true "Request body"
+// @Success 200 {object} model.PublicProfile
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/profile [put]
+func (h *ProfileHandler) UpdateProfileDetails(c *gin.Context) {
+	var request model.ProfileDetailsUpdate
+	if !validation.BindJSON(c, &request) {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	profile, err := h.profileService.UpdateProfileDetails(c.Request.Context(), userID.(int), &request)
+	if err != nil {
+		h.logger.Error("failed to update profile details", zap.Error(err))
+		apierror.Respond(c, err, "Failed to update profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
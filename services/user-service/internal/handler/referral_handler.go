@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"services/shared/apierror"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReferralHandler handles HTTP requests for referral codes, referral credit and the
+// referral fraud report
+type ReferralHandler struct {
+	referralService *service.ReferralService
+	logger          *zap.Logger
+}
+
+// NewReferralHandler creates a new referral handler
+func NewReferralHandler(referralService *service.ReferralService, logger *zap.Logger) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+		logger:          logger,
+	}
+}
+
+// GetMyReferrals handles getting the current user's referral code and referral statistics
+// GET /api/v1/users/me/referrals
+//
+// @Summary Get the current user's referral code and statistics
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{data=model.ReferralSummary}
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/referrals [get]
+func (h *ReferralHandler) GetMyReferrals(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	summary, err := h.referralService.GetSummary(c.Request.Context(), userID.(int))
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to get referral summary", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to get referrals")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// GetFraudReport handles listing referrers whose recent referrals look fraudulent, e.g.
+// referees signing up from the referrer's own IP
+// GET /api/v1/admin/referrals/fraud
+//
+// @Summary List the fraud indicators of referrers' recent referrals
+// @Tags admin
+// @Produce json
+// @Param days query integer false "Days of referrals to look at (default 30)"
+// @Param min_referrals query integer false "Fewest referrals a referrer needs to be listed (default 3)"
+// @Param limit query integer false "limit"
+// @Success 200 {object} object{data=[]model.ReferralFraudEntry}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/referrals/fraud [get]
+func (h *ReferralHandler) GetFraudReport(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		days = 30
+	}
+	minReferrals, err := strconv.Atoi(c.DefaultQuery("min_referrals", "3"))
+	if err != nil || minReferrals < 1 {
+		minReferrals = 3
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	entries, err := h.referralService.GetFraudReport(c.Request.Context(), since, minReferrals, limit)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get referral fraud report")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// GetCredit handles a service getting a user's referral credit balance
+// GET /api/v1/service/users/:id/referral-credit
+//
+// @Summary Get a user's referral credit balance
+// @Tags service
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} object{data=model.ReferralCredit}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/users/{id}/referral-credit [get]
+func (h *ReferralHandler) GetCredit(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	credit, err := h.referralService.GetCredit(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get referral credit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credit})
+}
+
+// RedeemCredit handles a service spending a user's referral credit, e.g. on a marketplace
+// purchase. Less than asked for is spent when the balance is lower, and nothing when it's
+// empty.
+// POST /api/v1/service/referral-credit/redeem
+//
+// @Summary Spend a user's referral credit
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body model.ReferralCreditRedeem true "Request body"
+// @Success 200 {object} object{data=model.ReferralRedemption}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/referral-credit/redeem [post]
+func (h *ReferralHandler) RedeemCredit(c *gin.Context) {
+	var request model.ReferralCreditRedeem
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	redemption, err := h.referralService.RedeemCredit(c.Request.Context(), &request)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to redeem referral credit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": redemption})
+}
+
+// ReverseRedemption handles a service giving back the referral credit of a redemption,
+// e.g. when the purchase it paid for failed
+// POST /api/v1/service/referral-credit/redemptions/:id/reverse
+//
+// @Summary Give back the referral credit of a redemption
+// @Tags service
+// @Produce json
+// @Param id path integer true "id"
+// @Success 200 {object} object{success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/referral-credit/redemptions/{id}/reverse [post]
+func (h *ReferralHandler) ReverseRedemption(c *gin.Context) {
+	redemptionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid redemption ID")
+		return
+	}
+
+	if err := h.referralService.ReverseRedemption(c.Request.Context(), redemptionID); err != nil {
+		apierror.Respond(c, err, "Failed to reverse referral credit redemption")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RoleHandler handles role and permission management requests
+type RoleHandler struct {
+	roleService *service.RoleService
+	logger      *zap.Logger
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(roleService *service.RoleService, logger *zap.Logger) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListRoles handles listing all roles
+// GET /api/v1/admin/roles
+//
+// @Summary List all roles
+// @Tags admin
+// @Produce json
+// @Success 200 {object} []model.Role
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.GetAllRoles(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to list roles", zap.Error(err))
+		apierror.Respond(c, err, "Failed to retrieve roles")
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole handles retrieving a single role
+// GET /api/v1/admin/roles/:id
+//
+// @Summary Retrieve a single role
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+
+	role, err := h.roleService.GetRole(c.Request.Context(), id)
+	if err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole handles creating a custom role
+// POST /api/v1/admin/roles
+//
+// @Summary Create a custom role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.RoleCreate true "Request body"
+// @Success 201 {object} model.Role
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var request model.RoleCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), &request)
+	if err != nil {
+		h.logger.Debug("failed to create role", zap.Error(err))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole handles updating a role
+// PUT /api/v1/admin/roles/:id
+//
+// @Summary Update a role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.RoleUpdate true "Request body"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+
+	var request model.RoleUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), id, &request)
+	if err != nil {
+		h.logger.Debug("failed to update role", zap.Error(err), zap.Int("roleID", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole handles deleting a custom role
+// DELETE /api/v1/admin/roles/:id
+//
+// @Summary Delete a custom role
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+		h.logger.Debug("failed to delete role", zap.Error(err), zap.Int("roleID", id))
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// ListPermissions handles listing the permission catalog
+// GET /api/v1/admin/permissions
+//
+// @Summary List the permission catalog
+// @Tags admin
+// @Produce json
+// @Success 200 {object} []model.Permission
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.GetAllPermissions(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to list permissions", zap.Error(err))
+		apierror.Respond(c, err, "Failed to retrieve permissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// GetUserRoles handles listing the roles held by a user
+// GET /api/v1/admin/users/:id/roles
+//
+// @Summary List the roles held by a user
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} []model.Role
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	roles, err := h.roleService.GetUserRoles(c.Request.Context(), userID)
+	if err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// AssignUserRole handles assigning a role to a user
+// POST /api/v1/admin/users/:id/roles
+//
+// @Summary Assign a role to a user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.UserRoleAssignment true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/roles [post]
+func (h *RoleHandler) AssignUserRole(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var request model.UserRoleAssignment
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.roleService.AssignRole(c.Request.Context(), userID, request.RoleID); err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
+}
+
+// BulkAssignUserRole handles assigning a custom role to many users at once
+// POST /api/v1/admin/users/bulk/roles
+//
+// @Summary Assign a custom role to many users at once
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.BulkUserRole true "Request body"
+// @Success 200 {object} object{data=model.BulkUserResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/bulk/roles [post]
+func (h *RoleHandler) BulkAssignUserRole(c *gin.Context) {
+	var request model.BulkUserRole
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.roleService.BulkAssignRole(c.Request.Context(), request.UserIDs, request.RoleID)
+	if err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// BulkRemoveUserRole handles removing a custom role from many users at once
+// POST /api/v1/admin/users/bulk/roles/remove
+//
+// @Summary Remove a custom role from many users at once
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.BulkUserRole true "Request body"
+// @Success 200 {object} object{data=model.BulkUserResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/bulk/roles/remove [post]
+func (h *RoleHandler) BulkRemoveUserRole(c *gin.Context) {
+	var request model.BulkUserRole
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.roleService.BulkRemoveRole(c.Request.Context(), request.UserIDs, request.RoleID)
+	if err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// RemoveUserRole handles removing a role from a user
+// DELETE /api/v1/admin/users/:id/roles/:roleId
+//
+// @Summary Remove a role from a user
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Param roleId path integer true "role ID"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/roles/{roleId} [delete]
+func (h *RoleHandler) RemoveUserRole(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	roleID, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+
+	if err := h.roleService.RemoveRole(c.Request.Context(), userID, roleID); err != nil {
+		apierror.RespondWithStatus(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role removed successfully"})
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SavedViewHandler handles HTTP requests for the views users save for the backtest,
+// strategy and marketplace lists
+type SavedViewHandler struct {
+	savedViewService *service.SavedViewService
+	logger           *zap.Logger
+}
+
+// NewSavedViewHandler creates a new saved view handler
+func NewSavedViewHandler(savedViewService *service.SavedViewService, logger *zap.Logger) *SavedViewHandler {
+	return &SavedViewHandler{
+		savedViewService: savedViewService,
+		logger:           logger,
+	}
+}
+
+// ListViews handles listing the current user's saved views, optionally of one list
+// GET /api/v1/users/me/views
+//
+// @Summary List the current user's saved list views
+// @Tags users
+// @Produce json
+// @Param list_type query string false "backtests, strategies or marketplace"
+// @Success 200 {object} object{data=[]model.SavedView}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/views [get]
+func (h *SavedViewHandler) ListViews(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	views, err := h.savedViewService.ListViews(c.Request.Context(), userID.(int), c.Query("list_type"))
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get saved views")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// CreateView handles saving a named filter and sort combination of a list. The list
+// endpoints apply it when called with ?view=<id>.
+// POST /api/v1/users/me/views
+//
+// @Summary Save a view of a list
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.SavedViewCreate true "Request body"
+// @Success 201 {object} object{data=model.SavedView}
+// @Failure 400 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/views [post]
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	var request model.SavedViewCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	view, err := h.savedViewService.CreateView(c.Request.Context(), userID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to save view", zap.Error(err))
+		}
+		apierror.Respond(c, err, "Failed to save view")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": view})
+}
+
+// DeleteView handles deleting a saved view of the current user
+// DELETE /api/v1/users/me/views/:id
+//
+// @Summary Delete a saved list view
+// @Tags users
+// @Param id path integer true "id"
+// @Success 204
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me/views/{id} [delete]
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	viewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid view ID")
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := h.savedViewService.DeleteView(c.Request.Context(), userID.(int), viewID); err != nil {
+		apierror.Respond(c, err, "Failed to delete saved view")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUserView handles a service getting a saved view of a user, to apply it to one of its
+// lists
+// GET /api/v1/service/users/:id/views/:viewId
+//
+// @Summary Get a saved list view of a user
+// @Tags service
+// @Produce json
+// @Param id path integer true "id"
+// @Param viewId path integer true "view ID"
+// @Success 200 {object} object{data=model.SavedView}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/users/{id}/views/{viewId} [get]
+func (h *SavedViewHandler) GetUserView(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	viewID, err := strconv.Atoi(c.Param("viewId"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid view ID")
+		return
+	}
+
+	view, err := h.savedViewService.GetView(c.Request.Context(), userID, viewID)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to get saved view")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": view})
+}
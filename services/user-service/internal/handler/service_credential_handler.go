@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"services/shared/apierror"
+	"services/user-service/internal/model"
+	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ServiceCredentialHandler handles issuing, revoking and verifying the keys of internal services
+type ServiceCredentialHandler struct {
+	credentialService *service.ServiceCredentialService
+	logger            *zap.Logger
+}
+
+// NewServiceCredentialHandler creates a new service credential handler
+func NewServiceCredentialHandler(credentialService *service.ServiceCredentialService, logger *zap.Logger) *ServiceCredentialHandler {
+	return &ServiceCredentialHandler{
+		credentialService: credentialService,
+		logger:            logger,
+	}
+}
+
+// ListCredentials handles listing the keys of internal services
+// GET /api/v1/admin/service-credentials?service=
+//
+// @Summary List the keys of internal services
+// @Tags admin
+// @Produce json
+// @Param service query string false "Only the keys of this service"
+// @Success 200 {object} object{data=[]model.ServiceCredential}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/service-credentials [get]
+func (h *ServiceCredentialHandler) ListCredentials(c *gin.Context) {
+	credentials, err := h.credentialService.GetCredentials(c.Request.Context(), c.Query("service"))
+	if err != nil {
+		apierror.Respond(c, err, "Failed to list service credentials")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credentials})
+}
+
+// IssueCredential handles issuing a key to an internal service. Setting
+// rotation_grace_hours rotates the service's keys: its other keys stop working after the
+// grace period.
+// POST /api/v1/admin/service-credentials
+//
+// @Summary Issue a key to an internal service
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.ServiceCredentialIssue true "Request body"
+// @Success 201 {object} object{data=model.IssuedServiceCredential}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/service-credentials [post]
+func (h *ServiceCredentialHandler) IssueCredential(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	var request model.ServiceCredentialIssue
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	issued, err := h.credentialService.IssueCredential(c.Request.Context(), adminID.(int), &request)
+	if err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to issue service credential", zap.Error(err),
+				zap.String("service", request.ServiceName))
+		}
+		apierror.Respond(c, err, "Failed to issue service credential")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": issued})
+}
+
+// RevokeCredential handles revoking a key of an internal service
+// DELETE /api/v1/admin/service-credentials/:id
+//
+// @Summary Revoke a key of an internal service
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} object{success=boolean}
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 409 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/service-credentials/{id} [delete]
+func (h *ServiceCredentialHandler) RevokeCredential(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid credential ID")
+		return
+	}
+
+	if err := h.credentialService.RevokeCredential(c.Request.Context(), id, adminID.(int)); err != nil {
+		if apierror.From(err) == nil {
+			h.logger.Error("failed to revoke service credential", zap.Error(err), zap.Int("id", id))
+		}
+		apierror.Respond(c, err, "Failed to revoke service credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// VerifyCredential handles a service checking a key it was called with, so services
+// without database access accept issued keys. valid is false for unknown, expired and
+// revoked keys.
+// POST /api/v1/service/credentials/verify
+//
+// @Summary Verify a key an internal service was called with
+// @Tags service
+// @Accept json
+// @Produce json
+// @Param request body model.ServiceCredentialVerify true "Request body"
+// @Success 200 {object} object{valid=boolean,data=model.AuthenticatedService}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/credentials/verify [post]
+func (h *ServiceCredentialHandler) VerifyCredential(c *gin.Context) {
+	var request model.ServiceCredentialVerify
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	authenticated, err := h.credentialService.Authenticate(c.Request.Context(), request.Key)
+	if err != nil {
+		apierror.Respond(c, err, "Failed to verify service credential")
+		return
+	}
+	if authenticated == nil {
+		c.JSON(http.StatusOK, gin.H{"valid": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "data": authenticated})
+}
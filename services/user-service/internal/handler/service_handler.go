@@ -5,7 +5,11 @@ import (
 	"strconv"
 	"strings"
 
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -27,11 +31,21 @@ func NewServiceHandler(userService *service.UserService, logger *zap.Logger) *Se
 
 // BatchGetUsers handles fetching multiple users by ID for service-to-service communication
 // GET /api/v1/service/users/batch?ids=1,2,3
+//
+// @Summary Fetch multiple users by ID for service-to-service communication
+// @Tags service
+// @Produce json
+// @Param ids query string false "ids"
+// @Success 200 {object} object{users=[]object{id=integer,username=string,profile_photo_url=string}}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/users/batch [get]
 func (h *ServiceHandler) BatchGetUsers(c *gin.Context) {
 	// Parse ids from query parameter
 	idsParam := c.Query("ids")
 	if idsParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User IDs required"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "User IDs required")
 		return
 	}
 
@@ -52,7 +66,7 @@ func (h *ServiceHandler) BatchGetUsers(c *gin.Context) {
 	}
 
 	if len(ids) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid user IDs provided"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No valid user IDs provided")
 		return
 	}
 
@@ -60,7 +74,7 @@ func (h *ServiceHandler) BatchGetUsers(c *gin.Context) {
 	users, err := h.userService.GetUsersByIDs(c.Request.Context(), ids)
 	if err != nil {
 		h.logger.Error("Failed to get users batch", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+		apierror.Respond(c, err, "Failed to get users")
 		return
 	}
 
@@ -85,23 +99,34 @@ func (h *ServiceHandler) BatchGetUsers(c *gin.Context) {
 
 // GetUserByID handles fetching a user by ID for service-to-service communication
 // GET /api/v1/service/users/:id
+//
+// @Summary Fetch a user by ID for service-to-service communication
+// @Tags service
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.User
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security ServiceKey
+// @Router /api/v1/service/users/{id} [get]
 func (h *ServiceHandler) GetUserByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	user, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		apierror.Respond(c, err, "Failed to get user")
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeUserNotFound, "User not found")
 		return
 	}
 
@@ -1,12 +1,19 @@
 package handler
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"services/shared/apierror"
+	"services/shared/pagination"
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/service"
+	"services/user-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -28,17 +35,26 @@ func NewUserHandler(userService *service.UserService, logger *zap.Logger) *UserH
 
 // GetCurrentUser handles fetching current user profile
 // GET /api/v1/users/me
+//
+// @Summary Fetch current user profile
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.UserDetails
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me [get]
 func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	user, err := h.userService.GetCurrentUser(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("failed to get current user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		apierror.Respond(c, err, "Failed to get user data")
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeUserNotFound, "User not found")
 		return
 	}
 
@@ -47,10 +63,21 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 
 // UpdateCurrentUser handles updating current user profile
 // PUT /api/v1/users/me
+//
+// @Summary Update current user profile
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body model.UserUpdate true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me [put]
 func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
 	var request model.UserUpdate
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -58,7 +85,7 @@ func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
 	err := h.userService.Update(c.Request.Context(), userID.(int), &request)
 	if err != nil {
 		h.logger.Error("failed to update user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		apierror.Respond(c, err, "Failed to update user")
 		return
 	}
 
@@ -75,13 +102,21 @@ func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
 
 // DeleteCurrentUser handles deleting the current user (deactivating)
 // DELETE /api/v1/users/me
+//
+// @Summary Delete the current user (deactivating)
+// @Tags users
+// @Produce json
+// @Success 200 {object} object{message=string}
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/users/me [delete]
 func (h *UserHandler) DeleteCurrentUser(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	err := h.userService.DeleteUser(c.Request.Context(), userID.(int))
 	if err != nil {
 		h.logger.Error("failed to delete user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		apierror.Respond(c, err, "Failed to delete user")
 		return
 	}
 
@@ -90,23 +125,34 @@ func (h *UserHandler) DeleteCurrentUser(c *gin.Context) {
 
 // GetUserByID handles fetching a user by ID (admin only)
 // GET /api/v1/admin/users/{id}
+//
+// @Summary Fetch a user by ID (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path integer true "ID"
+// @Success 200 {object} model.User
+// @Failure 400 {object} apierror.Body
+// @Failure 404 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	user, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("failed to get user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		apierror.Respond(c, err, "Failed to get user")
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierror.Send(c, http.StatusNotFound, errcode.CodeUserNotFound, "User not found")
 		return
 	}
 
@@ -115,24 +161,36 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 
 // UpdateUser handles updating a user (admin only)
 // PUT /api/v1/admin/users/{id}
+//
+// @Summary Update a user (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID"
+// @Param request body model.UserUpdate true "Request body"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	var request model.UserUpdate
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	err = h.userService.Update(c.Request.Context(), id, &request)
 	if err != nil {
 		h.logger.Error("failed to update user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		apierror.Respond(c, err, "Failed to update user")
 		return
 	}
 
@@ -147,27 +205,205 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-// ListUsers handles listing users (admin only)
+// ListUsers handles listing users (admin only). Users can be searched by username or
+// email, filtered by system or custom role, active status and creation date
+// (created_after; RFC 3339 or YYYY-MM-DD) and sorted by id, username, email, created_at
+// or last_login.
 // GET /api/v1/admin/users
+//
+// @Summary List and search users (admin only)
+// @Tags admin
+// @Produce json
+// @Param page query integer false "page"
+// @Param limit query integer false "limit"
+// @Param search query string false "search"
+// @Param role query string false "role"
+// @Param is_active query boolean false "is_active"
+// @Param created_after query string false "created_after"
+// @Param sort_by query string false "sort by"
+// @Param sort_direction query string false "sort direction"
+// @Success 200 {object} object{data=[]model.User,meta=pagination.Meta}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users [get]
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	params := pagination.Parse(c, 10, 100)
 
-	users, total, err := h.userService.ListUsers(c.Request.Context(), page, limit)
+	search := model.UserSearch{
+		Search:        c.Query("search"),
+		Role:          c.Query("role"),
+		SortBy:        c.DefaultQuery("sort_by", "id"),
+		SortDirection: c.DefaultQuery("sort_direction", "ASC"),
+	}
+	if activeStr := c.Query("is_active"); activeStr != "" {
+		isActive, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid is_active; use true or false")
+			return
+		}
+		search.IsActive = &isActive
+	}
+	createdAfter, err := parseTimeQuery(c.Query("created_after"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid created_after; use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	search.CreatedAfter = createdAfter
+
+	users, total, err := h.userService.ListUsers(c.Request.Context(), &search, params.Page, params.Limit)
 	if err != nil {
 		h.logger.Error("failed to list users", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		apierror.Respond(c, err, "Failed to list users")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-		"meta": gin.H{
-			"total": total,
-			"page":  page,
-			"limit": limit,
-		},
-	})
+	pagination.Send(c, http.StatusOK, users, total, params.Page, params.Limit)
+}
+
+// BulkActivateUsers handles activating many users at once (admin only)
+// POST /api/v1/admin/users/bulk/activate
+//
+// @Summary Activate many users at once (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.BulkUserStatus true "Request body"
+// @Success 200 {object} object{data=model.BulkUserResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/bulk/activate [post]
+func (h *UserHandler) BulkActivateUsers(c *gin.Context) {
+	h.bulkSetActive(c, true)
+}
+
+// BulkDeactivateUsers handles deactivating many users at once (admin only). Deactivated
+// users are signed out everywhere.
+// POST /api/v1/admin/users/bulk/deactivate
+//
+// @Summary Deactivate many users at once (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.BulkUserStatus true "Request body"
+// @Success 200 {object} object{data=model.BulkUserResult}
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/bulk/deactivate [post]
+func (h *UserHandler) BulkDeactivateUsers(c *gin.Context) {
+	h.bulkSetActive(c, false)
+}
+
+func (h *UserHandler) bulkSetActive(c *gin.Context, isActive bool) {
+	var request model.BulkUserStatus
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	result, err := h.userService.SetUsersActive(c.Request.Context(), adminID.(int), request.UserIDs, isActive)
+	if err != nil {
+		h.logger.Error("failed to bulk update users", zap.Error(err), zap.Bool("is_active", isActive))
+		apierror.Respond(c, err, "Failed to update users")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ExportUsers handles exporting the user list as CSV (admin only). Users can be filtered
+// by creation date (created_from inclusive, created_to exclusive; RFC 3339 or YYYY-MM-DD),
+// system or custom role and active status.
+// GET /api/v1/admin/users/export
+//
+// @Summary Export the user list as CSV (admin only)
+// @Tags admin
+// @Produce text/csv
+// @Param created_from query string false "created_from"
+// @Param created_to query string false "created_to"
+// @Param role query string false "role"
+// @Param is_active query boolean false "is_active"
+// @Success 200 {string} string
+// @Failure 400 {object} apierror.Body
+// @Failure 500 {object} apierror.Body
+// @Security BearerAuth
+// @Router /api/v1/admin/users/export [get]
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	var filter model.UserExportFilter
+	var err error
+
+	if filter.CreatedFrom, err = parseTimeQuery(c.Query("created_from")); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid created_from; use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	if filter.CreatedTo, err = parseTimeQuery(c.Query("created_to")); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid created_to; use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	if role := c.Query("role"); role != "" {
+		filter.Role = &role
+	}
+	if activeStr := c.Query("is_active"); activeStr != "" {
+		isActive, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid is_active; use true or false")
+			return
+		}
+		filter.IsActive = &isActive
+	}
+
+	users, err := h.userService.ExportUsers(c.Request.Context(), &filter)
+	if err != nil {
+		h.logger.Error("failed to export users", zap.Error(err))
+		apierror.Respond(c, err, "Failed to export users")
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.csv", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "username", "email", "role", "custom_roles", "is_active", "last_login", "created_at"})
+	for _, user := range users {
+		lastLogin := ""
+		if user.LastLogin != nil {
+			lastLogin = user.LastLogin.Format(time.RFC3339)
+		}
+		w.Write([]string{
+			strconv.Itoa(user.ID),
+			user.Username,
+			user.Email,
+			user.Role,
+			strings.Join(user.Roles, ";"),
+			strconv.FormatBool(user.IsActive),
+			lastLogin,
+			user.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		h.logger.Error("failed to write users export", zap.Error(err))
+	}
+}
+
+// parseTimeQuery parses an optional RFC 3339 time or YYYY-MM-DD date
+func parseTimeQuery(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
 
 // BatchGetServiceUsers handles fetching multiple users by ID for service-to-service communication
@@ -176,21 +412,21 @@ func (h *UserHandler) BatchGetServiceUsers(c *gin.Context) {
 	// Verify service key
 	serviceKey := c.GetHeader("X-Service-Key")
 	if serviceKey == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Service key required"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Service key required")
 		return
 	}
 
 	// Validate service key
 	valid, err := h.userService.ValidateServiceKey(c.Request.Context(), "strategy-service", serviceKey)
 	if err != nil || !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service key"})
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid service key")
 		return
 	}
 
 	// Parse ids from query parameter
 	idsParam := c.Query("ids")
 	if idsParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User IDs required"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "User IDs required")
 		return
 	}
 
@@ -211,7 +447,7 @@ func (h *UserHandler) BatchGetServiceUsers(c *gin.Context) {
 	}
 
 	if len(ids) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid user IDs provided"})
+		utils.SendErrorResponse(c, http.StatusBadRequest, "No valid user IDs provided")
 		return
 	}
 
@@ -219,7 +455,7 @@ func (h *UserHandler) BatchGetServiceUsers(c *gin.Context) {
 	users, err := h.userService.GetUsersByIDs(c.Request.Context(), ids)
 	if err != nil {
 		h.logger.Error("failed to get users batch", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+		apierror.Respond(c, err, "Failed to get users")
 		return
 	}
 
@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +22,7 @@ func AuthMiddleware(authService *service.AuthService, logger *zap.Logger) gin.Ha
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header required")
 			c.Abort()
 			return
 		}
@@ -25,24 +30,77 @@ func AuthMiddleware(authService *service.AuthService, logger *zap.Logger) gin.Ha
 		// Check if it's a Bearer token
 		headerParts := strings.Split(authHeader, " ")
 		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid authorization format")
 			c.Abort()
 			return
 		}
 
 		// Validate the token
 		tokenString := headerParts[1]
-		userID, role, err := authService.ValidateToken(tokenString)
+		claims, err := authService.ParseAccessToken(tokenString)
 		if err != nil {
 			logger.Debug("token validation failed", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			apierror.Send(c, http.StatusUnauthorized, errcode.CodeInvalidToken, "Invalid or expired token")
+			c.Abort()
+			return
+		}
+
+		// Tokens stop working once the user logs out everywhere or is deactivated
+		if err := authService.CheckRevoked(c.Request.Context(), claims); err != nil {
+			if errors.Is(err, errcode.ErrTokenRevoked) {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeTokenRevoked, "Token has been revoked")
+			} else {
+				logger.Error("failed to check token revocation", zap.Error(err))
+				apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate token")
+			}
+			c.Abort()
+			return
+		}
+
+		// Impersonation tokens stop working as soon as their session is revoked
+		if err := authService.CheckImpersonation(c.Request.Context(), claims); err != nil {
+			if errors.Is(err, service.ErrImpersonationEnded) {
+				apierror.Send(c, http.StatusUnauthorized, errcode.CodeImpersonationEnded, "Impersonation session has ended")
+			} else {
+				logger.Error("failed to check impersonation session", zap.Error(err))
+				apierror.Send(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate token")
+			}
+			c.Abort()
+			return
+		}
+
+		// Set user ID, role and permissions in context
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("userPermissions", claims.Permissions)
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonatorID", claims.ImpersonatorID)
+			c.Set("impersonationSessionID", claims.ImpersonationSessionID)
+		}
+		c.Next()
+
+		if claims.ImpersonatorID != 0 {
+			logger.Info("impersonated request",
+				zap.Int("impersonationSessionID", claims.ImpersonationSessionID),
+				zap.Int("impersonatorID", claims.ImpersonatorID),
+				zap.Int("userID", claims.UserID),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("status", c.Writer.Status()))
+		}
+	}
+}
+
+// ForbidImpersonation rejects requests made with impersonation tokens, for account security
+// actions support staff must not take on a user's behalf
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("impersonatorID"); impersonated {
+			apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Not allowed while impersonating a user")
 			c.Abort()
 			return
 		}
 
-		// Set user ID and role in context
-		c.Set("userID", userID)
-		c.Set("userRole", role)
 		c.Next()
 	}
 }
@@ -53,7 +111,7 @@ func RequireRole(requiredRoles ...string) gin.HandlerFunc {
 		// Get role from context (set by AuthMiddleware)
 		userRole, exists := c.Get("userRole")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
 			c.Abort()
 			return
 		}
@@ -68,7 +126,7 @@ func RequireRole(requiredRoles ...string) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
 			c.Abort()
 			return
 		}
@@ -77,27 +135,87 @@ func RequireRole(requiredRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// ServiceAuthMiddleware creates middleware for service-to-service authentication
-func ServiceAuthMiddleware(expectedKey string, logger *zap.Logger) gin.HandlerFunc {
+// RequirePermission middleware checks that the token grants at least one of the given permissions
+func RequirePermission(requiredPermissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get permissions from context (set by AuthMiddleware)
+		value, exists := c.Get("userPermissions")
+		if !exists {
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		granted, _ := value.([]string)
+		for _, required := range requiredPermissions {
+			if HasPermission(granted, required) {
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Send(c, http.StatusForbidden, apierror.CodeForbidden, "Insufficient permissions")
+		c.Abort()
+	}
+}
+
+// HasPermission reports whether the granted set covers the required permission.
+// "*" grants everything and "resource:*" grants every action on a resource.
+func HasPermission(granted []string, required string) bool {
+	resource := required
+	if i := strings.Index(required, ":"); i >= 0 {
+		resource = required[:i]
+	}
+
+	for _, p := range granted {
+		if p == "*" || p == required || p == resource+":*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServiceAuthMiddleware creates middleware for service-to-service authentication. A call
+// is accepted with the statically configured key, or with a key issued to the calling
+// service, whose name is then set as "serviceName" in the context. An empty expectedKey
+// accepts issued keys only.
+func ServiceAuthMiddleware(
+	expectedKey string,
+	credentialService *service.ServiceCredentialService,
+	logger *zap.Logger,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get service key from header
 		serviceKey := c.GetHeader("X-Service-Key")
 		if serviceKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service key required"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Service key required")
 			c.Abort()
 			return
 		}
 
-		// Simple comparison for service key
-		if serviceKey != expectedKey {
+		if expectedKey != "" && subtle.ConstantTimeCompare([]byte(serviceKey), []byte(expectedKey)) == 1 {
+			c.Next()
+			return
+		}
+
+		authenticated, err := credentialService.Authenticate(c.Request.Context(), serviceKey)
+		if err != nil {
+			logger.Error("failed to authenticate service key", zap.Error(err))
+			apierror.Send(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Failed to authenticate service key")
+			c.Abort()
+			return
+		}
+		if authenticated == nil {
 			logger.Warn("Invalid service key in request",
 				zap.String("IP", c.ClientIP()),
 				zap.String("Path", c.Request.URL.Path))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service key"})
+			apierror.Send(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid service key")
 			c.Abort()
 			return
 		}
 
+		c.Set("serviceName", authenticated.ServiceName)
 		c.Next()
 	}
 }
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+)
+
+// Activity types shown in a user's activity feed
+const (
+	ActivityProfileUpdated    = "profile_updated"
+	ActivityStrategyCreated   = "strategy_created"
+	ActivityStrategyUpdated   = "strategy_updated"
+	ActivityStrategyDeleted   = "strategy_deleted"
+	ActivityListingCreated    = "listing_created"
+	ActivityStrategyPurchased = "strategy_purchased"
+	ActivityStrategySold      = "strategy_sold"
+	ActivityBacktestCompleted = "backtest_completed"
+	ActivityBacktestFailed    = "backtest_failed"
+)
+
+// ActivityTypes lists every activity type, in the order they are documented
+var ActivityTypes = []string{
+	ActivityProfileUpdated,
+	ActivityStrategyCreated,
+	ActivityStrategyUpdated,
+	ActivityStrategyDeleted,
+	ActivityListingCreated,
+	ActivityStrategyPurchased,
+	ActivityStrategySold,
+	ActivityBacktestCompleted,
+	ActivityBacktestFailed,
+}
+
+// EventReviewCreated is the event the strategy service publishes when a listing is
+// reviewed. It notifies the seller but has no entry in the activity feed.
+const EventReviewCreated = "review_created"
+
+// Activity is an entry in a user's activity feed
+type Activity struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Type       string    `json:"type" db:"type"`
+	Summary    string    `json:"summary" db:"summary"` // e.g. "You created strategy Golden Cross"
+	EntityType *string   `json:"entity_type,omitempty" db:"entity_type"`
+	EntityID   *int      `json:"entity_id,omitempty" db:"entity_id"`
+	EntityName *string   `json:"entity_name,omitempty" db:"entity_name"`
+	ActorID    *int      `json:"actor_id,omitempty" db:"actor_id"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+}
+
+// ActivityCreate represents data for adding an activity entry
+type ActivityCreate struct {
+	UserID     int
+	Type       string
+	Summary    string
+	EntityType string
+	EntityID   int
+	EntityName string
+	ActorID    int
+	EventKey   string // Source Kafka message, used to skip redelivered events
+	OccurredAt time.Time
+}
+
+// ActivityEvent is the payload consumed from the user, strategy, marketplace and backtest
+// event topics. Producers publish one event per action; the consumer decides whose feeds
+// it appears in.
+type ActivityEvent struct {
+	EventType  string `json:"event_type"` // e.g. strategy_created, strategy_purchased, backtest_completed
+	UserID     int    `json:"user_id"`    // User who performed the action
+	OwnerID    int    `json:"owner_id,omitempty"`
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   int    `json:"entity_id,omitempty"`
+	EntityName string `json:"entity_name,omitempty"`
+	BuyerIDs   []int  `json:"buyer_ids,omitempty"` // Buyers of the strategy, for new versions
+	Timestamp  string `json:"timestamp"`           // RFC 3339
+}
@@ -6,8 +6,9 @@ import (
 
 // UserLogin represents data needed for user login
 type UserLogin struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email         string `json:"email" binding:"required,email"`
+	Password      string `json:"password" binding:"required"`
+	TwoFactorCode string `json:"two_factor_code,omitempty"` // TOTP or recovery code, required when 2FA is enabled
 }
 
 // UserChangePassword represents data for changing password
@@ -35,7 +36,142 @@ type TokenResponse struct {
 	User         User      `json:"user"`
 }
 
+// AccessClaims represents the validated claims of an access token
+type AccessClaims struct {
+	UserID      int      `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	IssuedAt    int64    `json:"issued_at"`
+	IssuedAtMs  int64    `json:"-"` // Revocation checks need sub-second precision
+
+	// Set on impersonation tokens: the admin acting as the user and their session
+	ImpersonatorID         int `json:"impersonator_id,omitempty"`
+	ImpersonationSessionID int `json:"impersonation_session_id,omitempty"`
+}
+
 // RefreshRequest represents a request to refresh an access token
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+// TwoFactorSettings represents a user's stored TOTP configuration
+type TwoFactorSettings struct {
+	UserID       int        `db:"user_id"`
+	Secret       string     `db:"secret"`
+	IsEnabled    bool       `db:"is_enabled"`
+	LastUsedStep int64      `db:"last_used_step"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// RecoveryCode represents a stored (hashed) 2FA recovery code
+type RecoveryCode struct {
+	ID       int    `db:"id"`
+	CodeHash string `db:"code_hash"`
+}
+
+// TwoFactorEnrollment is returned when a user starts 2FA enrollment
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"` // Encode as a QR code for authenticator apps
+}
+
+// TwoFactorStatus describes whether 2FA is enabled for a user
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Pending                bool       `json:"pending"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RemainingRecoveryCodes int        `json:"remaining_recovery_codes"`
+}
+
+// TwoFactorVerifyRequest represents a request to confirm 2FA enrollment
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorVerifyResponse is returned once 2FA is enabled
+type TwoFactorVerifyResponse struct {
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorDisableRequest represents a request to turn 2FA off
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+// ImpersonationRequest represents a request to impersonate a user
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // Recorded with the session, e.g. a support ticket
+}
+
+// ImpersonationSession represents a support session acting as a user
+type ImpersonationSession struct {
+	ID            int        `json:"id" db:"id"`
+	AdminID       int        `json:"admin_id" db:"admin_id"`
+	AdminUsername string     `json:"admin_username" db:"admin_username"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	Username      string     `json:"username" db:"username"`
+	Reason        string     `json:"reason" db:"reason"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy     *int       `json:"revoked_by,omitempty" db:"revoked_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ImpersonationResponse is returned when an impersonation session starts. The token has
+// no refresh token and carries the impersonator in its claims.
+type ImpersonationResponse struct {
+	AccessToken   string               `json:"access_token"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	Impersonation bool                 `json:"impersonation"`
+	Session       ImpersonationSession `json:"session"`
+	User          User                 `json:"user"`
+}
+
+// LoginDeviceCheck reports whether a successful login came from an IP or device new for the user
+type LoginDeviceCheck struct {
+	NewIP      bool `db:"new_ip"`
+	NewDevice  bool `db:"new_device"`
+	FirstLogin bool `db:"first_login"`
+}
+
+// LoginLockout describes an account temporarily locked after repeated failed logins
+type LoginLockout struct {
+	UserID         int       `json:"user_id"`
+	Email          string    `json:"email"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LastIP         string    `json:"last_ip,omitempty"`
+	LockedAt       time.Time `json:"locked_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// JSONWebKey is the public half of a key access tokens are signed with (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// Modulus and exponent of an RSA key, base64url encoded without padding
+	N string `json:"n"`
+	E string `json:"e"`
+}
+
+// JSONWebKeySet is served at /.well-known/jwks.json for services to verify access
+// tokens locally
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// TokenRevocation is broadcast when all tokens of a user are revoked, e.g. on logout from
+// all devices or deactivation. Tokens of the user issued before RevokedBeforeMs are
+// rejected; ExpiresAt is when the last of them would have expired anyway. RevokedBefore
+// holds the same time in seconds.
+type TokenRevocation struct {
+	UserID          int    `json:"user_id"`
+	RevokedBefore   int64  `json:"revoked_before"`
+	RevokedBeforeMs int64  `json:"revoked_before_ms"`
+	ExpiresAt       int64  `json:"expires_at"`
+	Reason          string `json:"reason"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// Notification types sent to followers
+const (
+	NotificationFollowedListing = "followed_seller_listing"
+	NotificationVersionUpdate   = "strategy_version_published"
+)
+
+// FollowCounts holds how many users follow a user and how many they follow
+type FollowCounts struct {
+	FollowersCount int `json:"followers_count" db:"followers_count"`
+	FollowingCount int `json:"following_count" db:"following_count"`
+}
+
+// FollowedUser is an entry in a user's followers or following list
+type FollowedUser struct {
+	UserID          int       `json:"user_id" db:"user_id"`
+	Username        string    `json:"username" db:"username"`
+	DisplayName     string    `json:"display_name" db:"display_name"`
+	ProfilePhotoURL string    `json:"profile_photo_url,omitempty" db:"profile_photo_url"`
+	FollowedAt      time.Time `json:"followed_at" db:"followed_at"`
+}
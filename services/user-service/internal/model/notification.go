@@ -1,28 +1,119 @@
 package model
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 )
 
+// Notification categories, each with its own delivery preference
+const (
+	NotificationCategoryBacktest    = "backtest"
+	NotificationCategoryMarketplace = "marketplace"
+	NotificationCategorySystem      = "system"
+	NotificationCategorySecurity    = "security"
+)
+
+// NotificationCategories lists the categories in display order
+var NotificationCategories = []string{
+	NotificationCategoryBacktest,
+	NotificationCategoryMarketplace,
+	NotificationCategorySystem,
+	NotificationCategorySecurity,
+}
+
+// IsNotificationCategory reports whether a category exists
+func IsNotificationCategory(category string) bool {
+	for _, known := range NotificationCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification delivery preferences
+const (
+	NotificationDeliveryInstant = "instant" // Stored and pushed right away
+	NotificationDeliveryDigest  = "digest"  // Stored silently and collapsed into the daily digest
+	NotificationDeliveryOff     = "off"     // Dropped
+)
+
+// NotificationTypeDigest is the type of daily digest notifications
+const NotificationTypeDigest = "digest"
+
+// marketplaceTypePrefixes are the notification type prefixes of marketplace events
+var marketplaceTypePrefixes = []string{
+	"strategy_", "purchase", "subscription", "refund", "review", "listing", "coupon", "report", "payout",
+}
+
+// securityTypePrefixes are the notification type prefixes of account security events
+var securityTypePrefixes = []string{
+	"security", "account_", "login", "password", "two_factor", "lockout", "impersonation",
+}
+
+// NotificationCategoryForType returns the category of a notification type, for events
+// published without one
+func NotificationCategoryForType(notificationType string) string {
+	if strings.HasPrefix(notificationType, "backtest") {
+		return NotificationCategoryBacktest
+	}
+	for _, prefix := range marketplaceTypePrefixes {
+		if strings.HasPrefix(notificationType, prefix) {
+			return NotificationCategoryMarketplace
+		}
+	}
+	for _, prefix := range securityTypePrefixes {
+		if strings.HasPrefix(notificationType, prefix) {
+			return NotificationCategorySecurity
+		}
+	}
+	return NotificationCategorySystem
+}
+
 // Notification represents a user notification
 type Notification struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	Type      string    `json:"type" db:"type"`
-	Title     string    `json:"title" db:"title"`
-	Message   string    `json:"message" db:"message"`
-	IsRead    bool      `json:"is_read" db:"is_read"`
-	Link      string    `json:"link,omitempty" db:"link"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Type       string     `json:"type" db:"type"`
+	Category   string     `json:"category" db:"category"`
+	Title      string     `json:"title" db:"title"`
+	Message    string     `json:"message" db:"message"`
+	IsRead     bool       `json:"is_read" db:"is_read"`
+	Link       string     `json:"link,omitempty" db:"link"`
+	BatchCount int        `json:"batch_count" db:"batch_count"` // Events merged into this notification
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" db:"updated_at"` // When the latest merged event arrived
 }
 
-// NotificationCreate represents data for creating a notification
+// NotificationCreate represents data for creating a notification. Category defaults to
+// the category of the type.
 type NotificationCreate struct {
-	UserID  int    `json:"user_id" binding:"required"`
-	Type    string `json:"type" binding:"required"`
-	Title   string `json:"title" binding:"required"`
-	Message string `json:"message" binding:"required"`
-	Link    string `json:"link,omitempty"`
+	UserID   int    `json:"user_id" binding:"required"`
+	Type     string `json:"type" binding:"required,max=50"`
+	Category string `json:"category,omitempty" binding:"omitempty,oneof=backtest marketplace system security"`
+	Title    string `json:"title" binding:"required,max=100"`
+	Message  string `json:"message" binding:"required"`
+	Link     string `json:"link,omitempty"`
+}
+
+// NotificationPreference is a user's delivery setting of a notification category
+type NotificationPreference struct {
+	Category string `json:"category" db:"category" binding:"required,oneof=backtest marketplace system security"`
+	Delivery string `json:"delivery" db:"delivery" binding:"required,oneof=instant digest off"`
+}
+
+// NotificationPreferencesUpdate represents data for updating notification preferences.
+// Categories left out keep their setting.
+type NotificationPreferencesUpdate struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// NotificationDigest is a digest notification created for a user
+type NotificationDigest struct {
+	UserID            int `db:"user_id"`
+	DigestID          int `db:"digest_id"`
+	NotificationCount int `db:"notification_count"`
 }
 
 // NotificationListResponse represents a paginated list of notifications with metadata
@@ -42,3 +133,95 @@ type NotificationMarkResponse struct {
 	Success     bool `json:"success"`
 	MarkedCount int  `json:"marked_count"`
 }
+
+// NotificationEvent is the payload consumed from the notifications Kafka topic.
+// Producers publish one event per recipient; purchase, review and backtest notifications
+// are built from the marketplace and backtest event topics instead.
+type NotificationEvent struct {
+	UserID  int    `json:"user_id"`
+	Type    string `json:"type"` // e.g. backtest_complete, purchase, review_received
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Link    string `json:"link,omitempty"`
+	// Category is optional; without it the category is derived from the type
+	Category string `json:"category,omitempty"`
+}
+
+// NotificationPush is the message written to connected websocket clients
+type NotificationPush struct {
+	Event        string        `json:"event"`
+	Notification *Notification `json:"notification,omitempty"`
+	UnreadCount  int           `json:"unread_count"`
+}
+
+// Notification broadcast audiences
+const (
+	BroadcastAudienceAll               = "all"                // Every active user
+	BroadcastAudienceRole              = "role"               // Users with a system or custom role
+	BroadcastAudienceListingPurchasers = "listing_purchasers" // Buyers with access to a marketplace listing
+	BroadcastAudienceStrategyUsers     = "strategy_users"     // Owner, buyers and users a strategy is shared with
+)
+
+// Notification broadcast statuses
+const (
+	BroadcastStatusScheduled = "scheduled"
+	BroadcastStatusRunning   = "running"
+	BroadcastStatusCompleted = "completed"
+	BroadcastStatusFailed    = "failed"
+	BroadcastStatusCancelled = "cancelled"
+)
+
+// NotificationTypeBroadcast is the default type of broadcast notifications
+const NotificationTypeBroadcast = "broadcast"
+
+// NotificationBroadcastCreate represents data for broadcasting a notification to an
+// audience. Title and message may use {{username}}, {{user_id}} and the keys of
+// Variables as placeholders. Type defaults to "broadcast" and category to the category of
+// the type; broadcasts without ScheduledAt are sent right away.
+type NotificationBroadcastCreate struct {
+	Type             string            `json:"type,omitempty" binding:"omitempty,max=50"`
+	Category         string            `json:"category,omitempty" binding:"omitempty,oneof=backtest marketplace system security"`
+	Title            string            `json:"title" binding:"required,max=100"`
+	Message          string            `json:"message" binding:"required"`
+	Link             string            `json:"link,omitempty" binding:"omitempty,max=255"`
+	Audience         string            `json:"audience" binding:"required,oneof=all role listing_purchasers strategy_users"`
+	AudienceRole     string            `json:"audience_role,omitempty" binding:"omitempty,max=50"` // Required for the "role" audience
+	AudienceTargetID int               `json:"audience_target_id,omitempty"`                       // Listing ID or strategy ID of those audiences
+	Variables        map[string]string `json:"variables,omitempty"`
+	ScheduledAt      *time.Time        `json:"scheduled_at,omitempty"`
+}
+
+// NotificationBroadcast is a notification sent to an audience by the broadcast worker,
+// with the progress of sending it
+type NotificationBroadcast struct {
+	ID               int             `json:"id" db:"id"`
+	CreatedBy        int             `json:"created_by" db:"created_by"`
+	Type             string          `json:"type" db:"type"`
+	Category         string          `json:"category" db:"category"`
+	Title            string          `json:"title" db:"title"`
+	Message          string          `json:"message" db:"message"`
+	Link             *string         `json:"link,omitempty" db:"link"`
+	Audience         string          `json:"audience" db:"audience"`
+	AudienceRole     *string         `json:"audience_role,omitempty" db:"audience_role"`
+	AudienceTargetID *int            `json:"audience_target_id,omitempty" db:"audience_target_id"`
+	Variables        json.RawMessage `json:"variables" db:"variables"`
+	Status           string          `json:"status" db:"status"`
+	ScheduledAt      time.Time       `json:"scheduled_at" db:"scheduled_at"`
+	StartedAt        *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt      *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	TotalCount       int             `json:"total_count" db:"total_count"`
+	SentCount        int             `json:"sent_count" db:"sent_count"`
+	SkippedCount     int             `json:"skipped_count" db:"skipped_count"` // Recipients who turned the category off
+	FailedCount      int             `json:"failed_count" db:"failed_count"`
+	Progress         float64         `json:"progress" db:"-"` // Share of recipients processed, from 0 to 1
+	LastUserID       int             `json:"-" db:"last_user_id"`
+	Error            *string         `json:"error,omitempty" db:"error"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// BroadcastRecipient is a user a broadcast is sent to
+type BroadcastRecipient struct {
+	ID       int    `db:"id"`
+	Username string `db:"username"`
+}
@@ -2,22 +2,23 @@ package model
 
 import (
 	"encoding/json"
+	"time"
 )
 
 // UserPreferences represents user preferences
 type UserPreferences struct {
 	Theme                string          `json:"theme,omitempty" db:"theme"`
 	DefaultTimeframe     string          `json:"default_timeframe,omitempty" db:"default_timeframe"`
-	ChartPreferences     json.RawMessage `json:"chart_preferences,omitempty" db:"chart_preferences"`
-	NotificationSettings json.RawMessage `json:"notification_settings,omitempty" db:"notification_settings"`
+	ChartPreferences     json.RawMessage `json:"chart_preferences,omitempty" db:"chart_preferences" swaggertype:"object"`
+	NotificationSettings json.RawMessage `json:"notification_settings,omitempty" db:"notification_settings" swaggertype:"object"`
 }
 
 // PreferencesUpdate represents data for updating user preferences
 type PreferencesUpdate struct {
 	Theme                *string         `json:"theme,omitempty"`
 	DefaultTimeframe     *string         `json:"default_timeframe,omitempty"`
-	ChartPreferences     json.RawMessage `json:"chart_preferences,omitempty"`
-	NotificationSettings json.RawMessage `json:"notification_settings,omitempty"`
+	ChartPreferences     json.RawMessage `json:"chart_preferences,omitempty" swaggertype:"object"`
+	NotificationSettings json.RawMessage `json:"notification_settings,omitempty" swaggertype:"object"`
 }
 
 // ChartPreference represents chart-specific preferences
@@ -37,3 +38,28 @@ type NotificationSetting struct {
 	SystemUpdates      bool `json:"system_updates"`
 	MarketingEmails    bool `json:"marketing_emails"`
 }
+
+// Workspace is a named JSON layout of the UI, such as chart configs, watchlists or the
+// builder's panel state, synced across a user's devices. Version increases with every
+// save and is sent as the ETag.
+type Workspace struct {
+	Name      string          `json:"name" db:"name"`
+	Layout    json.RawMessage `json:"layout,omitempty" db:"layout" swaggertype:"object"`
+	Version   int             `json:"version" db:"version"`
+	SizeBytes int             `json:"size_bytes" db:"size_bytes"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// WorkspaceUpdate represents data for saving a workspace
+type WorkspaceUpdate struct {
+	Layout json.RawMessage `json:"layout" binding:"required" swaggertype:"object"`
+}
+
+// WorkspaceList lists a user's workspaces, without their layouts, and the quota they count against
+type WorkspaceList struct {
+	Workspaces    []Workspace `json:"workspaces"`
+	UsedBytes     int         `json:"used_bytes"`
+	MaxBytes      int         `json:"max_bytes"`
+	MaxWorkspaces int         `json:"max_workspaces"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // ProfilePhotoUpload represents a profile photo upload request
 type ProfilePhotoUpload struct {
 	UserID int    `json:"user_id" binding:"required"`
@@ -8,14 +10,56 @@ type ProfilePhotoUpload struct {
 
 // ProfilePhotoResponse represents a response after uploading a profile photo
 type ProfilePhotoResponse struct {
-	URL        string      `json:"url"`
-	Thumbnails []Thumbnail `json:"thumbnails,omitempty"`
+	URL      string         `json:"url"`
+	Variants []ImageVariant `json:"variants,omitempty"`
+}
+
+// ImageVariant represents a processed rendition of an uploaded image, e.g. "thumb", "medium" or "original"
+type ImageVariant struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// PublicProfile is what anyone can see of a user, e.g. buyers browsing a seller's catalog
+type PublicProfile struct {
+	ID              int               `json:"id"`
+	Username        string            `json:"username"`
+	DisplayName     string            `json:"display_name"`
+	ProfilePhotoURL string            `json:"profile_photo_url,omitempty"`
+	Bio             string            `json:"bio"`
+	SocialLinks     map[string]string `json:"social_links"`
+	MemberSince     time.Time         `json:"member_since"`
+	FollowCounts
+	// Stats are left out when the strategy service can't be reached
+	Stats *SellerStats `json:"stats,omitempty"`
+}
+
+// ProfileSearchResult is a public profile found by a search, without its details and counts
+type ProfileSearchResult struct {
+	ID              int    `json:"id" db:"id"`
+	Username        string `json:"username" db:"username"`
+	DisplayName     string `json:"display_name" db:"display_name"`
+	ProfilePhotoURL string `json:"profile_photo_url,omitempty" db:"profile_photo_url"`
+	Bio             string `json:"bio" db:"bio"`
+}
+
+// SellerStats holds the marketplace counts of a user, from the strategy service
+type SellerStats struct {
+	ListingsCount int     `json:"listings_count"`
+	SalesCount    int     `json:"sales_count"`
+	ReviewsCount  int     `json:"reviews_count"`
+	AverageRating float64 `json:"average_rating"`
 }
 
-// Thumbnail represents a generated thumbnail
-type Thumbnail struct {
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
+// ProfileDetailsUpdate represents an update of the public profile details. Omitted fields
+// are left unchanged; an empty display name or bio clears it and social links replace the
+// current ones. Social links are keyed by network: website, twitter, github, linkedin,
+// youtube or telegram.
+type ProfileDetailsUpdate struct {
+	DisplayName *string           `json:"display_name,omitempty" binding:"omitempty,max=50"`
+	Bio         *string           `json:"bio,omitempty" binding:"omitempty,max=500"`
+	SocialLinks map[string]string `json:"social_links,omitempty" binding:"omitempty,max=6,dive,keys,oneof=website twitter github linkedin youtube telegram,endkeys,url,max=255"`
 }
@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// Reasons of referral credit entries
+const (
+	ReferralCreditReferrerReward = "referrer_reward"
+	ReferralCreditRefereeReward  = "referee_reward"
+	ReferralCreditRedemption     = "redemption"
+	ReferralCreditReversal       = "reversal"
+)
+
+// Fraud indicators flagged in the referral fraud report
+const (
+	ReferralFlagSharedIP   = "shared_signup_ip"   // Several referees signed up from one IP
+	ReferralFlagReferrerIP = "referrer_signup_ip" // Referees signed up from an IP the referrer uses
+	ReferralFlagDormant    = "dormant_referees"   // Most referees never came back after signing up
+	ReferralFlagBurst      = "signup_burst"       // Many referrals on a single day
+)
+
+// ReferralStats holds how many users a user referred and the credit they earned with it
+type ReferralStats struct {
+	ReferralsCount    int     `json:"referrals_count" db:"referrals_count"`
+	ActiveReferrals   int     `json:"active_referrals" db:"active_referrals"`
+	RewardedReferrals int     `json:"rewarded_referrals" db:"rewarded_referrals"`
+	CreditEarned      float64 `json:"credit_earned" db:"credit_earned"`
+	CreditBalance     float64 `json:"credit_balance" db:"credit_balance"`
+}
+
+// ReferralSummary is a user's referral code with their referral statistics
+type ReferralSummary struct {
+	Code     string        `json:"code"`
+	Currency string        `json:"currency"` // Currency of the credit amounts
+	Stats    ReferralStats `json:"stats"`
+	// Rewards credited for each new user registering with the code
+	ReferrerCredit float64 `json:"referrer_credit"`
+	RefereeCredit  float64 `json:"referee_credit"`
+}
+
+// Referral is a user registering with another user's code
+type Referral struct {
+	ID         int `db:"referral_id"`
+	ReferrerID int `db:"referrer_id"`
+}
+
+// ReferralCredit is a user's referral credit balance
+type ReferralCredit struct {
+	UserID   int     `json:"user_id"`
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency"`
+}
+
+// ReferralCreditRedeem represents a request of another service to spend a user's credit
+type ReferralCreditRedeem struct {
+	UserID    int     `json:"user_id" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Currency  string  `json:"currency" binding:"required,len=3"`
+	Reference string  `json:"reference" binding:"max=100"` // e.g. the purchase paid for
+}
+
+// ReferralRedemption is credit spent by a redemption. Amount is 0, and there's no
+// redemption to reverse, when the user had no credit.
+type ReferralRedemption struct {
+	RedemptionID int     `json:"redemption_id" db:"redemption_id"`
+	Amount       float64 `json:"amount" db:"amount"`
+	Currency     string  `json:"currency" db:"-"`
+}
+
+// ReferralFraudEntry holds the fraud indicators of a referrer's recent referrals
+type ReferralFraudEntry struct {
+	ReferrerID          int       `json:"referrer_id" db:"referrer_id"`
+	Username            string    `json:"username" db:"username"`
+	Email               string    `json:"email" db:"email"`
+	IsActive            bool      `json:"is_active" db:"is_active"`
+	ReferralsCount      int       `json:"referrals_count" db:"referrals_count"`
+	SharedIPReferrals   int       `json:"shared_ip_referrals" db:"shared_ip_referrals"`
+	ReferrerIPReferrals int       `json:"referrer_ip_referrals" db:"referrer_ip_referrals"`
+	DormantReferrals    int       `json:"dormant_referrals" db:"dormant_referrals"`
+	MaxReferralsPerDay  int       `json:"max_referrals_per_day" db:"max_referrals_per_day"`
+	RewardedReferrals   int       `json:"rewarded_referrals" db:"rewarded_referrals"`
+	CreditEarned        float64   `json:"credit_earned" db:"credit_earned"`
+	FirstReferralAt     time.Time `json:"first_referral_at" db:"first_referral_at"`
+	LastReferralAt      time.Time `json:"last_referral_at" db:"last_referral_at"`
+	Flags               []string  `json:"flags" db:"-"`
+}
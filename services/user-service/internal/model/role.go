@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+// Role represents a named set of permissions
+type Role struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	IsSystem    bool       `json:"is_system"`
+	Permissions []string   `json:"permissions"`
+	UserCount   int        `json:"user_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Permission represents a single resource:action permission
+type Permission struct {
+	ID          int     `json:"id" db:"id"`
+	Name        string  `json:"name" db:"name"`
+	Description *string `json:"description,omitempty" db:"description"`
+}
+
+// RoleCreate represents data for creating a role
+type RoleCreate struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleUpdate represents data for updating a role
+type RoleUpdate struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // Replaces the full set when provided
+}
+
+// UserRoleAssignment represents a request to assign a role to a user
+type UserRoleAssignment struct {
+	RoleID int `json:"role_id" binding:"required"`
+}
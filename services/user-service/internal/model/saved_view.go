@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Lists saved views can be applied to
+const (
+	ViewListBacktests   = "backtests"
+	ViewListStrategies  = "strategies"
+	ViewListMarketplace = "marketplace"
+)
+
+// SavedViewFilters are the query parameters of each list a saved view can filter on
+var SavedViewFilters = map[string][]string{
+	ViewListBacktests:   {"search", "status", "tags"},
+	ViewListStrategies:  {"search", "purchased_only", "tags"},
+	ViewListMarketplace: {"search", "currency", "min_price", "max_price", "is_free", "tags", "min_rating", "seller_id"},
+}
+
+// SavedView is a named filter and sort combination of a list, applied with ?view=<id>
+type SavedView struct {
+	ID            int             `json:"id" db:"id"`
+	UserID        int             `json:"user_id" db:"user_id"`
+	ListType      string          `json:"list_type" db:"list_type"`
+	Name          string          `json:"name" db:"name"`
+	Filters       json.RawMessage `json:"filters" db:"filters" swaggertype:"object"`
+	SortBy        *string         `json:"sort_by,omitempty" db:"sort_by"`
+	SortDirection *string         `json:"sort_direction,omitempty" db:"sort_direction"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// SavedViewCreate represents data for saving a view of a list. Filters are the list's
+// query parameters by name, e.g. {"status": "completed", "tags": "btc"}.
+type SavedViewCreate struct {
+	ListType      string            `json:"list_type" binding:"required,oneof=backtests strategies marketplace"`
+	Name          string            `json:"name" binding:"required,max=100"`
+	Filters       map[string]string `json:"filters,omitempty" binding:"max=20,dive,max=200"`
+	SortBy        string            `json:"sort_by,omitempty" binding:"max=50"`
+	SortDirection string            `json:"sort_direction,omitempty" binding:"omitempty,oneof=asc desc ASC DESC"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Statuses of service credentials
+const (
+	ServiceCredentialActive   = "active"
+	ServiceCredentialExpiring = "expiring"
+	ServiceCredentialExpired  = "expired"
+	ServiceCredentialRevoked  = "revoked"
+)
+
+// ServiceCredential is a key an internal service authenticates with. The key itself is
+// only returned when it is issued.
+type ServiceCredential struct {
+	ID          int        `json:"id" db:"id"`
+	ServiceName string     `json:"service_name" db:"service_name"`
+	KeyPrefix   string     `json:"key_prefix" db:"key_prefix"` // Identifies the key without revealing it
+	Description *string    `json:"description,omitempty" db:"description"`
+	Status      string     `json:"status" db:"status"`
+	CreatedBy   int        `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// ServiceCredentialIssue represents a request to issue a key to a service
+type ServiceCredentialIssue struct {
+	ServiceName   string `json:"service_name" binding:"required,min=2,max=50"`
+	Description   string `json:"description" binding:"max=255"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // 0 never expires
+	// RotationGraceHours rotates the service's keys: its other keys stop working after
+	// this many hours. Unset keeps them.
+	RotationGraceHours *int `json:"rotation_grace_hours,omitempty" binding:"omitempty,min=0,max=720"`
+}
+
+// IssuedServiceCredential is returned once when a key is issued; the key can't be
+// retrieved again
+type IssuedServiceCredential struct {
+	Key        string            `json:"key"`
+	Credential ServiceCredential `json:"credential"`
+}
+
+// ServiceCredentialVerify represents a request of a service to verify a key it was called with
+type ServiceCredentialVerify struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// AuthenticatedService is the service a key belongs to
+type AuthenticatedService struct {
+	CredentialID int        `json:"credential_id" db:"id"`
+	ServiceName  string     `json:"service_name" db:"service_name"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
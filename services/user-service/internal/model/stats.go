@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// UserStats represents platform user KPIs over a time window
+type UserStats struct {
+	Window        string    `json:"window"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	TotalUsers    int64     `json:"total_users" db:"total_users"`
+	ActiveUsers   int64     `json:"active_users" db:"active_users"`
+	NewUsers      int64     `json:"new_users" db:"new_users"`
+	DisabledUsers int64     `json:"disabled_users" db:"disabled_users"`
+	AdminUsers    int64     `json:"admin_users" db:"admin_users"`
+	GeneratedAt   time.Time `json:"generated_at"`
+}
@@ -33,8 +33,8 @@ type UserDetails struct {
 	UnreadNotificationsCount int             `json:"unread_notifications_count" db:"unread_notifications_count"`
 	Theme                    string          `json:"theme,omitempty" db:"theme"`
 	DefaultTimeframe         string          `json:"default_timeframe,omitempty" db:"default_timeframe"`
-	ChartPreferences         json.RawMessage `json:"chart_preferences,omitempty" db:"chart_preferences"`
-	NotificationSettings     json.RawMessage `json:"notification_settings,omitempty" db:"notification_settings"`
+	ChartPreferences         json.RawMessage `json:"chart_preferences,omitempty" db:"chart_preferences" swaggertype:"object"`
+	NotificationSettings     json.RawMessage `json:"notification_settings,omitempty" db:"notification_settings" swaggertype:"object"`
 }
 
 // UserCreate represents data needed to create a new user
@@ -44,6 +44,7 @@ type UserCreate struct {
 	Password        string `json:"password" binding:"required,min=8"`
 	Role            string `json:"role"`
 	ProfilePhotoURL string `json:"profile_photo_url,omitempty"`
+	ReferralCode    string `json:"referral_code,omitempty" binding:"max=16"` // Code of the user who invited them
 }
 
 // UserUpdate represents data for updating user profile
@@ -53,3 +54,51 @@ type UserUpdate struct {
 	ProfilePhotoURL *string `json:"profile_photo_url,omitempty"`
 	IsActive        *bool   `json:"is_active,omitempty"`
 }
+
+// UserSearch holds the filters and sorting of the admin user list
+type UserSearch struct {
+	Search        string // Matched anywhere in the username or email
+	Role          string // System role or assigned custom role
+	IsActive      *bool
+	CreatedAfter  *time.Time
+	SortBy        string // id, username, email, created_at or last_login
+	SortDirection string // ASC or DESC
+}
+
+// BulkUserStatus represents a request to activate or deactivate many users at once
+type BulkUserStatus struct {
+	UserIDs []int `json:"user_ids" binding:"required,min=1,max=1000"`
+}
+
+// BulkUserRole represents a request to assign a role to, or remove it from, many users at once
+type BulkUserRole struct {
+	UserIDs []int `json:"user_ids" binding:"required,min=1,max=1000"`
+	RoleID  int   `json:"role_id" binding:"required"`
+}
+
+// BulkUserResult reports the outcome of a bulk user operation
+type BulkUserResult struct {
+	Requested int   `json:"requested"` // Distinct users in the request
+	Updated   int   `json:"updated"`
+	UserIDs   []int `json:"user_ids"` // Users that were changed; unknown users and users already in the requested state are left out
+}
+
+// UserExportFilter narrows down the users in an export
+type UserExportFilter struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Role        *string // System role or assigned custom role
+	IsActive    *bool
+}
+
+// UserExportRow represents a user in an export
+type UserExportRow struct {
+	ID        int
+	Username  string
+	Email     string
+	Role      string
+	Roles     []string // Assigned custom roles
+	IsActive  bool
+	LastLogin *time.Time
+	CreatedAt time.Time
+}
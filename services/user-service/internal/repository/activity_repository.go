@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ActivityRepository handles database operations for user activity feeds
+type ActivityRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewActivityRepository creates a new activity repository
+func NewActivityRepository(db *sqlx.DB, logger *zap.Logger) *ActivityRepository {
+	return &ActivityRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetActivities retrieves a user's activity with pagination using get_user_activities function.
+// An empty types list returns every type.
+func (r *ActivityRepository) GetActivities(
+	ctx context.Context,
+	userID int,
+	types []string,
+	limit,
+	offset int,
+) ([]model.Activity, error) {
+	query := `SELECT * FROM get_user_activities($1, $2, $3, $4)`
+
+	activities := []model.Activity{}
+	err := r.db.SelectContext(ctx, &activities, query, userID, typesArg(types), limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to get user activities", zap.Error(err))
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// CountActivities counts a user's activity using count_user_activities function
+func (r *ActivityRepository) CountActivities(ctx context.Context, userID int, types []string) (int, error) {
+	query := `SELECT count_user_activities($1, $2)`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, userID, typesArg(types))
+	if err != nil {
+		r.logger.Error("Failed to count user activities", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// AddActivity adds an activity entry using add_user_activity function. It returns false if
+// the event was already stored for the user.
+func (r *ActivityRepository) AddActivity(ctx context.Context, activity *model.ActivityCreate) (bool, error) {
+	query := `SELECT add_user_activity($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	var id sql.NullInt64
+	err := r.db.GetContext(
+		ctx,
+		&id,
+		query,
+		activity.UserID,
+		activity.Type,
+		activity.Summary,
+		nullString(activity.EntityType),
+		nullInt(activity.EntityID),
+		nullString(activity.EntityName),
+		nullInt(activity.ActorID),
+		activity.EventKey,
+		activity.OccurredAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to add user activity", zap.Error(err))
+		return false, err
+	}
+
+	return id.Valid, nil
+}
+
+// typesArg passes an activity type filter as a text array, or NULL for no filter
+func typesArg(types []string) interface{} {
+	if len(types) == 0 {
+		return nil
+	}
+	return types
+}
+
+// nullString stores empty strings as NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// nullInt stores zero IDs as NULL
+func nullInt(value int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value), Valid: value != 0}
+}
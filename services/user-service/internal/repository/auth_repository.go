@@ -146,3 +146,21 @@ func (r *AuthRepository) ValidateServiceKey(ctx context.Context, serviceName, ke
 
 	return valid, nil
 }
+
+// RecordLoginDevice records a successful login's IP and device using record_login_device function
+func (r *AuthRepository) RecordLoginDevice(
+	ctx context.Context,
+	userID int,
+	ipAddress string,
+	userAgent string,
+) (*model.LoginDeviceCheck, error) {
+	query := `SELECT * FROM record_login_device($1, $2, $3)`
+
+	var check model.LoginDeviceCheck
+	if err := r.db.GetContext(ctx, &check, query, userID, ipAddress, userAgent); err != nil {
+		r.logger.Error("failed to record login device", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	return &check, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// BroadcastRepository handles database operations for notification broadcasts
+type BroadcastRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewBroadcastRepository creates a new broadcast repository
+func NewBroadcastRepository(db *sqlx.DB, logger *zap.Logger) *BroadcastRepository {
+	return &BroadcastRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// broadcastRow is the row shape of the notification_broadcasts table. The resolved
+// audience stays in the database; recipients are read in batches instead.
+type broadcastRow struct {
+	model.NotificationBroadcast
+	RecipientIDs pgtype.Int4Array `db:"recipient_ids"`
+}
+
+// Create adds a broadcast using create_notification_broadcast function and returns its ID
+func (r *BroadcastRepository) Create(
+	ctx context.Context,
+	createdBy int,
+	notificationType string,
+	category string,
+	broadcast *model.NotificationBroadcastCreate,
+	variables json.RawMessage,
+) (int, error) {
+	query := `SELECT create_notification_broadcast($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	var targetID *int
+	if broadcast.AudienceTargetID != 0 {
+		targetID = &broadcast.AudienceTargetID
+	}
+
+	var id int
+	err := r.db.GetContext(
+		ctx,
+		&id,
+		query,
+		createdBy,
+		notificationType,
+		category,
+		broadcast.Title,
+		broadcast.Message,
+		broadcast.Link,
+		broadcast.Audience,
+		broadcast.AudienceRole,
+		targetID,
+		variables,
+		broadcast.ScheduledAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create notification broadcast", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetByID retrieves a broadcast using get_notification_broadcast function. It returns nil
+// when the broadcast doesn't exist.
+func (r *BroadcastRepository) GetByID(ctx context.Context, id int) (*model.NotificationBroadcast, error) {
+	query := `SELECT * FROM get_notification_broadcast($1)`
+
+	var row broadcastRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get notification broadcast", zap.Error(err), zap.Int("broadcastID", id))
+		return nil, err
+	}
+
+	return &row.NotificationBroadcast, nil
+}
+
+// List retrieves broadcasts newest first using get_notification_broadcasts function. An
+// empty status returns broadcasts of every status.
+func (r *BroadcastRepository) List(ctx context.Context, status string, limit, offset int) ([]model.NotificationBroadcast, error) {
+	query := `SELECT * FROM get_notification_broadcasts($1, $2, $3)`
+
+	var statusParam interface{}
+	if status != "" {
+		statusParam = status
+	}
+
+	var rows []broadcastRow
+	if err := r.db.SelectContext(ctx, &rows, query, statusParam, limit, offset); err != nil {
+		r.logger.Error("Failed to list notification broadcasts", zap.Error(err))
+		return nil, err
+	}
+
+	broadcasts := make([]model.NotificationBroadcast, 0, len(rows))
+	for _, row := range rows {
+		broadcasts = append(broadcasts, row.NotificationBroadcast)
+	}
+
+	return broadcasts, nil
+}
+
+// Count counts broadcasts using count_notification_broadcasts function
+func (r *BroadcastRepository) Count(ctx context.Context, status string) (int, error) {
+	query := `SELECT count_notification_broadcasts($1)`
+
+	var statusParam interface{}
+	if status != "" {
+		statusParam = status
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, statusParam); err != nil {
+		r.logger.Error("Failed to count notification broadcasts", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Claim moves the next due broadcast, or a running one whose worker stopped reporting
+// progress for staleAfter, to running using claim_notification_broadcast function. It
+// returns nil when there is nothing to send.
+func (r *BroadcastRepository) Claim(ctx context.Context, staleAfter time.Duration) (*model.NotificationBroadcast, error) {
+	query := `SELECT * FROM claim_notification_broadcast($1)`
+
+	var row broadcastRow
+	err := r.db.GetContext(ctx, &row, query, int(staleAfter.Seconds()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to claim notification broadcast", zap.Error(err))
+		return nil, err
+	}
+
+	return &row.NotificationBroadcast, nil
+}
+
+// Start stores the resolved audience of a broadcast using start_notification_broadcast
+// function and returns its recipient count. recipientIDs is nil for audiences resolved
+// from the users table.
+func (r *BroadcastRepository) Start(ctx context.Context, id int, recipientIDs []int) (int, error) {
+	query := `SELECT start_notification_broadcast($1, $2)`
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, query, id, recipientIDs); err != nil {
+		r.logger.Error("Failed to start notification broadcast", zap.Error(err), zap.Int("broadcastID", id))
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetRecipients retrieves the next recipients of a broadcast after a user ID using
+// get_broadcast_recipients function
+func (r *BroadcastRepository) GetRecipients(ctx context.Context, id, afterUserID, limit int) ([]model.BroadcastRecipient, error) {
+	query := `SELECT * FROM get_broadcast_recipients($1, $2, $3)`
+
+	var recipients []model.BroadcastRecipient
+	if err := r.db.SelectContext(ctx, &recipients, query, id, afterUserID, limit); err != nil {
+		r.logger.Error("Failed to get broadcast recipients", zap.Error(err), zap.Int("broadcastID", id))
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// RecordProgress records a sent batch using record_notification_broadcast_progress
+// function and returns the broadcast's current status
+func (r *BroadcastRepository) RecordProgress(ctx context.Context, id, lastUserID, sent, skipped, failed int) (string, error) {
+	query := `SELECT record_notification_broadcast_progress($1, $2, $3, $4, $5)`
+
+	var status string
+	if err := r.db.GetContext(ctx, &status, query, id, lastUserID, sent, skipped, failed); err != nil {
+		r.logger.Error("Failed to record notification broadcast progress", zap.Error(err), zap.Int("broadcastID", id))
+		return "", err
+	}
+
+	return status, nil
+}
+
+// Complete marks a running broadcast completed using complete_notification_broadcast function
+func (r *BroadcastRepository) Complete(ctx context.Context, id int) error {
+	query := `SELECT complete_notification_broadcast($1)`
+
+	var updated bool
+	if err := r.db.GetContext(ctx, &updated, query, id); err != nil {
+		r.logger.Error("Failed to complete notification broadcast", zap.Error(err), zap.Int("broadcastID", id))
+		return err
+	}
+
+	return nil
+}
+
+// Fail marks a running broadcast failed using fail_notification_broadcast function
+func (r *BroadcastRepository) Fail(ctx context.Context, id int, reason string) error {
+	query := `SELECT fail_notification_broadcast($1, $2)`
+
+	var updated bool
+	if err := r.db.GetContext(ctx, &updated, query, id, reason); err != nil {
+		r.logger.Error("Failed to mark notification broadcast failed", zap.Error(err), zap.Int("broadcastID", id))
+		return err
+	}
+
+	return nil
+}
+
+// Cancel cancels a broadcast that hasn't finished using cancel_notification_broadcast
+// function. It reports whether the broadcast was cancelled.
+func (r *BroadcastRepository) Cancel(ctx context.Context, id int) (bool, error) {
+	query := `SELECT cancel_notification_broadcast($1)`
+
+	var cancelled bool
+	if err := r.db.GetContext(ctx, &cancelled, query, id); err != nil {
+		r.logger.Error("Failed to cancel notification broadcast", zap.Error(err), zap.Int("broadcastID", id))
+		return false, err
+	}
+
+	return cancelled, nil
+}
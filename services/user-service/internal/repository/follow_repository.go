@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// FollowRepository handles database operations for users following each other
+type FollowRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewFollowRepository creates a new follow repository
+func NewFollowRepository(db *sqlx.DB, logger *zap.Logger) *FollowRepository {
+	return &FollowRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Follow makes a user follow another using follow_user function. It returns false if
+// they already did.
+func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	query := `SELECT follow_user($1, $2)`
+
+	var followed bool
+	err := r.db.GetContext(ctx, &followed, query, followerID, followeeID)
+	if err != nil {
+		r.logger.Error("Failed to follow user", zap.Error(err),
+			zap.Int("follower_id", followerID),
+			zap.Int("followee_id", followeeID))
+		return false, err
+	}
+
+	return followed, nil
+}
+
+// Unfollow makes a user stop following another using unfollow_user function. It returns
+// false if they weren't following.
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	query := `SELECT unfollow_user($1, $2)`
+
+	var unfollowed bool
+	err := r.db.GetContext(ctx, &unfollowed, query, followerID, followeeID)
+	if err != nil {
+		r.logger.Error("Failed to unfollow user", zap.Error(err),
+			zap.Int("follower_id", followerID),
+			zap.Int("followee_id", followeeID))
+		return false, err
+	}
+
+	return unfollowed, nil
+}
+
+// GetCounts counts a user's followers and the users they follow using get_follow_counts function
+func (r *FollowRepository) GetCounts(ctx context.Context, userID int) (*model.FollowCounts, error) {
+	query := `SELECT * FROM get_follow_counts($1)`
+
+	var counts model.FollowCounts
+	if err := r.db.GetContext(ctx, &counts, query, userID); err != nil {
+		r.logger.Error("Failed to get follow counts", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+// GetFollows retrieves a user's followers, or the users they follow, with pagination using
+// get_follows function
+func (r *FollowRepository) GetFollows(ctx context.Context, userID int, followers bool, limit, offset int) ([]model.FollowedUser, error) {
+	query := `SELECT * FROM get_follows($1, $2, $3, $4)`
+
+	users := []model.FollowedUser{}
+	if err := r.db.SelectContext(ctx, &users, query, userID, followers, limit, offset); err != nil {
+		r.logger.Error("Failed to get follows", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetFollowerIDs retrieves the IDs of a user's followers using get_follower_ids function.
+// A non-nil among limits them to those users.
+func (r *FollowRepository) GetFollowerIDs(ctx context.Context, followeeID int, among []int) ([]int, error) {
+	query := `SELECT follower_id FROM get_follower_ids($1, $2)`
+
+	var amongArg interface{}
+	if among != nil {
+		amongArg = among
+	}
+
+	var followerIDs []int
+	if err := r.db.SelectContext(ctx, &followerIDs, query, followeeID, amongArg); err != nil {
+		r.logger.Error("Failed to get follower IDs", zap.Error(err), zap.Int("followee_id", followeeID))
+		return nil, err
+	}
+
+	return followerIDs, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ImpersonationRepository handles database operations for admin impersonation sessions
+type ImpersonationRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewImpersonationRepository creates a new impersonation repository
+func NewImpersonationRepository(db *sqlx.DB, logger *zap.Logger) *ImpersonationRepository {
+	return &ImpersonationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateSession starts an impersonation session using create_impersonation_session function
+func (r *ImpersonationRepository) CreateSession(
+	ctx context.Context,
+	adminID int,
+	userID int,
+	reason string,
+	ttl time.Duration,
+) (int, error) {
+	query := `SELECT create_impersonation_session($1, $2, $3, $4)`
+
+	var sessionID int
+	err := r.db.GetContext(ctx, &sessionID, query, adminID, userID, reason, int(ttl.Seconds()))
+	if err != nil {
+		r.logger.Error("failed to create impersonation session", zap.Error(err),
+			zap.Int("adminID", adminID), zap.Int("userID", userID))
+		return 0, err
+	}
+
+	return sessionID, nil
+}
+
+// GetSession gets an impersonation session using get_impersonation_session function
+func (r *ImpersonationRepository) GetSession(ctx context.Context, sessionID int) (*model.ImpersonationSession, error) {
+	query := `SELECT * FROM get_impersonation_session($1)`
+
+	var session model.ImpersonationSession
+	if err := r.db.GetContext(ctx, &session, query, sessionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get impersonation session", zap.Error(err), zap.Int("sessionID", sessionID))
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetActiveSessions lists unexpired, unrevoked sessions using get_active_impersonation_sessions function
+func (r *ImpersonationRepository) GetActiveSessions(ctx context.Context) ([]model.ImpersonationSession, error) {
+	query := `SELECT * FROM get_active_impersonation_sessions()`
+
+	sessions := []model.ImpersonationSession{}
+	if err := r.db.SelectContext(ctx, &sessions, query); err != nil {
+		r.logger.Error("failed to get active impersonation sessions", zap.Error(err))
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// IsSessionActive checks a session using is_impersonation_session_active function
+func (r *ImpersonationRepository) IsSessionActive(ctx context.Context, sessionID int) (bool, error) {
+	query := `SELECT is_impersonation_session_active($1)`
+
+	var active bool
+	if err := r.db.GetContext(ctx, &active, query, sessionID); err != nil {
+		r.logger.Error("failed to check impersonation session", zap.Error(err), zap.Int("sessionID", sessionID))
+		return false, err
+	}
+
+	return active, nil
+}
+
+// RevokeSession ends a session using revoke_impersonation_session function
+func (r *ImpersonationRepository) RevokeSession(ctx context.Context, sessionID int, revokedBy int) (bool, error) {
+	query := `SELECT revoke_impersonation_session($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, sessionID, revokedBy); err != nil {
+		r.logger.Error("failed to revoke impersonation session", zap.Error(err), zap.Int("sessionID", sessionID))
+		return false, err
+	}
+
+	return success, nil
+}
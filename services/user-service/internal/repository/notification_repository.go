@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"services/user-service/internal/model"
 
@@ -54,12 +55,18 @@ func (r *NotificationRepository) GetUnreadCount(ctx context.Context, userID int)
 	return count, nil
 }
 
-// GetAllNotifications retrieves all notifications with pagination using get_all_notifications function
-func (r *NotificationRepository) GetAllNotifications(ctx context.Context, userID, limit, offset int) ([]model.Notification, error) {
-	query := `SELECT * FROM get_all_notifications($1, $2, $3)`
+// GetAllNotifications retrieves all notifications with pagination using get_all_notifications
+// function. An empty category returns notifications of every category.
+func (r *NotificationRepository) GetAllNotifications(ctx context.Context, userID, limit, offset int, category string) ([]model.Notification, error) {
+	query := `SELECT * FROM get_all_notifications($1, $2, $3, $4)`
+
+	var categoryParam interface{}
+	if category != "" {
+		categoryParam = category
+	}
 
 	var notifications []model.Notification
-	err := r.db.SelectContext(ctx, &notifications, query, userID, limit, offset)
+	err := r.db.SelectContext(ctx, &notifications, query, userID, limit, offset, categoryParam)
 	if err != nil {
 		r.logger.Error("Failed to get all notifications", zap.Error(err))
 		return nil, err
@@ -96,19 +103,28 @@ func (r *NotificationRepository) MarkAllNotificationsAsRead(ctx context.Context,
 	return count, nil
 }
 
-// AddNotification adds a new notification using add_notification function
+// AddNotification adds a new notification using add_notification function. With a positive
+// batchWindow, an unread notification of the same type received within the window is updated
+// instead. Returns the ID of the added or updated notification.
 func (r *NotificationRepository) AddNotification(
 	ctx context.Context,
 	userID int,
 	notificationType,
+	category,
 	title,
 	message,
 	link string,
+	batchWindow time.Duration,
 ) (int, error) {
-	query := `SELECT add_notification($1, $2::notification_type, $3, $4, $5)`
+	query := `SELECT add_notification($1, $2, $3, $4, $5, $6, $7 * INTERVAL '1 second')`
+
+	var window interface{}
+	if batchWindow > 0 {
+		window = batchWindow.Seconds()
+	}
 
 	var id int
-	err := r.db.GetContext(ctx, &id, query, userID, notificationType, title, message, link)
+	err := r.db.GetContext(ctx, &id, query, userID, notificationType, category, title, message, link, window)
 	if err != nil {
 		r.logger.Error("Failed to add notification", zap.Error(err))
 		return 0, err
@@ -147,3 +163,82 @@ func (r *NotificationRepository) GetNotificationByID(ctx context.Context, notifi
 
 	return &notification, nil
 }
+
+// GetDigestNotifications retrieves the notifications collapsed into a digest of the user using
+// get_digest_notifications function
+func (r *NotificationRepository) GetDigestNotifications(ctx context.Context, digestID, userID int) ([]model.Notification, error) {
+	query := `SELECT * FROM get_digest_notifications($1, $2)`
+
+	notifications := []model.Notification{}
+	err := r.db.SelectContext(ctx, &notifications, query, digestID, userID)
+	if err != nil {
+		r.logger.Error("Failed to get digest notifications", zap.Error(err))
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// CreateDigests collapses unread notifications of digest categories received before the
+// given time into one digest per user using create_notification_digests function
+func (r *NotificationRepository) CreateDigests(ctx context.Context, before time.Time) ([]model.NotificationDigest, error) {
+	query := `SELECT * FROM create_notification_digests($1)`
+
+	digests := []model.NotificationDigest{}
+	err := r.db.SelectContext(ctx, &digests, query, before)
+	if err != nil {
+		r.logger.Error("Failed to create notification digests", zap.Error(err))
+		return nil, err
+	}
+
+	return digests, nil
+}
+
+// GetPreferences retrieves a user's delivery setting of every category using
+// get_notification_preferences function
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID int) ([]model.NotificationPreference, error) {
+	query := `SELECT * FROM get_notification_preferences($1)`
+
+	preferences := []model.NotificationPreference{}
+	err := r.db.SelectContext(ctx, &preferences, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get notification preferences", zap.Error(err))
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// GetDelivery retrieves a user's delivery setting of a category using get_notification_delivery function
+func (r *NotificationRepository) GetDelivery(ctx context.Context, userID int, category string) (string, error) {
+	query := `SELECT get_notification_delivery($1, $2)`
+
+	var delivery string
+	err := r.db.GetContext(ctx, &delivery, query, userID, category)
+	if err != nil {
+		r.logger.Error("Failed to get notification delivery", zap.Error(err))
+		return "", err
+	}
+
+	return delivery, nil
+}
+
+// SetPreferences sets a user's delivery settings using set_notification_preference function
+func (r *NotificationRepository) SetPreferences(ctx context.Context, userID int, preferences []model.NotificationPreference) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT set_notification_preference($1, $2, $3)`
+	for _, preference := range preferences {
+		if _, err := tx.ExecContext(ctx, query, userID, preference.Category, preference.Delivery); err != nil {
+			r.logger.Error("Failed to set notification preference", zap.Error(err), zap.String("category", preference.Category))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
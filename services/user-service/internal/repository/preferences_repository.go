@@ -101,3 +101,84 @@ func (r *PreferenceRepository) DeletePreferences(ctx context.Context, userID int
 
 	return success, nil
 }
+
+// Outcomes of saving or deleting a workspace
+const (
+	WorkspaceSaved           = "saved"
+	WorkspaceDeleted         = "deleted"
+	WorkspaceVersionMismatch = "version_mismatch"
+	WorkspaceQuotaExceeded   = "quota_exceeded"
+	WorkspaceLimitReached    = "limit_reached"
+	WorkspaceNotFound        = "not_found"
+)
+
+// GetWorkspace retrieves a workspace using get_user_workspace function. It returns nil when
+// the user has no workspace of that name.
+func (r *PreferenceRepository) GetWorkspace(ctx context.Context, userID int, name string) (*model.Workspace, error) {
+	query := `SELECT * FROM get_user_workspace($1, $2)`
+
+	var workspace model.Workspace
+	if err := r.db.GetContext(ctx, &workspace, query, userID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get workspace", zap.Error(err), zap.Int("user_id", userID), zap.String("name", name))
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// ListWorkspaces retrieves a user's workspaces without their layouts using get_user_workspaces function
+func (r *PreferenceRepository) ListWorkspaces(ctx context.Context, userID int) ([]model.Workspace, error) {
+	query := `SELECT * FROM get_user_workspaces($1)`
+
+	workspaces := []model.Workspace{}
+	if err := r.db.SelectContext(ctx, &workspaces, query, userID); err != nil {
+		r.logger.Error("Failed to list workspaces", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// SaveWorkspace creates or replaces a workspace using save_user_workspace function. A nil
+// expectedVersion saves unconditionally, 0 only creates and -1 only replaces. It returns
+// the outcome and the workspace's version afterwards.
+func (r *PreferenceRepository) SaveWorkspace(
+	ctx context.Context,
+	userID int,
+	name string,
+	layout json.RawMessage,
+	expectedVersion *int,
+	maxBytes int,
+	maxCount int,
+) (string, int, error) {
+	query := `SELECT * FROM save_user_workspace($1, $2, $3, $4, $5, $6, $7)`
+
+	var outcome struct {
+		Result  string        `db:"result"`
+		Version sql.NullInt64 `db:"version"`
+	}
+	err := r.db.GetContext(ctx, &outcome, query, userID, name, string(layout), len(layout), expectedVersion, maxBytes, maxCount)
+	if err != nil {
+		r.logger.Error("Failed to save workspace", zap.Error(err), zap.Int("user_id", userID), zap.String("name", name))
+		return "", 0, err
+	}
+
+	return outcome.Result, int(outcome.Version.Int64), nil
+}
+
+// DeleteWorkspace deletes a workspace using delete_user_workspace function. A nil
+// expectedVersion deletes unconditionally. It returns the outcome.
+func (r *PreferenceRepository) DeleteWorkspace(ctx context.Context, userID int, name string, expectedVersion *int) (string, error) {
+	query := `SELECT delete_user_workspace($1, $2, $3)`
+
+	var result string
+	if err := r.db.GetContext(ctx, &result, query, userID, name, expectedVersion); err != nil {
+		r.logger.Error("Failed to delete workspace", zap.Error(err), zap.Int("user_id", userID), zap.String("name", name))
+		return "", err
+	}
+
+	return result, nil
+}
@@ -4,7 +4,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"time"
+
+	"services/user-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -68,3 +72,80 @@ func (r *ProfileRepository) ClearProfilePhoto(ctx context.Context, userID int) (
 
 	return success, nil
 }
+
+// GetPublicProfile gets the public profile of an active user using get_public_profile function.
+// Returns nil if the user doesn't exist or is inactive.
+func (r *ProfileRepository) GetPublicProfile(ctx context.Context, userID int) (*model.PublicProfile, error) {
+	query := `SELECT * FROM get_public_profile($1)`
+
+	var row struct {
+		ID              int             `db:"id"`
+		Username        string          `db:"username"`
+		DisplayName     string          `db:"display_name"`
+		ProfilePhotoURL string          `db:"profile_photo_url"`
+		Bio             string          `db:"bio"`
+		SocialLinks     json.RawMessage `db:"social_links"`
+		CreatedAt       time.Time       `db:"created_at"`
+	}
+	err := r.db.GetContext(ctx, &row, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get public profile", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	profile := &model.PublicProfile{
+		ID:              row.ID,
+		Username:        row.Username,
+		DisplayName:     row.DisplayName,
+		ProfilePhotoURL: row.ProfilePhotoURL,
+		Bio:             row.Bio,
+		SocialLinks:     map[string]string{},
+		MemberSince:     row.CreatedAt,
+	}
+	if err := json.Unmarshal(row.SocialLinks, &profile.SocialLinks); err != nil {
+		r.logger.Warn("Ignoring malformed social links", zap.Error(err), zap.Int("user_id", userID))
+	}
+
+	return profile, nil
+}
+
+// SearchPublicProfiles searches the public profiles of active users by username or display
+// name using search_public_profiles function, best matches first
+func (r *ProfileRepository) SearchPublicProfiles(ctx context.Context, search string, limit int) ([]model.ProfileSearchResult, error) {
+	query := `SELECT * FROM search_public_profiles($1, $2)`
+
+	profiles := []model.ProfileSearchResult{}
+	if err := r.db.SelectContext(ctx, &profiles, query, search, limit); err != nil {
+		r.logger.Error("Failed to search public profiles", zap.Error(err), zap.String("search", search))
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// UpdateProfileDetails updates a user's public profile details using update_profile_details function
+func (r *ProfileRepository) UpdateProfileDetails(ctx context.Context, userID int, update *model.ProfileDetailsUpdate) (bool, error) {
+	query := `SELECT update_profile_details($1, $2, $3, $4)`
+
+	// NULL leaves the social links unchanged
+	var socialLinks interface{}
+	if update.SocialLinks != nil {
+		encoded, err := json.Marshal(update.SocialLinks)
+		if err != nil {
+			return false, err
+		}
+		socialLinks = string(encoded)
+	}
+
+	var success bool
+	err := r.db.GetContext(ctx, &success, query, userID, update.DisplayName, update.Bio, socialLinks)
+	if err != nil {
+		r.logger.Error("Failed to update profile details", zap.Error(err), zap.Int("user_id", userID))
+		return false, err
+	}
+
+	return success, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ReferralRepository handles database operations for referral codes, referrals and the
+// referral credit ledger
+type ReferralRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewReferralRepository creates a new referral repository
+func NewReferralRepository(db *sqlx.DB, logger *zap.Logger) *ReferralRepository {
+	return &ReferralRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetOrCreateCode retrieves a user's referral code, creating it the first time, using
+// get_or_create_referral_code function
+func (r *ReferralRepository) GetOrCreateCode(ctx context.Context, userID int) (string, error) {
+	query := `SELECT get_or_create_referral_code($1)`
+
+	var code string
+	if err := r.db.GetContext(ctx, &code, query, userID); err != nil {
+		r.logger.Error("failed to get referral code", zap.Error(err), zap.Int("user_id", userID))
+		return "", err
+	}
+
+	return code, nil
+}
+
+// RecordReferral records that a new user registered with a referral code using
+// record_referral function. It returns nil if the code can't refer the user.
+func (r *ReferralRepository) RecordReferral(ctx context.Context, code string, refereeID int, signupIP, signupUserAgent string) (*model.Referral, error) {
+	query := `SELECT * FROM record_referral($1, $2, $3, $4)`
+
+	var referral model.Referral
+	err := r.db.GetContext(ctx, &referral, query, code, refereeID, signupIP, signupUserAgent)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to record referral", zap.Error(err), zap.Int("referee_id", refereeID))
+		return nil, err
+	}
+
+	return &referral, nil
+}
+
+// GrantRewards credits the rewards of a referral using grant_referral_rewards function.
+// It returns whether the referrer was credited.
+func (r *ReferralRepository) GrantRewards(ctx context.Context, referralID int, referrerCredit, refereeCredit float64, currency string, maxRewarded int) (bool, error) {
+	query := `SELECT grant_referral_rewards($1, $2, $3, $4, $5)`
+
+	var granted bool
+	err := r.db.GetContext(ctx, &granted, query, referralID, referrerCredit, refereeCredit, currency, maxRewarded)
+	if err != nil {
+		r.logger.Error("failed to grant referral rewards", zap.Error(err), zap.Int("referral_id", referralID))
+		return false, err
+	}
+
+	return granted, nil
+}
+
+// GetStats retrieves a user's referral statistics using get_referral_stats function
+func (r *ReferralRepository) GetStats(ctx context.Context, userID int, currency string) (*model.ReferralStats, error) {
+	query := `SELECT * FROM get_referral_stats($1, $2)`
+
+	var stats model.ReferralStats
+	if err := r.db.GetContext(ctx, &stats, query, userID, currency); err != nil {
+		r.logger.Error("failed to get referral stats", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// GetCreditBalance retrieves a user's referral credit balance using
+// get_referral_credit_balance function
+func (r *ReferralRepository) GetCreditBalance(ctx context.Context, userID int, currency string) (float64, error) {
+	query := `SELECT get_referral_credit_balance($1, $2)`
+
+	var balance float64
+	if err := r.db.GetContext(ctx, &balance, query, userID, currency); err != nil {
+		r.logger.Error("failed to get referral credit balance", zap.Error(err), zap.Int("user_id", userID))
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// RedeemCredit spends up to amount of a user's credit using redeem_referral_credit
+// function. It returns a zero redemption if the user has no credit.
+func (r *ReferralRepository) RedeemCredit(ctx context.Context, userID int, amount float64, currency, reference string) (*model.ReferralRedemption, error) {
+	query := `SELECT * FROM redeem_referral_credit($1, $2, $3, $4)`
+
+	redemption := model.ReferralRedemption{Currency: currency}
+	err := r.db.GetContext(ctx, &redemption, query, userID, amount, currency, reference)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("failed to redeem referral credit", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// ReverseRedemption gives back the credit of a redemption using
+// reverse_referral_redemption function. It returns false if there is no such redemption
+// or it was already reversed.
+func (r *ReferralRepository) ReverseRedemption(ctx context.Context, redemptionID int) (bool, error) {
+	query := `SELECT reverse_referral_redemption($1)`
+
+	var reversed bool
+	if err := r.db.GetContext(ctx, &reversed, query, redemptionID); err != nil {
+		r.logger.Error("failed to reverse referral redemption", zap.Error(err), zap.Int("redemption_id", redemptionID))
+		return false, err
+	}
+
+	return reversed, nil
+}
+
+// GetFraudReport retrieves the fraud indicators of referrers with at least minReferrals
+// referrals since a point in time using get_referral_fraud_report function
+func (r *ReferralRepository) GetFraudReport(ctx context.Context, since time.Time, minReferrals, limit int) ([]model.ReferralFraudEntry, error) {
+	query := `SELECT * FROM get_referral_fraud_report($1, $2, $3)`
+
+	entries := []model.ReferralFraudEntry{}
+	if err := r.db.SelectContext(ctx, &entries, query, since, minReferrals, limit); err != nil {
+		r.logger.Error("failed to get referral fraud report", zap.Error(err))
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,229 @@
+// internal/repository/role_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// RoleRepository handles database operations for roles and permissions
+type RoleRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *sqlx.DB, logger *zap.Logger) *RoleRepository {
+	return &RoleRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// roleRow is the row shape returned by the role functions
+type roleRow struct {
+	ID          int              `db:"id"`
+	Name        string           `db:"name"`
+	Description *string          `db:"description"`
+	IsSystem    bool             `db:"is_system"`
+	Permissions pgtype.TextArray `db:"permissions"`
+	UserCount   int              `db:"user_count"`
+	CreatedAt   time.Time        `db:"created_at"`
+	UpdatedAt   *time.Time       `db:"updated_at"`
+}
+
+func (row roleRow) toModel() model.Role {
+	permissions := []string{}
+	_ = row.Permissions.AssignTo(&permissions)
+
+	return model.Role{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		IsSystem:    row.IsSystem,
+		Permissions: permissions,
+		UserCount:   row.UserCount,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// GetAllRoles retrieves all roles using get_all_roles function
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]model.Role, error) {
+	return r.selectRoles(ctx, `SELECT * FROM get_all_roles()`)
+}
+
+// GetUserRoles retrieves the roles held by a user using get_user_roles function
+func (r *RoleRepository) GetUserRoles(ctx context.Context, userID int) ([]model.Role, error) {
+	return r.selectRoles(ctx, `SELECT * FROM get_user_roles($1)`, userID)
+}
+
+func (r *RoleRepository) selectRoles(ctx context.Context, query string, args ...interface{}) ([]model.Role, error) {
+	var rows []roleRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		r.logger.Error("failed to get roles", zap.Error(err))
+		return nil, err
+	}
+
+	roles := make([]model.Role, len(rows))
+	for i, row := range rows {
+		roles[i] = row.toModel()
+	}
+
+	return roles, nil
+}
+
+// GetRoleByID retrieves a role by ID using get_role_by_id function
+func (r *RoleRepository) GetRoleByID(ctx context.Context, id int) (*model.Role, error) {
+	query := `SELECT * FROM get_role_by_id($1)`
+
+	var row roleRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get role by ID", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	role := row.toModel()
+	return &role, nil
+}
+
+// CreateRole creates a custom role using create_role function
+func (r *RoleRepository) CreateRole(ctx context.Context, role *model.RoleCreate) (int, error) {
+	query := `SELECT create_role($1, $2, $3)`
+
+	var id int
+	if err := r.db.GetContext(ctx, &id, query, role.Name, role.Description, role.Permissions); err != nil {
+		r.logger.Error("failed to create role", zap.Error(err), zap.String("name", role.Name))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdateRole updates a role using update_role function
+func (r *RoleRepository) UpdateRole(ctx context.Context, id int, update *model.RoleUpdate) (bool, error) {
+	query := `SELECT update_role($1, $2, $3, $4)`
+
+	// A nil slice is sent as NULL, which leaves permissions unchanged
+	var permissions interface{}
+	if update.Permissions != nil {
+		permissions = update.Permissions
+	}
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, id, update.Name, update.Description, permissions); err != nil {
+		r.logger.Error("failed to update role", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// DeleteRole deletes a custom role using delete_role function
+func (r *RoleRepository) DeleteRole(ctx context.Context, id int) (bool, error) {
+	query := `SELECT delete_role($1)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, id); err != nil {
+		r.logger.Error("failed to delete role", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// GetAllPermissions retrieves the permission catalog using get_all_permissions function
+func (r *RoleRepository) GetAllPermissions(ctx context.Context) ([]model.Permission, error) {
+	query := `SELECT * FROM get_all_permissions()`
+
+	var permissions []model.Permission
+	if err := r.db.SelectContext(ctx, &permissions, query); err != nil {
+		r.logger.Error("failed to get permissions", zap.Error(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// AssignUserRole assigns a role to a user using assign_user_role function
+func (r *RoleRepository) AssignUserRole(ctx context.Context, userID, roleID int) (bool, error) {
+	query := `SELECT assign_user_role($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID, roleID); err != nil {
+		r.logger.Error("failed to assign user role", zap.Error(err), zap.Int("userID", userID), zap.Int("roleID", roleID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// RemoveUserRole removes a role from a user using remove_user_role function
+func (r *RoleRepository) RemoveUserRole(ctx context.Context, userID, roleID int) (bool, error) {
+	query := `SELECT remove_user_role($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID, roleID); err != nil {
+		r.logger.Error("failed to remove user role", zap.Error(err), zap.Int("userID", userID), zap.Int("roleID", roleID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// BulkAssignUserRole assigns a role to many users using bulk_assign_user_role function.
+// It returns the IDs of the users that didn't have the role yet.
+func (r *RoleRepository) BulkAssignUserRole(ctx context.Context, userIDs []int, roleID int) ([]int, error) {
+	query := `SELECT user_id FROM bulk_assign_user_role($1, $2)`
+
+	assigned := []int{}
+	if err := r.db.SelectContext(ctx, &assigned, query, userIDs, roleID); err != nil {
+		r.logger.Error("failed to bulk assign user role", zap.Error(err), zap.Int("count", len(userIDs)), zap.Int("roleID", roleID))
+		return nil, err
+	}
+
+	return assigned, nil
+}
+
+// BulkRemoveUserRole removes a role from many users using bulk_remove_user_role function.
+// It returns the IDs of the users that had the role.
+func (r *RoleRepository) BulkRemoveUserRole(ctx context.Context, userIDs []int, roleID int) ([]int, error) {
+	query := `SELECT user_id FROM bulk_remove_user_role($1, $2)`
+
+	removed := []int{}
+	if err := r.db.SelectContext(ctx, &removed, query, userIDs, roleID); err != nil {
+		r.logger.Error("failed to bulk remove user role", zap.Error(err), zap.Int("count", len(userIDs)), zap.Int("roleID", roleID))
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// GetUserPermissions retrieves a user's effective permissions using get_user_permissions function
+func (r *RoleRepository) GetUserPermissions(ctx context.Context, userID int) ([]string, error) {
+	query := `SELECT get_user_permissions($1)`
+
+	var result pgtype.TextArray
+	if err := r.db.GetContext(ctx, &result, query, userID); err != nil {
+		r.logger.Error("failed to get user permissions", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	permissions := []string{}
+	if err := result.AssignTo(&permissions); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
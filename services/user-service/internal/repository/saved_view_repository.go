@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// SavedViewRepository handles database operations for users' saved list views
+type SavedViewRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewSavedViewRepository creates a new saved view repository
+func NewSavedViewRepository(db *sqlx.DB, logger *zap.Logger) *SavedViewRepository {
+	return &SavedViewRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create saves a view of a list using create_saved_view function. The user can have at
+// most maxCount views per list.
+func (r *SavedViewRepository) Create(ctx context.Context, userID int, request *model.SavedViewCreate, filters []byte, maxCount int) (*model.SavedView, error) {
+	query := `SELECT * FROM create_saved_view($1, $2, $3, $4, $5, $6, $7)`
+
+	var view model.SavedView
+	err := r.db.GetContext(ctx, &view, query,
+		userID,
+		request.ListType,
+		request.Name,
+		string(filters),
+		request.SortBy,
+		request.SortDirection,
+		maxCount,
+	)
+	if err != nil {
+		r.logger.Error("failed to create saved view", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// List retrieves a user's saved views using get_saved_views function. An empty list type
+// retrieves the views of every list.
+func (r *SavedViewRepository) List(ctx context.Context, userID int, listType string) ([]model.SavedView, error) {
+	query := `SELECT * FROM get_saved_views($1, $2)`
+
+	var listTypeArg interface{}
+	if listType != "" {
+		listTypeArg = listType
+	}
+
+	views := []model.SavedView{}
+	if err := r.db.SelectContext(ctx, &views, query, userID, listTypeArg); err != nil {
+		r.logger.Error("failed to get saved views", zap.Error(err), zap.Int("user_id", userID))
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// Get retrieves a saved view of a user using get_saved_view function. It returns nil if
+// the user has no such view.
+func (r *SavedViewRepository) Get(ctx context.Context, userID, viewID int) (*model.SavedView, error) {
+	query := `SELECT * FROM get_saved_view($1, $2)`
+
+	var view model.SavedView
+	if err := r.db.GetContext(ctx, &view, query, userID, viewID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get saved view", zap.Error(err), zap.Int("user_id", userID), zap.Int("view_id", viewID))
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// Delete deletes a saved view of a user using delete_saved_view function. It returns false
+// if the user has no such view.
+func (r *SavedViewRepository) Delete(ctx context.Context, userID, viewID int) (bool, error) {
+	query := `SELECT delete_saved_view($1, $2)`
+
+	var deleted bool
+	if err := r.db.GetContext(ctx, &deleted, query, userID, viewID); err != nil {
+		r.logger.Error("failed to delete saved view", zap.Error(err), zap.Int("user_id", userID), zap.Int("view_id", viewID))
+		return false, err
+	}
+
+	return deleted, nil
+}
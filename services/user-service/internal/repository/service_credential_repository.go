@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ServiceCredentialRepository handles database operations for the keys of internal services
+type ServiceCredentialRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewServiceCredentialRepository creates a new service credential repository
+func NewServiceCredentialRepository(db *sqlx.DB, logger *zap.Logger) *ServiceCredentialRepository {
+	return &ServiceCredentialRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// IssueCredential stores a new key using issue_service_credential function. rotateAfter,
+// when not nil, limits how long the service's other keys keep working.
+func (r *ServiceCredentialRepository) IssueCredential(
+	ctx context.Context,
+	serviceName string,
+	keyPrefix string,
+	keyHash string,
+	description string,
+	expiresAt *time.Time,
+	rotateAfter *time.Duration,
+	createdBy int,
+) (int, error) {
+	query := `SELECT issue_service_credential($1, $2, $3, $4, $5, $6, $7)`
+
+	var rotateSeconds *int
+	if rotateAfter != nil {
+		seconds := int(rotateAfter.Seconds())
+		rotateSeconds = &seconds
+	}
+
+	var id int
+	err := r.db.GetContext(ctx, &id, query,
+		serviceName, keyPrefix, keyHash, description, expiresAt, rotateSeconds, createdBy)
+	if err != nil {
+		r.logger.Error("failed to issue service credential", zap.Error(err), zap.String("service", serviceName))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetCredentials lists the keys of a service, or of every service when serviceName is
+// empty, using get_service_credentials function
+func (r *ServiceCredentialRepository) GetCredentials(ctx context.Context, serviceName string) ([]model.ServiceCredential, error) {
+	query := `SELECT * FROM get_service_credentials($1)`
+
+	var service *string
+	if serviceName != "" {
+		service = &serviceName
+	}
+
+	credentials := []model.ServiceCredential{}
+	if err := r.db.SelectContext(ctx, &credentials, query, service); err != nil {
+		r.logger.Error("failed to get service credentials", zap.Error(err), zap.String("service", serviceName))
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// GetCredential gets a key by ID
+func (r *ServiceCredentialRepository) GetCredential(ctx context.Context, id int) (*model.ServiceCredential, error) {
+	query := `SELECT * FROM get_service_credentials(NULL) WHERE id = $1`
+
+	var credential model.ServiceCredential
+	if err := r.db.GetContext(ctx, &credential, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get service credential", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// RevokeCredential revokes a key using revoke_service_credential function
+func (r *ServiceCredentialRepository) RevokeCredential(ctx context.Context, id int, revokedBy int) (bool, error) {
+	query := `SELECT revoke_service_credential($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, id, revokedBy); err != nil {
+		r.logger.Error("failed to revoke service credential", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// Authenticate finds the service of a usable key by its hash using
+// authenticate_service_credential function. Returns nil for unknown, expired and revoked keys.
+func (r *ServiceCredentialRepository) Authenticate(ctx context.Context, keyHash string) (*model.AuthenticatedService, error) {
+	query := `SELECT * FROM authenticate_service_credential($1)`
+
+	var service model.AuthenticatedService
+	if err := r.db.GetContext(ctx, &service, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to authenticate service credential", zap.Error(err))
+		return nil, err
+	}
+
+	return &service, nil
+}
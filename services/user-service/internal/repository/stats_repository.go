@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// StatsRepository handles aggregation queries for admin statistics
+type StatsRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *sqlx.DB, logger *zap.Logger) *StatsRepository {
+	return &StatsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUserStats aggregates user KPIs, counting activity since the given time
+func (r *StatsRepository) GetUserStats(ctx context.Context, since time.Time) (*model.UserStats, error) {
+	query := `SELECT * FROM get_user_stats($1)`
+
+	var stats model.UserStats
+	err := r.db.GetContext(ctx, &stats, query, since)
+	if err != nil {
+		r.logger.Error("Failed to get user stats", zap.Error(err), zap.Time("since", since))
+		return nil, err
+	}
+
+	return &stats, nil
+}
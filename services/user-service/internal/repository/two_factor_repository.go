@@ -0,0 +1,121 @@
+// internal/repository/two_factor_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"services/user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// TwoFactorRepository handles database operations for two-factor authentication
+type TwoFactorRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *sqlx.DB, logger *zap.Logger) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetSettings gets a user's 2FA settings using get_user_two_factor function
+func (r *TwoFactorRepository) GetSettings(ctx context.Context, userID int) (*model.TwoFactorSettings, error) {
+	query := `SELECT * FROM get_user_two_factor($1)`
+
+	var settings model.TwoFactorSettings
+	if err := r.db.GetContext(ctx, &settings, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get two-factor settings", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// SetPendingSecret stores a new, not yet enabled, TOTP secret using set_pending_two_factor_secret function
+func (r *TwoFactorRepository) SetPendingSecret(ctx context.Context, userID int, secret string) (bool, error) {
+	query := `SELECT set_pending_two_factor_secret($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID, secret); err != nil {
+		r.logger.Error("failed to set pending two-factor secret", zap.Error(err), zap.Int("userID", userID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// UseStep records a used TOTP time step using use_two_factor_step function
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `SELECT use_two_factor_step($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID, step); err != nil {
+		r.logger.Error("failed to record two-factor step", zap.Error(err), zap.Int("userID", userID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// Enable enables 2FA and stores recovery code hashes using enable_two_factor function
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID int, recoveryCodeHashes []string) (bool, error) {
+	query := `SELECT enable_two_factor($1, $2)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID, recoveryCodeHashes); err != nil {
+		r.logger.Error("failed to enable two-factor", zap.Error(err), zap.Int("userID", userID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// Disable removes 2FA settings and recovery codes using disable_two_factor function
+func (r *TwoFactorRepository) Disable(ctx context.Context, userID int) (bool, error) {
+	query := `SELECT disable_two_factor($1)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, userID); err != nil {
+		r.logger.Error("failed to disable two-factor", zap.Error(err), zap.Int("userID", userID))
+		return false, err
+	}
+
+	return success, nil
+}
+
+// GetUnusedRecoveryCodes gets a user's unused recovery codes using get_unused_recovery_codes function
+func (r *TwoFactorRepository) GetUnusedRecoveryCodes(ctx context.Context, userID int) ([]model.RecoveryCode, error) {
+	query := `SELECT * FROM get_unused_recovery_codes($1)`
+
+	var codes []model.RecoveryCode
+	if err := r.db.SelectContext(ctx, &codes, query, userID); err != nil {
+		r.logger.Error("failed to get recovery codes", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks a recovery code as used using use_recovery_code function
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, codeID int) (bool, error) {
+	query := `SELECT use_recovery_code($1)`
+
+	var success bool
+	if err := r.db.GetContext(ctx, &success, query, codeID); err != nil {
+		r.logger.Error("failed to use recovery code", zap.Error(err), zap.Int("codeID", codeID))
+		return false, err
+	}
+
+	return success, nil
+}
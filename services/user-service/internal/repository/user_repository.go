@@ -5,9 +5,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
+	"services/shared/database"
 	"services/user-service/internal/model"
 
+	"github.com/jackc/pgtype"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
@@ -15,13 +18,16 @@ import (
 // UserRepository handles database operations for users
 type UserRepository struct {
 	db     *sqlx.DB
+	router *database.Router[*sqlx.DB]
 	logger *zap.Logger
 }
 
-// NewUserRepository creates a new user repository
-func NewUserRepository(db *sqlx.DB, logger *zap.Logger) *UserRepository {
+// NewUserRepository creates a new user repository. Admin listings, searches and exports
+// go to the router's reader; everything else uses the primary.
+func NewUserRepository(router *database.Router[*sqlx.DB], logger *zap.Logger) *UserRepository {
 	return &UserRepository{
-		db:     db,
+		db:     router.Primary(),
+		router: router,
 		logger: logger,
 	}
 }
@@ -148,7 +154,7 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]model.U
 	query := `SELECT * FROM list_users($1, $2)`
 
 	var users []model.User
-	if err := r.db.SelectContext(ctx, &users, query, limit, offset); err != nil {
+	if err := r.router.Reader().SelectContext(ctx, &users, query, limit, offset); err != nil {
 		r.logger.Error("failed to list users", zap.Error(err))
 		return nil, err
 	}
@@ -156,15 +162,126 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]model.U
 	return users, nil
 }
 
+// Search retrieves a page of the users matching the admin search filters using
+// search_users function. pattern is the ILIKE pattern built from search.Search.
+func (r *UserRepository) Search(ctx context.Context, search *model.UserSearch, pattern *string, limit, offset int) ([]model.User, error) {
+	query := `SELECT * FROM search_users($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	users := []model.User{}
+	if err := r.router.Reader().SelectContext(
+		ctx,
+		&users,
+		query,
+		pattern,
+		nullString(search.Role),
+		search.IsActive,
+		search.CreatedAfter,
+		search.SortBy,
+		search.SortDirection,
+		limit,
+		offset,
+	); err != nil {
+		r.logger.Error("failed to search users", zap.Error(err))
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// CountSearch counts the users matching the admin search filters using count_search_users function
+func (r *UserRepository) CountSearch(ctx context.Context, search *model.UserSearch, pattern *string) (int, error) {
+	query := `SELECT count_search_users($1, $2, $3, $4)`
+
+	var count int
+	if err := r.router.Reader().GetContext(
+		ctx,
+		&count,
+		query,
+		pattern,
+		nullString(search.Role),
+		search.IsActive,
+		search.CreatedAfter,
+	); err != nil {
+		r.logger.Error("failed to count searched users", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // Count returns the total number of users using get_user_count function
 func (r *UserRepository) Count(ctx context.Context) (int, error) {
 	query := `SELECT get_user_count()`
 
 	var count int
-	if err := r.db.GetContext(ctx, &count, query); err != nil {
+	if err := r.router.Reader().GetContext(ctx, &count, query); err != nil {
 		r.logger.Error("failed to count users", zap.Error(err))
 		return 0, err
 	}
 
 	return count, nil
 }
+
+// SetActive activates or deactivates users using bulk_set_users_active function. It
+// returns the IDs of the users whose status changed.
+func (r *UserRepository) SetActive(ctx context.Context, userIDs []int, isActive bool) ([]int, error) {
+	query := `SELECT user_id FROM bulk_set_users_active($1, $2)`
+
+	changed := []int{}
+	if err := r.db.SelectContext(ctx, &changed, query, userIDs, isActive); err != nil {
+		r.logger.Error("failed to set users active", zap.Error(err), zap.Int("count", len(userIDs)))
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// exportRow is the row shape returned by the export_users function
+type exportRow struct {
+	ID        int              `db:"id"`
+	Username  string           `db:"username"`
+	Email     string           `db:"email"`
+	Role      string           `db:"role"`
+	Roles     pgtype.TextArray `db:"roles"`
+	IsActive  bool             `db:"is_active"`
+	LastLogin *time.Time       `db:"last_login"`
+	CreatedAt time.Time        `db:"created_at"`
+}
+
+// Export retrieves the users matching a filter using export_users function
+func (r *UserRepository) Export(ctx context.Context, filter *model.UserExportFilter) ([]model.UserExportRow, error) {
+	query := `SELECT * FROM export_users($1, $2, $3, $4)`
+
+	var rows []exportRow
+	if err := r.router.Reader().SelectContext(
+		ctx,
+		&rows,
+		query,
+		filter.CreatedFrom,
+		filter.CreatedTo,
+		filter.Role,
+		filter.IsActive,
+	); err != nil {
+		r.logger.Error("failed to export users", zap.Error(err))
+		return nil, err
+	}
+
+	users := make([]model.UserExportRow, 0, len(rows))
+	for _, row := range rows {
+		roles := []string{}
+		_ = row.Roles.AssignTo(&roles)
+
+		users = append(users, model.UserExportRow{
+			ID:        row.ID,
+			Username:  row.Username,
+			Email:     row.Email,
+			Role:      row.Role,
+			Roles:     roles,
+			IsActive:  row.IsActive,
+			LastLogin: row.LastLogin,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return users, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// ActivityConsumer reads user, strategy, marketplace and backtest events from Kafka
+// and records them in the users' activity feeds
+type ActivityConsumer struct {
+	reader          *kafka.Reader
+	activityService *ActivityService
+	logger          *zap.Logger
+}
+
+// NewActivityConsumer creates a new activity consumer for a set of event topics
+func NewActivityConsumer(
+	brokers []string,
+	groupID string,
+	topics []string,
+	activityService *ActivityService,
+	logger *zap.Logger,
+) *ActivityConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        groupID,
+		GroupTopics:    topics,
+		MinBytes:       1,
+		MaxBytes:       1 << 20,
+		CommitInterval: time.Second,
+	})
+
+	return &ActivityConsumer{
+		reader:          reader,
+		activityService: activityService,
+		logger:          logger,
+	}
+}
+
+// Run consumes events until the context is cancelled
+func (c *ActivityConsumer) Run(ctx context.Context) {
+	c.logger.Info("Starting activity consumer", zap.Strings("topics", c.reader.Config().GroupTopics))
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to read activity event", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.handleMessage(ctx, msg)
+	}
+}
+
+// handleMessage records a single event. Malformed events are logged and skipped.
+func (c *ActivityConsumer) handleMessage(ctx context.Context, msg kafka.Message) {
+	var event model.ActivityEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Warn("Skipping malformed activity event",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	if event.UserID == 0 || event.EventType == "" {
+		c.logger.Warn("Skipping incomplete activity event",
+			zap.Int("userID", event.UserID),
+			zap.String("eventType", event.EventType),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	eventKey := fmt.Sprintf("%s:%d:%d", msg.Topic, msg.Partition, msg.Offset)
+	if err := c.activityService.RecordEvent(ctx, &event, eventKey); err != nil {
+		c.logger.Error("Failed to record activity event",
+			zap.Error(err),
+			zap.Int("userID", event.UserID),
+			zap.String("eventType", event.EventType))
+	}
+}
+
+// Close closes the underlying Kafka reader
+func (c *ActivityConsumer) Close() error {
+	return c.reader.Close()
+}
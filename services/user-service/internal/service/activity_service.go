@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ActivityService builds and serves per-user activity feeds
+type ActivityService struct {
+	activityRepo *repository.ActivityRepository
+	userRepo     *repository.UserRepository
+	logger       *zap.Logger
+}
+
+// NewActivityService creates a new activity service
+func NewActivityService(
+	activityRepo *repository.ActivityRepository,
+	userRepo *repository.UserRepository,
+	logger *zap.Logger,
+) *ActivityService {
+	return &ActivityService{
+		activityRepo: activityRepo,
+		userRepo:     userRepo,
+		logger:       logger,
+	}
+}
+
+// GetActivities retrieves a page of a user's activity, newest first, with the total count.
+// types limits the feed to some activity types; empty returns every type.
+func (s *ActivityService) GetActivities(
+	ctx context.Context,
+	userID int,
+	types []string,
+	limit,
+	offset int,
+) ([]model.Activity, int, error) {
+	for _, activityType := range types {
+		if !isActivityType(activityType) {
+			return nil, 0, fmt.Errorf("invalid activity type: %s", activityType)
+		}
+	}
+
+	total, err := s.activityRepo.CountActivities(ctx, userID, types)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	activities, err := s.activityRepo.GetActivities(ctx, userID, types, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return activities, total, nil
+}
+
+// RecordEvent adds the feed entries for an event. eventKey identifies the source message
+// so a redelivered event is not stored twice. Events that don't belong in a feed are ignored.
+func (s *ActivityService) RecordEvent(ctx context.Context, event *model.ActivityEvent, eventKey string) error {
+	occurredAt, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		occurredAt = time.Now()
+	}
+
+	for _, activity := range s.activitiesForEvent(ctx, event) {
+		activity.EntityType = event.EntityType
+		activity.EntityID = event.EntityID
+		activity.EntityName = event.EntityName
+		activity.EventKey = eventKey
+		activity.OccurredAt = occurredAt.UTC()
+
+		if _, err := s.activityRepo.AddActivity(ctx, &activity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// activitiesForEvent decides whose feeds an event appears in and how it reads there
+func (s *ActivityService) activitiesForEvent(ctx context.Context, event *model.ActivityEvent) []model.ActivityCreate {
+	name := event.EntityName
+	if name == "" && event.EntityID != 0 {
+		name = fmt.Sprintf("#%d", event.EntityID)
+	}
+
+	own := func(activityType, summary string) []model.ActivityCreate {
+		return []model.ActivityCreate{{UserID: event.UserID, Type: activityType, Summary: summary}}
+	}
+
+	switch event.EventType {
+	case "user_updated":
+		return own(model.ActivityProfileUpdated, "Your profile was updated")
+	case model.ActivityStrategyCreated:
+		return own(model.ActivityStrategyCreated, fmt.Sprintf("You created strategy %s", name))
+	case model.ActivityStrategyUpdated:
+		return own(model.ActivityStrategyUpdated, fmt.Sprintf("You updated strategy %s", name))
+	case model.ActivityStrategyDeleted:
+		return own(model.ActivityStrategyDeleted, fmt.Sprintf("You deleted strategy %s", name))
+	case model.ActivityListingCreated:
+		return own(model.ActivityListingCreated, fmt.Sprintf("You listed strategy %s on the marketplace", name))
+	case model.ActivityBacktestCompleted:
+		return own(model.ActivityBacktestCompleted, fmt.Sprintf("Backtest %s completed", name))
+	case model.ActivityBacktestFailed:
+		return own(model.ActivityBacktestFailed, fmt.Sprintf("Backtest %s failed", name))
+	case model.ActivityStrategyPurchased:
+		activities := own(model.ActivityStrategyPurchased, fmt.Sprintf("You purchased strategy %s", name))
+		if event.OwnerID != 0 && event.OwnerID != event.UserID {
+			activities = append(activities, model.ActivityCreate{
+				UserID:  event.OwnerID,
+				Type:    model.ActivityStrategySold,
+				Summary: fmt.Sprintf("%s purchased your strategy %s", s.username(ctx, event.UserID), name),
+				ActorID: event.UserID,
+			})
+		}
+		return activities
+	}
+
+	return nil
+}
+
+// username returns a user's name for feed summaries, falling back to their ID
+func (s *ActivityService) username(ctx context.Context, userID int) string {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Sprintf("User %d", userID)
+	}
+	return user.Username
+}
+
+// isActivityType reports whether a feed filter names a known activity type
+func isActivityType(activityType string) bool {
+	for _, known := range model.ActivityTypes {
+		if activityType == known {
+			return true
+		}
+	}
+	return false
+}
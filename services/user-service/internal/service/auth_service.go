@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"crypto/rsa"
 	"errors"
 	"time"
 
+	"services/user-service/internal/client"
 	"services/user-service/internal/config"
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/repository"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
@@ -16,29 +20,62 @@ import (
 
 // AuthService handles authentication and token generation
 type AuthService struct {
-	userRepo *repository.UserRepository
-	authRepo *repository.AuthRepository
-	cfg      *config.Config
-	logger   *zap.Logger
+	userRepo      *repository.UserRepository
+	authRepo      *repository.AuthRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	roleRepo      *repository.RoleRepository
+	// Impersonation sessions; revocations are also written to Redis for the gateway
+	impersonationRepo *repository.ImpersonationRepository
+	// Security alerts for lockouts and logins from new devices
+	notificationService *NotificationService
+	mailClient          *client.MailClient
+	// Attributes registrations with a referral code
+	referralService *ReferralService
+	// Also holds login failure counters and lockouts
+	redisClient *redis.Client
+	// Broadcasts revocations so other services reject tokens they verify locally
+	tokenRevoker *TokenRevoker
+	cfg          *config.Config
+	logger       *zap.Logger
+
+	// Set by LoadSigningKey to sign access tokens RS256
+	signingKey   *rsa.PrivateKey
+	signingKeyID string
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	userRepo *repository.UserRepository,
 	authRepo *repository.AuthRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	roleRepo *repository.RoleRepository,
+	impersonationRepo *repository.ImpersonationRepository,
+	notificationService *NotificationService,
+	mailClient *client.MailClient,
+	referralService *ReferralService,
+	redisClient *redis.Client,
+	tokenRevoker *TokenRevoker,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		authRepo: authRepo,
-		cfg:      cfg,
-		logger:   logger,
+		userRepo:            userRepo,
+		authRepo:            authRepo,
+		twoFactorRepo:       twoFactorRepo,
+		roleRepo:            roleRepo,
+		impersonationRepo:   impersonationRepo,
+		notificationService: notificationService,
+		mailClient:          mailClient,
+		referralService:     referralService,
+		redisClient:         redisClient,
+		tokenRevoker:        tokenRevoker,
+		cfg:                 cfg,
+		logger:              logger,
 	}
 }
 
-// Register creates a new user account
-func (s *AuthService) Register(ctx context.Context, userCreate *model.UserCreate) (*model.TokenResponse, error) {
+// Register creates a new user account, referred by the user whose referral code it has
+func (s *AuthService) Register(ctx context.Context, userCreate *model.UserCreate, clientIP, userAgent string) (*model.TokenResponse, error) {
 	// Check if email already exists
 	existingUser, err := s.userRepo.GetByEmail(ctx, userCreate.Email)
 	if err != nil {
@@ -66,14 +103,26 @@ func (s *AuthService) Register(ctx context.Context, userCreate *model.UserCreate
 		return nil, err
 	}
 
+	// A failed referral never fails the registration
+	if userCreate.ReferralCode != "" {
+		if err := s.referralService.AttributeRegistration(ctx, userCreate.ReferralCode, userID, clientIP, userAgent); err != nil {
+			s.logger.Warn("failed to attribute referral", zap.Error(err), zap.Int("userID", userID))
+		}
+	}
+
 	// Get the created user to access the role
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate tokens with role information
-	accessToken, refreshToken, expiresAt, err := s.generateTokens(userID, user.Role)
+	accessToken, refreshToken, expiresAt, err := s.generateTokens(userID, user.Role, permissions)
 	if err != nil {
 		return nil, err
 	}
@@ -84,9 +133,7 @@ func (s *AuthService) Register(ctx context.Context, userCreate *model.UserCreate
 	}
 
 	// Store refresh token in the database
-	ip := ""
-	ua := ""
-	_, err = s.authRepo.CreateUserSession(ctx, userID, refreshToken, expiresAt, ip, ua)
+	_, err = s.authRepo.CreateUserSession(ctx, userID, refreshToken, expiresAt, clientIP, userAgent)
 	if err != nil {
 		s.logger.Warn("failed to create user session", zap.Error(err))
 	}
@@ -99,8 +146,9 @@ func (s *AuthService) Register(ctx context.Context, userCreate *model.UserCreate
 	}, nil
 }
 
-// Login authenticates a user and returns tokens
-func (s *AuthService) Login(ctx context.Context, login *model.UserLogin) (*model.TokenResponse, error) {
+// Login authenticates a user and returns tokens. Repeated failures lock the account for a
+// while, and logins from a new IP or device alert the user.
+func (s *AuthService) Login(ctx context.Context, login *model.UserLogin, clientIP, userAgent string) (*model.TokenResponse, error) {
 	// Find user by email
 	user, err := s.userRepo.GetByEmail(ctx, login.Email)
 	if err != nil {
@@ -115,22 +163,41 @@ func (s *AuthService) Login(ctx context.Context, login *model.UserLogin) (*model
 		return nil, errors.New("account is disabled")
 	}
 
+	// Locked accounts are rejected before the password is checked
+	if err := s.checkLoginLockout(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(login.Password)); err != nil {
 		s.logger.Debug("password verification failed", zap.Error(err))
+		s.recordLoginFailure(ctx, user, clientIP)
 		return nil, errors.New("invalid email or password")
 	}
 
+	// Require a second factor for accounts with 2FA enabled
+	if err := s.verifyLoginSecondFactor(ctx, user.ID, login.TwoFactorCode); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.recordLoginFailure(ctx, user, clientIP)
+		}
+		return nil, err
+	}
+	s.clearLoginFailures(ctx, user.ID)
+
+	permissions, err := s.userPermissions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate tokens with user role
-	accessToken, refreshToken, expiresAt, err := s.generateTokens(user.ID, user.Role)
+	accessToken, refreshToken, expiresAt, err := s.generateTokens(user.ID, user.Role, permissions)
 	if err != nil {
 		return nil, err
 	}
 
 	// Store session info
-	ip := ""
-	ua := ""
-	_, err = s.authRepo.CreateUserSession(ctx, user.ID, refreshToken, expiresAt, ip, ua)
+	userAgent = truncateUserAgent(userAgent)
+	_, err = s.authRepo.CreateUserSession(ctx, user.ID, refreshToken, expiresAt, clientIP, userAgent)
 	if err != nil {
 		s.logger.Warn("failed to create user session", zap.Error(err))
 	}
@@ -140,6 +207,8 @@ func (s *AuthService) Login(ctx context.Context, login *model.UserLogin) (*model
 		s.logger.Warn("failed to update last login", zap.Error(err), zap.Int("userID", user.ID))
 	}
 
+	s.checkLoginDevice(ctx, user, clientIP, userAgent)
+
 	return &model.TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -196,11 +265,16 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, err
 	}
 	if user == nil || !user.IsActive {
-		return nil, errors.New("user not found or inactive")
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate new tokens with role
-	accessToken, newRefreshToken, expiresAt, err := s.generateTokens(userID, user.Role)
+	accessToken, newRefreshToken, expiresAt, err := s.generateTokens(userID, user.Role, permissions)
 	if err != nil {
 		return nil, err
 	}
@@ -239,9 +313,27 @@ func (s *AuthService) Logout(ctx context.Context, token string) error {
 	return nil
 }
 
-// LogoutAll invalidates all of a user's sessions
+// LogoutAll invalidates all of a user's sessions and revokes their access tokens
 func (s *AuthService) LogoutAll(ctx context.Context, userID int) (int, error) {
-	return s.authRepo.DeleteUserSessions(ctx, userID)
+	count, err := s.authRepo.DeleteUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.tokenRevoker.RevokeUser(ctx, userID, "logout_all")
+	return count, nil
+}
+
+// CheckRevoked verifies that the user's tokens weren't revoked after the token was issued
+func (s *AuthService) CheckRevoked(ctx context.Context, claims *model.AccessClaims) error {
+	revoked, err := s.tokenRevoker.IsRevoked(ctx, claims.UserID, claims.IssuedAtMs)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errcode.ErrTokenRevoked
+	}
+	return nil
 }
 
 // ChangePassword changes a user's password
@@ -252,7 +344,7 @@ func (s *AuthService) ChangePassword(ctx context.Context, id int, change *model.
 		return err
 	}
 	if passwordHash == "" {
-		return errors.New("user not found")
+		return errcode.ErrUserNotFound
 	}
 
 	// Verify current password
@@ -282,26 +374,42 @@ func (s *AuthService) ChangePassword(ctx context.Context, id int, change *model.
 	if err != nil {
 		s.logger.Warn("failed to delete user sessions after password change", zap.Error(err))
 	}
+	s.tokenRevoker.RevokeUser(ctx, id, "password_changed")
 
 	return nil
 }
 
-// generateTokens creates a new pair of access and refresh tokens with role information
-func (s *AuthService) generateTokens(userID int, role string) (accessToken, refreshToken string, expiresAt time.Time, err error) {
+// userPermissions loads the user's effective permissions for the token claims. Failures
+// are returned rather than signing an empty permissions claim, which would strip the user's
+// rights for the token's lifetime.
+func (s *AuthService) userPermissions(ctx context.Context, userID int) ([]string, error) {
+	permissions, err := s.roleRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to load user permissions", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// generateTokens creates a new pair of access and refresh tokens with role and permission information
+func (s *AuthService) generateTokens(userID int, role string, permissions []string) (accessToken, refreshToken string, expiresAt time.Time, err error) {
 	// Access token expiry
-	accessExpiry := time.Now().Add(s.cfg.Auth.AccessTokenDuration)
+	issuedAt := time.Now()
+	accessExpiry := issuedAt.Add(s.cfg.Auth.AccessTokenDuration)
 
-	// Create access token with role information
+	// Create access token with role and permission information. iat_ms tells a token issued
+	// right after a revocation apart from those issued in the same second before it.
 	accessClaims := jwt.MapClaims{
-		"sub":  userID,
-		"exp":  accessExpiry.Unix(),
-		"iat":  time.Now().Unix(),
-		"type": "access",
-		"role": role, // Include role in the token
+		"sub":         userID,
+		"exp":         accessExpiry.Unix(),
+		"iat":         issuedAt.Unix(),
+		"iat_ms":      issuedAt.UnixMilli(),
+		"type":        "access",
+		"role":        role,        // Include role in the token
+		"permissions": permissions, // Consumed by other services' permission middleware
 	}
 
-	access := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = access.SignedString([]byte(s.cfg.Auth.JWTSecret))
+	accessToken, err = s.signAccessToken(accessClaims)
 	if err != nil {
 		s.logger.Error("failed to sign access token", zap.Error(err))
 		return "", "", time.Time{}, err
@@ -328,36 +436,41 @@ func (s *AuthService) generateTokens(userID int, role string) (accessToken, refr
 
 // ValidateToken validates a JWT token and returns the user ID and role if valid
 func (s *AuthService) ValidateToken(tokenString string) (int, string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(s.cfg.Auth.JWTSecret), nil
-	})
-
+	claims, err := s.ParseAccessToken(tokenString)
 	if err != nil {
 		return 0, "", err
 	}
 
+	return claims.UserID, claims.Role, nil
+}
+
+// ParseAccessToken validates a JWT access token and returns its claims
+func (s *AuthService) ParseAccessToken(tokenString string) (*model.AccessClaims, error) {
+	token, err := jwt.Parse(tokenString, s.accessTokenKey)
+
+	if err != nil {
+		return nil, err
+	}
+
 	if !token.Valid {
-		return 0, "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, "", errors.New("invalid claims")
+		return nil, errors.New("invalid claims")
 	}
 
 	// Check token type
 	tokenType, ok := claims["type"].(string)
 	if !ok || tokenType != "access" {
-		return 0, "", errors.New("invalid token type")
+		return nil, errors.New("invalid token type")
 	}
 
 	// Extract user ID
 	userIDFloat, ok := claims["sub"].(float64)
 	if !ok {
-		return 0, "", errors.New("invalid user ID in token")
+		return nil, errors.New("invalid user ID in token")
 	}
 
 	// Extract role
@@ -367,7 +480,41 @@ func (s *AuthService) ValidateToken(tokenString string) (int, string, error) {
 		role = "user"
 	}
 
-	return int(userIDFloat), role, nil
+	// Extract permissions
+	permissions := []string{}
+	if raw, ok := claims["permissions"].([]interface{}); ok {
+		for _, p := range raw {
+			if name, ok := p.(string); ok {
+				permissions = append(permissions, name)
+			}
+		}
+	} else if role == "admin" {
+		// Tokens issued before permissions existed: admins keep full access
+		permissions = []string{"*"}
+	}
+
+	accessClaims := &model.AccessClaims{
+		UserID:      int(userIDFloat),
+		Role:        role,
+		Permissions: permissions,
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		accessClaims.IssuedAt = int64(iat)
+		accessClaims.IssuedAtMs = int64(iat) * 1000
+	}
+	if iatMs, ok := claims["iat_ms"].(float64); ok {
+		accessClaims.IssuedAtMs = int64(iatMs)
+	}
+
+	// Impersonation tokens name the admin acting as the user
+	if impersonatorID, ok := claims["impersonator_id"].(float64); ok {
+		accessClaims.ImpersonatorID = int(impersonatorID)
+		if sessionID, ok := claims["impersonation_session_id"].(float64); ok {
+			accessClaims.ImpersonationSessionID = int(sessionID)
+		}
+	}
+
+	return accessClaims, nil
 }
 
 // GetJWTSecret returns the JWT secret for service-to-service validation
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"services/shared/apierror"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// ImpersonationRevokedKeyPrefix prefixes the Redis keys marking revoked impersonation sessions.
+// The API gateway reads them to reject revoked tokens before they reach other services.
+const ImpersonationRevokedKeyPrefix = "impersonation:revoked:"
+
+// ErrImpersonationEnded is returned for impersonation tokens whose session expired or was revoked
+var ErrImpersonationEnded = errcode.ErrImpersonationEnded
+
+// Impersonate starts a support session acting as a user. The access token carries the user's
+// role and permissions plus the impersonator, has no refresh token and is short-lived.
+func (s *AuthService) Impersonate(
+	ctx context.Context,
+	adminID int,
+	userID int,
+	request *model.ImpersonationRequest,
+) (*model.ImpersonationResponse, error) {
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return nil, errors.New("invalid request: a reason is required")
+	}
+	if adminID == userID {
+		return nil, errors.New("invalid request: you can't impersonate yourself")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errcode.ErrUserNotFound
+	}
+	if !user.IsActive {
+		return nil, errors.New("invalid request: user is inactive")
+	}
+
+	// Acting as another administrator would hand out their full access
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == "admin" {
+		return nil, errors.New("forbidden: administrators can't be impersonated")
+	}
+	for _, permission := range permissions {
+		if permission == "*" || permission == "users:impersonate" {
+			return nil, errors.New("forbidden: users who can impersonate can't be impersonated")
+		}
+	}
+
+	ttl := s.cfg.Auth.ImpersonationTokenDuration
+	sessionID, err := s.impersonationRepo.CreateSession(ctx, adminID, userID, reason, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.impersonationRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, errcode.ErrImpersonationMissing
+	}
+
+	accessToken, expiresAt, err := s.generateImpersonationToken(user.ID, user.Role, permissions, adminID, sessionID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Warn("admin started impersonation session",
+		zap.Int("sessionID", sessionID),
+		zap.Int("adminID", adminID),
+		zap.Int("userID", userID),
+		zap.String("reason", reason))
+
+	return &model.ImpersonationResponse{
+		AccessToken:   accessToken,
+		ExpiresAt:     expiresAt,
+		Impersonation: true,
+		Session:       *session,
+		User:          *user,
+	}, nil
+}
+
+// GetActiveImpersonations lists the impersonation sessions that are neither expired nor revoked
+func (s *AuthService) GetActiveImpersonations(ctx context.Context) ([]model.ImpersonationSession, error) {
+	return s.impersonationRepo.GetActiveSessions(ctx)
+}
+
+// RevokeImpersonation ends an impersonation session. Its token is rejected from then on.
+func (s *AuthService) RevokeImpersonation(ctx context.Context, sessionID int, adminID int) error {
+	session, err := s.impersonationRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errcode.ErrImpersonationMissing
+	}
+
+	revoked, err := s.impersonationRepo.RevokeSession(ctx, sessionID, adminID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return apierror.New(http.StatusConflict, errcode.CodeImpersonationEnded, "Impersonation session already ended")
+	}
+
+	// Let the gateway reject the token until it would have expired anyway
+	if s.redisClient != nil {
+		ttl := time.Until(session.ExpiresAt) + time.Minute
+		if ttl < time.Minute {
+			ttl = time.Minute
+		}
+		key := fmt.Sprintf("%s%d", ImpersonationRevokedKeyPrefix, sessionID)
+		if err := s.redisClient.Set(ctx, key, adminID, ttl).Err(); err != nil {
+			s.logger.Warn("failed to publish impersonation revocation", zap.Error(err), zap.Int("sessionID", sessionID))
+		}
+	}
+
+	s.logger.Warn("admin revoked impersonation session",
+		zap.Int("sessionID", sessionID),
+		zap.Int("revokedBy", adminID))
+
+	return nil
+}
+
+// CheckImpersonation verifies that the session of an impersonation token is still active.
+// Tokens that aren't impersonation tokens always pass.
+func (s *AuthService) CheckImpersonation(ctx context.Context, claims *model.AccessClaims) error {
+	if claims.ImpersonatorID == 0 {
+		return nil
+	}
+
+	active, err := s.impersonationRepo.IsSessionActive(ctx, claims.ImpersonationSessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrImpersonationEnded
+	}
+
+	return nil
+}
+
+// generateImpersonationToken creates an access token for a user that names the impersonating admin
+func (s *AuthService) generateImpersonationToken(
+	userID int,
+	role string,
+	permissions []string,
+	adminID int,
+	sessionID int,
+	ttl time.Duration,
+) (string, time.Time, error) {
+	issuedAt := time.Now()
+	expiresAt := issuedAt.Add(ttl)
+
+	claims := jwt.MapClaims{
+		"sub":                      userID,
+		"exp":                      expiresAt.Unix(),
+		"iat":                      issuedAt.Unix(),
+		"iat_ms":                   issuedAt.UnixMilli(),
+		"type":                     "access",
+		"role":                     role,
+		"permissions":              permissions,
+		"impersonator_id":          adminID,
+		"impersonation_session_id": sessionID,
+	}
+
+	accessToken, err := s.signAccessToken(claims)
+	if err != nil {
+		s.logger.Error("failed to sign impersonation token", zap.Error(err))
+		return "", time.Time{}, err
+	}
+
+	return accessToken, expiresAt, nil
+}
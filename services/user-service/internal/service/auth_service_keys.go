@@ -0,0 +1,103 @@
+package service
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"services/user-service/internal/model"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// LoadSigningKey reads the RSA private key access tokens are signed with. Until a key
+// is loaded access tokens are signed HS256 with the shared secret.
+func (s *AuthService) LoadSigningKey(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	keyID, err := rsaKeyID(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	s.signingKey = key
+	s.signingKeyID = keyID
+	s.logger.Info("access tokens are signed RS256", zap.String("kid", keyID))
+	return nil
+}
+
+// signAccessToken signs the claims of an access token with the RSA key if one is
+// loaded, otherwise with the shared secret
+func (s *AuthService) signAccessToken(claims jwt.MapClaims) (string, error) {
+	if s.signingKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.cfg.Auth.JWTSecret))
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.signingKeyID
+	return token.SignedString(s.signingKey)
+}
+
+// accessTokenKey returns the key to verify an access token with by its signing method
+func (s *AuthService) accessTokenKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		// Tokens issued before the RSA key was configured stay valid until they expire
+		return []byte(s.cfg.Auth.JWTSecret), nil
+	case *jwt.SigningMethodRSA:
+		if s.signingKey == nil {
+			return nil, errors.New("no RSA signing key is configured")
+		}
+		if kid, _ := token.Header["kid"].(string); kid != s.signingKeyID {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return &s.signingKey.PublicKey, nil
+	default:
+		return nil, errors.New("unexpected signing method")
+	}
+}
+
+// JWKS returns the public keys access tokens are signed with. The set is empty when
+// tokens are signed with the shared secret.
+func (s *AuthService) JWKS() *model.JSONWebKeySet {
+	set := &model.JSONWebKeySet{Keys: []model.JSONWebKey{}}
+	if s.signingKey == nil {
+		return set
+	}
+
+	pub := s.signingKey.PublicKey
+	set.Keys = append(set.Keys, model.JSONWebKey{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     s.signingKeyID,
+		N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	})
+	return set
+}
+
+// rsaKeyID derives a stable key ID from the public key so a rotated key gets a new ID
+func rsaKeyID(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
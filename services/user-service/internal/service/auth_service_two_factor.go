@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/utils"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when 2FA is enabled
+const recoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorRequired is returned by Login when the account has 2FA enabled and no code was supplied
+	ErrTwoFactorRequired = errcode.ErrTwoFactorRequired
+
+	// ErrInvalidTwoFactorCode is returned when a TOTP or recovery code does not match
+	ErrInvalidTwoFactorCode = errcode.ErrInvalidTwoFactorCode
+)
+
+// EnrollTwoFactor generates a new TOTP secret for the user. 2FA stays disabled
+// until the user confirms the secret with VerifyTwoFactor.
+func (s *AuthService) EnrollTwoFactor(ctx context.Context, userID int) (*model.TwoFactorEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errcode.ErrUserNotFound
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.Error("failed to generate TOTP secret", zap.Error(err))
+		return nil, errors.New("failed to generate two-factor secret")
+	}
+
+	success, err := s.twoFactorRepo.SetPendingSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	return &model.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPAuthURL(s.cfg.Auth.TwoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// VerifyTwoFactor confirms enrollment with a TOTP code, enables 2FA and returns
+// freshly generated recovery codes. The plain codes are only shown this once.
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, userID int, code string) (*model.TwoFactorVerifyResponse, error) {
+	settings, err := s.twoFactorRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+	if settings.IsEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if err := s.checkTOTP(ctx, settings, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		s.logger.Error("failed to generate recovery codes", zap.Error(err))
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(utils.NormalizeRecoveryCode(c)), bcrypt.DefaultCost)
+		if err != nil {
+			s.logger.Error("failed to hash recovery code", zap.Error(err))
+			return nil, errors.New("failed to generate recovery codes")
+		}
+		hashes[i] = string(hash)
+	}
+
+	success, err := s.twoFactorRepo.Enable(ctx, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	return &model.TwoFactorVerifyResponse{
+		Enabled:       true,
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// DisableTwoFactor turns 2FA off after re-checking the password and a current code
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID int, request *model.TwoFactorDisableRequest) error {
+	passwordHash, err := s.authRepo.GetUserPasswordByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if passwordHash == "" {
+		return errcode.ErrUserNotFound
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(request.Password)); err != nil {
+		return errors.New("password is incorrect")
+	}
+
+	settings, err := s.twoFactorRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if settings == nil || !settings.IsEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.checkSecondFactor(ctx, settings, request.Code); err != nil {
+		return err
+	}
+
+	if _, err := s.twoFactorRepo.Disable(ctx, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTwoFactorStatus returns the user's current 2FA state
+func (s *AuthService) GetTwoFactorStatus(ctx context.Context, userID int) (*model.TwoFactorStatus, error) {
+	settings, err := s.twoFactorRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TwoFactorStatus{}
+	if settings == nil {
+		return status, nil
+	}
+
+	status.Enabled = settings.IsEnabled
+	status.Pending = !settings.IsEnabled
+	status.EnabledAt = settings.EnabledAt
+
+	if settings.IsEnabled {
+		codes, err := s.twoFactorRepo.GetUnusedRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		status.RemainingRecoveryCodes = len(codes)
+	}
+
+	return status, nil
+}
+
+// verifyLoginSecondFactor enforces 2FA during login for users who have it enabled
+func (s *AuthService) verifyLoginSecondFactor(ctx context.Context, userID int, code string) error {
+	settings, err := s.twoFactorRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if settings == nil || !settings.IsEnabled {
+		return nil
+	}
+
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+
+	return s.checkSecondFactor(ctx, settings, code)
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery code. Only
+// codes shaped like recovery codes are compared with the recovery code hashes, so wrong
+// TOTP codes don't cost a bcrypt comparison per recovery code.
+func (s *AuthService) checkSecondFactor(ctx context.Context, settings *model.TwoFactorSettings, code string) error {
+	if normalized := utils.NormalizeRecoveryCode(code); utils.IsRecoveryCode(normalized) {
+		return s.consumeRecoveryCode(ctx, settings.UserID, normalized)
+	}
+
+	return s.checkTOTP(ctx, settings, code)
+}
+
+// checkTOTP validates a TOTP code and records its time step so it can't be replayed
+func (s *AuthService) checkTOTP(ctx context.Context, settings *model.TwoFactorSettings, code string) error {
+	step, ok := utils.ValidateTOTP(settings.Secret, code, time.Now())
+	if !ok || step <= settings.LastUsedStep {
+		return ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.twoFactorRepo.UseStep(ctx, settings.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// consumeRecoveryCode matches a normalized code against the user's unused recovery codes
+// and burns it. Codes issued before the dash became optional were hashed with the dash.
+func (s *AuthService) consumeRecoveryCode(ctx context.Context, userID int, normalized string) error {
+	legacy := utils.LegacyRecoveryCode(normalized)
+
+	codes, err := s.twoFactorRepo.GetUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, rc := range codes {
+		if bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(normalized)) != nil &&
+			bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(legacy)) != nil {
+			continue
+		}
+
+		used, err := s.twoFactorRepo.UseRecoveryCode(ctx, rc.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+
+		s.logger.Info("recovery code used", zap.Int("userID", userID), zap.Int("remaining", len(codes)-1))
+		return nil
+	}
+
+	return ErrInvalidTwoFactorCode
+}
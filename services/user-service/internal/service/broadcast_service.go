@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"services/user-service/internal/client"
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// broadcastPlaceholder matches the {{name}} placeholders of broadcast templates
+var broadcastPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// broadcastVariableName is the form of custom template variable names
+var broadcastVariableName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Template variables filled in per recipient
+const (
+	broadcastVariableUsername = "username"
+	broadcastVariableUserID   = "user_id"
+)
+
+// maxNotificationTitle is the longest title a notification may have
+const maxNotificationTitle = 100
+
+// BroadcastService handles admin notification broadcasts. Creating a broadcast only
+// stores it; the broadcast worker sends it through the notification service once it's due.
+type BroadcastService struct {
+	broadcastRepo       *repository.BroadcastRepository
+	notificationService *NotificationService
+	strategyClient      *client.StrategyClient
+	batchSize           int
+	staleAfter          time.Duration
+	logger              *zap.Logger
+}
+
+// NewBroadcastService creates a new broadcast service
+func NewBroadcastService(
+	broadcastRepo *repository.BroadcastRepository,
+	notificationService *NotificationService,
+	strategyClient *client.StrategyClient,
+	batchSize int,
+	staleAfter time.Duration,
+	logger *zap.Logger,
+) *BroadcastService {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &BroadcastService{
+		broadcastRepo:       broadcastRepo,
+		notificationService: notificationService,
+		strategyClient:      strategyClient,
+		batchSize:           batchSize,
+		staleAfter:          staleAfter,
+		logger:              logger,
+	}
+}
+
+// CreateBroadcast validates and schedules a broadcast
+func (s *BroadcastService) CreateBroadcast(
+	ctx context.Context,
+	adminID int,
+	request *model.NotificationBroadcastCreate,
+) (*model.NotificationBroadcast, error) {
+	switch request.Audience {
+	case model.BroadcastAudienceRole:
+		if request.AudienceRole == "" {
+			return nil, errors.New("audience_role is required for the role audience")
+		}
+	case model.BroadcastAudienceListingPurchasers, model.BroadcastAudienceStrategyUsers:
+		if request.AudienceTargetID <= 0 {
+			return nil, fmt.Errorf("audience_target_id is required for the %s audience", request.Audience)
+		}
+	}
+
+	if err := validateBroadcastTemplate(request.Variables, request.Title, request.Message); err != nil {
+		return nil, err
+	}
+
+	// Unknown listings and strategies are rejected now rather than when the broadcast is sent
+	if _, err := s.resolveAudience(ctx, request.Audience, request.AudienceTargetID); err != nil {
+		return nil, err
+	}
+
+	notificationType := request.Type
+	if notificationType == "" {
+		notificationType = model.NotificationTypeBroadcast
+	}
+	category := request.Category
+	if category == "" {
+		category = model.NotificationCategoryForType(notificationType)
+	}
+
+	variables, err := json.Marshal(request.Variables)
+	if err != nil {
+		return nil, err
+	}
+	if request.Variables == nil {
+		variables = []byte("{}")
+	}
+
+	// Timestamps are stored without a zone, in UTC
+	if request.ScheduledAt != nil {
+		scheduledAt := request.ScheduledAt.UTC()
+		request.ScheduledAt = &scheduledAt
+	}
+
+	id, err := s.broadcastRepo.Create(ctx, adminID, notificationType, category, request, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Notification broadcast scheduled",
+		zap.Int("broadcastID", id),
+		zap.Int("adminID", adminID),
+		zap.String("audience", request.Audience))
+
+	return s.GetBroadcast(ctx, id)
+}
+
+// GetBroadcast retrieves a broadcast with its progress
+func (s *BroadcastService) GetBroadcast(ctx context.Context, id int) (*model.NotificationBroadcast, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast == nil {
+		return nil, errcode.ErrBroadcastNotFound
+	}
+
+	setBroadcastProgress(broadcast)
+	return broadcast, nil
+}
+
+// ListBroadcasts retrieves a page of broadcasts newest first, optionally of one status,
+// and the total number of them
+func (s *BroadcastService) ListBroadcasts(ctx context.Context, status string, page, limit int) ([]model.NotificationBroadcast, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	broadcasts, err := s.broadcastRepo.List(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.broadcastRepo.Count(ctx, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range broadcasts {
+		setBroadcastProgress(&broadcasts[i])
+	}
+
+	return broadcasts, total, nil
+}
+
+// CancelBroadcast cancels a broadcast that hasn't finished. A broadcast being sent stops
+// after its current batch; recipients already notified keep their notification.
+func (s *BroadcastService) CancelBroadcast(ctx context.Context, id int) (*model.NotificationBroadcast, error) {
+	cancelled, err := s.broadcastRepo.Cancel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	broadcast, err := s.GetBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, errcode.ErrBroadcastFinished
+	}
+
+	return broadcast, nil
+}
+
+// SendNextBroadcast claims the next due broadcast and sends it to its audience. It reports
+// whether there was a broadcast to send.
+func (s *BroadcastService) SendNextBroadcast(ctx context.Context) (bool, error) {
+	broadcast, err := s.broadcastRepo.Claim(ctx, s.staleAfter)
+	if err != nil {
+		return false, err
+	}
+	if broadcast == nil {
+		return false, nil
+	}
+
+	s.sendBroadcast(ctx, broadcast)
+	return true, nil
+}
+
+// sendBroadcast sends a claimed broadcast in batches of recipients, resuming after the last
+// recipient a previous run recorded. It returns early when the context is cancelled,
+// leaving the broadcast running for a later run to resume.
+func (s *BroadcastService) sendBroadcast(ctx context.Context, broadcast *model.NotificationBroadcast) {
+	logger := s.logger.With(zap.Int("broadcastID", broadcast.ID))
+
+	var targetID int
+	if broadcast.AudienceTargetID != nil {
+		targetID = *broadcast.AudienceTargetID
+	}
+	recipientIDs, err := s.resolveAudience(ctx, broadcast.Audience, targetID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		s.failBroadcast(ctx, broadcast.ID, "failed to resolve audience: "+err.Error())
+		return
+	}
+
+	total, err := s.broadcastRepo.Start(ctx, broadcast.ID, recipientIDs)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		s.failBroadcast(ctx, broadcast.ID, "failed to start: "+err.Error())
+		return
+	}
+
+	variables := map[string]string{}
+	if len(broadcast.Variables) > 0 {
+		if err := json.Unmarshal(broadcast.Variables, &variables); err != nil {
+			s.failBroadcast(ctx, broadcast.ID, "invalid template variables: "+err.Error())
+			return
+		}
+	}
+
+	logger.Info("Sending notification broadcast",
+		zap.Int("recipients", total),
+		zap.Int("resumeAfterUserID", broadcast.LastUserID))
+
+	lastUserID := broadcast.LastUserID
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		recipients, err := s.broadcastRepo.GetRecipients(ctx, broadcast.ID, lastUserID, s.batchSize)
+		if err != nil {
+			// Left running; another run resumes it once it's stale
+			logger.Error("Failed to get broadcast recipients", zap.Error(err))
+			return
+		}
+		if len(recipients) == 0 {
+			break
+		}
+
+		var sent, skipped, failed int
+		for _, recipient := range recipients {
+			if ctx.Err() != nil {
+				break
+			}
+
+			id, err := s.notificationService.AddNotification(ctx, &model.NotificationCreate{
+				UserID:   recipient.ID,
+				Type:     broadcast.Type,
+				Category: broadcast.Category,
+				Title:    truncateRunes(renderBroadcastTemplate(broadcast.Title, variables, recipient), maxNotificationTitle),
+				Message:  renderBroadcastTemplate(broadcast.Message, variables, recipient),
+				Link:     stringValue(broadcast.Link),
+			})
+			switch {
+			case err != nil:
+				logger.Warn("Failed to send broadcast notification", zap.Error(err), zap.Int("userID", recipient.ID))
+				failed++
+			case id == 0:
+				// The recipient turned the category off
+				skipped++
+			default:
+				sent++
+			}
+			lastUserID = recipient.ID
+		}
+
+		// Progress is recorded even when shutting down mid-batch, so the recipients already
+		// notified aren't notified again on resume
+		status, err := s.broadcastRepo.RecordProgress(context.WithoutCancel(ctx), broadcast.ID, lastUserID, sent, skipped, failed)
+		if err != nil {
+			logger.Error("Failed to record broadcast progress", zap.Error(err))
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if status != model.BroadcastStatusRunning {
+			logger.Info("Notification broadcast stopped", zap.String("status", status))
+			return
+		}
+	}
+
+	if err := s.broadcastRepo.Complete(ctx, broadcast.ID); err != nil {
+		logger.Error("Failed to complete notification broadcast", zap.Error(err))
+		return
+	}
+	logger.Info("Notification broadcast completed")
+}
+
+// failBroadcast marks a broadcast failed, logging the reason
+func (s *BroadcastService) failBroadcast(ctx context.Context, id int, reason string) {
+	s.logger.Error("Notification broadcast failed", zap.Int("broadcastID", id), zap.String("reason", reason))
+	if err := s.broadcastRepo.Fail(ctx, id, reason); err != nil {
+		s.logger.Error("Failed to mark notification broadcast failed", zap.Error(err), zap.Int("broadcastID", id))
+	}
+}
+
+// resolveAudience returns the IDs of the users of audiences kept by the strategy
+// service, or nil for audiences resolved from the users table
+func (s *BroadcastService) resolveAudience(ctx context.Context, audience string, targetID int) ([]int, error) {
+	switch audience {
+	case model.BroadcastAudienceListingPurchasers:
+		return s.strategyClient.GetListingPurchasers(ctx, targetID)
+	case model.BroadcastAudienceStrategyUsers:
+		return s.strategyClient.GetStrategyUsers(ctx, targetID)
+	}
+	return nil, nil
+}
+
+// validateBroadcastTemplate checks that custom variables have valid names that don't
+// shadow the per-recipient ones, and that the templates only use known variables
+func validateBroadcastTemplate(variables map[string]string, templates ...string) error {
+	for name := range variables {
+		if !broadcastVariableName.MatchString(name) {
+			return fmt.Errorf("invalid template variable name %q; use letters, digits and underscores", name)
+		}
+		if name == broadcastVariableUsername || name == broadcastVariableUserID {
+			return fmt.Errorf("invalid template variable %q; it is filled in per recipient", name)
+		}
+	}
+
+	for _, template := range templates {
+		for _, match := range broadcastPlaceholder.FindAllStringSubmatch(template, -1) {
+			name := match[1]
+			if name == broadcastVariableUsername || name == broadcastVariableUserID {
+				continue
+			}
+			if _, ok := variables[name]; !ok {
+				return fmt.Errorf("invalid template: unknown variable %q", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// renderBroadcastTemplate fills in a template's placeholders for a recipient
+func renderBroadcastTemplate(template string, variables map[string]string, recipient model.BroadcastRecipient) string {
+	return broadcastPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := broadcastPlaceholder.FindStringSubmatch(placeholder)[1]
+		switch name {
+		case broadcastVariableUsername:
+			return recipient.Username
+		case broadcastVariableUserID:
+			return strconv.Itoa(recipient.ID)
+		}
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// setBroadcastProgress fills in the share of a broadcast's recipients processed so far
+func setBroadcastProgress(broadcast *model.NotificationBroadcast) {
+	switch {
+	case broadcast.Status == model.BroadcastStatusCompleted:
+		broadcast.Progress = 1
+	case broadcast.TotalCount > 0:
+		processed := broadcast.SentCount + broadcast.SkippedCount + broadcast.FailedCount
+		broadcast.Progress = min(float64(processed)/float64(broadcast.TotalCount), 1)
+	}
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
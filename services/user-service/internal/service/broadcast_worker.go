@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BroadcastWorker sends due notification broadcasts in the background, so creating one
+// doesn't wait for every recipient to be notified
+type BroadcastWorker struct {
+	broadcastService *BroadcastService
+	interval         time.Duration
+	logger           *zap.Logger
+}
+
+// NewBroadcastWorker creates a new broadcast worker polling at the given interval
+func NewBroadcastWorker(broadcastService *BroadcastService, interval time.Duration, logger *zap.Logger) *BroadcastWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &BroadcastWorker{
+		broadcastService: broadcastService,
+		interval:         interval,
+		logger:           logger,
+	}
+}
+
+// Run sends due broadcasts at start and then on every interval until the context is cancelled
+func (w *BroadcastWorker) Run(ctx context.Context) {
+	w.logger.Info("Starting notification broadcast worker", zap.Duration("interval", w.interval))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.RunOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce sends every broadcast that is due, one after another
+func (w *BroadcastWorker) RunOnce(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := w.broadcastService.SendNextBroadcast(ctx)
+		if err != nil {
+			w.logger.Error("Failed to claim notification broadcast", zap.Error(err))
+			return
+		}
+		if !sent {
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// FollowConsumer reads strategy and marketplace events from Kafka and notifies the
+// followers of the sellers behind them
+type FollowConsumer struct {
+	reader        *kafka.Reader
+	followService *FollowService
+	logger        *zap.Logger
+}
+
+// NewFollowConsumer creates a new follow consumer for a set of event topics
+func NewFollowConsumer(
+	brokers []string,
+	groupID string,
+	topics []string,
+	followService *FollowService,
+	logger *zap.Logger,
+) *FollowConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        groupID,
+		GroupTopics:    topics,
+		MinBytes:       1,
+		MaxBytes:       1 << 20,
+		CommitInterval: time.Second,
+	})
+
+	return &FollowConsumer{
+		reader:        reader,
+		followService: followService,
+		logger:        logger,
+	}
+}
+
+// Run consumes events until the context is cancelled
+func (c *FollowConsumer) Run(ctx context.Context) {
+	c.logger.Info("Starting follow consumer", zap.Strings("topics", c.reader.Config().GroupTopics))
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to read follow event", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.handleMessage(ctx, msg)
+	}
+}
+
+// handleMessage notifies the followers about a single event. Malformed events are logged and skipped.
+func (c *FollowConsumer) handleMessage(ctx context.Context, msg kafka.Message) {
+	var event model.ActivityEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Warn("Skipping malformed follow event",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	if event.UserID == 0 || event.EventType == "" {
+		return
+	}
+
+	if err := c.followService.NotifyFollowers(ctx, &event); err != nil {
+		c.logger.Error("Failed to notify followers",
+			zap.Error(err),
+			zap.Int("userID", event.UserID),
+			zap.String("eventType", event.EventType))
+	}
+}
+
+// Close closes the underlying Kafka reader
+func (c *FollowConsumer) Close() error {
+	return c.reader.Close()
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Event types of the strategy and marketplace topics followers are notified about
+const (
+	eventListingCreated   = "listing_created"
+	eventVersionPublished = "strategy_version_published"
+)
+
+// FollowService handles users following sellers and notifying followers of their new
+// listings and strategy versions
+type FollowService struct {
+	followRepo          *repository.FollowRepository
+	userRepo            *repository.UserRepository
+	notificationService *NotificationService
+	logger              *zap.Logger
+}
+
+// NewFollowService creates a new follow service
+func NewFollowService(
+	followRepo *repository.FollowRepository,
+	userRepo *repository.UserRepository,
+	notificationService *NotificationService,
+	logger *zap.Logger,
+) *FollowService {
+	return &FollowService{
+		followRepo:          followRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// Follow makes a user follow another active user. Following someone twice is a no-op.
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID int) (*model.FollowCounts, error) {
+	if followerID == followeeID {
+		return nil, errcode.ErrCannotFollowSelf
+	}
+
+	followee, err := s.userRepo.GetByID(ctx, followeeID)
+	if err != nil {
+		return nil, err
+	}
+	if followee == nil || !followee.IsActive {
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	if _, err := s.followRepo.Follow(ctx, followerID, followeeID); err != nil {
+		return nil, err
+	}
+
+	return s.followRepo.GetCounts(ctx, followeeID)
+}
+
+// Unfollow makes a user stop following another. Unfollowing someone not followed is a no-op.
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID int) (*model.FollowCounts, error) {
+	if _, err := s.followRepo.Unfollow(ctx, followerID, followeeID); err != nil {
+		return nil, err
+	}
+
+	return s.followRepo.GetCounts(ctx, followeeID)
+}
+
+// GetFollowers retrieves a page of a user's followers, newest first, with the total count
+func (s *FollowService) GetFollowers(ctx context.Context, userID int, limit, offset int) ([]model.FollowedUser, int, error) {
+	return s.getFollows(ctx, userID, true, limit, offset)
+}
+
+// GetFollowing retrieves a page of the users a user follows, newest first, with the total count
+func (s *FollowService) GetFollowing(ctx context.Context, userID int, limit, offset int) ([]model.FollowedUser, int, error) {
+	return s.getFollows(ctx, userID, false, limit, offset)
+}
+
+func (s *FollowService) getFollows(ctx context.Context, userID int, followers bool, limit, offset int) ([]model.FollowedUser, int, error) {
+	counts, err := s.followRepo.GetCounts(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.followRepo.GetFollows(ctx, userID, followers, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := counts.FollowingCount
+	if followers {
+		total = counts.FollowersCount
+	}
+
+	return users, total, nil
+}
+
+// NotifyFollowers notifies the followers of a seller about a strategy or marketplace event:
+// all of them about a new listing, and those who bought the strategy about a new version.
+// Other events are ignored.
+func (s *FollowService) NotifyFollowers(ctx context.Context, event *model.ActivityEvent) error {
+	var among []int
+	switch event.EventType {
+	case eventListingCreated:
+	case eventVersionPublished:
+		if len(event.BuyerIDs) == 0 {
+			return nil
+		}
+		among = event.BuyerIDs
+	default:
+		return nil
+	}
+
+	followerIDs, err := s.followRepo.GetFollowerIDs(ctx, event.UserID, among)
+	if err != nil {
+		return err
+	}
+	if len(followerIDs) == 0 {
+		return nil
+	}
+
+	seller := fmt.Sprintf("User %d", event.UserID)
+	if user, err := s.userRepo.GetByID(ctx, event.UserID); err == nil && user != nil {
+		seller = user.Username
+	}
+
+	name := event.EntityName
+	if name == "" {
+		name = fmt.Sprintf("#%d", event.EntityID)
+	}
+
+	notification := model.NotificationCreate{
+		Category: model.NotificationCategoryMarketplace,
+	}
+	if event.EventType == eventListingCreated {
+		notification.Type = model.NotificationFollowedListing
+		notification.Title = fmt.Sprintf("New strategy from %s", seller)
+		notification.Message = fmt.Sprintf("%s listed %s on the marketplace.", seller, name)
+		notification.Link = fmt.Sprintf("/marketplace/%d", event.EntityID)
+	} else {
+		notification.Type = model.NotificationVersionUpdate
+		notification.Title = fmt.Sprintf("New version of %s", name)
+		notification.Message = fmt.Sprintf("%s published a new version of %s, which you bought.", seller, name)
+		notification.Link = fmt.Sprintf("/strategies/%d", event.EntityID)
+	}
+
+	for _, followerID := range followerIDs {
+		notification.UserID = followerID
+		if _, err := s.notificationService.AddNotification(ctx, &notification); err != nil {
+			s.logger.Error("Failed to notify follower",
+				zap.Error(err),
+				zap.Int("followerID", followerID),
+				zap.String("eventType", event.EventType))
+		}
+	}
+
+	return nil
+}
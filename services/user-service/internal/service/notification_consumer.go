@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// NotificationConsumer reads notification events from Kafka, stores them and
+// pushes them to connected websocket clients. Besides the notifications topic it reads
+// the marketplace and backtest event topics, turning purchases, reviews and finished
+// backtests into notifications.
+type NotificationConsumer struct {
+	reader              *kafka.Reader
+	notificationsTopic  string
+	notificationService *NotificationService
+	userRepo            *repository.UserRepository
+	logger              *zap.Logger
+}
+
+// NewNotificationConsumer creates a new notification consumer for the notifications
+// topic and a set of event topics
+func NewNotificationConsumer(
+	brokers []string,
+	groupID string,
+	notificationsTopic string,
+	eventTopics []string,
+	notificationService *NotificationService,
+	userRepo *repository.UserRepository,
+	logger *zap.Logger,
+) *NotificationConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        groupID,
+		GroupTopics:    append([]string{notificationsTopic}, eventTopics...),
+		MinBytes:       1,
+		MaxBytes:       1 << 20,
+		CommitInterval: time.Second,
+	})
+
+	return &NotificationConsumer{
+		reader:              reader,
+		notificationsTopic:  notificationsTopic,
+		notificationService: notificationService,
+		userRepo:            userRepo,
+		logger:              logger,
+	}
+}
+
+// Run consumes events until the context is cancelled
+func (c *NotificationConsumer) Run(ctx context.Context) {
+	c.logger.Info("Starting notification consumer", zap.Strings("topics", c.reader.Config().GroupTopics))
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to read notification event", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.handleMessage(ctx, msg)
+	}
+}
+
+// handleMessage stores the notifications of a single event. Malformed events are logged
+// and skipped.
+func (c *NotificationConsumer) handleMessage(ctx context.Context, msg kafka.Message) {
+	if msg.Topic != c.notificationsTopic {
+		c.handleDomainEvent(ctx, msg)
+		return
+	}
+
+	var event model.NotificationEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Warn("Skipping malformed notification event",
+			zap.Error(err),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	if event.UserID == 0 || event.Type == "" || event.Title == "" {
+		c.logger.Warn("Skipping incomplete notification event",
+			zap.Int("userID", event.UserID),
+			zap.String("type", event.Type),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	c.store(ctx, &model.NotificationCreate{
+		UserID:   event.UserID,
+		Type:     event.Type,
+		Title:    event.Title,
+		Message:  event.Message,
+		Link:     event.Link,
+		Category: event.Category,
+	})
+}
+
+// handleDomainEvent stores the notification of a marketplace or backtest event, if it
+// has one. Events of other types are skipped.
+func (c *NotificationConsumer) handleDomainEvent(ctx context.Context, msg kafka.Message) {
+	var event model.ActivityEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Warn("Skipping malformed event",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int64("offset", msg.Offset))
+		return
+	}
+
+	if notification, ok := c.notificationForEvent(ctx, &event); ok {
+		c.store(ctx, notification)
+	}
+}
+
+// notificationForEvent returns the notification an event sends, if any: sellers hear of
+// purchases and reviews of their listings, users of their finished backtests
+func (c *NotificationConsumer) notificationForEvent(ctx context.Context, event *model.ActivityEvent) (*model.NotificationCreate, bool) {
+	name := event.EntityName
+	if name == "" && event.EntityID != 0 {
+		name = fmt.Sprintf("#%d", event.EntityID)
+	}
+
+	switch event.EventType {
+	case model.ActivityStrategyPurchased:
+		if event.OwnerID == 0 || event.OwnerID == event.UserID {
+			return nil, false
+		}
+		return &model.NotificationCreate{
+			UserID:  event.OwnerID,
+			Type:    "purchase",
+			Title:   "Your strategy was purchased",
+			Message: fmt.Sprintf("%s purchased your strategy %s.", c.username(ctx, event.UserID), name),
+			Link:    fmt.Sprintf("/marketplace/%d", event.EntityID),
+		}, true
+	case model.EventReviewCreated:
+		if event.OwnerID == 0 || event.OwnerID == event.UserID {
+			return nil, false
+		}
+		return &model.NotificationCreate{
+			UserID:  event.OwnerID,
+			Type:    "review_received",
+			Title:   "New review of your strategy",
+			Message: fmt.Sprintf("%s reviewed your strategy %s.", c.username(ctx, event.UserID), name),
+			Link:    fmt.Sprintf("/marketplace/%d", event.EntityID),
+		}, true
+	case model.ActivityBacktestCompleted:
+		return &model.NotificationCreate{
+			UserID:  event.UserID,
+			Type:    "backtest_complete",
+			Title:   "Backtest completed",
+			Message: fmt.Sprintf("Backtest %s completed.", name),
+			Link:    fmt.Sprintf("/backtests/%d", event.EntityID),
+		}, event.UserID != 0
+	case model.ActivityBacktestFailed:
+		return &model.NotificationCreate{
+			UserID:  event.UserID,
+			Type:    "backtest_failed",
+			Title:   "Backtest failed",
+			Message: fmt.Sprintf("Backtest %s failed.", name),
+			Link:    fmt.Sprintf("/backtests/%d", event.EntityID),
+		}, event.UserID != 0
+	}
+
+	return nil, false
+}
+
+// store adds a notification, logging rather than failing when it can't be stored
+func (c *NotificationConsumer) store(ctx context.Context, notification *model.NotificationCreate) {
+	if _, err := c.notificationService.AddNotification(ctx, notification); err != nil {
+		c.logger.Error("Failed to store notification event",
+			zap.Error(err),
+			zap.Int("userID", notification.UserID),
+			zap.String("type", notification.Type))
+	}
+}
+
+// username returns the username of a user, or a placeholder if it can't be loaded
+func (c *NotificationConsumer) username(ctx context.Context, userID int) string {
+	user, err := c.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Sprintf("User %d", userID)
+	}
+	return user.Username
+}
+
+// Close closes the underlying Kafka reader
+func (c *NotificationConsumer) Close() error {
+	return c.reader.Close()
+}
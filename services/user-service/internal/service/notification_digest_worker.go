@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NotificationDigestWorker builds the daily notification digests once a day at a set hour
+type NotificationDigestWorker struct {
+	notificationService *NotificationService
+	hour                int
+	logger              *zap.Logger
+}
+
+// NewNotificationDigestWorker creates a new digest worker running at the given hour (UTC)
+func NewNotificationDigestWorker(notificationService *NotificationService, hour int, logger *zap.Logger) *NotificationDigestWorker {
+	if hour < 0 || hour > 23 {
+		hour = 8
+	}
+	return &NotificationDigestWorker{
+		notificationService: notificationService,
+		hour:                hour,
+		logger:              logger,
+	}
+}
+
+// Run builds the digests every day at the configured hour until the context is cancelled
+func (w *NotificationDigestWorker) Run(ctx context.Context) {
+	w.logger.Info("Starting notification digest worker", zap.Int("hour", w.hour))
+
+	for {
+		next := nextDigestTime(time.Now().UTC(), w.hour)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		w.RunOnce(ctx, next)
+	}
+}
+
+// RunOnce collapses the notifications received before the given time into digests.
+// Failures are logged; the next day's digest picks up anything left over.
+func (w *NotificationDigestWorker) RunOnce(ctx context.Context, before time.Time) {
+	count, err := w.notificationService.CreateDigests(ctx, before)
+	if err != nil {
+		w.logger.Error("Notification digest run failed", zap.Error(err))
+		return
+	}
+
+	if count > 0 {
+		w.logger.Info("Created notification digests", zap.Int("count", count))
+	}
+}
+
+// nextDigestTime returns the next time after now at the given hour (UTC)
+func nextDigestTime(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
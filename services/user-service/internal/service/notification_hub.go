@@ -0,0 +1,74 @@
+package service
+
+import (
+	"sync"
+
+	"services/user-service/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// notificationBufferSize is how many pushes a slow client may fall behind before
+// messages are dropped for it. Dropped pushes are still readable via the REST API.
+const notificationBufferSize = 32
+
+// NotificationHub fans notification pushes out to connected websocket clients
+type NotificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[chan *model.NotificationPush]struct{}
+	logger      *zap.Logger
+}
+
+// NewNotificationHub creates a new notification hub
+func NewNotificationHub(logger *zap.Logger) *NotificationHub {
+	return &NotificationHub{
+		subscribers: make(map[int]map[chan *model.NotificationPush]struct{}),
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a client for a user's pushes. The returned function must be
+// called when the client disconnects.
+func (h *NotificationHub) Subscribe(userID int) (<-chan *model.NotificationPush, func()) {
+	ch := make(chan *model.NotificationPush, notificationBufferSize)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan *model.NotificationPush]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends a push to every client connected as the given user
+func (h *NotificationHub) Publish(userID int, push *model.NotificationPush) {
+	if h == nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- push:
+		default:
+			h.logger.Warn("Dropping notification push for slow client", zap.Int("userID", userID))
+		}
+	}
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/repository"
 
@@ -14,6 +17,9 @@ import (
 type NotificationService struct {
 	notificationRepo *repository.NotificationRepository
 	userRepo         *repository.UserRepository
+	hub              *NotificationHub
+	batchWindow      time.Duration
+	digestEnabled    bool
 	logger           *zap.Logger
 }
 
@@ -21,11 +27,17 @@ type NotificationService struct {
 func NewNotificationService(
 	notificationRepo *repository.NotificationRepository,
 	userRepo *repository.UserRepository,
+	hub *NotificationHub,
+	batchWindow time.Duration,
+	digestEnabled bool,
 	logger *zap.Logger,
 ) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
 		userRepo:         userRepo,
+		hub:              hub,
+		batchWindow:      batchWindow,
+		digestEnabled:    digestEnabled,
 		logger:           logger,
 	}
 }
@@ -38,7 +50,7 @@ func (s *NotificationService) GetActiveNotifications(ctx context.Context, userID
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("user not found or inactive")
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	return s.notificationRepo.GetActiveNotifications(ctx, userID)
@@ -52,18 +64,19 @@ func (s *NotificationService) GetUnreadCount(ctx context.Context, userID int) (i
 		return 0, err
 	}
 	if !exists {
-		return 0, errors.New("user not found or inactive")
+		return 0, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	return s.notificationRepo.GetUnreadCount(ctx, userID)
 }
 
-// GetAllNotifications retrieves all notifications for a user with pagination
+// GetAllNotifications retrieves all notifications for a user with pagination, optionally of one category
 func (s *NotificationService) GetAllNotifications(
 	ctx context.Context,
 	userID int,
 	limit int,
 	offset int,
+	category string,
 ) ([]model.Notification, error) {
 	// Check if user exists and is active
 	exists, err := s.checkUserActive(ctx, userID)
@@ -71,7 +84,7 @@ func (s *NotificationService) GetAllNotifications(
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("user not found or inactive")
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	// Set default values for limit and offset
@@ -82,7 +95,7 @@ func (s *NotificationService) GetAllNotifications(
 		offset = 0
 	}
 
-	return s.notificationRepo.GetAllNotifications(ctx, userID, limit, offset)
+	return s.notificationRepo.GetAllNotifications(ctx, userID, limit, offset, category)
 }
 
 // MarkNotificationAsRead marks a notification as read
@@ -102,10 +115,15 @@ func (s *NotificationService) MarkNotificationAsRead(ctx context.Context, notifi
 		return false, err
 	}
 	if !exists {
-		return false, errors.New("user not found or inactive")
+		return false, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
-	return s.notificationRepo.MarkNotificationAsRead(ctx, notificationID)
+	success, err := s.notificationRepo.MarkNotificationAsRead(ctx, notificationID)
+	if err == nil && success {
+		s.push(ctx, notification.UserID, "read", nil)
+	}
+
+	return success, err
 }
 
 // MarkAllNotificationsAsRead marks all notifications for a user as read
@@ -116,13 +134,21 @@ func (s *NotificationService) MarkAllNotificationsAsRead(ctx context.Context, us
 		return 0, err
 	}
 	if !exists {
-		return 0, errors.New("user not found or inactive")
+		return 0, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
-	return s.notificationRepo.MarkAllNotificationsAsRead(ctx, userID)
+	count, err := s.notificationRepo.MarkAllNotificationsAsRead(ctx, userID)
+	if err == nil && count > 0 {
+		s.push(ctx, userID, "read", nil)
+	}
+
+	return count, err
 }
 
-// AddNotification adds a new notification for a user
+// AddNotification adds a new notification for a user, following the user's delivery
+// preference of its category. Repeated events of the same type within the batch window are
+// merged into one notification; security notifications are never merged or dropped.
+// Returns 0 if the user turned the category off.
 func (s *NotificationService) AddNotification(ctx context.Context, notification *model.NotificationCreate) (int, error) {
 	// Check if user exists and is active
 	exists, err := s.checkUserActive(ctx, notification.UserID)
@@ -130,17 +156,139 @@ func (s *NotificationService) AddNotification(ctx context.Context, notification
 		return 0, err
 	}
 	if !exists {
-		return 0, errors.New("user not found or inactive")
+		return 0, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
-	return s.notificationRepo.AddNotification(
+	category := notification.Category
+	if !model.IsNotificationCategory(category) {
+		category = model.NotificationCategoryForType(notification.Type)
+	}
+
+	delivery := model.NotificationDeliveryInstant
+	batchWindow := s.batchWindow
+	if category == model.NotificationCategorySecurity {
+		batchWindow = 0
+	} else {
+		delivery, err = s.notificationRepo.GetDelivery(ctx, notification.UserID, category)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if delivery == model.NotificationDeliveryOff {
+		s.logger.Debug("Dropping notification of a category the user turned off",
+			zap.Int("userID", notification.UserID),
+			zap.String("category", category))
+		return 0, nil
+	}
+
+	id, err := s.notificationRepo.AddNotification(
 		ctx,
 		notification.UserID,
 		notification.Type,
+		category,
 		notification.Title,
 		notification.Message,
 		notification.Link,
+		batchWindow,
 	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Digest categories wait for the daily digest
+	if delivery == model.NotificationDeliveryDigest && s.digestEnabled {
+		return id, nil
+	}
+
+	// Push to any connected websocket clients
+	created, err := s.notificationRepo.GetNotificationByID(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to load notification for push", zap.Error(err), zap.Int("notificationID", id))
+	} else if created != nil {
+		s.push(ctx, notification.UserID, "notification", created)
+	}
+
+	return id, nil
+}
+
+// GetDigestNotifications retrieves the notifications collapsed into one of the user's digests
+func (s *NotificationService) GetDigestNotifications(ctx context.Context, digestID, userID int) ([]model.Notification, error) {
+	digest, err := s.notificationRepo.GetNotificationByID(ctx, digestID)
+	if err != nil {
+		return nil, err
+	}
+	if digest == nil || digest.UserID != userID || digest.Type != model.NotificationTypeDigest {
+		return nil, errcode.ErrDigestNotFound
+	}
+
+	return s.notificationRepo.GetDigestNotifications(ctx, digestID, userID)
+}
+
+// CreateDigests collapses the unread notifications of every user's digest categories into
+// one digest notification per user and pushes the digests. Returns the number of digests.
+func (s *NotificationService) CreateDigests(ctx context.Context, before time.Time) (int, error) {
+	digests, err := s.notificationRepo.CreateDigests(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, digest := range digests {
+		created, err := s.notificationRepo.GetNotificationByID(ctx, digest.DigestID)
+		if err != nil {
+			s.logger.Warn("Failed to load digest for push", zap.Error(err), zap.Int("notificationID", digest.DigestID))
+			continue
+		}
+		if created != nil {
+			s.push(ctx, digest.UserID, "notification", created)
+		}
+	}
+
+	return len(digests), nil
+}
+
+// GetPreferences retrieves the user's delivery setting of every notification category
+func (s *NotificationService) GetPreferences(ctx context.Context, userID int) ([]model.NotificationPreference, error) {
+	exists, err := s.checkUserActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	return s.notificationRepo.GetPreferences(ctx, userID)
+}
+
+// UpdatePreferences sets the user's delivery settings of the given categories and returns
+// the settings of every category. Security notifications are always delivered instantly.
+func (s *NotificationService) UpdatePreferences(
+	ctx context.Context,
+	userID int,
+	update *model.NotificationPreferencesUpdate,
+) ([]model.NotificationPreference, error) {
+	exists, err := s.checkUserActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	for _, preference := range update.Preferences {
+		if preference.Category == model.NotificationCategorySecurity && preference.Delivery != model.NotificationDeliveryInstant {
+			return nil, fmt.Errorf("invalid preference: %s notifications are always delivered instantly", preference.Category)
+		}
+		if preference.Delivery == model.NotificationDeliveryDigest && !s.digestEnabled {
+			return nil, errors.New("invalid preference: daily digests are not enabled")
+		}
+	}
+
+	if err := s.notificationRepo.SetPreferences(ctx, userID, update.Preferences); err != nil {
+		return nil, err
+	}
+
+	return s.notificationRepo.GetPreferences(ctx, userID)
 }
 
 // DeleteUserNotifications deletes all notifications for a user
@@ -151,12 +299,30 @@ func (s *NotificationService) DeleteUserNotifications(ctx context.Context, userI
 		return 0, err
 	}
 	if user == nil {
-		return 0, errors.New("user not found")
+		return 0, errcode.ErrUserNotFound
 	}
 
 	return s.notificationRepo.DeleteUserNotifications(ctx, userID)
 }
 
+// push sends a notification event with the current unread count to the user's connected clients
+func (s *NotificationService) push(ctx context.Context, userID int, event string, notification *model.Notification) {
+	if s.hub == nil {
+		return
+	}
+
+	unread, err := s.notificationRepo.GetUnreadCount(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get unread count for push", zap.Error(err), zap.Int("userID", userID))
+	}
+
+	s.hub.Publish(userID, &model.NotificationPush{
+		Event:        event,
+		Notification: notification,
+		UnreadCount:  unread,
+	})
+}
+
 // checkUserActive checks if a user exists and is active
 func (s *NotificationService) checkUserActive(ctx context.Context, userID int) (bool, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
 
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/repository"
 
@@ -15,19 +19,26 @@ import (
 type PreferenceService struct {
 	preferenceRepo *repository.PreferenceRepository
 	userRepo       *repository.UserRepository
-	logger         *zap.Logger
+	// Quota of a user's workspaces: their total size and how many there can be
+	workspaceMaxBytes int
+	workspaceMaxCount int
+	logger            *zap.Logger
 }
 
 // NewPreferenceService creates a new preference service
 func NewPreferenceService(
 	preferenceRepo *repository.PreferenceRepository,
 	userRepo *repository.UserRepository,
+	workspaceMaxBytes int,
+	workspaceMaxCount int,
 	logger *zap.Logger,
 ) *PreferenceService {
 	return &PreferenceService{
-		preferenceRepo: preferenceRepo,
-		userRepo:       userRepo,
-		logger:         logger,
+		preferenceRepo:    preferenceRepo,
+		userRepo:          userRepo,
+		workspaceMaxBytes: workspaceMaxBytes,
+		workspaceMaxCount: workspaceMaxCount,
+		logger:            logger,
 	}
 }
 
@@ -39,7 +50,7 @@ func (s *PreferenceService) GetPreferences(ctx context.Context, userID int) (*mo
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("user not found or inactive")
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	return s.preferenceRepo.GetPreferences(ctx, userID)
@@ -53,7 +64,7 @@ func (s *PreferenceService) UpdatePreferences(ctx context.Context, userID int, p
 		return err
 	}
 	if !exists {
-		return errors.New("user not found or inactive")
+		return errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	// Validate JSON objects if provided
@@ -100,7 +111,7 @@ func (s *PreferenceService) CheckPreferencesExist(ctx context.Context, userID in
 		return false, err
 	}
 	if user == nil {
-		return false, errors.New("user not found")
+		return false, errcode.ErrUserNotFound
 	}
 
 	return s.preferenceRepo.CheckPreferencesExist(ctx, userID)
@@ -114,7 +125,7 @@ func (s *PreferenceService) DeletePreferences(ctx context.Context, userID int) e
 		return err
 	}
 	if user == nil {
-		return errors.New("user not found")
+		return errcode.ErrUserNotFound
 	}
 
 	success, err := s.preferenceRepo.DeletePreferences(ctx, userID)
@@ -137,7 +148,7 @@ func (s *PreferenceService) ResetPreferences(ctx context.Context, userID int) er
 		return err
 	}
 	if !exists {
-		return errors.New("user not found or inactive")
+		return errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	// Delete existing preferences
@@ -168,6 +179,138 @@ func (s *PreferenceService) ResetPreferences(ctx context.Context, userID int) er
 	return nil
 }
 
+// workspaceNamePattern matches valid workspace names, e.g. "default" or "charts-2"
+var workspaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// validateWorkspaceName rejects names that aren't safe to use in a URL path
+func validateWorkspaceName(name string) error {
+	if !workspaceNamePattern.MatchString(name) {
+		return errors.New("invalid workspace name: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	return nil
+}
+
+// GetWorkspace gets a workspace of a user by name
+func (s *PreferenceService) GetWorkspace(ctx context.Context, userID int, name string) (*model.Workspace, error) {
+	if err := validateWorkspaceName(name); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.preferenceRepo.GetWorkspace(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, errcode.ErrWorkspaceNotFound
+	}
+
+	return workspace, nil
+}
+
+// ListWorkspaces lists a user's workspaces and how much of the quota they use
+func (s *PreferenceService) ListWorkspaces(ctx context.Context, userID int) (*model.WorkspaceList, error) {
+	workspaces, err := s.preferenceRepo.ListWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &model.WorkspaceList{
+		Workspaces:    workspaces,
+		MaxBytes:      s.workspaceMaxBytes,
+		MaxWorkspaces: s.workspaceMaxCount,
+	}
+	for _, workspace := range workspaces {
+		list.UsedBytes += workspace.SizeBytes
+	}
+
+	return list, nil
+}
+
+// SaveWorkspace creates or replaces a workspace if it still has expectedVersion (see
+// PreferenceRepository.SaveWorkspace) and it fits the user's quota. It reports whether
+// the workspace was created.
+func (s *PreferenceService) SaveWorkspace(
+	ctx context.Context,
+	userID int,
+	name string,
+	layout json.RawMessage,
+	expectedVersion *int,
+) (*model.Workspace, bool, error) {
+	if err := validateWorkspaceName(name); err != nil {
+		return nil, false, err
+	}
+
+	// Layouts are stored compacted, which is also the size counted against the quota
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, layout); err != nil || !bytes.HasPrefix(compacted.Bytes(), []byte("{")) {
+		return nil, false, errors.New("invalid workspace layout: must be a JSON object")
+	}
+	layout = compacted.Bytes()
+
+	quotaErr := errcode.ErrWorkspaceQuota.WithMessage(
+		fmt.Sprintf("Workspaces can use at most %d bytes in total", s.workspaceMaxBytes))
+	if len(layout) > s.workspaceMaxBytes {
+		return nil, false, quotaErr
+	}
+
+	result, version, err := s.preferenceRepo.SaveWorkspace(
+		ctx,
+		userID,
+		name,
+		layout,
+		expectedVersion,
+		s.workspaceMaxBytes,
+		s.workspaceMaxCount,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch result {
+	case repository.WorkspaceSaved:
+	case repository.WorkspaceVersionMismatch:
+		return nil, false, errcode.ErrWorkspaceModified
+	case repository.WorkspaceQuotaExceeded:
+		return nil, false, quotaErr
+	case repository.WorkspaceLimitReached:
+		return nil, false, errcode.ErrWorkspaceQuota.WithMessage(
+			fmt.Sprintf("At most %d workspaces can be saved", s.workspaceMaxCount))
+	default:
+		return nil, false, fmt.Errorf("unexpected result saving workspace: %s", result)
+	}
+
+	workspace, err := s.GetWorkspace(ctx, userID, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return workspace, version == 1, nil
+}
+
+// DeleteWorkspace deletes a workspace if it still has expectedVersion; nil deletes it
+// whatever its version
+func (s *PreferenceService) DeleteWorkspace(ctx context.Context, userID int, name string, expectedVersion *int) error {
+	if err := validateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	result, err := s.preferenceRepo.DeleteWorkspace(ctx, userID, name, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	switch result {
+	case repository.WorkspaceDeleted:
+		return nil
+	case repository.WorkspaceNotFound:
+		return errcode.ErrWorkspaceNotFound
+	case repository.WorkspaceVersionMismatch:
+		return errcode.ErrWorkspaceModified
+	default:
+		return fmt.Errorf("unexpected result deleting workspace: %s", result)
+	}
+}
+
 // checkUserActive checks if a user exists and is active
 func (s *PreferenceService) checkUserActive(ctx context.Context, userID int) (bool, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
@@ -3,8 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
+	"strings"
+
+	"services/shared/apierror"
 
 	"services/user-service/internal/client"
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/repository"
 
@@ -13,24 +18,30 @@ import (
 
 // ProfileService handles profile-related operations
 type ProfileService struct {
-	profileRepo *repository.ProfileRepository
-	userRepo    *repository.UserRepository
-	mediaClient *client.MediaClient
-	logger      *zap.Logger
+	profileRepo    *repository.ProfileRepository
+	userRepo       *repository.UserRepository
+	followRepo     *repository.FollowRepository
+	mediaClient    *client.MediaClient
+	strategyClient *client.StrategyClient
+	logger         *zap.Logger
 }
 
 // NewProfileService creates a new profile service
 func NewProfileService(
 	profileRepo *repository.ProfileRepository,
 	userRepo *repository.UserRepository,
+	followRepo *repository.FollowRepository,
 	mediaClient *client.MediaClient,
+	strategyClient *client.StrategyClient,
 	logger *zap.Logger,
 ) *ProfileService {
 	return &ProfileService{
-		profileRepo: profileRepo,
-		userRepo:    userRepo,
-		mediaClient: mediaClient,
-		logger:      logger,
+		profileRepo:    profileRepo,
+		userRepo:       userRepo,
+		followRepo:     followRepo,
+		mediaClient:    mediaClient,
+		strategyClient: strategyClient,
+		logger:         logger,
 	}
 }
 
@@ -42,7 +53,7 @@ func (s *ProfileService) GetProfilePhotoURL(ctx context.Context, userID int) (st
 		return "", err
 	}
 	if !exists {
-		return "", errors.New("user not found or inactive")
+		return "", errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	return s.profileRepo.GetProfilePhotoURL(ctx, userID)
@@ -56,7 +67,7 @@ func (s *ProfileService) UpdateProfilePhoto(ctx context.Context, userID int, pho
 		return err
 	}
 	if !exists {
-		return errors.New("user not found or inactive")
+		return errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	success, err := s.profileRepo.UpdateProfilePhoto(ctx, userID, photoURL)
@@ -85,7 +96,7 @@ func (s *ProfileService) UploadProfilePhoto(
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("user not found or inactive")
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	// Upload to media service
@@ -106,21 +117,22 @@ func (s *ProfileService) UploadProfilePhoto(
 		return nil, errors.New("failed to update profile photo URL")
 	}
 
-	// Convert client.Thumbnail to model.Thumbnail
-	var thumbnails []model.Thumbnail
-	for _, t := range mediaFile.Thumbnails {
-		thumbnails = append(thumbnails, model.Thumbnail{
-			Name:   t.Name,
-			URL:    t.URL,
-			Width:  t.Width,
-			Height: t.Height,
+	// Convert client.MediaVariant to model.ImageVariant
+	var variants []model.ImageVariant
+	for _, v := range mediaFile.Variants {
+		variants = append(variants, model.ImageVariant{
+			Name:        v.Name,
+			URL:         v.URL,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
 		})
 	}
 
-	// Return response with converted thumbnails
+	// Return response with converted variants
 	response := &model.ProfilePhotoResponse{
-		URL:        mediaFile.URL,
-		Thumbnails: thumbnails,
+		URL:      mediaFile.URL,
+		Variants: variants,
 	}
 
 	return response, nil
@@ -134,7 +146,7 @@ func (s *ProfileService) ClearProfilePhoto(ctx context.Context, userID int) erro
 		return err
 	}
 	if !exists {
-		return errors.New("user not found or inactive")
+		return errcode.ErrUserNotFound.WithMessage("User not found or inactive")
 	}
 
 	// Get current photo URL for potential cleanup
@@ -164,6 +176,66 @@ func (s *ProfileService) ClearProfilePhoto(ctx context.Context, userID int) erro
 	return nil
 }
 
+// GetPublicProfile gets the public profile of a user with their follower and marketplace
+// counts. The marketplace counts are left out rather than failing the request when the
+// strategy service is down.
+func (s *ProfileService) GetPublicProfile(ctx context.Context, userID int) (*model.PublicProfile, error) {
+	profile, err := s.profileRepo.GetPublicProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	counts, err := s.followRepo.GetCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile.FollowCounts = *counts
+
+	stats, err := s.strategyClient.GetSellerStats(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get seller stats for public profile", zap.Error(err), zap.Int("userID", userID))
+	} else {
+		profile.Stats = stats
+	}
+
+	return profile, nil
+}
+
+// SearchPublicProfiles searches the public profiles of active users by username or display name
+func (s *ProfileService) SearchPublicProfiles(ctx context.Context, search string, limit int) ([]model.ProfileSearchResult, error) {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "Search term is required")
+	}
+
+	return s.profileRepo.SearchPublicProfiles(ctx, search, limit)
+}
+
+// UpdateProfileDetails updates the display name, bio and social links of a user's public profile
+func (s *ProfileService) UpdateProfileDetails(ctx context.Context, userID int, update *model.ProfileDetailsUpdate) (*model.PublicProfile, error) {
+	// Check if user exists and is active
+	exists, err := s.checkUserActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errcode.ErrUserNotFound.WithMessage("User not found or inactive")
+	}
+
+	success, err := s.profileRepo.UpdateProfileDetails(ctx, userID, update)
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		return nil, errors.New("failed to update profile details")
+	}
+
+	return s.GetPublicProfile(ctx, userID)
+}
+
 // checkUserActive checks if a user exists and is active
 func (s *ProfileService) checkUserActive(ctx context.Context, userID int) (bool, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
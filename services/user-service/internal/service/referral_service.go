@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"services/user-service/internal/config"
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Thresholds above which a referrer's referrals are flagged in the fraud report
+const (
+	// fraudSharedIPReferrals is how many referees signing up from a shared IP are suspicious
+	fraudSharedIPReferrals = 2
+	// fraudBurstReferrals is how many referrals on a single day are suspicious
+	fraudBurstReferrals = 10
+	// fraudDormantMinReferrals is how many referrals a referrer needs before the share of
+	// dormant referees is considered
+	fraudDormantMinReferrals = 3
+)
+
+// ReferralService handles users inviting others with referral codes, the credit both
+// earn for it and the credit spent on marketplace purchases
+type ReferralService struct {
+	referralRepo *repository.ReferralRepository
+	cfg          config.ReferralsConfig
+	logger       *zap.Logger
+}
+
+// NewReferralService creates a new referral service
+func NewReferralService(referralRepo *repository.ReferralRepository, cfg config.ReferralsConfig, logger *zap.Logger) *ReferralService {
+	return &ReferralService{
+		referralRepo: referralRepo,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+// GetSummary retrieves a user's referral code, created the first time, with their
+// referral statistics
+func (s *ReferralService) GetSummary(ctx context.Context, userID int) (*model.ReferralSummary, error) {
+	if !s.cfg.Enabled {
+		return nil, errcode.ErrReferralsDisabled
+	}
+
+	code, err := s.referralRepo.GetOrCreateCode(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.referralRepo.GetStats(ctx, userID, s.cfg.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ReferralSummary{
+		Code:           code,
+		Currency:       s.cfg.Currency,
+		Stats:          *stats,
+		ReferrerCredit: s.cfg.ReferrerCredit,
+		RefereeCredit:  s.cfg.RefereeCredit,
+	}, nil
+}
+
+// AttributeRegistration records that a new user registered with a referral code and
+// credits the rewards. Unknown codes are ignored, so a mistyped code never fails a
+// registration.
+func (s *ReferralService) AttributeRegistration(ctx context.Context, code string, refereeID int, signupIP, signupUserAgent string) error {
+	code = strings.TrimSpace(code)
+	if !s.cfg.Enabled || code == "" {
+		return nil
+	}
+
+	referral, err := s.referralRepo.RecordReferral(ctx, code, refereeID, signupIP, signupUserAgent)
+	if err != nil {
+		return err
+	}
+	if referral == nil {
+		s.logger.Debug("Ignoring unknown referral code",
+			zap.String("code", code),
+			zap.Int("referee_id", refereeID))
+		return nil
+	}
+
+	rewarded, err := s.referralRepo.GrantRewards(ctx, referral.ID, s.cfg.ReferrerCredit, s.cfg.RefereeCredit, s.cfg.Currency, s.cfg.MaxRewardedReferrals)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Recorded referral",
+		zap.Int("referral_id", referral.ID),
+		zap.Int("referrer_id", referral.ReferrerID),
+		zap.Int("referee_id", refereeID),
+		zap.Bool("referrer_rewarded", rewarded))
+	return nil
+}
+
+// GetCredit retrieves a user's referral credit balance
+func (s *ReferralService) GetCredit(ctx context.Context, userID int) (*model.ReferralCredit, error) {
+	balance, err := s.referralRepo.GetCreditBalance(ctx, userID, s.cfg.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ReferralCredit{
+		UserID:   userID,
+		Balance:  balance,
+		Currency: s.cfg.Currency,
+	}, nil
+}
+
+// RedeemCredit spends up to the requested amount of a user's credit. Credit is only
+// held in the program's currency, so nothing is spent on amounts in another.
+func (s *ReferralService) RedeemCredit(ctx context.Context, request *model.ReferralCreditRedeem) (*model.ReferralRedemption, error) {
+	currency := strings.ToUpper(request.Currency)
+	if currency != s.cfg.Currency {
+		return &model.ReferralRedemption{Currency: currency}, nil
+	}
+
+	redemption, err := s.referralRepo.RedeemCredit(ctx, request.UserID, request.Amount, currency, request.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if redemption.Amount > 0 {
+		s.logger.Info("Redeemed referral credit",
+			zap.Int("user_id", request.UserID),
+			zap.Int("redemption_id", redemption.RedemptionID),
+			zap.Float64("amount", redemption.Amount),
+			zap.String("reference", request.Reference))
+	}
+	return redemption, nil
+}
+
+// ReverseRedemption gives back the credit of a redemption, e.g. when the purchase it paid
+// for failed
+func (s *ReferralService) ReverseRedemption(ctx context.Context, redemptionID int) error {
+	reversed, err := s.referralRepo.ReverseRedemption(ctx, redemptionID)
+	if err != nil {
+		return err
+	}
+	if !reversed {
+		return errcode.ErrRedemptionNotFound
+	}
+
+	s.logger.Info("Reversed referral credit redemption", zap.Int("redemption_id", redemptionID))
+	return nil
+}
+
+// GetFraudReport retrieves the referrers with at least minReferrals referrals since a point
+// in time, most suspicious first, with the fraud indicators their referrals raise
+func (s *ReferralService) GetFraudReport(ctx context.Context, since time.Time, minReferrals, limit int) ([]model.ReferralFraudEntry, error) {
+	entries, err := s.referralRepo.GetFraudReport(ctx, since, minReferrals, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Flags = fraudFlags(&entries[i])
+	}
+
+	return entries, nil
+}
+
+// fraudFlags returns the fraud indicators a referrer's referrals raise
+func fraudFlags(entry *model.ReferralFraudEntry) []string {
+	flags := []string{}
+	if entry.SharedIPReferrals >= fraudSharedIPReferrals {
+		flags = append(flags, model.ReferralFlagSharedIP)
+	}
+	if entry.ReferrerIPReferrals > 0 {
+		flags = append(flags, model.ReferralFlagReferrerIP)
+	}
+	if entry.ReferralsCount >= fraudDormantMinReferrals && entry.DormantReferrals*2 > entry.ReferralsCount {
+		flags = append(flags, model.ReferralFlagDormant)
+	}
+	if entry.MaxReferralsPerDay >= fraudBurstReferrals {
+		flags = append(flags, model.ReferralFlagBurst)
+	}
+	return flags
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// RoleService handles role and permission management
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+	userRepo *repository.UserRepository
+	logger   *zap.Logger
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(roleRepo *repository.RoleRepository, userRepo *repository.UserRepository, logger *zap.Logger) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// GetAllRoles returns all roles with their permissions
+func (s *RoleService) GetAllRoles(ctx context.Context) ([]model.Role, error) {
+	return s.roleRepo.GetAllRoles(ctx)
+}
+
+// GetRole returns a single role
+func (s *RoleService) GetRole(ctx context.Context, id int) (*model.Role, error) {
+	role, err := s.roleRepo.GetRoleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errcode.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// GetAllPermissions returns the permission catalog
+func (s *RoleService) GetAllPermissions(ctx context.Context) ([]model.Permission, error) {
+	return s.roleRepo.GetAllPermissions(ctx)
+}
+
+// CreateRole creates a custom role
+func (s *RoleService) CreateRole(ctx context.Context, create *model.RoleCreate) (*model.Role, error) {
+	create.Name = strings.ToLower(strings.TrimSpace(create.Name))
+	if create.Name == "" {
+		return nil, errors.New("role name is required")
+	}
+
+	if err := s.validatePermissions(ctx, create.Permissions); err != nil {
+		return nil, err
+	}
+	if create.Permissions == nil {
+		create.Permissions = []string{}
+	}
+
+	id, err := s.roleRepo.CreateRole(ctx, create)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("a role with this name already exists")
+		}
+		return nil, err
+	}
+
+	return s.roleRepo.GetRoleByID(ctx, id)
+}
+
+// UpdateRole updates a role's name, description or permissions
+func (s *RoleService) UpdateRole(ctx context.Context, id int, update *model.RoleUpdate) (*model.Role, error) {
+	if update.Name != nil {
+		name := strings.ToLower(strings.TrimSpace(*update.Name))
+		if name == "" {
+			return nil, errors.New("role name cannot be empty")
+		}
+		update.Name = &name
+	}
+
+	if err := s.validatePermissions(ctx, update.Permissions); err != nil {
+		return nil, err
+	}
+
+	success, err := s.roleRepo.UpdateRole(ctx, id, update)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("a role with this name already exists")
+		}
+		return nil, err
+	}
+	if !success {
+		return nil, errcode.ErrRoleNotFound
+	}
+
+	return s.roleRepo.GetRoleByID(ctx, id)
+}
+
+// DeleteRole deletes a custom role. System roles can't be deleted.
+func (s *RoleService) DeleteRole(ctx context.Context, id int) error {
+	role, err := s.roleRepo.GetRoleByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errcode.ErrRoleNotFound
+	}
+	if role.IsSystem {
+		return errors.New("system roles cannot be deleted")
+	}
+
+	success, err := s.roleRepo.DeleteRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return errcode.ErrRoleNotFound
+	}
+
+	return nil
+}
+
+// GetUserRoles returns the roles held by a user
+func (s *RoleService) GetUserRoles(ctx context.Context, userID int) ([]model.Role, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errcode.ErrUserNotFound
+	}
+
+	return s.roleRepo.GetUserRoles(ctx, userID)
+}
+
+// AssignRole assigns a custom role to a user. System roles are set through users.role.
+func (s *RoleService) AssignRole(ctx context.Context, userID, roleID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errcode.ErrUserNotFound
+	}
+
+	role, err := s.roleRepo.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errcode.ErrRoleNotFound
+	}
+	if role.IsSystem {
+		return errors.New("system roles are assigned through the user's role field")
+	}
+
+	if _, err := s.roleRepo.AssignUserRole(ctx, userID, roleID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveRole removes a custom role from a user
+func (s *RoleService) RemoveRole(ctx context.Context, userID, roleID int) error {
+	success, err := s.roleRepo.RemoveUserRole(ctx, userID, roleID)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return errors.New("user does not have this role")
+	}
+	return nil
+}
+
+// BulkAssignRole assigns a custom role to many users at once
+func (s *RoleService) BulkAssignRole(ctx context.Context, userIDs []int, roleID int) (*model.BulkUserResult, error) {
+	if err := s.checkAssignableRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+
+	userIDs = uniqueIDs(userIDs)
+	assigned, err := s.roleRepo.BulkAssignUserRole(ctx, userIDs, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BulkUserResult{
+		Requested: len(userIDs),
+		Updated:   len(assigned),
+		UserIDs:   assigned,
+	}, nil
+}
+
+// BulkRemoveRole removes a custom role from many users at once
+func (s *RoleService) BulkRemoveRole(ctx context.Context, userIDs []int, roleID int) (*model.BulkUserResult, error) {
+	if err := s.checkAssignableRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+
+	userIDs = uniqueIDs(userIDs)
+	removed, err := s.roleRepo.BulkRemoveUserRole(ctx, userIDs, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BulkUserResult{
+		Requested: len(userIDs),
+		Updated:   len(removed),
+		UserIDs:   removed,
+	}, nil
+}
+
+// checkAssignableRole checks that a role exists and is a custom role
+func (s *RoleService) checkAssignableRole(ctx context.Context, roleID int) error {
+	role, err := s.roleRepo.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errcode.ErrRoleNotFound
+	}
+	if role.IsSystem {
+		return errors.New("system roles are assigned through the user's role field")
+	}
+	return nil
+}
+
+// validatePermissions checks that every permission exists in the catalog
+func (s *RoleService) validatePermissions(ctx context.Context, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	catalog, err := s.roleRepo.GetAllPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(catalog))
+	for _, p := range catalog {
+		known[p.Name] = true
+	}
+
+	for _, p := range permissions {
+		if !known[p] {
+			return fmt.Errorf("unknown permission: %s", p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxSavedViewsPerList bounds how many views a user can save for each list
+const maxSavedViewsPerList = 50
+
+// SavedViewService handles the named filter and sort combinations users save for the
+// backtest, strategy and marketplace lists. The services owning the lists apply them.
+type SavedViewService struct {
+	savedViewRepo *repository.SavedViewRepository
+	logger        *zap.Logger
+}
+
+// NewSavedViewService creates a new saved view service
+func NewSavedViewService(savedViewRepo *repository.SavedViewRepository, logger *zap.Logger) *SavedViewService {
+	return &SavedViewService{
+		savedViewRepo: savedViewRepo,
+		logger:        logger,
+	}
+}
+
+// CreateView saves a view of a list. Only the filters the list supports can be saved.
+func (s *SavedViewService) CreateView(ctx context.Context, userID int, request *model.SavedViewCreate) (*model.SavedView, error) {
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		return nil, errors.New("view name is required")
+	}
+
+	allowed := model.SavedViewFilters[request.ListType]
+	filters := make(map[string]string, len(request.Filters))
+	for name, value := range request.Filters {
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("invalid filter %q for the %s list: use one of %s", name, request.ListType, strings.Join(allowed, ", "))
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			filters[name] = value
+		}
+	}
+	request.SortDirection = strings.ToUpper(request.SortDirection)
+
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := s.savedViewRepo.Create(ctx, userID, request, encoded, maxSavedViewsPerList)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Saved list view",
+		zap.Int("user_id", userID),
+		zap.Int("view_id", view.ID),
+		zap.String("list_type", view.ListType))
+	return view, nil
+}
+
+// ListViews retrieves a user's saved views, of one list or of all of them
+func (s *SavedViewService) ListViews(ctx context.Context, userID int, listType string) ([]model.SavedView, error) {
+	if _, ok := model.SavedViewFilters[listType]; listType != "" && !ok {
+		return nil, errors.New("invalid list type: use backtests, strategies or marketplace")
+	}
+
+	return s.savedViewRepo.List(ctx, userID, listType)
+}
+
+// GetView retrieves a saved view of a user
+func (s *SavedViewService) GetView(ctx context.Context, userID, viewID int) (*model.SavedView, error) {
+	view, err := s.savedViewRepo.Get(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
+	if view == nil {
+		return nil, errcode.ErrSavedViewNotFound
+	}
+
+	return view, nil
+}
+
+// DeleteView deletes a saved view of a user
+func (s *SavedViewService) DeleteView(ctx context.Context, userID, viewID int) error {
+	deleted, err := s.savedViewRepo.Delete(ctx, userID, viewID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errcode.ErrSavedViewNotFound
+	}
+
+	return nil
+}
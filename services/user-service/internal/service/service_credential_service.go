@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"services/user-service/internal/errcode"
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// serviceKeyPrefix starts every issued key, so leaked keys are easy to find in code and logs
+	serviceKeyPrefix = "svc_"
+	// serviceKeySecretBytes is the randomness of a key
+	serviceKeySecretBytes = 32
+)
+
+// serviceCredentialEntry is a cached authentication of a usable key
+type serviceCredentialEntry struct {
+	service   *model.AuthenticatedService
+	expiresAt time.Time
+}
+
+// ServiceCredentialService issues, revokes and authenticates the keys internal services
+// call each other with. Keys are stored hashed. Usable keys are cached briefly, so a
+// revoked key can keep working for up to the cache TTL on other instances.
+type ServiceCredentialService struct {
+	credentialRepo *repository.ServiceCredentialRepository
+	cacheTTL       time.Duration
+	logger         *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]serviceCredentialEntry
+}
+
+// NewServiceCredentialService creates a new service credential service
+func NewServiceCredentialService(
+	credentialRepo *repository.ServiceCredentialRepository,
+	cacheTTL time.Duration,
+	logger *zap.Logger,
+) *ServiceCredentialService {
+	return &ServiceCredentialService{
+		credentialRepo: credentialRepo,
+		cacheTTL:       cacheTTL,
+		logger:         logger,
+		cache:          make(map[string]serviceCredentialEntry),
+	}
+}
+
+// IssueCredential generates a key for a service. The key is returned only here.
+func (s *ServiceCredentialService) IssueCredential(
+	ctx context.Context,
+	adminID int,
+	request *model.ServiceCredentialIssue,
+) (*model.IssuedServiceCredential, error) {
+	serviceName := strings.ToLower(strings.TrimSpace(request.ServiceName))
+
+	key, prefix, err := generateServiceKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresInDays > 0 {
+		expiry := time.Now().UTC().AddDate(0, 0, request.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	var rotateAfter *time.Duration
+	if request.RotationGraceHours != nil {
+		grace := time.Duration(*request.RotationGraceHours) * time.Hour
+		rotateAfter = &grace
+	}
+
+	id, err := s.credentialRepo.IssueCredential(ctx, serviceName, prefix, hashServiceKey(key),
+		request.Description, expiresAt, rotateAfter, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.credentialRepo.GetCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errcode.ErrCredentialNotFound
+	}
+
+	// Rotation shortened the life of other keys
+	if rotateAfter != nil {
+		s.clearCache()
+	}
+
+	s.logger.Info("issued service credential",
+		zap.String("service", serviceName),
+		zap.String("keyPrefix", prefix),
+		zap.Int("adminID", adminID),
+		zap.Bool("rotated", rotateAfter != nil))
+
+	return &model.IssuedServiceCredential{Key: key, Credential: *credential}, nil
+}
+
+// GetCredentials lists the keys of a service, or of every service when serviceName is empty
+func (s *ServiceCredentialService) GetCredentials(ctx context.Context, serviceName string) ([]model.ServiceCredential, error) {
+	return s.credentialRepo.GetCredentials(ctx, strings.ToLower(strings.TrimSpace(serviceName)))
+}
+
+// RevokeCredential revokes a key
+func (s *ServiceCredentialService) RevokeCredential(ctx context.Context, id int, adminID int) error {
+	credential, err := s.credentialRepo.GetCredential(ctx, id)
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return errcode.ErrCredentialNotFound
+	}
+
+	revoked, err := s.credentialRepo.RevokeCredential(ctx, id, adminID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errcode.ErrCredentialRevoked
+	}
+
+	s.clearCache()
+
+	s.logger.Warn("revoked service credential",
+		zap.Int("id", id),
+		zap.String("service", credential.ServiceName),
+		zap.String("keyPrefix", credential.KeyPrefix),
+		zap.Int("adminID", adminID))
+
+	return nil
+}
+
+// Authenticate returns the service a key belongs to, or nil if the key is unknown,
+// expired or revoked
+func (s *ServiceCredentialService) Authenticate(ctx context.Context, key string) (*model.AuthenticatedService, error) {
+	if !strings.HasPrefix(key, serviceKeyPrefix) {
+		return nil, nil
+	}
+
+	hash := hashServiceKey(key)
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.service, nil
+	}
+
+	service, err := s.credentialRepo.Authenticate(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if service != nil && s.cacheTTL > 0 {
+		expiresAt := now.Add(s.cacheTTL)
+		if service.ExpiresAt != nil && service.ExpiresAt.Before(expiresAt) {
+			expiresAt = *service.ExpiresAt
+		}
+		s.mu.Lock()
+		s.cache[hash] = serviceCredentialEntry{service: service, expiresAt: expiresAt}
+		s.mu.Unlock()
+	}
+
+	return service, nil
+}
+
+// clearCache forgets cached authentication results after keys changed
+func (s *ServiceCredentialService) clearCache() {
+	s.mu.Lock()
+	s.cache = make(map[string]serviceCredentialEntry)
+	s.mu.Unlock()
+}
+
+// generateServiceKey returns a new random key and the prefix identifying it
+func generateServiceKey() (key string, prefix string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, serviceKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix = serviceKeyPrefix + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, nil
+}
+
+// hashServiceKey returns the hex SHA-256 a key is stored as. Keys are random, so they
+// don't need a slow password hash.
+func hashServiceKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"services/user-service/internal/model"
+	"services/user-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// statsWindows are the time windows admin statistics can be requested for
+var statsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// IsValidStatsWindow reports whether window is a supported statistics window
+func IsValidStatsWindow(window string) bool {
+	_, ok := statsWindows[window]
+	return ok
+}
+
+// userStatsEntry is a cached statistics result
+type userStatsEntry struct {
+	stats     *model.UserStats
+	expiresAt time.Time
+}
+
+// StatsService computes admin dashboard statistics, caching results briefly since the
+// aggregation queries scan whole tables
+type StatsService struct {
+	statsRepo *repository.StatsRepository
+	cacheTTL  time.Duration
+	logger    *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]userStatsEntry
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(statsRepo *repository.StatsRepository, cacheTTL time.Duration, logger *zap.Logger) *StatsService {
+	return &StatsService{
+		statsRepo: statsRepo,
+		cacheTTL:  cacheTTL,
+		logger:    logger,
+		cache:     make(map[string]userStatsEntry),
+	}
+}
+
+// GetUserStats returns user KPIs for a window such as "7d"
+func (s *StatsService) GetUserStats(ctx context.Context, window string) (*model.UserStats, error) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	entry, ok := s.cache[window]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.stats, nil
+	}
+
+	from := now.Add(-statsWindows[window])
+	stats, err := s.statsRepo.GetUserStats(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Window = window
+	stats.From = from
+	stats.To = now
+	stats.GeneratedAt = now
+
+	if s.cacheTTL > 0 {
+		s.mu.Lock()
+		s.cache[window] = userStatsEntry{stats: stats, expiresAt: now.Add(s.cacheTTL)}
+		s.mu.Unlock()
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"services/user-service/internal/model"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// TokenRevocationChannel is the Redis pub/sub channel revocations are broadcast on. The
+// gateway and the other services keep the revoked users in memory and reject their tokens.
+const TokenRevocationChannel = "auth:token-revocations"
+
+// TokenRevokedKeyPrefix prefixes the Redis keys holding a user's latest revocation. The
+// other services load them when they start, so a restart doesn't forget revoked tokens.
+const TokenRevokedKeyPrefix = "auth:revoked:user:"
+
+// TokenRevoker revokes all access tokens of a user. Access tokens are verified locally
+// by every service, so revocations are recorded in Redis and broadcast until the revoked
+// tokens would have expired anyway. Without Redis revoked tokens stay valid until they expire.
+type TokenRevoker struct {
+	redisClient *redis.Client
+	// Longest lifetime of an access token; revocations are kept this long
+	tokenLifetime time.Duration
+	logger        *zap.Logger
+}
+
+// NewTokenRevoker creates a new token revoker
+func NewTokenRevoker(redisClient *redis.Client, tokenLifetime time.Duration, logger *zap.Logger) *TokenRevoker {
+	return &TokenRevoker{
+		redisClient:   redisClient,
+		tokenLifetime: tokenLifetime,
+		logger:        logger,
+	}
+}
+
+// RevokeUser revokes every access token of the user issued until now. Failures are
+// logged rather than returned so they don't undo the logout or deactivation itself.
+func (r *TokenRevoker) RevokeUser(ctx context.Context, userID int, reason string) {
+	if r.redisClient == nil {
+		r.logger.Warn("no Redis, access tokens stay valid until they expire",
+			zap.Int("userID", userID),
+			zap.String("reason", reason))
+		return
+	}
+
+	now := time.Now()
+	revocation := model.TokenRevocation{
+		UserID:          userID,
+		RevokedBefore:   now.Unix(),
+		RevokedBeforeMs: now.UnixMilli(),
+		ExpiresAt:       now.Add(r.tokenLifetime).Unix(),
+		Reason:          reason,
+	}
+
+	payload, err := json.Marshal(revocation)
+	if err != nil {
+		r.logger.Error("failed to encode token revocation", zap.Error(err))
+		return
+	}
+
+	key := fmt.Sprintf("%s%d", TokenRevokedKeyPrefix, userID)
+	if err := r.redisClient.Set(ctx, key, payload, r.tokenLifetime).Err(); err != nil {
+		r.logger.Error("failed to record token revocation", zap.Error(err), zap.Int("userID", userID))
+	}
+
+	if err := r.redisClient.Publish(ctx, TokenRevocationChannel, payload).Err(); err != nil {
+		r.logger.Error("failed to broadcast token revocation", zap.Error(err), zap.Int("userID", userID))
+		return
+	}
+
+	r.logger.Info("revoked access tokens",
+		zap.Int("userID", userID),
+		zap.String("reason", reason))
+}
+
+// IsRevoked reports whether the user's tokens were revoked after issuedAt, in Unix
+// milliseconds
+func (r *TokenRevoker) IsRevoked(ctx context.Context, userID int, issuedAt int64) (bool, error) {
+	if r.redisClient == nil {
+		return false, nil
+	}
+
+	value, err := r.redisClient.Get(ctx, fmt.Sprintf("%s%d", TokenRevokedKeyPrefix, userID)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var revocation model.TokenRevocation
+	if err := json.Unmarshal([]byte(value), &revocation); err == nil {
+		return issuedAt < revocation.RevokedBeforeMs, nil
+	}
+
+	// Revocations stored before they were stored whole hold only the second they were
+	// made in, and revoke the tokens issued in or before it
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid token revocation for user %d: %w", userID, err)
+	}
+
+	return issuedAt < (revokedBefore+1)*1000, nil
+}
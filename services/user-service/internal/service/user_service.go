@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"services/user-service/internal/errcode"
 	"services/user-service/internal/model"
 	"services/user-service/internal/repository"
 
@@ -21,6 +23,8 @@ type UserService struct {
 	logger      *zap.Logger
 	redisClient *redis.Client // Added Redis client
 	kafkaWriter *kafka.Writer // Added Kafka writer
+	// Revokes the access tokens of deactivated users
+	tokenRevoker *TokenRevoker
 }
 
 // NewUserService creates a new user service
@@ -29,12 +33,14 @@ func NewUserService(
 	logger *zap.Logger,
 	redisClient *redis.Client, // New parameter
 	kafkaWriter *kafka.Writer, // New parameter
+	tokenRevoker *TokenRevoker,
 ) *UserService {
 	return &UserService{
-		userRepo:    userRepo,
-		logger:      logger,
-		redisClient: redisClient,
-		kafkaWriter: kafkaWriter,
+		userRepo:     userRepo,
+		logger:       logger,
+		redisClient:  redisClient,
+		kafkaWriter:  kafkaWriter,
+		tokenRevoker: tokenRevoker,
 	}
 }
 
@@ -166,6 +172,11 @@ func (s *UserService) Update(ctx context.Context, id int, update *model.UserUpda
 		s.redisClient.Del(ctx, fmt.Sprintf("user:details:%d", id))
 	}
 
+	// Deactivated users are signed out everywhere
+	if update.IsActive != nil && !*update.IsActive {
+		s.tokenRevoker.RevokeUser(ctx, id, "deactivated")
+	}
+
 	// Publish update event to Kafka if available
 	if s.kafkaWriter != nil {
 		event := map[string]interface{}{
@@ -216,6 +227,9 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) error {
 		// Note: We can't easily invalidate the email cache since we don't have the email here
 	}
 
+	// Deactivated users are signed out everywhere
+	s.tokenRevoker.RevokeUser(ctx, id, "deactivated")
+
 	// Publish delete event to Kafka if available
 	if s.kafkaWriter != nil {
 		event := map[string]interface{}{
@@ -245,8 +259,17 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) error {
 	return nil
 }
 
-// ListUsers gets a paginated list of users
-func (s *UserService) ListUsers(ctx context.Context, page, limit int) ([]model.User, int, error) {
+// userSortColumns are the columns the admin user list can be sorted by
+var userSortColumns = map[string]bool{
+	"id":         true,
+	"username":   true,
+	"email":      true,
+	"created_at": true,
+	"last_login": true,
+}
+
+// ListUsers gets a paginated list of the users matching the search filters
+func (s *UserService) ListUsers(ctx context.Context, search *model.UserSearch, page, limit int) ([]model.User, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -254,17 +277,39 @@ func (s *UserService) ListUsers(ctx context.Context, page, limit int) ([]model.U
 		limit = 10
 	}
 
+	if search.SortBy == "" {
+		search.SortBy = "id"
+	}
+	if !userSortColumns[search.SortBy] {
+		return nil, 0, errors.New("invalid sort_by; use id, username, email, created_at or last_login")
+	}
+
+	search.SortDirection = strings.ToUpper(search.SortDirection)
+	if search.SortDirection == "" {
+		search.SortDirection = "ASC"
+	}
+	if search.SortDirection != "ASC" && search.SortDirection != "DESC" {
+		return nil, 0, errors.New("invalid sort_direction; use asc or desc")
+	}
+
+	// The search term is matched anywhere in the username or email
+	var pattern *string
+	if term := strings.TrimSpace(search.Search); term != "" {
+		p := "%" + likeEscaper.Replace(term) + "%"
+		pattern = &p
+	}
+
 	offset := (page - 1) * limit
 
 	// For list operations, we typically don't cache as they change frequently
 	// However, we could cache short-lived special lists if needed
 
-	users, err := s.userRepo.List(ctx, offset, limit)
+	users, err := s.userRepo.Search(ctx, search, pattern, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	count, err := s.userRepo.Count(ctx)
+	count, err := s.userRepo.CountSearch(ctx, search, pattern)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -272,6 +317,114 @@ func (s *UserService) ListUsers(ctx context.Context, page, limit int) ([]model.U
 	return users, count, nil
 }
 
+// likeEscaper escapes the wildcards of LIKE patterns so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SetUsersActive activates or deactivates many users at once. Admins can't deactivate
+// their own account. Deactivated users are signed out everywhere.
+func (s *UserService) SetUsersActive(ctx context.Context, adminID int, userIDs []int, isActive bool) (*model.BulkUserResult, error) {
+	userIDs = uniqueIDs(userIDs)
+	if !isActive {
+		for _, id := range userIDs {
+			if id == adminID {
+				return nil, errcode.ErrCannotDeactivateSelf
+			}
+		}
+	}
+
+	changed, err := s.userRepo.SetActive(ctx, userIDs, isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUsers(ctx, changed)
+
+	if !isActive {
+		for _, id := range changed {
+			s.tokenRevoker.RevokeUser(ctx, id, "deactivated")
+		}
+	}
+
+	// Publish one update event per changed user, in a single write
+	if s.kafkaWriter != nil && len(changed) > 0 {
+		now := time.Now()
+		messages := make([]kafka.Message, 0, len(changed))
+		for _, id := range changed {
+			event := map[string]interface{}{
+				"event_type": "user_updated",
+				"user_id":    id,
+				"is_active":  isActive,
+				"timestamp":  now.Format(time.RFC3339),
+			}
+			eventJSON, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			messages = append(messages, kafka.Message{
+				Key:   []byte(fmt.Sprintf("%d", id)),
+				Value: eventJSON,
+				Time:  now,
+			})
+		}
+
+		// Don't block on Kafka errors
+		go func() {
+			if err := s.kafkaWriter.WriteMessages(context.Background(), messages...); err != nil {
+				s.logger.Error("Failed to publish bulk user update events",
+					zap.Error(err),
+					zap.Int("count", len(messages)))
+			}
+		}()
+	}
+
+	s.logger.Info("Bulk updated user status",
+		zap.Int("admin_id", adminID),
+		zap.Bool("is_active", isActive),
+		zap.Int("requested", len(userIDs)),
+		zap.Int("updated", len(changed)))
+
+	return &model.BulkUserResult{
+		Requested: len(userIDs),
+		Updated:   len(changed),
+		UserIDs:   changed,
+	}, nil
+}
+
+// ExportUsers returns the users matching a filter, oldest first
+func (s *UserService) ExportUsers(ctx context.Context, filter *model.UserExportFilter) ([]model.UserExportRow, error) {
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, errors.New("invalid date range: created_from must be before created_to")
+	}
+
+	return s.userRepo.Export(ctx, filter)
+}
+
+// invalidateUsers drops the cached records of users
+func (s *UserService) invalidateUsers(ctx context.Context, userIDs []int) {
+	if s.redisClient == nil || len(userIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(userIDs)*2)
+	for _, id := range userIDs {
+		keys = append(keys, fmt.Sprintf("user:%d", id), fmt.Sprintf("user:details:%d", id))
+	}
+	s.redisClient.Del(ctx, keys...)
+}
+
+// uniqueIDs returns the IDs without duplicates, keeping their order
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
 // CheckUserExists checks if a user exists
 func (s *UserService) CheckUserExists(ctx context.Context, id int) (bool, error) {
 	// Try cache if Redis is available
@@ -411,7 +564,7 @@ func (s *UserService) AddAuthSession(ctx context.Context, userID int, token stri
 	}
 
 	if user == nil {
-		return errors.New("user not found")
+		return errcode.ErrUserNotFound
 	}
 
 	// Create session data
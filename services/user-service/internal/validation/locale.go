@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// languageKey is the context key of the negotiated language
+const languageKey = "language"
+
+// Locale creates middleware negotiating the language of validation messages from the
+// Accept-Language header
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(languageKey, Negotiate(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// Language returns the language of validation messages for the request
+func Language(c *gin.Context) string {
+	if lang, ok := c.Get(languageKey); ok {
+		if s, ok := lang.(string); ok {
+			return s
+		}
+	}
+	return Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Negotiate returns the catalog language a client prefers by its Accept-Language
+// header, e.g. "es-MX,es;q=0.9,en;q=0.8" gives es. Region subtags are ignored.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		lang := strings.SplitN(tag, "-", 2)[0]
+		candidates = append(candidates, candidate{lang: lang, quality: quality})
+	}
+
+	// Stable so equally preferred languages keep the client's order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if candidate.quality <= 0 {
+			break
+		}
+		if _, ok := catalogs[candidate.lang]; ok {
+			return candidate.lang
+		}
+	}
+	return DefaultLanguage
+}
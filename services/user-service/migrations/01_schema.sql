@@ -1,5 +1,7 @@
 -- User Service Database Schema - Core Tables and Types
 
+-- +goose Up
+-- +goose StatementBegin
 -- Create types
 CREATE TYPE "user_role" AS ENUM (
   'admin',
@@ -133,4 +135,5 @@ SELECT
     COALESCE(p.notification_settings, '{}'::jsonb) as notification_settings
 FROM
     users u
-    LEFT JOIN user_preferences p ON u.id = p.user_id;
\ No newline at end of file
+    LEFT JOIN user_preferences p ON u.id = p.user_id;
+-- +goose StatementEnd
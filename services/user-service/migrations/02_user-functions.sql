@@ -1,5 +1,7 @@
 -- User Service Database - User Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get user by ID
 CREATE OR REPLACE FUNCTION get_user_by_id(p_user_id INT)
 RETURNS TABLE (
@@ -228,4 +230,5 @@ BEGIN
     
     RETURN user_role;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
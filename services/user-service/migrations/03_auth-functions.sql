@@ -1,5 +1,7 @@
 -- User Service Database - Authentication Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get user password by ID
 CREATE OR REPLACE FUNCTION get_user_password_by_id(p_user_id INT)
 RETURNS VARCHAR AS $$
@@ -155,4 +157,5 @@ BEGIN
     -- Check if key exists, is active, and matches
     RETURN FOUND AND is_key_active AND stored_key_hash = p_key_hash;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
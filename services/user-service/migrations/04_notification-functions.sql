@@ -1,5 +1,7 @@
 -- User Service Database - Notification Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get active notifications for a user
 CREATE OR REPLACE FUNCTION get_active_notifications(p_user_id INT)
 RETURNS TABLE (
@@ -142,4 +144,5 @@ BEGIN
     FROM notifications n
     WHERE n.id = p_notification_id;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
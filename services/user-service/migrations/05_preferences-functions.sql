@@ -1,5 +1,7 @@
 -- User Service Database - Preference Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get user preferences
 CREATE OR REPLACE FUNCTION get_user_preferences(p_user_id INT)
 RETURNS TABLE (
@@ -105,4 +107,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
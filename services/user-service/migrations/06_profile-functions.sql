@@ -1,5 +1,7 @@
 -- User Service Database - Profile Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Get user profile photo URL
 CREATE OR REPLACE FUNCTION get_profile_photo_url(p_user_id INT)
 RETURNS VARCHAR AS $$
@@ -51,4 +53,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
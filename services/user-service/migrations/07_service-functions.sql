@@ -1,5 +1,7 @@
 -- User Service Database - Service Communication Functions
 
+-- +goose Up
+-- +goose StatementBegin
 -- Log service communication
 CREATE OR REPLACE FUNCTION log_service_communication(
     p_source_service VARCHAR,
@@ -153,4 +155,5 @@ BEGIN
     GET DIAGNOSTICS affected_rows = ROW_COUNT;
     RETURN affected_rows > 0;
 END;
-$$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,163 @@
+-- User Service Database - Two-Factor Authentication
+
+-- +goose Up
+-- +goose StatementBegin
+-- TOTP secrets, one per user. A row exists from enrollment; is_enabled flips once
+-- the user proves possession of the secret with a valid code.
+CREATE TABLE IF NOT EXISTS "user_two_factor" (
+  "user_id" int PRIMARY KEY,
+  "secret" varchar(64) NOT NULL,
+  "is_enabled" boolean NOT NULL DEFAULT false,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "enabled_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp
+);
+
+-- One-time recovery codes, stored as bcrypt hashes
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "code_hash" varchar(255) NOT NULL,
+  "used_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_user_recovery_codes_user" ON "user_recovery_codes" ("user_id") WHERE used_at IS NULL;
+
+ALTER TABLE "user_two_factor" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Start (or restart) enrollment with a new secret. Fails if 2FA is already enabled.
+CREATE OR REPLACE FUNCTION set_pending_two_factor_secret(
+    p_user_id INT,
+    p_secret VARCHAR(64)
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    INSERT INTO user_two_factor (user_id, secret, is_enabled, created_at)
+    VALUES (p_user_id, p_secret, FALSE, NOW())
+    ON CONFLICT (user_id) DO UPDATE SET
+        secret = EXCLUDED.secret,
+        last_used_step = 0,
+        updated_at = NOW()
+    WHERE user_two_factor.is_enabled = FALSE;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's 2FA settings
+CREATE OR REPLACE FUNCTION get_user_two_factor(p_user_id INT)
+RETURNS TABLE (
+    user_id INT,
+    secret VARCHAR(64),
+    is_enabled BOOLEAN,
+    last_used_step BIGINT,
+    enabled_at TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.user_id, t.secret, t.is_enabled, t.last_used_step, t.enabled_at, t.created_at
+    FROM user_two_factor t
+    WHERE t.user_id = p_user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Record a successfully used TOTP time step. Returns false if the step (or a later
+-- one) was already used, which rejects replayed codes.
+CREATE OR REPLACE FUNCTION use_two_factor_step(
+    p_user_id INT,
+    p_step BIGINT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE user_two_factor
+    SET last_used_step = p_step
+    WHERE user_id = p_user_id AND last_used_step < p_step;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Enable 2FA and replace the user's recovery codes in one step
+CREATE OR REPLACE FUNCTION enable_two_factor(
+    p_user_id INT,
+    p_recovery_code_hashes TEXT[]
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE user_two_factor
+    SET is_enabled = TRUE,
+        enabled_at = NOW(),
+        updated_at = NOW()
+    WHERE user_id = p_user_id AND is_enabled = FALSE;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    IF affected_rows = 0 THEN
+        RETURN FALSE;
+    END IF;
+
+    DELETE FROM user_recovery_codes WHERE user_id = p_user_id;
+
+    INSERT INTO user_recovery_codes (user_id, code_hash, created_at)
+    SELECT p_user_id, h, NOW()
+    FROM unnest(p_recovery_code_hashes) AS h;
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Disable 2FA and drop the secret and recovery codes
+CREATE OR REPLACE FUNCTION disable_two_factor(p_user_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM user_two_factor WHERE user_id = p_user_id;
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+
+    DELETE FROM user_recovery_codes WHERE user_id = p_user_id;
+
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's unused recovery codes
+CREATE OR REPLACE FUNCTION get_unused_recovery_codes(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    code_hash VARCHAR(255)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT rc.id, rc.code_hash
+    FROM user_recovery_codes rc
+    WHERE rc.user_id = p_user_id AND rc.used_at IS NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Mark a recovery code as used. Returns false if it was already consumed.
+CREATE OR REPLACE FUNCTION use_recovery_code(p_code_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE user_recovery_codes
+    SET used_at = NOW()
+    WHERE id = p_code_id AND used_at IS NULL;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,290 @@
+-- User Service Database - Roles and Permissions
+
+-- +goose Up
+-- +goose StatementBegin
+-- Roles group permissions. System roles mirror the user_role enum stored on
+-- users.role and can't be deleted; custom roles are assigned via user_roles.
+CREATE TABLE IF NOT EXISTS "roles" (
+  "id" SERIAL PRIMARY KEY,
+  "name" varchar(50) UNIQUE NOT NULL,
+  "description" text,
+  "is_system" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp
+);
+
+-- Permissions use resource:action names, e.g. indicators:write
+CREATE TABLE IF NOT EXISTS "permissions" (
+  "id" SERIAL PRIMARY KEY,
+  "name" varchar(100) UNIQUE NOT NULL,
+  "description" text,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+  "role_id" int NOT NULL,
+  "permission_id" int NOT NULL,
+  PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+  "user_id" int NOT NULL,
+  "role_id" int NOT NULL,
+  "assigned_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("user_id", "role_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_user_roles_role" ON "user_roles" ("role_id");
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE;
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE;
+
+-- Default permissions
+INSERT INTO permissions (name, description) VALUES
+('*', 'Full access to every resource'),
+('users:read', 'View any user account'),
+('users:write', 'Modify any user account'),
+('roles:manage', 'Create, update and assign roles'),
+('notifications:send', 'Send notifications to users'),
+('indicators:write', 'Create, update and delete indicators'),
+('tags:write', 'Create, update and delete strategy tags'),
+('marketplace:moderate', 'Moderate marketplace listings and reviews'),
+('symbols:write', 'Create, update and delete symbols'),
+('market-data:import', 'Import candle data'),
+('downloads:manage', 'View and manage all market data downloads'),
+('metrics:read', 'View platform metrics')
+ON CONFLICT (name) DO NOTHING;
+
+-- System roles
+INSERT INTO roles (name, description, is_system) VALUES
+('admin', 'Platform administrator', true),
+('user', 'Regular user', true)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = '*'
+ON CONFLICT DO NOTHING;
+
+-- Get all permissions
+CREATE OR REPLACE FUNCTION get_all_permissions()
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(100),
+    description TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT p.id, p.name, p.description
+    FROM permissions p
+    ORDER BY p.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get all roles with their permission names
+CREATE OR REPLACE FUNCTION get_all_roles()
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    description TEXT,
+    is_system BOOLEAN,
+    permissions TEXT[],
+    user_count BIGINT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.id,
+        r.name,
+        r.description,
+        r.is_system,
+        COALESCE(
+            (SELECT array_agg(p.name::TEXT ORDER BY p.name)
+             FROM role_permissions rp
+             JOIN permissions p ON rp.permission_id = p.id
+             WHERE rp.role_id = r.id),
+            ARRAY[]::TEXT[]
+        ),
+        (SELECT COUNT(*) FROM user_roles ur WHERE ur.role_id = r.id),
+        r.created_at,
+        r.updated_at
+    FROM roles r
+    ORDER BY r.is_system DESC, r.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a role by ID
+CREATE OR REPLACE FUNCTION get_role_by_id(p_role_id INT)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    description TEXT,
+    is_system BOOLEAN,
+    permissions TEXT[],
+    user_count BIGINT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM get_all_roles() r
+    WHERE r.id = p_role_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replace the permission set of a role
+CREATE OR REPLACE FUNCTION set_role_permissions(
+    p_role_id INT,
+    p_permissions TEXT[]
+)
+RETURNS VOID AS $$
+BEGIN
+    DELETE FROM role_permissions WHERE role_id = p_role_id;
+
+    INSERT INTO role_permissions (role_id, permission_id)
+    SELECT p_role_id, p.id
+    FROM permissions p
+    WHERE p.name = ANY(p_permissions);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a custom role
+CREATE OR REPLACE FUNCTION create_role(
+    p_name VARCHAR(50),
+    p_description TEXT,
+    p_permissions TEXT[]
+)
+RETURNS INT AS $$
+DECLARE
+    v_role_id INT;
+BEGIN
+    INSERT INTO roles (name, description, is_system, created_at)
+    VALUES (p_name, p_description, FALSE, NOW())
+    RETURNING id INTO v_role_id;
+
+    PERFORM set_role_permissions(v_role_id, p_permissions);
+
+    RETURN v_role_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update a role. System roles keep their name but their permissions can change.
+CREATE OR REPLACE FUNCTION update_role(
+    p_role_id INT,
+    p_name VARCHAR(50),
+    p_description TEXT,
+    p_permissions TEXT[]
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE roles
+    SET
+        name = CASE WHEN is_system THEN name ELSE COALESCE(p_name, name) END,
+        description = COALESCE(p_description, description),
+        updated_at = NOW()
+    WHERE id = p_role_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    IF affected_rows = 0 THEN
+        RETURN FALSE;
+    END IF;
+
+    IF p_permissions IS NOT NULL THEN
+        PERFORM set_role_permissions(p_role_id, p_permissions);
+    END IF;
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a custom role
+CREATE OR REPLACE FUNCTION delete_role(p_role_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM roles
+    WHERE id = p_role_id AND is_system = FALSE;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Assign a role to a user
+CREATE OR REPLACE FUNCTION assign_user_role(
+    p_user_id INT,
+    p_role_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    INSERT INTO user_roles (user_id, role_id, assigned_at)
+    VALUES (p_user_id, p_role_id, NOW())
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Remove a role from a user
+CREATE OR REPLACE FUNCTION remove_user_role(
+    p_user_id INT,
+    p_role_id INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM user_roles
+    WHERE user_id = p_user_id AND role_id = p_role_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the roles assigned to a user, including the system role from users.role
+CREATE OR REPLACE FUNCTION get_user_roles(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    name VARCHAR(50),
+    description TEXT,
+    is_system BOOLEAN,
+    permissions TEXT[],
+    user_count BIGINT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT r.* FROM get_all_roles() r
+    WHERE r.name = (SELECT u.role::TEXT FROM users u WHERE u.id = p_user_id)
+       OR r.id IN (SELECT ur.role_id FROM user_roles ur WHERE ur.user_id = p_user_id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the effective permission names for a user
+CREATE OR REPLACE FUNCTION get_user_permissions(p_user_id INT)
+RETURNS TEXT[] AS $$
+BEGIN
+    RETURN COALESCE(
+        (SELECT array_agg(DISTINCT p.name::TEXT ORDER BY p.name::TEXT)
+         FROM roles r
+         JOIN role_permissions rp ON rp.role_id = r.id
+         JOIN permissions p ON rp.permission_id = p.id
+         WHERE r.name = (SELECT u.role::TEXT FROM users u WHERE u.id = p_user_id)
+            OR r.id IN (SELECT ur.role_id FROM user_roles ur WHERE ur.user_id = p_user_id)),
+        ARRAY[]::TEXT[]
+    );
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
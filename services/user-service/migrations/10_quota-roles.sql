@@ -0,0 +1,19 @@
+-- User Service Database - Quota Tiers
+
+-- +goose Up
+-- +goose StatementBegin
+-- Holding this permission puts a user on the historical data service's "pro" quota tier
+INSERT INTO permissions (name, description) VALUES
+('quotas:pro', 'Pro tier backtest, download and storage quotas')
+ON CONFLICT (name) DO NOTHING;
+
+-- Assignable role granting the pro tier
+INSERT INTO roles (name, description, is_system) VALUES
+('pro', 'Pro subscriber with raised usage quotas', false)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'pro' AND p.name = 'quotas:pro'
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- User Service Database - Admin Statistics
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_users_last_login" ON "users" ("last_login");
+CREATE INDEX IF NOT EXISTS "idx_users_created_at" ON "users" ("created_at");
+
+-- Platform user KPIs for the admin dashboard. Active users logged in since p_since.
+CREATE OR REPLACE FUNCTION get_user_stats(p_since TIMESTAMP)
+RETURNS TABLE (
+    total_users BIGINT,
+    active_users BIGINT,
+    new_users BIGINT,
+    disabled_users BIGINT,
+    admin_users BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        COUNT(*),
+        COUNT(*) FILTER (WHERE u.last_login >= p_since),
+        COUNT(*) FILTER (WHERE u.created_at >= p_since),
+        COUNT(*) FILTER (WHERE NOT u.is_active),
+        COUNT(*) FILTER (WHERE u.role = 'admin')
+    FROM users u;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
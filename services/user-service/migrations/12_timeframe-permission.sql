@@ -0,0 +1,9 @@
+-- User Service Database - Timeframe Catalog Permission
+
+-- +goose Up
+-- +goose StatementBegin
+-- Lets a user manage the historical data service's timeframe catalog
+INSERT INTO permissions (name, description) VALUES
+('timeframes:write', 'Create, update and delete timeframes')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
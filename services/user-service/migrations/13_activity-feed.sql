@@ -0,0 +1,105 @@
+-- User Service Database - Activity Feed
+
+-- +goose Up
+-- +goose StatementBegin
+-- Per-user activity built from the user, strategy, marketplace and backtest event topics.
+-- event_key identifies the Kafka message an entry came from, so redelivered events are
+-- only stored once.
+CREATE TABLE IF NOT EXISTS "user_activities" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "type" varchar(50) NOT NULL,
+  "summary" varchar(255) NOT NULL,
+  "entity_type" varchar(50),
+  "entity_id" int,
+  "entity_name" varchar(255),
+  "actor_id" int,
+  "event_key" varchar(255) NOT NULL,
+  "occurred_at" timestamp NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_activities_event" ON "user_activities" ("user_id", "event_key");
+CREATE INDEX IF NOT EXISTS "idx_user_activities_user" ON "user_activities" ("user_id", "occurred_at" DESC);
+
+ALTER TABLE "user_activities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Add an activity entry. Returns NULL if the event was already stored for the user.
+CREATE OR REPLACE FUNCTION add_user_activity(
+    p_user_id INT,
+    p_type VARCHAR(50),
+    p_summary VARCHAR(255),
+    p_entity_type VARCHAR(50),
+    p_entity_id INT,
+    p_entity_name VARCHAR(255),
+    p_actor_id INT,
+    p_event_key VARCHAR(255),
+    p_occurred_at TIMESTAMP
+)
+RETURNS INT AS $$
+DECLARE
+    activity_id INT;
+BEGIN
+    INSERT INTO user_activities (
+        user_id, type, summary, entity_type, entity_id, entity_name, actor_id, event_key, occurred_at
+    )
+    VALUES (
+        p_user_id, p_type, p_summary, p_entity_type, p_entity_id, p_entity_name, p_actor_id, p_event_key,
+        COALESCE(p_occurred_at, NOW())
+    )
+    ON CONFLICT (user_id, event_key) DO NOTHING
+    RETURNING id INTO activity_id;
+
+    RETURN activity_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's activity, newest first, optionally limited to some activity types
+CREATE OR REPLACE FUNCTION get_user_activities(
+    p_user_id INT,
+    p_types TEXT[] DEFAULT NULL,
+    p_limit INT DEFAULT 20,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    id INT,
+    user_id INT,
+    type VARCHAR(50),
+    summary VARCHAR(255),
+    entity_type VARCHAR(50),
+    entity_id INT,
+    entity_name VARCHAR(255),
+    actor_id INT,
+    occurred_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT a.id, a.user_id, a.type, a.summary, a.entity_type, a.entity_id, a.entity_name,
+           a.actor_id, a.occurred_at
+    FROM user_activities a
+    WHERE a.user_id = p_user_id
+      AND (p_types IS NULL OR a.type = ANY(p_types))
+    ORDER BY a.occurred_at DESC, a.id DESC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count a user's activity, optionally limited to some activity types
+CREATE OR REPLACE FUNCTION count_user_activities(
+    p_user_id INT,
+    p_types TEXT[] DEFAULT NULL
+)
+RETURNS INTEGER AS $$
+DECLARE
+    activity_count INTEGER;
+BEGIN
+    SELECT COUNT(*) INTO activity_count
+    FROM user_activities a
+    WHERE a.user_id = p_user_id
+      AND (p_types IS NULL OR a.type = ANY(p_types));
+
+    RETURN activity_count;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,127 @@
+-- User Service Database - Admin Impersonation
+
+-- +goose Up
+-- +goose StatementBegin
+-- Lets support staff act as a user to debug their account
+INSERT INTO permissions (name, description) VALUES
+('users:impersonate', 'Act as another user with a short-lived impersonation token')
+ON CONFLICT (name) DO NOTHING;
+
+-- Impersonation sessions, one per issued token. A session ends when it expires or is revoked;
+-- tokens of ended sessions are rejected. Requests made during a session are tagged in the
+-- gateway's request audit stream.
+CREATE TABLE IF NOT EXISTS "impersonation_sessions" (
+  "id" SERIAL PRIMARY KEY,
+  "admin_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "reason" varchar(255) NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "revoked_at" timestamp,
+  "revoked_by" int,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_impersonation_sessions_active" ON "impersonation_sessions" ("expires_at") WHERE revoked_at IS NULL;
+
+ALTER TABLE "impersonation_sessions" ADD FOREIGN KEY ("admin_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "impersonation_sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Start an impersonation session that lasts p_ttl_seconds
+CREATE OR REPLACE FUNCTION create_impersonation_session(
+    p_admin_id INT,
+    p_user_id INT,
+    p_reason VARCHAR(255),
+    p_ttl_seconds INT
+)
+RETURNS INT AS $$
+DECLARE
+    session_id INT;
+BEGIN
+    INSERT INTO impersonation_sessions (admin_id, user_id, reason, expires_at)
+    VALUES (p_admin_id, p_user_id, p_reason, NOW() + make_interval(secs => p_ttl_seconds))
+    RETURNING id INTO session_id;
+
+    RETURN session_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get an impersonation session with the names of both users
+CREATE OR REPLACE FUNCTION get_impersonation_session(p_session_id INT)
+RETURNS TABLE (
+    id INT,
+    admin_id INT,
+    admin_username VARCHAR(50),
+    user_id INT,
+    username VARCHAR(50),
+    reason VARCHAR(255),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by INT,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT s.id, s.admin_id, a.username, s.user_id, u.username, s.reason, s.expires_at,
+           s.revoked_at, s.revoked_by, s.created_at
+    FROM impersonation_sessions s
+    JOIN users a ON a.id = s.admin_id
+    JOIN users u ON u.id = s.user_id
+    WHERE s.id = p_session_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the sessions that are neither expired nor revoked, newest first
+CREATE OR REPLACE FUNCTION get_active_impersonation_sessions()
+RETURNS TABLE (
+    id INT,
+    admin_id INT,
+    admin_username VARCHAR(50),
+    user_id INT,
+    username VARCHAR(50),
+    reason VARCHAR(255),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by INT,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT s.id, s.admin_id, a.username, s.user_id, u.username, s.reason, s.expires_at,
+           s.revoked_at, s.revoked_by, s.created_at
+    FROM impersonation_sessions s
+    JOIN users a ON a.id = s.admin_id
+    JOIN users u ON u.id = s.user_id
+    WHERE s.revoked_at IS NULL AND s.expires_at > NOW()
+    ORDER BY s.created_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Check whether a session can still be used
+CREATE OR REPLACE FUNCTION is_impersonation_session_active(p_session_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    RETURN EXISTS (
+        SELECT 1 FROM impersonation_sessions s
+        WHERE s.id = p_session_id AND s.revoked_at IS NULL AND s.expires_at > NOW()
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Revoke an active session. Returns FALSE if it is unknown or already ended.
+CREATE OR REPLACE FUNCTION revoke_impersonation_session(
+    p_session_id INT,
+    p_revoked_by INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INTEGER;
+BEGIN
+    UPDATE impersonation_sessions
+    SET revoked_at = NOW(), revoked_by = p_revoked_by
+    WHERE id = p_session_id AND revoked_at IS NULL AND expires_at > NOW();
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
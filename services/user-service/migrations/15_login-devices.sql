@@ -0,0 +1,57 @@
+-- User Service Database - Login Devices
+
+-- +goose Up
+-- +goose StatementBegin
+-- IP addresses and devices (user agents) each user has successfully logged in from.
+-- Logins from an IP or device not seen before trigger a security alert.
+CREATE TABLE IF NOT EXISTS "user_login_devices" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "ip_address" varchar(45) NOT NULL,
+  "user_agent" varchar(255) NOT NULL,
+  "login_count" int NOT NULL DEFAULT 1,
+  "first_seen_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "last_seen_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_login_devices_unique" ON "user_login_devices" ("user_id", "ip_address", "user_agent");
+
+ALTER TABLE "user_login_devices" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Record a successful login and report whether its IP and device are new for the user.
+-- first_login is TRUE when nothing was recorded for the user yet, so there is nothing to compare to.
+CREATE OR REPLACE FUNCTION record_login_device(
+    p_user_id INT,
+    p_ip_address VARCHAR(45),
+    p_user_agent VARCHAR(255)
+)
+RETURNS TABLE (
+    new_ip BOOLEAN,
+    new_device BOOLEAN,
+    first_login BOOLEAN
+) AS $$
+DECLARE
+    v_first_login BOOLEAN;
+    v_new_ip BOOLEAN;
+    v_new_device BOOLEAN;
+BEGIN
+    v_first_login := NOT EXISTS (SELECT 1 FROM user_login_devices d WHERE d.user_id = p_user_id);
+    v_new_ip := NOT EXISTS (
+        SELECT 1 FROM user_login_devices d
+        WHERE d.user_id = p_user_id AND d.ip_address = p_ip_address
+    );
+    v_new_device := NOT EXISTS (
+        SELECT 1 FROM user_login_devices d
+        WHERE d.user_id = p_user_id AND d.user_agent = p_user_agent
+    );
+
+    INSERT INTO user_login_devices (user_id, ip_address, user_agent)
+    VALUES (p_user_id, p_ip_address, p_user_agent)
+    ON CONFLICT (user_id, ip_address, user_agent) DO UPDATE
+    SET login_count = user_login_devices.login_count + 1,
+        last_seen_at = NOW();
+
+    RETURN QUERY SELECT v_new_ip, v_new_device, v_first_login;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
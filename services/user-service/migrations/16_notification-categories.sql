@@ -0,0 +1,319 @@
+-- User Service Database - Notification Categories, Batching and Digests
+
+-- +goose Up
+-- +goose StatementBegin
+-- Functions returning or taking the notification_type enum are replaced below
+DROP FUNCTION IF EXISTS get_active_notifications(INT);
+DROP FUNCTION IF EXISTS get_all_notifications(INT, INT, INT);
+DROP FUNCTION IF EXISTS get_notification_by_id(INT);
+DROP FUNCTION IF EXISTS add_notification(INT, notification_type, VARCHAR, TEXT, VARCHAR);
+
+-- Services publish their own notification types, so the type is free text and the
+-- category groups them for preferences and digests
+ALTER TABLE "notifications" ALTER COLUMN "type" TYPE varchar(50) USING "type"::text;
+DROP TYPE IF EXISTS "notification_type";
+
+ALTER TABLE "notifications" ADD COLUMN IF NOT EXISTS "category" varchar(20) NOT NULL DEFAULT 'system'
+    CHECK ("category" IN ('backtest', 'marketplace', 'system', 'security'));
+-- batch_count counts the events merged into a notification; updated_at is when the latest one arrived
+ALTER TABLE "notifications" ADD COLUMN IF NOT EXISTS "batch_count" int NOT NULL DEFAULT 1;
+ALTER TABLE "notifications" ADD COLUMN IF NOT EXISTS "updated_at" timestamp;
+-- Notifications collapsed into a digest point to it and are no longer listed on their own
+ALTER TABLE "notifications" ADD COLUMN IF NOT EXISTS "digest_id" int;
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("digest_id") REFERENCES "notifications" ("id") ON DELETE CASCADE;
+
+UPDATE notifications SET category = CASE
+    WHEN type LIKE 'backtest%' THEN 'backtest'
+    WHEN type LIKE 'strategy_%' THEN 'marketplace'
+    WHEN type IN ('account_update', 'security') THEN 'security'
+    ELSE 'system'
+END;
+
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_type" ON "notifications" ("user_id", "type", "created_at") WHERE "is_read" = FALSE;
+CREATE INDEX IF NOT EXISTS "idx_notifications_digest" ON "notifications" ("digest_id");
+
+-- How a user receives each category: 'instant' (stored and pushed), 'digest' (stored silently
+-- and collapsed into the daily digest) or 'off' (dropped). Missing rows mean 'instant'.
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+  "user_id" int NOT NULL,
+  "category" varchar(20) NOT NULL CHECK ("category" IN ('backtest', 'marketplace', 'system', 'security')),
+  "delivery" varchar(10) NOT NULL CHECK ("delivery" IN ('instant', 'digest', 'off')),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("user_id", "category")
+);
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Get active notifications for a user
+CREATE OR REPLACE FUNCTION get_active_notifications(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    type VARCHAR(50),
+    category VARCHAR(20),
+    title VARCHAR(100),
+    message TEXT,
+    is_read BOOLEAN,
+    link VARCHAR(255),
+    batch_count INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT n.id, n.type, n.category, n.title, n.message, n.is_read, n.link, n.batch_count, n.created_at, n.updated_at
+    FROM notifications n
+    WHERE n.user_id = p_user_id AND n.digest_id IS NULL
+    ORDER BY COALESCE(n.updated_at, n.created_at) DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get all notifications for a user with pagination, optionally of one category
+CREATE OR REPLACE FUNCTION get_all_notifications(
+    p_user_id INT,
+    p_limit INT DEFAULT 100,
+    p_offset INT DEFAULT 0,
+    p_category VARCHAR DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    type VARCHAR(50),
+    category VARCHAR(20),
+    title VARCHAR(100),
+    message TEXT,
+    is_read BOOLEAN,
+    link VARCHAR(255),
+    batch_count INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT n.id, n.type, n.category, n.title, n.message, n.is_read, n.link, n.batch_count, n.created_at, n.updated_at
+    FROM notifications n
+    WHERE n.user_id = p_user_id
+    AND n.digest_id IS NULL
+    AND (p_category IS NULL OR n.category = p_category)
+    ORDER BY COALESCE(n.updated_at, n.created_at) DESC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get notification by ID
+CREATE OR REPLACE FUNCTION get_notification_by_id(p_notification_id INT)
+RETURNS TABLE (
+    id INT,
+    user_id INT,
+    type VARCHAR(50),
+    category VARCHAR(20),
+    title VARCHAR(100),
+    message TEXT,
+    is_read BOOLEAN,
+    link VARCHAR(255),
+    batch_count INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT n.id, n.user_id, n.type, n.category, n.title, n.message, n.is_read, n.link, n.batch_count, n.created_at, n.updated_at
+    FROM notifications n
+    WHERE n.id = p_notification_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the notifications collapsed into a digest of the user
+CREATE OR REPLACE FUNCTION get_digest_notifications(p_digest_id INT, p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    type VARCHAR(50),
+    category VARCHAR(20),
+    title VARCHAR(100),
+    message TEXT,
+    is_read BOOLEAN,
+    link VARCHAR(255),
+    batch_count INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT n.id, n.type, n.category, n.title, n.message, n.is_read, n.link, n.batch_count, n.created_at, n.updated_at
+    FROM notifications n
+    WHERE n.digest_id = p_digest_id AND n.user_id = p_user_id
+    ORDER BY COALESCE(n.updated_at, n.created_at) DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Add notification. With a batch window, an event of the same type as an unread notification
+-- received within the window is merged into it instead: the notification takes the new
+-- title, message and link and its batch_count goes up. Returns the notification's ID.
+CREATE OR REPLACE FUNCTION add_notification(
+    p_user_id INT,
+    p_type VARCHAR(50),
+    p_category VARCHAR(20),
+    p_title VARCHAR(100),
+    p_message TEXT,
+    p_link VARCHAR(255) DEFAULT NULL,
+    p_batch_window INTERVAL DEFAULT NULL
+)
+RETURNS INT AS $$
+DECLARE
+    v_notification_id INT;
+BEGIN
+    IF p_batch_window IS NOT NULL THEN
+        SELECT n.id INTO v_notification_id
+        FROM notifications n
+        WHERE n.user_id = p_user_id
+        AND n.type = p_type
+        AND n.is_read = FALSE
+        AND n.digest_id IS NULL
+        AND COALESCE(n.updated_at, n.created_at) > NOW() - p_batch_window
+        ORDER BY n.created_at DESC
+        LIMIT 1
+        FOR UPDATE;
+
+        IF FOUND THEN
+            UPDATE notifications
+            SET
+                title = p_title,
+                message = p_message,
+                link = p_link,
+                batch_count = batch_count + 1,
+                updated_at = NOW()
+            WHERE id = v_notification_id;
+
+            RETURN v_notification_id;
+        END IF;
+    END IF;
+
+    INSERT INTO notifications (user_id, type, category, title, message, link, is_read, created_at)
+    VALUES (p_user_id, p_type, p_category, p_title, p_message, p_link, FALSE, NOW())
+    RETURNING id INTO v_notification_id;
+
+    RETURN v_notification_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's delivery setting of every category
+CREATE OR REPLACE FUNCTION get_notification_preferences(p_user_id INT)
+RETURNS TABLE (
+    category VARCHAR(20),
+    delivery VARCHAR(10)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT c.category::VARCHAR(20), COALESCE(p.delivery, 'instant')::VARCHAR(10)
+    FROM (VALUES (1, 'backtest'), (2, 'marketplace'), (3, 'system'), (4, 'security')) AS c(position, category)
+    LEFT JOIN notification_preferences p ON p.user_id = p_user_id AND p.category = c.category
+    ORDER BY c.position;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's delivery setting of one category
+CREATE OR REPLACE FUNCTION get_notification_delivery(p_user_id INT, p_category VARCHAR)
+RETURNS VARCHAR AS $$
+DECLARE
+    v_delivery VARCHAR(10);
+BEGIN
+    SELECT p.delivery INTO v_delivery
+    FROM notification_preferences p
+    WHERE p.user_id = p_user_id AND p.category = p_category;
+
+    RETURN COALESCE(v_delivery, 'instant');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Set a user's delivery setting of a category
+CREATE OR REPLACE FUNCTION set_notification_preference(
+    p_user_id INT,
+    p_category VARCHAR,
+    p_delivery VARCHAR
+)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO notification_preferences (user_id, category, delivery, updated_at)
+    VALUES (p_user_id, p_category, p_delivery, NOW())
+    ON CONFLICT (user_id, category) DO UPDATE
+    SET delivery = EXCLUDED.delivery, updated_at = EXCLUDED.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Collapse the unread notifications received before p_before in each user's digest
+-- categories into one digest notification per user, marking them read. Returns the
+-- digests created, none if another call is already building them.
+CREATE OR REPLACE FUNCTION create_notification_digests(p_before TIMESTAMP)
+RETURNS TABLE (
+    user_id INT,
+    digest_id INT,
+    notification_count INT
+) AS $$
+DECLARE
+    v_user RECORD;
+    v_digest_id INT;
+    v_summary TEXT;
+    v_count INT;
+BEGIN
+    -- Only one instance builds digests at a time
+    IF NOT pg_try_advisory_xact_lock(hashtext('create_notification_digests')) THEN
+        RETURN;
+    END IF;
+
+    FOR v_user IN
+        SELECT DISTINCT n.user_id
+        FROM notifications n
+        JOIN notification_preferences p
+            ON p.user_id = n.user_id AND p.category = n.category AND p.delivery = 'digest'
+        WHERE n.is_read = FALSE
+        AND n.digest_id IS NULL
+        AND n.type <> 'digest'
+        AND n.created_at < p_before
+    LOOP
+        SELECT
+            SUM(c.total)::INT,
+            string_agg(c.total || ' ' || c.category, ', ' ORDER BY c.total DESC, c.category)
+        INTO v_count, v_summary
+        FROM (
+            SELECT n.category, SUM(n.batch_count) AS total
+            FROM notifications n
+            JOIN notification_preferences p
+                ON p.user_id = n.user_id AND p.category = n.category AND p.delivery = 'digest'
+            WHERE n.user_id = v_user.user_id
+            AND n.is_read = FALSE
+            AND n.digest_id IS NULL
+            AND n.type <> 'digest'
+            AND n.created_at < p_before
+            GROUP BY n.category
+        ) c;
+
+        INSERT INTO notifications (user_id, type, category, title, message, is_read, created_at)
+        VALUES (
+            v_user.user_id,
+            'digest',
+            'system',
+            'Your daily digest',
+            'You have ' || v_count || CASE WHEN v_count = 1 THEN ' update: ' ELSE ' updates: ' END || v_summary || '.',
+            FALSE,
+            NOW()
+        )
+        RETURNING id INTO v_digest_id;
+
+        UPDATE notifications n
+        SET digest_id = v_digest_id, is_read = TRUE
+        FROM notification_preferences p
+        WHERE p.user_id = n.user_id AND p.category = n.category AND p.delivery = 'digest'
+        AND n.user_id = v_user.user_id
+        AND n.is_read = FALSE
+        AND n.digest_id IS NULL
+        AND n.type <> 'digest'
+        AND n.created_at < p_before;
+
+        user_id := v_user.user_id;
+        digest_id := v_digest_id;
+        notification_count := v_count;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
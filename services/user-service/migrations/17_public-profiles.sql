@@ -0,0 +1,62 @@
+-- User Service Database - Public Profiles
+
+-- +goose Up
+-- +goose StatementBegin
+-- Public profile details. display_name falls back to the username when not set;
+-- social_links maps a network (e.g. github) to the URL of the user's page there.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "display_name" varchar(50);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "bio" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "social_links" jsonb NOT NULL DEFAULT '{}';
+
+-- Get the public profile of an active user
+CREATE OR REPLACE FUNCTION get_public_profile(p_user_id INT)
+RETURNS TABLE (
+    id INT,
+    username VARCHAR,
+    display_name VARCHAR,
+    profile_photo_url VARCHAR,
+    bio TEXT,
+    social_links JSONB,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        u.id,
+        u.username,
+        COALESCE(u.display_name, u.username),
+        COALESCE(u.profile_photo_url, ''),
+        COALESCE(u.bio, ''),
+        u.social_links,
+        u.created_at
+    FROM users u
+    WHERE u.id = p_user_id AND u.is_active = TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update the public profile details of a user. NULL leaves a field unchanged;
+-- an empty display name or bio clears it.
+CREATE OR REPLACE FUNCTION update_profile_details(
+    p_user_id INT,
+    p_display_name VARCHAR(50),
+    p_bio TEXT,
+    p_social_links JSONB
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    UPDATE users
+    SET
+        display_name = CASE WHEN p_display_name IS NULL THEN display_name ELSE NULLIF(p_display_name, '') END,
+        bio = CASE WHEN p_bio IS NULL THEN bio ELSE NULLIF(p_bio, '') END,
+        social_links = COALESCE(p_social_links, social_links),
+        updated_at = NOW()
+    WHERE
+        id = p_user_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
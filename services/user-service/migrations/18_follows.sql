@@ -0,0 +1,117 @@
+-- User Service Database - Follows
+
+-- +goose Up
+-- +goose StatementBegin
+-- Users following sellers, to hear about their new listings and new versions of
+-- strategies they bought from them
+CREATE TABLE IF NOT EXISTS "user_follows" (
+  "follower_id" int NOT NULL,
+  "followee_id" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("follower_id", "followee_id"),
+  CHECK ("follower_id" <> "followee_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_user_follows_followee" ON "user_follows" ("followee_id", "created_at" DESC);
+
+ALTER TABLE "user_follows" ADD FOREIGN KEY ("follower_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_follows" ADD FOREIGN KEY ("followee_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Follow a user. Returns FALSE if already following.
+CREATE OR REPLACE FUNCTION follow_user(p_follower_id INT, p_followee_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    INSERT INTO user_follows (follower_id, followee_id)
+    VALUES (p_follower_id, p_followee_id)
+    ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Unfollow a user. Returns FALSE if not following.
+CREATE OR REPLACE FUNCTION unfollow_user(p_follower_id INT, p_followee_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INT;
+BEGIN
+    DELETE FROM user_follows
+    WHERE follower_id = p_follower_id AND followee_id = p_followee_id;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count the active followers of a user and the active users they follow
+CREATE OR REPLACE FUNCTION get_follow_counts(p_user_id INT)
+RETURNS TABLE (
+    followers_count BIGINT,
+    following_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        (SELECT COUNT(*)
+         FROM user_follows f JOIN users u ON f.follower_id = u.id
+         WHERE f.followee_id = p_user_id AND u.is_active = TRUE),
+        (SELECT COUNT(*)
+         FROM user_follows f JOIN users u ON f.followee_id = u.id
+         WHERE f.follower_id = p_user_id AND u.is_active = TRUE);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the active followers of a user, or the active users they follow, newest first
+CREATE OR REPLACE FUNCTION get_follows(
+    p_user_id INT,
+    p_followers BOOLEAN,
+    p_limit INT DEFAULT 20,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    user_id INT,
+    username VARCHAR,
+    display_name VARCHAR,
+    profile_photo_url VARCHAR,
+    followed_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        u.id,
+        u.username,
+        COALESCE(u.display_name, u.username),
+        COALESCE(u.profile_photo_url, ''),
+        f.created_at
+    FROM user_follows f
+    JOIN users u ON u.id = CASE WHEN p_followers THEN f.follower_id ELSE f.followee_id END
+    WHERE
+        u.is_active = TRUE
+        AND CASE WHEN p_followers THEN f.followee_id ELSE f.follower_id END = p_user_id
+    ORDER BY f.created_at DESC, u.id DESC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the IDs of the active followers of a user, optionally only those among p_user_ids
+CREATE OR REPLACE FUNCTION get_follower_ids(
+    p_followee_id INT,
+    p_user_ids INT[] DEFAULT NULL
+)
+RETURNS TABLE (follower_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT f.follower_id
+    FROM user_follows f
+    JOIN users u ON f.follower_id = u.id
+    WHERE
+        f.followee_id = p_followee_id
+        AND u.is_active = TRUE
+        AND (p_user_ids IS NULL OR f.follower_id = ANY(p_user_ids));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
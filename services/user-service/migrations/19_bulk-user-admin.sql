@@ -0,0 +1,100 @@
+-- User Service Database - Bulk User Administration
+
+-- +goose Up
+-- +goose StatementBegin
+-- Activate or deactivate many users at once. Returns the IDs of the users whose
+-- status changed; unknown users and users already in that state are left out.
+CREATE OR REPLACE FUNCTION bulk_set_users_active(
+    p_user_ids INT[],
+    p_is_active BOOLEAN
+)
+RETURNS TABLE (user_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE users u
+    SET is_active = p_is_active,
+        updated_at = NOW()
+    WHERE u.id = ANY(p_user_ids)
+      AND u.is_active IS DISTINCT FROM p_is_active
+    RETURNING u.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Assign a role to many users at once. Returns the IDs of the users that didn't
+-- have the role yet; unknown users are left out.
+CREATE OR REPLACE FUNCTION bulk_assign_user_role(
+    p_user_ids INT[],
+    p_role_id INT
+)
+RETURNS TABLE (user_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO user_roles AS ur (user_id, role_id, assigned_at)
+    SELECT u.id, p_role_id, NOW()
+    FROM users u
+    WHERE u.id = ANY(p_user_ids)
+    ON CONFLICT DO NOTHING
+    RETURNING ur.user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Remove a role from many users at once. Returns the IDs of the users that had it.
+CREATE OR REPLACE FUNCTION bulk_remove_user_role(
+    p_user_ids INT[],
+    p_role_id INT
+)
+RETURNS TABLE (user_id INT) AS $$
+BEGIN
+    RETURN QUERY
+    DELETE FROM user_roles ur
+    WHERE ur.user_id = ANY(p_user_ids)
+      AND ur.role_id = p_role_id
+    RETURNING ur.user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Users matching the optional filters, oldest first, for exporting. p_role matches the
+-- system role on users.role as well as assigned custom roles.
+CREATE OR REPLACE FUNCTION export_users(
+    p_created_from TIMESTAMP DEFAULT NULL,
+    p_created_to TIMESTAMP DEFAULT NULL,
+    p_role VARCHAR DEFAULT NULL,
+    p_is_active BOOLEAN DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    username VARCHAR,
+    email VARCHAR,
+    role VARCHAR,
+    roles TEXT[],
+    is_active BOOLEAN,
+    last_login TIMESTAMP,
+    created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        u.id,
+        u.username,
+        u.email,
+        u.role::VARCHAR,
+        COALESCE(custom.names, ARRAY[]::TEXT[]),
+        u.is_active,
+        u.last_login,
+        u.created_at
+    FROM users u
+    LEFT JOIN LATERAL (
+        SELECT array_agg(r.name::TEXT ORDER BY r.name) AS names
+        FROM user_roles ur
+        JOIN roles r ON r.id = ur.role_id
+        WHERE ur.user_id = u.id
+    ) custom ON TRUE
+    WHERE
+        (p_created_from IS NULL OR u.created_at >= p_created_from)
+        AND (p_created_to IS NULL OR u.created_at < p_created_to)
+        AND (p_is_active IS NULL OR u.is_active = p_is_active)
+        AND (p_role IS NULL OR u.role::TEXT = p_role OR p_role = ANY(custom.names))
+    ORDER BY u.created_at, u.id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
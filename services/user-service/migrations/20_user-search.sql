@@ -0,0 +1,92 @@
+-- User Service Database - Admin User Search
+
+-- +goose Up
+-- +goose StatementBegin
+-- Trigram indexes let substring searches (ILIKE '%term%') over usernames and emails
+-- use an index instead of scanning every user
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "idx_users_username_trgm" ON "users" USING gin ("username" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_users_email_trgm" ON "users" USING gin ("email" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_users_created_at" ON "users" ("created_at");
+
+-- Search users for the admin panel with filters, sorting and pagination. p_search is an
+-- ILIKE pattern matched against username and email; p_role matches the system role on
+-- users.role as well as assigned custom roles.
+CREATE OR REPLACE FUNCTION search_users(
+    p_search VARCHAR,
+    p_role VARCHAR,
+    p_is_active BOOLEAN,
+    p_created_after TIMESTAMP,
+    p_sort_by VARCHAR,
+    p_sort_direction VARCHAR,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS TABLE (
+    id INT,
+    username VARCHAR(50),
+    email VARCHAR(100),
+    role user_role,
+    profile_photo_url VARCHAR(255),
+    is_active BOOLEAN,
+    last_login TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT u.id, u.username, u.email, u.role, u.profile_photo_url, u.is_active, u.last_login, u.created_at, u.updated_at
+    FROM users u
+    WHERE
+        (p_search IS NULL OR u.username ILIKE p_search OR u.email ILIKE p_search)
+        AND (p_is_active IS NULL OR u.is_active = p_is_active)
+        AND (p_created_after IS NULL OR u.created_at >= p_created_after)
+        AND (p_role IS NULL OR u.role::TEXT = p_role OR EXISTS (
+            SELECT 1
+            FROM user_roles ur
+            JOIN roles r ON r.id = ur.role_id
+            WHERE ur.user_id = u.id AND r.name = p_role
+        ))
+    ORDER BY
+        CASE WHEN p_sort_by = 'username' AND p_sort_direction = 'ASC' THEN u.username END ASC,
+        CASE WHEN p_sort_by = 'username' AND p_sort_direction = 'DESC' THEN u.username END DESC,
+        CASE WHEN p_sort_by = 'email' AND p_sort_direction = 'ASC' THEN u.email END ASC,
+        CASE WHEN p_sort_by = 'email' AND p_sort_direction = 'DESC' THEN u.email END DESC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'ASC' THEN u.created_at END ASC,
+        CASE WHEN p_sort_by = 'created_at' AND p_sort_direction = 'DESC' THEN u.created_at END DESC,
+        CASE WHEN p_sort_by = 'last_login' AND p_sort_direction = 'ASC' THEN u.last_login END ASC NULLS FIRST,
+        CASE WHEN p_sort_by = 'last_login' AND p_sort_direction = 'DESC' THEN u.last_login END DESC NULLS LAST,
+        CASE WHEN p_sort_direction = 'DESC' THEN u.id END DESC,
+        u.id ASC
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count the users matching the admin search filters
+CREATE OR REPLACE FUNCTION count_search_users(
+    p_search VARCHAR,
+    p_role VARCHAR,
+    p_is_active BOOLEAN,
+    p_created_after TIMESTAMP
+)
+RETURNS INT AS $$
+BEGIN
+    RETURN (
+        SELECT COUNT(*)::INT
+        FROM users u
+        WHERE
+            (p_search IS NULL OR u.username ILIKE p_search OR u.email ILIKE p_search)
+            AND (p_is_active IS NULL OR u.is_active = p_is_active)
+            AND (p_created_after IS NULL OR u.created_at >= p_created_after)
+            AND (p_role IS NULL OR u.role::TEXT = p_role OR EXISTS (
+                SELECT 1
+                FROM user_roles ur
+                JOIN roles r ON r.id = ur.role_id
+                WHERE ur.user_id = u.id AND r.name = p_role
+            ))
+    );
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
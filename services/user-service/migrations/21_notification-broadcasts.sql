@@ -0,0 +1,263 @@
+-- User Service Database - Notification Broadcasts
+
+-- +goose Up
+-- +goose StatementBegin
+-- Notifications admins send to an audience at once. Title and message are templates
+-- rendered per recipient. The broadcast worker claims due broadcasts and sends them in
+-- batches of users ordered by ID, recording the last one sent so an interrupted broadcast
+-- resumes where it stopped.
+CREATE TABLE IF NOT EXISTS "notification_broadcasts" (
+  "id" SERIAL PRIMARY KEY,
+  "created_by" int NOT NULL,
+  "type" varchar(50) NOT NULL,
+  "category" varchar(20) NOT NULL CHECK ("category" IN ('backtest', 'marketplace', 'system', 'security')),
+  "title" varchar(255) NOT NULL,
+  "message" text NOT NULL,
+  "link" varchar(255),
+  "audience" varchar(30) NOT NULL CHECK ("audience" IN ('all', 'role', 'listing_purchasers', 'strategy_users')),
+  "audience_role" varchar(50),
+  "audience_target_id" int,
+  "variables" jsonb NOT NULL DEFAULT '{}',
+  "status" varchar(20) NOT NULL DEFAULT 'scheduled' CHECK ("status" IN ('scheduled', 'running', 'completed', 'failed', 'cancelled')),
+  "scheduled_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "started_at" timestamp,
+  "completed_at" timestamp,
+  "recipient_ids" int[], -- Audience resolved by the strategy service; NULL for all users and roles
+  "total_count" int NOT NULL DEFAULT 0,
+  "sent_count" int NOT NULL DEFAULT 0,
+  "skipped_count" int NOT NULL DEFAULT 0, -- Recipients who turned the category off
+  "failed_count" int NOT NULL DEFAULT 0,
+  "last_user_id" int NOT NULL DEFAULT 0,
+  "error" text,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP) -- Heartbeat of the worker sending it
+);
+
+ALTER TABLE "notification_broadcasts" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+CREATE INDEX IF NOT EXISTS idx_notification_broadcasts_due
+    ON notification_broadcasts (status, scheduled_at);
+
+-- Create a broadcast, returning its ID
+CREATE OR REPLACE FUNCTION create_notification_broadcast(
+    p_created_by INT,
+    p_type VARCHAR,
+    p_category VARCHAR,
+    p_title VARCHAR,
+    p_message TEXT,
+    p_link VARCHAR,
+    p_audience VARCHAR,
+    p_audience_role VARCHAR,
+    p_audience_target_id INT,
+    p_variables JSONB,
+    p_scheduled_at TIMESTAMP
+)
+RETURNS INT AS $$
+DECLARE
+    v_id INT;
+BEGIN
+    INSERT INTO notification_broadcasts (
+        created_by, type, category, title, message, link,
+        audience, audience_role, audience_target_id, variables, scheduled_at
+    )
+    VALUES (
+        p_created_by, p_type, p_category, p_title, p_message, NULLIF(p_link, ''),
+        p_audience, NULLIF(p_audience_role, ''), p_audience_target_id,
+        COALESCE(p_variables, '{}'), COALESCE(p_scheduled_at, NOW())
+    )
+    RETURNING id INTO v_id;
+
+    RETURN v_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a broadcast by ID
+CREATE OR REPLACE FUNCTION get_notification_broadcast(p_id INT)
+RETURNS SETOF notification_broadcasts AS $$
+BEGIN
+    RETURN QUERY
+    SELECT * FROM notification_broadcasts b WHERE b.id = p_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- List broadcasts newest first, optionally of one status
+CREATE OR REPLACE FUNCTION get_notification_broadcasts(
+    p_status VARCHAR,
+    p_limit INT,
+    p_offset INT
+)
+RETURNS SETOF notification_broadcasts AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM notification_broadcasts b
+    WHERE p_status IS NULL OR b.status = p_status
+    ORDER BY b.created_at DESC, b.id DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Count broadcasts, optionally of one status
+CREATE OR REPLACE FUNCTION count_notification_broadcasts(p_status VARCHAR)
+RETURNS INT AS $$
+BEGIN
+    RETURN (
+        SELECT COUNT(*)
+        FROM notification_broadcasts b
+        WHERE p_status IS NULL OR b.status = p_status
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Claim the next broadcast to send: the longest-due scheduled one, or a running one whose
+-- worker stopped reporting progress. Claiming moves it to running and refreshes its heartbeat.
+CREATE OR REPLACE FUNCTION claim_notification_broadcast(p_stale_after_seconds INT)
+RETURNS SETOF notification_broadcasts AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE notification_broadcasts b
+    SET status = 'running',
+        started_at = COALESCE(b.started_at, NOW()),
+        updated_at = NOW()
+    WHERE b.id = (
+        SELECT c.id
+        FROM notification_broadcasts c
+        WHERE (c.status = 'scheduled' AND c.scheduled_at <= NOW())
+           OR (c.status = 'running' AND c.updated_at < NOW() - make_interval(secs => p_stale_after_seconds))
+        ORDER BY c.scheduled_at, c.id
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING b.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Store the resolved audience of a broadcast when it starts and count its recipients.
+-- Only users that exist when it starts receive it. Returns the recipient count.
+CREATE OR REPLACE FUNCTION start_notification_broadcast(p_id INT, p_recipient_ids INT[])
+RETURNS INT AS $$
+DECLARE
+    v_total INT;
+BEGIN
+    UPDATE notification_broadcasts
+    SET recipient_ids = p_recipient_ids,
+        updated_at = NOW()
+    WHERE id = p_id;
+
+    SELECT COUNT(*) INTO v_total
+    FROM get_broadcast_recipients(p_id, 0, NULL);
+
+    UPDATE notification_broadcasts
+    SET total_count = v_total
+    WHERE id = p_id;
+
+    RETURN v_total;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the next recipients of a broadcast after a user ID: active users of its audience
+-- who existed when it started, in ID order. A NULL limit returns all of them.
+CREATE OR REPLACE FUNCTION get_broadcast_recipients(p_id INT, p_after_id INT, p_limit INT)
+RETURNS TABLE (
+    id INT,
+    username VARCHAR
+) AS $$
+DECLARE
+    v_broadcast notification_broadcasts%ROWTYPE;
+BEGIN
+    SELECT * INTO v_broadcast
+    FROM notification_broadcasts b
+    WHERE b.id = p_id;
+
+    RETURN QUERY
+    SELECT u.id, u.username
+    FROM users u
+    WHERE
+        u.is_active = true
+        AND u.id > p_after_id
+        AND u.created_at <= COALESCE(v_broadcast.started_at, NOW())
+        AND (v_broadcast.recipient_ids IS NULL OR u.id = ANY(v_broadcast.recipient_ids))
+        AND (
+            v_broadcast.audience <> 'role'
+            OR u.role::TEXT = v_broadcast.audience_role
+            OR EXISTS (
+                SELECT 1
+                FROM user_roles ur
+                JOIN roles r ON r.id = ur.role_id
+                WHERE ur.user_id = u.id AND r.name = v_broadcast.audience_role
+            )
+        )
+    ORDER BY u.id
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Record a sent batch: add its counts, move the resume point past it and refresh the
+-- heartbeat. Returns the broadcast's status, so a worker notices it was cancelled.
+CREATE OR REPLACE FUNCTION record_notification_broadcast_progress(
+    p_id INT,
+    p_last_user_id INT,
+    p_sent INT,
+    p_skipped INT,
+    p_failed INT
+)
+RETURNS VARCHAR AS $$
+DECLARE
+    v_status VARCHAR;
+BEGIN
+    UPDATE notification_broadcasts
+    SET last_user_id = GREATEST(last_user_id, p_last_user_id),
+        sent_count = sent_count + p_sent,
+        skipped_count = skipped_count + p_skipped,
+        failed_count = failed_count + p_failed,
+        updated_at = NOW()
+    WHERE id = p_id
+    RETURNING status INTO v_status;
+
+    RETURN v_status;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Mark a running broadcast completed
+CREATE OR REPLACE FUNCTION complete_notification_broadcast(p_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE notification_broadcasts
+    SET status = 'completed',
+        completed_at = NOW(),
+        updated_at = NOW()
+    WHERE id = p_id AND status = 'running';
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Mark a running broadcast failed
+CREATE OR REPLACE FUNCTION fail_notification_broadcast(p_id INT, p_error TEXT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE notification_broadcasts
+    SET status = 'failed',
+        error = p_error,
+        completed_at = NOW(),
+        updated_at = NOW()
+    WHERE id = p_id AND status = 'running';
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Cancel a broadcast that hasn't finished. A running broadcast stops after its current batch.
+CREATE OR REPLACE FUNCTION cancel_notification_broadcast(p_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE notification_broadcasts
+    SET status = 'cancelled',
+        completed_at = NOW(),
+        updated_at = NOW()
+    WHERE id = p_id AND status IN ('scheduled', 'running');
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
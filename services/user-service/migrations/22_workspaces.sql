@@ -0,0 +1,154 @@
+-- User Service Database - UI Workspaces
+
+-- +goose Up
+-- +goose StatementBegin
+-- Named JSON layouts of the UI (chart configs, watchlists, builder panel state) synced
+-- across a user's devices. Every save bumps the version, which clients send back as an
+-- ETag so concurrent edits from two devices don't overwrite each other.
+CREATE TABLE IF NOT EXISTS "user_workspaces" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar(64) NOT NULL,
+  "layout" jsonb NOT NULL,
+  "version" int NOT NULL DEFAULT 1,
+  "size_bytes" int NOT NULL, -- Counted against the user's workspace quota
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  UNIQUE ("user_id", "name")
+);
+
+ALTER TABLE "user_workspaces" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Get a workspace of a user by name
+CREATE OR REPLACE FUNCTION get_user_workspace(p_user_id INT, p_name VARCHAR)
+RETURNS TABLE (
+    name VARCHAR,
+    layout JSONB,
+    version INT,
+    size_bytes INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT w.name, w.layout, w.version, w.size_bytes, w.created_at, w.updated_at
+    FROM user_workspaces w
+    WHERE w.user_id = p_user_id AND w.name = p_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- List the workspaces of a user by name, without their layouts
+CREATE OR REPLACE FUNCTION get_user_workspaces(p_user_id INT)
+RETURNS TABLE (
+    name VARCHAR,
+    version INT,
+    size_bytes INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT w.name, w.version, w.size_bytes, w.created_at, w.updated_at
+    FROM user_workspaces w
+    WHERE w.user_id = p_user_id
+    ORDER BY w.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create or replace a workspace if it still has the version the client last saw and the
+-- user's workspaces stay within the quota. p_expected_version is NULL to save
+-- unconditionally, 0 to only create and -1 to only replace. Saves of one user are
+-- serialized, so concurrent saves can't exceed the quota together.
+-- Returns the outcome (saved, version_mismatch, quota_exceeded or limit_reached) and
+-- the workspace's version afterwards.
+CREATE OR REPLACE FUNCTION save_user_workspace(
+    p_user_id INT,
+    p_name VARCHAR,
+    p_layout JSONB,
+    p_size_bytes INT,
+    p_expected_version INT,
+    p_max_bytes INT,
+    p_max_count INT
+)
+RETURNS TABLE (
+    result VARCHAR,
+    version INT
+) AS $$
+DECLARE
+    v_current INT;
+    v_other_bytes BIGINT;
+    v_other_count INT;
+BEGIN
+    PERFORM 1 FROM users u WHERE u.id = p_user_id FOR UPDATE;
+
+    SELECT w.version INTO v_current
+    FROM user_workspaces w
+    WHERE w.user_id = p_user_id AND w.name = p_name;
+
+    IF p_expected_version IS NOT NULL AND (
+        (p_expected_version = 0 AND v_current IS NOT NULL)
+        OR (p_expected_version = -1 AND v_current IS NULL)
+        OR (p_expected_version > 0 AND v_current IS DISTINCT FROM p_expected_version)
+    ) THEN
+        RETURN QUERY SELECT 'version_mismatch'::VARCHAR, v_current;
+        RETURN;
+    END IF;
+
+    SELECT COALESCE(SUM(w.size_bytes), 0), COUNT(*) INTO v_other_bytes, v_other_count
+    FROM user_workspaces w
+    WHERE w.user_id = p_user_id AND w.name <> p_name;
+
+    IF v_other_bytes + p_size_bytes > p_max_bytes THEN
+        RETURN QUERY SELECT 'quota_exceeded'::VARCHAR, v_current;
+        RETURN;
+    END IF;
+
+    IF v_current IS NULL AND v_other_count >= p_max_count THEN
+        RETURN QUERY SELECT 'limit_reached'::VARCHAR, v_current;
+        RETURN;
+    END IF;
+
+    INSERT INTO user_workspaces (user_id, name, layout, size_bytes)
+    VALUES (p_user_id, p_name, p_layout, p_size_bytes)
+    ON CONFLICT (user_id, name) DO UPDATE SET
+        layout = EXCLUDED.layout,
+        size_bytes = EXCLUDED.size_bytes,
+        version = user_workspaces.version + 1,
+        updated_at = CURRENT_TIMESTAMP
+    RETURNING user_workspaces.version INTO v_current;
+
+    RETURN QUERY SELECT 'saved'::VARCHAR, v_current;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a workspace if it still has the version the client last saw; a NULL version
+-- deletes unconditionally. Returns deleted, version_mismatch or not_found.
+CREATE OR REPLACE FUNCTION delete_user_workspace(
+    p_user_id INT,
+    p_name VARCHAR,
+    p_expected_version INT
+)
+RETURNS VARCHAR AS $$
+DECLARE
+    v_current INT;
+BEGIN
+    SELECT w.version INTO v_current
+    FROM user_workspaces w
+    WHERE w.user_id = p_user_id AND w.name = p_name
+    FOR UPDATE;
+
+    IF v_current IS NULL THEN
+        RETURN 'not_found';
+    END IF;
+
+    IF p_expected_version IS NOT NULL AND p_expected_version > 0 AND v_current <> p_expected_version THEN
+        RETURN 'version_mismatch';
+    END IF;
+
+    DELETE FROM user_workspaces
+    WHERE user_id = p_user_id AND name = p_name;
+
+    RETURN 'deleted';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- User Service Database - Public Profile Search
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "idx_users_display_name_trgm" ON "users" USING gin ("display_name" gin_trgm_ops);
+
+-- Search the public profiles of active users by username or display name. Exact matches
+-- come first, then names starting with the term, then names containing it.
+CREATE OR REPLACE FUNCTION search_public_profiles(p_search VARCHAR, p_limit INT)
+RETURNS TABLE (
+    id INT,
+    username VARCHAR,
+    display_name VARCHAR,
+    profile_photo_url VARCHAR,
+    bio TEXT
+) AS $$
+DECLARE
+    v_term VARCHAR := lower(p_search);
+    v_pattern VARCHAR := '%' || replace(replace(replace(lower(p_search), '\', '\\'), '%', '\%'), '_', '\_') || '%';
+BEGIN
+    RETURN QUERY
+    SELECT
+        u.id,
+        u.username,
+        COALESCE(u.display_name, u.username),
+        COALESCE(u.profile_photo_url, ''),
+        COALESCE(u.bio, '')
+    FROM users u
+    WHERE
+        u.is_active = TRUE
+        AND (u.username ILIKE v_pattern OR u.display_name ILIKE v_pattern)
+    ORDER BY
+        CASE
+            WHEN lower(u.username) = v_term OR lower(u.display_name) = v_term THEN 0
+            WHEN starts_with(lower(u.username), v_term) OR starts_with(lower(u.display_name), v_term) THEN 1
+            ELSE 2
+        END,
+        u.username
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
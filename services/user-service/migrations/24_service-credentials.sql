@@ -0,0 +1,125 @@
+-- User Service Database - Service Credentials
+
+-- +goose Up
+-- +goose StatementBegin
+-- Keys internal services authenticate with in X-Service-Key. A service can hold several
+-- keys so a new one can be rolled out before the old one stops working. Only the SHA-256
+-- hash of a key is stored; the prefix identifies it in listings and logs.
+CREATE TABLE IF NOT EXISTS "service_credentials" (
+  "id" SERIAL PRIMARY KEY,
+  "service_name" varchar(50) NOT NULL,
+  "key_prefix" varchar(16) UNIQUE NOT NULL,
+  "key_hash" char(64) UNIQUE NOT NULL,
+  "description" varchar(255),
+  "created_by" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "expires_at" timestamp,
+  "revoked_at" timestamp,
+  "revoked_by" int,
+  "last_used_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "idx_service_credentials_service" ON "service_credentials" ("service_name");
+
+-- Issue a key to a service. When p_rotate_after is set, the service's other usable keys
+-- expire after that many seconds (or keep an earlier expiry), so callers can switch to the
+-- new key. Returns the id of the credential.
+CREATE OR REPLACE FUNCTION issue_service_credential(
+    p_service_name VARCHAR,
+    p_key_prefix VARCHAR,
+    p_key_hash VARCHAR,
+    p_description VARCHAR,
+    p_expires_at TIMESTAMP,
+    p_rotate_after INT,
+    p_created_by INT
+)
+RETURNS INT AS $$
+DECLARE
+    v_id INT;
+BEGIN
+    IF p_rotate_after IS NOT NULL THEN
+        UPDATE service_credentials
+        SET expires_at = LEAST(COALESCE(expires_at, 'infinity'), NOW() + make_interval(secs => p_rotate_after))
+        WHERE service_name = p_service_name
+          AND revoked_at IS NULL
+          AND (expires_at IS NULL OR expires_at > NOW());
+    END IF;
+
+    INSERT INTO service_credentials (service_name, key_prefix, key_hash, description, created_by, expires_at)
+    VALUES (p_service_name, p_key_prefix, p_key_hash, NULLIF(p_description, ''), p_created_by, p_expires_at)
+    RETURNING id INTO v_id;
+
+    RETURN v_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Revoke a key. Returns FALSE if it is unknown or already revoked.
+CREATE OR REPLACE FUNCTION revoke_service_credential(
+    p_id INT,
+    p_revoked_by INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INTEGER;
+BEGIN
+    UPDATE service_credentials
+    SET revoked_at = NOW(), revoked_by = p_revoked_by
+    WHERE id = p_id AND revoked_at IS NULL;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- List the keys of a service, or of every service when p_service_name is NULL, with their
+-- status: active, expiring (within its rotation grace period or about to expire), expired
+-- or revoked
+CREATE OR REPLACE FUNCTION get_service_credentials(p_service_name VARCHAR)
+RETURNS TABLE (
+    id INT,
+    service_name VARCHAR(50),
+    key_prefix VARCHAR(16),
+    description VARCHAR(255),
+    status VARCHAR,
+    created_by INT,
+    created_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.id, c.service_name, c.key_prefix, c.description,
+        (CASE
+            WHEN c.revoked_at IS NOT NULL THEN 'revoked'
+            WHEN c.expires_at IS NOT NULL AND c.expires_at <= NOW() THEN 'expired'
+            WHEN c.expires_at IS NOT NULL AND c.expires_at <= NOW() + INTERVAL '7 days' THEN 'expiring'
+            ELSE 'active'
+        END)::VARCHAR,
+        c.created_by, c.created_at, c.expires_at, c.revoked_at, c.last_used_at
+    FROM service_credentials c
+    WHERE p_service_name IS NULL OR c.service_name = p_service_name
+    ORDER BY c.service_name, c.created_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Find the service of a usable key by its hash and record that it was used. Returns no
+-- row for unknown, expired and revoked keys.
+CREATE OR REPLACE FUNCTION authenticate_service_credential(p_key_hash VARCHAR)
+RETURNS TABLE (
+    id INT,
+    service_name VARCHAR(50),
+    expires_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE service_credentials c
+    SET last_used_at = NOW()
+    WHERE c.key_hash = p_key_hash
+      AND c.revoked_at IS NULL
+      AND (c.expires_at IS NULL OR c.expires_at > NOW())
+    RETURNING c.id, c.service_name, c.expires_at;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- User Service Database - Strategy Template Permission
+
+-- +goose Up
+-- +goose StatementBegin
+-- Lets a user curate the strategy service's starter strategy templates
+INSERT INTO permissions (name, description) VALUES
+('templates:write', 'Create, update and delete strategy templates')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
@@ -0,0 +1,330 @@
+-- User Service Database - Referrals
+
+-- +goose Up
+-- +goose StatementBegin
+-- Each user's code for inviting others, created the first time they ask for it
+CREATE TABLE IF NOT EXISTS "referral_codes" (
+  "user_id" int PRIMARY KEY,
+  "code" varchar(16) UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Users who registered with someone's code. A user is referred at most once. The IP and
+-- user agent of the registration are kept to spot referrers inviting themselves.
+CREATE TABLE IF NOT EXISTS "referrals" (
+  "id" SERIAL PRIMARY KEY,
+  "referrer_id" int NOT NULL,
+  "referee_id" int UNIQUE NOT NULL,
+  "code" varchar(16) NOT NULL,
+  "signup_ip" varchar(45),
+  "signup_user_agent" varchar(255),
+  "rewarded" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  CHECK ("referrer_id" <> "referee_id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_referrals_referrer" ON "referrals" ("referrer_id", "created_at");
+
+-- Ledger of referral credit. Rewards and reversals add credit, redemptions (negative
+-- amounts) spend it on marketplace purchases; a user's balance is the sum of their entries.
+CREATE TABLE IF NOT EXISTS "referral_credits" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "amount" numeric(10,2) NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "reason" varchar(20) NOT NULL CHECK ("reason" IN ('referrer_reward', 'referee_reward', 'redemption', 'reversal')),
+  "referral_id" int,
+  "reference" varchar(100),
+  "reversal_of" int UNIQUE,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_referral_credits_user" ON "referral_credits" ("user_id", "currency");
+
+ALTER TABLE "referral_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "referrals" ADD FOREIGN KEY ("referrer_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "referrals" ADD FOREIGN KEY ("referee_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "referral_credits" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "referral_credits" ADD FOREIGN KEY ("referral_id") REFERENCES "referrals" ("id") ON DELETE SET NULL;
+ALTER TABLE "referral_credits" ADD FOREIGN KEY ("reversal_of") REFERENCES "referral_credits" ("id") ON DELETE CASCADE;
+
+-- Get a user's referral code, creating a random one the first time
+CREATE OR REPLACE FUNCTION get_or_create_referral_code(p_user_id INT)
+RETURNS VARCHAR AS $$
+DECLARE
+    v_code VARCHAR(16);
+BEGIN
+    SELECT code INTO v_code FROM referral_codes WHERE user_id = p_user_id;
+    IF FOUND THEN
+        RETURN v_code;
+    END IF;
+
+    LOOP
+        v_code := upper(substr(md5(random()::text || clock_timestamp()::text || p_user_id::text), 1, 8));
+        BEGIN
+            INSERT INTO referral_codes (user_id, code)
+            VALUES (p_user_id, v_code)
+            ON CONFLICT (user_id) DO NOTHING;
+
+            -- Another request may have created the user's code first
+            SELECT code INTO v_code FROM referral_codes WHERE user_id = p_user_id;
+            RETURN v_code;
+        EXCEPTION WHEN unique_violation THEN
+            -- The code belongs to someone else, try another
+        END;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Record that a new user registered with a code. Returns no row if the code is unknown,
+-- belongs to an inactive user or to the new user, or the user was already referred.
+CREATE OR REPLACE FUNCTION record_referral(
+    p_code VARCHAR,
+    p_referee_id INT,
+    p_signup_ip VARCHAR,
+    p_signup_user_agent VARCHAR
+)
+RETURNS TABLE (
+    referral_id INT,
+    referrer_id INT
+) AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO referrals (referrer_id, referee_id, code, signup_ip, signup_user_agent)
+    SELECT rc.user_id, p_referee_id, rc.code, NULLIF(p_signup_ip, ''), NULLIF(p_signup_user_agent, '')
+    FROM referral_codes rc
+    JOIN users u ON u.id = rc.user_id
+    WHERE rc.code = upper(p_code)
+      AND rc.user_id <> p_referee_id
+      AND u.is_active = true
+    ON CONFLICT (referee_id) DO NOTHING
+    RETURNING referrals.id, referrals.referrer_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Credit the rewards of a referral. The referrer is only credited while they have fewer
+-- than p_max_rewarded rewarded referrals (0 for no limit); the referee always is. Amounts
+-- of 0 credit nothing. Returns whether the referrer was credited.
+CREATE OR REPLACE FUNCTION grant_referral_rewards(
+    p_referral_id INT,
+    p_referrer_credit NUMERIC,
+    p_referee_credit NUMERIC,
+    p_currency VARCHAR,
+    p_max_rewarded INT
+)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_referral referrals%ROWTYPE;
+    v_rewarded_count INT;
+BEGIN
+    SELECT * INTO v_referral FROM referrals WHERE id = p_referral_id FOR UPDATE;
+    IF NOT FOUND OR v_referral.rewarded THEN
+        RETURN FALSE;
+    END IF;
+
+    IF p_referee_credit > 0 THEN
+        INSERT INTO referral_credits (user_id, amount, currency, reason, referral_id)
+        VALUES (v_referral.referee_id, p_referee_credit, p_currency, 'referee_reward', p_referral_id);
+    END IF;
+
+    -- Serialize rewards of the same referrer so the limit holds
+    PERFORM pg_advisory_xact_lock(hashtext('referral_rewards'), v_referral.referrer_id);
+
+    SELECT COUNT(*) INTO v_rewarded_count
+    FROM referrals
+    WHERE referrer_id = v_referral.referrer_id AND rewarded = true;
+
+    IF p_max_rewarded > 0 AND v_rewarded_count >= p_max_rewarded THEN
+        RETURN FALSE;
+    END IF;
+
+    IF p_referrer_credit > 0 THEN
+        INSERT INTO referral_credits (user_id, amount, currency, reason, referral_id)
+        VALUES (v_referral.referrer_id, p_referrer_credit, p_currency, 'referrer_reward', p_referral_id);
+    END IF;
+
+    UPDATE referrals SET rewarded = true WHERE id = p_referral_id;
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's referral statistics, with the credit they earned and have left in a currency
+CREATE OR REPLACE FUNCTION get_referral_stats(p_user_id INT, p_currency VARCHAR)
+RETURNS TABLE (
+    referrals_count INT,
+    active_referrals INT,
+    rewarded_referrals INT,
+    credit_earned NUMERIC,
+    credit_balance NUMERIC
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        (SELECT COUNT(*)::INT FROM referrals r WHERE r.referrer_id = p_user_id),
+        (SELECT COUNT(*)::INT FROM referrals r JOIN users u ON u.id = r.referee_id
+         WHERE r.referrer_id = p_user_id AND u.is_active = true),
+        (SELECT COUNT(*)::INT FROM referrals r WHERE r.referrer_id = p_user_id AND r.rewarded = true),
+        (SELECT COALESCE(SUM(c.amount), 0) FROM referral_credits c
+         WHERE c.user_id = p_user_id AND c.currency = p_currency
+           AND c.reason IN ('referrer_reward', 'referee_reward')),
+        (SELECT COALESCE(SUM(c.amount), 0) FROM referral_credits c
+         WHERE c.user_id = p_user_id AND c.currency = p_currency);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a user's referral credit balance in a currency
+CREATE OR REPLACE FUNCTION get_referral_credit_balance(p_user_id INT, p_currency VARCHAR)
+RETURNS NUMERIC AS $$
+BEGIN
+    RETURN (
+        SELECT COALESCE(SUM(amount), 0)
+        FROM referral_credits
+        WHERE user_id = p_user_id AND currency = p_currency
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Spend up to p_amount of a user's credit, e.g. on a purchase identified by p_reference.
+-- Returns the redemption and the amount spent, which is less than asked for when the
+-- balance is lower; nothing is recorded for an empty balance.
+CREATE OR REPLACE FUNCTION redeem_referral_credit(
+    p_user_id INT,
+    p_amount NUMERIC,
+    p_currency VARCHAR,
+    p_reference VARCHAR
+)
+RETURNS TABLE (
+    redemption_id INT,
+    amount NUMERIC
+) AS $$
+DECLARE
+    v_balance NUMERIC;
+    v_amount NUMERIC;
+    v_id INT;
+BEGIN
+    -- Serialize redemptions of the same user so the balance can't be spent twice
+    PERFORM pg_advisory_xact_lock(hashtext('referral_credits'), p_user_id);
+
+    v_balance := get_referral_credit_balance(p_user_id, p_currency);
+    v_amount := LEAST(v_balance, p_amount);
+    IF v_amount <= 0 THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO referral_credits (user_id, amount, currency, reason, reference)
+    VALUES (p_user_id, -v_amount, p_currency, 'redemption', NULLIF(p_reference, ''))
+    RETURNING id INTO v_id;
+
+    RETURN QUERY SELECT v_id, v_amount;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Give back the credit of a redemption, e.g. when the purchase it paid for failed.
+-- Returns FALSE if there is no such redemption or it was already reversed.
+CREATE OR REPLACE FUNCTION reverse_referral_redemption(p_redemption_id INT)
+RETURNS BOOLEAN AS $$
+DECLARE
+    affected_rows INTEGER;
+BEGIN
+    INSERT INTO referral_credits (user_id, amount, currency, reason, reference, reversal_of)
+    SELECT c.user_id, -c.amount, c.currency, 'reversal', c.reference, c.id
+    FROM referral_credits c
+    WHERE c.id = p_redemption_id AND c.reason = 'redemption'
+    ON CONFLICT (reversal_of) DO NOTHING;
+
+    GET DIAGNOSTICS affected_rows = ROW_COUNT;
+    RETURN affected_rows > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the fraud indicators of referrers with at least p_min_referrals referrals since
+-- p_since:
+--   shared_ip_referrals   referees who signed up from the same IP as another of their referees
+--   referrer_ip_referrals referees who signed up from an IP the referrer logged in from
+--   dormant_referrals     referees who never logged in again after signing up
+--   max_referrals_per_day most referrals on a single day
+-- Referrers with the most suspicious referrals come first.
+CREATE OR REPLACE FUNCTION get_referral_fraud_report(
+    p_since TIMESTAMP,
+    p_min_referrals INT,
+    p_limit INT
+)
+RETURNS TABLE (
+    referrer_id INT,
+    username VARCHAR(50),
+    email VARCHAR(100),
+    is_active BOOLEAN,
+    referrals_count INT,
+    shared_ip_referrals INT,
+    referrer_ip_referrals INT,
+    dormant_referrals INT,
+    max_referrals_per_day INT,
+    rewarded_referrals INT,
+    credit_earned NUMERIC,
+    first_referral_at TIMESTAMP,
+    last_referral_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH recent AS (
+        SELECT r.*
+        FROM referrals r
+        WHERE r.created_at >= p_since
+    ),
+    per_referrer AS (
+        SELECT
+            r.referrer_id,
+            COUNT(*)::INT AS referrals_count,
+            COUNT(*) FILTER (WHERE r.signup_ip IS NOT NULL AND EXISTS (
+                SELECT 1 FROM recent o
+                WHERE o.referrer_id = r.referrer_id AND o.id <> r.id AND o.signup_ip = r.signup_ip
+            ))::INT AS shared_ip_referrals,
+            COUNT(*) FILTER (WHERE r.signup_ip IS NOT NULL AND EXISTS (
+                SELECT 1 FROM user_login_devices d
+                WHERE d.user_id = r.referrer_id AND d.ip_address = r.signup_ip
+            ))::INT AS referrer_ip_referrals,
+            COUNT(*) FILTER (WHERE NOT EXISTS (
+                SELECT 1 FROM user_sessions s
+                WHERE s.user_id = r.referee_id AND s.created_at > r.created_at + INTERVAL '1 hour'
+            ))::INT AS dormant_referrals,
+            COUNT(*) FILTER (WHERE r.rewarded)::INT AS rewarded_referrals,
+            MIN(r.created_at) AS first_referral_at,
+            MAX(r.created_at) AS last_referral_at
+        FROM recent r
+        GROUP BY r.referrer_id
+        HAVING COUNT(*) >= p_min_referrals
+    ),
+    per_day AS (
+        SELECT r.referrer_id, MAX(day_count)::INT AS max_referrals_per_day
+        FROM (
+            SELECT r2.referrer_id, COUNT(*) AS day_count
+            FROM recent r2
+            GROUP BY r2.referrer_id, date_trunc('day', r2.created_at)
+        ) r
+        GROUP BY r.referrer_id
+    )
+    SELECT
+        p.referrer_id,
+        u.username,
+        u.email,
+        u.is_active,
+        p.referrals_count,
+        p.shared_ip_referrals,
+        p.referrer_ip_referrals,
+        p.dormant_referrals,
+        d.max_referrals_per_day,
+        p.rewarded_referrals,
+        (SELECT COALESCE(SUM(c.amount), 0) FROM referral_credits c
+         WHERE c.user_id = p.referrer_id AND c.reason = 'referrer_reward'),
+        p.first_referral_at,
+        p.last_referral_at
+    FROM per_referrer p
+    JOIN users u ON u.id = p.referrer_id
+    JOIN per_day d ON d.referrer_id = p.referrer_id
+    ORDER BY p.shared_ip_referrals + p.referrer_ip_referrals + p.dormant_referrals DESC,
+             p.referrals_count DESC,
+             p.referrer_id
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
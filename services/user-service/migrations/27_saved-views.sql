@@ -0,0 +1,88 @@
+-- User Service Database - Saved List Views
+
+-- +goose Up
+-- +goose StatementBegin
+-- Named filter and sort combinations users apply to the backtest, strategy and marketplace
+-- lists. Filters hold the list's query parameters by name.
+CREATE TABLE IF NOT EXISTS "saved_views" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "list_type" varchar(20) NOT NULL CHECK ("list_type" IN ('backtests', 'strategies', 'marketplace')),
+  "name" varchar(100) NOT NULL,
+  "filters" jsonb NOT NULL DEFAULT '{}',
+  "sort_by" varchar(50),
+  "sort_direction" varchar(4),
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  UNIQUE ("user_id", "list_type", "name")
+);
+
+ALTER TABLE "saved_views" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Save a view of a list for a user, who can have at most p_max_count views per list.
+-- Saves of one user are serialized, so concurrent saves can't exceed the limit together.
+CREATE OR REPLACE FUNCTION create_saved_view(
+    p_user_id INT,
+    p_list_type VARCHAR,
+    p_name VARCHAR,
+    p_filters JSONB,
+    p_sort_by VARCHAR,
+    p_sort_direction VARCHAR,
+    p_max_count INT
+)
+RETURNS SETOF saved_views AS $$
+BEGIN
+    PERFORM 1 FROM users u WHERE u.id = p_user_id FOR UPDATE;
+
+    IF EXISTS (
+        SELECT 1 FROM saved_views v
+        WHERE v.user_id = p_user_id AND v.list_type = p_list_type AND v.name = p_name
+    ) THEN
+        RAISE EXCEPTION 'A saved view with this name already exists';
+    END IF;
+
+    IF (SELECT COUNT(*) FROM saved_views v WHERE v.user_id = p_user_id AND v.list_type = p_list_type) >= p_max_count THEN
+        RAISE EXCEPTION 'Saved view limit reached: at most % views can be saved per list', p_max_count;
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO saved_views (user_id, list_type, name, filters, sort_by, sort_direction)
+    VALUES (p_user_id, p_list_type, p_name, COALESCE(p_filters, '{}'), NULLIF(p_sort_by, ''), NULLIF(p_sort_direction, ''))
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get the saved views of a user by list and name. A NULL list type gets those of every list.
+CREATE OR REPLACE FUNCTION get_saved_views(p_user_id INT, p_list_type VARCHAR)
+RETURNS SETOF saved_views AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM saved_views v
+    WHERE v.user_id = p_user_id
+      AND (p_list_type IS NULL OR v.list_type = p_list_type)
+    ORDER BY v.list_type, v.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get a saved view of a user
+CREATE OR REPLACE FUNCTION get_saved_view(p_user_id INT, p_view_id INT)
+RETURNS SETOF saved_views AS $$
+BEGIN
+    RETURN QUERY
+    SELECT *
+    FROM saved_views v
+    WHERE v.id = p_view_id AND v.user_id = p_user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Delete a saved view of a user. Returns whether it existed.
+CREATE OR REPLACE FUNCTION delete_saved_view(p_user_id INT, p_view_id INT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM saved_views
+    WHERE id = p_view_id AND user_id = p_user_id;
+
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd